@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"telegrambot/internal/admin"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/monitoring"
+	"telegrambot/internal/notifications"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/outbox"
+	"telegrambot/internal/preferences"
+	"telegrambot/internal/snapshot"
+	"telegrambot/pkg/config"
+	"telegrambot/pkg/db"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `jarvisctl — администрирование бота без ручного SQL
+
+Использование:
+  jarvisctl user <id|@username>                 информация о пользователе
+  jarvisctl grant-subscription <user> <days>    выдать или продлить подписку
+  jarvisctl revoke-subscription <user>          перевести пользователя на бесплатный тариф
+  jarvisctl block <user> | unblock <user>       заблокировать или разблокировать доступ
+  jarvisctl reset-google <user>                 сбросить привязку Google Calendar
+  jarvisctl reports failed                      список отчетов OKR, которые не удалось отправить
+  jarvisctl reports rerun <user>                повторно сформировать и отправить отчет OKR
+  jarvisctl outbox                              очередь неотправленных уведомлений
+  jarvisctl outbox failed                       сообщения, которые не удалось доставить
+  jarvisctl outbox retry [id...]                вернуть недоставленные сообщения в очередь
+  jarvisctl snapshot <dsn> [days] [user...]     анонимизированная копия данных в staging-базу
+                                                (соль анонимизации — SNAPSHOT_SALT)
+
+Настройки подключения берутся из тех же переменных окружения (.env), что и у бота.`
+
+type app struct {
+	cfg	*config.Config
+	db	*sqlx.DB
+	admin	*admin.Service
+}
+
+func main() {
+	logrus.SetOutput(os.Stderr)
+	logrus.SetLevel(logrus.WarnLevel)
+
+	args := os.Args[1:]
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Println(usage)
+		return
+	}
+
+	cfg := config.LoadConfig()
+	database, err := db.NewPostgresDB(cfg)
+	if err != nil {
+		fail(fmt.Errorf("ошибка при подключении к базе данных: %v", err))
+	}
+	defer database.Close()
+
+	a := &app{
+		cfg:	cfg,
+		db:	database,
+		admin:	admin.NewService(database, admin.ParseAdminIDs(cfg.AdminTelegramIDs)),
+	}
+
+	timeout := 2 * time.Minute
+	if args[0] == "snapshot" {
+		timeout = time.Hour
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := a.run(ctx, args[0], args[1:]); err != nil {
+		cancel()
+		database.Close()
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "❌", err)
+	os.Exit(1)
+}
+
+func (a *app) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "user":
+		return a.showUser(ctx, args)
+	case "grant-subscription":
+		return a.grantSubscription(ctx, args)
+	case "revoke-subscription":
+		return a.revokeSubscription(ctx, args)
+	case "block":
+		return a.setBlocked(ctx, args, true)
+	case "unblock":
+		return a.setBlocked(ctx, args, false)
+	case "reset-google":
+		return a.resetGoogle(ctx, args)
+	case "reports":
+		return a.reports(ctx, args)
+	case "outbox":
+		return a.outbox(ctx, args)
+	case "snapshot":
+		return a.snapshot(ctx, args)
+	}
+	return fmt.Errorf("неизвестная команда %q\n\n%s", command, usage)
+}
+
+func (a *app) findUser(ctx context.Context, args []string, expected int) (*admin.User, error) {
+	if len(args) != expected {
+		return nil, fmt.Errorf("неверное количество аргументов\n\n%s", usage)
+	}
+	user, err := a.admin.FindUser(ctx, args[0])
+	if errors.Is(err, admin.ErrUserNotFound) {
+		return nil, fmt.Errorf("пользователь %s не найден", args[0])
+	}
+	return user, err
+}
+
+func (a *app) showUser(ctx context.Context, args []string) error {
+	user, err := a.findUser(ctx, args, 1)
+	if err != nil {
+		return err
+	}
+
+	var details struct {
+		ExpiresAt	*time.Time	`db:"subscription_expires_at"`
+		GoogleLinked	bool		`db:"google_linked"`
+		GoogleExpiry	*time.Time	`db:"google_expiry"`
+		GoogleInvalid	*time.Time	`db:"google_invalid_at"`
+	}
+	err = a.db.GetContext(ctx, &details, `
+		SELECT u.subscription_expires_at,
+			gt.user_id IS NOT NULL AS google_linked,
+			gt.expiry AS google_expiry,
+			gt.invalid_at AS google_invalid_at
+		FROM users u
+		LEFT JOIN google_tokens gt ON gt.user_id = u.id
+		WHERE u.id = $1
+	`, user.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении данных пользователя: %v", err)
+	}
+
+	fmt.Printf("Пользователь:  %s\n", user.DisplayName())
+	fmt.Printf("Telegram ID:   %d\n", user.ID)
+	fmt.Printf("Роль:          %s\n", user.Role)
+	if details.ExpiresAt != nil {
+		fmt.Printf("Подписка до:   %s\n", details.ExpiresAt.Local().Format("02.01.2006 15:04"))
+	}
+	fmt.Printf("Заблокирован:  %s\n", yesNo(user.IsBlocked))
+	fmt.Printf("Администратор: %s\n", yesNo(user.IsAdmin))
+	fmt.Printf("Google:        %s", yesNo(details.GoogleLinked))
+	if details.GoogleExpiry != nil {
+		fmt.Printf(" (токен до %s)", details.GoogleExpiry.Local().Format("02.01.2006 15:04"))
+	}
+	if details.GoogleInvalid != nil {
+		fmt.Printf(", доступ отозван %s — нужно переподключение", details.GoogleInvalid.Local().Format("02.01.2006 15:04"))
+	}
+	fmt.Printf("\nСоздан:        %s\n", user.CreatedAt.Local().Format("02.01.2006 15:04"))
+	return nil
+}
+
+func (a *app) grantSubscription(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("использование: jarvisctl grant-subscription <user> <days>")
+	}
+	days, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("некорректное количество дней: %s", args[1])
+	}
+	user, err := a.findUser(ctx, args[:1], 1)
+	if err != nil {
+		return err
+	}
+
+	expiresAt, err := a.admin.GrantSubscription(ctx, user.ID, days)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s: подписка активна до %s\n", user.DisplayName(), expiresAt.Local().Format("02.01.2006 15:04"))
+	return nil
+}
+
+func (a *app) revokeSubscription(ctx context.Context, args []string) error {
+	user, err := a.findUser(ctx, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := a.admin.SetRole(ctx, user.ID, admin.RoleFree); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s переведен на бесплатный тариф\n", user.DisplayName())
+	return nil
+}
+
+func (a *app) setBlocked(ctx context.Context, args []string, blocked bool) error {
+	user, err := a.findUser(ctx, args, 1)
+	if err != nil {
+		return err
+	}
+	if err := a.admin.SetBlocked(ctx, user.ID, blocked); err != nil {
+		return err
+	}
+	if blocked {
+		fmt.Printf("⛔ %s заблокирован\n", user.DisplayName())
+	} else {
+		fmt.Printf("✅ %s разблокирован\n", user.DisplayName())
+	}
+	return nil
+}
+
+func (a *app) resetGoogle(ctx context.Context, args []string) error {
+	user, err := a.findUser(ctx, args, 1)
+	if err != nil {
+		return err
+	}
+
+	removed, err := calendar.NewService(a.db, &config.Config{}).ResetGoogleLink(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		fmt.Printf("ℹ️ У %s не было привязки Google, состояние синхронизации очищено\n", user.DisplayName())
+		return nil
+	}
+	fmt.Printf("✅ Привязка Google для %s сброшена. Пользователь может заново выполнить /google_auth\n", user.DisplayName())
+	return nil
+}
+
+func (a *app) reports(ctx context.Context, args []string) error {
+	okrService := okr.NewService(a.db)
+
+	if len(args) == 1 && args[0] == "failed" {
+		failed, err := okrService.ListFailedReports(ctx)
+		if err != nil {
+			return err
+		}
+		if len(failed) == 0 {
+			fmt.Println("✅ Упавших отчетов нет")
+			return nil
+		}
+		for _, r := range failed {
+			fmt.Printf("%d\t%s\t%s\t%s\n", r.UserID, r.ReportPeriod, r.LastFailedAt.Local().Format("02.01.2006 15:04"), oneLine(*r.LastError))
+		}
+		return nil
+	}
+
+	if len(args) == 2 && args[0] == "rerun" {
+		user, err := a.findUser(ctx, args[1:], 1)
+		if err != nil {
+			return err
+		}
+		bot, err := tgbotapi.NewBotAPI(a.cfg.TelegramToken)
+		if err != nil {
+			return fmt.Errorf("ошибка при инициализации Telegram бота: %v", err)
+		}
+		send := func(chatID int64, text string) error {
+			_, err := bot.Send(tgbotapi.NewMessage(chatID, text))
+			return err
+		}
+		if err := okrService.RerunReport(ctx, user.ID, send); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Отчет OKR отправлен %s\n", user.DisplayName())
+		return nil
+	}
+
+	return fmt.Errorf("использование: jarvisctl reports failed | jarvisctl reports rerun <user>")
+}
+
+func (a *app) outbox(ctx context.Context, args []string) error {
+	outboxService := outbox.NewService(a.db)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "failed":
+			return a.outboxFailed(ctx, outboxService)
+		case "retry":
+			return a.outboxRetry(ctx, outboxService, args[1:])
+		}
+		return fmt.Errorf("использование: jarvisctl outbox [failed | retry [id...]]")
+	}
+
+	counts, err := monitoring.PendingNotifications(ctx, a.db)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, c := range counts {
+		fmt.Printf("%-24s %d\n", c.Source, c.Pending)
+		total += c.Pending
+	}
+
+	deferred, err := notifications.NewService(a.db, preferences.NewService(a.db)).CountPending(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-24s %d\n", "deferred_notifications", deferred)
+	fmt.Printf("%-24s %d\n", "итого", total+deferred)
+
+	stats, err := outboxService.GetStats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.OldestPending != nil {
+		fmt.Printf("\nСамое старое сообщение в очереди: %s\n", stats.OldestPending.Local().Format("02.01.2006 15:04:05"))
+	}
+	if stats.Failed > 0 {
+		fmt.Printf("Недоставлено: %d (jarvisctl outbox failed)\n", stats.Failed)
+	}
+	return nil
+}
+
+func (a *app) outboxFailed(ctx context.Context, outboxService *outbox.Service) error {
+	messages, err := outboxService.ListFailed(ctx, 50)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		fmt.Println("✅ Недоставленных сообщений нет")
+		return nil
+	}
+	for _, m := range messages {
+		lastError := ""
+		if m.LastError != nil {
+			lastError = oneLine(*m.LastError)
+		}
+		fmt.Printf("%d\t%d\t%s\tпопыток: %d\t%s\t%s\n", m.ID, m.ChatID, m.CreatedAt.Local().Format("02.01.2006 15:04"), m.Attempts, lastError, truncate(oneLine(m.Text), 60))
+	}
+	return nil
+}
+
+func (a *app) outboxRetry(ctx context.Context, outboxService *outbox.Service, args []string) error {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("некорректный ID сообщения: %s", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	retried, err := outboxService.Retry(ctx, ids)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔁 Возвращено в очередь: %d\n", retried)
+	return nil
+}
+
+func (a *app) snapshot(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("использование: jarvisctl snapshot <dsn> [days] [user...]")
+	}
+	anonymizer, err := snapshot.NewAnonymizer(a.cfg.SnapshotSalt)
+	if err != nil {
+		return err
+	}
+
+	var opts snapshot.Options
+	rest := args[1:]
+	if len(rest) > 0 {
+		if days, err := strconv.Atoi(rest[0]); err == nil {
+			opts.Since = time.Now().AddDate(0, 0, -days)
+			rest = rest[1:]
+		}
+	}
+	for _, arg := range rest {
+		user, err := a.findUser(ctx, []string{arg}, 1)
+		if err != nil {
+			return err
+		}
+		opts.UserIDs = append(opts.UserIDs, user.ID)
+	}
+
+	target, err := sqlx.ConnectContext(ctx, "postgres", args[0])
+	if err != nil {
+		return fmt.Errorf("ошибка при подключении к целевой базе: %v", err)
+	}
+	defer target.Close()
+
+	stats, err := snapshot.Copy(ctx, a.db, target, anonymizer, opts)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, s := range stats {
+		fmt.Printf("%-24s %d\n", s.Table, s.Rows)
+		total += s.Rows
+	}
+	fmt.Printf("✅ Снимок загружен, строк: %d\n", total)
+	return nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "да"
+	}
+	return "нет"
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -5,21 +5,56 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"telegrambot/internal/admin"
+	"telegrambot/internal/ai_coach"
 	"telegrambot/internal/api"
 	"telegrambot/internal/auth"
+	"telegrambot/internal/automations"
 	"telegrambot/internal/calendar"
+	"telegrambot/internal/challenges"
 	"telegrambot/internal/chatgpt"
+	"telegrambot/internal/dashboard"
+	"telegrambot/internal/dates"
 	"telegrambot/internal/finance"
+	"telegrambot/internal/finance/receipts"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/habits"
+	"telegrambot/internal/health"
+	"telegrambot/internal/health/nutrition"
+	"telegrambot/internal/inbox"
+	"telegrambot/internal/integrations"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/journal"
 	"telegrambot/internal/linking"
+	"telegrambot/internal/mailer"
+	"telegrambot/internal/maintenance"
 	"telegrambot/internal/meetings"
 	"telegrambot/internal/messagestore"
 	"telegrambot/internal/middleware"
+	"telegrambot/internal/monitoring"
+	"telegrambot/internal/notifications"
 	"telegrambot/internal/okr"
+	"telegrambot/internal/outbox"
+	"telegrambot/internal/payments"
+	"telegrambot/internal/plugins"
+	"telegrambot/internal/preferences"
+	"telegrambot/internal/rollout"
+	"telegrambot/internal/semantic"
+	"telegrambot/internal/slack"
+	"telegrambot/internal/stripe"
+	"telegrambot/internal/support"
 	"telegrambot/internal/telegram"
+	"telegrambot/internal/transcription"
+	"telegrambot/internal/travel"
 	"telegrambot/internal/users"
+	"telegrambot/internal/worklocation"
 	"telegrambot/pkg/config"
 	"telegrambot/pkg/db"
+	"telegrambot/pkg/logging"
+	"telegrambot/pkg/tracing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -32,22 +67,60 @@ func main() {
 
 	cfg := config.LoadConfig()
 
+	if err := logging.Init(cfg.LogLevel, cfg.LogModuleLevels); err != nil {
+		logrus.Fatalf("Ошибка при настройке логирования: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		logrus.Fatalf("Ошибка при инициализации трассировки: %v", err)
+	}
+
 	database, err := db.NewPostgresDB(cfg)
 	if err != nil {
 		logrus.Fatalf("Ошибка при подключении к базе данных: %v", err)
 	}
 	defer database.Close()
 
-	chatgptService := chatgpt.NewChatGPTService(cfg, database)
+	plugins.Configure(context.Background(), cfg)
+
+	rolloutService := rollout.NewService(database)
+	chatgptService := chatgpt.NewChatGPTService(cfg, database, rolloutService)
 	calendarService := calendar.NewService(database, cfg)
 	meetingsService := meetings.NewService(database)
 	financeService := finance.NewService(database)
 	okrService := okr.NewService(database)
+	datesService := dates.NewService(database)
+	healthService := health.NewService(database)
+	habitsService := habits.NewService(database, okrService)
+	nutritionService := nutrition.NewService(database, okrService)
+	workLocationService := worklocation.NewService(database)
+	travelService := travel.NewService(database, calendarService)
 	userRepo := users.NewRepository(database)
 	userService := users.NewService(userRepo)
 	linkingSvc := linking.NewService()
-
-	messageStoreRepo := messagestore.NewRepository(database)
+	adminService := admin.NewService(database, admin.ParseAdminIDs(cfg.AdminTelegramIDs))
+	paymentsService := payments.NewService(database, cfg)
+	stripeClient := stripe.NewClient(cfg)
+	focusService := focus.NewService(database)
+	transcriptionService := transcription.NewService(database)
+	automationsService := automations.NewService(database, okrService, calendarService)
+	challengesService := challenges.NewService(database)
+	integrationsService := integrations.NewService(database)
+	dashboardService := dashboard.NewService(database)
+	semanticService := semantic.NewService(database, cfg)
+	inboxService := inbox.NewService(database)
+	preferencesService := preferences.NewService(database)
+	receiptsService := receipts.NewService(database, financeService)
+	notificationsService := notifications.NewService(database, preferencesService)
+	outboxService := outbox.NewService(database)
+	supportService := support.NewService(database, cfg)
+	journalService := journal.NewService(database)
+
+	jobManager := jobs.NewManager(jobs.ParseOverrides(cfg.JobSchedules))
+	maintenanceService := maintenance.NewService(database, jobManager)
+
+	messageStoreRepo := messagestore.NewRepository(database, cfg)
 	messageStoreService := messagestore.NewService(messageStoreRepo)
 
 	telegramHandler, err := telegram.NewHandler(
@@ -57,9 +130,27 @@ func main() {
 		meetingsService,
 		financeService,
 		okrService,
+		datesService,
+		healthService,
+		habitsService,
 		messageStoreService,
 		userService,
 		linkingSvc,
+		adminService,
+		paymentsService,
+		challengesService,
+		rolloutService,
+		dashboardService,
+		inboxService,
+		preferencesService,
+		receiptsService,
+		notificationsService,
+		outboxService,
+		maintenanceService,
+		supportService,
+		journalService,
+		focusService,
+		transcriptionService,
 		database,
 	)
 	if err != nil {
@@ -73,24 +164,124 @@ func main() {
 		logrus.Warn("Не удалось получить имя пользователя бота для API Handler. Ссылки на привязку Telegram могут быть неполными.")
 	}
 
+	predictionService := ai_coach.NewPredictionService(database)
 	apiHandler := api.NewHandler(
 		calendarService,
 		userService,
 		linkingSvc,
 		okrService,
+		financeService,
+		meetingsService,
+		healthService,
+		focusService,
+		automationsService,
+		integrationsService,
+		rolloutService,
+		adminService,
+		stripeClient,
+		dashboardService,
+		semanticService,
+		maintenanceService,
+		notificationsService,
+		predictionService,
 		database,
 		cfg.JWTSigningKey,
 		botUsername,
 	)
 
-	calendarService.StartReminderChecker(telegramHandler.SendMessage)
-	calendarService.StartGoogleCalendarSync()
+	calendarService.StartReminderChecker(jobManager, telegramHandler.SendNotification(notifications.KindReminder))
+	calendarService.StartGoogleCalendarSync(jobManager)
+	calendarService.StartGoogleRelinkNotifier(jobManager, telegramHandler.SendGoogleRelink)
+	calendarService.StartCalDAVImport(jobManager)
+	calendarService.StartGuestInvitations(jobManager, mailer.NewClient(cfg))
+	calendarService.StartDailyDigest(jobManager, workLocationService, healthService, telegramHandler.SendNotification(notifications.KindReport))
+
+	okrService.StartReportChecker(jobManager, telegramHandler.SendNotification(notifications.KindReport), telegramHandler.SendWeeklyOKRReport)
+	okrService.StartRecurringTaskReminders(jobManager, telegramHandler.SendRecurringTaskReminder)
+	okrService.StartDeadlineReminders(jobManager, predictionService.CompletionProbability, telegramHandler.SendDeferrable(notifications.KindReminder))
+	okrService.StartKeyResultOwnerNudger(jobManager, telegramHandler.SendUnsolicited(preferences.KindNudge))
+	okrService.StartTeamNotifier(jobManager, telegramHandler.SendMessage, slack.NewClient())
+	okrService.StartTeamInviteNotifier(jobManager, telegramHandler.SendTeamInvite)
+	okrService.StartTrashPurger(jobManager)
+	okrService.StartPartnerJobs(jobManager, telegramHandler.SendPartnerInvite, telegramHandler.SendUnsolicited(preferences.KindInsight))
+
+	travelService.StartCheckinReminder(jobManager, telegramHandler.SendNotification(notifications.KindReminder))
+
+	datesService.StartReminderChecker(jobManager, telegramHandler.SendNotification(notifications.KindReminder), telegramHandler.SendDateReminder)
+
+	focusService.StartPacingAlerts(jobManager, calendarService, telegramHandler.SendUnsolicited(preferences.KindNudge))
+	focusService.StartSessionTimer(jobManager, telegramHandler.SendNotification(notifications.KindReminder))
+	focusService.StartWeeklyTimeReport(jobManager, telegramHandler.SendUnsolicited(preferences.KindInsight))
+	focusService.StartPeakHoursJob(jobManager)
+	focusService.StartWeeklyPlanTracking(jobManager, telegramHandler.SendUnsolicited(preferences.KindNudge))
+
+	automationsService.StartRuleEngine(jobManager, telegramHandler.SendNotification(notifications.KindAlert))
+
+	challengesService.StartChallengeNotifier(jobManager, telegramHandler.SendMessage, telegramHandler.SendChallengeInvite)
+
+	integrationsService.StartWebhookDelivery(jobManager)
+
+	healthService.StartMedicationReminders(jobManager, telegramHandler.SendNotification(notifications.KindReminder), telegramHandler.SendMedicationReminder)
+	nutritionService.StartNutritionJobs(jobManager, telegramHandler.SendUnsolicited(preferences.KindInsight))
+
+	paymentsService.StartExpiryChecker(jobManager, telegramHandler.SendNotification(notifications.KindAlert))
+
+	financeService.StartInviteNotifier(jobManager, telegramHandler.SendFinanceInvite)
+
+	meetingsService.StartInviteNotifier(jobManager, telegramHandler.SendMeetingInvite, telegramHandler.SendMessage)
+
+	monitoring.RegisterGauge(monitoring.GaugeOutboxBacklog, monitoring.PendingNotificationsGauge(database))
+	alertChatID, _ := strconv.ParseInt(cfg.AdminAlertChatID, 10, 64)
+	alerter := monitoring.NewAlerter(
+		alertChatID,
+		monitoring.ParseThresholds(cfg.AlertWebhookErrorRate, cfg.AlertOpenAIFailureRate, cfg.AlertOutboxBacklog),
+		telegramHandler.SendMessage,
+	)
+	alerter.Start(jobManager)
+
+	rolloutService.StartRolloutGuard(jobManager, alerter.Notify)
+
+	ai_coach.NewPersonalityService(database).StartProfileLearning(jobManager)
+	predictionService.StartPredictionCalibration(jobManager)
+	ai_coach.NewMotivationService(database).StartMotivationTriggers(jobManager, telegramHandler.SendUnsolicited(preferences.KindMotivation))
+
+	chatgptService.StartConversationSummaries(jobManager)
+	chatgptService.StartJournalReflections(jobManager, telegramHandler.SendUnsolicited(preferences.KindInsight))
+
+	journalService.StartEveningPrompt(jobManager, telegramHandler.SendJournalPrompt)
+
+	semanticService.StartIndexing(jobManager)
 
-	okrService.StartReportChecker(telegramHandler.SendMessage)
+	inboxService.StartTriage(jobManager, telegramHandler.SendInboxTriage)
+
+	notificationsService.StartCatchUp(jobManager, telegramHandler.SendMessage)
+	notificationsService.StartLogCleanup(jobManager)
+
+	outboxService.StartSender(jobManager, telegramHandler.DeliverOutbound)
+
+	transcriptionService.StartWorker(jobManager, telegramHandler.ProcessTranscriptionJob)
+
+	if err := maintenanceService.Start(context.Background(), telegramHandler.SendMessage); err != nil {
+		logrus.Errorf("Ошибка при восстановлении режима обслуживания: %v", err)
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/webhook", telegramHandler.HandleWebhook)
 
+	if cfg.MetricsToken == "" {
+		logrus.Warn("METRICS_TOKEN не задан, эндпоинт /metrics закрыт")
+	}
+	mux.Handle("/metrics", monitoring.MetricsHandler(cfg.MetricsToken))
+
+	mux.Handle("/api/alerts/alertmanager", alerter.AlertmanagerHandler(cfg.AlertWebhookToken))
+
+	mux.Handle("/google/notifications", calendarService.PushNotificationHandler())
+
+	mux.Handle("/api/email/inbound", calendarService.InboundEmailHandler(cfg.InboundEmailToken, telegramHandler.SendMessage))
+
+	jobStatsHandler := apiHandler.AdminJobStatsHandler(http.HandlerFunc(jobManager.StatsHandler))
+	mux.Handle("/api/jobs", middleware.CORSMiddleware(auth.JWTMiddleware(jobStatsHandler, cfg.JWTSigningKey)))
+
 	mux.Handle("/api/auth/login", middleware.CORSMiddleware(http.HandlerFunc(apiHandler.AuthLoginHandler)))
 
 	mux.Handle("/api/auth/register", middleware.CORSMiddleware(http.HandlerFunc(apiHandler.RegisterWebUserHandler)))
@@ -110,6 +301,17 @@ func main() {
 	deleteEventHandler := http.HandlerFunc(apiHandler.DeleteCalendarEventHandler)
 	mux.Handle("/api/calendar/event/delete", middleware.CORSMiddleware(auth.JWTMiddleware(deleteEventHandler, cfg.JWTSigningKey)))
 
+	calendarFeedHandler := http.HandlerFunc(apiHandler.CalendarFeedHandler)
+	mux.Handle("/api/calendar/feed", middleware.CORSMiddleware(auth.JWTMiddleware(calendarFeedHandler, cfg.JWTSigningKey)))
+
+	mux.Handle("/api/calendar/feed/", calendarService.FeedHandler())
+
+	eventGuestsHandler := http.HandlerFunc(apiHandler.EventGuestsHandler)
+	mux.Handle("/api/calendar/event/guests", middleware.CORSMiddleware(auth.JWTMiddleware(eventGuestsHandler, cfg.JWTSigningKey)))
+
+	calDAVHandler := http.HandlerFunc(apiHandler.CalDAVHandler)
+	mux.Handle("/api/calendar/caldav", middleware.CORSMiddleware(auth.JWTMiddleware(calDAVHandler, cfg.JWTSigningKey)))
+
 	setOKRReportSettingsHandler := http.HandlerFunc(apiHandler.SetOKRReportSettingsHandler)
 	mux.Handle("/api/okr/report-settings/set", middleware.CORSMiddleware(auth.JWTMiddleware(setOKRReportSettingsHandler, cfg.JWTSigningKey)))
 
@@ -119,9 +321,190 @@ func main() {
 	getOKRReportSettingsHandler := http.HandlerFunc(apiHandler.GetOKRReportSettingsHandler)
 	mux.Handle("/api/okr/report-settings/get", middleware.CORSMiddleware(auth.JWTMiddleware(getOKRReportSettingsHandler, cfg.JWTSigningKey)))
 
+	okrObjectivesHandler := http.HandlerFunc(apiHandler.ObjectivesHandler)
+	mux.Handle("/api/okr/objectives", middleware.CORSMiddleware(auth.JWTMiddleware(okrObjectivesHandler, cfg.JWTSigningKey)))
+
+	okrObjectiveDraftHandler := http.HandlerFunc(apiHandler.ObjectiveDraftHandler)
+	mux.Handle("/api/okr/objectives/draft", middleware.CORSMiddleware(auth.JWTMiddleware(okrObjectiveDraftHandler, cfg.JWTSigningKey)))
+
+	okrTemplatesHandler := http.HandlerFunc(apiHandler.TemplatesHandler)
+	mux.Handle("/api/okr/templates", middleware.CORSMiddleware(auth.JWTMiddleware(okrTemplatesHandler, cfg.JWTSigningKey)))
+
+	okrSpheresHandler := http.HandlerFunc(apiHandler.SpheresHandler)
+	mux.Handle("/api/okr/spheres", middleware.CORSMiddleware(auth.JWTMiddleware(okrSpheresHandler, cfg.JWTSigningKey)))
+
+	okrKeyResultsHandler := http.HandlerFunc(apiHandler.KeyResultsHandler)
+	mux.Handle("/api/okr/keyresults", middleware.CORSMiddleware(auth.JWTMiddleware(okrKeyResultsHandler, cfg.JWTSigningKey)))
+
+	okrTasksHandler := http.HandlerFunc(apiHandler.TasksHandler)
+	mux.Handle("/api/okr/tasks", middleware.CORSMiddleware(auth.JWTMiddleware(okrTasksHandler, cfg.JWTSigningKey)))
+
+	okrTeamsHandler := http.HandlerFunc(apiHandler.TeamsHandler)
+	mux.Handle("/api/okr/teams", middleware.CORSMiddleware(auth.JWTMiddleware(okrTeamsHandler, cfg.JWTSigningKey)))
+
+	okrTeamInviteHandler := http.HandlerFunc(apiHandler.TeamInviteHandler)
+	mux.Handle("/api/okr/teams/invite", middleware.CORSMiddleware(auth.JWTMiddleware(okrTeamInviteHandler, cfg.JWTSigningKey)))
+
+	okrTeamInvitationRespondHandler := http.HandlerFunc(apiHandler.TeamInvitationRespondHandler)
+	mux.Handle("/api/okr/teams/invitations/respond", middleware.CORSMiddleware(auth.JWTMiddleware(okrTeamInvitationRespondHandler, cfg.JWTSigningKey)))
+
+	okrTeamObjectivesHandler := http.HandlerFunc(apiHandler.TeamObjectivesHandler)
+	mux.Handle("/api/okr/teams/objectives", middleware.CORSMiddleware(auth.JWTMiddleware(okrTeamObjectivesHandler, cfg.JWTSigningKey)))
+
+	okrShareObjectiveHandler := http.HandlerFunc(apiHandler.ShareObjectiveHandler)
+	mux.Handle("/api/okr/objectives/share", middleware.CORSMiddleware(auth.JWTMiddleware(okrShareObjectiveHandler, cfg.JWTSigningKey)))
+
+	okrShareLinkHandler := http.HandlerFunc(apiHandler.ShareLinkHandler)
+	mux.Handle("/api/okr/objectives/share-link", middleware.CORSMiddleware(auth.JWTMiddleware(okrShareLinkHandler, cfg.JWTSigningKey)))
+
+	mux.Handle(okr.ShareLinkPathPrefix, middleware.CORSMiddleware(http.HandlerFunc(apiHandler.SharedObjectiveHandler)))
+
+	okrRestoreObjectiveHandler := http.HandlerFunc(apiHandler.RestoreObjectiveHandler)
+	mux.Handle("/api/okr/objectives/restore", middleware.CORSMiddleware(auth.JWTMiddleware(okrRestoreObjectiveHandler, cfg.JWTSigningKey)))
+
+	okrCloseObjectiveHandler := http.HandlerFunc(apiHandler.CloseObjectiveHandler)
+	mux.Handle("/api/okr/objectives/close", middleware.CORSMiddleware(auth.JWTMiddleware(okrCloseObjectiveHandler, cfg.JWTSigningKey)))
+
+	okrTrashHandler := http.HandlerFunc(apiHandler.TrashHandler)
+	mux.Handle("/api/okr/trash", middleware.CORSMiddleware(auth.JWTMiddleware(okrTrashHandler, cfg.JWTSigningKey)))
+
+	okrRestoreDeletedHandler := http.HandlerFunc(apiHandler.RestoreDeletedHandler)
+	mux.Handle("/api/okr/trash/restore", middleware.CORSMiddleware(auth.JWTMiddleware(okrRestoreDeletedHandler, cfg.JWTSigningKey)))
+
+	okrRetrospectiveHandler := http.HandlerFunc(apiHandler.QuarterRetrospectiveHandler)
+	mux.Handle("/api/okr/retrospective", middleware.CORSMiddleware(auth.JWTMiddleware(okrRetrospectiveHandler, cfg.JWTSigningKey)))
+
+	searchMessagesHandler := http.HandlerFunc(apiHandler.SearchMessagesHandler)
+	mux.Handle("/api/messages/search", middleware.CORSMiddleware(auth.JWTMiddleware(searchMessagesHandler, cfg.JWTSigningKey)))
+
+	notificationsHandler := http.HandlerFunc(apiHandler.NotificationsHandler)
+	mux.Handle("/api/notifications", middleware.CORSMiddleware(auth.JWTMiddleware(notificationsHandler, cfg.JWTSigningKey)))
+
+	markNotificationsReadHandler := http.HandlerFunc(apiHandler.MarkNotificationsReadHandler)
+	mux.Handle("/api/notifications/read", middleware.CORSMiddleware(auth.JWTMiddleware(markNotificationsReadHandler, cfg.JWTSigningKey)))
+
+	notificationSettingsHandler := http.HandlerFunc(apiHandler.NotificationSettingsHandler)
+	mux.Handle("/api/notifications/settings", middleware.CORSMiddleware(auth.JWTMiddleware(notificationSettingsHandler, cfg.JWTSigningKey)))
+
+	monthlyDashboardHandler := http.HandlerFunc(apiHandler.MonthlyDashboardHandler)
+	mux.Handle("/api/dashboard/monthly", middleware.CORSMiddleware(auth.JWTMiddleware(monthlyDashboardHandler, cfg.JWTSigningKey)))
+
+	getTransactionsHandler := http.HandlerFunc(apiHandler.GetTransactionsHandler)
+	mux.Handle("/api/finance/transactions", middleware.CORSMiddleware(auth.JWTMiddleware(getTransactionsHandler, cfg.JWTSigningKey)))
+
+	createTransactionHandler := http.HandlerFunc(apiHandler.CreateTransactionHandler)
+	mux.Handle("/api/finance/transaction/create", middleware.CORSMiddleware(auth.JWTMiddleware(createTransactionHandler, cfg.JWTSigningKey)))
+
+	deleteTransactionHandler := http.HandlerFunc(apiHandler.DeleteTransactionHandler)
+	mux.Handle("/api/finance/transaction/delete", middleware.CORSMiddleware(auth.JWTMiddleware(deleteTransactionHandler, cfg.JWTSigningKey)))
+
+	linkTransactionHandler := http.HandlerFunc(apiHandler.LinkTransactionHandler)
+	mux.Handle("/api/finance/transaction/link", middleware.CORSMiddleware(auth.JWTMiddleware(linkTransactionHandler, cfg.JWTSigningKey)))
+
+	getFinanceSummaryHandler := http.HandlerFunc(apiHandler.GetFinanceSummaryHandler)
+	mux.Handle("/api/finance/summary", middleware.CORSMiddleware(auth.JWTMiddleware(getFinanceSummaryHandler, cfg.JWTSigningKey)))
+
+	getMeetingsHandler := http.HandlerFunc(apiHandler.GetMeetingsHandler)
+	mux.Handle("/api/meetings", middleware.CORSMiddleware(auth.JWTMiddleware(getMeetingsHandler, cfg.JWTSigningKey)))
+
+	createMeetingHandler := http.HandlerFunc(apiHandler.CreateMeetingHandler)
+	mux.Handle("/api/meetings/create", middleware.CORSMiddleware(auth.JWTMiddleware(createMeetingHandler, cfg.JWTSigningKey)))
+
+	respondMeetingHandler := http.HandlerFunc(apiHandler.RespondMeetingHandler)
+	mux.Handle("/api/meetings/respond", middleware.CORSMiddleware(auth.JWTMiddleware(respondMeetingHandler, cfg.JWTSigningKey)))
+
+	meetingAvailabilityHandler := http.HandlerFunc(apiHandler.MeetingAvailabilityHandler)
+	mux.Handle("/api/meetings/availability", middleware.CORSMiddleware(auth.JWTMiddleware(meetingAvailabilityHandler, cfg.JWTSigningKey)))
+
 	getGoogleAuthURLHandler := http.HandlerFunc(apiHandler.GetGoogleAuthURLHandler)
 	mux.Handle("/api/calendar/google/auth-url", middleware.CORSMiddleware(auth.JWTMiddleware(getGoogleAuthURLHandler, cfg.JWTSigningKey)))
 
+	googleSyncStatusHandler := http.HandlerFunc(apiHandler.GoogleSyncStatusHandler)
+	mux.Handle("/api/calendar/google/status", middleware.CORSMiddleware(auth.JWTMiddleware(googleSyncStatusHandler, cfg.JWTSigningKey)))
+
+	adminUsersHandler := http.HandlerFunc(apiHandler.AdminUsersHandler)
+	mux.Handle("/api/admin/users", middleware.CORSMiddleware(auth.JWTMiddleware(adminUsersHandler, cfg.JWTSigningKey)))
+
+	adminSetUserRoleHandler := http.HandlerFunc(apiHandler.AdminSetUserRoleHandler)
+	mux.Handle("/api/admin/users/role", middleware.CORSMiddleware(auth.JWTMiddleware(adminSetUserRoleHandler, cfg.JWTSigningKey)))
+
+	adminPromptReleasesHandler := http.HandlerFunc(apiHandler.AdminPromptReleasesHandler)
+	mux.Handle("/api/admin/prompt-releases", middleware.CORSMiddleware(auth.JWTMiddleware(adminPromptReleasesHandler, cfg.JWTSigningKey)))
+
+	adminPromptRollbackHandler := http.HandlerFunc(apiHandler.AdminPromptRollbackHandler)
+	mux.Handle("/api/admin/prompt-releases/rollback", middleware.CORSMiddleware(auth.JWTMiddleware(adminPromptRollbackHandler, cfg.JWTSigningKey)))
+
+	adminBlockUserHandler := http.HandlerFunc(apiHandler.AdminBlockUserHandler)
+	mux.Handle("/api/admin/users/block", middleware.CORSMiddleware(auth.JWTMiddleware(adminBlockUserHandler, cfg.JWTSigningKey)))
+
+	adminStatsHandler := http.HandlerFunc(apiHandler.AdminStatsHandler)
+	mux.Handle("/api/admin/stats", middleware.CORSMiddleware(auth.JWTMiddleware(adminStatsHandler, cfg.JWTSigningKey)))
+
+	adminMaintenanceHandler := http.HandlerFunc(apiHandler.AdminMaintenanceHandler)
+	mux.Handle("/api/admin/maintenance", middleware.CORSMiddleware(auth.JWTMiddleware(adminMaintenanceHandler, cfg.JWTSigningKey)))
+
+	adminLogLevelsHandler := http.HandlerFunc(apiHandler.AdminLogLevelsHandler)
+	mux.Handle("/api/admin/log-levels", middleware.CORSMiddleware(auth.JWTMiddleware(adminLogLevelsHandler, cfg.JWTSigningKey)))
+
+	adminPredictionCalibrationHandler := http.HandlerFunc(apiHandler.AdminPredictionCalibrationHandler)
+	mux.Handle("/api/admin/predictions/calibration", middleware.CORSMiddleware(auth.JWTMiddleware(adminPredictionCalibrationHandler, cfg.JWTSigningKey)))
+
+	sleepHandler := http.HandlerFunc(apiHandler.SleepHandler)
+	mux.Handle("/api/health/sleep", middleware.CORSMiddleware(auth.JWTMiddleware(sleepHandler, cfg.JWTSigningKey)))
+
+	reportDistractionsHandler := http.HandlerFunc(apiHandler.ReportDistractionsHandler)
+	mux.Handle("/api/focus/distractions", middleware.CORSMiddleware(auth.JWTMiddleware(reportDistractionsHandler, cfg.JWTSigningKey)))
+
+	distractionStatsHandler := http.HandlerFunc(apiHandler.DistractionStatsHandler)
+	mux.Handle("/api/focus/distractions/stats", middleware.CORSMiddleware(auth.JWTMiddleware(distractionStatsHandler, cfg.JWTSigningKey)))
+
+	logTimeHandler := http.HandlerFunc(apiHandler.LogTimeHandler)
+	mux.Handle("/api/focus/time", middleware.CORSMiddleware(auth.JWTMiddleware(logTimeHandler, cfg.JWTSigningKey)))
+
+	timeAllocationHandler := http.HandlerFunc(apiHandler.TimeAllocationHandler)
+	mux.Handle("/api/focus/time/allocation", middleware.CORSMiddleware(auth.JWTMiddleware(timeAllocationHandler, cfg.JWTSigningKey)))
+
+	peakHoursHandler := http.HandlerFunc(apiHandler.PeakHoursHandler)
+	mux.Handle("/api/focus/peak-hours", middleware.CORSMiddleware(auth.JWTMiddleware(peakHoursHandler, cfg.JWTSigningKey)))
+
+	automationRulesHandler := http.HandlerFunc(apiHandler.AutomationRulesHandler)
+	mux.Handle("/api/automations", middleware.CORSMiddleware(auth.JWTMiddleware(automationRulesHandler, cfg.JWTSigningKey)))
+
+	runAutomationRulesHandler := http.HandlerFunc(apiHandler.RunAutomationRulesHandler)
+	mux.Handle("/api/automations/run", middleware.CORSMiddleware(auth.JWTMiddleware(runAutomationRulesHandler, cfg.JWTSigningKey)))
+
+	apiKeysHandler := http.HandlerFunc(apiHandler.APIKeysHandler)
+	mux.Handle("/api/integrations/keys", middleware.CORSMiddleware(auth.JWTMiddleware(apiKeysHandler, cfg.JWTSigningKey)))
+
+	integrationMeHandler := http.HandlerFunc(apiHandler.IntegrationMeHandler)
+	mux.Handle("/api/integrations/me", middleware.CORSMiddleware(auth.APIKeyMiddleware(integrationMeHandler, userService.AuthenticateAPIKey)))
+
+	newObjectivesTriggerHandler := apiHandler.IntegrationTriggerHandler(integrations.EventObjectiveCreated)
+	mux.Handle("/api/integrations/triggers/objectives", middleware.CORSMiddleware(auth.APIKeyMiddleware(newObjectivesTriggerHandler, userService.AuthenticateAPIKey)))
+
+	completedTasksTriggerHandler := apiHandler.IntegrationTriggerHandler(integrations.EventTaskCompleted)
+	mux.Handle("/api/integrations/triggers/completed-tasks", middleware.CORSMiddleware(auth.APIKeyMiddleware(completedTasksTriggerHandler, userService.AuthenticateAPIKey)))
+
+	newTransactionsTriggerHandler := apiHandler.IntegrationTriggerHandler(integrations.EventTransactionCreated)
+	mux.Handle("/api/integrations/triggers/transactions", middleware.CORSMiddleware(auth.APIKeyMiddleware(newTransactionsTriggerHandler, userService.AuthenticateAPIKey)))
+
+	integrationHooksHandler := http.HandlerFunc(apiHandler.IntegrationHooksHandler)
+	mux.Handle("/api/integrations/hooks", middleware.CORSMiddleware(auth.APIKeyMiddleware(integrationHooksHandler, userService.AuthenticateAPIKey)))
+
+	integrationCreateTaskHandler := http.HandlerFunc(apiHandler.IntegrationCreateTaskHandler)
+	mux.Handle("/api/integrations/actions/create-task", middleware.CORSMiddleware(auth.APIKeyMiddleware(integrationCreateTaskHandler, userService.AuthenticateAPIKey)))
+
+	integrationLogProgressHandler := http.HandlerFunc(apiHandler.IntegrationLogProgressHandler)
+	mux.Handle("/api/integrations/actions/log-progress", middleware.CORSMiddleware(auth.APIKeyMiddleware(integrationLogProgressHandler, userService.AuthenticateAPIKey)))
+
+	billingCheckoutHandler := http.HandlerFunc(apiHandler.CreateCheckoutSessionHandler)
+	mux.Handle("/api/billing/checkout", middleware.CORSMiddleware(auth.JWTMiddleware(billingCheckoutHandler, cfg.JWTSigningKey)))
+
+	billingStatusHandler := http.HandlerFunc(apiHandler.BillingStatusHandler)
+	mux.Handle("/api/billing/status", middleware.CORSMiddleware(auth.JWTMiddleware(billingStatusHandler, cfg.JWTSigningKey)))
+
+	mux.Handle("/api/billing/webhook", http.HandlerFunc(apiHandler.StripeWebhookHandler))
+
 	mux.Handle("/api/calendar/google/callback", middleware.CORSMiddleware(http.HandlerFunc(apiHandler.HandleGoogleCallbackHandler)))
 
 	server := &http.Server{
@@ -142,11 +525,19 @@ func main() {
 
 	logrus.Info("Завершение работы сервера...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		logrus.Fatalf("Ошибка при остановке сервера: %v", err)
+		logrus.Errorf("Ошибка при остановке сервера: %v", err)
+	}
+
+	if err := jobManager.Stop(ctx); err != nil {
+		logrus.Errorf("Ошибка при остановке фоновых задач: %v", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.Errorf("Ошибка при остановке трассировки: %v", err)
 	}
 
 	logrus.Info("Сервер остановлен")
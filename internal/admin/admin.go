@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	RoleFree	= "free"
+	RolePremium	= "premium"
+)
+
+var (
+	ErrUserNotFound	= errors.New("пользователь не найден")
+	ErrInvalidRole	= errors.New("неверная роль")
+)
+
+type Service struct {
+	db		*sqlx.DB
+	adminIDs	map[int64]bool
+}
+
+type User struct {
+	ID		int64		`db:"id" json:"id"`
+	Username	*string		`db:"username" json:"username,omitempty"`
+	FirstName	*string		`db:"first_name" json:"first_name,omitempty"`
+	Role		string		`db:"role" json:"role"`
+	IsBlocked	bool		`db:"is_blocked" json:"is_blocked"`
+	BlockedAt	*time.Time	`db:"blocked_at" json:"blocked_at,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	IsAdmin		bool		`db:"-" json:"is_admin"`
+}
+
+type Stats struct {
+	TotalUsers	int		`json:"total_users"`
+	ByRole		map[string]int	`json:"by_role"`
+	BlockedUsers	int		`json:"blocked_users"`
+	NewLastWeek	int		`json:"new_last_week"`
+	ActiveLastWeek	int		`json:"active_last_week"`
+}
+
+const userSelect = `SELECT id, username, first_name, role, is_blocked, blocked_at, created_at FROM users`
+
+func NewService(db *sqlx.DB, adminIDs []int64) *Service {
+	ids := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		ids[id] = true
+	}
+	return &Service{db: db, adminIDs: ids}
+}
+
+func ParseAdminIDs(value string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func ValidateRole(role string) error {
+	if role != RoleFree && role != RolePremium {
+		return fmt.Errorf("%w: %s. Допустимые значения: free, premium", ErrInvalidRole, role)
+	}
+	return nil
+}
+
+func (s *Service) IsAdmin(telegramID int64) bool {
+	return s.adminIDs[telegramID]
+}
+
+func (s *Service) IsAnyAdmin(telegramIDs []int64) bool {
+	for _, id := range telegramIDs {
+		if s.IsAdmin(id) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) ListUsers(ctx context.Context, search string, limit, offset int) ([]User, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var list []User
+	query := userSelect + `
+		WHERE $1::text = '' OR username ILIKE '%' || $1 || '%' OR first_name ILIKE '%' || $1 || '%' OR id::text = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	if err := s.db.SelectContext(ctx, &list, query, strings.TrimPrefix(search, "@"), limit, offset); err != nil {
+		return nil, fmt.Errorf("ошибка при получении списка пользователей: %v", err)
+	}
+
+	for i := range list {
+		list[i].IsAdmin = s.IsAdmin(list[i].ID)
+	}
+	return list, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, userID int64) (*User, error) {
+	var user User
+	err := s.db.GetContext(ctx, &user, userSelect+` WHERE id = $1`, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении пользователя: %v", err)
+	}
+
+	user.IsAdmin = s.IsAdmin(user.ID)
+	return &user, nil
+}
+
+func (s *Service) FindUser(ctx context.Context, ref string) (*User, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return s.GetUser(ctx, id)
+	}
+
+	var user User
+	err := s.db.GetContext(ctx, &user, userSelect+` WHERE LOWER(username) = LOWER($1)`, strings.TrimPrefix(ref, "@"))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске пользователя: %v", err)
+	}
+
+	user.IsAdmin = s.IsAdmin(user.ID)
+	return &user, nil
+}
+
+func (s *Service) SetRole(ctx context.Context, userID int64, role string) error {
+	if err := ValidateRole(role); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE users SET role = $1, subscription_expires_at = NULL WHERE id = $2`, role, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при изменении роли: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Service) GrantSubscription(ctx context.Context, userID int64, days int) (time.Time, error) {
+	if days <= 0 {
+		return time.Time{}, fmt.Errorf("срок подписки должен быть больше нуля")
+	}
+
+	var expiresAt time.Time
+	err := s.db.GetContext(ctx, &expiresAt, `
+		UPDATE users
+		SET role = $1,
+			subscription_expires_at = GREATEST(COALESCE(subscription_expires_at, NOW()), NOW()) + make_interval(days => $2),
+			subscription_reminded = FALSE
+		WHERE id = $3
+		RETURNING subscription_expires_at
+	`, RolePremium, days, userID)
+	if err == sql.ErrNoRows {
+		return time.Time{}, ErrUserNotFound
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка при выдаче подписки: %v", err)
+	}
+	return expiresAt, nil
+}
+
+func (s *Service) SetBlocked(ctx context.Context, userID int64, blocked bool) error {
+	query := `
+		UPDATE users
+		SET is_blocked = $1, blocked_at = CASE WHEN $1 THEN NOW() ELSE NULL END
+		WHERE id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, blocked, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при изменении блокировки: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Service) GetStats(ctx context.Context) (*Stats, error) {
+	stats := &Stats{ByRole: map[string]int{}}
+
+	query := `
+		SELECT
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE is_blocked) AS blocked,
+			COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '7 days') AS new_week
+		FROM users
+	`
+	row := s.db.QueryRowxContext(ctx, query)
+	if err := row.Scan(&stats.TotalUsers, &stats.BlockedUsers, &stats.NewLastWeek); err != nil {
+		return nil, fmt.Errorf("ошибка при получении статистики пользователей: %v", err)
+	}
+
+	var roles []struct {
+		Role	string	`db:"role"`
+		Count	int	`db:"count"`
+	}
+	if err := s.db.SelectContext(ctx, &roles, `SELECT role, COUNT(*) AS count FROM users GROUP BY role`); err != nil {
+		return nil, fmt.Errorf("ошибка при получении статистики ролей: %v", err)
+	}
+	for _, r := range roles {
+		stats.ByRole[r.Role] = r.Count
+	}
+
+	err := s.db.GetContext(ctx, &stats.ActiveLastWeek, `
+		SELECT COUNT(DISTINCT user_identifier) FROM user_messages
+		WHERE created_at >= NOW() - INTERVAL '7 days'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении активных пользователей: %v", err)
+	}
+
+	return stats, nil
+}
+
+func FormatStats(stats *Stats) string {
+	var b strings.Builder
+	b.WriteString("📊 Статистика пользователей\n\n")
+	b.WriteString(fmt.Sprintf("👥 Всего: %d\n", stats.TotalUsers))
+	b.WriteString(fmt.Sprintf("💎 С подпиской: %d\n", stats.TotalUsers-stats.ByRole[RoleFree]))
+	b.WriteString(fmt.Sprintf("🆓 Без подписки: %d\n", stats.ByRole[RoleFree]))
+	b.WriteString(fmt.Sprintf("⛔ Заблокировано: %d\n", stats.BlockedUsers))
+	b.WriteString(fmt.Sprintf("🆕 Новых за неделю: %d\n", stats.NewLastWeek))
+	b.WriteString(fmt.Sprintf("💬 Активных за неделю: %d", stats.ActiveLastWeek))
+	return b.String()
+}
+
+func (u *User) DisplayName() string {
+	if u.Username != nil && *u.Username != "" {
+		return "@" + *u.Username
+	}
+	if u.FirstName != nil && *u.FirstName != "" {
+		return fmt.Sprintf("%s (%d)", *u.FirstName, u.ID)
+	}
+	return strconv.FormatInt(u.ID, 10)
+}
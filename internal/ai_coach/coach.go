@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"telegrambot/internal/focus"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -18,6 +19,7 @@ type AICoachService struct {
 	motivationEngine	*MotivationService
 	predictionEngine	*PredictionService
 	learningEngine		*LearningService
+	focus			*focus.Service
 }
 
 type AIInsight struct {
@@ -78,6 +80,11 @@ type ProductivityMetrics struct {
 	WeeklyProductivity	map[string]float64	`json:"weekly_productivity"`
 	CategoryPerformance	map[string]float64	`json:"category_performance"`
 	StreakDays		int			`json:"streak_days"`
+	DeepWorkMinutes		int			`json:"deep_work_minutes"`
+	DeepWorkTarget		int			`json:"deep_work_target_minutes"`
+	Distractions		int			`json:"distractions"`
+	FocusDistractions	int			`json:"focus_distractions"`
+	DistractionAdvice	[]string		`json:"distraction_advice,omitempty"`
 	TotalPointsEarned	int			`json:"total_points_earned"`
 	Level			int			`json:"level"`
 	RecentAchievements	[]Achievement		`json:"recent_achievements"`
@@ -127,6 +134,7 @@ func NewAICoachService(db *sqlx.DB) *AICoachService {
 		motivationEngine:	NewMotivationService(db),
 		predictionEngine:	NewPredictionService(db),
 		learningEngine:		NewLearningService(db),
+		focus:			focus.NewService(db),
 	}
 }
 
@@ -341,7 +349,7 @@ func (s *AICoachService) AnalyzeProductivity(ctx context.Context, userID int64)
 	metrics.CompletionRate = completionStats.Rate
 	metrics.AverageTaskTime = completionStats.AverageTime
 
-	peakHours, err := s.analyzePeakProductivityHours(ctx, userID)
+	peakHours, err := s.AnalyzePeakProductivityHours(ctx, userID)
 	if err != nil {
 		logrus.Warnf("Ошибка анализа пиковых часов: %v", err)
 	} else {
@@ -399,6 +407,23 @@ func (s *AICoachService) AnalyzeProductivity(ctx context.Context, userID int64)
 		metrics.PredictedOutcomes = predictions
 	}
 
+	focusStats, err := s.focus.GetWeeklyStats(ctx, userID, focus.WeekStart(time.Now()))
+	if err != nil {
+		logrus.Warnf("Ошибка получения фокус-времени: %v", err)
+	} else {
+		metrics.DeepWorkMinutes = focusStats.Minutes
+		metrics.DeepWorkTarget = focusStats.TargetMinutes
+	}
+
+	distractions, err := s.focus.GetDistractionStats(ctx, userID, time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		logrus.Warnf("Ошибка получения статистики отвлечений: %v", err)
+	} else {
+		metrics.Distractions = distractions.Total
+		metrics.FocusDistractions = distractions.DuringFocus
+		metrics.DistractionAdvice = distractions.SuggestAdjustments()
+	}
+
 	improvements := s.generateImprovementSuggestions(metrics)
 	metrics.ImprovementSuggestions = improvements
 
@@ -515,6 +540,10 @@ func (s *AICoachService) PredictCompletionProbability(ctx context.Context, userI
 	return s.predictionEngine.PredictCompletionProbability(ctx, userID, objectiveID)
 }
 
+func (s *AICoachService) PredictProgressTrajectory(ctx context.Context, userID int64, objectiveID string) (*ProgressPrediction, error) {
+	return s.predictionEngine.PredictProgressTrajectory(ctx, userID, objectiveID)
+}
+
 func (s *AICoachService) UpdateMoodContext(ctx context.Context, userID int64, moodScore, sleepQuality int) error {
 	return s.contextEngine.UpdateMoodContext(ctx, userID, moodScore, sleepQuality)
 }
@@ -528,7 +557,7 @@ func (s *AICoachService) GetActiveUserGoals(ctx context.Context, userID int64) (
 	query := `
 		SELECT id, title, description, priority, status, created_at, updated_at 
 		FROM objectives 
-		WHERE user_id = $1 AND status IN ('active', 'in_progress')
+		WHERE user_id = $1 AND deleted_at IS NULL AND status IN ('active', 'in_progress')
 		ORDER BY priority DESC, created_at DESC
 	`
 
@@ -563,6 +592,10 @@ func (s *AICoachService) GetActiveUserGoals(ctx context.Context, userID int64) (
 	return goals, nil
 }
 
+func (s *AICoachService) UpdateMotivationEffectiveness(ctx context.Context, userID int64, strategyType string, effectiveness float64) error {
+	return s.motivationEngine.UpdateMotivationEffectiveness(ctx, userID, strategyType, effectiveness)
+}
+
 func (s *AICoachService) GenerateMotivationPlan(ctx context.Context, userID int64, goals []interface{}) (map[string]interface{}, error) {
 	return s.motivationEngine.GenerateMotivationPlan(ctx, userID, goals)
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"telegrambot/internal/wellbeing"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -12,7 +13,8 @@ import (
 )
 
 type ContextService struct {
-	db *sqlx.DB
+	db		*sqlx.DB
+	wellbeing	*wellbeing.Service
 }
 
 type UserContext struct {
@@ -105,7 +107,7 @@ type ContextualInsight struct {
 }
 
 func NewContextService(db *sqlx.DB) *ContextService {
-	return &ContextService{db: db}
+	return &ContextService{db: db, wellbeing: wellbeing.NewService(db)}
 }
 
 func (s *ContextService) GetCurrentContext(ctx context.Context, userID int64) (map[string]interface{}, error) {
@@ -462,7 +464,7 @@ func (s *ContextService) extractMentionedGoals(ctx context.Context, userID int64
 	query := `
 		SELECT id, title 
 		FROM objectives 
-		WHERE user_id = $1 AND status = 'active'
+		WHERE user_id = $1 AND deleted_at IS NULL AND status = 'active'
 	`
 
 	rows, err := s.db.QueryContext(ctx, query, userID)
@@ -498,7 +500,7 @@ func (s *ContextService) getCurrentFocusGoals(ctx context.Context, userID int64)
 	query := `
 		SELECT o.id, o.title
 		FROM objectives o
-		WHERE o.user_id = $1 AND o.status = 'active'
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.status = 'active'
 		ORDER BY o.priority DESC, o.created_at DESC
 		LIMIT 3
 	`
@@ -528,7 +530,7 @@ func (s *ContextService) getRecentCompletions(ctx context.Context, userID int64)
 			COALESCE(ht.mood_after, 3) as mood, 5 as satisfaction
 		FROM objectives o
 		LEFT JOIN habit_tracking ht ON ht.objective_id = o.id AND ht.date = CURRENT_DATE
-		WHERE o.user_id = $1 AND o.completion_date IS NOT NULL 
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.completion_date IS NOT NULL 
 		AND o.completion_date > NOW() - INTERVAL '7 days'
 		
 		UNION ALL
@@ -539,7 +541,7 @@ func (s *ContextService) getRecentCompletions(ctx context.Context, userID int64)
 		FROM key_results kr
 		JOIN objectives o ON kr.objective_id = o.id
 		LEFT JOIN habit_tracking ht ON ht.key_result_id = kr.id AND ht.date = CURRENT_DATE
-		WHERE o.user_id = $1 AND kr.completion_date IS NOT NULL 
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND kr.completion_date IS NOT NULL 
 		AND kr.completion_date > NOW() - INTERVAL '7 days'
 		
 		ORDER BY completion_date DESC
@@ -577,10 +579,10 @@ func (s *ContextService) getPendingDeadlines(ctx context.Context, userID int64)
 		SELECT 
 			o.id, o.title, 'objective' as type, o.deadline,
 			EXTRACT(DAYS FROM o.deadline - NOW())::int as days_left,
-			COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id), 0) as progress,
+			COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id AND deleted_at IS NULL), 0) as progress,
 			o.priority, o.status
 		FROM objectives o
-		WHERE o.user_id = $1 AND o.deadline IS NOT NULL AND o.deadline > NOW()
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.deadline IS NOT NULL AND o.deadline > NOW()
 		AND o.status = 'active'
 		
 		UNION ALL
@@ -592,7 +594,7 @@ func (s *ContextService) getPendingDeadlines(ctx context.Context, userID int64)
 			kr.priority, kr.status
 		FROM key_results kr
 		JOIN objectives o ON kr.objective_id = o.id
-		WHERE o.user_id = $1 AND kr.deadline IS NOT NULL AND kr.deadline > NOW()
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND kr.deadline IS NOT NULL AND kr.deadline > NOW()
 		AND kr.status = 'active'
 		
 		ORDER BY days_left ASC
@@ -626,7 +628,7 @@ func (s *ContextService) getPreferredCategories(ctx context.Context, userID int6
 		SELECT oc.name, COUNT(o.id) as count
 		FROM objectives o
 		JOIN objective_categories oc ON o.category_id = oc.id
-		WHERE o.user_id = $1 AND o.created_at > NOW() - INTERVAL '90 days'
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.created_at > NOW() - INTERVAL '90 days'
 		GROUP BY oc.name
 		ORDER BY count DESC
 		LIMIT 3
@@ -705,13 +707,17 @@ func (s *ContextService) calculateMotivationLevel(mood int, energy int) float64
 
 func (s *ContextService) estimateStressLevel(ctx context.Context, userID int64, mood int, energy int) int {
 
+	if latest, err := s.wellbeing.GetLatest(ctx, userID, 1); err == nil && latest != nil && latest.StressLevel != nil {
+		return *latest.StressLevel
+	}
+
 	baseStress := 5 - mood
 
 	if energy < 2 {
 		baseStress += 2
 	}
 
-	query := `SELECT COUNT(*) FROM objectives WHERE user_id = $1 AND status = 'active'`
+	query := `SELECT COUNT(*) FROM objectives WHERE user_id = $1 AND deleted_at IS NULL AND status = 'active'`
 	var activeGoals int
 	err := s.db.GetContext(ctx, &activeGoals, query, userID)
 	if err == nil && activeGoals > 5 {
@@ -735,7 +741,7 @@ func (s *ContextService) calculateConfidenceLevel(ctx context.Context, userID in
 	query := `
 		SELECT COUNT(*) 
 		FROM objectives 
-		WHERE user_id = $1 AND completion_date IS NOT NULL 
+		WHERE user_id = $1 AND deleted_at IS NULL AND completion_date IS NOT NULL 
 		AND completion_date > NOW() - INTERVAL '7 days'
 	`
 
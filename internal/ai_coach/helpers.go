@@ -10,7 +10,7 @@ import (
 func (s *AICoachService) generateProductivityInsights(ctx context.Context, userID int64, personality *PersonalityProfile) ([]AIInsight, error) {
 	var insights []AIInsight
 
-	peakHours, err := s.analyzePeakProductivityHours(ctx, userID)
+	peakHours, err := s.AnalyzePeakProductivityHours(ctx, userID)
 	if err == nil && len(peakHours) > 0 {
 		insight := AIInsight{
 			UserID:			userID,
@@ -231,33 +231,12 @@ func (s *AICoachService) generatePersonalizedTips(ctx context.Context, userID in
 	return insights, nil
 }
 
-func (s *AICoachService) analyzePeakProductivityHours(ctx context.Context, userID int64) ([]int, error) {
-	query := `
-		SELECT EXTRACT(hour FROM created_at) as hour, COUNT(*) as count
-		FROM habit_tracking
-		WHERE user_id = $1 AND completed = true AND created_at > NOW() - INTERVAL '14 days'
-		GROUP BY EXTRACT(hour FROM created_at)
-		ORDER BY count DESC
-		LIMIT 3
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, userID)
+func (s *AICoachService) AnalyzePeakProductivityHours(ctx context.Context, userID int64) ([]int, error) {
+	peak, err := s.focus.GetPeakHours(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var hours []int
-	for rows.Next() {
-		var hour int
-		var count int
-		if err := rows.Scan(&hour, &count); err != nil {
-			continue
-		}
-		hours = append(hours, hour)
-	}
-
-	return hours, nil
+	return peak.Hours, nil
 }
 
 func (s *AICoachService) getRecentCompletionRate(ctx context.Context, userID int64) (float64, error) {
@@ -289,9 +268,9 @@ func (s *AICoachService) getUrgentDeadlines(ctx context.Context, userID int64) (
 	query := `
 		SELECT id, title, deadline,
 			EXTRACT(DAYS FROM deadline - NOW())::int as days_left,
-			COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id), 0) as progress
+			COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id AND deleted_at IS NULL), 0) as progress
 		FROM objectives o
-		WHERE user_id = $1 AND deadline IS NOT NULL 
+		WHERE user_id = $1 AND deleted_at IS NULL AND deadline IS NOT NULL 
 		AND deadline > NOW() AND deadline < NOW() + INTERVAL '3 days'
 		AND status = 'active'
 		ORDER BY deadline ASC
@@ -322,11 +301,11 @@ func (s *AICoachService) getLowProgressGoals(ctx context.Context, userID int64)
 }, error) {
 	query := `
 		SELECT o.id, o.title,
-			COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id), 0) as progress
+			COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id AND deleted_at IS NULL), 0) as progress
 		FROM objectives o
-		WHERE o.user_id = $1 AND o.status = 'active'
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.status = 'active'
 		AND o.created_at < NOW() - INTERVAL '7 days'
-		HAVING COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id), 0) < 20
+		HAVING COALESCE((SELECT AVG(progress/target*100) FROM key_results WHERE objective_id = o.id AND deleted_at IS NULL), 0) < 20
 		ORDER BY progress ASC
 		LIMIT 3
 	`
@@ -360,7 +339,7 @@ func (s *AICoachService) getRecentCompletedGoals(ctx context.Context, userID int
 	query := `
 		SELECT id, title
 		FROM objectives
-		WHERE user_id = $1 AND completion_date IS NOT NULL
+		WHERE user_id = $1 AND deleted_at IS NULL AND completion_date IS NOT NULL
 		AND completion_date > NOW() - INTERVAL '24 hours'
 		ORDER BY completion_date DESC
 		LIMIT 3
@@ -515,7 +494,14 @@ func (s *AICoachService) convertPredictionsToRisks(predictions []PredictionResul
 }
 
 func (s *AICoachService) getCompletionStatistics(ctx context.Context, userID int64) (struct{ Rate, AverageTime float64 }, error) {
-	return struct{ Rate, AverageTime float64 }{Rate: 0.7, AverageTime: 25.5}, nil
+	stats := struct{ Rate, AverageTime float64 }{Rate: 0.7}
+
+	focusStats, err := s.focus.GetSessionStats(ctx, userID, time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		return stats, err
+	}
+	stats.AverageTime = focusStats.AverageMinutes
+	return stats, nil
 }
 
 func (s *AICoachService) getWeeklyProductivity(ctx context.Context, userID int64) (map[string]float64, error) {
@@ -558,6 +544,12 @@ func (s *AICoachService) generateImprovementSuggestions(metrics *ProductivityMet
 		suggestions = append(suggestions, "Планируй важные задачи на часы пиковой продуктивности")
 	}
 
+	if metrics.DeepWorkTarget > 0 && metrics.DeepWorkMinutes < metrics.DeepWorkTarget/2 {
+		suggestions = append(suggestions, "Забронируй в календаре блоки для глубокой работы — до недельной цели еще далеко")
+	}
+
+	suggestions = append(suggestions, metrics.DistractionAdvice...)
+
 	return suggestions
 }
 
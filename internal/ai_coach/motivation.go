@@ -4,15 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/rand"
 	"strings"
+	"telegrambot/internal/preferences"
+	"telegrambot/internal/wellbeing"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
 )
 
+const MotivationStrategyGenerated = "generated"
+
 type MotivationService struct {
-	db *sqlx.DB
+	db		*sqlx.DB
+	wellbeing	*wellbeing.Service
+	preferences	*preferences.Service
 }
 
 type MotivationStrategy struct {
@@ -111,11 +119,12 @@ const (
 )
 
 func NewMotivationService(db *sqlx.DB) *MotivationService {
-	return &MotivationService{db: db}
+	return &MotivationService{db: db, wellbeing: wellbeing.NewService(db), preferences: preferences.NewService(db)}
 }
 
 func (s *MotivationService) GeneratePersonalizedMotivation(personality *PersonalityProfile, context map[string]interface{}, productivity *ProductivityMetrics) string {
 	motivationCtx := s.buildMotivationContext(context, productivity)
+	s.applyWellbeing(personality.UserID, motivationCtx)
 	profile := s.getMotivationProfile(personality.UserID)
 
 	strategy := s.selectOptimalStrategy(profile, motivationCtx, personality)
@@ -143,7 +152,7 @@ func (s *MotivationService) RecordMotivationUsage(ctx context.Context, userID in
 
 	dataJSON, _ := json.Marshal(strategyData)
 
-	_, err := s.db.ExecContext(ctx, query, userID, "generated", string(dataJSON), time.Now(), time.Now())
+	_, err := s.db.ExecContext(ctx, query, userID, MotivationStrategyGenerated, string(dataJSON), time.Now(), time.Now())
 	return err
 }
 
@@ -245,8 +254,128 @@ func (s *MotivationService) buildMotivationContext(context map[string]interface{
 	return motivationCtx
 }
 
+func (s *MotivationService) applyWellbeing(userID int64, motivationCtx *MotivationContext) {
+	ctx := context.Background()
+
+	latest, err := s.wellbeing.GetLatest(ctx, userID, 2)
+	if err != nil {
+		logrus.Warnf("Не удалось получить самочувствие пользователя %d для мотивации: %v", userID, err)
+		return
+	}
+	if latest != nil {
+		if latest.StressLevel != nil {
+			motivationCtx.StressLevel = *latest.StressLevel
+		}
+		if latest.SleepQuality != nil {
+			motivationCtx.PersonalFactors["sleep_quality"] = *latest.SleepQuality
+		}
+		if latest.WorkLifeBalance != nil {
+			motivationCtx.PersonalFactors["work_life_balance"] = *latest.WorkLifeBalance
+		}
+	}
+
+	if risk, ok, err := s.wellbeing.BurnoutRisk(ctx, userID); err == nil && ok {
+		motivationCtx.PersonalFactors["burnout_risk"] = risk
+		if risk >= 0.7 && motivationCtx.StressLevel < 4 {
+			motivationCtx.StressLevel = 4
+		}
+	}
+}
+
 func (s *MotivationService) getMotivationProfile(userID int64) *MotivationProfile {
+	profile := defaultMotivationProfile(userID)
+	ctx := context.Background()
+
+	learned, err := loadLearnedProfile(ctx, s.db, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось загрузить профиль личности пользователя %d: %v", userID, err)
+	} else if learned != nil {
+		preferMotivator(profile, learned.MotivationStyle, 0.8)
+		profile.SuccessPatterns["best_time"] = learned.BestTime()
+		profile.SuccessPatterns["preferred_difficulty"] = int(math.Round(learned.PreferredDifficulty * 5))
+		profile.SuccessPatterns["completion_rate"] = learned.CompletionRate
+		profile.SuccessPatterns["peak_hours"] = []int64(learned.PeakHours)
+		if learned.Consistency < 0.3 {
+			profile.PersonalChallenges = []string{"consistency", "procrastination"}
+		}
+		profile.LastMotivationUpdate = learned.ComputedAt
+	}
+
+	prefs, err := s.preferences.Get(ctx, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить предпочтения пользователя %d: %v", userID, err)
+		return profile
+	}
+
+	if prefs.MotivationType != nil {
+		preferMotivator(profile, *prefs.MotivationType, 0.9)
+	}
+
+	if prefs.CommunicationStyle != nil {
+		switch *prefs.CommunicationStyle {
+		case "friendly":
+			profile.PreferredTones = []string{ToneFriendly, ToneEncouraging}
+		case "professional", "concise":
+			profile.PreferredTones = []string{ToneProfessional, ToneCalm}
+			profile.AvoidedTones = append(profile.AvoidedTones, ToneEnergetic)
+		case "energetic":
+			profile.PreferredTones = []string{ToneEnergetic, ToneChallenging}
+		case "calm":
+			profile.PreferredTones = []string{ToneCalm, ToneSupportive}
+			profile.AvoidedTones = append(profile.AvoidedTones, ToneChallenging)
+		}
+	}
+
+	if prefs.DifficultyLevel != nil {
+		difficulty := map[string]int{"easy": 2, "medium": 3, "hard": 4}[*prefs.DifficultyLevel]
+		profile.SuccessPatterns["preferred_difficulty"] = difficulty
+	}
+
+	if prefs.ReminderFrequency != nil {
+		profile.MotivationSchedule = map[string]interface{}{"reminder_frequency": *prefs.ReminderFrequency}
+	}
+
+	feedback, err := s.preferences.GetFeatureFeedback(ctx, userID, 90)
+	if err != nil {
+		logrus.Warnf("Не удалось получить обратную связь пользователя %d: %v", userID, err)
+		return profile
+	}
+	for _, f := range feedback {
+		score, ok := profile.EffectiveStrategies[f.Feature]
+		if !ok {
+			if !isMotivationType(f.Feature) {
+				continue
+			}
+			score = 0.6
+		}
+		score += 0.05 * float64(f.Positive-f.Negative)
+		profile.EffectiveStrategies[f.Feature] = math.Max(0.1, math.Min(1, score))
+	}
+
+	return profile
+}
+
+func preferMotivator(profile *MotivationProfile, preferred string, score float64) {
+	motivators := []string{preferred}
+	for _, m := range profile.PrimaryMotivators {
+		if m != preferred {
+			motivators = append(motivators, m)
+		}
+	}
+	profile.PrimaryMotivators = motivators
+	profile.EffectiveStrategies[preferred] = math.Max(profile.EffectiveStrategies[preferred], score)
+}
+
+func isMotivationType(value string) bool {
+	for _, t := range preferences.AllowedValues[preferences.TypeMotivationType] {
+		if t == value {
+			return true
+		}
+	}
+	return false
+}
 
+func defaultMotivationProfile(userID int64) *MotivationProfile {
 	return &MotivationProfile{
 		UserID:			userID,
 		PrimaryMotivators:	[]string{MotivationTypeAchievement, MotivationTypeProgress},
@@ -701,27 +830,30 @@ func (s *MotivationService) createRewardSchedule(profile *MotivationProfile, goa
 func (s *MotivationService) setupMotivationTriggers(profile *MotivationProfile) []MotivationTrigger {
 	triggers := []MotivationTrigger{
 		{
-			Type:		"low_progress",
+			Type:		TriggerLowProgress,
 			Condition:	"progress < 0.3",
+			Parameters:	map[string]interface{}{"threshold": 0.3, "min_elapsed": 0.5},
 			Action:		"send_motivation",
 			Message:	"Не сдавайся! Каждый шаг важен! 💪",
-			Frequency:	"daily",
+			Frequency:	TriggerFrequencyDaily,
 			Enabled:	true,
 		},
 		{
-			Type:		"deadline_approaching",
+			Type:		TriggerDeadlineApproaching,
 			Condition:	"days_left <= 3",
+			Parameters:	map[string]interface{}{"days_left": 3},
 			Action:		"send_urgency_motivation",
 			Message:	"Время поджимает! Сосредоточься на главном! ⏰",
-			Frequency:	"daily",
+			Frequency:	TriggerFrequencyDaily,
 			Enabled:	true,
 		},
 		{
-			Type:		"streak_breaking",
+			Type:		TriggerStreakBreaking,
 			Condition:	"days_without_activity > 2",
+			Parameters:	map[string]interface{}{"inactive_days": 2, "active_within_days": 30},
 			Action:		"send_comeback_motivation",
 			Message:	"Время вернуться! Твоя серия ждет продолжения! 🔥",
-			Frequency:	"once",
+			Frequency:	TriggerFrequencyOnce,
 			Enabled:	true,
 		},
 	}
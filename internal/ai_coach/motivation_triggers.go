@@ -0,0 +1,276 @@
+package ai_coach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TriggerLowProgress		= "low_progress"
+	TriggerDeadlineApproaching	= "deadline_approaching"
+	TriggerStreakBreaking		= "streak_breaking"
+
+	TriggerFrequencyDaily	= "daily"
+	TriggerFrequencyWeekly	= "weekly"
+	TriggerFrequencyOnce	= "once"
+)
+
+var triggerPriority = map[string]int{
+	TriggerDeadlineApproaching:	1,
+	TriggerLowProgress:		2,
+	TriggerStreakBreaking:		3,
+}
+
+type objectiveStanding struct {
+	UserID		int64	`db:"user_id"`
+	ObjectiveID	string	`db:"id"`
+	Title		string	`db:"title"`
+	Progress	float64	`db:"progress"`
+	Elapsed		float64	`db:"elapsed"`
+	DaysLeft	int	`db:"days_left"`
+}
+
+type firedTrigger struct {
+	Trigger		MotivationTrigger
+	ObjectiveID	*string
+	Anchor		time.Time
+	Detail		string
+}
+
+func (s *MotivationService) StartMotivationTriggers(jm *jobs.Manager, send func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"motivation_triggers",
+		Spec:	"0 12 * * *",
+		Run: func(ctx context.Context) {
+			s.evaluateMotivationTriggers(ctx, send)
+		},
+	})
+
+	logrus.Info("Запущена проверка мотивационных триггеров")
+}
+
+func (s *MotivationService) evaluateMotivationTriggers(ctx context.Context, send func(chatID int64, text string) error) {
+	var standings []objectiveStanding
+	err := s.db.SelectContext(ctx, &standings, `
+		SELECT o.user_id, o.id, o.title,
+			COALESCE(AVG(LEAST(kr.progress / NULLIF(kr.target, 0), 1)), 0) AS progress,
+			COALESCE(EXTRACT(EPOCH FROM NOW() - o.created_at) / NULLIF(EXTRACT(EPOCH FROM o.deadline - o.created_at), 0), 0) AS elapsed,
+			CEIL(EXTRACT(EPOCH FROM o.deadline - NOW()) / 86400)::INT AS days_left
+		FROM objectives o
+		LEFT JOIN key_results kr ON kr.objective_id = o.id AND kr.deleted_at IS NULL
+		WHERE o.deleted_at IS NULL AND COALESCE(o.status, 'active') = 'active' AND o.deadline > NOW()
+		GROUP BY o.id
+		ORDER BY o.user_id, o.deadline
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении целей для мотивационных триггеров: %v", err)
+		return
+	}
+
+	byUser := make(map[int64][]objectiveStanding)
+	var userIDs []int64
+	for _, standing := range standings {
+		if _, ok := byUser[standing.UserID]; !ok {
+			userIDs = append(userIDs, standing.UserID)
+		}
+		byUser[standing.UserID] = append(byUser[standing.UserID], standing)
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		fired, err := s.fireTriggers(ctx, userID, byUser[userID])
+		if err != nil {
+			logrus.Warnf("Не удалось проверить мотивационные триггеры пользователя %d: %v", userID, err)
+			continue
+		}
+		if fired == nil {
+			continue
+		}
+
+		if err := send(userID, fired.Trigger.Message+"\n\n"+fired.Detail); err != nil {
+			logrus.Errorf("Ошибка при отправке мотивации пользователю %d: %v", userID, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `
+			INSERT INTO motivation_trigger_log (user_id, trigger_type, objective_id) VALUES ($1, $2, $3)
+		`, userID, fired.Trigger.Type, fired.ObjectiveID); err != nil {
+			logrus.Warnf("Не удалось записать срабатывание триггера %s пользователя %d: %v", fired.Trigger.Type, userID, err)
+		}
+		sent++
+	}
+	if sent > 0 {
+		logrus.Infof("Отправлены мотивационные сообщения по триггерам: %d", sent)
+	}
+}
+
+func (s *MotivationService) fireTriggers(ctx context.Context, userID int64, standings []objectiveStanding) (*firedTrigger, error) {
+	triggers := s.setupMotivationTriggers(s.getMotivationProfile(userID))
+	sort.SliceStable(triggers, func(i, j int) bool {
+		return triggerPriority[triggers[i].Type] < triggerPriority[triggers[j].Type]
+	})
+
+	now := time.Now()
+	for _, trigger := range triggers {
+		if !trigger.Enabled {
+			continue
+		}
+
+		var fired *firedTrigger
+		switch trigger.Type {
+		case TriggerLowProgress:
+			fired = lowProgressTrigger(trigger, standings, now)
+		case TriggerDeadlineApproaching:
+			fired = deadlineTrigger(trigger, standings, now)
+		case TriggerStreakBreaking:
+			lastActivity, err := s.lastProgressActivity(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			fired = streakTrigger(trigger, lastActivity, now)
+		}
+		if fired == nil {
+			continue
+		}
+
+		capped, err := s.triggerCapped(ctx, userID, fired)
+		if err != nil {
+			return nil, err
+		}
+		if !capped {
+			return fired, nil
+		}
+	}
+	return nil, nil
+}
+
+func lowProgressTrigger(trigger MotivationTrigger, standings []objectiveStanding, now time.Time) *firedTrigger {
+	threshold := triggerParam(trigger, "threshold", 0.3)
+	minElapsed := triggerParam(trigger, "min_elapsed", 0.5)
+
+	var worst *objectiveStanding
+	for i := range standings {
+		standing := &standings[i]
+		if standing.Elapsed < minElapsed || standing.Progress >= threshold {
+			continue
+		}
+		if worst == nil || standing.Elapsed-standing.Progress > worst.Elapsed-worst.Progress {
+			worst = standing
+		}
+	}
+	if worst == nil {
+		return nil
+	}
+	return &firedTrigger{
+		Trigger:	trigger,
+		ObjectiveID:	&worst.ObjectiveID,
+		Anchor:		now,
+		Detail:		fmt.Sprintf("🎯 «%s»: выполнено %.0f%%, а прошло уже %.0f%% срока", worst.Title, worst.Progress*100, math.Min(worst.Elapsed, 1)*100),
+	}
+}
+
+func deadlineTrigger(trigger MotivationTrigger, standings []objectiveStanding, now time.Time) *firedTrigger {
+	daysLeft := int(triggerParam(trigger, "days_left", 3))
+	for i := range standings {
+		standing := &standings[i]
+		if standing.DaysLeft > daysLeft || standing.Progress >= 1 {
+			continue
+		}
+		return &firedTrigger{
+			Trigger:	trigger,
+			ObjectiveID:	&standing.ObjectiveID,
+			Anchor:		now,
+			Detail:		fmt.Sprintf("🎯 «%s»: до дедлайна %d дн., выполнено %.0f%%", standing.Title, standing.DaysLeft, standing.Progress*100),
+		}
+	}
+	return nil
+}
+
+func streakTrigger(trigger MotivationTrigger, lastActivity *time.Time, now time.Time) *firedTrigger {
+	if lastActivity == nil {
+		return nil
+	}
+	inactiveDays := triggerParam(trigger, "inactive_days", 2)
+	activeWithinDays := triggerParam(trigger, "active_within_days", 30)
+
+	idle := now.Sub(*lastActivity).Hours() / 24
+	if idle <= inactiveDays || idle > activeWithinDays {
+		return nil
+	}
+	return &firedTrigger{
+		Trigger:	trigger,
+		Anchor:		*lastActivity,
+		Detail:		fmt.Sprintf("📅 Последний прогресс был %d дн. назад — отметьте хотя бы маленький шаг сегодня", int(idle)),
+	}
+}
+
+func (s *MotivationService) lastProgressActivity(ctx context.Context, userID int64) (*time.Time, error) {
+	var last sql.NullTime
+	err := s.db.GetContext(ctx, &last, `
+		SELECT MAX(at) FROM (
+			SELECT l.created_at AS at
+			FROM key_result_progress_log l
+			JOIN key_results kr ON kr.id = l.key_result_id
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE o.user_id = $1 AND l.progress_before IS NOT NULL AND l.progress_after > l.progress_before
+			UNION ALL
+			SELECT t.completion_date AS at
+			FROM tasks t
+			JOIN key_results kr ON kr.id = t.key_result_id
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE o.user_id = $1 AND t.completion_date IS NOT NULL
+		) activity
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении последней активности: %v", err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	return &last.Time, nil
+}
+
+func (s *MotivationService) triggerCapped(ctx context.Context, userID int64, fired *firedTrigger) (bool, error) {
+	since := fired.Anchor
+	switch fired.Trigger.Frequency {
+	case TriggerFrequencyDaily:
+		since = time.Now().Add(-20 * time.Hour)
+	case TriggerFrequencyWeekly:
+		since = time.Now().AddDate(0, 0, -6)
+	}
+
+	var sentAt time.Time
+	err := s.db.GetContext(ctx, &sentAt, `
+		SELECT sent_at FROM motivation_trigger_log
+		WHERE user_id = $1 AND trigger_type = $2 AND sent_at > $3
+		ORDER BY sent_at DESC
+		LIMIT 1
+	`, userID, fired.Trigger.Type, since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке частоты триггера %s: %v", fired.Trigger.Type, err)
+	}
+	return true, nil
+}
+
+func triggerParam(trigger MotivationTrigger, key string, fallback float64) float64 {
+	switch value := trigger.Parameters[key].(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	}
+	return fallback
+}
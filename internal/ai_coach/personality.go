@@ -95,6 +95,22 @@ func (s *PersonalityService) GetUserPersonality(ctx context.Context, userID int6
 		profile = s.refineProfileWithBehavior(profile, behaviorAnalysis)
 	}
 
+	learned, err := loadLearnedProfile(ctx, s.db, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось загрузить профиль личности пользователя %d: %v", userID, err)
+	} else if learned != nil {
+		profile.PersonalityType = learned.PersonalityType
+		profile.MotivationStyle = learned.MotivationStyle
+		profile.ActivityLevel = learned.ActivityLevel
+		profile.PersonalityTraits = learned.Traits
+		if profile.WorkingHours == nil {
+			profile.WorkingHours = make(map[string]interface{})
+		}
+		profile.WorkingHours["peak_hours"] = []int64(learned.PeakHours)
+		profile.WorkingHours["chronotype"] = learned.Chronotype
+		profile.LastUpdated = learned.ComputedAt
+	}
+
 	return profile, nil
 }
 
@@ -362,7 +378,7 @@ func (s *PersonalityService) getCompletionPatterns(ctx context.Context, userID i
 			AVG(CASE WHEN progress > 0 THEN 1 ELSE 0 END) as activity_rate
 		FROM key_results kr
 		JOIN objectives o ON kr.objective_id = o.id
-		WHERE o.user_id = $1 AND o.created_at > NOW() - INTERVAL '30 days'
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND o.created_at > NOW() - INTERVAL '30 days'
 	`
 
 	var completionRate, activityRate float64
@@ -388,7 +404,7 @@ func (s *PersonalityService) getPreferredDifficulty(ctx context.Context, userID
 	query := `
 		SELECT AVG(difficulty_level::float / 5.0) as avg_difficulty
 		FROM objectives 
-		WHERE user_id = $1 AND created_at > NOW() - INTERVAL '30 days'
+		WHERE user_id = $1 AND deleted_at IS NULL AND created_at > NOW() - INTERVAL '30 days'
 	`
 
 	var avgDifficulty float64
@@ -402,7 +418,7 @@ func (s *PersonalityService) getOptimalTaskDuration(ctx context.Context, userID
 		FROM tasks t
 		JOIN key_results kr ON t.key_result_id = kr.id
 		JOIN objectives o ON kr.objective_id = o.id
-		WHERE o.user_id = $1 AND t.actual_hours > 0 AND t.created_at > NOW() - INTERVAL '30 days'
+		WHERE o.user_id = $1 AND t.deleted_at IS NULL AND t.actual_hours > 0 AND t.created_at > NOW() - INTERVAL '30 days'
 	`
 
 	var avgDuration float64
@@ -457,7 +473,7 @@ func (s *PersonalityService) getCategoryPreferences(ctx context.Context, userID
 			COUNT(o.id) as count
 		FROM objectives o
 		JOIN objective_categories oc ON o.category_id = oc.id
-		WHERE o.user_id = $1 AND o.created_at > NOW() - INTERVAL '90 days'
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.created_at > NOW() - INTERVAL '90 days'
 		GROUP BY oc.name
 	`
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"telegrambot/internal/wellbeing"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,7 +14,9 @@ import (
 )
 
 type PredictionService struct {
-	db *sqlx.DB
+	db		*sqlx.DB
+	wellbeing	*wellbeing.Service
+	advisor		CompletionAdvisor
 }
 
 type GoalPrediction struct {
@@ -47,13 +50,14 @@ type PredictionFactors struct {
 }
 
 type CompletionPrediction struct {
-	Probability		float64		`json:"probability"`
-	EstimatedCompletionDate	time.Time	`json:"estimated_completion_date"`
-	ConfidenceLevel		float64		`json:"confidence_level"`
-	RiskFactors		[]string	`json:"risk_factors"`
-	SuccessFactors		[]string	`json:"success_factors"`
-	Recommendations		[]string	`json:"recommendations"`
-	AlternativeScenarios	[]Scenario	`json:"alternative_scenarios"`
+	Probability		float64			`json:"probability"`
+	EstimatedCompletionDate	time.Time		`json:"estimated_completion_date"`
+	ConfidenceLevel		float64			`json:"confidence_level"`
+	RiskFactors		[]string		`json:"risk_factors"`
+	SuccessFactors		[]string		`json:"success_factors"`
+	Recommendations		[]string		`json:"recommendations"`
+	AlternativeScenarios	[]Scenario		`json:"alternative_scenarios"`
+	Sources			[]PredictionSource	`json:"sources"`
 }
 
 type ProgressPrediction struct {
@@ -73,6 +77,8 @@ type EffortPrediction struct {
 	EnergyRequirements	float64		`json:"energy_requirements"`
 	SkillGapAnalysis	[]string	`json:"skill_gap_analysis"`
 	ResourceRequirements	[]string	`json:"resource_requirements"`
+	LoggedHours		float64		`json:"logged_hours"`
+	BasedOnTrackedTime	bool		`json:"based_on_tracked_time"`
 }
 
 type Scenario struct {
@@ -121,7 +127,7 @@ const (
 )
 
 func NewPredictionService(db *sqlx.DB) *PredictionService {
-	return &PredictionService{db: db}
+	return &PredictionService{db: db, wellbeing: wellbeing.NewService(db)}
 }
 
 func (s *PredictionService) PredictGoalOutcomes(ctx context.Context, userID int64, goals []interface{}) ([]PredictionResult, error) {
@@ -180,9 +186,23 @@ func (s *PredictionService) PredictCompletionProbability(ctx context.Context, us
 		AlternativeScenarios:		scenarios,
 	}
 
+	s.mergeLLMPrediction(ctx, userID, objectiveID, goalData, factors, prediction)
+
+	if err := s.saveObjectivePrediction(ctx, userID, objectiveID, prediction, factors); err != nil {
+		logrus.Warnf("Не удалось сохранить предсказание по цели %s: %v", objectiveID, err)
+	}
+
 	return prediction, nil
 }
 
+func (s *PredictionService) CompletionProbability(ctx context.Context, userID int64, objectiveID string) (float64, error) {
+	prediction, err := s.PredictCompletionProbability(ctx, userID, objectiveID)
+	if err != nil {
+		return 0, err
+	}
+	return prediction.Probability, nil
+}
+
 func (s *PredictionService) PredictProgressTrajectory(ctx context.Context, userID int64, objectiveID string) (*ProgressPrediction, error) {
 
 	progressHistory, err := s.getProgressHistory(ctx, userID, objectiveID)
@@ -196,9 +216,9 @@ func (s *PredictionService) PredictProgressTrajectory(ctx context.Context, userI
 
 	expectedProgress := s.predictExpectedProgress(velocity, progressHistory)
 
-	milestones := s.predictMilestones(ctx, objectiveID, velocity)
+	milestones := s.predictMilestones(progressHistory, velocity)
 
-	bottlenecks := s.predictBottlenecks(ctx, userID, objectiveID, progressHistory)
+	bottlenecks := s.predictBottlenecks(progressHistory, velocity)
 
 	optimizations := s.generateOptimizationSuggestions(progressHistory, velocity)
 
@@ -233,6 +253,16 @@ func (s *PredictionService) PredictRequiredEffort(ctx context.Context, userID in
 
 	requiredDays := s.calculateRequiredDays(requiredHours, userProductivity)
 
+	tracked, err := s.getTrackedEffort(ctx, objectiveID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить учтенное время по цели %s: %v", objectiveID, err)
+		tracked = &trackedEffort{}
+	}
+	if hours, ok := tracked.remainingHours(); ok {
+		requiredHours = hours
+		requiredDays = int(math.Ceil(requiredHours / tracked.hoursPerActiveDay()))
+	}
+
 	intensityLevel := s.calculateIntensityLevel(requiredHours, goalData)
 
 	schedule := s.generateOptimalSchedule(ctx, userID, requiredHours)
@@ -251,6 +281,8 @@ func (s *PredictionService) PredictRequiredEffort(ctx context.Context, userID in
 		EnergyRequirements:	energyRequirements,
 		SkillGapAnalysis:	skillGaps,
 		ResourceRequirements:	resourceRequirements,
+		LoggedHours:		tracked.Hours,
+		BasedOnTrackedTime:	tracked.usable(),
 	}
 
 	return prediction, nil
@@ -271,7 +303,7 @@ func (s *PredictionService) GenerateProductivityPredictions(ctx context.Context,
 
 	monthlyTrend := s.analyzeMonthlyTrend(productivityHistory)
 
-	optimalHours := s.findOptimalWorkingHours(patterns)
+	optimalHours := s.findOptimalWorkingHours(ctx, userID, patterns)
 
 	burnoutRisk := s.assessBurnoutRisk(ctx, userID, productivityHistory)
 
@@ -388,9 +420,9 @@ func (s *PredictionService) analyzePredictionFactors(ctx context.Context, userID
 
 	factors.MotivationLevel = s.calculateMotivationLevel(ctx, userID)
 
-	factors.ExternalFactors = s.calculateExternalFactors()
+	factors.ExternalFactors = s.calculateExternalFactors(ctx, userID)
 
-	factors.SeasonalFactors = s.calculateSeasonalFactors()
+	factors.SeasonalFactors = s.calculateSeasonalFactors(ctx, userID)
 
 	factors.PersonalityAlignment = s.calculatePersonalityAlignment(ctx, userID, goalData)
 
@@ -600,38 +632,12 @@ func (s *PredictionService) calculateConfidenceLevel(factors *PredictionFactors)
 	return confidence
 }
 
-func (s *PredictionService) calculateUserBehaviorScore(ctx context.Context, userID int64) float64 {
-
-	query := `
-		SELECT 
-			COUNT(CASE WHEN completed = true THEN 1 END)::float / COUNT(*)::float as completion_rate,
-			AVG(CASE WHEN time_spent_minutes > 0 THEN 1 ELSE 0 END) as engagement_rate
-		FROM habit_tracking 
-		WHERE user_id = $1 AND date > CURRENT_DATE - INTERVAL '30 days'
-	`
-
-	var completionRate, engagementRate float64
-	err := s.db.GetContext(ctx, &struct {
-		CompletionRate	float64	`db:"completion_rate"`
-		EngagementRate	float64	`db:"engagement_rate"`
-	}{
-		CompletionRate:	completionRate,
-		EngagementRate:	engagementRate,
-	}, query, userID)
-
-	if err != nil {
-		return 0.5
-	}
-
-	return (completionRate + engagementRate) / 2.0
-}
-
 func (s *PredictionService) calculateHistoricalPerformance(ctx context.Context, userID int64) float64 {
 	query := `
 		SELECT 
 			COUNT(CASE WHEN completion_date IS NOT NULL THEN 1 END)::float / COUNT(*)::float as success_rate
 		FROM objectives 
-		WHERE user_id = $1 AND created_at > NOW() - INTERVAL '90 days'
+		WHERE user_id = $1 AND deleted_at IS NULL AND created_at > NOW() - INTERVAL '90 days'
 	`
 
 	var successRate float64
@@ -735,118 +741,64 @@ func (s *PredictionService) getGoalData(ctx context.Context, userID int64, objec
 	data := make(map[string]interface{})
 
 	query := `
-		SELECT title, difficulty_level, estimated_hours, deadline, created_at
+		SELECT title, COALESCE(difficulty_level, 3) AS difficulty_level, COALESCE(estimated_hours, 0) AS estimated_hours, deadline, created_at
 		FROM objectives 
-		WHERE id = $1 AND user_id = $2
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 
-	var title string
-	var difficultyLevel int
-	var estimatedHours float64
-	var deadline *time.Time
-	var createdAt time.Time
-
-	err := s.db.GetContext(ctx, &struct {
+	var row struct {
 		Title		string		`db:"title"`
 		DifficultyLevel	int		`db:"difficulty_level"`
 		EstimatedHours	float64		`db:"estimated_hours"`
 		Deadline	*time.Time	`db:"deadline"`
 		CreatedAt	time.Time	`db:"created_at"`
-	}{
-		Title:			title,
-		DifficultyLevel:	difficultyLevel,
-		EstimatedHours:		estimatedHours,
-		Deadline:		deadline,
-		CreatedAt:		createdAt,
-	}, query, objectiveID, userID)
-
-	if err != nil {
+	}
+	if err := s.db.GetContext(ctx, &row, query, objectiveID, userID); err != nil {
 		return nil, err
 	}
 
-	data["title"] = title
-	data["difficulty_level"] = difficultyLevel
-	data["estimated_hours"] = estimatedHours
-	if deadline != nil {
-		data["deadline"] = *deadline
+	data["title"] = row.Title
+	data["difficulty_level"] = row.DifficultyLevel
+	data["estimated_hours"] = row.EstimatedHours
+	if row.Deadline != nil {
+		data["deadline"] = *row.Deadline
 	}
-	data["created_at"] = createdAt
+	data["created_at"] = row.CreatedAt
 
 	return data, nil
 }
 
-func (s *PredictionService) getCompletionStatistics(ctx context.Context, userID int64) (map[string]interface{}, error) {
-	return map[string]interface{}{"rate": 0.7}, nil
-}
-
-func (s *PredictionService) getAverageCompletionTime(ctx context.Context, userID int64) (float64, error) {
-	return 30.0, nil
-}
-
-func (s *PredictionService) getProductivityPatterns(ctx context.Context, userID int64) (map[string]interface{}, error) {
-	return map[string]interface{}{"pattern": "stable"}, nil
-}
-
-func (s *PredictionService) calculateResourceAvailability(ctx context.Context, userID int64) float64 {
-	return 0.7
-}
-
-func (s *PredictionService) calculateMotivationLevel(ctx context.Context, userID int64) float64 {
-	return 0.7
-}
-
-func (s *PredictionService) calculateExternalFactors() float64 {
-	return 0.5
-}
-
-func (s *PredictionService) calculateSeasonalFactors() float64 {
-	return 0.5
+func (s *PredictionService) learnedProfile(ctx context.Context, userID int64) *LearnedProfile {
+	profile, err := loadLearnedProfile(ctx, s.db, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось загрузить профиль личности пользователя %d: %v", userID, err)
+		return nil
+	}
+	return profile
 }
 
 func (s *PredictionService) calculatePersonalityAlignment(ctx context.Context, userID int64, goalData map[string]interface{}) float64 {
-	return 0.7
+	profile := s.learnedProfile(ctx, userID)
+	difficultyLevel, ok := goalData["difficulty_level"].(int)
+	if profile == nil || !ok || difficultyLevel <= 0 {
+		return 0.7
+	}
+	gap := math.Abs(float64(difficultyLevel)/5.0 - profile.PreferredDifficulty)
+	return math.Max(0.2, 1-gap*1.5)
 }
 
 func (s *PredictionService) calculateSupportSystem(ctx context.Context, userID int64) float64 {
-	return 0.6
-}
-
-func (s *PredictionService) getProgressHistory(ctx context.Context, userID int64, objectiveID string) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
-}
-
-func (s *PredictionService) analyzeTrend(history []map[string]interface{}) string {
-	return "stable"
-}
-
-func (s *PredictionService) calculateProgressVelocity(history []map[string]interface{}) float64 {
-	return 0.1
-}
-
-func (s *PredictionService) predictExpectedProgress(velocity float64, history []map[string]interface{}) float64 {
-	return velocity * 7
-}
-
-func (s *PredictionService) predictMilestones(ctx context.Context, objectiveID string, velocity float64) []MilestonePrediction {
-	return []MilestonePrediction{}
-}
-
-func (s *PredictionService) predictBottlenecks(ctx context.Context, userID int64, objectiveID string, history []map[string]interface{}) []BottleneckPrediction {
-	return []BottleneckPrediction{}
-}
-
-func (s *PredictionService) generateOptimizationSuggestions(history []map[string]interface{}, velocity float64) []string {
-	return []string{"Поддерживай текущий темп", "Следи за прогрессом"}
+	profile := s.learnedProfile(ctx, userID)
+	if profile == nil {
+		return 0.6
+	}
+	return 0.4 + 0.6*profile.SocialScore
 }
 
 func (s *PredictionService) analyzeGoalComplexity(goalData map[string]interface{}) float64 {
 	return s.calculateGoalComplexity(goalData)
 }
 
-func (s *PredictionService) getUserProductivityMetrics(ctx context.Context, userID int64) (float64, error) {
-	return 0.7, nil
-}
-
 func (s *PredictionService) calculateRequiredHours(complexity, productivity float64, goalData map[string]interface{}) float64 {
 	baseHours := 20.0
 	if estimatedHours, ok := goalData["estimated_hours"].(float64); ok && estimatedHours > 0 {
@@ -894,37 +846,14 @@ func (s *PredictionService) analyzeResourceRequirements(goalData map[string]inte
 	return []string{"Время", "Мотивация", "Фокус"}
 }
 
-func (s *PredictionService) getProductivityHistory(ctx context.Context, userID int64) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
-}
-
-func (s *PredictionService) analyzeProductivityPatterns(history []map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{"pattern": "stable"}
-}
-
-func (s *PredictionService) predictTomorrowProductivity(patterns map[string]interface{}, history []map[string]interface{}) float64 {
-	return 0.7
-}
-
-func (s *PredictionService) predictWeeklyAverage(patterns map[string]interface{}, history []map[string]interface{}) float64 {
-	return 0.65
-}
-
-func (s *PredictionService) analyzeMonthlyTrend(history []map[string]interface{}) string {
-	return "stable"
-}
-
-func (s *PredictionService) findOptimalWorkingHours(patterns map[string]interface{}) []int {
-	return []int{9, 10, 11, 15, 16}
-}
-
-func (s *PredictionService) assessBurnoutRisk(ctx context.Context, userID int64, history []map[string]interface{}) float64 {
-	return 0.2
-}
-
-func (s *PredictionService) analyzeRecoveryNeeds(burnoutRisk float64, history []map[string]interface{}) []string {
-	if burnoutRisk > 0.7 {
-		return []string{"Больше отдыха", "Снижение нагрузки", "Смена активности"}
+func (s *PredictionService) findOptimalWorkingHours(ctx context.Context, userID int64, patterns map[string]interface{}) []int {
+	profile := s.learnedProfile(ctx, userID)
+	if profile == nil || len(profile.PeakHours) == 0 {
+		return []int{9, 10, 11, 15, 16}
+	}
+	hours := make([]int, 0, len(profile.PeakHours))
+	for _, h := range profile.PeakHours {
+		hours = append(hours, int(h))
 	}
-	return []string{"Поддержание текущего режима"}
+	return hours
 }
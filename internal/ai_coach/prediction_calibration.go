@@ -0,0 +1,194 @@
+package ai_coach
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const calibrationBins = 10
+
+type CalibrationBin struct {
+	From		float64	`json:"from"`
+	To		float64	`json:"to"`
+	Count		int	`json:"count"`
+	MeanPredicted	float64	`json:"mean_predicted"`
+	ObservedRate	float64	`json:"observed_rate"`
+}
+
+type CalibrationMetrics struct {
+	PredictionType		string			`json:"prediction_type"`
+	Resolved		int			`json:"resolved"`
+	Pending			int			`json:"pending"`
+	MeanPredicted		*float64		`json:"mean_predicted,omitempty"`
+	ObservedRate		*float64		`json:"observed_rate,omitempty"`
+	BrierScore		*float64		`json:"brier_score,omitempty"`
+	CalibrationError	*float64		`json:"expected_calibration_error,omitempty"`
+	DateMAEDays		*float64		`json:"date_mae_days,omitempty"`
+	DateBiasDays		*float64		`json:"date_bias_days,omitempty"`
+	Bins			[]CalibrationBin	`json:"bins,omitempty"`
+}
+
+type resolvedPrediction struct {
+	PredictionType	string		`db:"prediction_type"`
+	PredictedValue	*float64	`db:"predicted_value"`
+	PredictedDate	*time.Time	`db:"predicted_date"`
+	ActualOutcome	*float64	`db:"actual_outcome"`
+	ActualDate	*time.Time	`db:"actual_date"`
+}
+
+func (s *PredictionService) saveObjectivePrediction(ctx context.Context, userID int64, objectiveID string, prediction *CompletionPrediction, factors *PredictionFactors) error {
+	factorsJSON, err := json.Marshal(factors)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации факторов предсказания: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO goal_predictions (user_id, objective_id, prediction_type, predicted_value, predicted_date, confidence_score, factors)
+		SELECT $1, $2, t.prediction_type, t.predicted_value, t.predicted_date, $6, $7
+		FROM (VALUES ($3::varchar, $4::float8, NULL::date), ($8::varchar, NULL::float8, $5::date)) AS t(prediction_type, predicted_value, predicted_date)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM goal_predictions p
+			WHERE p.objective_id = $2 AND p.prediction_type = t.prediction_type AND p.created_at >= CURRENT_DATE
+		)
+	`, userID, objectiveID, PredictionTypeCompletion, prediction.Probability, prediction.EstimatedCompletionDate,
+		prediction.ConfidenceLevel, string(factorsJSON), PredictionTypeDate)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении предсказания по цели: %v", err)
+	}
+	return nil
+}
+
+func (s *PredictionService) StartPredictionCalibration(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:	"prediction_outcomes",
+		Spec:	"30 3 * * *",
+		Run: func(ctx context.Context) {
+			resolved, err := s.BackfillOutcomes(ctx)
+			if err != nil {
+				logrus.Errorf("Ошибка при заполнении фактических исходов предсказаний: %v", err)
+				return
+			}
+			if resolved > 0 {
+				logrus.Infof("Заполнены фактические исходы предсказаний: %d", resolved)
+			}
+		},
+	})
+
+	logrus.Info("Запущено заполнение фактических исходов предсказаний")
+}
+
+func (s *PredictionService) BackfillOutcomes(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE goal_predictions p
+		SET actual_outcome = CASE
+				WHEN o.completion_date IS NOT NULL AND (o.deadline IS NULL OR o.completion_date <= o.deadline) THEN 1
+				ELSE 0
+			END,
+			actual_date = COALESCE(o.completion_date, o.archived_at, o.deadline)::date
+		FROM objectives o
+		WHERE p.objective_id = o.id
+			AND p.actual_outcome IS NULL
+			AND p.prediction_type IN ($1, $2)
+			AND (o.completion_date IS NOT NULL OR o.archived_at IS NOT NULL OR o.deadline < NOW())
+	`, PredictionTypeCompletion, PredictionTypeDate)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при заполнении исходов предсказаний: %v", err)
+	}
+	return res.RowsAffected()
+}
+
+func (s *PredictionService) CalibrationMetrics(ctx context.Context) ([]CalibrationMetrics, error) {
+	var rows []resolvedPrediction
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT prediction_type, predicted_value, predicted_date, actual_outcome, actual_date
+		FROM goal_predictions
+		WHERE objective_id IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении предсказаний для калибровки: %v", err)
+	}
+	return calibrate(rows), nil
+}
+
+func calibrate(rows []resolvedPrediction) []CalibrationMetrics {
+	byType := make(map[string][]resolvedPrediction)
+	for _, row := range rows {
+		byType[row.PredictionType] = append(byType[row.PredictionType], row)
+	}
+
+	metrics := make([]CalibrationMetrics, 0, len(byType))
+	for predictionType, typed := range byType {
+		metrics = append(metrics, calibrateType(predictionType, typed))
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].PredictionType < metrics[j].PredictionType })
+	return metrics
+}
+
+func calibrateType(predictionType string, rows []resolvedPrediction) CalibrationMetrics {
+	m := CalibrationMetrics{PredictionType: predictionType}
+	bins := make([]CalibrationBin, calibrationBins)
+	for i := range bins {
+		bins[i].From = float64(i) / calibrationBins
+		bins[i].To = float64(i+1) / calibrationBins
+	}
+
+	var probCount, dateCount int
+	var sumPredicted, sumObserved, sumSquared, sumAbsDays, sumDays float64
+	for _, row := range rows {
+		if row.ActualOutcome == nil {
+			m.Pending++
+			continue
+		}
+		m.Resolved++
+
+		if row.PredictedValue != nil {
+			p := math.Max(0, math.Min(1, *row.PredictedValue))
+			probCount++
+			sumPredicted += p
+			sumObserved += *row.ActualOutcome
+			sumSquared += (p - *row.ActualOutcome) * (p - *row.ActualOutcome)
+
+			bin := &bins[int(math.Min(p*calibrationBins, calibrationBins-1))]
+			bin.Count++
+			bin.MeanPredicted += p
+			bin.ObservedRate += *row.ActualOutcome
+		}
+
+		if row.PredictedDate != nil && row.ActualDate != nil && *row.ActualOutcome > 0 {
+			days := row.PredictedDate.Sub(*row.ActualDate).Hours() / 24
+			dateCount++
+			sumDays += days
+			sumAbsDays += math.Abs(days)
+		}
+	}
+
+	if probCount > 0 {
+		meanPredicted := sumPredicted / float64(probCount)
+		observedRate := sumObserved / float64(probCount)
+		brier := sumSquared / float64(probCount)
+		ece := 0.0
+		for i := range bins {
+			if bins[i].Count == 0 {
+				continue
+			}
+			bins[i].MeanPredicted /= float64(bins[i].Count)
+			bins[i].ObservedRate /= float64(bins[i].Count)
+			ece += float64(bins[i].Count) / float64(probCount) * math.Abs(bins[i].MeanPredicted-bins[i].ObservedRate)
+			m.Bins = append(m.Bins, bins[i])
+		}
+		m.MeanPredicted, m.ObservedRate, m.BrierScore, m.CalibrationError = &meanPredicted, &observedRate, &brier, &ece
+	}
+	if dateCount > 0 {
+		mae := sumAbsDays / float64(dateCount)
+		bias := sumDays / float64(dateCount)
+		m.DateMAEDays, m.DateBiasDays = &mae, &bias
+	}
+	return m
+}
@@ -0,0 +1,439 @@
+package ai_coach
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	productivityHistoryDays	= 28
+	productivityTrendDays	= 14
+	productivitySmoothing	= 0.2
+	productivityTrendDelta	= 0.1
+	seasonalMinMonths	= 6
+	messageActivityWeight	= 0.25
+)
+
+type productivityDay struct {
+	Day		time.Time	`db:"day"`
+	ProgressEvents	int		`db:"progress_events"`
+	CompletedTasks	int		`db:"completed_tasks"`
+	Habits		int		`db:"habits"`
+	Messages	int		`db:"messages"`
+	Score		float64		`db:"-"`
+}
+
+func (d productivityDay) units() float64 {
+	return float64(d.ProgressEvents+d.CompletedTasks+d.Habits) + messageActivityWeight*float64(d.Messages)
+}
+
+type habitStats struct {
+	Total		int	`db:"total"`
+	Completed	int	`db:"completed"`
+	Engaged		int	`db:"engaged"`
+}
+
+type objectiveOutcomes struct {
+	Completed	int		`db:"completed"`
+	Missed		int		`db:"missed"`
+	AvgDays		sql.NullFloat64	`db:"avg_days"`
+}
+
+type workload struct {
+	Objectives	int	`db:"objectives"`
+	OpenTasks	int	`db:"open_tasks"`
+	OverdueTasks	int	`db:"overdue_tasks"`
+}
+
+func (s *PredictionService) calculateUserBehaviorScore(ctx context.Context, userID int64) float64 {
+	var stats habitStats
+	err := s.db.GetContext(ctx, &stats, `
+		SELECT COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE completed) AS completed,
+			COUNT(*) FILTER (WHERE time_spent_minutes > 0) AS engaged
+		FROM habit_tracking
+		WHERE user_id = $1 AND date > CURRENT_DATE - INTERVAL '30 days'
+	`, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить статистику привычек пользователя %d: %v", userID, err)
+		return 0.5
+	}
+	if stats.Total > 0 {
+		return habitBehaviorScore(stats)
+	}
+
+	history, err := s.getProductivityHistory(ctx, userID)
+	if err != nil || len(history) == 0 {
+		return 0.5
+	}
+	return activeDaysRatio(history)
+}
+
+func habitBehaviorScore(stats habitStats) float64 {
+	if stats.Total == 0 {
+		return 0.5
+	}
+	completionRate := float64(stats.Completed) / float64(stats.Total)
+	engagementRate := float64(stats.Engaged) / float64(stats.Total)
+	return (completionRate + engagementRate) / 2.0
+}
+
+func (s *PredictionService) getObjectiveOutcomes(ctx context.Context, userID int64) (*objectiveOutcomes, error) {
+	var outcomes objectiveOutcomes
+	err := s.db.GetContext(ctx, &outcomes, `
+		SELECT COUNT(*) FILTER (WHERE completion_date IS NOT NULL) AS completed,
+			COUNT(*) FILTER (WHERE completion_date IS NULL AND deadline < NOW()) AS missed,
+			AVG(EXTRACT(EPOCH FROM completion_date - created_at) / 86400) FILTER (WHERE completion_date IS NOT NULL) AS avg_days
+		FROM objectives
+		WHERE user_id = $1 AND deleted_at IS NULL AND created_at > NOW() - INTERVAL '180 days'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении итогов целей: %v", err)
+	}
+	return &outcomes, nil
+}
+
+func (s *PredictionService) getCompletionStatistics(ctx context.Context, userID int64) (map[string]interface{}, error) {
+	outcomes, err := s.getObjectiveOutcomes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats := map[string]interface{}{
+		"completed":	outcomes.Completed,
+		"missed":	outcomes.Missed,
+	}
+	if finished := outcomes.Completed + outcomes.Missed; finished > 0 {
+		stats["rate"] = float64(outcomes.Completed) / float64(finished)
+	}
+	return stats, nil
+}
+
+func (s *PredictionService) getAverageCompletionTime(ctx context.Context, userID int64) (float64, error) {
+	outcomes, err := s.getObjectiveOutcomes(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !outcomes.AvgDays.Valid {
+		return 30.0, nil
+	}
+	return math.Max(1, outcomes.AvgDays.Float64), nil
+}
+
+func (s *PredictionService) getProductivityPatterns(ctx context.Context, userID int64) (map[string]interface{}, error) {
+	history, err := s.getProductivityHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.analyzeProductivityPatterns(history), nil
+}
+
+func (s *PredictionService) calculateResourceAvailability(ctx context.Context, userID int64) float64 {
+	var load workload
+	err := s.db.GetContext(ctx, &load, `
+		SELECT COUNT(DISTINCT o.id) AS objectives,
+			COUNT(t.id) FILTER (WHERE t.completion_date IS NULL) AS open_tasks,
+			COUNT(t.id) FILTER (WHERE t.completion_date IS NULL AND t.deadline < NOW()) AS overdue_tasks
+		FROM objectives o
+		LEFT JOIN key_results kr ON kr.objective_id = o.id AND kr.deleted_at IS NULL
+		LEFT JOIN tasks t ON t.key_result_id = kr.id AND t.deleted_at IS NULL
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.completion_date IS NULL AND COALESCE(o.status, 'active') = 'active'
+	`, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось оценить нагрузку пользователя %d: %v", userID, err)
+		return 0.7
+	}
+
+	availability := workloadAvailability(load)
+	if risk, ok, err := s.wellbeing.BurnoutRisk(ctx, userID); err == nil && ok {
+		availability *= 1 - 0.5*risk
+	}
+	return math.Max(0.1, availability)
+}
+
+func workloadAvailability(load workload) float64 {
+	objectivesLoad := math.Min(math.Max(float64(load.Objectives-1), 0)/5, 1)
+	tasksLoad := math.Min(float64(load.OpenTasks)/30, 1)
+	overdueLoad := 0.0
+	if load.OpenTasks > 0 {
+		overdueLoad = float64(load.OverdueTasks) / float64(load.OpenTasks)
+	}
+	return 1 - 0.4*objectivesLoad - 0.3*tasksLoad - 0.2*overdueLoad
+}
+
+func (s *PredictionService) calculateMotivationLevel(ctx context.Context, userID int64) float64 {
+	if profile := s.learnedProfile(ctx, userID); profile != nil {
+		return math.Min(0.3+0.4*profile.EngagementTrend+0.3*profile.Consistency, 1)
+	}
+
+	history, err := s.getProductivityHistory(ctx, userID)
+	if err != nil || len(history) == 0 {
+		return 0.7
+	}
+	motivation := 0.3 + 0.5*activeDaysRatio(history)
+	switch productivityTrend(history) {
+	case "improving":
+		motivation += 0.2
+	case "stable":
+		motivation += 0.1
+	}
+	return math.Min(motivation, 1)
+}
+
+func (s *PredictionService) calculateExternalFactors(ctx context.Context, userID int64) float64 {
+	summary, err := s.wellbeing.GetSummary(ctx, userID, 14)
+	if err != nil {
+		logrus.Warnf("Не удалось получить самочувствие пользователя %d: %v", userID, err)
+		return 0.5
+	}
+	if summary.CheckIns == 0 {
+		return 0.5
+	}
+
+	var total, count float64
+	if summary.AvgStress != nil {
+		total += (5 - *summary.AvgStress) / 4
+		count++
+	}
+	if summary.AvgSleep != nil {
+		total += (*summary.AvgSleep - 1) / 4
+		count++
+	}
+	if summary.AvgBalance != nil {
+		total += (*summary.AvgBalance - 1) / 4
+		count++
+	}
+	if count == 0 {
+		return 0.5
+	}
+	return total / count
+}
+
+func (s *PredictionService) calculateSeasonalFactors(ctx context.Context, userID int64) float64 {
+	var months []struct {
+		Month	time.Time	`db:"month"`
+		Events	int		`db:"events"`
+	}
+	err := s.db.SelectContext(ctx, &months, `
+		SELECT date_trunc('month', l.created_at) AS month, COUNT(*) AS events
+		FROM key_result_progress_log l
+		JOIN key_results kr ON kr.id = l.key_result_id
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = $1 AND l.progress_before IS NOT NULL AND l.progress_after > l.progress_before
+			AND l.created_at > NOW() - INTERVAL '13 months'
+		GROUP BY month
+	`, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить помесячную активность пользователя %d: %v", userID, err)
+		return 0.5
+	}
+
+	counts := make(map[time.Month]int, len(months))
+	for _, m := range months {
+		counts[m.Month.Month()] += m.Events
+	}
+	return seasonalFactor(counts, time.Now().Month())
+}
+
+func seasonalFactor(counts map[time.Month]int, month time.Month) float64 {
+	if len(counts) < seasonalMinMonths {
+		return 0.5
+	}
+	total := 0
+	for _, events := range counts {
+		total += events
+	}
+	if total == 0 {
+		return 0.5
+	}
+	average := float64(total) / float64(len(counts))
+	ratio := float64(counts[month]) / average
+	return math.Max(0.2, math.Min(0.8, 0.5*ratio))
+}
+
+func (s *PredictionService) getUserProductivityMetrics(ctx context.Context, userID int64) (float64, error) {
+	history, err := s.getProductivityHistory(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if activeDaysRatio(history) == 0 {
+		return 0.7, nil
+	}
+	return 0.3 + 0.7*averageScore(history), nil
+}
+
+func (s *PredictionService) getProductivityHistory(ctx context.Context, userID int64) ([]productivityDay, error) {
+	var history []productivityDay
+	err := s.db.SelectContext(ctx, &history, `
+		SELECT d::date AS day,
+			(SELECT COUNT(*) FROM key_result_progress_log l
+				JOIN key_results kr ON kr.id = l.key_result_id
+				JOIN objectives o ON o.id = kr.objective_id
+				WHERE o.user_id = $1 AND l.progress_before IS NOT NULL AND l.progress_after > l.progress_before
+					AND l.created_at >= d AND l.created_at < d + INTERVAL '1 day') AS progress_events,
+			(SELECT COUNT(*) FROM tasks t
+				JOIN key_results kr ON kr.id = t.key_result_id
+				JOIN objectives o ON o.id = kr.objective_id
+				WHERE o.user_id = $1 AND t.completion_date >= d AND t.completion_date < d + INTERVAL '1 day') AS completed_tasks,
+			(SELECT COUNT(*) FROM habit_tracking h
+				WHERE h.user_id = $1 AND h.date = d::date AND h.completed) AS habits,
+			(SELECT COUNT(*) FROM user_messages m
+				WHERE m.user_identifier = $1::text AND m.created_at >= d AND m.created_at < d + INTERVAL '1 day') AS messages
+		FROM generate_series(CURRENT_DATE - ($2::int - 1), CURRENT_DATE, INTERVAL '1 day') d
+		ORDER BY d
+	`, userID, productivityHistoryDays)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории продуктивности: %v", err)
+	}
+	scoreProductivity(history)
+	return history, nil
+}
+
+func scoreProductivity(history []productivityDay) {
+	var active []float64
+	for _, day := range history {
+		if units := day.units(); units > 0 {
+			active = append(active, units)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+	sort.Float64s(active)
+	norm := math.Max(1, active[int(math.Ceil(0.9*float64(len(active))))-1])
+	for i := range history {
+		history[i].Score = math.Min(1, history[i].units()/norm)
+	}
+}
+
+func activeDaysRatio(history []productivityDay) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+	active := 0
+	for _, day := range history {
+		if day.units() > 0 {
+			active++
+		}
+	}
+	return float64(active) / float64(len(history))
+}
+
+func averageScore(history []productivityDay) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, day := range history {
+		total += day.Score
+	}
+	return total / float64(len(history))
+}
+
+func productivityTrend(history []productivityDay) string {
+	if len(history) < 2*productivityTrendDays {
+		return "stable"
+	}
+	recent := averageScore(history[len(history)-productivityTrendDays:])
+	previous := averageScore(history[len(history)-2*productivityTrendDays : len(history)-productivityTrendDays])
+	switch {
+	case recent-previous > productivityTrendDelta:
+		return "improving"
+	case previous-recent > productivityTrendDelta:
+		return "declining"
+	}
+	return "stable"
+}
+
+func weekdayScores(history []productivityDay) map[time.Weekday]float64 {
+	totals := make(map[time.Weekday]float64)
+	counts := make(map[time.Weekday]int)
+	for _, day := range history {
+		totals[day.Day.Weekday()] += day.Score
+		counts[day.Day.Weekday()]++
+	}
+	scores := make(map[time.Weekday]float64, len(totals))
+	for weekday, total := range totals {
+		scores[weekday] = total / float64(counts[weekday])
+	}
+	return scores
+}
+
+func (s *PredictionService) analyzeProductivityPatterns(history []productivityDay) map[string]interface{} {
+	return map[string]interface{}{
+		"pattern":		productivityTrend(history),
+		"active_ratio":		activeDaysRatio(history),
+		"average_score":	averageScore(history),
+		"weekday_scores":	weekdayScores(history),
+	}
+}
+
+func (s *PredictionService) predictTomorrowProductivity(patterns map[string]interface{}, history []productivityDay) float64 {
+	if activeDaysRatio(history) == 0 {
+		return 0.5
+	}
+	recent := history
+	if len(recent) > 7 {
+		recent = recent[len(recent)-7:]
+	}
+	prediction := averageScore(recent)
+	if scores, ok := patterns["weekday_scores"].(map[time.Weekday]float64); ok {
+		if weekday, ok := scores[time.Now().AddDate(0, 0, 1).Weekday()]; ok {
+			prediction = 0.5*prediction + 0.5*weekday
+		}
+	}
+	return prediction
+}
+
+func (s *PredictionService) predictWeeklyAverage(patterns map[string]interface{}, history []productivityDay) float64 {
+	if activeDaysRatio(history) == 0 {
+		return 0.5
+	}
+	smoothed := history[0].Score
+	for _, day := range history[1:] {
+		smoothed = productivitySmoothing*day.Score + (1-productivitySmoothing)*smoothed
+	}
+	return smoothed
+}
+
+func (s *PredictionService) analyzeMonthlyTrend(history []productivityDay) string {
+	return productivityTrend(history)
+}
+
+func (s *PredictionService) assessBurnoutRisk(ctx context.Context, userID int64, history []productivityDay) float64 {
+	risk, ok, err := s.wellbeing.BurnoutRisk(ctx, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось оценить риск выгорания по самочувствию пользователя %d: %v", userID, err)
+	}
+	if err == nil && ok {
+		return risk
+	}
+	return overloadRisk(history)
+}
+
+func overloadRisk(history []productivityDay) float64 {
+	if len(history) < 7 {
+		return 0.2
+	}
+	lastWeek := history[len(history)-7:]
+	risk := 0.1 + 0.3*activeDaysRatio(lastWeek) + 0.3*averageScore(lastWeek)
+	if productivityTrend(history) == "declining" {
+		risk += 0.1
+	}
+	return math.Min(risk, 1)
+}
+
+func (s *PredictionService) analyzeRecoveryNeeds(burnoutRisk float64, history []productivityDay) []string {
+	if burnoutRisk > 0.7 {
+		return []string{"Больше отдыха", "Снижение нагрузки", "Смена активности"}
+	}
+	if len(history) >= 7 && activeDaysRatio(history[len(history)-7:]) == 1 && burnoutRisk > 0.5 {
+		return []string{"Запланируй хотя бы один день без задач на этой неделе"}
+	}
+	return []string{"Поддержание текущего режима"}
+}
@@ -0,0 +1,94 @@
+package ai_coach
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func historyFromPattern(pattern string) []productivityDay {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	history := make([]productivityDay, 0, len(pattern))
+	for i, c := range pattern {
+		day := productivityDay{Day: start.AddDate(0, 0, i)}
+		switch c {
+		case 'p':
+			day.ProgressEvents = 1
+		case 't':
+			day.CompletedTasks = 2
+		case 'h':
+			day.Habits = 1
+		case 'm':
+			day.Messages = 1
+		}
+		history = append(history, day)
+	}
+	return history
+}
+
+func TestHabitBehaviorScore(t *testing.T) {
+	tests := []struct {
+		name	string
+		stats	habitStats
+		want	float64
+	}{
+		{"empty history", habitStats{}, 0.5},
+		{"all active", habitStats{Total: 30, Completed: 30, Engaged: 30}, 1},
+		{"nothing done", habitStats{Total: 30, Completed: 0, Engaged: 0}, 0},
+		{"sparse", habitStats{Total: 30, Completed: 6, Engaged: 3}, 0.15},
+		{"streak break", habitStats{Total: 14, Completed: 7, Engaged: 7}, 0.5},
+		{"engaged without completion", habitStats{Total: 10, Completed: 0, Engaged: 10}, 0.5},
+		{"single completed entry", habitStats{Total: 1, Completed: 1, Engaged: 0}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := habitBehaviorScore(tt.stats)
+			if got < 0 || got > 1 {
+				t.Fatalf("оценка %v вне диапазона [0, 1]", got)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("habitBehaviorScore(%+v) = %v, ожидалось %v", tt.stats, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveDaysRatio(t *testing.T) {
+	tests := []struct {
+		name	string
+		pattern	string
+		want	float64
+	}{
+		{"empty history", "", 0},
+		{"all idle", "-------", 0},
+		{"all active", "pthmpth", 1},
+		{"messages only count as active", "mmmm", 1},
+		{"sparse", "p------h------t------m------", 4.0 / 28},
+		{"streak break", "ppppppp-------ppppppp", 14.0 / 21},
+		{"single active day", "p", 1},
+		{"single idle day", "-", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := activeDaysRatio(historyFromPattern(tt.pattern))
+			if got < 0 || got > 1 {
+				t.Fatalf("доля %v вне диапазона [0, 1]", got)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("activeDaysRatio(%q) = %v, ожидалось %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveDaysRatioStreakBreakLowersLastWeek(t *testing.T) {
+	history := historyFromPattern("ppppppppppppppp---p---")
+	full := activeDaysRatio(history)
+	lastWeek := activeDaysRatio(history[len(history)-7:])
+	if lastWeek >= full {
+		t.Fatalf("после обрыва серии доля за неделю %v должна быть ниже общей %v", lastWeek, full)
+	}
+	if math.Abs(lastWeek-1.0/7) > 1e-9 {
+		t.Fatalf("доля за последнюю неделю = %v, ожидалось %v", lastWeek, 1.0/7)
+	}
+}
@@ -0,0 +1,144 @@
+package ai_coach
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PredictionSourceHeuristic	= "heuristic"
+	PredictionSourceLLM		= "llm"
+
+	llmPredictionWeight	= 0.4
+)
+
+type LLMCompletionPrediction struct {
+	Probability	float64		`json:"probability"`
+	Risks		[]string	`json:"risks"`
+	Recommendations	[]string	`json:"recommendations"`
+}
+
+type CompletionAdvisor func(ctx context.Context, history string) (*LLMCompletionPrediction, error)
+
+type PredictionSource struct {
+	Name		string		`json:"name"`
+	Probability	float64		`json:"probability"`
+	Weight		float64		`json:"weight"`
+	RiskFactors	[]string	`json:"risk_factors,omitempty"`
+	Recommendations	[]string	`json:"recommendations,omitempty"`
+}
+
+func (s *PredictionService) SetCompletionAdvisor(advisor CompletionAdvisor) {
+	s.advisor = advisor
+}
+
+func (s *AICoachService) SetCompletionAdvisor(advisor CompletionAdvisor) {
+	s.predictionEngine.SetCompletionAdvisor(advisor)
+}
+
+func (s *PredictionService) mergeLLMPrediction(ctx context.Context, userID int64, objectiveID string, goalData map[string]interface{}, factors *PredictionFactors, prediction *CompletionPrediction) {
+	heuristic := PredictionSource{
+		Name:			PredictionSourceHeuristic,
+		Probability:		prediction.Probability,
+		Weight:			1,
+		RiskFactors:		prediction.RiskFactors,
+		Recommendations:	prediction.Recommendations,
+	}
+	prediction.Sources = []PredictionSource{heuristic}
+	if s.advisor == nil {
+		return
+	}
+
+	history, err := s.describeGoalHistory(ctx, userID, objectiveID, goalData, factors)
+	if err != nil {
+		logrus.Warnf("Не удалось собрать историю цели %s для LLM-прогноза: %v", objectiveID, err)
+		return
+	}
+	advice, err := s.advisor(ctx, history)
+	if err != nil {
+		logrus.Warnf("LLM-прогноз по цели %s недоступен, используется эвристика: %v", objectiveID, err)
+		return
+	}
+
+	llm := PredictionSource{
+		Name:			PredictionSourceLLM,
+		Probability:		math.Max(0, math.Min(1, advice.Probability)),
+		Weight:			llmPredictionWeight,
+		RiskFactors:		advice.Risks,
+		Recommendations:	advice.Recommendations,
+	}
+	prediction.Sources[0].Weight = 1 - llmPredictionWeight
+	prediction.Sources = append(prediction.Sources, llm)
+
+	prediction.Probability = prediction.Sources[0].Weight*heuristic.Probability + llm.Weight*llm.Probability
+	prediction.RiskFactors = mergeUnique(prediction.RiskFactors, llm.RiskFactors)
+	prediction.Recommendations = mergeUnique(prediction.Recommendations, llm.Recommendations)
+}
+
+func (s *PredictionService) describeGoalHistory(ctx context.Context, userID int64, objectiveID string, goalData map[string]interface{}, factors *PredictionFactors) (string, error) {
+	var keyResults []struct {
+		Title		string	`db:"title"`
+		Progress	float64	`db:"progress"`
+		Target		float64	`db:"target"`
+		Unit		string	`db:"unit"`
+	}
+	err := s.db.SelectContext(ctx, &keyResults, `
+		SELECT title, progress, target, unit FROM key_results
+		WHERE objective_id = $1 AND deleted_at IS NULL
+		ORDER BY id
+	`, objectiveID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при получении ключевых результатов: %v", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Сегодня: %s\n", time.Now().Format("2006-01-02"))
+	fmt.Fprintf(&b, "Цель: %v\n", goalData["title"])
+	if createdAt, ok := goalData["created_at"].(time.Time); ok {
+		fmt.Fprintf(&b, "Создана: %s\n", createdAt.Format("2006-01-02"))
+	}
+	if deadline, ok := goalData["deadline"].(time.Time); ok {
+		fmt.Fprintf(&b, "Дедлайн: %s\n", deadline.Format("2006-01-02"))
+	}
+	fmt.Fprintf(&b, "Сложность: %v из 5\n", goalData["difficulty_level"])
+
+	b.WriteString("\nКлючевые результаты:\n")
+	for _, kr := range keyResults {
+		fmt.Fprintf(&b, "- %s: %.1f из %.1f %s\n", kr.Title, kr.Progress, kr.Target, kr.Unit)
+	}
+
+	if history, err := s.getProgressHistory(ctx, userID, objectiveID); err == nil && len(history.Samples) > 0 {
+		b.WriteString("\nПрогресс цели по неделям, %:\n")
+		for i := len(history.Samples) - 1; i >= 0; i -= 7 {
+			fmt.Fprintf(&b, "- %s: %.0f\n", history.Samples[i].Day.Format("2006-01-02"), history.Samples[i].Percent)
+		}
+	}
+
+	if stats, err := s.getCompletionStatistics(ctx, userID); err == nil {
+		fmt.Fprintf(&b, "\nПрошлые цели за полгода: выполнено %v, сорвано %v\n", stats["completed"], stats["missed"])
+	}
+
+	fmt.Fprintf(&b, "\nОценки факторов (0-1): регулярность %.2f, история выполнения %.2f, сложность %.2f, запас времени %.2f, свободные ресурсы %.2f, мотивация %.2f, самочувствие %.2f\n",
+		factors.UserBehaviorScore, factors.HistoricalPerformance, factors.GoalComplexity, factors.TimeRemaining,
+		factors.ResourceAvailability, factors.MotivationLevel, factors.ExternalFactors)
+	return b.String(), nil
+}
+
+func mergeUnique(base, extra []string) []string {
+	seen := make(map[string]bool, len(base)+len(extra))
+	merged := make([]string, 0, len(base)+len(extra))
+	for _, item := range append(append([]string{}, base...), extra...) {
+		key := strings.ToLower(strings.TrimSpace(item))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, item)
+	}
+	return merged
+}
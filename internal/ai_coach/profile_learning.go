@@ -0,0 +1,339 @@
+package ai_coach
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	minProfileSamples	= 5
+	profileStaleAfter	= 7 * 24 * time.Hour
+)
+
+type LearnedProfile struct {
+	UserID			int64		`db:"user_id" json:"user_id"`
+	PersonalityType		string		`db:"personality_type" json:"personality_type"`
+	MotivationStyle		string		`db:"motivation_style" json:"motivation_style"`
+	ActivityLevel		string		`db:"activity_level" json:"activity_level"`
+	Chronotype		string		`db:"chronotype" json:"chronotype"`
+	CompletionRate		float64		`db:"completion_rate" json:"completion_rate"`
+	Consistency		float64		`db:"consistency" json:"consistency"`
+	HabitAdherence		float64		`db:"habit_adherence" json:"habit_adherence"`
+	PreferredDifficulty	float64		`db:"preferred_difficulty" json:"preferred_difficulty"`
+	SocialScore		float64		`db:"social_score" json:"social_score"`
+	EngagementTrend		float64		`db:"engagement_trend" json:"engagement_trend"`
+	PeakHours		pq.Int64Array	`db:"peak_hours" json:"peak_hours"`
+	TraitsJSON		[]byte		`db:"traits" json:"-"`
+	Traits			map[string]float64	`db:"-" json:"traits"`
+	SampleSize		int		`db:"sample_size" json:"sample_size"`
+	ComputedAt		time.Time	`db:"computed_at" json:"computed_at"`
+}
+
+func loadLearnedProfile(ctx context.Context, db *sqlx.DB, userID int64) (*LearnedProfile, error) {
+	var profile LearnedProfile
+	err := db.GetContext(ctx, &profile, `
+		SELECT user_id, personality_type, motivation_style, activity_level, chronotype, completion_rate,
+			consistency, habit_adherence, preferred_difficulty, social_score, engagement_trend,
+			peak_hours, traits, sample_size, computed_at
+		FROM personality_profiles
+		WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении профиля личности: %v", err)
+	}
+
+	profile.Traits = make(map[string]float64)
+	if len(profile.TraitsJSON) > 0 {
+		json.Unmarshal(profile.TraitsJSON, &profile.Traits)
+	}
+	if profile.SampleSize < minProfileSamples {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+func (p *LearnedProfile) BestTime() string {
+	switch p.Chronotype {
+	case "morning":
+		return "morning"
+	case "evening":
+		return "evening"
+	default:
+		return "afternoon"
+	}
+}
+
+func (s *PersonalityService) StartProfileLearning(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:		"personality_profile_learning",
+		Spec:		"30 3 * * *",
+		Run: func(ctx context.Context) {
+			s.learnActiveProfiles(ctx)
+		},
+	})
+
+	logrus.Info("Запущено обучение профилей личности по поведению")
+}
+
+func (s *PersonalityService) learnActiveProfiles(ctx context.Context) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT u.id
+		FROM users u
+		LEFT JOIN personality_profiles p ON p.user_id = u.id
+		WHERE (p.computed_at IS NULL OR p.computed_at < NOW() - INTERVAL '20 hours')
+		  AND (
+			EXISTS (SELECT 1 FROM user_messages m WHERE m.user_identifier = u.id::text AND m.created_at > NOW() - INTERVAL '30 days')
+			OR EXISTS (SELECT 1 FROM key_result_progress_log l WHERE l.user_id = u.id AND l.delta <> 0 AND l.created_at > NOW() - INTERVAL '30 days')
+		  )
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении пользователей для обучения профиля: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.LearnProfile(ctx, userID); err != nil {
+			logrus.Warnf("Не удалось обновить профиль личности пользователя %d: %v", userID, err)
+		}
+	}
+	logrus.Infof("Обновлены профили личности: %d", len(userIDs))
+}
+
+func (s *PersonalityService) LearnProfile(ctx context.Context, userID int64) (*LearnedProfile, error) {
+	profile := &LearnedProfile{
+		UserID:			userID,
+		PreferredDifficulty:	0.6,
+		EngagementTrend:	0.5,
+		Traits:			make(map[string]float64),
+	}
+	telegramID := strconv.FormatInt(userID, 10)
+
+	var completion struct {
+		Total		int	`db:"total"`
+		Completed	int	`db:"completed"`
+		Difficulty	float64	`db:"difficulty"`
+		Spheres		int	`db:"spheres"`
+	}
+	err := s.db.GetContext(ctx, &completion, `
+		SELECT COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE kr.progress >= kr.target) AS completed,
+			COALESCE(AVG(o.difficulty_level) FILTER (WHERE kr.progress >= kr.target), 0)::float8 AS difficulty,
+			COUNT(DISTINCT o.sphere) AS spheres
+		FROM key_results kr
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND kr.created_at > NOW() - INTERVAL '90 days'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе выполнения OKR: %v", err)
+	}
+	if completion.Total > 0 {
+		profile.CompletionRate = float64(completion.Completed) / float64(completion.Total)
+	}
+	if completion.Difficulty > 0 {
+		profile.PreferredDifficulty = completion.Difficulty / 5.0
+	}
+
+	var activeDays int
+	err = s.db.GetContext(ctx, &activeDays, `
+		SELECT COUNT(DISTINCT day) FROM (
+			SELECT created_at::date AS day FROM key_result_progress_log
+			WHERE user_id = $1 AND delta <> 0 AND created_at > NOW() - INTERVAL '30 days'
+			UNION
+			SELECT log_date FROM habit_counter_days
+			WHERE user_id = $1 AND taps > 0 AND log_date > CURRENT_DATE - 30
+			UNION
+			SELECT created_at::date FROM user_messages
+			WHERE user_identifier = $2 AND created_at > NOW() - INTERVAL '30 days'
+		) days
+	`, userID, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе регулярности: %v", err)
+	}
+	profile.Consistency = math.Min(float64(activeDays)/30.0, 1)
+
+	var habits struct {
+		Total	int	`db:"total"`
+		Reached	int	`db:"reached"`
+	}
+	err = s.db.GetContext(ctx, &habits, `
+		SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE goal_reached) AS reached
+		FROM habit_counter_days
+		WHERE user_id = $1 AND log_date > CURRENT_DATE - 30
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе привычек: %v", err)
+	}
+	if habits.Total > 0 {
+		profile.HabitAdherence = float64(habits.Reached) / float64(habits.Total)
+	}
+
+	var hours []struct {
+		Hour	int64	`db:"hour"`
+		Events	int	`db:"events"`
+	}
+	err = s.db.SelectContext(ctx, &hours, `
+		SELECT EXTRACT(HOUR FROM created_at)::bigint AS hour, COUNT(*) AS events
+		FROM (
+			SELECT created_at FROM user_messages
+			WHERE user_identifier = $2 AND created_at > NOW() - INTERVAL '30 days'
+			UNION ALL
+			SELECT created_at FROM key_result_progress_log
+			WHERE user_id = $1 AND delta <> 0 AND created_at > NOW() - INTERVAL '30 days'
+		) events
+		GROUP BY hour
+		ORDER BY events DESC, hour
+	`, userID, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе времени активности: %v", err)
+	}
+	var weightedHour float64
+	for i, h := range hours {
+		profile.SampleSize += h.Events
+		weightedHour += float64(h.Hour * int64(h.Events))
+		if i < 3 {
+			profile.PeakHours = append(profile.PeakHours, h.Hour)
+		}
+	}
+	sort.Slice(profile.PeakHours, func(i, j int) bool { return profile.PeakHours[i] < profile.PeakHours[j] })
+	profile.Chronotype = "day"
+	if profile.SampleSize > 0 {
+		switch avg := weightedHour / float64(profile.SampleSize); {
+		case avg < 12:
+			profile.Chronotype = "morning"
+		case avg >= 17:
+			profile.Chronotype = "evening"
+		}
+	}
+
+	var trend struct {
+		Recent		int	`db:"recent"`
+		Previous	int	`db:"previous"`
+	}
+	err = s.db.GetContext(ctx, &trend, `
+		SELECT COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '14 days') AS recent,
+			COUNT(*) FILTER (WHERE created_at <= NOW() - INTERVAL '14 days') AS previous
+		FROM key_result_progress_log
+		WHERE user_id = $1 AND delta <> 0 AND created_at > NOW() - INTERVAL '28 days'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе динамики активности: %v", err)
+	}
+	if trend.Recent+trend.Previous > 0 {
+		profile.EngagementTrend = float64(trend.Recent) / float64(trend.Recent+trend.Previous)
+	}
+
+	var socialLinks int
+	err = s.db.GetContext(ctx, &socialLinks, `
+		SELECT
+			(SELECT COUNT(*) FROM team_members WHERE user_id = $1 AND is_active = TRUE) +
+			(SELECT COUNT(*) FROM challenge_participants WHERE user_id = $1 AND status = 'joined')
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при анализе социальной активности: %v", err)
+	}
+	profile.SocialScore = math.Min(float64(socialLinks)*0.3, 1)
+
+	var avgStress sql.NullFloat64
+	s.db.GetContext(ctx, &avgStress, `
+		SELECT AVG(stress_level)::float8 FROM wellbeing_checkins
+		WHERE user_id = $1 AND checkin_date > CURRENT_DATE - 30
+	`, userID)
+
+	profile.Traits["conscientiousness"] = 0.6*profile.CompletionRate + 0.4*profile.Consistency
+	profile.Traits["openness"] = math.Min(float64(completion.Spheres)/5.0, 1)
+	profile.Traits["extraversion"] = profile.SocialScore
+	profile.Traits["persistence"] = 0.5*profile.HabitAdherence + 0.5*profile.Consistency
+	if avgStress.Valid {
+		profile.Traits["neuroticism"] = (avgStress.Float64 - 1) / 4
+	}
+
+	profile.PersonalityType = s.determinePersonalityType(profile.Traits)
+	profile.MotivationStyle = learnedMotivationStyle(profile)
+	switch {
+	case profile.Consistency > 0.8:
+		profile.ActivityLevel = "very_high"
+	case profile.Consistency > 0.6:
+		profile.ActivityLevel = "high"
+	case profile.Consistency > 0.3:
+		profile.ActivityLevel = "moderate"
+	default:
+		profile.ActivityLevel = "low"
+	}
+	profile.SampleSize += completion.Total + habits.Total
+
+	if err := s.saveLearnedProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func learnedMotivationStyle(profile *LearnedProfile) string {
+	switch {
+	case profile.SocialScore >= 0.6:
+		return MotivationTypeSocial
+	case profile.PreferredDifficulty >= 0.7:
+		return MotivationTypeChallenge
+	case profile.CompletionRate >= 0.7:
+		return MotivationTypeAchievement
+	case profile.Consistency >= 0.5:
+		return MotivationTypeProgress
+	default:
+		return MotivationTypeGrowth
+	}
+}
+
+func (s *PersonalityService) saveLearnedProfile(ctx context.Context, profile *LearnedProfile) error {
+	traits, _ := json.Marshal(profile.Traits)
+	if profile.PeakHours == nil {
+		profile.PeakHours = pq.Int64Array{}
+	}
+	profile.ComputedAt = time.Now()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO personality_profiles (
+			user_id, personality_type, motivation_style, activity_level, chronotype, completion_rate,
+			consistency, habit_adherence, preferred_difficulty, social_score, engagement_trend,
+			peak_hours, traits, sample_size, computed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (user_id) DO UPDATE SET
+			personality_type = EXCLUDED.personality_type,
+			motivation_style = EXCLUDED.motivation_style,
+			activity_level = EXCLUDED.activity_level,
+			chronotype = EXCLUDED.chronotype,
+			completion_rate = EXCLUDED.completion_rate,
+			consistency = EXCLUDED.consistency,
+			habit_adherence = EXCLUDED.habit_adherence,
+			preferred_difficulty = EXCLUDED.preferred_difficulty,
+			social_score = EXCLUDED.social_score,
+			engagement_trend = EXCLUDED.engagement_trend,
+			peak_hours = EXCLUDED.peak_hours,
+			traits = EXCLUDED.traits,
+			sample_size = EXCLUDED.sample_size,
+			computed_at = EXCLUDED.computed_at
+	`, profile.UserID, profile.PersonalityType, profile.MotivationStyle, profile.ActivityLevel, profile.Chronotype,
+		profile.CompletionRate, profile.Consistency, profile.HabitAdherence, profile.PreferredDifficulty,
+		profile.SocialScore, profile.EngagementTrend, profile.PeakHours, string(traits), profile.SampleSize, profile.ComputedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении профиля личности: %v", err)
+	}
+	return nil
+}
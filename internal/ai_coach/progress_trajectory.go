@@ -0,0 +1,207 @@
+package ai_coach
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	progressHistoryDays	= 60
+	velocityWindowDays	= 14
+	trendWindowDays		= 7
+	trendThreshold		= 0.2
+	stallDays		= 7
+)
+
+type progressSample struct {
+	Day	time.Time	`db:"day"`
+	Percent	float64		`db:"percent"`
+}
+
+type progressHistory struct {
+	Samples		[]progressSample
+	Deadline	*time.Time
+}
+
+func (h *progressHistory) current() float64 {
+	if len(h.Samples) == 0 {
+		return 0
+	}
+	return h.Samples[len(h.Samples)-1].Percent
+}
+
+func (h *progressHistory) percentDaysAgo(days int) (float64, bool) {
+	i := len(h.Samples) - 1 - days
+	if i < 0 {
+		return 0, false
+	}
+	return h.Samples[i].Percent, true
+}
+
+func (h *progressHistory) requiredVelocity(now time.Time) (float64, bool) {
+	if h.Deadline == nil {
+		return 0, false
+	}
+	days := h.Deadline.Sub(now).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+	return math.Max(0, 100-h.current()) / days, true
+}
+
+func (s *PredictionService) getProgressHistory(ctx context.Context, userID int64, objectiveID string) (*progressHistory, error) {
+	var deadline *time.Time
+	if err := s.db.GetContext(ctx, &deadline, `SELECT deadline FROM objectives WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, objectiveID, userID); err != nil {
+		return nil, fmt.Errorf("цель не найдена или не принадлежит пользователю: %v", err)
+	}
+
+	history := &progressHistory{Deadline: deadline}
+	err := s.db.SelectContext(ctx, &history.Samples, `
+		SELECT d::date AS day, AVG(LEAST(GREATEST(p.progress_after / NULLIF(p.target, 0), 0), 1) * 100) AS percent
+		FROM generate_series(CURRENT_DATE - $2::int, CURRENT_DATE, INTERVAL '1 day') d
+		JOIN key_results kr ON kr.objective_id = $1 AND kr.deleted_at IS NULL
+		CROSS JOIN LATERAL (
+			SELECT l.progress_after, l.target
+			FROM key_result_progress_log l
+			WHERE l.key_result_id = kr.id AND l.created_at < d + INTERVAL '1 day'
+			ORDER BY l.created_at DESC, l.id DESC
+			LIMIT 1
+		) p
+		GROUP BY d
+		ORDER BY d
+	`, objectiveID, progressHistoryDays)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории прогресса: %v", err)
+	}
+	return history, nil
+}
+
+func (s *PredictionService) calculateProgressVelocity(history *progressHistory) float64 {
+	if len(history.Samples) < 2 {
+		return 0
+	}
+	window := velocityWindowDays
+	if window > len(history.Samples)-1 {
+		window = len(history.Samples) - 1
+	}
+	past, _ := history.percentDaysAgo(window)
+	return (history.current() - past) / float64(window)
+}
+
+func (s *PredictionService) analyzeTrend(history *progressHistory) string {
+	weekAgo, ok := history.percentDaysAgo(trendWindowDays)
+	if !ok {
+		return "stable"
+	}
+	twoWeeksAgo, ok := history.percentDaysAgo(2 * trendWindowDays)
+	if !ok {
+		twoWeeksAgo = history.Samples[0].Percent
+	}
+
+	recent := (history.current() - weekAgo) / trendWindowDays
+	previous := (weekAgo - twoWeeksAgo) / trendWindowDays
+	switch {
+	case recent-previous > trendThreshold:
+		return "improving"
+	case previous-recent > trendThreshold:
+		return "declining"
+	}
+	return "stable"
+}
+
+func (s *PredictionService) predictExpectedProgress(velocity float64, history *progressHistory) float64 {
+	return math.Max(0, math.Min(history.current()+velocity*7, 100))
+}
+
+func (s *PredictionService) predictMilestones(history *progressHistory, velocity float64) []MilestonePrediction {
+	milestones := make([]MilestonePrediction, 0)
+	if velocity <= 0 {
+		return milestones
+	}
+
+	now := time.Now()
+	current := history.current()
+	for _, level := range []float64{25, 50, 75, 100} {
+		if level <= current {
+			continue
+		}
+		days := (level - current) / velocity
+		date := now.AddDate(0, 0, int(math.Ceil(days)))
+
+		probability := 0.6
+		if history.Deadline != nil {
+			if date.After(*history.Deadline) {
+				probability = 0.3
+			} else {
+				probability = 0.8
+			}
+		}
+		milestones = append(milestones, MilestonePrediction{
+			Title:			fmt.Sprintf("%.0f%% цели", level),
+			PredictedDate:		date,
+			Probability:		probability,
+			RequiredProgress:	level - current,
+			CriticalPath:		level == 100,
+		})
+	}
+	return milestones
+}
+
+func (s *PredictionService) predictBottlenecks(history *progressHistory, velocity float64) []BottleneckPrediction {
+	bottlenecks := make([]BottleneckPrediction, 0)
+	now := time.Now()
+	current := history.current()
+	if current >= 100 {
+		return bottlenecks
+	}
+
+	if weekAgo, ok := history.percentDaysAgo(stallDays); ok && current-weekAgo < 0.5 {
+		bottlenecks = append(bottlenecks, BottleneckPrediction{
+			Type:		"stall",
+			Description:	fmt.Sprintf("Прогресс не менялся %d дней", stallDays),
+			PredictedDate:	now,
+			ImpactSeverity:	0.7,
+			PreventionTips:	[]string{"Выбери одно маленькое действие по цели на сегодня", "Проверь, не устарели ли ключевые результаты"},
+		})
+	}
+
+	if required, ok := history.requiredVelocity(now); ok && velocity < required {
+		severity := 1.0
+		if required > 0 {
+			severity = math.Min(1, (required-velocity)/required)
+		}
+		bottlenecks = append(bottlenecks, BottleneckPrediction{
+			Type:		"pace",
+			Description:	fmt.Sprintf("Текущий темп %.1f п.п. в день, а до дедлайна нужно %.1f", velocity, required),
+			PredictedDate:	*history.Deadline,
+			ImpactSeverity:	severity,
+			PreventionTips:	[]string{"Запланируй фиксированные блоки времени под цель", "Пересмотри объем ключевых результатов или дедлайн"},
+		})
+	}
+	return bottlenecks
+}
+
+func (s *PredictionService) generateOptimizationSuggestions(history *progressHistory, velocity float64) []string {
+	if history.current() >= 100 {
+		return []string{"Цель выполнена — зафиксируй результат и поставь следующую"}
+	}
+	if len(history.Samples) < 2 {
+		return []string{"Отмечай прогресс по ключевым результатам, чтобы прогноз стал точнее"}
+	}
+
+	required, hasDeadline := history.requiredVelocity(time.Now())
+	switch {
+	case velocity <= 0:
+		return []string{"Прогресса за последние недели нет — начни с самого простого ключевого результата", "Поставь напоминание на конкретное время"}
+	case hasDeadline && velocity < required:
+		return []string{
+			fmt.Sprintf("Чтобы успеть к дедлайну, нужно ускориться примерно в %.1f раза", required/velocity),
+			"Сфокусируйся на ключевом результате, который сильнее всего отстает",
+		}
+	case hasDeadline && velocity > required*1.5:
+		return []string{"Ты идешь с опережением — можно поднять планку или переключить часть времени на другие цели"}
+	}
+	return []string{"Поддерживай текущий темп", "Отмечай прогресс регулярно, чтобы видеть тренд"}
+}
@@ -0,0 +1,52 @@
+package ai_coach
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	minTrackedHours		= 1.0
+	minTrackedProgress	= 5.0
+)
+
+type trackedEffort struct {
+	Hours		float64	`db:"hours"`
+	ActiveDays	int	`db:"active_days"`
+	Progress	float64	`db:"progress"`
+}
+
+func (s *PredictionService) getTrackedEffort(ctx context.Context, objectiveID string) (*trackedEffort, error) {
+	var effort trackedEffort
+	err := s.db.GetContext(ctx, &effort, `
+		SELECT
+			COALESCE((SELECT SUM(minutes) FROM time_entries WHERE objective_id = $1), 0) / 60.0 AS hours,
+			(SELECT COUNT(DISTINCT spent_on) FROM time_entries WHERE objective_id = $1) AS active_days,
+			COALESCE((
+				SELECT AVG(LEAST(kr.progress / NULLIF(kr.target, 0), 1)) * 100
+				FROM key_results kr WHERE kr.objective_id = $1 AND kr.deleted_at IS NULL
+			), 0) AS progress
+	`, objectiveID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении учтенного времени по цели: %v", err)
+	}
+	return &effort, nil
+}
+
+func (e *trackedEffort) usable() bool {
+	return e.Hours >= minTrackedHours && e.ActiveDays > 0 && e.Progress >= minTrackedProgress && e.Progress < 100
+}
+
+func (e *trackedEffort) remainingHours() (float64, bool) {
+	if !e.usable() {
+		return 0, false
+	}
+	return e.Hours * (100 - e.Progress) / e.Progress, true
+}
+
+func (e *trackedEffort) hoursPerActiveDay() float64 {
+	if e.ActiveDays == 0 {
+		return 1
+	}
+	return e.Hours / float64(e.ActiveDays)
+}
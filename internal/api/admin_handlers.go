@@ -0,0 +1,298 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/admin"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/maintenance"
+	"telegrambot/pkg/logging"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type SetUserRoleRequest struct {
+	UserID	int64	`json:"user_id"`
+	Role	string	`json:"role"`
+}
+
+type BlockUserRequest struct {
+	UserID	int64	`json:"user_id"`
+	Blocked	bool	`json:"blocked"`
+}
+
+type MaintenanceRequest struct {
+	Enabled	bool		`json:"enabled"`
+	Message	string		`json:"message"`
+	EndsAt	*time.Time	`json:"ends_at,omitempty"`
+}
+
+type MaintenanceResponse struct {
+	maintenance.State
+	Announced	int	`json:"announced"`
+}
+
+type LogLevelRequest struct {
+	Module	string	`json:"module"`
+	Level	string	`json:"level"`
+}
+
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request, handlerName string) bool {
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Errorf("Не удалось извлечь webUserID из контекста в %s", handlerName)
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return false
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return false
+	}
+	if webUser == nil || !h.adminService.IsAnyAdmin(webUser.TelegramIDs) {
+		logrus.Warnf("Попытка доступа к админ API от web_user %d без прав администратора", webUserID)
+		http.Error(w, "Доступ запрещен", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) writeAdminError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, admin.ErrUserNotFound):
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+	case errors.Is(err, admin.ErrInvalidRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logrus.Errorf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) AdminUsersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AdminUsersHandler") {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	list, err := h.adminService.ListUsers(r.Context(), query.Get("search"), limit, offset)
+	if err != nil {
+		h.writeAdminError(w, err, "Ошибка при получении списка пользователей")
+		return
+	}
+	if list == nil {
+		list = []admin.User{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		logrus.Errorf("Ошибка API при сериализации пользователей в JSON: %v", err)
+		http.Error(w, "Ошибка при формировании ответа", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) AdminSetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AdminSetUserRoleHandler") {
+		return
+	}
+
+	var req SetUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 || req.Role == "" {
+		http.Error(w, "Обязательные поля: user_id, role", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.adminService.SetRole(ctx, req.UserID, req.Role); err != nil {
+		h.writeAdminError(w, err, "Ошибка при изменении роли")
+		return
+	}
+
+	user, err := h.adminService.GetUser(ctx, req.UserID)
+	if err != nil {
+		h.writeAdminError(w, err, "Ошибка при получении пользователя")
+		return
+	}
+
+	logrus.Infof("Роль пользователя %d изменена на %s через админ API", req.UserID, req.Role)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *Handler) AdminBlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AdminBlockUserHandler") {
+		return
+	}
+
+	var req BlockUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 {
+		http.Error(w, "Обязательное поле: user_id", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.adminService.SetBlocked(ctx, req.UserID, req.Blocked); err != nil {
+		h.writeAdminError(w, err, "Ошибка при изменении блокировки")
+		return
+	}
+
+	user, err := h.adminService.GetUser(ctx, req.UserID)
+	if err != nil {
+		h.writeAdminError(w, err, "Ошибка при получении пользователя")
+		return
+	}
+
+	logrus.Infof("Блокировка пользователя %d изменена на %t через админ API", req.UserID, req.Blocked)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *Handler) AdminStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AdminStatsHandler") {
+		return
+	}
+
+	stats, err := h.adminService.GetStats(r.Context())
+	if err != nil {
+		h.writeAdminError(w, err, "Ошибка при получении статистики")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (h *Handler) AdminMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "AdminMaintenanceHandler") {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MaintenanceResponse{State: h.maintenance.State()})
+	case http.MethodPost:
+		var req MaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		response := MaintenanceResponse{}
+		if req.Enabled {
+			state, err := h.maintenance.Enable(ctx, req.Message, req.EndsAt)
+			if err != nil {
+				logrus.Errorf("Ошибка при включении режима обслуживания: %v", err)
+				http.Error(w, "Ошибка при включении режима обслуживания", http.StatusInternalServerError)
+				return
+			}
+			response.State = state
+			logrus.Warn("Режим обслуживания включен через админ API")
+		} else {
+			announced, err := h.maintenance.Disable(ctx)
+			if err != nil {
+				logrus.Errorf("Ошибка при выключении режима обслуживания: %v", err)
+				http.Error(w, "Ошибка при выключении режима обслуживания", http.StatusInternalServerError)
+				return
+			}
+			response.State = h.maintenance.State()
+			response.Announced = announced
+			logrus.Infof("Режим обслуживания выключен через админ API, уведомлено пользователей: %d", announced)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) AdminLogLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "AdminLogLevelsHandler") {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req LogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+		if err := logging.SetLevel(req.Module, req.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logrus.Warnf("Уровень логирования модуля %q изменен через админ API на %q", req.Module, req.Level)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logging.Current())
+}
+
+func (h *Handler) AdminPredictionCalibrationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AdminPredictionCalibrationHandler") {
+		return
+	}
+
+	metrics, err := h.predictions.CalibrationMetrics(r.Context())
+	if err != nil {
+		h.writeAdminError(w, err, "Ошибка при расчете калибровки предсказаний")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+func (h *Handler) AdminJobStatsHandler(stats http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.requireAdmin(w, r, "AdminJobStatsHandler") {
+			return
+		}
+		stats.ServeHTTP(w, r)
+	}
+}
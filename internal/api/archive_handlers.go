@@ -0,0 +1,277 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultArchiveLimit	= 20
+	maxArchiveLimit		= 100
+)
+
+type ArchivedObjectiveResponse struct {
+	ID		string		`json:"id"`
+	UserID		int64		`json:"user_id"`
+	Title		string		`json:"title"`
+	Sphere		string		`json:"sphere"`
+	Period		string		`json:"period"`
+	Status		string		`json:"status"`
+	Deadline	*time.Time	`json:"deadline,omitempty"`
+	CompletionDate	*time.Time	`json:"completion_date,omitempty"`
+	Progress	float64		`json:"progress"`
+	CreatedAt	time.Time	`json:"created_at"`
+}
+
+type RestoreObjectiveRequest struct {
+	ID string `json:"id"`
+}
+
+type CloseObjectiveRequest struct {
+	ID		string	`json:"id"`
+	Outcome		string	`json:"outcome"`
+	WhatWorked	string	`json:"what_worked"`
+	WhatDidnt	string	`json:"what_didnt"`
+}
+
+func newArchivedObjectiveResponse(o okr.ArchivedObjective) ArchivedObjectiveResponse {
+	return ArchivedObjectiveResponse{
+		ID:		o.ID,
+		UserID:		o.UserID,
+		Title:		o.Title,
+		Sphere:		o.Sphere,
+		Period:		o.Period,
+		Status:		o.Status,
+		Deadline:	o.Deadline,
+		CompletionDate:	o.CompletionDate,
+		Progress:	o.Progress,
+		CreatedAt:	o.CreatedAt,
+	}
+}
+
+func isArchiveRequest(r *http.Request) bool {
+	archived, _ := strconv.ParseBool(r.URL.Query().Get("archived"))
+	return archived
+}
+
+func parseArchivePagination(r *http.Request) (int, int, error) {
+	limit := defaultArchiveLimit
+	offset := 0
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return 0, 0, errors.New("некорректный параметр limit")
+		}
+		limit = parsed
+	}
+	if limit > maxArchiveLimit {
+		limit = maxArchiveLimit
+	}
+
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("некорректный параметр offset")
+		}
+		offset = parsed
+	}
+
+	return limit, offset, nil
+}
+
+func (h *Handler) listArchivedObjectives(w http.ResponseWriter, r *http.Request, telegramIDs []int64) {
+	limit, offset, err := parseArchivePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	objectives := make([]okr.ArchivedObjective, 0)
+	for _, telegramID := range telegramIDs {
+		userObjectives, err := h.okrService.GetArchivedObjectives(ctx, telegramID, limit+offset, 0)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении архивных целей пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении архива целей", http.StatusInternalServerError)
+			return
+		}
+		objectives = append(objectives, userObjectives...)
+	}
+
+	sort.SliceStable(objectives, func(i, j int) bool {
+		return archivedAt(objectives[i]).After(archivedAt(objectives[j]))
+	})
+
+	response := make([]ArchivedObjectiveResponse, 0, limit)
+	for i := offset; i < len(objectives) && i < offset+limit; i++ {
+		response = append(response, newArchivedObjectiveResponse(objectives[i]))
+	}
+
+	writeOKRJSON(w, http.StatusOK, response)
+}
+
+func archivedAt(o okr.ArchivedObjective) time.Time {
+	if o.CompletionDate != nil {
+		return *o.CompletionDate
+	}
+	return o.CreatedAt
+}
+
+func (h *Handler) RestoreObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "RestoreObjectiveHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req RestoreObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		http.Error(w, "Необходимо указать id цели", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, _, found := h.findObjectiveOwner(ctx, telegramIDs, req.ID)
+	if !found {
+		http.Error(w, "Цель не найдена", http.StatusNotFound)
+		return
+	}
+
+	err := h.okrService.RestoreObjective(ctx, ownerID, req.ID)
+	if errors.Is(err, okr.ErrObjectiveNotArchived) {
+		http.Error(w, "Цель не находится в архиве", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при восстановлении цели %s: %v", req.ID, err)
+		http.Error(w, "Ошибка при восстановлении цели", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, ownerID, req.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении восстановленной цели %s: %v", req.ID, err)
+		http.Error(w, "Ошибка при получении цели", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, newObjectiveResponse(details))
+}
+
+func (h *Handler) CloseObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "CloseObjectiveHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req CloseObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		http.Error(w, "Необходимо указать id цели", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, _, found := h.findObjectiveOwner(ctx, telegramIDs, req.ID)
+	if !found {
+		http.Error(w, "Цель не найдена", http.StatusNotFound)
+		return
+	}
+
+	_, err := h.okrService.CloseObjective(ctx, ownerID, req.ID, req.Outcome, req.WhatWorked, req.WhatDidnt)
+	switch {
+	case errors.Is(err, okr.ErrInvalidClosingOutcome):
+		http.Error(w, "outcome должен быть completed или archived", http.StatusBadRequest)
+		return
+	case errors.Is(err, okr.ErrObjectiveAlreadyClosed):
+		http.Error(w, "Цель уже закрыта", http.StatusConflict)
+		return
+	case err != nil:
+		logrus.Errorf("Ошибка API при закрытии цели %s: %v", req.ID, err)
+		http.Error(w, "Ошибка при закрытии цели", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, ownerID, req.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении закрытой цели %s: %v", req.ID, err)
+		http.Error(w, "Ошибка при получении цели", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, newObjectiveResponse(details))
+}
+
+func (h *Handler) listPastEvents(w http.ResponseWriter, r *http.Request, telegramIDs []int64) {
+	limit, offset, err := parseArchivePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.calendarService.GetPastEvents(r.Context(), telegramIDs, limit, offset)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении прошедших событий: %v", err)
+		http.Error(w, "Ошибка при получении событий", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []calendar.Event{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		logrus.Errorf("Ошибка API при сериализации прошедших событий в JSON: %v", err)
+	}
+}
+
+func (h *Handler) listArchivedTransactions(w http.ResponseWriter, r *http.Request, telegramIDs []int64) {
+	limit, offset, err := parseArchivePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	before := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+		parsed, parseErr := time.Parse("2006-01-02", beforeStr)
+		if parseErr != nil {
+			http.Error(w, "Некорректный формат даты 'before' (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		before = parsed
+	}
+
+	transactions, err := h.financeService.GetArchivedTransactions(r.Context(), telegramIDs, before, limit, offset)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении архивных транзакций: %v", err)
+		http.Error(w, "Ошибка при получении транзакций", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]TransactionResponse, 0, len(transactions))
+	for _, t := range transactions {
+		response = append(response, newTransactionResponse(t))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logrus.Errorf("Ошибка API при сериализации архивных транзакций в JSON: %v", err)
+	}
+}
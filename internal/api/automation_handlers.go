@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/automations"
+
+	"github.com/sirupsen/logrus"
+)
+
+func automationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, automations.ErrRuleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, automations.ErrUnknownCondition), errors.Is(err, automations.ErrUnknownAction),
+		errors.Is(err, automations.ErrInvalidRule), errors.Is(err, automations.ErrTooManyRules):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *Handler) AutomationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "AutomationRulesHandler")
+	if !ok {
+		return
+	}
+	telegramID := telegramIDs[0]
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := h.automations.GetRules(ctx, telegramID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении правил автоматизации пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении правил", http.StatusInternalServerError)
+			return
+		}
+		if rules == nil {
+			rules = make([]automations.Rule, 0)
+		}
+		writeOKRJSON(w, http.StatusOK, rules)
+	case http.MethodPost:
+		var rule automations.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+			return
+		}
+		rule.UserID = telegramID
+
+		created, err := h.automations.CreateRule(ctx, rule)
+		if err != nil {
+			logrus.Warnf("Ошибка API при создании правила автоматизации пользователя %d: %v", telegramID, err)
+			http.Error(w, err.Error(), automationErrorStatus(err))
+			return
+		}
+		writeOKRJSON(w, http.StatusCreated, created)
+	case http.MethodDelete:
+		ruleID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Необходимо указать id правила", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.automations.DeleteRule(ctx, telegramID, ruleID); err != nil {
+			http.Error(w, err.Error(), automationErrorStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) RunAutomationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "RunAutomationRulesHandler")
+	if !ok {
+		return
+	}
+
+	fired, err := h.automations.EvaluateRules(r.Context(), telegramIDs[0], nil)
+	if err != nil {
+		logrus.Errorf("Ошибка API при запуске правил автоматизации пользователя %d: %v", telegramIDs[0], err)
+		http.Error(w, "Ошибка при запуске правил", http.StatusInternalServerError)
+		return
+	}
+	if fired == nil {
+		fired = make([]automations.Rule, 0)
+	}
+
+	writeOKRJSON(w, http.StatusOK, map[string]interface{}{"fired": fired})
+}
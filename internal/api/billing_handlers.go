@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/stripe"
+	"telegrambot/internal/users"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const maxWebhookBodyBytes = 512 << 10
+
+type CheckoutSessionResponse struct {
+	SessionID	string	`json:"session_id"`
+	URL		string	`json:"url"`
+}
+
+type BillingStatusResponse struct {
+	Status			string		`json:"status"`
+	Active			bool		`json:"active"`
+	CurrentPeriodEnd	*time.Time	`json:"current_period_end,omitempty"`
+	TelegramLinked		bool		`json:"telegram_linked"`
+}
+
+func (h *Handler) CreateCheckoutSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.stripeClient.Configured() {
+		http.Error(w, "Оплата временно недоступна", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в CreateCheckoutSessionHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := h.userService.GetWebSubscription(ctx, webUserID)
+	if err != nil {
+		http.Error(w, "Ошибка при получении подписки", http.StatusInternalServerError)
+		return
+	}
+	if sub.IsActive() {
+		http.Error(w, "Подписка уже активна", http.StatusConflict)
+		return
+	}
+
+	customerID, email := "", ""
+	if sub != nil && sub.StripeCustomerID != nil {
+		customerID = *sub.StripeCustomerID
+	}
+	if webUser.Email != nil {
+		email = *webUser.Email
+	}
+
+	session, err := h.stripeClient.CreateCheckoutSession(ctx, webUserID, customerID, email)
+	if err != nil {
+		logrus.Errorf("Ошибка создания Stripe checkout для web_user %d: %v", webUserID, err)
+		http.Error(w, "Не удалось создать сессию оплаты", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(CheckoutSessionResponse{SessionID: session.ID, URL: session.URL}); err != nil {
+		logrus.Errorf("Ошибка API при сериализации сессии оплаты в JSON: %v", err)
+		http.Error(w, "Ошибка при формировании ответа", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) BillingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в BillingStatusHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := h.userService.GetWebSubscription(ctx, webUserID)
+	if err != nil {
+		http.Error(w, "Ошибка при получении подписки", http.StatusInternalServerError)
+		return
+	}
+
+	resp := BillingStatusResponse{Status: "none", TelegramLinked: len(webUser.TelegramIDs) > 0}
+	if sub != nil {
+		resp.Status = sub.Status
+		resp.Active = sub.IsActive()
+		resp.CurrentPeriodEnd = sub.CurrentPeriodEnd
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logrus.Errorf("Ошибка API при сериализации статуса подписки в JSON: %v", err)
+		http.Error(w, "Ошибка при формировании ответа", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) StripeWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		logrus.Errorf("Webhook Stripe отклонен: тело запроса превышает лимит %d байт", tooLarge.Limit)
+		http.Error(w, "Слишком большое тело запроса", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Не удалось прочитать тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.stripeClient.ConstructEvent(payload, r.Header.Get("Stripe-Signature"))
+	if err != nil {
+		logrus.Warnf("Отклонен webhook Stripe: %v", err)
+		http.Error(w, "Неверная подпись", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	switch event.Type {
+	case "checkout.session.completed":
+		var session stripe.CheckoutSession
+		if err = json.Unmarshal(event.Data.Object, &session); err != nil {
+			break
+		}
+		webUserID, parseErr := strconv.ParseInt(session.ClientReferenceID, 10, 64)
+		if parseErr != nil {
+			logrus.Warnf("Stripe checkout %s без корректного client_reference_id: %q", session.ID, session.ClientReferenceID)
+			break
+		}
+		err = h.userService.AttachStripeCheckout(ctx, webUserID, session.Customer, session.Subscription)
+
+	case "invoice.paid":
+		var invoice stripe.Invoice
+		if err = json.Unmarshal(event.Data.Object, &invoice); err != nil {
+			break
+		}
+		if invoice.Subscription == "" {
+			break
+		}
+		if webUserID, ok := invoice.WebUserID(); ok {
+			if err = h.userService.AttachStripeCheckout(ctx, webUserID, invoice.Customer, invoice.Subscription); err != nil {
+				break
+			}
+		}
+		_, err = h.userService.ActivateWebSubscription(ctx, invoice.Subscription, invoice.Customer, invoice.PeriodEnd())
+		if errors.Is(err, users.ErrSubscriptionNotFound) {
+			logrus.Warnf("Оплата %s по подписке %s пришла раньше checkout, ждем повторной доставки от Stripe", invoice.ID, invoice.Subscription)
+			http.Error(w, "Подписка еще не привязана к пользователю", http.StatusServiceUnavailable)
+			return
+		}
+
+	case "customer.subscription.deleted":
+		var subscription stripe.Subscription
+		if err = json.Unmarshal(event.Data.Object, &subscription); err != nil {
+			break
+		}
+		_, err = h.userService.CancelWebSubscription(ctx, subscription.ID, subscription.Customer)
+
+	default:
+		logrus.Debugf("Пропущено событие Stripe %s (%s)", event.Type, event.ID)
+	}
+
+	if err != nil {
+		if errors.Is(err, users.ErrSubscriptionNotFound) || errors.Is(err, users.ErrUserNotFound) {
+			logrus.Warnf("Событие Stripe %s (%s) не связано с пользователем: %v", event.Type, event.ID, err)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		logrus.Errorf("Ошибка обработки события Stripe %s (%s): %v", event.Type, event.ID, err)
+		http.Error(w, "Ошибка обработки события", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"telegrambot/internal/calendar"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CalendarFeedResponse struct {
+	URL string `json:"url"`
+}
+
+type CalDAVConnectRequest struct {
+	URL		string	`json:"url"`
+	Username	string	`json:"username"`
+	Password	string	`json:"password"`
+}
+
+type CalDAVConnectResponse struct {
+	Imported int `json:"imported"`
+}
+
+func (h *Handler) CalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.focusTelegramID(w, r, "CalendarFeedHandler")
+	if !ok {
+		return
+	}
+
+	var token string
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		token, err = h.calendarService.FeedToken(r.Context(), telegramID)
+	case http.MethodPost:
+		token, err = h.calendarService.RotateFeedToken(r.Context(), telegramID)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при получении ICS-ссылки пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при получении ссылки на календарь", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CalendarFeedResponse{URL: h.calendarService.FeedURL(token)})
+}
+
+func (h *Handler) CalDAVHandler(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.focusTelegramID(w, r, "CalDAVHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		account, err := h.calendarService.GetCalDAVAccount(ctx, telegramID)
+		if errors.Is(err, calendar.ErrCalDAVNotConnected) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка при получении CalDAV пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении календаря", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(account)
+	case http.MethodPost:
+		var req CalDAVConnectRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+		imported, err := h.calendarService.ConnectCalDAV(ctx, telegramID, req.URL, req.Username, req.Password)
+		if err != nil {
+			logrus.Warnf("Не удалось подключить CalDAV пользователя %d: %v", telegramID, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(CalDAVConnectResponse{Imported: imported})
+	case http.MethodDelete:
+		err := h.calendarService.DisconnectCalDAV(ctx, telegramID)
+		if errors.Is(err, calendar.ErrCalDAVNotConnected) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка при отключении CalDAV пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при отключении календаря", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
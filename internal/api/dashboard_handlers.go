@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"telegrambot/internal/dashboard"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) MonthlyDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "MonthlyDashboardHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	month, err := dashboard.ParseMonth(r.URL.Query().Get("month"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	redaction := dashboard.ParseRedaction(strings.Split(r.URL.Query().Get("redact"), ","))
+
+	card, err := h.dashboard.BuildMonthlyCard(ctx, telegramIDs[0], month)
+	if err != nil {
+		logrus.Errorf("Ошибка API при сборе карточки месяца пользователя %d: %v", telegramIDs[0], err)
+		http.Error(w, "Ошибка при сборе итогов месяца", http.StatusInternalServerError)
+		return
+	}
+
+	image, err := card.RenderPNG(redaction)
+	if err != nil {
+		logrus.Errorf("Ошибка API при отрисовке карточки месяца: %v", err)
+		http.Error(w, "Ошибка при отрисовке карточки", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Disposition", `inline; filename="dashboard-`+month.Format("2006-01")+`.png"`)
+	w.Write(image)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"telegrambot/internal/calendar"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EventGuestsRequest struct {
+	EventID	string		`json:"event_id"`
+	Emails	[]string	`json:"emails"`
+}
+
+func (h *Handler) EventGuestsHandler(w http.ResponseWriter, r *http.Request) {
+	telegramID, ok := h.focusTelegramID(w, r, "EventGuestsHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		eventID := r.URL.Query().Get("event_id")
+		if _, err := h.calendarService.GetEventByID(ctx, telegramID, eventID); err != nil {
+			http.Error(w, "Событие не найдено", http.StatusNotFound)
+			return
+		}
+		guests, err := h.calendarService.GetEventGuests(ctx, eventID)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении гостей события %s: %v", eventID, err)
+			http.Error(w, "Ошибка при получении гостей", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(guests)
+	case http.MethodPost:
+		var req EventGuestsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+		if req.EventID == "" || len(req.Emails) == 0 {
+			http.Error(w, "Обязательные поля: event_id, emails", http.StatusBadRequest)
+			return
+		}
+		guests, err := h.calendarService.AddEventGuests(ctx, telegramID, req.EventID, req.Emails)
+		if errors.Is(err, calendar.ErrInvalidGuestEmail) || errors.Is(err, calendar.ErrTooManyGuests) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logrus.Warnf("Не удалось добавить гостей к событию %s пользователя %d: %v", req.EventID, telegramID, err)
+			http.Error(w, "Событие не найдено", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(guests)
+	case http.MethodDelete:
+		err := h.calendarService.RemoveEventGuest(ctx, telegramID, r.URL.Query().Get("event_id"), r.URL.Query().Get("email"))
+		if errors.Is(err, calendar.ErrGuestNotInvited) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка при удалении гостя события пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при удалении гостя", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
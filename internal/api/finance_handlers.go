@@ -0,0 +1,327 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CreateTransactionRequest struct {
+	Amount		float64	`json:"amount"`
+	Details		string	`json:"details"`
+	Category	string	`json:"category"`
+	ObjectiveID	*string	`json:"objective_id"`
+	KeyResultID	*int64	`json:"key_result_id"`
+}
+
+type DeleteTransactionRequest struct {
+	TransactionID string `json:"transaction_id"`
+}
+
+type TransactionResponse struct {
+	ID		string		`json:"id"`
+	UserID		int64		`json:"user_id"`
+	Amount		float64		`json:"amount"`
+	Details		string		`json:"details"`
+	Category	string		`json:"category"`
+	SpaceID		*string		`json:"space_id,omitempty"`
+	ObjectiveID	*string		`json:"objective_id,omitempty"`
+	KeyResultID	*int64		`json:"key_result_id,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+}
+
+type FinanceSummaryResponse struct {
+	Period		string			`json:"period"`
+	Income		float64			`json:"income"`
+	Expenses	float64			`json:"expenses"`
+	Balance		float64			`json:"balance"`
+	Categories	map[string]float64	`json:"categories"`
+}
+
+func newTransactionResponse(t finance.Transaction) TransactionResponse {
+	return TransactionResponse{
+		ID:		t.ID,
+		UserID:		t.UserID,
+		Amount:		t.Amount,
+		Details:	t.Details,
+		Category:	t.Category,
+		SpaceID:	t.SpaceID,
+		ObjectiveID:	t.ObjectiveID,
+		KeyResultID:	t.KeyResultID,
+		CreatedAt:	t.CreatedAt,
+	}
+}
+
+func (h *Handler) GetTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в GetTransactionsHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil {
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+		return
+	}
+
+	if len(webUser.TelegramIDs) == 0 {
+		logrus.Infof("У web_user_id %d нет привязанных Telegram ID. Возвращаем пустой список транзакций.", webUserID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]TransactionResponse{})
+		return
+	}
+
+	if isArchiveRequest(r) {
+		h.listArchivedTransactions(w, r, webUser.TelegramIDs)
+		return
+	}
+
+	now := time.Now()
+	rangeStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	rangeEnd := now
+
+	startDateStr := r.URL.Query().Get("start_date")
+	endDateStr := r.URL.Query().Get("end_date")
+
+	if startDateStr != "" || endDateStr != "" {
+		if startDateStr == "" || endDateStr == "" {
+			http.Error(w, "Необходимо указать и 'start_date', и 'end_date'", http.StatusBadRequest)
+			return
+		}
+
+		parsedStartDate, parseErr := time.Parse("2006-01-02", startDateStr)
+		if parseErr != nil {
+			http.Error(w, "Некорректный формат начальной даты (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		parsedEndDate, parseErr := time.Parse("2006-01-02", endDateStr)
+		if parseErr != nil {
+			http.Error(w, "Некорректный формат конечной даты (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+
+		rangeStart = parsedStartDate
+		rangeEnd = parsedEndDate.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	transactions, err := h.financeService.GetTransactionsForUsers(ctx, webUser.TelegramIDs, rangeStart, rangeEnd)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении транзакций для web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении транзакций", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]TransactionResponse, 0, len(transactions))
+	for _, t := range transactions {
+		response = append(response, newTransactionResponse(t))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logrus.Errorf("Ошибка API при сериализации транзакций в JSON: %v", err)
+		http.Error(w, "Ошибка при формировании ответа", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) CreateTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в CreateTransactionHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		logrus.Warnf("Пользователь с ID %d не найден или не имеет привязанных Telegram аккаунтов", webUserID)
+		http.Error(w, "Для добавления транзакции требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if req.Amount == 0 {
+		http.Error(w, "Сумма транзакции обязательна и не может быть равна нулю", http.StatusBadRequest)
+		return
+	}
+
+	telegramID := webUser.TelegramIDs[0]
+
+	link := finance.GoalLink{ObjectiveID: req.ObjectiveID, KeyResultID: req.KeyResultID}
+	transactionID, err := h.financeService.AddGoalTransaction(ctx, telegramID, req.Amount, req.Details, req.Category, link)
+	if ownership.IsNotOwned(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при создании транзакции для пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при создании транзакции", http.StatusInternalServerError)
+		return
+	}
+
+	transaction, err := h.financeService.GetTransactionByID(ctx, telegramID, transactionID)
+	if err != nil {
+		logrus.Errorf("Транзакция создана, но ошибка при получении данных: %v", err)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": transactionID})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newTransactionResponse(*transaction))
+}
+
+func (h *Handler) DeleteTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в DeleteTransactionHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		logrus.Warnf("Пользователь с ID %d не найден или не имеет привязанных Telegram аккаунтов", webUserID)
+		http.Error(w, "Для удаления транзакции требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return
+	}
+
+	transactionID := r.URL.Query().Get("transaction_id")
+	if transactionID == "" {
+
+		var req DeleteTransactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TransactionID == "" {
+			http.Error(w, "ID транзакции обязателен", http.StatusBadRequest)
+			return
+		}
+		transactionID = req.TransactionID
+	}
+
+	var transactionFound bool
+	var telegramIDForTransaction int64
+
+	for _, telegramID := range webUser.TelegramIDs {
+		transaction, err := h.financeService.GetTransactionByID(ctx, telegramID, transactionID)
+		if err == nil && transaction != nil {
+			transactionFound = true
+			telegramIDForTransaction = telegramID
+			break
+		}
+	}
+
+	if !transactionFound {
+		http.Error(w, "Транзакция не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	err = h.financeService.DeleteTransaction(ctx, telegramIDForTransaction, transactionID)
+	if err != nil {
+		logrus.Errorf("Ошибка при удалении транзакции %s: %v", transactionID, err)
+		http.Error(w, "Ошибка при удалении транзакции", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+func (h *Handler) GetFinanceSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в GetFinanceSummaryHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		logrus.Warnf("Пользователь с ID %d не найден или не имеет привязанных Telegram аккаунтов", webUserID)
+		http.Error(w, "Для получения сводки требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "month"
+	}
+	if period != "day" && period != "week" && period != "month" && period != "year" {
+		http.Error(w, "Неверный период. Допустимые значения: day, week, month, year", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.financeService.GetSummaryForUsers(ctx, webUser.TelegramIDs, period)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении финансовой сводки для web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении финансовой сводки", http.StatusInternalServerError)
+		return
+	}
+
+	response := FinanceSummaryResponse{
+		Period:		period,
+		Income:		summary.Income,
+		Expenses:	summary.Expenses,
+		Balance:	summary.Balance,
+		Categories:	summary.Categories,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ReportDistractionsRequest struct {
+	Client	string			`json:"client"`
+	Events	[]focus.Distraction	`json:"events"`
+}
+
+type LogTimeRequest struct {
+	Minutes		int	`json:"minutes"`
+	Note		string	`json:"note"`
+	ObjectiveID	*string	`json:"objective_id"`
+	KeyResultID	*int64	`json:"key_result_id"`
+	TaskID		*int64	`json:"task_id"`
+	SpentOn		string	`json:"spent_on"`
+}
+
+type DistractionStatsResponse struct {
+	*focus.DistractionStats
+	Suggestions	[]string	`json:"suggestions"`
+}
+
+func (h *Handler) focusTelegramID(w http.ResponseWriter, r *http.Request, handlerName string) (int64, bool) {
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Errorf("Не удалось извлечь webUserID из контекста в %s", handlerName)
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return 0, false
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		http.Error(w, "Требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return webUser.TelegramIDs[0], true
+}
+
+func (h *Handler) ReportDistractionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramID, ok := h.focusTelegramID(w, r, "ReportDistractionsHandler")
+	if !ok {
+		return
+	}
+
+	var req ReportDistractionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.focusService.ReportDistractions(r.Context(), telegramID, req.Client, req.Events)
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении отвлечений пользователя %d: %v", telegramID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"saved": saved})
+}
+
+func (h *Handler) DistractionStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramID, ok := h.focusTelegramID(w, r, "DistractionStatsHandler")
+	if !ok {
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 {
+		days = 7
+	}
+
+	stats, err := h.focusService.GetDistractionStats(r.Context(), telegramID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logrus.Errorf("Ошибка при получении статистики отвлечений пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при получении статистики отвлечений", http.StatusInternalServerError)
+		return
+	}
+
+	suggestions := stats.SuggestAdjustments()
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DistractionStatsResponse{DistractionStats: stats, Suggestions: suggestions})
+}
+
+func (h *Handler) LogTimeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramID, ok := h.focusTelegramID(w, r, "LogTimeHandler")
+	if !ok {
+		return
+	}
+
+	var req LogTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	var spentOn time.Time
+	if req.SpentOn != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.SpentOn, time.Local)
+		if err != nil {
+			http.Error(w, "Некорректная дата, ожидается YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		spentOn = parsed
+	}
+
+	entry, err := h.focusService.LogTime(r.Context(), telegramID, req.Minutes, req.Note, req.ObjectiveID, req.KeyResultID, req.TaskID, spentOn)
+	if ownership.IsNotOwned(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при записи времени пользователя %d: %v", telegramID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *Handler) TimeAllocationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramID, ok := h.focusTelegramID(w, r, "TimeAllocationHandler")
+	if !ok {
+		return
+	}
+
+	weekStart := focus.WeekStart(time.Now())
+	if week := r.URL.Query().Get("week"); week != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", week, time.Local)
+		if err != nil {
+			http.Error(w, "Некорректная неделя, ожидается YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		weekStart = focus.WeekStart(parsed)
+	}
+
+	allocation, err := h.focusService.GetWeeklyAllocation(r.Context(), telegramID, weekStart)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении распределения времени пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при получении распределения времени", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(allocation)
+}
+
+func (h *Handler) PeakHoursHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramID, ok := h.focusTelegramID(w, r, "PeakHoursHandler")
+	if !ok {
+		return
+	}
+
+	var peak *focus.PeakHours
+	var err error
+	if r.Method == http.MethodPost {
+		peak, err = h.focusService.RecomputePeakHours(r.Context(), telegramID)
+	} else {
+		peak, err = h.focusService.GetPeakHours(r.Context(), telegramID)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при получении пиковых часов пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при получении пиковых часов", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(peak)
+}
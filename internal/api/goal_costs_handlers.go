@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LinkTransactionRequest struct {
+	TransactionID	string	`json:"transaction_id"`
+	ObjectiveID	*string	`json:"objective_id"`
+	KeyResultID	*int64	`json:"key_result_id"`
+}
+
+func (h *Handler) LinkTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "LinkTransactionHandler")
+	if !ok {
+		return
+	}
+
+	var req LinkTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TransactionID == "" {
+		http.Error(w, "ID транзакции обязателен", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	link := finance.GoalLink{ObjectiveID: req.ObjectiveID, KeyResultID: req.KeyResultID}
+	for _, telegramID := range telegramIDs {
+		err := h.financeService.LinkTransaction(ctx, telegramID, req.TransactionID, link)
+		if errors.Is(err, finance.ErrTransactionNotFound) {
+			continue
+		}
+		if ownership.IsNotOwned(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при привязке транзакции %s: %v", req.TransactionID, err)
+			http.Error(w, "Ошибка при привязке транзакции", http.StatusInternalServerError)
+			return
+		}
+
+		transaction, err := h.financeService.GetTransactionByID(ctx, telegramID, req.TransactionID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении транзакции %s: %v", req.TransactionID, err)
+			http.Error(w, "Ошибка при получении транзакции", http.StatusInternalServerError)
+			return
+		}
+		writeOKRJSON(w, http.StatusOK, newTransactionResponse(*transaction))
+		return
+	}
+
+	http.Error(w, finance.ErrTransactionNotFound.Error(), http.StatusNotFound)
+}
+
+func (h *Handler) QuarterRetrospectiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "QuarterRetrospectiveHandler")
+	if !ok {
+		return
+	}
+
+	quarter := r.URL.Query().Get("quarter")
+	if _, _, _, err := okr.ParseQuarter(quarter, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	var result *okr.QuarterRetrospective
+	for _, telegramID := range telegramIDs {
+		retro, err := h.okrService.GetQuarterRetrospective(ctx, telegramID, quarter)
+		if err != nil {
+			logrus.Errorf("Ошибка API при построении ретроспективы для пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при построении ретроспективы", http.StatusInternalServerError)
+			return
+		}
+		if result == nil {
+			result = retro
+			continue
+		}
+		result.Goals = append(result.Goals, retro.Goals...)
+		result.TotalInvested += retro.TotalInvested
+	}
+
+	writeOKRJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GoogleSyncStatusResponse struct {
+	Linked		bool		`json:"linked"`
+	NeedsRelink	bool		`json:"needs_relink"`
+	InvalidSince	*time.Time	`json:"invalid_since,omitempty"`
+	RelinkURL	string		`json:"relink_url,omitempty"`
+	LastSyncAt	*time.Time	`json:"last_sync_at,omitempty"`
+	LastError	*string		`json:"last_error,omitempty"`
+	LastErrorAt	*time.Time	`json:"last_error_at,omitempty"`
+	PushActive	bool		`json:"push_active"`
+	PendingPush	int		`json:"pending_push"`
+	PendingDeletes	int		`json:"pending_deletes"`
+}
+
+func (h *Handler) GoogleSyncStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	telegramID, ok := h.focusTelegramID(w, r, "GoogleSyncStatusHandler")
+	if !ok {
+		return
+	}
+
+	status, err := h.calendarService.GetSyncStatus(r.Context(), telegramID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении состояния синхронизации Google пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при получении состояния синхронизации", http.StatusInternalServerError)
+		return
+	}
+
+	response := GoogleSyncStatusResponse{
+		Linked:		status.Linked,
+		NeedsRelink:	status.NeedsRelink,
+		InvalidSince:	status.InvalidSince,
+		LastSyncAt:	status.LastSyncAt,
+		LastError:	status.LastError,
+		LastErrorAt:	status.LastErrorAt,
+		PushActive:	status.PushActive,
+		PendingPush:	status.PendingPush,
+		PendingDeletes:	status.PendingDeletes,
+	}
+	if status.NeedsRelink {
+		authURL, err := h.calendarService.GetGoogleAuthURL(telegramID, "web")
+		if err != nil {
+			logrus.Warnf("Не удалось создать ссылку переподключения Google для пользователя %d: %v", telegramID, err)
+		} else {
+			response.RelinkURL = authURL
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -7,10 +7,24 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"telegrambot/internal/admin"
+	"telegrambot/internal/ai_coach"
 	"telegrambot/internal/auth"
+	"telegrambot/internal/automations"
 	"telegrambot/internal/calendar"
+	"telegrambot/internal/dashboard"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/health"
+	"telegrambot/internal/integrations"
 	"telegrambot/internal/linking"
+	"telegrambot/internal/maintenance"
+	"telegrambot/internal/meetings"
+	"telegrambot/internal/notifications"
 	"telegrambot/internal/okr"
+	"telegrambot/internal/rollout"
+	"telegrambot/internal/semantic"
+	"telegrambot/internal/stripe"
 	"telegrambot/internal/users"
 	"time"
 
@@ -23,6 +37,20 @@ type Handler struct {
 	userService	*users.Service
 	linkingService	*linking.Service
 	okrService	*okr.Service
+	financeService	*finance.Service
+	meetingsService	*meetings.Service
+	healthService	*health.Service
+	focusService	*focus.Service
+	automations	*automations.Service
+	integrations	*integrations.Service
+	rollout		*rollout.Service
+	adminService	*admin.Service
+	stripeClient	*stripe.Client
+	dashboard	*dashboard.Service
+	semantic	*semantic.Service
+	maintenance	*maintenance.Service
+	notifications	*notifications.Service
+	predictions	*ai_coach.PredictionService
 	db		*sqlx.DB
 	jwtSigningKey	string
 	telegramBotName	string
@@ -33,6 +61,20 @@ func NewHandler(
 	userService *users.Service,
 	linkService *linking.Service,
 	okrService *okr.Service,
+	financeService *finance.Service,
+	meetingsService *meetings.Service,
+	healthService *health.Service,
+	focusService *focus.Service,
+	automationsService *automations.Service,
+	integrationsService *integrations.Service,
+	rolloutService *rollout.Service,
+	adminService *admin.Service,
+	stripeClient *stripe.Client,
+	dashboardService *dashboard.Service,
+	semanticService *semantic.Service,
+	maintenanceService *maintenance.Service,
+	notificationsService *notifications.Service,
+	predictionService *ai_coach.PredictionService,
 	database *sqlx.DB,
 	jwtKey string,
 	tgBotName string,
@@ -42,6 +84,20 @@ func NewHandler(
 		userService:		userService,
 		linkingService:		linkService,
 		okrService:		okrService,
+		financeService:		financeService,
+		meetingsService:	meetingsService,
+		healthService:		healthService,
+		focusService:		focusService,
+		automations:		automationsService,
+		integrations:		integrationsService,
+		rollout:		rolloutService,
+		adminService:		adminService,
+		stripeClient:		stripeClient,
+		dashboard:		dashboardService,
+		semantic:		semanticService,
+		maintenance:		maintenanceService,
+		notifications:		notificationsService,
+		predictions:		predictionService,
 		db:			database,
 		jwtSigningKey:		jwtKey,
 		telegramBotName:	tgBotName,
@@ -187,6 +243,11 @@ func (h *Handler) GetCalendarEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isArchiveRequest(r) {
+		h.listPastEvents(w, r, webUser.TelegramIDs)
+		return
+	}
+
 	dateStr := r.URL.Query().Get("date")
 	startDateStr := r.URL.Query().Get("start_date")
 	endDateStr := r.URL.Query().Get("end_date")
@@ -250,6 +311,9 @@ func (h *Handler) GetCalendarEvents(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Ошибка при получении событий", http.StatusInternalServerError)
 		return
 	}
+	if err := h.calendarService.AttachGuests(ctx, events); err != nil {
+		logrus.Warnf("Не удалось получить гостей событий: %v", err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(events); err != nil {
@@ -295,10 +359,17 @@ func (h *Handler) GenerateTelegramLinkHandler(w http.ResponseWriter, r *http.Req
 }
 
 type CreateEventRequest struct {
-	Title		string	`json:"title"`
-	Description	string	`json:"description"`
-	StartTime	string	`json:"start_time"`
-	EndTime		string	`json:"end_time"`
+	Title		string		`json:"title"`
+	Description	string		`json:"description"`
+	StartTime	string		`json:"start_time"`
+	EndTime		string		`json:"end_time"`
+	AllowOverlap	bool		`json:"allow_overlap"`
+	Guests		[]string	`json:"guests,omitempty"`
+}
+
+type ScheduleConflictResponse struct {
+	Error		string			`json:"error"`
+	Conflicts	[]calendar.BusySlot	`json:"conflicts"`
 }
 
 type UpdateEventRequest struct {
@@ -314,12 +385,13 @@ type DeleteEventRequest struct {
 }
 
 type EventResponse struct {
-	ID		string		`json:"id"`
-	Title		string		`json:"title"`
-	Description	string		`json:"description"`
-	StartTime	time.Time	`json:"start_time"`
-	EndTime		time.Time	`json:"end_time"`
-	CreatedAt	time.Time	`json:"created_at"`
+	ID		string			`json:"id"`
+	Title		string			`json:"title"`
+	Description	string			`json:"description"`
+	StartTime	time.Time		`json:"start_time"`
+	EndTime		time.Time		`json:"end_time"`
+	CreatedAt	time.Time		`json:"created_at"`
+	Guests		[]calendar.EventGuest	`json:"guests,omitempty"`
 }
 
 func (h *Handler) CreateCalendarEventHandler(w http.ResponseWriter, r *http.Request) {
@@ -361,13 +433,29 @@ func (h *Handler) CreateCalendarEventHandler(w http.ResponseWriter, r *http.Requ
 
 	telegramID := webUser.TelegramIDs[0]
 
-	eventID, err := h.calendarService.CreateEvent(ctx, telegramID, req.Title, req.Description, req.StartTime, req.EndTime)
+	var eventID string
+	if req.AllowOverlap {
+		eventID, err = h.calendarService.CreateEvent(ctx, telegramID, req.Title, req.Description, req.StartTime, req.EndTime)
+	} else {
+		eventID, err = h.calendarService.CreateEventIfFree(ctx, telegramID, req.Title, req.Description, req.StartTime, req.EndTime)
+	}
+	if writeScheduleConflict(w, err) {
+		return
+	}
 	if err != nil {
 		logrus.Errorf("Ошибка при создании события для пользователя %d: %v", telegramID, err)
 		http.Error(w, "Ошибка при создании события", http.StatusInternalServerError)
 		return
 	}
 
+	var guests []calendar.EventGuest
+	if len(req.Guests) > 0 {
+		guests, err = h.calendarService.AddEventGuests(ctx, telegramID, eventID, req.Guests)
+		if err != nil {
+			logrus.Warnf("Событие %s создано, но гости не добавлены: %v", eventID, err)
+		}
+	}
+
 	createdEvent, err := h.calendarService.GetEventByID(ctx, telegramID, eventID)
 	if err != nil {
 		logrus.Errorf("Событие создано, но ошибка при получении данных: %v", err)
@@ -386,7 +474,22 @@ func (h *Handler) CreateCalendarEventHandler(w http.ResponseWriter, r *http.Requ
 		StartTime:	createdEvent.StartTime,
 		EndTime:	createdEvent.EndTime,
 		CreatedAt:	createdEvent.CreatedAt,
+		Guests:		guests,
+	})
+}
+
+func writeScheduleConflict(w http.ResponseWriter, err error) bool {
+	var conflictErr *calendar.ConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(ScheduleConflictResponse{
+		Error:		conflictErr.Error() + ". Перенесите время или повторите запрос с allow_overlap=true",
+		Conflicts:	conflictErr.Conflicts,
 	})
+	return true
 }
 
 func (h *Handler) UpdateCalendarEventHandler(w http.ResponseWriter, r *http.Request) {
@@ -431,20 +534,16 @@ func (h *Handler) UpdateCalendarEventHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var foundEvent *calendar.Event
-	var telegramIDForEvent int64
-
-	for _, telegramID := range webUser.TelegramIDs {
-		event, err := h.calendarService.GetEventByID(ctx, telegramID, req.EventID)
-		if err == nil && event != nil {
-			foundEvent = event
-			telegramIDForEvent = telegramID
-			break
-		}
+	telegramIDForEvent, found := h.findEventOwner(ctx, webUser.TelegramIDs, req.EventID)
+	if !found {
+		http.Error(w, "Событие не найдено или не принадлежит пользователю", http.StatusNotFound)
+		return
 	}
 
-	if foundEvent == nil {
-		http.Error(w, "Событие не найдено или не принадлежит пользователю", http.StatusNotFound)
+	foundEvent, err := h.calendarService.GetEventByID(ctx, telegramIDForEvent, req.EventID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении события %s: %v", req.EventID, err)
+		http.Error(w, "Ошибка при получении события", http.StatusInternalServerError)
 		return
 	}
 
@@ -530,19 +629,8 @@ func (h *Handler) DeleteCalendarEventHandler(w http.ResponseWriter, r *http.Requ
 		eventID = req.EventID
 	}
 
-	var eventFound bool
-	var telegramIDForEvent int64
-
-	for _, telegramID := range webUser.TelegramIDs {
-		event, err := h.calendarService.GetEventByID(ctx, telegramID, eventID)
-		if err == nil && event != nil {
-			eventFound = true
-			telegramIDForEvent = telegramID
-			break
-		}
-	}
-
-	if !eventFound {
+	telegramIDForEvent, found := h.findEventOwner(ctx, webUser.TelegramIDs, eventID)
+	if !found {
 		http.Error(w, "Событие не найдено или не принадлежит пользователю", http.StatusNotFound)
 		return
 	}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/health"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LogSleepRequest struct {
+	BedTime		time.Time	`json:"bed_time"`
+	WakeTime	time.Time	`json:"wake_time"`
+	Quality		int		`json:"quality,omitempty"`
+	Source		string		`json:"source,omitempty"`
+}
+
+func (h *Handler) SleepHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в SleepHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		http.Error(w, "Для дневника сна требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return
+	}
+
+	telegramID := webUser.TelegramIDs[0]
+
+	if r.Method == http.MethodGet {
+		days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+		if days <= 0 {
+			days = 14
+		}
+
+		logs, err := h.healthService.GetSleepLogs(ctx, telegramID, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			logrus.Errorf("Ошибка при получении сна пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении данных о сне", http.StatusInternalServerError)
+			return
+		}
+		if logs == nil {
+			logs = []health.SleepLog{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logs)
+		return
+	}
+
+	var req LogSleepRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса: ожидаются bed_time и wake_time в формате RFC3339", http.StatusBadRequest)
+		return
+	}
+	if req.Source == "" {
+		req.Source = health.SleepSourceIntegration
+	}
+
+	log, err := h.healthService.LogSleep(ctx, telegramID, req.BedTime, req.WakeTime, req.Quality, req.Source)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(log)
+}
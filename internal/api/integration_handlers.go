@@ -0,0 +1,319 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/integrations"
+	"telegrambot/internal/users"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+type CreateAPIKeyResponse struct {
+	Key	string		`json:"key"`
+	APIKey	*users.APIKey	`json:"api_key"`
+}
+
+type SubscribeWebhookRequest struct {
+	Event		string	`json:"event"`
+	TargetURL	string	`json:"target_url"`
+}
+
+type IntegrationTaskRequest struct {
+	KeyResultID	int64	`json:"key_result_id"`
+	KeyResult	string	`json:"key_result"`
+	Objective	string	`json:"objective"`
+	Title		string	`json:"title"`
+	Target		float64	`json:"target"`
+	Unit		string	`json:"unit"`
+	Deadline	*string	`json:"deadline"`
+}
+
+type IntegrationProgressRequest struct {
+	KeyResultID	int64	`json:"key_result_id"`
+	KeyResult	string	`json:"key_result"`
+	Objective	string	`json:"objective"`
+	Amount		float64	`json:"amount"`
+}
+
+func (h *Handler) APIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := h.userService.ListAPIKeys(ctx, webUserID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении API-ключей web_user %d: %v", webUserID, err)
+			http.Error(w, "Ошибка при получении API-ключей", http.StatusInternalServerError)
+			return
+		}
+		if keys == nil {
+			keys = make([]users.APIKey, 0)
+		}
+		writeOKRJSON(w, http.StatusOK, keys)
+	case http.MethodPost:
+		var req CreateAPIKeyRequest
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+				return
+			}
+		}
+
+		plain, key, err := h.userService.CreateAPIKey(ctx, webUserID, req.Name)
+		if err != nil {
+			logrus.Errorf("Ошибка API при создании API-ключа web_user %d: %v", webUserID, err)
+			http.Error(w, "Ошибка при создании API-ключа", http.StatusInternalServerError)
+			return
+		}
+		writeOKRJSON(w, http.StatusCreated, CreateAPIKeyResponse{Key: plain, APIKey: key})
+	case http.MethodDelete:
+		keyID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Необходимо указать id ключа", http.StatusBadRequest)
+			return
+		}
+		if err := h.userService.RevokeAPIKey(ctx, webUserID, keyID); err != nil {
+			if errors.Is(err, users.ErrAPIKeyNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Ошибка при отзыве API-ключа", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) IntegrationMeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webUserID, _ := auth.GetUserIDFromContext(ctx)
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil || webUser == nil {
+		http.Error(w, "Пользователь не найден", http.StatusUnauthorized)
+		return
+	}
+	writeOKRJSON(w, http.StatusOK, map[string]interface{}{"id": webUser.ID, "login": webUser.Login})
+}
+
+func parsePollParams(r *http.Request) (time.Time, int) {
+	since := time.Now().AddDate(0, 0, -30)
+	if v := r.URL.Query().Get("since"); v != "" {
+		if parsed, err := time.Parse(time.RFC3339, v); err == nil {
+			since = parsed
+		}
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	return since, limit
+}
+
+func (h *Handler) IntegrationTriggerHandler(event string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+			return
+		}
+		telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "IntegrationTriggerHandler")
+		if !ok {
+			return
+		}
+		ctx := r.Context()
+		since, limit := parsePollParams(r)
+
+		var (
+			items	interface{}
+			err	error
+		)
+		switch event {
+		case integrations.EventObjectiveCreated:
+			items, err = h.integrations.NewObjectives(ctx, telegramIDs, since, limit)
+		case integrations.EventTaskCompleted:
+			items, err = h.integrations.CompletedTasks(ctx, telegramIDs, since, limit)
+		case integrations.EventTransactionCreated:
+			items, err = h.integrations.NewTransactions(ctx, telegramIDs, since, limit)
+		default:
+			err = integrations.ErrUnknownEvent
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при опросе триггера %s: %v", event, err)
+			http.Error(w, "Ошибка при получении событий", http.StatusInternalServerError)
+			return
+		}
+
+		writeOKRJSON(w, http.StatusOK, items)
+	}
+}
+
+func (h *Handler) IntegrationHooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webUserID, _ := auth.GetUserIDFromContext(ctx)
+
+	switch r.Method {
+	case http.MethodPost:
+		var req SubscribeWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+			return
+		}
+
+		hook, err := h.integrations.Subscribe(ctx, webUserID, req.Event, req.TargetURL)
+		if err != nil {
+			if errors.Is(err, integrations.ErrUnknownEvent) || errors.Is(err, integrations.ErrInvalidTargetURL) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logrus.Errorf("Ошибка API при подписке на %s: %v", req.Event, err)
+			http.Error(w, "Ошибка при создании подписки", http.StatusInternalServerError)
+			return
+		}
+		writeOKRJSON(w, http.StatusCreated, hook)
+	case http.MethodDelete:
+		hookID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Необходимо указать id подписки", http.StatusBadRequest)
+			return
+		}
+		if err := h.integrations.Unsubscribe(ctx, webUserID, hookID); err != nil {
+			if errors.Is(err, integrations.ErrWebhookNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Ошибка при удалении подписки", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) resolveIntegrationKeyResult(w http.ResponseWriter, r *http.Request, telegramIDs []int64, keyResultID int64, keyResult, objective string) (int64, int64, bool) {
+	ctx := r.Context()
+
+	if keyResultID != 0 {
+		ownerID, found := h.findKeyResultOwner(ctx, telegramIDs, keyResultID)
+		if !found {
+			http.Error(w, "Ключевой результат не найден", http.StatusNotFound)
+			return 0, 0, false
+		}
+		return ownerID, keyResultID, true
+	}
+
+	if strings.TrimSpace(keyResult) == "" {
+		http.Error(w, "Необходимо указать key_result_id или key_result", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	for _, telegramID := range telegramIDs {
+		keyResults, err := h.okrService.FindKeyResultByDescription(ctx, telegramID, keyResult, objective)
+		if err == nil && len(keyResults) > 0 {
+			return telegramID, keyResults[0].ID, true
+		}
+	}
+
+	http.Error(w, "Ключевой результат не найден", http.StatusNotFound)
+	return 0, 0, false
+}
+
+func (h *Handler) IntegrationCreateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "IntegrationCreateTaskHandler")
+	if !ok {
+		return
+	}
+
+	var req IntegrationTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Title) == "" {
+		http.Error(w, "Необходимо указать title задачи", http.StatusBadRequest)
+		return
+	}
+	if req.Target <= 0 {
+		req.Target = 1
+	}
+	if req.Unit == "" {
+		req.Unit = "раз"
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ownerID, keyResultID, ok := h.resolveIntegrationKeyResult(w, r, telegramIDs, req.KeyResultID, req.KeyResult, req.Objective)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	taskID, err := h.okrService.CreateTask(ctx, ownerID, keyResultID, req.Title, req.Target, req.Unit, deadline)
+	if err != nil {
+		logrus.Errorf("Ошибка API интеграции при создании задачи: %v", err)
+		http.Error(w, "Ошибка при создании задачи", http.StatusInternalServerError)
+		return
+	}
+
+	task, err := h.okrService.GetTaskByID(ctx, ownerID, taskID)
+	if err != nil {
+		writeOKRJSON(w, http.StatusCreated, map[string]interface{}{"id": taskID})
+		return
+	}
+	writeOKRJSON(w, http.StatusCreated, newTaskResponse(*task))
+}
+
+func (h *Handler) IntegrationLogProgressHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "IntegrationLogProgressHandler")
+	if !ok {
+		return
+	}
+
+	var req IntegrationProgressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount == 0 {
+		http.Error(w, "Необходимо указать amount", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, keyResultID, ok := h.resolveIntegrationKeyResult(w, r, telegramIDs, req.KeyResultID, req.KeyResult, req.Objective)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	exceeded, err := h.okrService.UpdateKeyResultProgress(ctx, ownerID, keyResultID, req.Amount)
+	if err != nil {
+		logrus.Errorf("Ошибка API интеграции при записи прогресса: %v", err)
+		http.Error(w, "Ошибка при записи прогресса", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"key_result_id": keyResultID, "exceeded": exceeded}
+	if keyResult, err := h.okrService.GetKeyResultByID(ctx, ownerID, keyResultID); err == nil {
+		response["progress"] = keyResult.Progress
+		response["target"] = keyResult.Target
+	}
+	writeOKRJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/meetings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CreateMeetingRequest struct {
+	ParticipantUsername	string	`json:"participant_username"`
+	Title			string	`json:"title"`
+	Description		string	`json:"description"`
+	StartTime		string	`json:"start_time"`
+	EndTime			string	`json:"end_time"`
+	InPerson		bool	`json:"in_person"`
+	AllowOverlap		bool	`json:"allow_overlap"`
+}
+
+type RespondMeetingRequest struct {
+	MeetingID	string	`json:"meeting_id"`
+	Accept		bool	`json:"accept"`
+}
+
+type MeetingResponse struct {
+	ID			string		`json:"id"`
+	InitiatorID		int64		`json:"initiator_id"`
+	InitiatorName		string		`json:"initiator_name"`
+	ParticipantID		int64		`json:"participant_id"`
+	ParticipantName		string		`json:"participant_name"`
+	Title			string		`json:"title"`
+	Description		string		`json:"description"`
+	StartTime		time.Time	`json:"start_time"`
+	EndTime			time.Time	`json:"end_time"`
+	Status			string		`json:"status"`
+	InPerson		bool		`json:"in_person"`
+	Incoming		bool		`json:"incoming"`
+	RespondedAt		*time.Time	`json:"responded_at,omitempty"`
+	CreatedAt		time.Time	`json:"created_at"`
+}
+
+func newMeetingResponse(m meetings.MeetingDetails, telegramIDs []int64) MeetingResponse {
+	incoming := false
+	for _, id := range telegramIDs {
+		if m.ParticipantID == id {
+			incoming = true
+			break
+		}
+	}
+
+	return MeetingResponse{
+		ID:			m.ID,
+		InitiatorID:		m.InitiatorID,
+		InitiatorName:		m.InitiatorName,
+		ParticipantID:		m.ParticipantID,
+		ParticipantName:	m.ParticipantName,
+		Title:			m.Title,
+		Description:		m.Description,
+		StartTime:		m.StartTime,
+		EndTime:		m.EndTime,
+		Status:			m.Status,
+		InPerson:		m.InPerson,
+		Incoming:		incoming,
+		RespondedAt:		m.RespondedAt,
+		CreatedAt:		m.CreatedAt,
+	}
+}
+
+func (h *Handler) GetMeetingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в GetMeetingsHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil {
+		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+		return
+	}
+
+	if len(webUser.TelegramIDs) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]MeetingResponse{})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "pending" && status != "accepted" && status != "declined" {
+		http.Error(w, "Неверный статус. Допустимые значения: pending, accepted, declined", http.StatusBadRequest)
+		return
+	}
+
+	meetingList, err := h.meetingsService.GetMeetingsForUsers(ctx, webUser.TelegramIDs, status)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении встреч для web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении встреч", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]MeetingResponse, 0, len(meetingList))
+	for _, m := range meetingList {
+		response = append(response, newMeetingResponse(m, webUser.TelegramIDs))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logrus.Errorf("Ошибка API при сериализации встреч в JSON: %v", err)
+		http.Error(w, "Ошибка при формировании ответа", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) CreateMeetingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в CreateMeetingHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		logrus.Warnf("Пользователь с ID %d не найден или не имеет привязанных Telegram аккаунтов", webUserID)
+		http.Error(w, "Для создания встречи требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateMeetingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if req.ParticipantUsername == "" || req.Title == "" || req.StartTime == "" || req.EndTime == "" {
+		http.Error(w, "Обязательные поля: participant_username, title, start_time, end_time", http.StatusBadRequest)
+		return
+	}
+
+	telegramID := webUser.TelegramIDs[0]
+
+	meetingID, err := h.meetingsService.CreateMeeting(ctx, telegramID, req.ParticipantUsername, req.Title, req.Description, req.StartTime, req.EndTime, req.InPerson, req.AllowOverlap)
+	if writeScheduleConflict(w, err) {
+		return
+	}
+	if errors.Is(err, meetings.ErrParticipantBusy) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при создании встречи для пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при создании встречи: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	meeting, err := h.meetingsService.GetMeetingByID(ctx, meetingID)
+	if err != nil {
+		logrus.Errorf("Встреча создана, но ошибка при получении данных: %v", err)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"id": meetingID})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newMeetingResponse(*meeting, webUser.TelegramIDs))
+}
+
+func (h *Handler) RespondMeetingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Error("Не удалось извлечь webUserID из контекста в RespondMeetingHandler")
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		logrus.Warnf("Пользователь с ID %d не найден или не имеет привязанных Telegram аккаунтов", webUserID)
+		http.Error(w, "Для ответа на приглашение требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return
+	}
+
+	var req RespondMeetingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MeetingID == "" {
+		http.Error(w, "ID встречи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	meeting, err := h.meetingsService.GetMeetingByID(ctx, req.MeetingID)
+	if err != nil {
+		http.Error(w, "Встреча не найдена", http.StatusNotFound)
+		return
+	}
+
+	var participantID int64
+	for _, telegramID := range webUser.TelegramIDs {
+		if telegramID == meeting.ParticipantID {
+			participantID = telegramID
+			break
+		}
+	}
+
+	if participantID == 0 {
+		http.Error(w, "Вы не являетесь участником этой встречи", http.StatusForbidden)
+		return
+	}
+
+	updated, err := h.meetingsService.RespondToMeeting(ctx, req.MeetingID, participantID, req.Accept)
+	if err != nil {
+		if errors.Is(err, meetings.ErrAlreadyResponded) {
+			http.Error(w, "На приглашение уже дан ответ", http.StatusConflict)
+			return
+		}
+		logrus.Errorf("Ошибка при ответе на встречу %s: %v", req.MeetingID, err)
+		http.Error(w, "Ошибка при ответе на приглашение", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newMeetingResponse(*updated, webUser.TelegramIDs))
+}
+
+type AvailabilitySlot struct {
+	StartTime	time.Time	`json:"start_time"`
+	EndTime		time.Time	`json:"end_time"`
+}
+
+type AvailabilityResponse struct {
+	ParticipantUsername	string			`json:"participant_username"`
+	DurationMinutes		int			`json:"duration_minutes"`
+	Slots			[]AvailabilitySlot	`json:"slots"`
+}
+
+func (h *Handler) MeetingAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramID, ok := h.focusTelegramID(w, r, "MeetingAvailabilityHandler")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	username := query.Get("username")
+	if username == "" {
+		http.Error(w, "Обязательный параметр: username", http.StatusBadRequest)
+		return
+	}
+
+	req := meetings.AvailabilityRequest{From: time.Now(), IncludeWeekends: query.Get("include_weekends") == "true"}
+	if fromStr := query.Get("from"); fromStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			http.Error(w, "Некорректный формат from (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		req.From = day
+	}
+	req.To = req.From.AddDate(0, 0, 7)
+	if toStr := query.Get("to"); toStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			http.Error(w, "Некорректный формат to (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		req.To = day.AddDate(0, 0, 1)
+	}
+	if durationStr := query.Get("duration"); durationStr != "" {
+		minutes, err := strconv.Atoi(durationStr)
+		if err != nil || minutes <= 0 {
+			http.Error(w, "Некорректная длительность (в минутах)", http.StatusBadRequest)
+			return
+		}
+		req.Duration = time.Duration(minutes) * time.Minute
+	}
+	var err error
+	if req.WorkFrom, err = parseClockParam(query.Get("work_from"), 9*time.Hour); err != nil {
+		http.Error(w, "Некорректный формат work_from (ожидается HH:MM)", http.StatusBadRequest)
+		return
+	}
+	if req.WorkTo, err = parseClockParam(query.Get("work_to"), 18*time.Hour); err != nil {
+		http.Error(w, "Некорректный формат work_to (ожидается HH:MM)", http.StatusBadRequest)
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		req.Limit, _ = strconv.Atoi(limitStr)
+	}
+
+	availability, err := h.meetingsService.FindMutualAvailability(r.Context(), telegramID, username, req)
+	switch {
+	case errors.Is(err, meetings.ErrParticipantNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, meetings.ErrSelfAvailability), errors.Is(err, meetings.ErrInvalidWorkingHours), errors.Is(err, meetings.ErrInvalidSearchRange):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		logrus.Errorf("Ошибка при поиске общего времени для пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при поиске свободного времени", http.StatusInternalServerError)
+		return
+	}
+
+	response := AvailabilityResponse{
+		ParticipantUsername:	availability.Participant.Username,
+		DurationMinutes:	int(availability.Duration.Minutes()),
+		Slots:			make([]AvailabilitySlot, 0, len(availability.Slots)),
+	}
+	for _, slot := range availability.Slots {
+		response.Slots = append(response.Slots, AvailabilitySlot{StartTime: slot, EndTime: slot.Add(availability.Duration)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func parseClockParam(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
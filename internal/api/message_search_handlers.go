@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/semantic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) SearchMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "SearchMessagesHandler")
+	if !ok {
+		return
+	}
+
+	opts := semantic.SearchOptions{}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Некорректный параметр limit", http.StatusBadRequest)
+			return
+		}
+		opts.Limit = limit
+	}
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			http.Error(w, "Некорректный формат даты 'from' (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		opts.From = &from
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			http.Error(w, "Некорректный формат даты 'to' (ожидается YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		opts.To = &to
+	}
+
+	matches, err := h.semantic.Search(r.Context(), telegramIDs, r.URL.Query().Get("q"), opts)
+	if errors.Is(err, semantic.ErrEmptyQuery) {
+		http.Error(w, "Необходимо указать параметр 'q'", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при поиске по истории сообщений: %v", err)
+		http.Error(w, "Ошибка при поиске по истории сообщений", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, matches)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"telegrambot/internal/notifications"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationsResponse struct {
+	Items		[]notifications.Notification	`json:"items"`
+	UnreadCount	int				`json:"unread_count"`
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs	[]int64	`json:"ids"`
+	All	bool	`json:"all"`
+}
+
+func (h *Handler) NotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "NotificationsHandler")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, offset, err := parseArchivePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := notifications.LogFilter{Limit: limit, Offset: offset}
+	if value := query.Get("kind"); value != "" {
+		for _, kind := range strings.Split(value, ",") {
+			filter.Kinds = append(filter.Kinds, strings.TrimSpace(kind))
+		}
+	}
+	filter.UnreadOnly, _ = strconv.ParseBool(query.Get("unread"))
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Некорректный формат 'since' (ожидается RFC3339)", http.StatusBadRequest)
+			return
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Некорректный формат 'until' (ожидается RFC3339)", http.StatusBadRequest)
+			return
+		}
+		filter.Until = &until
+	}
+
+	items, err := h.notifications.List(r.Context(), telegramIDs, filter)
+	if errors.Is(err, notifications.ErrUnknownKind) {
+		http.Error(w, "kind должен быть одним из: "+strings.Join(notifications.Kinds, ", "), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении уведомлений: %v", err)
+		http.Error(w, "Ошибка при получении уведомлений", http.StatusInternalServerError)
+		return
+	}
+	unread, err := h.notifications.CountUnread(r.Context(), telegramIDs)
+	if err != nil {
+		logrus.Errorf("Ошибка API при подсчете непрочитанных уведомлений: %v", err)
+		http.Error(w, "Ошибка при получении уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, NotificationsResponse{Items: items, UnreadCount: unread})
+}
+
+func (h *Handler) MarkNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "MarkNotificationsReadHandler")
+	if !ok {
+		return
+	}
+
+	var req MarkNotificationsReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (len(req.IDs) == 0 && !req.All) {
+		http.Error(w, "Необходимо указать ids уведомлений или all: true", http.StatusBadRequest)
+		return
+	}
+	if req.All {
+		req.IDs = nil
+	}
+
+	marked, err := h.notifications.MarkRead(r.Context(), telegramIDs, req.IDs)
+	if err != nil {
+		logrus.Errorf("Ошибка API при отметке уведомлений прочитанными: %v", err)
+		http.Error(w, "Ошибка при отметке уведомлений", http.StatusInternalServerError)
+		return
+	}
+	unread, err := h.notifications.CountUnread(r.Context(), telegramIDs)
+	if err != nil {
+		logrus.Errorf("Ошибка API при подсчете непрочитанных уведомлений: %v", err)
+		http.Error(w, "Ошибка при отметке уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, map[string]interface{}{"marked": marked, "unread_count": unread})
+}
+
+type NotificationSettingsResponse struct {
+	QuietHours	string		`json:"quiet_hours"`
+	MutedKinds	[]string	`json:"muted_kinds"`
+	Kinds		[]string	`json:"kinds"`
+}
+
+type UpdateNotificationSettingsRequest struct {
+	QuietHours	*string		`json:"quiet_hours"`
+	MutedKinds	*[]string	`json:"muted_kinds"`
+}
+
+func (h *Handler) NotificationSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "NotificationSettingsHandler")
+	if !ok {
+		return
+	}
+
+	settings, err := h.notifications.GetSettings(r.Context(), telegramIDs[0])
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении настроек уведомлений: %v", err)
+		http.Error(w, "Ошибка при получении настроек уведомлений", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var req UpdateNotificationSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректный формат запроса", http.StatusBadRequest)
+			return
+		}
+		if req.QuietHours != nil {
+			if *req.QuietHours == "" {
+				settings.QuietStart, settings.QuietEnd = nil, nil
+			} else {
+				start, end, err := notifications.ParseQuietHours(*req.QuietHours)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				settings.QuietStart, settings.QuietEnd = &start, &end
+			}
+		}
+		if req.MutedKinds != nil {
+			settings.MutedKinds = *req.MutedKinds
+		}
+
+		err := h.notifications.SaveSettings(r.Context(), settings)
+		if errors.Is(err, notifications.ErrUnknownKind) {
+			http.Error(w, "muted_kinds может содержать только: "+strings.Join(notifications.Kinds, ", "), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при сохранении настроек уведомлений: %v", err)
+			http.Error(w, "Ошибка при сохранении настроек уведомлений", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeOKRJSON(w, http.StatusOK, NotificationSettingsResponse{
+		QuietHours:	settings.QuietHours(),
+		MutedKinds:	settings.MutedKinds,
+		Kinds:		notifications.Kinds,
+	})
+}
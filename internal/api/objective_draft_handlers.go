@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ObjectiveDraftRequest struct {
+	Fields	map[string]interface{}	`json:"fields"`
+	Version	int			`json:"version"`
+}
+
+type ObjectiveDraftResponse struct {
+	*okr.ObjectiveDraft
+	Missing	[]string	`json:"missing"`
+}
+
+type ObjectiveDraftConflict struct {
+	Error	string			`json:"error"`
+	Draft	*ObjectiveDraftResponse	`json:"draft,omitempty"`
+}
+
+func newObjectiveDraftResponse(draft *okr.ObjectiveDraft) *ObjectiveDraftResponse {
+	return &ObjectiveDraftResponse{ObjectiveDraft: draft, Missing: draft.Missing()}
+}
+
+func (h *Handler) ObjectiveDraftHandler(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "ObjectiveDraftHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	ownerID, draft, err := h.findObjectiveDraft(ctx, telegramIDs)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении черновика цели: %v", err)
+		http.Error(w, "Ошибка при получении черновика", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if draft == nil {
+			http.Error(w, "Черновик цели не найден", http.StatusNotFound)
+			return
+		}
+		writeOKRJSON(w, http.StatusOK, newObjectiveDraftResponse(draft))
+	case http.MethodPut:
+		var req ObjectiveDraftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version < 0 {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+		saved, err := h.okrService.SaveObjectiveDraft(ctx, ownerID, req.Fields, req.Version, okr.DraftViaWeb)
+		if errors.Is(err, okr.ErrDraftConflict) {
+			h.writeDraftConflict(w, ctx, ownerID)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при сохранении черновика цели пользователя %d: %v", ownerID, err)
+			http.Error(w, "Ошибка при сохранении черновика", http.StatusInternalServerError)
+			return
+		}
+		writeOKRJSON(w, http.StatusOK, newObjectiveDraftResponse(saved))
+	case http.MethodDelete:
+		version, err := strconv.Atoi(r.URL.Query().Get("version"))
+		if err != nil || version <= 0 {
+			http.Error(w, "Параметр 'version' обязателен", http.StatusBadRequest)
+			return
+		}
+		err = h.okrService.DeleteObjectiveDraft(ctx, ownerID, version)
+		if errors.Is(err, okr.ErrDraftConflict) {
+			h.writeDraftConflict(w, ctx, ownerID)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при удалении черновика цели пользователя %d: %v", ownerID, err)
+			http.Error(w, "Ошибка при удалении черновика", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) findObjectiveDraft(ctx context.Context, telegramIDs []int64) (int64, *okr.ObjectiveDraft, error) {
+	for _, telegramID := range telegramIDs {
+		draft, err := h.okrService.GetObjectiveDraft(ctx, telegramID)
+		if errors.Is(err, okr.ErrDraftNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		return telegramID, draft, nil
+	}
+	return telegramIDs[0], nil, nil
+}
+
+func (h *Handler) writeDraftConflict(w http.ResponseWriter, ctx context.Context, ownerID int64) {
+	conflict := ObjectiveDraftConflict{Error: okr.ErrDraftConflict.Error()}
+	draft, err := h.okrService.GetObjectiveDraft(ctx, ownerID)
+	switch {
+	case err == nil:
+		conflict.Draft = newObjectiveDraftResponse(draft)
+	case !errors.Is(err, okr.ErrDraftNotFound):
+		logrus.Errorf("Ошибка API при получении черновика цели пользователя %d: %v", ownerID, err)
+	}
+	writeOKRJSON(w, http.StatusConflict, conflict)
+}
+
+func (h *Handler) claimObjectiveDraft(w http.ResponseWriter, ctx context.Context, telegramIDs []int64, version int) (int64, *okr.ObjectiveDraft, bool) {
+	ownerID, draft, err := h.findObjectiveDraft(ctx, telegramIDs)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении черновика цели: %v", err)
+		http.Error(w, "Ошибка при получении черновика", http.StatusInternalServerError)
+		return 0, nil, false
+	}
+	err = h.okrService.DeleteObjectiveDraft(ctx, ownerID, version)
+	if errors.Is(err, okr.ErrDraftConflict) {
+		h.writeDraftConflict(w, ctx, ownerID)
+		return 0, nil, false
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при завершении черновика цели пользователя %d: %v", ownerID, err)
+		http.Error(w, "Ошибка при завершении черновика", http.StatusInternalServerError)
+		return 0, nil, false
+	}
+	return ownerID, draft, true
+}
+
+func (h *Handler) restoreObjectiveDraft(ctx context.Context, draft *okr.ObjectiveDraft) {
+	if _, err := h.okrService.SaveObjectiveDraft(ctx, draft.UserID, draft.Fields, 0, draft.UpdatedVia); err != nil {
+		logrus.Warnf("Не удалось восстановить черновик цели пользователя %d: %v", draft.UserID, err)
+	}
+}
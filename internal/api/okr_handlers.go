@@ -0,0 +1,823 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"telegrambot/internal/auth"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ObjectiveRequest struct {
+	ID		string			`json:"id"`
+	Title		*string			`json:"title"`
+	Sphere		*string			`json:"sphere"`
+	Period		*string			`json:"period"`
+	Deadline	*string			`json:"deadline"`
+	KeyResults	[]KeyResultRequest	`json:"key_results"`
+	DraftVersion	*int			`json:"draft_version"`
+}
+
+type KeyResultRequest struct {
+	ID		int64		`json:"id"`
+	ObjectiveID	string		`json:"objective_id"`
+	Title		*string		`json:"title"`
+	Target		*float64	`json:"target"`
+	Unit		*string		`json:"unit"`
+	Progress	*float64	`json:"progress"`
+	Deadline	*string		`json:"deadline"`
+	TargetReason	string		`json:"target_reason"`
+}
+
+type TaskRequest struct {
+	ID		int64		`json:"id"`
+	KeyResultID	int64		`json:"key_result_id"`
+	Title		*string		`json:"title"`
+	Target		*float64	`json:"target"`
+	Unit		*string		`json:"unit"`
+	Progress	*float64	`json:"progress"`
+	Deadline	*string		`json:"deadline"`
+}
+
+type ObjectiveResponse struct {
+	ID		string			`json:"id"`
+	UserID		int64			`json:"user_id"`
+	Title		string			`json:"title"`
+	Sphere		string			`json:"sphere"`
+	Period		string			`json:"period"`
+	Deadline	*time.Time		`json:"deadline,omitempty"`
+	Progress	float64			`json:"progress"`
+	Invested	float64			`json:"invested"`
+	TrackedMinutes	int			`json:"tracked_minutes"`
+	CreatedAt	time.Time		`json:"created_at"`
+	KeyResults	[]KeyResultResponse	`json:"key_results"`
+	Closing		*okr.ObjectiveClosing	`json:"closing,omitempty"`
+}
+
+type KeyResultResponse struct {
+	ID		int64			`json:"id"`
+	ObjectiveID	string			`json:"objective_id"`
+	Title		string			`json:"title"`
+	Target		float64			`json:"target"`
+	Unit		string			`json:"unit"`
+	Progress	float64			`json:"progress"`
+	Percent		float64			`json:"percent"`
+	Invested	float64			`json:"invested"`
+	TrackedMinutes	int			`json:"tracked_minutes"`
+	Deadline	*time.Time		`json:"deadline,omitempty"`
+	CreatedAt	time.Time		`json:"created_at"`
+	Revisions	[]okr.TargetRevision	`json:"target_revisions,omitempty"`
+	Tasks		[]TaskResponse		`json:"tasks"`
+}
+
+type TaskResponse struct {
+	ID		int64		`json:"id"`
+	KeyResultID	int64		`json:"key_result_id"`
+	Title		string		`json:"title"`
+	Target		float64		`json:"target"`
+	Unit		string		`json:"unit"`
+	Progress	float64		`json:"progress"`
+	TrackedMinutes	int		`json:"tracked_minutes"`
+	Deadline	*time.Time	`json:"deadline,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+}
+
+func newTaskResponse(t okr.Task) TaskResponse {
+	return TaskResponse{
+		ID:		t.ID,
+		KeyResultID:	t.KeyResultID,
+		Title:		t.Title,
+		Target:		t.Target,
+		Unit:		t.Unit,
+		Progress:	t.Progress,
+		Deadline:	t.Deadline,
+		CreatedAt:	t.CreatedAt,
+	}
+}
+
+func newKeyResultResponse(kr okr.KeyResult, tasks []okr.Task) KeyResultResponse {
+	percent := 0.0
+	if kr.Target > 0 {
+		percent = (kr.Progress / kr.Target) * 100
+		if percent > 100 {
+			percent = 100
+		}
+	}
+
+	response := KeyResultResponse{
+		ID:		kr.ID,
+		ObjectiveID:	kr.ObjectiveID,
+		Title:		kr.Title,
+		Target:		kr.Target,
+		Unit:		kr.Unit,
+		Progress:	kr.Progress,
+		Percent:	percent,
+		Deadline:	kr.Deadline,
+		CreatedAt:	kr.CreatedAt,
+		Tasks:		make([]TaskResponse, 0, len(tasks)),
+	}
+	for _, t := range tasks {
+		response.Tasks = append(response.Tasks, newTaskResponse(t))
+	}
+	return response
+}
+
+func newObjectiveResponse(details *okr.ObjectiveDetails) ObjectiveResponse {
+	response := ObjectiveResponse{
+		ID:		details.Objective.ID,
+		UserID:		details.Objective.UserID,
+		Title:		details.Objective.Title,
+		Sphere:		details.Objective.Sphere,
+		Period:		details.Objective.Period,
+		Deadline:	details.Objective.Deadline,
+		Progress:	details.Progress,
+		Invested:	details.Invested,
+		TrackedMinutes:	details.TrackedMinutes,
+		CreatedAt:	details.Objective.CreatedAt,
+		KeyResults:	make([]KeyResultResponse, 0, len(details.KeyResults)),
+		Closing:	details.Closing,
+	}
+	for _, kr := range details.KeyResults {
+		krResponse := newKeyResultResponse(kr.KeyResult, kr.Tasks)
+		krResponse.Invested = kr.Invested
+		krResponse.TrackedMinutes = kr.TrackedMinutes
+		krResponse.Revisions = kr.Revisions
+		for i := range krResponse.Tasks {
+			krResponse.Tasks[i].TrackedMinutes = kr.TaskMinutes[krResponse.Tasks[i].ID]
+		}
+		response.KeyResults = append(response.KeyResults, krResponse)
+	}
+	return response
+}
+
+func parseOKRDeadline(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, *value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (h *Handler) getLinkedTelegramIDs(w http.ResponseWriter, r *http.Request, handlerName string) ([]int64, bool) {
+	ctx := r.Context()
+	webUserID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok {
+		logrus.Errorf("Не удалось извлечь webUserID из контекста в %s", handlerName)
+		http.Error(w, "Ошибка авторизации: webUserID не найден в токене", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	webUser, err := h.userService.GetWebUserByID(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении web_user %d: %v", webUserID, err)
+		http.Error(w, "Ошибка при получении данных пользователя", http.StatusInternalServerError)
+		return nil, false
+	}
+	if webUser == nil || len(webUser.TelegramIDs) == 0 {
+		logrus.Warnf("Пользователь с ID %d не найден или не имеет привязанных Telegram аккаунтов", webUserID)
+		http.Error(w, "Для работы с целями требуется привязанный Telegram аккаунт", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return webUser.TelegramIDs, true
+}
+
+func (h *Handler) findObjectiveOwner(ctx context.Context, telegramIDs []int64, objectiveID string) (int64, *okr.ObjectiveDetails, bool) {
+	ownerID, found := h.findOwner(telegramIDs, func(userID int64) error {
+		return ownership.MustOwnObjective(ctx, h.db, userID, objectiveID)
+	})
+	if !found {
+		return 0, nil, false
+	}
+	details, err := h.okrService.GetObjectiveDetails(ctx, ownerID, objectiveID)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении цели %s: %v", objectiveID, err)
+		return 0, nil, false
+	}
+	return ownerID, details, true
+}
+
+func (h *Handler) findKeyResultOwner(ctx context.Context, telegramIDs []int64, keyResultID int64) (int64, bool) {
+	return h.findOwner(telegramIDs, func(userID int64) error {
+		return ownership.MustOwnKeyResult(ctx, h.db, userID, keyResultID)
+	})
+}
+
+func (h *Handler) findTaskOwner(ctx context.Context, telegramIDs []int64, taskID int64) (int64, bool) {
+	return h.findOwner(telegramIDs, func(userID int64) error {
+		return ownership.MustOwnTask(ctx, h.db, userID, taskID)
+	})
+}
+
+func (h *Handler) findEventOwner(ctx context.Context, telegramIDs []int64, eventID string) (int64, bool) {
+	return h.findOwner(telegramIDs, func(userID int64) error {
+		return ownership.MustOwnEvent(ctx, h.db, userID, eventID)
+	})
+}
+
+func (h *Handler) findOwner(telegramIDs []int64, guard func(userID int64) error) (int64, bool) {
+	ownerID, err := ownership.FirstOwner(telegramIDs, guard)
+	if err != nil {
+		if !ownership.IsNotOwned(err) {
+			logrus.Errorf("Ошибка API при проверке владельца: %v", err)
+		}
+		return 0, false
+	}
+	return ownerID, true
+}
+
+func writeOKRJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		logrus.Errorf("Ошибка API при сериализации ответа OKR в JSON: %v", err)
+	}
+}
+
+func (h *Handler) ObjectivesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listObjectives(w, r)
+	case http.MethodPost:
+		h.createObjective(w, r)
+	case http.MethodPut:
+		h.updateObjective(w, r)
+	case http.MethodDelete:
+		h.deleteObjective(w, r)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) listObjectives(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "listObjectives")
+	if !ok {
+		return
+	}
+	if isArchiveRequest(r) {
+		h.listArchivedObjectives(w, r, telegramIDs)
+		return
+	}
+	ctx := r.Context()
+
+	response := make([]ObjectiveResponse, 0)
+	for _, telegramID := range telegramIDs {
+		objectives, err := h.okrService.GetObjectives(ctx, telegramID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении целей пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении целей", http.StatusInternalServerError)
+			return
+		}
+
+		for _, objective := range objectives {
+			details, err := h.okrService.GetObjectiveDetails(ctx, telegramID, objective.ID)
+			if err != nil {
+				logrus.Errorf("Ошибка API при получении деталей цели %s: %v", objective.ID, err)
+				http.Error(w, "Ошибка при получении целей", http.StatusInternalServerError)
+				return
+			}
+			response = append(response, newObjectiveResponse(details))
+		}
+	}
+
+	writeOKRJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) createObjective(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "createObjective")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req ObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if req.Title == nil || *req.Title == "" {
+		http.Error(w, "Название цели обязательно", http.StatusBadRequest)
+		return
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil {
+		http.Error(w, "Некорректный формат дедлайна (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	sphere := ""
+	if req.Sphere != nil {
+		sphere = *req.Sphere
+	}
+	period := "quarter"
+	if req.Period != nil && *req.Period != "" {
+		period = *req.Period
+	}
+
+	keyResults := make([]okr.KeyResult, 0, len(req.KeyResults))
+	for _, krReq := range req.KeyResults {
+		if krReq.Title == nil || *krReq.Title == "" || krReq.Target == nil {
+			http.Error(w, "Для ключевого результата обязательны название и целевое значение", http.StatusBadRequest)
+			return
+		}
+		krDeadline, err := parseOKRDeadline(krReq.Deadline)
+		if err != nil {
+			http.Error(w, "Некорректный формат дедлайна ключевого результата", http.StatusBadRequest)
+			return
+		}
+		kr := okr.KeyResult{
+			Title:		*krReq.Title,
+			Target:		*krReq.Target,
+			Deadline:	krDeadline,
+		}
+		if krReq.Unit != nil {
+			kr.Unit = *krReq.Unit
+		}
+		keyResults = append(keyResults, kr)
+	}
+
+	telegramID := telegramIDs[0]
+	var draft *okr.ObjectiveDraft
+	if req.DraftVersion != nil {
+		ownerID, claimed, ok := h.claimObjectiveDraft(w, ctx, telegramIDs, *req.DraftVersion)
+		if !ok {
+			return
+		}
+		telegramID, draft = ownerID, claimed
+	}
+
+	objectiveID, err := h.okrService.CreateObjective(ctx, telegramID, *req.Title, sphere, period, deadline, keyResults)
+	if err != nil {
+		if draft != nil {
+			h.restoreObjectiveDraft(ctx, draft)
+		}
+		logrus.Errorf("Ошибка при создании цели для пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при создании цели", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, telegramID, objectiveID)
+	if err != nil {
+		logrus.Errorf("Цель создана, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusCreated, map[string]string{"id": objectiveID})
+		return
+	}
+
+	writeOKRJSON(w, http.StatusCreated, newObjectiveResponse(details))
+}
+
+func (h *Handler) updateObjective(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "updateObjective")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req ObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		http.Error(w, "ID цели обязателен", http.StatusBadRequest)
+		return
+	}
+
+	if req.Title != nil && *req.Title == "" {
+		http.Error(w, "Название цели не может быть пустым", http.StatusBadRequest)
+		return
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil {
+		http.Error(w, "Некорректный формат дедлайна (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, _, found := h.findObjectiveOwner(ctx, telegramIDs, req.ID)
+	if !found {
+		http.Error(w, "Цель не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.UpdateObjective(ctx, ownerID, req.ID, req.Title, req.Sphere, req.Period, deadline); err != nil {
+		logrus.Errorf("Ошибка при обновлении цели %s: %v", req.ID, err)
+		http.Error(w, "Ошибка при обновлении цели", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, ownerID, req.ID)
+	if err != nil {
+		logrus.Errorf("Цель обновлена, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusOK, map[string]string{"status": "success"})
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, newObjectiveResponse(details))
+}
+
+func (h *Handler) deleteObjective(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "deleteObjective")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	objectiveID := r.URL.Query().Get("id")
+	if objectiveID == "" {
+		var req ObjectiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+			http.Error(w, "ID цели обязателен", http.StatusBadRequest)
+			return
+		}
+		objectiveID = req.ID
+	}
+
+	ownerID, _, found := h.findObjectiveOwner(ctx, telegramIDs, objectiveID)
+	if !found {
+		http.Error(w, "Цель не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.DeleteObjective(ctx, ownerID, objectiveID); err != nil {
+		logrus.Errorf("Ошибка при удалении цели %s: %v", objectiveID, err)
+		http.Error(w, "Ошибка при удалении цели", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (h *Handler) KeyResultsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listKeyResults(w, r)
+	case http.MethodPost:
+		h.createKeyResult(w, r)
+	case http.MethodPut:
+		h.updateKeyResult(w, r)
+	case http.MethodDelete:
+		h.deleteKeyResult(w, r)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) listKeyResults(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "listKeyResults")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	objectiveID := r.URL.Query().Get("objective_id")
+	if objectiveID == "" {
+		http.Error(w, "Параметр 'objective_id' обязателен", http.StatusBadRequest)
+		return
+	}
+
+	_, details, found := h.findObjectiveOwner(ctx, telegramIDs, objectiveID)
+	if !found {
+		http.Error(w, "Цель не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	response := make([]KeyResultResponse, 0, len(details.KeyResults))
+	for _, kr := range details.KeyResults {
+		response = append(response, newKeyResultResponse(kr.KeyResult, kr.Tasks))
+	}
+
+	writeOKRJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) createKeyResult(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "createKeyResult")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req KeyResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if req.ObjectiveID == "" || req.Title == nil || *req.Title == "" || req.Target == nil {
+		http.Error(w, "Обязательные поля: objective_id, title, target", http.StatusBadRequest)
+		return
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil {
+		http.Error(w, "Некорректный формат дедлайна (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, _, found := h.findObjectiveOwner(ctx, telegramIDs, req.ObjectiveID)
+	if !found {
+		http.Error(w, "Цель не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	unit := ""
+	if req.Unit != nil {
+		unit = *req.Unit
+	}
+
+	keyResultID, err := h.okrService.CreateKeyResult(ctx, ownerID, req.ObjectiveID, *req.Title, *req.Target, unit, deadline)
+	if err != nil {
+		logrus.Errorf("Ошибка при создании ключевого результата для цели %s: %v", req.ObjectiveID, err)
+		http.Error(w, "Ошибка при создании ключевого результата", http.StatusInternalServerError)
+		return
+	}
+
+	keyResult, err := h.okrService.GetKeyResultByID(ctx, ownerID, keyResultID)
+	if err != nil {
+		logrus.Errorf("Ключевой результат создан, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusCreated, map[string]int64{"id": keyResultID})
+		return
+	}
+
+	writeOKRJSON(w, http.StatusCreated, newKeyResultResponse(*keyResult, nil))
+}
+
+func (h *Handler) updateKeyResult(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "updateKeyResult")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req KeyResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+		http.Error(w, "ID ключевого результата обязателен", http.StatusBadRequest)
+		return
+	}
+
+	if req.Title != nil && *req.Title == "" {
+		http.Error(w, "Название ключевого результата не может быть пустым", http.StatusBadRequest)
+		return
+	}
+	if req.Target != nil && *req.Target <= 0 {
+		http.Error(w, "Целевое значение должно быть больше нуля", http.StatusBadRequest)
+		return
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil {
+		http.Error(w, "Некорректный формат дедлайна (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, found := h.findKeyResultOwner(ctx, telegramIDs, req.ID)
+	if !found {
+		http.Error(w, "Ключевой результат не найден или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.UpdateKeyResult(ctx, ownerID, req.ID, req.Title, req.Unit, req.Target, req.Progress, deadline, req.TargetReason); err != nil {
+		logrus.Errorf("Ошибка при обновлении ключевого результата %d: %v", req.ID, err)
+		http.Error(w, "Ошибка при обновлении ключевого результата", http.StatusInternalServerError)
+		return
+	}
+
+	keyResult, err := h.okrService.GetKeyResultByID(ctx, ownerID, req.ID)
+	if err != nil {
+		logrus.Errorf("Ключевой результат обновлен, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusOK, map[string]string{"status": "success"})
+		return
+	}
+
+	tasks, err := h.okrService.GetTasks(ctx, keyResult.ID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить задачи ключевого результата %d: %v", keyResult.ID, err)
+	}
+
+	writeOKRJSON(w, http.StatusOK, newKeyResultResponse(*keyResult, tasks))
+}
+
+func (h *Handler) deleteKeyResult(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "deleteKeyResult")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	keyResultID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		var req KeyResultRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+			http.Error(w, "ID ключевого результата обязателен", http.StatusBadRequest)
+			return
+		}
+		keyResultID = req.ID
+	}
+
+	ownerID, found := h.findKeyResultOwner(ctx, telegramIDs, keyResultID)
+	if !found {
+		http.Error(w, "Ключевой результат не найден или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.DeleteKeyResult(ctx, ownerID, keyResultID); err != nil {
+		logrus.Errorf("Ошибка при удалении ключевого результата %d: %v", keyResultID, err)
+		http.Error(w, "Ошибка при удалении ключевого результата", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
+
+func (h *Handler) TasksHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listTasks(w, r)
+	case http.MethodPost:
+		h.createTask(w, r)
+	case http.MethodPut:
+		h.updateTask(w, r)
+	case http.MethodDelete:
+		h.deleteTask(w, r)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) listTasks(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "listTasks")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	keyResultID, err := strconv.ParseInt(r.URL.Query().Get("key_result_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Параметр 'key_result_id' обязателен", http.StatusBadRequest)
+		return
+	}
+
+	if _, found := h.findKeyResultOwner(ctx, telegramIDs, keyResultID); !found {
+		http.Error(w, "Ключевой результат не найден или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	tasks, err := h.okrService.GetTasks(ctx, keyResultID)
+	if err != nil {
+		logrus.Errorf("Ошибка API при получении задач ключевого результата %d: %v", keyResultID, err)
+		http.Error(w, "Ошибка при получении задач", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]TaskResponse, 0, len(tasks))
+	for _, t := range tasks {
+		response = append(response, newTaskResponse(t))
+	}
+
+	writeOKRJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "createTask")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req TaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+		return
+	}
+
+	if req.KeyResultID == 0 || req.Title == nil || *req.Title == "" || req.Target == nil || req.Deadline == nil {
+		http.Error(w, "Обязательные поля: key_result_id, title, target, deadline", http.StatusBadRequest)
+		return
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil || deadline == nil {
+		http.Error(w, "Некорректный формат дедлайна (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, found := h.findKeyResultOwner(ctx, telegramIDs, req.KeyResultID)
+	if !found {
+		http.Error(w, "Ключевой результат не найден или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	unit := ""
+	if req.Unit != nil {
+		unit = *req.Unit
+	}
+
+	taskID, err := h.okrService.CreateTask(ctx, ownerID, req.KeyResultID, *req.Title, *req.Target, unit, deadline)
+	if err != nil {
+		logrus.Errorf("Ошибка при создании задачи для ключевого результата %d: %v", req.KeyResultID, err)
+		http.Error(w, "Ошибка при создании задачи", http.StatusInternalServerError)
+		return
+	}
+
+	task, err := h.okrService.GetTaskByID(ctx, ownerID, taskID)
+	if err != nil {
+		logrus.Errorf("Задача создана, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusCreated, map[string]int64{"id": taskID})
+		return
+	}
+
+	writeOKRJSON(w, http.StatusCreated, newTaskResponse(*task))
+}
+
+func (h *Handler) updateTask(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "updateTask")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req TaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	if req.Title != nil && *req.Title == "" {
+		http.Error(w, "Название задачи не может быть пустым", http.StatusBadRequest)
+		return
+	}
+	if req.Target != nil && *req.Target <= 0 {
+		http.Error(w, "Целевое значение должно быть больше нуля", http.StatusBadRequest)
+		return
+	}
+
+	deadline, err := parseOKRDeadline(req.Deadline)
+	if err != nil {
+		http.Error(w, "Некорректный формат дедлайна (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, found := h.findTaskOwner(ctx, telegramIDs, req.ID)
+	if !found {
+		http.Error(w, "Задача не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.UpdateTask(ctx, ownerID, req.ID, req.Title, req.Unit, req.Target, req.Progress, deadline); err != nil {
+		logrus.Errorf("Ошибка при обновлении задачи %d: %v", req.ID, err)
+		http.Error(w, "Ошибка при обновлении задачи", http.StatusInternalServerError)
+		return
+	}
+
+	task, err := h.okrService.GetTaskByID(ctx, ownerID, req.ID)
+	if err != nil {
+		logrus.Errorf("Задача обновлена, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusOK, map[string]string{"status": "success"})
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, newTaskResponse(*task))
+}
+
+func (h *Handler) deleteTask(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "deleteTask")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	taskID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		var req TaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == 0 {
+			http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+			return
+		}
+		taskID = req.ID
+	}
+
+	ownerID, found := h.findTaskOwner(ctx, telegramIDs, taskID)
+	if !found {
+		http.Error(w, "Задача не найдена или не принадлежит пользователю", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.DeleteTask(ctx, ownerID, taskID); err != nil {
+		logrus.Errorf("Ошибка при удалении задачи %d: %v", taskID, err)
+		http.Error(w, "Ошибка при удалении задачи", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+type TeamInviteRequest struct {
+	TeamID		int64	`json:"team_id"`
+	UserID		int64	`json:"user_id"`
+	Username	string	`json:"username"`
+}
+
+type TeamInvitationResponseRequest struct {
+	InvitationID	int64	`json:"invitation_id"`
+	Accept		bool	`json:"accept"`
+}
+
+type ShareObjectiveRequest struct {
+	ObjectiveID	string	`json:"objective_id"`
+	TeamID		int64	`json:"team_id"`
+}
+
+type TeamsResponse struct {
+	Teams		[]okr.Team		`json:"teams"`
+	Invitations	[]okr.TeamInvitation	`json:"invitations"`
+}
+
+type TeamObjectiveResponse struct {
+	Objective	ObjectiveResponse		`json:"objective"`
+	Members		[]okr.MemberContribution	`json:"members"`
+}
+
+func teamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, okr.ErrTeamNotFound), errors.Is(err, okr.ErrInvitationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, okr.ErrTeamPermission), errors.Is(err, okr.ErrNotTeamMember):
+		return http.StatusForbidden
+	case errors.Is(err, okr.ErrAlreadyTeamMember):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *Handler) TeamsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "TeamsHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	response := TeamsResponse{Teams: make([]okr.Team, 0), Invitations: make([]okr.TeamInvitation, 0)}
+	for _, telegramID := range telegramIDs {
+		teams, err := h.okrService.GetUserTeams(ctx, telegramID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении команд пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении команд", http.StatusInternalServerError)
+			return
+		}
+		response.Teams = append(response.Teams, teams...)
+
+		invitations, err := h.okrService.GetPendingInvitations(ctx, telegramID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении приглашений пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении приглашений", http.StatusInternalServerError)
+			return
+		}
+		response.Invitations = append(response.Invitations, invitations...)
+	}
+
+	writeOKRJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) TeamInviteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "TeamInviteHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req TeamInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+	if req.TeamID == 0 || (req.UserID == 0 && strings.TrimSpace(req.Username) == "") {
+		http.Error(w, "Необходимо указать team_id и user_id или username", http.StatusBadRequest)
+		return
+	}
+
+	inviteeID := req.UserID
+	if inviteeID == 0 {
+		username := strings.TrimPrefix(strings.TrimSpace(req.Username), "@")
+		if err := h.db.GetContext(ctx, &inviteeID, `SELECT id FROM users WHERE LOWER(username) = LOWER($1)`, username); err != nil {
+			http.Error(w, "Пользователь не найден. Он должен хотя бы раз написать боту", http.StatusNotFound)
+			return
+		}
+	}
+
+	var lastErr error
+	for _, telegramID := range telegramIDs {
+		invitation, err := h.okrService.InviteToTeam(ctx, telegramID, req.TeamID, inviteeID)
+		if err == nil {
+			writeOKRJSON(w, http.StatusCreated, invitation)
+			return
+		}
+		lastErr = err
+		if !errors.Is(err, okr.ErrNotTeamMember) && !errors.Is(err, okr.ErrTeamPermission) {
+			break
+		}
+	}
+
+	logrus.Errorf("Ошибка API при приглашении пользователя %d в команду %d: %v", inviteeID, req.TeamID, lastErr)
+	http.Error(w, lastErr.Error(), teamErrorStatus(lastErr))
+}
+
+func (h *Handler) TeamInvitationRespondHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "TeamInvitationRespondHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req TeamInvitationResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.InvitationID == 0 {
+		http.Error(w, "Необходимо указать invitation_id", http.StatusBadRequest)
+		return
+	}
+
+	var lastErr error
+	for _, telegramID := range telegramIDs {
+		invitation, err := h.okrService.RespondToInvitation(ctx, telegramID, req.InvitationID, req.Accept)
+		if err == nil {
+			writeOKRJSON(w, http.StatusOK, invitation)
+			return
+		}
+		lastErr = err
+		if !errors.Is(err, okr.ErrInvitationNotFound) {
+			break
+		}
+	}
+
+	logrus.Errorf("Ошибка API при ответе на приглашение %d: %v", req.InvitationID, lastErr)
+	http.Error(w, lastErr.Error(), teamErrorStatus(lastErr))
+}
+
+func (h *Handler) ShareObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "ShareObjectiveHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req ShareObjectiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ObjectiveID == "" || req.TeamID == 0 {
+		http.Error(w, "Необходимо указать objective_id и team_id", http.StatusBadRequest)
+		return
+	}
+
+	ownerID, _, found := h.findObjectiveOwner(ctx, telegramIDs, req.ObjectiveID)
+	if !found {
+		http.Error(w, "Цель не найдена", http.StatusNotFound)
+		return
+	}
+
+	if err := h.okrService.ShareObjectiveWithTeam(ctx, ownerID, req.ObjectiveID, req.TeamID); err != nil {
+		logrus.Errorf("Ошибка API при открытии цели %s команде %d: %v", req.ObjectiveID, req.TeamID, err)
+		http.Error(w, err.Error(), teamErrorStatus(err))
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, map[string]interface{}{"objective_id": req.ObjectiveID, "team_id": req.TeamID})
+}
+
+func (h *Handler) TeamObjectivesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "TeamObjectivesHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	seen := make(map[string]bool)
+	response := make([]TeamObjectiveResponse, 0)
+	for _, telegramID := range telegramIDs {
+		objectives, err := h.okrService.GetTeamObjectives(ctx, telegramID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении командных целей пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении командных целей", http.StatusInternalServerError)
+			return
+		}
+
+		for _, objective := range objectives {
+			if seen[objective.ID] {
+				continue
+			}
+			seen[objective.ID] = true
+
+			details, err := h.okrService.GetObjectiveDetails(ctx, objective.UserID, objective.ID)
+			if err != nil {
+				logrus.Errorf("Ошибка API при получении деталей цели %s: %v", objective.ID, err)
+				http.Error(w, "Ошибка при получении командных целей", http.StatusInternalServerError)
+				return
+			}
+
+			members, err := h.okrService.GetMemberContributions(ctx, objective.ID)
+			if err != nil {
+				logrus.Errorf("Ошибка API при получении вклада участников цели %s: %v", objective.ID, err)
+				http.Error(w, "Ошибка при получении командных целей", http.StatusInternalServerError)
+				return
+			}
+			if members == nil {
+				members = make([]okr.MemberContribution, 0)
+			}
+
+			response = append(response, TeamObjectiveResponse{Objective: newObjectiveResponse(details), Members: members})
+		}
+	}
+
+	writeOKRJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"telegrambot/internal/rollout"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CreatePromptReleaseRequest struct {
+	Version			string		`json:"version"`
+	Description		string		`json:"description"`
+	PromptAddendum		string		`json:"prompt_addendum"`
+	FunctionOverrides	json.RawMessage	`json:"function_overrides"`
+	RolloutPercent		int		`json:"rollout_percent"`
+}
+
+type SetRolloutPercentRequest struct {
+	Percent int `json:"percent"`
+}
+
+type RollbackPromptReleaseRequest struct {
+	Reason string `json:"reason"`
+}
+
+type PromptReleasesResponse struct {
+	Releases	[]rollout.Release	`json:"releases"`
+	Comparison	*rollout.Comparison	`json:"comparison,omitempty"`
+}
+
+func (h *Handler) writeRolloutError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, rollout.ErrNoActiveRelease):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, rollout.ErrReleaseActive):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, rollout.ErrInvalidVersion), errors.Is(err, rollout.ErrInvalidPercent), errors.Is(err, rollout.ErrInvalidOverrides):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		logrus.Errorf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) AdminPromptReleasesHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r, "AdminPromptReleasesHandler") {
+		return
+	}
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		releases, err := h.rollout.ListReleases(ctx, 10)
+		if err != nil {
+			h.writeRolloutError(w, err, "Ошибка при получении выкаток")
+			return
+		}
+
+		response := PromptReleasesResponse{Releases: releases}
+		for i := range releases {
+			if releases[i].Status != rollout.StatusActive {
+				continue
+			}
+			comparison, err := h.rollout.Compare(ctx, &releases[i], time.Now().Add(-24*time.Hour))
+			if err != nil {
+				h.writeRolloutError(w, err, "Ошибка при сравнении версий")
+				return
+			}
+			response.Comparison = comparison
+		}
+		writeOKRJSON(w, http.StatusOK, response)
+	case http.MethodPost:
+		var req CreatePromptReleaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+
+		release, err := h.rollout.CreateRelease(ctx, req.Version, req.Description, req.PromptAddendum, req.FunctionOverrides, req.RolloutPercent)
+		if err != nil {
+			h.writeRolloutError(w, err, "Ошибка при создании выкатки")
+			return
+		}
+		writeOKRJSON(w, http.StatusCreated, release)
+	case http.MethodPut:
+		var req SetRolloutPercentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+
+		release, err := h.rollout.SetPercent(ctx, req.Percent)
+		if err != nil {
+			h.writeRolloutError(w, err, "Ошибка при изменении процента выкатки")
+			return
+		}
+		writeOKRJSON(w, http.StatusOK, release)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) AdminPromptRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AdminPromptRollbackHandler") {
+		return
+	}
+
+	var req RollbackPromptReleaseRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Некорректное тело запроса", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "откат через админ API"
+	}
+
+	release, err := h.rollout.Rollback(r.Context(), req.Reason)
+	if err != nil {
+		h.writeRolloutError(w, err, "Ошибка при откате выкатки")
+		return
+	}
+	writeOKRJSON(w, http.StatusOK, release)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ShareLinkRequest struct {
+	ID		string	`json:"id"`
+	SharingType	string	`json:"sharing_type"`
+	Audience	string	`json:"audience"`
+}
+
+type ShareLinkResponse struct {
+	okr.ShareLink
+	URL	string	`json:"url"`
+}
+
+func (h *Handler) ShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "ShareLinkHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req ShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		http.Error(w, "Необходимо указать id цели", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		var revoked int64
+		_, err := ownership.FirstOwner(telegramIDs, func(userID int64) error {
+			count, err := h.okrService.RevokeShareLinks(ctx, userID, req.ID)
+			revoked = count
+			return err
+		})
+		if ownership.IsNotOwned(err) {
+			http.Error(w, "Цель не найдена", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при отзыве ссылки на цель %s: %v", req.ID, err)
+			http.Error(w, "Ошибка при отзыве ссылки", http.StatusInternalServerError)
+			return
+		}
+		writeOKRJSON(w, http.StatusOK, map[string]int64{"revoked": revoked})
+		return
+	}
+
+	var link *okr.ShareLink
+	_, err := ownership.FirstOwner(telegramIDs, func(userID int64) error {
+		created, err := h.okrService.CreateShareLink(ctx, userID, req.ID, req.SharingType, req.Audience)
+		link = created
+		return err
+	})
+	if ownership.IsNotOwned(err) {
+		http.Error(w, "Цель не найдена", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при создании ссылки на цель %s: %v", req.ID, err)
+		http.Error(w, "Ошибка при создании ссылки", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusCreated, ShareLinkResponse{
+		ShareLink:	*link,
+		URL:		h.calendarService.PublicURL(okr.ShareLinkPathPrefix + link.Token),
+	})
+}
+
+func (h *Handler) SharedObjectiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, okr.ShareLinkPathPrefix)
+	if token == "" || strings.Contains(token, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	shared, err := h.okrService.GetSharedObjective(r.Context(), token)
+	if errors.Is(err, okr.ErrShareLinkNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при открытии ссылки на цель: %v", err)
+		http.Error(w, "Внутренняя ошибка", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=60")
+	writeOKRJSON(w, http.StatusOK, shared)
+}
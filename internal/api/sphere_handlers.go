@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+type SphereRequest struct {
+	Name	string	`json:"name"`
+	Icon	string	`json:"icon"`
+}
+
+func (h *Handler) SpheresHandler(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "SpheresHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	telegramID := telegramIDs[0]
+
+	if r.Method == http.MethodGet {
+		spheres, err := h.okrService.GetSpheres(ctx, telegramID)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении сфер пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при получении сфер", http.StatusInternalServerError)
+			return
+		}
+		writeOKRJSON(w, http.StatusOK, spheres)
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SphereRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		http.Error(w, "Необходимо указать name", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		err := h.okrService.DeleteCustomSphere(ctx, telegramID, req.Name)
+		if errors.Is(err, okr.ErrSphereNotFound) {
+			http.Error(w, "Своя сфера не найдена", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка API при удалении сферы пользователя %d: %v", telegramID, err)
+			http.Error(w, "Ошибка при удалении сферы", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sphere, err := h.okrService.AddCustomSphere(ctx, telegramID, req.Name, req.Icon)
+	switch {
+	case errors.Is(err, okr.ErrInvalidSphereName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, okr.ErrStandardSphere):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		logrus.Errorf("Ошибка API при добавлении сферы пользователя %d: %v", telegramID, err)
+		http.Error(w, "Ошибка при добавлении сферы", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusCreated, sphere)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type InstantiateTemplateRequest struct {
+	TemplateID	string	`json:"template_id"`
+	Title		string	`json:"title"`
+	StartDate	*string	`json:"start_date"`
+}
+
+func (h *Handler) TemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeOKRJSON(w, http.StatusOK, okr.ObjectiveTemplates())
+	case http.MethodPost:
+		h.instantiateTemplate(w, r)
+	default:
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) instantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "instantiateTemplate")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req InstantiateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TemplateID == "" {
+		http.Error(w, "Необходимо указать template_id", http.StatusBadRequest)
+		return
+	}
+	start, err := parseOKRDeadline(req.StartDate)
+	if err != nil {
+		http.Error(w, "Некорректный формат start_date (ожидается YYYY-MM-DD или RFC3339)", http.StatusBadRequest)
+		return
+	}
+	if start == nil {
+		now := time.Now()
+		start = &now
+	}
+
+	telegramID := telegramIDs[0]
+	instance, err := h.okrService.InstantiateTemplate(ctx, telegramID, req.TemplateID, req.Title, *start)
+	if errors.Is(err, okr.ErrTemplateNotFound) {
+		http.Error(w, "Шаблон не найден", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка API при создании цели по шаблону %s для пользователя %d: %v", req.TemplateID, telegramID, err)
+		http.Error(w, "Ошибка при создании цели по шаблону", http.StatusInternalServerError)
+		return
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, telegramID, instance.ObjectiveID)
+	if err != nil {
+		logrus.Errorf("Цель по шаблону создана, но ошибка при получении данных: %v", err)
+		writeOKRJSON(w, http.StatusCreated, map[string]string{"id": instance.ObjectiveID})
+		return
+	}
+
+	writeOKRJSON(w, http.StatusCreated, newObjectiveResponse(details))
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type DeletedItemResponse struct {
+	Kind		string		`json:"kind"`
+	ID		string		`json:"id"`
+	Title		string		`json:"title"`
+	Parent		string		`json:"parent,omitempty"`
+	DeletedAt	time.Time	`json:"deleted_at"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+}
+
+type RestoreDeletedRequest struct {
+	Kind	string	`json:"kind"`
+	ID	string	`json:"id"`
+}
+
+func newDeletedItemResponse(item okr.DeletedItem) DeletedItemResponse {
+	return DeletedItemResponse{
+		Kind:		item.Kind,
+		ID:		item.ID,
+		Title:		item.Title,
+		Parent:		item.Parent,
+		DeletedAt:	item.DeletedAt,
+		ExpiresAt:	item.ExpiresAt(),
+	}
+}
+
+func (h *Handler) TrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "TrashHandler")
+	if !ok {
+		return
+	}
+
+	limit, _, err := parseArchivePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items := make([]DeletedItemResponse, 0)
+	for _, userID := range telegramIDs {
+		deleted, err := h.okrService.GetDeletedItems(r.Context(), userID, limit)
+		if err != nil {
+			logrus.Errorf("Ошибка API при получении удаленных элементов пользователя %d: %v", userID, err)
+			http.Error(w, "Ошибка при получении корзины", http.StatusInternalServerError)
+			return
+		}
+		for _, item := range deleted {
+			items = append(items, newDeletedItemResponse(item))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	writeOKRJSON(w, http.StatusOK, items)
+}
+
+func (h *Handler) RestoreDeletedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+	telegramIDs, ok := h.getLinkedTelegramIDs(w, r, "RestoreDeletedHandler")
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	var req RestoreDeletedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		http.Error(w, "Необходимо указать id удаленного элемента", http.StatusBadRequest)
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = okr.DeletedKindObjective
+	}
+
+	var restored *okr.DeletedItem
+	_, err := ownership.FirstOwner(telegramIDs, func(userID int64) error {
+		item, err := h.okrService.RestoreDeletedItem(ctx, userID, req.Kind, req.ID)
+		restored = item
+		return err
+	})
+	switch {
+	case errors.Is(err, okr.ErrUnknownDeletedKind):
+		http.Error(w, "kind должен быть objective, key_result или task", http.StatusBadRequest)
+		return
+	case errors.Is(err, okr.ErrParentDeleted):
+		http.Error(w, "Сначала восстановите родительский элемент", http.StatusConflict)
+		return
+	case ownership.IsNotOwned(err):
+		http.Error(w, "Удаленный элемент не найден", http.StatusNotFound)
+		return
+	case err != nil:
+		logrus.Errorf("Ошибка API при восстановлении %s %s: %v", req.Kind, req.ID, err)
+		http.Error(w, "Ошибка при восстановлении", http.StatusInternalServerError)
+		return
+	}
+
+	writeOKRJSON(w, http.StatusOK, newDeletedItemResponse(*restored))
+}
@@ -80,6 +80,31 @@ func JWTMiddleware(next http.Handler, signingKey string) http.Handler {
 	})
 }
 
+func APIKeyMiddleware(next http.Handler, authenticate func(ctx context.Context, key string) (int64, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			parts := strings.Split(r.Header.Get("Authorization"), " ")
+			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+				key = parts[1]
+			}
+		}
+		if key == "" {
+			http.Error(w, "Отсутствует API-ключ (заголовок X-API-Key)", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := authenticate(r.Context(), key)
+		if err != nil {
+			http.Error(w, "Невалидный API-ключ", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "userID", userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func GetUserIDFromContext(ctx context.Context) (int64, bool) {
 	userID, ok := ctx.Value("userID").(int64)
 	return userID, ok
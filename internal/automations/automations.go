@@ -0,0 +1,277 @@
+package automations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	ConditionKeyResultStale		= "kr_stale"
+	ConditionKeyResultDeadlineNear	= "kr_deadline_near"
+
+	ActionCreateTask	= "create_task"
+	ActionSendReminder	= "send_reminder"
+	ActionShiftEvent	= "shift_event"
+
+	maxRulesPerUser		= 20
+	maxThresholdDays	= 90
+	maxShiftMinutes		= 7 * 24 * 60
+)
+
+var (
+	ErrRuleNotFound		= errors.New("правило не найдено")
+	ErrUnknownCondition	= errors.New("неизвестное условие")
+	ErrUnknownAction	= errors.New("неизвестное действие")
+	ErrInvalidRule		= errors.New("некорректные параметры правила")
+	ErrTooManyRules		= errors.New("достигнут лимит правил")
+)
+
+var Conditions = []string{ConditionKeyResultStale, ConditionKeyResultDeadlineNear}
+
+var Actions = []string{ActionCreateTask, ActionSendReminder, ActionShiftEvent}
+
+type Service struct {
+	db		*sqlx.DB
+	okr		*okr.Service
+	calendar	*calendar.Service
+}
+
+type Rule struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Name		string		`db:"name" json:"name"`
+	ConditionType	string		`db:"condition_type" json:"condition_type"`
+	KeyResultID	int64		`db:"key_result_id" json:"key_result_id"`
+	KeyResultTitle	string		`db:"key_result_title" json:"key_result_title"`
+	ThresholdDays	int		`db:"threshold_days" json:"threshold_days"`
+	ActionType	string		`db:"action_type" json:"action_type"`
+	TaskTitle	*string		`db:"task_title" json:"task_title,omitempty"`
+	Message		*string		`db:"message" json:"message,omitempty"`
+	EventQuery	*string		`db:"event_query" json:"event_query,omitempty"`
+	ShiftMinutes	*int		`db:"shift_minutes" json:"shift_minutes,omitempty"`
+	IsActive	bool		`db:"is_active" json:"is_active"`
+	LastFiredAt	*time.Time	`db:"last_fired_at" json:"last_fired_at,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+const ruleSelect = `
+	SELECT r.id, r.user_id, r.name, r.condition_type, r.key_result_id, kr.title AS key_result_title,
+		r.threshold_days, r.action_type, r.task_title, r.message, r.event_query, r.shift_minutes,
+		r.is_active, r.last_fired_at, r.created_at
+	FROM automation_rules r
+	JOIN key_results kr ON kr.id = r.key_result_id
+`
+
+func NewService(db *sqlx.DB, okrService *okr.Service, calendarService *calendar.Service) *Service {
+	return &Service{
+		db:		db,
+		okr:		okrService,
+		calendar:	calendarService,
+	}
+}
+
+func isKnown(value string, vocabulary []string) bool {
+	for _, v := range vocabulary {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) validate(ctx context.Context, rule *Rule) error {
+	if !isKnown(rule.ConditionType, Conditions) {
+		return ErrUnknownCondition
+	}
+	if !isKnown(rule.ActionType, Actions) {
+		return ErrUnknownAction
+	}
+	if rule.ThresholdDays <= 0 || rule.ThresholdDays > maxThresholdDays {
+		return fmt.Errorf("%w: количество дней должно быть от 1 до %d", ErrInvalidRule, maxThresholdDays)
+	}
+
+	keyResult, err := s.okr.GetKeyResultByID(ctx, rule.UserID, rule.KeyResultID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	rule.KeyResultTitle = keyResult.Title
+
+	if rule.ConditionType == ConditionKeyResultDeadlineNear && keyResult.Deadline == nil {
+		return fmt.Errorf("%w: у ключевого результата нет дедлайна", ErrInvalidRule)
+	}
+
+	if rule.ActionType == ActionShiftEvent {
+		if rule.EventQuery == nil || strings.TrimSpace(*rule.EventQuery) == "" {
+			return fmt.Errorf("%w: укажите, какое событие сдвигать", ErrInvalidRule)
+		}
+		if rule.ShiftMinutes == nil || *rule.ShiftMinutes == 0 || abs(*rule.ShiftMinutes) > maxShiftMinutes {
+			return fmt.Errorf("%w: сдвиг события должен быть ненулевым и не больше недели", ErrInvalidRule)
+		}
+	}
+
+	if strings.TrimSpace(rule.Name) == "" {
+		rule.Name = rule.Describe()
+	}
+
+	return nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func (s *Service) CreateRule(ctx context.Context, rule Rule) (*Rule, error) {
+	if err := s.validate(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	var count int
+	err := s.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM automation_rules WHERE user_id = $1 AND is_active = TRUE`, rule.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете правил: %v", err)
+	}
+	if count >= maxRulesPerUser {
+		return nil, ErrTooManyRules
+	}
+
+	var id int64
+	err = s.db.GetContext(ctx, &id, `
+		INSERT INTO automation_rules (user_id, name, condition_type, key_result_id, threshold_days,
+			action_type, task_title, message, event_query, shift_minutes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, rule.UserID, rule.Name, rule.ConditionType, rule.KeyResultID, rule.ThresholdDays,
+		rule.ActionType, rule.TaskTitle, rule.Message, rule.EventQuery, rule.ShiftMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании правила: %v", err)
+	}
+
+	return s.GetRule(ctx, rule.UserID, id)
+}
+
+func (s *Service) GetRule(ctx context.Context, userID, ruleID int64) (*Rule, error) {
+	var rule Rule
+	err := s.db.GetContext(ctx, &rule, ruleSelect+` WHERE r.id = $1 AND r.user_id = $2`, ruleID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении правила: %v", err)
+	}
+	return &rule, nil
+}
+
+func (s *Service) GetRules(ctx context.Context, userID int64) ([]Rule, error) {
+	var rules []Rule
+	err := s.db.SelectContext(ctx, &rules, ruleSelect+`
+		WHERE r.user_id = $1 AND r.is_active = TRUE
+		ORDER BY r.created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении правил: %v", err)
+	}
+	return rules, nil
+}
+
+func (s *Service) DeleteRule(ctx context.Context, userID, ruleID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE automation_rules SET is_active = FALSE
+		WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+	`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении правила: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+func (r *Rule) Describe() string {
+	var condition string
+	switch r.ConditionType {
+	case ConditionKeyResultStale:
+		condition = fmt.Sprintf("прогресс по «%s» не менялся %d дн.", r.KeyResultTitle, r.ThresholdDays)
+	case ConditionKeyResultDeadlineNear:
+		condition = fmt.Sprintf("до дедлайна «%s» осталось %d дн., а он не выполнен", r.KeyResultTitle, r.ThresholdDays)
+	default:
+		condition = r.ConditionType
+	}
+
+	var action string
+	switch r.ActionType {
+	case ActionCreateTask:
+		action = fmt.Sprintf("создать задачу «%s»", r.taskTitle())
+	case ActionSendReminder:
+		action = "прислать напоминание"
+	case ActionShiftEvent:
+		action = fmt.Sprintf("сдвинуть событие «%s» на %s", valueOrEmpty(r.EventQuery), formatShift(valueOrZero(r.ShiftMinutes)))
+	default:
+		action = r.ActionType
+	}
+
+	return fmt.Sprintf("Если %s → %s", condition, action)
+}
+
+func (r *Rule) taskTitle() string {
+	if r.TaskTitle != nil && strings.TrimSpace(*r.TaskTitle) != "" {
+		return *r.TaskTitle
+	}
+	return fmt.Sprintf("Сдвинуть с места «%s»", r.KeyResultTitle)
+}
+
+func valueOrEmpty(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func valueOrZero(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func formatShift(minutes int) string {
+	sign := "+"
+	if minutes < 0 {
+		sign = "−"
+		minutes = -minutes
+	}
+	if minutes%(24*60) == 0 {
+		return fmt.Sprintf("%s%d дн.", sign, minutes/(24*60))
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%s%d ч", sign, minutes/60)
+	}
+	return fmt.Sprintf("%s%d мин", sign, minutes)
+}
+
+func FormatRules(rules []Rule) string {
+	if len(rules) == 0 {
+		return "🤖 Правил автоматизации пока нет. Например: «если по KR X нет прогресса 3 дня — создай задачу»"
+	}
+
+	var b strings.Builder
+	b.WriteString("🤖 *Правила автоматизации:*\n")
+	for _, rule := range rules {
+		b.WriteString(fmt.Sprintf("\n%d. %s", rule.ID, rule.Describe()))
+		if rule.LastFiredAt != nil {
+			b.WriteString(fmt.Sprintf("\n   последний запуск: %s", rule.LastFiredAt.In(time.Local).Format("02.01 15:04")))
+		}
+	}
+	return b.String()
+}
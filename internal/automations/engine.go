@@ -0,0 +1,184 @@
+package automations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const eventSearchWindow = 7 * 24 * time.Hour
+
+type keyResultState struct {
+	Progress	float64		`db:"progress"`
+	Target		float64		`db:"target"`
+	Deadline	*time.Time	`db:"deadline"`
+	LastChange	time.Time	`db:"last_change"`
+}
+
+func (s *Service) StartRuleEngine(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"automation_rules",
+		Spec:		"0 * * * *",
+		Run: func(ctx context.Context) {
+			s.evaluateAllRules(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущен движок правил автоматизации")
+}
+
+func (s *Service) evaluateAllRules(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	var rules []Rule
+	err := s.db.SelectContext(ctx, &rules, ruleSelect+` WHERE r.is_active = TRUE AND kr.deleted_at IS NULL ORDER BY r.user_id, r.id`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении правил автоматизации: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range rules {
+		if _, err := s.runRule(ctx, &rules[i], now, sendMessageFunc); err != nil {
+			logrus.Errorf("Ошибка при выполнении правила %d пользователя %d: %v", rules[i].ID, rules[i].UserID, err)
+		}
+	}
+}
+
+func (s *Service) EvaluateRules(ctx context.Context, userID int64, sendMessageFunc func(chatID int64, text string) error) ([]Rule, error) {
+	rules, err := s.GetRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var fired []Rule
+	for i := range rules {
+		ok, err := s.runRule(ctx, &rules[i], now, sendMessageFunc)
+		if err != nil {
+			logrus.Errorf("Ошибка при выполнении правила %d пользователя %d: %v", rules[i].ID, userID, err)
+			continue
+		}
+		if ok {
+			fired = append(fired, rules[i])
+		}
+	}
+	return fired, nil
+}
+
+func (s *Service) runRule(ctx context.Context, rule *Rule, now time.Time, sendMessageFunc func(chatID int64, text string) error) (bool, error) {
+	matched, err := s.matches(ctx, rule, now)
+	if err != nil || !matched {
+		return false, err
+	}
+
+	result, err := s.execute(ctx, rule, now)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE automation_rules SET last_fired_at = $2 WHERE id = $1`, rule.ID, now); err != nil {
+		return false, fmt.Errorf("ошибка при сохранении времени запуска правила: %v", err)
+	}
+	rule.LastFiredAt = &now
+
+	if sendMessageFunc != nil {
+		text := fmt.Sprintf("🤖 *Сработало правило «%s»*\n\n%s", rule.Name, result)
+		if err := sendMessageFunc(rule.UserID, text); err != nil {
+			logrus.Errorf("Ошибка при отправке уведомления о правиле %d пользователю %d: %v", rule.ID, rule.UserID, err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Service) matches(ctx context.Context, rule *Rule, now time.Time) (bool, error) {
+	var state keyResultState
+	err := s.db.GetContext(ctx, &state, `
+		SELECT kr.progress, kr.target, kr.deadline,
+			GREATEST(kr.created_at, COALESCE(MAX(l.created_at), kr.created_at)) AS last_change
+		FROM key_results kr
+		LEFT JOIN key_result_progress_log l ON l.key_result_id = kr.id
+		WHERE kr.id = $1
+		GROUP BY kr.id
+	`, rule.KeyResultID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при получении состояния ключевого результата: %v", err)
+	}
+
+	if state.Target > 0 && state.Progress >= state.Target {
+		return false, nil
+	}
+
+	threshold := time.Duration(rule.ThresholdDays) * 24 * time.Hour
+
+	switch rule.ConditionType {
+	case ConditionKeyResultStale:
+		reference := state.LastChange
+		if rule.LastFiredAt != nil && rule.LastFiredAt.After(reference) {
+			reference = *rule.LastFiredAt
+		}
+		return now.Sub(reference) >= threshold, nil
+	case ConditionKeyResultDeadlineNear:
+		if state.Deadline == nil || state.Deadline.Before(now) {
+			return false, nil
+		}
+		windowStart := state.Deadline.Add(-threshold)
+		if now.Before(windowStart) {
+			return false, nil
+		}
+		return rule.LastFiredAt == nil || rule.LastFiredAt.Before(windowStart), nil
+	default:
+		return false, ErrUnknownCondition
+	}
+}
+
+func (s *Service) execute(ctx context.Context, rule *Rule, now time.Time) (string, error) {
+	switch rule.ActionType {
+	case ActionCreateTask:
+		title := rule.taskTitle()
+		deadline := time.Date(now.Year(), now.Month(), now.Day()+1, 23, 59, 0, 0, now.Location())
+		if _, err := s.okr.CreateTask(ctx, rule.UserID, rule.KeyResultID, title, 1, "раз", &deadline); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("📝 Создана задача «%s» со сроком до %s", title, deadline.Format("02.01")), nil
+	case ActionSendReminder:
+		if rule.Message != nil && strings.TrimSpace(*rule.Message) != "" {
+			return "🔔 " + *rule.Message, nil
+		}
+		return fmt.Sprintf("🔔 Пора вернуться к «%s»", rule.KeyResultTitle), nil
+	case ActionShiftEvent:
+		return s.shiftEvent(ctx, rule, now)
+	default:
+		return "", ErrUnknownAction
+	}
+}
+
+func (s *Service) shiftEvent(ctx context.Context, rule *Rule, now time.Time) (string, error) {
+	query := strings.ToLower(strings.TrimSpace(valueOrEmpty(rule.EventQuery)))
+	shift := time.Duration(valueOrZero(rule.ShiftMinutes)) * time.Minute
+
+	events, err := s.calendar.GetEventsByDateRange(ctx, rule.UserID, now, now.Add(eventSearchWindow))
+	if err != nil {
+		return "", err
+	}
+
+	for _, event := range events {
+		if !strings.Contains(strings.ToLower(event.Title), query) || event.StartTime.Before(now) {
+			continue
+		}
+
+		start := event.StartTime.Add(shift)
+		end := event.EndTime.Add(shift)
+		err := s.calendar.UpdateEvent(ctx, rule.UserID, event.ID, event.Title, event.Description,
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("📅 Событие «%s» перенесено на %s", event.Title, start.In(time.Local).Format("02.01 15:04")), nil
+	}
+
+	return fmt.Sprintf("📅 Не нашел ближайшее событие «%s», переносить нечего", valueOrEmpty(rule.EventQuery)), nil
+}
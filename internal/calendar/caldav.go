@@ -0,0 +1,315 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CalDAVSource		= "caldav"
+	caldavPastWindow	= 7 * 24 * time.Hour
+	caldavFutureWindow	= 90 * 24 * time.Hour
+	caldavMaxResponse	= 10 << 20
+)
+
+var ErrCalDAVNotConnected = errors.New("календарь CalDAV не подключен")
+
+type CalDAVAccount struct {
+	UserID		int64		`db:"user_id" json:"-"`
+	URL		string		`db:"url" json:"url"`
+	Username	string		`db:"username" json:"username"`
+	Password	string		`db:"password" json:"-"`
+	LastImportAt	*time.Time	`db:"last_import_at" json:"last_import_at,omitempty"`
+	LastError	*string		`db:"last_error" json:"last_error,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type caldavMultistatus struct {
+	Responses []struct {
+		Href		string	`xml:"href"`
+		CalendarData	string	`xml:"propstat>prop>calendar-data"`
+	} `xml:"response"`
+}
+
+var caldavHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func (s *Service) ConnectCalDAV(ctx context.Context, userID int64, rawURL, username, password string) (int, error) {
+	account := &CalDAVAccount{
+		UserID:		userID,
+		URL:		normalizeCalDAVURL(rawURL),
+		Username:	strings.TrimSpace(username),
+		Password:	password,
+	}
+	parsed, err := url.Parse(account.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return 0, fmt.Errorf("укажите ссылку на календарь CalDAV или .ics (https://...)")
+	}
+
+	events, err := fetchCalDAVEvents(ctx, account, time.Now().Add(-caldavPastWindow), time.Now().Add(caldavFutureWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO caldav_accounts (user_id, url, username, password)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			url = $2, username = $3, password = $4, last_error = NULL, created_at = NOW()
+	`, userID, account.URL, account.Username, account.Password)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при сохранении календаря CalDAV: %v", err)
+	}
+
+	imported, err := s.storeCalDAVEvents(ctx, userID, events, time.Now().Add(-caldavPastWindow), time.Now().Add(caldavFutureWindow))
+	if err != nil {
+		return 0, err
+	}
+	s.recordCalDAVImport(ctx, userID, nil)
+	return imported, nil
+}
+
+func (s *Service) DisconnectCalDAV(ctx context.Context, userID int64) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM caldav_accounts WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отключении календаря CalDAV: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrCalDAVNotConnected
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM events WHERE user_id = $1 AND external_source = $2`, userID, CalDAVSource); err != nil {
+		return fmt.Errorf("ошибка при удалении импортированных событий: %v", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Service) GetCalDAVAccount(ctx context.Context, userID int64) (*CalDAVAccount, error) {
+	var account CalDAVAccount
+	err := s.db.GetContext(ctx, &account, `
+		SELECT user_id, url, username, password, last_import_at, last_error, created_at
+		FROM caldav_accounts WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCalDAVNotConnected
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении календаря CalDAV: %v", err)
+	}
+	return &account, nil
+}
+
+func (s *Service) StartCalDAVImport(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:		"caldav_import",
+		Spec:		"@every 15m",
+		Jitter:		time.Minute,
+		Run:		s.importAllCalDAV,
+	})
+
+	logrus.Info("Запущен импорт календарей CalDAV")
+}
+
+func (s *Service) importAllCalDAV(ctx context.Context) {
+	var accounts []CalDAVAccount
+	err := s.db.SelectContext(ctx, &accounts, `
+		SELECT user_id, url, username, password, last_import_at, last_error, created_at FROM caldav_accounts
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении календарей CalDAV: %v", err)
+		return
+	}
+
+	for i := range accounts {
+		from, to := time.Now().Add(-caldavPastWindow), time.Now().Add(caldavFutureWindow)
+		events, err := fetchCalDAVEvents(ctx, &accounts[i], from, to)
+		if err == nil {
+			_, err = s.storeCalDAVEvents(ctx, accounts[i].UserID, events, from, to)
+		}
+		if err != nil {
+			logrus.Warnf("Ошибка импорта CalDAV для пользователя %d: %v", accounts[i].UserID, err)
+		}
+		s.recordCalDAVImport(ctx, accounts[i].UserID, err)
+	}
+}
+
+func (s *Service) storeCalDAVEvents(ctx context.Context, userID int64, events []ICSEvent, from, to time.Time) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	seen := make([]string, 0, len(events))
+	imported := 0
+	for _, event := range events {
+		if event.Cancelled || event.End.Before(from) || event.Start.After(to) {
+			continue
+		}
+		title := event.Summary
+		if title == "" {
+			title = "Без названия"
+		}
+		if runes := []rune(title); len(runes) > 255 {
+			title = string(runes[:255])
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO events (id, user_id, title, description, start_time, end_time, created_at, external_source, external_uid)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW(), $7, $8)
+			ON CONFLICT (user_id, external_source, external_uid) WHERE external_uid IS NOT NULL
+			DO UPDATE SET title = $3, description = $4, start_time = $5, end_time = $6, updated_at = NOW(),
+				reminder_sent = events.reminder_sent AND events.start_time = $5
+		`, uuid.New().String(), userID, title, event.Description, event.Start, event.End, CalDAVSource, event.UID)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка при сохранении события из CalDAV: %v", err)
+		}
+		seen = append(seen, event.UID)
+		imported++
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM events
+		WHERE user_id = $1 AND external_source = $2
+			AND start_time BETWEEN $3 AND $4
+			AND NOT (external_uid = ANY($5))
+	`, userID, CalDAVSource, from, to, pq.Array(seen))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при удалении событий, исчезнувших из CalDAV: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при сохранении событий из CalDAV: %v", err)
+	}
+	return imported, nil
+}
+
+func (s *Service) recordCalDAVImport(ctx context.Context, userID int64, importErr error) {
+	var lastError *string
+	if importErr != nil {
+		message := importErr.Error()
+		lastError = &message
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE caldav_accounts
+		SET last_error = $2, last_import_at = CASE WHEN $2::TEXT IS NULL THEN NOW() ELSE last_import_at END
+		WHERE user_id = $1
+	`, userID, lastError)
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении результата импорта CalDAV: %v", err)
+	}
+}
+
+func fetchCalDAVEvents(ctx context.Context, account *CalDAVAccount, from, to time.Time) ([]ICSEvent, error) {
+	if isICSURL(account.URL) {
+		body, err := caldavRequest(ctx, account, http.MethodGet, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return ParseICS(string(body))
+	}
+
+	query := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data>
+      <C:expand start="%[1]s" end="%[2]s"/>
+    </C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="%[1]s" end="%[2]s"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`, from.UTC().Format(icsDateTimeUTC), to.UTC().Format(icsDateTimeUTC))
+
+	headers := map[string]string{"Depth": "1", "Content-Type": "application/xml; charset=utf-8"}
+	body, err := caldavRequest(ctx, account, "REPORT", []byte(query), headers)
+	if err != nil {
+		return nil, err
+	}
+
+	var multistatus caldavMultistatus
+	if err := xml.Unmarshal(body, &multistatus); err != nil {
+		return nil, fmt.Errorf("сервер вернул некорректный ответ CalDAV: %v", err)
+	}
+
+	var events []ICSEvent
+	for _, response := range multistatus.Responses {
+		if strings.TrimSpace(response.CalendarData) == "" {
+			continue
+		}
+		parsed, err := ParseICS(response.CalendarData)
+		if err != nil {
+			logrus.Warnf("Пропущено событие CalDAV %s: %v", response.Href, err)
+			continue
+		}
+		events = append(events, parsed...)
+	}
+	return events, nil
+}
+
+func caldavRequest(ctx context.Context, account *CalDAVAccount, method string, payload []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, account.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("некорректная ссылка на календарь: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if account.Username != "" {
+		req.SetBasicAuth(account.Username, account.Password)
+	}
+
+	resp, err := caldavHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("календарь недоступен: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("календарь отклонил логин или пароль приложения")
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("календарь ответил статусом %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, caldavMaxResponse))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении календаря: %v", err)
+	}
+	return body, nil
+}
+
+func normalizeCalDAVURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if strings.HasPrefix(rawURL, "webcal://") {
+		return "https://" + strings.TrimPrefix(rawURL, "webcal://")
+	}
+	return rawURL
+}
+
+func isICSURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && strings.HasSuffix(strings.ToLower(parsed.Path), ".ics")
+}
@@ -2,13 +2,17 @@ package calendar
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"telegrambot/internal/jobs"
 	"telegrambot/pkg/config"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/api/calendar/v3"
 )
 
 type Service struct {
@@ -27,6 +31,10 @@ type Event struct {
 	CreatedAt	time.Time	`db:"created_at"`
 	GoogleEventID	string		`db:"google_event_id"`
 	ReminderSent	bool		`db:"reminder_sent"`
+	UpdatedAt	time.Time	`db:"updated_at"`
+	GoogleUpdatedAt	*time.Time	`db:"google_updated_at"`
+	SyncPending	bool		`db:"google_sync_pending"`
+	Guests		[]EventGuest	`db:"-"`
 }
 
 func NewService(db *sqlx.DB, cfg *config.Config) *Service {
@@ -83,17 +91,15 @@ func (s *Service) CreateEvent(ctx context.Context, userID int64, title, descript
 		return "", fmt.Errorf("ошибка при сохранении события: %v", err)
 	}
 
-	if s.googleClient != nil {
-		googleEventID, err := s.googleClient.CreateEvent(ctx, userID, event)
+	if s.googleClient != nil && s.googleClient.isLinked(ctx, userID) {
+		googleEvent, err := s.googleClient.CreateEvent(ctx, userID, event)
 		if err != nil {
-			logrus.Warnf("Не удалось создать событие в Google Calendar: %v", err)
+			logrus.Warnf("Не удалось создать событие в Google Calendar, отправим при следующей синхронизации: %v", err)
+			s.googleClient.markPending(ctx, eventID)
+		} else if err := s.googleClient.markSynced(ctx, eventID, googleEvent); err != nil {
+			logrus.Warnf("Событие создано в Google Calendar, но связь не сохранена: %v", err)
 		} else {
-
-			updateQuery := `
-				UPDATE events SET google_event_id = $1 WHERE id = $2
-			`
-			_, _ = s.db.ExecContext(ctx, updateQuery, googleEventID, eventID)
-			logrus.Infof("Событие успешно создано в Google Calendar (ID: %s)", googleEventID)
+			logrus.Infof("Событие успешно создано в Google Calendar (ID: %s)", googleEvent.Id)
 		}
 	}
 
@@ -156,40 +162,48 @@ func (s *Service) MarkReminderSent(ctx context.Context, eventID string) error {
 	return nil
 }
 
-func (s *Service) StartReminderChecker(sendMessage func(int64, string) error) {
-	go func() {
-		ticker := time.NewTicker(20 * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			ctx := context.Background()
-			events, err := s.CheckReminders(ctx)
-			if err != nil {
-				logrus.Errorf("Ошибка при проверке напоминаний: %v", err)
-				continue
-			}
+func (s *Service) StartReminderChecker(jm *jobs.Manager, sendMessage func(int64, string) error) {
+	jm.Register(jobs.Job{
+		Name:		"calendar_reminders",
+		Spec:		"@every 20s",
+		Run: func(ctx context.Context) {
+			s.sendDueReminders(ctx, sendMessage)
+		},
+	})
+}
+
+func (s *Service) sendDueReminders(ctx context.Context, sendMessage func(int64, string) error) {
+	events, err := s.CheckReminders(ctx)
+	if err != nil {
+		logrus.Errorf("Ошибка при проверке напоминаний: %v", err)
+		return
+	}
+	if err := s.AttachGuests(ctx, events); err != nil {
+		logrus.Warnf("Не удалось получить гостей для напоминаний: %v", err)
+	}
 
-			for _, event := range events {
-				message := fmt.Sprintf("⏰ Напоминание: у вас через час событие '%s' в %s",
-					event.Title, event.StartTime.Format("15:04"))
+	for _, event := range events {
+		message := fmt.Sprintf("⏰ Напоминание: у вас через час событие '%s' в %s",
+			event.Title, event.StartTime.Format("15:04"))
 
-				if event.Description != "" {
-					message += fmt.Sprintf("\nОписание: %s", event.Description)
-				}
+		if event.Description != "" {
+			message += fmt.Sprintf("\nОписание: %s", event.Description)
+		}
+		if guests := FormatGuests(event.Guests); guests != "" {
+			message += "\n" + guests
+		}
 
-				err := sendMessage(event.UserID, message)
-				if err != nil {
-					logrus.Errorf("Ошибка при отправке напоминания пользователю %d: %v", event.UserID, err)
-					continue
-				}
+		err := sendMessage(event.UserID, message)
+		if err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания пользователю %d: %v", event.UserID, err)
+			continue
+		}
 
-				err = s.MarkReminderSent(ctx, event.ID)
-				if err != nil {
-					logrus.Errorf("Ошибка при обновлении статуса напоминания: %v", err)
-				}
-			}
+		err = s.MarkReminderSent(ctx, event.ID)
+		if err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса напоминания: %v", err)
 		}
-	}()
+	}
 }
 
 func (s *Service) GetGoogleAuthURL(userID int64, callbackType string) (string, error) {
@@ -231,7 +245,8 @@ func (s *Service) GetEventsByDate(ctx context.Context, userID int64, date time.T
 
 func (s *Service) GetEventByID(ctx context.Context, userID int64, eventID string) (*Event, error) {
 	query := `
-		SELECT id, user_id, title, description, start_time, end_time, created_at, google_event_id
+		SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at,
+			COALESCE(google_event_id, '') AS google_event_id, updated_at, google_updated_at, google_sync_pending
 		FROM events
 		WHERE id = $1 AND user_id = $2
 	`
@@ -262,6 +277,24 @@ func (s *Service) GetEventsByDateRange(ctx context.Context, userID int64, startD
 	return events, nil
 }
 
+func (s *Service) GetPastEvents(ctx context.Context, userIDs []int64, limit, offset int) ([]Event, error) {
+	query := `
+		SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at
+		FROM events
+		WHERE user_id = ANY($1) AND end_time < NOW()
+		ORDER BY start_time DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	events := make([]Event, 0)
+	err := s.db.SelectContext(ctx, &events, query, pq.Array(userIDs), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении прошедших событий: %v", err)
+	}
+
+	return events, nil
+}
+
 func (s *Service) UpdateEvent(ctx context.Context, userID int64, eventID, title, description, startTimeStr, endTimeStr string) error {
 
 	event, err := s.GetEventByID(ctx, userID, eventID)
@@ -284,7 +317,7 @@ func (s *Service) UpdateEvent(ctx context.Context, userID int64, eventID, title,
 
 	query := `
 		UPDATE events
-		SET title = $1, description = $2, start_time = $3, end_time = $4
+		SET title = $1, description = $2, start_time = $3, end_time = $4, updated_at = NOW()
 		WHERE id = $5 AND user_id = $6
 	`
 
@@ -293,32 +326,39 @@ func (s *Service) UpdateEvent(ctx context.Context, userID int64, eventID, title,
 		return fmt.Errorf("ошибка при обновлении события: %v", err)
 	}
 
-	if s.googleClient != nil && event.GoogleEventID != "" {
-		logrus.Infof("Отправка обновления в Google Calendar: ID=%s, GoogleID=%s",
-			eventID, event.GoogleEventID)
-
-		updatedEvent := &Event{
-			ID:		event.ID,
-			UserID:		userID,
-			Title:		title,
-			Description:	description,
-			StartTime:	startTime,
-			EndTime:	endTime,
-			GoogleEventID:	event.GoogleEventID,
-		}
+	if !startTime.Equal(event.StartTime) || !endTime.Equal(event.EndTime) || title != event.Title || description != event.Description {
+		s.resendGuestInvites(ctx, eventID)
+	}
 
-		err = s.googleClient.UpdateEvent(ctx, userID, updatedEvent)
-		if err != nil {
-			logrus.Warnf("Не удалось обновить событие в Google Calendar: %v", err)
+	if s.googleClient == nil || !s.googleClient.isLinked(ctx, userID) {
+		return nil
+	}
 
-		} else {
-			logrus.Infof("Событие успешно обновлено в Google Calendar: ID=%s, GoogleID=%s",
-				eventID, event.GoogleEventID)
-		}
-	} else if s.googleClient != nil {
-		logrus.Warnf("Событие ID=%s не имеет GoogleEventID, обновление в Google Calendar пропущено", eventID)
+	updatedEvent := &Event{
+		ID:		event.ID,
+		UserID:		userID,
+		Title:		title,
+		Description:	description,
+		StartTime:	startTime,
+		EndTime:	endTime,
+		GoogleEventID:	event.GoogleEventID,
+	}
+
+	var googleEvent *calendar.Event
+	if event.GoogleEventID != "" {
+		googleEvent, err = s.googleClient.UpdateEvent(ctx, userID, updatedEvent)
+	} else {
+		googleEvent, err = s.googleClient.CreateEvent(ctx, userID, updatedEvent)
+	}
+	if err != nil {
+		logrus.Warnf("Не удалось обновить событие %s в Google Calendar, отправим при следующей синхронизации: %v", eventID, err)
+		s.googleClient.markPending(ctx, eventID)
+		return nil
 	}
 
+	if err := s.googleClient.markSynced(ctx, eventID, googleEvent); err != nil {
+		logrus.Warnf("Событие обновлено в Google Calendar, но состояние не сохранено: %v", err)
+	}
 	return nil
 }
 
@@ -332,8 +372,8 @@ func (s *Service) DeleteEvent(ctx context.Context, userID int64, eventID string)
 	if s.googleClient != nil && event.GoogleEventID != "" {
 		err = s.googleClient.DeleteEvent(ctx, userID, event.GoogleEventID)
 		if err != nil {
-			logrus.Warnf("Не удалось удалить событие из Google Calendar: %v", err)
-
+			logrus.Warnf("Не удалось удалить событие из Google Calendar, повторим при следующей синхронизации: %v", err)
+			s.googleClient.queueDeletion(ctx, userID, event.GoogleEventID, err)
 		}
 	}
 
@@ -366,25 +406,34 @@ func (s *Service) DeleteEventsByDateRange(ctx context.Context, userID int64, sta
 	return deletedCount, nil
 }
 
-func (s *Service) StartGoogleCalendarSync() {
+func (s *Service) StartGoogleCalendarSync(jm *jobs.Manager) {
 	if s.googleClient == nil {
 		logrus.Warn("Google Calendar не интегрирован, синхронизация не запущена")
 		return
 	}
 
-	go func() {
+	jm.Register(jobs.Job{
+		Name:		"google_calendar_sync",
+		Spec:		"@every 1m",
+		Jitter:		10 * time.Second,
+		RunOnStart:	true,
+		Run:		s.syncGoogleCalendarForAllUsers,
+	})
 
-		ticker := time.NewTicker(1 * time.Minute)
-		defer ticker.Stop()
+	if !s.pushEnabled() {
+		logrus.Info("Запущена периодическая синхронизация с Google Calendar")
+		return
+	}
 
-		s.syncGoogleCalendarForAllUsers()
+	jm.Register(jobs.Job{
+		Name:		"google_calendar_watch",
+		Spec:		"@every 1h",
+		Jitter:		time.Minute,
+		RunOnStart:	true,
+		Run:		s.renewWatchChannels,
+	})
 
-		for range ticker.C {
-			s.syncGoogleCalendarForAllUsers()
-		}
-	}()
-
-	logrus.Info("Запущена периодическая синхронизация с Google Calendar")
+	logrus.Info("Запущена синхронизация с Google Calendar по push-уведомлениям с резервным опросом")
 }
 
 func (s *Service) SyncGoogleCalendarForUser(ctx context.Context, userID int64) error {
@@ -395,13 +444,64 @@ func (s *Service) SyncGoogleCalendarForUser(ctx context.Context, userID int64) e
 	return s.googleClient.SyncEventsFromGoogleCalendar(ctx, userID)
 }
 
-func (s *Service) syncGoogleCalendarForAllUsers() {
-	ctx := context.Background()
+func (s *Service) ResetGoogleLink(ctx context.Context, userID int64) (bool, error) {
+	if s.googleClient != nil {
+		var channel watchChannel
+		err := s.db.GetContext(ctx, &channel, `
+			SELECT user_id, channel_id, resource_id, token, expires_at FROM google_watch_channels WHERE user_id = $1
+		`, userID)
+		if err == nil {
+			if err := s.googleClient.stopChannel(ctx, &channel); err != nil {
+				logrus.Warnf("Не удалось остановить канал Google %s: %v", channel.ChannelID, err)
+			}
+		}
+	}
 
-	query := `SELECT DISTINCT user_id FROM google_tokens`
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM google_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при удалении токена Google: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM google_sync_state WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("ошибка при сбросе состояния синхронизации Google: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM google_watch_channels WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("ошибка при удалении канала уведомлений Google: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM google_event_deletions WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("ошибка при сбросе очереди удалений Google: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE events SET google_sync_pending = FALSE WHERE user_id = $1 AND google_sync_pending`, userID); err != nil {
+		return false, fmt.Errorf("ошибка при сбросе очереди синхронизации Google: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка при сбросе привязки Google: %v", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+func (s *Service) syncGoogleCalendarForAllUsers(ctx context.Context) {
+	query := `
+		SELECT t.user_id
+		FROM google_tokens t
+		LEFT JOIN google_watch_channels c ON c.user_id = t.user_id AND c.expires_at > NOW()
+		LEFT JOIN google_sync_state st ON st.user_id = t.user_id
+		WHERE t.invalid_at IS NULL AND (c.user_id IS NULL
+			OR st.last_sync_time IS NULL
+			OR st.last_sync_time < $1
+			OR EXISTS(SELECT 1 FROM events e WHERE e.user_id = t.user_id AND e.google_sync_pending)
+			OR EXISTS(SELECT 1 FROM google_event_deletions d WHERE d.user_id = t.user_id))
+	`
 	var userIDs []int64
 
-	err := s.db.SelectContext(ctx, &userIDs, query)
+	err := s.db.SelectContext(ctx, &userIDs, query, time.Now().Add(-watchFallbackPoll))
 	if err != nil {
 		logrus.Errorf("Ошибка при получении списка пользователей для синхронизации Google Calendar: %v", err)
 		return
@@ -411,7 +511,7 @@ func (s *Service) syncGoogleCalendarForAllUsers() {
 
 	for _, userID := range userIDs {
 		err := s.SyncGoogleCalendarForUser(ctx, userID)
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrSyncInProgress) {
 			logrus.Errorf("Ошибка при синхронизации Google Calendar для пользователя %d: %v", userID, err)
 		}
 	}
@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/health"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/worklocation"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDigestTime	= 8 * time.Hour
+	digestWakeOffset	= 30 * time.Minute
+	earliestDigestTime	= 6 * time.Hour
+	latestDigestTime	= 11 * time.Hour
+)
+
+func (s *Service) StartDailyDigest(jm *jobs.Manager, locations *worklocation.Service, sleep *health.Service, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"daily_digest",
+		Spec:		"*/15 5-11 * * *",
+		Run: func(ctx context.Context) {
+			s.sendDailyDigests(ctx, locations, sleep, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущена ежедневная сводка по рабочему дню с учетом времени пробуждения")
+}
+
+func (s *Service) DigestTime(ctx context.Context, sleep *health.Service, userID int64) time.Duration {
+	wake, ok, err := sleep.TypicalWakeTime(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при расчете времени пробуждения пользователя %d: %v", userID, err)
+	}
+	if !ok {
+		return defaultDigestTime
+	}
+
+	target := wake + digestWakeOffset
+	if target < earliestDigestTime {
+		return earliestDigestTime
+	}
+	if target > latestDigestTime {
+		return latestDigestTime
+	}
+	return target.Truncate(15 * time.Minute)
+}
+
+func (s *Service) sendDailyDigests(ctx context.Context, locations *worklocation.Service, sleep *health.Service, sendMessageFunc func(chatID int64, text string) error) {
+	userIDs, err := locations.GetTrackingUsers(ctx)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении получателей ежедневной сводки: %v", err)
+		return
+	}
+
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, userID := range userIDs {
+		if now.Before(midnight.Add(s.DigestTime(ctx, sleep, userID))) {
+			continue
+		}
+
+		claimed, err := s.claimDigestDelivery(ctx, userID, now)
+		if err != nil {
+			logrus.Errorf("Ошибка при отметке ежедневной сводки для пользователя %d: %v", userID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		digest, err := s.BuildDailyDigest(ctx, locations, sleep, userID, now)
+		if err != nil {
+			logrus.Errorf("Ошибка при формировании ежедневной сводки для пользователя %d: %v", userID, err)
+			s.releaseDigestDelivery(ctx, userID, now)
+			continue
+		}
+
+		if err := sendMessageFunc(userID, digest); err != nil {
+			logrus.Errorf("Ошибка при отправке ежедневной сводки пользователю %d: %v", userID, err)
+			s.releaseDigestDelivery(ctx, userID, now)
+		}
+	}
+}
+
+func (s *Service) claimDigestDelivery(ctx context.Context, userID int64, date time.Time) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO digest_deliveries (user_id, digest_date)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, userID, date.Format("2006-01-02"))
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s *Service) releaseDigestDelivery(ctx context.Context, userID int64, date time.Time) {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM digest_deliveries WHERE user_id = $1 AND digest_date = $2`, userID, date.Format("2006-01-02"))
+	if err != nil {
+		logrus.Errorf("Ошибка при снятии отметки ежедневной сводки для пользователя %d: %v", userID, err)
+	}
+}
+
+func (s *Service) BuildDailyDigest(ctx context.Context, locations *worklocation.Service, sleep *health.Service, userID int64, date time.Time) (string, error) {
+	location, err := locations.GetLocation(ctx, userID, date)
+	if err != nil {
+		return "", err
+	}
+
+	events, err := s.GetEventsByDate(ctx, userID, date)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("☀️ *Доброе утро! План на %s*\n\n", date.Format("02.01.2006")))
+
+	if lastNight, err := sleep.GetSleepForDate(ctx, userID, date); err != nil {
+		logrus.Errorf("Ошибка при получении сна пользователя %d для сводки: %v", userID, err)
+	} else if lastNight != nil {
+		b.WriteString(fmt.Sprintf("😴 Сон: %s\n\n", health.FormatSleepLog(lastNight)))
+	}
+
+	if location != nil {
+		b.WriteString(fmt.Sprintf("📍 Сегодня работаете: %s", worklocation.LocationLabel(location.Location)))
+		if location.Note != nil && *location.Note != "" {
+			b.WriteString(fmt.Sprintf(" (%s)", *location.Note))
+		}
+		b.WriteString("\n\n")
+	} else {
+		b.WriteString("📍 Место работы на сегодня не указано\n\n")
+	}
+
+	if len(events) == 0 {
+		b.WriteString("📅 Событий в календаре нет")
+		return b.String(), nil
+	}
+
+	b.WriteString("📅 *События:*\n")
+	for _, event := range events {
+		b.WriteString(fmt.Sprintf("• %s–%s %s\n", event.StartTime.Format("15:04"), event.EndTime.Format("15:04"), event.Title))
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
@@ -0,0 +1,114 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	feedPathPrefix		= "/api/calendar/feed/"
+	feedPastWindow		= 30 * 24 * time.Hour
+	feedFutureWindow	= 365 * 24 * time.Hour
+)
+
+func (s *Service) FeedToken(ctx context.Context, userID int64) (string, error) {
+	var token string
+	err := s.db.GetContext(ctx, &token, `SELECT token FROM calendar_feed_tokens WHERE user_id = $1`, userID)
+	if err == nil {
+		return token, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("ошибка при получении ссылки на календарь: %v", err)
+	}
+	return s.RotateFeedToken(ctx, userID)
+}
+
+func (s *Service) RotateFeedToken(ctx context.Context, userID int64) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO calendar_feed_tokens (user_id, token)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token = $2, created_at = NOW(), last_accessed_at = NULL
+	`, userID, token)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при создании ссылки на календарь: %v", err)
+	}
+	return token, nil
+}
+
+func (s *Service) FeedURL(token string) string {
+	return s.PublicURL(feedPathPrefix + token + ".ics")
+}
+
+func (s *Service) PublicURL(path string) string {
+	base := strings.TrimRight(s.cfg.PublicBaseURL, "/")
+	if base == "" {
+		base = fmt.Sprintf("https://%s:%s", s.cfg.ServerHost, s.cfg.ServerPort)
+	}
+	return base + path
+}
+
+func (s *Service) FeedHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, feedPathPrefix), ".ics")
+		if token == "" || strings.Contains(token, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		var userID int64
+		err := s.db.GetContext(ctx, &userID, `
+			UPDATE calendar_feed_tokens SET last_accessed_at = NOW() WHERE token = $1 RETURNING user_id
+		`, token)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка при проверке токена ICS-фида: %v", err)
+			http.Error(w, "Внутренняя ошибка", http.StatusInternalServerError)
+			return
+		}
+
+		var events []Event
+		err = s.db.SelectContext(ctx, &events, `
+			SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at, updated_at
+			FROM events
+			WHERE user_id = $1 AND start_time BETWEEN $2 AND $3
+				AND external_source IS DISTINCT FROM 'caldav'
+			ORDER BY start_time
+		`, userID, time.Now().Add(-feedPastWindow), time.Now().Add(feedFutureWindow))
+		if err != nil {
+			logrus.Errorf("Ошибка при получении событий ICS-фида пользователя %d: %v", userID, err)
+			http.Error(w, "Внутренняя ошибка", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="jarvis.ics"`)
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		if r.Method == http.MethodHead {
+			return
+		}
+		if err := WriteICS(w, "Jarvis", events); err != nil {
+			logrus.Warnf("Ошибка при отправке ICS-фида пользователя %d: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	BusyEvent	= "event"
+	BusyMeeting	= "meeting"
+
+	freeSlotStep		= 30 * time.Minute
+	freeSlotDayStart	= 9
+	freeSlotDayEnd		= 21
+	freeSlotsPerDay		= 3
+)
+
+var ErrTimeConflict = errors.New("в это время уже есть другие дела")
+
+var weekdayLabels = []string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+type BusySlot struct {
+	Kind	string		`db:"kind" json:"kind"`
+	ID	string		`db:"id" json:"id"`
+	Title	string		`db:"title" json:"title"`
+	Start	time.Time	`db:"start_time" json:"start_time"`
+	End	time.Time	`db:"end_time" json:"end_time"`
+}
+
+type ConflictError struct {
+	Conflicts []BusySlot
+}
+
+func (e *ConflictError) Error() string {
+	return ErrTimeConflict.Error() + ": " + FormatConflicts(e.Conflicts)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrTimeConflict
+}
+
+func FindBusySlots(ctx context.Context, db sqlx.QueryerContext, userID int64, from, to time.Time) ([]BusySlot, error) {
+	var slots []BusySlot
+	err := sqlx.SelectContext(ctx, db, &slots, `
+		SELECT 'event' AS kind, id, title, start_time, end_time
+		FROM events
+		WHERE user_id = $1 AND start_time < $3 AND end_time > $2
+		UNION ALL
+		SELECT 'meeting' AS kind, id, title, start_time, end_time
+		FROM meetings
+		WHERE (initiator_id = $1 OR participant_id = $1) AND status IN ('pending', 'accepted')
+			AND start_time < $3 AND end_time > $2
+		ORDER BY start_time
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке занятости: %v", err)
+	}
+	return slots, nil
+}
+
+func (s *Service) FindConflicts(ctx context.Context, userID int64, start, end time.Time) ([]BusySlot, error) {
+	return FindBusySlots(ctx, s.db, userID, start, end)
+}
+
+func (s *Service) CreateEventIfFree(ctx context.Context, userID int64, title, description, startTimeStr, endTimeStr string) (string, error) {
+	startTime, err := time.Parse(time.RFC3339, startTimeStr)
+	if err != nil {
+		return "", fmt.Errorf("некорректный формат времени начала: %v", err)
+	}
+	endTime, err := time.Parse(time.RFC3339, endTimeStr)
+	if err != nil {
+		return "", fmt.Errorf("некорректный формат времени окончания: %v", err)
+	}
+
+	conflicts, err := s.FindConflicts(ctx, userID, startTime, endTime)
+	if err != nil {
+		return "", err
+	}
+	if len(conflicts) > 0 {
+		return "", &ConflictError{Conflicts: conflicts}
+	}
+	return s.CreateEvent(ctx, userID, title, description, startTimeStr, endTimeStr)
+}
+
+func (s *Service) SuggestFreeSlots(ctx context.Context, userID int64, from, to time.Time, duration time.Duration, limit int) ([]time.Time, error) {
+	if duration <= 0 {
+		duration = time.Hour
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+
+	busy, err := FindBusySlots(ctx, s.db, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	windows := make([]freeWindow, 0, len(busy))
+	for _, slot := range busy {
+		windows = append(windows, freeWindow{start: slot.Start, end: slot.End})
+	}
+
+	now := time.Now()
+	var slots []time.Time
+	for day := startOfDay(from); day.Before(to) && len(slots) < limit; day = day.AddDate(0, 0, 1) {
+		perDay := 0
+		dayEnd := day.Add(freeSlotDayEnd * time.Hour)
+		for at := day.Add(freeSlotDayStart * time.Hour); !at.Add(duration).After(dayEnd) && perDay < freeSlotsPerDay && len(slots) < limit; at = at.Add(freeSlotStep) {
+			end := at.Add(duration)
+			if at.Before(from) || at.Before(now) || end.After(to) || overlapsAny(at, end, windows) {
+				continue
+			}
+			slots = append(slots, at)
+			windows = append(windows, freeWindow{start: at, end: end})
+			perDay++
+		}
+	}
+	return slots, nil
+}
+
+func FormatConflicts(conflicts []BusySlot) string {
+	parts := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		kind := "событие"
+		if c.Kind == BusyMeeting {
+			kind = "встреча"
+		}
+		parts = append(parts, fmt.Sprintf("%s «%s» (%s–%s)", kind, c.Title, c.Start.Local().Format("02.01 15:04"), c.End.Local().Format("15:04")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func FormatFreeSlots(slots []time.Time, duration time.Duration) string {
+	if len(slots) == 0 {
+		return "Свободного времени в этом промежутке не нашлось"
+	}
+	var b strings.Builder
+	for _, slot := range slots {
+		local := slot.Local()
+		b.WriteString(fmt.Sprintf("• %s, %s %s–%s\n", weekdayLabels[local.Weekday()], local.Format("02.01"), local.Format("15:04"), local.Add(duration).Format("15:04")))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
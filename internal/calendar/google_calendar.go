@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,12 +16,15 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 type GoogleCalendarClient struct {
 	config	*oauth2.Config
 	db	*sqlx.DB
+	syncing	sync.Map
+	dirty	sync.Map
 }
 
 func NewGoogleCalendarClient(credentialsPath string, db *sqlx.DB) (*GoogleCalendarClient, error) {
@@ -53,47 +57,87 @@ func (g *GoogleCalendarClient) HandleAuthCallback(ctx context.Context, code stri
 	return g.saveToken(userID, token)
 }
 
-func (g *GoogleCalendarClient) CreateEvent(ctx context.Context, userID int64, event *Event) (string, error) {
+func (g *GoogleCalendarClient) CreateEvent(ctx context.Context, userID int64, event *Event) (*calendar.Event, error) {
+	srv, err := g.service(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return insertGoogleEvent(ctx, srv, event)
+}
+
+func (g *GoogleCalendarClient) service(ctx context.Context, userID int64) (*calendar.Service, error) {
 	client, err := g.getClient(ctx, userID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
-		return "", fmt.Errorf("не удалось создать сервис календаря: %v", err)
+		return nil, fmt.Errorf("не удалось создать сервис календаря: %v", err)
 	}
+	return srv, nil
+}
 
-	calendarEvent := &calendar.Event{
+func toGoogleEvent(event *Event) *calendar.Event {
+	return &calendar.Event{
 		Summary:	event.Title,
 		Description:	event.Description,
 		Start: &calendar.EventDateTime{
-			DateTime:	event.StartTime.Format(time.RFC3339),
+			DateTime:	event.StartTime.UTC().Format(time.RFC3339),
 			TimeZone:	"UTC",
 		},
 		End: &calendar.EventDateTime{
-			DateTime:	event.EndTime.Format(time.RFC3339),
+			DateTime:	event.EndTime.UTC().Format(time.RFC3339),
 			TimeZone:	"UTC",
 		},
 	}
+}
 
-	calendarID := "primary"
-	createdEvent, err := srv.Events.Insert(calendarID, calendarEvent).Do()
+func insertGoogleEvent(ctx context.Context, srv *calendar.Service, event *Event) (*calendar.Event, error) {
+	createdEvent, err := srv.Events.Insert("primary", toGoogleEvent(event)).Context(ctx).Do()
 	if err != nil {
-		return "", fmt.Errorf("не удалось создать событие: %v", err)
+		return nil, fmt.Errorf("не удалось создать событие: %v", err)
 	}
+	return createdEvent, nil
+}
 
-	return createdEvent.Id, nil
+func updateGoogleEvent(ctx context.Context, srv *calendar.Service, event *Event) (*calendar.Event, error) {
+	updatedEvent, err := srv.Events.Update("primary", event.GoogleEventID, toGoogleEvent(event)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось обновить событие: %w", err)
+	}
+	return updatedEvent, nil
+}
+
+func deleteGoogleEvent(ctx context.Context, srv *calendar.Service, googleEventID string) error {
+	err := srv.Events.Delete("primary", googleEventID).Context(ctx).Do()
+	if err != nil && !isGoogleGone(err) {
+		return fmt.Errorf("не удалось удалить событие из Google Calendar: %v", err)
+	}
+	return nil
+}
+
+func isGoogleGone(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone)
 }
 
 func (g *GoogleCalendarClient) getClient(ctx context.Context, userID int64) (*http.Client, error) {
-	token, err := g.loadToken(userID)
+	token, invalid, err := g.loadToken(userID)
 	if err != nil {
 		return nil, fmt.Errorf("пользователь не авторизован в Google Calendar: %v", err)
 	}
+	if invalid {
+		return nil, ErrGoogleRelinkRequired
+	}
 
 	if token.Expiry.Before(time.Now()) {
 		newToken, err := g.config.TokenSource(ctx, token).Token()
+		if isInvalidGrant(err) {
+			g.markTokenInvalid(ctx, userID, err)
+			return nil, ErrGoogleRelinkRequired
+		}
 		if err != nil {
 			return nil, fmt.Errorf("не удалось обновить токен: %v", err)
 		}
@@ -118,6 +162,9 @@ func (g *GoogleCalendarClient) saveToken(userID int64, token *oauth2.Token) erro
 			refresh_token = COALESCE($3, google_tokens.refresh_token),
 			token_type = $4,
 			expiry = $5,
+			invalid_at = NULL,
+			invalid_reason = NULL,
+			relink_notified_at = NULL,
 			updated_at = NOW()
 	`
 
@@ -136,9 +183,9 @@ func (g *GoogleCalendarClient) saveToken(userID int64, token *oauth2.Token) erro
 	return err
 }
 
-func (g *GoogleCalendarClient) loadToken(userID int64) (*oauth2.Token, error) {
+func (g *GoogleCalendarClient) loadToken(userID int64) (*oauth2.Token, bool, error) {
 	query := `
-		SELECT access_token, refresh_token, token_type, expiry 
+		SELECT access_token, refresh_token, token_type, expiry, invalid_at IS NOT NULL AS invalid
 		FROM google_tokens 
 		WHERE user_id = $1
 	`
@@ -148,11 +195,12 @@ func (g *GoogleCalendarClient) loadToken(userID int64) (*oauth2.Token, error) {
 		RefreshToken	string		`db:"refresh_token"`
 		TokenType	string		`db:"token_type"`
 		Expiry		time.Time	`db:"expiry"`
+		Invalid		bool		`db:"invalid"`
 	}
 
 	err := g.db.Get(&tokenData, query, userID)
 	if err != nil {
-		return nil, fmt.Errorf("токен не найден: %v", err)
+		return nil, false, fmt.Errorf("токен не найден: %v", err)
 	}
 
 	token := &oauth2.Token{
@@ -162,84 +210,20 @@ func (g *GoogleCalendarClient) loadToken(userID int64) (*oauth2.Token, error) {
 		Expiry:		tokenData.Expiry,
 	}
 
-	return token, nil
+	return token, tokenData.Invalid, nil
 }
 
-func adjustTimeForGoogleCalendar(originalTime time.Time, offsetHours int) time.Time {
-
-	return originalTime.Add(time.Duration(-offsetHours) * time.Hour)
-}
-
-func (g *GoogleCalendarClient) UpdateEvent(ctx context.Context, userID int64, event *Event) error {
+func (g *GoogleCalendarClient) UpdateEvent(ctx context.Context, userID int64, event *Event) (*calendar.Event, error) {
 	if event.GoogleEventID == "" {
-		return fmt.Errorf("отсутствует ID события в Google Calendar")
+		return nil, fmt.Errorf("отсутствует ID события в Google Calendar")
 	}
 
-	fmt.Printf("Обновление события: ID=%s, Title=%s, StartTime=%s\n",
-		event.GoogleEventID, event.Title, event.StartTime.Format(time.RFC3339))
-
-	client, err := g.getClient(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("ошибка получения клиента: %v", err)
-	}
-
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := g.service(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("не удалось создать сервис календаря: %v", err)
+		return nil, fmt.Errorf("ошибка получения клиента: %v", err)
 	}
 
-	localLoc, _ := time.LoadLocation("Local")
-	_, localOffset := time.Now().In(localLoc).Zone()
-	localOffsetHours := localOffset / 3600
-
-	fmt.Printf("DEBUG: Локальное смещение часового пояса: %+d часов\n", localOffsetHours)
-
-	existingEvent, err := srv.Events.Get("primary", event.GoogleEventID).Do()
-	if err == nil && existingEvent != nil {
-		fmt.Printf("DEBUG: Существующее событие: StartDateTime=%s, TimeZone=%s\n",
-			existingEvent.Start.DateTime, existingEvent.Start.TimeZone)
-	}
-
-	adjustedStartTime := adjustTimeForGoogleCalendar(event.StartTime, localOffsetHours)
-	adjustedEndTime := adjustTimeForGoogleCalendar(event.EndTime, localOffsetHours)
-
-	fmt.Printf("DEBUG: Исходное время: Start=%s, End=%s\n",
-		event.StartTime.Format(time.RFC3339), event.EndTime.Format(time.RFC3339))
-	fmt.Printf("DEBUG: Адаптированное время: Start=%s, End=%s\n",
-		adjustedStartTime.Format(time.RFC3339), adjustedEndTime.Format(time.RFC3339))
-
-	startStr := adjustedStartTime.Format("2006-01-02T15:04:05")
-	endStr := adjustedEndTime.Format("2006-01-02T15:04:05")
-
-	fmt.Printf("DEBUG: Используем форматы - Start=%s, End=%s\n", startStr, endStr)
-
-	calendarEvent := &calendar.Event{
-		Summary:	event.Title,
-		Description:	event.Description,
-		Start: &calendar.EventDateTime{
-			DateTime:	startStr,
-			TimeZone:	"UTC",
-		},
-		End: &calendar.EventDateTime{
-			DateTime:	endStr,
-			TimeZone:	"UTC",
-		},
-	}
-
-	fmt.Printf("DEBUG: Отправляем в Google Calendar: StartDateTime=%s, StartTimeZone=%s\n",
-		calendarEvent.Start.DateTime, calendarEvent.Start.TimeZone)
-
-	updatedEvent, err := srv.Events.Update("primary", event.GoogleEventID, calendarEvent).Do()
-	if err != nil {
-		return fmt.Errorf("не удалось обновить событие: %v", err)
-	}
-
-	fmt.Printf("DEBUG: Ответ от Google Calendar: ID=%s, StartDateTime=%s, StartTimeZone=%s\n",
-		updatedEvent.Id, updatedEvent.Start.DateTime, updatedEvent.Start.TimeZone)
-
-	fmt.Printf("Событие успешно обновлено: ID=%s, Title=%s, StartTime=%s\n",
-		updatedEvent.Id, updatedEvent.Summary, updatedEvent.Start.DateTime)
-	return nil
+	return updateGoogleEvent(ctx, srv, event)
 }
 
 func (g *GoogleCalendarClient) DeleteEvent(ctx context.Context, userID int64, googleEventID string) error {
@@ -247,48 +231,20 @@ func (g *GoogleCalendarClient) DeleteEvent(ctx context.Context, userID int64, go
 		return fmt.Errorf("отсутствует ID события в Google Calendar")
 	}
 
-	fmt.Printf("Удаление события из Google Calendar: ID=%s\n", googleEventID)
-
-	client, err := g.getClient(ctx, userID)
+	srv, err := g.service(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("ошибка получения клиента: %v", err)
 	}
 
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		return fmt.Errorf("не удалось создать сервис календаря: %v", err)
-	}
-
-	calendarID := "primary"
-
-	_, err = srv.Events.Get(calendarID, googleEventID).Do()
-	if err != nil {
-		fmt.Printf("Событие %s не найдено при попытке удаления: %v\n", googleEventID, err)
-
-		return nil
-	}
-
-	err = srv.Events.Delete(calendarID, googleEventID).Do()
-	if err != nil {
-		fmt.Printf("Ошибка при удалении события %s: %v\n", googleEventID, err)
-		return fmt.Errorf("не удалось удалить событие из Google Calendar: %v", err)
-	}
-
-	fmt.Printf("Событие успешно удалено из Google Calendar: ID=%s\n", googleEventID)
-	return nil
+	return deleteGoogleEvent(ctx, srv, googleEventID)
 }
 
 func (g *GoogleCalendarClient) GetEvents(ctx context.Context, userID int64, startTime, endTime time.Time) ([]*calendar.Event, error) {
-	client, err := g.getClient(ctx, userID)
+	srv, err := g.service(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		return nil, fmt.Errorf("не удалось создать сервис календаря: %v", err)
-	}
-
 	calendarID := "primary"
 	events, err := srv.Events.List(calendarID).
 		TimeMin(startTime.Format(time.RFC3339)).
@@ -319,91 +275,10 @@ func (g *GoogleCalendarClient) GetEventByID(ctx context.Context, userID int64, e
 	return &event, nil
 }
 
-func (g *GoogleCalendarClient) SyncEventsFromGoogleCalendar(ctx context.Context, userID int64) error {
-
-	lastSyncTime, err := g.getLastSyncTime(userID)
-	isFirstSync := false
-	if err != nil {
-
-		lastSyncTime = time.Now().Add(-7 * 24 * time.Hour)
-		isFirstSync = true
-		logrus.Warnf("Не удалось получить время последней синхронизации: %v. Используем последние 7 дней", err)
-	}
-
-	client, err := g.getClient(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("ошибка получения клиента: %v", err)
-	}
-
-	srv, err := calendar.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		return fmt.Errorf("не удалось создать сервис календаря: %v", err)
-	}
-
-	timeMin := lastSyncTime.Format(time.RFC3339)
-	timeMax := time.Now().Add(365 * 24 * time.Hour).Format(time.RFC3339)
-
-	logrus.Infof("Синхронизация событий Google Calendar для userID=%d с %s по %s",
-		userID, timeMin, timeMax)
-
-	eventsListCall := srv.Events.List("primary").
-		TimeMin(timeMin).
-		TimeMax(timeMax).
-		SingleEvents(true).
-		OrderBy("updated")
-
-	if !isFirstSync {
-		eventsListCall = eventsListCall.UpdatedMin(timeMin)
-	}
-
-	events, err := eventsListCall.Do()
-	if err != nil {
-		return fmt.Errorf("не удалось получить события из Google Calendar: %v", err)
-	}
-
-	logrus.Infof("Получено %d событий из Google Calendar для синхронизации", len(events.Items))
-
-	for _, googleEvent := range events.Items {
-
-		if googleEvent.Status == "cancelled" {
-			err = g.handleDeletedGoogleEvent(ctx, userID, googleEvent.Id)
-			if err != nil {
-				logrus.Warnf("Ошибка при обработке удаленного события %s: %v", googleEvent.Id, err)
-			}
-			continue
-		}
-
-		localEvent, err := g.findLocalEventByGoogleID(ctx, userID, googleEvent.Id)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			logrus.Warnf("Ошибка при поиске локального события для Google ID %s: %v", googleEvent.Id, err)
-			continue
-		}
-
-		if localEvent == nil || errors.Is(err, sql.ErrNoRows) {
-			err = g.createLocalEventFromGoogle(ctx, userID, googleEvent)
-			if err != nil {
-				logrus.Warnf("Ошибка при создании нового события из Google: %v", err)
-			}
-		} else {
-
-			err = g.updateLocalEventFromGoogle(ctx, userID, localEvent.ID, googleEvent)
-			if err != nil {
-				logrus.Warnf("Ошибка при обновлении события из Google: %v", err)
-			}
-		}
-	}
-
-	err = g.updateLastSyncTime(userID, time.Now())
-	if err != nil {
-		logrus.Warnf("Ошибка при обновлении времени последней синхронизации: %v", err)
-	}
-
-	return nil
-}
-
 func (g *GoogleCalendarClient) findLocalEventByGoogleID(ctx context.Context, userID int64, googleEventID string) (*Event, error) {
 	query := `
-		SELECT id, user_id, title, description, start_time, end_time, created_at, google_event_id
+		SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at,
+			google_event_id, updated_at, google_updated_at, google_sync_pending
 		FROM events
 		WHERE google_event_id = $1 AND user_id = $2
 	`
@@ -431,8 +306,8 @@ func (g *GoogleCalendarClient) createLocalEventFromGoogle(ctx context.Context, u
 	}
 
 	query := `
-		INSERT INTO events (id, user_id, title, description, start_time, end_time, created_at, google_event_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO events (id, user_id, title, description, start_time, end_time, created_at, google_event_id, google_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	_, err = g.db.ExecContext(ctx, query,
@@ -443,7 +318,8 @@ func (g *GoogleCalendarClient) createLocalEventFromGoogle(ctx context.Context, u
 		startTime,
 		endTime,
 		time.Now(),
-		googleEvent.Id)
+		googleEvent.Id,
+		parseGoogleUpdated(googleEvent.Updated))
 
 	if err != nil {
 		return fmt.Errorf("ошибка при сохранении события из Google Calendar: %v", err)
@@ -469,7 +345,8 @@ func (g *GoogleCalendarClient) updateLocalEventFromGoogle(ctx context.Context, u
 
 	query := `
 		UPDATE events
-		SET title = $1, description = $2, start_time = $3, end_time = $4
+		SET title = $1, description = $2, start_time = $3, end_time = $4,
+			google_updated_at = $7, google_sync_pending = FALSE, updated_at = NOW()
 		WHERE id = $5 AND user_id = $6
 	`
 
@@ -479,7 +356,8 @@ func (g *GoogleCalendarClient) updateLocalEventFromGoogle(ctx context.Context, u
 		startTime,
 		endTime,
 		eventID,
-		userID)
+		userID,
+		parseGoogleUpdated(googleEvent.Updated))
 
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении события из Google Calendar: %v", err)
@@ -526,30 +404,10 @@ func parseGoogleEventTime(eventTime *calendar.EventDateTime) (time.Time, error)
 	return time.Time{}, fmt.Errorf("не удалось определить формат времени")
 }
 
-func (g *GoogleCalendarClient) getLastSyncTime(userID int64) (time.Time, error) {
-	query := `
-		SELECT last_sync_time 
-		FROM google_sync_state 
-		WHERE user_id = $1
-	`
-
-	var lastSyncTime time.Time
-	err := g.db.Get(&lastSyncTime, query, userID)
+func parseGoogleUpdated(updated string) *time.Time {
+	parsed, err := time.Parse(time.RFC3339, updated)
 	if err != nil {
-		return time.Time{}, err
+		return nil
 	}
-
-	return lastSyncTime, nil
-}
-
-func (g *GoogleCalendarClient) updateLastSyncTime(userID int64, syncTime time.Time) error {
-	query := `
-		INSERT INTO google_sync_state (user_id, last_sync_time)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id)
-		DO UPDATE SET last_sync_time = $2
-	`
-
-	_, err := g.db.Exec(query, userID, syncTime)
-	return err
+	return &parsed
 }
@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"telegrambot/internal/jobs"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+var ErrGoogleRelinkRequired = errors.New("доступ к Google Calendar отозван, аккаунт нужно переподключить")
+
+func isInvalidGrant(err error) bool {
+	if err == nil {
+		return false
+	}
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+		return true
+	}
+	return strings.Contains(err.Error(), "invalid_grant")
+}
+
+func (g *GoogleCalendarClient) markTokenInvalid(ctx context.Context, userID int64, cause error) {
+	result, err := g.db.ExecContext(ctx, `
+		UPDATE google_tokens SET invalid_at = NOW(), invalid_reason = $2
+		WHERE user_id = $1 AND invalid_at IS NULL
+	`, userID, cause.Error())
+	if err != nil {
+		logrus.Errorf("Ошибка при пометке токена Google пользователя %d как отозванного: %v", userID, err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		logrus.Warnf("Токен Google пользователя %d отозван, синхронизация остановлена до переподключения: %v", userID, cause)
+	}
+}
+
+func (s *Service) StartGoogleRelinkNotifier(jm *jobs.Manager, sendRelink func(chatID int64, text, url string) error) {
+	if s.googleClient == nil {
+		return
+	}
+
+	jm.Register(jobs.Job{
+		Name:	"google_relink_notifier",
+		Spec:	"*/10 * * * *",
+		Run: func(ctx context.Context) {
+			s.notifyRelinkRequired(ctx, sendRelink)
+		},
+	})
+
+	logrus.Info("Запущены напоминания о переподключении Google Calendar")
+}
+
+func (s *Service) notifyRelinkRequired(ctx context.Context, sendRelink func(chatID int64, text, url string) error) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		UPDATE google_tokens SET relink_notified_at = NOW()
+		WHERE invalid_at IS NOT NULL AND relink_notified_at IS NULL
+		RETURNING user_id
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе пользователей для переподключения Google: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		authURL, err := s.GetGoogleAuthURL(userID, "telegram")
+		if err != nil {
+			logrus.Errorf("Не удалось получить ссылку переподключения Google для пользователя %d: %v", userID, err)
+			continue
+		}
+		if err := sendRelink(userID, FormatRelinkPrompt(), authURL); err != nil {
+			logrus.Errorf("Ошибка при отправке ссылки переподключения Google пользователю %d: %v", userID, err)
+		}
+	}
+}
+
+func FormatRelinkPrompt() string {
+	return "⚠️ Google Calendar отключился: доступ был отозван или истек. Синхронизация событий остановлена.\n\n" +
+		"Нажмите кнопку ниже и заново разрешите доступ — события, созданные за это время, отправятся в Google автоматически"
+}
@@ -0,0 +1,454 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/calendar/v3"
+)
+
+const (
+	initialSyncWindow	= 7 * 24 * time.Hour
+	pushBatchSize		= 100
+)
+
+var (
+	ErrSyncInProgress	= errors.New("синхронизация с Google Calendar уже выполняется")
+	errSyncTokenExpired	= errors.New("токен синхронизации Google устарел")
+)
+
+type SyncStatus struct {
+	Linked		bool		`db:"linked"`
+	NeedsRelink	bool		`db:"needs_relink"`
+	InvalidSince	*time.Time	`db:"invalid_at"`
+	LastSyncAt	*time.Time	`db:"last_sync_time"`
+	LastFullSyncAt	*time.Time	`db:"last_full_sync_at"`
+	LastError	*string		`db:"last_error"`
+	LastErrorAt	*time.Time	`db:"last_error_at"`
+	Incremental	bool		`db:"incremental"`
+	PushActive	bool		`db:"push_active"`
+	Pulled		int		`db:"pulled_count"`
+	Pushed		int		`db:"pushed_count"`
+	Conflicts	int		`db:"conflicts_count"`
+	PendingPush	int		`db:"pending_push"`
+	PendingDeletes	int		`db:"pending_deletes"`
+}
+
+type syncResult struct {
+	pulled		int
+	pushed		int
+	conflicts	int
+	full		bool
+	syncToken	string
+}
+
+type pendingDeletion struct {
+	GoogleEventID string `db:"google_event_id"`
+}
+
+func (g *GoogleCalendarClient) SyncEventsFromGoogleCalendar(ctx context.Context, userID int64) error {
+	for {
+		if _, running := g.syncing.LoadOrStore(userID, struct{}{}); running {
+			g.dirty.Store(userID, struct{}{})
+			return ErrSyncInProgress
+		}
+		g.dirty.Delete(userID)
+
+		err := g.syncOnce(ctx, userID)
+		g.syncing.Delete(userID)
+		if err != nil {
+			return err
+		}
+
+		if _, again := g.dirty.LoadAndDelete(userID); !again {
+			return nil
+		}
+	}
+}
+
+func (g *GoogleCalendarClient) syncOnce(ctx context.Context, userID int64) error {
+	result, err := g.sync(ctx, userID)
+	if err != nil {
+		if isInvalidGrant(err) {
+			g.markTokenInvalid(ctx, userID, err)
+			err = ErrGoogleRelinkRequired
+		}
+		g.recordSyncError(ctx, userID, err)
+		return err
+	}
+
+	logrus.Infof("Синхронизация Google Calendar для userID=%d: получено %d, отправлено %d, конфликтов %d (полная: %t)",
+		userID, result.pulled, result.pushed, result.conflicts, result.full)
+	return g.recordSyncResult(ctx, userID, result)
+}
+
+func (g *GoogleCalendarClient) sync(ctx context.Context, userID int64) (*syncResult, error) {
+	srv, err := g.service(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения клиента: %v", err)
+	}
+
+	result := &syncResult{}
+	if err := g.pushLocalChanges(ctx, srv, userID, result); err != nil {
+		return nil, err
+	}
+
+	syncToken, err := g.getSyncToken(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.full = syncToken == ""
+	result.syncToken, err = g.pullRemoteChanges(ctx, srv, userID, syncToken, result)
+	if errors.Is(err, errSyncTokenExpired) {
+		logrus.Warnf("Токен синхронизации Google для userID=%d устарел, выполняем полную синхронизацию", userID)
+		result.full = true
+		result.syncToken, err = g.pullRemoteChanges(ctx, srv, userID, "", result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (g *GoogleCalendarClient) pullRemoteChanges(ctx context.Context, srv *calendar.Service, userID int64, syncToken string, result *syncResult) (string, error) {
+	call := srv.Events.List("primary").SingleEvents(true).MaxResults(250)
+	if syncToken != "" {
+		call = call.SyncToken(syncToken).ShowDeleted(true)
+	} else {
+		call = call.TimeMin(time.Now().Add(-initialSyncWindow).Format(time.RFC3339))
+	}
+
+	for {
+		events, err := call.Context(ctx).Do()
+		if err != nil {
+			if syncToken != "" && isGoogleGone(err) {
+				return "", errSyncTokenExpired
+			}
+			return "", fmt.Errorf("не удалось получить события из Google Calendar: %v", err)
+		}
+
+		for _, googleEvent := range events.Items {
+			if err := g.applyRemoteEvent(ctx, userID, googleEvent, result); err != nil {
+				logrus.Warnf("Ошибка при применении события Google %s для userID=%d: %v", googleEvent.Id, userID, err)
+			}
+		}
+
+		if events.NextPageToken == "" {
+			return events.NextSyncToken, nil
+		}
+		call = call.PageToken(events.NextPageToken)
+	}
+}
+
+func (g *GoogleCalendarClient) applyRemoteEvent(ctx context.Context, userID int64, googleEvent *calendar.Event, result *syncResult) error {
+	localEvent, err := g.findLocalEventByGoogleID(ctx, userID, googleEvent.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		if googleEvent.Status == "cancelled" {
+			return nil
+		}
+		if err := g.createLocalEventFromGoogle(ctx, userID, googleEvent); err != nil {
+			return err
+		}
+		result.pulled++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при поиске локального события: %v", err)
+	}
+
+	remoteUpdated := parseGoogleUpdated(googleEvent.Updated)
+	localWins := localEvent.SyncPending && (remoteUpdated == nil || localEvent.UpdatedAt.After(*remoteUpdated))
+
+	if googleEvent.Status == "cancelled" {
+		if localWins {
+			result.conflicts++
+			return g.detachFromGoogle(ctx, localEvent.ID)
+		}
+		if err := g.handleDeletedGoogleEvent(ctx, userID, googleEvent.Id); err != nil {
+			return err
+		}
+		result.pulled++
+		return nil
+	}
+
+	if remoteUpdated != nil && localEvent.GoogleUpdatedAt != nil && !remoteUpdated.After(*localEvent.GoogleUpdatedAt) {
+		return nil
+	}
+	if localEvent.SyncPending {
+		result.conflicts++
+		if localWins {
+			return nil
+		}
+	}
+
+	if err := g.updateLocalEventFromGoogle(ctx, userID, localEvent.ID, googleEvent); err != nil {
+		return err
+	}
+	result.pulled++
+	return nil
+}
+
+func (g *GoogleCalendarClient) pushLocalChanges(ctx context.Context, srv *calendar.Service, userID int64, result *syncResult) error {
+	var pending []Event
+	err := g.db.SelectContext(ctx, &pending, `
+		SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at,
+			COALESCE(google_event_id, '') AS google_event_id, updated_at, google_updated_at, google_sync_pending
+		FROM events
+		WHERE user_id = $1 AND google_sync_pending
+		ORDER BY updated_at
+		LIMIT $2
+	`, userID, pushBatchSize)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении несинхронизированных событий: %v", err)
+	}
+
+	for i := range pending {
+		if err := g.pushEvent(ctx, srv, &pending[i], result); err != nil {
+			logrus.Warnf("Не удалось отправить событие %s в Google Calendar: %v", pending[i].ID, err)
+		}
+	}
+
+	var deletions []pendingDeletion
+	err = g.db.SelectContext(ctx, &deletions, `
+		SELECT google_event_id FROM google_event_deletions
+		WHERE user_id = $1
+		ORDER BY created_at
+		LIMIT $2
+	`, userID, pushBatchSize)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении отложенных удалений: %v", err)
+	}
+
+	for _, deletion := range deletions {
+		if err := deleteGoogleEvent(ctx, srv, deletion.GoogleEventID); err != nil {
+			g.db.ExecContext(ctx, `
+				UPDATE google_event_deletions SET attempts = attempts + 1, last_error = $3
+				WHERE user_id = $1 AND google_event_id = $2
+			`, userID, deletion.GoogleEventID, err.Error())
+			logrus.Warnf("Не удалось удалить событие %s из Google Calendar: %v", deletion.GoogleEventID, err)
+			continue
+		}
+		if _, err := g.db.ExecContext(ctx, `DELETE FROM google_event_deletions WHERE user_id = $1 AND google_event_id = $2`, userID, deletion.GoogleEventID); err != nil {
+			return fmt.Errorf("ошибка при очистке отложенного удаления: %v", err)
+		}
+		result.pushed++
+	}
+
+	return nil
+}
+
+func (g *GoogleCalendarClient) pushEvent(ctx context.Context, srv *calendar.Service, event *Event, result *syncResult) error {
+	if event.GoogleEventID == "" {
+		created, err := insertGoogleEvent(ctx, srv, event)
+		if err != nil {
+			return err
+		}
+		result.pushed++
+		return g.markSynced(ctx, event.ID, created)
+	}
+
+	remote, err := srv.Events.Get("primary", event.GoogleEventID).Context(ctx).Do()
+	if err != nil && !isGoogleGone(err) {
+		return fmt.Errorf("не удалось получить событие из Google Calendar: %v", err)
+	}
+
+	if err != nil || remote.Status == "cancelled" {
+		created, err := insertGoogleEvent(ctx, srv, event)
+		if err != nil {
+			return err
+		}
+		result.conflicts++
+		return g.markSynced(ctx, event.ID, created)
+	}
+
+	remoteUpdated := parseGoogleUpdated(remote.Updated)
+	changedRemotely := remoteUpdated != nil && (event.GoogleUpdatedAt == nil || remoteUpdated.After(*event.GoogleUpdatedAt))
+	if changedRemotely && remoteUpdated.After(event.UpdatedAt) {
+		result.conflicts++
+		return g.updateLocalEventFromGoogle(ctx, event.UserID, event.ID, remote)
+	}
+
+	updated, err := updateGoogleEvent(ctx, srv, event)
+	if err != nil {
+		return err
+	}
+	if changedRemotely {
+		result.conflicts++
+	}
+	result.pushed++
+	return g.markSynced(ctx, event.ID, updated)
+}
+
+func (g *GoogleCalendarClient) markSynced(ctx context.Context, eventID string, googleEvent *calendar.Event) error {
+	_, err := g.db.ExecContext(ctx, `
+		UPDATE events
+		SET google_event_id = $2, google_updated_at = $3, google_sync_pending = FALSE
+		WHERE id = $1
+	`, eventID, googleEvent.Id, parseGoogleUpdated(googleEvent.Updated))
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении состояния синхронизации события: %v", err)
+	}
+	return nil
+}
+
+func (g *GoogleCalendarClient) markPending(ctx context.Context, eventID string) {
+	_, err := g.db.ExecContext(ctx, `UPDATE events SET google_sync_pending = TRUE WHERE id = $1`, eventID)
+	if err != nil {
+		logrus.Errorf("Ошибка при постановке события %s в очередь синхронизации: %v", eventID, err)
+	}
+}
+
+func (g *GoogleCalendarClient) detachFromGoogle(ctx context.Context, eventID string) error {
+	_, err := g.db.ExecContext(ctx, `
+		UPDATE events
+		SET google_event_id = NULL, google_updated_at = NULL, google_sync_pending = TRUE
+		WHERE id = $1
+	`, eventID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отвязке события от Google Calendar: %v", err)
+	}
+	return nil
+}
+
+func (g *GoogleCalendarClient) queueDeletion(ctx context.Context, userID int64, googleEventID string, cause error) {
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO google_event_deletions (user_id, google_event_id, last_error)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, google_event_id) DO NOTHING
+	`, userID, googleEventID, cause.Error())
+	if err != nil {
+		logrus.Errorf("Ошибка при постановке удаления события %s в очередь: %v", googleEventID, err)
+	}
+}
+
+func (g *GoogleCalendarClient) isLinked(ctx context.Context, userID int64) bool {
+	var linked bool
+	err := g.db.GetContext(ctx, &linked, `SELECT EXISTS(SELECT 1 FROM google_tokens WHERE user_id = $1)`, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при проверке привязки Google для пользователя %d: %v", userID, err)
+	}
+	return linked
+}
+
+func (g *GoogleCalendarClient) getSyncToken(ctx context.Context, userID int64) (string, error) {
+	var syncToken string
+	err := g.db.GetContext(ctx, &syncToken, `SELECT COALESCE(sync_token, '') FROM google_sync_state WHERE user_id = $1`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("ошибка при получении токена синхронизации: %v", err)
+	}
+	return syncToken, nil
+}
+
+func (g *GoogleCalendarClient) recordSyncResult(ctx context.Context, userID int64, result *syncResult) error {
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO google_sync_state (user_id, last_sync_time, sync_token, last_full_sync_at, pulled_count, pushed_count, conflicts_count)
+		VALUES ($1, NOW(), NULLIF($2, ''), CASE WHEN $3 THEN NOW() END, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			last_sync_time = NOW(),
+			sync_token = COALESCE(NULLIF($2, ''), google_sync_state.sync_token),
+			last_full_sync_at = CASE WHEN $3 THEN NOW() ELSE google_sync_state.last_full_sync_at END,
+			last_error = NULL,
+			last_error_at = NULL,
+			pulled_count = $4,
+			pushed_count = $5,
+			conflicts_count = $6
+	`, userID, result.syncToken, result.full, result.pulled, result.pushed, result.conflicts)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении состояния синхронизации: %v", err)
+	}
+	return nil
+}
+
+func (g *GoogleCalendarClient) recordSyncError(ctx context.Context, userID int64, syncErr error) {
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO google_sync_state (user_id, last_error, last_error_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET last_error = $2, last_error_at = NOW()
+	`, userID, syncErr.Error())
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении ошибки синхронизации для пользователя %d: %v", userID, err)
+	}
+}
+
+func (s *Service) GetSyncStatus(ctx context.Context, userID int64) (*SyncStatus, error) {
+	var status SyncStatus
+	err := s.db.GetContext(ctx, &status, `
+		SELECT
+			EXISTS(SELECT 1 FROM google_tokens WHERE user_id = $1) AS linked,
+			EXISTS(SELECT 1 FROM google_tokens WHERE user_id = $1 AND invalid_at IS NOT NULL) AS needs_relink,
+			(SELECT invalid_at FROM google_tokens WHERE user_id = $1) AS invalid_at,
+			st.last_sync_time, st.last_full_sync_at, st.last_error, st.last_error_at,
+			COALESCE(st.sync_token, '') <> '' AS incremental,
+			EXISTS(SELECT 1 FROM google_watch_channels WHERE user_id = $1 AND expires_at > NOW()) AS push_active,
+			COALESCE(st.pulled_count, 0) AS pulled_count,
+			COALESCE(st.pushed_count, 0) AS pushed_count,
+			COALESCE(st.conflicts_count, 0) AS conflicts_count,
+			(SELECT COUNT(*) FROM events WHERE user_id = $1 AND google_sync_pending) AS pending_push,
+			(SELECT COUNT(*) FROM google_event_deletions WHERE user_id = $1) AS pending_deletes
+		FROM (SELECT $1::BIGINT AS user_id) u
+		LEFT JOIN google_sync_state st ON st.user_id = u.user_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении состояния синхронизации: %v", err)
+	}
+	return &status, nil
+}
+
+func FormatSyncStatus(status *SyncStatus) string {
+	if !status.Linked {
+		return "📅 Google Calendar не подключен. Подключить: /google_auth"
+	}
+
+	if status.NeedsRelink {
+		text := "📅 **Синхронизация с Google Calendar остановлена**\n\n⚠️ Доступ к Google отозван"
+		if status.InvalidSince != nil {
+			text += " " + status.InvalidSince.Local().Format("02.01 15:04")
+		}
+		text += ". Переподключите аккаунт: /google_auth"
+		if pending := status.PendingPush + status.PendingDeletes; pending > 0 {
+			text += fmt.Sprintf("\n⏳ Ждут отправки в Google: %d", pending)
+		}
+		return text
+	}
+
+	var b strings.Builder
+	b.WriteString("📅 **Синхронизация с Google Calendar**\n\n")
+	if status.LastSyncAt != nil {
+		fmt.Fprintf(&b, "Последняя синхронизация: %s\n", status.LastSyncAt.Local().Format("02.01 15:04"))
+	} else {
+		b.WriteString("Синхронизация еще не выполнялась\n")
+	}
+	if status.Incremental {
+		b.WriteString("Режим: инкрементальный (только изменения)\n")
+	} else {
+		b.WriteString("Режим: полная синхронизация при следующем запуске\n")
+	}
+	if status.PushActive {
+		b.WriteString("Изменения из Google приходят сразу (push-уведомления)\n")
+	} else {
+		b.WriteString("Изменения из Google проверяются раз в минуту\n")
+	}
+	if status.LastFullSyncAt != nil {
+		fmt.Fprintf(&b, "Полная синхронизация: %s\n", status.LastFullSyncAt.Local().Format("02.01 15:04"))
+	}
+	fmt.Fprintf(&b, "\nЗа последний прогон: из Google %d, в Google %d", status.Pulled, status.Pushed)
+	if status.Conflicts > 0 {
+		fmt.Fprintf(&b, ", конфликтов %d (побеждает более позднее изменение)", status.Conflicts)
+	}
+	b.WriteString("\n")
+
+	if pending := status.PendingPush + status.PendingDeletes; pending > 0 {
+		fmt.Fprintf(&b, "⏳ Ждут отправки в Google: %d\n", pending)
+	}
+	if status.LastError != nil && status.LastErrorAt != nil && (status.LastSyncAt == nil || status.LastErrorAt.After(*status.LastSyncAt)) {
+		fmt.Fprintf(&b, "\n⚠️ Ошибка %s: %s\n", status.LastErrorAt.Local().Format("02.01 15:04"), *status.LastError)
+	}
+	return b.String()
+}
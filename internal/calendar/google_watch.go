@@ -0,0 +1,198 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/calendar/v3"
+)
+
+const (
+	watchChannelTTL		= 7 * 24 * time.Hour
+	watchRenewBefore	= 24 * time.Hour
+	watchFallbackPoll	= 30 * time.Minute
+	pushSyncTimeout		= 2 * time.Minute
+)
+
+type watchChannel struct {
+	UserID		int64		`db:"user_id"`
+	ChannelID	string		`db:"channel_id"`
+	ResourceID	string		`db:"resource_id"`
+	Token		string		`db:"token"`
+	ExpiresAt	time.Time	`db:"expires_at"`
+}
+
+func (g *GoogleCalendarClient) watch(ctx context.Context, userID int64, address string) (*watchChannel, error) {
+	srv, err := g.service(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := srv.Events.Watch("primary", &calendar.Channel{
+		Id:		uuid.New().String(),
+		Type:		"web_hook",
+		Address:	address,
+		Token:		token,
+		Expiration:	time.Now().Add(watchChannelTTL).UnixMilli(),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось зарегистрировать канал уведомлений: %v", err)
+	}
+
+	expiresAt := time.Now().Add(watchChannelTTL)
+	if channel.Expiration > 0 {
+		expiresAt = time.UnixMilli(channel.Expiration)
+	}
+
+	return &watchChannel{
+		UserID:		userID,
+		ChannelID:	channel.Id,
+		ResourceID:	channel.ResourceId,
+		Token:		token,
+		ExpiresAt:	expiresAt,
+	}, nil
+}
+
+func (g *GoogleCalendarClient) stopChannel(ctx context.Context, channel *watchChannel) error {
+	srv, err := g.service(ctx, channel.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = srv.Channels.Stop(&calendar.Channel{Id: channel.ChannelID, ResourceId: channel.ResourceID}).Context(ctx).Do()
+	if err != nil && !isGoogleGone(err) {
+		return fmt.Errorf("не удалось остановить канал уведомлений: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) pushEnabled() bool {
+	return s.googleClient != nil && s.cfg.GoogleWebhookURL != ""
+}
+
+func (s *Service) renewWatchChannels(ctx context.Context) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT t.user_id
+		FROM google_tokens t
+		LEFT JOIN google_watch_channels c ON c.user_id = t.user_id
+		WHERE t.invalid_at IS NULL AND (c.user_id IS NULL OR c.expires_at < $1)
+	`, time.Now().Add(watchRenewBefore))
+	if err != nil {
+		logrus.Errorf("Ошибка при получении пользователей для каналов Google: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if err := s.registerWatchChannel(ctx, userID); err != nil {
+			logrus.Warnf("Не удалось зарегистрировать push-канал Google для пользователя %d, остается опрос: %v", userID, err)
+		}
+	}
+}
+
+func (s *Service) registerWatchChannel(ctx context.Context, userID int64) error {
+	var previous watchChannel
+	err := s.db.GetContext(ctx, &previous, `
+		SELECT user_id, channel_id, resource_id, token, expires_at FROM google_watch_channels WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("ошибка при получении канала уведомлений: %v", err)
+	}
+	hadPrevious := err == nil
+
+	channel, err := s.googleClient.watch(ctx, userID, s.cfg.GoogleWebhookURL)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO google_watch_channels (user_id, channel_id, resource_id, token, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			channel_id = $2, resource_id = $3, token = $4, expires_at = $5, created_at = NOW()
+	`, userID, channel.ChannelID, channel.ResourceID, channel.Token, channel.ExpiresAt)
+	if err != nil {
+		s.googleClient.stopChannel(ctx, channel)
+		return fmt.Errorf("ошибка при сохранении канала уведомлений: %v", err)
+	}
+
+	if hadPrevious {
+		if err := s.googleClient.stopChannel(ctx, &previous); err != nil {
+			logrus.Warnf("Не удалось остановить старый канал Google %s: %v", previous.ChannelID, err)
+		}
+	}
+
+	logrus.Infof("Зарегистрирован push-канал Google Calendar для пользователя %d до %s", userID, channel.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+func (s *Service) PushNotificationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+			return
+		}
+
+		channelID := r.Header.Get("X-Goog-Channel-ID")
+		state := r.Header.Get("X-Goog-Resource-State")
+
+		var channel watchChannel
+		err := s.db.GetContext(r.Context(), &channel, `
+			SELECT user_id, channel_id, resource_id, token, expires_at FROM google_watch_channels WHERE channel_id = $1
+		`, channelID)
+		if errors.Is(err, sql.ErrNoRows) {
+			logrus.Debugf("Уведомление Google для неизвестного канала %s", channelID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка при получении канала уведомлений %s: %v", channelID, err)
+			http.Error(w, "Внутренняя ошибка", http.StatusInternalServerError)
+			return
+		}
+
+		token := r.Header.Get("X-Goog-Channel-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(channel.Token)) != 1 || r.Header.Get("X-Goog-Resource-ID") != channel.ResourceID {
+			http.Error(w, "Доступ запрещен", http.StatusForbidden)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if state == "sync" {
+			return
+		}
+		go s.syncAfterPush(channel.UserID)
+	}
+}
+
+func (s *Service) syncAfterPush(userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), pushSyncTimeout)
+	defer cancel()
+
+	err := s.SyncGoogleCalendarForUser(ctx, userID)
+	if err != nil && !errors.Is(err, ErrSyncInProgress) {
+		logrus.Errorf("Ошибка синхронизации Google Calendar по уведомлению для пользователя %d: %v", userID, err)
+	}
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка при генерации токена канала: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
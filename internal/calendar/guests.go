@@ -0,0 +1,412 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/mailer"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	GuestNeedsAction	= "needs_action"
+	GuestAccepted		= "accepted"
+	GuestDeclined		= "declined"
+	GuestTentative		= "tentative"
+
+	maxEventGuests		= 50
+	maxInviteAttempts	= 5
+	inviteBatchSize		= 20
+	maxInboundEmailSize	= 10 << 20
+)
+
+var (
+	ErrInvalidGuestEmail	= errors.New("некорректный email гостя")
+	ErrTooManyGuests	= errors.New("слишком много гостей у события")
+	ErrGuestNotInvited	= errors.New("гость не приглашен на это событие")
+)
+
+var guestStatusLabels = map[string]string{
+	GuestNeedsAction:	"⏳ ждем ответа",
+	GuestAccepted:		"✅ придет",
+	GuestDeclined:		"❌ не придет",
+	GuestTentative:		"🤔 возможно",
+}
+
+type EventGuest struct {
+	EventID		string		`db:"event_id" json:"-"`
+	Email		string		`db:"email" json:"email"`
+	Name		string		`db:"name" json:"name,omitempty"`
+	Status		string		`db:"status" json:"status"`
+	InviteSentAt	*time.Time	`db:"invite_sent_at" json:"invite_sent_at,omitempty"`
+	RespondedAt	*time.Time	`db:"responded_at" json:"responded_at,omitempty"`
+}
+
+type GuestReply struct {
+	UserID		int64		`db:"user_id"`
+	EventTitle	string		`db:"title"`
+	EventStart	time.Time	`db:"start_time"`
+	Email		string		`db:"email"`
+	Name		string		`db:"name"`
+	Status		string		`db:"status"`
+}
+
+type pendingInvite struct {
+	AttendeeID	int64	`db:"attendee_id"`
+	Email		string	`db:"email"`
+	Name		string	`db:"name"`
+	Sequence	int	`db:"sequence"`
+	Attempts	int	`db:"send_attempts"`
+	OwnerName	string	`db:"owner_name"`
+	Event
+}
+
+func (s *Service) AddEventGuests(ctx context.Context, userID int64, eventID string, emails []string) ([]EventGuest, error) {
+	if _, err := s.GetEventByID(ctx, userID, eventID); err != nil {
+		return nil, fmt.Errorf("событие не найдено или не принадлежит пользователю: %v", err)
+	}
+
+	var addresses []*mail.Address
+	for _, raw := range emails {
+		address, err := mail.ParseAddress(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidGuestEmail, raw)
+		}
+		address.Address = strings.ToLower(address.Address)
+		addresses = append(addresses, address)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, address := range addresses {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO event_attendees (event_id, email, name)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (event_id, email) DO NOTHING
+		`, eventID, address.Address, address.Name)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при добавлении гостя: %v", err)
+		}
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM event_attendees WHERE event_id = $1`, eventID); err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете гостей: %v", err)
+	}
+	if count > maxEventGuests {
+		return nil, fmt.Errorf("%w: не больше %d", ErrTooManyGuests, maxEventGuests)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении гостей: %v", err)
+	}
+	return s.GetEventGuests(ctx, eventID)
+}
+
+func (s *Service) RemoveEventGuest(ctx context.Context, userID int64, eventID, email string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM event_attendees a
+		USING events e
+		WHERE a.event_id = e.id AND e.id = $1 AND e.user_id = $2 AND a.email = $3
+	`, eventID, userID, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении гостя: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrGuestNotInvited
+	}
+	return nil
+}
+
+func (s *Service) GetEventGuests(ctx context.Context, eventID string) ([]EventGuest, error) {
+	guests := []EventGuest{}
+	err := s.db.SelectContext(ctx, &guests, `
+		SELECT event_id, email, name, status, invite_sent_at, responded_at
+		FROM event_attendees
+		WHERE event_id = $1
+		ORDER BY created_at, id
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении гостей события: %v", err)
+	}
+	return guests, nil
+}
+
+func (s *Service) AttachGuests(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	var guests []EventGuest
+	err := s.db.SelectContext(ctx, &guests, `
+		SELECT event_id, email, name, status, invite_sent_at, responded_at
+		FROM event_attendees
+		WHERE event_id = ANY($1)
+		ORDER BY created_at, id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("ошибка при получении гостей событий: %v", err)
+	}
+
+	byEvent := make(map[string][]EventGuest)
+	for _, guest := range guests {
+		byEvent[guest.EventID] = append(byEvent[guest.EventID], guest)
+	}
+	for i := range events {
+		events[i].Guests = byEvent[events[i].ID]
+	}
+	return nil
+}
+
+func (s *Service) resendGuestInvites(ctx context.Context, eventID string) {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE event_attendees
+		SET sequence = sequence + 1, invite_sent_at = NULL, send_attempts = 0, last_error = NULL
+		WHERE event_id = $1
+	`, eventID)
+	if err != nil {
+		logrus.Warnf("Не удалось запланировать обновление приглашений для события %s: %v", eventID, err)
+	}
+}
+
+func (s *Service) StartGuestInvitations(jm *jobs.Manager, mailClient *mailer.Client) {
+	if !mailClient.Enabled() {
+		logrus.Warn("SMTP не настроен, приглашения гостям событий не отправляются")
+		return
+	}
+
+	jm.Register(jobs.Job{
+		Name:	"event_guest_invitations",
+		Spec:	"@every 1m",
+		Run: func(ctx context.Context) {
+			s.sendGuestInvitations(ctx, mailClient)
+		},
+	})
+
+	logrus.Info("Запущена отправка приглашений гостям событий")
+}
+
+func (s *Service) sendGuestInvitations(ctx context.Context, mailClient *mailer.Client) {
+	var invites []pendingInvite
+	err := s.db.SelectContext(ctx, &invites, `
+		SELECT a.id AS attendee_id, a.email, a.name, a.sequence, a.send_attempts,
+			COALESCE(NULLIF(u.first_name, ''), u.username, '') AS owner_name,
+			e.id, e.user_id, e.title, COALESCE(e.description, '') AS description, e.start_time, e.end_time, e.created_at
+		FROM event_attendees a
+		JOIN events e ON e.id = a.event_id
+		LEFT JOIN users u ON u.id = e.user_id
+		WHERE a.invite_sent_at IS NULL AND a.send_attempts < $1 AND e.end_time > NOW()
+		ORDER BY a.created_at
+		LIMIT $2
+	`, maxInviteAttempts, inviteBatchSize)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении приглашений для отправки: %v", err)
+		return
+	}
+
+	for _, invite := range invites {
+		err := s.sendGuestInvitation(ctx, mailClient, invite)
+		if err != nil {
+			logrus.Warnf("Не удалось отправить приглашение %s на событие %s: %v", invite.Email, invite.ID, err)
+			if _, dbErr := s.db.ExecContext(ctx, `
+				UPDATE event_attendees SET send_attempts = send_attempts + 1, last_error = $2 WHERE id = $1
+			`, invite.AttendeeID, err.Error()); dbErr != nil {
+				logrus.Errorf("Ошибка при сохранении ошибки отправки приглашения: %v", dbErr)
+			}
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE event_attendees SET invite_sent_at = NOW(), last_error = NULL WHERE id = $1
+		`, invite.AttendeeID); err != nil {
+			logrus.Errorf("Приглашение отправлено, но статус не сохранен: %v", err)
+		}
+	}
+}
+
+func (s *Service) sendGuestInvitation(ctx context.Context, mailClient *mailer.Client, invite pendingInvite) error {
+	var ics bytes.Buffer
+	guest := ICSAttendee{Email: invite.Email, Name: invite.Name}
+	if err := WriteInvitationICS(&ics, "REQUEST", invite.Event, invite.Sequence, invite.OwnerName, mailClient.From(), guest); err != nil {
+		return fmt.Errorf("ошибка при формировании приглашения: %v", err)
+	}
+
+	organizer := invite.OwnerName
+	if organizer == "" {
+		organizer = "Вас"
+	}
+	when := invite.StartTime.Local().Format("02.01.2006 15:04") + "–" + invite.EndTime.Local().Format("15:04")
+	subject := fmt.Sprintf("Приглашение: %s (%s)", invite.Title, when)
+	text := fmt.Sprintf("%s приглашает на «%s»\nКогда: %s\n", organizer, invite.Title, when)
+	if invite.Sequence > 0 {
+		subject = fmt.Sprintf("Изменено: %s (%s)", invite.Title, when)
+		text = fmt.Sprintf("Событие «%s» изменилось\nКогда: %s\n", invite.Title, when)
+	}
+	if invite.Description != "" {
+		text += "\n" + invite.Description + "\n"
+	}
+	text += "\nОтветьте на приглашение в своем календаре — организатор увидит ответ."
+
+	return mailClient.Send(ctx, mailer.Message{
+		To:		invite.Email,
+		Subject:	subject,
+		Text:		text,
+		Calendar:	ics.Bytes(),
+		CalendarMethod:	"REQUEST",
+	})
+}
+
+func (s *Service) ProcessGuestReply(ctx context.Context, data string) ([]GuestReply, error) {
+	reply, err := ParseICSReply(data)
+	if err != nil {
+		return nil, err
+	}
+	eventID := strings.TrimSuffix(reply.UID, "@jarvis")
+
+	var replies []GuestReply
+	for _, attendee := range reply.Attendees {
+		status := guestStatusFromPartStat(attendee.PartStat)
+		if status == "" {
+			continue
+		}
+
+		var result GuestReply
+		err := s.db.GetContext(ctx, &result, `
+			UPDATE event_attendees a
+			SET status = $3, responded_at = NOW(), name = CASE WHEN a.name = '' THEN $4 ELSE a.name END
+			FROM events e
+			WHERE a.event_id = e.id AND a.event_id = $1 AND a.email = $2
+			RETURNING e.user_id, e.title, e.start_time, a.email, a.name, a.status
+		`, eventID, attendee.Email, status, attendee.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			logrus.Warnf("Ответ на приглашение от %s для неизвестного события или гостя %s", attendee.Email, reply.UID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сохранении ответа гостя: %v", err)
+		}
+		replies = append(replies, result)
+	}
+
+	if len(replies) == 0 {
+		return nil, ErrGuestNotInvited
+	}
+	return replies, nil
+}
+
+func (s *Service) InboundEmailHandler(token string, sendMessage func(int64, string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+			return
+		}
+		provided := r.URL.Query().Get("token")
+		if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			provided = strings.TrimPrefix(header, "Bearer ")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			http.Error(w, "Доступ запрещен", http.StatusUnauthorized)
+			return
+		}
+
+		message, err := mailer.ParseInbound(io.LimitReader(r.Body, maxInboundEmailSize))
+		if err != nil {
+			logrus.Warnf("Не удалось разобрать входящее письмо: %v", err)
+			http.Error(w, "Неверный формат письма", http.StatusBadRequest)
+			return
+		}
+		if len(message.Calendars) == 0 {
+			logrus.Debugf("Входящее письмо от %s без календаря, пропускаем", message.From)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		for _, data := range message.Calendars {
+			replies, err := s.ProcessGuestReply(r.Context(), data)
+			if err != nil {
+				logrus.Warnf("Входящее письмо от %s не обработано: %v", message.From, err)
+				continue
+			}
+			for _, reply := range replies {
+				if err := sendMessage(reply.UserID, FormatGuestReply(reply)); err != nil {
+					logrus.Warnf("Не удалось уведомить пользователя %d об ответе гостя: %v", reply.UserID, err)
+				}
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func guestStatusFromPartStat(partStat string) string {
+	switch partStat {
+	case "ACCEPTED":
+		return GuestAccepted
+	case "DECLINED":
+		return GuestDeclined
+	case "TENTATIVE":
+		return GuestTentative
+	case "NEEDS-ACTION":
+		return GuestNeedsAction
+	default:
+		return ""
+	}
+}
+
+func GuestStatusLabel(status string) string {
+	if label, ok := guestStatusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+func FormatGuestReply(reply GuestReply) string {
+	guest := reply.Email
+	if reply.Name != "" {
+		guest = fmt.Sprintf("%s (%s)", reply.Name, reply.Email)
+	}
+	verb := "ответил(а) на приглашение"
+	switch reply.Status {
+	case GuestAccepted:
+		verb = "принял(а) приглашение"
+	case GuestDeclined:
+		verb = "отклонил(а) приглашение"
+	case GuestTentative:
+		verb = "возможно придет"
+	}
+	return fmt.Sprintf("📨 %s %s на «%s» (%s)", guest, verb, reply.EventTitle, reply.EventStart.Local().Format("02.01 15:04"))
+}
+
+func FormatGuests(guests []EventGuest) string {
+	if len(guests) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Гости:")
+	for _, guest := range guests {
+		name := guest.Email
+		if guest.Name != "" {
+			name = guest.Name
+		}
+		b.WriteString(fmt.Sprintf("\n• %s — %s", name, GuestStatusLabel(guest.Status)))
+	}
+	return b.String()
+}
@@ -0,0 +1,337 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icsDateTimeUTC	= "20060102T150405Z"
+	icsDateTime	= "20060102T150405"
+	icsDate		= "20060102"
+	icsLineLimit	= 75
+)
+
+type ICSEvent struct {
+	UID		string
+	Summary		string
+	Description	string
+	Start		time.Time
+	End		time.Time
+	AllDay		bool
+	Cancelled	bool
+}
+
+func WriteICS(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(text string) { writeFolded(bw, text) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Jarvis//Calendar Feed//RU")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICS(name))
+	line("X-PUBLISHED-TTL:PT15M")
+
+	stamp := time.Now().UTC().Format(icsDateTimeUTC)
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.ID + "@jarvis")
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + event.StartTime.UTC().Format(icsDateTimeUTC))
+		line("DTEND:" + event.EndTime.UTC().Format(icsDateTimeUTC))
+		if !event.UpdatedAt.IsZero() {
+			line("LAST-MODIFIED:" + event.UpdatedAt.UTC().Format(icsDateTimeUTC))
+		}
+		line("SUMMARY:" + escapeICS(event.Title))
+		if event.Description != "" {
+			line("DESCRIPTION:" + escapeICS(event.Description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+func writeFolded(w *bufio.Writer, text string) {
+	limit := icsLineLimit
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		w.WriteString(text[:cut])
+		w.WriteString("\r\n ")
+		text = text[cut:]
+		limit = icsLineLimit - 1
+	}
+	w.WriteString(text)
+	w.WriteString("\r\n")
+}
+
+func escapeICS(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+func unescapeICS(text string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(text)
+}
+
+func ParseICS(data string) ([]ICSEvent, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	var events []ICSEvent
+	var current *ICSEvent
+	var recurrenceID string
+	var hasEnd bool
+	depth := 0
+
+	for _, raw := range strings.Split(data, "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		if raw == "" {
+			continue
+		}
+
+		name, params, value := splitICSLine(raw)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &ICSEvent{}
+			recurrenceID = ""
+			hasEnd = false
+			depth = 0
+			continue
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END" && value != "VEVENT":
+			depth--
+			continue
+		case name == "END":
+			if current.UID != "" && !current.Start.IsZero() {
+				if recurrenceID != "" {
+					current.UID += "/" + recurrenceID
+				}
+				if !hasEnd || !current.End.After(current.Start) {
+					if current.AllDay {
+						current.End = current.Start.AddDate(0, 0, 1)
+					} else {
+						current.End = current.Start.Add(time.Hour)
+					}
+				}
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeICS(value)
+		case "DESCRIPTION":
+			current.Description = unescapeICS(value)
+		case "STATUS":
+			current.Cancelled = strings.EqualFold(value, "CANCELLED")
+		case "RECURRENCE-ID":
+			recurrenceID = value
+		case "DTSTART":
+			start, allDay, err := parseICSTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("некорректное время начала события %q: %v", current.UID, err)
+			}
+			current.Start = start
+			current.AllDay = allDay
+		case "DTEND":
+			end, _, err := parseICSTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("некорректное время окончания события %q: %v", current.UID, err)
+			}
+			current.End = end
+			hasEnd = true
+		case "DURATION":
+			if duration, err := parseICSDuration(value); err == nil && !current.Start.IsZero() {
+				current.End = current.Start.Add(duration)
+				hasEnd = true
+			}
+		}
+	}
+
+	return events, nil
+}
+
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+func parseICSTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icsDate) {
+		t, err := time.ParseInLocation(icsDate, value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsDateTimeUTC, value)
+		return t, false, err
+	}
+
+	location := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			location = loc
+		}
+	}
+	t, err := time.ParseInLocation(icsDateTime, value, location)
+	return t, false, err
+}
+
+func parseICSDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	var total time.Duration
+	inTime := false
+	number := 0
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+		case r == 'T':
+			inTime = true
+		case r == 'W':
+			total += time.Duration(number) * 7 * 24 * time.Hour
+			number = 0
+		case r == 'D':
+			total += time.Duration(number) * 24 * time.Hour
+			number = 0
+		case r == 'H' && inTime:
+			total += time.Duration(number) * time.Hour
+			number = 0
+		case r == 'M' && inTime:
+			total += time.Duration(number) * time.Minute
+			number = 0
+		case r == 'S' && inTime:
+			total += time.Duration(number) * time.Second
+			number = 0
+		default:
+			return 0, fmt.Errorf("некорректная длительность %q", value)
+		}
+	}
+	return total, nil
+}
+
+type ICSAttendee struct {
+	Email		string
+	Name		string
+	PartStat	string
+}
+
+type ICSReply struct {
+	UID		string
+	Attendees	[]ICSAttendee
+}
+
+func WriteInvitationICS(w io.Writer, method string, event Event, sequence int, organizerName, organizerEmail string, guest ICSAttendee) error {
+	bw := bufio.NewWriter(w)
+	line := func(text string) { writeFolded(bw, text) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Jarvis//Calendar Invite//RU")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:" + method)
+	line("BEGIN:VEVENT")
+	line("UID:" + event.ID + "@jarvis")
+	line(fmt.Sprintf("SEQUENCE:%d", sequence))
+	line("DTSTAMP:" + time.Now().UTC().Format(icsDateTimeUTC))
+	line("DTSTART:" + event.StartTime.UTC().Format(icsDateTimeUTC))
+	line("DTEND:" + event.EndTime.UTC().Format(icsDateTimeUTC))
+	line("SUMMARY:" + escapeICS(event.Title))
+	if event.Description != "" {
+		line("DESCRIPTION:" + escapeICS(event.Description))
+	}
+	line("ORGANIZER" + icsCommonName(organizerName) + ":mailto:" + organizerEmail)
+	partStat := guest.PartStat
+	if partStat == "" {
+		partStat = "NEEDS-ACTION"
+	}
+	line("ATTENDEE" + icsCommonName(guest.Name) + ";ROLE=REQ-PARTICIPANT;PARTSTAT=" + partStat + ";RSVP=TRUE:mailto:" + guest.Email)
+	if method == "CANCEL" {
+		line("STATUS:CANCELLED")
+	} else {
+		line("STATUS:CONFIRMED")
+	}
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+func icsCommonName(name string) string {
+	name = strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	return `;CN="` + name + `"`
+}
+
+func ParseICSReply(data string) (*ICSReply, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	reply := &ICSReply{}
+	method := ""
+	inEvent := false
+	for _, raw := range strings.Split(data, "\n") {
+		name, params, value := splitICSLine(strings.TrimRight(raw, "\r"))
+		switch {
+		case name == "METHOD" && !inEvent:
+			method = strings.ToUpper(value)
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+		case !inEvent:
+		case name == "UID" && reply.UID == "":
+			reply.UID = value
+		case name == "ATTENDEE":
+			email := strings.ToLower(strings.TrimSpace(value))
+			if len(email) > len("mailto:") && strings.EqualFold(email[:len("mailto:")], "mailto:") {
+				email = email[len("mailto:"):]
+			}
+			reply.Attendees = append(reply.Attendees, ICSAttendee{
+				Email:		email,
+				Name:		params["CN"],
+				PartStat:	strings.ToUpper(params["PARTSTAT"]),
+			})
+		}
+	}
+
+	if method != "REPLY" {
+		return nil, fmt.Errorf("календарь в письме не является ответом на приглашение (METHOD:%s)", method)
+	}
+	if reply.UID == "" || len(reply.Attendees) == 0 {
+		return nil, fmt.Errorf("в ответе на приглашение нет UID или участника")
+	}
+	return reply, nil
+}
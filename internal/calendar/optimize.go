@@ -0,0 +1,136 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	OptimizeRequest	= "оптимизация расписания по часам пиковой продуктивности"
+
+	optimizeHorizonDays	= 7
+	optimizeMinNotice	= time.Hour
+	optimizeDeepMin		= 45 * time.Minute
+	optimizeShallowMax	= 30 * time.Minute
+	optimizeMaxDuration	= 3 * time.Hour
+	optimizeMaxMoves	= 8
+)
+
+func (s *Service) PlanScheduleOptimization(ctx context.Context, userID int64, peakHours []int) (*ReschedulePlan, error) {
+	if len(peakHours) == 0 {
+		return nil, fmt.Errorf("не заданы часы пиковой продуктивности")
+	}
+	now := time.Now()
+	earliest := now.Add(optimizeMinNotice)
+	horizon := startOfDay(now).AddDate(0, 0, optimizeHorizonDays+1)
+
+	var events []Event
+	err := s.db.SelectContext(ctx, &events, `
+		SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at,
+			COALESCE(google_event_id, '') AS google_event_id
+		FROM events
+		WHERE user_id = $1 AND end_time > $2 AND start_time < $3
+			AND external_source IS DISTINCT FROM 'caldav'
+		ORDER BY start_time
+	`, userID, now, horizon)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий для оптимизации: %v", err)
+	}
+	if err := s.AttachGuests(ctx, events); err != nil {
+		return nil, err
+	}
+
+	peak := make(map[int]bool, len(peakHours))
+	for _, hour := range peakHours {
+		peak[hour] = true
+	}
+
+	busy := make(map[string]freeWindow, len(events))
+	var deep, shallow []Event
+	for _, event := range events {
+		event.StartTime, event.EndTime = event.StartTime.Local(), event.EndTime.Local()
+		busy[event.ID] = freeWindow{start: event.StartTime, end: event.EndTime}
+
+		duration := event.EndTime.Sub(event.StartTime)
+		if event.StartTime.Before(earliest) || len(event.Guests) > 0 || duration <= 0 || duration > optimizeMaxDuration {
+			continue
+		}
+		inPeak := peak[event.StartTime.Hour()]
+		switch {
+		case duration >= optimizeDeepMin && !inPeak:
+			deep = append(deep, event)
+		case duration <= optimizeShallowMax && inPeak:
+			shallow = append(shallow, event)
+		}
+	}
+
+	var changes []ScheduleChange
+	moved := make(map[string]bool)
+	move := func(event Event, toPeak bool, reason string) {
+		if moved[event.ID] || len(changes) >= optimizeMaxMoves {
+			return
+		}
+		start, ok := findOptimizedSlot(event, peak, toPeak, busy, earliest)
+		if !ok {
+			return
+		}
+		end := start.Add(event.EndTime.Sub(event.StartTime))
+		busy[event.ID] = freeWindow{start: start, end: end}
+		moved[event.ID] = true
+		changes = append(changes, ScheduleChange{
+			Kind:		ChangeMoveEvent,
+			EventID:	event.ID,
+			Title:		event.Title,
+			Description:	event.Description,
+			GoogleEventID:	event.GoogleEventID,
+			OldStart:	event.StartTime,
+			OldEnd:		event.EndTime,
+			NewStart:	&start,
+			NewEnd:		&end,
+			Reason:		reason,
+		})
+	}
+
+	for _, event := range deep {
+		move(event, true, "пиковый час продуктивности для долгой задачи")
+	}
+	for _, event := range shallow {
+		move(event, false, "освобождает пиковый час для глубокой работы")
+	}
+	for _, event := range deep {
+		move(event, true, "пиковый час продуктивности для долгой задачи")
+	}
+
+	plan := &ReschedulePlan{UserID: userID, Request: OptimizeRequest, Status: PlanStatusProposed, Changes: changes}
+	if len(changes) == 0 {
+		return plan, nil
+	}
+	return s.saveReschedulePlan(ctx, plan)
+}
+
+func findOptimizedSlot(event Event, peak map[int]bool, toPeak bool, busy map[string]freeWindow, earliest time.Time) (time.Time, bool) {
+	duration := event.EndTime.Sub(event.StartTime)
+	day := startOfDay(event.StartTime)
+
+	var best time.Time
+	found := false
+	for at := day.Add(rescheduleDayStart * time.Hour); !at.Add(duration).After(day.Add(rescheduleDayEnd * time.Hour)); at = at.Add(rescheduleSlotStep) {
+		if at.Before(earliest) || peak[at.Hour()] != toPeak || overlapsBusy(at, at.Add(duration), busy, event.ID) {
+			continue
+		}
+		if !found || absDuration(at.Sub(event.StartTime)) < absDuration(best.Sub(event.StartTime)) {
+			best, found = at, true
+		}
+	}
+	return best, found
+}
+
+func overlapsBusy(start, end time.Time, busy map[string]freeWindow, skipID string) bool {
+	for id, w := range busy {
+		if id != skipID && start.Before(w.end) && end.After(w.start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,454 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	ChangeMoveEvent		= "move_event"
+	ChangeCancelEvent	= "cancel_event"
+	ChangeShiftTask		= "shift_task"
+
+	PlanStatusProposed	= "proposed"
+	PlanStatusApplied	= "applied"
+	PlanStatusUndone	= "undone"
+	PlanStatusDiscarded	= "discarded"
+
+	rescheduleSearchDays	= 7
+	rescheduleSlotStep	= 30 * time.Minute
+	rescheduleDayStart	= 8
+	rescheduleDayEnd	= 21
+	rescheduleUndoWindow	= 24 * time.Hour
+	reschedulePlanTTL	= 2 * time.Hour
+)
+
+var (
+	ErrPlanNotFound		= errors.New("план переноса не найден или уже неактуален")
+	ErrPlanOutdated		= errors.New("календарь изменился после составления плана, составьте план заново")
+	ErrNothingToUndo	= errors.New("нет примененного плана, который можно отменить")
+)
+
+type RescheduleRequest struct {
+	From		time.Time
+	To		time.Time
+	FreeFrom	time.Duration
+	FreeTo		time.Duration
+	CancelEvents	bool
+	ShiftTasks	bool
+	Description	string
+}
+
+type ScheduleChange struct {
+	Kind		string		`json:"kind"`
+	EventID		string		`json:"event_id,omitempty"`
+	TaskID		int64		`json:"task_id,omitempty"`
+	Title		string		`json:"title"`
+	Description	string		`json:"description,omitempty"`
+	GoogleEventID	string		`json:"google_event_id,omitempty"`
+	OldStart	time.Time	`json:"old_start"`
+	OldEnd		time.Time	`json:"old_end,omitempty"`
+	NewStart	*time.Time	`json:"new_start,omitempty"`
+	NewEnd		*time.Time	`json:"new_end,omitempty"`
+	Reason		string		`json:"reason,omitempty"`
+}
+
+type ReschedulePlan struct {
+	ID		int64			`db:"id"`
+	UserID		int64			`db:"user_id"`
+	Request		string			`db:"request"`
+	Status		string			`db:"status"`
+	CreatedAt	time.Time		`db:"created_at"`
+	AppliedAt	*time.Time		`db:"applied_at"`
+	RawChanges	[]byte			`db:"changes"`
+	Changes		[]ScheduleChange	`db:"-"`
+}
+
+type freeWindow struct {
+	start	time.Time
+	end	time.Time
+}
+
+func (s *Service) PlanReschedule(ctx context.Context, userID int64, req RescheduleRequest) (*ReschedulePlan, error) {
+	windows := req.windows()
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("не удалось определить, какое время освободить")
+	}
+	now := time.Now()
+	horizon := windows[len(windows)-1].end.AddDate(0, 0, rescheduleSearchDays)
+
+	var events []Event
+	err := s.db.SelectContext(ctx, &events, `
+		SELECT id, user_id, title, COALESCE(description, '') AS description, start_time, end_time, created_at,
+			COALESCE(google_event_id, '') AS google_event_id
+		FROM events
+		WHERE user_id = $1 AND end_time > $2 AND start_time < $3
+			AND external_source IS DISTINCT FROM 'caldav'
+		ORDER BY start_time
+	`, userID, windows[0].start, horizon)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий для переноса: %v", err)
+	}
+
+	var busy []freeWindow
+	var affected []Event
+	for _, event := range events {
+		if overlapsAny(event.StartTime, event.EndTime, windows) && event.StartTime.After(now) {
+			affected = append(affected, event)
+			continue
+		}
+		busy = append(busy, freeWindow{start: event.StartTime, end: event.EndTime})
+	}
+
+	var changes []ScheduleChange
+	for _, event := range affected {
+		change := ScheduleChange{
+			Kind:		ChangeCancelEvent,
+			EventID:	event.ID,
+			Title:		event.Title,
+			Description:	event.Description,
+			GoogleEventID:	event.GoogleEventID,
+			OldStart:	event.StartTime,
+			OldEnd:		event.EndTime,
+		}
+		if !req.CancelEvents {
+			if start, ok := findRescheduleSlot(event.StartTime, event.EndTime.Sub(event.StartTime), windows, busy, horizon, now); ok {
+				end := start.Add(event.EndTime.Sub(event.StartTime))
+				change.Kind = ChangeMoveEvent
+				change.NewStart, change.NewEnd = &start, &end
+				busy = append(busy, freeWindow{start: start, end: end})
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if req.ShiftTasks {
+		var tasks []struct {
+			ID		int64		`db:"id"`
+			Title		string		`db:"title"`
+			Deadline	time.Time	`db:"deadline"`
+		}
+		err = s.db.SelectContext(ctx, &tasks, `
+			SELECT t.id, t.title, t.deadline
+			FROM tasks t
+			JOIN key_results kr ON kr.id = t.key_result_id
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE o.user_id = $1 AND t.deleted_at IS NULL AND t.completion_date IS NULL AND t.status IS DISTINCT FROM 'skipped'
+				AND t.recurrence_series IS NULL AND t.deadline >= $2 AND t.deadline < $3
+			ORDER BY t.deadline
+		`, userID, windows[0].start, windows[len(windows)-1].end)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении задач для переноса: %v", err)
+		}
+
+		lastDay := startOfDay(windows[len(windows)-1].start)
+		for _, task := range tasks {
+			if !overlapsAny(task.Deadline, task.Deadline.Add(time.Second), windows) {
+				continue
+			}
+			days := int(lastDay.Sub(startOfDay(task.Deadline)).Hours()/24) + 1
+			deadline := task.Deadline.AddDate(0, 0, days)
+			changes = append(changes, ScheduleChange{
+				Kind:		ChangeShiftTask,
+				TaskID:		task.ID,
+				Title:		task.Title,
+				OldStart:	task.Deadline,
+				NewStart:	&deadline,
+			})
+		}
+	}
+
+	plan := &ReschedulePlan{UserID: userID, Request: req.Description, Status: PlanStatusProposed, Changes: changes}
+	if len(changes) == 0 {
+		return plan, nil
+	}
+
+	return s.saveReschedulePlan(ctx, plan)
+}
+
+func (s *Service) ApplyReschedulePlan(ctx context.Context, userID int64) (*ReschedulePlan, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	plan, err := s.lockPlan(ctx, tx, userID, PlanStatusProposed)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(plan.CreatedAt) > reschedulePlanTTL {
+		return nil, ErrPlanOutdated
+	}
+
+	for _, change := range plan.Changes {
+		if err := applyScheduleChange(ctx, tx, userID, change); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE reschedule_plans SET status = $2, applied_at = NOW() WHERE id = $1`, plan.ID, PlanStatusApplied); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении статуса плана: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при применении плана переноса: %v", err)
+	}
+
+	plan.Status = PlanStatusApplied
+	return plan, nil
+}
+
+func (s *Service) UndoReschedulePlan(ctx context.Context, userID int64) (*ReschedulePlan, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	plan, err := s.lockPlan(ctx, tx, userID, PlanStatusApplied)
+	if errors.Is(err, ErrPlanNotFound) || (err == nil && plan.AppliedAt != nil && time.Since(*plan.AppliedAt) > rescheduleUndoWindow) {
+		return nil, ErrNothingToUndo
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(plan.Changes) - 1; i >= 0; i-- {
+		if err := revertScheduleChange(ctx, tx, userID, plan.Changes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE reschedule_plans SET status = $2, undone_at = NOW() WHERE id = $1`, plan.ID, PlanStatusUndone); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении статуса плана: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при отмене плана переноса: %v", err)
+	}
+
+	plan.Status = PlanStatusUndone
+	return plan, nil
+}
+
+func (s *Service) saveReschedulePlan(ctx context.Context, plan *ReschedulePlan) (*ReschedulePlan, error) {
+	raw, err := json.Marshal(plan.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении плана переноса: %v", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE reschedule_plans SET status = $2 WHERE user_id = $1 AND status = $3
+	`, plan.UserID, PlanStatusDiscarded, PlanStatusProposed)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при закрытии прежних планов: %v", err)
+	}
+	err = tx.GetContext(ctx, plan, `
+		INSERT INTO reschedule_plans (user_id, request, changes)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, request, status, created_at, applied_at, changes
+	`, plan.UserID, plan.Request, raw)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении плана переноса: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении плана переноса: %v", err)
+	}
+	return plan, nil
+}
+
+func (s *Service) lockPlan(ctx context.Context, tx *sqlx.Tx, userID int64, status string) (*ReschedulePlan, error) {
+	var plan ReschedulePlan
+	err := tx.GetContext(ctx, &plan, `
+		SELECT id, user_id, request, status, created_at, applied_at, changes
+		FROM reschedule_plans
+		WHERE user_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, userID, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении плана переноса: %v", err)
+	}
+	if err := json.Unmarshal(plan.RawChanges, &plan.Changes); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении плана переноса: %v", err)
+	}
+	return &plan, nil
+}
+
+func applyScheduleChange(ctx context.Context, tx *sqlx.Tx, userID int64, change ScheduleChange) error {
+	var result sql.Result
+	var err error
+	switch change.Kind {
+	case ChangeMoveEvent:
+		result, err = tx.ExecContext(ctx, `
+			UPDATE events
+			SET start_time = $3, end_time = $4, updated_at = NOW(), reminder_sent = FALSE,
+				google_sync_pending = google_sync_pending OR EXISTS(SELECT 1 FROM google_tokens WHERE user_id = $2)
+			WHERE id = $1 AND user_id = $2 AND start_time = $5
+		`, change.EventID, userID, *change.NewStart, *change.NewEnd, change.OldStart)
+	case ChangeCancelEvent:
+		result, err = tx.ExecContext(ctx, `DELETE FROM events WHERE id = $1 AND user_id = $2 AND start_time = $3`, change.EventID, userID, change.OldStart)
+		if err == nil && change.GoogleEventID != "" {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO google_event_deletions (user_id, google_event_id) VALUES ($1, $2)
+				ON CONFLICT (user_id, google_event_id) DO NOTHING
+			`, userID, change.GoogleEventID)
+		}
+	case ChangeShiftTask:
+		result, err = tx.ExecContext(ctx, `
+			UPDATE tasks t SET deadline = $3, updated_at = NOW()
+			FROM key_results kr JOIN objectives o ON o.id = kr.objective_id
+			WHERE t.id = $1 AND kr.id = t.key_result_id AND o.user_id = $2 AND t.deadline = $4
+		`, change.TaskID, userID, *change.NewStart, change.OldStart)
+	default:
+		return fmt.Errorf("неизвестный тип изменения %q", change.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при применении изменения «%s»: %v", change.Title, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrPlanOutdated
+	}
+	return nil
+}
+
+func revertScheduleChange(ctx context.Context, tx *sqlx.Tx, userID int64, change ScheduleChange) error {
+	var err error
+	switch change.Kind {
+	case ChangeMoveEvent:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE events
+			SET start_time = $3, end_time = $4, updated_at = NOW(), reminder_sent = FALSE,
+				google_sync_pending = google_sync_pending OR EXISTS(SELECT 1 FROM google_tokens WHERE user_id = $2)
+			WHERE id = $1 AND user_id = $2
+		`, change.EventID, userID, change.OldStart, change.OldEnd)
+	case ChangeCancelEvent:
+		googleEventID := sql.NullString{}
+		if change.GoogleEventID != "" {
+			result, delErr := tx.ExecContext(ctx, `
+				DELETE FROM google_event_deletions WHERE user_id = $1 AND google_event_id = $2
+			`, userID, change.GoogleEventID)
+			if delErr != nil {
+				return fmt.Errorf("ошибка при отмене удаления из Google Calendar: %v", delErr)
+			}
+			if rows, _ := result.RowsAffected(); rows > 0 {
+				googleEventID = sql.NullString{String: change.GoogleEventID, Valid: true}
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO events (id, user_id, title, description, start_time, end_time, created_at, google_event_id, google_sync_pending)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW(), $7, $8)
+			ON CONFLICT (id) DO NOTHING
+		`, change.EventID, userID, change.Title, change.Description, change.OldStart, change.OldEnd,
+			googleEventID, change.GoogleEventID != "" && !googleEventID.Valid)
+	case ChangeShiftTask:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE tasks t SET deadline = $3, updated_at = NOW()
+			FROM key_results kr JOIN objectives o ON o.id = kr.objective_id
+			WHERE t.id = $1 AND kr.id = t.key_result_id AND o.user_id = $2
+		`, change.TaskID, userID, change.OldStart)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при откате изменения «%s»: %v", change.Title, err)
+	}
+	return nil
+}
+
+func (r RescheduleRequest) windows() []freeWindow {
+	freeTo := r.FreeTo
+	if freeTo <= r.FreeFrom {
+		freeTo = 24 * time.Hour
+	}
+	var windows []freeWindow
+	for day := startOfDay(r.From); !day.After(r.To); day = day.AddDate(0, 0, 1) {
+		windows = append(windows, freeWindow{start: day.Add(r.FreeFrom), end: day.Add(freeTo)})
+	}
+	return windows
+}
+
+func findRescheduleSlot(original time.Time, duration time.Duration, windows, busy []freeWindow, horizon, now time.Time) (time.Time, bool) {
+	var candidates []time.Time
+	for day := startOfDay(original); day.Before(horizon); day = day.AddDate(0, 0, 1) {
+		for at := day.Add(rescheduleDayStart * time.Hour); !at.Add(duration).After(day.Add(rescheduleDayEnd * time.Hour)); at = at.Add(rescheduleSlotStep) {
+			if at.After(now) && !overlapsAny(at, at.Add(duration), windows) && !overlapsAny(at, at.Add(duration), busy) {
+				candidates = append(candidates, at)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return time.Time{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return absDuration(candidates[i].Sub(original)) < absDuration(candidates[j].Sub(original))
+	})
+	return candidates[0], true
+}
+
+func overlapsAny(start, end time.Time, windows []freeWindow) bool {
+	for _, w := range windows {
+		if start.Before(w.end) && end.After(w.start) {
+			return true
+		}
+	}
+	return false
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func FormatReschedulePlan(plan *ReschedulePlan) string {
+	if len(plan.Changes) == 0 {
+		return "✅ В этом промежутке нет событий и задач, которые нужно переносить"
+	}
+
+	var b strings.Builder
+	if plan.Request == OptimizeRequest {
+		b.WriteString("🗓 **План оптимизации расписания**\n\n")
+	} else {
+		b.WriteString("🗓 **План разгрузки**\n\n")
+	}
+	for _, change := range plan.Changes {
+		switch change.Kind {
+		case ChangeMoveEvent:
+			b.WriteString(fmt.Sprintf("➡️ %s: %s → %s", change.Title, change.OldStart.Format("02.01 15:04"), change.NewStart.Format("02.01 15:04")))
+			if change.Reason != "" {
+				b.WriteString(" — " + change.Reason)
+			}
+			b.WriteString("\n")
+		case ChangeCancelEvent:
+			b.WriteString(fmt.Sprintf("❌ %s (%s) — отменить, свободного места не нашлось\n", change.Title, change.OldStart.Format("02.01 15:04")))
+		case ChangeShiftTask:
+			b.WriteString(fmt.Sprintf("📌 Задача «%s»: дедлайн %s → %s\n", change.Title, change.OldStart.Format("02.01 15:04"), change.NewStart.Format("02.01 15:04")))
+		}
+	}
+	return b.String()
+}
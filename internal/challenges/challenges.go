@@ -0,0 +1,413 @@
+package challenges
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	PeriodDaily	= "daily"
+	PeriodWeekly	= "weekly"
+	PeriodMonthly	= "monthly"
+
+	StatusPending	= "pending"
+	StatusJoined	= "joined"
+	StatusDeclined	= "declined"
+
+	maxDurationDays	= 366
+)
+
+var (
+	ErrChallengeNotFound	= errors.New("челлендж не найден")
+	ErrUnknownPeriod	= errors.New("неизвестный период челленджа")
+	ErrInvalidChallenge	= errors.New("некорректные параметры челленджа")
+	ErrNotParticipant	= errors.New("пользователь не участвует в челлендже")
+	ErrAlreadyParticipant	= errors.New("пользователь уже участвует в челлендже")
+	ErrChallengeNotActive	= errors.New("челлендж сейчас не идет")
+	ErrNotCreator		= errors.New("только автор челленджа может приглашать участников")
+)
+
+var Periods = []string{PeriodDaily, PeriodWeekly, PeriodMonthly}
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type Challenge struct {
+	ID		int64		`db:"id" json:"id"`
+	CreatedBy	int64		`db:"created_by" json:"created_by"`
+	CreatorName	string		`db:"creator_name" json:"creator_name"`
+	Title		string		`db:"title" json:"title"`
+	Description	string		`db:"description" json:"description,omitempty"`
+	Period		string		`db:"period" json:"period"`
+	Target		float64		`db:"target" json:"target"`
+	Unit		string		`db:"unit" json:"unit"`
+	StartsOn	time.Time	`db:"starts_on" json:"starts_on"`
+	EndsOn		time.Time	`db:"ends_on" json:"ends_on"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type Standing struct {
+	UserID		int64	`db:"user_id" json:"user_id"`
+	Username	*string	`db:"username" json:"username,omitempty"`
+	FirstName	*string	`db:"first_name" json:"first_name,omitempty"`
+	Total		float64	`db:"total" json:"total"`
+	Completed	int	`db:"completed" json:"completed"`
+	Current		float64	`db:"current" json:"current"`
+}
+
+const challengeSelect = `
+	SELECT c.id, c.created_by, COALESCE(NULLIF(u.first_name, ''), u.username, '') AS creator_name,
+		c.title, c.description, c.period, c.target, c.unit, c.starts_on, c.ends_on, c.created_at
+	FROM challenges c
+	LEFT JOIN users u ON u.id = c.created_by
+`
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func truncUnit(period string) string {
+	switch period {
+	case PeriodWeekly:
+		return "week"
+	case PeriodMonthly:
+		return "month"
+	default:
+		return "day"
+	}
+}
+
+func (c *Challenge) IsActive(now time.Time) bool {
+	today := dateOnly(now)
+	return !today.Before(dateOnly(c.StartsOn)) && !today.After(dateOnly(c.EndsOn))
+}
+
+func (c *Challenge) TotalPeriods() int {
+	days := int(dateOnly(c.EndsOn).Sub(dateOnly(c.StartsOn)).Hours()/24) + 1
+	switch c.Period {
+	case PeriodWeekly:
+		return (days + 6) / 7
+	case PeriodMonthly:
+		months := (c.EndsOn.Year()-c.StartsOn.Year())*12 + int(c.EndsOn.Month()-c.StartsOn.Month()) + 1
+		return months
+	default:
+		return days
+	}
+}
+
+func (c *Challenge) PeriodLabel() string {
+	switch c.Period {
+	case PeriodWeekly:
+		return "в неделю"
+	case PeriodMonthly:
+		return "в месяц"
+	default:
+		return "в день"
+	}
+}
+
+func (c *Challenge) Goal() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", formatAmount(c.Target), c.Unit, c.PeriodLabel()))
+}
+
+func (st *Standing) DisplayName() string {
+	if st.FirstName != nil && *st.FirstName != "" {
+		return *st.FirstName
+	}
+	if st.Username != nil && *st.Username != "" {
+		return "@" + *st.Username
+	}
+	return fmt.Sprintf("%d", st.UserID)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func formatAmount(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+func (s *Service) CreateChallenge(ctx context.Context, userID int64, title, description, period string, target float64, unit string, durationDays int, startsOn time.Time) (*Challenge, error) {
+	if period != PeriodDaily && period != PeriodWeekly && period != PeriodMonthly {
+		return nil, ErrUnknownPeriod
+	}
+	if strings.TrimSpace(title) == "" || target <= 0 {
+		return nil, fmt.Errorf("%w: нужны название и положительная цель", ErrInvalidChallenge)
+	}
+	if durationDays <= 0 || durationDays > maxDurationDays {
+		return nil, fmt.Errorf("%w: длительность должна быть от 1 до %d дней", ErrInvalidChallenge, maxDurationDays)
+	}
+
+	startsOn = dateOnly(startsOn)
+	endsOn := startsOn.AddDate(0, 0, durationDays-1)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var challengeID int64
+	err = tx.GetContext(ctx, &challengeID, `
+		INSERT INTO challenges (created_by, title, description, period, target, unit, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, userID, strings.TrimSpace(title), strings.TrimSpace(description), period, target, strings.TrimSpace(unit), startsOn, endsOn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании челленджа: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO challenge_participants (challenge_id, user_id, status, notified, joined_at)
+		VALUES ($1, $2, 'joined', TRUE, NOW())
+	`, challengeID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении автора в челлендж: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+
+	return s.GetChallenge(ctx, challengeID)
+}
+
+func (s *Service) GetChallenge(ctx context.Context, challengeID int64) (*Challenge, error) {
+	var challenge Challenge
+	err := s.db.GetContext(ctx, &challenge, challengeSelect+` WHERE c.id = $1`, challengeID)
+	if err == sql.ErrNoRows {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении челленджа: %v", err)
+	}
+	return &challenge, nil
+}
+
+func (s *Service) participantStatus(ctx context.Context, challengeID, userID int64) (string, error) {
+	var status string
+	err := s.db.GetContext(ctx, &status, `
+		SELECT status FROM challenge_participants WHERE challenge_id = $1 AND user_id = $2
+	`, challengeID, userID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotParticipant
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка при проверке участия в челлендже: %v", err)
+	}
+	return status, nil
+}
+
+func (s *Service) InviteParticipant(ctx context.Context, userID, challengeID, inviteeID int64) error {
+	challenge, err := s.GetChallenge(ctx, challengeID)
+	if err != nil {
+		return err
+	}
+	if challenge.CreatedBy != userID {
+		return ErrNotCreator
+	}
+	if dateOnly(time.Now()).After(dateOnly(challenge.EndsOn)) {
+		return ErrChallengeNotActive
+	}
+
+	status, err := s.participantStatus(ctx, challengeID, inviteeID)
+	if err == nil && status == StatusJoined {
+		return ErrAlreadyParticipant
+	}
+	if err != nil && !errors.Is(err, ErrNotParticipant) {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO challenge_participants (challenge_id, user_id, status, invited_by)
+		VALUES ($1, $2, 'pending', $3)
+		ON CONFLICT (challenge_id, user_id) DO UPDATE SET status = 'pending', invited_by = EXCLUDED.invited_by, notified = FALSE
+	`, challengeID, inviteeID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при приглашении в челлендж: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) RespondToInvite(ctx context.Context, userID, challengeID int64, accept bool) (*Challenge, error) {
+	status := StatusDeclined
+	if accept {
+		status = StatusJoined
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE challenge_participants
+		SET status = $3, joined_at = CASE WHEN $3 = 'joined' THEN NOW() ELSE joined_at END
+		WHERE challenge_id = $1 AND user_id = $2 AND status = 'pending'
+	`, challengeID, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при ответе на приглашение в челлендж: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrNotParticipant
+	}
+
+	return s.GetChallenge(ctx, challengeID)
+}
+
+func (s *Service) GetUserChallenges(ctx context.Context, userID int64, includeFinished bool) ([]Challenge, error) {
+	query := challengeSelect + `
+		JOIN challenge_participants p ON p.challenge_id = c.id
+		WHERE p.user_id = $1 AND p.status IN ('joined', 'pending')
+	`
+	if !includeFinished {
+		query += ` AND c.ends_on >= CURRENT_DATE`
+	}
+	query += ` ORDER BY c.starts_on DESC`
+
+	var challenges []Challenge
+	if err := s.db.SelectContext(ctx, &challenges, query, userID); err != nil {
+		return nil, fmt.Errorf("ошибка при получении челленджей: %v", err)
+	}
+	return challenges, nil
+}
+
+func (s *Service) FindChallenge(ctx context.Context, userID int64, description string) (*Challenge, error) {
+	challenges, err := s.GetUserChallenges(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(challenges) == 0 {
+		return nil, ErrChallengeNotFound
+	}
+
+	description = strings.ToLower(strings.TrimSpace(description))
+	if description == "" {
+		return &challenges[0], nil
+	}
+	for i := range challenges {
+		if strings.Contains(strings.ToLower(challenges[i].Title), description) {
+			return &challenges[i], nil
+		}
+	}
+	return nil, ErrChallengeNotFound
+}
+
+func (s *Service) LogProgress(ctx context.Context, userID, challengeID int64, amount float64) (*Standing, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: значение должно быть положительным", ErrInvalidChallenge)
+	}
+
+	challenge, err := s.GetChallenge(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.IsActive(time.Now()) {
+		return nil, ErrChallengeNotActive
+	}
+
+	status, err := s.participantStatus(ctx, challengeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusJoined {
+		return nil, ErrNotParticipant
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO challenge_progress (challenge_id, user_id, amount) VALUES ($1, $2, $3)
+	`, challengeID, userID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при записи прогресса челленджа: %v", err)
+	}
+
+	standings, err := s.GetLeaderboard(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	for i := range standings {
+		if standings[i].UserID == userID {
+			return &standings[i], nil
+		}
+	}
+	return &Standing{UserID: userID, Total: amount, Current: amount}, nil
+}
+
+func (s *Service) GetLeaderboard(ctx context.Context, challenge *Challenge) ([]Standing, error) {
+	var standings []Standing
+	err := s.db.SelectContext(ctx, &standings, `
+		WITH buckets AS (
+			SELECT user_id, date_trunc($2, logged_on) AS bucket, SUM(amount) AS total
+			FROM challenge_progress
+			WHERE challenge_id = $1
+			GROUP BY user_id, date_trunc($2, logged_on)
+		)
+		SELECT p.user_id, u.username, u.first_name,
+			COALESCE(SUM(b.total), 0) AS total,
+			COUNT(b.bucket) FILTER (WHERE b.total >= $3) AS completed,
+			COALESCE(SUM(b.total) FILTER (WHERE b.bucket = date_trunc($2, CURRENT_DATE)), 0) AS current
+		FROM challenge_participants p
+		LEFT JOIN buckets b ON b.user_id = p.user_id
+		LEFT JOIN users u ON u.id = p.user_id
+		WHERE p.challenge_id = $1 AND p.status = 'joined'
+		GROUP BY p.user_id, u.username, u.first_name
+		ORDER BY completed DESC, total DESC
+	`, challenge.ID, truncUnit(challenge.Period), challenge.Target)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении таблицы лидеров: %v", err)
+	}
+	return standings, nil
+}
+
+func (s *Service) getParticipantIDs(ctx context.Context, challengeID int64) ([]int64, error) {
+	var ids []int64
+	err := s.db.SelectContext(ctx, &ids, `
+		SELECT user_id FROM challenge_participants WHERE challenge_id = $1 AND status = 'joined'
+	`, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении участников челленджа: %v", err)
+	}
+	return ids, nil
+}
+
+func FormatChallenge(challenge *Challenge) string {
+	text := fmt.Sprintf("🏆 **%s**\n🎯 %s\n📅 %s — %s",
+		challenge.Title, challenge.Goal(), challenge.StartsOn.Format("02.01.2006"), challenge.EndsOn.Format("02.01.2006"))
+	if challenge.Description != "" {
+		text += "\n📝 " + challenge.Description
+	}
+	return text
+}
+
+func FormatLeaderboard(challenge *Challenge, standings []Standing) string {
+	var b strings.Builder
+	b.WriteString(FormatChallenge(challenge))
+	b.WriteString("\n\n📊 **Таблица лидеров:**\n")
+
+	if len(standings) == 0 {
+		b.WriteString("Пока нет участников")
+		return b.String()
+	}
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	total := challenge.TotalPeriods()
+	for i, st := range standings {
+		place := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			place = medals[i]
+		}
+		b.WriteString(fmt.Sprintf("%s %s — выполнено %d из %d, всего %s %s\n",
+			place, st.DisplayName(), st.Completed, total, formatAmount(st.Total), challenge.Unit))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
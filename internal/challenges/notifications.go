@@ -0,0 +1,137 @@
+package challenges
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/jobs"
+
+	"github.com/sirupsen/logrus"
+)
+
+type pendingInvite struct {
+	ChallengeID	int64	`db:"challenge_id"`
+	UserID		int64	`db:"user_id"`
+	InviterName	string	`db:"inviter_name"`
+}
+
+func (s *Service) StartChallengeNotifier(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error, sendInvite func(chatID int64, text string, challengeID int64) error) {
+	jm.Register(jobs.Job{
+		Name:		"challenge_invites",
+		Spec:		"@every 20s",
+		Run: func(ctx context.Context) {
+			s.notifyPendingInvites(ctx, sendInvite)
+		},
+	})
+
+	jm.Register(jobs.Job{
+		Name:		"challenge_lifecycle",
+		Spec:		"0 9 * * *",
+		Run: func(ctx context.Context) {
+			s.notifyStartedChallenges(ctx, sendMessageFunc)
+			s.notifyFinishedChallenges(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущены уведомления о челленджах")
+}
+
+func (s *Service) notifyPendingInvites(ctx context.Context, sendInvite func(chatID int64, text string, challengeID int64) error) {
+	var invites []pendingInvite
+	err := s.db.SelectContext(ctx, &invites, `
+		SELECT p.challenge_id, p.user_id, COALESCE(NULLIF(u.first_name, ''), u.username, '') AS inviter_name
+		FROM challenge_participants p
+		JOIN challenges c ON c.id = p.challenge_id
+		LEFT JOIN users u ON u.id = p.invited_by
+		WHERE p.status = 'pending' AND p.notified = FALSE AND c.ends_on >= CURRENT_DATE
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении приглашений в челленджи: %v", err)
+		return
+	}
+
+	for _, invite := range invites {
+		challenge, err := s.GetChallenge(ctx, invite.ChallengeID)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении челленджа %d: %v", invite.ChallengeID, err)
+			continue
+		}
+
+		message := fmt.Sprintf("🏆 %s зовет вас в челлендж!\n\n%s", invite.InviterName, FormatChallenge(challenge))
+		if err := sendInvite(invite.UserID, message, invite.ChallengeID); err != nil {
+			logrus.Errorf("Ошибка при отправке приглашения в челлендж пользователю %d: %v", invite.UserID, err)
+			continue
+		}
+
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE challenge_participants SET notified = TRUE WHERE challenge_id = $1 AND user_id = $2
+		`, invite.ChallengeID, invite.UserID)
+		if err != nil {
+			logrus.Errorf("Ошибка при отметке приглашения в челлендж: %v", err)
+		}
+	}
+}
+
+func (s *Service) notifyStartedChallenges(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	var challenges []Challenge
+	err := s.db.SelectContext(ctx, &challenges, challengeSelect+`
+		WHERE c.start_notified = FALSE AND c.starts_on <= CURRENT_DATE AND c.ends_on >= CURRENT_DATE
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении стартующих челленджей: %v", err)
+		return
+	}
+
+	for i := range challenges {
+		challenge := &challenges[i]
+		message := fmt.Sprintf("🚀 Челлендж стартовал!\n\n%s\n\nОтмечайте прогресс: «сделал 20 отжиманий в челлендже»", FormatChallenge(challenge))
+		s.broadcast(ctx, challenge.ID, message, sendMessageFunc)
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE challenges SET start_notified = TRUE WHERE id = $1`, challenge.ID); err != nil {
+			logrus.Errorf("Ошибка при отметке старта челленджа %d: %v", challenge.ID, err)
+		}
+	}
+}
+
+func (s *Service) notifyFinishedChallenges(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	var challenges []Challenge
+	err := s.db.SelectContext(ctx, &challenges, challengeSelect+`
+		WHERE c.end_notified = FALSE AND c.ends_on < CURRENT_DATE
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении завершившихся челленджей: %v", err)
+		return
+	}
+
+	for i := range challenges {
+		challenge := &challenges[i]
+		standings, err := s.GetLeaderboard(ctx, challenge)
+		if err != nil {
+			logrus.Errorf("Ошибка при подведении итогов челленджа %d: %v", challenge.ID, err)
+			continue
+		}
+
+		message := "🏁 Челлендж завершен!\n\n" + FormatLeaderboard(challenge, standings)
+		if len(standings) > 0 && standings[0].Completed > 0 {
+			message += fmt.Sprintf("\n\n🎉 Победитель — %s!", standings[0].DisplayName())
+		}
+		s.broadcast(ctx, challenge.ID, message, sendMessageFunc)
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE challenges SET end_notified = TRUE, start_notified = TRUE WHERE id = $1`, challenge.ID); err != nil {
+			logrus.Errorf("Ошибка при отметке завершения челленджа %d: %v", challenge.ID, err)
+		}
+	}
+}
+
+func (s *Service) broadcast(ctx context.Context, challengeID int64, message string, sendMessageFunc func(chatID int64, text string) error) {
+	participants, err := s.getParticipantIDs(ctx, challengeID)
+	if err != nil {
+		logrus.Errorf("Ошибка при рассылке по челленджу %d: %v", challengeID, err)
+		return
+	}
+
+	for _, userID := range participants {
+		if err := sendMessageFunc(userID, message); err != nil {
+			logrus.Errorf("Ошибка при отправке уведомления о челлендже пользователю %d: %v", userID, err)
+		}
+	}
+}
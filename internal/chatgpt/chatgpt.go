@@ -47,7 +47,7 @@ func (s *Service) ProcessTextMessage(ctx context.Context, message string, functi
 		Functions:	functions,
 	}
 
-	resp, err := s.client.CreateChatCompletion(ctx, chatReq)
+	resp, err := createChatCompletion(ctx, s.client, chatReq)
 	if err != nil {
 		logrus.Errorf("Ошибка при запросе к OpenAI: %v", err)
 		return "", nil, err
@@ -98,7 +98,7 @@ func (s *Service) ProcessTextMessageWithHistory(ctx context.Context, message str
 		Functions:	functions,
 	}
 
-	resp, err := s.client.CreateChatCompletion(ctx, chatReq)
+	resp, err := createChatCompletion(ctx, s.client, chatReq)
 	if err != nil {
 		logrus.Errorf("Ошибка при запросе к OpenAI с историей: %v", err)
 		return "", nil, err, nil, nil
@@ -166,8 +166,9 @@ func (s *Service) transcribeAudio(ctx context.Context, audioData []byte) (string
 		return "", fmt.Errorf("ошибка записи аудиоданных: %w", err)
 	}
 
-	resp, err := s.client.CreateTranscription(
+	resp, err := createTranscription(
 		ctx,
+		s.client,
 		openai.AudioRequest{
 			Model:		openai.Whisper1,
 			FilePath:	tempFile.Name(),
@@ -209,6 +210,10 @@ func DefineFunctions() []openai.FunctionDefinition {
 						"type":		"string",
 						"description":	"Время окончания события в формате ISO 8601 (YYYY-MM-DDTHH:MM:SS)",
 					},
+					"allow_overlap": map[string]interface{}{
+						"type":		"boolean",
+						"description":	"Создать поверх уже занятого времени. Ставь true только после того, как пользователь подтвердил это в ответ на вопрос о пересечении",
+					},
 				},
 				"required":	[]string{"title", "start_time", "end_time"},
 			},
@@ -239,6 +244,14 @@ func DefineFunctions() []openai.FunctionDefinition {
 						"type":		"string",
 						"description":	"Время окончания встречи в формате ISO 8601 (YYYY-MM-DDTHH:MM:SS)",
 					},
+					"in_person": map[string]interface{}{
+						"type":		"boolean",
+						"description":	"Очная встреча в офисе (true) или онлайн (false)",
+					},
+					"allow_overlap": map[string]interface{}{
+						"type":		"boolean",
+						"description":	"Создать поверх уже занятого времени. Ставь true только после того, как пользователь подтвердил это в ответ на вопрос о пересечении",
+					},
 				},
 				"required":	[]string{"title", "participant_username", "start_time", "end_time"},
 			},
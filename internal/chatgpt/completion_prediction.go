@@ -0,0 +1,44 @@
+package chatgpt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"telegrambot/internal/ai_coach"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const completionPredictionPrompt = `Ты аналитик личных целей. По истории цели и пользователя оцени, успеет ли он выполнить цель к дедлайну.
+Ответь JSON-объектом строго по схеме: {"probability": число от 0 до 1, "risks": ["риск", ...], "recommendations": ["рекомендация", ...]}.
+Опирайся только на приведенные данные: темп прогресса, запас времени, прошлые результаты и самочувствие. Не больше трех рисков и трех рекомендаций, каждая — одно короткое предложение на русском.`
+
+func (c *ChatGPTService) AdviseCompletion(ctx context.Context, history string) (*ai_coach.LLMCompletionPrediction, error) {
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: completionPredictionPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: history},
+		},
+		ResponseFormat:	&openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:	0.2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к OpenAI: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("нет ответа от OpenAI")
+	}
+
+	var prediction ai_coach.LLMCompletionPrediction
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &prediction); err != nil {
+		return nil, fmt.Errorf("ошибка разбора прогноза выполнения цели: %w", err)
+	}
+	if prediction.Probability > 1 && prediction.Probability <= 100 {
+		prediction.Probability /= 100
+	}
+	if prediction.Probability < 0 || prediction.Probability > 1 {
+		return nil, fmt.Errorf("некорректная вероятность в прогнозе: %v", prediction.Probability)
+	}
+	return &prediction, nil
+}
@@ -0,0 +1,158 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/messagestore/models"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	recentHistoryLimit	= 10
+	summaryMinMessages	= 20
+	summaryBatchLimit	= 200
+	summaryMaxRunes		= 2000
+	summaryMessageMaxRunes	= 500
+)
+
+const summaryInstruction = `Ты ведешь долговременную память ассистента Jarvis о пользователе.
+Обнови резюме, объединив прежнее резюме и новые сообщения диалога.
+Сохраняй только то, что пригодится в будущих разговорах: цели и их прогресс, планы и договоренности, предпочтения и привычки, имена людей и их роль, важные факты о жизни и работе пользователя.
+Не пересказывай светскую беседу и технические ответы ассистента, не выдумывай факты. Если новые сведения противоречат старым, оставь новые.
+Пиши по-русски, короткими пунктами, не длиннее 1200 символов.`
+
+func (c *ChatGPTService) StartConversationSummaries(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:	"conversation_summaries",
+		Spec:	"15 * * * *",
+		Run: func(ctx context.Context) {
+			c.summarizeConversations(ctx)
+		},
+	})
+
+	logrus.Info("Запущено сжатие истории диалогов")
+}
+
+func (c *ChatGPTService) summarizeConversations(ctx context.Context) {
+	userIDs, err := c.messages.GetUsersForSummarization(ctx, summaryMinMessages)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении пользователей для сжатия истории: %v", err)
+		return
+	}
+
+	summarized := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		ok, err := c.SummarizeConversation(ctx, userID)
+		if err != nil {
+			logrus.Warnf("Не удалось сжать историю диалога пользователя %s: %v", userID, err)
+			continue
+		}
+		if ok {
+			summarized++
+		}
+	}
+	logrus.Infof("Обновлены резюме диалогов: %d", summarized)
+}
+
+func (c *ChatGPTService) SummarizeConversation(ctx context.Context, userID string) (bool, error) {
+	existing, err := c.messages.GetConversationSummary(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	after := time.Time{}
+	previous := ""
+	if existing != nil {
+		after = existing.SummarizedUntil
+		previous = existing.Summary
+	}
+
+	batch, err := c.messages.GetMessagesToSummarize(ctx, userID, after, recentHistoryLimit, summaryBatchLimit)
+	if err != nil {
+		return false, err
+	}
+	if len(batch) < recentHistoryLimit {
+		return false, nil
+	}
+
+	summary, err := c.requestSummary(ctx, previous, batch)
+	if err != nil {
+		return false, err
+	}
+
+	until := batch[len(batch)-1].CreatedAt
+	if err := c.messages.SaveConversationSummary(ctx, userID, summary, until, len(batch)); err != nil {
+		return false, err
+	}
+
+	logrus.Infof("Сжато %d сообщений диалога пользователя %s", len(batch), userID)
+	return true, nil
+}
+
+func (c *ChatGPTService) requestSummary(ctx context.Context, previous string, batch []models.TimedMessage) (string, error) {
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("ПРЕЖНЕЕ РЕЗЮМЕ:\n")
+		transcript.WriteString(previous)
+		transcript.WriteString("\n\n")
+	}
+	transcript.WriteString("НОВЫЕ СООБЩЕНИЯ:\n")
+	for _, m := range batch {
+		speaker := "Пользователь"
+		if m.Role == openai.ChatMessageRoleAssistant {
+			speaker = "Jarvis"
+		}
+		transcript.WriteString(fmt.Sprintf("[%s] %s: %s\n", m.CreatedAt.Format("02.01 15:04"), speaker, truncateRunes(m.Content, summaryMessageMaxRunes)))
+	}
+
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: summaryInstruction},
+			{Role: openai.ChatMessageRoleUser, Content: transcript.String()},
+		},
+		Temperature:	0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("ошибка при запросе резюме диалога: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("пустой ответ при запросе резюме диалога")
+	}
+
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if summary == "" {
+		return "", fmt.Errorf("пустое резюме диалога")
+	}
+	return truncateRunes(summary, summaryMaxRunes), nil
+}
+
+func (c *ChatGPTService) conversationMemory(ctx context.Context, userID int64) string {
+	summary, err := c.messages.GetConversationSummary(ctx, fmt.Sprintf("%d", userID))
+	if err != nil {
+		logrus.Warnf("Не удалось получить резюме диалога пользователя %d: %v", userID, err)
+		return ""
+	}
+	if summary == nil || summary.Summary == "" {
+		return ""
+	}
+
+	return "\n\nДОЛГОВРЕМЕННАЯ ПАМЯТЬ (резюме прошлых разговоров до " + summary.SummarizedUntil.Format("02.01.2006") + "):\n" +
+		summary.Summary + "\nИспользуй эти сведения как контекст, но приоритет у текущего сообщения и свежей истории."
+}
+
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,145 @@
+package chatgpt
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DisambiguationCallbackPrefix = "pick"
+
+var ErrDisambiguationExpired = errors.New("выбор устарел или уже сделан")
+
+var disambiguationArguments = map[string]string{
+	okr.EntityObjective:	"objective_id",
+	okr.EntityKeyResult:	"key_result_id",
+	okr.EntityTask:		"task_id",
+}
+
+var disambiguationKinds = map[string]string{
+	okr.EntityObjective:	"целей",
+	okr.EntityKeyResult:	"ключевых результатов",
+	okr.EntityTask:		"задач",
+}
+
+type ReplyChoice struct {
+	Label	string
+	Data	string
+}
+
+func unresolvedEntity(err error, function *ChatGPTFunction, notFound string) (string, *ChatGPTFunction, error) {
+	var ambiguous *okr.AmbiguousMatchError
+	if errors.As(err, &ambiguous) {
+		return "", function, err
+	}
+	if !errors.Is(err, okr.ErrNoMatch) {
+		logrus.Errorf("Ошибка поиска по описанию: %v", err)
+	}
+	return notFound, function, nil
+}
+
+func (c *ChatGPTService) askToDisambiguate(ctx context.Context, userID int64, functionCall *ChatGPTFunctionCall, ambiguous *okr.AmbiguousMatchError) (*Reply, error) {
+	arguments, err := json.Marshal(functionCall.Arguments)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сериализации аргументов: %v", err)
+	}
+	candidates, err := json.Marshal(ambiguous.Candidates)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сериализации вариантов: %v", err)
+	}
+
+	var pendingID int64
+	err = c.db.GetContext(ctx, &pendingID, `
+		INSERT INTO pending_disambiguations (user_id, function, arguments, id_argument, candidates)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, userID, functionCall.Name, string(arguments), disambiguationArguments[ambiguous.Kind], string(candidates))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении уточнения: %v", err)
+	}
+
+	choices := make([]ReplyChoice, 0, len(ambiguous.Candidates))
+	for i, candidate := range ambiguous.Candidates {
+		label := candidate.Title
+		if candidate.Parent != "" {
+			label += " · " + candidate.Parent
+		}
+		choices = append(choices, ReplyChoice{
+			Label:	label,
+			Data:	fmt.Sprintf("%s:%d:%d", DisambiguationCallbackPrefix, pendingID, i),
+		})
+	}
+
+	return &Reply{
+		Text:		fmt.Sprintf("🤔 Под «%s» подходит несколько %s — какой вариант вы имели в виду?", ambiguous.Query, disambiguationKinds[ambiguous.Kind]),
+		Function:	functionCall.Name,
+		Arguments:	functionCall.Arguments,
+		Choices:	choices,
+	}, nil
+}
+
+func (c *ChatGPTService) ResolveDisambiguation(ctx context.Context, userID, pendingID int64, index int) (*Reply, error) {
+	var pending struct {
+		Function	string	`db:"function"`
+		Arguments	[]byte	`db:"arguments"`
+		IDArgument	string	`db:"id_argument"`
+		Candidates	[]byte	`db:"candidates"`
+	}
+	err := c.db.GetContext(ctx, &pending, `
+		UPDATE pending_disambiguations SET resolved_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND resolved_at IS NULL AND created_at > NOW() - INTERVAL '1 day'
+		RETURNING function, arguments, id_argument, candidates
+	`, pendingID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDisambiguationExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении уточнения: %v", err)
+	}
+
+	var candidates []okr.Candidate
+	if err := json.Unmarshal(pending.Candidates, &candidates); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении вариантов: %v", err)
+	}
+	if index < 0 || index >= len(candidates) {
+		return nil, ErrDisambiguationExpired
+	}
+	functionCall := &ChatGPTFunctionCall{Name: pending.Function}
+	if err := json.Unmarshal(pending.Arguments, &functionCall.Arguments); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении аргументов: %v", err)
+	}
+	if functionCall.Arguments == nil {
+		functionCall.Arguments = map[string]interface{}{}
+	}
+
+	chosen := candidates[index]
+	if pending.IDArgument == disambiguationArguments[okr.EntityObjective] {
+		functionCall.Arguments[pending.IDArgument] = chosen.ID
+	} else {
+		id, err := strconv.ParseFloat(chosen.ID, 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный идентификатор варианта %q: %v", chosen.ID, err)
+		}
+		functionCall.Arguments[pending.IDArgument] = id
+	}
+
+	result, _, err := c.handleFunctionCall(ctx, functionCall, userID)
+	var ambiguous *okr.AmbiguousMatchError
+	if errors.As(err, &ambiguous) {
+		return c.askToDisambiguate(ctx, userID, functionCall, ambiguous)
+	}
+	if err != nil {
+		var fnErr *FunctionError
+		if errors.As(err, &fnErr) {
+			return &Reply{Text: fnErr.UserMessage(), Function: functionCall.Name}, nil
+		}
+		return nil, err
+	}
+	return &Reply{Text: result, Function: functionCall.Name, Arguments: functionCall.Arguments}, nil
+}
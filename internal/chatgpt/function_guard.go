@@ -0,0 +1,135 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"telegrambot/internal/monitoring"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FunctionFailurePanic	= "panic"
+	FunctionFailureTimeout	= "timeout"
+	FunctionFailureError	= "error"
+
+	defaultFunctionTimeout	= 30 * time.Second
+)
+
+var functionTimeouts = map[string]time.Duration{
+	"generate_workout_plan":	90 * time.Second,
+	"import_travel_booking":	60 * time.Second,
+	"log_meal":			60 * time.Second,
+	"generate_weekly_plan":		60 * time.Second,
+	"optimize_schedule":		60 * time.Second,
+	"schedule_focus_blocks":	60 * time.Second,
+	"analyze_productivity":		60 * time.Second,
+	"generate_personal_insights":	60 * time.Second,
+}
+
+type FunctionError struct {
+	Function	string
+	Kind		string
+	Err		error
+}
+
+func (e *FunctionError) Error() string {
+	return fmt.Sprintf("функция %s: %s: %v", e.Function, e.Kind, e.Err)
+}
+
+func (e *FunctionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FunctionError) UserMessage() string {
+	switch e.Kind {
+	case FunctionFailureTimeout:
+		return "⏳ Операция заняла слишком много времени и была прервана. Попробуйте еще раз чуть позже"
+	case FunctionFailurePanic:
+		return "😔 Что-то пошло не так при выполнении запроса. Я уже сообщил об ошибке, попробуйте переформулировать или повторить позже"
+	default:
+		return "❌ Не удалось выполнить запрос. Попробуйте еще раз или уточните детали"
+	}
+}
+
+func functionTimeout(name string) time.Duration {
+	if timeout, ok := functionTimeouts[name]; ok {
+		return timeout
+	}
+	return defaultFunctionTimeout
+}
+
+type functionResult struct {
+	result		string
+	function	*ChatGPTFunction
+	err		error
+}
+
+func (c *ChatGPTService) runFunctionGuarded(ctx context.Context, functionCall *ChatGPTFunctionCall, userID int64) (string, *ChatGPTFunction, error) {
+	timeout := functionTimeout(functionCall.Name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan functionResult, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- functionResult{err: &FunctionError{
+					Function:	functionCall.Name,
+					Kind:		FunctionFailurePanic,
+					Err:		fmt.Errorf("%v", rec),
+				}}
+				logrus.WithFields(logrus.Fields{
+					"function":	functionCall.Name,
+					"user_id":	userID,
+					"arguments":	functionCall.Arguments,
+					"stack":	string(debug.Stack()),
+				}).Errorf("Паника при выполнении функции %s: %v", functionCall.Name, rec)
+			}
+		}()
+
+		result, function, err := c.dispatchFunction(ctx, functionCall, userID)
+		done <- functionResult{result: result, function: function, err: err}
+	}()
+
+	var res functionResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = &FunctionError{
+			Function:	functionCall.Name,
+			Kind:		FunctionFailureTimeout,
+			Err:		fmt.Errorf("превышено время ожидания %s", timeout),
+		}
+	}
+
+	var ambiguous *okr.AmbiguousMatchError
+	if errors.As(res.err, &ambiguous) {
+		monitoring.Observe(monitoring.EventJarvisFunction, nil)
+		return "", res.function, ambiguous
+	}
+
+	if res.err != nil {
+		var fnErr *FunctionError
+		if !errors.As(res.err, &fnErr) {
+			fnErr = &FunctionError{Function: functionCall.Name, Kind: FunctionFailureError, Err: res.err}
+		}
+		if fnErr.Kind != FunctionFailurePanic {
+			logrus.WithFields(logrus.Fields{
+				"function":	functionCall.Name,
+				"user_id":	userID,
+				"kind":		fnErr.Kind,
+				"arguments":	functionCall.Arguments,
+			}).Errorf("Ошибка выполнения функции %s: %v", functionCall.Name, fnErr.Err)
+		}
+		monitoring.Observe(monitoring.EventJarvisFunction, fnErr)
+		return "", nil, fnErr
+	}
+
+	monitoring.Observe(monitoring.EventJarvisFunction, nil)
+	return res.result, res.function, nil
+}
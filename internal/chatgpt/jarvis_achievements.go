@@ -0,0 +1,58 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (c *ChatGPTService) handleCheckAchievements(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	showProgress, _ := args["show_progress"].(bool)
+	category, _ := args["achievement_category"].(string)
+	if category == "all" {
+		category = ""
+	}
+
+	achievements, err := c.okr.CheckAchievements(ctx, userID, category)
+	if err != nil {
+		logrus.Errorf("Ошибка проверки достижений пользователя %d: %v", userID, err)
+		return "❌ Не удалось проверить достижения", &CheckAchievementsFunction, nil
+	}
+	if len(achievements) == 0 {
+		return "ℹ️ В этой категории пока нет достижений, которые я умею считать", &CheckAchievementsFunction, nil
+	}
+
+	var earned, next []string
+	points, total := 0, 0
+	for _, a := range achievements {
+		total += a.Points
+		if !a.Earned() {
+			next = append(next, fmt.Sprintf("• %s %s — %s: %d/%d", a.Icon, a.Name, a.Description, a.Current, a.Required))
+			continue
+		}
+		points += a.Points
+		line := fmt.Sprintf("• %s %s — %s (+%d)", a.Icon, a.Name, a.Description, a.Points)
+		if a.New {
+			line = "🆕 " + strings.TrimPrefix(line, "• ")
+		}
+		earned = append(earned, line)
+	}
+
+	var b strings.Builder
+	b.WriteString("🏆 **Достижения**\n\n")
+	if len(earned) == 0 {
+		b.WriteString("Пока ни одного — вот что ближе всего\n")
+		showProgress = true
+	} else {
+		b.WriteString(strings.Join(earned, "\n") + "\n")
+	}
+	if showProgress && len(next) > 0 {
+		b.WriteString("\n📈 **Дальше:**\n" + strings.Join(next, "\n") + "\n")
+	}
+	b.WriteString(fmt.Sprintf("\n⭐ Очки: %d из %d", points, total))
+	return b.String(), &CheckAchievementsFunction, nil
+}
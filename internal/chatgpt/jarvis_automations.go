@@ -0,0 +1,172 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/automations"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CreateAutomationRuleFunction = ChatGPTFunction{
+	Name:		"create_automation_rule",
+	Description:	"Создать правило автоматизации по ключевому результату: 'если по KR X нет прогресса 3 дня — создай задачу / пришли напоминание / сдвинь событие'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"key_result_description": {
+				Type:		"string",
+				Description:	"Описание ключевого результата, за которым следит правило",
+			},
+			"objective_description": {
+				Type:		"string",
+				Description:	"Описание цели для уточнения поиска ключевого результата",
+			},
+			"condition": {
+				Type:		"string",
+				Description:	"Условие: kr_stale - прогресс не менялся N дней; kr_deadline_near - до дедлайна осталось N дней, а KR не выполнен",
+				Enum:		automations.Conditions,
+			},
+			"days": {
+				Type:		"integer",
+				Description:	"N дней для условия",
+				Minimum:	1,
+				Maximum:	90,
+			},
+			"action": {
+				Type:		"string",
+				Description:	"Действие: create_task - создать задачу в KR; send_reminder - прислать напоминание; shift_event - сдвинуть ближайшее событие календаря",
+				Enum:		automations.Actions,
+			},
+			"task_title": {
+				Type:		"string",
+				Description:	"Название задачи для create_task",
+			},
+			"message": {
+				Type:		"string",
+				Description:	"Текст напоминания для send_reminder",
+			},
+			"event_query": {
+				Type:		"string",
+				Description:	"Часть названия события для shift_event",
+			},
+			"shift_minutes": {
+				Type:		"integer",
+				Description:	"На сколько минут сдвинуть событие для shift_event (отрицательное - раньше, 1440 - на день)",
+				Minimum:	-10080,
+				Maximum:	10080,
+			},
+			"name": {
+				Type:		"string",
+				Description:	"Короткое название правила",
+			},
+		},
+		Required:	[]string{"key_result_description", "condition", "days", "action"},
+	},
+}
+
+var GetAutomationRulesFunction = ChatGPTFunction{
+	Name:		"get_automation_rules",
+	Description:	"Показать активные правила автоматизации пользователя",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+var DeleteAutomationRuleFunction = ChatGPTFunction{
+	Name:		"delete_automation_rule",
+	Description:	"Удалить правило автоматизации по его номеру из списка правил",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"rule_id": {
+				Type:		"integer",
+				Description:	"Номер правила",
+			},
+		},
+		Required:	[]string{"rule_id"},
+	},
+}
+
+func (c *ChatGPTService) handleCreateAutomationRule(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	keyResultDescription, _ := args["key_result_description"].(string)
+	objectiveDescription, _ := args["objective_description"].(string)
+
+	keyResults, err := c.okr.FindKeyResultByDescription(ctx, userID, keyResultDescription, objectiveDescription)
+	if err != nil || len(keyResults) == 0 {
+		return "❌ Не найден ключевой результат по описанию: " + keyResultDescription, &CreateAutomationRuleFunction, nil
+	}
+
+	rule := automations.Rule{
+		UserID:		userID,
+		KeyResultID:	keyResults[0].ID,
+	}
+	rule.Name, _ = args["name"].(string)
+	rule.ConditionType, _ = args["condition"].(string)
+	rule.ActionType, _ = args["action"].(string)
+	if days, ok := args["days"].(float64); ok {
+		rule.ThresholdDays = int(days)
+	}
+	if v, ok := args["task_title"].(string); ok && strings.TrimSpace(v) != "" {
+		rule.TaskTitle = &v
+	}
+	if v, ok := args["message"].(string); ok && strings.TrimSpace(v) != "" {
+		rule.Message = &v
+	}
+	if v, ok := args["event_query"].(string); ok && strings.TrimSpace(v) != "" {
+		rule.EventQuery = &v
+	}
+	if v, ok := args["shift_minutes"].(float64); ok {
+		minutes := int(v)
+		rule.ShiftMinutes = &minutes
+	}
+
+	created, err := c.automations.CreateRule(ctx, rule)
+	if err != nil {
+		return automationErrorMessage(err), &CreateAutomationRuleFunction, nil
+	}
+
+	return fmt.Sprintf("🤖 Правило №%d создано:\n%s\n\nПроверяю условия каждый час", created.ID, created.Describe()), &CreateAutomationRuleFunction, nil
+}
+
+func (c *ChatGPTService) handleGetAutomationRules(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	rules, err := c.automations.GetRules(context.Background(), userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения правил автоматизации: %v", err)
+		return "❌ Не удалось получить правила автоматизации", &GetAutomationRulesFunction, nil
+	}
+	return automations.FormatRules(rules), &GetAutomationRulesFunction, nil
+}
+
+func (c *ChatGPTService) handleDeleteAutomationRule(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ruleID, _ := args["rule_id"].(float64)
+
+	if err := c.automations.DeleteRule(context.Background(), userID, int64(ruleID)); err != nil {
+		return automationErrorMessage(err), &DeleteAutomationRuleFunction, nil
+	}
+	return fmt.Sprintf("🗑 Правило №%d удалено", int64(ruleID)), &DeleteAutomationRuleFunction, nil
+}
+
+func automationErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, automations.ErrRuleNotFound):
+		return "❌ Правило не найдено"
+	case errors.Is(err, automations.ErrUnknownCondition):
+		return "❌ Такое условие не поддерживается. Доступно: нет прогресса N дней, до дедлайна N дней"
+	case errors.Is(err, automations.ErrUnknownAction):
+		return "❌ Такое действие не поддерживается. Доступно: создать задачу, напомнить, сдвинуть событие"
+	case errors.Is(err, automations.ErrTooManyRules):
+		return "❌ Слишком много правил. Удалите ненужные, чтобы добавить новое"
+	case errors.Is(err, automations.ErrInvalidRule):
+		return "❌ " + err.Error()
+	default:
+		logrus.Errorf("Ошибка правил автоматизации: %v", err)
+		return "❌ Не удалось сохранить правило автоматизации"
+	}
+}
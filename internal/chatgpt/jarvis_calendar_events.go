@@ -0,0 +1,309 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CreateEventFunction = ChatGPTFunction{
+	Name:		"create_event",
+	Description:	"Создать событие в календаре пользователя. Если время занято, сначала предложи свободные слоты, а allow_overlap передавай только после явного согласия пользователя",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"title": {
+				Type:		"string",
+				Description:	"Название события",
+			},
+			"description": {
+				Type:		"string",
+				Description:	"Описание события",
+			},
+			"start_time": {
+				Type:		"string",
+				Description:	"Начало события в формате YYYY-MM-DDTHH:MM:SS (местное время)",
+			},
+			"end_time": {
+				Type:		"string",
+				Description:	"Окончание события в формате YYYY-MM-DDTHH:MM:SS (по умолчанию через час после начала)",
+			},
+			"allow_overlap": {
+				Type:		"boolean",
+				Description:	"true — создать событие, даже если время уже занято",
+			},
+		},
+		Required:	[]string{"title", "start_time"},
+	},
+}
+
+var UpdateEventFunction = ChatGPTFunction{
+	Name:		"update_event",
+	Description:	"Изменить событие в календаре: название, описание или время. Передавай только те поля, которые пользователь хочет поменять",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"event_id": {
+				Type:		"string",
+				Description:	"ID события",
+			},
+			"event_title": {
+				Type:		"string",
+				Description:	"Название или часть названия события (если ID не указан)",
+			},
+			"event_date": {
+				Type:		"string",
+				Description:	"Дата события в формате YYYY-MM-DD, чтобы найти его по названию (по умолчанию сегодня)",
+			},
+			"title": {
+				Type:		"string",
+				Description:	"Новое название",
+			},
+			"description": {
+				Type:		"string",
+				Description:	"Новое описание",
+			},
+			"start_time": {
+				Type:		"string",
+				Description:	"Новое начало в формате YYYY-MM-DDTHH:MM:SS. Если не указано окончание, длительность сохранится",
+			},
+			"end_time": {
+				Type:		"string",
+				Description:	"Новое окончание в формате YYYY-MM-DDTHH:MM:SS",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var DeleteEventFunction = ChatGPTFunction{
+	Name:		"delete_event",
+	Description:	"Удалить событие из календаря пользователя",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"event_id": {
+				Type:		"string",
+				Description:	"ID события",
+			},
+			"event_title": {
+				Type:		"string",
+				Description:	"Название или часть названия события (если ID не указан)",
+			},
+			"event_date": {
+				Type:		"string",
+				Description:	"Дата события в формате YYYY-MM-DD, чтобы найти его по названию (по умолчанию сегодня)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var localEventTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+func parseEventTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localEventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("некорректное время %q", value)
+}
+
+func formatEventSpan(start, end time.Time) string {
+	return fmt.Sprintf("%s–%s", start.Local().Format("02.01 15:04"), end.Local().Format("15:04"))
+}
+
+func (c *ChatGPTService) handleCreateEvent(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Создание события для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	title, _ := args["title"].(string)
+	description, _ := args["description"].(string)
+	startStr, _ := args["start_time"].(string)
+	endStr, _ := args["end_time"].(string)
+	allowOverlap, _ := args["allow_overlap"].(bool)
+
+	if strings.TrimSpace(title) == "" {
+		return "❌ Не указано название события", &CreateEventFunction, nil
+	}
+	start, err := parseEventTime(startStr)
+	if err != nil {
+		return "❌ Некорректное время начала, нужен формат YYYY-MM-DDTHH:MM:SS", &CreateEventFunction, nil
+	}
+	end := start.Add(time.Hour)
+	if endStr != "" {
+		if end, err = parseEventTime(endStr); err != nil {
+			return "❌ Некорректное время окончания, нужен формат YYYY-MM-DDTHH:MM:SS", &CreateEventFunction, nil
+		}
+	}
+	if !end.After(start) {
+		return "❌ Событие должно заканчиваться позже, чем начинается", &CreateEventFunction, nil
+	}
+
+	var eventID string
+	if allowOverlap {
+		eventID, err = c.calendar.CreateEvent(ctx, userID, title, description, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	} else {
+		eventID, err = c.calendar.CreateEventIfFree(ctx, userID, title, description, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	var conflictErr *calendar.ConflictError
+	if errors.As(err, &conflictErr) {
+		return fmt.Sprintf("⚠️ На %s уже запланировано: %s. Подобрать свободное время или все равно поставить событие?",
+			formatEventSpan(start, end), calendar.FormatConflicts(conflictErr.Conflicts)), &CreateEventFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка создания события пользователя %d: %v", userID, err)
+		return "❌ Не удалось создать событие в календаре", &CreateEventFunction, nil
+	}
+
+	response := "📅 **Событие создано**\n\n"
+	response += fmt.Sprintf("📋 **%s**\n", title)
+	response += fmt.Sprintf("🕒 %s\n", formatEventSpan(start, end))
+	if description != "" {
+		response += fmt.Sprintf("📝 %s\n", description)
+	}
+	response += fmt.Sprintf("🆔 %s", eventID)
+	return response, &CreateEventFunction, nil
+}
+
+func (c *ChatGPTService) handleUpdateEvent(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Обновление события для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	title, _ := args["title"].(string)
+	description, _ := args["description"].(string)
+	startStr, _ := args["start_time"].(string)
+	endStr, _ := args["end_time"].(string)
+	if strings.TrimSpace(title) == "" && description == "" && startStr == "" && endStr == "" {
+		return "❌ Не указано, что изменить в событии", &UpdateEventFunction, nil
+	}
+
+	var start, end time.Time
+	var err error
+	if startStr != "" {
+		if start, err = parseEventTime(startStr); err != nil {
+			return "❌ Некорректное время начала, нужен формат YYYY-MM-DDTHH:MM:SS", &UpdateEventFunction, nil
+		}
+	}
+	if endStr != "" {
+		if end, err = parseEventTime(endStr); err != nil {
+			return "❌ Некорректное время окончания, нужен формат YYYY-MM-DDTHH:MM:SS", &UpdateEventFunction, nil
+		}
+	}
+
+	event, message := c.resolveEvent(ctx, userID, args)
+	if event == nil {
+		return message, &UpdateEventFunction, nil
+	}
+
+	if strings.TrimSpace(title) == "" {
+		title = event.Title
+	}
+	if description == "" {
+		description = event.Description
+	}
+	if start.IsZero() {
+		start = event.StartTime
+	}
+	if end.IsZero() {
+		end = start.Add(event.EndTime.Sub(event.StartTime))
+	}
+	if !end.After(start) {
+		return "❌ Событие должно заканчиваться позже, чем начинается", &UpdateEventFunction, nil
+	}
+
+	if err := c.calendar.UpdateEvent(ctx, userID, event.ID, title, description, start.Format(time.RFC3339), end.Format(time.RFC3339)); err != nil {
+		logrus.Errorf("Ошибка обновления события %s пользователя %d: %v", event.ID, userID, err)
+		return "❌ Не удалось обновить событие", &UpdateEventFunction, nil
+	}
+
+	response := "✏️ **Событие обновлено**\n\n"
+	response += fmt.Sprintf("📋 **%s**\n", title)
+	response += fmt.Sprintf("🕒 %s", formatEventSpan(start, end))
+	if !start.Equal(event.StartTime) || !end.Equal(event.EndTime) {
+		response += fmt.Sprintf(" (было %s)", formatEventSpan(event.StartTime, event.EndTime))
+	}
+	if description != "" {
+		response += fmt.Sprintf("\n📝 %s", description)
+	}
+	return response, &UpdateEventFunction, nil
+}
+
+func (c *ChatGPTService) handleDeleteEvent(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Удаление события для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	event, message := c.resolveEvent(ctx, userID, args)
+	if event == nil {
+		return message, &DeleteEventFunction, nil
+	}
+
+	if err := c.calendar.DeleteEvent(ctx, userID, event.ID); err != nil {
+		logrus.Errorf("Ошибка удаления события %s пользователя %d: %v", event.ID, userID, err)
+		return "❌ Не удалось удалить событие", &DeleteEventFunction, nil
+	}
+
+	return fmt.Sprintf("🗑️ Событие «%s» (%s) удалено", event.Title, formatEventSpan(event.StartTime, event.EndTime)), &DeleteEventFunction, nil
+}
+
+func (c *ChatGPTService) resolveEvent(ctx context.Context, userID int64, args map[string]interface{}) (*calendar.Event, string) {
+	if eventID, _ := args["event_id"].(string); eventID != "" {
+		event, err := c.calendar.GetEventByID(ctx, userID, eventID)
+		if err != nil {
+			logrus.Warnf("Событие %s пользователя %d не найдено: %v", eventID, userID, err)
+			return nil, "❌ Событие не найдено или не принадлежит пользователю"
+		}
+		return event, ""
+	}
+
+	eventTitle, _ := args["event_title"].(string)
+	eventTitle = strings.TrimSpace(eventTitle)
+	if eventTitle == "" {
+		return nil, "❌ Не указан ID или название события"
+	}
+	day := time.Now()
+	if dateStr, _ := args["event_date"].(string); dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return nil, "❌ Некорректная дата события, нужен формат YYYY-MM-DD"
+		}
+		day = parsed
+	}
+
+	events, err := c.calendar.GetEventsByDate(ctx, userID, day)
+	if err != nil {
+		logrus.Errorf("Ошибка поиска события «%s» пользователя %d: %v", eventTitle, userID, err)
+		return nil, "❌ Не удалось найти событие"
+	}
+	var matches []calendar.Event
+	for _, event := range events {
+		if strings.Contains(strings.ToLower(event.Title), strings.ToLower(eventTitle)) {
+			matches = append(matches, event)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Sprintf("❓ Не нашел событие «%s» на %s", eventTitle, day.Format("02.01.2006"))
+	case 1:
+		return &matches[0], ""
+	}
+
+	options := make([]string, 0, len(matches))
+	for _, event := range matches {
+		options = append(options, fmt.Sprintf("«%s» (%s)", event.Title, formatEventSpan(event.StartTime, event.EndTime)))
+	}
+	return nil, fmt.Sprintf("❓ Под «%s» подходит несколько событий: %s. Уточните, какое выбрать", eventTitle, strings.Join(options, ", "))
+}
@@ -0,0 +1,229 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/challenges"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CreateChallengeFunction = ChatGPTFunction{
+	Name:		"create_challenge",
+	Description:	"Создать челлендж ('30 дней по 10000 шагов', 'челлендж: 3 тренировки в неделю месяц') и позвать друзей",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"title": {
+				Type:		"string",
+				Description:	"Название челленджа",
+			},
+			"challenge_type": {
+				Type:		"string",
+				Description:	"Период цели: daily - каждый день, weekly - каждую неделю, monthly - каждый месяц",
+				Enum:		challenges.Periods,
+			},
+			"target": {
+				Type:		"number",
+				Description:	"Сколько нужно сделать за один период",
+			},
+			"unit": {
+				Type:		"string",
+				Description:	"Единица измерения (шагов, отжиманий, км, раз)",
+			},
+			"duration_days": {
+				Type:		"integer",
+				Description:	"Длительность челленджа в днях",
+				Minimum:	1,
+				Maximum:	366,
+			},
+			"start_date": {
+				Type:		"string",
+				Description:	"Дата старта в формате YYYY-MM-DD (по умолчанию сегодня)",
+			},
+			"participants": {
+				Type:		"array",
+				Description:	"Username участников в Telegram (без @), которых нужно пригласить",
+				Items: &ChatGPTProperty{
+					Type: "string",
+				},
+			},
+		},
+		Required:	[]string{"challenge_type", "title", "target", "duration_days"},
+	},
+}
+
+var LogChallengeProgressFunction = ChatGPTFunction{
+	Name:		"log_challenge_progress",
+	Description:	"Отметить прогресс в челлендже ('сделал 50 отжиманий в челлендже', 'прошел 12000 шагов для челленджа')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"challenge_description": {
+				Type:		"string",
+				Description:	"Название челленджа (пусто - последний активный)",
+			},
+			"amount": {
+				Type:		"number",
+				Description:	"Сколько сделано",
+			},
+		},
+		Required:	[]string{"amount"},
+	},
+}
+
+var GetChallengeLeaderboardFunction = ChatGPTFunction{
+	Name:		"get_challenge_leaderboard",
+	Description:	"Показать челленджи пользователя и таблицу лидеров",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"challenge_description": {
+				Type:		"string",
+				Description:	"Название челленджа (пусто - все активные)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleCreateChallenge(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	title, _ := args["title"].(string)
+	period, _ := args["challenge_type"].(string)
+	description, _ := args["description"].(string)
+	target, _ := args["target"].(float64)
+	unit, _ := args["unit"].(string)
+	duration, _ := args["duration_days"].(float64)
+
+	startsOn := time.Now()
+	if startStr, ok := args["start_date"].(string); ok && startStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", startStr, time.Local)
+		if err != nil {
+			return "❌ Не понял дату старта, используйте формат ГГГГ-ММ-ДД", &CreateChallengeFunction, nil
+		}
+		startsOn = parsed
+	}
+
+	challenge, err := c.challenges.CreateChallenge(ctx, userID, title, description, period, target, unit, int(duration), startsOn)
+	if err != nil {
+		return challengeErrorMessage(err), &CreateChallengeFunction, nil
+	}
+
+	var invited, missing []string
+	participants, _ := args["participants"].([]interface{})
+	for _, p := range participants {
+		username, _ := p.(string)
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if username == "" {
+			continue
+		}
+
+		memberID, err := c.findUserIDByUsername(ctx, username)
+		if err != nil {
+			missing = append(missing, "@"+username)
+			continue
+		}
+		if memberID == userID {
+			continue
+		}
+		if err := c.challenges.InviteParticipant(ctx, userID, challenge.ID, memberID); err != nil {
+			logrus.Warnf("Не удалось пригласить @%s в челлендж %d: %v", username, challenge.ID, err)
+			missing = append(missing, "@"+username)
+			continue
+		}
+		invited = append(invited, "@"+username)
+	}
+
+	response := "🏆 Челлендж создан!\n\n" + challenges.FormatChallenge(challenge)
+	if len(invited) > 0 {
+		response += "\n\n📨 Приглашения отправлены: " + strings.Join(invited, ", ")
+	}
+	if len(missing) > 0 {
+		response += "\n⚠️ Не удалось пригласить: " + strings.Join(missing, ", ") + ". Они должны хотя бы раз написать боту"
+	}
+	return response, &CreateChallengeFunction, nil
+}
+
+func (c *ChatGPTService) handleLogChallengeProgress(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	description, _ := args["challenge_description"].(string)
+	amount, _ := args["amount"].(float64)
+
+	challenge, err := c.challenges.FindChallenge(ctx, userID, description)
+	if err != nil {
+		return challengeErrorMessage(err), &LogChallengeProgressFunction, nil
+	}
+
+	standing, err := c.challenges.LogProgress(ctx, userID, challenge.ID, amount)
+	if err != nil {
+		return challengeErrorMessage(err), &LogChallengeProgressFunction, nil
+	}
+
+	response := fmt.Sprintf("✅ Записал в челлендж «%s»: +%g %s\n📈 В этом периоде: %g из %g, выполнено периодов: %d из %d",
+		challenge.Title, amount, challenge.Unit, standing.Current, challenge.Target, standing.Completed, challenge.TotalPeriods())
+	if standing.Current >= challenge.Target {
+		response += "\n🎉 Цель на этот период выполнена!"
+	}
+	return response, &LogChallengeProgressFunction, nil
+}
+
+func (c *ChatGPTService) handleGetChallengeLeaderboard(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	description, _ := args["challenge_description"].(string)
+
+	var list []challenges.Challenge
+	if strings.TrimSpace(description) != "" {
+		challenge, err := c.challenges.FindChallenge(ctx, userID, description)
+		if err != nil {
+			return challengeErrorMessage(err), &GetChallengeLeaderboardFunction, nil
+		}
+		list = append(list, *challenge)
+	} else {
+		var err error
+		list, err = c.challenges.GetUserChallenges(ctx, userID, false)
+		if err != nil {
+			logrus.Errorf("Ошибка получения челленджей: %v", err)
+			return "❌ Не удалось получить челленджи", &GetChallengeLeaderboardFunction, nil
+		}
+	}
+
+	if len(list) == 0 {
+		return "🏆 Активных челленджей нет. Скажите «создай челлендж 30 дней по 10000 шагов»", &GetChallengeLeaderboardFunction, nil
+	}
+
+	var sections []string
+	for i := range list {
+		standings, err := c.challenges.GetLeaderboard(ctx, &list[i])
+		if err != nil {
+			logrus.Errorf("Ошибка получения таблицы лидеров челленджа %d: %v", list[i].ID, err)
+			continue
+		}
+		sections = append(sections, challenges.FormatLeaderboard(&list[i], standings))
+	}
+	return strings.Join(sections, "\n\n"), &GetChallengeLeaderboardFunction, nil
+}
+
+func challengeErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, challenges.ErrChallengeNotFound):
+		return "❌ Челлендж не найден"
+	case errors.Is(err, challenges.ErrUnknownPeriod):
+		return "❌ Период челленджа может быть только ежедневным, еженедельным или ежемесячным"
+	case errors.Is(err, challenges.ErrChallengeNotActive):
+		return "⏳ Челлендж сейчас не идет"
+	case errors.Is(err, challenges.ErrNotParticipant):
+		return "❌ Вы не участвуете в этом челлендже"
+	case errors.Is(err, challenges.ErrInvalidChallenge):
+		return "❌ " + err.Error()
+	default:
+		logrus.Errorf("Ошибка челленджа: %v", err)
+		return "❌ Не удалось выполнить действие с челленджем"
+	}
+}
@@ -0,0 +1,210 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/dates"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var AddImportantDateFunction = ChatGPTFunction{
+	Name:		"add_important_date",
+	Description:	"Сохранить важную дату (день рождения, годовщину и т.п.), которая повторяется каждый год. Используй, когда пользователь говорит 'у мамы ДР 12 марта', 'запомни нашу годовщину 5 июня' и т.д.",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"title": {
+				Type:		"string",
+				Description:	"Короткое название события в том виде, как его удобно читать в напоминании, например 'ДР у мамы', 'Годовщина свадьбы'",
+			},
+			"person_name": {
+				Type:		"string",
+				Description:	"Имя или роль человека (мама, Саша, жена), если применимо",
+			},
+			"date_type": {
+				Type:		"string",
+				Description:	"Тип даты",
+				Enum:		[]string{"birthday", "anniversary", "other"},
+			},
+			"date": {
+				Type:		"string",
+				Description:	"Дата в формате MM-DD, либо YYYY-MM-DD если известен год (год рождения, год свадьбы)",
+			},
+			"remind_days_before": {
+				Type:		"integer",
+				Description:	"За сколько дней заранее напомнить (по умолчанию 3)",
+				Minimum:	0,
+				Maximum:	60,
+			},
+		},
+		Required:	[]string{"title", "date_type", "date"},
+	},
+}
+
+var GetImportantDatesFunction = ChatGPTFunction{
+	Name:		"get_important_dates",
+	Description:	"Показать сохраненные важные даты (дни рождения, годовщины) в порядке приближения",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"days": {
+				Type:		"integer",
+				Description:	"Показать только даты в ближайшие N дней (если не указано - все даты)",
+				Minimum:	1,
+				Maximum:	366,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var DeleteImportantDateFunction = ChatGPTFunction{
+	Name:		"delete_important_date",
+	Description:	"Удалить сохраненную важную дату",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"date_description": {
+				Type:		"string",
+				Description:	"Название даты или имя человека для поиска",
+			},
+			"confirm": {
+				Type:		"boolean",
+				Description:	"Подтверждение удаления (обязательно true для удаления)",
+			},
+		},
+		Required:	[]string{"date_description", "confirm"},
+	},
+}
+
+func (c *ChatGPTService) handleAddImportantDate(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Добавление важной даты для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	title, _ := args["title"].(string)
+	personName, _ := args["person_name"].(string)
+	dateType, _ := args["date_type"].(string)
+	dateStr, _ := args["date"].(string)
+
+	remindDaysBefore := 3
+	if v, ok := args["remind_days_before"].(float64); ok {
+		remindDaysBefore = int(v)
+	}
+
+	if title == "" || dateStr == "" {
+		return "❌ Не указаны название или дата события", &AddImportantDateFunction, nil
+	}
+
+	month, day, year, err := dates.ParseDate(dateStr)
+	if err != nil {
+		return fmt.Sprintf("❌ Не удалось распознать дату: %v", err), &AddImportantDateFunction, nil
+	}
+
+	id, err := c.dates.AddDate(ctx, userID, title, personName, dateType, month, day, year, remindDaysBefore)
+	if err != nil {
+		logrus.Errorf("Ошибка сохранения важной даты: %v", err)
+		return fmt.Sprintf("❌ Не удалось сохранить дату: %v", err), &AddImportantDateFunction, nil
+	}
+
+	saved, err := c.dates.GetDateByID(ctx, userID, id)
+	if err != nil {
+		return "✅ Дата сохранена", &AddImportantDateFunction, nil
+	}
+
+	now := time.Now()
+	response := fmt.Sprintf("%s **Дата сохранена!**\n\n", saved.TypeIcon())
+	response += fmt.Sprintf("📋 **Событие:** %s\n", saved.Title)
+	response += fmt.Sprintf("📅 **Дата:** %s (каждый год)\n", saved.NextOccurrence(now).Format("02.01"))
+	if age := saved.Age(now); age > 0 {
+		response += fmt.Sprintf("🔢 **Исполнится:** %d\n", age)
+	}
+	response += fmt.Sprintf("⏳ **До события:** %d дн.\n", saved.DaysUntil(now))
+	if saved.RemindDaysBefore > 0 {
+		response += fmt.Sprintf("\n🔔 Напомню за %d дн. и предложу создать задачу на подготовку", saved.RemindDaysBefore)
+	} else {
+		response += "\n🔔 Напомню в сам день события"
+	}
+
+	return response, &AddImportantDateFunction, nil
+}
+
+func (c *ChatGPTService) handleGetImportantDates(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	var list []dates.ImportantDate
+	var err error
+	if days, ok := args["days"].(float64); ok && days > 0 {
+		list, err = c.dates.GetUpcomingDates(ctx, userID, int(days))
+	} else {
+		list, err = c.dates.GetDates(ctx, userID)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка получения важных дат: %v", err)
+		return "❌ Не удалось получить список дат", &GetImportantDatesFunction, nil
+	}
+
+	if len(list) == 0 {
+		return "📭 Важных дат пока нет. Скажи, например: 'у мамы день рождения 12 марта'", &GetImportantDatesFunction, nil
+	}
+
+	now := time.Now()
+	response := "📅 **Важные даты:**\n\n"
+	for _, d := range list {
+		response += fmt.Sprintf("%s **%s** — %s", d.TypeIcon(), d.Title, d.NextOccurrence(now).Format("02.01"))
+		if age := d.Age(now); age > 0 {
+			response += fmt.Sprintf(" (%d)", age)
+		}
+
+		daysUntil := d.DaysUntil(now)
+		if daysUntil == 0 {
+			response += " — 🎉 сегодня!\n"
+		} else {
+			response += fmt.Sprintf(" — через %d дн.\n", daysUntil)
+		}
+	}
+
+	return response, &GetImportantDatesFunction, nil
+}
+
+func (c *ChatGPTService) handleDeleteImportantDate(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	description, _ := args["date_description"].(string)
+	confirm, _ := args["confirm"].(bool)
+
+	if !confirm {
+		return "❌ Для удаления даты необходимо подтверждение. Скажи что-то вроде 'да, удали'", &DeleteImportantDateFunction, nil
+	}
+
+	if description == "" {
+		return "❌ Не указана дата для удаления", &DeleteImportantDateFunction, nil
+	}
+
+	found, err := c.dates.FindDateByDescription(ctx, userID, description)
+	if err != nil {
+		logrus.Errorf("Ошибка поиска важной даты: %v", err)
+		return "❌ Не удалось найти дату", &DeleteImportantDateFunction, nil
+	}
+
+	if len(found) == 0 {
+		return "❌ Не найдена дата по описанию: " + description, &DeleteImportantDateFunction, nil
+	}
+
+	if len(found) > 1 {
+		response := "🤔 Нашлось несколько дат, уточни какую удалить:\n\n"
+		for _, d := range found {
+			response += fmt.Sprintf("%s %s — %02d.%02d\n", d.TypeIcon(), d.Title, d.Day, d.Month)
+		}
+		return response, &DeleteImportantDateFunction, nil
+	}
+
+	if err := c.dates.DeleteDate(ctx, userID, found[0].ID); err != nil {
+		logrus.Errorf("Ошибка удаления важной даты: %v", err)
+		return "❌ Не удалось удалить дату", &DeleteImportantDateFunction, nil
+	}
+
+	return fmt.Sprintf("🗑️ **Дата удалена:** %s", found[0].Title), &DeleteImportantDateFunction, nil
+}
@@ -0,0 +1,54 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/notifications"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var SetDoNotDisturbFunction = ChatGPTFunction{
+	Name:		"set_do_not_disturb",
+	Description:	"Включает или выключает режим «не беспокоить»: некритичные проактивные сообщения откладываются и приходят одной сводкой после окончания периода",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"period": {
+				Type:		"string",
+				Description:	"Период: 2h, 30m, 1d, «до 18:00», «до утра», «до вечера», «до завтра». Для выключения — off",
+			},
+		},
+		Required:	[]string{"period"},
+	},
+}
+
+func (c *ChatGPTService) handleSetDoNotDisturb(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	period, _ := args["period"].(string)
+	if strings.EqualFold(strings.TrimSpace(period), "off") {
+		if err := c.notifications.ClearDND(ctx, userID); err != nil {
+			logrus.Errorf("Ошибка выключения режима «не беспокоить» пользователя %d: %v", userID, err)
+			return "❌ Не удалось выключить режим «не беспокоить»", &SetDoNotDisturbFunction, nil
+		}
+		return "🔔 Режим «не беспокоить» выключен, накопленные уведомления пришлю сводкой в ближайшие минуты", &SetDoNotDisturbFunction, nil
+	}
+
+	until, err := notifications.ParseUntil(period, time.Now())
+	if err == nil {
+		err = c.notifications.SetDND(ctx, userID, until)
+	}
+	if errors.Is(err, notifications.ErrInvalidPeriod) || errors.Is(err, notifications.ErrPeriodTooLong) {
+		return "❌ " + err.Error(), &SetDoNotDisturbFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка включения режима «не беспокоить» пользователя %d: %v", userID, err)
+		return "❌ Не удалось включить режим «не беспокоить»", &SetDoNotDisturbFunction, nil
+	}
+
+	return fmt.Sprintf("🔕 Не беспокою до %s. Напоминания о событиях и лекарствах продолжат приходить, остальное пришлю одной сводкой после", until.Format("02.01 15:04")), &SetDoNotDisturbFunction, nil
+}
@@ -0,0 +1,403 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"telegrambot/internal/finance"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CreateFinanceSpaceFunction = ChatGPTFunction{
+	Name:		"create_finance_space",
+	Description:	"Создать общее (семейное) финансовое пространство с общими категориями, бюджетами и сводками",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"name": {
+				Type:		"string",
+				Description:	"Название пространства, например 'Семейный бюджет'",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var InviteToFinanceSpaceFunction = ChatGPTFunction{
+	Name:		"invite_to_finance_space",
+	Description:	"Пригласить другого пользователя бота в общее финансовое пространство по его username в Telegram",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"username": {
+				Type:		"string",
+				Description:	"Username пользователя в Telegram (без @)",
+			},
+			"role": {
+				Type:		"string",
+				Description:	"Права участника: editor - может добавлять операции, viewer - только просмотр",
+				Enum:		[]string{"editor", "viewer"},
+			},
+		},
+		Required:	[]string{"username"},
+	},
+}
+
+var ManageFinanceSpaceMemberFunction = ChatGPTFunction{
+	Name:		"manage_finance_space_member",
+	Description:	"Изменить права участника общего финансового пространства или удалить его (только для владельца)",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"username": {
+				Type:		"string",
+				Description:	"Username участника в Telegram (без @)",
+			},
+			"action": {
+				Type:		"string",
+				Description:	"Действие",
+				Enum:		[]string{"set_role", "remove"},
+			},
+			"role": {
+				Type:		"string",
+				Description:	"Новая роль для set_role",
+				Enum:		[]string{"editor", "viewer"},
+			},
+		},
+		Required:	[]string{"username", "action"},
+	},
+}
+
+var LeaveFinanceSpaceFunction = ChatGPTFunction{
+	Name:		"leave_finance_space",
+	Description:	"Выйти из общего финансового пространства",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"confirm": {
+				Type:		"boolean",
+				Description:	"Подтверждение выхода (обязательно true)",
+			},
+		},
+		Required:	[]string{"confirm"},
+	},
+}
+
+var AddSharedTransactionFunction = ChatGPTFunction{
+	Name:		"add_shared_transaction",
+	Description:	"Добавить операцию в общий (семейный) бюджет. Используй, когда пользователь говорит об общих тратах: 'общие расходы', 'в семейный бюджет', 'на дом'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"amount": {
+				Type:		"number",
+				Description:	"Сумма: положительная для доходов, отрицательная для расходов",
+			},
+			"details": {
+				Type:		"string",
+				Description:	"Описание операции",
+			},
+			"category": {
+				Type:		"string",
+				Description:	"Категория (Продукты, Дом, Коммунальные услуги, Транспорт, Здоровье, Дети, Развлечения или своя)",
+			},
+		},
+		Required:	[]string{"amount"},
+	},
+}
+
+var GetSharedFinanceSummaryFunction = ChatGPTFunction{
+	Name:		"get_shared_finance_summary",
+	Description:	"Получить сводку общего бюджета: итоги, разбивку по участникам и исполнение бюджетов",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"period": {
+				Type:		"string",
+				Description:	"Период",
+				Enum:		[]string{"day", "week", "month", "year"},
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var SetSharedBudgetFunction = ChatGPTFunction{
+	Name:		"set_shared_budget",
+	Description:	"Установить месячный лимит расходов по категории общего бюджета (только для владельца)",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"category": {
+				Type:		"string",
+				Description:	"Категория расходов",
+			},
+			"monthly_limit": {
+				Type:		"number",
+				Description:	"Лимит в месяц (0 - удалить лимит)",
+			},
+		},
+		Required:	[]string{"category", "monthly_limit"},
+	},
+}
+
+func (c *ChatGPTService) handleCreateFinanceSpace(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	name, _ := args["name"].(string)
+
+	space, err := c.finance.CreateSpace(ctx, userID, name)
+	if err != nil {
+		if errors.Is(err, finance.ErrAlreadyInSpace) {
+			return "ℹ️ Ты уже состоишь в общем финансовом пространстве. Чтобы создать новое, сначала выйди из текущего", &CreateFinanceSpaceFunction, nil
+		}
+		logrus.Errorf("Ошибка создания финансового пространства: %v", err)
+		return "❌ Не удалось создать общее пространство", &CreateFinanceSpaceFunction, nil
+	}
+
+	response := "👨‍👩‍👧 **Общий бюджет создан!**\n\n"
+	response += fmt.Sprintf("📋 **Название:** %s\n", space.Name)
+	if categories, err := c.finance.GetSpaceCategories(ctx, userID); err == nil && len(categories) > 0 {
+		response += "🗂 **Категории:** " + strings.Join(categories, ", ") + "\n"
+	}
+	response += "\n"
+	response += "Пригласи участника: 'добавь @username в семейный бюджет'"
+
+	return response, &CreateFinanceSpaceFunction, nil
+}
+
+func (c *ChatGPTService) handleInviteToFinanceSpace(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	username, _ := args["username"].(string)
+	role, _ := args["role"].(string)
+
+	inviteeID, err := c.findUserIDByUsername(ctx, username)
+	if err != nil {
+		return fmt.Sprintf("❌ Пользователь @%s не найден. Он должен хотя бы раз написать боту", strings.TrimPrefix(username, "@")), &InviteToFinanceSpaceFunction, nil
+	}
+
+	err = c.finance.InviteMember(ctx, userID, inviteeID, role)
+	if err != nil {
+		return financeSpaceErrorMessage(err), &InviteToFinanceSpaceFunction, nil
+	}
+
+	return fmt.Sprintf("📨 Приглашение отправлено @%s. Как только оно будет принято, операции и сводки станут общими", strings.TrimPrefix(username, "@")), &InviteToFinanceSpaceFunction, nil
+}
+
+func (c *ChatGPTService) handleManageFinanceSpaceMember(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	username, _ := args["username"].(string)
+	action, _ := args["action"].(string)
+	role, _ := args["role"].(string)
+
+	memberID, err := c.findUserIDByUsername(ctx, username)
+	if err != nil {
+		return fmt.Sprintf("❌ Пользователь @%s не найден", strings.TrimPrefix(username, "@")), &ManageFinanceSpaceMemberFunction, nil
+	}
+
+	switch action {
+	case "set_role":
+		err = c.finance.SetMemberRole(ctx, userID, memberID, role)
+		if err != nil {
+			return financeSpaceErrorMessage(err), &ManageFinanceSpaceMemberFunction, nil
+		}
+		return fmt.Sprintf("✅ Права @%s изменены: %s", strings.TrimPrefix(username, "@"), financeRoleName(role)), &ManageFinanceSpaceMemberFunction, nil
+	case "remove":
+		err = c.finance.RemoveMember(ctx, userID, memberID)
+		if err != nil {
+			return financeSpaceErrorMessage(err), &ManageFinanceSpaceMemberFunction, nil
+		}
+		return fmt.Sprintf("🗑️ @%s удален из общего бюджета", strings.TrimPrefix(username, "@")), &ManageFinanceSpaceMemberFunction, nil
+	default:
+		return "❌ Неизвестное действие", &ManageFinanceSpaceMemberFunction, nil
+	}
+}
+
+func (c *ChatGPTService) handleLeaveFinanceSpace(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	confirm, _ := args["confirm"].(bool)
+	if !confirm {
+		return "❌ Для выхода из общего бюджета необходимо подтверждение", &LeaveFinanceSpaceFunction, nil
+	}
+
+	if err := c.finance.LeaveSpace(ctx, userID); err != nil {
+		return financeSpaceErrorMessage(err), &LeaveFinanceSpaceFunction, nil
+	}
+
+	return "👋 Ты вышел из общего бюджета. Твои операции в нем сохранены для остальных участников", &LeaveFinanceSpaceFunction, nil
+}
+
+func (c *ChatGPTService) handleAddSharedTransaction(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	amount, _ := args["amount"].(float64)
+	details, _ := args["details"].(string)
+	category, _ := args["category"].(string)
+
+	if amount == 0 {
+		return "❌ Не указана сумма операции", &AddSharedTransactionFunction, nil
+	}
+
+	_, budget, err := c.finance.AddSpaceTransaction(ctx, userID, amount, details, category)
+	if err != nil {
+		return financeSpaceErrorMessage(err), &AddSharedTransactionFunction, nil
+	}
+
+	response := "✅ **Операция добавлена в общий бюджет**\n\n"
+	if amount > 0 {
+		response += fmt.Sprintf("💰 Доход: %.2f", amount)
+	} else {
+		response += fmt.Sprintf("💸 Расход: %.2f", -amount)
+	}
+	if category != "" {
+		response += fmt.Sprintf(" (%s)", category)
+	}
+	if details != "" {
+		response += fmt.Sprintf("\n📝 %s", details)
+	}
+
+	if budget != nil {
+		response += fmt.Sprintf("\n\n📊 Бюджет «%s»: %.2f из %.2f (%.0f%%)", budget.Category, budget.Spent, budget.Limit, budget.Percent)
+		if budget.Percent >= 100 {
+			response += "\n⚠️ Лимит на этот месяц превышен!"
+		} else if budget.Percent >= 80 {
+			response += "\n⚠️ Лимит почти исчерпан"
+		}
+	}
+
+	return response, &AddSharedTransactionFunction, nil
+}
+
+func (c *ChatGPTService) handleGetSharedFinanceSummary(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	period, _ := args["period"].(string)
+	if period == "" {
+		period = "month"
+	}
+
+	summary, err := c.finance.GetSpaceSummary(ctx, userID, period)
+	if err != nil {
+		return financeSpaceErrorMessage(err), &GetSharedFinanceSummaryFunction, nil
+	}
+
+	response := fmt.Sprintf("👨‍👩‍👧 **%s** — сводка за %s\n\n", summary.Space.Name, getFinancePeriodName(period))
+	response += fmt.Sprintf("💰 Доходы: %.2f\n", summary.Total.Income)
+	response += fmt.Sprintf("💸 Расходы: %.2f\n", summary.Total.Expenses)
+	response += fmt.Sprintf("📈 Баланс: %.2f\n", summary.Total.Balance)
+
+	if len(summary.Members) > 0 {
+		response += "\n👥 **По участникам:**\n"
+		for _, m := range summary.Members {
+			response += fmt.Sprintf("• %s: расходы %.2f, доходы %.2f\n", m.Name, m.Expenses, m.Income)
+		}
+	}
+
+	if len(summary.Total.Categories) > 0 {
+		categories := make([]string, 0, len(summary.Total.Categories))
+		for category := range summary.Total.Categories {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		response += "\n🗂 **По категориям:**\n"
+		for _, category := range categories {
+			response += fmt.Sprintf("• %s: %.2f\n", category, summary.Total.Categories[category])
+		}
+	}
+
+	if len(summary.Budgets) > 0 {
+		response += "\n📊 **Бюджеты (текущий месяц):**\n"
+		for _, b := range summary.Budgets {
+			icon := "🟢"
+			if b.Percent >= 100 {
+				icon = "🔴"
+			} else if b.Percent >= 80 {
+				icon = "🟡"
+			}
+			response += fmt.Sprintf("%s %s: %.2f / %.2f (%.0f%%)\n", icon, b.Category, b.Spent, b.Limit, b.Percent)
+		}
+	}
+
+	return response, &GetSharedFinanceSummaryFunction, nil
+}
+
+func (c *ChatGPTService) handleSetSharedBudget(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	category, _ := args["category"].(string)
+	monthlyLimit, _ := args["monthly_limit"].(float64)
+
+	if category == "" {
+		return "❌ Не указана категория", &SetSharedBudgetFunction, nil
+	}
+
+	if monthlyLimit <= 0 {
+		if err := c.finance.DeleteBudget(ctx, userID, category); err != nil {
+			return financeSpaceErrorMessage(err), &SetSharedBudgetFunction, nil
+		}
+		return fmt.Sprintf("🗑️ Лимит для категории «%s» удален", category), &SetSharedBudgetFunction, nil
+	}
+
+	if err := c.finance.SetBudget(ctx, userID, category, monthlyLimit); err != nil {
+		return financeSpaceErrorMessage(err), &SetSharedBudgetFunction, nil
+	}
+
+	return fmt.Sprintf("📊 Лимит для категории «%s»: %.2f в месяц", category, monthlyLimit), &SetSharedBudgetFunction, nil
+}
+
+func (c *ChatGPTService) findUserIDByUsername(ctx context.Context, username string) (int64, error) {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+
+	var id int64
+	err := c.db.GetContext(ctx, &id, `SELECT id FROM users WHERE LOWER(username) = LOWER($1)`, username)
+	if err != nil {
+		return 0, fmt.Errorf("пользователь не найден: %v", err)
+	}
+
+	return id, nil
+}
+
+func financeSpaceErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, finance.ErrNoSpace):
+		return "ℹ️ У тебя пока нет общего бюджета. Скажи 'создай семейный бюджет', чтобы начать"
+	case errors.Is(err, finance.ErrAlreadyInSpace):
+		return "ℹ️ Этот пользователь уже состоит в другом общем бюджете"
+	case errors.Is(err, finance.ErrPermissionDenied):
+		return "🔒 Недостаточно прав для этого действия"
+	default:
+		logrus.Errorf("Ошибка общего бюджета: %v", err)
+		return fmt.Sprintf("❌ %v", err)
+	}
+}
+
+func financeRoleName(role string) string {
+	switch role {
+	case "owner":
+		return "владелец"
+	case "viewer":
+		return "только просмотр"
+	default:
+		return "может добавлять операции"
+	}
+}
+
+func getFinancePeriodName(period string) string {
+	switch period {
+	case "day":
+		return "день"
+	case "week":
+		return "неделю"
+	case "year":
+		return "год"
+	default:
+		return "месяц"
+	}
+}
@@ -0,0 +1,292 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telegrambot/internal/focus"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var StartFocusSessionFunction = ChatGPTFunction{
+	Name:		"start_focus_session",
+	Description:	"Начать фокус-сессию глубокой работы. Используй, когда пользователь говорит 'начинаю фокус', 'сажусь писать отчет на 90 минут'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"label": {
+				Type:		"string",
+				Description:	"Над чем работает пользователь",
+			},
+			"planned_minutes": {
+				Type:		"integer",
+				Description:	"Запланированная длительность в минутах",
+				Minimum:	5,
+				Maximum:	480,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var StopFocusSessionFunction = ChatGPTFunction{
+	Name:		"stop_focus_session",
+	Description:	"Завершить текущую фокус-сессию ('закончил фокус', 'всё, отвлекся')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+var LogFocusTimeFunction = ChatGPTFunction{
+	Name:		"log_focus_time",
+	Description:	"Записать уже прошедшую глубокую работу ('поработал в фокусе 2 часа над презентацией')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"minutes": {
+				Type:		"integer",
+				Description:	"Длительность в минутах",
+				Minimum:	1,
+				Maximum:	480,
+			},
+			"label": {
+				Type:		"string",
+				Description:	"Над чем работал пользователь",
+			},
+		},
+		Required:	[]string{"minutes"},
+	},
+}
+
+var SetDeepWorkTargetFunction = ChatGPTFunction{
+	Name:		"set_deep_work_target",
+	Description:	"Установить недельную цель по глубокой работе ('хочу 10 часов фокуса в неделю')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"hours_per_week": {
+				Type:		"number",
+				Description:	"Количество часов глубокой работы в неделю",
+				Minimum:	0.5,
+				Maximum:	56,
+			},
+		},
+		Required:	[]string{"hours_per_week"},
+	},
+}
+
+var GetFocusStatsFunction = ChatGPTFunction{
+	Name:		"get_focus_stats",
+	Description:	"Показать статистику глубокой работы за текущую неделю и темп относительно цели",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+var ScheduleFocusBlocksFunction = ChatGPTFunction{
+	Name:		"schedule_focus_blocks",
+	Description:	"Добавить в календарь фокус-блоки в свободные окна до конца рабочей недели, чтобы догнать цель по глубокой работе",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"count": {
+				Type:		"integer",
+				Description:	"Сколько блоков по 90 минут добавить (по умолчанию — сколько нужно до цели)",
+				Minimum:	1,
+				Maximum:	5,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var GetWeeklyPlanProgressFunction = ChatGPTFunction{
+	Name:		"get_weekly_plan_progress",
+	Description:	"Показать, как пользователь идет по недельному плану, добавленному в календарь: какие блоки выполнены по фокус-сессиям, а какие пропущены",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleStartFocusSession(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	label, _ := args["label"].(string)
+	planned := 0
+	if v, ok := args["planned_minutes"].(float64); ok {
+		planned = int(v)
+	}
+
+	session, err := c.focus.StartSession(ctx, userID, label, planned)
+	if errors.Is(err, focus.ErrSessionActive) {
+		return "⏳ Фокус-сессия уже идет. Скажите «закончил фокус», чтобы ее завершить", &StartFocusSessionFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка запуска фокус-сессии: %v", err)
+		return "❌ Не удалось начать фокус-сессию", &StartFocusSessionFunction, nil
+	}
+
+	response := fmt.Sprintf("🧠 Фокус-сессия «%s» началась в %s", session.Title(), session.StartedAt.In(time.Local).Format("15:04"))
+	if session.PlannedMinutes != nil {
+		response += fmt.Sprintf(", план — %s. Напишу, когда время выйдет", focus.FormatMinutes(*session.PlannedMinutes))
+	}
+	return response + ". Удачной работы!", &StartFocusSessionFunction, nil
+}
+
+func (c *ChatGPTService) handleStopFocusSession(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	session, err := c.focus.StopSession(ctx, userID)
+	if errors.Is(err, focus.ErrNoActiveSession) {
+		return "ℹ️ Активной фокус-сессии нет", &StopFocusSessionFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка завершения фокус-сессии: %v", err)
+		return "❌ Не удалось завершить фокус-сессию", &StopFocusSessionFunction, nil
+	}
+
+	response := fmt.Sprintf("✅ Фокус-сессия «%s» завершена: %s", session.Title(), focus.FormatMinutes(*session.DurationMinutes))
+	if stats, err := c.focus.GetWeeklyStats(ctx, userID, focus.WeekStart(time.Now())); err == nil {
+		response += "\n" + focus.FormatWeeklyStats(stats)
+	}
+	return response, &StopFocusSessionFunction, nil
+}
+
+func (c *ChatGPTService) handleLogFocusTime(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	minutes := 0
+	if v, ok := args["minutes"].(float64); ok {
+		minutes = int(v)
+	}
+	label, _ := args["label"].(string)
+
+	session, err := c.focus.LogSession(ctx, userID, minutes, label)
+	if err != nil {
+		logrus.Errorf("Ошибка записи фокус-времени: %v", err)
+		return fmt.Sprintf("❌ Не удалось записать фокус-время: %v", err), &LogFocusTimeFunction, nil
+	}
+
+	response := fmt.Sprintf("✅ Записал %s глубокой работы: «%s»", focus.FormatMinutes(*session.DurationMinutes), session.Title())
+	if stats, err := c.focus.GetWeeklyStats(ctx, userID, focus.WeekStart(time.Now())); err == nil {
+		response += "\n" + focus.FormatWeeklyStats(stats)
+	}
+	return response, &LogFocusTimeFunction, nil
+}
+
+func (c *ChatGPTService) handleSetDeepWorkTarget(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	hours, _ := args["hours_per_week"].(float64)
+	minutes := int(hours * 60)
+
+	if err := c.focus.SetWeeklyTarget(ctx, userID, minutes); err != nil {
+		logrus.Errorf("Ошибка установки цели по глубокой работе: %v", err)
+		return fmt.Sprintf("❌ Не удалось установить цель: %v", err), &SetDeepWorkTargetFunction, nil
+	}
+
+	return fmt.Sprintf("🎯 Цель по глубокой работе: %s в неделю. В среду проверю темп и подскажу свободные окна, если будем отставать", focus.FormatMinutes(minutes)), &SetDeepWorkTargetFunction, nil
+}
+
+func (c *ChatGPTService) handleGetFocusStats(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	now := time.Now()
+	stats, err := c.focus.GetWeeklyStats(ctx, userID, focus.WeekStart(now))
+	if err != nil {
+		logrus.Errorf("Ошибка получения фокус-статистики: %v", err)
+		return "❌ Не удалось получить статистику глубокой работы", &GetFocusStatsFunction, nil
+	}
+
+	response := "🧠 **Глубокая работа на этой неделе**\n\n" + focus.FormatWeeklyStats(stats)
+
+	if active, err := c.focus.GetActiveSession(ctx, userID); err == nil {
+		response += fmt.Sprintf("\n⏳ Сейчас идет сессия «%s» с %s", active.Title(), active.StartedAt.In(time.Local).Format("15:04"))
+	}
+
+	if distractions, err := c.focus.GetDistractionStats(ctx, userID, focus.WeekStart(now)); err != nil {
+		logrus.Errorf("Ошибка получения статистики отвлечений: %v", err)
+	} else if text := focus.FormatDistractionStats(distractions); text != "" {
+		response += "\n\n" + text
+		for _, advice := range distractions.SuggestAdjustments() {
+			response += "\n💡 " + advice
+		}
+	}
+
+	if stats.TargetMinutes == 0 {
+		return response + "\n\nЦель не задана — скажите, например: «хочу 10 часов фокуса в неделю»", &GetFocusStatsFunction, nil
+	}
+
+	expected := stats.ExpectedByNow(now)
+	if stats.Minutes >= expected {
+		return response + "\n\n✅ Идете по плану", &GetFocusStatsFunction, nil
+	}
+
+	response += fmt.Sprintf("\n\n⚠️ Отставание от темпа: %s", focus.FormatMinutes(expected-stats.Minutes))
+	blocks, err := c.focus.SuggestBlocks(ctx, c.calendar, userID, now, stats.TargetMinutes-stats.Minutes)
+	if err != nil {
+		logrus.Errorf("Ошибка подбора фокус-блоков: %v", err)
+	} else if len(blocks) > 0 {
+		response += "\n\n📅 **Свободные окна:**\n" + focus.FormatBlocks(blocks)
+	}
+
+	return response, &GetFocusStatsFunction, nil
+}
+
+func (c *ChatGPTService) handleScheduleFocusBlocks(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	now := time.Now()
+	stats, err := c.focus.GetWeeklyStats(ctx, userID, focus.WeekStart(now))
+	if err != nil {
+		logrus.Errorf("Ошибка получения фокус-статистики: %v", err)
+		return "❌ Не удалось получить статистику глубокой работы", &ScheduleFocusBlocksFunction, nil
+	}
+
+	needed := stats.TargetMinutes - stats.Minutes
+	if v, ok := args["count"].(float64); ok && v > 0 {
+		needed = int(v) * 90
+	}
+	if needed <= 0 {
+		return "✅ Недельная цель по глубокой работе уже выполнена", &ScheduleFocusBlocksFunction, nil
+	}
+
+	blocks, err := c.focus.SuggestBlocks(ctx, c.calendar, userID, now, needed)
+	if err != nil {
+		logrus.Errorf("Ошибка подбора фокус-блоков: %v", err)
+		return "❌ Не удалось подобрать свободные окна", &ScheduleFocusBlocksFunction, nil
+	}
+	if len(blocks) == 0 {
+		return "📅 До конца рабочей недели свободных окон по 90 минут не нашлось", &ScheduleFocusBlocksFunction, nil
+	}
+
+	created, err := c.focus.ScheduleBlocks(ctx, c.calendar, userID, blocks)
+	if err != nil {
+		logrus.Errorf("Ошибка создания фокус-блоков: %v", err)
+		if created == 0 {
+			return "❌ Не удалось добавить фокус-блоки в календарь", &ScheduleFocusBlocksFunction, nil
+		}
+	}
+
+	return fmt.Sprintf("📅 Добавил в календарь фокус-блоков: %d\n\n%s", created, focus.FormatBlocks(blocks[:created])), &ScheduleFocusBlocksFunction, nil
+}
+
+func (c *ChatGPTService) handleGetWeeklyPlanProgress(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	adherence, err := c.focus.GetPlanAdherence(context.Background(), userID, time.Now())
+	if errors.Is(err, focus.ErrWeeklyPlanNotFound) {
+		return "ℹ️ На эту неделю план в календарь не добавлен. Скажи «составь план на неделю» — предложу блоки и добавлю их после подтверждения", &GetWeeklyPlanProgressFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка сверки недельного плана пользователя %d: %v", userID, err)
+		return "❌ Не удалось сверить недельный план", &GetWeeklyPlanProgressFunction, nil
+	}
+	return focus.FormatPlanAdherence(adherence), &GetWeeklyPlanProgressFunction, nil
+}
@@ -0,0 +1,169 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/meetings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var SuggestFreeSlotsFunction = ChatGPTFunction{
+	Name:		"suggest_free_slots",
+	Description:	"Подобрать свободное время в календаре пользователя с учетом событий и встреч. Используй, когда нужно предложить время для нового дела или когда выбранное время уже занято",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"from_date": {
+				Type:		"string",
+				Description:	"С какого дня искать, YYYY-MM-DD (по умолчанию сегодня)",
+			},
+			"to_date": {
+				Type:		"string",
+				Description:	"По какой день искать включительно, YYYY-MM-DD (по умолчанию через неделю после from_date)",
+			},
+			"duration_minutes": {
+				Type:		"integer",
+				Description:	"Нужная длительность в минутах (по умолчанию 60)",
+			},
+			"limit": {
+				Type:		"integer",
+				Description:	"Сколько вариантов предложить (по умолчанию 5)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleSuggestFreeSlots(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Поиск свободного времени для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	from := time.Now()
+	if fromStr, _ := args["from_date"].(string); fromStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			return "❌ Некорректная дата from_date, нужен формат YYYY-MM-DD", &SuggestFreeSlotsFunction, nil
+		}
+		from = day
+	}
+	to := from.AddDate(0, 0, 7)
+	if toStr, _ := args["to_date"].(string); toStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil || day.AddDate(0, 0, 1).Before(from) || day.Sub(from) > 31*24*time.Hour {
+			return "❌ Некорректная дата to_date: нужен формат YYYY-MM-DD, не раньше from_date и не дальше месяца", &SuggestFreeSlotsFunction, nil
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+
+	duration := time.Hour
+	if minutes, ok := args["duration_minutes"].(float64); ok && minutes > 0 {
+		duration = time.Duration(minutes) * time.Minute
+	}
+	limit := 5
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	slots, err := c.calendar.SuggestFreeSlots(ctx, userID, from, to, duration, limit)
+	if err != nil {
+		logrus.Errorf("Ошибка поиска свободного времени для пользователя %d: %v", userID, err)
+		return "❌ Не удалось подобрать свободное время", &SuggestFreeSlotsFunction, nil
+	}
+
+	return fmt.Sprintf("🕒 **Свободное время** (%d мин):\n%s", int(duration.Minutes()), calendar.FormatFreeSlots(slots, duration)), &SuggestFreeSlotsFunction, nil
+}
+
+var FindMeetingSlotFunction = ChatGPTFunction{
+	Name:		"find_meeting_slot",
+	Description:	"Найти общее свободное время пользователя и другого пользователя бота в рабочие часы с учетом событий и встреч обоих",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"participant_username": {
+				Type:		"string",
+				Description:	"Telegram username второго участника (без @)",
+			},
+			"from_date": {
+				Type:		"string",
+				Description:	"С какого дня искать, YYYY-MM-DD (по умолчанию сегодня)",
+			},
+			"to_date": {
+				Type:		"string",
+				Description:	"По какой день искать включительно, YYYY-MM-DD (по умолчанию через неделю после from_date)",
+			},
+			"duration_minutes": {
+				Type:		"integer",
+				Description:	"Длительность встречи в минутах (по умолчанию 60)",
+			},
+			"work_from": {
+				Type:		"string",
+				Description:	"Начало рабочих часов, HH:MM (по умолчанию 09:00)",
+			},
+			"work_to": {
+				Type:		"string",
+				Description:	"Конец рабочих часов, HH:MM (по умолчанию 18:00)",
+			},
+			"include_weekends": {
+				Type:		"boolean",
+				Description:	"Искать и в выходные (по умолчанию false)",
+			},
+		},
+		Required:	[]string{"participant_username"},
+	},
+}
+
+func (c *ChatGPTService) handleFindMeetingSlot(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Поиск общего времени для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	username, _ := args["participant_username"].(string)
+	if username == "" {
+		return "❌ Укажите username второго участника", &FindMeetingSlotFunction, nil
+	}
+
+	req := meetings.AvailabilityRequest{From: time.Now()}
+	if fromStr, _ := args["from_date"].(string); fromStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			return "❌ Некорректная дата from_date, нужен формат YYYY-MM-DD", &FindMeetingSlotFunction, nil
+		}
+		req.From = day
+	}
+	req.To = req.From.AddDate(0, 0, 7)
+	if toStr, _ := args["to_date"].(string); toStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			return "❌ Некорректная дата to_date, нужен формат YYYY-MM-DD", &FindMeetingSlotFunction, nil
+		}
+		req.To = day.AddDate(0, 0, 1)
+	}
+	if minutes, ok := args["duration_minutes"].(float64); ok && minutes > 0 {
+		req.Duration = time.Duration(minutes) * time.Minute
+	}
+
+	var err error
+	if req.WorkFrom, err = parseClock(args["work_from"], 9*time.Hour); err != nil {
+		return "❌ Некорректное время work_from, нужен формат HH:MM", &FindMeetingSlotFunction, nil
+	}
+	if req.WorkTo, err = parseClock(args["work_to"], 18*time.Hour); err != nil {
+		return "❌ Некорректное время work_to, нужен формат HH:MM", &FindMeetingSlotFunction, nil
+	}
+	req.IncludeWeekends, _ = args["include_weekends"].(bool)
+
+	availability, err := c.meetings.FindMutualAvailability(ctx, userID, username, req)
+	if errors.Is(err, meetings.ErrParticipantNotFound) || errors.Is(err, meetings.ErrSelfAvailability) || errors.Is(err, meetings.ErrInvalidWorkingHours) || errors.Is(err, meetings.ErrInvalidSearchRange) {
+		return "❌ " + err.Error(), &FindMeetingSlotFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка поиска общего времени для пользователя %d: %v", userID, err)
+		return "❌ Не удалось подобрать общее время", &FindMeetingSlotFunction, nil
+	}
+
+	return meetings.FormatAvailability(availability), &FindMeetingSlotFunction, nil
+}
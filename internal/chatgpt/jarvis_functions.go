@@ -2,8 +2,16 @@ package chatgpt
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
+	"strings"
+	"telegrambot/internal/ai_coach"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
 
@@ -142,6 +150,14 @@ var GenerateWeeklyPlanFunction = ChatGPTFunction{
 				Type:		"boolean",
 				Description:	"Включить перерывы и отдых в план",
 			},
+			"confirm": {
+				Type:		"boolean",
+				Description:	"false — составить план и предложить блоки в календаре, true — добавить в календарь последний показанный план",
+			},
+			"revert": {
+				Type:		"boolean",
+				Description:	"true — отменить последний добавленный в календарь план и удалить его блоки из календаря",
+			},
 		},
 		Required:	[]string{},
 	},
@@ -149,35 +165,28 @@ var GenerateWeeklyPlanFunction = ChatGPTFunction{
 
 var OptimizeScheduleFunction = ChatGPTFunction{
 	Name:		"optimize_schedule",
-	Description:	"Оптимизирует расписание на основе пиковых часов продуктивности",
+	Description:	"Оптимизирует расписание на ближайшие 7 дней по пиковым часам продуктивности: долгие события переносит на пиковые часы, короткие — с них. Сначала вызывай с confirm=false и покажи предложенные переносы, после согласия пользователя — с confirm=true",
 	Parameters: ChatGPTFunctionParameters{
 		Type:	"object",
 		Properties: map[string]ChatGPTProperty{
-			"current_schedule": {
-				Type:		"string",
-				Description:	"Текущее расписание в JSON формате",
-			},
-			"constraints": {
-				Type:		"array",
-				Description:	"Ограничения расписания",
-				Items: &ChatGPTProperty{
-					Type: "string",
-				},
+			"confirm": {
+				Type:		"boolean",
+				Description:	"false — только предложить переносы, true — применить последний показанный план",
 			},
 		},
-		Required:	[]string{},
+		Required:	[]string{"confirm"},
 	},
 }
 
 var ShareGoalFunction = ChatGPTFunction{
 	Name:		"share_goal",
-	Description:	"Помогает поделиться целью с друзьями или командой",
+	Description:	"Создать ссылку только для просмотра прогресса цели, чтобы показать ее друзьям или ментору, либо отозвать ссылку",
 	Parameters: ChatGPTFunctionParameters{
 		Type:	"object",
 		Properties: map[string]ChatGPTProperty{
 			"goal_id": {
 				Type:		"string",
-				Description:	"ID цели для шаринга",
+				Description:	"Название или ID цели для шаринга",
 			},
 			"sharing_type": {
 				Type:		"string",
@@ -189,8 +198,12 @@ var ShareGoalFunction = ChatGPTFunction{
 				Description:	"Аудитория для шаринга",
 				Enum:		[]string{"friends", "team", "public", "family"},
 			},
+			"revoke": {
+				Type:		"boolean",
+				Description:	"true — отозвать ранее созданную ссылку на цель",
+			},
 		},
-		Required:	[]string{"goal_id", "sharing_type"},
+		Required:	[]string{"goal_id"},
 	},
 }
 
@@ -214,83 +227,6 @@ var FindAccountabilityPartnerFunction = ChatGPTFunction{
 	},
 }
 
-var UpdatePreferencesFunction = ChatGPTFunction{
-	Name:		"update_preferences",
-	Description:	"Обновляет предпочтения пользователя на основе обратной связи",
-	Parameters: ChatGPTFunctionParameters{
-		Type:	"object",
-		Properties: map[string]ChatGPTProperty{
-			"preference_type": {
-				Type:		"string",
-				Description:	"Тип предпочтения",
-				Enum:		[]string{"communication_style", "motivation_type", "reminder_frequency", "difficulty_level"},
-			},
-			"new_value": {
-				Type:		"string",
-				Description:	"Новое значение предпочтения",
-			},
-			"feedback_reason": {
-				Type:		"string",
-				Description:	"Причина изменения предпочтения",
-			},
-		},
-		Required:	[]string{"preference_type", "new_value"},
-	},
-}
-
-var LearnFromFeedbackFunction = ChatGPTFunction{
-	Name:		"learn_from_feedback",
-	Description:	"Обучается на основе обратной связи пользователя",
-	Parameters: ChatGPTFunctionParameters{
-		Type:	"object",
-		Properties: map[string]ChatGPTProperty{
-			"feedback_type": {
-				Type:		"string",
-				Description:	"Тип обратной связи",
-				Enum:		[]string{"positive", "negative", "suggestion", "complaint"},
-			},
-			"context": {
-				Type:		"string",
-				Description:	"Контекст обратной связи",
-			},
-			"specific_feature": {
-				Type:		"string",
-				Description:	"Конкретная функция, к которой относится обратная связь",
-			},
-		},
-		Required:	[]string{"feedback_type", "context"},
-	},
-}
-
-var CheckWellbeingFunction = ChatGPTFunction{
-	Name:		"check_wellbeing",
-	Description:	"Проверяет самочувствие и предлагает рекомендации по благополучию",
-	Parameters: ChatGPTFunctionParameters{
-		Type:	"object",
-		Properties: map[string]ChatGPTProperty{
-			"current_stress_level": {
-				Type:		"integer",
-				Description:	"Текущий уровень стресса (1-5)",
-				Minimum:	1,
-				Maximum:	5,
-			},
-			"sleep_quality": {
-				Type:		"integer",
-				Description:	"Качество сна (1-5)",
-				Minimum:	1,
-				Maximum:	5,
-			},
-			"work_life_balance": {
-				Type:		"integer",
-				Description:	"Баланс работы и жизни (1-5)",
-				Minimum:	1,
-				Maximum:	5,
-			},
-		},
-		Required:	[]string{},
-	},
-}
-
 var SuggestBreakFunction = ChatGPTFunction{
 	Name:		"suggest_break",
 	Description:	"Предлагает персональные рекомендации для перерыва и восстановления",
@@ -330,43 +266,13 @@ var CheckAchievementsFunction = ChatGPTFunction{
 			"achievement_category": {
 				Type:		"string",
 				Description:	"Категория достижений",
-				Enum:		[]string{"goals", "completion", "streak", "social", "learning", "all"},
+				Enum:		[]string{"goals", "completion", "speed", "social", "all"},
 			},
 		},
 		Required:	[]string{},
 	},
 }
 
-var CreateChallengeFunction = ChatGPTFunction{
-	Name:		"create_challenge",
-	Description:	"Создает новый вызов или соревнование для мотивации",
-	Parameters: ChatGPTFunctionParameters{
-		Type:	"object",
-		Properties: map[string]ChatGPTProperty{
-			"challenge_type": {
-				Type:		"string",
-				Description:	"Тип вызова",
-				Enum:		[]string{"daily", "weekly", "monthly", "custom"},
-			},
-			"title": {
-				Type:		"string",
-				Description:	"Название вызова",
-			},
-			"description": {
-				Type:		"string",
-				Description:	"Описание вызова",
-			},
-			"duration_days": {
-				Type:		"integer",
-				Description:	"Продолжительность в днях",
-				Minimum:	1,
-				Maximum:	365,
-			},
-		},
-		Required:	[]string{"challenge_type", "title"},
-	},
-}
-
 var CreateObjectiveFunction = ChatGPTFunction{
 	Name:		"create_objective",
 	Description:	"Создать новую цель OKR",
@@ -379,7 +285,7 @@ var CreateObjectiveFunction = ChatGPTFunction{
 			},
 			"sphere": {
 				Type:		"string",
-				Description:	"Сфера цели (бизнес, финансы, здоровье, творчество, образование и т.д.)",
+				Description:	"Сфера цели: Здоровье, Карьера, Бизнес, Финансы, Образование, Творчество, Отношения, Саморазвитие, Хобби и отдых, Дом и быт или своя сфера пользователя",
 			},
 			"period": {
 				Type:		"string",
@@ -390,6 +296,10 @@ var CreateObjectiveFunction = ChatGPTFunction{
 				Type:		"string",
 				Description:	"Дедлайн для цели в формате YYYY-MM-DD",
 			},
+			"draft_version": {
+				Type:		"integer",
+				Description:	"Версия черновика из ответа «Черновик цели сохранен (версия N)», если пользователь дополняет начатую цель. Для новой цели не передавай",
+			},
 			"key_results": {
 				Type:		"array",
 				Description:	"Ключевые результаты (2-5 измеримых целей)",
@@ -413,6 +323,24 @@ var CreateObjectiveFunction = ChatGPTFunction{
 							Type:		"string",
 							Description:	"Дедлайн в формате YYYY-MM-DD",
 						},
+						"daily_task": {
+							Type:		"object",
+							Description:	"Необязательная ежедневная задача для этого ключевого результата: создается на каждый день с сегодняшнего до дедлайна KR",
+							Properties: map[string]ChatGPTProperty{
+								"title": {
+									Type:		"string",
+									Description:	"Название ежедневной задачи",
+								},
+								"daily_target": {
+									Type:		"number",
+									Description:	"Цель на день",
+								},
+								"unit": {
+									Type:		"string",
+									Description:	"Единица измерения",
+								},
+							},
+						},
 					},
 				},
 			},
@@ -434,8 +362,29 @@ var GetObjectivesFunction = ChatGPTFunction{
 			},
 			"status": {
 				Type:		"string",
-				Description:	"Статус для фильтрации (active, completed, paused, all)",
-				Enum:		[]string{"active", "completed", "paused", "all"},
+				Description:	"Статус для фильтрации: open (по умолчанию — все незакрытые), active, completed, archived, paused, all (включая завершенные и архивные)",
+				Enum:		[]string{"open", "active", "completed", "archived", "paused", "all"},
+			},
+			"sphere": {
+				Type:		"string",
+				Description:	"Сфера жизни для фильтрации (например Здоровье, Карьера, Финансы)",
+			},
+			"deadline_within_days": {
+				Type:		"integer",
+				Description:	"Только с дедлайном в ближайшие N дней, включая просроченные (например 7 — «что горит на неделе»)",
+				Minimum:	1,
+				Maximum:	365,
+			},
+			"limit": {
+				Type:		"integer",
+				Description:	"Сколько записей показать (по умолчанию 20)",
+				Minimum:	1,
+				Maximum:	50,
+			},
+			"offset": {
+				Type:		"integer",
+				Description:	"Сколько записей пропустить — для следующей страницы («покажи еще»)",
+				Minimum:	0,
 			},
 		},
 		Required:	[]string{},
@@ -572,7 +521,7 @@ var AddTaskProgressFunction = ChatGPTFunction{
 
 var GetTasksFunction = ChatGPTFunction{
 	Name:		"get_tasks",
-	Description:	"Получить задачи по ключевому результату",
+	Description:	"Получить задачи по ключевому результату, цели, сфере или ближайшим дедлайнам",
 	Parameters: ChatGPTFunctionParameters{
 		Type:	"object",
 		Properties: map[string]ChatGPTProperty{
@@ -584,6 +533,27 @@ var GetTasksFunction = ChatGPTFunction{
 				Type:		"string",
 				Description:	"ID цели для получения всех задач",
 			},
+			"sphere": {
+				Type:		"string",
+				Description:	"Сфера жизни для фильтрации (например Здоровье, Карьера, Финансы)",
+			},
+			"deadline_within_days": {
+				Type:		"integer",
+				Description:	"Только с дедлайном в ближайшие N дней, включая просроченные (например 7 — «что горит на неделе»)",
+				Minimum:	1,
+				Maximum:	365,
+			},
+			"limit": {
+				Type:		"integer",
+				Description:	"Сколько записей показать (по умолчанию 20)",
+				Minimum:	1,
+				Maximum:	50,
+			},
+			"offset": {
+				Type:		"integer",
+				Description:	"Сколько записей пропустить — для следующей страницы («покажи еще»)",
+				Minimum:	0,
+			},
 		},
 		Required:	[]string{},
 	},
@@ -685,8 +655,18 @@ func (c *ChatGPTService) handleAnalyzeProductivity(args map[string]interface{},
 
 	response := fmt.Sprintf("📊 **Анализ продуктивности за %s:**\n\n", getPeriodName(timePeriod))
 	response += fmt.Sprintf("• Уровень завершения: %.1f%%\n", metrics.CompletionRate*100)
-	response += fmt.Sprintf("• Среднее время задачи: %.1f мин\n", metrics.AverageTaskTime)
+	if metrics.AverageTaskTime > 0 {
+		response += fmt.Sprintf("• Средняя фокус-сессия: %.0f мин\n", metrics.AverageTaskTime)
+	}
 	response += fmt.Sprintf("• Серия: %d дней\n", metrics.StreakDays)
+	if metrics.DeepWorkTarget > 0 {
+		response += fmt.Sprintf("• Глубокая работа за неделю: %s из %s\n", focus.FormatMinutes(metrics.DeepWorkMinutes), focus.FormatMinutes(metrics.DeepWorkTarget))
+	} else if metrics.DeepWorkMinutes > 0 {
+		response += fmt.Sprintf("• Глубокая работа за неделю: %s\n", focus.FormatMinutes(metrics.DeepWorkMinutes))
+	}
+	if metrics.Distractions > 0 {
+		response += fmt.Sprintf("• Отвлечений за неделю: %d (во время фокуса: %d)\n", metrics.Distractions, metrics.FocusDistractions)
+	}
 	response += fmt.Sprintf("• Уровень: %d (%d очков)\n\n", metrics.Level, metrics.TotalPointsEarned)
 
 	if len(metrics.PeakProductivityHours) > 0 {
@@ -762,7 +742,33 @@ func (c *ChatGPTService) handlePredictGoalSuccess(args map[string]interface{}, u
 	response := "🎯 **Прогноз успеха цели:**\n\n"
 	response += fmt.Sprintf("📊 Вероятность успеха: %.1f%%\n", prediction.Probability*100)
 	response += fmt.Sprintf("📅 Ожидаемое завершение: %s\n", prediction.EstimatedCompletionDate.Format("02.01.2006"))
-	response += fmt.Sprintf("🎯 Уверенность прогноза: %.1f%%\n\n", prediction.ConfidenceLevel*100)
+	response += fmt.Sprintf("🎯 Уверенность прогноза: %.1f%%\n", prediction.ConfidenceLevel*100)
+	if len(prediction.Sources) > 1 {
+		labels := map[string]string{ai_coach.PredictionSourceHeuristic: "статистика", ai_coach.PredictionSourceLLM: "анализ истории ИИ"}
+		parts := make([]string, 0, len(prediction.Sources))
+		for _, source := range prediction.Sources {
+			parts = append(parts, fmt.Sprintf("%s %.0f%% (вес %.0f%%)", labels[source.Name], source.Probability*100, source.Weight*100))
+		}
+		response += "🧮 Источники: " + strings.Join(parts, ", ") + "\n"
+	}
+	response += "\n"
+
+	if trajectory, err := c.aiCoach.PredictProgressTrajectory(ctx, userID, goalID); err != nil {
+		logrus.Warnf("Не удалось рассчитать траекторию цели %s: %v", goalID, err)
+	} else {
+		trends := map[string]string{"improving": "ускоряется 📈", "declining": "замедляется 📉", "stable": "ровный"}
+		response += fmt.Sprintf("🏃 Темп: %+.1f п.п. в день, %s\n", trajectory.ProgressVelocity, trends[trajectory.TrendDirection])
+		response += fmt.Sprintf("🔮 Через неделю: ~%.0f%%\n", trajectory.ExpectedProgress)
+		for _, m := range trajectory.PredictedMilestones {
+			if m.CriticalPath {
+				response += fmt.Sprintf("🏁 100%% при текущем темпе: %s\n", m.PredictedDate.Format("02.01.2006"))
+			}
+		}
+		for _, b := range trajectory.BottleneckPredictions {
+			response += fmt.Sprintf("🚧 %s\n", b.Description)
+		}
+		response += "\n"
+	}
 
 	if len(prediction.SuccessFactors) > 0 {
 		response += "✅ **Факторы успеха:**\n"
@@ -877,6 +883,49 @@ func (c *ChatGPTService) handleCreateMotivationPlan(args map[string]interface{},
 
 func (c *ChatGPTService) handleGenerateWeeklyPlan(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
 	ctx := context.Background()
+
+	if revert, _ := args["revert"].(bool); revert {
+		applied, err := c.focus.LatestWeeklyPlan(ctx, userID, focus.WeeklyPlanApplied)
+		if errors.Is(err, focus.ErrWeeklyPlanNotFound) {
+			return "❌ Нет плана на неделю, добавленного в календарь", &GenerateWeeklyPlanFunction, nil
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка получения недельного плана пользователя %d: %v", userID, err)
+			return "❌ Не удалось отменить план", &GenerateWeeklyPlanFunction, nil
+		}
+		removed, err := c.focus.RevertWeeklyPlan(ctx, c.calendar, userID, applied.ID)
+		if err != nil {
+			logrus.Errorf("Ошибка отмены недельного плана %d пользователя %d: %v", applied.ID, userID, err)
+			return "❌ Не удалось отменить план", &GenerateWeeklyPlanFunction, nil
+		}
+		return fmt.Sprintf("↩️ План на неделю отменен, удалено блоков из календаря: %d", removed), &GenerateWeeklyPlanFunction, nil
+	}
+
+	if confirm, _ := args["confirm"].(bool); confirm {
+		applied, err := c.focus.ApplyWeeklyPlan(ctx, c.calendar, userID)
+		if errors.Is(err, focus.ErrWeeklyPlanNotFound) {
+			return "❌ " + err.Error() + ". Вызови generate_weekly_plan с confirm=false, чтобы составить план заново", &GenerateWeeklyPlanFunction, nil
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка применения недельного плана пользователя %d: %v", userID, err)
+			return "❌ Не удалось добавить план в календарь", &GenerateWeeklyPlanFunction, nil
+		}
+		created := 0
+		for _, block := range applied.Blocks {
+			if block.EventID != nil {
+				created++
+			}
+		}
+		if created == 0 {
+			return "❌ Не удалось добавить блоки плана в календарь", &GenerateWeeklyPlanFunction, nil
+		}
+		response := fmt.Sprintf("✅ **План добавлен в календарь**, блоков: %d из %d\n\n", created, len(applied.Blocks))
+		response += focus.FormatPlanBlocks(applied.Blocks)
+		response += "\n\nБлоки предварительные — если план не подошел, скажи «отмени план», и я уберу их из календаря."
+		response += "\nКаждый вечер сверяю план с фокус-сессиями — говори «начинаю фокус», когда садишься за блок. Прогресс по плану: «как я иду по плану?»"
+		return response, &GenerateWeeklyPlanFunction, nil
+	}
+
 	plan, err := c.aiCoach.GenerateWeeklyPlan(ctx, userID)
 	if err != nil {
 		return "Не удалось создать недельный план: " + err.Error(), &GenerateWeeklyPlanFunction, err
@@ -887,19 +936,31 @@ func (c *ChatGPTService) handleGenerateWeeklyPlan(args map[string]interface{}, u
 	days := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
 	dayNames := []string{"Понедельник", "Вторник", "Среда", "Четверг", "Пятница", "Суббота", "Воскресенье"}
 
+	weekStart := focus.PlanWeekStart(time.Now())
+	var entries []focus.PlanEntry
 	for i, day := range days {
 		if dayPlan, ok := plan[day].(map[string]interface{}); ok {
 			response += fmt.Sprintf("**%s:**\n", dayNames[i])
 
-			if focus, ok := dayPlan["focus"].(string); ok {
-				response += fmt.Sprintf("🎯 Фокус: %s\n", focus)
+			dayFocus, _ := dayPlan["focus"].(string)
+			if dayFocus != "" {
+				response += fmt.Sprintf("🎯 Фокус: %s\n", dayFocus)
 			}
 
-			if time, ok := dayPlan["time"].(float64); ok {
-				response += fmt.Sprintf("⏱️ Время: %.1f часа\n", time)
+			hours, ok := dayPlan["time"].(float64)
+			if ok {
+				response += fmt.Sprintf("⏱️ Время: %.1f часа\n", hours)
 			}
 
 			response += "\n"
+
+			if dayFocus != "" && hours > 0 {
+				entries = append(entries, focus.PlanEntry{
+					Day:		weekStart.AddDate(0, 0, i),
+					Focus:		dayFocus,
+					Duration:	time.Duration(hours * float64(time.Hour)),
+				})
+			}
 		}
 	}
 
@@ -909,6 +970,16 @@ func (c *ChatGPTService) handleGenerateWeeklyPlan(args map[string]interface{}, u
 	response += "• Завершай день рефлексией\n"
 	response += "• Адаптируй план под свое самочувствие\n"
 
+	proposal, err := c.focus.ProposeWeeklyPlan(ctx, c.calendar, userID, weekStart, entries)
+	if err != nil {
+		logrus.Errorf("Ошибка подбора времени для недельного плана пользователя %d: %v", userID, err)
+		return response, &GenerateWeeklyPlanFunction, nil
+	}
+	if len(proposal.Blocks) > 0 {
+		response += "\n🗓️ **Могу забронировать время в календаре:**\n" + focus.FormatPlanBlocks(proposal.Blocks)
+		response += "\n\nДобавить эти блоки в календарь?"
+	}
+
 	return response, &GenerateWeeklyPlanFunction, nil
 }
 
@@ -953,6 +1024,7 @@ func GetAllJarvisFunctions() []ChatGPTFunction {
 		GenerateMotivationFunction,
 		CreateMotivationPlanFunction,
 		GenerateWeeklyPlanFunction,
+		GetWeeklyPlanProgressFunction,
 		OptimizeScheduleFunction,
 		ShareGoalFunction,
 		FindAccountabilityPartnerFunction,
@@ -964,6 +1036,7 @@ func GetAllJarvisFunctions() []ChatGPTFunction {
 		CreateChallengeFunction,
 		CreateObjectiveFunction,
 		GetObjectivesFunction,
+		GetObjectiveDetailsFunction,
 		CreateKeyResultFunction,
 		AddKeyResultProgressFunction,
 		CreateTaskFunction,
@@ -972,6 +1045,87 @@ func GetAllJarvisFunctions() []ChatGPTFunction {
 		DeleteObjectiveFunction,
 		DeleteKeyResultFunction,
 		DeleteTaskFunction,
+		AddImportantDateFunction,
+		GetImportantDatesFunction,
+		DeleteImportantDateFunction,
+		CreateFinanceSpaceFunction,
+		InviteToFinanceSpaceFunction,
+		ManageFinanceSpaceMemberFunction,
+		LeaveFinanceSpaceFunction,
+		AddSharedTransactionFunction,
+		GetSharedFinanceSummaryFunction,
+		SetSharedBudgetFunction,
+		CreateOKRTeamFunction,
+		AddOKRTeamMemberFunction,
+		RespondTeamInvitationFunction,
+		ShareObjectiveWithTeamFunction,
+		AssignKeyResultOwnerFunction,
+		GetTeamOKRReportFunction,
+		SetTeamNotificationsFunction,
+		SetWorkLocationFunction,
+		GetWorkLocationsFunction,
+		ImportTravelBookingFunction,
+		GetTripsFunction,
+		AddMedicationFunction,
+		LogMedicationTakenFunction,
+		GetMedicationsFunction,
+		UpdateMedicationScheduleFunction,
+		StopMedicationFunction,
+		SetQuickCounterFunction,
+		LogQuickCounterFunction,
+		GetQuickCountersFunction,
+		GenerateWorkoutPlanFunction,
+		CompleteWorkoutFunction,
+		GetWorkoutPlanFunction,
+		LogMealFunction,
+		SetNutritionTargetFunction,
+		GetNutritionSummaryFunction,
+		DeleteLastMealFunction,
+		LogSleepFunction,
+		GetSleepStatsFunction,
+		StartFocusSessionFunction,
+		StopFocusSessionFunction,
+		LogFocusTimeFunction,
+		SetDeepWorkTargetFunction,
+		GetFocusStatsFunction,
+		ScheduleFocusBlocksFunction,
+		CreateAutomationRuleFunction,
+		GetAutomationRulesFunction,
+		DeleteAutomationRuleFunction,
+		LogChallengeProgressFunction,
+		GetChallengeLeaderboardFunction,
+		GetWellbeingTrendsFunction,
+		SearchHistoryFunction,
+		SetDoNotDisturbFunction,
+		AddTransactionFunction,
+		GetQuarterRetrospectiveFunction,
+		UpdateKeyResultTargetFunction,
+		RestoreObjectiveFunction,
+		GetObjectiveTemplatesFunction,
+		ManageSpheresFunction,
+		CreateObjectiveFromTemplateFunction,
+		CloseObjectiveFunction,
+		InviteAccountabilityPartnerFunction,
+		RespondPartnerInvitationFunction,
+		ShareObjectiveWithPartnerFunction,
+		GetPartnerObjectivesFunction,
+		EndPartnershipFunction,
+		LogTimeFunction,
+		StartTimeTrackingFunction,
+		StopTimeTrackingFunction,
+		GetTimeAllocationFunction,
+		EscalateToHumanFunction,
+		RescheduleDayFunction,
+		UndoRescheduleFunction,
+		SuggestFreeSlotsFunction,
+		FindMeetingSlotFunction,
+		GetJournalEntriesFunction,
+		CreateEventFunction,
+		UpdateEventFunction,
+		DeleteEventFunction,
+		UpdateObjectiveFunction,
+		UpdateKeyResultFunction,
+		DeleteTransactionFunction,
 	}
 }
 
@@ -991,11 +1145,15 @@ func (c *ChatGPTService) handleNewJarvisFunctions(functionCall *ChatGPTFunctionC
 		return c.handleCreateMotivationPlan(args, userID)
 	case "generate_weekly_plan":
 		return c.handleGenerateWeeklyPlan(args, userID)
+	case "get_weekly_plan_progress":
+		return c.handleGetWeeklyPlanProgress(args, userID)
 
 	case "create_objective":
 		return c.handleCreateObjective(args, userID)
 	case "get_objectives":
 		return c.handleGetObjectives(args, userID)
+	case "get_objective_details":
+		return c.handleGetObjectiveDetails(args, userID)
 	case "create_key_result":
 		return c.handleCreateKeyResult(args, userID)
 	case "add_key_result_progress":
@@ -1014,13 +1172,240 @@ func (c *ChatGPTService) handleNewJarvisFunctions(functionCall *ChatGPTFunctionC
 	case "delete_task":
 		return c.handleDeleteTask(args, userID)
 
+	case "add_important_date":
+		return c.handleAddImportantDate(args, userID)
+	case "get_important_dates":
+		return c.handleGetImportantDates(args, userID)
+	case "delete_important_date":
+		return c.handleDeleteImportantDate(args, userID)
+
+	case "create_finance_space":
+		return c.handleCreateFinanceSpace(args, userID)
+	case "invite_to_finance_space":
+		return c.handleInviteToFinanceSpace(args, userID)
+	case "manage_finance_space_member":
+		return c.handleManageFinanceSpaceMember(args, userID)
+	case "leave_finance_space":
+		return c.handleLeaveFinanceSpace(args, userID)
+	case "add_shared_transaction":
+		return c.handleAddSharedTransaction(args, userID)
+	case "get_shared_finance_summary":
+		return c.handleGetSharedFinanceSummary(args, userID)
+	case "set_shared_budget":
+		return c.handleSetSharedBudget(args, userID)
+
+	case "create_okr_team":
+		return c.handleCreateOKRTeam(args, userID)
+	case "add_okr_team_member":
+		return c.handleAddOKRTeamMember(args, userID)
+	case "respond_team_invitation":
+		return c.handleRespondTeamInvitation(args, userID)
+	case "share_objective_with_team":
+		return c.handleShareObjectiveWithTeam(args, userID)
+	case "assign_key_result_owner":
+		return c.handleAssignKeyResultOwner(args, userID)
+	case "get_team_okr_report":
+		return c.handleGetTeamOKRReport(args, userID)
+	case "set_team_notifications":
+		return c.handleSetTeamNotifications(args, userID)
+	case "set_work_location":
+		return c.handleSetWorkLocation(args, userID)
+	case "get_work_locations":
+		return c.handleGetWorkLocations(args, userID)
+	case "import_travel_booking":
+		return c.handleImportTravelBooking(args, userID)
+	case "get_trips":
+		return c.handleGetTrips(args, userID)
+	case "add_medication":
+		return c.handleAddMedication(args, userID)
+	case "log_medication_taken":
+		return c.handleLogMedicationTaken(args, userID)
+	case "get_medications":
+		return c.handleGetMedications(args, userID)
+	case "update_medication_schedule":
+		return c.handleUpdateMedicationSchedule(args, userID)
+	case "stop_medication":
+		return c.handleStopMedication(args, userID)
+	case "set_quick_counter":
+		return c.handleSetQuickCounter(args, userID)
+	case "log_quick_counter":
+		return c.handleLogQuickCounter(args, userID)
+	case "get_quick_counters":
+		return c.handleGetQuickCounters(args, userID)
+	case "generate_workout_plan":
+		return c.handleGenerateWorkoutPlan(args, userID)
+	case "complete_workout":
+		return c.handleCompleteWorkout(args, userID)
+	case "get_workout_plan":
+		return c.handleGetWorkoutPlan(args, userID)
+	case "log_meal":
+		return c.handleLogMeal(args, userID)
+	case "set_nutrition_target":
+		return c.handleSetNutritionTarget(args, userID)
+	case "get_nutrition_summary":
+		return c.handleGetNutritionSummary(args, userID)
+	case "delete_last_meal":
+		return c.handleDeleteLastMeal(args, userID)
+	case "log_sleep":
+		return c.handleLogSleep(args, userID)
+	case "get_sleep_stats":
+		return c.handleGetSleepStats(args, userID)
+	case "start_focus_session":
+		return c.handleStartFocusSession(args, userID)
+	case "stop_focus_session":
+		return c.handleStopFocusSession(args, userID)
+	case "log_focus_time":
+		return c.handleLogFocusTime(args, userID)
+	case "set_deep_work_target":
+		return c.handleSetDeepWorkTarget(args, userID)
+	case "get_focus_stats":
+		return c.handleGetFocusStats(args, userID)
+	case "schedule_focus_blocks":
+		return c.handleScheduleFocusBlocks(args, userID)
+	case "create_automation_rule":
+		return c.handleCreateAutomationRule(args, userID)
+	case "get_automation_rules":
+		return c.handleGetAutomationRules(args, userID)
+	case "delete_automation_rule":
+		return c.handleDeleteAutomationRule(args, userID)
+	case "create_challenge":
+		return c.handleCreateChallenge(args, userID)
+	case "log_challenge_progress":
+		return c.handleLogChallengeProgress(args, userID)
+	case "get_challenge_leaderboard":
+		return c.handleGetChallengeLeaderboard(args, userID)
+	case "check_wellbeing":
+		return c.handleCheckWellbeing(args, userID)
+	case "get_wellbeing_trends":
+		return c.handleGetWellbeingTrends(args, userID)
+	case "update_preferences":
+		return c.handleUpdatePreferences(args, userID)
+	case "learn_from_feedback":
+		return c.handleLearnFromFeedback(args, userID)
+	case "search_history":
+		return c.handleSearchHistory(args, userID)
+
+	case "set_do_not_disturb":
+		return c.handleSetDoNotDisturb(args, userID)
+
+	case "add_transaction":
+		return c.handleAddTransaction(args, userID)
+
+	case "get_quarter_retrospective":
+		return c.handleGetQuarterRetrospective(args, userID)
+
+	case "update_key_result_target":
+		return c.handleUpdateKeyResultTarget(args, userID)
+
+	case "restore_objective":
+		return c.handleRestoreObjective(args, userID)
+
+	case "share_goal":
+		return c.handleShareGoal(args, userID)
+
+	case "manage_spheres":
+		return c.handleManageSpheres(args, userID)
+
+	case "get_objective_templates":
+		return c.handleGetObjectiveTemplates(args, userID)
+
+	case "create_objective_from_template":
+		return c.handleCreateObjectiveFromTemplate(args, userID)
+
+	case "close_objective":
+		return c.handleCloseObjective(args, userID)
+
+	case "invite_accountability_partner":
+		return c.handleInviteAccountabilityPartner(args, userID)
+
+	case "respond_partner_invitation":
+		return c.handleRespondPartnerInvitation(args, userID)
+
+	case "share_objective_with_partner":
+		return c.handleShareObjectiveWithPartner(args, userID)
+
+	case "get_partner_objectives":
+		return c.handleGetPartnerObjectives(args, userID)
+
+	case "end_partnership":
+		return c.handleEndPartnership(args, userID)
+
+	case "log_time":
+		return c.handleLogTime(args, userID)
+
+	case "start_time_tracking":
+		return c.handleStartTimeTracking(args, userID)
+
+	case "stop_time_tracking":
+		return c.handleStopTimeTracking(args, userID)
+
+	case "get_time_allocation":
+		return c.handleGetTimeAllocation(args, userID)
+
+	case "escalate_to_human":
+		return c.handleEscalateToHuman(args, userID)
+
+	case "reschedule_day":
+		return c.handleRescheduleDay(args, userID)
+
+	case "undo_reschedule":
+		return c.handleUndoReschedule(args, userID)
+
+	case "optimize_schedule":
+		return c.handleOptimizeSchedule(args, userID)
+
+	case "suggest_free_slots":
+		return c.handleSuggestFreeSlots(args, userID)
+
+	case "find_meeting_slot":
+		return c.handleFindMeetingSlot(args, userID)
+
+	case "get_journal_entries":
+		return c.handleGetJournalEntries(args, userID)
+
+	case "find_accountability_partner":
+		return c.handleFindAccountabilityPartner(args, userID)
+
+	case "suggest_break":
+		return c.handleSuggestBreak(args, userID)
+
+	case "check_achievements":
+		return c.handleCheckAchievements(args, userID)
+
+	case "create_event":
+		return c.handleCreateEvent(args, userID)
+
+	case "update_event":
+		return c.handleUpdateEvent(args, userID)
+
+	case "delete_event":
+		return c.handleDeleteEvent(args, userID)
+
+	case "update_objective":
+		return c.handleUpdateObjective(args, userID)
+
+	case "update_key_result":
+		return c.handleUpdateKeyResult(args, userID)
+
+	case "delete_transaction":
+		return c.handleDeleteTransaction(args, userID)
+
 	default:
 		return "", nil, fmt.Errorf("неизвестная функция: %s", functionCall.Name)
 	}
 }
 
 func (c *ChatGPTService) handleCreateObjective(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Создание цели для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Создание цели для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+	args, draft, err := c.objectiveDraftFields(ctx, userID, args)
+	if errors.Is(err, okr.ErrDraftConflict) {
+		return c.draftConflictReply(ctx, userID), &CreateObjectiveFunction, nil
+	}
+	if err != nil {
+		logrus.Warnf("Не удалось получить черновик цели пользователя %d: %v", userID, err)
+	}
 
 	title, _ := args["title"].(string)
 	sphere, _ := args["sphere"].(string)
@@ -1028,147 +1413,217 @@ func (c *ChatGPTService) handleCreateObjective(args map[string]interface{}, user
 	deadline, _ := args["deadline"].(string)
 	keyResultsInterface, _ := args["key_results"].([]interface{})
 
-	logrus.Infof("Параметры цели: title=%s, sphere=%s, period=%s, deadline=%s, keyResults=%d",
+	logrus.Debugf("Параметры цели: title=%s, sphere=%s, period=%s, deadline=%s, keyResults=%d",
 		title, sphere, period, deadline, len(keyResultsInterface))
 
 	if title == "" || sphere == "" || period == "" || deadline == "" {
 		logrus.Errorf("Отсутствуют обязательные параметры: title=%s, sphere=%s, period=%s, deadline=%s",
 			title, sphere, period, deadline)
-		return "❌ Не указаны обязательные параметры для создания цели", &CreateObjectiveFunction, nil
+		base := 0
+		if draft != nil {
+			base = draft.Version
+		}
+		saved, err := c.okr.SaveObjectiveDraft(ctx, userID, args, base, okr.DraftViaTelegram)
+		if errors.Is(err, okr.ErrDraftConflict) {
+			return c.draftConflictReply(ctx, userID), &CreateObjectiveFunction, nil
+		}
+		if err != nil {
+			logrus.Warnf("Не удалось сохранить черновик цели пользователя %d: %v", userID, err)
+			return "❌ Не указаны обязательные параметры для создания цели", &CreateObjectiveFunction, nil
+		}
+		return draftPrompt(saved), &CreateObjectiveFunction, nil
 	}
 
-	query := `
-		INSERT INTO objectives (id, user_id, title, sphere, period, deadline, status, created_at, updated_at) 
-		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, 'active', NOW(), NOW()) 
-		RETURNING id
-	`
-
-	var objectiveID string
-	logrus.Infof("Выполняем SQL запрос создания цели: %s", query)
-	err := c.db.QueryRow(query, userID, title, sphere, period, deadline).Scan(&objectiveID)
+	objectiveDeadline, err := time.ParseInLocation("2006-01-02", deadline, time.Local)
 	if err != nil {
-		logrus.Errorf("Ошибка создания цели: %v", err)
-		return "❌ Не удалось создать цель в базе данных", &CreateObjectiveFunction, fmt.Errorf("database error: %w", err)
+		return "❌ Неверный формат дедлайна цели. Используйте YYYY-MM-DD", &CreateObjectiveFunction, nil
 	}
-
-	logrus.Infof("Цель создана успешно с ID: %s", objectiveID)
-
-	keyResultsCreated := 0
-	logrus.Infof("Обрабатываем %d ключевых результатов", len(keyResultsInterface))
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	plan := okr.ObjectivePlan{Title: title, Sphere: sphere, Period: period, Deadline: &objectiveDeadline}
+	logrus.Debugf("Обрабатываем %d ключевых результатов", len(keyResultsInterface))
 
 	for i, krInterface := range keyResultsInterface {
-		logrus.Infof("Обработка KR #%d: %+v", i+1, krInterface)
-
-		if krMap, ok := krInterface.(map[string]interface{}); ok {
-			krTitle, _ := krMap["title"].(string)
-			target, _ := krMap["target"].(float64)
-			unit, _ := krMap["unit"].(string)
-			krDeadline, _ := krMap["deadline"].(string)
-
-			logrus.Infof("KR параметры: title=%s, target=%.1f, unit=%s, deadline=%s",
-				krTitle, target, unit, krDeadline)
-
-			if krTitle != "" && target > 0 && unit != "" && krDeadline != "" {
-				krQuery := `
-					INSERT INTO key_results (objective_id, title, target, unit, deadline, status, progress, created_at, updated_at)
-					VALUES ($1, $2, $3, $4, $5, 'active', 0, NOW(), NOW())
-				`
-
-				logrus.Infof("Создаем KR: %s", krTitle)
-				_, err := c.db.Exec(krQuery, objectiveID, krTitle, target, unit, krDeadline)
-				if err != nil {
-					logrus.Errorf("Ошибка создания ключевого результата: %v", err)
-				} else {
-					keyResultsCreated++
-					logrus.Infof("KR создан успешно: %s", krTitle)
+		krMap, ok := krInterface.(map[string]interface{})
+		if !ok {
+			logrus.Warnf("KR #%d не является объектом: %T", i+1, krInterface)
+			continue
+		}
+		krTitle, _ := krMap["title"].(string)
+		target, _ := krMap["target"].(float64)
+		unit, _ := krMap["unit"].(string)
+		krDeadlineStr, _ := krMap["deadline"].(string)
+
+		krDeadline, err := time.ParseInLocation("2006-01-02", krDeadlineStr, time.Local)
+		if krTitle == "" || target <= 0 || unit == "" || err != nil {
+			logrus.Warnf("KR пропущен из-за неполных данных: title=%s, target=%.1f, unit=%s, deadline=%s",
+				krTitle, target, unit, krDeadlineStr)
+			continue
+		}
+		keyResult := okr.KeyResultPlan{KeyResult: okr.KeyResult{Title: krTitle, Target: target, Unit: unit, Deadline: &krDeadline}}
+
+		if task, ok := krMap["daily_task"].(map[string]interface{}); ok {
+			taskTitle, _ := task["title"].(string)
+			dailyTarget, _ := task["daily_target"].(float64)
+			taskUnit, _ := task["unit"].(string)
+			if taskTitle != "" && dailyTarget > 0 && !krDeadline.Before(today) {
+				if taskUnit == "" {
+					taskUnit = unit
 				}
-			} else {
-				logrus.Warnf("KR пропущен из-за неполных данных: title=%s, target=%.1f, unit=%s, deadline=%s",
-					krTitle, target, unit, krDeadline)
+				keyResult.Recurring = &okr.RecurringTaskPlan{Title: taskTitle, DailyTarget: dailyTarget, Unit: taskUnit, StartDate: today, EndDate: krDeadline}
 			}
-		} else {
-			logrus.Warnf("KR #%d не является объектом: %T", i+1, krInterface)
 		}
+		plan.KeyResults = append(plan.KeyResults, keyResult)
 	}
 
+	if draft != nil {
+		err := c.okr.DeleteObjectiveDraft(ctx, userID, draft.Version)
+		if errors.Is(err, okr.ErrDraftConflict) {
+			return c.draftConflictReply(ctx, userID), &CreateObjectiveFunction, nil
+		}
+		if err != nil {
+			logrus.Warnf("Не удалось удалить черновик цели пользователя %d: %v", userID, err)
+		}
+	}
+
+	created, err := c.okr.CreateObjectivePlan(ctx, userID, plan)
+	if err != nil {
+		logrus.Errorf("Ошибка создания цели: %v", err)
+		if draft != nil {
+			if _, err := c.okr.SaveObjectiveDraft(ctx, userID, draft.Fields, 0, draft.UpdatedVia); err != nil {
+				logrus.Warnf("Не удалось восстановить черновик цели пользователя %d: %v", userID, err)
+			}
+		}
+		return "❌ Не удалось создать цель в базе данных", &CreateObjectiveFunction, fmt.Errorf("database error: %w", err)
+	}
+	sphere = created.Sphere
+	keyResultsCreated := len(created.KeyResultIDs)
+
+	logrus.Debugf("Цель создана успешно с ID: %s", created.ObjectiveID)
+
 	response := fmt.Sprintf("🎯 **Цель успешно создана!**\n\n")
 	response += fmt.Sprintf("📋 **Название:** %s\n", title)
-	response += fmt.Sprintf("🎌 **Сфера:** %s\n", sphere)
+	response += fmt.Sprintf("%s **Сфера:** %s\n", okr.SphereIcon(sphere), sphere)
 	response += fmt.Sprintf("⏰ **Период:** %s\n", getPeriodName(period))
 	response += fmt.Sprintf("📅 **Дедлайн:** %s\n", deadline)
-	response += fmt.Sprintf("🔑 **Ключевые результаты:** %d создано\n\n", keyResultsCreated)
+	response += fmt.Sprintf("🔑 **Ключевые результаты:** %d создано\n", keyResultsCreated)
+	if len(created.TaskIDs) > 0 {
+		response += fmt.Sprintf("🔁 **Ежедневные задачи:** %d создано\n", len(created.TaskIDs))
+	}
+	response += "\n"
 
 	response += "✨ Jarvis будет отслеживать твой прогресс и поможет достичь этой цели!"
 
 	return response, &CreateObjectiveFunction, nil
 }
 
-func (c *ChatGPTService) handleGetObjectives(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Получение целей для пользователя %d с аргументами: %+v", userID, args)
+var draftFieldNames = map[string]string{
+	"title":	"название",
+	"sphere":	"сферу",
+	"period":	"период",
+	"deadline":	"дедлайн",
+}
 
-	period, _ := args["period"].(string)
-	status, _ := args["status"].(string)
+var draftFieldLabels = map[string]string{
+	"title":	"Название",
+	"sphere":	"Сфера",
+	"period":	"Период",
+	"deadline":	"Дедлайн",
+}
 
-	if period == "" {
-		period = "all"
+func (c *ChatGPTService) objectiveDraftFields(ctx context.Context, userID int64, args map[string]interface{}) (map[string]interface{}, *okr.ObjectiveDraft, error) {
+	version := 0
+	if value, ok := args["draft_version"].(float64); ok {
+		version = int(value)
 	}
-	if status == "" {
-		status = "all"
-	}
-
-	logrus.Infof("Фильтры: period=%s, status=%s", period, status)
+	delete(args, "draft_version")
 
-	query := `
-		SELECT o.id, o.title, o.sphere, o.period, o.deadline, o.status, o.created_at,
-		       COUNT(kr.id) as key_results_count,
-		       COALESCE(AVG(CASE WHEN kr.target > 0 THEN (kr.progress::float / kr.target::float) * 100 END), 0) as avg_progress
-		FROM objectives o
-		LEFT JOIN key_results kr ON o.id = kr.objective_id
-		WHERE o.user_id = $1
-	`
+	draft, err := c.okr.GetObjectiveDraft(ctx, userID)
+	if errors.Is(err, okr.ErrDraftNotFound) {
+		if version != 0 {
+			return args, nil, okr.ErrDraftConflict
+		}
+		return args, nil, nil
+	}
+	if err != nil {
+		return args, nil, err
+	}
+	if version != 0 && version != draft.Version {
+		return args, draft, okr.ErrDraftConflict
+	}
+	if draft.Continues(args, version) {
+		return draft.Merge(args), draft, nil
+	}
+	return args, draft, nil
+}
 
-	args_list := []interface{}{userID}
-	argCount := 1
+func draftPrompt(draft *okr.ObjectiveDraft) string {
+	missing := draft.Missing()
+	names := make([]string, 0, len(missing))
+	for _, name := range missing {
+		names = append(names, draftFieldNames[name])
+	}
+	return fmt.Sprintf("📝 Черновик цели сохранен (версия %d). Осталось указать: %s.\nМожно продолжить здесь или закончить в веб-приложении.", draft.Version, strings.Join(names, ", "))
+}
 
-	if period != "all" {
-		argCount++
-		query += fmt.Sprintf(" AND o.period = $%d", argCount)
-		args_list = append(args_list, period)
+func (c *ChatGPTService) draftConflictReply(ctx context.Context, userID int64) string {
+	draft, err := c.okr.GetObjectiveDraft(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, okr.ErrDraftNotFound) {
+			logrus.Warnf("Не удалось получить черновик цели пользователя %d: %v", userID, err)
+		}
+		return "⚠️ Черновик цели уже завершили или удалили в веб-приложении. Если цель еще нужна, опишите ее заново."
 	}
 
-	if status != "all" {
-		argCount++
-		query += fmt.Sprintf(" AND o.status = $%d", argCount)
-		args_list = append(args_list, status)
+	via := "в чате"
+	if draft.UpdatedVia == okr.DraftViaWeb {
+		via = "в веб-приложении"
+	}
+	lines := []string{fmt.Sprintf("⚠️ Черновик цели изменили %s, поэтому я не стал его перезаписывать. Сейчас в черновике (версия %d):", via, draft.Version)}
+	for _, name := range okr.ObjectiveDraftRequired {
+		value, _ := draft.Fields[name].(string)
+		if value == "" {
+			value = "—"
+		} else if name == "period" {
+			value = getPeriodName(value)
+		}
+		lines = append(lines, fmt.Sprintf("• %s: %s", draftFieldLabels[name], value))
 	}
+	if keyResults, _ := draft.Fields["key_results"].([]interface{}); len(keyResults) > 0 {
+		lines = append(lines, fmt.Sprintf("• Ключевые результаты: %d", len(keyResults)))
+	}
+	lines = append(lines, "", "Скажите, что поменять, — продолжу с этой версии.")
+	return strings.Join(lines, "\n")
+}
 
-	query += " GROUP BY o.id, o.title, o.sphere, o.period, o.deadline, o.status, o.created_at ORDER BY o.created_at DESC"
+func (c *ChatGPTService) handleGetObjectives(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Получение целей для пользователя %d с аргументами: %+v", userID, args)
 
-	logrus.Infof("Выполняем SQL запрос получения целей: %s с параметрами: %+v", query, args_list)
-	rows, err := c.db.Query(query, args_list...)
+	ctx := context.Background()
+	filter := okr.ObjectiveFilter{DeadlineWithinDays: deadlineWindow(args)}
+	filter.Period, _ = args["period"].(string)
+	filter.Status, _ = args["status"].(string)
+	sphere, err := c.sphereFilter(ctx, userID, args)
+	if errors.Is(err, okr.ErrSphereNotFound) {
+		return fmt.Sprintf("❌ Сфера «%s» не найдена. Список сфер — «какие есть сферы?»", args["sphere"]), &GetObjectivesFunction, nil
+	}
+	if err != nil {
+		return "", &GetObjectivesFunction, err
+	}
+	filter.Sphere = sphere
+	limit, offset := listWindow(args)
+
+	objectives, total, err := c.okr.ListObjectiveSummaries(ctx, userID, filter, limit, offset)
 	if err != nil {
 		logrus.Errorf("Ошибка получения целей: %v", err)
 		return "❌ Не удалось получить цели из базы данных", &GetObjectivesFunction, fmt.Errorf("database error: %w", err)
 	}
-	defer rows.Close()
 
 	response := "🎯 **Твои цели:**\n\n"
-	objectiveCount := 0
-
-	for rows.Next() {
-		var id, title, sphere, period, deadline, status, createdAt string
-		var keyResultsCount int
-		var avgProgress float64
-
-		err := rows.Scan(&id, &title, &sphere, &period, &deadline, &status, &createdAt, &keyResultsCount, &avgProgress)
-		if err != nil {
-			continue
-		}
-
-		objectiveCount++
 
+	for _, objective := range objectives {
 		statusEmoji := "🔄"
-		switch status {
+		switch objective.Status {
 		case "completed":
 			statusEmoji = "✅"
 		case "paused":
@@ -1177,25 +1632,44 @@ func (c *ChatGPTService) handleGetObjectives(args map[string]interface{}, userID
 			statusEmoji = "🎯"
 		}
 
-		response += fmt.Sprintf("%s **%s** (%s)\n", statusEmoji, title, sphere)
-		response += fmt.Sprintf("📊 Прогресс: %.1f%% | 🔑 KR: %d | 📅 %s\n\n", avgProgress, keyResultsCount, deadline)
+		deadline := "без дедлайна"
+		if objective.Deadline != nil {
+			deadline = objective.Deadline.Format("02.01.2006")
+		}
+		response += fmt.Sprintf("%s **%s** (%s)\n", statusEmoji, objective.Title, objective.Sphere)
+		response += fmt.Sprintf("📊 Прогресс: %.1f%% | 🔑 KR: %d | 📅 %s\n\n", objective.Progress, objective.KeyResultsCount, deadline)
 	}
 
-	logrus.Infof("Найдено целей для пользователя %d: %d", userID, objectiveCount)
+	logrus.Debugf("Найдено целей для пользователя %d: %d из %d", userID, len(objectives), total)
+
+	teamSection := ""
+	if offset+len(objectives) >= total && filter.Sphere == "" && filter.DeadlineWithinDays == 0 {
+		teamSection = c.formatTeamObjectives(userID)
+	}
 
-	if objectiveCount == 0 {
-		response = "🎯 **У тебя пока нет целей**\n\n"
-		response += "💡 Скажи мне о своих планах, и я помогу их структурировать в цели OKR!"
+	if len(objectives) == 0 && teamSection == "" {
+		if total > 0 {
+			response = fmt.Sprintf("🎯 **Больше целей нет** — всего %d", total)
+		} else if filter.Sphere != "" || filter.DeadlineWithinDays > 0 {
+			response = "🎯 **Под этот фильтр целей нет**"
+		} else {
+			response = "🎯 **У тебя пока нет целей**\n\n"
+			response += "💡 Скажи мне о своих планах, и я помогу их структурировать в цели OKR!"
+		}
+	} else if len(objectives) == 0 {
+		response = teamSection
 	} else {
-		response += fmt.Sprintf("📈 **Всего целей:** %d", objectiveCount)
+		response += listFooter("целей", offset, len(objectives), total)
+		if teamSection != "" {
+			response += "\n\n" + teamSection
+		}
 	}
 
-	logrus.Infof("Возвращаем ответ get_objectives для пользователя %d: %s", userID, response)
 	return response, &GetObjectivesFunction, nil
 }
 
 func (c *ChatGPTService) handleCreateKeyResult(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Создание ключевого результата для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Создание ключевого результата для пользователя %d с аргументами: %+v", userID, args)
 
 	title, _ := args["title"].(string)
 	target, _ := args["target"].(float64)
@@ -1209,22 +1683,20 @@ func (c *ChatGPTService) handleCreateKeyResult(args map[string]interface{}, user
 	}
 
 	if objectiveID == "" && objectiveDescription != "" {
-		query := `SELECT id FROM objectives WHERE user_id = $1 AND LOWER(title) LIKE LOWER($2) ORDER BY created_at DESC LIMIT 1`
-		err := c.db.QueryRow(query, userID, "%"+objectiveDescription+"%").Scan(&objectiveID)
+		candidate, err := c.okr.ResolveObjective(context.Background(), userID, objectiveDescription)
 		if err != nil {
-			return "❌ Не найдена цель по описанию: " + objectiveDescription, &CreateKeyResultFunction, nil
+			return unresolvedEntity(err, &CreateKeyResultFunction, "❌ Не найдена цель по описанию: "+objectiveDescription)
 		}
+		objectiveID = candidate.ID
 	}
 
 	if objectiveID == "" {
 		return "❌ Не указана цель для ключевого результата", &CreateKeyResultFunction, nil
 	}
 
-	var ownerID int64
-	checkQuery := `SELECT user_id FROM objectives WHERE id = $1`
-	err := c.db.QueryRow(checkQuery, objectiveID).Scan(&ownerID)
-	if err != nil || ownerID != userID {
-		return "❌ Цель не найдена или не принадлежит пользователю", &CreateKeyResultFunction, nil
+	err := ownership.MustOwnObjective(context.Background(), c.db, userID, objectiveID)
+	if err != nil {
+		return ownershipErrorMessage(err), &CreateKeyResultFunction, nil
 	}
 
 	insertQuery := `
@@ -1256,7 +1728,7 @@ func (c *ChatGPTService) handleCreateKeyResult(args map[string]interface{}, user
 }
 
 func (c *ChatGPTService) handleAddKeyResultProgress(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Добавление прогресса ключевого результата для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Добавление прогресса ключевого результата для пользователя %d с аргументами: %+v", userID, args)
 
 	keyResultID, hasID := args["key_result_id"].(float64)
 	keyResultDescription, _ := args["key_result_description"].(string)
@@ -1274,38 +1746,11 @@ func (c *ChatGPTService) handleAddKeyResultProgress(args map[string]interface{},
 			return "❌ Не указан ID или описание ключевого результата", &AddKeyResultProgressFunction, nil
 		}
 
-		var query string
-		var params []interface{}
-
-		if objectiveDescription != "" {
-
-			query = `
-				SELECT kr.id 
-				FROM key_results kr
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(kr.title) LIKE LOWER($2)
-				AND LOWER(o.title) LIKE LOWER($3)
-				ORDER BY kr.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + keyResultDescription + "%", "%" + objectiveDescription + "%"}
-		} else {
-
-			query = `
-				SELECT kr.id 
-				FROM key_results kr
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(kr.title) LIKE LOWER($2)
-				ORDER BY kr.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + keyResultDescription + "%"}
-		}
-
-		err := c.db.QueryRow(query, params...).Scan(&finalKeyResultID)
+		candidate, err := c.okr.ResolveKeyResult(context.Background(), userID, keyResultDescription, objectiveDescription)
 		if err != nil {
-			return "❌ Не найден ключевой результат по описанию: " + keyResultDescription, &AddKeyResultProgressFunction, nil
+			return unresolvedEntity(err, &AddKeyResultProgressFunction, "❌ Не найден ключевой результат по описанию: "+keyResultDescription)
 		}
+		finalKeyResultID = candidate.NumericID()
 	} else {
 		finalKeyResultID = int64(keyResultID)
 
@@ -1313,7 +1758,7 @@ func (c *ChatGPTService) handleAddKeyResultProgress(args map[string]interface{},
 			SELECT kr.id 
 			FROM key_results kr
 			JOIN objectives o ON kr.objective_id = o.id
-			WHERE kr.id = $1 AND o.user_id = $2
+			WHERE kr.id = $1 AND (o.user_id = $2 OR kr.owner_id = $2) AND kr.deleted_at IS NULL
 		`
 		var checkID int64
 		err := c.db.QueryRow(checkQuery, finalKeyResultID, userID).Scan(&checkID)
@@ -1354,12 +1799,20 @@ func (c *ChatGPTService) handleAddKeyResultProgress(args map[string]interface{},
 		SET progress = $1, updated_at = NOW()
 		WHERE id = $2
 	`
-	_, err = c.db.Exec(updateQuery, newProgress, finalKeyResultID)
+	err = okr.WithProgressAuthor(context.Background(), c.db, userID, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(updateQuery, newProgress, finalKeyResultID)
+		return err
+	})
 	if err != nil {
 		logrus.Errorf("Ошибка обновления прогресса: %v", err)
 		return "❌ Не удалось обновить прогресс", &AddKeyResultProgressFunction, nil
 	}
 
+	reconciled, err := c.okr.ReconcileDailyTasks(context.Background(), finalKeyResultID, newProgress-krData.Progress)
+	if err != nil {
+		logrus.Warnf("Не удалось сверить задачи на сегодня с ключевым результатом %d: %v", finalKeyResultID, err)
+	}
+
 	completionPercent := (newProgress / krData.Target) * 100
 	if completionPercent > 100 {
 		completionPercent = 100
@@ -1371,6 +1824,9 @@ func (c *ChatGPTService) handleAddKeyResultProgress(args map[string]interface{},
 	response += fmt.Sprintf("➕ **Добавлено:** +%.1f %s\n", progress, krData.Unit)
 	response += fmt.Sprintf("📊 **Текущий прогресс:** %.1f / %.1f %s (%.1f%%)\n\n",
 		newProgress, krData.Target, krData.Unit, completionPercent)
+	if len(reconciled) > 0 {
+		response += okr.FormatReconciledTasks(reconciled) + "\n\n"
+	}
 
 	if completionPercent >= 100 {
 		response += "🎉 **Поздравляю! Ключевой результат выполнен на 100%!**\n"
@@ -1390,7 +1846,7 @@ func (c *ChatGPTService) handleAddKeyResultProgress(args map[string]interface{},
 }
 
 func (c *ChatGPTService) handleCreateTask(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Создание задачи для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Создание задачи для пользователя %d с аргументами: %+v", userID, args)
 
 	title, _ := args["title"].(string)
 	target, _ := args["target"].(float64)
@@ -1411,51 +1867,16 @@ func (c *ChatGPTService) handleCreateTask(args map[string]interface{}, userID in
 			return "❌ Не указан ID или описание ключевого результата", &CreateTaskFunction, nil
 		}
 
-		var query string
-		var params []interface{}
-
-		if objectiveDescription != "" {
-
-			query = `
-				SELECT kr.id 
-				FROM key_results kr
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(kr.title) LIKE LOWER($2)
-				AND LOWER(o.title) LIKE LOWER($3)
-				ORDER BY kr.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + keyResultDescription + "%", "%" + objectiveDescription + "%"}
-		} else {
-
-			query = `
-				SELECT kr.id 
-				FROM key_results kr
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(kr.title) LIKE LOWER($2)
-				ORDER BY kr.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + keyResultDescription + "%"}
-		}
-
-		err := c.db.QueryRow(query, params...).Scan(&finalKeyResultID)
+		candidate, err := c.okr.ResolveKeyResult(context.Background(), userID, keyResultDescription, objectiveDescription)
 		if err != nil {
-			return "❌ Не найден ключевой результат по описанию: " + keyResultDescription, &CreateTaskFunction, nil
+			return unresolvedEntity(err, &CreateTaskFunction, "❌ Не найден ключевой результат по описанию: "+keyResultDescription)
 		}
+		finalKeyResultID = candidate.NumericID()
 	} else {
 		finalKeyResultID = int64(keyResultID)
 
-		checkQuery := `
-			SELECT kr.id 
-			FROM key_results kr
-			JOIN objectives o ON kr.objective_id = o.id
-			WHERE kr.id = $1 AND o.user_id = $2
-		`
-		var checkID int64
-		err := c.db.QueryRow(checkQuery, finalKeyResultID, userID).Scan(&checkID)
-		if err != nil {
-			return "❌ Ключевой результат не найден или не принадлежит пользователю", &CreateTaskFunction, nil
+		if err := ownership.MustOwnKeyResult(context.Background(), c.db, userID, finalKeyResultID); err != nil {
+			return ownershipErrorMessage(err), &CreateTaskFunction, nil
 		}
 	}
 
@@ -1499,7 +1920,7 @@ func (c *ChatGPTService) handleCreateTask(args map[string]interface{}, userID in
 }
 
 func (c *ChatGPTService) handleAddTaskProgress(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Добавление прогресса задачи для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Добавление прогресса задачи для пользователя %d с аргументами: %+v", userID, args)
 
 	taskID, hasID := args["task_id"].(float64)
 	taskDescription, _ := args["task_description"].(string)
@@ -1517,54 +1938,16 @@ func (c *ChatGPTService) handleAddTaskProgress(args map[string]interface{}, user
 			return "❌ Не указан ID или описание задачи", &AddTaskProgressFunction, nil
 		}
 
-		var query string
-		var params []interface{}
-
-		if keyResultDescription != "" {
-
-			query = `
-				SELECT t.id 
-				FROM tasks t
-				JOIN key_results kr ON t.key_result_id = kr.id
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(t.title) LIKE LOWER($2)
-				AND LOWER(kr.title) LIKE LOWER($3)
-				ORDER BY t.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + taskDescription + "%", "%" + keyResultDescription + "%"}
-		} else {
-
-			query = `
-				SELECT t.id 
-				FROM tasks t
-				JOIN key_results kr ON t.key_result_id = kr.id
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(t.title) LIKE LOWER($2)
-				ORDER BY t.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + taskDescription + "%"}
-		}
-
-		err := c.db.QueryRow(query, params...).Scan(&finalTaskID)
+		candidate, err := c.okr.ResolveTask(context.Background(), userID, taskDescription, keyResultDescription)
 		if err != nil {
-			return "❌ Не найдена задача по описанию: " + taskDescription, &AddTaskProgressFunction, nil
+			return unresolvedEntity(err, &AddTaskProgressFunction, "❌ Не найдена задача по описанию: "+taskDescription)
 		}
+		finalTaskID = candidate.NumericID()
 	} else {
 		finalTaskID = int64(taskID)
 
-		checkQuery := `
-			SELECT t.id 
-			FROM tasks t
-			JOIN key_results kr ON t.key_result_id = kr.id
-			JOIN objectives o ON kr.objective_id = o.id
-			WHERE t.id = $1 AND o.user_id = $2
-		`
-		var checkID int64
-		err := c.db.QueryRow(checkQuery, finalTaskID, userID).Scan(&checkID)
-		if err != nil {
-			return "❌ Задача не найдена или не принадлежит пользователю", &AddTaskProgressFunction, nil
+		if err := ownership.MustOwnTask(context.Background(), c.db, userID, finalTaskID); err != nil {
+			return ownershipErrorMessage(err), &AddTaskProgressFunction, nil
 		}
 	}
 
@@ -1602,7 +1985,9 @@ func (c *ChatGPTService) handleAddTaskProgress(args map[string]interface{}, user
 
 	updateTaskQuery := `
 		UPDATE tasks 
-		SET progress = $1, updated_at = NOW()
+		SET progress = $1,
+			completion_date = CASE WHEN $1 >= target THEN COALESCE(completion_date, NOW()) ELSE completion_date END,
+			updated_at = NOW()
 		WHERE id = $2
 	`
 	_, err = c.db.Exec(updateTaskQuery, newTaskProgress, finalTaskID)
@@ -1613,15 +1998,10 @@ func (c *ChatGPTService) handleAddTaskProgress(args map[string]interface{}, user
 
 	var krUpdateInfo string
 	taskCompletionPercent := (newTaskProgress / taskData.Target) * 100
-	if taskCompletionPercent >= 100 && newTaskProgress == taskData.Target {
-
-		addKRProgressQuery := `
-			UPDATE key_results 
-			SET progress = progress + $1, updated_at = NOW()
-			WHERE id = $2
-		`
-		_, err = c.db.Exec(addKRProgressQuery, taskData.Target, taskData.KeyResultID)
-		if err == nil {
+	if taskCompletionPercent >= 100 && newTaskProgress == taskData.Target && taskData.Progress < taskData.Target {
+		if err := c.okr.CreditKeyResultFromTask(context.Background(), userID, finalTaskID, taskData.Target); err != nil {
+			logrus.Warnf("Не удалось зачесть задачу %d в ключевой результат: %v", finalTaskID, err)
+		} else {
 			krUpdateInfo = "\n🎯 **Автоматически обновлен ключевой результат:** +" + fmt.Sprintf("%.1f %s", taskData.Target, taskData.Unit)
 		}
 	}
@@ -1658,87 +2038,45 @@ func (c *ChatGPTService) handleAddTaskProgress(args map[string]interface{}, user
 }
 
 func (c *ChatGPTService) handleGetTasks(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Получение задач для пользователя %d с аргументами: %+v", userID, args)
-
-	keyResultID, hasKRID := args["key_result_id"].(float64)
-	objectiveID, _ := args["objective_id"].(string)
-
-	var query string
-	var params []interface{}
-
-	if hasKRID && keyResultID > 0 {
+	logrus.Debugf("Получение задач для пользователя %d с аргументами: %+v", userID, args)
 
-		query = `
-			SELECT t.id, t.title, t.target, t.unit, t.progress, t.deadline, t.status,
-			       kr.title as kr_title, o.title as obj_title
-			FROM tasks t
-			JOIN key_results kr ON t.key_result_id = kr.id
-			JOIN objectives o ON kr.objective_id = o.id
-			WHERE t.key_result_id = $1 AND o.user_id = $2
-			ORDER BY t.created_at DESC
-		`
-		params = []interface{}{int64(keyResultID), userID}
-	} else if objectiveID != "" {
-
-		query = `
-			SELECT t.id, t.title, t.target, t.unit, t.progress, t.deadline, t.status,
-			       kr.title as kr_title, o.title as obj_title
-			FROM tasks t
-			JOIN key_results kr ON t.key_result_id = kr.id
-			JOIN objectives o ON kr.objective_id = o.id
-			WHERE o.id = $1 AND o.user_id = $2
-			ORDER BY kr.created_at, t.created_at DESC
-		`
-		params = []interface{}{objectiveID, userID}
-	} else {
-
-		query = `
-			SELECT t.id, t.title, t.target, t.unit, t.progress, t.deadline, t.status,
-			       kr.title as kr_title, o.title as obj_title
-			FROM tasks t
-			JOIN key_results kr ON t.key_result_id = kr.id
-			JOIN objectives o ON kr.objective_id = o.id
-			WHERE o.user_id = $1
-			ORDER BY t.created_at DESC
-			LIMIT 20
-		`
-		params = []interface{}{userID}
+	ctx := context.Background()
+	filter := okr.TaskFilter{DeadlineWithinDays: deadlineWindow(args)}
+	if keyResultID, ok := args["key_result_id"].(float64); ok && keyResultID > 0 {
+		filter.KeyResultID = int64(keyResultID)
+	}
+	filter.ObjectiveID, _ = args["objective_id"].(string)
+	sphere, err := c.sphereFilter(ctx, userID, args)
+	if errors.Is(err, okr.ErrSphereNotFound) {
+		return fmt.Sprintf("❌ Сфера «%s» не найдена. Список сфер — «какие есть сферы?»", args["sphere"]), &GetTasksFunction, nil
 	}
+	if err != nil {
+		return "", &GetTasksFunction, err
+	}
+	filter.Sphere = sphere
+	limit, offset := listWindow(args)
 
-	rows, err := c.db.Query(query, params...)
+	tasks, total, err := c.okr.ListTaskSummaries(ctx, userID, filter, limit, offset)
 	if err != nil {
 		logrus.Errorf("Ошибка получения задач: %v", err)
 		return "❌ Не удалось получить задачи из базы данных", &GetTasksFunction, nil
 	}
-	defer rows.Close()
 
+	grouped := filter.ObjectiveID != "" && filter.KeyResultID == 0 && filter.DeadlineWithinDays == 0
 	response := "📋 **Твои задачи:**\n\n"
-	taskCount := 0
 	currentKR := ""
 
-	for rows.Next() {
-		var taskID int64
-		var title, unit, deadline, status, krTitle, objTitle string
-		var target, progress float64
-
-		err := rows.Scan(&taskID, &title, &target, &unit, &progress, &deadline, &status,
-			&krTitle, &objTitle)
-		if err != nil {
-			continue
-		}
-
-		taskCount++
-
-		if objectiveID != "" && krTitle != currentKR {
+	for _, task := range tasks {
+		if grouped && task.KeyResultTitle != currentKR {
 			if currentKR != "" {
 				response += "\n"
 			}
-			response += fmt.Sprintf("🔑 **%s**\n", krTitle)
-			currentKR = krTitle
+			response += fmt.Sprintf("🔑 **%s**\n", task.KeyResultTitle)
+			currentKR = task.KeyResultTitle
 		}
 
 		statusEmoji := "📋"
-		switch status {
+		switch task.Status {
 		case "completed":
 			statusEmoji = "✅"
 		case "paused":
@@ -1747,49 +2085,52 @@ func (c *ChatGPTService) handleGetTasks(args map[string]interface{}, userID int6
 			statusEmoji = "🔄"
 		}
 
-		completionPercent := (progress / target) * 100
+		completionPercent := 0.0
+		if task.Target > 0 {
+			completionPercent = (task.Progress / task.Target) * 100
+		}
 		if completionPercent > 100 {
 			completionPercent = 100
 		}
 
-		response += fmt.Sprintf("%s **%s**\n", statusEmoji, title)
+		deadline := "без дедлайна"
+		if task.Deadline != nil {
+			deadline = task.Deadline.Format("02.01.2006")
+		}
+		response += fmt.Sprintf("%s **%s**\n", statusEmoji, task.Title)
 		response += fmt.Sprintf("   📊 %.1f / %.1f %s (%.1f%%) | 📅 %s\n",
-			progress, target, unit, completionPercent, deadline)
-
-		if objectiveID == "" && hasKRID == false {
+			task.Progress, task.Target, task.Unit, completionPercent, deadline)
 
-			response += fmt.Sprintf("   🎯 %s → 🔑 %s\n", objTitle, krTitle)
+		if filter.ObjectiveID == "" && filter.KeyResultID == 0 {
+			response += fmt.Sprintf("   🎯 %s → 🔑 %s\n", task.ObjectiveTitle, task.KeyResultTitle)
 		}
 
 		response += "\n"
 	}
 
-	if taskCount == 0 {
+	if len(tasks) == 0 {
+		if total > 0 {
+			return fmt.Sprintf("📋 **Больше задач нет** — всего %d", total), &GetTasksFunction, nil
+		}
 		response = "📋 **Задач пока нет**\n\n"
-		if hasKRID && keyResultID > 0 {
+		if filter.KeyResultID > 0 {
 			response += "💡 Создай задачи для детализации ключевого результата!"
-		} else if objectiveID != "" {
+		} else if filter.ObjectiveID != "" {
 			response += "💡 Создай задачи для ключевых результатов этой цели!"
+		} else if filter.Sphere != "" || filter.DeadlineWithinDays > 0 {
+			response = "📋 **Под этот фильтр задач нет**"
 		} else {
 			response += "💡 Создай цели и разбей их на ключевые результаты и задачи!"
 		}
 	} else {
-		response += fmt.Sprintf("📈 **Всего задач:** %d", taskCount)
-
-		if taskCount >= 10 {
-			response += "\n🔥 Wow! Ты отлично детализируешь свои цели!"
-		} else if taskCount >= 5 {
-			response += "\n💪 Хорошая детализация целей!"
-		} else {
-			response += "\n🚀 Отличное начало!"
-		}
+		response += listFooter("задач", offset, len(tasks), total)
 	}
 
 	return response, &GetTasksFunction, nil
 }
 
 func (c *ChatGPTService) handleDeleteObjective(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Удаление цели для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Удаление цели для пользователя %d с аргументами: %+v", userID, args)
 
 	objectiveID, _ := args["objective_id"].(string)
 	objectiveDescription, _ := args["objective_description"].(string)
@@ -1800,45 +2141,43 @@ func (c *ChatGPTService) handleDeleteObjective(args map[string]interface{}, user
 	}
 
 	if objectiveID == "" && objectiveDescription != "" {
-		query := `SELECT id FROM objectives WHERE user_id = $1 AND LOWER(title) LIKE LOWER($2) ORDER BY created_at DESC LIMIT 1`
-		err := c.db.QueryRow(query, userID, "%"+objectiveDescription+"%").Scan(&objectiveID)
+		candidate, err := c.okr.ResolveObjective(context.Background(), userID, objectiveDescription)
 		if err != nil {
-			return "❌ Не найдена цель по описанию: " + objectiveDescription, &DeleteObjectiveFunction, nil
+			return unresolvedEntity(err, &DeleteObjectiveFunction, "❌ Не найдена цель по описанию: "+objectiveDescription)
 		}
+		objectiveID = candidate.ID
 	}
 
 	if objectiveID == "" {
 		return "❌ Не указана цель для удаления", &DeleteObjectiveFunction, nil
 	}
 
+	if err := ownership.MustOwnObjective(context.Background(), c.db, userID, objectiveID); err != nil {
+		return ownershipErrorMessage(err), &DeleteObjectiveFunction, nil
+	}
+
 	var objectiveTitle string
 	titleQuery := `SELECT title FROM objectives WHERE id = $1 AND user_id = $2`
 	err := c.db.QueryRow(titleQuery, objectiveID, userID).Scan(&objectiveTitle)
 	if err != nil {
-		return "❌ Цель не найдена или не принадлежит пользователю", &DeleteObjectiveFunction, nil
+		return "❌ Не удалось получить данные цели", &DeleteObjectiveFunction, nil
 	}
 
-	deleteQuery := `DELETE FROM objectives WHERE id = $1 AND user_id = $2`
-	result, err := c.db.Exec(deleteQuery, objectiveID, userID)
-	if err != nil {
+	if err := c.okr.DeleteObjective(context.Background(), userID, objectiveID); err != nil {
 		logrus.Errorf("Ошибка удаления цели: %v", err)
 		return "❌ Не удалось удалить цель из базы данных", &DeleteObjectiveFunction, nil
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return "❌ Цель не найдена", &DeleteObjectiveFunction, nil
-	}
-
 	response := fmt.Sprintf("🗑️ **Цель удалена!**\n\n")
 	response += fmt.Sprintf("📋 **Удаленная цель:** %s\n\n", objectiveTitle)
-	response += "⚠️ Все связанные ключевые результаты и задачи также удалены"
+	response += "⚠️ Все связанные ключевые результаты и задачи также удалены\n"
+	response += fmt.Sprintf("↩️ Передумал? Цель можно восстановить в течение %d дней: «восстанови цель»", okr.TrashRetentionDays)
 
 	return response, &DeleteObjectiveFunction, nil
 }
 
 func (c *ChatGPTService) handleDeleteKeyResult(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Удаление ключевого результата для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Удаление ключевого результата для пользователя %d с аргументами: %+v", userID, args)
 
 	keyResultID, hasID := args["key_result_id"].(float64)
 	keyResultDescription, _ := args["key_result_description"].(string)
@@ -1856,42 +2195,19 @@ func (c *ChatGPTService) handleDeleteKeyResult(args map[string]interface{}, user
 			return "❌ Не указан ID или описание ключевого результата", &DeleteKeyResultFunction, nil
 		}
 
-		var query string
-		var params []interface{}
-
-		if objectiveDescription != "" {
-
-			query = `
-				SELECT kr.id 
-				FROM key_results kr
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(kr.title) LIKE LOWER($2)
-				AND LOWER(o.title) LIKE LOWER($3)
-				ORDER BY kr.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + keyResultDescription + "%", "%" + objectiveDescription + "%"}
-		} else {
-
-			query = `
-				SELECT kr.id 
-				FROM key_results kr
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(kr.title) LIKE LOWER($2)
-				ORDER BY kr.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + keyResultDescription + "%"}
-		}
-
-		err := c.db.QueryRow(query, params...).Scan(&finalKeyResultID)
+		candidate, err := c.okr.ResolveKeyResult(context.Background(), userID, keyResultDescription, objectiveDescription)
 		if err != nil {
-			return "❌ Не найден ключевой результат по описанию: " + keyResultDescription, &DeleteKeyResultFunction, nil
+			return unresolvedEntity(err, &DeleteKeyResultFunction, "❌ Не найден ключевой результат по описанию: "+keyResultDescription)
 		}
+		finalKeyResultID = candidate.NumericID()
 	} else {
 		finalKeyResultID = int64(keyResultID)
 	}
 
+	if err := ownership.MustOwnKeyResult(context.Background(), c.db, userID, finalKeyResultID); err != nil {
+		return ownershipErrorMessage(err), &DeleteKeyResultFunction, nil
+	}
+
 	var krTitle, objectiveTitle string
 	titleQuery := `
 		SELECT kr.title, o.title
@@ -1901,31 +2217,25 @@ func (c *ChatGPTService) handleDeleteKeyResult(args map[string]interface{}, user
 	`
 	err := c.db.QueryRow(titleQuery, finalKeyResultID, userID).Scan(&krTitle, &objectiveTitle)
 	if err != nil {
-		return "❌ Ключевой результат не найден или не принадлежит пользователю", &DeleteKeyResultFunction, nil
+		return "❌ Не удалось получить данные ключевого результата", &DeleteKeyResultFunction, nil
 	}
 
-	deleteQuery := `DELETE FROM key_results WHERE id = $1`
-	result, err := c.db.Exec(deleteQuery, finalKeyResultID)
-	if err != nil {
+	if err := c.okr.DeleteKeyResult(context.Background(), userID, finalKeyResultID); err != nil {
 		logrus.Errorf("Ошибка удаления ключевого результата: %v", err)
 		return "❌ Не удалось удалить ключевой результат", &DeleteKeyResultFunction, nil
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return "❌ Ключевой результат не найден", &DeleteKeyResultFunction, nil
-	}
-
 	response := fmt.Sprintf("🗑️ **Ключевой результат удален!**\n\n")
 	response += fmt.Sprintf("🔑 **Удаленный KR:** %s\n", krTitle)
 	response += fmt.Sprintf("🎯 **Цель:** %s\n\n", objectiveTitle)
-	response += "⚠️ Все связанные задачи также удалены"
+	response += "⚠️ Все связанные задачи также удалены\n"
+	response += fmt.Sprintf("↩️ Восстановить можно в течение %d дней: «верни удаленный ключевой результат»", okr.TrashRetentionDays)
 
 	return response, &DeleteKeyResultFunction, nil
 }
 
 func (c *ChatGPTService) handleDeleteTask(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
-	logrus.Infof("Удаление задачи для пользователя %d с аргументами: %+v", userID, args)
+	logrus.Debugf("Удаление задачи для пользователя %d с аргументами: %+v", userID, args)
 
 	taskID, hasID := args["task_id"].(float64)
 	taskDescription, _ := args["task_description"].(string)
@@ -1943,44 +2253,19 @@ func (c *ChatGPTService) handleDeleteTask(args map[string]interface{}, userID in
 			return "❌ Не указан ID или описание задачи", &DeleteTaskFunction, nil
 		}
 
-		var query string
-		var params []interface{}
-
-		if keyResultDescription != "" {
-
-			query = `
-				SELECT t.id 
-				FROM tasks t
-				JOIN key_results kr ON t.key_result_id = kr.id
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(t.title) LIKE LOWER($2)
-				AND LOWER(kr.title) LIKE LOWER($3)
-				ORDER BY t.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + taskDescription + "%", "%" + keyResultDescription + "%"}
-		} else {
-
-			query = `
-				SELECT t.id 
-				FROM tasks t
-				JOIN key_results kr ON t.key_result_id = kr.id
-				JOIN objectives o ON kr.objective_id = o.id
-				WHERE o.user_id = $1 
-				AND LOWER(t.title) LIKE LOWER($2)
-				ORDER BY t.created_at DESC LIMIT 1
-			`
-			params = []interface{}{userID, "%" + taskDescription + "%"}
-		}
-
-		err := c.db.QueryRow(query, params...).Scan(&finalTaskID)
+		candidate, err := c.okr.ResolveTask(context.Background(), userID, taskDescription, keyResultDescription)
 		if err != nil {
-			return "❌ Не найдена задача по описанию: " + taskDescription, &DeleteTaskFunction, nil
+			return unresolvedEntity(err, &DeleteTaskFunction, "❌ Не найдена задача по описанию: "+taskDescription)
 		}
+		finalTaskID = candidate.NumericID()
 	} else {
 		finalTaskID = int64(taskID)
 	}
 
+	if err := ownership.MustOwnTask(context.Background(), c.db, userID, finalTaskID); err != nil {
+		return ownershipErrorMessage(err), &DeleteTaskFunction, nil
+	}
+
 	var taskTitle, krTitle, objectiveTitle string
 	titleQuery := `
 		SELECT t.title, kr.title, o.title
@@ -1991,25 +2276,34 @@ func (c *ChatGPTService) handleDeleteTask(args map[string]interface{}, userID in
 	`
 	err := c.db.QueryRow(titleQuery, finalTaskID, userID).Scan(&taskTitle, &krTitle, &objectiveTitle)
 	if err != nil {
-		return "❌ Задача не найдена или не принадлежит пользователю", &DeleteTaskFunction, nil
+		return "❌ Не удалось получить данные задачи", &DeleteTaskFunction, nil
 	}
 
-	deleteQuery := `DELETE FROM tasks WHERE id = $1`
-	result, err := c.db.Exec(deleteQuery, finalTaskID)
-	if err != nil {
+	if err := c.okr.DeleteTask(context.Background(), userID, finalTaskID); err != nil {
 		logrus.Errorf("Ошибка удаления задачи: %v", err)
 		return "❌ Не удалось удалить задачу", &DeleteTaskFunction, nil
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return "❌ Задача не найдена", &DeleteTaskFunction, nil
-	}
-
 	response := fmt.Sprintf("🗑️ **Задача удалена!**\n\n")
 	response += fmt.Sprintf("📝 **Удаленная задача:** %s\n", taskTitle)
 	response += fmt.Sprintf("🔑 **Ключевой результат:** %s\n", krTitle)
-	response += fmt.Sprintf("🎯 **Цель:** %s", objectiveTitle)
+	response += fmt.Sprintf("🎯 **Цель:** %s\n\n", objectiveTitle)
+	response += fmt.Sprintf("↩️ Восстановить можно в течение %d дней: «верни удаленную задачу»", okr.TrashRetentionDays)
 
 	return response, &DeleteTaskFunction, nil
 }
+
+func ownershipErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ownership.ErrObjectiveNotOwned):
+		return "❌ Цель не найдена или не принадлежит пользователю"
+	case errors.Is(err, ownership.ErrKeyResultNotOwned):
+		return "❌ Ключевой результат не найден или не принадлежит пользователю"
+	case errors.Is(err, ownership.ErrTaskNotOwned):
+		return "❌ Задача не найдена или не принадлежит пользователю"
+	case errors.Is(err, ownership.ErrEventNotOwned):
+		return "❌ Событие не найдено или не принадлежит пользователю"
+	}
+	logrus.Errorf("Ошибка проверки владельца: %v", err)
+	return "❌ Не удалось проверить доступ, попробуйте позже"
+}
@@ -0,0 +1,961 @@
+package chatgpt
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/wellbeing"
+	"telegrambot/pkg/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/sashabaranov/go-openai"
+)
+
+const scenarioUserID = int64(555)
+
+type scenario struct {
+	name		string
+	call		openai.FunctionCall
+	expect		func(m sqlmock.Sqlmock)
+	reply		[]string
+	notInReply	[]string
+}
+
+func recorded(name, arguments string) openai.FunctionCall {
+	return openai.FunctionCall{Name: name, Arguments: arguments}
+}
+
+func q(query string) string {
+	return regexp.QuoteMeta(query)
+}
+
+type timeArg time.Time
+
+func (t timeArg) Match(v driver.Value) bool {
+	got, ok := v.(time.Time)
+	return ok && got.Equal(time.Time(t))
+}
+
+var (
+	planCreated	= time.Now().Add(-10 * time.Minute)
+	planApplied	= time.Now().Add(-time.Hour)
+	movedOldStart	= time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	movedOldEnd	= movedOldStart.Add(time.Hour)
+	movedNewStart	= time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	movedNewEnd	= movedNewStart.Add(time.Hour)
+	cancelledStart	= time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cancelledEnd	= cancelledStart.Add(30 * time.Minute)
+	doctorStart	= time.Date(2026, 10, 20, 9, 0, 0, 0, time.Local)
+)
+
+func planChanges(t *testing.T) []byte {
+	t.Helper()
+	raw, err := json.Marshal([]calendar.ScheduleChange{
+		{Kind: calendar.ChangeMoveEvent, EventID: "ev-1", Title: "Отчет", OldStart: movedOldStart, OldEnd: movedOldEnd, NewStart: &movedNewStart, NewEnd: &movedNewEnd},
+		{Kind: calendar.ChangeCancelEvent, EventID: "ev-2", Title: "Созвон", GoogleEventID: "g-2", OldStart: cancelledStart, OldEnd: cancelledEnd},
+	})
+	if err != nil {
+		t.Fatalf("не удалось подготовить план переноса: %v", err)
+	}
+	return raw
+}
+
+func planRows(raw []byte, status string, appliedAt interface{}, createdAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "request", "status", "created_at", "applied_at", "changes"}).
+		AddRow(int64(7), scenarioUserID, "разгрузи пятницу", status, createdAt, appliedAt, raw)
+}
+
+func draftRow(fields string, version int, via string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"user_id", "fields", "version", "updated_via", "updated_at"}).
+		AddRow(scenarioUserID, []byte(fields), version, via, time.Now())
+}
+
+func noDraft() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"user_id", "fields", "version", "updated_via", "updated_at"})
+}
+
+func busyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"kind", "id", "title", "start_time", "end_time"})
+}
+
+func eventRow(id, title, description string, start, end time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "title", "description", "start_time", "end_time", "created_at",
+		"google_event_id", "updated_at", "google_updated_at", "google_sync_pending"}).
+		AddRow(id, scenarioUserID, title, description, start, end, time.Now(), "", time.Now(), nil, false)
+}
+
+func transactionRow(id string, amount float64, details, category string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "amount", "details", "category", "space_id", "objective_id", "key_result_id", "created_at"}).
+		AddRow(id, scenarioUserID, amount, details, category, nil, nil, nil, time.Now())
+}
+
+func partnershipRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "partner_id", "status", "created_at", "other_id", "other_name"})
+}
+
+func membershipRow(role string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"space_id", "user_id", "role", "status", "invited_by", "username", "first_name", "created_at"}).
+		AddRow("space-1", scenarioUserID, role, "active", nil, "anna", "Анна", time.Now())
+}
+
+func exists(value bool) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"exists"}).AddRow(value)
+}
+
+func okrScenarios() []scenario {
+	const marathon = `{"title":"Пробежать марафон","sphere":"Здоровье","period":"quarter","deadline":"2027-06-30",` +
+		`"key_results":[{"title":"Пробежать 500 км","target":500,"unit":"км","deadline":"2027-06-30"}]}`
+	return []scenario{
+		{
+			name:	"create objective in one call",
+			call:	recorded("create_objective", marathon),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).WithArgs(scenarioUserID).WillReturnRows(noDraft())
+				m.ExpectBegin()
+				m.ExpectExec(q("INSERT INTO objectives")).
+					WithArgs(sqlmock.AnyArg(), scenarioUserID, "Пробежать марафон", "Здоровье", "quarter", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectQuery(q("INSERT INTO key_results")).
+					WithArgs(sqlmock.AnyArg(), "Пробежать 500 км", 500.0, "км", 0.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(11)))
+				m.ExpectCommit()
+			},
+			reply:		[]string{"Цель успешно создана", "**Название:** Пробежать марафон", "**Ключевые результаты:** 1 создано"},
+			notInReply:	[]string{"Черновик"},
+		},
+		{
+			name:	"create objective keeps a draft for missing fields",
+			call:	recorded("create_objective", `{"title":"Выучить испанский"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).WithArgs(scenarioUserID).WillReturnRows(noDraft())
+				m.ExpectQuery(q("INSERT INTO objective_drafts")).
+					WithArgs(scenarioUserID, `{"title":"Выучить испанский"}`, okr.DraftViaTelegram).
+					WillReturnRows(draftRow(`{"title":"Выучить испанский"}`, 1, okr.DraftViaTelegram))
+			},
+			reply:	[]string{"Черновик цели сохранен (версия 1)", "Осталось указать: сферу, период, дедлайн"},
+		},
+		{
+			name:	"create objective finishes the draft by version",
+			call:	recorded("create_objective", `{"sphere":"Образование","period":"year","deadline":"2027-12-31","draft_version":1}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(draftRow(`{"title":"Выучить испанский"}`, 1, okr.DraftViaTelegram))
+				m.ExpectExec(q("DELETE FROM objective_drafts")).
+					WithArgs(scenarioUserID, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectBegin()
+				m.ExpectExec(q("INSERT INTO objectives")).
+					WithArgs(sqlmock.AnyArg(), scenarioUserID, "Выучить испанский", "Образование", "year", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			reply:	[]string{"Цель успешно создана", "**Название:** Выучить испанский", "**Ключевые результаты:** 0 создано"},
+		},
+		{
+			name:	"create objective continues the draft with the same title",
+			call:	recorded("create_objective", `{"title":"выучить испанский","deadline":"2027-12-31"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(draftRow(`{"title":"Выучить испанский","sphere":"Образование"}`, 2, okr.DraftViaTelegram))
+				m.ExpectQuery(q("UPDATE objective_drafts")).
+					WithArgs(scenarioUserID, 2, `{"deadline":"2027-12-31","sphere":"Образование","title":"выучить испанский"}`, okr.DraftViaTelegram).
+					WillReturnRows(draftRow(`{"deadline":"2027-12-31","sphere":"Образование","title":"выучить испанский"}`, 3, okr.DraftViaTelegram))
+			},
+			reply:	[]string{"Черновик цели сохранен (версия 3)", "Осталось указать: период."},
+		},
+		{
+			name:	"create objective with a new title replaces the stale draft",
+			call:	recorded("create_objective", `{"title":"Пробежать марафон"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(draftRow(`{"title":"Выучить испанский","sphere":"Образование","period":"year"}`, 2, okr.DraftViaTelegram))
+				m.ExpectQuery(q("UPDATE objective_drafts")).
+					WithArgs(scenarioUserID, 2, `{"title":"Пробежать марафон"}`, okr.DraftViaTelegram).
+					WillReturnRows(draftRow(`{"title":"Пробежать марафон"}`, 3, okr.DraftViaTelegram))
+			},
+			reply:	[]string{"Черновик цели сохранен (версия 3)", "Осталось указать: сферу, период, дедлайн"},
+		},
+		{
+			name:	"create objective in one call drops an unrelated draft",
+			call:	recorded("create_objective", marathon),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(draftRow(`{"title":"Выучить испанский","sphere":"Образование"}`, 4, okr.DraftViaTelegram))
+				m.ExpectExec(q("DELETE FROM objective_drafts")).
+					WithArgs(scenarioUserID, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectBegin()
+				m.ExpectExec(q("INSERT INTO objectives")).
+					WithArgs(sqlmock.AnyArg(), scenarioUserID, "Пробежать марафон", "Здоровье", "quarter", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectQuery(q("INSERT INTO key_results")).
+					WithArgs(sqlmock.AnyArg(), "Пробежать 500 км", 500.0, "км", 0.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(11)))
+				m.ExpectCommit()
+			},
+			reply:		[]string{"Цель успешно создана", "**Название:** Пробежать марафон"},
+			notInReply:	[]string{"испанский"},
+		},
+		{
+			name:	"create objective does not overwrite a draft edited on the web",
+			call:	recorded("create_objective", `{"sphere":"Образование","period":"year","deadline":"2027-12-31","draft_version":1}`),
+			expect: func(m sqlmock.Sqlmock) {
+				web := `{"title":"Выучить испанский","sphere":"Саморазвитие","period":"quarter"}`
+				m.ExpectQuery(q("FROM objective_drafts")).WithArgs(scenarioUserID).WillReturnRows(draftRow(web, 2, okr.DraftViaWeb))
+				m.ExpectQuery(q("FROM objective_drafts")).WithArgs(scenarioUserID).WillReturnRows(draftRow(web, 2, okr.DraftViaWeb))
+			},
+			reply: []string{
+				"Черновик цели изменили в веб-приложении",
+				"Сейчас в черновике (версия 2)",
+				"• Сфера: Саморазвитие",
+				"• Период: квартал",
+				"• Дедлайн: —",
+			},
+			notInReply:	[]string{"Цель успешно создана", "Образование"},
+		},
+		{
+			name:	"create objective after the draft was finished on the web",
+			call:	recorded("create_objective", `{"deadline":"2027-12-31","draft_version":3}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM objective_drafts")).WithArgs(scenarioUserID).WillReturnRows(noDraft())
+				m.ExpectQuery(q("FROM objective_drafts")).WithArgs(scenarioUserID).WillReturnRows(noDraft())
+			},
+			reply:	[]string{"Черновик цели уже завершили или удалили в веб-приложении"},
+		},
+		{
+			name:	"add key result progress credits today's task",
+			call:	recorded("add_key_result_progress", `{"key_result_id":11,"progress":5}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT kr.id")).
+					WithArgs(int64(11), scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(11)))
+				m.ExpectQuery(q("SELECT kr.title, kr.target")).
+					WithArgs(int64(11)).
+					WillReturnRows(sqlmock.NewRows([]string{"title", "target", "unit", "progress", "objective_title"}).
+						AddRow("Пробежать 500 км", 500.0, "км", 120.0, "Пробежать марафон"))
+				m.ExpectBegin()
+				m.ExpectExec(q("SELECT set_config('jarvis.actor_id'")).
+					WithArgs(strconv.FormatInt(scenarioUserID, 10)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("UPDATE key_results")).
+					WithArgs(125.0, int64(11)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+				m.ExpectQuery(q("FROM tasks t")).
+					WithArgs(int64(11), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "target", "progress", "unit", "completed"}).
+						AddRow(int64(70), "Пробежка (16.10.2026)", 5.0, 0.0, "км", false))
+				m.ExpectQuery(q("UPDATE tasks")).
+					WithArgs(int64(70), 5.0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "target", "progress", "unit", "completed"}).
+						AddRow(int64(70), "Пробежка (16.10.2026)", 5.0, 5.0, "км", true))
+			},
+			reply: []string{
+				"Прогресс обновлен",
+				"**Текущий прогресс:** 125.0 / 500.0 км (25.0%)",
+				"✅ Задача на сегодня «Пробежка (16.10.2026)» отмечена выполненной",
+			},
+		},
+		{
+			name:	"delete objective",
+			call:	recorded("delete_objective", `{"objective_id":"obj-1","confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT EXISTS (SELECT 1 FROM objectives")).
+					WithArgs("obj-1", scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectQuery(q("SELECT title FROM objectives")).
+					WithArgs("obj-1", scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Пробежать марафон"))
+				m.ExpectQuery(q("SELECT EXISTS (SELECT 1 FROM objectives")).
+					WithArgs("obj-1", scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectBegin()
+				m.ExpectExec(q("UPDATE tasks SET deleted_at")).WithArgs("obj-1").WillReturnResult(sqlmock.NewResult(0, 3))
+				m.ExpectExec(q("UPDATE key_results SET deleted_at")).WithArgs("obj-1").WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("UPDATE objectives SET deleted_at")).WithArgs("obj-1", scenarioUserID).WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			reply:	[]string{"Цель удалена", "**Удаленная цель:** Пробежать марафон"},
+		},
+		{
+			name:	"delete another user's objective",
+			call:	recorded("delete_objective", `{"objective_id":"obj-2","confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT EXISTS (SELECT 1 FROM objectives")).
+					WithArgs("obj-2", scenarioUserID).
+					WillReturnRows(exists(false))
+			},
+			reply:	[]string{"❌ Цель не найдена или не принадлежит пользователю"},
+		},
+		{
+			name:	"delete objective without confirmation",
+			call:	recorded("delete_objective", `{"objective_id":"obj-1"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"необходимо подтверждение"},
+		},
+		{
+			name:	"update objective",
+			call:	recorded("update_objective", `{"objective_id":"obj-1","title":"Пробежать полумарафон","deadline":"2027-03-31"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT EXISTS (SELECT 1 FROM objectives")).
+					WithArgs("obj-1", scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectExec(q("UPDATE objectives")).
+					WithArgs("Пробежать полумарафон", nil, nil, timeArg(time.Date(2027, 3, 31, 0, 0, 0, 0, time.Local)), "obj-1", scenarioUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:		[]string{"Цель обновлена", "**Название:** Пробежать полумарафон", "**Дедлайн:** 2027-03-31"},
+			notInReply:	[]string{"Сфера", "Период"},
+		},
+		{
+			name:	"update another user's objective",
+			call:	recorded("update_objective", `{"objective_id":"obj-2","title":"Чужая цель"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT EXISTS (SELECT 1 FROM objectives")).
+					WithArgs("obj-2", scenarioUserID).
+					WillReturnRows(exists(false))
+			},
+			reply:	[]string{"❌ Цель не найдена или не принадлежит пользователю"},
+		},
+		{
+			name:	"update objective with a bad deadline",
+			call:	recorded("update_objective", `{"objective_id":"obj-1","deadline":"31.03.2027"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Неверный формат дедлайна цели"},
+		},
+		{
+			name:	"update objective with an unknown period",
+			call:	recorded("update_objective", `{"objective_id":"obj-1","period":"decade"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Период цели должен быть одним из"},
+		},
+		{
+			name:	"update objective without changes",
+			call:	recorded("update_objective", `{"objective_id":"obj-1"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указано, что изменить в цели"},
+		},
+		{
+			name:	"update key result",
+			call:	recorded("update_key_result", `{"key_result_id":11,"unit":"километры"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT 1 FROM key_results kr")).
+					WithArgs(int64(11), scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectBegin()
+				m.ExpectQuery(q("SELECT kr.target")).
+					WithArgs(int64(11), scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"target"}).AddRow(500.0))
+				m.ExpectExec(q("UPDATE key_results")).
+					WithArgs(nil, "километры", nil, nil, nil, int64(11)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			reply:		[]string{"Ключевой результат обновлен", "**Единица измерения:** километры"},
+			notInReply:	[]string{"Название", "Дедлайн"},
+		},
+		{
+			name:	"update key result without id",
+			call:	recorded("update_key_result", `{"title":"Пробежать 600 км"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указан ID или описание ключевого результата"},
+		},
+		{
+			name:	"update key result with a bad deadline",
+			call:	recorded("update_key_result", `{"key_result_id":11,"deadline":"скоро"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Неверный формат дедлайна ключевого результата"},
+		},
+		{
+			name:	"update key result without changes",
+			call:	recorded("update_key_result", `{"key_result_id":11}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указано, что изменить в ключевом результате"},
+		},
+		{
+			name:	"delete key result",
+			call:	recorded("delete_key_result", `{"key_result_id":11,"confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT 1 FROM key_results kr")).
+					WithArgs(int64(11), scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectQuery(q("SELECT kr.title, o.title")).
+					WithArgs(int64(11), scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"kr_title", "objective_title"}).AddRow("Пробежать 500 км", "Пробежать марафон"))
+				m.ExpectQuery(q("SELECT 1 FROM key_results kr")).
+					WithArgs(int64(11), scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectBegin()
+				m.ExpectExec(q("UPDATE tasks SET deleted_at")).WithArgs(int64(11)).WillReturnResult(sqlmock.NewResult(0, 2))
+				m.ExpectExec(q("UPDATE key_results kr SET deleted_at")).WithArgs(int64(11), scenarioUserID).WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			reply:	[]string{"Ключевой результат удален", "**Удаленный KR:** Пробежать 500 км", "**Цель:** Пробежать марафон"},
+		},
+		{
+			name:	"delete key result without confirmation",
+			call:	recorded("delete_key_result", `{"key_result_id":11}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"необходимо подтверждение"},
+		},
+		{
+			name:	"delete key result without id",
+			call:	recorded("delete_key_result", `{"confirm":true}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указан ID или описание ключевого результата"},
+		},
+		{
+			name:	"delete task",
+			call:	recorded("delete_task", `{"task_id":70,"confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT 1 FROM tasks t")).
+					WithArgs(int64(70), scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectQuery(q("SELECT t.title, kr.title, o.title")).
+					WithArgs(int64(70), scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"task_title", "kr_title", "objective_title"}).
+						AddRow("Пробежка (16.10.2026)", "Пробежать 500 км", "Пробежать марафон"))
+				m.ExpectQuery(q("SELECT 1 FROM tasks t")).
+					WithArgs(int64(70), scenarioUserID).
+					WillReturnRows(exists(true))
+				m.ExpectExec(q("UPDATE tasks t SET deleted_at")).WithArgs(int64(70), scenarioUserID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"Задача удалена", "**Удаленная задача:** Пробежка (16.10.2026)"},
+		},
+		{
+			name:	"delete another user's task",
+			call:	recorded("delete_task", `{"task_id":71,"confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT 1 FROM tasks t")).
+					WithArgs(int64(71), scenarioUserID).
+					WillReturnRows(exists(false))
+			},
+			reply:	[]string{"❌ Задача не найдена или не принадлежит пользователю"},
+		},
+		{
+			name:	"delete task without id",
+			call:	recorded("delete_task", `{"confirm":true}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указан ID или описание задачи"},
+		},
+	}
+}
+
+func calendarScenarios(t *testing.T) []scenario {
+	changes := planChanges(t)
+	return []scenario{
+		{
+			name:	"apply reschedule plan",
+			call:	recorded("reschedule_day", `{"confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(q("FROM reschedule_plans")).
+					WithArgs(scenarioUserID, calendar.PlanStatusProposed).
+					WillReturnRows(planRows(changes, calendar.PlanStatusProposed, nil, planCreated))
+				m.ExpectExec(q("UPDATE events")).
+					WithArgs("ev-1", scenarioUserID, timeArg(movedNewStart), timeArg(movedNewEnd), timeArg(movedOldStart)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("DELETE FROM events")).
+					WithArgs("ev-2", scenarioUserID, timeArg(cancelledStart)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("INSERT INTO google_event_deletions")).
+					WithArgs(scenarioUserID, "g-2").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("UPDATE reschedule_plans SET status")).
+					WithArgs(int64(7), calendar.PlanStatusApplied).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			reply:	[]string{"✅ **План применен**, изменений: 2"},
+		},
+		{
+			name:	"apply reschedule plan after the calendar changed",
+			call:	recorded("reschedule_day", `{"confirm":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(q("FROM reschedule_plans")).
+					WithArgs(scenarioUserID, calendar.PlanStatusProposed).
+					WillReturnRows(planRows(changes, calendar.PlanStatusProposed, nil, planCreated))
+				m.ExpectExec(q("UPDATE events")).
+					WithArgs("ev-1", scenarioUserID, timeArg(movedNewStart), timeArg(movedNewEnd), timeArg(movedOldStart)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectRollback()
+			},
+			reply:	[]string{"❌ " + calendar.ErrPlanOutdated.Error()},
+		},
+		{
+			name:	"undo reschedule restores cancelled and moved events",
+			call:	recorded("undo_reschedule", `{}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(q("FROM reschedule_plans")).
+					WithArgs(scenarioUserID, calendar.PlanStatusApplied).
+					WillReturnRows(planRows(changes, calendar.PlanStatusApplied, planApplied, planCreated))
+				m.ExpectExec(q("DELETE FROM google_event_deletions")).
+					WithArgs(scenarioUserID, "g-2").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("INSERT INTO events")).
+					WithArgs("ev-2", scenarioUserID, "Созвон", "", timeArg(cancelledStart), timeArg(cancelledEnd), "g-2", false).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("UPDATE events")).
+					WithArgs("ev-1", scenarioUserID, timeArg(movedOldStart), timeArg(movedOldEnd)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("UPDATE reschedule_plans SET status")).
+					WithArgs(int64(7), calendar.PlanStatusUndone).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectCommit()
+			},
+			reply:	[]string{"↩️ **Расписание восстановлено**, возвращено изменений: 2"},
+		},
+		{
+			name:	"undo reschedule without an applied plan",
+			call:	recorded("undo_reschedule", `{}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery(q("FROM reschedule_plans")).
+					WithArgs(scenarioUserID, calendar.PlanStatusApplied).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				m.ExpectRollback()
+			},
+			reply:	[]string{"ℹ️ " + calendar.ErrNothingToUndo.Error()},
+		},
+		{
+			name:	"create event",
+			call:	recorded("create_event", `{"title":"Врач","description":"Взять анализы","start_time":"2026-10-20T09:00:00"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT 'event' AS kind")).
+					WithArgs(scenarioUserID, timeArg(doctorStart), timeArg(doctorStart.Add(time.Hour))).
+					WillReturnRows(busyRows())
+				m.ExpectExec(q("INSERT INTO events")).
+					WithArgs(sqlmock.AnyArg(), scenarioUserID, "Врач", "Взять анализы", timeArg(doctorStart), timeArg(doctorStart.Add(time.Hour)), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"Событие создано", "📋 **Врач**", "🕒 20.10 09:00–10:00", "📝 Взять анализы"},
+		},
+		{
+			name:	"create event in a busy slot",
+			call:	recorded("create_event", `{"title":"Врач","start_time":"2026-10-20T09:00:00","end_time":"2026-10-20T09:30:00"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT 'event' AS kind")).
+					WithArgs(scenarioUserID, timeArg(doctorStart), timeArg(doctorStart.Add(30*time.Minute))).
+					WillReturnRows(busyRows().AddRow(calendar.BusyEvent, "ev-3", "Планерка", doctorStart, doctorStart.Add(time.Hour)))
+			},
+			reply:		[]string{"⚠️ На 20.10 09:00–09:30 уже запланировано", "Планерка"},
+			notInReply:	[]string{"Событие создано"},
+		},
+		{
+			name:	"create event with a bad start time",
+			call:	recorded("create_event", `{"title":"Врач","start_time":"завтра"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Некорректное время начала"},
+		},
+		{
+			name:	"create event that ends before it starts",
+			call:	recorded("create_event", `{"title":"Врач","start_time":"2026-10-20T09:00:00","end_time":"2026-10-20T08:00:00"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Событие должно заканчиваться позже, чем начинается"},
+		},
+		{
+			name:	"update event keeps its duration",
+			call:	recorded("update_event", `{"event_id":"ev-1","start_time":"2026-10-20T11:00:00"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("COALESCE(google_event_id")).
+					WithArgs("ev-1", scenarioUserID).
+					WillReturnRows(eventRow("ev-1", "Врач", "Взять анализы", doctorStart, doctorStart.Add(time.Hour)))
+				m.ExpectQuery(q("COALESCE(google_event_id")).
+					WithArgs("ev-1", scenarioUserID).
+					WillReturnRows(eventRow("ev-1", "Врач", "Взять анализы", doctorStart, doctorStart.Add(time.Hour)))
+				m.ExpectExec(q("UPDATE events")).
+					WithArgs("Врач", "Взять анализы", timeArg(doctorStart.Add(2*time.Hour)), timeArg(doctorStart.Add(3*time.Hour)), "ev-1", scenarioUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(q("UPDATE event_attendees")).
+					WithArgs("ev-1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			reply:	[]string{"Событие обновлено", "🕒 20.10 11:00–12:00 (было 20.10 09:00–10:00)"},
+		},
+		{
+			name:	"update event without changes",
+			call:	recorded("update_event", `{"event_id":"ev-1"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указано, что изменить в событии"},
+		},
+		{
+			name:	"update event with a bad end time",
+			call:	recorded("update_event", `{"event_id":"ev-1","end_time":"в обед"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Некорректное время окончания"},
+		},
+		{
+			name:	"update another user's event",
+			call:	recorded("update_event", `{"event_id":"ev-9","title":"Чужое"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("COALESCE(google_event_id")).
+					WithArgs("ev-9", scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			reply:	[]string{"❌ Событие не найдено или не принадлежит пользователю"},
+		},
+		{
+			name:	"delete event by title",
+			call:	recorded("delete_event", `{"event_title":"врач","event_date":"2026-10-20"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("SELECT id, user_id, title, description, start_time, end_time, created_at")).
+					WithArgs(scenarioUserID, timeArg(doctorStart.Add(-9*time.Hour)), timeArg(doctorStart.Add(15*time.Hour))).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "description", "start_time", "end_time", "created_at"}).
+						AddRow("ev-2", scenarioUserID, "Врач", "", doctorStart, doctorStart.Add(time.Hour), time.Now()).
+						AddRow("ev-3", scenarioUserID, "Планерка", "", doctorStart.Add(2*time.Hour), doctorStart.Add(3*time.Hour), time.Now()))
+				m.ExpectQuery(q("COALESCE(google_event_id")).
+					WithArgs("ev-2", scenarioUserID).
+					WillReturnRows(eventRow("ev-2", "Врач", "", doctorStart, doctorStart.Add(time.Hour)))
+				m.ExpectExec(q("DELETE FROM events")).
+					WithArgs("ev-2", scenarioUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:		[]string{"🗑️ Событие «Врач» (20.10 09:00–10:00) удалено"},
+			notInReply:	[]string{"Планерка"},
+		},
+		{
+			name:	"delete event without id or title",
+			call:	recorded("delete_event", `{}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указан ID или название события"},
+		},
+		{
+			name:	"delete event with a bad date",
+			call:	recorded("delete_event", `{"event_title":"Врач","event_date":"20.10"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Некорректная дата события"},
+		},
+	}
+}
+
+func financeScenarios() []scenario {
+	return []scenario{
+		{
+			name:	"add transaction",
+			call:	recorded("add_transaction", `{"amount":-450,"category":"Кафе","details":"Обед"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectExec(q("INSERT INTO transactions")).
+					WithArgs(sqlmock.AnyArg(), scenarioUserID, -450.0, "Обед", "Кафе", nil, nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"Операция записана", "💸 Расход: 450.00 (Кафе)", "📝 Обед"},
+		},
+		{
+			name:	"add transaction without amount",
+			call:	recorded("add_transaction", `{"category":"Кафе"}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указана сумма операции"},
+		},
+		{
+			name:	"update shared budget",
+			call:	recorded("set_shared_budget", `{"category":"продукты","monthly_limit":15000}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM finance_space_members m")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(membershipRow("owner"))
+				m.ExpectQuery(q("SELECT name FROM finance_space_categories")).
+					WithArgs("space-1", "продукты").
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Продукты"))
+				m.ExpectExec(q("INSERT INTO finance_budgets")).
+					WithArgs("space-1", "Продукты", 15000.0).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"📊 Лимит для категории «продукты»: 15000.00 в месяц"},
+		},
+		{
+			name:	"delete shared budget",
+			call:	recorded("set_shared_budget", `{"category":"Кафе","monthly_limit":0}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM finance_space_members m")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(membershipRow("owner"))
+				m.ExpectExec(q("DELETE FROM finance_budgets")).
+					WithArgs("space-1", "Кафе").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"🗑️ Лимит для категории «Кафе» удален"},
+		},
+		{
+			name:	"viewer cannot change shared budget",
+			call:	recorded("set_shared_budget", `{"category":"Кафе","monthly_limit":5000}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM finance_space_members m")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(membershipRow("viewer"))
+			},
+			reply:	[]string{"🔒 Недостаточно прав для этого действия"},
+		},
+		{
+			name:	"delete last transaction",
+			call:	recorded("delete_transaction", `{"last":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM transactions")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(transactionRow("tx-1", -450, "Обед", "Кафе"))
+				m.ExpectExec(q("DELETE FROM transactions")).
+					WithArgs("tx-1", scenarioUserID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"Операция удалена", "💸 Расход: 450.00 (Кафе)", "📝 Обед"},
+		},
+		{
+			name:	"delete last transaction when there are none",
+			call:	recorded("delete_transaction", `{"last":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM transactions")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			reply:	[]string{"ℹ️ Записанных операций пока нет"},
+		},
+		{
+			name:	"delete another user's transaction",
+			call:	recorded("delete_transaction", `{"transaction_id":"tx-9"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM transactions")).
+					WithArgs("tx-9", scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			reply:	[]string{"❌ Операция не найдена или не принадлежит пользователю"},
+		},
+		{
+			name:	"delete transaction without id",
+			call:	recorded("delete_transaction", `{}`),
+			expect:	func(m sqlmock.Sqlmock) {},
+			reply:	[]string{"❌ Не указан ID операции"},
+		},
+	}
+}
+
+func coachingScenarios() []scenario {
+	return []scenario{
+		{
+			name:	"suggest a longer break when tired and stressed",
+			call:	recorded("suggest_break", `{"energy_level":2}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM focus_sessions")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "label", "started_at", "ended_at", "planned_minutes", "duration_minutes"}).
+						AddRow(int64(3), scenarioUserID, nil, time.Now().Add(-100*time.Minute-30*time.Second), nil, nil, nil))
+				m.ExpectQuery(q("FROM wellbeing_checkins")).
+					WithArgs(scenarioUserID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "checkin_date", "stress_level", "sleep_quality", "work_life_balance", "note", "created_at", "updated_at"}).
+						AddRow(int64(9), scenarioUserID, time.Now(), 4, 3, 3, "", time.Now(), time.Now()))
+			},
+			reply:	[]string{"перерыв на 25 мин", "стресс в последней отметке — 4/5", "Закрой глаза", "Фокус-сессия идет уже 100 мин"},
+		},
+		{
+			name:	"suggest a short break without a focus session",
+			call:	recorded("suggest_break", `{"work_duration":20,"break_type":"creative"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM focus_sessions")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				m.ExpectQuery(q("FROM wellbeing_checkins")).
+					WithArgs(scenarioUserID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			reply:		[]string{"перерыв на 5 мин", "Порисуй"},
+			notInReply:	[]string{"Фокус-сессия"},
+		},
+		{
+			name:	"check achievements awards new ones",
+			call:	recorded("check_achievements", `{"achievement_category":"goals","show_progress":true}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM achievement_types")).
+					WithArgs("goals").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "icon", "category", "points", "rarity", "requirements"}).
+						AddRow(1, "Первый шаг", "Создать первую цель", "🎯", "goals", 10, "common", []byte(`{"goals_created": 1}`)).
+						AddRow(2, "Целеустремленный", "Создать 5 целей", "🚀", "goals", 25, "rare", []byte(`{"goals_created": 5}`)))
+				m.ExpectQuery(q("SELECT COUNT(*) FROM objectives")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+				m.ExpectExec(q("INSERT INTO user_achievements")).
+					WithArgs(scenarioUserID, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			reply:	[]string{"🆕 🎯 Первый шаг", "🚀 Целеустремленный — Создать 5 целей: 3/5", "Очки: 10 из 35"},
+		},
+		{
+			name:	"find accountability partner points to unshared goals",
+			call:	recorded("find_accountability_partner", `{"goal_category":"здоровье","interaction_frequency":"daily"}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM accountability_partners p")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(partnershipRows().AddRow(int64(4), scenarioUserID, int64(777), "accepted", time.Now(), int64(777), "Иван"))
+				m.ExpectQuery(q("FROM objectives o")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "sphere", "period", "deadline", "created_at"}).
+						AddRow("obj-1", scenarioUserID, "Пробежать марафон", "Здоровье", "quarter", time.Now().AddDate(0, 3, 0), time.Now()).
+						AddRow("obj-2", scenarioUserID, "Выучить испанский", "Образование", "year", time.Now().AddDate(1, 0, 0), time.Now()))
+				m.ExpectQuery(q("FROM partner_shared_objectives ps")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			reply:		[]string{"Твои партнеры: Иван", "Партнеры еще не видят: «Пробежать марафон»", "Для ежедневной поддержки"},
+			notInReply:	[]string{"испанский"},
+		},
+		{
+			name:	"find accountability partner without partners",
+			call:	recorded("find_accountability_partner", `{}`),
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(q("FROM accountability_partners p")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(partnershipRows())
+				m.ExpectQuery(q("FROM objectives o")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				m.ExpectQuery(q("FROM accountability_partners p")).
+					WithArgs(scenarioUserID).
+					WillReturnRows(partnershipRows())
+			},
+			reply:	[]string{"стань моим партнером @username", "Каждое воскресенье"},
+		},
+	}
+}
+
+func newScenarioService(t *testing.T) (*ChatGPTService, sqlmock.Sqlmock) {
+	t.Helper()
+	raw, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("не удалось создать sqlmock: %v", err)
+	}
+	t.Cleanup(func() { raw.Close() })
+	db := sqlx.NewDb(raw, "postgres")
+	return &ChatGPTService{
+		db:		db,
+		okr:		okr.NewService(db),
+		calendar:	calendar.NewService(db, &config.Config{}),
+		finance:	finance.NewService(db),
+		focus:		focus.NewService(db),
+		wellbeing:	wellbeing.NewService(db),
+	}, mock
+}
+
+func TestFunctionCallScenarios(t *testing.T) {
+	registered := make(map[string]bool)
+	for _, function := range GetAllJarvisFunctions() {
+		registered[function.Name] = true
+	}
+
+	groups := map[string][]scenario{
+		"okr":		okrScenarios(),
+		"calendar":	calendarScenarios(t),
+		"finance":	financeScenarios(),
+		"coaching":	coachingScenarios(),
+	}
+	for group, scenarios := range groups {
+		for _, sc := range scenarios {
+			t.Run(group+"/"+sc.name, func(t *testing.T) {
+				if !registered[sc.call.Name] {
+					t.Fatalf("функция %s не зарегистрирована", sc.call.Name)
+				}
+				var args map[string]interface{}
+				if err := json.Unmarshal([]byte(sc.call.Arguments), &args); err != nil {
+					t.Fatalf("не удалось разобрать аргументы функции: %v", err)
+				}
+				functionCall := &ChatGPTFunctionCall{Name: sc.call.Name, Arguments: args}
+
+				c, mock := newScenarioService(t)
+				sc.expect(mock)
+
+				reply, function, err := c.handleFunctionCall(context.Background(), functionCall, scenarioUserID)
+				if err != nil {
+					t.Fatalf("функция вернула ошибку: %v", err)
+				}
+				if function == nil || function.Name != sc.call.Name {
+					t.Fatalf("ожидался ответ функции %s, получено %+v", sc.call.Name, function)
+				}
+				for _, want := range sc.reply {
+					if !strings.Contains(reply, want) {
+						t.Errorf("в ответе нет %q:\n%s", want, reply)
+					}
+				}
+				for _, unwanted := range sc.notInReply {
+					if strings.Contains(reply, unwanted) {
+						t.Errorf("в ответе не должно быть %q:\n%s", unwanted, reply)
+					}
+				}
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("изменения в базе не совпали с ожидаемыми: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestEveryRegisteredFunctionIsRouted(t *testing.T) {
+	routed := dispatchedFunctions(t)
+
+	registered := make(map[string]bool)
+	for _, function := range GetAllJarvisFunctions() {
+		if registered[function.Name] {
+			t.Errorf("функция %s зарегистрирована дважды", function.Name)
+		}
+		registered[function.Name] = true
+		if !routed[function.Name] {
+			t.Errorf("функция %s доступна модели, но не обрабатывается в handleNewJarvisFunctions", function.Name)
+		}
+	}
+	for name := range routed {
+		if !registered[name] {
+			t.Errorf("функция %s обрабатывается, но не зарегистрирована для модели", name)
+		}
+	}
+}
+
+func dispatchedFunctions(t *testing.T) map[string]bool {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "jarvis_functions.go", nil, 0)
+	if err != nil {
+		t.Fatalf("не удалось разобрать jarvis_functions.go: %v", err)
+	}
+
+	routed := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "handleNewJarvisFunctions" {
+			continue
+		}
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			clause, ok := node.(*ast.CaseClause)
+			if !ok {
+				return true
+			}
+			for _, expr := range clause.List {
+				if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					name, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						t.Fatalf("некорректное имя функции %s: %v", lit.Value, err)
+					}
+					routed[name] = true
+				}
+			}
+			return true
+		})
+	}
+	if len(routed) == 0 {
+		t.Fatal("в handleNewJarvisFunctions не найдено ни одной функции")
+	}
+	return routed
+}
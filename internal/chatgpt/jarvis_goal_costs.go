@@ -0,0 +1,227 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+
+	"github.com/sirupsen/logrus"
+)
+
+var AddTransactionFunction = ChatGPTFunction{
+	Name:		"add_transaction",
+	Description:	"Записать личный доход или расход. Если пользователь связывает трату с целью («потратил 15к на курс — цель Образование»), укажи objective или key_result_id",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"amount": {
+				Type:		"number",
+				Description:	"Сумма: положительная для доходов, отрицательная для расходов. 15к = 15000",
+			},
+			"details": {
+				Type:		"string",
+				Description:	"Описание операции",
+			},
+			"category": {
+				Type:		"string",
+				Description:	"Категория (Продукты, Кафе, Дом, Транспорт, Здоровье, Образование, Развлечения или своя)",
+			},
+			"objective": {
+				Type:		"string",
+				Description:	"Цель, в которую вложены деньги: ID или часть названия",
+			},
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата, если трата относится к конкретному KR",
+			},
+		},
+		Required:	[]string{"amount"},
+	},
+}
+
+var DeleteTransactionFunction = ChatGPTFunction{
+	Name:		"delete_transaction",
+	Description:	"Удалить ошибочно записанную операцию: по ID или последнюю записанную («удали последнюю трату»)",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"transaction_id": {
+				Type:		"string",
+				Description:	"ID операции",
+			},
+			"last": {
+				Type:		"boolean",
+				Description:	"true — удалить последнюю записанную операцию",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var GetQuarterRetrospectiveFunction = ChatGPTFunction{
+	Name:		"get_quarter_retrospective",
+	Description:	"Квартальная ретроспектива целей: прогресс, сколько денег вложено в каждую цель и цена прогресса",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"quarter": {
+				Type:		"string",
+				Description:	"Квартал в формате 2026-Q3. По умолчанию текущий",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleAddTransaction(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	amount, _ := args["amount"].(float64)
+	details, _ := args["details"].(string)
+	category, _ := args["category"].(string)
+	if amount == 0 {
+		return "❌ Не указана сумма операции", &AddTransactionFunction, nil
+	}
+
+	var link finance.GoalLink
+	var goalTitle string
+	if v, ok := args["key_result_id"].(float64); ok && v > 0 {
+		id := int64(v)
+		link.KeyResultID = &id
+	}
+	if ref, _ := args["objective"].(string); strings.TrimSpace(ref) != "" && link.KeyResultID == nil {
+		objective, message := c.resolveObjectiveRef(ctx, userID, ref)
+		if objective == nil {
+			return message, &AddTransactionFunction, nil
+		}
+		link.ObjectiveID = &objective.ID
+		goalTitle = objective.Title
+	}
+
+	_, err := c.finance.AddGoalTransaction(ctx, userID, amount, details, category, link)
+	if ownership.IsNotOwned(err) {
+		return "❌ " + err.Error(), &AddTransactionFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка добавления транзакции пользователя %d: %v", userID, err)
+		return "❌ Не удалось записать операцию", &AddTransactionFunction, nil
+	}
+
+	response := "✅ **Операция записана**\n\n"
+	if amount > 0 {
+		response += fmt.Sprintf("💰 Доход: %.2f", amount)
+	} else {
+		response += fmt.Sprintf("💸 Расход: %.2f", -amount)
+	}
+	if category != "" {
+		response += fmt.Sprintf(" (%s)", category)
+	}
+	if details != "" {
+		response += fmt.Sprintf("\n📝 %s", details)
+	}
+
+	if link.ObjectiveID != nil || link.KeyResultID != nil {
+		objectiveID := ""
+		if link.ObjectiveID != nil {
+			objectiveID = *link.ObjectiveID
+		} else if kr, err := c.okr.GetKeyResultByID(ctx, userID, *link.KeyResultID); err == nil {
+			objectiveID = kr.ObjectiveID
+		}
+		if goalDetails, err := c.okr.GetObjectiveDetails(ctx, userID, objectiveID); err == nil {
+			goalTitle = goalDetails.Objective.Title
+			response += fmt.Sprintf("\n🎯 Цель «%s»: всего вложено %.2f", goalTitle, goalDetails.Invested)
+		}
+	}
+
+	return response, &AddTransactionFunction, nil
+}
+
+func (c *ChatGPTService) handleDeleteTransaction(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	transactionID, _ := args["transaction_id"].(string)
+	last, _ := args["last"].(bool)
+
+	var transaction *finance.Transaction
+	var err error
+	switch {
+	case strings.TrimSpace(transactionID) != "":
+		transaction, err = c.finance.GetTransactionByID(ctx, userID, strings.TrimSpace(transactionID))
+		if err != nil {
+			return "❌ Операция не найдена или не принадлежит пользователю", &DeleteTransactionFunction, nil
+		}
+	case last:
+		transaction, err = c.finance.GetLastTransaction(ctx, userID)
+		if err != nil {
+			logrus.Errorf("Ошибка получения последней транзакции пользователя %d: %v", userID, err)
+			return "❌ Не удалось найти последнюю операцию", &DeleteTransactionFunction, nil
+		}
+		if transaction == nil {
+			return "ℹ️ Записанных операций пока нет", &DeleteTransactionFunction, nil
+		}
+	default:
+		return "❌ Не указан ID операции", &DeleteTransactionFunction, nil
+	}
+
+	if err := c.finance.DeleteTransaction(ctx, userID, transaction.ID); err != nil {
+		logrus.Errorf("Ошибка удаления транзакции %s пользователя %d: %v", transaction.ID, userID, err)
+		return "❌ Не удалось удалить операцию", &DeleteTransactionFunction, nil
+	}
+
+	response := "🗑️ **Операция удалена**\n\n"
+	if transaction.Amount > 0 {
+		response += fmt.Sprintf("💰 Доход: %.2f", transaction.Amount)
+	} else {
+		response += fmt.Sprintf("💸 Расход: %.2f", -transaction.Amount)
+	}
+	if transaction.Category != "" {
+		response += fmt.Sprintf(" (%s)", transaction.Category)
+	}
+	if transaction.Details != "" {
+		response += fmt.Sprintf("\n📝 %s", transaction.Details)
+	}
+	response += fmt.Sprintf("\n📅 %s", transaction.CreatedAt.Local().Format("02.01.2006 15:04"))
+
+	return response, &DeleteTransactionFunction, nil
+}
+
+func (c *ChatGPTService) resolveObjectiveRef(ctx context.Context, userID int64, ref string) (*okr.Objective, string) {
+	ref = strings.TrimSpace(ref)
+	if details, err := c.okr.GetObjectiveDetails(ctx, userID, ref); err == nil {
+		return &details.Objective, ""
+	}
+
+	objectives, err := c.okr.FindObjectiveByDescription(ctx, userID, ref)
+	if err != nil {
+		logrus.Errorf("Ошибка поиска цели «%s» пользователя %d: %v", ref, userID, err)
+		return nil, "❌ Не удалось найти цель"
+	}
+	switch len(objectives) {
+	case 0:
+		return nil, fmt.Sprintf("❓ Не нашел цель «%s». Уточните название или запишите операцию без цели", ref)
+	case 1:
+		return &objectives[0], ""
+	}
+
+	titles := make([]string, 0, len(objectives))
+	for _, o := range objectives {
+		titles = append(titles, "«"+o.Title+"»")
+	}
+	return nil, fmt.Sprintf("❓ Под «%s» подходит несколько целей: %s. Уточните, какую выбрать", ref, strings.Join(titles, ", "))
+}
+
+func (c *ChatGPTService) handleGetQuarterRetrospective(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	quarter, _ := args["quarter"].(string)
+	retro, err := c.okr.GetQuarterRetrospective(ctx, userID, quarter)
+	if err != nil {
+		logrus.Errorf("Ошибка построения ретроспективы пользователя %d: %v", userID, err)
+		return "❌ Не удалось построить ретроспективу: " + err.Error(), &GetQuarterRetrospectiveFunction, nil
+	}
+
+	return okr.FormatQuarterRetrospective(retro), &GetQuarterRetrospectiveFunction, nil
+}
@@ -0,0 +1,166 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/habits"
+
+	"github.com/sirupsen/logrus"
+)
+
+var SetQuickCounterFunction = ChatGPTFunction{
+	Name:		"set_quick_counter",
+	Description:	"Включить или настроить быстрый счетчик привычки (вода, шаги, страницы) с кнопками для отметки в одно нажатие. Используй для 'хочу считать воду', 'цель 8000 шагов в день', 'привяжи страницы к ключевому результату'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"kind": {
+				Type:		"string",
+				Description:	"Тип счетчика",
+				Enum:		[]string{habits.KindWater, habits.KindSteps, habits.KindPages},
+			},
+			"step": {
+				Type:		"number",
+				Description:	"На сколько увеличивает одно нажатие (по умолчанию: вода 250 мл, шаги 1000, страницы 10)",
+			},
+			"daily_goal": {
+				Type:		"number",
+				Description:	"Дневная цель (по умолчанию: вода 2000 мл, шаги 10000, страницы 30)",
+			},
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата, в который засчитывать каждое нажатие",
+			},
+			"enabled": {
+				Type:		"boolean",
+				Description:	"false — отключить счетчик",
+			},
+		},
+		Required:	[]string{"kind"},
+	},
+}
+
+var LogQuickCounterFunction = ChatGPTFunction{
+	Name:		"log_quick_counter",
+	Description:	"Добавить значение в быстрый счетчик: 'выпил стакан воды', 'прошел 3000 шагов', 'прочитал 20 страниц'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"kind": {
+				Type:		"string",
+				Description:	"Тип счетчика",
+				Enum:		[]string{habits.KindWater, habits.KindSteps, habits.KindPages},
+			},
+			"amount": {
+				Type:		"number",
+				Description:	"Сколько добавить (мл, шаги, страницы). Не указывай, чтобы добавить стандартный шаг",
+			},
+		},
+		Required:	[]string{"kind"},
+	},
+}
+
+var GetQuickCountersFunction = ChatGPTFunction{
+	Name:		"get_quick_counters",
+	Description:	"Показать быстрые счетчики (вода, шаги, страницы): сегодня, среднее и дни с выполненной целью",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"days": {
+				Type:		"integer",
+				Description:	"За сколько дней статистика (по умолчанию 7)",
+				Minimum:	1,
+				Maximum:	90,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleSetQuickCounter(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Настройка быстрого счетчика для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	kind, _ := args["kind"].(string)
+	if enabled, ok := args["enabled"].(bool); ok && !enabled {
+		err := c.habits.DisableCounter(ctx, userID, kind)
+		if errors.Is(err, habits.ErrCounterNotFound) {
+			return "ℹ️ Такой счетчик не был включен", &SetQuickCounterFunction, nil
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка отключения счетчика: %v", err)
+			return "❌ Не удалось отключить счетчик", &SetQuickCounterFunction, nil
+		}
+		return "✅ Счетчик отключен. Отправьте /habits, чтобы обновить кнопки", &SetQuickCounterFunction, nil
+	}
+
+	step, _ := args["step"].(float64)
+	goal, _ := args["daily_goal"].(float64)
+	var keyResultID *int64
+	if v, ok := args["key_result_id"].(float64); ok && v > 0 {
+		id := int64(v)
+		keyResultID = &id
+	}
+
+	counter, err := c.habits.EnableCounter(ctx, userID, kind, step, goal, keyResultID)
+	if err != nil {
+		logrus.Errorf("Ошибка настройки счетчика: %v", err)
+		return fmt.Sprintf("❌ Не удалось настроить счетчик: %v", err), &SetQuickCounterFunction, nil
+	}
+
+	response := fmt.Sprintf("✅ **Счетчик включен**\n\n%s\n🔘 Кнопка: %s\n", counter.FormatProgress(0), counter.ButtonText())
+	if counter.KeyResultID != nil {
+		response += fmt.Sprintf("🎯 Каждое нажатие засчитывается в ключевой результат #%d\n", *counter.KeyResultID)
+	}
+	response += "\nОтправьте /habits, чтобы показать кнопки под полем ввода"
+
+	return response, &SetQuickCounterFunction, nil
+}
+
+func (c *ChatGPTService) handleLogQuickCounter(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	kind, _ := args["kind"].(string)
+	amount, _ := args["amount"].(float64)
+
+	counter, err := c.habits.GetCounter(ctx, userID, kind)
+	if errors.Is(err, habits.ErrCounterNotFound) {
+		counter, err = c.habits.EnableCounter(ctx, userID, kind, 0, 0, nil)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка получения счетчика: %v", err)
+		return fmt.Sprintf("❌ Не удалось найти счетчик: %v", err), &LogQuickCounterFunction, nil
+	}
+
+	result, err := c.habits.Add(ctx, counter, amount)
+	if err != nil {
+		logrus.Errorf("Ошибка обновления счетчика: %v", err)
+		return "❌ Не удалось записать значение", &LogQuickCounterFunction, nil
+	}
+
+	return habits.FormatTapResult(result), &LogQuickCounterFunction, nil
+}
+
+func (c *ChatGPTService) handleGetQuickCounters(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	days := 7
+	if v, ok := args["days"].(float64); ok && v > 0 {
+		days = int(v)
+	}
+
+	summaries, err := c.habits.GetSummaries(ctx, userID, days)
+	if err != nil {
+		logrus.Errorf("Ошибка получения статистики счетчиков: %v", err)
+		return "❌ Не удалось получить статистику", &GetQuickCountersFunction, nil
+	}
+
+	if len(summaries) == 0 {
+		return "📊 Быстрые счетчики не включены. Скажите, например: 'хочу считать воду'", &GetQuickCountersFunction, nil
+	}
+
+	return "📊 **Быстрые счетчики:**\n\n" + strings.TrimRight(habits.FormatSummaries(summaries), "\n"), &GetQuickCountersFunction, nil
+}
@@ -0,0 +1,262 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/health"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var AddMedicationFunction = ChatGPTFunction{
+	Name:		"add_medication",
+	Description:	"Добавить лекарство, витамин или БАД с напоминаниями о приеме. Используй, когда пользователь говорит 'напоминай пить витамин D в 9 утра', 'курс антибиотика 7 дней, 2 раза в день'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"name": {
+				Type:		"string",
+				Description:	"Название лекарства или добавки",
+			},
+			"dosage": {
+				Type:		"string",
+				Description:	"Дозировка, например '1 таблетка', '500 мг'",
+			},
+			"times": {
+				Type:		"array",
+				Description:	"Время приема в течение дня в формате ЧЧ:ММ",
+				Items:		&ChatGPTProperty{Type: "string"},
+			},
+			"duration_days": {
+				Type:		"integer",
+				Description:	"Длительность курса в днях (не указывай для постоянного приема)",
+				Minimum:	1,
+				Maximum:	365,
+			},
+		},
+		Required:	[]string{"name", "times"},
+	},
+}
+
+var LogMedicationTakenFunction = ChatGPTFunction{
+	Name:		"log_medication_taken",
+	Description:	"Отметить, что пользователь принял лекарство ('принял витамин D', 'выпил таблетку')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"name": {
+				Type:		"string",
+				Description:	"Название лекарства",
+			},
+		},
+		Required:	[]string{"name"},
+	},
+}
+
+var GetMedicationsFunction = ChatGPTFunction{
+	Name:		"get_medications",
+	Description:	"Показать активные курсы лекарств, расписание приема и статистику соблюдения",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"days": {
+				Type:		"integer",
+				Description:	"За сколько последних дней посчитать статистику (по умолчанию 7)",
+				Minimum:	1,
+				Maximum:	90,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var UpdateMedicationScheduleFunction = ChatGPTFunction{
+	Name:		"update_medication_schedule",
+	Description:	"Изменить время приема лекарства",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"name": {
+				Type:		"string",
+				Description:	"Название лекарства",
+			},
+			"times": {
+				Type:		"array",
+				Description:	"Новое время приема в формате ЧЧ:ММ",
+				Items:		&ChatGPTProperty{Type: "string"},
+			},
+		},
+		Required:	[]string{"name", "times"},
+	},
+}
+
+var StopMedicationFunction = ChatGPTFunction{
+	Name:		"stop_medication",
+	Description:	"Завершить курс лекарства и перестать напоминать о нем",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"name": {
+				Type:		"string",
+				Description:	"Название лекарства",
+			},
+		},
+		Required:	[]string{"name"},
+	},
+}
+
+func stringList(value interface{}) []string {
+	raw, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var result []string
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (c *ChatGPTService) findMedicationByArgs(ctx context.Context, args map[string]interface{}, userID int64) (*health.Medication, string) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return nil, "❌ Не указано название лекарства"
+	}
+
+	medication, err := c.health.FindMedication(ctx, userID, name)
+	if errors.Is(err, health.ErrMedicationNotFound) {
+		return nil, fmt.Sprintf("❌ Лекарство «%s» не найдено среди активных курсов", name)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка поиска лекарства: %v", err)
+		return nil, "❌ Не удалось найти лекарство"
+	}
+
+	return medication, ""
+}
+
+func (c *ChatGPTService) handleAddMedication(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Добавление лекарства для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	name, _ := args["name"].(string)
+	dosage, _ := args["dosage"].(string)
+	durationDays := 0
+	if v, ok := args["duration_days"].(float64); ok {
+		durationDays = int(v)
+	}
+
+	medication, err := c.health.AddMedication(ctx, userID, name, dosage, stringList(args["times"]), durationDays)
+	if err != nil {
+		logrus.Errorf("Ошибка добавления лекарства: %v", err)
+		return fmt.Sprintf("❌ Не удалось добавить лекарство: %v", err), &AddMedicationFunction, nil
+	}
+
+	response := fmt.Sprintf("💊 **Добавлено: %s**\n\n", medication.Label())
+	response += fmt.Sprintf("🕐 **Прием:** %s\n", strings.Join(medication.Times, ", "))
+	if medication.EndDate != nil {
+		response += fmt.Sprintf("📅 **Курс до:** %s\n", medication.EndDate.Format("02.01.2006"))
+	} else {
+		response += "📅 **Курс:** постоянно\n"
+	}
+	response += "\n🔔 Буду напоминать в это время — нажмите «Принял», чтобы отметить прием"
+
+	return response, &AddMedicationFunction, nil
+}
+
+func (c *ChatGPTService) handleLogMedicationTaken(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	medication, errText := c.findMedicationByArgs(ctx, args, userID)
+	if medication == nil {
+		return errText, &LogMedicationTakenFunction, nil
+	}
+
+	dose, err := c.health.LogTaken(ctx, userID, medication.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка отметки приема лекарства: %v", err)
+		return "❌ Не удалось отметить прием", &LogMedicationTakenFunction, nil
+	}
+
+	return fmt.Sprintf("✅ Отметил прием: **%s** в %s", medication.Label(), dose.TakenAt.Format("15:04")), &LogMedicationTakenFunction, nil
+}
+
+func (c *ChatGPTService) handleGetMedications(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	days := 7
+	if v, ok := args["days"].(float64); ok && v > 0 {
+		days = int(v)
+	}
+
+	medications, err := c.health.GetMedications(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения лекарств: %v", err)
+		return "❌ Не удалось получить список лекарств", &GetMedicationsFunction, nil
+	}
+
+	if len(medications) == 0 {
+		return "💊 Активных курсов нет. Скажите, например: 'напоминай пить витамин D в 9:00'", &GetMedicationsFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("💊 **Ваши курсы:**\n\n")
+	for _, m := range medications {
+		b.WriteString(fmt.Sprintf("• **%s** — %s", m.Label(), strings.Join(m.Times, ", ")))
+		if m.EndDate != nil {
+			b.WriteString(fmt.Sprintf(" (до %s)", m.EndDate.Format("02.01")))
+		}
+		b.WriteString("\n")
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	adherence, err := c.health.GetAdherence(ctx, userID, since)
+	if err != nil {
+		logrus.Errorf("Ошибка получения статистики приема: %v", err)
+	} else if text := health.FormatAdherence(adherence); text != "" {
+		b.WriteString(fmt.Sprintf("\n📊 **Соблюдение за %d дн.:**\n", days))
+		b.WriteString(text)
+	}
+
+	return strings.TrimRight(b.String(), "\n"), &GetMedicationsFunction, nil
+}
+
+func (c *ChatGPTService) handleUpdateMedicationSchedule(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	medication, errText := c.findMedicationByArgs(ctx, args, userID)
+	if medication == nil {
+		return errText, &UpdateMedicationScheduleFunction, nil
+	}
+
+	updated, err := c.health.UpdateMedicationTimes(ctx, userID, medication.ID, stringList(args["times"]))
+	if err != nil {
+		logrus.Errorf("Ошибка изменения времени приема: %v", err)
+		return fmt.Sprintf("❌ Не удалось изменить время приема: %v", err), &UpdateMedicationScheduleFunction, nil
+	}
+
+	return fmt.Sprintf("🕐 **%s** — новое время приема: %s", updated.Label(), strings.Join(updated.Times, ", ")), &UpdateMedicationScheduleFunction, nil
+}
+
+func (c *ChatGPTService) handleStopMedication(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	medication, errText := c.findMedicationByArgs(ctx, args, userID)
+	if medication == nil {
+		return errText, &StopMedicationFunction, nil
+	}
+
+	if err := c.health.StopMedication(ctx, userID, medication.ID); err != nil {
+		logrus.Errorf("Ошибка остановки курса: %v", err)
+		return "❌ Не удалось завершить курс", &StopMedicationFunction, nil
+	}
+
+	return fmt.Sprintf("🏁 Курс **%s** завершен, напоминания отключены", medication.Label()), &StopMedicationFunction, nil
+}
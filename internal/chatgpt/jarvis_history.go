@@ -0,0 +1,88 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/semantic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var SearchHistoryFunction = ChatGPTFunction{
+	Name:		"search_history",
+	Description:	"Ищет в прошлых сообщениях пользователя по смыслу. Используй, когда пользователь спрашивает, что он говорил, писал или планировал раньше",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"query": {
+				Type:		"string",
+				Description:	"О чем искать, своими словами, например: запуск курса",
+			},
+			"from_date": {
+				Type:		"string",
+				Description:	"Начало периода в формате YYYY-MM-DD, если пользователь указал время (например, «в марте»)",
+			},
+			"to_date": {
+				Type:		"string",
+				Description:	"Конец периода включительно в формате YYYY-MM-DD",
+			},
+			"limit": {
+				Type:		"integer",
+				Description:	"Сколько сообщений вернуть (по умолчанию 5, максимум 20)",
+			},
+		},
+		Required:	[]string{"query"},
+	},
+}
+
+func (c *ChatGPTService) handleSearchHistory(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	query, _ := args["query"].(string)
+	opts := semantic.SearchOptions{}
+	if limit, ok := args["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	if fromStr, _ := args["from_date"].(string); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return "❌ Некорректная дата начала периода, ожидается YYYY-MM-DD", &SearchHistoryFunction, nil
+		}
+		opts.From = &from
+	}
+	if toStr, _ := args["to_date"].(string); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return "❌ Некорректная дата конца периода, ожидается YYYY-MM-DD", &SearchHistoryFunction, nil
+		}
+		to = to.AddDate(0, 0, 1)
+		opts.To = &to
+	}
+
+	matches, err := c.semantic.Search(ctx, []int64{userID}, query, opts)
+	if errors.Is(err, semantic.ErrEmptyQuery) {
+		return "❓ Уточните, что найти в истории сообщений", &SearchHistoryFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка поиска по истории пользователя %d: %v", userID, err)
+		return "❌ Не удалось выполнить поиск по истории", &SearchHistoryFunction, nil
+	}
+
+	if len(matches) == 0 {
+		return fmt.Sprintf("🔎 Не нашел сообщений про «%s»", query), &SearchHistoryFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🔎 Вот что вы писали про «%s»:\n", query))
+	for i, m := range matches {
+		b.WriteString(fmt.Sprintf("\n%d. %s\n«%s»\n", i+1, m.CreatedAt.Format("02.01.2006 15:04"), truncateRunes(m.Text, 400)))
+		if m.Response != "" {
+			b.WriteString(fmt.Sprintf("↳ Jarvis: %s\n", truncateRunes(m.Response, 200)))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n"), &SearchHistoryFunction, nil
+}
@@ -0,0 +1,174 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/journal"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	reflectionEntryMaxRunes		= 600
+	reflectionMaxRunes		= 1500
+	reflectionEntriesPerWeek	= 50
+)
+
+const reflectionInstruction = `Ты помогаешь пользователю подвести итоги недели по его вечернему дневнику.
+По записям напиши короткую рефлексию: что получилось и чем можно гордиться, какие темы и настроения повторялись, что мешало, и один-два мягких вопроса или идеи на следующую неделю.
+Опирайся только на записи, не выдумывай факты и не давай оценок личности. Обращайся на «ты».
+Пиши по-русски, не длиннее 1000 символов, без заголовков Markdown.`
+
+var GetJournalEntriesFunction = ChatGPTFunction{
+	Name:		"get_journal_entries",
+	Description:	"Получить записи вечернего дневника пользователя (ответы на вопрос «что получилось сегодня?») и итоги недель. Используй, когда пользователь спрашивает, что он делал, чего добился или как себя чувствовал в какой-то период",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"from_date": {
+				Type:		"string",
+				Description:	"С какого дня, YYYY-MM-DD (по умолчанию неделю назад)",
+			},
+			"to_date": {
+				Type:		"string",
+				Description:	"По какой день включительно, YYYY-MM-DD (по умолчанию сегодня)",
+			},
+			"query": {
+				Type:		"string",
+				Description:	"Слово или фраза для поиска по записям; если задано, период не учитывается",
+			},
+			"limit": {
+				Type:		"integer",
+				Description:	"Максимум записей (по умолчанию 20)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleGetJournalEntries(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Получение записей дневника для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	limit := 20
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	var entries []journal.Entry
+	var err error
+	if query, _ := args["query"].(string); strings.TrimSpace(query) != "" {
+		entries, err = c.journal.SearchEntries(ctx, userID, strings.TrimSpace(query), limit)
+	} else {
+		to := time.Now()
+		if toStr, _ := args["to_date"].(string); toStr != "" {
+			day, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+			if err != nil {
+				return "❌ Некорректная дата to_date, нужен формат YYYY-MM-DD", &GetJournalEntriesFunction, nil
+			}
+			to = day
+		}
+		from := to.AddDate(0, 0, -7)
+		if fromStr, _ := args["from_date"].(string); fromStr != "" {
+			day, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+			if err != nil || day.After(to) {
+				return "❌ Некорректная дата from_date: нужен формат YYYY-MM-DD, не позже to_date", &GetJournalEntriesFunction, nil
+			}
+			from = day
+		}
+		entries, err = c.journal.GetEntries(ctx, userID, from, to, limit)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка получения записей дневника пользователя %d: %v", userID, err)
+		return "❌ Не удалось загрузить дневник", &GetJournalEntriesFunction, nil
+	}
+
+	result := journal.FormatEntries(entries)
+
+	summaries, err := c.journal.GetSummaries(ctx, userID, 1)
+	if err != nil {
+		logrus.Warnf("Не удалось получить итоги недели пользователя %d: %v", userID, err)
+	} else if len(summaries) > 0 {
+		result += fmt.Sprintf("\n\n🪞 **Итоги недели с %s:**\n%s", summaries[0].WeekStart.Format("02.01.2006"), summaries[0].Summary)
+	}
+	return result, &GetJournalEntriesFunction, nil
+}
+
+func (c *ChatGPTService) StartJournalReflections(jm *jobs.Manager, sendMessage func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"journal_weekly_reflections",
+		Spec:	"0 19 * * 0",
+		Run: func(ctx context.Context) {
+			c.sendJournalReflections(ctx, sendMessage)
+		},
+	})
+
+	logrus.Info("Запущены еженедельные итоги дневника")
+}
+
+func (c *ChatGPTService) sendJournalReflections(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	weekStart := journal.WeekStart(time.Now())
+	userIDs, err := c.journal.UsersForWeeklySummary(ctx, weekStart)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе пользователей для итогов недели: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		entries, err := c.journal.GetEntries(ctx, userID, weekStart, weekStart.AddDate(0, 0, 6), reflectionEntriesPerWeek)
+		if err != nil {
+			logrus.Warnf("Не удалось получить записи дневника пользователя %d: %v", userID, err)
+			continue
+		}
+
+		summary, err := c.requestReflection(ctx, entries)
+		if err != nil {
+			logrus.Warnf("Не удалось подготовить итоги недели пользователя %d: %v", userID, err)
+			continue
+		}
+		if err := c.journal.SaveSummary(ctx, userID, weekStart, summary, len(entries)); err != nil {
+			logrus.Errorf("Ошибка сохранения итогов недели пользователя %d: %v", userID, err)
+			continue
+		}
+
+		if err := sendMessage(userID, "🪞 Итоги недели по твоему дневнику\n\n"+summary); err != nil {
+			logrus.Warnf("Не удалось отправить итоги недели пользователю %d: %v", userID, err)
+		}
+	}
+}
+
+func (c *ChatGPTService) requestReflection(ctx context.Context, entries []journal.Entry) (string, error) {
+	var diary strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		diary.WriteString(fmt.Sprintf("[%s] %s\n", entries[i].EntryDate.Format("02.01 Mon"), truncateRunes(entries[i].Text, reflectionEntryMaxRunes)))
+	}
+
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: reflectionInstruction},
+			{Role: openai.ChatMessageRoleUser, Content: diary.String()},
+		},
+		Temperature:	0.5,
+	})
+	if err != nil {
+		return "", fmt.Errorf("ошибка при запросе итогов недели: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("пустой ответ при запросе итогов недели")
+	}
+
+	summary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if summary == "" {
+		return "", fmt.Errorf("пустые итоги недели")
+	}
+	return truncateRunes(summary, reflectionMaxRunes), nil
+}
@@ -0,0 +1,284 @@
+package chatgpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/health/nutrition"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
+)
+
+const mealEstimatePrompt = `Ты нутрициолог. Оцени калорийность и БЖУ приема пищи по описанию или фото.
+Если порция не указана, считай стандартную порцию для взрослого.
+Ответь строго JSON без пояснений:
+{"description": "краткое название блюд на русском с примерным весом", "calories": целое число ккал, "protein": граммы, "fat": граммы, "carbs": граммы}
+Если на фото нет еды, верни {"description": "", "calories": 0, "protein": 0, "fat": 0, "carbs": 0}`
+
+var LogMealFunction = ChatGPTFunction{
+	Name:		"log_meal",
+	Description:	"Записать прием пищи с автоматической оценкой калорий и БЖУ. Используй, когда пользователь пишет что съел: 'съел овсянку с бананом', 'на обед борщ и котлета'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"description": {
+				Type:		"string",
+				Description:	"Что съел пользователь, с количеством, если указано",
+			},
+			"time": {
+				Type:		"string",
+				Description:	"Время приема пищи ЧЧ:ММ сегодня, если пользователь его назвал",
+			},
+		},
+		Required:	[]string{"description"},
+	},
+}
+
+var SetNutritionTargetFunction = ChatGPTFunction{
+	Name:		"set_nutrition_target",
+	Description:	"Задать дневную норму калорий (и белка), опционально связав ее с ключевым результатом здоровья: каждый день в пределах нормы добавляет +1 к ключевому результату",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"daily_calories": {
+				Type:		"integer",
+				Description:	"Дневная норма калорий",
+				Minimum:	800,
+				Maximum:	6000,
+			},
+			"protein": {
+				Type:		"integer",
+				Description:	"Дневная норма белка в граммах",
+			},
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата, например 'Дней в норме калорий'",
+			},
+		},
+		Required:	[]string{"daily_calories"},
+	},
+}
+
+var GetNutritionSummaryFunction = ChatGPTFunction{
+	Name:		"get_nutrition_summary",
+	Description:	"Показать питание: приемы пищи за сегодня и калории против нормы, или сводку за несколько дней",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"days": {
+				Type:		"integer",
+				Description:	"1 — только сегодня, 7 — сводка за неделю",
+				Minimum:	1,
+				Maximum:	31,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var DeleteLastMealFunction = ChatGPTFunction{
+	Name:		"delete_last_meal",
+	Description:	"Удалить последний записанный прием пищи, если он записан ошибочно",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) estimateMeal(ctx context.Context, description string, image []byte) (*nutrition.Estimate, error) {
+	userMessage := openai.ChatCompletionMessage{
+		Role:		openai.ChatMessageRoleUser,
+		Content:	description,
+	}
+	if len(image) > 0 {
+		text := description
+		if text == "" {
+			text = "Что на фото и сколько это калорий?"
+		}
+		userMessage = openai.ChatCompletionMessage{
+			Role:	openai.ChatMessageRoleUser,
+			MultiContent: []openai.ChatMessagePart{
+				{Type: openai.ChatMessagePartTypeText, Text: text},
+				{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: imageDataURL(image), Detail: openai.ImageURLDetailLow}},
+			},
+		}
+	}
+
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: mealEstimatePrompt},
+			userMessage,
+		},
+		ResponseFormat:	&openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:	0.2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к OpenAI: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("нет ответа от OpenAI")
+	}
+
+	var estimate nutrition.Estimate
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &estimate); err != nil {
+		return nil, fmt.Errorf("ошибка разбора оценки калорийности: %w", err)
+	}
+
+	return &estimate, nil
+}
+
+func (c *ChatGPTService) logMeal(ctx context.Context, userID int64, description string, image []byte, source string, eatenAt time.Time) (string, error) {
+	estimate, err := c.estimateMeal(ctx, description, image)
+	if err != nil {
+		return "", err
+	}
+
+	meal, err := c.nutrition.LogMeal(ctx, userID, *estimate, source, eatenAt)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err), nil
+	}
+
+	meals, err := c.nutrition.GetMealsForDay(ctx, userID, time.Now())
+	if err != nil {
+		logrus.Warnf("Не удалось получить приемы пищи за день: %v", err)
+	}
+	target, err := c.nutrition.GetTarget(ctx, userID)
+	if err != nil {
+		target = nil
+	}
+
+	return "✅ Записал:\n" + nutrition.FormatMeal(*meal) + "\n\n" + nutrition.FormatDayProgress(meals, target), nil
+}
+
+func (c *ChatGPTService) ProcessMealPhoto(ctx context.Context, userID int64, image []byte, caption string) (string, error) {
+	return c.logMeal(ctx, userID, caption, image, nutrition.SourcePhoto, time.Now())
+}
+
+func (c *ChatGPTService) handleLogMeal(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Запись приема пищи для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	description, _ := args["description"].(string)
+	if strings.TrimSpace(description) == "" {
+		return "❌ Не указано, что вы съели", &LogMealFunction, nil
+	}
+
+	eatenAt := time.Now()
+	if value, ok := args["time"].(string); ok && value != "" {
+		if t, err := time.Parse("15:04", value); err == nil {
+			eatenAt = time.Date(eatenAt.Year(), eatenAt.Month(), eatenAt.Day(), t.Hour(), t.Minute(), 0, 0, eatenAt.Location())
+		}
+	}
+
+	response, err := c.logMeal(ctx, userID, description, nil, nutrition.SourceText, eatenAt)
+	if err != nil {
+		logrus.Errorf("Ошибка оценки приема пищи: %v", err)
+		return "❌ Не удалось оценить калорийность, попробуйте описать подробнее", &LogMealFunction, nil
+	}
+
+	return response, &LogMealFunction, nil
+}
+
+func (c *ChatGPTService) handleSetNutritionTarget(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	calories := 0
+	if v, ok := args["daily_calories"].(float64); ok {
+		calories = int(v)
+	}
+	var protein *int
+	if v, ok := args["protein"].(float64); ok && v > 0 {
+		p := int(v)
+		protein = &p
+	}
+	var keyResultID *int64
+	if v, ok := args["key_result_id"].(float64); ok && v > 0 {
+		id := int64(v)
+		keyResultID = &id
+	}
+
+	target, err := c.nutrition.SetTarget(ctx, userID, calories, protein, keyResultID)
+	if err != nil {
+		logrus.Errorf("Ошибка сохранения нормы калорий: %v", err)
+		return fmt.Sprintf("❌ Не удалось сохранить норму: %v", err), &SetNutritionTargetFunction, nil
+	}
+
+	response := fmt.Sprintf("🎯 Дневная норма: **%d ккал**", target.DailyCalories)
+	if target.Protein != nil {
+		response += fmt.Sprintf(", белок %d г", *target.Protein)
+	}
+	if target.KeyResultID != nil {
+		response += fmt.Sprintf("\n📈 Каждый день в пределах нормы добавит +1 к ключевому результату #%d", *target.KeyResultID)
+	}
+
+	return response, &SetNutritionTargetFunction, nil
+}
+
+func (c *ChatGPTService) handleGetNutritionSummary(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	days := 1
+	if v, ok := args["days"].(float64); ok && v > 0 {
+		days = int(v)
+	}
+
+	target, err := c.nutrition.GetTarget(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, nutrition.ErrTargetNotSet) {
+			logrus.Errorf("Ошибка получения нормы калорий: %v", err)
+		}
+		target = nil
+	}
+
+	if days == 1 {
+		meals, err := c.nutrition.GetMealsForDay(ctx, userID, time.Now())
+		if err != nil {
+			logrus.Errorf("Ошибка получения приемов пищи: %v", err)
+			return "❌ Не удалось получить питание за сегодня", &GetNutritionSummaryFunction, nil
+		}
+		if len(meals) == 0 {
+			return "🍽 Сегодня приемов пищи еще нет. Напишите, что съели, или пришлите фото тарелки", &GetNutritionSummaryFunction, nil
+		}
+
+		var b strings.Builder
+		b.WriteString("🍽 **Сегодня:**\n\n")
+		for _, m := range meals {
+			b.WriteString(m.EatenAt.Format("15:04") + " " + nutrition.FormatMeal(m) + "\n")
+		}
+		b.WriteString("\n" + nutrition.FormatDayProgress(meals, target))
+		return b.String(), &GetNutritionSummaryFunction, nil
+	}
+
+	totals, err := c.nutrition.GetDailyTotals(ctx, userID, days)
+	if err != nil {
+		logrus.Errorf("Ошибка получения сводки питания: %v", err)
+		return "❌ Не удалось получить сводку питания", &GetNutritionSummaryFunction, nil
+	}
+	if len(totals) == 0 {
+		return fmt.Sprintf("🍽 За последние %d дн. записей о питании нет", days), &GetNutritionSummaryFunction, nil
+	}
+
+	return fmt.Sprintf("🥗 **Питание за %d дн.:**\n\n", days) + nutrition.FormatWeeklySummary(totals, target), &GetNutritionSummaryFunction, nil
+}
+
+func (c *ChatGPTService) handleDeleteLastMeal(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	meal, err := c.nutrition.DeleteLastMeal(ctx, userID)
+	if errors.Is(err, nutrition.ErrMealNotFound) {
+		return "ℹ️ Записей о питании нет", &DeleteLastMealFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка удаления приема пищи: %v", err)
+		return "❌ Не удалось удалить запись", &DeleteLastMealFunction, nil
+	}
+
+	return "🗑 Удалил: " + nutrition.FormatMeal(*meal), &DeleteLastMealFunction, nil
+}
@@ -0,0 +1,78 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CloseObjectiveFunction = ChatGPTFunction{
+	Name:		"close_objective",
+	Description:	"Закрыть цель: завершить (completed) или отправить в архив (archived) с короткой ретроспективой. Перед вызовом спроси пользователя, что сработало и что не сработало, и передай ответы. Закрытые цели пропадают из обычного списка и видны в /archive",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"objective": {
+				Type:		"string",
+				Description:	"Название или ID цели",
+			},
+			"outcome": {
+				Type:		"string",
+				Description:	"completed — цель достигнута, archived — цель больше не актуальна",
+				Enum:		[]string{okr.ObjectiveStatusCompleted, okr.ObjectiveStatusArchived},
+			},
+			"what_worked": {
+				Type:		"string",
+				Description:	"Что сработало — ответ пользователя своими словами",
+			},
+			"what_didnt": {
+				Type:		"string",
+				Description:	"Что не сработало или помешало — ответ пользователя своими словами",
+			},
+		},
+		Required:	[]string{"objective", "outcome"},
+	},
+}
+
+func (c *ChatGPTService) handleCloseObjective(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	ref, _ := args["objective"].(string)
+	outcome, _ := args["outcome"].(string)
+	whatWorked, _ := args["what_worked"].(string)
+	whatDidnt, _ := args["what_didnt"].(string)
+
+	objective, message := c.resolveObjectiveRef(ctx, userID, ref)
+	if objective == nil {
+		return message, &CloseObjectiveFunction, nil
+	}
+
+	if strings.TrimSpace(whatWorked) == "" && strings.TrimSpace(whatDidnt) == "" {
+		return fmt.Sprintf("📝 Перед тем как закрыть «%s», два коротких вопроса:\n1. Что сработало?\n2. Что не сработало или помешало?\n\nОтветь одним сообщением — сохраню это в ретроспективу цели", objective.Title), &CloseObjectiveFunction, nil
+	}
+
+	closing, err := c.okr.CloseObjective(ctx, userID, objective.ID, outcome, whatWorked, whatDidnt)
+	if err != nil {
+		switch {
+		case errors.Is(err, okr.ErrObjectiveAlreadyClosed):
+			return fmt.Sprintf("ℹ️ Цель «%s» уже закрыта. Вернуть ее в работу можно из /archive", objective.Title), &CloseObjectiveFunction, nil
+		case errors.Is(err, okr.ErrInvalidClosingOutcome):
+			return "❓ Цель достигнута или просто больше не актуальна?", &CloseObjectiveFunction, nil
+		case ownership.IsNotOwned(err):
+			return ownershipErrorMessage(err), &CloseObjectiveFunction, nil
+		}
+		logrus.Errorf("Ошибка закрытия цели %s пользователя %d: %v", objective.ID, userID, err)
+		return "❌ Не удалось закрыть цель", &CloseObjectiveFunction, nil
+	}
+
+	header := fmt.Sprintf("🏁 Цель «%s» завершена!", objective.Title)
+	if closing.Outcome == okr.ObjectiveStatusArchived {
+		header = fmt.Sprintf("🗄 Цель «%s» отправлена в архив", objective.Title)
+	}
+	return header + "\n\n" + okr.FormatObjectiveClosing(closing) + "\n\nРетроспектива сохранена, цель больше не в списке активных", &CloseObjectiveFunction, nil
+}
@@ -0,0 +1,82 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+var GetObjectiveDetailsFunction = ChatGPTFunction{
+	Name:		"get_objective_details",
+	Description:	"Показать карточку одной цели: прогресс, дедлайн, ключевые результаты и задачи. Пользователь сможет раскрыть KR и задачи кнопками",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"objective_id": {
+				Type:		"string",
+				Description:	"ID цели",
+			},
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название или часть названия цели (если ID не указан)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleGetObjectiveDetails(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Получение деталей цели для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	objectiveID, _ := args["objective_id"].(string)
+	objectiveDescription, _ := args["objective_description"].(string)
+	if objectiveID == "" && objectiveDescription != "" {
+		objectives, err := c.okr.FindObjectiveByDescription(ctx, userID, objectiveDescription)
+		if err != nil || len(objectives) == 0 {
+			return "❌ Не найдена цель по описанию: " + objectiveDescription, &GetObjectiveDetailsFunction, nil
+		}
+		objectiveID = objectives[0].ID
+	}
+	if objectiveID == "" {
+		return "❌ Не указана цель", &GetObjectiveDetailsFunction, nil
+	}
+
+	details, err := c.okr.GetObjectiveDetails(ctx, userID, objectiveID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить детали цели %s пользователя %d: %v", objectiveID, userID, err)
+		return "❌ Цель не найдена или не принадлежит пользователю", &GetObjectiveDetailsFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🎯 **%s**\n", details.Objective.Title))
+	b.WriteString(fmt.Sprintf("📊 Прогресс: %.1f%%", details.Progress))
+	if details.Objective.Deadline != nil {
+		b.WriteString(fmt.Sprintf(" • 📅 %s", details.Objective.Deadline.Format("02.01.2006")))
+	}
+	if details.TrackedMinutes > 0 {
+		b.WriteString(fmt.Sprintf(" • ⏱ %s", focus.FormatMinutes(details.TrackedMinutes)))
+	}
+	b.WriteString("\n")
+	if details.Closing != nil {
+		b.WriteString(okr.FormatObjectiveClosing(details.Closing) + "\n")
+	}
+	for i, kr := range details.KeyResults {
+		b.WriteString(fmt.Sprintf("%d. %s — %.0f%% (задач: %d)", i+1, kr.KeyResult.Title, kr.Progress, len(kr.Tasks)))
+		if kr.TrackedMinutes > 0 {
+			b.WriteString(fmt.Sprintf(", ⏱ %s", focus.FormatMinutes(kr.TrackedMinutes)))
+		}
+		b.WriteString("\n")
+		for _, r := range kr.Revisions {
+			b.WriteString("   " + okr.FormatTargetRevision(r, kr.KeyResult.Unit) + "\n")
+		}
+	}
+	b.WriteString("\nID цели: " + details.Objective.ID)
+
+	return b.String(), &GetObjectiveDetailsFunction, nil
+}
@@ -0,0 +1,216 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var UpdateObjectiveFunction = ChatGPTFunction{
+	Name:		"update_objective",
+	Description:	"Изменить название, сферу, период или дедлайн существующей цели. Передавай только те поля, которые пользователь хочет поменять",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"objective_id": {
+				Type:		"string",
+				Description:	"ID цели",
+			},
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название или часть названия цели (если ID не указан)",
+			},
+			"title": {
+				Type:		"string",
+				Description:	"Новое название цели",
+			},
+			"sphere": {
+				Type:		"string",
+				Description:	"Новая сфера цели",
+			},
+			"period": {
+				Type:		"string",
+				Description:	"Новый период (week, month, quarter, year)",
+				Enum:		[]string{"week", "month", "quarter", "year"},
+			},
+			"deadline": {
+				Type:		"string",
+				Description:	"Новый дедлайн в формате YYYY-MM-DD",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var UpdateKeyResultFunction = ChatGPTFunction{
+	Name:		"update_key_result",
+	Description:	"Изменить название, единицу измерения или дедлайн ключевого результата. Целевое значение меняй через update_key_result_target, прогресс — через add_key_result_progress",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата",
+			},
+			"key_result_description": {
+				Type:		"string",
+				Description:	"Название или часть названия ключевого результата (если ID не указан)",
+			},
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название цели, чтобы уточнить ключевой результат",
+			},
+			"title": {
+				Type:		"string",
+				Description:	"Новое название ключевого результата",
+			},
+			"unit": {
+				Type:		"string",
+				Description:	"Новая единица измерения",
+			},
+			"deadline": {
+				Type:		"string",
+				Description:	"Новый дедлайн в формате YYYY-MM-DD",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var validObjectivePeriods = map[string]bool{"week": true, "month": true, "quarter": true, "year": true}
+
+func optionalString(args map[string]interface{}, name string) *string {
+	value, _ := args[name].(string)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func optionalDate(args map[string]interface{}, name string) (*time.Time, bool) {
+	value := optionalString(args, name)
+	if value == nil {
+		return nil, true
+	}
+	date, err := time.ParseInLocation("2006-01-02", *value, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &date, true
+}
+
+func (c *ChatGPTService) handleUpdateObjective(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Обновление цели для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	title := optionalString(args, "title")
+	sphere := optionalString(args, "sphere")
+	period := optionalString(args, "period")
+	deadline, ok := optionalDate(args, "deadline")
+	if !ok {
+		return "❌ Неверный формат дедлайна цели. Используйте YYYY-MM-DD", &UpdateObjectiveFunction, nil
+	}
+	if period != nil && !validObjectivePeriods[*period] {
+		return "❌ Период цели должен быть одним из: week, month, quarter, year", &UpdateObjectiveFunction, nil
+	}
+	if title == nil && sphere == nil && period == nil && deadline == nil {
+		return "❌ Не указано, что изменить в цели", &UpdateObjectiveFunction, nil
+	}
+
+	objectiveID, _ := args["objective_id"].(string)
+	if objectiveID == "" {
+		description, _ := args["objective_description"].(string)
+		if strings.TrimSpace(description) == "" {
+			return "❌ Не указан ID или описание цели", &UpdateObjectiveFunction, nil
+		}
+		candidate, err := c.okr.ResolveObjective(ctx, userID, description)
+		if err != nil {
+			return unresolvedEntity(err, &UpdateObjectiveFunction, "❌ Не найдена цель по описанию: "+description)
+		}
+		objectiveID = candidate.ID
+	}
+
+	if err := ownership.MustOwnObjective(ctx, c.db, userID, objectiveID); err != nil {
+		return ownershipErrorMessage(err), &UpdateObjectiveFunction, nil
+	}
+
+	if err := c.okr.UpdateObjective(ctx, userID, objectiveID, title, sphere, period, deadline); err != nil {
+		logrus.Errorf("Ошибка обновления цели %s пользователя %d: %v", objectiveID, userID, err)
+		return "❌ Не удалось обновить цель", &UpdateObjectiveFunction, nil
+	}
+
+	response := "✏️ **Цель обновлена**\n"
+	if title != nil {
+		response += fmt.Sprintf("\n📋 **Название:** %s", *title)
+	}
+	if sphere != nil {
+		response += fmt.Sprintf("\n%s **Сфера:** %s", okr.SphereIcon(*sphere), *sphere)
+	}
+	if period != nil {
+		response += fmt.Sprintf("\n⏰ **Период:** %s", getPeriodName(*period))
+	}
+	if deadline != nil {
+		response += fmt.Sprintf("\n📅 **Дедлайн:** %s", deadline.Format("2006-01-02"))
+	}
+	return response, &UpdateObjectiveFunction, nil
+}
+
+func (c *ChatGPTService) handleUpdateKeyResult(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Обновление ключевого результата для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	title := optionalString(args, "title")
+	unit := optionalString(args, "unit")
+	deadline, ok := optionalDate(args, "deadline")
+	if !ok {
+		return "❌ Неверный формат дедлайна ключевого результата. Используйте YYYY-MM-DD", &UpdateKeyResultFunction, nil
+	}
+	if title == nil && unit == nil && deadline == nil {
+		return "❌ Не указано, что изменить в ключевом результате", &UpdateKeyResultFunction, nil
+	}
+
+	var keyResultID int64
+	if id, ok := args["key_result_id"].(float64); ok && id > 0 {
+		keyResultID = int64(id)
+	} else {
+		description, _ := args["key_result_description"].(string)
+		objectiveDescription, _ := args["objective_description"].(string)
+		if strings.TrimSpace(description) == "" {
+			return "❌ Не указан ID или описание ключевого результата", &UpdateKeyResultFunction, nil
+		}
+		candidate, err := c.okr.ResolveKeyResult(ctx, userID, description, objectiveDescription)
+		if err != nil {
+			return unresolvedEntity(err, &UpdateKeyResultFunction, "❌ Не найден ключевой результат по описанию: "+description)
+		}
+		keyResultID = candidate.NumericID()
+	}
+
+	if err := ownership.MustOwnKeyResult(ctx, c.db, userID, keyResultID); err != nil {
+		return ownershipErrorMessage(err), &UpdateKeyResultFunction, nil
+	}
+
+	if err := c.okr.UpdateKeyResult(ctx, userID, keyResultID, title, unit, nil, nil, deadline, ""); err != nil {
+		logrus.Errorf("Ошибка обновления ключевого результата %d пользователя %d: %v", keyResultID, userID, err)
+		return "❌ Не удалось обновить ключевой результат", &UpdateKeyResultFunction, nil
+	}
+
+	response := "✏️ **Ключевой результат обновлен**\n"
+	if title != nil {
+		response += fmt.Sprintf("\n🔑 **Название:** %s", *title)
+	}
+	if unit != nil {
+		response += fmt.Sprintf("\n📏 **Единица измерения:** %s", *unit)
+	}
+	if deadline != nil {
+		response += fmt.Sprintf("\n📅 **Дедлайн:** %s", deadline.Format("2006-01-02"))
+	}
+	return response, &UpdateKeyResultFunction, nil
+}
@@ -0,0 +1,343 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+var InviteAccountabilityPartnerFunction = ChatGPTFunction{
+	Name:		"invite_accountability_partner",
+	Description:	"Пригласить пользователя бота в партнеры по ответственности по username в Telegram. Партнерство начнется, когда он примет приглашение",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"username": {
+				Type:		"string",
+				Description:	"Username партнера в Telegram (без @)",
+			},
+		},
+		Required:	[]string{"username"},
+	},
+}
+
+var RespondPartnerInvitationFunction = ChatGPTFunction{
+	Name:		"respond_partner_invitation",
+	Description:	"Принять или отклонить приглашение стать партнерами по ответственности",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"accept": {
+				Type:		"boolean",
+				Description:	"true — принять, false — отклонить",
+			},
+			"from": {
+				Type:		"string",
+				Description:	"Имя или username пригласившего (если приглашений несколько)",
+			},
+		},
+		Required:	[]string{"accept"},
+	},
+}
+
+var ShareObjectiveWithPartnerFunction = ChatGPTFunction{
+	Name:		"share_objective_with_partner",
+	Description:	"Открыть партнеру по ответственности прогресс конкретной цели (только просмотр) или закрыть доступ",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"objective": {
+				Type:		"string",
+				Description:	"Название или ID цели",
+			},
+			"partner": {
+				Type:		"string",
+				Description:	"Имя или username партнера (можно не указывать, если партнер один)",
+			},
+			"revoke": {
+				Type:		"boolean",
+				Description:	"true — закрыть партнеру доступ к цели",
+			},
+		},
+		Required:	[]string{"objective"},
+	},
+}
+
+var GetPartnerObjectivesFunction = ChatGPTFunction{
+	Name:		"get_partner_objectives",
+	Description:	"Показать партнеров по ответственности, их открытые цели с прогрессом и цели, которые пользователь открыл им",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+var EndPartnershipFunction = ChatGPTFunction{
+	Name:		"end_partnership",
+	Description:	"Завершить партнерство по ответственности: обе стороны теряют доступ к открытым целям",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"partner": {
+				Type:		"string",
+				Description:	"Имя или username партнера (можно не указывать, если партнер один)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleInviteAccountabilityPartner(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	username, _ := args["username"].(string)
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	partnerID, err := c.findUserIDByUsername(ctx, username)
+	if err != nil {
+		return fmt.Sprintf("❌ Пользователь @%s не найден. Он должен хотя бы раз написать боту", username), &InviteAccountabilityPartnerFunction, nil
+	}
+
+	if _, err := c.okr.InvitePartner(ctx, userID, partnerID); err != nil {
+		return partnerErrorMessage(err), &InviteAccountabilityPartnerFunction, nil
+	}
+	return fmt.Sprintf("📨 Приглашение стать партнерами отправлено @%s. Когда он согласится, выбери, какие цели ему показать", username), &InviteAccountabilityPartnerFunction, nil
+}
+
+func (c *ChatGPTService) handleRespondPartnerInvitation(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	accept := true
+	if v, ok := args["accept"].(bool); ok {
+		accept = v
+	}
+	from, _ := args["from"].(string)
+
+	invitations, err := c.okr.GetPendingPartnerInvitations(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения приглашений в партнеры: %v", err)
+		return "❌ Не удалось получить приглашения", &RespondPartnerInvitationFunction, nil
+	}
+	if len(invitations) == 0 {
+		return "ℹ️ У тебя нет приглашений в партнеры", &RespondPartnerInvitationFunction, nil
+	}
+
+	var invitation *okr.Partnership
+	needle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(from), "@"))
+	for i := range invitations {
+		if needle == "" || strings.Contains(strings.ToLower(invitations[i].OtherName), needle) {
+			invitation = &invitations[i]
+			break
+		}
+	}
+	if invitation == nil || (needle == "" && len(invitations) > 1) {
+		names := make([]string, 0, len(invitations))
+		for _, inv := range invitations {
+			names = append(names, inv.OtherName)
+		}
+		return "ℹ️ Уточни, от кого приглашение: " + strings.Join(names, ", "), &RespondPartnerInvitationFunction, nil
+	}
+
+	if _, err := c.okr.RespondToPartnerInvitation(ctx, userID, invitation.ID, accept); err != nil {
+		logrus.Errorf("Ошибка ответа на приглашение в партнеры %d: %v", invitation.ID, err)
+		return "❌ Приглашение больше не действительно", &RespondPartnerInvitationFunction, nil
+	}
+	if !accept {
+		return fmt.Sprintf("❌ Приглашение от %s отклонено", invitation.OtherName), &RespondPartnerInvitationFunction, nil
+	}
+	return fmt.Sprintf("🤝 Теперь вы с %s партнеры. Открыть цель: «покажи партнеру цель X»", invitation.OtherName), &RespondPartnerInvitationFunction, nil
+}
+
+func (c *ChatGPTService) handleShareObjectiveWithPartner(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	ref, _ := args["objective"].(string)
+	partnerName, _ := args["partner"].(string)
+	revoke, _ := args["revoke"].(bool)
+
+	partnership, err := c.okr.FindPartner(ctx, userID, partnerName)
+	if err != nil {
+		return partnerErrorMessage(err), &ShareObjectiveWithPartnerFunction, nil
+	}
+	objective, message := c.resolveObjectiveRef(ctx, userID, ref)
+	if objective == nil {
+		return message, &ShareObjectiveWithPartnerFunction, nil
+	}
+
+	if revoke {
+		revoked, err := c.okr.RevokeObjectiveFromPartner(ctx, userID, objective.ID, partnership.ID)
+		if err != nil {
+			return partnerErrorMessage(err), &ShareObjectiveWithPartnerFunction, nil
+		}
+		if !revoked {
+			return fmt.Sprintf("ℹ️ Цель «%s» и так не открыта %s", objective.Title, partnership.OtherName), &ShareObjectiveWithPartnerFunction, nil
+		}
+		return fmt.Sprintf("🔒 %s больше не видит цель «%s»", partnership.OtherName, objective.Title), &ShareObjectiveWithPartnerFunction, nil
+	}
+
+	if err := c.okr.ShareObjectiveWithPartner(ctx, userID, objective.ID, partnership.ID); err != nil {
+		return partnerErrorMessage(err), &ShareObjectiveWithPartnerFunction, nil
+	}
+	return fmt.Sprintf("👀 %s теперь видит прогресс цели «%s» (без права менять). В воскресенье обоим придет сводка", partnership.OtherName, objective.Title), &ShareObjectiveWithPartnerFunction, nil
+}
+
+func (c *ChatGPTService) handleGetPartnerObjectives(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	partners, err := c.okr.GetPartners(ctx, userID)
+	if err != nil {
+		return partnerErrorMessage(err), &GetPartnerObjectivesFunction, nil
+	}
+	if len(partners) == 0 {
+		return "ℹ️ Партнеров по ответственности пока нет. Пригласи: «стань моим партнером @username»", &GetPartnerObjectivesFunction, nil
+	}
+
+	theirs, err := c.okr.GetObjectivesSharedWithMe(ctx, userID)
+	if err != nil {
+		return partnerErrorMessage(err), &GetPartnerObjectivesFunction, nil
+	}
+	mine, err := c.okr.GetObjectivesISharedWithPartners(ctx, userID)
+	if err != nil {
+		return partnerErrorMessage(err), &GetPartnerObjectivesFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("🤝 **Партнеры по ответственности**\n")
+	for _, p := range partners {
+		b.WriteString(fmt.Sprintf("\n👤 **%s**\n", p.OtherName))
+		shown := 0
+		for _, o := range theirs {
+			if o.PartnershipID == p.ID {
+				b.WriteString(fmt.Sprintf("• %s — %.0f%%\n", o.Title, o.Progress))
+				shown++
+			}
+		}
+		if shown == 0 {
+			b.WriteString("• пока не открыл ни одной цели\n")
+		}
+
+		var opened []string
+		for _, o := range mine {
+			if o.PartnershipID == p.ID {
+				opened = append(opened, "«"+o.Title+"»")
+			}
+		}
+		if len(opened) > 0 {
+			b.WriteString("Ты открыл: " + strings.Join(opened, ", ") + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), &GetPartnerObjectivesFunction, nil
+}
+
+func (c *ChatGPTService) handleEndPartnership(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	partnerName, _ := args["partner"].(string)
+	partnership, err := c.okr.FindPartner(ctx, userID, partnerName)
+	if err != nil {
+		return partnerErrorMessage(err), &EndPartnershipFunction, nil
+	}
+	if _, err := c.okr.EndPartnership(ctx, userID, partnership.ID); err != nil {
+		return partnerErrorMessage(err), &EndPartnershipFunction, nil
+	}
+	return fmt.Sprintf("👋 Партнерство с %s завершено, доступ к открытым целям закрыт с обеих сторон", partnership.OtherName), &EndPartnershipFunction, nil
+}
+
+func (c *ChatGPTService) handleFindAccountabilityPartner(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	category, _ := args["goal_category"].(string)
+	category = strings.TrimSpace(category)
+	frequency, _ := args["interaction_frequency"].(string)
+
+	partners, err := c.okr.GetPartners(ctx, userID)
+	if err != nil {
+		return partnerErrorMessage(err), &FindAccountabilityPartnerFunction, nil
+	}
+	objectives, err := c.okr.GetObjectives(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения целей пользователя %d: %v", userID, err)
+		return "❌ Не удалось получить цели", &FindAccountabilityPartnerFunction, nil
+	}
+	var matching []okr.Objective
+	for _, o := range objectives {
+		if category == "" || strings.Contains(strings.ToLower(o.Sphere+" "+o.Title), strings.ToLower(category)) {
+			matching = append(matching, o)
+		}
+	}
+
+	var b strings.Builder
+	if len(partners) == 0 {
+		invitations, err := c.okr.GetPendingPartnerInvitations(ctx, userID)
+		if err != nil {
+			return partnerErrorMessage(err), &FindAccountabilityPartnerFunction, nil
+		}
+		if len(invitations) > 0 {
+			names := make([]string, 0, len(invitations))
+			for _, inv := range invitations {
+				names = append(names, inv.OtherName)
+			}
+			b.WriteString(fmt.Sprintf("📨 Тебя уже зовут в партнеры: %s. Ответь «принимаю приглашение от %s» — и сможете видеть цели друг друга\n", strings.Join(names, ", "), invitations[0].OtherName))
+		} else {
+			b.WriteString("🤝 Партнера по ответственности пока нет. Пригласи человека, которому доверяешь: «стань моим партнером @username». Он должен хотя бы раз написать боту\n")
+		}
+	} else {
+		shared, err := c.okr.GetObjectivesISharedWithPartners(ctx, userID)
+		if err != nil {
+			return partnerErrorMessage(err), &FindAccountabilityPartnerFunction, nil
+		}
+		sharedIDs := make(map[string]bool, len(shared))
+		for _, o := range shared {
+			sharedIDs[o.ID] = true
+		}
+
+		names := make([]string, 0, len(partners))
+		for _, p := range partners {
+			names = append(names, p.OtherName)
+		}
+		b.WriteString("🤝 Твои партнеры: " + strings.Join(names, ", ") + "\n")
+
+		var hidden []string
+		for _, o := range matching {
+			if !sharedIDs[o.ID] {
+				hidden = append(hidden, "«"+o.Title+"»")
+			}
+		}
+		switch {
+		case len(hidden) > 0:
+			b.WriteString(fmt.Sprintf("Партнеры еще не видят: %s. Открыть: «покажи партнеру цель %s»\n", strings.Join(hidden, ", "), strings.Trim(hidden[0], "«»")))
+		case len(matching) > 0:
+			b.WriteString("✅ Все подходящие цели уже открыты партнерам\n")
+		}
+	}
+	if len(matching) == 0 {
+		b.WriteString(fmt.Sprintf("ℹ️ Целей по теме «%s» пока нет — создай цель, и партнеру будет что поддержать\n", category))
+	}
+
+	if frequency == "daily" {
+		b.WriteString("📆 Общая сводка приходит обоим по воскресеньям. Для ежедневной поддержки отмечайте прогресс каждый день — партнер видит его сразу")
+	} else {
+		b.WriteString("📆 Каждое воскресенье обоим приходит сводка по открытым целям")
+	}
+	return b.String(), &FindAccountabilityPartnerFunction, nil
+}
+
+func partnerErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, okr.ErrPartnershipNotFound):
+		return "ℹ️ Партнер не найден. Уточни имя или пригласи: «стань моим партнером @username»"
+	case errors.Is(err, okr.ErrAlreadyPartners):
+		return "ℹ️ Вы уже партнеры или приглашение ждет ответа"
+	case errors.Is(err, okr.ErrSelfPartner):
+		return "ℹ️ Нельзя стать партнером самому себе"
+	case errors.Is(err, okr.ErrNotObjectiveOwner):
+		return "🔒 Открывать партнеру можно только свои цели"
+	default:
+		logrus.Errorf("Ошибка партнерства по ответственности: %v", err)
+		return "❌ Не удалось выполнить действие с партнером"
+	}
+}
@@ -0,0 +1,110 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/preferences"
+
+	"github.com/sirupsen/logrus"
+)
+
+var UpdatePreferencesFunction = ChatGPTFunction{
+	Name:		"update_preferences",
+	Description:	"Обновляет предпочтения пользователя на основе обратной связи: стиль общения, тип мотивации, частоту напоминаний, сложность задач, проактивность и краткость ответов",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"preference_type": {
+				Type:		"string",
+				Description:	"Тип предпочтения",
+				Enum:		preferences.Types,
+			},
+			"new_value": {
+				Type:		"string",
+				Description:	"Новое значение предпочтения. communication_style: friendly, professional, concise, energetic, calm; motivation_type: achievement, progress, challenge, social, reward, growth, visualization, storytelling; reminder_frequency: rare, normal, frequent; difficulty_level: easy, medium, hard; proactivity: off, low, medium, high (насколько часто Jarvis пишет первым); verbosity: concise, normal, detailed (краткость ответов)",
+			},
+			"feedback_reason": {
+				Type:		"string",
+				Description:	"Причина изменения предпочтения",
+			},
+		},
+		Required:	[]string{"preference_type", "new_value"},
+	},
+}
+
+var LearnFromFeedbackFunction = ChatGPTFunction{
+	Name:		"learn_from_feedback",
+	Description:	"Обучается на основе обратной связи пользователя",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"feedback_type": {
+				Type:		"string",
+				Description:	"Тип обратной связи",
+				Enum:		preferences.FeedbackTypes,
+			},
+			"context": {
+				Type:		"string",
+				Description:	"Контекст обратной связи",
+			},
+			"specific_feature": {
+				Type:		"string",
+				Description:	"Конкретная функция, к которой относится обратная связь. Для мотивации укажи ее тип: achievement, progress, challenge, social, reward, growth",
+			},
+		},
+		Required:	[]string{"feedback_type", "context"},
+	},
+}
+
+func (c *ChatGPTService) handleUpdatePreferences(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	preferenceType, _ := args["preference_type"].(string)
+	newValue, _ := args["new_value"].(string)
+	reason, _ := args["feedback_reason"].(string)
+
+	prefs, normalized, err := c.preferences.Set(ctx, userID, preferenceType, newValue)
+	if err != nil {
+		if errors.Is(err, preferences.ErrUnknownPreference) || errors.Is(err, preferences.ErrInvalidValue) {
+			return "❌ " + err.Error(), &UpdatePreferencesFunction, nil
+		}
+		logrus.Errorf("Ошибка сохранения предпочтения пользователя %d: %v", userID, err)
+		return "❌ Не удалось сохранить предпочтение", &UpdatePreferencesFunction, nil
+	}
+
+	if strings.TrimSpace(reason) != "" {
+		if err := c.preferences.RecordFeedback(ctx, userID, preferences.FeedbackSuggestion, reason, preferenceType); err != nil {
+			logrus.Warnf("Не удалось сохранить причину изменения предпочтения: %v", err)
+		}
+	}
+
+	response := fmt.Sprintf("✅ %s: %s\n\n%s", preferences.Label(preferenceType), normalized, preferences.Format(prefs))
+	return response, &UpdatePreferencesFunction, nil
+}
+
+func (c *ChatGPTService) handleLearnFromFeedback(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	feedbackType, _ := args["feedback_type"].(string)
+	feedbackContext, _ := args["context"].(string)
+	feature, _ := args["specific_feature"].(string)
+
+	if err := c.preferences.RecordFeedback(ctx, userID, feedbackType, feedbackContext, feature); err != nil {
+		if errors.Is(err, preferences.ErrInvalidFeedback) {
+			return "❌ " + err.Error(), &LearnFromFeedbackFunction, nil
+		}
+		logrus.Errorf("Ошибка сохранения обратной связи пользователя %d: %v", userID, err)
+		return "❌ Не удалось сохранить обратную связь", &LearnFromFeedbackFunction, nil
+	}
+
+	switch feedbackType {
+	case preferences.FeedbackPositive:
+		return "🙏 Спасибо! Запомнил, что это работает для вас — буду делать так чаще", &LearnFromFeedbackFunction, nil
+	case preferences.FeedbackNegative, preferences.FeedbackComplaint:
+		return "📝 Понял, учту и буду реже так делать. Если хотите, скажите, как лучше — например, «пиши короче» или «напоминай реже»", &LearnFromFeedbackFunction, nil
+	default:
+		return "💡 Спасибо за идею, сохранил ее", &LearnFromFeedbackFunction, nil
+	}
+}
@@ -0,0 +1,197 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var RescheduleDayFunction = ChatGPTFunction{
+	Name:		"reschedule_day",
+	Description:	"Разгрузить день или неделю: предложить перенос или отмену событий и сдвиг дедлайнов задач, чтобы освободить указанное время. Сначала вызывай с confirm=false и покажи план, после согласия пользователя — с confirm=true",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"start_date": {
+				Type:		"string",
+				Description:	"Первый день, который нужно разгрузить, в формате YYYY-MM-DD",
+			},
+			"end_date": {
+				Type:		"string",
+				Description:	"Последний день для разгрузки недели или нескольких дней, YYYY-MM-DD (по умолчанию совпадает с start_date)",
+			},
+			"free_from": {
+				Type:		"string",
+				Description:	"Начало освобождаемого времени в каждом дне, HH:MM (например 18:00 для «вечера»; по умолчанию весь день)",
+			},
+			"free_to": {
+				Type:		"string",
+				Description:	"Конец освобождаемого времени в каждом дне, HH:MM (по умолчанию до конца дня)",
+			},
+			"action": {
+				Type:		"string",
+				Description:	"move — переносить события на ближайшее свободное время, cancel — отменять их",
+				Enum:		[]string{"move", "cancel"},
+			},
+			"shift_tasks": {
+				Type:		"boolean",
+				Description:	"Сдвигать дедлайны задач из освобождаемого времени (по умолчанию true)",
+			},
+			"request": {
+				Type:		"string",
+				Description:	"Просьба пользователя своими словами, например «освободи вечер пятницы»",
+			},
+			"confirm": {
+				Type:		"boolean",
+				Description:	"false — только составить и показать план, true — применить последний показанный план",
+			},
+		},
+		Required:	[]string{"start_date", "confirm"},
+	},
+}
+
+var UndoRescheduleFunction = ChatGPTFunction{
+	Name:		"undo_reschedule",
+	Description:	"Откатить последний примененный план разгрузки: вернуть события и дедлайны задач на прежние места",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleRescheduleDay(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Разгрузка расписания для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	if confirm, _ := args["confirm"].(bool); confirm {
+		plan, err := c.calendar.ApplyReschedulePlan(ctx, userID)
+		if errors.Is(err, calendar.ErrPlanNotFound) || errors.Is(err, calendar.ErrPlanOutdated) {
+			return "❌ " + err.Error() + ". Вызови reschedule_day с confirm=false, чтобы показать актуальный план", &RescheduleDayFunction, nil
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка применения плана переноса для пользователя %d: %v", userID, err)
+			return "❌ Не удалось применить план, ничего не изменено", &RescheduleDayFunction, nil
+		}
+		return fmt.Sprintf("✅ **План применен**, изменений: %d\n\nЕсли передумаете — скажите «верни как было» в течение суток", len(plan.Changes)), &RescheduleDayFunction, nil
+	}
+
+	req := calendar.RescheduleRequest{ShiftTasks: true}
+	startStr, _ := args["start_date"].(string)
+	start, err := time.ParseInLocation("2006-01-02", startStr, time.Local)
+	if err != nil {
+		return "❌ Некорректная дата начала, нужен формат YYYY-MM-DD", &RescheduleDayFunction, nil
+	}
+	req.From, req.To = start, start
+	if endStr, _ := args["end_date"].(string); endStr != "" {
+		end, err := time.ParseInLocation("2006-01-02", endStr, time.Local)
+		if err != nil || end.Before(start) || end.Sub(start) > 14*24*time.Hour {
+			return "❌ Некорректная дата окончания: нужен формат YYYY-MM-DD, не раньше начала и не дальше двух недель", &RescheduleDayFunction, nil
+		}
+		req.To = end
+	}
+
+	if req.FreeFrom, err = parseClock(args["free_from"], 0); err != nil {
+		return "❌ Некорректное время free_from, нужен формат HH:MM", &RescheduleDayFunction, nil
+	}
+	if req.FreeTo, err = parseClock(args["free_to"], 24*time.Hour); err != nil {
+		return "❌ Некорректное время free_to, нужен формат HH:MM", &RescheduleDayFunction, nil
+	}
+	if action, _ := args["action"].(string); action == "cancel" {
+		req.CancelEvents = true
+	}
+	if shift, ok := args["shift_tasks"].(bool); ok {
+		req.ShiftTasks = shift
+	}
+	req.Description, _ = args["request"].(string)
+
+	plan, err := c.calendar.PlanReschedule(ctx, userID, req)
+	if err != nil {
+		logrus.Errorf("Ошибка составления плана переноса для пользователя %d: %v", userID, err)
+		return "❌ Не удалось составить план переноса", &RescheduleDayFunction, nil
+	}
+
+	response := calendar.FormatReschedulePlan(plan)
+	if len(plan.Changes) > 0 {
+		response += "\nПрименить этот план? Все изменения пройдут одним действием, и их можно будет отменить"
+	}
+	return response, &RescheduleDayFunction, nil
+}
+
+func (c *ChatGPTService) handleUndoReschedule(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	plan, err := c.calendar.UndoReschedulePlan(context.Background(), userID)
+	if errors.Is(err, calendar.ErrNothingToUndo) {
+		return "ℹ️ " + err.Error(), &UndoRescheduleFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка отмены плана переноса для пользователя %d: %v", userID, err)
+		return "❌ Не удалось вернуть расписание, ничего не изменено", &UndoRescheduleFunction, nil
+	}
+	return fmt.Sprintf("↩️ **Расписание восстановлено**, возвращено изменений: %d", len(plan.Changes)), &UndoRescheduleFunction, nil
+}
+
+func (c *ChatGPTService) handleOptimizeSchedule(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Оптимизация расписания для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	if confirm, _ := args["confirm"].(bool); confirm {
+		plan, err := c.calendar.ApplyReschedulePlan(ctx, userID)
+		if errors.Is(err, calendar.ErrPlanNotFound) || errors.Is(err, calendar.ErrPlanOutdated) {
+			return "❌ " + err.Error() + ". Вызови optimize_schedule с confirm=false, чтобы показать актуальные переносы", &OptimizeScheduleFunction, nil
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка применения оптимизации расписания для пользователя %d: %v", userID, err)
+			return "❌ Не удалось перенести события, ничего не изменено", &OptimizeScheduleFunction, nil
+		}
+		return fmt.Sprintf("✅ **Расписание оптимизировано**, перенесено событий: %d\n\nЕсли передумаете — скажите «верни как было» в течение суток", len(plan.Changes)), &OptimizeScheduleFunction, nil
+	}
+
+	peakHours, err := c.aiCoach.AnalyzePeakProductivityHours(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения пиковых часов пользователя %d: %v", userID, err)
+		return "❌ Не удалось определить часы пиковой продуктивности", &OptimizeScheduleFunction, nil
+	}
+	if len(peakHours) == 0 {
+		return "ℹ️ Пока мало данных, чтобы определить часы пиковой продуктивности. Отмечайте фокус-сессии и выполненные задачи — через несколько дней я смогу перестроить расписание", &OptimizeScheduleFunction, nil
+	}
+
+	plan, err := c.calendar.PlanScheduleOptimization(ctx, userID, peakHours)
+	if err != nil {
+		logrus.Errorf("Ошибка оптимизации расписания для пользователя %d: %v", userID, err)
+		return "❌ Не удалось составить оптимизированное расписание", &OptimizeScheduleFunction, nil
+	}
+
+	hours := make([]string, 0, len(peakHours))
+	for _, hour := range peakHours {
+		hours = append(hours, fmt.Sprintf("%02d:00", hour))
+	}
+	response := fmt.Sprintf("⚡ Ваши пиковые часы продуктивности: %s\n\n", strings.Join(hours, ", "))
+	if len(plan.Changes) == 0 {
+		return response + "✅ Расписание на ближайшую неделю уже хорошо ложится на эти часы — переносить нечего", &OptimizeScheduleFunction, nil
+	}
+	response += calendar.FormatReschedulePlan(plan)
+	response += "\nПеренести эти события? Все изменения пройдут одним действием, и их можно будет отменить"
+	return response, &OptimizeScheduleFunction, nil
+}
+
+func parseClock(value interface{}, fallback time.Duration) (time.Duration, error) {
+	text, _ := value.(string)
+	if text == "" {
+		return fallback, nil
+	}
+	if text == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
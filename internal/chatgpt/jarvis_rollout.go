@@ -0,0 +1,50 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"telegrambot/internal/rollout"
+
+	"github.com/sirupsen/logrus"
+)
+
+var errFunctionArguments = errors.New("ошибка парсинга аргументов функции")
+
+func applyRelease(release *rollout.Release, systemPrompt string, functions []ChatGPTFunction) (string, []ChatGPTFunction) {
+	if addendum := strings.TrimSpace(release.PromptAddendum); addendum != "" {
+		systemPrompt += "\n\n" + addendum
+	}
+
+	overrides, err := release.Overrides()
+	if err != nil {
+		logrus.Errorf("Некорректные переопределения функций в выкатке %s: %v", release.Version, err)
+		return systemPrompt, functions
+	}
+	if len(overrides) == 0 {
+		return systemPrompt, functions
+	}
+
+	result := make([]ChatGPTFunction, 0, len(functions))
+	for _, function := range functions {
+		override, ok := overrides[function.Name]
+		if !ok {
+			result = append(result, function)
+			continue
+		}
+
+		patched := function
+		patched.Parameters.Properties = make(map[string]ChatGPTProperty, len(function.Parameters.Properties))
+		for name, property := range function.Parameters.Properties {
+			patched.Parameters.Properties[name] = property
+		}
+		if err := json.Unmarshal(override, &patched); err != nil {
+			logrus.Errorf("Не удалось применить переопределение функции %s из выкатки %s: %v", function.Name, release.Version, err)
+			result = append(result, function)
+			continue
+		}
+		patched.Name = function.Name
+		result = append(result, patched)
+	}
+	return systemPrompt, result
+}
@@ -3,22 +3,79 @@ package chatgpt
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"telegrambot/internal/ai_coach"
+	"telegrambot/internal/automations"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/challenges"
+	"telegrambot/internal/dates"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/habits"
+	"telegrambot/internal/health"
+	"telegrambot/internal/health/nutrition"
+	"telegrambot/internal/journal"
+	"telegrambot/internal/meetings"
+	"telegrambot/internal/messagestore"
 	"telegrambot/internal/messagestore/models"
+	"telegrambot/internal/notifications"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/preferences"
+	"telegrambot/internal/rollout"
+	"telegrambot/internal/semantic"
+	"telegrambot/internal/support"
+	"telegrambot/internal/slack"
+	"telegrambot/internal/travel"
+	"telegrambot/internal/wellbeing"
+	"telegrambot/internal/workouts"
+	"telegrambot/internal/worklocation"
 	"telegrambot/pkg/config"
+	"telegrambot/pkg/tracing"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/sashabaranov/go-openai"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ChatGPTService struct {
-	client	*openai.Client
-	aiCoach	*ai_coach.AICoachService
-	db	*sqlx.DB
+	client		*openai.Client
+	apiKey		string
+	aiCoach		*ai_coach.AICoachService
+	dates		*dates.Service
+	finance		*finance.Service
+	okr		*okr.Service
+	slack		*slack.Client
+	locations	*worklocation.Service
+	travel		*travel.Service
+	health		*health.Service
+	habits		*habits.Service
+	workouts	*workouts.Service
+	nutrition	*nutrition.Service
+	calendar	*calendar.Service
+	focus		*focus.Service
+	automations	*automations.Service
+	challenges	*challenges.Service
+	wellbeing	*wellbeing.Service
+	rollout		*rollout.Service
+	preferences	*preferences.Service
+	messages	*messagestore.Service
+	semantic	*semantic.Service
+	notifications	*notifications.Service
+	support		*support.Service
+	meetings	*meetings.Service
+	journal		*journal.Service
+	db		*sqlx.DB
+}
+
+type Reply struct {
+	Text		string
+	Function	string
+	Arguments	map[string]interface{}
+	Choices		[]ReplyChoice
 }
 
 type ChatGPTFunctionCall struct {
@@ -48,19 +105,50 @@ type ChatGPTProperty struct {
 	Maximum		interface{}			`json:"maximum,omitempty"`
 }
 
-func NewChatGPTService(cfg *config.Config, db *sqlx.DB) *ChatGPTService {
+func NewChatGPTService(cfg *config.Config, db *sqlx.DB, rolloutService *rollout.Service) *ChatGPTService {
 	client := openai.NewClient(cfg.OpenAIKey)
 	aiCoach := ai_coach.NewAICoachService(db)
+	okrService := okr.NewService(db)
+	calendarService := calendar.NewService(db, cfg)
+	preferencesService := preferences.NewService(db)
 
-	return &ChatGPTService{
+	service := &ChatGPTService{
 		client:		client,
+		apiKey:		cfg.OpenAIKey,
 		aiCoach:	aiCoach,
+		dates:		dates.NewService(db),
+		finance:	finance.NewService(db),
+		okr:		okrService,
+		slack:		slack.NewClient(),
+		locations:	worklocation.NewService(db),
+		travel:		travel.NewService(db, calendarService),
+		health:		health.NewService(db),
+		habits:		habits.NewService(db, okrService),
+		workouts:	workouts.NewService(db, calendarService, okrService),
+		nutrition:	nutrition.NewService(db, okrService),
+		calendar:	calendarService,
+		focus:		focus.NewService(db),
+		automations:	automations.NewService(db, okrService, calendarService),
+		challenges:	challenges.NewService(db),
+		wellbeing:	wellbeing.NewService(db),
+		rollout:	rolloutService,
+		preferences:	preferencesService,
+		messages:	messagestore.NewService(messagestore.NewRepository(db, cfg)),
+		semantic:	semantic.NewService(db, cfg),
+		notifications:	notifications.NewService(db, preferencesService),
+		support:	support.NewService(db, cfg),
+		meetings:	meetings.NewService(db),
+		journal:	journal.NewService(db),
 		db:		db,
 	}
+	if cfg.PredictionLLMEnabled == "true" {
+		aiCoach.SetCompletionAdvisor(service.AdviseCompletion)
+	}
+	return service
 }
 
-func (c *ChatGPTService) ProcessMessage(ctx context.Context, userID int64, message string, history []models.MessageHistoryItem) (string, error) {
-	logrus.Infof("Обработка сообщения от пользователя %d через Jarvis", userID)
+func (c *ChatGPTService) ProcessMessage(ctx context.Context, userID int64, message string, history []models.MessageHistoryItem) (*Reply, error) {
+	logrus.Debugf("Обработка сообщения от пользователя %d через Jarvis", userID)
 
 	userContext, err := c.aiCoach.GetCurrentContext(ctx, userID)
 	if err != nil {
@@ -74,57 +162,83 @@ func (c *ChatGPTService) ProcessMessage(ctx context.Context, userID int64, messa
 	}
 
 	systemPrompt := c.buildJarvisSystemPrompt(userContext, personality)
+	systemPrompt += c.ratingGuidance(ctx, userID)
+	systemPrompt += c.conversationMemory(ctx, userID)
+	generation := c.generationSettings(ctx, userID)
+	systemPrompt += generation.Instruction
+
+	jarvisFunctions := append(GetAllJarvisFunctions(), pluginFunctions()...)
+
+	version := rollout.StableVersion
+	if release := c.rollout.Assign(ctx, userID); release != nil {
+		version = release.Version
+		systemPrompt, jarvisFunctions = applyRelease(release, systemPrompt, jarvisFunctions)
+	}
 
-	jarvisFunctions := GetAllJarvisFunctions()
 	functions := c.convertToOpenAIFunctions(jarvisFunctions)
 
-	logrus.Infof("Передаем %d функций в OpenAI для пользователя %d", len(functions), userID)
+	logrus.Debugf("Передаем %d функций в OpenAI для пользователя %d", len(functions), userID)
 	for _, f := range functions {
 		logrus.Debugf("Функция: %s - %s", f.Name, f.Description)
 	}
 
 	messages := c.buildMessages(systemPrompt, message, history)
 
-	logrus.Infof("Отправляем запрос в OpenAI с %d сообщениями и %d функциями", len(messages), len(functions))
+	logrus.Debugf("Отправляем запрос в OpenAI с %d сообщениями и %d функциями", len(messages), len(functions))
 
-	response, functionCall, err := c.sendChatCompletionRequest(ctx, messages, functions)
+	response, functionCall, err := c.sendChatCompletionRequest(ctx, messages, functions, generation.Temperature)
 	if err != nil {
-		return "", err
+		if errors.Is(err, errFunctionArguments) {
+			c.rollout.RecordOutcome(ctx, version, userID, "", rollout.OutcomeParseError)
+		}
+		return nil, err
 	}
 
 	if functionCall != nil {
-		logrus.Infof("ChatGPT вызвал функцию: %s с аргументами: %+v", functionCall.Name, functionCall.Arguments)
+		logrus.Debugf("ChatGPT вызвал функцию: %s с аргументами: %+v", functionCall.Name, functionCall.Arguments)
 
-		result, _, err := c.handleFunctionCall(functionCall, userID)
+		result, _, err := c.handleFunctionCall(ctx, functionCall, userID)
+		var ambiguous *okr.AmbiguousMatchError
+		if errors.As(err, &ambiguous) {
+			c.rollout.RecordOutcome(ctx, version, userID, functionCall.Name, rollout.OutcomeSuccess)
+			return c.askToDisambiguate(ctx, userID, functionCall, ambiguous)
+		}
 		if err != nil {
 			logrus.Errorf("Ошибка выполнения функции %s: %v", functionCall.Name, err)
-			return fmt.Sprintf("Произошла ошибка при выполнении функции: %v", err), nil
+			c.rollout.RecordOutcome(ctx, version, userID, functionCall.Name, rollout.OutcomeFunctionError)
+			var fnErr *FunctionError
+			if errors.As(err, &fnErr) {
+				return &Reply{Text: fnErr.UserMessage(), Function: functionCall.Name}, nil
+			}
+			return &Reply{Text: "❌ Не удалось выполнить запрос. Попробуйте еще раз или уточните детали", Function: functionCall.Name}, nil
 		}
 
-		logrus.Infof("Функция %s выполнена успешно для пользователя %d", functionCall.Name, userID)
+		logrus.Debugf("Функция %s выполнена успешно для пользователя %d", functionCall.Name, userID)
+		c.rollout.RecordOutcome(ctx, version, userID, functionCall.Name, rollout.OutcomeSuccess)
 
 		c.updateConversationContext(ctx, userID, message, functionCall.Name)
 
-		return result, nil
+		return &Reply{Text: result, Function: functionCall.Name, Arguments: functionCall.Arguments}, nil
 	}
 
-	logrus.Infof("ChatGPT НЕ вызвал никаких функций для сообщения: %s", message)
+	logrus.Debugf("ChatGPT НЕ вызвал никаких функций для сообщения: %s", message)
+	c.rollout.RecordOutcome(ctx, version, userID, "", rollout.OutcomeNoCall)
 
 	c.updateConversationContext(ctx, userID, message, "chat")
 
 	c.learnFromInteraction(ctx, userID, message, response)
 
-	return response, nil
+	return &Reply{Text: response, Function: ReplyFunctionChat}, nil
 }
 
-func (c *ChatGPTService) ProcessAudioMessage(ctx context.Context, userID int64, audioData []byte, history []models.MessageHistoryItem) (string, error) {
+func (c *ChatGPTService) ProcessAudioMessage(ctx context.Context, userID int64, audioData []byte, history []models.MessageHistoryItem) (*Reply, error) {
 
 	transcription, err := c.transcribeAudio(ctx, audioData)
 	if err != nil {
-		return "", fmt.Errorf("ошибка транскрибации аудио: %w", err)
+		return nil, fmt.Errorf("ошибка транскрибации аудио: %w", err)
 	}
 
-	logrus.Infof("Транскрибированное сообщение от пользователя %d: %s", userID, transcription)
+	logrus.Debugf("Транскрибированное сообщение от пользователя %d: %s", userID, transcription)
 
 	return c.ProcessMessage(ctx, userID, transcription, history)
 }
@@ -187,8 +301,52 @@ func (c *ChatGPTService) buildJarvisSystemPrompt(userContext map[string]interfac
 
 КОГДА ИСПОЛЬЗОВАТЬ ФУНКЦИИ:
 ❗ create_objective: "хочу стать...", "планирую...", "моя цель...", "достичь...", упоминания планов/мечт
-❗ get_objectives: "мои цели", "что у меня", "покажи цели", "какие цели"
+❗ get_objectives: "мои цели", "что у меня", "покажи цели", "какие цели", "цели по здоровью" (sphere), "что горит в ближайшие 2 недели" (deadline_within_days=14)
+❗ get_tasks: "мои задачи", "задачи по карьере" (sphere), "что сделать на этой неделе" (deadline_within_days=7), "покажи еще" (offset из прошлого ответа)
+❗ get_objective_details: "покажи цель про английский", "что с целью X", "подробнее о цели" — одна конкретная цель
 ❗ add_key_result_progress: "сделал", "выполнил", упоминания прогресса
+❗ add_shared_transaction: траты и доходы в общий/семейный бюджет
+❗ add_important_date: "у мамы ДР 12 марта", "запомни годовщину", дни рождения и памятные даты
+❗ assign_key_result_owner: "ответственный за...", "поручи @username ключевой результат" в командных целях
+❗ import_travel_booking: пересланные подтверждения бронирования (авиабилеты, отели, поезда)
+❗ add_medication: "напоминай пить витамин D", "курс таблеток 2 раза в день"
+❗ log_medication_taken: "принял витамин", "выпил таблетку"
+❗ generate_workout_plan: "составь план тренировок", "хочу тренироваться по пн, ср, пт"
+❗ complete_workout: "потренировался", "сделал тренировку", "пропустил тренировку"
+❗ log_meal: "съел овсянку", "на обед борщ и котлета", любые упоминания еды
+❗ log_sleep: "лег в 23:30, встал в 7", "спал с часу до восьми"
+❗ start_focus_session / stop_focus_session: "начинаю фокус", "сел за глубокую работу", "закончил фокус"
+❗ log_quick_counter: "выпил стакан воды", "прошел 5000 шагов", "прочитал 20 страниц"
+❗ create_automation_rule: "если по KR нет прогресса 3 дня — создай задачу", "напоминай, если за неделю до дедлайна KR не готов"
+❗ log_challenge_progress: "сделал 50 отжиманий в челлендже", "прошел 12000 шагов для челленджа"
+❗ check_wellbeing: "сегодня стресс 4 из 5", "плохо спал", "совсем нет баланса работы и жизни"
+❗ update_preferences: "пиши короче", "отвечай подробнее" (verbosity), "напоминай реже", "давай задачи посложнее", "меня мотивируют соревнования", "не пиши мне первым" (proactivity)
+❗ search_history: "что я говорил о запуске курса в марте?", "когда я писал про ремонт", "напомни, что я планировал по отпуску"
+❗ set_do_not_disturb: "не беспокой до завтра", "тишина на 2 часа", "не пиши до 18:00", "можно снова писать" (off)
+❗ add_transaction: "потратил 500 на продукты", "получил зарплату 120000", "потратил 15к на курс — цель Образование" (objective)
+❗ get_quarter_retrospective: "итоги квартала", "сколько денег ушло на цели", "ретроспектива за Q2"
+❗ invite_accountability_partner: "стань моим партнером @anna", "хочу партнера по ответственности @ivan"; share_objective_with_partner: "покажи партнеру цель про бег", "закрой Ане цель X" (revoke)
+❗ close_objective: "закрой цель про марафон", "цель выполнена, убери ее", "эта цель больше не актуальна" (archived). Сначала спроси, что сработало и что нет
+❗ share_goal: "дай ссылку на цель, покажу ментору", "хочу похвастаться целью перед друзьями" (achievement), "отзови ссылку на цель X" (revoke)
+❗ manage_spheres: "какие есть сферы?" (list), "добавь сферу Волонтерство 🤝" (add), "удали сферу X" (delete). Сферу цели выбирай из списка: спорт и фитнес — это Здоровье
+❗ create_objective_from_template: "хочу цель по шаблону", "дай готовый план для изучения языка", "начну копить — есть шаблон?" (get_objective_templates — показать список)
+❗ restore_objective: "верни удаленную цель", "отмени удаление", "восстанови задачу про отчет" (kind=task)
+❗ update_key_result_target: "давай снизим цель до 50 подписчиков", "подниму планку до 20 км — иду с опережением" (спроси причину, если не сказал)
+❗ log_time: "потратил 3 часа на проект X", "вчера час учил испанский — цель Языки" (не путай с log_focus_time для глубокой работы без цели)
+❗ start_time_tracking: "начни трекать время по задаче X", "засеки время на отчет"; stop_time_tracking: "стоп таймер", "закончил с задачей"
+❗ escalate_to_human: "позовите человека", "хочу поговорить с оператором", "ты меня не понимаешь, это уже третий раз" (раздражение, повторные неудачи)
+❗ generate_weekly_plan: "составь план на неделю" (confirm=false — план и предложенные блоки), "да, добавь в календарь" (confirm=true); get_weekly_plan_progress: "как я иду по плану?", "что я пропустил на этой неделе?"
+❗ reschedule_day: "разгрузи мою пятницу", "освободи вечер пятницы", "перенеси все со среды"; undo_reschedule: "верни как было"
+❗ optimize_schedule: "оптимизируй мое расписание", "перестрой неделю под мои продуктивные часы" (confirm=false — предложить переносы), "да, переноси" (confirm=true)
+❗ suggest_free_slots: "когда я свободен на неделе?", "найди час на созвон"; если время уже занято — спроси "у тебя в это время уже встреча X — перенести или создать поверх?"
+❗ find_meeting_slot: "когда мы с @ivan оба свободны?", "найди время для встречи с @anna на следующей неделе" — покажи варианты и уточни, какой подходит
+❗ get_journal_entries: "что я писал в дневнике на этой неделе?", "когда я упоминал бег?", "что у меня получалось в марте?"
+❗ set_work_location: "завтра работаю из дома", "по пятницам я в офисе", "с 10 по 14 в командировке"
+❗ create_event / update_event / delete_event: "поставь врача завтра в 15:00", "перенеси созвон на 16:00", "удали тренировку в четверг" (если ID неизвестен — event_title и event_date)
+❗ update_objective / update_key_result: "переименуй цель X", "сдвинь дедлайн цели на конец года", "считай KR в километрах"
+❗ delete_transaction: "удали последнюю трату", "я ошибся с суммой, убери запись" (last=true)
+❗ find_accountability_partner: "с кем бы вместе бегать?", "хочу, чтобы кто-то следил за моим прогрессом по английскому"
+❗ suggest_break: "устал, что делать?", "сижу три часа без перерыва"; check_achievements: "какие у меня достижения?", "сколько осталось до следующей награды?"
 
 СТРУКТУРА OKR:
 - Objective: амбициозная качественная цель
@@ -203,10 +361,51 @@ func (c *ChatGPTService) buildJarvisSystemPrompt(userContext map[string]interfac
 ДОСТУПНЫЕ ФУНКЦИИ:
 - create_objective: создание новых целей OKR
 - get_objectives: получение списка целей  
+- get_objective_details: карточка одной цели с раскрытием KR и задач
 - create_key_result: добавление ключевых результатов
 - add_key_result_progress: обновление прогресса
+- update_objective / update_key_result: изменить название, сферу, период, единицу измерения или дедлайн
+- create_event / update_event / delete_event: события календаря с проверкой занятости
 - analyze_productivity: анализ продуктивности
-- generate_motivation: создание мотивации`
+- generate_motivation: создание мотивации
+- add_important_date / get_important_dates / delete_important_date: дни рождения и важные даты
+- create_finance_space / invite_to_finance_space / add_shared_transaction / get_shared_finance_summary / set_shared_budget: общий семейный бюджет
+- create_okr_team / add_okr_team_member / respond_team_invitation / share_objective_with_team / assign_key_result_owner / get_team_okr_report: командные цели по приглашению, ответственные за ключевые результаты и вклад участников
+- set_team_notifications: канал уведомлений команды (Telegram, Slack или оба)
+- set_work_location / get_work_locations: откуда работаю (офис, дом, командировка) по датам и по постоянному графику
+- import_travel_booking / get_trips: поездки из подтверждений бронирования с событиями в календаре
+- add_medication / log_medication_taken / get_medications / update_medication_schedule / stop_medication: напоминания о лекарствах и статистика соблюдения
+- set_quick_counter / log_quick_counter / get_quick_counters: быстрые счетчики воды, шагов и страниц с кнопками в одно нажатие
+- generate_workout_plan / complete_workout / get_workout_plan: план тренировок под цели здоровья с событиями в календаре и учетом в ключевом результате
+- log_meal / set_nutrition_target / get_nutrition_summary / delete_last_meal: дневник питания с оценкой калорий и БЖУ, норма калорий и недельная сводка
+- log_sleep / get_sleep_stats: дневник сна; утренняя сводка приходит с учетом обычного времени пробуждения
+- start_focus_session / stop_focus_session / log_focus_time / set_deep_work_target / get_focus_stats / schedule_focus_blocks: фокус-сессии, недельная цель по глубокой работе и фокус-блоки в календаре
+- create_automation_rule / get_automation_rules / delete_automation_rule: правила "если условие по KR → действие" (задача, напоминание, сдвиг события)
+- create_challenge / log_challenge_progress / get_challenge_leaderboard: челленджи с друзьями и таблица лидеров
+- check_wellbeing / get_wellbeing_trends: ежедневные отметки стресса, сна и баланса с динамикой по неделям и оценкой риска выгорания
+- suggest_break: перерыв с учетом времени работы, текущей фокус-сессии, энергии и последней отметки стресса
+- check_achievements: полученные достижения, очки и прогресс к следующим
+- search_history: поиск по смыслу в прошлых сообщениях пользователя, с периодом from_date/to_date
+- set_do_not_disturb: режим «не беспокоить» на период, проактивные сообщения придут сводкой после
+- add_transaction: личный доход/расход, можно привязать к цели (objective) или KR (key_result_id)
+- delete_transaction: удалить ошибочную операцию по ID или последнюю записанную
+- get_quarter_retrospective: прогресс целей за квартал, вложенные в них деньги и частые понижения целевых значений
+- find_accountability_partner: подсказать, как найти партнера и какие цели ему открыть
+- invite_accountability_partner / respond_partner_invitation / share_objective_with_partner / get_partner_objectives / end_partnership: партнеры по ответственности по взаимному согласию, выборочно открытые цели только на просмотр и недельная сводка обоим
+- close_objective: завершить или архивировать цель с ретроспективой «что сработало / что нет»
+- share_goal: ссылка только для просмотра прогресса цели (друзьям, ментору) и ее отзыв
+- manage_spheres: стандартные сферы целей с иконками и свои сферы пользователя
+- get_objective_templates / create_objective_from_template: библиотека готовых целей (фитнес, контент, накопления, язык) с ключевыми результатами и ежедневными задачами
+- restore_objective: восстановить удаленную цель, KR или задачу (удаленное хранится 30 дней)
+- update_key_result_target: изменить целевое значение KR с сохранением истории изменений и причины
+- log_time / start_time_tracking / stop_time_tracking / get_time_allocation: учет времени по целям, KR и задачам (вручную или таймером), распределение времени за неделю по сферам
+- escalate_to_human: передать разговор живому оператору поддержки (или команда /support)
+- generate_weekly_plan / get_weekly_plan_progress: недельный план с блоками в календаре после подтверждения и сверкой выполнения по фокус-сессиям
+- reschedule_day / undo_reschedule: план разгрузки дня или недели (перенос/отмена событий, сдвиг дедлайнов), применяется после подтверждения и откатывается целиком
+- optimize_schedule: перенос событий ближайшей недели под часы пиковой продуктивности, применяется после подтверждения и откатывается через undo_reschedule
+- suggest_free_slots: свободные окна в календаре с учетом событий и встреч
+- find_meeting_slot: общее свободное время с другим пользователем в рабочие часы
+- get_journal_entries: записи вечернего дневника и итоги недели (вечерний вопрос настраивается командой /journal)`
 
 	if userContext != nil {
 		if moodCtx, ok := userContext["mood"]; ok {
@@ -240,10 +439,9 @@ func (c *ChatGPTService) buildMessages(systemPrompt, message string, history []m
 		Content:	systemPrompt,
 	})
 
-	historyLimit := 10
 	startIndex := 0
-	if len(history) > historyLimit {
-		startIndex = len(history) - historyLimit
+	if len(history) > recentHistoryLimit {
+		startIndex = len(history) - recentHistoryLimit
 	}
 
 	for i := startIndex; i < len(history); i++ {
@@ -261,14 +459,15 @@ func (c *ChatGPTService) buildMessages(systemPrompt, message string, history []m
 	return messages
 }
 
-func (c *ChatGPTService) sendChatCompletionRequest(ctx context.Context, messages []openai.ChatCompletionMessage, functions []openai.FunctionDefinition) (string, *ChatGPTFunctionCall, error) {
+func (c *ChatGPTService) sendChatCompletionRequest(ctx context.Context, messages []openai.ChatCompletionMessage, functions []openai.FunctionDefinition, temperature float32) (string, *ChatGPTFunctionCall, error) {
 	req := openai.ChatCompletionRequest{
 		Model:		openai.GPT4Dot1,
 		Messages:	messages,
 		Functions:	functions,
+		Temperature:	temperature,
 	}
 
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+	resp, err := createChatCompletion(ctx, c.client, req)
 	if err != nil {
 		return "", nil, fmt.Errorf("ошибка запроса к OpenAI: %w", err)
 	}
@@ -282,7 +481,7 @@ func (c *ChatGPTService) sendChatCompletionRequest(ctx context.Context, messages
 	if choice.Message.FunctionCall != nil {
 		var args map[string]interface{}
 		if err := json.Unmarshal([]byte(choice.Message.FunctionCall.Arguments), &args); err != nil {
-			return "", nil, fmt.Errorf("ошибка парсинга аргументов функции: %w", err)
+			return "", nil, fmt.Errorf("%w: %v", errFunctionArguments, err)
 		}
 
 		return "", &ChatGPTFunctionCall{
@@ -294,14 +493,12 @@ func (c *ChatGPTService) sendChatCompletionRequest(ctx context.Context, messages
 	return choice.Message.Content, nil, nil
 }
 
-func (c *ChatGPTService) handleFunctionCall(functionCall *ChatGPTFunctionCall, userID int64) (string, *ChatGPTFunction, error) {
-
-	result, function, err := c.handleNewJarvisFunctions(functionCall, userID)
-	if err == nil {
-		return result, function, nil
-	}
+func (c *ChatGPTService) handleFunctionCall(ctx context.Context, functionCall *ChatGPTFunctionCall, userID int64) (string, *ChatGPTFunction, error) {
+	ctx, span := tracing.Start(ctx, "jarvis.function", attribute.String("jarvis.function", functionCall.Name))
 
-	return "", nil, fmt.Errorf("неизвестная функция: %s", functionCall.Name)
+	result, function, err := c.runFunctionGuarded(ctx, functionCall, userID)
+	tracing.End(span, err)
+	return result, function, err
 }
 
 func (c *ChatGPTService) convertToOpenAIFunctions(jarvisFunctions []ChatGPTFunction) []openai.FunctionDefinition {
@@ -346,6 +543,13 @@ func (c *ChatGPTService) convertProperties(properties map[string]ChatGPTProperty
 			if len(prop.Items.Enum) > 0 {
 				propMap["items"].(map[string]interface{})["enum"] = prop.Items.Enum
 			}
+			if len(prop.Items.Properties) > 0 {
+				propMap["items"].(map[string]interface{})["properties"] = c.convertProperties(prop.Items.Properties)
+			}
+		}
+
+		if len(prop.Properties) > 0 {
+			propMap["properties"] = c.convertProperties(prop.Properties)
 		}
 
 		if prop.Minimum != nil {
@@ -385,8 +589,12 @@ func (c *ChatGPTService) learnFromInteraction(ctx context.Context, userID int64,
 }
 
 func (c *ChatGPTService) transcribeAudio(ctx context.Context, audioData []byte) (string, error) {
+	return c.TranscribeAudioChunk(ctx, audioData, ".ogg")
+}
+
+func (c *ChatGPTService) TranscribeAudioChunk(ctx context.Context, audioData []byte, extension string) (string, error) {
 
-	tempFile, err := os.CreateTemp("", "audio-*.ogg")
+	tempFile, err := os.CreateTemp("", "audio-*"+extension)
 	if err != nil {
 		return "", fmt.Errorf("ошибка создания временного файла: %w", err)
 	}
@@ -397,8 +605,9 @@ func (c *ChatGPTService) transcribeAudio(ctx context.Context, audioData []byte)
 		return "", fmt.Errorf("ошибка записи аудиоданных: %w", err)
 	}
 
-	resp, err := c.client.CreateTranscription(
+	resp, err := createTranscription(
 		ctx,
+		c.client,
 		openai.AudioRequest{
 			Model:		openai.Whisper1,
 			FilePath:	tempFile.Name(),
@@ -0,0 +1,62 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (c *ChatGPTService) handleShareGoal(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	ref, _ := args["goal_id"].(string)
+	sharingType, _ := args["sharing_type"].(string)
+	audience, _ := args["audience"].(string)
+	revoke, _ := args["revoke"].(bool)
+
+	objective, message := c.resolveObjectiveRef(ctx, userID, ref)
+	if objective == nil {
+		return message, &ShareGoalFunction, nil
+	}
+
+	if revoke {
+		revoked, err := c.okr.RevokeShareLinks(ctx, userID, objective.ID)
+		if err != nil {
+			logrus.Errorf("Ошибка отзыва ссылки на цель %s: %v", objective.ID, err)
+			return "❌ Не удалось отозвать ссылку", &ShareGoalFunction, nil
+		}
+		if revoked == 0 {
+			return fmt.Sprintf("ℹ️ У цели «%s» нет активной ссылки", objective.Title), &ShareGoalFunction, nil
+		}
+		return fmt.Sprintf("🔒 Ссылка на цель «%s» отозвана и больше не открывается", objective.Title), &ShareGoalFunction, nil
+	}
+
+	link, err := c.okr.CreateShareLink(ctx, userID, objective.ID, sharingType, audience)
+	if ownership.IsNotOwned(err) {
+		return "🔒 Делиться можно только своими целями", &ShareGoalFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка создания ссылки на цель %s: %v", objective.ID, err)
+		return "❌ Не удалось создать ссылку", &ShareGoalFunction, nil
+	}
+
+	url := c.calendar.PublicURL(okr.ShareLinkPathPrefix + link.Token)
+	return fmt.Sprintf("🔗 %s\n\n%s\n\nПо ссылке виден только прогресс цели, изменить ничего нельзя. Отозвать: «отзови ссылку на цель %s»",
+		shareGoalIntro(link.SharingType, objective.Title), url, objective.Title), &ShareGoalFunction, nil
+}
+
+func shareGoalIntro(sharingType, title string) string {
+	switch sharingType {
+	case "achievement":
+		return fmt.Sprintf("Похвастайся: цель «%s» — вот чего удалось добиться", title)
+	case "help_request":
+		return fmt.Sprintf("Попроси совета: покажи, где застряла цель «%s»", title)
+	case "motivation":
+		return fmt.Sprintf("Пусть близкие поддержат тебя на пути к цели «%s»", title)
+	default:
+		return fmt.Sprintf("Ссылка на прогресс цели «%s»", title)
+	}
+}
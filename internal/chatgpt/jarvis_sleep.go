@@ -0,0 +1,106 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/health"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var LogSleepFunction = ChatGPTFunction{
+	Name:		"log_sleep",
+	Description:	"Записать сон пользователя. Используй, когда пользователь говорит 'лег в 23:30, встал в 7', 'спал с часу до восьми, выспался плохо'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"bed_time": {
+				Type:		"string",
+				Description:	"Время отхода ко сну в формате ЧЧ:ММ",
+			},
+			"wake_time": {
+				Type:		"string",
+				Description:	"Время пробуждения в формате ЧЧ:ММ",
+			},
+			"quality": {
+				Type:		"integer",
+				Description:	"Качество сна от 1 (ужасно) до 5 (отлично), если пользователь его оценил",
+				Minimum:	1,
+				Maximum:	5,
+			},
+		},
+		Required:	[]string{"bed_time", "wake_time"},
+	},
+}
+
+var GetSleepStatsFunction = ChatGPTFunction{
+	Name:		"get_sleep_stats",
+	Description:	"Показать статистику сна за последние дни и обычное время пробуждения",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"days": {
+				Type:		"integer",
+				Description:	"За сколько последних дней показать сон (по умолчанию 7)",
+				Minimum:	1,
+				Maximum:	60,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleLogSleep(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Запись сна для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	bed, _ := args["bed_time"].(string)
+	wake, _ := args["wake_time"].(string)
+	quality := 0
+	if v, ok := args["quality"].(float64); ok {
+		quality = int(v)
+	}
+
+	bedTime, wakeTime, err := health.ResolveSleepWindow(bed, wake, time.Now())
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err), &LogSleepFunction, nil
+	}
+
+	log, err := c.health.LogSleep(ctx, userID, bedTime, wakeTime, quality, health.SleepSourceManual)
+	if err != nil {
+		logrus.Errorf("Ошибка записи сна: %v", err)
+		return fmt.Sprintf("❌ Не удалось записать сон: %v", err), &LogSleepFunction, nil
+	}
+
+	return fmt.Sprintf("😴 Записал сон: %s", health.FormatSleepLog(log)), &LogSleepFunction, nil
+}
+
+func (c *ChatGPTService) handleGetSleepStats(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	days := 7
+	if v, ok := args["days"].(float64); ok && v > 0 {
+		days = int(v)
+	}
+
+	logs, err := c.health.GetSleepLogs(ctx, userID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logrus.Errorf("Ошибка получения записей сна: %v", err)
+		return "❌ Не удалось получить данные о сне", &GetSleepStatsFunction, nil
+	}
+	if len(logs) == 0 {
+		return "😴 Записей о сне пока нет. Скажите, например: 'лег в 23:30, встал в 7:10'", &GetSleepStatsFunction, nil
+	}
+
+	response := fmt.Sprintf("😴 **Сон за %d дн.**\n\n", days) + health.FormatSleepSummary(logs)
+
+	if wake, ok, err := c.health.TypicalWakeTime(ctx, userID); err != nil {
+		logrus.Errorf("Ошибка расчета времени пробуждения: %v", err)
+	} else if ok {
+		response += fmt.Sprintf("\n\n⏰ Обычно вы просыпаетесь в %02d:%02d — утренняя сводка придет примерно через полчаса после этого", int(wake.Hours()), int(wake.Minutes())%60)
+	}
+
+	return response, &GetSleepStatsFunction, nil
+}
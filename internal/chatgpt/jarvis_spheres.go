@@ -0,0 +1,86 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ManageSpheresFunction = ChatGPTFunction{
+	Name:		"manage_spheres",
+	Description:	"Показать сферы целей (стандартные и свои), добавить свою сферу с иконкой или удалить ее",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"action": {
+				Type:		"string",
+				Description:	"Действие",
+				Enum:		[]string{"list", "add", "delete"},
+			},
+			"name": {
+				Type:		"string",
+				Description:	"Название своей сферы для add/delete",
+			},
+			"icon": {
+				Type:		"string",
+				Description:	"Эмодзи-иконка своей сферы для add",
+			},
+		},
+		Required:	[]string{"action"},
+	},
+}
+
+func (c *ChatGPTService) handleManageSpheres(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	action, _ := args["action"].(string)
+	name, _ := args["name"].(string)
+	icon, _ := args["icon"].(string)
+
+	switch action {
+	case "add":
+		sphere, err := c.okr.AddCustomSphere(ctx, userID, name, icon)
+		switch {
+		case errors.Is(err, okr.ErrStandardSphere):
+			return fmt.Sprintf("ℹ️ «%s» уже есть среди стандартных сфер — цели будут попадать туда", strings.TrimSpace(name)), &ManageSpheresFunction, nil
+		case errors.Is(err, okr.ErrInvalidSphereName):
+			return "❌ " + err.Error(), &ManageSpheresFunction, nil
+		case err != nil:
+			logrus.Errorf("Ошибка добавления сферы пользователя %d: %v", userID, err)
+			return "❌ Не удалось добавить сферу", &ManageSpheresFunction, nil
+		}
+		return fmt.Sprintf("%s Сфера «%s» добавлена", sphere.Icon, sphere.Name), &ManageSpheresFunction, nil
+
+	case "delete":
+		err := c.okr.DeleteCustomSphere(ctx, userID, name)
+		if errors.Is(err, okr.ErrSphereNotFound) {
+			return fmt.Sprintf("ℹ️ Своей сферы «%s» нет. Стандартные сферы удалить нельзя", strings.TrimSpace(name)), &ManageSpheresFunction, nil
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка удаления сферы пользователя %d: %v", userID, err)
+			return "❌ Не удалось удалить сферу", &ManageSpheresFunction, nil
+		}
+		return fmt.Sprintf("🗑 Сфера «%s» удалена из списка. Цели в ней остались без изменений", strings.TrimSpace(name)), &ManageSpheresFunction, nil
+	}
+
+	spheres, err := c.okr.GetSpheres(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения сфер пользователя %d: %v", userID, err)
+		return "❌ Не удалось получить сферы", &ManageSpheresFunction, nil
+	}
+	var b strings.Builder
+	b.WriteString("🗂 **Сферы целей**\n")
+	custom := false
+	for _, sphere := range spheres {
+		if sphere.Custom && !custom {
+			b.WriteString("\n**Свои:**\n")
+			custom = true
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", sphere.Icon, sphere.Name))
+	}
+	return strings.TrimRight(b.String(), "\n"), &ManageSpheresFunction, nil
+}
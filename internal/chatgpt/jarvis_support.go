@@ -0,0 +1,44 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/support"
+
+	"github.com/sirupsen/logrus"
+)
+
+var EscalateToHumanFunction = ChatGPTFunction{
+	Name:		"escalate_to_human",
+	Description:	"Передать разговор живому оператору поддержки: пользователь просит человека, жалуется на работу бота, явно раздражен или Jarvis несколько раз не смог помочь",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"reason": {
+				Type:		"string",
+				Description:	"Кратко, в чем проблема пользователя, чтобы оператору не пришлось переспрашивать",
+			},
+		},
+		Required:	[]string{"reason"},
+	},
+}
+
+func (c *ChatGPTService) handleEscalateToHuman(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	if !c.support.Available() {
+		return "😔 Сейчас не получается подключить оператора. Опишите проблему подробнее — постараюсь помочь сам", &EscalateToHumanFunction, nil
+	}
+
+	reason, _ := args["reason"].(string)
+	ticket, created, err := c.support.Open(ctx, userID, support.SourceAssistant, reason)
+	if err != nil {
+		logrus.Errorf("Ошибка при создании обращения пользователя %d: %v", userID, err)
+		return "❌ Не удалось передать разговор оператору, попробуйте команду /support", &EscalateToHumanFunction, nil
+	}
+
+	if !created {
+		return fmt.Sprintf("🎫 Обращение #%d уже у оператора. Пишите сюда — сообщения передаются ему, пока он не закроет обращение", ticket.ID), &EscalateToHumanFunction, nil
+	}
+	return fmt.Sprintf("🎫 Передал разговор оператору, обращение #%d. Он ответит здесь же; до закрытия обращения ваши сообщения уходят ему, а не мне", ticket.ID), &EscalateToHumanFunction, nil
+}
@@ -0,0 +1,101 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+var UpdateKeyResultTargetFunction = ChatGPTFunction{
+	Name:		"update_key_result_target",
+	Description:	"Изменить целевое значение ключевого результата с сохранением истории (старое и новое значение, причина)",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата",
+			},
+			"key_result_description": {
+				Type:		"string",
+				Description:	"Название или часть названия ключевого результата (если ID не указан)",
+			},
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название цели, чтобы уточнить ключевой результат",
+			},
+			"new_target": {
+				Type:		"number",
+				Description:	"Новое целевое значение",
+			},
+			"reason": {
+				Type:		"string",
+				Description:	"Почему меняется цель, своими словами пользователя",
+			},
+		},
+		Required:	[]string{"new_target"},
+	},
+}
+
+func (c *ChatGPTService) handleUpdateKeyResultTarget(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	newTarget, _ := args["new_target"].(float64)
+	if newTarget <= 0 {
+		return "❌ Целевое значение должно быть больше нуля", &UpdateKeyResultTargetFunction, nil
+	}
+	reason, _ := args["reason"].(string)
+
+	var keyResult *okr.KeyResult
+	if id, ok := args["key_result_id"].(float64); ok && id > 0 {
+		kr, err := c.okr.GetKeyResultByID(ctx, userID, int64(id))
+		if err != nil {
+			return "❌ Ключевой результат не найден или не принадлежит пользователю", &UpdateKeyResultTargetFunction, nil
+		}
+		keyResult = kr
+	} else {
+		description, _ := args["key_result_description"].(string)
+		objectiveDescription, _ := args["objective_description"].(string)
+		if strings.TrimSpace(description) == "" {
+			return "❌ Не указан ID или описание ключевого результата", &UpdateKeyResultTargetFunction, nil
+		}
+		keyResults, err := c.okr.FindKeyResultByDescription(ctx, userID, description, objectiveDescription)
+		if err != nil {
+			logrus.Errorf("Ошибка поиска ключевого результата «%s» пользователя %d: %v", description, userID, err)
+			return "❌ Не удалось найти ключевой результат", &UpdateKeyResultTargetFunction, nil
+		}
+		switch len(keyResults) {
+		case 0:
+			return "❌ Не найден ключевой результат по описанию: " + description, &UpdateKeyResultTargetFunction, nil
+		case 1:
+			keyResult = &keyResults[0]
+		default:
+			titles := make([]string, 0, len(keyResults))
+			for _, kr := range keyResults {
+				titles = append(titles, "«"+kr.Title+"»")
+			}
+			return fmt.Sprintf("❓ Под «%s» подходит несколько ключевых результатов: %s. Уточни, какой изменить", description, strings.Join(titles, ", ")), &UpdateKeyResultTargetFunction, nil
+		}
+	}
+
+	if keyResult.Target == newTarget {
+		return fmt.Sprintf("Цель «%s» и так %s %s", keyResult.Title, formatAmount(newTarget), keyResult.Unit), &UpdateKeyResultTargetFunction, nil
+	}
+
+	if err := c.okr.UpdateKeyResult(ctx, userID, keyResult.ID, nil, nil, &newTarget, nil, nil, reason); err != nil {
+		logrus.Errorf("Ошибка изменения цели ключевого результата %d пользователя %d: %v", keyResult.ID, userID, err)
+		return "❌ Не удалось изменить цель: " + err.Error(), &UpdateKeyResultTargetFunction, nil
+	}
+
+	response := fmt.Sprintf("✏️ «%s»: цель %s → %s %s", keyResult.Title, formatAmount(keyResult.Target), formatAmount(newTarget), keyResult.Unit)
+	if newTarget < keyResult.Target {
+		response += "\nИсходную планку я сохранил в истории — в ретроспективе будет видно, как менялась цель"
+	}
+	if strings.TrimSpace(reason) == "" {
+		response += "\nЕсли расскажешь, почему поменял цель, запишу причину"
+	}
+	return response, &UpdateKeyResultTargetFunction, nil
+}
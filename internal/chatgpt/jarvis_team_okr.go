@@ -0,0 +1,463 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CreateOKRTeamFunction = ChatGPTFunction{
+	Name:		"create_okr_team",
+	Description:	"Создать команду для совместной работы над целями OKR",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"name": {
+				Type:		"string",
+				Description:	"Название команды",
+			},
+		},
+		Required:	[]string{"name"},
+	},
+}
+
+var AddOKRTeamMemberFunction = ChatGPTFunction{
+	Name:		"add_okr_team_member",
+	Description:	"Пригласить пользователя бота в команду OKR по его username в Telegram (только для администратора команды). Участник присоединится после того, как примет приглашение",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"username": {
+				Type:		"string",
+				Description:	"Username пользователя в Telegram (без @)",
+			},
+			"team_name": {
+				Type:		"string",
+				Description:	"Название команды (можно не указывать, если команда одна)",
+			},
+		},
+		Required:	[]string{"username"},
+	},
+}
+
+var RespondTeamInvitationFunction = ChatGPTFunction{
+	Name:		"respond_team_invitation",
+	Description:	"Принять или отклонить приглашение в команду OKR ('принимаю приглашение в команду', 'вступить в команду Маркетинг')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"team_name": {
+				Type:		"string",
+				Description:	"Название команды (можно не указывать, если приглашение одно)",
+			},
+			"accept": {
+				Type:		"boolean",
+				Description:	"true - принять, false - отклонить",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var ShareObjectiveWithTeamFunction = ChatGPTFunction{
+	Name:		"share_objective_with_team",
+	Description:	"Сделать цель командной: открыть ее участникам команды, чтобы назначать ответственных за ключевые результаты",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название или описание цели",
+			},
+			"team_name": {
+				Type:		"string",
+				Description:	"Название команды (можно не указывать, если команда одна)",
+			},
+		},
+		Required:	[]string{"objective_description"},
+	},
+}
+
+var AssignKeyResultOwnerFunction = ChatGPTFunction{
+	Name:		"assign_key_result_owner",
+	Description:	"Назначить ответственного участника команды за ключевой результат командной цели. Напоминания и чек-ины по нему будут приходить ответственному",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"key_result_description": {
+				Type:		"string",
+				Description:	"Название или описание ключевого результата",
+			},
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название цели для уточнения поиска",
+			},
+			"username": {
+				Type:		"string",
+				Description:	"Username ответственного в Telegram (без @). Пусто - назначить себя",
+			},
+		},
+		Required:	[]string{"key_result_description"},
+	},
+}
+
+var GetTeamOKRReportFunction = ChatGPTFunction{
+	Name:		"get_team_okr_report",
+	Description:	"Показать командный отчет по цели: статус ключевых результатов по каждому ответственному",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"objective_description": {
+				Type:		"string",
+				Description:	"Название командной цели (пусто - все командные цели)",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var SetTeamNotificationsFunction = ChatGPTFunction{
+	Name:		"set_team_notifications",
+	Description:	"Настроить канал уведомлений команды (Telegram, Slack или оба) для командных отчетов и сообщений о достижении целей",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"team_name": {
+				Type:		"string",
+				Description:	"Название команды (можно не указывать, если команда одна)",
+			},
+			"channel": {
+				Type:		"string",
+				Description:	"Канал уведомлений",
+				Enum:		[]string{"telegram", "slack", "both"},
+			},
+			"slack_webhook_url": {
+				Type:		"string",
+				Description:	"Адрес входящего webhook Slack (https://hooks.slack.com/...)",
+			},
+			"slack_bot_token": {
+				Type:		"string",
+				Description:	"Токен Slack-бота (xoxb-...), если используется вместо webhook",
+			},
+			"slack_channel": {
+				Type:		"string",
+				Description:	"Канал Slack для токена бота, например #team-okr",
+			},
+			"weekly_report": {
+				Type:		"boolean",
+				Description:	"Присылать еженедельный командный отчет по понедельникам",
+			},
+		},
+		Required:	[]string{"channel"},
+	},
+}
+
+func (c *ChatGPTService) handleCreateOKRTeam(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	name, _ := args["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "❌ Укажи название команды", &CreateOKRTeamFunction, nil
+	}
+
+	_, err := c.okr.CreateTeam(ctx, userID, name)
+	if err != nil {
+		logrus.Errorf("Ошибка создания команды OKR: %v", err)
+		return "❌ Не удалось создать команду", &CreateOKRTeamFunction, nil
+	}
+
+	response := "👥 **Команда создана!**\n\n"
+	response += fmt.Sprintf("📋 **Название:** %s\n\n", name)
+	response += "Пригласи участников: 'добавь @username в команду', затем сделай цель командной и назначь ответственных за ключевые результаты"
+
+	return response, &CreateOKRTeamFunction, nil
+}
+
+func (c *ChatGPTService) handleAddOKRTeamMember(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	username, _ := args["username"].(string)
+	teamName, _ := args["team_name"].(string)
+
+	team, err := c.okr.FindTeamByName(ctx, userID, teamName)
+	if err != nil {
+		return teamOKRErrorMessage(err), &AddOKRTeamMemberFunction, nil
+	}
+
+	memberID, err := c.findUserIDByUsername(ctx, username)
+	if err != nil {
+		return fmt.Sprintf("❌ Пользователь @%s не найден. Он должен хотя бы раз написать боту", strings.TrimPrefix(username, "@")), &AddOKRTeamMemberFunction, nil
+	}
+
+	_, err = c.okr.InviteToTeam(ctx, userID, team.ID, memberID)
+	if err != nil {
+		return teamOKRErrorMessage(err), &AddOKRTeamMemberFunction, nil
+	}
+
+	return fmt.Sprintf("📨 Приглашение в команду «%s» отправлено @%s. Сообщу, когда он ответит", team.Name, strings.TrimPrefix(username, "@")), &AddOKRTeamMemberFunction, nil
+}
+
+func (c *ChatGPTService) handleRespondTeamInvitation(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	teamName, _ := args["team_name"].(string)
+	accept := true
+	if v, ok := args["accept"].(bool); ok {
+		accept = v
+	}
+
+	invitations, err := c.okr.GetPendingInvitations(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения приглашений в команды: %v", err)
+		return "❌ Не удалось получить приглашения", &RespondTeamInvitationFunction, nil
+	}
+	if len(invitations) == 0 {
+		return "ℹ️ У тебя нет приглашений в команды", &RespondTeamInvitationFunction, nil
+	}
+
+	var invitation *okr.TeamInvitation
+	needle := strings.ToLower(strings.TrimSpace(teamName))
+	for i := range invitations {
+		if needle == "" || strings.Contains(strings.ToLower(invitations[i].TeamName), needle) {
+			invitation = &invitations[i]
+			break
+		}
+	}
+	if invitation == nil || (needle == "" && len(invitations) > 1) {
+		names := make([]string, 0, len(invitations))
+		for _, inv := range invitations {
+			names = append(names, "«"+inv.TeamName+"»")
+		}
+		return "ℹ️ Уточни команду. Приглашения: " + strings.Join(names, ", "), &RespondTeamInvitationFunction, nil
+	}
+
+	if _, err := c.okr.RespondToInvitation(ctx, userID, invitation.ID, accept); err != nil {
+		logrus.Errorf("Ошибка ответа на приглашение %d: %v", invitation.ID, err)
+		return "❌ Приглашение больше не действительно", &RespondTeamInvitationFunction, nil
+	}
+
+	if !accept {
+		return fmt.Sprintf("❌ Приглашение в команду «%s» отклонено", invitation.TeamName), &RespondTeamInvitationFunction, nil
+	}
+	return fmt.Sprintf("✅ Ты в команде «%s»! Отмечай прогресс по общим ключевым результатам как обычно — команда увидит твой вклад", invitation.TeamName), &RespondTeamInvitationFunction, nil
+}
+
+func (c *ChatGPTService) handleShareObjectiveWithTeam(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	objectiveDescription, _ := args["objective_description"].(string)
+	teamName, _ := args["team_name"].(string)
+
+	objectives, err := c.okr.FindObjectiveByDescription(ctx, userID, objectiveDescription)
+	if err != nil || len(objectives) == 0 {
+		return "❌ Не найдена цель по описанию: " + objectiveDescription, &ShareObjectiveWithTeamFunction, nil
+	}
+	objective := objectives[0]
+
+	team, err := c.okr.FindTeamByName(ctx, userID, teamName)
+	if err != nil {
+		return teamOKRErrorMessage(err), &ShareObjectiveWithTeamFunction, nil
+	}
+
+	err = c.okr.ShareObjectiveWithTeam(ctx, userID, objective.ID, team.ID)
+	if err != nil {
+		return teamOKRErrorMessage(err), &ShareObjectiveWithTeamFunction, nil
+	}
+
+	response := fmt.Sprintf("👥 Цель «%s» теперь командная (%s)\n\n", objective.Title, team.Name)
+	response += "Назначь ответственных: 'ответственный за <ключевой результат> — @username'"
+
+	return response, &ShareObjectiveWithTeamFunction, nil
+}
+
+func (c *ChatGPTService) handleAssignKeyResultOwner(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	keyResultDescription, _ := args["key_result_description"].(string)
+	objectiveDescription, _ := args["objective_description"].(string)
+	username, _ := args["username"].(string)
+
+	keyResults, err := c.okr.FindKeyResultByDescription(ctx, userID, keyResultDescription, objectiveDescription)
+	if err != nil || len(keyResults) == 0 {
+		return "❌ Не найден ключевой результат по описанию: " + keyResultDescription, &AssignKeyResultOwnerFunction, nil
+	}
+
+	ownerID := userID
+	if strings.TrimSpace(username) != "" {
+		ownerID, err = c.findUserIDByUsername(ctx, username)
+		if err != nil {
+			return fmt.Sprintf("❌ Пользователь @%s не найден", strings.TrimPrefix(username, "@")), &AssignKeyResultOwnerFunction, nil
+		}
+	}
+
+	kr, err := c.okr.AssignKeyResultOwner(ctx, userID, keyResults[0].ID, ownerID)
+	if err != nil {
+		return teamOKRErrorMessage(err), &AssignKeyResultOwnerFunction, nil
+	}
+
+	response := "🎯 **Ответственный назначен!**\n\n"
+	response += fmt.Sprintf("🔑 **Ключевой результат:** %s\n", kr.Title)
+	response += fmt.Sprintf("🎯 **Цель:** %s\n", kr.ObjectiveTitle)
+	response += fmt.Sprintf("👤 **Ответственный:** %s\n\n", kr.OwnerName())
+	if ownerID != userID {
+		response += "Я сообщу ответственному о назначении и буду присылать ему напоминания и чек-ины"
+	}
+
+	return response, &AssignKeyResultOwnerFunction, nil
+}
+
+func (c *ChatGPTService) handleGetTeamOKRReport(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	objectiveDescription, _ := args["objective_description"].(string)
+
+	objectives, err := c.okr.GetTeamObjectives(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения командных целей: %v", err)
+		return "❌ Не удалось получить командные цели", &GetTeamOKRReportFunction, nil
+	}
+
+	needle := strings.ToLower(strings.TrimSpace(objectiveDescription))
+	var reports []string
+	for _, objective := range objectives {
+		if needle != "" && !strings.Contains(strings.ToLower(objective.Title), needle) {
+			continue
+		}
+
+		report, err := c.okr.GenerateTeamReport(ctx, userID, objective.ID)
+		if err != nil {
+			logrus.Warnf("Не удалось сформировать командный отчет по цели %s: %v", objective.ID, err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+
+	if len(reports) == 0 {
+		return "ℹ️ Командных целей не найдено. Сделай цель командной: 'открой цель <название> команде'", &GetTeamOKRReportFunction, nil
+	}
+
+	return strings.Join(reports, "\n\n"), &GetTeamOKRReportFunction, nil
+}
+
+func (c *ChatGPTService) handleSetTeamNotifications(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	teamName, _ := args["team_name"].(string)
+	channel, _ := args["channel"].(string)
+	webhookURL, _ := args["slack_webhook_url"].(string)
+	botToken, _ := args["slack_bot_token"].(string)
+	slackChannel, _ := args["slack_channel"].(string)
+
+	team, err := c.okr.FindTeamByName(ctx, userID, teamName)
+	if err != nil {
+		return teamOKRErrorMessage(err), &SetTeamNotificationsFunction, nil
+	}
+
+	current, err := c.okr.GetTeamNotificationSettings(ctx, team.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения настроек уведомлений команды: %v", err)
+		return "❌ Не удалось получить настройки команды", &SetTeamNotificationsFunction, nil
+	}
+
+	settings := *current
+	settings.Channel = channel
+	if webhookURL = strings.TrimSpace(webhookURL); webhookURL != "" {
+		settings.SlackWebhookURL = &webhookURL
+	}
+	if botToken = strings.TrimSpace(botToken); botToken != "" {
+		settings.SlackBotToken = &botToken
+	}
+	if slackChannel = strings.TrimSpace(slackChannel); slackChannel != "" {
+		settings.SlackChannel = &slackChannel
+	}
+	if weeklyReport, ok := args["weekly_report"].(bool); ok {
+		settings.WeeklyReport = weeklyReport
+	}
+
+	if settings.UsesSlack() {
+		if !settings.SlackTarget().Configured() {
+			return "ℹ️ Для Slack пришли адрес входящего webhook (https://hooks.slack.com/...) или токен бота и название канала", &SetTeamNotificationsFunction, nil
+		}
+		err = c.slack.Send(ctx, settings.SlackTarget(), fmt.Sprintf("✅ Уведомления команды «%s» подключены к этому каналу", team.Name))
+		if err != nil {
+			logrus.Warnf("Проверочное сообщение в Slack не доставлено: %v", err)
+			return "❌ Не удалось отправить проверочное сообщение в Slack: " + err.Error(), &SetTeamNotificationsFunction, nil
+		}
+	}
+
+	err = c.okr.SetTeamNotificationSettings(ctx, userID, settings)
+	if err != nil {
+		if errors.Is(err, okr.ErrTeamPermission) || errors.Is(err, okr.ErrNotTeamMember) {
+			return teamOKRErrorMessage(err), &SetTeamNotificationsFunction, nil
+		}
+		logrus.Errorf("Ошибка сохранения настроек уведомлений команды: %v", err)
+		return "❌ Не удалось сохранить настройки уведомлений", &SetTeamNotificationsFunction, nil
+	}
+
+	channelNames := map[string]string{
+		"telegram":	"Telegram",
+		"slack":	"Slack",
+		"both":		"Telegram и Slack",
+	}
+
+	response := "🔔 **Уведомления команды настроены!**\n\n"
+	response += fmt.Sprintf("👥 **Команда:** %s\n", team.Name)
+	response += fmt.Sprintf("📡 **Канал:** %s\n", channelNames[settings.Channel])
+	if settings.WeeklyReport {
+		response += "📊 **Еженедельный отчет:** по понедельникам\n"
+	} else {
+		response += "📊 **Еженедельный отчет:** выключен\n"
+	}
+	response += "🎉 Сообщения о достижении командных целей будут приходить в выбранный канал"
+
+	return response, &SetTeamNotificationsFunction, nil
+}
+
+func teamOKRErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, okr.ErrTeamNotFound):
+		return "ℹ️ Команда не найдена. Создай ее: 'создай команду <название>' или уточни название"
+	case errors.Is(err, okr.ErrNotTeamMember):
+		return "ℹ️ Этот пользователь не состоит в команде, которой открыта цель"
+	case errors.Is(err, okr.ErrNotTeamObjective):
+		return "ℹ️ Цель пока не командная. Сначала открой ее команде"
+	case errors.Is(err, okr.ErrTeamPermission):
+		return "🔒 Недостаточно прав для этого действия"
+	case errors.Is(err, okr.ErrAlreadyTeamMember):
+		return "ℹ️ Этот пользователь уже состоит в команде"
+	default:
+		logrus.Errorf("Ошибка командных OKR: %v", err)
+		return "❌ Не удалось выполнить действие с командой"
+	}
+}
+
+func (c *ChatGPTService) formatTeamObjectives(userID int64) string {
+	ctx := context.Background()
+
+	objectives, err := c.okr.GetTeamObjectives(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения командных целей: %v", err)
+		return ""
+	}
+	if len(objectives) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("👥 **Командные цели (вклад участников):**\n\n")
+	for _, objective := range objectives {
+		text, err := c.okr.FormatTeamObjectiveProgress(ctx, objective)
+		if err != nil {
+			logrus.Errorf("Ошибка формирования прогресса командной цели %s: %v", objective.ID, err)
+			continue
+		}
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,95 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var GetObjectiveTemplatesFunction = ChatGPTFunction{
+	Name:		"get_objective_templates",
+	Description:	"Показать библиотеку готовых шаблонов целей (фитнес, контент, накопления, изучение языка) с ключевыми результатами и ежедневными задачами",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+var CreateObjectiveFromTemplateFunction = ChatGPTFunction{
+	Name:		"create_objective_from_template",
+	Description:	"Создать цель по готовому шаблону: ключевые результаты и повторяющиеся ежедневные задачи заполняются автоматически",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"template": {
+				Type:		"string",
+				Description:	"ID шаблона (fitness, content, savings, language) или тема своими словами",
+			},
+			"title": {
+				Type:		"string",
+				Description:	"Свое название цели (по умолчанию — название шаблона)",
+			},
+			"start_date": {
+				Type:		"string",
+				Description:	"Дата старта в формате YYYY-MM-DD (по умолчанию — сегодня)",
+			},
+		},
+		Required:	[]string{"template"},
+	},
+}
+
+func (c *ChatGPTService) handleGetObjectiveTemplates(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	var b strings.Builder
+	b.WriteString("📚 **Шаблоны целей**\n")
+	for _, t := range okr.ObjectiveTemplates() {
+		b.WriteString("\n" + okr.FormatObjectiveTemplate(t))
+	}
+	b.WriteString("\nСкажи, например: «создай цель по шаблону language»")
+	return b.String(), &GetObjectiveTemplatesFunction, nil
+}
+
+func (c *ChatGPTService) handleCreateObjectiveFromTemplate(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	ref, _ := args["template"].(string)
+	title, _ := args["title"].(string)
+	start := time.Now()
+	if value, _ := args["start_date"].(string); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return "❌ Дата старта должна быть в формате YYYY-MM-DD", &CreateObjectiveFromTemplateFunction, nil
+		}
+		start = parsed
+	}
+
+	instance, err := c.okr.InstantiateTemplate(ctx, userID, ref, title, start)
+	if errors.Is(err, okr.ErrTemplateNotFound) {
+		ids := make([]string, 0, len(okr.ObjectiveTemplates()))
+		for _, t := range okr.ObjectiveTemplates() {
+			ids = append(ids, t.ID)
+		}
+		return "ℹ️ Такого шаблона нет. Доступные: " + strings.Join(ids, ", "), &CreateObjectiveFromTemplateFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка создания цели по шаблону %s: %v", ref, err)
+		return "❌ Не удалось создать цель по шаблону", &CreateObjectiveFromTemplateFunction, nil
+	}
+
+	template, _ := okr.FindObjectiveTemplate(ref)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("✅ Цель «%s» создана по шаблону, дедлайн %s\n", instance.Title, instance.Deadline.Format("02.01.2006")))
+	for _, kr := range template.KeyResults {
+		b.WriteString(fmt.Sprintf("• %s: %g %s\n", kr.Title, kr.Target, kr.Unit))
+	}
+	if instance.RecurringTasks > 0 {
+		b.WriteString(fmt.Sprintf("🔁 Ежедневных задач: %d — напоминания придут вечером\n", instance.RecurringTasks))
+	}
+	b.WriteString("Целевые значения можно поменять под себя: «снизь цель по словам до 500»")
+	return b.String(), &CreateObjectiveFromTemplateFunction, nil
+}
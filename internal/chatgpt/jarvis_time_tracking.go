@@ -0,0 +1,285 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var LogTimeFunction = ChatGPTFunction{
+	Name:		"log_time",
+	Description:	"Записать время, потраченное на цель, ключевой результат или проект («потратил 3 часа на проект X», «вчера 40 минут учил испанский»)",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"minutes": {
+				Type:		"integer",
+				Description:	"Затраченное время в минутах (3 часа = 180)",
+				Minimum:	1,
+				Maximum:	1440,
+			},
+			"objective": {
+				Type:		"string",
+				Description:	"Цель или проект: ID или часть названия",
+			},
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата, если время относится к конкретному KR",
+			},
+			"task": {
+				Type:		"string",
+				Description:	"Задача: ID или часть названия, если время относится к конкретной задаче",
+			},
+			"note": {
+				Type:		"string",
+				Description:	"Чем занимался пользователь",
+			},
+			"date": {
+				Type:		"string",
+				Description:	"Дата в формате YYYY-MM-DD, если не сегодня",
+			},
+		},
+		Required:	[]string{"minutes"},
+	},
+}
+
+var StartTimeTrackingFunction = ChatGPTFunction{
+	Name:		"start_time_tracking",
+	Description:	"Запустить таймер учета времени по задаче, ключевому результату или цели («начни трекать время по задаче X», «засеки время на отчет»). Время запишется при остановке",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"task": {
+				Type:		"string",
+				Description:	"Задача: ID или часть названия",
+			},
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата, если таймер не по задаче",
+			},
+			"objective": {
+				Type:		"string",
+				Description:	"Цель: ID или часть названия, если таймер не по задаче и не по KR",
+			},
+			"note": {
+				Type:		"string",
+				Description:	"Чем занимается пользователь",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var StopTimeTrackingFunction = ChatGPTFunction{
+	Name:		"stop_time_tracking",
+	Description:	"Остановить запущенный таймер учета времени и записать затраченное время («стоп таймер», «закончил работать над задачей»)",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+var GetTimeAllocationFunction = ChatGPTFunction{
+	Name:		"get_time_allocation",
+	Description:	"Показать, как время за неделю распределилось по сферам жизни и целям",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"weeks_ago": {
+				Type:		"integer",
+				Description:	"0 — текущая неделя, 1 — прошлая и т.д.",
+				Minimum:	0,
+				Maximum:	12,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleLogTime(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	minutes := 0
+	if v, ok := args["minutes"].(float64); ok {
+		minutes = int(v)
+	}
+	note, _ := args["note"].(string)
+
+	spentOn := time.Now()
+	if date, _ := args["date"].(string); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return "❌ Не понял дату, укажите в формате ГГГГ-ММ-ДД", &LogTimeFunction, nil
+		}
+		spentOn = parsed
+	}
+
+	objectiveID, keyResultID, taskID, objectiveTitle, message := c.resolveTimeTarget(ctx, userID, args)
+	if message != "" {
+		return message, &LogTimeFunction, nil
+	}
+
+	entry, err := c.focus.LogTime(ctx, userID, minutes, note, objectiveID, keyResultID, taskID, spentOn)
+	if ownership.IsNotOwned(err) {
+		return "❌ " + err.Error(), &LogTimeFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка записи времени пользователя %d: %v", userID, err)
+		return fmt.Sprintf("❌ Не удалось записать время: %v", err), &LogTimeFunction, nil
+	}
+
+	response := fmt.Sprintf("⏱ Записал %s", focus.FormatMinutes(entry.Minutes))
+	if entry.Note != nil {
+		response += fmt.Sprintf(": «%s»", *entry.Note)
+	}
+	response += c.trackedTotalLine(ctx, userID, entry, objectiveTitle)
+	if !sameDay(entry.SpentOn, time.Now()) {
+		response += fmt.Sprintf("\n📅 Дата: %s", entry.SpentOn.Format("02.01.2006"))
+	}
+	return response, &LogTimeFunction, nil
+}
+
+func (c *ChatGPTService) handleStartTimeTracking(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Запуск таймера учета времени для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	if active, err := c.focus.GetActiveTracker(ctx, userID); err == nil {
+		return fmt.Sprintf("⏱ Уже идет таймер «%s» с %s (%s). Сначала останови его",
+			active.Title(), active.StartedAt.In(time.Local).Format("15:04"), focus.FormatMinutes(active.ElapsedMinutes(time.Now()))), &StartTimeTrackingFunction, nil
+	}
+
+	objectiveID, keyResultID, taskID, _, message := c.resolveTimeTarget(ctx, userID, args)
+	if message != "" {
+		return message, &StartTimeTrackingFunction, nil
+	}
+	note, _ := args["note"].(string)
+
+	tracker, err := c.focus.StartTracking(ctx, userID, note, objectiveID, keyResultID, taskID)
+	if errors.Is(err, focus.ErrTrackerActive) {
+		return "⏱ Таймер уже запущен. Сначала останови его", &StartTimeTrackingFunction, nil
+	}
+	if ownership.IsNotOwned(err) {
+		return "❌ " + err.Error(), &StartTimeTrackingFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка запуска таймера пользователя %d: %v", userID, err)
+		return "❌ Не удалось запустить таймер", &StartTimeTrackingFunction, nil
+	}
+
+	return fmt.Sprintf("▶️ Засекаю время: «%s» с %s. Скажи «стоп», когда закончишь",
+		tracker.Title(), tracker.StartedAt.In(time.Local).Format("15:04")), &StartTimeTrackingFunction, nil
+}
+
+func (c *ChatGPTService) handleStopTimeTracking(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Остановка таймера учета времени для пользователя %d", userID)
+
+	ctx := context.Background()
+
+	tracker, entry, err := c.focus.StopTracking(ctx, userID)
+	if errors.Is(err, focus.ErrNoActiveTracker) {
+		return "ℹ️ Таймер не запущен. Запиши время вручную: «потратил 2 часа на задачу X»", &StopTimeTrackingFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка остановки таймера пользователя %d: %v", userID, err)
+		return "❌ Не удалось остановить таймер", &StopTimeTrackingFunction, nil
+	}
+
+	response := fmt.Sprintf("⏹ Таймер «%s» остановлен: записал %s", tracker.Title(), focus.FormatMinutes(entry.Minutes))
+	if tracker.Capped(time.Now()) {
+		response += "\n⚠️ Таймер шел слишком долго, записал максимум. Поправь вручную, если нужно"
+	}
+	response += c.trackedTotalLine(ctx, userID, entry, "")
+	return response, &StopTimeTrackingFunction, nil
+}
+
+func (c *ChatGPTService) resolveTimeTarget(ctx context.Context, userID int64, args map[string]interface{}) (*string, *int64, *int64, string, string) {
+	if ref, _ := args["task"].(string); strings.TrimSpace(ref) != "" {
+		ref = strings.TrimSpace(ref)
+		if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+			if task, err := c.okr.GetTaskByID(ctx, userID, id); err == nil {
+				return nil, nil, &task.ID, "", ""
+			}
+		}
+		tasks, err := c.okr.FindTaskByDescription(ctx, userID, ref, "")
+		if err != nil {
+			logrus.Errorf("Ошибка поиска задачи «%s» пользователя %d: %v", ref, userID, err)
+			return nil, nil, nil, "", "❌ Не удалось найти задачу"
+		}
+		switch len(tasks) {
+		case 0:
+			return nil, nil, nil, "", fmt.Sprintf("❓ Не нашел задачу «%s». Уточни название", ref)
+		case 1:
+			return nil, nil, &tasks[0].ID, "", ""
+		}
+		titles := make([]string, 0, len(tasks))
+		for _, t := range tasks {
+			titles = append(titles, "«"+t.Title+"»")
+		}
+		return nil, nil, nil, "", fmt.Sprintf("❓ Под «%s» подходит несколько задач: %s. Уточни, какую выбрать", ref, strings.Join(titles, ", "))
+	}
+
+	if v, ok := args["key_result_id"].(float64); ok && v > 0 {
+		id := int64(v)
+		return nil, &id, nil, "", ""
+	}
+
+	if ref, _ := args["objective"].(string); strings.TrimSpace(ref) != "" {
+		objective, message := c.resolveObjectiveRef(ctx, userID, ref)
+		if objective == nil {
+			return nil, nil, nil, "", message
+		}
+		return &objective.ID, nil, nil, objective.Title, ""
+	}
+	return nil, nil, nil, "", ""
+}
+
+func (c *ChatGPTService) trackedTotalLine(ctx context.Context, userID int64, entry *focus.TimeEntry, objectiveTitle string) string {
+	if entry.ObjectiveID == nil {
+		return ""
+	}
+	if objectiveTitle == "" {
+		if details, err := c.okr.GetObjectiveDetails(ctx, userID, *entry.ObjectiveID); err == nil {
+			objectiveTitle = details.Objective.Title
+		}
+	}
+	tracked, err := c.focus.GetTrackedMinutes(ctx, *entry.ObjectiveID)
+	if err != nil || objectiveTitle == "" {
+		return ""
+	}
+	line := fmt.Sprintf("\n🎯 Цель «%s»: всего %s", objectiveTitle, focus.FormatMinutes(tracked.Total))
+	if entry.TaskID != nil {
+		line += fmt.Sprintf(", по этой задаче — %s", focus.FormatMinutes(tracked.ByTask[*entry.TaskID]))
+	}
+	return line
+}
+
+func (c *ChatGPTService) handleGetTimeAllocation(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	weekStart := focus.WeekStart(time.Now())
+	if v, ok := args["weeks_ago"].(float64); ok && v > 0 {
+		weekStart = weekStart.AddDate(0, 0, -7*int(v))
+	}
+
+	allocation, err := c.focus.GetWeeklyAllocation(ctx, userID, weekStart)
+	if err != nil {
+		logrus.Errorf("Ошибка получения распределения времени пользователя %d: %v", userID, err)
+		return "❌ Не удалось получить распределение времени", &GetTimeAllocationFunction, nil
+	}
+	return focus.FormatWeeklyAllocation(allocation), &GetTimeAllocationFunction, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
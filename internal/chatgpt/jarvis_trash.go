@@ -0,0 +1,71 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+
+	"github.com/sirupsen/logrus"
+)
+
+var RestoreObjectiveFunction = ChatGPTFunction{
+	Name:		"restore_objective",
+	Description:	"Отменить удаление: восстановить удаленную цель вместе с ее ключевыми результатами и задачами, либо отдельно удаленный ключевой результат или задачу. Удаленное хранится 30 дней",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"kind": {
+				Type:		"string",
+				Description:	"Что восстановить: objective (по умолчанию), key_result или task",
+				Enum:		[]string{okr.DeletedKindObjective, okr.DeletedKindKeyResult, okr.DeletedKindTask},
+			},
+			"description": {
+				Type:		"string",
+				Description:	"Название или часть названия удаленного элемента. Если не указано — восстанавливается последний удаленный",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleRestoreObjective(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	kind, _ := args["kind"].(string)
+	if kind == "" {
+		kind = okr.DeletedKindObjective
+	}
+	description, _ := args["description"].(string)
+
+	items, err := c.okr.FindDeletedItems(ctx, userID, kind, strings.TrimSpace(description))
+	if err != nil {
+		logrus.Errorf("Ошибка поиска удаленных элементов: %v", err)
+		return "❌ Не удалось найти удаленные элементы", &RestoreObjectiveFunction, nil
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf("ℹ️ Ничего подходящего среди удаленного за последние %d дней не найдено", okr.TrashRetentionDays), &RestoreObjectiveFunction, nil
+	}
+
+	item, err := c.okr.RestoreDeletedItem(ctx, userID, items[0].Kind, items[0].ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, okr.ErrParentDeleted):
+			return fmt.Sprintf("ℹ️ «%s» был внутри «%s», который тоже удален. Сначала восстанови его", items[0].Title, items[0].Parent), &RestoreObjectiveFunction, nil
+		case ownership.IsNotOwned(err):
+			return "ℹ️ Этот элемент уже восстановлен или удален окончательно", &RestoreObjectiveFunction, nil
+		}
+		logrus.Errorf("Ошибка восстановления %s %s: %v", items[0].Kind, items[0].ID, err)
+		return "❌ Не удалось восстановить", &RestoreObjectiveFunction, nil
+	}
+
+	switch item.Kind {
+	case okr.DeletedKindObjective:
+		return fmt.Sprintf("↩️ Цель «%s» восстановлена вместе с ключевыми результатами и задачами", item.Title), &RestoreObjectiveFunction, nil
+	case okr.DeletedKindKeyResult:
+		return fmt.Sprintf("↩️ Ключевой результат «%s» восстановлен в цели «%s»", item.Title, item.Parent), &RestoreObjectiveFunction, nil
+	}
+	return fmt.Sprintf("↩️ Задача «%s» восстановлена в «%s»", item.Title, item.Parent), &RestoreObjectiveFunction, nil
+}
@@ -0,0 +1,177 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/travel"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ImportTravelBookingFunction = ChatGPTFunction{
+	Name:		"import_travel_booking",
+	Description:	"Разобрать пересланное подтверждение бронирования (авиабилет, отель, поезд) и добавить его в календарь как часть поездки. Время указывай МЕСТНОЕ для точки отправления/прибытия вместе с часовым поясом IANA этого места",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"trip_name": {
+				Type:		"string",
+				Description:	"Название поездки, если пользователь его указал (например 'Конференция в Берлине')",
+			},
+			"destination": {
+				Type:		"string",
+				Description:	"Город или страна назначения",
+			},
+			"bookings": {
+				Type:		"array",
+				Description:	"Бронирования из подтверждения: каждый сегмент перелета, каждое проживание в отеле отдельно",
+				Items: &ChatGPTProperty{
+					Type:	"object",
+					Properties: map[string]ChatGPTProperty{
+						"type": {
+							Type:	"string",
+							Enum:	[]string{"flight", "hotel", "train", "other"},
+						},
+						"title": {
+							Type:		"string",
+							Description:	"Например 'Рейс SU 2310 Москва → Берлин' или 'Отель Adlon'",
+						},
+						"provider": {
+							Type:		"string",
+							Description:	"Авиакомпания, отель или перевозчик",
+						},
+						"confirmation_code": {
+							Type:		"string",
+							Description:	"Код бронирования / PNR",
+						},
+						"location": {
+							Type:		"string",
+							Description:	"Аэропорт вылета, вокзал или адрес отеля",
+						},
+						"start_local": {
+							Type:		"string",
+							Description:	"Местное время вылета/заселения в формате YYYY-MM-DDTHH:MM",
+						},
+						"start_timezone": {
+							Type:		"string",
+							Description:	"Часовой пояс IANA места начала, например Europe/Moscow",
+						},
+						"end_local": {
+							Type:		"string",
+							Description:	"Местное время прилета/выселения в формате YYYY-MM-DDTHH:MM",
+						},
+						"end_timezone": {
+							Type:		"string",
+							Description:	"Часовой пояс IANA места окончания, например Europe/Berlin",
+						},
+					},
+				},
+			},
+		},
+		Required:	[]string{"bookings"},
+	},
+}
+
+var GetTripsFunction = ChatGPTFunction{
+	Name:		"get_trips",
+	Description:	"Показать предстоящие поездки с перелетами и отелями",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleImportTravelBooking(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Импорт бронирования для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	tripName, _ := args["trip_name"].(string)
+	destination, _ := args["destination"].(string)
+
+	var bookings []travel.Booking
+	if rawBookings, ok := args["bookings"].([]interface{}); ok {
+		for _, raw := range rawBookings {
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			str := func(key string) string {
+				v, _ := m[key].(string)
+				return v
+			}
+			bookings = append(bookings, travel.Booking{
+				Type:			str("type"),
+				Title:			str("title"),
+				Provider:		str("provider"),
+				ConfirmationCode:	str("confirmation_code"),
+				Location:		str("location"),
+				StartLocal:		str("start_local"),
+				StartTimezone:		str("start_timezone"),
+				EndLocal:		str("end_local"),
+				EndTimezone:		str("end_timezone"),
+			})
+		}
+	}
+
+	result, err := c.travel.ImportBookings(ctx, userID, tripName, destination, bookings)
+	if err != nil {
+		if errors.Is(err, travel.ErrNoBookings) {
+			return "❌ Не нашел в сообщении ни одного бронирования. Перешлите письмо с подтверждением целиком", &ImportTravelBookingFunction, nil
+		}
+		logrus.Errorf("Ошибка импорта бронирования: %v", err)
+		return fmt.Sprintf("❌ Не удалось добавить бронирование: %v", err), &ImportTravelBookingFunction, nil
+	}
+
+	if len(result.Items) == 0 && result.Duplicates > 0 {
+		return fmt.Sprintf("ℹ️ Эти бронирования уже добавлены в поездку «%s»", result.Trip.Title), &ImportTravelBookingFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🧳 **Поездка «%s»** обновлена\n", result.Trip.Title))
+	b.WriteString(fmt.Sprintf("📅 %s – %s\n\n", result.Trip.StartTime.Format("02.01"), result.Trip.EndTime.Format("02.01.2006")))
+	b.WriteString("**Добавлено в календарь:**\n")
+	for _, item := range result.Items {
+		b.WriteString(travel.FormatItem(item) + "\n")
+	}
+	if result.Duplicates > 0 {
+		b.WriteString(fmt.Sprintf("\nПропущено повторов: %d\n", result.Duplicates))
+	}
+	b.WriteString("\n🔔 Напомню об онлайн-регистрации и заселении")
+
+	return b.String(), &ImportTravelBookingFunction, nil
+}
+
+func (c *ChatGPTService) handleGetTrips(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	trips, err := c.travel.GetUpcomingTrips(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения поездок: %v", err)
+		return "❌ Не удалось получить поездки", &GetTripsFunction, nil
+	}
+
+	if len(trips) == 0 {
+		return "🧳 Предстоящих поездок нет. Перешлите мне подтверждение бронирования билета или отеля, и я добавлю его в календарь", &GetTripsFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("🧳 **Ваши поездки:**\n")
+	for _, trip := range trips {
+		b.WriteString(fmt.Sprintf("\n**%s** (%s – %s)\n", trip.Title, trip.StartTime.Format("02.01"), trip.EndTime.Format("02.01.2006")))
+
+		items, err := c.travel.GetTripItems(ctx, userID, trip.ID)
+		if err != nil {
+			logrus.Errorf("Ошибка получения бронирований поездки %d: %v", trip.ID, err)
+			continue
+		}
+		for _, item := range items {
+			b.WriteString(travel.FormatItem(item) + "\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n"), &GetTripsFunction, nil
+}
@@ -0,0 +1,177 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/wellbeing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var CheckWellbeingFunction = ChatGPTFunction{
+	Name:		"check_wellbeing",
+	Description:	"Записывает ежедневную отметку самочувствия (стресс, сон, баланс работы и жизни) и предлагает рекомендации по благополучию",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"current_stress_level": {
+				Type:		"integer",
+				Description:	"Текущий уровень стресса (1-5)",
+				Minimum:	1,
+				Maximum:	5,
+			},
+			"sleep_quality": {
+				Type:		"integer",
+				Description:	"Качество сна (1-5)",
+				Minimum:	1,
+				Maximum:	5,
+			},
+			"work_life_balance": {
+				Type:		"integer",
+				Description:	"Баланс работы и жизни (1-5)",
+				Minimum:	1,
+				Maximum:	5,
+			},
+			"note": {
+				Type:		"string",
+				Description:	"Короткий комментарий пользователя о самочувствии",
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+var GetWellbeingTrendsFunction = ChatGPTFunction{
+	Name:		"get_wellbeing_trends",
+	Description:	"Показать динамику стресса, сна и баланса работы и жизни по неделям и риск выгорания",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"weeks": {
+				Type:		"integer",
+				Description:	"За сколько недель показать динамику (по умолчанию 6)",
+				Minimum:	1,
+				Maximum:	26,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func wellbeingScoreArg(args map[string]interface{}, key string) *int {
+	value, ok := args[key].(float64)
+	if !ok {
+		return nil
+	}
+	score := int(value)
+	return &score
+}
+
+func (c *ChatGPTService) handleCheckWellbeing(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	stress := wellbeingScoreArg(args, "current_stress_level")
+	sleep := wellbeingScoreArg(args, "sleep_quality")
+	balance := wellbeingScoreArg(args, "work_life_balance")
+	note, _ := args["note"].(string)
+
+	if stress == nil && sleep == nil && balance == nil {
+		return c.handleGetWellbeingTrends(args, userID)
+	}
+
+	checkIn, err := c.wellbeing.RecordCheckIn(ctx, userID, stress, sleep, balance, note)
+	if err != nil {
+		if errors.Is(err, wellbeing.ErrInvalidScore) || errors.Is(err, wellbeing.ErrEmptyCheckIn) {
+			return "❌ " + err.Error(), &CheckWellbeingFunction, nil
+		}
+		logrus.Errorf("Ошибка сохранения отметки самочувствия: %v", err)
+		return "❌ Не удалось сохранить отметку самочувствия", &CheckWellbeingFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("💚 Отметка самочувствия за сегодня сохранена:\n")
+	if checkIn.StressLevel != nil {
+		b.WriteString(fmt.Sprintf("• Стресс: %d/5\n", *checkIn.StressLevel))
+	}
+	if checkIn.SleepQuality != nil {
+		b.WriteString(fmt.Sprintf("• Сон: %d/5\n", *checkIn.SleepQuality))
+	}
+	if checkIn.WorkLifeBalance != nil {
+		b.WriteString(fmt.Sprintf("• Баланс: %d/5\n", *checkIn.WorkLifeBalance))
+	}
+
+	if risk, ok, err := c.wellbeing.BurnoutRisk(ctx, userID); err == nil && ok {
+		b.WriteString("\n" + wellbeing.FormatRisk(risk) + "\n")
+	}
+
+	b.WriteString("\n💡 Рекомендации:\n")
+	for _, tip := range wellbeing.Recommendations(checkIn) {
+		b.WriteString("• " + tip + "\n")
+	}
+
+	return b.String(), &CheckWellbeingFunction, nil
+}
+
+func (c *ChatGPTService) handleGetWellbeingTrends(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	weeks := 0
+	if v, ok := args["weeks"].(float64); ok {
+		weeks = int(v)
+	}
+
+	trends, err := c.wellbeing.GetWeeklyTrends(ctx, userID, weeks)
+	if err != nil {
+		logrus.Errorf("Ошибка получения динамики самочувствия: %v", err)
+		return "❌ Не удалось получить динамику самочувствия", &GetWellbeingTrendsFunction, nil
+	}
+
+	response := wellbeing.FormatTrends(trends)
+	if risk, ok, err := c.wellbeing.BurnoutRisk(ctx, userID); err == nil && ok {
+		response += "\n" + wellbeing.FormatRisk(risk)
+	}
+	return response, &GetWellbeingTrendsFunction, nil
+}
+
+func (c *ChatGPTService) handleSuggestBreak(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	workMinutes := 0
+	if v, ok := args["work_duration"].(float64); ok && v > 0 {
+		workMinutes = int(v)
+	}
+	energy := 0
+	if v, ok := args["energy_level"].(float64); ok {
+		energy = int(v)
+	}
+	breakType, _ := args["break_type"].(string)
+
+	session, err := c.focus.GetActiveSession(ctx, userID)
+	if err != nil && !errors.Is(err, focus.ErrNoActiveSession) {
+		logrus.Warnf("Не удалось получить фокус-сессию пользователя %d: %v", userID, err)
+	}
+	if session != nil && workMinutes == 0 {
+		workMinutes = int(time.Since(session.StartedAt).Minutes())
+	}
+
+	latest, err := c.wellbeing.GetLatest(ctx, userID, 1)
+	if err != nil {
+		logrus.Warnf("Не удалось получить отметку самочувствия пользователя %d: %v", userID, err)
+	}
+
+	suggestion := wellbeing.SuggestBreak(workMinutes, energy, breakType, latest)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("☕ Сделай перерыв на %d мин: %s\n\n", suggestion.Minutes, suggestion.Reason))
+	for _, activity := range suggestion.Activities {
+		b.WriteString("• " + activity + "\n")
+	}
+	if session != nil {
+		b.WriteString(fmt.Sprintf("\n⏸ Фокус-сессия идет уже %d мин — останови ее («стоп фокус»), чтобы перерыв не засчитался в глубокую работу", int(time.Since(session.StartedAt).Minutes())))
+	}
+	return strings.TrimRight(b.String(), "\n"), &SuggestBreakFunction, nil
+}
@@ -0,0 +1,207 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/worklocation"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var SetWorkLocationFunction = ChatGPTFunction{
+	Name:		"set_work_location",
+	Description:	"Указать, откуда пользователь работает: из офиса, из дома или в командировке. Можно задать конкретную дату, диапазон дат (командировка) или постоянный график по дням недели ('по вторникам и четвергам я дома')",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"location": {
+				Type:		"string",
+				Description:	"Место работы: office - офис, home - из дома, trip - командировка, none - сбросить",
+				Enum:		[]string{"office", "home", "trip", "none"},
+			},
+			"date": {
+				Type:		"string",
+				Description:	"Дата в формате YYYY-MM-DD (по умолчанию сегодня). Не указывай для постоянного графика",
+			},
+			"end_date": {
+				Type:		"string",
+				Description:	"Последний день периода в формате YYYY-MM-DD, например для командировки на несколько дней",
+			},
+			"weekdays": {
+				Type:		"array",
+				Description:	"Дни недели для постоянного графика",
+				Items: &ChatGPTProperty{
+					Type:	"string",
+					Enum:	[]string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"},
+				},
+			},
+			"note": {
+				Type:		"string",
+				Description:	"Комментарий, например город командировки",
+			},
+		},
+		Required:	[]string{"location"},
+	},
+}
+
+var GetWorkLocationsFunction = ChatGPTFunction{
+	Name:		"get_work_locations",
+	Description:	"Показать, откуда пользователь работает в ближайшие дни, и его постоянный график офис/дом",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"days": {
+				Type:		"integer",
+				Description:	"На сколько дней вперед показать (по умолчанию 7)",
+				Minimum:	1,
+				Maximum:	31,
+			},
+		},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleSetWorkLocation(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Установка места работы для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	location, _ := args["location"].(string)
+	note, _ := args["note"].(string)
+	if location == "none" {
+		location = ""
+	}
+
+	if rawWeekdays, ok := args["weekdays"].([]interface{}); ok && len(rawWeekdays) > 0 {
+		var names []string
+		for _, w := range rawWeekdays {
+			if name, ok := w.(string); ok {
+				names = append(names, name)
+			}
+		}
+
+		weekdays, err := worklocation.ParseWeekdays(names)
+		if err != nil {
+			return fmt.Sprintf("❌ %v", err), &SetWorkLocationFunction, nil
+		}
+
+		if err := c.locations.SetPattern(ctx, userID, weekdays, location); err != nil {
+			logrus.Errorf("Ошибка сохранения графика работы: %v", err)
+			return fmt.Sprintf("❌ Не удалось сохранить график: %v", err), &SetWorkLocationFunction, nil
+		}
+
+		var labels []string
+		for _, w := range weekdays {
+			labels = append(labels, worklocation.WeekdayLabel(w))
+		}
+
+		if location == "" {
+			return fmt.Sprintf("🗑 **График сброшен** для дней: %s", strings.Join(labels, ", ")), &SetWorkLocationFunction, nil
+		}
+		return fmt.Sprintf("📍 **График сохранен!**\n\nПо дням %s: %s\n\nУчту это в утренней сводке и при планировании очных встреч",
+			strings.Join(labels, ", "), worklocation.LocationLabel(location)), &SetWorkLocationFunction, nil
+	}
+
+	startDate := time.Now()
+	if dateStr, ok := args["date"].(string); ok && dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return "❌ Неверный формат даты. Используйте YYYY-MM-DD", &SetWorkLocationFunction, nil
+		}
+		startDate = parsed
+	}
+
+	endDate := startDate
+	if endStr, ok := args["end_date"].(string); ok && endStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", endStr, time.Local)
+		if err != nil {
+			return "❌ Неверный формат даты окончания. Используйте YYYY-MM-DD", &SetWorkLocationFunction, nil
+		}
+		if parsed.Before(startDate) {
+			return "❌ Дата окончания раньше даты начала", &SetWorkLocationFunction, nil
+		}
+		if parsed.Sub(startDate) > 62*24*time.Hour {
+			return "❌ Слишком длинный период, максимум 2 месяца", &SetWorkLocationFunction, nil
+		}
+		endDate = parsed
+	}
+
+	days := 0
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		var err error
+		if location == "" {
+			err = c.locations.ClearLocation(ctx, userID, day)
+		} else {
+			err = c.locations.SetLocation(ctx, userID, day, location, note)
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка сохранения места работы: %v", err)
+			return fmt.Sprintf("❌ Не удалось сохранить место работы: %v", err), &SetWorkLocationFunction, nil
+		}
+		days++
+	}
+
+	period := startDate.Format("02.01.2006")
+	if days > 1 {
+		period = fmt.Sprintf("%s – %s", startDate.Format("02.01"), endDate.Format("02.01.2006"))
+	}
+
+	if location == "" {
+		return fmt.Sprintf("🗑 **Место работы сброшено** на %s. Будет использован постоянный график, если он задан", period), &SetWorkLocationFunction, nil
+	}
+
+	response := fmt.Sprintf("📍 **Записал!** %s: %s", period, worklocation.LocationLabel(location))
+	if note != "" {
+		response += fmt.Sprintf(" (%s)", note)
+	}
+	if worklocation.IsRemote(location) {
+		response += "\n\n🚫 В эти дни не буду назначать очные встречи"
+	}
+
+	return response, &SetWorkLocationFunction, nil
+}
+
+func (c *ChatGPTService) handleGetWorkLocations(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	days := 7
+	if v, ok := args["days"].(float64); ok && v > 0 {
+		days = int(v)
+	}
+
+	locations, err := c.locations.GetLocations(ctx, userID, time.Now(), days)
+	if err != nil {
+		logrus.Errorf("Ошибка получения мест работы: %v", err)
+		return "❌ Не удалось получить график работы", &GetWorkLocationsFunction, nil
+	}
+
+	patterns, err := c.locations.GetPatterns(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения графика работы: %v", err)
+		return "❌ Не удалось получить график работы", &GetWorkLocationsFunction, nil
+	}
+
+	if len(locations) == 0 && len(patterns) == 0 {
+		return "📍 Вы пока не указали, откуда работаете. Скажите, например: 'по понедельникам и средам я в офисе, остальные дни дома'", &GetWorkLocationsFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📍 **Где вы работаете в ближайшие %d дн.:**\n\n", days))
+	if len(locations) == 0 {
+		b.WriteString("Нет данных на этот период\n")
+	}
+	for _, loc := range locations {
+		b.WriteString("• " + worklocation.FormatDayLocation(loc) + "\n")
+	}
+
+	if len(patterns) > 0 {
+		b.WriteString("\n🗓 **Постоянный график:**\n")
+		for _, p := range patterns {
+			b.WriteString(fmt.Sprintf("• %s: %s\n", worklocation.WeekdayLabel(p.Weekday), worklocation.LocationLabel(p.Location)))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n"), &GetWorkLocationsFunction, nil
+}
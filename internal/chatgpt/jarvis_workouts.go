@@ -0,0 +1,204 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/workouts"
+	"telegrambot/internal/worklocation"
+
+	"github.com/sirupsen/logrus"
+)
+
+var GenerateWorkoutPlanFunction = ChatGPTFunction{
+	Name:		"generate_workout_plan",
+	Description:	"Составить план тренировок на неделю (или несколько) под цели здоровья пользователя, с учетом свободных дней и инвентаря, и поставить тренировки в календарь. Используй для 'составь план тренировок', 'хочу тренироваться 3 раза в неделю'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"key_result_id": {
+				Type:		"integer",
+				Description:	"ID ключевого результата по здоровью, под который строится план. Не указывай — выберется последний ключевой результат из целей здоровья",
+			},
+			"days": {
+				Type:		"array",
+				Description:	"Дни недели для тренировок: mon, tue, wed, thu, fri, sat, sun (или пн, вт, ...)",
+				Items:		&ChatGPTProperty{Type: "string"},
+			},
+			"equipment": {
+				Type:		"array",
+				Description:	"Доступный инвентарь",
+				Items: &ChatGPTProperty{
+					Type:	"string",
+					Enum:	[]string{workouts.EquipmentNone, workouts.EquipmentDumbbells, workouts.EquipmentBands, workouts.EquipmentPullupBar, workouts.EquipmentGym},
+				},
+			},
+			"time": {
+				Type:		"string",
+				Description:	"Время начала тренировки в формате ЧЧ:ММ (по умолчанию 19:00)",
+			},
+			"duration_minutes": {
+				Type:		"integer",
+				Description:	"Длительность тренировки в минутах (по умолчанию 45)",
+				Minimum:	20,
+				Maximum:	120,
+			},
+			"weeks": {
+				Type:		"integer",
+				Description:	"На сколько недель запланировать (по умолчанию 1)",
+				Minimum:	1,
+				Maximum:	4,
+			},
+		},
+		Required:	[]string{"days"},
+	},
+}
+
+var CompleteWorkoutFunction = ChatGPTFunction{
+	Name:		"complete_workout",
+	Description:	"Отметить тренировку из плана выполненной или пропущенной. Прогресс засчитывается в связанный ключевой результат. Используй для 'потренировался', 'сделал тренировку', 'пробежал 5 км', 'пропустил тренировку'",
+	Parameters: ChatGPTFunctionParameters{
+		Type:	"object",
+		Properties: map[string]ChatGPTProperty{
+			"session_id": {
+				Type:		"integer",
+				Description:	"ID тренировки. Не указывай — возьмется ближайшая запланированная",
+			},
+			"done": {
+				Type:		"boolean",
+				Description:	"true — выполнена, false — пропущена",
+			},
+			"value": {
+				Type:		"number",
+				Description:	"Результат в единицах ключевого результата (км, подтягивания и т.п.), если пользователь его назвал",
+			},
+		},
+		Required:	[]string{"done"},
+	},
+}
+
+var GetWorkoutPlanFunction = ChatGPTFunction{
+	Name:		"get_workout_plan",
+	Description:	"Показать текущий план тренировок и отметки о выполнении",
+	Parameters: ChatGPTFunctionParameters{
+		Type:		"object",
+		Properties:	map[string]ChatGPTProperty{},
+		Required:	[]string{},
+	},
+}
+
+func (c *ChatGPTService) handleGenerateWorkoutPlan(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	logrus.Debugf("Генерация плана тренировок для пользователя %d с аргументами: %+v", userID, args)
+
+	ctx := context.Background()
+
+	weekdays, err := worklocation.ParseWeekdays(stringList(args["days"]))
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err), &GenerateWorkoutPlanFunction, nil
+	}
+
+	req := workouts.PlanRequest{
+		Weekdays:	weekdays,
+		Equipment:	stringList(args["equipment"]),
+	}
+	req.SessionTime, _ = args["time"].(string)
+	if v, ok := args["duration_minutes"].(float64); ok {
+		req.DurationMinutes = int(v)
+	}
+	if v, ok := args["weeks"].(float64); ok {
+		req.Weeks = int(v)
+	}
+	if v, ok := args["key_result_id"].(float64); ok && v > 0 {
+		id := int64(v)
+		req.KeyResultID = &id
+	}
+
+	plan, sessions, keyResult, err := c.workouts.GeneratePlan(ctx, userID, req)
+	if err != nil {
+		logrus.Errorf("Ошибка генерации плана тренировок: %v", err)
+		return fmt.Sprintf("❌ Не удалось составить план: %v", err), &GenerateWorkoutPlanFunction, nil
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🏋️ **План тренировок: %s**\n", workouts.FocusLabel(plan.Focus)))
+	if keyResult != nil {
+		b.WriteString(fmt.Sprintf("🎯 Под ключевой результат: %s (%.0f/%.0f %s)\n", keyResult.Title, keyResult.Progress, keyResult.Target, keyResult.Unit))
+	}
+	b.WriteString(fmt.Sprintf("⏱ %d мин, %s\n\n", plan.DurationMinutes, strings.Join(plan.Equipment, ", ")))
+
+	shown := make(map[string]bool)
+	for _, session := range sessions {
+		b.WriteString(workouts.FormatSession(session) + "\n")
+		if !shown[session.Title] {
+			shown[session.Title] = true
+			b.WriteString("   " + strings.Join(session.Exercises, "; ") + "\n")
+		}
+	}
+	b.WriteString("\n📅 Все тренировки добавлены в календарь. После тренировки напишите «потренировался» — засчитаю в цель")
+
+	return b.String(), &GenerateWorkoutPlanFunction, nil
+}
+
+func (c *ChatGPTService) handleCompleteWorkout(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	done := true
+	if v, ok := args["done"].(bool); ok {
+		done = v
+	}
+	var sessionID int64
+	if v, ok := args["session_id"].(float64); ok {
+		sessionID = int64(v)
+	}
+	var value *float64
+	if v, ok := args["value"].(float64); ok && v > 0 {
+		value = &v
+	}
+
+	result, err := c.workouts.CompleteSession(ctx, userID, sessionID, done, value)
+	if errors.Is(err, workouts.ErrSessionNotFound) {
+		return "❌ Не нашел запланированную тренировку. Посмотреть план: 'покажи план тренировок'", &CompleteWorkoutFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка отметки тренировки: %v", err)
+		return fmt.Sprintf("❌ %v", err), &CompleteWorkoutFunction, nil
+	}
+
+	if !done {
+		return fmt.Sprintf("⏭ Тренировка «%s» отмечена как пропущенная", result.Session.Title), &CompleteWorkoutFunction, nil
+	}
+
+	response := fmt.Sprintf("✅ Тренировка «%s» выполнена!", result.Session.Title)
+	if result.KeyResult != nil && result.Delta > 0 {
+		response += fmt.Sprintf("\n🎯 %s: +%.0f → %.0f/%.0f %s", result.KeyResult.Title, result.Delta, result.KeyResult.Progress, result.KeyResult.Target, result.KeyResult.Unit)
+	}
+
+	return response, &CompleteWorkoutFunction, nil
+}
+
+func (c *ChatGPTService) handleGetWorkoutPlan(args map[string]interface{}, userID int64) (string, *ChatGPTFunction, error) {
+	ctx := context.Background()
+
+	plan, sessions, err := c.workouts.GetActivePlan(ctx, userID)
+	if errors.Is(err, workouts.ErrPlanNotFound) {
+		return "🏋️ Плана тренировок пока нет. Скажите, например: 'составь план тренировок на пн, ср, пт'", &GetWorkoutPlanFunction, nil
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка получения плана тренировок: %v", err)
+		return "❌ Не удалось получить план тренировок", &GetWorkoutPlanFunction, nil
+	}
+
+	done := 0
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🏋️ **План тренировок: %s** (%s — %s)\n\n", workouts.FocusLabel(plan.Focus), plan.StartsOn.Format("02.01"), plan.EndsOn.Format("02.01")))
+	for _, session := range sessions {
+		if session.Status == workouts.StatusDone {
+			done++
+		}
+		b.WriteString(workouts.FormatSession(session) + "\n")
+	}
+	b.WriteString(fmt.Sprintf("\n📊 Выполнено: %d из %d", done, len(sessions)))
+
+	return b.String(), &GetWorkoutPlanFunction, nil
+}
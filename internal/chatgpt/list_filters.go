@@ -0,0 +1,56 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	defaultListLimit	= 20
+	maxListLimit		= 50
+)
+
+func listWindow(args map[string]interface{}) (int, int) {
+	limit := defaultListLimit
+	if v, ok := args["limit"].(float64); ok && v >= 1 {
+		limit = int(v)
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset := 0
+	if v, ok := args["offset"].(float64); ok && v > 0 {
+		offset = int(v)
+	}
+	return limit, offset
+}
+
+func deadlineWindow(args map[string]interface{}) int {
+	if v, ok := args["deadline_within_days"].(float64); ok && v >= 1 {
+		return int(v)
+	}
+	return 0
+}
+
+func (c *ChatGPTService) sphereFilter(ctx context.Context, userID int64, args map[string]interface{}) (string, error) {
+	name, _ := args["sphere"].(string)
+	if name == "" {
+		return "", nil
+	}
+	sphere, err := c.okr.FindSphere(ctx, userID, name)
+	if err != nil {
+		return "", err
+	}
+	return sphere.Name, nil
+}
+
+func listFooter(noun string, offset, shown, total int) string {
+	if offset == 0 && shown >= total {
+		return fmt.Sprintf("📈 **Всего %s:** %d", noun, total)
+	}
+	footer := fmt.Sprintf("📄 Показаны %d–%d из %d", offset+1, offset+shown, total)
+	if offset+shown < total {
+		footer += fmt.Sprintf(". Еще %d — следующая страница: offset=%d", total-offset-shown, offset+shown)
+	}
+	return footer
+}
@@ -0,0 +1,142 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"telegrambot/internal/dashboard"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	weeklyCommentaryMaxRunes	= 1200
+	weeklyReportMaxCharts		= 3
+)
+
+const weeklyCommentaryInstruction = `Ты — коуч по целям. По данным недели напиши короткий комментарий к отчету OKR:
+что сдвинулось и чем стоит гордиться, какие ключевые результаты под угрозой и почему, и 2-3 конкретных шага на следующую неделю.
+Опирайся только на цифры из данных, не пересказывай весь отчет и не выдумывай факты. Обращайся на «ты».
+Пиши по-русски, не длиннее 800 символов, без заголовков Markdown.`
+
+type ObjectiveChart struct {
+	Title	string
+	Image	[]byte
+}
+
+type WeeklyOKRReport struct {
+	Text		string
+	Chart		[]byte
+	Objectives	[]ObjectiveChart
+}
+
+func (c *ChatGPTService) ComposeWeeklyOKRReport(ctx context.Context, userID int64) (*WeeklyOKRReport, error) {
+	weekStart := focus.WeekStart(time.Now())
+	progress, err := c.okr.GetWeeklyProgress(ctx, userID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WeeklyOKRReport{Text: okr.FormatWeeklyProgress(progress)}
+	if len(progress.Objectives) == 0 {
+		return report, nil
+	}
+
+	chart := dashboard.WeeklyChart{WeekStart: weekStart}
+	for _, o := range progress.Objectives {
+		chart.Bars = append(chart.Bars, dashboard.WeeklyBar{Progress: o.Progress, Delta: o.Delta})
+	}
+	if image, err := chart.RenderPNG(); err != nil {
+		logrus.Warnf("Не удалось нарисовать график недели пользователя %d: %v", userID, err)
+	} else {
+		report.Chart = image
+	}
+	report.Objectives = c.renderObjectiveCharts(ctx, userID, progress)
+
+	commentary, err := c.requestWeeklyCommentary(ctx, progress, report.Text)
+	if err != nil {
+		logrus.Warnf("Не удалось получить комментарий к отчету пользователя %d: %v", userID, err)
+		return report, nil
+	}
+	report.Text += "\n\n💬 " + commentary
+	return report, nil
+}
+
+func (c *ChatGPTService) renderObjectiveCharts(ctx context.Context, userID int64, progress *okr.WeeklyProgress) []ObjectiveChart {
+	risky := make(map[string]bool)
+	for _, kr := range progress.AtRisk() {
+		risky[kr.ObjectiveID] = true
+	}
+
+	charts := make([]ObjectiveChart, 0)
+	for _, o := range progress.Objectives {
+		if len(charts) >= weeklyReportMaxCharts {
+			break
+		}
+		if math.Abs(o.Delta) < 0.5 && !risky[o.ID] {
+			continue
+		}
+		history, err := c.okr.GetObjectiveProgressHistory(ctx, userID, o.ID)
+		if err != nil {
+			logrus.Warnf("Не удалось получить историю прогресса цели %s: %v", o.ID, err)
+			continue
+		}
+		if len(history.Points) < 2 {
+			continue
+		}
+		image, err := dashboard.NewProgressChart(history).RenderPNG()
+		if err != nil {
+			logrus.Warnf("Не удалось нарисовать график цели %s: %v", o.ID, err)
+			continue
+		}
+		charts = append(charts, ObjectiveChart{Title: o.Title, Image: image})
+	}
+	return charts
+}
+
+func (c *ChatGPTService) requestWeeklyCommentary(ctx context.Context, progress *okr.WeeklyProgress, summary string) (string, error) {
+	var data strings.Builder
+	data.WriteString(summary)
+	data.WriteString("\n\nКлючевые результаты:\n")
+	for _, kr := range progress.KeyResults {
+		data.WriteString(fmt.Sprintf("- %s («%s»): %s/%s %s, %.0f%%, за неделю %+.1f",
+			kr.Title, kr.ObjectiveTitle, formatAmount(kr.Progress), formatAmount(kr.Target), kr.Unit, kr.Percent(), kr.WeekDelta))
+		if kr.Deadline != nil {
+			data.WriteString(", дедлайн " + kr.Deadline.Format("02.01.2006"))
+		}
+		data.WriteString("\n")
+	}
+
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: weeklyCommentaryInstruction},
+			{Role: openai.ChatMessageRoleUser, Content: data.String()},
+		},
+		Temperature:	0.5,
+	})
+	if err != nil {
+		return "", fmt.Errorf("ошибка при запросе комментария к отчету: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("пустой ответ при запросе комментария к отчету")
+	}
+
+	commentary := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if commentary == "" {
+		return "", fmt.Errorf("пустой комментарий к отчету")
+	}
+	return truncateRunes(commentary, weeklyCommentaryMaxRunes), nil
+}
+
+func formatAmount(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.1f", value)
+}
@@ -0,0 +1,48 @@
+package chatgpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"telegrambot/internal/plugins"
+
+	"github.com/sirupsen/logrus"
+)
+
+func pluginFunctions() []ChatGPTFunction {
+	var functions []ChatGPTFunction
+	for _, function := range plugins.Functions() {
+		parameters := ChatGPTFunctionParameters{Type: "object"}
+		if len(function.Parameters) > 0 {
+			if err := json.Unmarshal(function.Parameters, &parameters); err != nil {
+				logrus.Errorf("Некорректная схема параметров функции плагина %s: %v", function.Name, err)
+				continue
+			}
+		}
+		if parameters.Properties == nil {
+			parameters.Properties = map[string]ChatGPTProperty{}
+		}
+		functions = append(functions, ChatGPTFunction{
+			Name:		function.Name,
+			Description:	function.Description,
+			Parameters:	parameters,
+		})
+	}
+	return functions
+}
+
+func (c *ChatGPTService) dispatchFunction(ctx context.Context, functionCall *ChatGPTFunctionCall, userID int64) (string, *ChatGPTFunction, error) {
+	if !plugins.IsPluginFunction(functionCall.Name) {
+		return c.handleNewJarvisFunctions(functionCall, userID)
+	}
+
+	function := &ChatGPTFunction{Name: functionCall.Name}
+	result, err := plugins.Call(ctx, userID, functionCall.Name, functionCall.Arguments)
+	if errors.Is(err, plugins.ErrPluginDisabled) || errors.Is(err, plugins.ErrUnknownFunction) {
+		return "❌ Эта функция сейчас недоступна", function, nil
+	}
+	if err != nil {
+		return "", function, err
+	}
+	return result, function, nil
+}
@@ -0,0 +1,65 @@
+package chatgpt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const eventDraftPrompt = `Ты превращаешь короткую заметку пользователя в событие календаря.
+Ответь JSON-объектом: {"title": "короткое название", "description": "детали или пустая строка", "start_time": "YYYY-MM-DDTHH:MM:SS", "end_time": "YYYY-MM-DDTHH:MM:SS"}.
+Если время не указано, поставь 10:00 ближайшего подходящего дня. Если длительность не указана, сделай событие на 1 час.
+Если в заметке нет ничего похожего на событие, верни {"title": ""}.`
+
+type EventDraft struct {
+	Title		string	`json:"title"`
+	Description	string	`json:"description"`
+	StartTime	string	`json:"start_time"`
+	EndTime		string	`json:"end_time"`
+}
+
+func (c *ChatGPTService) TranscribeVoiceNote(ctx context.Context, audioData []byte) (string, error) {
+	text, err := c.transcribeAudio(ctx, audioData)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
+
+func (c *ChatGPTService) DraftEventFromNote(ctx context.Context, note string) (*EventDraft, error) {
+	now := time.Now()
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: eventDraftPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf("Сейчас %s (%s).\nЗаметка: %s", now.Format("2006-01-02 15:04"), now.Weekday(), note)},
+		},
+		ResponseFormat:	&openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:	0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к OpenAI: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("нет ответа от OpenAI")
+	}
+
+	var draft EventDraft
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &draft); err != nil {
+		return nil, fmt.Errorf("ошибка разбора события из заметки: %w", err)
+	}
+	if strings.TrimSpace(draft.Title) == "" {
+		return nil, nil
+	}
+
+	for _, value := range []*string{&draft.StartTime, &draft.EndTime} {
+		if t, err := time.ParseInLocation("2006-01-02T15:04:05", *value, time.Local); err == nil {
+			*value = t.Format(time.RFC3339)
+		}
+	}
+	return &draft, nil
+}
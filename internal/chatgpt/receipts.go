@@ -0,0 +1,109 @@
+package chatgpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"telegrambot/internal/finance/receipts"
+	"telegrambot/internal/monitoring"
+	"telegrambot/pkg/tracing"
+
+	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	chatCompletionsURL	= "https://api.openai.com/v1/chat/completions"
+	maxReceiptPDFSize	= 10 << 20
+)
+
+const receiptExtractionPrompt = `Ты распознаешь кассовые чеки и квитанции об оплате.
+Ответь строго JSON без пояснений:
+{"merchant": "название магазина", "total": итоговая сумма числом, "currency": "RUB", "date": "YYYY-MM-DD или пустая строка", "category": "Продукты, Кафе, Дом, Коммунальные услуги, Транспорт, Здоровье, Дети, Развлечения, Одежда или своя"}
+Если это не чек, верни {"total": 0}.`
+
+type filePart struct {
+	Type	string		`json:"type"`
+	Text	string		`json:"text,omitempty"`
+	File	*fileContent	`json:"file,omitempty"`
+}
+
+type fileContent struct {
+	Filename	string	`json:"filename"`
+	FileData	string	`json:"file_data"`
+}
+
+func (c *ChatGPTService) ExtractReceiptPDF(ctx context.Context, pdf []byte, filename string) (*receipts.Extraction, error) {
+	if len(pdf) > maxReceiptPDFSize {
+		return nil, fmt.Errorf("файл слишком большой: %d байт", len(pdf))
+	}
+	if filename == "" {
+		filename = "receipt.pdf"
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model":	openai.GPT4Dot1Mini,
+		"messages": []map[string]interface{}{
+			{"role": openai.ChatMessageRoleSystem, "content": receiptExtractionPrompt},
+			{"role": openai.ChatMessageRoleUser, "content": []filePart{
+				{Type: "text", Text: "Распознай чек"},
+				{Type: "file", File: &fileContent{Filename: filename, FileData: "data:application/pdf;base64," + base64.StdEncoding.EncodeToString(pdf)}},
+			}},
+		},
+		"response_format":	map[string]string{"type": "json_object"},
+		"temperature":		0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подготовке запроса: %v", err)
+	}
+
+	ctx, span := tracing.Start(ctx, "openai.chat_completion", attribute.String("openai.model", openai.GPT4Dot1Mini))
+	resp, err := c.postChatCompletion(ctx, body)
+	tracing.End(span, err)
+	monitoring.Observe(monitoring.EventOpenAI, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("нет ответа от OpenAI")
+	}
+
+	var ext receipts.Extraction
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &ext); err != nil {
+		return nil, fmt.Errorf("ошибка разбора чека: %w", err)
+	}
+	return &ext, nil
+}
+
+func (c *ChatGPTService) postChatCompletion(ctx context.Context, body []byte) (*openai.ChatCompletionResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chatCompletionsURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании запроса к OpenAI: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к OpenAI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ответа OpenAI: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI вернул статус %d: %s", resp.StatusCode, truncateRunes(string(data), 300))
+	}
+
+	var completion openai.ChatCompletionResponse
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ответа OpenAI: %v", err)
+	}
+	return &completion, nil
+}
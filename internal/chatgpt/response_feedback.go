@@ -0,0 +1,77 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"telegrambot/internal/ai_coach"
+	"telegrambot/internal/preferences"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ReplyFunctionChat	= "chat"
+
+	ratingWindowDays	= 30
+	minRatingsForGuidance	= 3
+)
+
+func (c *ChatGPTService) ApplyResponseRating(ctx context.Context, userID int64, function string, rating int) {
+	feedbackType := preferences.FeedbackPositive
+	effectiveness := 1.0
+	if rating < 0 {
+		feedbackType = preferences.FeedbackNegative
+		effectiveness = 0.0
+	}
+
+	if err := c.preferences.RecordFeedback(ctx, userID, feedbackType, "Оценка ответа Jarvis", function); err != nil {
+		logrus.Warnf("Не удалось сохранить оценку ответа как обратную связь: %v", err)
+	}
+
+	if function == GenerateMotivationFunction.Name {
+		if err := c.aiCoach.UpdateMotivationEffectiveness(ctx, userID, ai_coach.MotivationStrategyGenerated, effectiveness); err != nil {
+			logrus.Warnf("Не удалось обновить эффективность мотивации пользователя %d: %v", userID, err)
+		}
+	}
+}
+
+func (c *ChatGPTService) ratingGuidance(ctx context.Context, userID int64) string {
+	ratings, err := c.messages.GetRatingSummary(ctx, userID, ratingWindowDays)
+	if err != nil {
+		logrus.Warnf("Не удалось получить оценки ответов пользователя %d: %v", userID, err)
+		return ""
+	}
+
+	var liked, disliked []string
+	for _, r := range ratings {
+		if r.Total() < minRatingsForGuidance || r.Function == "" {
+			continue
+		}
+		line := fmt.Sprintf("%s (👍 %d / 👎 %d)", r.Function, r.Positive, r.Negative)
+		switch score := r.Score(); {
+		case score <= -0.2:
+			disliked = append(disliked, line)
+		case score >= 0.6:
+			liked = append(liked, line)
+		}
+	}
+	if len(liked) == 0 && len(disliked) == 0 {
+		return ""
+	}
+	sort.Strings(liked)
+	sort.Strings(disliked)
+
+	var b strings.Builder
+	b.WriteString("\n\nОЦЕНКИ ОТВЕТОВ ПОЛЬЗОВАТЕЛЕМ:")
+	if len(disliked) > 0 {
+		b.WriteString("\nЧасто 👎: " + strings.Join(disliked, ", "))
+		b.WriteString("\nВ этих сценариях отвечай короче и конкретнее, уточняй детали перед действием и не повторяй шаблонные фразы")
+	}
+	if len(liked) > 0 {
+		b.WriteString("\nЧасто 👍: " + strings.Join(liked, ", "))
+		b.WriteString("\nСохраняй в этих сценариях текущий стиль ответов")
+	}
+	return b.String()
+}
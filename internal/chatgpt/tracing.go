@@ -0,0 +1,37 @@
+package chatgpt
+
+import (
+	"context"
+	"telegrambot/internal/monitoring"
+	"telegrambot/pkg/tracing"
+
+	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func createChatCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ctx, span := tracing.Start(ctx, "openai.chat_completion",
+		attribute.String("openai.model", req.Model),
+		attribute.Int("openai.messages", len(req.Messages)),
+		attribute.Int("openai.functions", len(req.Functions)),
+	)
+
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err == nil {
+		span.SetAttributes(
+			attribute.Int("openai.prompt_tokens", resp.Usage.PromptTokens),
+			attribute.Int("openai.completion_tokens", resp.Usage.CompletionTokens),
+		)
+	}
+	tracing.End(span, err)
+	monitoring.Observe(monitoring.EventOpenAI, err)
+	return resp, err
+}
+
+func createTranscription(ctx context.Context, client *openai.Client, req openai.AudioRequest) (openai.AudioResponse, error) {
+	ctx, span := tracing.Start(ctx, "openai.transcription", attribute.String("openai.model", req.Model))
+	resp, err := client.CreateTranscription(ctx, req)
+	tracing.End(span, err)
+	monitoring.Observe(monitoring.EventOpenAI, err)
+	return resp, err
+}
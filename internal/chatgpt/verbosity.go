@@ -0,0 +1,36 @@
+package chatgpt
+
+import (
+	"context"
+	"telegrambot/internal/preferences"
+
+	"github.com/sirupsen/logrus"
+)
+
+type generationSettings struct {
+	Temperature	float32
+	Instruction	string
+}
+
+var verbositySettings = map[string]generationSettings{
+	preferences.VerbosityConcise: {
+		Temperature:	0.3,
+		Instruction:	"\n\nКРАТКОСТЬ ОТВЕТОВ: краткие. Пользователь быстро логирует данные — отвечай 1–3 короткими строками, без вступлений, мотивационных фраз и списков советов. Подтверждение действия — одна фраза",
+	},
+	preferences.VerbosityDetailed: {
+		Temperature:	0.8,
+		Instruction:	"\n\nКРАТКОСТЬ ОТВЕТОВ: подробные. Объясняй ход рассуждений, приводи примеры и предлагай конкретные следующие шаги",
+	},
+}
+
+func (c *ChatGPTService) generationSettings(ctx context.Context, userID int64) generationSettings {
+	prefs, err := c.preferences.Get(ctx, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить краткость ответов пользователя %d: %v", userID, err)
+		return generationSettings{}
+	}
+	if prefs.Verbosity == nil {
+		return generationSettings{}
+	}
+	return verbositySettings[*prefs.Verbosity]
+}
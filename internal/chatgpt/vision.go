@@ -0,0 +1,93 @@
+package chatgpt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"telegrambot/internal/finance/receipts"
+	"telegrambot/internal/messagestore/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	ImageKindMeal		= "meal"
+	ImageKindReceipt	= "receipt"
+	ImageKindDocument	= "document"
+	ImageKindOther		= "other"
+)
+
+const imageAnalysisPrompt = `Ты помогаешь личному ассистенту понять, что пользователь прислал на изображении.
+Определи тип изображения:
+- "meal" — еда или напиток, который пользователь ест или пьет;
+- "receipt" — кассовый чек, счет или квитанция об оплате;
+- "document" — доска, записи от руки, скриншот, документ, расписание, список дел;
+- "other" — все остальное.
+Ответь строго JSON без пояснений:
+{"kind": "meal|receipt|document|other", "text": "весь значимый текст с изображения или краткое описание на русском", "receipt": {"merchant": "название магазина", "total": итоговая сумма числом, "currency": "RUB", "date": "YYYY-MM-DD или пустая строка", "category": "Продукты, Кафе, Дом, Коммунальные услуги, Транспорт, Здоровье, Дети, Развлечения, Одежда или своя"}}
+Поле receipt заполняй только для чеков, для остальных типов верни null.`
+
+type ImageAnalysis struct {
+	Kind	string			`json:"kind"`
+	Text	string			`json:"text"`
+	Receipt	*receipts.Extraction	`json:"receipt"`
+}
+
+func imageDataURL(image []byte) string {
+	return fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(image), base64.StdEncoding.EncodeToString(image))
+}
+
+func (c *ChatGPTService) AnalyzeImage(ctx context.Context, image []byte, caption string) (*ImageAnalysis, error) {
+	text := "Что на изображении?"
+	if strings.TrimSpace(caption) != "" {
+		text = "Подпись пользователя: " + caption
+	}
+
+	resp, err := createChatCompletion(ctx, c.client, openai.ChatCompletionRequest{
+		Model:	openai.GPT4Dot1Mini,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: imageAnalysisPrompt},
+			{
+				Role:	openai.ChatMessageRoleUser,
+				MultiContent: []openai.ChatMessagePart{
+					{Type: openai.ChatMessagePartTypeText, Text: text},
+					{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: imageDataURL(image), Detail: openai.ImageURLDetailHigh}},
+				},
+			},
+		},
+		ResponseFormat:	&openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
+		Temperature:	0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к OpenAI: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("нет ответа от OpenAI")
+	}
+
+	var analysis ImageAnalysis
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
+		return nil, fmt.Errorf("ошибка разбора изображения: %w", err)
+	}
+	return &analysis, nil
+}
+
+func (c *ChatGPTService) ProcessImage(ctx context.Context, userID int64, image []byte, caption string, analysis *ImageAnalysis, history []models.MessageHistoryItem) (*Reply, error) {
+	if analysis.Kind == ImageKindMeal {
+		response, err := c.ProcessMealPhoto(ctx, userID, image, caption)
+		if err != nil {
+			return nil, err
+		}
+		return &Reply{Text: response, Function: LogMealFunction.Name}, nil
+	}
+
+	message := "Пользователь прислал изображение"
+	if strings.TrimSpace(caption) != "" {
+		message += " с подписью: " + caption
+	}
+	message += "\nСодержимое изображения:\n" + analysis.Text
+	return c.ProcessMessage(ctx, userID, message, history)
+}
@@ -0,0 +1,288 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"telegrambot/internal/habits"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxCardObjectives	= 5
+	maxCardHabits		= 3
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type ObjectiveProgress struct {
+	Title		string	`db:"title" json:"title"`
+	Progress	float64	`db:"progress" json:"progress"`
+}
+
+type HabitStreak struct {
+	Kind		string	`json:"kind"`
+	Label		string	`json:"label"`
+	Days		[]bool	`json:"days"`
+	GoalDays	int	`json:"goal_days"`
+	LongestStreak	int	`json:"longest_streak"`
+}
+
+type MonthlyCard struct {
+	UserID		int64			`json:"user_id"`
+	Month		time.Time		`json:"month"`
+	Objectives	[]ObjectiveProgress	`json:"objectives"`
+	Habits		[]HabitStreak		`json:"habits"`
+	Balance		[]float64		`json:"balance"`
+	Income		float64			`json:"income"`
+	Expenses	float64			`json:"expenses"`
+	Meetings	int			`json:"meetings"`
+	Events		int			`json:"events"`
+}
+
+type Redaction struct {
+	Titles		bool
+	Finance		bool
+	Meetings	bool
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func ParseMonth(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	month, err := time.ParseInLocation("2006-01", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("некорректный месяц %q, ожидается YYYY-MM", value)
+	}
+	return month, nil
+}
+
+func ParseRedaction(words []string) Redaction {
+	var r Redaction
+	for _, word := range words {
+		switch strings.ToLower(strings.TrimSpace(word)) {
+		case "private", "приватно", "all", "все":
+			r = Redaction{Titles: true, Finance: true, Meetings: true}
+		case "titles", "названия":
+			r.Titles = true
+		case "finance", "финансы", "деньги":
+			r.Finance = true
+		case "meetings", "встречи":
+			r.Meetings = true
+		}
+	}
+	return r
+}
+
+func (r Redaction) Any() bool {
+	return r.Titles || r.Finance || r.Meetings
+}
+
+func (s *Service) BuildMonthlyCard(ctx context.Context, userID int64, month time.Time) (*MonthlyCard, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	end := start.AddDate(0, 1, 0)
+	card := &MonthlyCard{UserID: userID, Month: start}
+
+	err := s.db.SelectContext(ctx, &card.Objectives, `
+		SELECT o.title, COALESCE(AVG(LEAST(kr.progress / NULLIF(kr.target, 0), 1)) * 100, 0) AS progress
+		FROM objectives o
+		LEFT JOIN key_results kr ON kr.objective_id = o.id AND kr.deleted_at IS NULL
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND o.created_at < $3
+		  AND (o.completion_date IS NULL OR o.completion_date >= $2)
+		GROUP BY o.id, o.title
+		ORDER BY progress DESC, o.title
+		LIMIT $4
+	`, userID, start, end, maxCardObjectives)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении прогресса целей для карточки: %v", err)
+	}
+
+	card.Habits, err = s.habitStreaks(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fillFinance(ctx, card, start, end); err != nil {
+		return nil, err
+	}
+
+	err = s.db.GetContext(ctx, &card.Meetings, `
+		SELECT COUNT(*)
+		FROM meetings
+		WHERE (initiator_id = $1 OR participant_id = $1) AND confirmed = TRUE
+		  AND start_time >= $2 AND start_time < $3
+	`, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете встреч для карточки: %v", err)
+	}
+
+	err = s.db.GetContext(ctx, &card.Events, `
+		SELECT COUNT(*) FROM events WHERE user_id = $1 AND start_time >= $2 AND start_time < $3
+	`, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете событий для карточки: %v", err)
+	}
+
+	return card, nil
+}
+
+func (s *Service) habitStreaks(ctx context.Context, userID int64, start, end time.Time) ([]HabitStreak, error) {
+	var rows []struct {
+		Kind		string		`db:"kind"`
+		Date		time.Time	`db:"log_date"`
+		GoalReached	bool		`db:"goal_reached"`
+	}
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT c.kind, d.log_date, d.goal_reached
+		FROM habit_counter_days d
+		JOIN habit_counters c ON c.id = d.counter_id
+		WHERE d.user_id = $1 AND d.log_date >= $2::date AND d.log_date < $3::date
+	`, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении серий привычек для карточки: %v", err)
+	}
+
+	daysInMonth := end.AddDate(0, 0, -1).Day()
+	byKind := make(map[string]*HabitStreak)
+	for _, row := range rows {
+		streak, ok := byKind[row.Kind]
+		if !ok {
+			label := row.Kind
+			if preset, found := habits.GetPreset(row.Kind); found {
+				label = preset.Emoji + " " + preset.Label
+			}
+			streak = &HabitStreak{Kind: row.Kind, Label: label, Days: make([]bool, daysInMonth)}
+			byKind[row.Kind] = streak
+		}
+		day := row.Date.Day() - 1
+		if row.GoalReached && day >= 0 && day < daysInMonth {
+			streak.Days[day] = true
+		}
+	}
+
+	result := make([]HabitStreak, 0, len(byKind))
+	for _, streak := range byKind {
+		current := 0
+		for _, reached := range streak.Days {
+			if !reached {
+				current = 0
+				continue
+			}
+			streak.GoalDays++
+			current++
+			if current > streak.LongestStreak {
+				streak.LongestStreak = current
+			}
+		}
+		result = append(result, *streak)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LongestStreak != result[j].LongestStreak {
+			return result[i].LongestStreak > result[j].LongestStreak
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	if len(result) > maxCardHabits {
+		result = result[:maxCardHabits]
+	}
+	return result, nil
+}
+
+func (s *Service) fillFinance(ctx context.Context, card *MonthlyCard, start, end time.Time) error {
+	var days []struct {
+		Day		time.Time	`db:"day"`
+		Income		float64		`db:"income"`
+		Expenses	float64		`db:"expenses"`
+	}
+	err := s.db.SelectContext(ctx, &days, `
+		SELECT DATE_TRUNC('day', created_at) AS day,
+			COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0) AS income,
+			COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0) AS expenses
+		FROM transactions
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY day
+		ORDER BY day
+	`, card.UserID, start, end)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении финансов для карточки: %v", err)
+	}
+
+	daysInMonth := end.AddDate(0, 0, -1).Day()
+	net := make([]float64, daysInMonth)
+	for _, d := range days {
+		card.Income += d.Income
+		card.Expenses += d.Expenses
+		index := d.Day.Day() - 1
+		if index >= 0 && index < daysInMonth {
+			net[index] += d.Income - d.Expenses
+		}
+	}
+
+	lastDay := daysInMonth
+	if now := time.Now(); now.Before(end) && now.After(start) {
+		lastDay = now.Day()
+	}
+	card.Balance = make([]float64, 0, lastDay)
+	running := 0.0
+	for i := 0; i < lastDay; i++ {
+		running += net[i]
+		card.Balance = append(card.Balance, running)
+	}
+	return nil
+}
+
+func (c *MonthlyCard) Caption(r Redaction) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📊 Итоги месяца %s\n", c.Month.Format("01.2006")))
+
+	if len(c.Objectives) > 0 {
+		b.WriteString("\n🎯 Цели:\n")
+		for i, o := range c.Objectives {
+			title := o.Title
+			if r.Titles {
+				title = fmt.Sprintf("Цель %d", i+1)
+			}
+			b.WriteString(fmt.Sprintf("%d. %s — %.0f%%\n", i+1, title, o.Progress))
+		}
+	}
+
+	if len(c.Habits) > 0 {
+		b.WriteString("\n🔥 Привычки:\n")
+		for _, h := range c.Habits {
+			b.WriteString(fmt.Sprintf("%s — серия %d дн., цель выполнена %d дн.\n", h.Label, h.LongestStreak, h.GoalDays))
+		}
+	}
+
+	if r.Finance {
+		b.WriteString(fmt.Sprintf("\n💰 Баланс: %s\n", c.balanceTrend()))
+	} else {
+		b.WriteString(fmt.Sprintf("\n💰 Доходы %.0f, расходы %.0f, итог %+.0f\n", c.Income, c.Expenses, c.Income-c.Expenses))
+	}
+
+	if !r.Meetings {
+		b.WriteString(fmt.Sprintf("\n🤝 Встречи: %d, событий в календаре: %d\n", c.Meetings, c.Events))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (c *MonthlyCard) balanceTrend() string {
+	switch net := c.Income - c.Expenses; {
+	case net > 0:
+		return "в плюсе 📈"
+	case net < 0:
+		return "в минусе 📉"
+	default:
+		return "без изменений"
+	}
+}
@@ -0,0 +1,79 @@
+package dashboard
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const (
+	glyphWidth	= 5
+	glyphHeight	= 7
+)
+
+var glyphs = map[rune][glyphHeight]string{
+	'0':	{".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1':	{"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2':	{".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3':	{"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4':	{"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5':	{"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6':	{"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7':	{"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8':	{".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9':	{".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'%':	{"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+	'+':	{".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'-':	{".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'.':	{".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+	'/':	{".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'*':	{".....", "#.#.#", ".###.", "#####", ".###.", "#.#.#", "....."},
+	'А':	{".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'Б':	{"#####", "#....", "#....", "####.", "#...#", "#...#", "####."},
+	'В':	{"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'Г':	{"#####", "#....", "#....", "#....", "#....", "#....", "#...."},
+	'Д':	{"..##.", ".#.#.", ".#.#.", ".#.#.", ".#.#.", "#####", "#...#"},
+	'Е':	{"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'И':	{"#...#", "#...#", "#..##", "#.#.#", "##..#", "#...#", "#...#"},
+	'К':	{"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'Л':	{"..###", ".#..#", ".#..#", ".#..#", ".#..#", ".#..#", "#...#"},
+	'М':	{"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'Н':	{"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'О':	{".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'П':	{"#####", "#...#", "#...#", "#...#", "#...#", "#...#", "#...#"},
+	'Р':	{"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'С':	{".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'Т':	{"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'Ф':	{"..#..", ".###.", "#.#.#", "#.#.#", "#.#.#", ".###.", "..#.."},
+	'Ц':	{"#..#.", "#..#.", "#..#.", "#..#.", "#..#.", "#####", "....#"},
+	'Ч':	{"#...#", "#...#", "#...#", ".####", "....#", "....#", "....#"},
+	'Ы':	{"#...#", "#...#", "#...#", "##..#", "#.#.#", "#.#.#", "##..#"},
+	'Я':	{".####", "#...#", "#...#", ".####", "..#.#", ".#..#", "#...#"},
+}
+
+func textWidth(text string, scale int) int {
+	count := len([]rune(text))
+	if count == 0 {
+		return 0
+	}
+	return count*(glyphWidth+1)*scale - scale
+}
+
+func drawText(img draw.Image, x, y int, text string, scale int, c color.Color) {
+	src := image.NewUniform(c)
+	for _, r := range text {
+		glyph, ok := glyphs[r]
+		if ok {
+			for row, line := range glyph {
+				for col, pixel := range line {
+					if pixel != '#' {
+						continue
+					}
+					rect := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+					draw.Draw(img, rect, src, image.Point{}, draw.Over)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
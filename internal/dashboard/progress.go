@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"telegrambot/internal/okr"
+	"time"
+)
+
+const (
+	progressChartWidth	= 1080
+	progressChartHeight	= 860
+	progressChartMaxDays	= 365
+)
+
+type ProgressChart struct {
+	Created		time.Time
+	Start		time.Time
+	Deadline	*time.Time
+	Points		[]float64
+}
+
+func NewProgressChart(h *okr.ProgressHistory) *ProgressChart {
+	chart := &ProgressChart{Created: h.Objective.CreatedAt, Start: h.Start, Deadline: h.Objective.Deadline}
+	for _, p := range h.Points {
+		chart.Points = append(chart.Points, p.Percent)
+	}
+	return chart
+}
+
+func (c *ProgressChart) end() time.Time {
+	end := c.Start.AddDate(0, 0, len(c.Points)-1)
+	if c.Deadline != nil && c.Deadline.After(end) && c.Deadline.Sub(c.Start) < progressChartMaxDays*24*time.Hour {
+		end = *c.Deadline
+	}
+	return end
+}
+
+func (c *ProgressChart) idealPercent(day time.Time) (float64, bool) {
+	if c.Deadline == nil || !c.Deadline.After(c.Created) {
+		return 0, false
+	}
+	share := float64(day.Sub(c.Created)) / float64(c.Deadline.Sub(c.Created))
+	return math.Max(0, math.Min(share, 1)) * 100, true
+}
+
+func (c *ProgressChart) RenderPNG() ([]byte, error) {
+	if len(c.Points) == 0 {
+		return nil, fmt.Errorf("нет данных для графика прогресса")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, progressChartWidth, progressChartHeight))
+	drawGradient(img)
+
+	current := math.Max(0, math.Min(c.Points[len(c.Points)-1], 100))
+	drawText(img, cardPadding, cardPadding, "ПРОГРЕСС ЦЕЛИ", 6, colorText)
+	drawText(img, cardPadding, cardPadding+60, fmt.Sprintf("%.0f%%", current), 10, colorAccent)
+	if c.Deadline != nil {
+		label := "СРОК " + c.Deadline.Format("02.01.2006")
+		drawText(img, progressChartWidth-cardPadding-textWidth(label, 4), cardPadding+90, label, 4, colorMuted)
+	}
+
+	p := panel{240, progressChartHeight - cardPadding + 20}
+	drawPanel(img, p, "ДИНАМИКА")
+
+	left, right := cardPadding+110, progressChartWidth-cardPadding
+	top, bottom := p.top+90, p.bottom-80
+	start, end := c.Start, c.end()
+	span := end.Sub(start)
+	if span <= 0 {
+		span = 24 * time.Hour
+	}
+	scaleX := func(day time.Time) int {
+		share := math.Max(0, math.Min(float64(day.Sub(start))/float64(span), 1))
+		return left + int(float64(right-left)*share)
+	}
+	scaleY := func(percent float64) int {
+		return bottom - int(float64(bottom-top)*math.Max(0, math.Min(percent, 100))/100)
+	}
+
+	for _, level := range []float64{0, 50, 100} {
+		y := scaleY(level)
+		fillRect(img, image.Rect(left, y, right, y+2), colorTrack)
+		label := fmt.Sprintf("%.0f%%", level)
+		drawText(img, left-24-textWidth(label, 4), y-14, label, 4, colorMuted)
+	}
+
+	if from, ok := c.idealPercent(start); ok {
+		to, _ := c.idealPercent(end)
+		drawLine(img, scaleX(start), scaleY(from), scaleX(end), scaleY(to), 3, colorMuted)
+	}
+	if c.Deadline != nil && !c.Deadline.Before(start) && !c.Deadline.After(end) {
+		x := scaleX(*c.Deadline)
+		fillRect(img, image.Rect(x-1, top, x+2, bottom), colorBad)
+	}
+
+	lineColor := colorGood
+	if ideal, ok := c.idealPercent(c.Start.AddDate(0, 0, len(c.Points)-1)); ok && current < ideal {
+		lineColor = colorAccent
+	}
+	for i := 1; i < len(c.Points); i++ {
+		x0 := scaleX(start.AddDate(0, 0, i-1))
+		x1 := scaleX(start.AddDate(0, 0, i))
+		drawLine(img, x0, scaleY(c.Points[i-1]), x1, scaleY(c.Points[i]), 5, lineColor)
+	}
+	last := c.Start.AddDate(0, 0, len(c.Points)-1)
+	fillRect(img, image.Rect(scaleX(last)-8, scaleY(current)-8, scaleX(last)+9, scaleY(current)+9), lineColor)
+
+	drawText(img, left, bottom+30, start.Format("02.01"), 4, colorMuted)
+	endLabel := end.Format("02.01")
+	drawText(img, right-textWidth(endLabel, 4), bottom+30, endLabel, 4, colorMuted)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("ошибка при кодировании графика прогресса в PNG: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,212 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+const (
+	cardWidth	= 1080
+	cardHeight	= 1350
+	cardPadding	= 60
+)
+
+var (
+	colorTop	= color.RGBA{24, 28, 56, 255}
+	colorBottom	= color.RGBA{70, 38, 102, 255}
+	colorPanel	= color.RGBA{22, 22, 22, 22}
+	colorText	= color.RGBA{240, 240, 250, 255}
+	colorMuted	= color.RGBA{160, 160, 190, 255}
+	colorTrack	= color.RGBA{40, 40, 40, 40}
+	colorAccent	= color.RGBA{255, 196, 72, 255}
+	colorGood	= color.RGBA{92, 214, 148, 255}
+	colorBad	= color.RGBA{245, 104, 110, 255}
+	colorHabit	= color.RGBA{255, 138, 76, 255}
+	colorMeeting	= color.RGBA{120, 170, 255, 255}
+)
+
+type panel struct {
+	top, bottom int
+}
+
+func (c *MonthlyCard) RenderPNG(r Redaction) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	drawGradient(img)
+
+	drawText(img, cardPadding, cardPadding, "ИТОГИ МЕСЯЦА", 6, colorText)
+	drawText(img, cardPadding, cardPadding+60, c.Month.Format("01.2006"), 10, colorAccent)
+
+	c.renderObjectives(img, panel{240, 560})
+	c.renderHabits(img, panel{580, 840})
+	c.renderBalance(img, panel{860, 1110}, r)
+	c.renderMeetings(img, panel{1130, 1290}, r)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("ошибка при кодировании карточки в PNG: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func drawGradient(img *image.RGBA) {
+	for y := 0; y < cardHeight; y++ {
+		t := float64(y) / float64(cardHeight-1)
+		row := color.RGBA{
+			R:	lerp(colorTop.R, colorBottom.R, t),
+			G:	lerp(colorTop.G, colorBottom.G, t),
+			B:	lerp(colorTop.B, colorBottom.B, t),
+			A:	255,
+		}
+		draw.Draw(img, image.Rect(0, y, cardWidth, y+1), image.NewUniform(row), image.Point{}, draw.Src)
+	}
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*t)
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+func drawPanel(img *image.RGBA, p panel, title string) {
+	fillRect(img, image.Rect(cardPadding-20, p.top, cardWidth-cardPadding+20, p.bottom), colorPanel)
+	drawText(img, cardPadding, p.top+24, title, 4, colorMuted)
+}
+
+func (c *MonthlyCard) renderObjectives(img *image.RGBA, p panel) {
+	drawPanel(img, p, "ЦЕЛИ")
+	if len(c.Objectives) == 0 {
+		drawText(img, cardPadding, p.top+120, "-", 6, colorMuted)
+		return
+	}
+
+	barLeft := cardPadding + 50
+	barRight := cardWidth - cardPadding - 150
+	for i, o := range c.Objectives {
+		y := p.top + 80 + i*46
+		drawText(img, cardPadding, y+2, fmt.Sprintf("%d", i+1), 4, colorMuted)
+
+		fillRect(img, image.Rect(barLeft, y, barRight, y+28), colorTrack)
+		progress := math.Max(0, math.Min(o.Progress, 100))
+		filled := barLeft + int(float64(barRight-barLeft)*progress/100)
+		barColor := colorAccent
+		if progress >= 100 {
+			barColor = colorGood
+		}
+		fillRect(img, image.Rect(barLeft, y, filled, y+28), barColor)
+
+		label := fmt.Sprintf("%.0f%%", progress)
+		drawText(img, cardWidth-cardPadding-textWidth(label, 4), y+2, label, 4, colorText)
+	}
+}
+
+func (c *MonthlyCard) renderHabits(img *image.RGBA, p panel) {
+	drawPanel(img, p, "ПРИВЫЧКИ")
+	if len(c.Habits) == 0 {
+		drawText(img, cardPadding, p.top+120, "-", 6, colorMuted)
+		return
+	}
+
+	const cell, gap = 22, 4
+	for i, h := range c.Habits {
+		y := p.top + 80 + i*54
+		for day, reached := range h.Days {
+			x := cardPadding + day*(cell+gap)
+			cellColor := color.Color(colorTrack)
+			if reached {
+				cellColor = colorHabit
+			}
+			fillRect(img, image.Rect(x, y, x+cell, y+cell), cellColor)
+		}
+
+		label := fmt.Sprintf("%d*", h.LongestStreak)
+		drawText(img, cardWidth-cardPadding-textWidth(label, 4), y, label, 4, colorHabit)
+	}
+}
+
+func (c *MonthlyCard) renderBalance(img *image.RGBA, p panel, r Redaction) {
+	drawPanel(img, p, "БАЛАНС")
+
+	net := c.Income - c.Expenses
+	trendColor := colorGood
+	if net < 0 {
+		trendColor = colorBad
+	}
+	if !r.Finance {
+		label := fmt.Sprintf("%+.0f", net)
+		drawText(img, cardWidth-cardPadding-textWidth(label, 4), p.top+24, label, 4, trendColor)
+	}
+
+	if len(c.Balance) < 2 {
+		drawText(img, cardPadding, p.top+120, "-", 6, colorMuted)
+		return
+	}
+
+	left, right := cardPadding, cardWidth-cardPadding
+	top, bottom := p.top+80, p.bottom-30
+
+	minValue, maxValue := 0.0, 0.0
+	for _, v := range c.Balance {
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+	if maxValue == minValue {
+		maxValue = minValue + 1
+	}
+	scaleY := func(v float64) int {
+		return bottom - int((v-minValue)/(maxValue-minValue)*float64(bottom-top))
+	}
+
+	zero := scaleY(0)
+	fillRect(img, image.Rect(left, zero, right, zero+2), colorTrack)
+
+	step := float64(right-left) / float64(len(c.Balance)-1)
+	for i := 1; i < len(c.Balance); i++ {
+		x0 := left + int(step*float64(i-1))
+		x1 := left + int(step*float64(i))
+		drawLine(img, x0, scaleY(c.Balance[i-1]), x1, scaleY(c.Balance[i]), 3, trendColor)
+	}
+}
+
+func (c *MonthlyCard) renderMeetings(img *image.RGBA, p panel, r Redaction) {
+	drawPanel(img, p, "ВСТРЕЧИ")
+	if r.Meetings {
+		drawText(img, cardPadding, p.top+80, "*", 8, colorMuted)
+		return
+	}
+
+	count := c.Meetings + c.Events
+	drawText(img, cardPadding, p.top+80, fmt.Sprintf("%d", count), 8, colorMeeting)
+
+	const dot, gap = 14, 8
+	left := cardPadding + 260
+	maxDots := (cardWidth - cardPadding - left) / (dot + gap)
+	for i := 0; i < count && i < maxDots*2; i++ {
+		row, col := i/maxDots, i%maxDots
+		x := left + col*(dot+gap)
+		y := p.top + 88 + row*(dot+gap)
+		fillRect(img, image.Rect(x, y, x+dot, y+dot), colorMeeting)
+	}
+}
+
+func drawLine(img *image.RGBA, x0, y0, x1, y1, width int, c color.Color) {
+	dx := math.Abs(float64(x1 - x0))
+	dy := math.Abs(float64(y1 - y0))
+	steps := int(math.Max(dx, dy))
+	if steps == 0 {
+		steps = 1
+	}
+	half := width / 2
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := x0 + int(math.Round(float64(x1-x0)*t))
+		y := y0 + int(math.Round(float64(y1-y0)*t))
+		fillRect(img, image.Rect(x-half, y-half, x+half+1, y+half+1), c)
+	}
+}
@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"time"
+)
+
+const (
+	weeklyChartWidth	= 1080
+	weeklyChartMaxBars	= 8
+	weeklyChartRowHeight	= 90
+)
+
+type WeeklyBar struct {
+	Progress	float64
+	Delta		float64
+}
+
+type WeeklyChart struct {
+	WeekStart	time.Time
+	Bars		[]WeeklyBar
+}
+
+func (c *WeeklyChart) RenderPNG() ([]byte, error) {
+	bars := c.Bars
+	if len(bars) > weeklyChartMaxBars {
+		bars = bars[:weeklyChartMaxBars]
+	}
+	height := 260 + len(bars)*weeklyChartRowHeight + cardPadding
+	img := image.NewRGBA(image.Rect(0, 0, weeklyChartWidth, height))
+	drawGradient(img)
+
+	drawText(img, cardPadding, cardPadding, "ИТОГИ НЕДЕЛИ", 6, colorText)
+	drawText(img, cardPadding, cardPadding+60, c.WeekStart.Format("02.01.2006"), 8, colorAccent)
+
+	p := panel{220, height - cardPadding + 20}
+	drawPanel(img, p, "ЦЕЛИ")
+	if len(bars) == 0 {
+		drawText(img, cardPadding, p.top+120, "-", 6, colorMuted)
+	}
+
+	barLeft := cardPadding + 50
+	barRight := weeklyChartWidth - cardPadding - 260
+	scaleX := func(percent float64) int {
+		return barLeft + int(float64(barRight-barLeft)*math.Max(0, math.Min(percent, 100))/100)
+	}
+	for i, bar := range bars {
+		y := p.top + 80 + i*weeklyChartRowHeight
+		drawText(img, cardPadding, y+6, fmt.Sprintf("%d", i+1), 5, colorMuted)
+
+		fillRect(img, image.Rect(barLeft, y, barRight, y+40), colorTrack)
+		before := bar.Progress - bar.Delta
+		if bar.Delta >= 0 {
+			fillRect(img, image.Rect(barLeft, y, scaleX(before), y+40), colorAccent)
+			fillRect(img, image.Rect(scaleX(before), y, scaleX(bar.Progress), y+40), colorGood)
+		} else {
+			fillRect(img, image.Rect(barLeft, y, scaleX(bar.Progress), y+40), colorAccent)
+			fillRect(img, image.Rect(scaleX(bar.Progress), y, scaleX(before), y+40), colorBad)
+		}
+
+		label := fmt.Sprintf("%.0f%%", math.Max(0, math.Min(bar.Progress, 100)))
+		drawText(img, barRight+24, y+6, label, 5, colorText)
+		if math.Abs(bar.Delta) >= 0.5 {
+			delta := fmt.Sprintf("%+.0f", bar.Delta)
+			deltaColor := colorGood
+			if bar.Delta < 0 {
+				deltaColor = colorBad
+			}
+			drawText(img, weeklyChartWidth-cardPadding-textWidth(delta, 5), y+6, delta, 5, deltaColor)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("ошибка при кодировании графика недели в PNG: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,295 @@
+package dates
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type ImportantDate struct {
+	ID			int64		`db:"id" json:"id"`
+	UserID			int64		`db:"user_id" json:"user_id"`
+	Title			string		`db:"title" json:"title"`
+	PersonName		*string		`db:"person_name" json:"person_name,omitempty"`
+	DateType		string		`db:"date_type" json:"date_type"`
+	Month			int		`db:"month" json:"month"`
+	Day			int		`db:"day" json:"day"`
+	Year			*int		`db:"year" json:"year,omitempty"`
+	RemindDaysBefore	int		`db:"remind_days_before" json:"remind_days_before"`
+	LastAdvanceSent		*time.Time	`db:"last_advance_sent" json:"-"`
+	LastDaySent		*time.Time	`db:"last_day_sent" json:"-"`
+	CreatedAt		time.Time	`db:"created_at" json:"created_at"`
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) AddDate(ctx context.Context, userID int64, title, personName, dateType string, month, day int, year *int, remindDaysBefore int) (int64, error) {
+
+	dateType = strings.ToLower(dateType)
+	if dateType == "" {
+		dateType = "birthday"
+	}
+	if dateType != "birthday" && dateType != "anniversary" && dateType != "other" {
+		return 0, fmt.Errorf("неверный тип даты: %s. Допустимые значения: birthday, anniversary, other", dateType)
+	}
+
+	if err := validateMonthDay(month, day); err != nil {
+		return 0, err
+	}
+
+	if remindDaysBefore < 0 || remindDaysBefore > 60 {
+		return 0, fmt.Errorf("неверное количество дней для напоминания: %d. Должно быть от 0 до 60", remindDaysBefore)
+	}
+
+	var person *string
+	if personName != "" {
+		person = &personName
+	}
+
+	query := `
+		INSERT INTO important_dates (user_id, title, person_name, date_type, month, day, year, remind_days_before)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query, userID, title, person, dateType, month, day, year, remindDaysBefore).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при сохранении важной даты: %v", err)
+	}
+
+	return id, nil
+}
+
+func (s *Service) GetDates(ctx context.Context, userID int64) ([]ImportantDate, error) {
+	query := `
+		SELECT id, user_id, title, person_name, date_type, month, day, year,
+			remind_days_before, last_advance_sent, last_day_sent, created_at
+		FROM important_dates
+		WHERE user_id = $1
+	`
+
+	var dates []ImportantDate
+	err := s.db.SelectContext(ctx, &dates, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении важных дат: %v", err)
+	}
+
+	now := time.Now()
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].NextOccurrence(now).Before(dates[j].NextOccurrence(now))
+	})
+
+	return dates, nil
+}
+
+func (s *Service) GetUpcomingDates(ctx context.Context, userID int64, days int) ([]ImportantDate, error) {
+	dates, err := s.GetDates(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var upcoming []ImportantDate
+	for _, d := range dates {
+		if d.DaysUntil(now) <= days {
+			upcoming = append(upcoming, d)
+		}
+	}
+
+	return upcoming, nil
+}
+
+func (s *Service) GetDateByID(ctx context.Context, userID int64, dateID int64) (*ImportantDate, error) {
+	query := `
+		SELECT id, user_id, title, person_name, date_type, month, day, year,
+			remind_days_before, last_advance_sent, last_day_sent, created_at
+		FROM important_dates
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var date ImportantDate
+	err := s.db.GetContext(ctx, &date, query, dateID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("важная дата не найдена или не принадлежит пользователю: %v", err)
+	}
+
+	return &date, nil
+}
+
+func (s *Service) FindDateByDescription(ctx context.Context, userID int64, description string) ([]ImportantDate, error) {
+
+	searchPattern := "%" + strings.ToLower(description) + "%"
+
+	query := `
+		SELECT id, user_id, title, person_name, date_type, month, day, year,
+			remind_days_before, last_advance_sent, last_day_sent, created_at
+		FROM important_dates
+		WHERE user_id = $1 AND (LOWER(title) LIKE $2 OR LOWER(COALESCE(person_name, '')) LIKE $2)
+		ORDER BY month, day
+	`
+
+	var dates []ImportantDate
+	err := s.db.SelectContext(ctx, &dates, query, userID, searchPattern)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске важных дат: %v", err)
+	}
+
+	return dates, nil
+}
+
+func (s *Service) DeleteDate(ctx context.Context, userID int64, dateID int64) error {
+	query := `
+		DELETE FROM important_dates
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := s.db.ExecContext(ctx, query, dateID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении важной даты: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("важная дата не найдена или не принадлежит пользователю")
+	}
+
+	return nil
+}
+
+func (d *ImportantDate) NextOccurrence(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	occurrence := occurrenceInYear(d.Month, d.Day, today.Year(), now.Location())
+	if occurrence.Before(today) {
+		occurrence = occurrenceInYear(d.Month, d.Day, today.Year()+1, now.Location())
+	}
+
+	return occurrence
+}
+
+func (d *ImportantDate) DaysUntil(now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return int(math.Round(d.NextOccurrence(now).Sub(today).Hours() / 24))
+}
+
+func (d *ImportantDate) Age(now time.Time) int {
+	if d.Year == nil {
+		return 0
+	}
+	return d.NextOccurrence(now).Year() - *d.Year
+}
+
+func (d *ImportantDate) TypeIcon() string {
+	switch d.DateType {
+	case "birthday":
+		return "🎂"
+	case "anniversary":
+		return "💍"
+	default:
+		return "📌"
+	}
+}
+
+func occurrenceInYear(month, day, year int, loc *time.Location) time.Time {
+	if month == 2 && day == 29 && !isLeapYear(year) {
+		day = 28
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func validateMonthDay(month, day int) error {
+	if month < 1 || month > 12 {
+		return fmt.Errorf("неверный месяц: %d", month)
+	}
+
+	maxDay := time.Date(2024, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day < 1 || day > maxDay {
+		return fmt.Errorf("неверный день: %d для месяца %d", day, month)
+	}
+
+	return nil
+}
+
+func ParseDate(value string) (month, day int, year *int, err error) {
+	value = strings.TrimSpace(value)
+
+	if t, parseErr := time.Parse("2006-01-02", value); parseErr == nil {
+		y := t.Year()
+		return int(t.Month()), t.Day(), &y, nil
+	}
+
+	if t, parseErr := time.Parse("02.01.2006", value); parseErr == nil {
+		y := t.Year()
+		return int(t.Month()), t.Day(), &y, nil
+	}
+
+	var parts []string
+	if strings.Contains(value, ".") {
+		parts = strings.Split(value, ".")
+		if len(parts) == 2 {
+			parts[0], parts[1] = parts[1], parts[0]
+		}
+	} else {
+		parts = strings.Split(value, "-")
+	}
+
+	if len(parts) != 2 {
+		return 0, 0, nil, fmt.Errorf("некорректный формат даты: %s", value)
+	}
+
+	month, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("некорректный месяц в дате: %s", value)
+	}
+
+	day, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("некорректный день в дате: %s", value)
+	}
+
+	if err := validateMonthDay(month, day); err != nil {
+		return 0, 0, nil, err
+	}
+
+	return month, day, nil, nil
+}
+
+func (d *ImportantDate) PreparationTask(now time.Time) (title, description string, start, end time.Time) {
+	occurrence := d.NextOccurrence(now)
+
+	if d.DateType == "birthday" || d.DateType == "anniversary" {
+		title = fmt.Sprintf("Купить подарок: %s", d.Title)
+	} else {
+		title = fmt.Sprintf("Подготовиться: %s", d.Title)
+	}
+	description = fmt.Sprintf("%s %s — %s", d.TypeIcon(), d.Title, occurrence.Format("02.01.2006"))
+
+	start = occurrence.AddDate(0, 0, -1).Add(10 * time.Hour)
+	if start.Before(now) {
+		start = now.Truncate(time.Hour).Add(time.Hour)
+	}
+	end = start.Add(time.Hour)
+
+	return title, description, start, end
+}
@@ -0,0 +1,164 @@
+package dates
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const reminderHour = 9
+
+func (s *Service) StartReminderChecker(jm *jobs.Manager, sendMessage func(int64, string) error, sendTaskOffer func(chatID int64, text string, dateID int64) error) {
+	jm.Register(jobs.Job{
+		Name:		"important_date_reminders",
+		Spec:		"*/10 * * * *",
+		Run: func(ctx context.Context) {
+			s.checkAndSendReminders(ctx, sendMessage, sendTaskOffer)
+		},
+	})
+
+	logrus.Info("Запущен механизм напоминаний о важных датах")
+}
+
+func (s *Service) checkAndSendReminders(ctx context.Context, sendMessage func(int64, string) error, sendTaskOffer func(chatID int64, text string, dateID int64) error) {
+	now := time.Now()
+
+	if now.Hour() < reminderHour {
+		return
+	}
+
+	query := `
+		SELECT id, user_id, title, person_name, date_type, month, day, year,
+			remind_days_before, last_advance_sent, last_day_sent, created_at
+		FROM important_dates
+	`
+
+	var dates []ImportantDate
+	err := s.db.SelectContext(ctx, &dates, query)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении важных дат для напоминаний: %v", err)
+		return
+	}
+
+	for _, date := range dates {
+		occurrence := date.NextOccurrence(now)
+		daysUntil := date.DaysUntil(now)
+
+		if daysUntil == 0 {
+			if sentFor(date.LastDaySent, occurrence) {
+				continue
+			}
+
+			err := sendMessage(date.UserID, FormatDayMessage(&date, now))
+			if err != nil {
+				logrus.Errorf("Ошибка при отправке поздравления о дате %d пользователю %d: %v", date.ID, date.UserID, err)
+				continue
+			}
+
+			if err := s.markSent(ctx, date.ID, "last_day_sent", occurrence); err != nil {
+				logrus.Errorf("Ошибка при обновлении статуса напоминания о дате %d: %v", date.ID, err)
+			}
+			continue
+		}
+
+		if date.RemindDaysBefore > 0 && daysUntil <= date.RemindDaysBefore {
+			if sentFor(date.LastAdvanceSent, occurrence) {
+				continue
+			}
+
+			err := sendTaskOffer(date.UserID, FormatAdvanceMessage(&date, now), date.ID)
+			if err != nil {
+				logrus.Errorf("Ошибка при отправке напоминания о дате %d пользователю %d: %v", date.ID, date.UserID, err)
+				continue
+			}
+
+			if err := s.markSent(ctx, date.ID, "last_advance_sent", occurrence); err != nil {
+				logrus.Errorf("Ошибка при обновлении статуса напоминания о дате %d: %v", date.ID, err)
+			}
+		}
+	}
+}
+
+func (s *Service) markSent(ctx context.Context, dateID int64, column string, occurrence time.Time) error {
+	query := fmt.Sprintf(`UPDATE important_dates SET %s = $1 WHERE id = $2`, column)
+
+	_, err := s.db.ExecContext(ctx, query, occurrence.Format("2006-01-02"), dateID)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении статуса напоминания: %v", err)
+	}
+
+	return nil
+}
+
+func sentFor(lastSent *time.Time, occurrence time.Time) bool {
+	if lastSent == nil {
+		return false
+	}
+	return lastSent.Format("2006-01-02") == occurrence.Format("2006-01-02")
+}
+
+func FormatAdvanceMessage(date *ImportantDate, now time.Time) string {
+	daysUntil := date.DaysUntil(now)
+
+	var when string
+	switch daysUntil {
+	case 1:
+		when = "завтра"
+	case 2:
+		when = "послезавтра"
+	default:
+		when = fmt.Sprintf("через %d %s", daysUntil, pluralDays(daysUntil))
+	}
+
+	message := fmt.Sprintf("%s %s %s", date.TypeIcon(), capitalize(when), date.Title)
+	if age := date.Age(now); age > 0 {
+		message += fmt.Sprintf(" (%d)", age)
+	}
+	message += fmt.Sprintf(", %s.", date.NextOccurrence(now).Format("02.01"))
+
+	if date.DateType == "birthday" || date.DateType == "anniversary" {
+		message += "\n\n🎁 Купить подарок?"
+	} else {
+		message += "\n\n📝 Запланировать подготовку?"
+	}
+
+	return message
+}
+
+func FormatDayMessage(date *ImportantDate, now time.Time) string {
+	message := fmt.Sprintf("🎉 Сегодня %s", date.Title)
+	if age := date.Age(now); age > 0 {
+		message += fmt.Sprintf(" (%d)", age)
+	}
+	message += "!"
+
+	if date.DateType == "birthday" || date.DateType == "anniversary" {
+		message += " Не забудьте поздравить 🥳"
+	}
+
+	return message
+}
+
+func pluralDays(n int) string {
+	if n%10 == 1 && n%100 != 11 {
+		return "день"
+	}
+	if n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) {
+		return "дня"
+	}
+	return "дней"
+}
+
+func capitalize(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	if runes[0] >= 'а' && runes[0] <= 'я' {
+		runes[0] = runes[0] - 'а' + 'А'
+	}
+	return string(runes)
+}
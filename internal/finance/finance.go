@@ -2,11 +2,13 @@ package finance
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Service struct {
@@ -19,6 +21,9 @@ type Transaction struct {
 	Amount		float64		`db:"amount"`
 	Details		string		`db:"details"`
 	Category	string		`db:"category"`
+	SpaceID		*string		`db:"space_id"`
+	ObjectiveID	*string		`db:"objective_id"`
+	KeyResultID	*int64		`db:"key_result_id"`
 	CreatedAt	time.Time	`db:"created_at"`
 }
 
@@ -36,40 +41,68 @@ func NewService(db *sqlx.DB) *Service {
 }
 
 func (s *Service) AddTransaction(ctx context.Context, userID int64, amount float64, details, category string) (string, error) {
+	return s.AddGoalTransaction(ctx, userID, amount, details, category, GoalLink{})
+}
 
-	transactionID := uuid.New().String()
+func (s *Service) GetTransactions(ctx context.Context, userID int64, startTime, endTime time.Time) ([]Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, details, category, created_at
+		FROM transactions
+		WHERE user_id = $1 AND created_at BETWEEN $2 AND $3
+		ORDER BY created_at DESC
+	`
 
-	if category == "" {
-		if amount > 0 {
-			category = "Доход"
-		} else {
-			category = "Расход"
-		}
+	var transactions []Transaction
+	err := s.db.SelectContext(ctx, &transactions, query, userID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении транзакций: %v", err)
 	}
 
-	query := `
-		INSERT INTO transactions (id, user_id, amount, details, category, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
+	return transactions, nil
+}
+
+func (s *Service) GetSummary(ctx context.Context, userID int64, period string) (*Summary, error) {
+
+	now := time.Now()
+	startTime, err := periodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := s.db.ExecContext(ctx, query, transactionID, userID, amount, details, category, time.Now())
+	transactions, err := s.GetTransactions(ctx, userID, startTime, now)
 	if err != nil {
-		return "", fmt.Errorf("ошибка при сохранении транзакции: %v", err)
+		return nil, err
 	}
 
-	return transactionID, nil
+	return summarize(transactions), nil
 }
 
-func (s *Service) GetTransactions(ctx context.Context, userID int64, startTime, endTime time.Time) ([]Transaction, error) {
+func (s *Service) GetSummaryForUsers(ctx context.Context, userIDs []int64, period string) (*Summary, error) {
+
+	now := time.Now()
+	startTime, err := periodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.GetTransactionsForUsers(ctx, userIDs, startTime, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(transactions), nil
+}
+
+func (s *Service) GetTransactionsForUsers(ctx context.Context, userIDs []int64, startTime, endTime time.Time) ([]Transaction, error) {
 	query := `
-		SELECT id, user_id, amount, details, category, created_at
+		SELECT id, user_id, amount, details, category, space_id, objective_id, key_result_id, created_at
 		FROM transactions
-		WHERE user_id = $1 AND created_at BETWEEN $2 AND $3
+		WHERE user_id = ANY($1) AND created_at BETWEEN $2 AND $3
 		ORDER BY created_at DESC
 	`
 
 	var transactions []Transaction
-	err := s.db.SelectContext(ctx, &transactions, query, userID, startTime, endTime)
+	err := s.db.SelectContext(ctx, &transactions, query, pq.Array(userIDs), startTime, endTime)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении транзакций: %v", err)
 	}
@@ -77,29 +110,85 @@ func (s *Service) GetTransactions(ctx context.Context, userID int64, startTime,
 	return transactions, nil
 }
 
-func (s *Service) GetSummary(ctx context.Context, userID int64, period string) (*Summary, error) {
+func (s *Service) GetArchivedTransactions(ctx context.Context, userIDs []int64, before time.Time, limit, offset int) ([]Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, details, category, space_id, objective_id, key_result_id, created_at
+		FROM transactions
+		WHERE user_id = ANY($1) AND created_at < $2
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
 
-	now := time.Now()
-	var startTime time.Time
+	transactions := make([]Transaction, 0)
+	err := s.db.SelectContext(ctx, &transactions, query, pq.Array(userIDs), before, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении архивных транзакций: %v", err)
+	}
 
-	switch period {
-	case "day":
-		startTime = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	case "week":
-		startTime = now.AddDate(0, 0, -7)
-	case "month":
-		startTime = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	case "year":
-		startTime = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
-	default:
-		return nil, fmt.Errorf("неизвестный период: %s", period)
+	return transactions, nil
+}
+
+func (s *Service) GetTransactionByID(ctx context.Context, userID int64, transactionID string) (*Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, details, category, space_id, objective_id, key_result_id, created_at
+		FROM transactions
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var transaction Transaction
+	err := s.db.GetContext(ctx, &transaction, query, transactionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("транзакция не найдена или не принадлежит пользователю: %v", err)
 	}
 
-	transactions, err := s.GetTransactions(ctx, userID, startTime, now)
+	return &transaction, nil
+}
+
+func (s *Service) GetLastTransaction(ctx context.Context, userID int64) (*Transaction, error) {
+	query := `
+		SELECT id, user_id, amount, details, category, space_id, objective_id, key_result_id, created_at
+		FROM transactions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var transaction Transaction
+	err := s.db.GetContext(ctx, &transaction, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("ошибка при получении последней транзакции: %v", err)
 	}
 
+	return &transaction, nil
+}
+
+func (s *Service) DeleteTransaction(ctx context.Context, userID int64, transactionID string) error {
+	query := `
+		DELETE FROM transactions
+		WHERE id = $1 AND user_id = $2
+	`
+
+	result, err := s.db.ExecContext(ctx, query, transactionID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении транзакции: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("транзакция не найдена или не принадлежит пользователю")
+	}
+
+	return nil
+}
+
+func summarize(transactions []Transaction) *Summary {
 	summary := &Summary{
 		Income:		0,
 		Expenses:	0,
@@ -117,5 +206,20 @@ func (s *Service) GetSummary(ctx context.Context, userID int64, period string) (
 		summary.Categories[t.Category] += t.Amount
 	}
 
-	return summary, nil
+	return summary
+}
+
+func periodStart(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case "day":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), nil
+	case "week":
+		return now.AddDate(0, 0, -7), nil
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	case "year":
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), nil
+	default:
+		return time.Time{}, fmt.Errorf("неизвестный период: %s", period)
+	}
 }
@@ -0,0 +1,87 @@
+package finance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrTransactionNotFound = errors.New("транзакция не найдена или не принадлежит пользователю")
+
+type GoalLink struct {
+	ObjectiveID	*string
+	KeyResultID	*int64
+}
+
+func (s *Service) AddGoalTransaction(ctx context.Context, userID int64, amount float64, details, category string, link GoalLink) (string, error) {
+	link, err := s.resolveGoalLink(ctx, userID, link)
+	if err != nil {
+		return "", err
+	}
+
+	if category == "" {
+		if amount > 0 {
+			category = "Доход"
+		} else {
+			category = "Расход"
+		}
+	}
+
+	transactionID := uuid.New().String()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO transactions (id, user_id, amount, details, category, objective_id, key_result_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, transactionID, userID, amount, details, category, link.ObjectiveID, link.KeyResultID, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("ошибка при сохранении транзакции: %v", err)
+	}
+
+	return transactionID, nil
+}
+
+func (s *Service) LinkTransaction(ctx context.Context, userID int64, transactionID string, link GoalLink) error {
+	link, err := s.resolveGoalLink(ctx, userID, link)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE transactions SET objective_id = $3, key_result_id = $4
+		WHERE id = $1 AND user_id = $2
+	`, transactionID, userID, link.ObjectiveID, link.KeyResultID)
+	if err != nil {
+		return fmt.Errorf("ошибка при привязке транзакции к цели: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTransactionNotFound
+	}
+	return nil
+}
+
+func (s *Service) resolveGoalLink(ctx context.Context, userID int64, link GoalLink) (GoalLink, error) {
+	if link.KeyResultID != nil {
+		if err := ownership.MustOwnKeyResult(ctx, s.db, userID, *link.KeyResultID); err != nil {
+			return link, err
+		}
+		var objectiveID string
+		if err := s.db.GetContext(ctx, &objectiveID, `SELECT objective_id FROM key_results WHERE id = $1`, *link.KeyResultID); err != nil {
+			return link, fmt.Errorf("ошибка при получении цели ключевого результата: %v", err)
+		}
+		if link.ObjectiveID != nil && *link.ObjectiveID != objectiveID {
+			return link, ownership.ErrKeyResultNotOwned
+		}
+		link.ObjectiveID = &objectiveID
+		return link, nil
+	}
+
+	if link.ObjectiveID != nil {
+		if err := ownership.MustOwnObjective(ctx, s.db, userID, *link.ObjectiveID); err != nil {
+			return link, err
+		}
+	}
+	return link, nil
+}
@@ -0,0 +1,189 @@
+package receipts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"telegrambot/internal/finance"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	SourcePhoto	= "photo"
+	SourcePDF	= "pdf"
+
+	StatusPending	= "pending"
+	StatusConfirmed	= "confirmed"
+	StatusCancelled	= "cancelled"
+)
+
+var (
+	ErrReceiptNotFound	= errors.New("чек не найден")
+	ErrReceiptProcessed	= errors.New("чек уже обработан")
+	ErrNoTotal		= errors.New("не удалось определить сумму чека")
+)
+
+var Categories = []string{"Продукты", "Кафе", "Дом", "Коммунальные услуги", "Транспорт", "Здоровье", "Дети", "Развлечения", "Одежда"}
+
+type Service struct {
+	db	*sqlx.DB
+	finance	*finance.Service
+}
+
+type Extraction struct {
+	Merchant	string	`json:"merchant"`
+	Total		float64	`json:"total"`
+	Currency	string	`json:"currency"`
+	Date		string	`json:"date"`
+	Category	string	`json:"category"`
+}
+
+type Receipt struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Merchant	string		`db:"merchant" json:"merchant"`
+	Amount		float64		`db:"amount" json:"amount"`
+	Currency	string		`db:"currency" json:"currency"`
+	PurchaseDate	*time.Time	`db:"purchase_date" json:"purchase_date,omitempty"`
+	Category	string		`db:"category" json:"category"`
+	Source		string		`db:"source" json:"source"`
+	Status		string		`db:"status" json:"status"`
+	TransactionID	*string		`db:"transaction_id" json:"transaction_id,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+const receiptColumns = `id, user_id, merchant, amount, currency, purchase_date, category, source, status, transaction_id, created_at`
+
+func NewService(db *sqlx.DB, financeService *finance.Service) *Service {
+	return &Service{db: db, finance: financeService}
+}
+
+func (s *Service) Create(ctx context.Context, userID int64, ext Extraction, source string) (*Receipt, error) {
+	amount := math.Round(math.Abs(ext.Total)*100) / 100
+	if amount == 0 {
+		return nil, ErrNoTotal
+	}
+
+	var purchaseDate *time.Time
+	if date, err := time.Parse("2006-01-02", strings.TrimSpace(ext.Date)); err == nil {
+		purchaseDate = &date
+	}
+
+	var receipt Receipt
+	err := s.db.GetContext(ctx, &receipt, `
+		INSERT INTO receipts (user_id, merchant, amount, currency, purchase_date, category, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+receiptColumns,
+		userID, strings.TrimSpace(ext.Merchant), amount, strings.ToUpper(strings.TrimSpace(ext.Currency)), purchaseDate, strings.TrimSpace(ext.Category), source)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении чека: %v", err)
+	}
+	return &receipt, nil
+}
+
+func (s *Service) GetPending(ctx context.Context, userID, receiptID int64) (*Receipt, error) {
+	var receipt Receipt
+	err := s.db.GetContext(ctx, &receipt, `SELECT `+receiptColumns+` FROM receipts WHERE id = $1 AND user_id = $2`, receiptID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении чека: %v", err)
+	}
+	if receipt.Status != StatusPending {
+		return nil, ErrReceiptProcessed
+	}
+	return &receipt, nil
+}
+
+func (s *Service) SetCategory(ctx context.Context, userID, receiptID int64, category string) (*Receipt, error) {
+	var receipt Receipt
+	err := s.db.GetContext(ctx, &receipt, `
+		UPDATE receipts SET category = $3
+		WHERE id = $1 AND user_id = $2 AND status = $4
+		RETURNING `+receiptColumns,
+		receiptID, userID, category, StatusPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReceiptProcessed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при изменении категории чека: %v", err)
+	}
+	return &receipt, nil
+}
+
+func (s *Service) Confirm(ctx context.Context, userID, receiptID int64) (*Receipt, error) {
+	receipt, err := s.GetPending(ctx, userID, receiptID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionID, err := s.finance.AddTransaction(ctx, userID, -receipt.Amount, receipt.Details(), receipt.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE receipts SET status = $3, transaction_id = $4, processed_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, receiptID, userID, StatusConfirmed, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении чека: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrReceiptNotFound
+	}
+
+	receipt.Status = StatusConfirmed
+	receipt.TransactionID = &transactionID
+	return receipt, nil
+}
+
+func (s *Service) Cancel(ctx context.Context, userID, receiptID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE receipts SET status = $3, processed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = $4
+	`, receiptID, userID, StatusCancelled, StatusPending)
+	if err != nil {
+		return fmt.Errorf("ошибка при отмене чека: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrReceiptProcessed
+	}
+	return nil
+}
+
+func (r *Receipt) Details() string {
+	details := r.Merchant
+	if details == "" {
+		details = "Покупка по чеку"
+	}
+	if r.PurchaseDate != nil {
+		details += " от " + r.PurchaseDate.Format("02.01.2006")
+	}
+	return details
+}
+
+func Format(r *Receipt) string {
+	var b strings.Builder
+	b.WriteString("🧾 Чек\n")
+	b.WriteString(fmt.Sprintf("• Магазин: %s\n", valueOrDash(r.Merchant)))
+	b.WriteString(fmt.Sprintf("• Сумма: %.2f %s\n", r.Amount, r.Currency))
+	if r.PurchaseDate != nil {
+		b.WriteString(fmt.Sprintf("• Дата: %s\n", r.PurchaseDate.Format("02.01.2006")))
+	}
+	b.WriteString(fmt.Sprintf("• Категория: %s", valueOrDash(r.Category)))
+	return b.String()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "—"
+	}
+	return value
+}
@@ -0,0 +1,817 @@
+package finance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrNoSpace		= errors.New("пользователь не состоит в общем финансовом пространстве")
+	ErrAlreadyInSpace	= errors.New("пользователь уже состоит в общем финансовом пространстве")
+	ErrPermissionDenied	= errors.New("недостаточно прав для этого действия")
+	ErrInviteNotFound	= errors.New("приглашение не найдено")
+)
+
+var defaultSpaceCategories = []string{"Продукты", "Дом", "Коммунальные услуги", "Транспорт", "Здоровье", "Дети", "Развлечения"}
+
+type Space struct {
+	ID		string		`db:"id" json:"id"`
+	Name		string		`db:"name" json:"name"`
+	OwnerID		int64		`db:"owner_id" json:"owner_id"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	UpdatedAt	time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+type SpaceMember struct {
+	SpaceID		string		`db:"space_id" json:"space_id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Role		string		`db:"role" json:"role"`
+	Status		string		`db:"status" json:"status"`
+	InvitedBy	*int64		`db:"invited_by" json:"invited_by,omitempty"`
+	Username	*string		`db:"username" json:"username,omitempty"`
+	FirstName	*string		`db:"first_name" json:"first_name,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type SpaceInvite struct {
+	SpaceID		string	`db:"space_id"`
+	SpaceName	string	`db:"space_name"`
+	UserID		int64	`db:"user_id"`
+	Role		string	`db:"role"`
+	InviterName	string	`db:"inviter_name"`
+}
+
+type Budget struct {
+	ID		int64		`db:"id" json:"id"`
+	SpaceID		string		`db:"space_id" json:"space_id"`
+	Category	string		`db:"category" json:"category"`
+	MonthlyLimit	float64		`db:"monthly_limit" json:"monthly_limit"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	UpdatedAt	time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+type BudgetStatus struct {
+	Category	string	`json:"category"`
+	Limit		float64	`json:"limit"`
+	Spent		float64	`json:"spent"`
+	Percent		float64	`json:"percent"`
+}
+
+type MemberSummary struct {
+	UserID		int64			`json:"user_id"`
+	Name		string			`json:"name"`
+	Income		float64			`json:"income"`
+	Expenses	float64			`json:"expenses"`
+	Balance		float64			`json:"balance"`
+	Categories	map[string]float64	`json:"categories"`
+}
+
+type SpaceSummary struct {
+	Space		*Space		`json:"space"`
+	Total		Summary		`json:"total"`
+	Members		[]MemberSummary	`json:"members"`
+	Budgets		[]BudgetStatus	`json:"budgets"`
+}
+
+func (m *SpaceMember) DisplayName() string {
+	if m.FirstName != nil && *m.FirstName != "" {
+		return *m.FirstName
+	}
+	if m.Username != nil && *m.Username != "" {
+		return "@" + *m.Username
+	}
+	return fmt.Sprintf("%d", m.UserID)
+}
+
+func (m *SpaceMember) CanEdit() bool {
+	return m.Role == "owner" || m.Role == "editor"
+}
+
+func (m *SpaceMember) CanManage() bool {
+	return m.Role == "owner"
+}
+
+func (s *Service) CreateSpace(ctx context.Context, userID int64, name string) (*Space, error) {
+
+	if _, err := s.GetActiveMembership(ctx, userID); err == nil {
+		return nil, ErrAlreadyInSpace
+	} else if !errors.Is(err, ErrNoSpace) {
+		return nil, err
+	}
+
+	if name == "" {
+		name = "Семейный бюджет"
+	}
+
+	space := &Space{
+		ID:		uuid.New().String(),
+		Name:		name,
+		OwnerID:	userID,
+		CreatedAt:	time.Now(),
+		UpdatedAt:	time.Now(),
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO finance_spaces (id, name, owner_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, space.ID, space.Name, space.OwnerID, space.CreatedAt, space.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании финансового пространства: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO finance_space_members (space_id, user_id, role, status, notified)
+		VALUES ($1, $2, 'owner', 'active', true)
+	`, space.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении владельца пространства: %v", err)
+	}
+
+	for _, category := range defaultSpaceCategories {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO finance_space_categories (space_id, name, created_by)
+			VALUES ($1, $2, $3)
+		`, space.ID, category, userID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при создании категорий пространства: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+
+	return space, nil
+}
+
+func (s *Service) GetActiveMembership(ctx context.Context, userID int64) (*SpaceMember, error) {
+	query := `
+		SELECT m.space_id, m.user_id, m.role, m.status, m.invited_by, u.username, u.first_name, m.created_at
+		FROM finance_space_members m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.user_id = $1 AND m.status = 'active'
+	`
+
+	var member SpaceMember
+	err := s.db.GetContext(ctx, &member, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSpace
+		}
+		return nil, fmt.Errorf("ошибка при получении участия в пространстве: %v", err)
+	}
+
+	return &member, nil
+}
+
+func (s *Service) GetSpace(ctx context.Context, spaceID string) (*Space, error) {
+	var space Space
+	err := s.db.GetContext(ctx, &space, `
+		SELECT id, name, owner_id, created_at, updated_at
+		FROM finance_spaces
+		WHERE id = $1
+	`, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении финансового пространства: %v", err)
+	}
+
+	return &space, nil
+}
+
+func (s *Service) GetSpaceMembers(ctx context.Context, userID int64) ([]SpaceMember, error) {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getMembers(ctx, membership.SpaceID)
+}
+
+func (s *Service) getMembers(ctx context.Context, spaceID string) ([]SpaceMember, error) {
+	query := `
+		SELECT m.space_id, m.user_id, m.role, m.status, m.invited_by, u.username, u.first_name, m.created_at
+		FROM finance_space_members m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.space_id = $1 AND m.status IN ('active', 'pending')
+		ORDER BY m.created_at ASC
+	`
+
+	var members []SpaceMember
+	err := s.db.SelectContext(ctx, &members, query, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении участников пространства: %v", err)
+	}
+
+	return members, nil
+}
+
+func (s *Service) InviteMember(ctx context.Context, inviterID, inviteeID int64, role string) error {
+	membership, err := s.GetActiveMembership(ctx, inviterID)
+	if err != nil {
+		return err
+	}
+
+	if !membership.CanManage() {
+		return ErrPermissionDenied
+	}
+
+	if inviterID == inviteeID {
+		return fmt.Errorf("нельзя пригласить самого себя")
+	}
+
+	if role == "" {
+		role = "editor"
+	}
+	if role != "editor" && role != "viewer" {
+		return fmt.Errorf("неверная роль: %s. Допустимые значения: editor, viewer", role)
+	}
+
+	if _, err := s.GetActiveMembership(ctx, inviteeID); err == nil {
+		return ErrAlreadyInSpace
+	} else if !errors.Is(err, ErrNoSpace) {
+		return err
+	}
+
+	query := `
+		INSERT INTO finance_space_members (space_id, user_id, role, status, invited_by, notified)
+		VALUES ($1, $2, $3, 'pending', $4, false)
+		ON CONFLICT (space_id, user_id)
+		DO UPDATE SET role = $3, status = 'pending', invited_by = $4, notified = false
+	`
+
+	_, err = s.db.ExecContext(ctx, query, membership.SpaceID, inviteeID, role, inviterID)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании приглашения: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) RespondToInvite(ctx context.Context, userID int64, spaceID string, accept bool) (*Space, error) {
+	var status string
+	err := s.db.GetContext(ctx, &status, `
+		SELECT status FROM finance_space_members WHERE space_id = $1 AND user_id = $2
+	`, spaceID, userID)
+	if err != nil || status != "pending" {
+		return nil, ErrInviteNotFound
+	}
+
+	newStatus := "declined"
+	if accept {
+		if _, err := s.GetActiveMembership(ctx, userID); err == nil {
+			return nil, ErrAlreadyInSpace
+		} else if !errors.Is(err, ErrNoSpace) {
+			return nil, err
+		}
+		newStatus = "active"
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE finance_space_members SET status = $1 WHERE space_id = $2 AND user_id = $3
+	`, newStatus, spaceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении приглашения: %v", err)
+	}
+
+	return s.GetSpace(ctx, spaceID)
+}
+
+func (s *Service) SetMemberRole(ctx context.Context, ownerID, memberID int64, role string) error {
+	membership, err := s.GetActiveMembership(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if !membership.CanManage() {
+		return ErrPermissionDenied
+	}
+
+	if ownerID == memberID {
+		return fmt.Errorf("нельзя изменить собственную роль владельца")
+	}
+
+	if role != "editor" && role != "viewer" {
+		return fmt.Errorf("неверная роль: %s. Допустимые значения: editor, viewer", role)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE finance_space_members SET role = $1
+		WHERE space_id = $2 AND user_id = $3 AND status IN ('active', 'pending')
+	`, role, membership.SpaceID, memberID)
+	if err != nil {
+		return fmt.Errorf("ошибка при изменении роли участника: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("участник не найден в пространстве")
+	}
+
+	return nil
+}
+
+func (s *Service) RemoveMember(ctx context.Context, ownerID, memberID int64) error {
+	membership, err := s.GetActiveMembership(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if !membership.CanManage() {
+		return ErrPermissionDenied
+	}
+
+	if ownerID == memberID {
+		return fmt.Errorf("владелец не может удалить себя, используйте выход из пространства")
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM finance_space_members WHERE space_id = $1 AND user_id = $2
+	`, membership.SpaceID, memberID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении участника: %v", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("участник не найден в пространстве")
+	}
+
+	return nil
+}
+
+func (s *Service) LeaveSpace(ctx context.Context, userID int64) error {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM finance_space_members WHERE space_id = $1 AND user_id = $2
+	`, membership.SpaceID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при выходе из пространства: %v", err)
+	}
+
+	if membership.CanManage() {
+		var nextOwnerID int64
+		err = tx.GetContext(ctx, &nextOwnerID, `
+			SELECT user_id FROM finance_space_members
+			WHERE space_id = $1 AND status = 'active'
+			ORDER BY created_at ASC
+			LIMIT 1
+		`, membership.SpaceID)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			_, err = tx.ExecContext(ctx, `DELETE FROM finance_spaces WHERE id = $1`, membership.SpaceID)
+			if err != nil {
+				return fmt.Errorf("ошибка при удалении пустого пространства: %v", err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("ошибка при поиске нового владельца: %v", err)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE finance_space_members SET role = 'owner' WHERE space_id = $1 AND user_id = $2
+			`, membership.SpaceID, nextOwnerID)
+			if err != nil {
+				return fmt.Errorf("ошибка при передаче прав владельца: %v", err)
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE finance_spaces SET owner_id = $1 WHERE id = $2
+			`, nextOwnerID, membership.SpaceID)
+			if err != nil {
+				return fmt.Errorf("ошибка при передаче прав владельца: %v", err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) AddSpaceCategory(ctx context.Context, userID int64, name string) error {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !membership.CanEdit() {
+		return ErrPermissionDenied
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO finance_space_categories (space_id, name, created_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (space_id, name) DO NOTHING
+	`, membership.SpaceID, strings.TrimSpace(name), userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении категории: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetSpaceCategories(ctx context.Context, userID int64) ([]string, error) {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []string
+	err = s.db.SelectContext(ctx, &categories, `
+		SELECT name FROM finance_space_categories WHERE space_id = $1 ORDER BY id
+	`, membership.SpaceID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении категорий: %v", err)
+	}
+
+	return categories, nil
+}
+
+func (s *Service) SetBudget(ctx context.Context, userID int64, category string, monthlyLimit float64) error {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !membership.CanManage() {
+		return ErrPermissionDenied
+	}
+
+	if monthlyLimit <= 0 {
+		return fmt.Errorf("лимит бюджета должен быть больше нуля")
+	}
+
+	category, err = s.resolveSpaceCategory(ctx, membership.SpaceID, category)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO finance_budgets (space_id, category, monthly_limit)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (space_id, category)
+		DO UPDATE SET monthly_limit = $3
+	`, membership.SpaceID, category, monthlyLimit)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении бюджета: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteBudget(ctx context.Context, userID int64, category string) error {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !membership.CanManage() {
+		return ErrPermissionDenied
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		DELETE FROM finance_budgets WHERE space_id = $1 AND LOWER(category) = LOWER($2)
+	`, membership.SpaceID, category)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении бюджета: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) AddSpaceTransaction(ctx context.Context, userID int64, amount float64, details, category string) (string, *BudgetStatus, error) {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !membership.CanEdit() {
+		return "", nil, ErrPermissionDenied
+	}
+
+	if category == "" {
+		if amount > 0 {
+			category = "Доход"
+		} else {
+			category = "Расход"
+		}
+	} else {
+		category, err = s.resolveSpaceCategory(ctx, membership.SpaceID, category)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	transactionID := uuid.New().String()
+
+	query := `
+		INSERT INTO transactions (id, user_id, amount, details, category, space_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err = s.db.ExecContext(ctx, query, transactionID, userID, amount, details, category, membership.SpaceID, time.Now())
+	if err != nil {
+		return "", nil, fmt.Errorf("ошибка при сохранении транзакции: %v", err)
+	}
+
+	if amount >= 0 {
+		return transactionID, nil, nil
+	}
+
+	statuses, err := s.getBudgetStatuses(ctx, membership.SpaceID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить статус бюджета пространства %s: %v", membership.SpaceID, err)
+		return transactionID, nil, nil
+	}
+
+	for i := range statuses {
+		if statuses[i].Category == category {
+			return transactionID, &statuses[i], nil
+		}
+	}
+
+	return transactionID, nil, nil
+}
+
+func (s *Service) GetSpaceTransactions(ctx context.Context, userID int64, startTime, endTime time.Time) ([]Transaction, error) {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, user_id, amount, details, category, space_id, created_at
+		FROM transactions
+		WHERE space_id = $1 AND created_at BETWEEN $2 AND $3
+		ORDER BY created_at DESC
+	`
+
+	var transactions []Transaction
+	err = s.db.SelectContext(ctx, &transactions, query, membership.SpaceID, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении транзакций пространства: %v", err)
+	}
+
+	return transactions, nil
+}
+
+func (s *Service) DeleteSpaceTransaction(ctx context.Context, userID int64, transactionID string) error {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var authorID int64
+	err = s.db.GetContext(ctx, &authorID, `
+		SELECT user_id FROM transactions WHERE id = $1 AND space_id = $2
+	`, transactionID, membership.SpaceID)
+	if err != nil {
+		return fmt.Errorf("транзакция не найдена в пространстве: %v", err)
+	}
+
+	if authorID != userID && !membership.CanManage() {
+		return ErrPermissionDenied
+	}
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM transactions WHERE id = $1`, transactionID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении транзакции: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetSpaceSummary(ctx context.Context, userID int64, period string) (*SpaceSummary, error) {
+	membership, err := s.GetActiveMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	startTime, err := periodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	space, err := s.GetSpace(ctx, membership.SpaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.GetSpaceTransactions(ctx, userID, startTime, now)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.getMembers(ctx, membership.SpaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SpaceSummary{
+		Space:	space,
+		Total: Summary{
+			Categories: make(map[string]float64),
+		},
+	}
+
+	perMember := make(map[int64]*MemberSummary)
+	for _, m := range members {
+		if m.Status != "active" {
+			continue
+		}
+		perMember[m.UserID] = &MemberSummary{
+			UserID:		m.UserID,
+			Name:		m.DisplayName(),
+			Categories:	make(map[string]float64),
+		}
+	}
+
+	for _, t := range transactions {
+		ms, ok := perMember[t.UserID]
+		if !ok {
+			ms = &MemberSummary{
+				UserID:		t.UserID,
+				Name:		fmt.Sprintf("%d", t.UserID),
+				Categories:	make(map[string]float64),
+			}
+			perMember[t.UserID] = ms
+		}
+
+		if t.Amount > 0 {
+			summary.Total.Income += t.Amount
+			ms.Income += t.Amount
+		} else {
+			summary.Total.Expenses += -t.Amount
+			ms.Expenses += -t.Amount
+		}
+		summary.Total.Balance += t.Amount
+		summary.Total.Categories[t.Category] += t.Amount
+		ms.Balance += t.Amount
+		ms.Categories[t.Category] += t.Amount
+	}
+
+	for _, ms := range perMember {
+		summary.Members = append(summary.Members, *ms)
+	}
+	sort.Slice(summary.Members, func(i, j int) bool {
+		return summary.Members[i].Expenses > summary.Members[j].Expenses
+	})
+
+	summary.Budgets, err = s.getBudgetStatuses(ctx, membership.SpaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (s *Service) getBudgetStatuses(ctx context.Context, spaceID string) ([]BudgetStatus, error) {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	query := `
+		SELECT b.category, b.monthly_limit,
+			COALESCE(-SUM(t.amount) FILTER (WHERE t.amount < 0), 0) AS spent
+		FROM finance_budgets b
+		LEFT JOIN transactions t ON t.space_id = b.space_id
+			AND t.category = b.category
+			AND t.created_at >= $2
+		WHERE b.space_id = $1
+		GROUP BY b.category, b.monthly_limit
+		ORDER BY b.category
+	`
+
+	var rows []struct {
+		Category	string	`db:"category"`
+		Limit		float64	`db:"monthly_limit"`
+		Spent		float64	`db:"spent"`
+	}
+	err := s.db.SelectContext(ctx, &rows, query, spaceID, monthStart)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении бюджетов: %v", err)
+	}
+
+	statuses := make([]BudgetStatus, 0, len(rows))
+	for _, r := range rows {
+		statuses = append(statuses, BudgetStatus{
+			Category:	r.Category,
+			Limit:		r.Limit,
+			Spent:		r.Spent,
+			Percent:	r.Spent / r.Limit * 100,
+		})
+	}
+
+	return statuses, nil
+}
+
+func (s *Service) resolveSpaceCategory(ctx context.Context, spaceID, category string) (string, error) {
+	category = strings.TrimSpace(category)
+
+	var existing string
+	err := s.db.GetContext(ctx, &existing, `
+		SELECT name FROM finance_space_categories
+		WHERE space_id = $1 AND LOWER(name) = LOWER($2)
+	`, spaceID, category)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("ошибка при поиске категории: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO finance_space_categories (space_id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (space_id, name) DO NOTHING
+	`, spaceID, category)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при добавлении категории: %v", err)
+	}
+
+	return category, nil
+}
+
+func (s *Service) StartInviteNotifier(jm *jobs.Manager, sendInvite func(chatID int64, text string, spaceID string) error) {
+	jm.Register(jobs.Job{
+		Name:		"finance_space_invites",
+		Spec:		"@every 20s",
+		Run: func(ctx context.Context) {
+			s.notifyPendingInvites(ctx, sendInvite)
+		},
+	})
+}
+
+func (s *Service) notifyPendingInvites(ctx context.Context, sendInvite func(chatID int64, text string, spaceID string) error) {
+
+	query := `
+		SELECT m.space_id, fs.name AS space_name, m.user_id, m.role,
+			COALESCE(NULLIF(u.first_name, ''), u.username, '') AS inviter_name
+		FROM finance_space_members m
+		JOIN finance_spaces fs ON fs.id = m.space_id
+		LEFT JOIN users u ON u.id = m.invited_by
+		WHERE m.status = 'pending' AND m.notified = false
+	`
+
+	var invites []SpaceInvite
+	err := s.db.SelectContext(ctx, &invites, query)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении приглашений в финансовые пространства: %v", err)
+		return
+	}
+
+	for _, invite := range invites {
+		roleName := "может добавлять расходы и доходы"
+		if invite.Role == "viewer" {
+			roleName = "только просмотр"
+		}
+
+		message := fmt.Sprintf("👨‍👩‍👧 %s приглашает вас в общий бюджет «%s» (%s).\n\nОбщие категории, бюджеты и сводки будут видны всем участникам.",
+			invite.InviterName, invite.SpaceName, roleName)
+
+		if err := sendInvite(invite.UserID, message, invite.SpaceID); err != nil {
+			logrus.Errorf("Ошибка при отправке приглашения пользователю %d: %v", invite.UserID, err)
+			continue
+		}
+
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE finance_space_members SET notified = true WHERE space_id = $1 AND user_id = $2
+		`, invite.SpaceID, invite.UserID)
+		if err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса приглашения: %v", err)
+		}
+	}
+}
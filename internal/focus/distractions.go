@@ -0,0 +1,227 @@
+package focus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxDistractionBatch	= 500
+	maxDistractionSeconds	= 8 * 60 * 60
+)
+
+type Distraction struct {
+	OccurredAt	time.Time	`json:"occurred_at"`
+	Source		string		`json:"source"`
+	Category	string		`json:"category,omitempty"`
+	DurationSeconds	int		`json:"duration_seconds,omitempty"`
+	Blocked		bool		`json:"blocked,omitempty"`
+}
+
+type SourceCount struct {
+	Source	string	`db:"source" json:"source"`
+	Count	int	`db:"count" json:"count"`
+	Seconds	int	`db:"seconds" json:"seconds"`
+}
+
+type DistractionStats struct {
+	Since		time.Time	`json:"since"`
+	Total		int		`json:"total"`
+	DuringFocus	int		`json:"during_focus"`
+	Blocked		int		`json:"blocked"`
+	TotalSeconds	int		`json:"total_seconds"`
+	FocusSessions	int		`json:"focus_sessions"`
+	TopSources	[]SourceCount	`json:"top_sources"`
+	ByHour		map[int]int	`json:"by_hour"`
+}
+
+func normalizeSource(source string) string {
+	source = strings.ToLower(strings.TrimSpace(source))
+	source = strings.TrimPrefix(source, "https://")
+	source = strings.TrimPrefix(source, "http://")
+	source = strings.TrimPrefix(source, "www.")
+	if i := strings.IndexAny(source, "/?#"); i >= 0 {
+		source = source[:i]
+	}
+	if len(source) > 255 {
+		source = source[:255]
+	}
+	return source
+}
+
+func (s *Service) ReportDistractions(ctx context.Context, userID int64, client string, events []Distraction) (int, error) {
+	if len(events) == 0 {
+		return 0, fmt.Errorf("нет событий для сохранения")
+	}
+	if len(events) > maxDistractionBatch {
+		return 0, fmt.Errorf("слишком много событий в одном запросе: максимум %d", maxDistractionBatch)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	saved := 0
+	for _, event := range events {
+		source := normalizeSource(event.Source)
+		if source == "" {
+			continue
+		}
+		occurredAt := event.OccurredAt
+		if occurredAt.IsZero() || occurredAt.After(now) {
+			occurredAt = now
+		}
+		duration := event.DurationSeconds
+		if duration < 0 {
+			duration = 0
+		}
+		if duration > maxDistractionSeconds {
+			duration = maxDistractionSeconds
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO distraction_events (user_id, occurred_at, source, category, duration_seconds, blocked, focus_session_id, client)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, (
+				SELECT id FROM focus_sessions
+				WHERE user_id = $1 AND started_at <= $2 AND COALESCE(ended_at, NOW()) >= $2
+				ORDER BY started_at DESC
+				LIMIT 1
+			), NULLIF($7, ''))
+		`, userID, occurredAt, source, strings.TrimSpace(event.Category), duration, event.Blocked, client)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка при сохранении отвлечения: %v", err)
+		}
+		saved++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при сохранении отвлечений: %v", err)
+	}
+	return saved, nil
+}
+
+func (s *Service) GetDistractionStats(ctx context.Context, userID int64, since time.Time) (*DistractionStats, error) {
+	stats := &DistractionStats{Since: since, ByHour: map[int]int{}}
+
+	var totals struct {
+		Total		int	`db:"total"`
+		DuringFocus	int	`db:"during_focus"`
+		Blocked		int	`db:"blocked"`
+		TotalSeconds	int	`db:"total_seconds"`
+		FocusSessions	int	`db:"focus_sessions"`
+	}
+	err := s.db.GetContext(ctx, &totals, `
+		SELECT COUNT(*) AS total,
+			COUNT(focus_session_id) AS during_focus,
+			COUNT(*) FILTER (WHERE blocked) AS blocked,
+			COALESCE(SUM(duration_seconds), 0) AS total_seconds,
+			COUNT(DISTINCT focus_session_id) AS focus_sessions
+		FROM distraction_events
+		WHERE user_id = $1 AND occurred_at >= $2
+	`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете отвлечений: %v", err)
+	}
+	stats.Total = totals.Total
+	stats.DuringFocus = totals.DuringFocus
+	stats.Blocked = totals.Blocked
+	stats.TotalSeconds = totals.TotalSeconds
+	stats.FocusSessions = totals.FocusSessions
+
+	if stats.Total == 0 {
+		return stats, nil
+	}
+
+	err = s.db.SelectContext(ctx, &stats.TopSources, `
+		SELECT source, COUNT(*) AS count, COALESCE(SUM(duration_seconds), 0) AS seconds
+		FROM distraction_events
+		WHERE user_id = $1 AND occurred_at >= $2
+		GROUP BY source
+		ORDER BY count DESC, seconds DESC
+		LIMIT 5
+	`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении источников отвлечений: %v", err)
+	}
+
+	var hours []struct {
+		Hour	int	`db:"hour"`
+		Count	int	`db:"count"`
+	}
+	err = s.db.SelectContext(ctx, &hours, `
+		SELECT EXTRACT(HOUR FROM occurred_at)::int AS hour, COUNT(*) AS count
+		FROM distraction_events
+		WHERE user_id = $1 AND occurred_at >= $2
+		GROUP BY hour
+	`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении отвлечений по часам: %v", err)
+	}
+	for _, h := range hours {
+		stats.ByHour[h.Hour] = h.Count
+	}
+
+	return stats, nil
+}
+
+func (d *DistractionStats) PeakHour() (int, bool) {
+	peak, max := 0, 0
+	for hour, count := range d.ByHour {
+		if count > max || (count == max && hour < peak) {
+			peak, max = hour, count
+		}
+	}
+	return peak, max > 0
+}
+
+func (d *DistractionStats) SuggestAdjustments() []string {
+	if d.Total == 0 {
+		return nil
+	}
+
+	var suggestions []string
+	if hour, ok := d.PeakHour(); ok && d.ByHour[hour]*4 >= d.Total {
+		suggestions = append(suggestions, fmt.Sprintf("Больше всего отвлечений около %02d:00 — не ставь фокус-блоки на это время, оставь его для почты и коротких задач", hour))
+	}
+	if d.FocusSessions > 0 && d.DuringFocus/d.FocusSessions >= 3 {
+		suggestions = append(suggestions, "Во время фокус-сессий много отвлечений — попробуй короткие сессии по 25–45 минут с перерывами")
+	}
+	if len(d.TopSources) > 0 && d.TopSources[0].Count*3 >= d.Total {
+		suggestions = append(suggestions, fmt.Sprintf("%s — главный источник отвлечений, стоит заблокировать его на время фокуса", d.TopSources[0].Source))
+	}
+	return suggestions
+}
+
+func FormatDistractionStats(stats *DistractionStats) string {
+	if stats.Total == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📵 Отвлечений: %d", stats.Total))
+	if stats.DuringFocus > 0 {
+		b.WriteString(fmt.Sprintf(", во время фокуса: %d", stats.DuringFocus))
+	}
+	if stats.Blocked > 0 {
+		b.WriteString(fmt.Sprintf(", заблокировано: %d", stats.Blocked))
+	}
+	if stats.TotalSeconds >= 60 {
+		b.WriteString(fmt.Sprintf(", потеряно ~%s", FormatMinutes(stats.TotalSeconds/60)))
+	}
+	b.WriteString("\n")
+
+	for _, source := range stats.TopSources {
+		b.WriteString(fmt.Sprintf("• %s — %d раз\n", source.Source, source.Count))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,232 @@
+package focus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrSessionActive	= errors.New("фокус-сессия уже идет")
+	ErrNoActiveSession	= errors.New("нет активной фокус-сессии")
+	ErrTargetNotSet		= errors.New("цель по глубокой работе не задана")
+)
+
+const (
+	minSessionMinutes	= 1
+	maxSessionMinutes	= 8 * 60
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type Session struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Label		*string		`db:"label" json:"label,omitempty"`
+	StartedAt	time.Time	`db:"started_at" json:"started_at"`
+	EndedAt		*time.Time	`db:"ended_at" json:"ended_at,omitempty"`
+	PlannedMinutes	*int		`db:"planned_minutes" json:"planned_minutes,omitempty"`
+	DurationMinutes	*int		`db:"duration_minutes" json:"duration_minutes,omitempty"`
+}
+
+type WeeklyStats struct {
+	WeekStart	time.Time	`json:"week_start"`
+	Minutes		int		`json:"minutes"`
+	Sessions	int		`json:"sessions"`
+	TargetMinutes	int		`json:"target_minutes"`
+}
+
+const sessionSelect = `
+	SELECT id, user_id, label, started_at, ended_at, planned_minutes, duration_minutes
+	FROM focus_sessions
+`
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func WeekStart(now time.Time) time.Time {
+	daysFromMonday := int(now.Weekday()) - 1
+	if daysFromMonday < 0 {
+		daysFromMonday = 6
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()-daysFromMonday, 0, 0, 0, 0, now.Location())
+}
+
+func (s *Session) Title() string {
+	if s.Label != nil && *s.Label != "" {
+		return *s.Label
+	}
+	return "Глубокая работа"
+}
+
+func (s *Service) StartSession(ctx context.Context, userID int64, label string, plannedMinutes int) (*Session, error) {
+	var planned *int
+	if plannedMinutes > 0 {
+		planned = &plannedMinutes
+	}
+
+	var session Session
+	err := s.db.GetContext(ctx, &session, `
+		INSERT INTO focus_sessions (user_id, label, planned_minutes)
+		VALUES ($1, NULLIF($2, ''), $3)
+		ON CONFLICT (user_id) WHERE ended_at IS NULL DO NOTHING
+		RETURNING id, user_id, label, started_at, ended_at, planned_minutes, duration_minutes
+	`, userID, strings.TrimSpace(label), planned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionActive
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запуске фокус-сессии: %v", err)
+	}
+	return &session, nil
+}
+
+func (s *Service) GetActiveSession(ctx context.Context, userID int64) (*Session, error) {
+	var session Session
+	err := s.db.GetContext(ctx, &session, sessionSelect+`WHERE user_id = $1 AND ended_at IS NULL`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении фокус-сессии: %v", err)
+	}
+	return &session, nil
+}
+
+func (s *Service) StopSession(ctx context.Context, userID int64) (*Session, error) {
+	var session Session
+	err := s.db.GetContext(ctx, &session, `
+		UPDATE focus_sessions
+		SET ended_at = NOW(),
+			duration_minutes = LEAST(GREATEST(ROUND(EXTRACT(EPOCH FROM NOW() - started_at) / 60)::int, 0), $2)
+		WHERE user_id = $1 AND ended_at IS NULL
+		RETURNING id, user_id, label, started_at, ended_at, planned_minutes, duration_minutes
+	`, userID, maxSessionMinutes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при завершении фокус-сессии: %v", err)
+	}
+	return &session, nil
+}
+
+func (s *Service) LogSession(ctx context.Context, userID int64, minutes int, label string) (*Session, error) {
+	if minutes < minSessionMinutes || minutes > maxSessionMinutes {
+		return nil, fmt.Errorf("длительность фокус-сессии должна быть от %d до %d минут", minSessionMinutes, maxSessionMinutes)
+	}
+
+	var session Session
+	err := s.db.GetContext(ctx, &session, `
+		INSERT INTO focus_sessions (user_id, label, started_at, ended_at, duration_minutes)
+		VALUES ($1, NULLIF($2, ''), NOW() - make_interval(mins => $3), NOW(), $3)
+		RETURNING id, user_id, label, started_at, ended_at, planned_minutes, duration_minutes
+	`, userID, strings.TrimSpace(label), minutes)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при записи фокус-сессии: %v", err)
+	}
+	return &session, nil
+}
+
+func (s *Service) SetWeeklyTarget(ctx context.Context, userID int64, minutes int) error {
+	if minutes <= 0 || minutes > 7*maxSessionMinutes {
+		return fmt.Errorf("недельная цель должна быть от 1 до %d часов", 7*maxSessionMinutes/60)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO focus_targets (user_id, weekly_minutes)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET weekly_minutes = EXCLUDED.weekly_minutes
+	`, userID, minutes)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении цели по глубокой работе: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) GetWeeklyTarget(ctx context.Context, userID int64) (int, error) {
+	var minutes int
+	err := s.db.GetContext(ctx, &minutes, `SELECT weekly_minutes FROM focus_targets WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrTargetNotSet
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении цели по глубокой работе: %v", err)
+	}
+	return minutes, nil
+}
+
+func (s *Service) GetWeeklyStats(ctx context.Context, userID int64, weekStart time.Time) (*WeeklyStats, error) {
+	stats := &WeeklyStats{WeekStart: weekStart}
+
+	var row struct {
+		Minutes		int	`db:"minutes"`
+		Sessions	int	`db:"sessions"`
+	}
+	err := s.db.GetContext(ctx, &row, `
+		SELECT COALESCE(SUM(duration_minutes), 0) AS minutes, COUNT(*) AS sessions
+		FROM focus_sessions
+		WHERE user_id = $1 AND ended_at IS NOT NULL AND started_at >= $2 AND started_at < $3
+	`, userID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете фокус-времени: %v", err)
+	}
+	stats.Minutes = row.Minutes
+	stats.Sessions = row.Sessions
+
+	target, err := s.GetWeeklyTarget(ctx, userID)
+	if err != nil && !errors.Is(err, ErrTargetNotSet) {
+		return nil, err
+	}
+	stats.TargetMinutes = target
+
+	return stats, nil
+}
+
+func (w *WeeklyStats) Percent() float64 {
+	if w.TargetMinutes <= 0 {
+		return 0
+	}
+	return float64(w.Minutes) / float64(w.TargetMinutes) * 100
+}
+
+func (w *WeeklyStats) ExpectedByNow(now time.Time) int {
+	elapsed := now.Sub(w.WeekStart)
+	workWeek := 5 * 24 * time.Hour
+	if elapsed >= workWeek {
+		return w.TargetMinutes
+	}
+	if elapsed < 0 {
+		return 0
+	}
+	return int(float64(w.TargetMinutes) * elapsed.Hours() / workWeek.Hours())
+}
+
+func FormatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d ч", minutes/60)
+	}
+	return fmt.Sprintf("%d ч %d мин", minutes/60, minutes%60)
+}
+
+func FormatWeeklyStats(stats *WeeklyStats) string {
+	text := fmt.Sprintf("🧠 Глубокая работа: %s", FormatMinutes(stats.Minutes))
+	if stats.TargetMinutes > 0 {
+		text += fmt.Sprintf(" из %s (%.0f%%)", FormatMinutes(stats.TargetMinutes), stats.Percent())
+	}
+	if stats.Sessions > 0 {
+		text += fmt.Sprintf(", сессий: %d", stats.Sessions)
+	}
+	return text
+}
@@ -0,0 +1,202 @@
+package focus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	blockDuration		= 90 * time.Minute
+	workdayStartHour	= 9
+	workdayEndHour		= 18
+	maxSuggestedBlocks	= 5
+	pacingThreshold		= 0.8
+)
+
+type Block struct {
+	Start	time.Time	`json:"start"`
+	End	time.Time	`json:"end"`
+}
+
+func (s *Service) StartPacingAlerts(jm *jobs.Manager, calendarService *calendar.Service, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"focus_pacing",
+		Spec:		"0 12 * * 3",
+		Run: func(ctx context.Context) {
+			s.checkPacing(ctx, calendarService, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущена проверка темпа глубокой работы")
+}
+
+func (s *Service) checkPacing(ctx context.Context, calendarService *calendar.Service, sendMessageFunc func(chatID int64, text string) error) {
+	now := time.Now()
+	weekStart := WeekStart(now)
+
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT user_id FROM focus_targets
+		WHERE last_pacing_alert IS NULL OR last_pacing_alert < $1
+	`, weekStart.Format("2006-01-02"))
+	if err != nil {
+		logrus.Errorf("Ошибка при получении целей по глубокой работе: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		stats, err := s.GetWeeklyStats(ctx, userID, weekStart)
+		if err != nil {
+			logrus.Errorf("Ошибка при подсчете фокус-времени пользователя %d: %v", userID, err)
+			continue
+		}
+
+		expected := stats.ExpectedByNow(now)
+		if expected == 0 || float64(stats.Minutes) >= float64(expected)*pacingThreshold {
+			continue
+		}
+
+		blocks, err := s.SuggestBlocks(ctx, calendarService, userID, now, stats.TargetMinutes-stats.Minutes)
+		if err != nil {
+			logrus.Errorf("Ошибка при подборе фокус-блоков для пользователя %d: %v", userID, err)
+		}
+
+		var b strings.Builder
+		b.WriteString("🧠 *Глубокая работа отстает от плана*\n\n")
+		b.WriteString(fmt.Sprintf("К середине недели ожидалось ~%s, сейчас %s из %s.\n",
+			FormatMinutes(expected), FormatMinutes(stats.Minutes), FormatMinutes(stats.TargetMinutes)))
+		if len(blocks) > 0 {
+			b.WriteString("\n📅 *Свободные окна для фокуса:*\n")
+			b.WriteString(FormatBlocks(blocks))
+			b.WriteString("\n\nСкажите «запланируй фокус-блоки», и я добавлю их в календарь")
+		}
+
+		if err := sendMessageFunc(userID, b.String()); err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания о глубокой работе пользователю %d: %v", userID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE focus_targets SET last_pacing_alert = $2 WHERE user_id = $1`, userID, now.Format("2006-01-02")); err != nil {
+			logrus.Errorf("Ошибка при отметке напоминания о глубокой работе для пользователя %d: %v", userID, err)
+		}
+	}
+}
+
+func (s *Service) SuggestBlocks(ctx context.Context, calendarService *calendar.Service, userID int64, from time.Time, neededMinutes int) ([]Block, error) {
+	if neededMinutes <= 0 {
+		return nil, nil
+	}
+
+	count := (neededMinutes + int(blockDuration.Minutes()) - 1) / int(blockDuration.Minutes())
+	if count > maxSuggestedBlocks {
+		count = maxSuggestedBlocks
+	}
+
+	avoidHour := -1
+	if distractions, err := s.GetDistractionStats(ctx, userID, from.AddDate(0, 0, -14)); err != nil {
+		logrus.Errorf("Ошибка при получении отвлечений пользователя %d: %v", userID, err)
+	} else if hour, ok := distractions.PeakHour(); ok && distractions.ByHour[hour]*4 >= distractions.Total {
+		avoidHour = hour
+	}
+
+	var preferredHours []int
+	if peak, err := s.GetPeakHours(ctx, userID); err != nil {
+		logrus.Warnf("Не удалось получить пиковые часы пользователя %d: %v", userID, err)
+	} else {
+		for _, hour := range peak.Hours {
+			if hour >= workdayStartHour && hour+int(blockDuration.Hours()) <= workdayEndHour && hour != avoidHour {
+				preferredHours = append(preferredHours, hour)
+			}
+		}
+	}
+
+	weekEnd := WeekStart(from).AddDate(0, 0, 5)
+	var blocks []Block
+	for day := from; day.Before(weekEnd) && len(blocks) < count; day = day.AddDate(0, 0, 1) {
+		events, err := calendarService.GetEventsByDate(ctx, userID, day)
+		if err != nil {
+			return blocks, err
+		}
+
+		busyUntil := func(slot time.Time) time.Time {
+			end := slot.Add(blockDuration)
+			until := time.Time{}
+			for _, event := range events {
+				if event.StartTime.Before(end) && event.EndTime.After(slot) && event.EndTime.After(until) {
+					until = event.EndTime
+				}
+			}
+			if avoidHour >= 0 {
+				avoidStart := time.Date(day.Year(), day.Month(), day.Day(), avoidHour, 0, 0, 0, day.Location())
+				avoidEnd := avoidStart.Add(time.Hour)
+				if avoidStart.Before(end) && avoidEnd.After(slot) && avoidEnd.After(until) {
+					until = avoidEnd
+				}
+			}
+			return until
+		}
+
+		found := false
+		for _, hour := range preferredHours {
+			slot := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, day.Location())
+			if slot.Before(from) {
+				continue
+			}
+			if busyUntil(slot).IsZero() {
+				blocks = append(blocks, Block{Start: slot, End: slot.Add(blockDuration)})
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		slot := time.Date(day.Year(), day.Month(), day.Day(), workdayStartHour, 0, 0, 0, day.Location())
+		dayEnd := time.Date(day.Year(), day.Month(), day.Day(), workdayEndHour, 0, 0, 0, day.Location())
+		if slot.Before(from) {
+			slot = from.Truncate(30 * time.Minute).Add(30 * time.Minute)
+		}
+
+		for !slot.Add(blockDuration).After(dayEnd) {
+			until := busyUntil(slot)
+			if until.IsZero() {
+				blocks = append(blocks, Block{Start: slot, End: slot.Add(blockDuration)})
+				break
+			}
+			slot = until.Truncate(15 * time.Minute)
+			if slot.Before(until) {
+				slot = slot.Add(15 * time.Minute)
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+func (s *Service) ScheduleBlocks(ctx context.Context, calendarService *calendar.Service, userID int64, blocks []Block) (int, error) {
+	created := 0
+	for _, block := range blocks {
+		_, err := calendarService.CreateEvent(ctx, userID, "🧠 Глубокая работа", "Фокус-блок без встреч и уведомлений",
+			block.Start.Format(time.RFC3339), block.End.Format(time.RFC3339))
+		if err != nil {
+			return created, fmt.Errorf("ошибка при создании фокус-блока: %v", err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+func FormatBlocks(blocks []Block) string {
+	lines := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		lines = append(lines, fmt.Sprintf("• %s %s–%s", block.Start.Format("02.01"), block.Start.Format("15:04"), block.End.Format("15:04")))
+	}
+	return strings.Join(lines, "\n")
+}
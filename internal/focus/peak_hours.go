@@ -0,0 +1,214 @@
+package focus
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	peakWindowDays		= 28
+	peakHalfLifeDays	= 7
+	peakMinSamples		= 10
+	peakHoursCount		= 3
+	peakStaleAfter		= 36 * time.Hour
+)
+
+type PeakHours struct {
+	UserID		int64			`json:"user_id"`
+	Hours		[]int			`json:"hours"`
+	Scores		map[int]float64		`json:"scores"`
+	Samples		int			`json:"samples"`
+	WindowDays	int			`json:"window_days"`
+	ComputedAt	time.Time		`json:"computed_at"`
+}
+
+const peakActivityQuery = `
+	WITH activity AS (
+		SELECT created_at AS at, 1.0 AS weight
+		FROM key_result_progress_log
+		WHERE user_id = $1 AND delta <> 0 AND created_at > NOW() - make_interval(days => $2)
+
+		UNION ALL
+
+		SELECT started_at, GREATEST(COALESCE(duration_minutes, 0), 1) / 25.0
+		FROM focus_sessions
+		WHERE user_id = $1 AND ended_at IS NOT NULL AND started_at > NOW() - make_interval(days => $2)
+
+		UNION ALL
+
+		SELECT started_at, minutes / 25.0
+		FROM time_entries
+		WHERE user_id = $1 AND started_at IS NOT NULL AND started_at > NOW() - make_interval(days => $2)
+
+		UNION ALL
+
+		SELECT created_at, 0.5
+		FROM habit_tracking
+		WHERE user_id = $1 AND completed = TRUE AND created_at > NOW() - make_interval(days => $2)
+
+		UNION ALL
+
+		SELECT created_at, 0.3
+		FROM user_messages
+		WHERE user_identifier = $1::text AND created_at > NOW() - make_interval(days => $2)
+	)
+	SELECT EXTRACT(HOUR FROM at)::int AS hour,
+		SUM(weight * EXP(-EXTRACT(EPOCH FROM NOW() - at) / 86400.0 / $3 * LN(2))) AS score,
+		COUNT(*) AS samples
+	FROM activity
+	GROUP BY 1
+`
+
+func (s *Service) RecomputePeakHours(ctx context.Context, userID int64) (*PeakHours, error) {
+	var rows []struct {
+		Hour	int	`db:"hour"`
+		Score	float64	`db:"score"`
+		Samples	int	`db:"samples"`
+	}
+	if err := s.db.SelectContext(ctx, &rows, peakActivityQuery, userID, peakWindowDays, peakHalfLifeDays); err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете активности по часам: %v", err)
+	}
+
+	peak := &PeakHours{UserID: userID, Scores: make(map[int]float64), WindowDays: peakWindowDays, ComputedAt: time.Now()}
+	for _, row := range rows {
+		peak.Scores[row.Hour] = row.Score
+		peak.Samples += row.Samples
+	}
+	if peak.Samples >= peakMinSamples {
+		peak.Hours = topHours(peak.Scores, peakHoursCount)
+	}
+
+	scores := make(map[string]float64, len(peak.Scores))
+	for hour, score := range peak.Scores {
+		scores[strconv.Itoa(hour)] = score
+	}
+	scoresJSON, err := json.Marshal(scores)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сериализации активности по часам: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO productivity_hours (user_id, peak_hours, scores, samples, window_days, computed_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			peak_hours = EXCLUDED.peak_hours, scores = EXCLUDED.scores, samples = EXCLUDED.samples,
+			window_days = EXCLUDED.window_days, computed_at = NOW()
+	`, userID, pq.Array(intsToInt64(peak.Hours)), scoresJSON, peak.Samples, peakWindowDays)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении пиковых часов: %v", err)
+	}
+	return peak, nil
+}
+
+func (s *Service) GetPeakHours(ctx context.Context, userID int64) (*PeakHours, error) {
+	var row struct {
+		Hours		pq.Int64Array	`db:"peak_hours"`
+		Scores		[]byte		`db:"scores"`
+		Samples		int		`db:"samples"`
+		WindowDays	int		`db:"window_days"`
+		ComputedAt	time.Time	`db:"computed_at"`
+	}
+	err := s.db.GetContext(ctx, &row, `
+		SELECT peak_hours, scores, samples, window_days, computed_at FROM productivity_hours WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && time.Since(row.ComputedAt) > peakStaleAfter) {
+		return s.RecomputePeakHours(ctx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении пиковых часов: %v", err)
+	}
+
+	peak := &PeakHours{UserID: userID, Samples: row.Samples, WindowDays: row.WindowDays, ComputedAt: row.ComputedAt, Scores: make(map[int]float64)}
+	for _, hour := range row.Hours {
+		peak.Hours = append(peak.Hours, int(hour))
+	}
+	var scores map[string]float64
+	if err := json.Unmarshal(row.Scores, &scores); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении активности по часам: %v", err)
+	}
+	for key, score := range scores {
+		if hour, err := strconv.Atoi(key); err == nil {
+			peak.Scores[hour] = score
+		}
+	}
+	return peak, nil
+}
+
+func (s *Service) StartPeakHoursJob(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:	"peak_hours_recompute",
+		Spec:	"30 3 * * *",
+		Run: func(ctx context.Context) {
+			s.recomputeActivePeakHours(ctx)
+		},
+	})
+
+	logrus.Info("Запущен пересчет пиковых часов продуктивности")
+}
+
+func (s *Service) recomputeActivePeakHours(ctx context.Context) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT user_id FROM key_result_progress_log WHERE delta <> 0 AND created_at > NOW() - make_interval(days => $1)
+		UNION
+		SELECT user_id FROM focus_sessions WHERE started_at > NOW() - make_interval(days => $1)
+		UNION
+		SELECT u.id FROM users u
+		WHERE EXISTS (
+			SELECT 1 FROM user_messages um
+			WHERE um.user_identifier = u.id::text AND um.created_at > NOW() - make_interval(days => $1)
+		)
+	`, peakWindowDays)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе пользователей для пересчета пиковых часов: %v", err)
+		return
+	}
+
+	updated := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.RecomputePeakHours(ctx, userID); err != nil {
+			logrus.Warnf("Не удалось пересчитать пиковые часы пользователя %d: %v", userID, err)
+			continue
+		}
+		updated++
+	}
+	logrus.Infof("Пересчитаны пиковые часы продуктивности: %d пользователей", updated)
+}
+
+func topHours(scores map[int]float64, n int) []int {
+	hours := make([]int, 0, len(scores))
+	for hour := range scores {
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		if scores[hours[i]] == scores[hours[j]] {
+			return hours[i] < hours[j]
+		}
+		return scores[hours[i]] > scores[hours[j]]
+	})
+	if len(hours) > n {
+		hours = hours[:n]
+	}
+	return hours
+}
+
+func intsToInt64(values []int) []int64 {
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i] = int64(v)
+	}
+	return result
+}
@@ -0,0 +1,237 @@
+package focus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	NoSphere	= "Без сферы"
+	NoObjective	= "Без цели"
+
+	maxEntryMinutes	= 24 * 60
+)
+
+type TimeEntry struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	ObjectiveID	*string		`db:"objective_id" json:"objective_id,omitempty"`
+	KeyResultID	*int64		`db:"key_result_id" json:"key_result_id,omitempty"`
+	TaskID		*int64		`db:"task_id" json:"task_id,omitempty"`
+	Minutes		int		`db:"minutes" json:"minutes"`
+	Note		*string		`db:"note" json:"note,omitempty"`
+	SpentOn		time.Time	`db:"spent_on" json:"spent_on"`
+	StartedAt	*time.Time	`db:"started_at" json:"started_at,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type SphereAllocation struct {
+	Sphere		string			`json:"sphere"`
+	Minutes		int			`json:"minutes"`
+	Objectives	[]ObjectiveAllocation	`json:"objectives"`
+}
+
+type ObjectiveAllocation struct {
+	ObjectiveID	*string	`db:"objective_id" json:"objective_id,omitempty"`
+	Title		string	`db:"title" json:"title"`
+	Sphere		string	`db:"sphere" json:"-"`
+	Minutes		int	`db:"minutes" json:"minutes"`
+}
+
+type TrackedMinutes struct {
+	Total		int
+	ByKeyResult	map[int64]int
+	ByTask		map[int64]int
+}
+
+type WeeklyAllocation struct {
+	WeekStart	time.Time		`json:"week_start"`
+	Minutes		int			`json:"minutes"`
+	Spheres		[]SphereAllocation	`json:"spheres"`
+}
+
+func (s *Service) LogTime(ctx context.Context, userID int64, minutes int, note string, objectiveID *string, keyResultID, taskID *int64, spentOn time.Time) (*TimeEntry, error) {
+	if minutes < 1 || minutes > maxEntryMinutes {
+		return nil, fmt.Errorf("длительность должна быть от 1 минуты до %d часов", maxEntryMinutes/60)
+	}
+	if spentOn.IsZero() {
+		spentOn = time.Now()
+	}
+	if spentOn.After(time.Now()) {
+		return nil, fmt.Errorf("нельзя записать время на будущую дату")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	objectiveID, keyResultID, err = resolveTimeTarget(ctx, tx, userID, objectiveID, keyResultID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := insertTimeEntry(ctx, tx, userID, minutes, note, objectiveID, keyResultID, taskID, spentOn, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении затраченного времени: %v", err)
+	}
+	return entry, nil
+}
+
+func resolveTimeTarget(ctx context.Context, tx *sqlx.Tx, userID int64, objectiveID *string, keyResultID, taskID *int64) (*string, *int64, error) {
+	if taskID != nil {
+		if err := ownership.MustOwnTask(ctx, tx, userID, *taskID); err != nil {
+			return nil, nil, err
+		}
+		var taskKeyResultID int64
+		if err := tx.GetContext(ctx, &taskKeyResultID, `SELECT key_result_id FROM tasks WHERE id = $1`, *taskID); err != nil {
+			return nil, nil, fmt.Errorf("ошибка при получении ключевого результата задачи: %v", err)
+		}
+		if keyResultID != nil && *keyResultID != taskKeyResultID {
+			return nil, nil, ownership.ErrTaskNotOwned
+		}
+		keyResultID = &taskKeyResultID
+	}
+
+	if keyResultID != nil {
+		if err := ownership.MustOwnKeyResult(ctx, tx, userID, *keyResultID); err != nil {
+			return nil, nil, err
+		}
+		var krObjectiveID string
+		if err := tx.GetContext(ctx, &krObjectiveID, `SELECT objective_id FROM key_results WHERE id = $1`, *keyResultID); err != nil {
+			return nil, nil, fmt.Errorf("ошибка при получении цели ключевого результата: %v", err)
+		}
+		if objectiveID != nil && *objectiveID != krObjectiveID {
+			return nil, nil, ownership.ErrKeyResultNotOwned
+		}
+		objectiveID = &krObjectiveID
+	} else if objectiveID != nil {
+		if err := ownership.MustOwnObjective(ctx, tx, userID, *objectiveID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return objectiveID, keyResultID, nil
+}
+
+func insertTimeEntry(ctx context.Context, tx *sqlx.Tx, userID int64, minutes int, note string, objectiveID *string, keyResultID, taskID *int64, spentOn time.Time, startedAt *time.Time) (*TimeEntry, error) {
+	var entry TimeEntry
+	err := tx.GetContext(ctx, &entry, `
+		INSERT INTO time_entries (user_id, objective_id, key_result_id, task_id, minutes, note, spent_on, started_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id, user_id, objective_id, key_result_id, task_id, minutes, note, spent_on, started_at, created_at
+	`, userID, objectiveID, keyResultID, taskID, minutes, strings.TrimSpace(note), spentOn.Format("2006-01-02"), startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при записи затраченного времени: %v", err)
+	}
+
+	hours := float64(minutes) / 60
+	if objectiveID != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE objectives SET actual_hours = COALESCE(actual_hours, 0) + $2 WHERE id = $1`, *objectiveID, hours); err != nil {
+			return nil, fmt.Errorf("ошибка при обновлении времени по цели: %v", err)
+		}
+	}
+	if keyResultID != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE key_results SET actual_hours = COALESCE(actual_hours, 0) + $2 WHERE id = $1`, *keyResultID, hours); err != nil {
+			return nil, fmt.Errorf("ошибка при обновлении времени по ключевому результату: %v", err)
+		}
+	}
+	if taskID != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE tasks SET actual_hours = COALESCE(actual_hours, 0) + $2 WHERE id = $1`, *taskID, hours); err != nil {
+			return nil, fmt.Errorf("ошибка при обновлении времени по задаче: %v", err)
+		}
+	}
+	return &entry, nil
+}
+
+func (s *Service) GetTrackedMinutes(ctx context.Context, objectiveID string) (*TrackedMinutes, error) {
+	var rows []struct {
+		KeyResultID	int64	`db:"key_result_id"`
+		TaskID		int64	`db:"task_id"`
+		Minutes		int	`db:"minutes"`
+	}
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT COALESCE(key_result_id, 0) AS key_result_id, COALESCE(task_id, 0) AS task_id, SUM(minutes) AS minutes
+		FROM time_entries
+		WHERE objective_id = $1
+		GROUP BY COALESCE(key_result_id, 0), COALESCE(task_id, 0)
+	`, objectiveID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете времени по цели: %v", err)
+	}
+
+	tracked := &TrackedMinutes{ByKeyResult: make(map[int64]int), ByTask: make(map[int64]int)}
+	for _, row := range rows {
+		tracked.Total += row.Minutes
+		if row.KeyResultID != 0 {
+			tracked.ByKeyResult[row.KeyResultID] += row.Minutes
+		}
+		if row.TaskID != 0 {
+			tracked.ByTask[row.TaskID] += row.Minutes
+		}
+	}
+	return tracked, nil
+}
+
+func (s *Service) GetWeeklyAllocation(ctx context.Context, userID int64, weekStart time.Time) (*WeeklyAllocation, error) {
+	var rows []ObjectiveAllocation
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT te.objective_id,
+			COALESCE(o.title, $4) AS title,
+			COALESCE(NULLIF(o.sphere, ''), $5) AS sphere,
+			SUM(te.minutes) AS minutes
+		FROM time_entries te
+		LEFT JOIN objectives o ON o.id = te.objective_id
+		WHERE te.user_id = $1 AND te.spent_on >= $2 AND te.spent_on < $3
+		GROUP BY te.objective_id, o.title, o.sphere
+		ORDER BY minutes DESC
+	`, userID, weekStart.Format("2006-01-02"), weekStart.AddDate(0, 0, 7).Format("2006-01-02"), NoObjective, NoSphere)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете распределения времени: %v", err)
+	}
+
+	allocation := &WeeklyAllocation{WeekStart: weekStart, Spheres: []SphereAllocation{}}
+	index := make(map[string]int)
+	for _, row := range rows {
+		i, ok := index[row.Sphere]
+		if !ok {
+			i = len(allocation.Spheres)
+			index[row.Sphere] = i
+			allocation.Spheres = append(allocation.Spheres, SphereAllocation{Sphere: row.Sphere})
+		}
+		allocation.Spheres[i].Minutes += row.Minutes
+		allocation.Spheres[i].Objectives = append(allocation.Spheres[i].Objectives, row)
+		allocation.Minutes += row.Minutes
+	}
+	sort.SliceStable(allocation.Spheres, func(i, j int) bool {
+		return allocation.Spheres[i].Minutes > allocation.Spheres[j].Minutes
+	})
+
+	return allocation, nil
+}
+
+func FormatWeeklyAllocation(allocation *WeeklyAllocation) string {
+	if allocation.Minutes == 0 {
+		return "⏱ На этой неделе время по целям еще не записано. Скажите, например: «потратил 3 часа на проект X»"
+	}
+
+	text := fmt.Sprintf("⏱ **Время по сферам с %s**: всего %s\n", allocation.WeekStart.Format("02.01"), FormatMinutes(allocation.Minutes))
+	for _, sphere := range allocation.Spheres {
+		share := float64(sphere.Minutes) / float64(allocation.Minutes) * 100
+		text += fmt.Sprintf("\n**%s** — %s (%.0f%%)\n", sphere.Sphere, FormatMinutes(sphere.Minutes), share)
+		for _, objective := range sphere.Objectives {
+			text += fmt.Sprintf("• %s: %s\n", objective.Title, FormatMinutes(objective.Minutes))
+		}
+	}
+	return strings.TrimRight(text, "\n")
+}
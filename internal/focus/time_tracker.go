@@ -0,0 +1,235 @@
+package focus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrTrackerActive	= errors.New("таймер учета времени уже запущен")
+	ErrNoActiveTracker	= errors.New("таймер учета времени не запущен")
+)
+
+const maxTrackerMinutes = 12 * 60
+
+type Tracker struct {
+	UserID		int64		`db:"user_id" json:"user_id"`
+	ObjectiveID	*string		`db:"objective_id" json:"objective_id,omitempty"`
+	KeyResultID	*int64		`db:"key_result_id" json:"key_result_id,omitempty"`
+	TaskID		*int64		`db:"task_id" json:"task_id,omitempty"`
+	Note		*string		`db:"note" json:"note,omitempty"`
+	StartedAt	time.Time	`db:"started_at" json:"started_at"`
+	Target		string		`db:"target" json:"target"`
+}
+
+const trackerSelect = `
+	SELECT tt.user_id, tt.objective_id, tt.key_result_id, tt.task_id, tt.note, tt.started_at,
+		COALESCE(t.title, kr.title, o.title, '') AS target
+	FROM time_trackers tt
+	LEFT JOIN objectives o ON o.id = tt.objective_id
+	LEFT JOIN key_results kr ON kr.id = tt.key_result_id
+	LEFT JOIN tasks t ON t.id = tt.task_id
+`
+
+func (t *Tracker) Title() string {
+	if t.Target != "" {
+		return t.Target
+	}
+	if t.Note != nil && *t.Note != "" {
+		return *t.Note
+	}
+	return "Без цели"
+}
+
+func (t *Tracker) ElapsedMinutes(now time.Time) int {
+	minutes := int(math.Round(now.Sub(t.StartedAt).Minutes()))
+	if minutes < 1 {
+		return 1
+	}
+	if minutes > maxTrackerMinutes {
+		return maxTrackerMinutes
+	}
+	return minutes
+}
+
+func (t *Tracker) Capped(now time.Time) bool {
+	return now.Sub(t.StartedAt) > maxTrackerMinutes*time.Minute
+}
+
+func (s *Service) StartTracking(ctx context.Context, userID int64, note string, objectiveID *string, keyResultID, taskID *int64) (*Tracker, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	objectiveID, keyResultID, err = resolveTimeTarget(ctx, tx, userID, objectiveID, keyResultID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO time_trackers (user_id, objective_id, key_result_id, task_id, note)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID, objectiveID, keyResultID, taskID, strings.TrimSpace(note))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запуске таймера: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrTrackerActive
+	}
+
+	var tracker Tracker
+	if err := tx.GetContext(ctx, &tracker, trackerSelect+`WHERE tt.user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("ошибка при получении таймера: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении таймера: %v", err)
+	}
+	return &tracker, nil
+}
+
+func (s *Service) GetActiveTracker(ctx context.Context, userID int64) (*Tracker, error) {
+	var tracker Tracker
+	err := s.db.GetContext(ctx, &tracker, trackerSelect+`WHERE tt.user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveTracker
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении таймера: %v", err)
+	}
+	return &tracker, nil
+}
+
+func (s *Service) StopTracking(ctx context.Context, userID int64) (*Tracker, *TimeEntry, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var tracker Tracker
+	err = tx.GetContext(ctx, &tracker, trackerSelect+`WHERE tt.user_id = $1 FOR UPDATE OF tt`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrNoActiveTracker
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при получении таймера: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM time_trackers WHERE user_id = $1`, userID); err != nil {
+		return nil, nil, fmt.Errorf("ошибка при остановке таймера: %v", err)
+	}
+
+	note := ""
+	if tracker.Note != nil {
+		note = *tracker.Note
+	}
+	startedAt := tracker.StartedAt
+	entry, err := insertTimeEntry(ctx, tx, userID, tracker.ElapsedMinutes(time.Now()), note,
+		tracker.ObjectiveID, tracker.KeyResultID, tracker.TaskID, startedAt.In(time.Local), &startedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка при сохранении затраченного времени: %v", err)
+	}
+	return &tracker, entry, nil
+}
+
+func (s *Service) StartWeeklyTimeReport(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"time_weekly_report",
+		Spec:	"0 18 * * 0",
+		Run: func(ctx context.Context) {
+			s.sendWeeklyTimeReports(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущен еженедельный отчет по учету времени")
+}
+
+func (s *Service) sendWeeklyTimeReports(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	weekStart := WeekStart(time.Now())
+
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT DISTINCT user_id FROM time_entries WHERE spent_on >= $1 AND spent_on < $2
+	`, weekStart.Format("2006-01-02"), weekStart.AddDate(0, 0, 7).Format("2006-01-02"))
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе пользователей для отчета по времени: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		report, err := s.weeklyTimeReport(ctx, userID, weekStart)
+		if err != nil {
+			logrus.Warnf("Не удалось подготовить отчет по времени пользователя %d: %v", userID, err)
+			continue
+		}
+		if err := sendMessageFunc(userID, report); err != nil {
+			logrus.Warnf("Не удалось отправить отчет по времени пользователю %d: %v", userID, err)
+		}
+	}
+}
+
+func (s *Service) weeklyTimeReport(ctx context.Context, userID int64, weekStart time.Time) (string, error) {
+	allocation, err := s.GetWeeklyAllocation(ctx, userID, weekStart)
+	if err != nil {
+		return "", err
+	}
+	previous, err := s.GetWeeklyAllocation(ctx, userID, weekStart.AddDate(0, 0, -7))
+	if err != nil {
+		return "", err
+	}
+
+	var tasks []struct {
+		Title	string	`db:"title"`
+		Minutes	int	`db:"minutes"`
+	}
+	err = s.db.SelectContext(ctx, &tasks, `
+		SELECT t.title, SUM(te.minutes) AS minutes
+		FROM time_entries te
+		JOIN tasks t ON t.id = te.task_id
+		WHERE te.user_id = $1 AND te.spent_on >= $2 AND te.spent_on < $3
+		GROUP BY t.id, t.title
+		ORDER BY minutes DESC
+		LIMIT 5
+	`, userID, weekStart.Format("2006-01-02"), weekStart.AddDate(0, 0, 7).Format("2006-01-02"))
+	if err != nil {
+		return "", fmt.Errorf("ошибка при подсчете времени по задачам: %v", err)
+	}
+
+	text := "📊 Недельный отчет по времени\n\n" + FormatWeeklyAllocation(allocation)
+	if len(tasks) > 0 {
+		text += "\n\n📋 **Больше всего времени по задачам:**\n"
+		for _, task := range tasks {
+			text += fmt.Sprintf("• %s: %s\n", task.Title, FormatMinutes(task.Minutes))
+		}
+		text = strings.TrimRight(text, "\n")
+	}
+	if previous.Minutes > 0 {
+		diff := allocation.Minutes - previous.Minutes
+		switch {
+		case diff > 0:
+			text += fmt.Sprintf("\n\n📈 На %s больше, чем на прошлой неделе", FormatMinutes(diff))
+		case diff < 0:
+			text += fmt.Sprintf("\n\n📉 На %s меньше, чем на прошлой неделе", FormatMinutes(-diff))
+		}
+	}
+	return text, nil
+}
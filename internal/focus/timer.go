@@ -0,0 +1,90 @@
+package focus
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultPomodoroMinutes = 25
+
+type SessionStats struct {
+	Sessions	int
+	TotalMinutes	int
+	AverageMinutes	float64
+	MinutesByHour	map[int]int
+}
+
+func (s *Service) StartSessionTimer(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"focus_session_timer",
+		Spec:	"@every 1m",
+		Run: func(ctx context.Context) {
+			s.completeDueSessions(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущен таймер фокус-сессий")
+}
+
+func (s *Service) completeDueSessions(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	var sessions []Session
+	err := s.db.SelectContext(ctx, &sessions, `
+		UPDATE focus_sessions
+		SET ended_at = started_at + make_interval(mins => planned_minutes), duration_minutes = planned_minutes
+		WHERE ended_at IS NULL AND planned_minutes IS NOT NULL
+			AND started_at + make_interval(mins => planned_minutes) <= NOW()
+		RETURNING id, user_id, label, started_at, ended_at, planned_minutes, duration_minutes
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при завершении фокус-сессий по таймеру: %v", err)
+		return
+	}
+
+	weekStart := WeekStart(time.Now())
+	for _, session := range sessions {
+		text := fmt.Sprintf("🍅 Время вышло! Фокус-сессия «%s» завершена: %s. Сделайте перерыв 5 минут", session.Title(), FormatMinutes(*session.DurationMinutes))
+		if stats, err := s.GetWeeklyStats(ctx, session.UserID, weekStart); err == nil {
+			text += "\n" + FormatWeeklyStats(stats)
+		}
+		text += "\n\nСледующая сессия — /focus " + fmt.Sprint(*session.PlannedMinutes)
+		if err := sendMessageFunc(session.UserID, text); err != nil {
+			logrus.Errorf("Ошибка при отправке уведомления о фокус-сессии пользователю %d: %v", session.UserID, err)
+		}
+	}
+}
+
+func (s *Service) GetSessionStats(ctx context.Context, userID int64, since time.Time) (*SessionStats, error) {
+	var sessions []Session
+	err := s.db.SelectContext(ctx, &sessions, sessionSelect+`
+		WHERE user_id = $1 AND ended_at IS NOT NULL AND duration_minutes > 0 AND started_at >= $2
+	`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении статистики фокус-сессий: %v", err)
+	}
+
+	stats := &SessionStats{MinutesByHour: make(map[int]int)}
+	for _, session := range sessions {
+		minutes := *session.DurationMinutes
+		stats.Sessions++
+		stats.TotalMinutes += minutes
+
+		at := session.StartedAt.In(time.Local)
+		for minutes > 0 {
+			chunk := 60 - at.Minute()
+			if chunk > minutes {
+				chunk = minutes
+			}
+			stats.MinutesByHour[at.Hour()] += chunk
+			at = at.Add(time.Duration(chunk) * time.Minute)
+			minutes -= chunk
+		}
+	}
+	if stats.Sessions > 0 {
+		stats.AverageMinutes = float64(stats.TotalMinutes) / float64(stats.Sessions)
+	}
+	return stats, nil
+}
@@ -0,0 +1,465 @@
+package focus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	WeeklyPlanProposed	= "proposed"
+	WeeklyPlanApplied	= "applied"
+	WeeklyPlanDiscarded	= "discarded"
+	WeeklyPlanReverted	= "reverted"
+
+	weeklyPlanTTL		= 24 * time.Hour
+	minPlanBlock		= 30 * time.Minute
+	maxPlanBlock		= 3 * time.Hour
+	keptBlockShare		= 0.5
+)
+
+var (
+	ErrWeeklyPlanNotFound	= errors.New("предложенный план на неделю не найден или устарел")
+	ErrWeeklyPlanNotApplied	= errors.New("план на неделю не добавлен в календарь или уже отменен")
+)
+
+type PlanEntry struct {
+	Day		time.Time
+	Focus		string
+	Duration	time.Duration
+}
+
+type PlannedBlock struct {
+	ID		int64		`db:"id"`
+	Focus		string		`db:"focus"`
+	Start		time.Time	`db:"start_time"`
+	End		time.Time	`db:"end_time"`
+	EventID		*string		`db:"event_id"`
+	DoneMinutes	*int		`db:"done_minutes"`
+}
+
+type WeeklyPlan struct {
+	ID		int64		`db:"id"`
+	UserID		int64		`db:"user_id"`
+	WeekStart	time.Time	`db:"week_start"`
+	Status		string		`db:"status"`
+	CreatedAt	time.Time	`db:"created_at"`
+	AppliedAt	*time.Time	`db:"applied_at"`
+	RevertedAt	*time.Time	`db:"reverted_at"`
+	Blocks		[]PlannedBlock	`db:"-"`
+}
+
+type PlanAdherence struct {
+	Plan		*WeeklyPlan
+	Total		int
+	Past		int
+	Kept		int
+	Removed		int
+	PlannedMinutes	int
+	DoneMinutes	int
+}
+
+func (b PlannedBlock) Minutes() int {
+	return int(b.End.Sub(b.Start).Minutes())
+}
+
+func (b PlannedBlock) Kept() bool {
+	return b.DoneMinutes != nil && float64(*b.DoneMinutes) >= float64(b.Minutes())*keptBlockShare
+}
+
+func PlanWeekStart(now time.Time) time.Time {
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return WeekStart(now).AddDate(0, 0, 7)
+	}
+	return WeekStart(now)
+}
+
+func (s *Service) ProposeWeeklyPlan(ctx context.Context, calendarService *calendar.Service, userID int64, weekStart time.Time, entries []PlanEntry) (*WeeklyPlan, error) {
+	plan := &WeeklyPlan{UserID: userID, WeekStart: weekStart, Status: WeeklyPlanProposed}
+	for _, entry := range entries {
+		duration := entry.Duration.Round(15 * time.Minute)
+		if duration < minPlanBlock {
+			duration = minPlanBlock
+		}
+		if duration > maxPlanBlock {
+			duration = maxPlanBlock
+		}
+		dayStart := time.Date(entry.Day.Year(), entry.Day.Month(), entry.Day.Day(), 0, 0, 0, 0, entry.Day.Location())
+
+		slots, err := calendarService.SuggestFreeSlots(ctx, userID, dayStart, dayStart.AddDate(0, 0, 1), duration, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(slots) == 0 {
+			continue
+		}
+		plan.Blocks = append(plan.Blocks, PlannedBlock{Focus: entry.Focus, Start: slots[0], End: slots[0].Add(duration)})
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE weekly_plans SET status = $2 WHERE user_id = $1 AND status = $3`, userID, WeeklyPlanDiscarded, WeeklyPlanProposed); err != nil {
+		return nil, fmt.Errorf("ошибка при сбросе прошлого плана: %v", err)
+	}
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO weekly_plans (user_id, week_start, status) VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, userID, weekStart.Format("2006-01-02"), WeeklyPlanProposed).Scan(&plan.ID, &plan.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении плана на неделю: %v", err)
+	}
+	for i := range plan.Blocks {
+		err = tx.GetContext(ctx, &plan.Blocks[i].ID, `
+			INSERT INTO weekly_plan_blocks (plan_id, focus, start_time, end_time) VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, plan.ID, plan.Blocks[i].Focus, plan.Blocks[i].Start, plan.Blocks[i].End)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сохранении блока плана: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении плана на неделю: %v", err)
+	}
+	return plan, nil
+}
+
+func (s *Service) ApplyWeeklyPlan(ctx context.Context, calendarService *calendar.Service, userID int64) (*WeeklyPlan, error) {
+	plan, err := s.latestWeeklyPlan(ctx, userID, WeeklyPlanProposed)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyWeeklyPlan(ctx, calendarService, userID, plan)
+}
+
+func (s *Service) ApplyWeeklyPlanByID(ctx context.Context, calendarService *calendar.Service, userID, planID int64) (*WeeklyPlan, error) {
+	plan, err := s.weeklyPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.UserID != userID || plan.Status != WeeklyPlanProposed {
+		return nil, ErrWeeklyPlanNotFound
+	}
+	return s.applyWeeklyPlan(ctx, calendarService, userID, plan)
+}
+
+func (s *Service) applyWeeklyPlan(ctx context.Context, calendarService *calendar.Service, userID int64, plan *WeeklyPlan) (*WeeklyPlan, error) {
+	if time.Since(plan.CreatedAt) > weeklyPlanTTL {
+		return nil, ErrWeeklyPlanNotFound
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE weekly_plans SET status = $3, applied_at = NOW() WHERE id = $1 AND user_id = $2 AND status = $4
+	`, plan.ID, userID, WeeklyPlanApplied, WeeklyPlanProposed)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении статуса плана: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrWeeklyPlanNotFound
+	}
+	var superseded []int64
+	err = tx.SelectContext(ctx, &superseded, `
+		UPDATE weekly_plans SET status = $4, reverted_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND status = $3 AND week_start = $5
+		RETURNING id
+	`, userID, plan.ID, WeeklyPlanApplied, WeeklyPlanReverted, plan.WeekStart.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отмене прошлого плана на неделю: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении статуса плана: %v", err)
+	}
+
+	for _, planID := range superseded {
+		previous, err := s.weeklyPlanByID(ctx, planID)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении прошлого плана на неделю %d: %v", planID, err)
+			continue
+		}
+		removed := s.removePlanEvents(ctx, calendarService, userID, previous)
+		logrus.Infof("План на неделю %d заменен планом %d, удалено блоков из календаря: %d", planID, plan.ID, removed)
+	}
+
+	for i, block := range plan.Blocks {
+		eventID, err := calendarService.CreateEvent(ctx, userID, "🎯 "+block.Focus, "Предварительный блок из недельного плана Jarvis. Отменить весь план можно кнопкой «Отменить план»",
+			block.Start.Format(time.RFC3339), block.End.Format(time.RFC3339))
+		if err != nil {
+			logrus.Errorf("План на неделю %d: ошибка при создании блока «%s»: %v", plan.ID, block.Focus, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE weekly_plan_blocks SET event_id = $2 WHERE id = $1`, block.ID, eventID); err != nil {
+			logrus.Errorf("Ошибка при сохранении события блока %d: %v", block.ID, err)
+		}
+		plan.Blocks[i].EventID = &eventID
+	}
+
+	plan.Status = WeeklyPlanApplied
+	return plan, nil
+}
+
+func (s *Service) DiscardWeeklyPlan(ctx context.Context, userID, planID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE weekly_plans SET status = $3 WHERE id = $1 AND user_id = $2 AND status = $4
+	`, planID, userID, WeeklyPlanDiscarded, WeeklyPlanProposed)
+	if err != nil {
+		return fmt.Errorf("ошибка при отклонении плана на неделю: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrWeeklyPlanNotFound
+	}
+	return nil
+}
+
+func (s *Service) RevertWeeklyPlan(ctx context.Context, calendarService *calendar.Service, userID, planID int64) (int, error) {
+	plan, err := s.weeklyPlanByID(ctx, planID)
+	if err != nil {
+		return 0, err
+	}
+	if plan.UserID != userID {
+		return 0, ErrWeeklyPlanNotApplied
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE weekly_plans SET status = $3, reverted_at = NOW() WHERE id = $1 AND user_id = $2 AND status = $4
+	`, planID, userID, WeeklyPlanReverted, WeeklyPlanApplied)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при отмене плана на неделю: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return 0, ErrWeeklyPlanNotApplied
+	}
+
+	return s.removePlanEvents(ctx, calendarService, userID, plan), nil
+}
+
+func (s *Service) removePlanEvents(ctx context.Context, calendarService *calendar.Service, userID int64, plan *WeeklyPlan) int {
+	removed := 0
+	for _, block := range plan.Blocks {
+		if block.EventID == nil {
+			continue
+		}
+		if err := calendarService.DeleteEvent(ctx, userID, *block.EventID); err != nil {
+			logrus.Warnf("План на неделю %d: не удалось удалить событие блока «%s»: %v", plan.ID, block.Focus, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE weekly_plan_blocks SET event_id = NULL WHERE id = $1`, block.ID); err != nil {
+			logrus.Errorf("Ошибка при сбросе события блока %d: %v", block.ID, err)
+		}
+		removed++
+	}
+	return removed
+}
+
+func (s *Service) LatestWeeklyPlan(ctx context.Context, userID int64, status string) (*WeeklyPlan, error) {
+	return s.latestWeeklyPlan(ctx, userID, status)
+}
+
+func (s *Service) GetPlanAdherence(ctx context.Context, userID int64, now time.Time) (*PlanAdherence, error) {
+	var planID int64
+	err := s.db.GetContext(ctx, &planID, `
+		SELECT id FROM weekly_plans
+		WHERE user_id = $1 AND status = $2 AND week_start = $3
+		ORDER BY applied_at DESC
+		LIMIT 1
+	`, userID, WeeklyPlanApplied, WeekStart(now).Format("2006-01-02"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWeeklyPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении плана на неделю: %v", err)
+	}
+
+	if err := s.measureBlocks(ctx, planID, userID, now); err != nil {
+		return nil, err
+	}
+	plan, err := s.weeklyPlanByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	adherence := &PlanAdherence{Plan: plan, Total: len(plan.Blocks)}
+	for _, block := range plan.Blocks {
+		adherence.PlannedMinutes += block.Minutes()
+		if block.End.After(now) {
+			continue
+		}
+		adherence.Past++
+		if block.DoneMinutes != nil {
+			adherence.DoneMinutes += *block.DoneMinutes
+		}
+		if block.Kept() {
+			adherence.Kept++
+		} else if block.EventID == nil {
+			adherence.Removed++
+		}
+	}
+	return adherence, nil
+}
+
+func (s *Service) measureBlocks(ctx context.Context, planID, userID int64, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE weekly_plan_blocks b SET done_minutes = COALESCE((
+			SELECT SUM(GREATEST(0, EXTRACT(EPOCH FROM LEAST(COALESCE(fs.ended_at, $3), b.end_time) - GREATEST(fs.started_at, b.start_time)) / 60))::int
+			FROM focus_sessions fs
+			WHERE fs.user_id = $2 AND fs.started_at < b.end_time AND COALESCE(fs.ended_at, $3) > b.start_time
+		), 0)
+		WHERE b.plan_id = $1 AND b.end_time <= $3 AND b.done_minutes IS NULL
+	`, planID, userID, now)
+	if err != nil {
+		return fmt.Errorf("ошибка при сверке плана с фокус-сессиями: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) latestWeeklyPlan(ctx context.Context, userID int64, status string) (*WeeklyPlan, error) {
+	var planID int64
+	err := s.db.GetContext(ctx, &planID, `
+		SELECT id FROM weekly_plans WHERE user_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWeeklyPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении плана на неделю: %v", err)
+	}
+	return s.weeklyPlanByID(ctx, planID)
+}
+
+func (s *Service) weeklyPlanByID(ctx context.Context, planID int64) (*WeeklyPlan, error) {
+	var plan WeeklyPlan
+	err := s.db.GetContext(ctx, &plan, `
+		SELECT id, user_id, week_start, status, created_at, applied_at, reverted_at FROM weekly_plans WHERE id = $1
+	`, planID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении плана на неделю: %v", err)
+	}
+	err = s.db.SelectContext(ctx, &plan.Blocks, `
+		SELECT id, focus, start_time, end_time, event_id, done_minutes
+		FROM weekly_plan_blocks WHERE plan_id = $1
+		ORDER BY start_time
+	`, planID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении блоков плана: %v", err)
+	}
+	return &plan, nil
+}
+
+func (s *Service) StartWeeklyPlanTracking(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"weekly_plan_adherence",
+		Spec:	"0 20 * * *",
+		Run: func(ctx context.Context) {
+			s.sendPlanAdherence(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущено отслеживание недельных планов")
+}
+
+func (s *Service) sendPlanAdherence(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT DISTINCT p.user_id FROM weekly_plans p
+		JOIN weekly_plan_blocks b ON b.plan_id = p.id
+		WHERE p.status = $1 AND p.week_start = $2
+			AND (p.last_adherence_alert IS NULL OR p.last_adherence_alert < $3)
+			AND b.start_time >= $4 AND b.end_time <= $5
+	`, WeeklyPlanApplied, WeekStart(now).Format("2006-01-02"), today.Format("2006-01-02"), today, now)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе недельных планов для сверки: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		adherence, err := s.GetPlanAdherence(ctx, userID, now)
+		if err != nil {
+			logrus.Warnf("Не удалось сверить недельный план пользователя %d: %v", userID, err)
+			continue
+		}
+		if err := sendMessageFunc(userID, FormatDailyAdherence(adherence, today)); err != nil {
+			logrus.Warnf("Не удалось отправить сверку недельного плана пользователю %d: %v", userID, err)
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE weekly_plans SET last_adherence_alert = $2 WHERE id = $1`, adherence.Plan.ID, today.Format("2006-01-02")); err != nil {
+			logrus.Errorf("Ошибка при отметке сверки недельного плана %d: %v", adherence.Plan.ID, err)
+		}
+	}
+}
+
+func FormatPlanBlocks(blocks []PlannedBlock) string {
+	lines := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		line := fmt.Sprintf("• %s %s %s–%s — %s", weekdayShort(block.Start), block.Start.Format("02.01"), block.Start.Format("15:04"), block.End.Format("15:04"), block.Focus)
+		if block.DoneMinutes != nil {
+			if block.Kept() {
+				line += " ✅"
+			} else {
+				line += " ❌"
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func FormatPlanAdherence(adherence *PlanAdherence) string {
+	var b strings.Builder
+	b.WriteString("📅 **План на неделю**\n\n")
+	b.WriteString(FormatPlanBlocks(adherence.Plan.Blocks))
+	if adherence.Past == 0 {
+		b.WriteString("\n\nНи один блок еще не прошел — сверю план по мере выполнения")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n\n✅ Выполнено блоков: %d из %d прошедших (всего в плане %d)", adherence.Kept, adherence.Past, adherence.Total)
+	fmt.Fprintf(&b, "\n⏱️ В фокусе: %s из %s запланированных", FormatMinutes(adherence.DoneMinutes), FormatMinutes(adherence.PlannedMinutes))
+	if adherence.Removed > 0 {
+		fmt.Fprintf(&b, "\n🗑️ Удалено из календаря: %d", adherence.Removed)
+	}
+	return b.String()
+}
+
+func FormatDailyAdherence(adherence *PlanAdherence, day time.Time) string {
+	var today []PlannedBlock
+	for _, block := range adherence.Plan.Blocks {
+		if !block.Start.Before(day) && block.Start.Before(day.AddDate(0, 0, 1)) {
+			today = append(today, block)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("📅 **Сверка с планом на неделю**\n\n")
+	b.WriteString(FormatPlanBlocks(today))
+	fmt.Fprintf(&b, "\n\nЗа неделю выполнено блоков: %d из %d", adherence.Kept, adherence.Past)
+	if adherence.Past > adherence.Kept {
+		b.WriteString("\nПропущенное можно догнать: скажи «добавь фокус-блоки» — подберу свободные окна")
+	}
+	return b.String()
+}
+
+func weekdayShort(t time.Time) string {
+	return [...]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}[t.Weekday()]
+}
@@ -0,0 +1,365 @@
+package habits
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KindWater	= "water"
+	KindSteps	= "steps"
+	KindPages	= "pages"
+)
+
+var (
+	ErrCounterNotFound	= errors.New("счетчик не найден")
+	ErrUnknownKind		= errors.New("неизвестный счетчик")
+)
+
+type Preset struct {
+	Kind	string
+	Label	string
+	Emoji	string
+	Unit	string
+	Step	float64
+	Goal	float64
+}
+
+var presets = []Preset{
+	{Kind: KindWater, Label: "Вода", Emoji: "💧", Unit: "мл", Step: 250, Goal: 2000},
+	{Kind: KindSteps, Label: "Шаги", Emoji: "👟", Unit: "шагов", Step: 1000, Goal: 10000},
+	{Kind: KindPages, Label: "Страницы", Emoji: "📖", Unit: "стр.", Step: 10, Goal: 30},
+}
+
+type Service struct {
+	db	*sqlx.DB
+	okr	*okr.Service
+}
+
+type Counter struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Kind		string		`db:"kind" json:"kind"`
+	Step		float64		`db:"step" json:"step"`
+	DailyGoal	float64		`db:"daily_goal" json:"daily_goal"`
+	KeyResultID	*int64		`db:"key_result_id" json:"key_result_id,omitempty"`
+	IsActive	bool		`db:"is_active" json:"is_active"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type DayTotal struct {
+	CounterID	int64		`db:"counter_id" json:"counter_id"`
+	Kind		string		`db:"kind" json:"kind"`
+	Date		time.Time	`db:"log_date" json:"date"`
+	Value		float64		`db:"value" json:"value"`
+	Taps		int		`db:"taps" json:"taps"`
+	GoalReached	bool		`db:"goal_reached" json:"goal_reached"`
+}
+
+type TapResult struct {
+	Counter		Counter
+	Total		float64
+	GoalReached	bool
+	KeyResultErr	error
+}
+
+type Summary struct {
+	Counter		Counter
+	Today		float64
+	Average		float64
+	GoalDays	int
+	Days		int
+	Streak		int
+}
+
+const counterSelect = `
+	SELECT id, user_id, kind, step, daily_goal, key_result_id, is_active, created_at
+	FROM habit_counters
+`
+
+func NewService(db *sqlx.DB, okrService *okr.Service) *Service {
+	return &Service{db: db, okr: okrService}
+}
+
+func GetPreset(kind string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Kind == kind {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+func (c *Counter) Preset() Preset {
+	p, _ := GetPreset(c.Kind)
+	return p
+}
+
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func (c *Counter) ButtonText() string {
+	p := c.Preset()
+	return fmt.Sprintf("%s +%s %s", p.Emoji, formatAmount(c.Step), p.Unit)
+}
+
+func (c *Counter) FormatProgress(total float64) string {
+	p := c.Preset()
+	return fmt.Sprintf("%s %s: %s / %s %s", p.Emoji, p.Label, formatAmount(total), formatAmount(c.DailyGoal), p.Unit)
+}
+
+func IsButtonText(text string) bool {
+	for _, p := range presets {
+		if strings.HasPrefix(text, p.Emoji+" +") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) EnableCounter(ctx context.Context, userID int64, kind string, step, goal float64, keyResultID *int64) (*Counter, error) {
+	preset, ok := GetPreset(kind)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s. Допустимые значения: water, steps, pages", ErrUnknownKind, kind)
+	}
+	if step <= 0 {
+		step = preset.Step
+	}
+	if goal <= 0 {
+		goal = preset.Goal
+	}
+
+	if keyResultID != nil {
+		if _, err := s.okr.GetKeyResultByID(ctx, userID, *keyResultID); err != nil {
+			return nil, fmt.Errorf("ключевой результат %d не найден: %v", *keyResultID, err)
+		}
+	}
+
+	query := `
+		INSERT INTO habit_counters (user_id, kind, step, daily_goal, key_result_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, kind)
+		DO UPDATE SET step = $3, daily_goal = $4, key_result_id = $5, is_active = TRUE
+		RETURNING id, user_id, kind, step, daily_goal, key_result_id, is_active, created_at
+	`
+
+	var counter Counter
+	if err := s.db.GetContext(ctx, &counter, query, userID, kind, step, goal, keyResultID); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении счетчика: %v", err)
+	}
+
+	return &counter, nil
+}
+
+func (s *Service) DisableCounter(ctx context.Context, userID int64, kind string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE habit_counters SET is_active = FALSE WHERE user_id = $1 AND kind = $2 AND is_active = TRUE
+	`, userID, kind)
+	if err != nil {
+		return fmt.Errorf("ошибка при отключении счетчика: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrCounterNotFound
+	}
+	return nil
+}
+
+func (s *Service) GetCounters(ctx context.Context, userID int64) ([]Counter, error) {
+	var counters []Counter
+	err := s.db.SelectContext(ctx, &counters, counterSelect+`
+		WHERE user_id = $1 AND is_active = TRUE
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении счетчиков: %v", err)
+	}
+	return counters, nil
+}
+
+func (s *Service) FindCounterByButton(ctx context.Context, userID int64, text string) (*Counter, error) {
+	counters, err := s.GetCounters(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range counters {
+		if c.ButtonText() == text {
+			return &c, nil
+		}
+	}
+	return nil, ErrCounterNotFound
+}
+
+func (s *Service) GetCounter(ctx context.Context, userID int64, kind string) (*Counter, error) {
+	var counter Counter
+	err := s.db.GetContext(ctx, &counter, counterSelect+`
+		WHERE user_id = $1 AND kind = $2 AND is_active = TRUE
+	`, userID, kind)
+	if err == sql.ErrNoRows {
+		return nil, ErrCounterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении счетчика: %v", err)
+	}
+	return &counter, nil
+}
+
+func (s *Service) Add(ctx context.Context, counter *Counter, amount float64) (*TapResult, error) {
+	if amount <= 0 {
+		amount = counter.Step
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var day struct {
+		Value		float64	`db:"value"`
+		WasReached	bool	`db:"was_reached"`
+	}
+	err = tx.GetContext(ctx, &day, `
+		INSERT INTO habit_counter_days (counter_id, user_id, log_date, value, taps)
+		VALUES ($1, $2, CURRENT_DATE, $3, 1)
+		ON CONFLICT (counter_id, log_date)
+		DO UPDATE SET value = habit_counter_days.value + $3, taps = habit_counter_days.taps + 1, updated_at = NOW()
+		RETURNING value, goal_reached AS was_reached
+	`, counter.ID, counter.UserID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении счетчика: %v", err)
+	}
+
+	result := &TapResult{Counter: *counter, Total: day.Value}
+
+	if !day.WasReached && day.Value >= counter.DailyGoal {
+		result.GoalReached = true
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE habit_counter_days SET goal_reached = TRUE WHERE counter_id = $1 AND log_date = CURRENT_DATE
+		`, counter.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при отметке цели: %v", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO habit_tracking (user_id, key_result_id, date, completed, completion_percentage, notes)
+			VALUES ($1, $2, CURRENT_DATE, TRUE, 100, $3)
+		`, counter.UserID, counter.KeyResultID, "Счетчик: "+counter.Preset().Label)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при записи привычки: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при фиксации транзакции: %v", err)
+	}
+
+	if counter.KeyResultID != nil {
+		if _, krErr := s.okr.UpdateKeyResultProgress(ctx, counter.UserID, *counter.KeyResultID, amount); krErr != nil {
+			logrus.Errorf("Ошибка при обновлении ключевого результата %d из счетчика %d: %v", *counter.KeyResultID, counter.ID, krErr)
+			result.KeyResultErr = krErr
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Service) GetSummaries(ctx context.Context, userID int64, days int) ([]Summary, error) {
+	counters, err := s.GetCounters(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = 7
+	}
+
+	var totals []DayTotal
+	err = s.db.SelectContext(ctx, &totals, `
+		SELECT d.counter_id, c.kind, d.log_date, d.value, d.taps, d.goal_reached
+		FROM habit_counter_days d
+		JOIN habit_counters c ON c.id = d.counter_id
+		WHERE d.user_id = $1 AND d.log_date > CURRENT_DATE - $2::int
+		ORDER BY d.log_date DESC
+	`, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении статистики счетчиков: %v", err)
+	}
+
+	byCounter := make(map[int64]map[string]DayTotal)
+	for _, t := range totals {
+		if byCounter[t.CounterID] == nil {
+			byCounter[t.CounterID] = make(map[string]DayTotal)
+		}
+		byCounter[t.CounterID][t.Date.Format("2006-01-02")] = t
+	}
+
+	today := time.Now()
+	var summaries []Summary
+	for _, c := range counters {
+		summary := Summary{Counter: c, Days: days}
+		entries := byCounter[c.ID]
+
+		var sum float64
+		streakOpen := true
+		for i := 0; i < days; i++ {
+			date := today.AddDate(0, 0, -i).Format("2006-01-02")
+			entry, ok := entries[date]
+			if i == 0 && ok {
+				summary.Today = entry.Value
+			}
+			sum += entry.Value
+			if ok && entry.GoalReached {
+				summary.GoalDays++
+				if streakOpen {
+					summary.Streak++
+				}
+			} else if i > 0 {
+				streakOpen = false
+			}
+		}
+		summary.Average = sum / float64(days)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func FormatSummaries(summaries []Summary) string {
+	var b strings.Builder
+	for _, s := range summaries {
+		p := s.Counter.Preset()
+		b.WriteString(s.Counter.FormatProgress(s.Today))
+		b.WriteString(fmt.Sprintf("\n   в среднем %s %s/день, цель выполнена %d из %d дн.", formatAmount(float64(int(s.Average))), p.Unit, s.GoalDays, s.Days))
+		if s.Streak > 1 {
+			b.WriteString(fmt.Sprintf(", серия %d дн. 🔥", s.Streak))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func FormatTapResult(result *TapResult) string {
+	text := "✅ " + result.Counter.FormatProgress(result.Total)
+	if result.GoalReached {
+		text += "\n🎉 Дневная цель выполнена!"
+	}
+	if result.KeyResultErr != nil {
+		text += "\n⚠️ Не удалось обновить связанный ключевой результат"
+	}
+	return text
+}
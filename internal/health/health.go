@@ -0,0 +1,356 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrMedicationNotFound	= errors.New("лекарство не найдено")
+	ErrDoseNotFound		= errors.New("прием не найден")
+	ErrDoseAlreadyLogged	= errors.New("прием уже отмечен")
+)
+
+const missedEscalationThreshold = 3
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type Medication struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Name		string		`db:"name" json:"name"`
+	Dosage		*string		`db:"dosage" json:"dosage,omitempty"`
+	Times		pq.StringArray	`db:"times" json:"times"`
+	StartDate	time.Time	`db:"start_date" json:"start_date"`
+	EndDate		*time.Time	`db:"end_date" json:"end_date,omitempty"`
+	IsActive	bool		`db:"is_active" json:"is_active"`
+	MissedStreak	int		`db:"missed_streak" json:"missed_streak"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type Dose struct {
+	ID		int64		`db:"id" json:"id"`
+	MedicationID	int64		`db:"medication_id" json:"medication_id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	ScheduledAt	time.Time	`db:"scheduled_at" json:"scheduled_at"`
+	Status		string		`db:"status" json:"status"`
+	TakenAt		*time.Time	`db:"taken_at" json:"taken_at,omitempty"`
+	Name		string		`db:"name" json:"name"`
+}
+
+type Adherence struct {
+	MedicationID	int64	`db:"medication_id" json:"medication_id"`
+	Name		string	`db:"name" json:"name"`
+	Taken		int	`db:"taken" json:"taken"`
+	Skipped		int	`db:"skipped" json:"skipped"`
+	Missed		int	`db:"missed" json:"missed"`
+	Total		int	`db:"total" json:"total"`
+}
+
+const medicationSelect = `
+	SELECT id, user_id, name, dosage, times, start_date, end_date, is_active, missed_streak, created_at
+	FROM medications
+`
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func (m *Medication) Label() string {
+	if m.Dosage != nil && *m.Dosage != "" {
+		return fmt.Sprintf("%s (%s)", m.Name, *m.Dosage)
+	}
+	return m.Name
+}
+
+func (a *Adherence) Rate() float64 {
+	if a.Total == 0 {
+		return 0
+	}
+	return float64(a.Taken) / float64(a.Total) * 100
+}
+
+func NormalizeTimes(values []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+
+	for _, value := range values {
+		t, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("некорректное время приема: %s. Используйте формат ЧЧ:ММ", value)
+		}
+		normalized := t.Format("15:04")
+		if !seen[normalized] {
+			seen[normalized] = true
+			result = append(result, normalized)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("не указано время приема")
+	}
+	if len(result) > 8 {
+		return nil, fmt.Errorf("слишком много приемов в день: %d", len(result))
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+func (s *Service) AddMedication(ctx context.Context, userID int64, name, dosage string, times []string, durationDays int) (*Medication, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("не указано название лекарства")
+	}
+
+	normalized, err := NormalizeTimes(times)
+	if err != nil {
+		return nil, err
+	}
+
+	if durationDays < 0 || durationDays > 365 {
+		return nil, fmt.Errorf("неверная длительность курса: %d. Должно быть от 1 до 365 дней", durationDays)
+	}
+
+	var endDate *string
+	if durationDays > 0 {
+		end := time.Now().AddDate(0, 0, durationDays-1).Format("2006-01-02")
+		endDate = &end
+	}
+
+	var dosagePtr *string
+	if dosage != "" {
+		dosagePtr = &dosage
+	}
+
+	query := `
+		INSERT INTO medications (user_id, name, dosage, times, end_date)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, name, dosage, times, start_date, end_date, is_active, missed_streak, created_at
+	`
+
+	var medication Medication
+	err = s.db.GetContext(ctx, &medication, query, userID, name, dosagePtr, pq.Array(normalized), endDate)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении лекарства: %v", err)
+	}
+
+	return &medication, nil
+}
+
+func (s *Service) GetMedications(ctx context.Context, userID int64) ([]Medication, error) {
+	var medications []Medication
+	err := s.db.SelectContext(ctx, &medications, medicationSelect+`
+		WHERE user_id = $1 AND is_active = TRUE AND (end_date IS NULL OR end_date >= CURRENT_DATE)
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении лекарств: %v", err)
+	}
+
+	return medications, nil
+}
+
+func (s *Service) FindMedication(ctx context.Context, userID int64, name string) (*Medication, error) {
+	var medication Medication
+	err := s.db.GetContext(ctx, &medication, medicationSelect+`
+		WHERE user_id = $1 AND is_active = TRUE AND name ILIKE '%' || $2 || '%'
+		ORDER BY LENGTH(name)
+		LIMIT 1
+	`, userID, strings.TrimSpace(name))
+	if err == sql.ErrNoRows {
+		return nil, ErrMedicationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске лекарства: %v", err)
+	}
+
+	return &medication, nil
+}
+
+func (s *Service) UpdateMedicationTimes(ctx context.Context, userID, medicationID int64, times []string) (*Medication, error) {
+	normalized, err := NormalizeTimes(times)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE medications SET times = $1, missed_streak = 0
+		WHERE id = $2 AND user_id = $3 AND is_active = TRUE
+		RETURNING id, user_id, name, dosage, times, start_date, end_date, is_active, missed_streak, created_at
+	`
+
+	var medication Medication
+	err = s.db.GetContext(ctx, &medication, query, pq.Array(normalized), medicationID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMedicationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении времени приема: %v", err)
+	}
+
+	return &medication, nil
+}
+
+func (s *Service) StopMedication(ctx context.Context, userID, medicationID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE medications SET is_active = FALSE WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+	`, medicationID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при остановке курса: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrMedicationNotFound
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE medication_doses SET status = 'skipped' WHERE medication_id = $1 AND status = 'pending'
+	`, medicationID)
+	if err != nil {
+		return fmt.Errorf("ошибка при закрытии ожидающих приемов: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) MarkDose(ctx context.Context, userID, doseID int64, taken bool) (*Dose, error) {
+	status := "skipped"
+	if taken {
+		status = "taken"
+	}
+
+	query := `
+		UPDATE medication_doses d
+		SET status = $1, taken_at = CASE WHEN $1 = 'taken' THEN NOW() ELSE NULL END
+		FROM medications m
+		WHERE d.id = $2 AND d.user_id = $3 AND m.id = d.medication_id AND d.status IN ('pending', 'missed')
+		RETURNING d.id, d.medication_id, d.user_id, d.scheduled_at, d.status, d.taken_at, m.name
+	`
+
+	var dose Dose
+	err := s.db.GetContext(ctx, &dose, query, status, doseID, userID)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := s.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM medication_doses WHERE id = $1 AND user_id = $2)`, doseID, userID); err == nil && exists {
+			return nil, ErrDoseAlreadyLogged
+		}
+		return nil, ErrDoseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отметке приема: %v", err)
+	}
+
+	if taken {
+		s.resetMissedStreak(ctx, dose.MedicationID)
+	}
+
+	return &dose, nil
+}
+
+func (s *Service) LogTaken(ctx context.Context, userID, medicationID int64) (*Dose, error) {
+	query := `
+		UPDATE medication_doses d
+		SET status = 'taken', taken_at = NOW()
+		FROM medications m
+		WHERE d.id = (
+				SELECT id FROM medication_doses
+				WHERE medication_id = $1 AND user_id = $2 AND status IN ('pending', 'missed')
+					AND scheduled_at BETWEEN NOW() - INTERVAL '6 hours' AND NOW() + INTERVAL '3 hours'
+				ORDER BY ABS(EXTRACT(EPOCH FROM scheduled_at - NOW()))
+				LIMIT 1
+			)
+			AND m.id = d.medication_id
+		RETURNING d.id, d.medication_id, d.user_id, d.scheduled_at, d.status, d.taken_at, m.name
+	`
+
+	var dose Dose
+	err := s.db.GetContext(ctx, &dose, query, medicationID, userID)
+	if err == sql.ErrNoRows {
+		err = s.db.GetContext(ctx, &dose, `
+			WITH inserted AS (
+				INSERT INTO medication_doses (medication_id, user_id, scheduled_at, status, taken_at)
+				SELECT id, user_id, date_trunc('minute', NOW()), 'taken', NOW()
+				FROM medications
+				WHERE id = $1 AND user_id = $2
+				ON CONFLICT (medication_id, scheduled_at) DO UPDATE SET status = 'taken', taken_at = NOW()
+				RETURNING id, medication_id, user_id, scheduled_at, status, taken_at
+			)
+			SELECT i.id, i.medication_id, i.user_id, i.scheduled_at, i.status, i.taken_at, m.name
+			FROM inserted i
+			JOIN medications m ON m.id = i.medication_id
+		`, medicationID, userID)
+		if err == sql.ErrNoRows {
+			return nil, ErrMedicationNotFound
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отметке приема: %v", err)
+	}
+
+	s.resetMissedStreak(ctx, medicationID)
+
+	return &dose, nil
+}
+
+func (s *Service) resetMissedStreak(ctx context.Context, medicationID int64) {
+	s.db.ExecContext(ctx, `UPDATE medications SET missed_streak = 0 WHERE id = $1`, medicationID)
+}
+
+func (s *Service) GetAdherence(ctx context.Context, userID int64, since time.Time) ([]Adherence, error) {
+	query := `
+		SELECT m.id AS medication_id, m.name,
+			COUNT(*) FILTER (WHERE d.status = 'taken') AS taken,
+			COUNT(*) FILTER (WHERE d.status = 'skipped') AS skipped,
+			COUNT(*) FILTER (WHERE d.status = 'missed') AS missed,
+			COUNT(d.id) FILTER (WHERE d.status <> 'pending') AS total
+		FROM medications m
+		JOIN medication_doses d ON d.medication_id = m.id
+		WHERE m.user_id = $1 AND d.scheduled_at >= $2
+		GROUP BY m.id, m.name
+		ORDER BY m.name
+	`
+
+	var stats []Adherence
+	err := s.db.SelectContext(ctx, &stats, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при расчете соблюдения приема: %v", err)
+	}
+
+	return stats, nil
+}
+
+func AdherenceIcon(rate float64) string {
+	switch {
+	case rate >= 90:
+		return "🟢"
+	case rate >= 70:
+		return "🟡"
+	default:
+		return "🔴"
+	}
+}
+
+func FormatAdherence(stats []Adherence) string {
+	var b strings.Builder
+	for _, a := range stats {
+		if a.Total == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s %s: %.0f%% (принято %d из %d", AdherenceIcon(a.Rate()), a.Name, a.Rate(), a.Taken, a.Total))
+		if a.Missed > 0 {
+			b.WriteString(fmt.Sprintf(", пропущено %d", a.Missed))
+		}
+		b.WriteString(")\n")
+	}
+	return b.String()
+}
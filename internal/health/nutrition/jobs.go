@@ -0,0 +1,102 @@
+package nutrition
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (s *Service) StartNutritionJobs(jm *jobs.Manager, sendMessage func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"nutrition_daily_score",
+		Spec:		"10 0 * * *",
+		Run:		s.scoreYesterday,
+	})
+
+	jm.Register(jobs.Job{
+		Name:		"nutrition_weekly_summary",
+		Spec:		"0 20 * * 0",
+		Run: func(ctx context.Context) {
+			s.sendWeeklySummaries(ctx, sendMessage)
+		},
+	})
+
+	logrus.Info("Запущены задачи учета питания")
+}
+
+func (s *Service) scoreYesterday(ctx context.Context) {
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+
+	var rows []struct {
+		Target
+		Calories	int	`db:"calories"`
+	}
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT t.user_id, t.daily_calories, t.protein, t.key_result_id, t.last_scored_on,
+			COALESCE((SELECT SUM(calories) FROM meals m WHERE m.user_id = t.user_id AND m.eaten_at::date = $1::date), 0) AS calories
+		FROM nutrition_targets t
+		WHERE t.last_scored_on IS NULL OR t.last_scored_on < $1::date
+	`, yesterday)
+	if err != nil {
+		logrus.Errorf("Ошибка при подсчете дней в пределах нормы калорий: %v", err)
+		return
+	}
+
+	for _, row := range rows {
+		within := row.WithinTarget(row.Calories)
+
+		if row.Calories > 0 {
+			_, err := s.db.ExecContext(ctx, `
+				INSERT INTO habit_tracking (user_id, key_result_id, date, completed, completion_percentage, notes)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, row.UserID, row.KeyResultID, yesterday, within,
+				float64(row.Calories)/float64(row.DailyCalories)*100, fmt.Sprintf("Питание: %d из %d ккал", row.Calories, row.DailyCalories))
+			if err != nil {
+				logrus.Warnf("Не удалось записать день питания пользователя %d: %v", row.UserID, err)
+			}
+		}
+
+		if within && row.KeyResultID != nil {
+			if _, err := s.okr.UpdateKeyResultProgress(ctx, row.UserID, *row.KeyResultID, 1); err != nil {
+				logrus.Errorf("Ошибка при обновлении ключевого результата %d по питанию: %v", *row.KeyResultID, err)
+				continue
+			}
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE nutrition_targets SET last_scored_on = $1 WHERE user_id = $2`, yesterday, row.UserID); err != nil {
+			logrus.Errorf("Ошибка при отметке дня питания пользователя %d: %v", row.UserID, err)
+		}
+	}
+}
+
+func (s *Service) sendWeeklySummaries(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT DISTINCT user_id FROM meals WHERE eaten_at > NOW() - INTERVAL '7 days'
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении пользователей для сводки питания: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		totals, err := s.GetDailyTotals(ctx, userID, 7)
+		if err != nil {
+			logrus.Errorf("Ошибка при расчете сводки питания пользователя %d: %v", userID, err)
+			continue
+		}
+
+		target, err := s.GetTarget(ctx, userID)
+		if err != nil {
+			target = nil
+		}
+
+		text := "🥗 Питание за неделю\n\n" + FormatWeeklySummary(totals, target)
+		if err := sendMessage(userID, text); err != nil {
+			logrus.Errorf("Ошибка при отправке сводки питания пользователю %d: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package nutrition
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/okr"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrMealNotFound		= errors.New("прием пищи не найден")
+	ErrTargetNotSet		= errors.New("дневная норма калорий не задана")
+)
+
+const (
+	SourceText	= "text"
+	SourcePhoto	= "photo"
+)
+
+const targetTolerance = 1.05
+
+type Service struct {
+	db	*sqlx.DB
+	okr	*okr.Service
+}
+
+type Estimate struct {
+	Description	string	`json:"description"`
+	Calories	int	`json:"calories"`
+	Protein		float64	`json:"protein"`
+	Fat		float64	`json:"fat"`
+	Carbs		float64	`json:"carbs"`
+}
+
+type Meal struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Description	string		`db:"description" json:"description"`
+	Calories	int		`db:"calories" json:"calories"`
+	Protein		float64		`db:"protein" json:"protein"`
+	Fat		float64		`db:"fat" json:"fat"`
+	Carbs		float64		`db:"carbs" json:"carbs"`
+	Source		string		`db:"source" json:"source"`
+	EatenAt		time.Time	`db:"eaten_at" json:"eaten_at"`
+}
+
+type Target struct {
+	UserID		int64		`db:"user_id" json:"user_id"`
+	DailyCalories	int		`db:"daily_calories" json:"daily_calories"`
+	Protein		*int		`db:"protein" json:"protein,omitempty"`
+	KeyResultID	*int64		`db:"key_result_id" json:"key_result_id,omitempty"`
+	LastScoredOn	*time.Time	`db:"last_scored_on" json:"-"`
+}
+
+type DayTotal struct {
+	Date		time.Time	`db:"day" json:"date"`
+	Calories	int		`db:"calories" json:"calories"`
+	Protein		float64		`db:"protein" json:"protein"`
+	Fat		float64		`db:"fat" json:"fat"`
+	Carbs		float64		`db:"carbs" json:"carbs"`
+	Meals		int		`db:"meals" json:"meals"`
+}
+
+const mealSelect = `
+	SELECT id, user_id, description, calories, protein, fat, carbs, source, eaten_at
+	FROM meals
+`
+
+func NewService(db *sqlx.DB, okrService *okr.Service) *Service {
+	return &Service{db: db, okr: okrService}
+}
+
+func (e *Estimate) Validate() error {
+	if strings.TrimSpace(e.Description) == "" {
+		return fmt.Errorf("не удалось распознать блюдо")
+	}
+	if e.Calories <= 0 || e.Calories > 5000 {
+		return fmt.Errorf("неправдоподобная оценка калорийности: %d ккал", e.Calories)
+	}
+	if e.Protein < 0 || e.Fat < 0 || e.Carbs < 0 {
+		return fmt.Errorf("отрицательные значения БЖУ")
+	}
+	return nil
+}
+
+func (s *Service) LogMeal(ctx context.Context, userID int64, estimate Estimate, source string, eatenAt time.Time) (*Meal, error) {
+	if err := estimate.Validate(); err != nil {
+		return nil, err
+	}
+	if eatenAt.IsZero() {
+		eatenAt = time.Now()
+	}
+
+	var meal Meal
+	err := s.db.GetContext(ctx, &meal, `
+		INSERT INTO meals (user_id, description, calories, protein, fat, carbs, source, eaten_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, user_id, description, calories, protein, fat, carbs, source, eaten_at
+	`, userID, estimate.Description, estimate.Calories, estimate.Protein, estimate.Fat, estimate.Carbs, source, eatenAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении приема пищи: %v", err)
+	}
+
+	return &meal, nil
+}
+
+func (s *Service) DeleteLastMeal(ctx context.Context, userID int64) (*Meal, error) {
+	var meal Meal
+	err := s.db.GetContext(ctx, &meal, `
+		DELETE FROM meals
+		WHERE id = (SELECT id FROM meals WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1)
+		RETURNING id, user_id, description, calories, protein, fat, carbs, source, eaten_at
+	`, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMealNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при удалении приема пищи: %v", err)
+	}
+	return &meal, nil
+}
+
+func (s *Service) GetMealsForDay(ctx context.Context, userID int64, day time.Time) ([]Meal, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+
+	var meals []Meal
+	err := s.db.SelectContext(ctx, &meals, mealSelect+`
+		WHERE user_id = $1 AND eaten_at >= $2 AND eaten_at < $3
+		ORDER BY eaten_at
+	`, userID, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении приемов пищи: %v", err)
+	}
+	return meals, nil
+}
+
+func (s *Service) GetDailyTotals(ctx context.Context, userID int64, days int) ([]DayTotal, error) {
+	var totals []DayTotal
+	err := s.db.SelectContext(ctx, &totals, `
+		SELECT eaten_at::date AS day, SUM(calories) AS calories, SUM(protein) AS protein,
+			SUM(fat) AS fat, SUM(carbs) AS carbs, COUNT(*) AS meals
+		FROM meals
+		WHERE user_id = $1 AND eaten_at::date > CURRENT_DATE - $2::int
+		GROUP BY eaten_at::date
+		ORDER BY day
+	`, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при расчете калорий по дням: %v", err)
+	}
+	return totals, nil
+}
+
+func (s *Service) SetTarget(ctx context.Context, userID int64, dailyCalories int, protein *int, keyResultID *int64) (*Target, error) {
+	if dailyCalories < 800 || dailyCalories > 6000 {
+		return nil, fmt.Errorf("норма калорий должна быть от 800 до 6000 ккал")
+	}
+	if keyResultID != nil {
+		if _, err := s.okr.GetKeyResultByID(ctx, userID, *keyResultID); err != nil {
+			return nil, err
+		}
+	}
+
+	var target Target
+	err := s.db.GetContext(ctx, &target, `
+		INSERT INTO nutrition_targets (user_id, daily_calories, protein, key_result_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET daily_calories = $2, protein = $3, key_result_id = $4
+		RETURNING user_id, daily_calories, protein, key_result_id, last_scored_on
+	`, userID, dailyCalories, protein, keyResultID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении нормы калорий: %v", err)
+	}
+	return &target, nil
+}
+
+func (s *Service) GetTarget(ctx context.Context, userID int64) (*Target, error) {
+	var target Target
+	err := s.db.GetContext(ctx, &target, `
+		SELECT user_id, daily_calories, protein, key_result_id, last_scored_on
+		FROM nutrition_targets WHERE user_id = $1
+	`, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTargetNotSet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении нормы калорий: %v", err)
+	}
+	return &target, nil
+}
+
+func (t *Target) WithinTarget(calories int) bool {
+	return calories > 0 && float64(calories) <= float64(t.DailyCalories)*targetTolerance
+}
+
+func FormatMeal(meal Meal) string {
+	return fmt.Sprintf("🍽 %s — %d ккал (Б %.0f / Ж %.0f / У %.0f)", meal.Description, meal.Calories, meal.Protein, meal.Fat, meal.Carbs)
+}
+
+func FormatDayProgress(meals []Meal, target *Target) string {
+	var calories int
+	var protein float64
+	for _, m := range meals {
+		calories += m.Calories
+		protein += m.Protein
+	}
+
+	if target == nil {
+		return fmt.Sprintf("📊 За сегодня: %d ккал, белок %.0f г", calories, protein)
+	}
+
+	text := fmt.Sprintf("📊 За сегодня: %d / %d ккал", calories, target.DailyCalories)
+	if left := target.DailyCalories - calories; left >= 0 {
+		text += fmt.Sprintf(" (осталось %d)", left)
+	} else {
+		text += fmt.Sprintf(" ⚠️ превышение на %d", -left)
+	}
+	if target.Protein != nil {
+		text += fmt.Sprintf("\n🥩 Белок: %.0f / %d г", protein, *target.Protein)
+	}
+	return text
+}
+
+func FormatWeeklySummary(totals []DayTotal, target *Target) string {
+	if len(totals) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	var sumCalories int
+	var sumProtein, sumFat, sumCarbs float64
+	within := 0
+	for _, t := range totals {
+		sumCalories += t.Calories
+		sumProtein += t.Protein
+		sumFat += t.Fat
+		sumCarbs += t.Carbs
+
+		icon := "▫️"
+		if target != nil {
+			if target.WithinTarget(t.Calories) {
+				icon = "🟢"
+				within++
+			} else {
+				icon = "🔴"
+			}
+		}
+		b.WriteString(fmt.Sprintf("%s %s: %d ккал\n", icon, t.Date.Format("02.01"), t.Calories))
+	}
+
+	n := float64(len(totals))
+	b.WriteString(fmt.Sprintf("\nВ среднем: %.0f ккал/день, Б %.0f / Ж %.0f / У %.0f г", float64(sumCalories)/n, sumProtein/n, sumFat/n, sumCarbs/n))
+	if target != nil {
+		b.WriteString(fmt.Sprintf("\nВ пределах нормы %d ккал: %d из %d дн.", target.DailyCalories, within, len(totals)))
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	doseReminderWindow	= 30 * time.Minute
+	doseMissedAfter		= 2 * time.Hour
+)
+
+func (s *Service) StartMedicationReminders(jm *jobs.Manager, sendMessage func(chatID int64, text string) error, sendDoseReminder func(chatID int64, text string, doseID int64) error) {
+	jm.Register(jobs.Job{
+		Name:		"medication_reminders",
+		Spec:		"* * * * *",
+		Run: func(ctx context.Context) {
+			s.sendDueDoses(ctx, sendDoseReminder)
+			s.markMissedDoses(ctx)
+			s.escalateMissedDoses(ctx, sendMessage)
+		},
+	})
+
+	logrus.Info("Запущены напоминания о приеме лекарств")
+}
+
+func (s *Service) sendDueDoses(ctx context.Context, sendDoseReminder func(chatID int64, text string, doseID int64) error) {
+	var medications []Medication
+	err := s.db.SelectContext(ctx, &medications, medicationSelect+`
+		WHERE is_active = TRUE AND start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении лекарств для напоминаний: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, medication := range medications {
+		for _, at := range medication.Times {
+			t, err := time.Parse("15:04", at)
+			if err != nil {
+				continue
+			}
+
+			scheduled := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+			if scheduled.After(now) || now.Sub(scheduled) > doseReminderWindow {
+				continue
+			}
+
+			var doseID int64
+			err = s.db.GetContext(ctx, &doseID, `
+				INSERT INTO medication_doses (medication_id, user_id, scheduled_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (medication_id, scheduled_at) DO NOTHING
+				RETURNING id
+			`, medication.ID, medication.UserID, scheduled)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				logrus.Errorf("Ошибка при создании приема лекарства %d: %v", medication.ID, err)
+				continue
+			}
+
+			text := fmt.Sprintf("💊 Время принять %s\n🕐 %s", medication.Label(), at)
+			if err := sendDoseReminder(medication.UserID, text, doseID); err != nil {
+				logrus.Errorf("Ошибка при отправке напоминания о приеме %d пользователю %d: %v", doseID, medication.UserID, err)
+			}
+		}
+	}
+}
+
+func (s *Service) markMissedDoses(ctx context.Context) {
+	query := `
+		WITH missed AS (
+			UPDATE medication_doses
+			SET status = 'missed'
+			WHERE status = 'pending' AND scheduled_at < $1
+			RETURNING medication_id
+		)
+		UPDATE medications m
+		SET missed_streak = m.missed_streak + c.cnt
+		FROM (SELECT medication_id, COUNT(*) AS cnt FROM missed GROUP BY medication_id) c
+		WHERE m.id = c.medication_id
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, time.Now().Add(-doseMissedAfter)); err != nil {
+		logrus.Errorf("Ошибка при отметке пропущенных приемов: %v", err)
+	}
+}
+
+func (s *Service) escalateMissedDoses(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	var medications []Medication
+	err := s.db.SelectContext(ctx, &medications, medicationSelect+`
+		WHERE is_active = TRUE AND missed_streak >= $1
+			AND (last_escalated_at IS NULL OR last_escalated_at < NOW() - INTERVAL '24 hours')
+	`, missedEscalationThreshold)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении пропущенных курсов: %v", err)
+		return
+	}
+
+	for _, medication := range medications {
+		text := fmt.Sprintf("⚠️ %s — пропущено приемов подряд: %d\n\n"+
+			"Регулярность важна для эффекта курса. Если время приема неудобное — скажите, и я перенесу напоминания. "+
+			"Если курс закончился — попросите его остановить.", medication.Label(), medication.MissedStreak)
+
+		if err := sendMessage(medication.UserID, text); err != nil {
+			logrus.Errorf("Ошибка при отправке предупреждения о пропусках пользователю %d: %v", medication.UserID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE medications SET last_escalated_at = NOW() WHERE id = $1`, medication.ID); err != nil {
+			logrus.Errorf("Ошибка при обновлении времени предупреждения для лекарства %d: %v", medication.ID, err)
+		}
+	}
+}
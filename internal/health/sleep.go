@@ -0,0 +1,170 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SleepSourceManual	= "manual"
+	SleepSourceIntegration	= "integration"
+
+	typicalWakeWindowDays	= 14
+	typicalWakeMinSamples	= 3
+	maxSleepDuration	= 16 * time.Hour
+)
+
+type SleepLog struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	SleepDate	time.Time	`db:"sleep_date" json:"sleep_date"`
+	BedTime		time.Time	`db:"bed_time" json:"bed_time"`
+	WakeTime	time.Time	`db:"wake_time" json:"wake_time"`
+	DurationMinutes	int		`db:"duration_minutes" json:"duration_minutes"`
+	Quality		*int		`db:"quality" json:"quality,omitempty"`
+	Source		string		`db:"source" json:"source"`
+}
+
+const sleepSelect = `
+	SELECT id, user_id, sleep_date, bed_time, wake_time, duration_minutes, quality, source
+	FROM sleep_logs
+`
+
+func ResolveSleepWindow(bed, wake string, now time.Time) (time.Time, time.Time, error) {
+	bedClock, err := time.Parse("15:04", strings.TrimSpace(bed))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("некорректное время отхода ко сну: %s", bed)
+	}
+	wakeClock, err := time.Parse("15:04", strings.TrimSpace(wake))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("некорректное время пробуждения: %s", wake)
+	}
+
+	wakeTime := time.Date(now.Year(), now.Month(), now.Day(), wakeClock.Hour(), wakeClock.Minute(), 0, 0, now.Location())
+	if wakeTime.After(now) {
+		wakeTime = wakeTime.AddDate(0, 0, -1)
+	}
+
+	bedTime := time.Date(wakeTime.Year(), wakeTime.Month(), wakeTime.Day(), bedClock.Hour(), bedClock.Minute(), 0, 0, now.Location())
+	if !bedTime.Before(wakeTime) {
+		bedTime = bedTime.AddDate(0, 0, -1)
+	}
+
+	return bedTime, wakeTime, nil
+}
+
+func (s *Service) LogSleep(ctx context.Context, userID int64, bedTime, wakeTime time.Time, quality int, source string) (*SleepLog, error) {
+	duration := wakeTime.Sub(bedTime)
+	if duration <= 0 {
+		return nil, fmt.Errorf("время пробуждения должно быть позже времени отхода ко сну")
+	}
+	if duration > maxSleepDuration {
+		return nil, fmt.Errorf("слишком длинный сон: %s", FormatSleepDuration(int(duration.Minutes())))
+	}
+	if quality < 0 || quality > 5 {
+		return nil, fmt.Errorf("оценка качества сна должна быть от 1 до 5")
+	}
+	if source == "" {
+		source = SleepSourceManual
+	}
+
+	var qualityValue *int
+	if quality > 0 {
+		qualityValue = &quality
+	}
+
+	var log SleepLog
+	err := s.db.GetContext(ctx, &log, `
+		INSERT INTO sleep_logs (user_id, sleep_date, bed_time, wake_time, duration_minutes, quality, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, sleep_date) DO UPDATE
+		SET bed_time = EXCLUDED.bed_time,
+			wake_time = EXCLUDED.wake_time,
+			duration_minutes = EXCLUDED.duration_minutes,
+			quality = COALESCE(EXCLUDED.quality, sleep_logs.quality),
+			source = EXCLUDED.source
+		RETURNING id, user_id, sleep_date, bed_time, wake_time, duration_minutes, quality, source
+	`, userID, wakeTime.Format("2006-01-02"), bedTime, wakeTime, int(duration.Minutes()), qualityValue, source)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении сна: %v", err)
+	}
+
+	return &log, nil
+}
+
+func (s *Service) GetSleepLogs(ctx context.Context, userID int64, since time.Time) ([]SleepLog, error) {
+	var logs []SleepLog
+	err := s.db.SelectContext(ctx, &logs, sleepSelect+`
+		WHERE user_id = $1 AND sleep_date >= $2
+		ORDER BY sleep_date DESC
+	`, userID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении записей сна: %v", err)
+	}
+	return logs, nil
+}
+
+func (s *Service) GetSleepForDate(ctx context.Context, userID int64, date time.Time) (*SleepLog, error) {
+	logs, err := s.GetSleepLogs(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		if log.SleepDate.Format("2006-01-02") == date.Format("2006-01-02") {
+			return &log, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Service) TypicalWakeTime(ctx context.Context, userID int64) (time.Duration, bool, error) {
+	logs, err := s.GetSleepLogs(ctx, userID, time.Now().AddDate(0, 0, -typicalWakeWindowDays))
+	if err != nil {
+		return 0, false, err
+	}
+	if len(logs) < typicalWakeMinSamples {
+		return 0, false, nil
+	}
+
+	offsets := make([]time.Duration, 0, len(logs))
+	for _, log := range logs {
+		local := log.WakeTime.In(time.Local)
+		offsets = append(offsets, time.Duration(local.Hour())*time.Hour+time.Duration(local.Minute())*time.Minute)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	return offsets[len(offsets)/2], true, nil
+}
+
+func FormatSleepDuration(minutes int) string {
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d ч", minutes/60)
+	}
+	return fmt.Sprintf("%d ч %d мин", minutes/60, minutes%60)
+}
+
+func FormatSleepLog(log *SleepLog) string {
+	text := fmt.Sprintf("%s–%s, %s", log.BedTime.In(time.Local).Format("15:04"), log.WakeTime.In(time.Local).Format("15:04"), FormatSleepDuration(log.DurationMinutes))
+	if log.Quality != nil {
+		text += fmt.Sprintf(", качество %d/5", *log.Quality)
+	}
+	return text
+}
+
+func FormatSleepSummary(logs []SleepLog) string {
+	if len(logs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	total := 0
+	for _, log := range logs {
+		total += log.DurationMinutes
+		b.WriteString(fmt.Sprintf("• %s: %s\n", log.SleepDate.Format("02.01"), FormatSleepLog(&log)))
+	}
+
+	return fmt.Sprintf("😴 В среднем: **%s** за ночь\n\n", FormatSleepDuration(total/len(logs))) + strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,220 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SourceVoice	= "voice"
+	SourceText	= "text"
+
+	StatusNew		= "new"
+	StatusTask		= "task"
+	StatusEvent		= "event"
+	StatusNote		= "note"
+	StatusDismissed		= "dismissed"
+
+	DefaultMaxVoiceSeconds	= 60
+	DefaultTriageHour	= 19
+	TriageBatchSize		= 10
+)
+
+var (
+	ErrItemNotFound		= errors.New("заметка не найдена")
+	ErrItemProcessed	= errors.New("заметка уже разобрана")
+	ErrEmptyItem		= errors.New("пустая заметка")
+	ErrInvalidTriageHour	= errors.New("час разбора должен быть от 0 до 23")
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type Item struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Text		string		`db:"text" json:"text"`
+	Source		string		`db:"source" json:"source"`
+	Status		string		`db:"status" json:"status"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	ProcessedAt	*time.Time	`db:"processed_at" json:"processed_at,omitempty"`
+}
+
+type Settings struct {
+	UserID		int64	`db:"user_id" json:"user_id"`
+	QuickCapture	bool	`db:"quick_capture" json:"quick_capture"`
+	MaxVoiceSeconds	int	`db:"max_voice_seconds" json:"max_voice_seconds"`
+	TriageHour	int	`db:"triage_hour" json:"triage_hour"`
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) GetSettings(ctx context.Context, userID int64) (*Settings, error) {
+	settings := Settings{
+		UserID:			userID,
+		MaxVoiceSeconds:	DefaultMaxVoiceSeconds,
+		TriageHour:		DefaultTriageHour,
+	}
+	err := s.db.GetContext(ctx, &settings, `
+		SELECT user_id, quick_capture, max_voice_seconds, triage_hour
+		FROM inbox_settings
+		WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при получении настроек инбокса: %v", err)
+	}
+	return &settings, nil
+}
+
+func (s *Service) SetQuickCapture(ctx context.Context, userID int64, enabled bool, triageHour *int) (*Settings, error) {
+	if triageHour != nil && (*triageHour < 0 || *triageHour > 23) {
+		return nil, ErrInvalidTriageHour
+	}
+
+	var settings Settings
+	err := s.db.GetContext(ctx, &settings, `
+		INSERT INTO inbox_settings (user_id, quick_capture, triage_hour)
+		VALUES ($1, $2, COALESCE($3, $4))
+		ON CONFLICT (user_id) DO UPDATE SET
+			quick_capture = EXCLUDED.quick_capture,
+			triage_hour = COALESCE($3, inbox_settings.triage_hour),
+			updated_at = NOW()
+		RETURNING user_id, quick_capture, max_voice_seconds, triage_hour
+	`, userID, enabled, triageHour, DefaultTriageHour)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении настроек инбокса: %v", err)
+	}
+	return &settings, nil
+}
+
+func (s *Service) Add(ctx context.Context, userID int64, text, source string) (*Item, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyItem
+	}
+
+	var item Item
+	err := s.db.GetContext(ctx, &item, `
+		INSERT INTO inbox_items (user_id, text, source)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, text, source, status, created_at, processed_at
+	`, userID, text, source)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении заметки в инбокс: %v", err)
+	}
+	return &item, nil
+}
+
+func (s *Service) GetNew(ctx context.Context, userID int64, limit int) ([]Item, error) {
+	items := make([]Item, 0)
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT id, user_id, text, source, status, created_at, processed_at
+		FROM inbox_items
+		WHERE user_id = $1 AND status = $2
+		ORDER BY created_at
+		LIMIT $3
+	`, userID, StatusNew, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении заметок инбокса: %v", err)
+	}
+	return items, nil
+}
+
+func (s *Service) CountNew(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := s.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM inbox_items WHERE user_id = $1 AND status = $2`, userID, StatusNew)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете заметок инбокса: %v", err)
+	}
+	return count, nil
+}
+
+func (s *Service) GetNewItem(ctx context.Context, userID, itemID int64) (*Item, error) {
+	var item Item
+	err := s.db.GetContext(ctx, &item, `
+		SELECT id, user_id, text, source, status, created_at, processed_at
+		FROM inbox_items
+		WHERE id = $1 AND user_id = $2
+	`, itemID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении заметки инбокса: %v", err)
+	}
+	if item.Status != StatusNew {
+		return nil, ErrItemProcessed
+	}
+	return &item, nil
+}
+
+func (s *Service) MarkProcessed(ctx context.Context, userID, itemID int64, status string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE inbox_items SET status = $3, processed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = $4
+	`, itemID, userID, status, StatusNew)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении заметки инбокса: %v", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrItemProcessed
+	}
+	return nil
+}
+
+func (s *Service) StartTriage(jm *jobs.Manager, sendTriage func(userID int64, items []Item, total int) error) {
+	jm.Register(jobs.Job{
+		Name:	"inbox_triage",
+		Spec:	"0 * * * *",
+		Run: func(ctx context.Context) {
+			s.sendDueTriage(ctx, sendTriage)
+		},
+	})
+
+	logrus.Info("Запущен ежедневный разбор инбокса")
+}
+
+func (s *Service) sendDueTriage(ctx context.Context, sendTriage func(userID int64, items []Item, total int) error) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		UPDATE inbox_settings st SET last_triage_date = CURRENT_DATE
+		WHERE st.quick_capture = TRUE
+		  AND st.triage_hour = EXTRACT(HOUR FROM NOW())
+		  AND (st.last_triage_date IS NULL OR st.last_triage_date < CURRENT_DATE)
+		  AND EXISTS (SELECT 1 FROM inbox_items i WHERE i.user_id = st.user_id AND i.status = $1)
+		RETURNING st.user_id
+	`, StatusNew)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе пользователей для разбора инбокса: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		total, err := s.CountNew(ctx, userID)
+		if err != nil {
+			logrus.Errorf("Ошибка при подготовке разбора инбокса пользователя %d: %v", userID, err)
+			continue
+		}
+		items, err := s.GetNew(ctx, userID, TriageBatchSize)
+		if err != nil {
+			logrus.Errorf("Ошибка при подготовке разбора инбокса пользователя %d: %v", userID, err)
+			continue
+		}
+		if err := sendTriage(userID, items, total); err != nil {
+			logrus.Errorf("Ошибка при отправке разбора инбокса пользователю %d: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const maxDeliveryFailures = 10
+
+type deliveryItem struct {
+	at	time.Time
+	payload	interface{}
+}
+
+func (s *Service) StartWebhookDelivery(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:		"integration_webhooks",
+		Spec:		"@every 1m",
+		Run: func(ctx context.Context) {
+			s.deliverWebhooks(ctx)
+		},
+	})
+
+	logrus.Info("Запущена доставка вебхуков интеграций")
+}
+
+func (s *Service) deliverWebhooks(ctx context.Context) {
+	var hooks []Webhook
+	err := s.db.SelectContext(ctx, &hooks, `
+		SELECT id, web_user_id, event, target_url, cursor_at, failures, is_active, created_at
+		FROM integration_webhooks
+		WHERE is_active = TRUE
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении подписок интеграций: %v", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if err := s.deliverHook(ctx, hook); err != nil {
+			logrus.Warnf("Ошибка доставки вебхука %d (%s): %v", hook.ID, hook.Event, err)
+		}
+	}
+}
+
+func (s *Service) pendingItems(ctx context.Context, hook Webhook) ([]deliveryItem, error) {
+	var telegramIDs pq.Int64Array
+	err := s.db.GetContext(ctx, &telegramIDs, `SELECT COALESCE(telegram_ids, '{}') FROM web_users WHERE id = $1`, hook.WebUserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении Telegram аккаунтов: %v", err)
+	}
+	if len(telegramIDs) == 0 {
+		return nil, nil
+	}
+
+	var items []deliveryItem
+	switch hook.Event {
+	case EventObjectiveCreated:
+		objectives, err := s.NewObjectives(ctx, telegramIDs, hook.CursorAt, defaultPollLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objectives {
+			items = append(items, deliveryItem{at: o.CreatedAt, payload: o})
+		}
+	case EventTaskCompleted:
+		tasks, err := s.CompletedTasks(ctx, telegramIDs, hook.CursorAt, defaultPollLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			items = append(items, deliveryItem{at: t.CompletedAt, payload: t})
+		}
+	case EventTransactionCreated:
+		transactions, err := s.NewTransactions(ctx, telegramIDs, hook.CursorAt, defaultPollLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range transactions {
+			items = append(items, deliveryItem{at: t.CreatedAt, payload: t})
+		}
+	default:
+		return nil, ErrUnknownEvent
+	}
+
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items, nil
+}
+
+func (s *Service) deliverHook(ctx context.Context, hook Webhook) error {
+	items, err := s.pendingItems(ctx, hook)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		status, err := s.post(ctx, hook.TargetURL, hook.Event, item.payload)
+		if status == http.StatusGone {
+			_, err := s.db.ExecContext(ctx, `UPDATE integration_webhooks SET is_active = FALSE WHERE id = $1`, hook.ID)
+			return err
+		}
+		if err != nil {
+			_, dbErr := s.db.ExecContext(ctx, `
+				UPDATE integration_webhooks
+				SET failures = failures + 1, is_active = failures + 1 < $2
+				WHERE id = $1
+			`, hook.ID, maxDeliveryFailures)
+			if dbErr != nil {
+				logrus.Errorf("Ошибка при учете неудачной доставки вебхука %d: %v", hook.ID, dbErr)
+			}
+			return err
+		}
+
+		_, err = s.db.ExecContext(ctx, `
+			UPDATE integration_webhooks SET cursor_at = $2, failures = 0 WHERE id = $1
+		`, hook.ID, item.at)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении позиции вебхука: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) post(ctx context.Context, targetURL, event string, payload interface{}) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сериализации события: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания запроса: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Jarvis-Event", event)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка отправки вебхука: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("вебхук вернул статус %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,189 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	EventObjectiveCreated	= "objective.created"
+	EventTaskCompleted	= "task.completed"
+	EventTransactionCreated	= "transaction.created"
+
+	defaultPollLimit	= 50
+	maxPollLimit		= 100
+)
+
+var (
+	ErrUnknownEvent		= errors.New("неизвестное событие")
+	ErrInvalidTargetURL	= errors.New("некорректный адрес вебхука")
+	ErrWebhookNotFound	= errors.New("подписка не найдена")
+)
+
+var Events = []string{EventObjectiveCreated, EventTaskCompleted, EventTransactionCreated}
+
+type Service struct {
+	db		*sqlx.DB
+	httpClient	*http.Client
+}
+
+type ObjectiveItem struct {
+	ID		string		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"telegram_id"`
+	Title		string		`db:"title" json:"title"`
+	Sphere		string		`db:"sphere" json:"sphere"`
+	Period		string		`db:"period" json:"period"`
+	Deadline	*time.Time	`db:"deadline" json:"deadline,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type TaskItem struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"telegram_id"`
+	Title		string		`db:"title" json:"title"`
+	KeyResultID	int64		`db:"key_result_id" json:"key_result_id"`
+	KeyResultTitle	string		`db:"key_result_title" json:"key_result_title"`
+	ObjectiveID	string		`db:"objective_id" json:"objective_id"`
+	ObjectiveTitle	string		`db:"objective_title" json:"objective_title"`
+	Target		float64		`db:"target" json:"target"`
+	Unit		string		`db:"unit" json:"unit"`
+	Progress	float64		`db:"progress" json:"progress"`
+	CompletedAt	time.Time	`db:"completed_at" json:"completed_at"`
+}
+
+type TransactionItem struct {
+	ID		string		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"telegram_id"`
+	Amount		float64		`db:"amount" json:"amount"`
+	Details		string		`db:"details" json:"details"`
+	Category	string		`db:"category" json:"category"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type Webhook struct {
+	ID		int64		`db:"id" json:"id"`
+	WebUserID	int64		`db:"web_user_id" json:"-"`
+	Event		string		`db:"event" json:"event"`
+	TargetURL	string		`db:"target_url" json:"target_url"`
+	CursorAt	time.Time	`db:"cursor_at" json:"-"`
+	Failures	int		`db:"failures" json:"-"`
+	IsActive	bool		`db:"is_active" json:"is_active"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{
+		db:		db,
+		httpClient:	&http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPollLimit
+	}
+	if limit > maxPollLimit {
+		return maxPollLimit
+	}
+	return limit
+}
+
+func (s *Service) NewObjectives(ctx context.Context, telegramIDs []int64, since time.Time, limit int) ([]ObjectiveItem, error) {
+	items := make([]ObjectiveItem, 0)
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT id, user_id, title, COALESCE(sphere, '') AS sphere, period, deadline, created_at
+		FROM objectives
+		WHERE user_id = ANY($1) AND deleted_at IS NULL AND created_at > $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, pq.Array(telegramIDs), since, normalizeLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении новых целей: %v", err)
+	}
+	return items, nil
+}
+
+func (s *Service) CompletedTasks(ctx context.Context, telegramIDs []int64, since time.Time, limit int) ([]TaskItem, error) {
+	items := make([]TaskItem, 0)
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT t.id, o.user_id, t.title, t.key_result_id, kr.title AS key_result_title,
+			o.id AS objective_id, o.title AS objective_title, t.target, t.unit, t.progress,
+			t.completion_date AS completed_at
+		FROM tasks t
+		JOIN key_results kr ON kr.id = t.key_result_id
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = ANY($1) AND t.deleted_at IS NULL AND t.completion_date IS NOT NULL AND t.completion_date > $2
+		ORDER BY t.completion_date DESC
+		LIMIT $3
+	`, pq.Array(telegramIDs), since, normalizeLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении выполненных задач: %v", err)
+	}
+	return items, nil
+}
+
+func (s *Service) NewTransactions(ctx context.Context, telegramIDs []int64, since time.Time, limit int) ([]TransactionItem, error) {
+	items := make([]TransactionItem, 0)
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT id, user_id, amount, COALESCE(details, '') AS details, COALESCE(category, '') AS category, created_at
+		FROM transactions
+		WHERE user_id = ANY($1) AND created_at > $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, pq.Array(telegramIDs), since, normalizeLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении новых транзакций: %v", err)
+	}
+	return items, nil
+}
+
+func isKnownEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) Subscribe(ctx context.Context, webUserID int64, event, targetURL string) (*Webhook, error) {
+	if !isKnownEvent(event) {
+		return nil, ErrUnknownEvent
+	}
+	parsed, err := url.Parse(targetURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, ErrInvalidTargetURL
+	}
+
+	var hook Webhook
+	err = s.db.GetContext(ctx, &hook, `
+		INSERT INTO integration_webhooks (web_user_id, event, target_url)
+		VALUES ($1, $2, $3)
+		RETURNING id, web_user_id, event, target_url, cursor_at, failures, is_active, created_at
+	`, webUserID, event, targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании подписки: %v", err)
+	}
+	return &hook, nil
+}
+
+func (s *Service) Unsubscribe(ctx context.Context, webUserID, hookID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE integration_webhooks SET is_active = FALSE
+		WHERE id = $1 AND web_user_id = $2 AND is_active = TRUE
+	`, hookID, webUserID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении подписки: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(e.interval)
+}
+
+type cronSchedule struct {
+	minutes		map[int]bool
+	hours		map[int]bool
+	days		map[int]bool
+	months		map[int]bool
+	weekdays	map[int]bool
+	anyDay		bool
+	anyWeekday	bool
+}
+
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("некорректный интервал в расписании %q: %v", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("интервал в расписании %q слишком мал", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("расписание %q должно содержать 5 полей: минута час день месяц день_недели", spec)
+	}
+
+	minutes, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("минуты: %v", err)
+	}
+	hours, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("часы: %v", err)
+	}
+	days, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("дни месяца: %v", err)
+	}
+	months, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("месяцы: %v", err)
+	}
+	weekdays, err := parseCronField(fields[4], 0, 7)
+	if err != nil {
+		return nil, fmt.Errorf("дни недели: %v", err)
+	}
+	if weekdays[7] {
+		weekdays[0] = true
+		delete(weekdays, 7)
+	}
+
+	return &cronSchedule{
+		minutes:	minutes,
+		hours:		hours,
+		days:		days,
+		months:		months,
+		weekdays:	weekdays,
+		anyDay:		fields[2] == "*",
+		anyWeekday:	fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, stepStr, ok := strings.Cut(part, "/"); ok {
+			s, err := strconv.Atoi(stepStr)
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("некорректный шаг %q", part)
+			}
+			step = s
+			part = base
+		}
+
+		start, end := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			from, to, _ := strings.Cut(part, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("некорректный диапазон %q", part)
+			}
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("некорректный диапазон %q", part)
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("некорректное значение %q", part)
+			}
+			start, end = v, v
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("значение %q вне диапазона %d-%d", part, min, max)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dayOK := c.days[t.Day()]
+	weekdayOK := c.weekdays[int(t.Weekday())]
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayOK
+	case c.anyWeekday:
+		return dayOK
+	default:
+		return dayOK || weekdayOK
+	}
+}
+
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !c.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func ParseOverrides(value string) map[string]string {
+	overrides := make(map[string]string)
+
+	for _, entry := range strings.Split(value, ";") {
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		spec = strings.TrimSpace(spec)
+		if name != "" && spec != "" {
+			overrides[name] = spec
+		}
+	}
+
+	return overrides
+}
@@ -0,0 +1,269 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Job struct {
+	Name		string
+	Spec		string
+	Jitter		time.Duration
+	RunOnStart	bool
+	Run		func(ctx context.Context)
+}
+
+type JobStats struct {
+	Name		string		`json:"name"`
+	Spec		string		`json:"spec"`
+	Runs		int64		`json:"runs"`
+	Failures	int64		`json:"failures"`
+	Running		bool		`json:"running"`
+	LastStart	*time.Time	`json:"last_start,omitempty"`
+	LastDuration	time.Duration	`json:"last_duration"`
+	TotalDuration	time.Duration	`json:"total_duration"`
+	NextRun		*time.Time	`json:"next_run,omitempty"`
+}
+
+type Manager struct {
+	ctx		context.Context
+	cancel		context.CancelFunc
+	wg		sync.WaitGroup
+	mu		sync.Mutex
+	overrides	map[string]string
+	stats		map[string]*JobStats
+	stopped		bool
+	paused		bool
+}
+
+func NewManager(overrides map[string]string) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	if overrides == nil {
+		overrides = make(map[string]string)
+	}
+	return &Manager{
+		ctx:		ctx,
+		cancel:		cancel,
+		overrides:	overrides,
+		stats:		make(map[string]*JobStats),
+	}
+}
+
+func (m *Manager) Register(job Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		logrus.Warnf("Фоновая задача %s не запущена: менеджер уже остановлен", job.Name)
+		return
+	}
+
+	if _, exists := m.stats[job.Name]; exists {
+		logrus.Warnf("Фоновая задача %s уже зарегистрирована", job.Name)
+		return
+	}
+
+	if override, ok := m.overrides[job.Name]; ok {
+		job.Spec = override
+	}
+
+	schedule, err := ParseSchedule(job.Spec)
+	if err != nil {
+		logrus.Errorf("Фоновая задача %s не запущена: некорректное расписание: %v", job.Name, err)
+		return
+	}
+
+	m.stats[job.Name] = &JobStats{Name: job.Name, Spec: job.Spec}
+	m.wg.Add(1)
+
+	go m.loop(job, schedule)
+
+	logrus.Infof("Запущена фоновая задача %s (расписание %s)", job.Name, job.Spec)
+}
+
+func (m *Manager) Stats() []JobStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]JobStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func (m *Manager) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Stats()); err != nil {
+		logrus.Errorf("Ошибка при сериализации статистики фоновых задач: %v", err)
+	}
+}
+
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("Все фоновые задачи остановлены")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("не все фоновые задачи успели завершиться: %v", ctx.Err())
+	}
+}
+
+func (m *Manager) Pause(ctx context.Context) error {
+	m.mu.Lock()
+	m.paused = true
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		running := m.runningJobs()
+		if len(running) == 0 {
+			logrus.Info("Фоновые задачи приостановлены")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("фоновые задачи приостановлены, но еще выполняются: %v", running)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) Resume() {
+	m.mu.Lock()
+	m.paused = false
+	m.mu.Unlock()
+
+	logrus.Info("Фоновые задачи возобновлены")
+}
+
+func (m *Manager) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+func (m *Manager) runningJobs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var running []string
+	for name, s := range m.stats {
+		if s.Running {
+			running = append(running, name)
+		}
+	}
+	sort.Strings(running)
+	return running
+}
+
+func (m *Manager) loop(job Job, schedule Schedule) {
+	defer m.wg.Done()
+
+	if job.RunOnStart {
+		m.runOnce(job)
+	}
+
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			logrus.Warnf("Для фоновой задачи %s нет следующего запуска по расписанию %s", job.Name, job.Spec)
+			return
+		}
+		if job.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(job.Jitter))))
+		}
+		m.setNextRun(job.Name, next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-m.ctx.Done():
+			timer.Stop()
+			logrus.Infof("Фоновая задача %s остановлена", job.Name)
+			return
+		case <-timer.C:
+			m.runOnce(job)
+		}
+	}
+}
+
+func (m *Manager) runOnce(job Job) {
+	if m.ctx.Err() != nil {
+		return
+	}
+
+	start := time.Now()
+	m.mu.Lock()
+	if m.paused {
+		m.mu.Unlock()
+		logrus.Debugf("Фоновая задача %s пропущена: задачи приостановлены", job.Name)
+		return
+	}
+	stats := m.stats[job.Name]
+	stats.Running = true
+	stats.LastStart = &start
+	m.mu.Unlock()
+
+	failed := false
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				failed = true
+				logrus.Errorf("Паника в фоновой задаче %s: %v", job.Name, r)
+			}
+		}()
+		job.Run(m.ctx)
+	}()
+
+	duration := time.Since(start)
+
+	m.mu.Lock()
+	stats.Running = false
+	stats.Runs++
+	stats.LastDuration = duration
+	stats.TotalDuration += duration
+	if failed {
+		stats.Failures++
+	}
+	m.mu.Unlock()
+
+	logrus.Debugf("Фоновая задача %s выполнена за %s", job.Name, duration)
+}
+
+func (m *Manager) setNextRun(name string, next time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stats, ok := m.stats[name]; ok {
+		stats.NextRun = &next
+	}
+}
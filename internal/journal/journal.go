@@ -0,0 +1,253 @@
+package journal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SourcePrompt	= "prompt"
+	SourceCommand	= "command"
+
+	DefaultPromptHour	= 21
+	PromptQuestion		= "что получилось сегодня?"
+
+	maxEntryRunes		= 4000
+	promptLateHours		= 3
+	summaryMinEntries	= 2
+)
+
+var (
+	ErrEmptyEntry		= errors.New("запись в дневнике не может быть пустой")
+	ErrInvalidPromptHour	= errors.New("час вечернего вопроса должен быть от 0 до 23")
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type Entry struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"-"`
+	EntryDate	time.Time	`db:"entry_date" json:"entry_date"`
+	Text		string		`db:"text" json:"text"`
+	Source		string		`db:"source" json:"source"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type Settings struct {
+	UserID		int64	`db:"user_id"`
+	PromptHour	int	`db:"prompt_hour"`
+	Enabled		bool	`db:"enabled"`
+}
+
+type Summary struct {
+	UserID		int64		`db:"user_id"`
+	WeekStart	time.Time	`db:"week_start"`
+	Summary		string		`db:"summary"`
+	EntryCount	int		`db:"entry_count"`
+	CreatedAt	time.Time	`db:"created_at"`
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) AddEntry(ctx context.Context, userID int64, date time.Time, text, source string) (*Entry, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyEntry
+	}
+	if runes := []rune(text); len(runes) > maxEntryRunes {
+		text = string(runes[:maxEntryRunes])
+	}
+
+	var entry Entry
+	err := s.db.GetContext(ctx, &entry, `
+		INSERT INTO journal_entries (user_id, entry_date, text, source)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, entry_date, text, source, created_at
+	`, userID, date.Format("2006-01-02"), text, source)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении записи в дневнике: %v", err)
+	}
+	return &entry, nil
+}
+
+func (s *Service) GetEntries(ctx context.Context, userID int64, from, to time.Time, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	entries := []Entry{}
+	err := s.db.SelectContext(ctx, &entries, `
+		SELECT id, user_id, entry_date, text, source, created_at
+		FROM journal_entries
+		WHERE user_id = $1 AND entry_date >= $2 AND entry_date <= $3
+		ORDER BY entry_date DESC, created_at DESC
+		LIMIT $4
+	`, userID, from.Format("2006-01-02"), to.Format("2006-01-02"), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении записей дневника: %v", err)
+	}
+	return entries, nil
+}
+
+func (s *Service) SearchEntries(ctx context.Context, userID int64, query string, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	entries := []Entry{}
+	err := s.db.SelectContext(ctx, &entries, `
+		SELECT id, user_id, entry_date, text, source, created_at
+		FROM journal_entries
+		WHERE user_id = $1 AND text ILIKE '%' || $2 || '%'
+		ORDER BY entry_date DESC, created_at DESC
+		LIMIT $3
+	`, userID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске в дневнике: %v", err)
+	}
+	return entries, nil
+}
+
+func (s *Service) GetSettings(ctx context.Context, userID int64) (*Settings, error) {
+	settings := Settings{UserID: userID, PromptHour: DefaultPromptHour}
+	err := s.db.GetContext(ctx, &settings, `
+		SELECT user_id, prompt_hour, enabled FROM journal_settings WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при получении настроек дневника: %v", err)
+	}
+	return &settings, nil
+}
+
+func (s *Service) SetSettings(ctx context.Context, userID int64, hour int, enabled bool) error {
+	if hour < 0 || hour > 23 {
+		return ErrInvalidPromptHour
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO journal_settings (user_id, prompt_hour, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET prompt_hour = $2, enabled = $3, updated_at = NOW()
+	`, userID, hour, enabled)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении настроек дневника: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) StartEveningPrompt(jm *jobs.Manager, sendPrompt func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"journal_evening_prompt",
+		Spec:	"*/5 * * * *",
+		Run: func(ctx context.Context) {
+			s.sendEveningPrompts(ctx, sendPrompt)
+		},
+	})
+
+	logrus.Info("Запущены вечерние вопросы дневника")
+}
+
+func (s *Service) sendEveningPrompts(ctx context.Context, sendPrompt func(chatID int64, text string) error) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		UPDATE journal_settings js
+		SET last_prompted_on = $1
+		WHERE js.enabled AND js.prompt_hour <= $2 AND js.prompt_hour > $2 - $3
+			AND js.last_prompted_on IS DISTINCT FROM $1
+			AND NOT EXISTS (SELECT 1 FROM journal_entries je WHERE je.user_id = js.user_id AND je.entry_date = $1)
+		RETURNING js.user_id
+	`, today, now.Hour(), promptLateHours)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе пользователей для вечернего вопроса: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if err := sendPrompt(userID, FormatPrompt()); err != nil {
+			logrus.Errorf("Ошибка при отправке вечернего вопроса пользователю %d: %v", userID, err)
+		}
+	}
+}
+
+func (s *Service) UsersForWeeklySummary(ctx context.Context, weekStart time.Time) ([]int64, error) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT je.user_id
+		FROM journal_entries je
+		WHERE je.entry_date >= $1 AND je.entry_date < $2
+			AND NOT EXISTS (SELECT 1 FROM journal_summaries js WHERE js.user_id = je.user_id AND js.week_start = $1)
+		GROUP BY je.user_id
+		HAVING COUNT(*) >= $3
+	`, weekStart.Format("2006-01-02"), weekStart.AddDate(0, 0, 7).Format("2006-01-02"), summaryMinEntries)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при выборе дневников для итогов недели: %v", err)
+	}
+	return userIDs, nil
+}
+
+func (s *Service) SaveSummary(ctx context.Context, userID int64, weekStart time.Time, summary string, entryCount int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO journal_summaries (user_id, week_start, summary, entry_count)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, week_start) DO UPDATE SET summary = $3, entry_count = $4, created_at = NOW()
+	`, userID, weekStart.Format("2006-01-02"), summary, entryCount)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении итогов недели: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) GetSummaries(ctx context.Context, userID int64, limit int) ([]Summary, error) {
+	summaries := []Summary{}
+	err := s.db.SelectContext(ctx, &summaries, `
+		SELECT user_id, week_start, summary, entry_count, created_at
+		FROM journal_summaries
+		WHERE user_id = $1
+		ORDER BY week_start DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении итогов недели: %v", err)
+	}
+	return summaries, nil
+}
+
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func FormatPrompt() string {
+	return "🌙 Вечерний дневник: " + PromptQuestion + "\n\nОтветьте на это сообщение — запишу в дневник. Пара строк о том, что удалось, что порадовало и чему научились"
+}
+
+func FormatEntries(entries []Entry) string {
+	if len(entries) == 0 {
+		return "📓 В дневнике пока нет записей за этот период"
+	}
+	var b strings.Builder
+	b.WriteString("📓 **Записи дневника:**\n")
+	current := ""
+	for _, entry := range entries {
+		day := entry.EntryDate.Format("02.01.2006")
+		if day != current {
+			b.WriteString("\n📅 **" + day + "**\n")
+			current = day
+		}
+		b.WriteString("• " + entry.Text + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
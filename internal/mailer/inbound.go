@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+)
+
+const maxMultipartDepth = 5
+
+type InboundMessage struct {
+	From		string
+	Subject		string
+	Calendars	[]string
+}
+
+func ParseInbound(r io.Reader) (*InboundMessage, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при разборе письма: %v", err)
+	}
+
+	inbound := &InboundMessage{Subject: decodeHeader(msg.Header.Get("Subject"))}
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		inbound.From = strings.ToLower(from.Address)
+	}
+
+	err = collectCalendars(inbound, msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+	return inbound, nil
+}
+
+func collectCalendars(inbound *InboundMessage, contentType, encoding string, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMultipartDepth || params["boundary"] == "" {
+			return nil
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("ошибка при разборе вложений письма: %v", err)
+			}
+			err = collectCalendars(inbound, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/calendar" && mediaType != "application/ics" {
+		return nil
+	}
+
+	data, err := io.ReadAll(decodeBody(encoding, body))
+	if err != nil {
+		return fmt.Errorf("ошибка при чтении календаря из письма: %v", err)
+	}
+	inbound.Calendars = append(inbound.Calendars, string(data))
+	return nil
+}
+
+func decodeBody(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
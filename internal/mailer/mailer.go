@@ -0,0 +1,216 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"telegrambot/pkg/config"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sendTimeout	= 30 * time.Second
+	base64LineLen	= 76
+)
+
+var ErrNotConfigured = errors.New("отправка почты не настроена (SMTP_HOST, SMTP_FROM)")
+
+type Client struct {
+	host		string
+	port		string
+	username	string
+	password	string
+	from		string
+}
+
+type Message struct {
+	To		string
+	Subject		string
+	Text		string
+	Calendar	[]byte
+	CalendarMethod	string
+}
+
+func NewClient(cfg *config.Config) *Client {
+	return &Client{
+		host:		cfg.SMTPHost,
+		port:		cfg.SMTPPort,
+		username:	cfg.SMTPUsername,
+		password:	cfg.SMTPPassword,
+		from:		cfg.SMTPFrom,
+	}
+}
+
+func (c *Client) Enabled() bool {
+	return c.host != "" && c.from != ""
+}
+
+func (c *Client) From() string {
+	if address, err := mail.ParseAddress(c.from); err == nil {
+		return address.Address
+	}
+	return c.from
+}
+
+func (c *Client) Send(ctx context.Context, msg Message) error {
+	if !c.Enabled() {
+		return ErrNotConfigured
+	}
+
+	body, err := c.build(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	address := net.JoinHostPort(c.host, c.port)
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if c.port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: c.host}}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при подключении к SMTP серверу: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("ошибка при подключении к SMTP серверу: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return fmt.Errorf("ошибка при включении TLS: %v", err)
+		}
+	}
+	if c.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return fmt.Errorf("ошибка авторизации на SMTP сервере: %v", err)
+		}
+	}
+
+	if err := client.Mail(c.From()); err != nil {
+		return fmt.Errorf("ошибка при отправке письма: %v", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("адрес %s отклонен сервером: %v", msg.To, err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке письма: %v", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("ошибка при отправке письма: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("ошибка при отправке письма: %v", err)
+	}
+	return client.Quit()
+}
+
+func (c *Client) build(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	domain := "localhost"
+	if _, host, ok := strings.Cut(c.From(), "@"); ok {
+		domain = host
+	}
+
+	header := func(key, value string) { fmt.Fprintf(&buf, "%s: %s\r\n", key, value) }
+	header("From", c.from)
+	header("To", msg.To)
+	header("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", uuid.New().String(), domain))
+	header("MIME-Version", "1.0")
+
+	if len(msg.Calendar) == 0 {
+		header("Content-Type", "text/plain; charset=UTF-8")
+		header("Content-Transfer-Encoding", "base64")
+		buf.WriteString("\r\n")
+		writeBase64(&buf, []byte(msg.Text))
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	var alternativeBody bytes.Buffer
+	alternative := multipart.NewWriter(&alternativeBody)
+	if err := writePart(alternative, "text/plain; charset=UTF-8", nil, []byte(msg.Text)); err != nil {
+		return nil, err
+	}
+	calendarType := "text/calendar; charset=UTF-8; method=" + msg.CalendarMethod
+	if err := writePart(alternative, calendarType, nil, msg.Calendar); err != nil {
+		return nil, err
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(alternativeBody.Bytes()); err != nil {
+		return nil, err
+	}
+	attachment := textproto.MIMEHeader{"Content-Disposition": {`attachment; filename="invite.ics"`}}
+	if err := writePart(mixed, `application/ics; name="invite.ics"`, attachment, msg.Calendar); err != nil {
+		return nil, err
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writePart(w *multipart.Writer, contentType string, extra textproto.MIMEHeader, data []byte) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":			{contentType},
+		"Content-Transfer-Encoding":	{"base64"},
+	}
+	for key, values := range extra {
+		header[key] = values
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании письма: %v", err)
+	}
+	var buf bytes.Buffer
+	writeBase64(&buf, data)
+	_, err = part.Write(buf.Bytes())
+	return err
+}
+
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > base64LineLen {
+		buf.WriteString(encoded[:base64LineLen])
+		buf.WriteString("\r\n")
+		encoded = encoded[base64LineLen:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+}
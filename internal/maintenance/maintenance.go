@@ -0,0 +1,202 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultMessage		= "🛠 Jarvis на техническом обслуживании. Скоро вернусь и сам напишу, когда все заработает"
+	AvailableMessage	= "✅ Техническое обслуживание завершено, Jarvis снова на связи. Если ваше сообщение осталось без ответа — пришлите его еще раз"
+
+	pauseTimeout	= 30 * time.Second
+)
+
+type State struct {
+	Enabled		bool		`db:"enabled" json:"enabled"`
+	Message		string		`db:"message" json:"message"`
+	StartedAt	*time.Time	`db:"started_at" json:"started_at,omitempty"`
+	EndsAt		*time.Time	`db:"ends_at" json:"ends_at,omitempty"`
+}
+
+type Service struct {
+	db		*sqlx.DB
+	jobs		*jobs.Manager
+	send		func(chatID int64, text string) error
+	mu		sync.RWMutex
+	state		State
+	affected	map[int64]bool
+}
+
+func NewService(db *sqlx.DB, jm *jobs.Manager) *Service {
+	return &Service{db: db, jobs: jm, affected: make(map[int64]bool)}
+}
+
+func (s *Service) Start(ctx context.Context, send func(chatID int64, text string) error) error {
+	s.send = send
+
+	var state State
+	err := s.db.GetContext(ctx, &state, `
+		SELECT enabled, COALESCE(message, '') AS message, started_at, ends_at
+		FROM maintenance_state WHERE id = 1
+	`)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при загрузке режима обслуживания: %v", err)
+	}
+
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+
+	if state.Enabled {
+		logrus.Warn("Бот запущен в режиме технического обслуживания")
+		pauseCtx, cancel := context.WithTimeout(ctx, pauseTimeout)
+		defer cancel()
+		return s.jobs.Pause(pauseCtx)
+	}
+	return nil
+}
+
+func (s *Service) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+func (s *Service) Notice() (string, bool) {
+	state := s.State()
+	if !state.Enabled {
+		return "", false
+	}
+
+	notice := state.Message
+	if notice == "" {
+		notice = DefaultMessage
+	}
+	if state.EndsAt != nil && state.EndsAt.After(time.Now()) {
+		notice += fmt.Sprintf("\n\n⏰ Ожидаемое окончание: %s", state.EndsAt.Local().Format("02.01 15:04"))
+	}
+	return notice, true
+}
+
+func (s *Service) Enable(ctx context.Context, message string, endsAt *time.Time) (State, error) {
+	now := time.Now()
+	state := State{Enabled: true, Message: strings.TrimSpace(message), StartedAt: &now, EndsAt: endsAt}
+
+	s.mu.Lock()
+	if s.state.Enabled && s.state.StartedAt != nil {
+		state.StartedAt = s.state.StartedAt
+	}
+	s.state = state
+	s.mu.Unlock()
+
+	if err := s.save(ctx, state); err != nil {
+		logrus.Errorf("Режим обслуживания включен, но не сохранен в базе: %v", err)
+	}
+
+	pauseCtx, cancel := context.WithTimeout(ctx, pauseTimeout)
+	defer cancel()
+	if err := s.jobs.Pause(pauseCtx); err != nil {
+		logrus.Warnf("Режим обслуживания включен: %v", err)
+	}
+
+	logrus.Warnf("Включен режим технического обслуживания до %v", endsAt)
+	return state, nil
+}
+
+func (s *Service) Disable(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	wasEnabled := s.state.Enabled
+	s.state = State{}
+	affected := make([]int64, 0, len(s.affected))
+	for userID := range s.affected {
+		affected = append(affected, userID)
+	}
+	s.affected = make(map[int64]bool)
+	s.mu.Unlock()
+
+	if err := s.save(ctx, State{}); err != nil {
+		return 0, err
+	}
+	s.jobs.Resume()
+
+	if !wasEnabled {
+		return 0, nil
+	}
+	logrus.Info("Режим технического обслуживания выключен")
+
+	var stored []int64
+	if err := s.db.SelectContext(ctx, &stored, `DELETE FROM maintenance_affected_users RETURNING user_id`); err != nil {
+		logrus.Errorf("Ошибка при получении пользователей, писавших во время обслуживания: %v", err)
+	}
+	recipients := uniqueIDs(append(affected, stored...))
+
+	announced := 0
+	for _, userID := range recipients {
+		if err := s.send(userID, AvailableMessage); err != nil {
+			logrus.Errorf("Ошибка при уведомлении пользователя %d о завершении обслуживания: %v", userID, err)
+			continue
+		}
+		announced++
+	}
+	return announced, nil
+}
+
+func (s *Service) RecordAffected(ctx context.Context, userID int64) {
+	s.mu.Lock()
+	known := s.affected[userID]
+	s.affected[userID] = true
+	s.mu.Unlock()
+	if known {
+		return
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO maintenance_affected_users (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID)
+	if err != nil {
+		logrus.Warnf("Не удалось сохранить пользователя %d для уведомления после обслуживания: %v", userID, err)
+	}
+}
+
+func (s *Service) save(ctx context.Context, state State) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO maintenance_state (id, enabled, message, started_at, ends_at, updated_at)
+		VALUES (1, $1, NULLIF($2, ''), $3, $4, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			message = EXCLUDED.message,
+			started_at = EXCLUDED.started_at,
+			ends_at = EXCLUDED.ends_at,
+			updated_at = NOW()
+	`, state.Enabled, state.Message, state.StartedAt, state.EndsAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении режима обслуживания: %v", err)
+	}
+	return nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package meetings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"time"
+)
+
+const (
+	availabilityStep	= 30 * time.Minute
+	availabilitySlotsPerDay	= 2
+	maxAvailabilityRange	= 31 * 24 * time.Hour
+)
+
+var (
+	ErrParticipantNotFound	= errors.New("пользователь не найден: он должен хотя бы раз написать боту")
+	ErrSelfAvailability	= errors.New("нельзя искать общее время с самим собой")
+	ErrInvalidWorkingHours	= errors.New("некорректные рабочие часы")
+	ErrInvalidSearchRange	= errors.New("некорректный период поиска: конец должен быть позже начала и не дальше 31 дня")
+)
+
+type AvailabilityRequest struct {
+	From			time.Time
+	To			time.Time
+	Duration		time.Duration
+	WorkFrom		time.Duration
+	WorkTo			time.Duration
+	IncludeWeekends		bool
+	Limit			int
+}
+
+type Availability struct {
+	Participant	*User
+	Duration	time.Duration
+	Slots		[]time.Time
+}
+
+func (s *Service) FindMutualAvailability(ctx context.Context, initiatorID int64, participantUsername string, req AvailabilityRequest) (*Availability, error) {
+	participant, err := s.GetUserByUsername(ctx, strings.TrimPrefix(participantUsername, "@"))
+	if err != nil {
+		return nil, ErrParticipantNotFound
+	}
+	if participant.ID == initiatorID {
+		return nil, ErrSelfAvailability
+	}
+
+	if req.Duration <= 0 {
+		req.Duration = time.Hour
+	}
+	if req.Limit <= 0 {
+		req.Limit = 5
+	}
+	if req.WorkFrom == 0 && req.WorkTo == 0 {
+		req.WorkFrom, req.WorkTo = 9*time.Hour, 18*time.Hour
+	}
+	if req.WorkFrom < 0 || req.WorkTo > 24*time.Hour || req.WorkTo-req.WorkFrom < req.Duration {
+		return nil, ErrInvalidWorkingHours
+	}
+	if !req.To.After(req.From) || req.To.Sub(req.From) > maxAvailabilityRange {
+		return nil, ErrInvalidSearchRange
+	}
+
+	initiatorBusy, err := calendar.FindBusySlots(ctx, s.db, initiatorID, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	participantBusy, err := calendar.FindBusySlots(ctx, s.db, participant.ID, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	busy := append(initiatorBusy, participantBusy...)
+
+	availability := &Availability{Participant: participant, Duration: req.Duration}
+	now := time.Now()
+	day := time.Date(req.From.Year(), req.From.Month(), req.From.Day(), 0, 0, 0, 0, req.From.Location())
+	for ; day.Before(req.To) && len(availability.Slots) < req.Limit; day = day.AddDate(0, 0, 1) {
+		if !req.IncludeWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		perDay := 0
+		dayEnd := day.Add(req.WorkTo)
+		for at := day.Add(req.WorkFrom); !at.Add(req.Duration).After(dayEnd); at = at.Add(availabilityStep) {
+			if perDay >= availabilitySlotsPerDay || len(availability.Slots) >= req.Limit {
+				break
+			}
+			end := at.Add(req.Duration)
+			if at.Before(req.From) || at.Before(now) || end.After(req.To) || overlapsBusy(at, end, busy) {
+				continue
+			}
+			availability.Slots = append(availability.Slots, at)
+			busy = append(busy, calendar.BusySlot{Start: at, End: end})
+			perDay++
+		}
+	}
+	return availability, nil
+}
+
+func overlapsBusy(start, end time.Time, busy []calendar.BusySlot) bool {
+	for _, slot := range busy {
+		if start.Before(slot.End) && end.After(slot.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+func FormatAvailability(availability *Availability) string {
+	name := "@" + availability.Participant.Username
+	if len(availability.Slots) == 0 {
+		return fmt.Sprintf("Общего свободного времени с %s в этом промежутке не нашлось. Попробуйте расширить период или рабочие часы", name)
+	}
+	return fmt.Sprintf("🤝 Общее свободное время с %s (%d мин):\n%s", name, int(availability.Duration.Minutes()),
+		calendar.FormatFreeSlots(availability.Slots, availability.Duration))
+}
@@ -2,15 +2,30 @@ package meetings
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/worklocation"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrMeetingNotFound	= errors.New("встреча не найдена")
+	ErrNotParticipant	= errors.New("вы не являетесь участником этой встречи")
+	ErrAlreadyResponded	= errors.New("на приглашение уже дан ответ")
+	ErrParticipantBusy	= errors.New("участник занят в это время")
 )
 
 type Service struct {
-	db *sqlx.DB
+	db		*sqlx.DB
+	locations	*worklocation.Service
 }
 
 type Meeting struct {
@@ -22,9 +37,18 @@ type Meeting struct {
 	StartTime	time.Time	`db:"start_time"`
 	EndTime		time.Time	`db:"end_time"`
 	Confirmed	bool		`db:"confirmed"`
+	Status		string		`db:"status"`
+	InPerson	bool		`db:"in_person"`
+	RespondedAt	*time.Time	`db:"responded_at"`
 	CreatedAt	time.Time	`db:"created_at"`
 }
 
+type MeetingDetails struct {
+	Meeting
+	InitiatorName	string	`db:"initiator_name"`
+	ParticipantName	string	`db:"participant_name"`
+}
+
 type User struct {
 	ID		int64		`db:"id"`
 	Username	string		`db:"username"`
@@ -35,7 +59,8 @@ type User struct {
 
 func NewService(db *sqlx.DB) *Service {
 	return &Service{
-		db: db,
+		db:		db,
+		locations:	worklocation.NewService(db),
 	}
 }
 
@@ -71,7 +96,7 @@ func (s *Service) GetUserByUsername(ctx context.Context, username string) (*User
 	return &user, nil
 }
 
-func (s *Service) CreateMeeting(ctx context.Context, initiatorID int64, participantUsername, title, description, startTimeStr, endTimeStr string) (string, error) {
+func (s *Service) CreateMeeting(ctx context.Context, initiatorID int64, participantUsername, title, description, startTimeStr, endTimeStr string, inPerson, allowOverlap bool) (string, error) {
 
 	participant, err := s.GetUserByUsername(ctx, participantUsername)
 	if err != nil {
@@ -88,14 +113,26 @@ func (s *Service) CreateMeeting(ctx context.Context, initiatorID int64, particip
 		return "", fmt.Errorf("неверный формат времени окончания: %v", err)
 	}
 
+	if inPerson {
+		if err := s.checkInPersonAvailability(ctx, initiatorID, participant, startTime); err != nil {
+			return "", err
+		}
+	}
+
+	if !allowOverlap {
+		if err := s.checkFreeBusy(ctx, initiatorID, participant, startTime, endTime); err != nil {
+			return "", err
+		}
+	}
+
 	meetingID := uuid.New().String()
 
 	query := `
-		INSERT INTO meetings (id, initiator_id, participant_id, title, description, start_time, end_time, confirmed, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO meetings (id, initiator_id, participant_id, title, description, start_time, end_time, confirmed, in_person, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
-	_, err = s.db.ExecContext(ctx, query, meetingID, initiatorID, participant.ID, title, description, startTime, endTime, false, time.Now())
+	_, err = s.db.ExecContext(ctx, query, meetingID, initiatorID, participant.ID, title, description, startTime, endTime, false, inPerson, time.Now())
 	if err != nil {
 		return "", fmt.Errorf("ошибка при сохранении встречи: %v", err)
 	}
@@ -103,6 +140,45 @@ func (s *Service) CreateMeeting(ctx context.Context, initiatorID int64, particip
 	return meetingID, nil
 }
 
+func (s *Service) checkInPersonAvailability(ctx context.Context, initiatorID int64, participant *User, date time.Time) error {
+	remote, err := s.locations.CheckInPersonAvailability(ctx, initiatorID, date)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		return fmt.Errorf("%s вы работаете не в офисе (%s), очная встреча невозможна", date.Format("02.01"), worklocation.LocationLabel(remote.Location))
+	}
+
+	remote, err = s.locations.CheckInPersonAvailability(ctx, participant.ID, date)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		return fmt.Errorf("%s @%s работает не в офисе (%s), очная встреча невозможна", date.Format("02.01"), participant.Username, worklocation.LocationLabel(remote.Location))
+	}
+
+	return nil
+}
+
+func (s *Service) checkFreeBusy(ctx context.Context, initiatorID int64, participant *User, start, end time.Time) error {
+	conflicts, err := calendar.FindBusySlots(ctx, s.db, initiatorID, start, end)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &calendar.ConflictError{Conflicts: conflicts}
+	}
+
+	busy, err := calendar.FindBusySlots(ctx, s.db, participant.ID, start, end)
+	if err != nil {
+		return err
+	}
+	if len(busy) > 0 {
+		return fmt.Errorf("%w: @%s, %s–%s", ErrParticipantBusy, participant.Username, start.Local().Format("02.01 15:04"), end.Local().Format("15:04"))
+	}
+	return nil
+}
+
 func parseFlexibleTime(timeStr string) (time.Time, error) {
 
 	t, err := time.Parse(time.RFC3339, timeStr)
@@ -155,7 +231,7 @@ func (s *Service) ConfirmMeeting(ctx context.Context, meetingID string, particip
 
 	updateQuery := `
 		UPDATE meetings
-		SET confirmed = true
+		SET confirmed = true, status = 'accepted', responded_at = NOW(), initiator_notified = false
 		WHERE id = $1
 	`
 
@@ -169,9 +245,10 @@ func (s *Service) ConfirmMeeting(ctx context.Context, meetingID string, particip
 
 func (s *Service) GetPendingMeetings(ctx context.Context, userID int64) ([]Meeting, error) {
 	query := `
-		SELECT id, initiator_id, participant_id, title, description, start_time, end_time, confirmed, created_at
+		SELECT id, initiator_id, participant_id, title, COALESCE(description, '') AS description,
+			start_time, end_time, confirmed, status, responded_at, created_at
 		FROM meetings
-		WHERE participant_id = $1 AND confirmed = false
+		WHERE participant_id = $1 AND status = 'pending'
 		ORDER BY start_time ASC
 	`
 
@@ -199,3 +276,160 @@ func (s *Service) GetInitiator(ctx context.Context, initiatorID int64) (*User, e
 
 	return &user, nil
 }
+
+const meetingDetailsSelect = `
+	SELECT m.id, m.initiator_id, m.participant_id, m.title, COALESCE(m.description, '') AS description,
+		m.start_time, m.end_time, m.confirmed, m.status, m.in_person, m.responded_at, m.created_at,
+		COALESCE(NULLIF(i.first_name, ''), i.username, '') AS initiator_name,
+		COALESCE(NULLIF(p.first_name, ''), p.username, '') AS participant_name
+	FROM meetings m
+	LEFT JOIN users i ON i.id = m.initiator_id
+	LEFT JOIN users p ON p.id = m.participant_id
+`
+
+func (s *Service) GetMeetingByID(ctx context.Context, meetingID string) (*MeetingDetails, error) {
+	var meeting MeetingDetails
+	err := s.db.GetContext(ctx, &meeting, meetingDetailsSelect+` WHERE m.id = $1`, meetingID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMeetingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении встречи: %v", err)
+	}
+
+	return &meeting, nil
+}
+
+func (s *Service) GetMeetingsForUsers(ctx context.Context, userIDs []int64, status string) ([]MeetingDetails, error) {
+	query := meetingDetailsSelect + `
+		WHERE (m.initiator_id = ANY($1) OR m.participant_id = ANY($1))
+			AND ($2::text = '' OR m.status = $2)
+		ORDER BY m.start_time ASC
+	`
+
+	var meetings []MeetingDetails
+	err := s.db.SelectContext(ctx, &meetings, query, pq.Array(userIDs), status)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении встреч: %v", err)
+	}
+
+	return meetings, nil
+}
+
+func (s *Service) RespondToMeeting(ctx context.Context, meetingID string, participantID int64, accept bool) (*MeetingDetails, error) {
+	meeting, err := s.GetMeetingByID(ctx, meetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if meeting.ParticipantID != participantID {
+		return nil, ErrNotParticipant
+	}
+
+	if meeting.Status != "pending" {
+		return nil, ErrAlreadyResponded
+	}
+
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+
+	query := `
+		UPDATE meetings
+		SET status = $1, confirmed = $2, responded_at = NOW(), initiator_notified = false
+		WHERE id = $3 AND status = 'pending'
+	`
+
+	result, err := s.db.ExecContext(ctx, query, status, accept, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении статуса встречи: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении количества обновленных строк: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil, ErrAlreadyResponded
+	}
+
+	meeting.Status = status
+	meeting.Confirmed = accept
+
+	return meeting, nil
+}
+
+func (s *Service) StartInviteNotifier(jm *jobs.Manager, sendInvite func(chatID int64, text string, meetingID string) error, sendMessage func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"meeting_invites",
+		Spec:		"@every 20s",
+		Run: func(ctx context.Context) {
+			s.notifyParticipants(ctx, sendInvite)
+			s.notifyInitiators(ctx, sendMessage)
+		},
+	})
+}
+
+func (s *Service) notifyParticipants(ctx context.Context, sendInvite func(chatID int64, text string, meetingID string) error) {
+
+	var meetings []MeetingDetails
+	err := s.db.SelectContext(ctx, &meetings, meetingDetailsSelect+`
+		WHERE m.status = 'pending' AND m.participant_notified = false
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении новых приглашений на встречи: %v", err)
+		return
+	}
+
+	for _, meeting := range meetings {
+		message := fmt.Sprintf("📅 %s приглашает вас на встречу «%s»\n🕐 %s — %s",
+			meeting.InitiatorName, meeting.Title, meeting.StartTime.Format("02.01.2006 15:04"), meeting.EndTime.Format("15:04"))
+		if meeting.Description != "" {
+			message += "\n📝 " + meeting.Description
+		}
+
+		if err := sendInvite(meeting.ParticipantID, message, meeting.ID); err != nil {
+			logrus.Errorf("Ошибка при отправке приглашения на встречу пользователю %d: %v", meeting.ParticipantID, err)
+			continue
+		}
+
+		_, err := s.db.ExecContext(ctx, `UPDATE meetings SET participant_notified = true WHERE id = $1`, meeting.ID)
+		if err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса приглашения на встречу: %v", err)
+		}
+	}
+}
+
+func (s *Service) notifyInitiators(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+
+	var meetings []MeetingDetails
+	err := s.db.SelectContext(ctx, &meetings, meetingDetailsSelect+`
+		WHERE m.status IN ('accepted', 'declined') AND m.initiator_notified = false
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении ответов на приглашения: %v", err)
+		return
+	}
+
+	for _, meeting := range meetings {
+		var message string
+		if meeting.Status == "accepted" {
+			message = fmt.Sprintf("✅ %s принял(а) приглашение на встречу «%s» (%s)",
+				meeting.ParticipantName, meeting.Title, meeting.StartTime.Format("02.01.2006 15:04"))
+		} else {
+			message = fmt.Sprintf("❌ %s отклонил(а) приглашение на встречу «%s» (%s)",
+				meeting.ParticipantName, meeting.Title, meeting.StartTime.Format("02.01.2006 15:04"))
+		}
+
+		if err := sendMessage(meeting.InitiatorID, message); err != nil {
+			logrus.Errorf("Ошибка при уведомлении организатора встречи %d: %v", meeting.InitiatorID, err)
+			continue
+		}
+
+		_, err := s.db.ExecContext(ctx, `UPDATE meetings SET initiator_notified = true WHERE id = $1`, meeting.ID)
+		if err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса уведомления организатора: %v", err)
+		}
+	}
+}
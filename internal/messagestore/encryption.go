@@ -0,0 +1,283 @@
+package messagestore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	encryptedPrefix		= "enc:v1:"
+	encryptionSaltSize	= 32
+	encryptionBatchSize	= 500
+)
+
+var (
+	ErrEncryptionUnavailable	= errors.New("шифрование истории не настроено на сервере")
+	ErrEncryptionKeyMissing		= errors.New("ключ шифрования пользователя не найден")
+)
+
+type EncryptionStatus struct {
+	Available	bool
+	Enabled		bool
+	Messages	int
+	Encrypted	int
+}
+
+type userKey struct {
+	Salt	[]byte	`db:"salt"`
+	Enabled	bool	`db:"enabled"`
+}
+
+func parseMasterKey(value string) []byte {
+	if value == "" {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) < 32 {
+		logrus.Error("MESSAGE_ENCRYPTION_KEY должен быть base64-строкой длиной не меньше 32 байт, шифрование истории отключено")
+		return nil
+	}
+	return key
+}
+
+func (r *Repository) EncryptionAvailable() bool {
+	return len(r.masterKey) > 0
+}
+
+func (r *Repository) loadUserKey(ctx context.Context, userID string) (*userKey, error) {
+	var key userKey
+	err := r.db.GetContext(ctx, &key, `SELECT salt, enabled FROM message_encryption_keys WHERE user_identifier = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить ключ шифрования: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *Repository) deriveKey(userID string, salt []byte) []byte {
+	mac := hmac.New(sha256.New, r.masterKey)
+	mac.Write([]byte("messagestore/v1"))
+	mac.Write(salt)
+	mac.Write([]byte(userID))
+	return mac.Sum(nil)
+}
+
+func (r *Repository) sealFor(ctx context.Context, userID, text string) (string, error) {
+	if !r.EncryptionAvailable() || text == "" {
+		return text, nil
+	}
+	key, err := r.loadUserKey(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if key == nil || !key.Enabled {
+		return text, nil
+	}
+	return seal(r.deriveKey(userID, key.Salt), text)
+}
+
+func (r *Repository) opener(ctx context.Context, userID string) (func(string) string, error) {
+	var dataKey []byte
+	if r.EncryptionAvailable() {
+		key, err := r.loadUserKey(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			dataKey = r.deriveKey(userID, key.Salt)
+		}
+	}
+
+	return func(value string) string {
+		if !strings.HasPrefix(value, encryptedPrefix) {
+			return value
+		}
+		if dataKey == nil {
+			return "[зашифровано]"
+		}
+		text, err := open(dataKey, value)
+		if err != nil {
+			logrus.Warnf("Не удалось расшифровать сообщение пользователя %s: %v", userID, err)
+			return "[зашифровано]"
+		}
+		return text
+	}, nil
+}
+
+func (r *Repository) SetEncryption(ctx context.Context, userID string, enabled bool) error {
+	if !r.EncryptionAvailable() {
+		return ErrEncryptionUnavailable
+	}
+
+	if !enabled {
+		_, err := r.db.ExecContext(ctx, `
+			UPDATE message_encryption_keys SET enabled = FALSE, updated_at = NOW() WHERE user_identifier = $1
+		`, userID)
+		if err != nil {
+			return fmt.Errorf("не удалось выключить шифрование: %w", err)
+		}
+		return nil
+	}
+
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("не удалось сгенерировать соль: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO message_encryption_keys (user_identifier, salt)
+		VALUES ($1, $2)
+		ON CONFLICT (user_identifier) DO UPDATE SET enabled = TRUE, updated_at = NOW()
+	`, userID, salt)
+	if err != nil {
+		return fmt.Errorf("не удалось включить шифрование: %w", err)
+	}
+
+	return r.encryptHistory(ctx, userID)
+}
+
+func (r *Repository) encryptHistory(ctx context.Context, userID string) error {
+	key, err := r.loadUserKey(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return ErrEncryptionKeyMissing
+	}
+	dataKey := r.deriveKey(userID, key.Salt)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	type row struct {
+		ID	int	`db:"id"`
+		Text	string	`db:"text"`
+	}
+	tables := []struct{ name, column, filter string }{
+		{"user_messages", "message_text", "user_identifier = $1"},
+		{"ai_responses", "response_text", "user_message_id IN (SELECT id FROM user_messages WHERE user_identifier = $1)"},
+	}
+	for _, table := range tables {
+		for {
+			var rows []row
+			err := tx.SelectContext(ctx, &rows, fmt.Sprintf(`
+				SELECT id, %[2]s AS text FROM %[1]s
+				WHERE %[3]s AND %[2]s <> '' AND %[2]s NOT LIKE '%[4]s%%'
+				LIMIT %[5]d
+			`, table.name, table.column, table.filter, encryptedPrefix, encryptionBatchSize), userID)
+			if err != nil {
+				return fmt.Errorf("не удалось прочитать историю для шифрования: %w", err)
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, m := range rows {
+				sealed, err := seal(dataKey, m.Text)
+				if err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE id = $2`, table.name, table.column), sealed, m.ID); err != nil {
+					return fmt.Errorf("не удалось зашифровать историю: %w", err)
+				}
+			}
+		}
+	}
+
+	var summary string
+	err = tx.GetContext(ctx, &summary, `SELECT summary FROM conversation_summaries WHERE user_identifier = $1`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("не удалось прочитать резюме диалога: %w", err)
+	}
+	if summary != "" && !strings.HasPrefix(summary, encryptedPrefix) {
+		sealed, err := seal(dataKey, summary)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE conversation_summaries SET summary = $1 WHERE user_identifier = $2`, sealed, userID); err != nil {
+			return fmt.Errorf("не удалось зашифровать резюме диалога: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM message_embeddings WHERE user_identifier = $1`, userID); err != nil {
+		return fmt.Errorf("не удалось удалить поисковый индекс истории: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось сохранить зашифрованную историю: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetEncryptionStatus(ctx context.Context, userID string) (*EncryptionStatus, error) {
+	status := &EncryptionStatus{Available: r.EncryptionAvailable()}
+
+	key, err := r.loadUserKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled = key != nil && key.Enabled
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE message_text LIKE $2 || '%')
+		FROM user_messages WHERE user_identifier = $1
+	`, userID, encryptedPrefix).Scan(&status.Messages, &status.Encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось посчитать сообщения: %w", err)
+	}
+	return status, nil
+}
+
+func seal(key []byte, text string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("ошибка шифрования: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("ошибка шифрования: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("ошибка шифрования: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(text), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func open(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("повреждённые данные")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
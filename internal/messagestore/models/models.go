@@ -16,6 +16,7 @@ type AiResponse struct {
 	ID			int		`db:"id" json:"id"`
 	UserMessageID		int		`db:"user_message_id" json:"user_message_id"`
 	ResponseText		string		`db:"response_text" json:"response_text"`
+	FunctionName		string		`db:"function_name" json:"function_name"`
 	PromptTokens		*int		`db:"prompt_tokens" json:"prompt_tokens,omitempty"`
 	CompletionTokens	*int		`db:"completion_tokens" json:"completion_tokens,omitempty"`
 	CreatedAt		time.Time	`db:"created_at" json:"created_at"`
@@ -25,3 +26,34 @@ type MessageHistoryItem struct {
 	Role	string	`json:"role"`
 	Content	string	`json:"content"`
 }
+
+type ResponseRating struct {
+	Function	string	`db:"function_name" json:"function"`
+	Positive	int	`db:"positive" json:"positive"`
+	Negative	int	`db:"negative" json:"negative"`
+}
+
+func (r ResponseRating) Total() int {
+	return r.Positive + r.Negative
+}
+
+func (r ResponseRating) Score() float64 {
+	if r.Total() == 0 {
+		return 0
+	}
+	return float64(r.Positive-r.Negative) / float64(r.Total())
+}
+
+type ConversationSummary struct {
+	UserIdentifier	string		`db:"user_identifier" json:"user_identifier"`
+	Summary		string		`db:"summary" json:"summary"`
+	SummarizedUntil	time.Time	`db:"summarized_until" json:"summarized_until"`
+	MessageCount	int		`db:"message_count" json:"message_count"`
+	UpdatedAt	time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+type TimedMessage struct {
+	Role		string		`db:"role" json:"role"`
+	Content		string		`db:"content" json:"content"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
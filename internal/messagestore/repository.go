@@ -2,21 +2,28 @@ package messagestore
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"telegrambot/internal/messagestore/models"
+	"telegrambot/pkg/config"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
 
+var ErrResponseNotFound = errors.New("ответ не найден")
+
 type Repository struct {
-	db *sqlx.DB
+	db		*sqlx.DB
+	masterKey	[]byte
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
+func NewRepository(db *sqlx.DB, cfg *config.Config) *Repository {
 	return &Repository{
-		db: db,
+		db:		db,
+		masterKey:	parseMasterKey(cfg.MessageEncryptionKey),
 	}
 }
 
@@ -27,8 +34,13 @@ func (r *Repository) StoreUserMessage(ctx context.Context, userID string, messag
 		RETURNING id
 	`
 
+	messageText, err := r.sealFor(ctx, userID, messageText)
+	if err != nil {
+		return 0, err
+	}
+
 	var messageID int
-	err := r.db.GetContext(ctx, &messageID, query, userID, messageText, platform)
+	err = r.db.GetContext(ctx, &messageID, query, userID, messageText, platform)
 	if err != nil {
 		return 0, fmt.Errorf("не удалось сохранить сообщение пользователя: %w", err)
 	}
@@ -36,18 +48,75 @@ func (r *Repository) StoreUserMessage(ctx context.Context, userID string, messag
 	return messageID, nil
 }
 
-func (r *Repository) StoreAiResponse(ctx context.Context, userMessageID int, responseText string, promptTokens, completionTokens *int) error {
+func (r *Repository) StoreAiResponse(ctx context.Context, userMessageID int, responseText, functionName string, promptTokens, completionTokens *int) (int, error) {
 	query := `
-		INSERT INTO ai_responses (user_message_id, response_text, prompt_tokens, completion_tokens, created_at)
-		VALUES ($1, $2, $3, $4, NOW())
+		INSERT INTO ai_responses (user_message_id, response_text, function_name, prompt_tokens, completion_tokens, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id
 	`
 
-	_, err := r.db.ExecContext(ctx, query, userMessageID, responseText, promptTokens, completionTokens)
+	if r.EncryptionAvailable() {
+		var userID string
+		if err := r.db.GetContext(ctx, &userID, `SELECT user_identifier FROM user_messages WHERE id = $1`, userMessageID); err != nil {
+			return 0, fmt.Errorf("не удалось найти сообщение пользователя: %w", err)
+		}
+		sealed, err := r.sealFor(ctx, userID, responseText)
+		if err != nil {
+			return 0, err
+		}
+		responseText = sealed
+	}
+
+	var responseID int
+	err := r.db.GetContext(ctx, &responseID, query, userMessageID, responseText, functionName, promptTokens, completionTokens)
 	if err != nil {
-		return fmt.Errorf("не удалось сохранить ответ ИИ: %w", err)
+		return 0, fmt.Errorf("не удалось сохранить ответ ИИ: %w", err)
 	}
 
-	return nil
+	return responseID, nil
+}
+
+func (r *Repository) RateResponse(ctx context.Context, responseID int, userID int64, rating int) (string, error) {
+	query := `
+		INSERT INTO ai_response_ratings (ai_response_id, user_id, rating, function_name)
+		SELECT ar.id, $2, $3, ar.function_name
+		FROM ai_responses ar
+		JOIN user_messages um ON um.id = ar.user_message_id
+		WHERE ar.id = $1 AND um.user_identifier = $2::text
+		ON CONFLICT (ai_response_id) DO UPDATE SET rating = EXCLUDED.rating, updated_at = NOW()
+		RETURNING function_name
+	`
+
+	var functionName string
+	err := r.db.GetContext(ctx, &functionName, query, responseID, userID, rating)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrResponseNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("не удалось сохранить оценку ответа: %w", err)
+	}
+
+	return functionName, nil
+}
+
+func (r *Repository) GetRatingSummary(ctx context.Context, userID int64, days int) ([]models.ResponseRating, error) {
+	query := `
+		SELECT function_name,
+			COUNT(*) FILTER (WHERE rating > 0) AS positive,
+			COUNT(*) FILTER (WHERE rating < 0) AS negative
+		FROM ai_response_ratings
+		WHERE user_id = $1 AND updated_at > NOW() - make_interval(days => $2)
+		GROUP BY function_name
+		ORDER BY function_name
+	`
+
+	ratings := make([]models.ResponseRating, 0)
+	err := r.db.SelectContext(ctx, &ratings, query, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить оценки ответов: %w", err)
+	}
+
+	return ratings, nil
 }
 
 func (r *Repository) GetMessageHistory(ctx context.Context, userID string) ([]models.MessageHistoryItem, error) {
@@ -88,6 +157,14 @@ func (r *Repository) GetMessageHistory(ctx context.Context, userID string) ([]mo
 		return nil, fmt.Errorf("не удалось получить историю сообщений: %w", err)
 	}
 
+	decrypt, err := r.opener(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		history[i].Content = decrypt(history[i].Content)
+	}
+
 	logrus.Infof("Получено %d элементов истории сообщений для пользователя %s", len(history), userID)
 	return history, nil
 }
@@ -135,14 +212,128 @@ func (r *Repository) GetMessageHistoryChronological(ctx context.Context, userID
 		return nil, fmt.Errorf("не удалось получить хронологическую историю сообщений: %w", err)
 	}
 
+	decrypt, err := r.opener(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	history := make([]models.MessageHistoryItem, len(messagesWithTime))
 	for i, msg := range messagesWithTime {
 		history[i] = models.MessageHistoryItem{
 			Role:		msg.Role,
-			Content:	msg.Content,
+			Content:	decrypt(msg.Content),
 		}
 	}
 
 	logrus.Infof("Получено %d элементов хронологической истории для пользователя %s", len(history), userID)
 	return history, nil
 }
+
+func (r *Repository) GetConversationSummary(ctx context.Context, userID string) (*models.ConversationSummary, error) {
+	query := `
+		SELECT user_identifier, summary, summarized_until, message_count, updated_at
+		FROM conversation_summaries
+		WHERE user_identifier = $1
+	`
+
+	var summary models.ConversationSummary
+	err := r.db.GetContext(ctx, &summary, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить резюме диалога: %w", err)
+	}
+
+	decrypt, err := r.opener(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Summary = decrypt(summary.Summary)
+
+	return &summary, nil
+}
+
+func (r *Repository) SaveConversationSummary(ctx context.Context, userID, summary string, summarizedUntil time.Time, messageCount int) error {
+	query := `
+		INSERT INTO conversation_summaries (user_identifier, summary, summarized_until, message_count)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_identifier) DO UPDATE SET
+			summary = EXCLUDED.summary,
+			summarized_until = EXCLUDED.summarized_until,
+			message_count = conversation_summaries.message_count + EXCLUDED.message_count,
+			updated_at = NOW()
+	`
+
+	summary, err := r.sealFor(ctx, userID, summary)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, userID, summary, summarizedUntil, messageCount)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить резюме диалога: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetMessagesToSummarize(ctx context.Context, userID string, after time.Time, keepRecent, limit int) ([]models.TimedMessage, error) {
+	query := `
+		WITH history AS (
+			SELECT 'user' AS role, um.message_text AS content, um.created_at
+			FROM user_messages um
+			WHERE um.user_identifier = $1
+
+			UNION ALL
+
+			SELECT 'assistant' AS role, ar.response_text AS content, ar.created_at
+			FROM ai_responses ar
+			JOIN user_messages um ON ar.user_message_id = um.id
+			WHERE um.user_identifier = $1
+		),
+		boundary AS (
+			SELECT created_at FROM history ORDER BY created_at DESC LIMIT 1 OFFSET $3
+		)
+		SELECT role, content, created_at
+		FROM history
+		WHERE created_at > $2 AND created_at <= (SELECT created_at FROM boundary)
+		ORDER BY created_at ASC
+		LIMIT $4
+	`
+
+	messages := make([]models.TimedMessage, 0)
+	err := r.db.SelectContext(ctx, &messages, query, userID, after, keepRecent, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить сообщения для резюме: %w", err)
+	}
+
+	decrypt, err := r.opener(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range messages {
+		messages[i].Content = decrypt(messages[i].Content)
+	}
+
+	return messages, nil
+}
+
+func (r *Repository) GetUsersForSummarization(ctx context.Context, minMessages int) ([]string, error) {
+	query := `
+		SELECT um.user_identifier
+		FROM user_messages um
+		LEFT JOIN conversation_summaries cs ON cs.user_identifier = um.user_identifier
+		WHERE um.created_at > COALESCE(cs.summarized_until, 'epoch'::timestamptz)
+		GROUP BY um.user_identifier
+		HAVING COUNT(*) >= $1
+	`
+
+	userIDs := make([]string, 0)
+	err := r.db.SelectContext(ctx, &userIDs, query, minMessages)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пользователей для резюме диалога: %w", err)
+	}
+
+	return userIDs, nil
+}
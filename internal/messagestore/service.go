@@ -3,6 +3,7 @@ package messagestore
 import (
 	"context"
 	"telegrambot/internal/messagestore/models"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -18,16 +19,51 @@ func NewService(repo *Repository) *Service {
 }
 
 func (s *Service) StoreUserMessage(ctx context.Context, userID string, messageText string, platform string) (int, error) {
-	logrus.Debugf("Сохранение сообщения пользователя %s: %s", userID, messageText)
+	logrus.Debugf("Сохранение сообщения пользователя %s (%d символов)", userID, len([]rune(messageText)))
 	return s.repo.StoreUserMessage(ctx, userID, messageText, platform)
 }
 
-func (s *Service) StoreAiResponse(ctx context.Context, userMessageID int, responseText string, promptTokens, completionTokens *int) error {
+func (s *Service) StoreAiResponse(ctx context.Context, userMessageID int, responseText, functionName string, promptTokens, completionTokens *int) (int, error) {
 	logrus.Debugf("Сохранение ответа ИИ на сообщение %d", userMessageID)
-	return s.repo.StoreAiResponse(ctx, userMessageID, responseText, promptTokens, completionTokens)
+	return s.repo.StoreAiResponse(ctx, userMessageID, responseText, functionName, promptTokens, completionTokens)
+}
+
+func (s *Service) RateResponse(ctx context.Context, responseID int, userID int64, rating int) (string, error) {
+	logrus.Debugf("Оценка %d ответа %d от пользователя %d", rating, responseID, userID)
+	return s.repo.RateResponse(ctx, responseID, userID, rating)
+}
+
+func (s *Service) GetRatingSummary(ctx context.Context, userID int64, days int) ([]models.ResponseRating, error) {
+	return s.repo.GetRatingSummary(ctx, userID, days)
 }
 
 func (s *Service) GetMessageHistory(ctx context.Context, userID string) ([]models.MessageHistoryItem, error) {
 	logrus.Debugf("Получение истории сообщений пользователя %s", userID)
 	return s.repo.GetMessageHistoryChronological(ctx, userID)
 }
+
+func (s *Service) GetConversationSummary(ctx context.Context, userID string) (*models.ConversationSummary, error) {
+	return s.repo.GetConversationSummary(ctx, userID)
+}
+
+func (s *Service) SaveConversationSummary(ctx context.Context, userID, summary string, summarizedUntil time.Time, messageCount int) error {
+	logrus.Debugf("Сохранение резюме диалога пользователя %s (%d сообщений)", userID, messageCount)
+	return s.repo.SaveConversationSummary(ctx, userID, summary, summarizedUntil, messageCount)
+}
+
+func (s *Service) GetMessagesToSummarize(ctx context.Context, userID string, after time.Time, keepRecent, limit int) ([]models.TimedMessage, error) {
+	return s.repo.GetMessagesToSummarize(ctx, userID, after, keepRecent, limit)
+}
+
+func (s *Service) GetUsersForSummarization(ctx context.Context, minMessages int) ([]string, error) {
+	return s.repo.GetUsersForSummarization(ctx, minMessages)
+}
+
+func (s *Service) SetEncryption(ctx context.Context, userID string, enabled bool) error {
+	logrus.Infof("Шифрование истории пользователя %s: %t", userID, enabled)
+	return s.repo.SetEncryption(ctx, userID, enabled)
+}
+
+func (s *Service) GetEncryptionStatus(ctx context.Context, userID string) (*EncryptionStatus, error) {
+	return s.repo.GetEncryptionStatus(ctx, userID)
+}
@@ -0,0 +1,175 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	alertWindow		= 5 * time.Minute
+	alertCooldown		= 30 * time.Minute
+	minAlertSamples		= 10
+)
+
+type Thresholds struct {
+	WebhookErrorRate	float64
+	OpenAIFailureRate	float64
+	OutboxBacklog		float64
+}
+
+type Alerter struct {
+	chatID		int64
+	thresholds	Thresholds
+	send		func(chatID int64, text string) error
+	mu		sync.Mutex
+	firing		map[string]time.Time
+}
+
+type alertmanagerPayload struct {
+	Status	string	`json:"status"`
+	Alerts	[]struct {
+		Status		string			`json:"status"`
+		Labels		map[string]string	`json:"labels"`
+		Annotations	map[string]string	`json:"annotations"`
+		StartsAt	time.Time		`json:"startsAt"`
+	} `json:"alerts"`
+}
+
+func ParseThresholds(webhookErrorRate, openAIFailureRate, outboxBacklog string) Thresholds {
+	parse := func(value string, fallback float64) float64 {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || parsed <= 0 {
+			return fallback
+		}
+		return parsed
+	}
+	return Thresholds{
+		WebhookErrorRate:	parse(webhookErrorRate, 0.2),
+		OpenAIFailureRate:	parse(openAIFailureRate, 0.3),
+		OutboxBacklog:		parse(outboxBacklog, 100),
+	}
+}
+
+func NewAlerter(chatID int64, thresholds Thresholds, send func(chatID int64, text string) error) *Alerter {
+	return &Alerter{
+		chatID:		chatID,
+		thresholds:	thresholds,
+		send:		send,
+		firing:		make(map[string]time.Time),
+	}
+}
+
+func (a *Alerter) Start(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:		"operational_alerts",
+		Spec:		"@every 1m",
+		Run: func(ctx context.Context) {
+			a.check(ctx, time.Now())
+		},
+	})
+
+	if a.chatID == 0 {
+		logrus.Warn("ADMIN_ALERT_CHAT_ID не задан: оповещения будут только в логах")
+	}
+	logrus.Info("Запущена проверка операционных оповещений")
+}
+
+func (a *Alerter) check(ctx context.Context, now time.Time) {
+	webhook := WindowRate(EventTelegramWebhook, alertWindow, now)
+	a.evaluate("webhook_errors", webhook.Total >= minAlertSamples && webhook.FailureRate() >= a.thresholds.WebhookErrorRate,
+		fmt.Sprintf("Доля ошибок вебхука Telegram %.0f%% (%d из %d за 5 мин, порог %.0f%%)",
+			webhook.FailureRate()*100, webhook.Failures, webhook.Total, a.thresholds.WebhookErrorRate*100), now)
+
+	openAI := WindowRate(EventOpenAI, alertWindow, now)
+	a.evaluate("openai_failures", openAI.Total >= minAlertSamples && openAI.FailureRate() >= a.thresholds.OpenAIFailureRate,
+		fmt.Sprintf("Доля ошибок OpenAI %.0f%% (%d из %d за 5 мин, порог %.0f%%)",
+			openAI.FailureRate()*100, openAI.Failures, openAI.Total, a.thresholds.OpenAIFailureRate*100), now)
+
+	backlog, ok, err := Gauge(ctx, GaugeOutboxBacklog)
+	if err != nil {
+		logrus.Errorf("Ошибка при проверке очереди уведомлений: %v", err)
+		return
+	}
+	if ok {
+		a.evaluate("outbox_backlog", backlog >= a.thresholds.OutboxBacklog,
+			fmt.Sprintf("Очередь неотправленных уведомлений: %.0f (порог %.0f)", backlog, a.thresholds.OutboxBacklog), now)
+	}
+}
+
+func (a *Alerter) evaluate(name string, breached bool, details string, now time.Time) {
+	a.mu.Lock()
+	lastSent, firing := a.firing[name]
+	switch {
+	case breached && (!firing || now.Sub(lastSent) >= alertCooldown):
+		a.firing[name] = now
+	case !breached && firing:
+		delete(a.firing, name)
+	default:
+		a.mu.Unlock()
+		return
+	}
+	a.mu.Unlock()
+
+	if breached {
+		a.Notify("🚨 " + details)
+	} else {
+		a.Notify("✅ Восстановлено: " + details)
+	}
+}
+
+func (a *Alerter) Notify(text string) {
+	logrus.Warnf("Операционное оповещение: %s", text)
+	if a.chatID == 0 || a.send == nil {
+		return
+	}
+	if err := a.send(a.chatID, text); err != nil {
+		logrus.Errorf("Ошибка при отправке оповещения администраторам: %v", err)
+	}
+}
+
+func (a *Alerter) AlertmanagerHandler(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(r, token) {
+			http.Error(w, "Доступ запрещен", http.StatusUnauthorized)
+			return
+		}
+
+		var payload alertmanagerPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+			return
+		}
+
+		for _, alert := range payload.Alerts {
+			icon := "🚨"
+			if alert.Status == "resolved" {
+				icon = "✅"
+			}
+			name := alert.Labels["alertname"]
+			summary := alert.Annotations["summary"]
+			if summary == "" {
+				summary = alert.Annotations["description"]
+			}
+			text := fmt.Sprintf("%s [%s] %s", icon, alert.Status, name)
+			if summary != "" {
+				text += "\n" + summary
+			}
+			a.Notify(text)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
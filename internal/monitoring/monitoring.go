@@ -0,0 +1,226 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	EventTelegramWebhook	= "telegram_webhook"
+	EventTelegramSend	= "telegram_send"
+	EventOpenAI		= "openai_request"
+	EventJarvisFunction	= "jarvis_function"
+
+	GaugeOutboxBacklog	= "outbox_backlog"
+
+	windowMinutes	= 60
+)
+
+type bucket struct {
+	minute		int64
+	total		int64
+	failures	int64
+}
+
+type counter struct {
+	total		int64
+	failures	int64
+	buckets		[windowMinutes]bucket
+}
+
+type GaugeFunc func(ctx context.Context) (float64, error)
+
+type registry struct {
+	mu		sync.Mutex
+	counters	map[string]*counter
+	gauges		map[string]GaugeFunc
+}
+
+var defaultRegistry = &registry{
+	counters:	make(map[string]*counter),
+	gauges:		make(map[string]GaugeFunc),
+}
+
+type Rate struct {
+	Total		int64	`json:"total"`
+	Failures	int64	`json:"failures"`
+}
+
+func (r Rate) FailureRate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Failures) / float64(r.Total)
+}
+
+func Observe(event string, err error) {
+	ObserveAt(event, err != nil, time.Now())
+}
+
+func ObserveAt(event string, failed bool, at time.Time) {
+	minute := at.Unix() / 60
+
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+
+	c, ok := defaultRegistry.counters[event]
+	if !ok {
+		c = &counter{}
+		defaultRegistry.counters[event] = c
+	}
+
+	b := &c.buckets[minute%windowMinutes]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+
+	c.total++
+	b.total++
+	if failed {
+		c.failures++
+		b.failures++
+	}
+}
+
+func RegisterGauge(name string, fn GaugeFunc) {
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	defaultRegistry.gauges[name] = fn
+}
+
+func WindowRate(event string, window time.Duration, now time.Time) Rate {
+	minutes := int64(window / time.Minute)
+	if minutes <= 0 || minutes > windowMinutes {
+		minutes = windowMinutes
+	}
+	current := now.Unix() / 60
+
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+
+	var rate Rate
+	c, ok := defaultRegistry.counters[event]
+	if !ok {
+		return rate
+	}
+	for _, b := range c.buckets {
+		if b.minute > current-minutes && b.minute <= current {
+			rate.Total += b.total
+			rate.Failures += b.failures
+		}
+	}
+	return rate
+}
+
+func Gauge(ctx context.Context, name string) (float64, bool, error) {
+	defaultRegistry.mu.Lock()
+	fn, ok := defaultRegistry.gauges[name]
+	defaultRegistry.mu.Unlock()
+
+	if !ok {
+		return 0, false, nil
+	}
+	value, err := fn(ctx)
+	return value, true, err
+}
+
+type OutboxCount struct {
+	Source	string	`db:"source"`
+	Pending	int	`db:"pending"`
+}
+
+func PendingNotifications(ctx context.Context, db *sqlx.DB) ([]OutboxCount, error) {
+	var counts []OutboxCount
+	err := db.SelectContext(ctx, &counts, `
+		SELECT 'team_invitations' AS source, COUNT(*) AS pending FROM team_invitations WHERE status = 'pending' AND notified = FALSE
+		UNION ALL
+		SELECT 'finance_space_members', COUNT(*) FROM finance_space_members WHERE status = 'pending' AND notified = FALSE
+		UNION ALL
+		SELECT 'challenge_participants', COUNT(*) FROM challenge_participants WHERE status = 'pending' AND notified = FALSE
+		UNION ALL
+		SELECT 'integration_webhooks', COUNT(*) FROM integration_webhooks WHERE is_active = TRUE AND failures > 0
+		UNION ALL
+		SELECT 'outbound_messages', COUNT(*) FROM outbound_messages WHERE status = 'pending'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете неотправленных уведомлений: %v", err)
+	}
+	return counts, nil
+}
+
+func PendingNotificationsGauge(db *sqlx.DB) GaugeFunc {
+	return func(ctx context.Context) (float64, error) {
+		counts, err := PendingNotifications(ctx, db)
+		if err != nil {
+			return 0, err
+		}
+		var pending float64
+		for _, c := range counts {
+			pending += float64(c.Pending)
+		}
+		return pending, nil
+	}
+}
+
+func authorized(r *http.Request, token string) bool {
+	provided := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		provided = strings.TrimPrefix(header, "Bearer ")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+func MetricsHandler(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			http.Error(w, "Доступ запрещен", http.StatusUnauthorized)
+			return
+		}
+
+		defaultRegistry.mu.Lock()
+		names := make([]string, 0, len(defaultRegistry.counters))
+		totals := make(map[string][2]int64, len(defaultRegistry.counters))
+		for name, c := range defaultRegistry.counters {
+			names = append(names, name)
+			totals[name] = [2]int64{c.total, c.failures}
+		}
+		gaugeNames := make([]string, 0, len(defaultRegistry.gauges))
+		for name := range defaultRegistry.gauges {
+			gaugeNames = append(gaugeNames, name)
+		}
+		defaultRegistry.mu.Unlock()
+
+		sort.Strings(names)
+		sort.Strings(gaugeNames)
+
+		var b strings.Builder
+		b.WriteString("# TYPE jarvis_events_total counter\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("jarvis_events_total{event=%q} %d\n", name, totals[name][0]))
+		}
+		b.WriteString("# TYPE jarvis_event_failures_total counter\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("jarvis_event_failures_total{event=%q} %d\n", name, totals[name][1]))
+		}
+		for _, name := range gaugeNames {
+			value, _, err := Gauge(r.Context(), name)
+			if err != nil {
+				logrus.Warnf("Не удалось получить значение метрики %s: %v", name, err)
+				continue
+			}
+			b.WriteString(fmt.Sprintf("# TYPE jarvis_%s gauge\njarvis_%s %g\n", name, name, value))
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(b.String()))
+	}
+}
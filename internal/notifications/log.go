@@ -0,0 +1,145 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/preferences"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KindReminder	= "reminder"
+	KindReport	= "report"
+	KindInsight	= preferences.KindInsight
+	KindNudge	= preferences.KindNudge
+	KindAlert	= "alert"
+	KindMotivation	= preferences.KindMotivation
+	KindSuggestion	= preferences.KindSuggestion
+)
+
+const (
+	logRetentionDays	= 90
+	defaultLogLimit		= 50
+	maxLogLimit		= 200
+)
+
+var ErrUnknownKind = errors.New("неизвестный тип уведомления")
+
+var Kinds = []string{KindReminder, KindReport, KindInsight, KindNudge, KindAlert, KindMotivation, KindSuggestion}
+
+type Notification struct {
+	ID		int64		`db:"id" json:"id"`
+	Kind		string		`db:"kind" json:"kind"`
+	Text		string		`db:"text" json:"text"`
+	ReadAt		*time.Time	`db:"read_at" json:"read_at,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type LogFilter struct {
+	Kinds		[]string
+	UnreadOnly	bool
+	Since		*time.Time
+	Until		*time.Time
+	Limit		int
+	Offset		int
+}
+
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) Record(ctx context.Context, userID int64, kind, text string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_log (user_id, kind, text) VALUES ($1, $2, $3)
+	`, userID, kind, text)
+	if err != nil {
+		return fmt.Errorf("ошибка при записи уведомления в журнал: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) List(ctx context.Context, userIDs []int64, filter LogFilter) ([]Notification, error) {
+	for _, kind := range filter.Kinds {
+		if !ValidKind(kind) {
+			return nil, ErrUnknownKind
+		}
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	if limit > maxLogLimit {
+		limit = maxLogLimit
+	}
+
+	items := make([]Notification, 0)
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT id, kind, text, read_at, created_at
+		FROM notification_log
+		WHERE user_id = ANY($1)
+			AND (cardinality($2::text[]) = 0 OR kind = ANY($2))
+			AND (NOT $3 OR read_at IS NULL)
+			AND ($4::timestamptz IS NULL OR created_at >= $4)
+			AND ($5::timestamptz IS NULL OR created_at < $5)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $6 OFFSET $7
+	`, pq.Array(userIDs), pq.Array(filter.Kinds), filter.UnreadOnly, filter.Since, filter.Until, limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении журнала уведомлений: %v", err)
+	}
+	return items, nil
+}
+
+func (s *Service) CountUnread(ctx context.Context, userIDs []int64) (int, error) {
+	var count int
+	err := s.db.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM notification_log WHERE user_id = ANY($1) AND read_at IS NULL
+	`, pq.Array(userIDs))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете непрочитанных уведомлений: %v", err)
+	}
+	return count, nil
+}
+
+func (s *Service) MarkRead(ctx context.Context, userIDs []int64, ids []int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notification_log SET read_at = NOW()
+		WHERE user_id = ANY($1) AND read_at IS NULL AND (cardinality($2::bigint[]) = 0 OR id = ANY($2))
+	`, pq.Array(userIDs), pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при отметке уведомлений прочитанными: %v", err)
+	}
+	marked, _ := result.RowsAffected()
+	return marked, nil
+}
+
+func (s *Service) StartLogCleanup(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:	"notification_log_cleanup",
+		Spec:	"45 4 * * *",
+		Run: func(ctx context.Context) {
+			result, err := s.db.ExecContext(ctx, `
+				DELETE FROM notification_log WHERE created_at < NOW() - $1 * INTERVAL '1 day'
+			`, logRetentionDays)
+			if err != nil {
+				logrus.Errorf("Ошибка очистки журнала уведомлений: %v", err)
+				return
+			}
+			if deleted, _ := result.RowsAffected(); deleted > 0 {
+				logrus.Infof("Удалено старых записей журнала уведомлений: %d", deleted)
+			}
+		},
+	})
+
+	logrus.Info("Запущена очистка журнала уведомлений старше 90 дней")
+}
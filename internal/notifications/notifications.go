@@ -0,0 +1,286 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/preferences"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type Decision int
+
+const (
+	Deliver Decision = iota
+	Suppress
+	Defer
+)
+
+const (
+	maxDNDDuration		= 7 * 24 * time.Hour
+	morningHour		= 8
+	maxCatchUpItems		= 15
+	maxCatchUpItemRunes	= 300
+)
+
+var (
+	ErrInvalidPeriod	= errors.New("не удалось понять период. Примеры: 2h, 30m, до 18:00, до завтра")
+	ErrPeriodTooLong	= errors.New("режим «не беспокоить» можно включить максимум на 7 дней")
+)
+
+var (
+	durationPattern	= regexp.MustCompile(`^(\d+)\s*(h|ч|час|часа|часов|m|м|мин|минут|минуты|d|д|день|дня|дней)?$`)
+	clockPattern	= regexp.MustCompile(`^(?:до\s+)?(\d{1,2}):(\d{2})$`)
+)
+
+type Service struct {
+	db		*sqlx.DB
+	preferences	*preferences.Service
+}
+
+type Deferred struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Kind		string		`db:"kind" json:"kind"`
+	Text		string		`db:"text" json:"text"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+func NewService(db *sqlx.DB, preferencesService *preferences.Service) *Service {
+	return &Service{db: db, preferences: preferencesService}
+}
+
+func (s *Service) Decide(ctx context.Context, userID int64, kind string) (Decision, error) {
+	allowed, err := s.preferences.AllowUnsolicited(ctx, userID, kind)
+	if err != nil {
+		return Deliver, err
+	}
+	if !allowed {
+		return Suppress, nil
+	}
+	return s.DecideQuiet(ctx, userID, kind)
+}
+
+func (s *Service) DecideQuiet(ctx context.Context, userID int64, kind string) (Decision, error) {
+	decision, err := s.DecideScheduled(ctx, userID, kind)
+	if err != nil || decision != Deliver {
+		return decision, err
+	}
+
+	until, err := s.GetDND(ctx, userID)
+	if err != nil {
+		return Deliver, err
+	}
+	if until != nil {
+		return Defer, nil
+	}
+	return Deliver, nil
+}
+
+func (s *Service) Defer(ctx context.Context, userID int64, kind, text string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO deferred_notifications (user_id, kind, text) VALUES ($1, $2, $3)
+	`, userID, kind, text)
+	if err != nil {
+		return fmt.Errorf("ошибка при откладывании уведомления: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) GetDND(ctx context.Context, userID int64) (*time.Time, error) {
+	var until time.Time
+	err := s.db.GetContext(ctx, &until, `SELECT until FROM do_not_disturb WHERE user_id = $1 AND until > NOW()`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении режима «не беспокоить»: %v", err)
+	}
+	return &until, nil
+}
+
+func (s *Service) SetDND(ctx context.Context, userID int64, until time.Time) error {
+	if time.Until(until) > maxDNDDuration {
+		return ErrPeriodTooLong
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO do_not_disturb (user_id, until) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET until = EXCLUDED.until, created_at = NOW()
+	`, userID, until)
+	if err != nil {
+		return fmt.Errorf("ошибка при включении режима «не беспокоить»: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) ClearDND(ctx context.Context, userID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM do_not_disturb WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("ошибка при выключении режима «не беспокоить»: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) CountPending(ctx context.Context) (int, error) {
+	var count int
+	if err := s.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM deferred_notifications WHERE delivered_at IS NULL`); err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете отложенных уведомлений: %v", err)
+	}
+	return count, nil
+}
+
+func ParseUntil(text string, now time.Time) (time.Time, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = strings.TrimPrefix(text, "на ")
+
+	switch text {
+	case "до завтра", "завтра":
+		tomorrow := now.AddDate(0, 0, 1)
+		return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), morningHour, 0, 0, 0, now.Location()), nil
+	case "до утра", "утра":
+		morning := time.Date(now.Year(), now.Month(), now.Day(), morningHour, 0, 0, 0, now.Location())
+		if !morning.After(now) {
+			morning = morning.AddDate(0, 0, 1)
+		}
+		return morning, nil
+	case "до вечера", "вечера":
+		evening := time.Date(now.Year(), now.Month(), now.Day(), 18, 0, 0, 0, now.Location())
+		if !evening.After(now) {
+			return time.Time{}, ErrInvalidPeriod
+		}
+		return evening, nil
+	}
+
+	if m := clockPattern.FindStringSubmatch(text); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 23 || minute > 59 {
+			return time.Time{}, ErrInvalidPeriod
+		}
+		until := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !until.After(now) {
+			until = until.AddDate(0, 0, 1)
+		}
+		return until, nil
+	}
+
+	if m := durationPattern.FindStringSubmatch(text); m != nil {
+		value, _ := strconv.Atoi(m[1])
+		if value <= 0 {
+			return time.Time{}, ErrInvalidPeriod
+		}
+		unit := time.Hour
+		switch {
+		case strings.HasPrefix(m[2], "m"), strings.HasPrefix(m[2], "м"):
+			unit = time.Minute
+		case strings.HasPrefix(m[2], "d"), strings.HasPrefix(m[2], "д"):
+			unit = 24 * time.Hour
+		}
+		return now.Add(time.Duration(value) * unit), nil
+	}
+
+	return time.Time{}, ErrInvalidPeriod
+}
+
+func (s *Service) StartCatchUp(jm *jobs.Manager, sendMessage func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"dnd_catch_up",
+		Spec:	"*/5 * * * *",
+		Run: func(ctx context.Context) {
+			s.sendDueCatchUps(ctx, sendMessage)
+		},
+	})
+
+	logrus.Info("Запущена отправка сводок после режима «не беспокоить»")
+}
+
+func (s *Service) sendDueCatchUps(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		SELECT DISTINCT dn.user_id
+		FROM deferred_notifications dn
+		LEFT JOIN do_not_disturb d ON d.user_id = dn.user_id AND d.until > NOW()
+		WHERE dn.delivered_at IS NULL AND d.user_id IS NULL
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе отложенных уведомлений: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		settings, err := s.GetSettings(ctx, userID)
+		if err != nil {
+			logrus.Warnf("Не удалось проверить тихие часы пользователя %d: %v", userID, err)
+		} else if settings.InQuietHours(now) {
+			continue
+		}
+		if err := s.SendCatchUp(ctx, userID, sendMessage); err != nil {
+			logrus.Errorf("Ошибка при отправке сводки пользователю %d: %v", userID, err)
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM do_not_disturb WHERE until <= NOW()`); err != nil {
+		logrus.Warnf("Не удалось удалить завершенные периоды «не беспокоить»: %v", err)
+	}
+}
+
+func (s *Service) SendCatchUp(ctx context.Context, userID int64, sendMessage func(chatID int64, text string) error) error {
+	var items []Deferred
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT id, user_id, kind, text, created_at
+		FROM deferred_notifications
+		WHERE user_id = $1 AND delivered_at IS NULL
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении отложенных уведомлений: %v", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := sendMessage(userID, FormatCatchUp(items)); err != nil {
+		return err
+	}
+
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE deferred_notifications SET delivered_at = NOW() WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("ошибка при отметке отложенных уведомлений: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_log (user_id, kind, text, created_at)
+		SELECT user_id, kind, text, created_at FROM deferred_notifications WHERE id = ANY($1)
+	`, pq.Array(ids)); err != nil {
+		logrus.Warnf("Не удалось записать сводку пользователя %d в журнал уведомлений: %v", userID, err)
+	}
+	return nil
+}
+
+func FormatCatchUp(items []Deferred) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🔔 Пока действовали тихие часы или режим «не беспокоить», накопилось уведомлений: %d\n", len(items)))
+	for i, item := range items {
+		if i == maxCatchUpItems {
+			b.WriteString(fmt.Sprintf("\n…и еще %d", len(items)-maxCatchUpItems))
+			break
+		}
+		text := []rune(strings.TrimSpace(item.Text))
+		if len(text) > maxCatchUpItemRunes {
+			text = append(text[:maxCatchUpItemRunes], '…')
+		}
+		b.WriteString(fmt.Sprintf("\n🕒 %s\n%s\n", item.CreatedAt.Format("02.01 15:04"), string(text)))
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrInvalidQuietHours = errors.New("не удалось понять тихие часы. Пример: 22:00-08:00")
+
+var quietHoursPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*[-–—]\s*(\d{1,2})(?::(\d{2}))?$`)
+
+var kindLabels = map[string]string{
+	KindReminder:	"напоминания",
+	KindReport:	"отчеты и сводки",
+	KindInsight:	"инсайты",
+	KindNudge:	"подсказки по срокам и темпу",
+	KindAlert:	"оповещения",
+	KindMotivation:	"мотивация",
+	KindSuggestion:	"предложения",
+}
+
+type Settings struct {
+	UserID		int64		`db:"user_id" json:"-"`
+	QuietStart	*int		`db:"quiet_start" json:"-"`
+	QuietEnd	*int		`db:"quiet_end" json:"-"`
+	MutedKinds	pq.StringArray	`db:"muted_kinds" json:"muted_kinds"`
+}
+
+func (s *Settings) HasQuietHours() bool {
+	return s.QuietStart != nil && s.QuietEnd != nil && *s.QuietStart != *s.QuietEnd
+}
+
+func (s *Settings) InQuietHours(at time.Time) bool {
+	if !s.HasQuietHours() {
+		return false
+	}
+	minute := at.Hour()*60 + at.Minute()
+	if *s.QuietStart < *s.QuietEnd {
+		return minute >= *s.QuietStart && minute < *s.QuietEnd
+	}
+	return minute >= *s.QuietStart || minute < *s.QuietEnd
+}
+
+func (s *Settings) Muted(kind string) bool {
+	for _, muted := range s.MutedKinds {
+		if muted == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Settings) QuietHours() string {
+	if !s.HasQuietHours() {
+		return ""
+	}
+	return fmt.Sprintf("%s-%s", formatMinuteOfDay(*s.QuietStart), formatMinuteOfDay(*s.QuietEnd))
+}
+
+func (s *Service) GetSettings(ctx context.Context, userID int64) (*Settings, error) {
+	settings := Settings{UserID: userID, MutedKinds: pq.StringArray{}}
+	err := s.db.GetContext(ctx, &settings, `
+		SELECT user_id, quiet_start, quiet_end, muted_kinds FROM notification_settings WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при получении настроек уведомлений: %v", err)
+	}
+	return &settings, nil
+}
+
+func (s *Service) SaveSettings(ctx context.Context, settings *Settings) error {
+	for _, kind := range settings.MutedKinds {
+		if !ValidKind(kind) {
+			return fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+		}
+	}
+	if settings.MutedKinds == nil {
+		settings.MutedKinds = pq.StringArray{}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_settings (user_id, quiet_start, quiet_end, muted_kinds)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET quiet_start = $2, quiet_end = $3, muted_kinds = $4, updated_at = NOW()
+	`, settings.UserID, settings.QuietStart, settings.QuietEnd, settings.MutedKinds)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении настроек уведомлений: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) ToggleKind(ctx context.Context, userID int64, kind string) (*Settings, error) {
+	if !ValidKind(kind) {
+		return nil, ErrUnknownKind
+	}
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Muted(kind) {
+		kept := pq.StringArray{}
+		for _, muted := range settings.MutedKinds {
+			if muted != kind {
+				kept = append(kept, muted)
+			}
+		}
+		settings.MutedKinds = kept
+	} else {
+		settings.MutedKinds = append(settings.MutedKinds, kind)
+	}
+	if err := s.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *Service) DecideScheduled(ctx context.Context, userID int64, kind string) (Decision, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return Deliver, err
+	}
+	if settings.Muted(kind) {
+		return Suppress, nil
+	}
+	if settings.InQuietHours(time.Now()) {
+		return Defer, nil
+	}
+	return Deliver, nil
+}
+
+func ParseQuietHours(text string) (int, int, error) {
+	m := quietHoursPattern.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return 0, 0, ErrInvalidQuietHours
+	}
+	start, err := minuteOfDay(m[1], m[2])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := minuteOfDay(m[3], m[4])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, ErrInvalidQuietHours
+	}
+	return start, end, nil
+}
+
+func minuteOfDay(hourText, minuteText string) (int, error) {
+	hour, _ := strconv.Atoi(hourText)
+	minute := 0
+	if minuteText != "" {
+		minute, _ = strconv.Atoi(minuteText)
+	}
+	if hour > 23 || minute > 59 {
+		return 0, ErrInvalidQuietHours
+	}
+	return hour*60 + minute, nil
+}
+
+func formatMinuteOfDay(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func KindLabel(kind string) string {
+	if label, ok := kindLabels[kind]; ok {
+		return label
+	}
+	return kind
+}
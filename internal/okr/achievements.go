@@ -0,0 +1,106 @@
+package okr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+var achievementMetrics = map[string]string{
+	"goals_created": `
+		SELECT COUNT(*) FROM objectives WHERE user_id = $1 AND deleted_at IS NULL
+	`,
+	"goals_completed": `
+		SELECT COUNT(*) FROM objectives WHERE user_id = $1 AND status = 'completed' AND deleted_at IS NULL
+	`,
+	"early_completion": `
+		SELECT COUNT(*) FROM objectives
+		WHERE user_id = $1 AND status = 'completed' AND deleted_at IS NULL AND completion_date < deadline
+	`,
+	"shared_goals": `
+		SELECT COUNT(*) FROM (
+			SELECT objective_id FROM partner_shared_objectives WHERE owner_id = $1
+			UNION
+			SELECT objective_id FROM shared_objectives WHERE shared_by = $1
+		) shared
+	`,
+}
+
+type Achievement struct {
+	ID		int	`db:"id" json:"id"`
+	Name		string	`db:"name" json:"name"`
+	Description	string	`db:"description" json:"description"`
+	Icon		string	`db:"icon" json:"icon"`
+	Category	string	`db:"category" json:"category"`
+	Points		int	`db:"points" json:"points"`
+	Rarity		string	`db:"rarity" json:"rarity"`
+	Requirements	[]byte	`db:"requirements" json:"-"`
+	Metric		string	`db:"-" json:"metric"`
+	Required	int	`db:"-" json:"required"`
+	Current		int	`db:"-" json:"current"`
+	New		bool	`db:"-" json:"new"`
+}
+
+func (a Achievement) Earned() bool {
+	return a.Current >= a.Required
+}
+
+func (s *Service) CheckAchievements(ctx context.Context, userID int64, category string) ([]Achievement, error) {
+	var types []Achievement
+	err := s.db.SelectContext(ctx, &types, `
+		SELECT id, name, COALESCE(description, '') AS description, COALESCE(icon, '🏆') AS icon,
+			COALESCE(category, 'general') AS category, COALESCE(points, 0) AS points,
+			COALESCE(rarity, 'common') AS rarity, COALESCE(requirements, '{}') AS requirements
+		FROM achievement_types
+		WHERE is_active AND ($1 = '' OR category = $1)
+		ORDER BY points, id
+	`, category)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении достижений: %v", err)
+	}
+
+	counts := make(map[string]int)
+	achievements := make([]Achievement, 0, len(types))
+	for _, a := range types {
+		var requirements map[string]int
+		if err := json.Unmarshal(a.Requirements, &requirements); err != nil {
+			logrus.Warnf("Некорректные условия достижения %q: %v", a.Name, err)
+			continue
+		}
+		for metric, required := range requirements {
+			if _, ok := achievementMetrics[metric]; ok {
+				a.Metric, a.Required = metric, required
+				break
+			}
+		}
+		if a.Metric == "" {
+			continue
+		}
+
+		current, ok := counts[a.Metric]
+		if !ok {
+			if err := s.db.GetContext(ctx, &current, achievementMetrics[a.Metric], userID); err != nil {
+				return nil, fmt.Errorf("ошибка при подсчете прогресса достижения: %v", err)
+			}
+			counts[a.Metric] = current
+		}
+		a.Current = current
+
+		if a.Earned() {
+			result, err := s.db.ExecContext(ctx, `
+				INSERT INTO user_achievements (user_id, achievement_id) VALUES ($1, $2)
+				ON CONFLICT (user_id, achievement_id) DO NOTHING
+			`, userID, a.ID)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при сохранении достижения: %v", err)
+			}
+			if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+				a.New = true
+			}
+		}
+		achievements = append(achievements, a)
+	}
+	return achievements, nil
+}
@@ -0,0 +1,80 @@
+package okr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"telegrambot/internal/ownership"
+	"time"
+)
+
+const (
+	ObjectiveStatusActive		= "active"
+	ObjectiveStatusCompleted	= "completed"
+	ObjectiveStatusArchived		= "archived"
+	ObjectiveStatusOpen		= "open"
+)
+
+var ErrObjectiveNotArchived = errors.New("цель не находится в архиве")
+
+const ArchivedObjectiveCondition = `(COALESCE(o.status, 'active') IN ('completed', 'archived') OR o.completion_date IS NOT NULL)`
+
+type ArchivedObjective struct {
+	ID		string		`db:"id"`
+	UserID		int64		`db:"user_id"`
+	Title		string		`db:"title"`
+	Sphere		string		`db:"sphere"`
+	Period		string		`db:"period"`
+	Status		string		`db:"status"`
+	Deadline	*time.Time	`db:"deadline"`
+	CompletionDate	*time.Time	`db:"completion_date"`
+	Progress	float64		`db:"progress"`
+	CreatedAt	time.Time	`db:"created_at"`
+}
+
+func (s *Service) GetArchivedObjectives(ctx context.Context, userID int64, limit, offset int) ([]ArchivedObjective, error) {
+	query := `
+		SELECT o.id, o.user_id, o.title, COALESCE(o.sphere, '') AS sphere, o.period,
+			COALESCE(o.status, 'completed') AS status, o.deadline, o.completion_date, o.created_at,
+			COALESCE(AVG(LEAST(kr.progress / NULLIF(kr.target, 0), 1)) * 100, 0) AS progress
+		FROM objectives o
+		LEFT JOIN key_results kr ON kr.objective_id = o.id AND kr.deleted_at IS NULL
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND ` + ArchivedObjectiveCondition + `
+		GROUP BY o.id
+		ORDER BY COALESCE(o.completion_date, o.updated_at, o.created_at) DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	objectives := make([]ArchivedObjective, 0)
+	err := s.db.SelectContext(ctx, &objectives, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении архивных целей: %v", err)
+	}
+
+	return objectives, nil
+}
+
+func (s *Service) RestoreObjective(ctx context.Context, userID int64, objectiveID string) error {
+	err := ownership.MustOwnObjective(ctx, s.db, userID, objectiveID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE objectives o
+		SET status = 'active', completion_date = NULL, archived_at = NULL, updated_at = NOW()
+		WHERE o.id = $1 AND o.user_id = $2 AND ` + ArchivedObjectiveCondition + `
+	`
+
+	result, err := s.db.ExecContext(ctx, query, objectiveID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при восстановлении цели: %v", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrObjectiveNotArchived
+	}
+
+	return nil
+}
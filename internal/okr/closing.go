@@ -0,0 +1,116 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/ownership"
+	"time"
+)
+
+var (
+	ErrInvalidClosingOutcome	= errors.New("цель можно только завершить или отправить в архив")
+	ErrObjectiveAlreadyClosed	= errors.New("цель уже завершена или в архиве")
+)
+
+type ObjectiveClosing struct {
+	ObjectiveID	string		`db:"objective_id" json:"objective_id"`
+	Outcome		string		`db:"outcome" json:"outcome"`
+	FinalProgress	float64		`db:"final_progress" json:"final_progress"`
+	WhatWorked	string		`db:"what_worked" json:"what_worked"`
+	WhatDidnt	string		`db:"what_didnt" json:"what_didnt"`
+	ClosedAt	time.Time	`db:"closed_at" json:"closed_at"`
+}
+
+func (s *Service) CloseObjective(ctx context.Context, userID int64, objectiveID, outcome, whatWorked, whatDidnt string) (*ObjectiveClosing, error) {
+	if outcome != ObjectiveStatusCompleted && outcome != ObjectiveStatusArchived {
+		return nil, ErrInvalidClosingOutcome
+	}
+	if err := ownership.MustOwnObjective(ctx, s.db, userID, objectiveID); err != nil {
+		return nil, err
+	}
+
+	progress, err := s.GetObjectiveProgress(ctx, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE objectives o
+		SET status = $3,
+			completion_date = CASE WHEN $3 = 'completed' THEN NOW() ELSE o.completion_date END,
+			archived_at = CASE WHEN $3 = 'archived' THEN NOW() END,
+			updated_at = NOW()
+		WHERE o.id = $1 AND o.user_id = $2 AND o.deleted_at IS NULL AND NOT `+ArchivedObjectiveCondition+`
+	`, objectiveID, userID, outcome)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при закрытии цели: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrObjectiveAlreadyClosed
+	}
+
+	closing := &ObjectiveClosing{
+		ObjectiveID:	objectiveID,
+		Outcome:	outcome,
+		FinalProgress:	progress,
+		WhatWorked:	strings.TrimSpace(whatWorked),
+		WhatDidnt:	strings.TrimSpace(whatDidnt),
+	}
+	err = tx.GetContext(ctx, &closing.ClosedAt, `
+		INSERT INTO objective_closings (objective_id, user_id, outcome, final_progress, what_worked, what_didnt)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING closed_at
+	`, objectiveID, userID, outcome, progress, closing.WhatWorked, closing.WhatDidnt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении ретроспективы цели: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении закрытия цели: %v", err)
+	}
+	return closing, nil
+}
+
+func (s *Service) GetObjectiveClosing(ctx context.Context, objectiveID string) (*ObjectiveClosing, error) {
+	var closing ObjectiveClosing
+	err := s.db.GetContext(ctx, &closing, `
+		SELECT c.objective_id, c.outcome, c.final_progress, c.what_worked, c.what_didnt, c.closed_at
+		FROM objective_closings c
+		JOIN objectives o ON o.id = c.objective_id
+		WHERE c.objective_id = $1 AND `+ArchivedObjectiveCondition+`
+		ORDER BY c.closed_at DESC
+		LIMIT 1
+	`, objectiveID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ретроспективы цели: %v", err)
+	}
+	return &closing, nil
+}
+
+func FormatObjectiveClosing(c *ObjectiveClosing) string {
+	var b strings.Builder
+	if c.Outcome == ObjectiveStatusCompleted {
+		b.WriteString(fmt.Sprintf("✅ Завершена %s с прогрессом %.0f%%\n", c.ClosedAt.Format("02.01.2006"), c.FinalProgress))
+	} else {
+		b.WriteString(fmt.Sprintf("🗄 В архиве с %s, прогресс %.0f%%\n", c.ClosedAt.Format("02.01.2006"), c.FinalProgress))
+	}
+	if c.WhatWorked != "" {
+		b.WriteString("👍 Сработало: " + c.WhatWorked + "\n")
+	}
+	if c.WhatDidnt != "" {
+		b.WriteString("👎 Не сработало: " + c.WhatDidnt + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
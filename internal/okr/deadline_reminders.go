@@ -0,0 +1,231 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultDeadlineReminderDays	= 3
+	MaxDeadlineReminderDays		= 30
+	AtRiskProbability		= 0.5
+
+	DeadlineStageUpcoming	= "upcoming"
+	DeadlineStageAtRisk	= "at_risk"
+	DeadlineStageFinal	= "final"
+
+	DeadlineEntityKeyResult	= "key_result"
+	DeadlineEntityTask	= "task"
+)
+
+type CompletionForecaster func(ctx context.Context, userID int64, objectiveID string) (float64, error)
+
+type DeadlineReminderSettings struct {
+	UserID		int64	`db:"user_id"`
+	DaysBefore	int	`db:"days_before"`
+	Enabled		bool	`db:"enabled"`
+}
+
+type DeadlineItem struct {
+	UserID		int64		`db:"user_id"`
+	EntityType	string		`db:"entity_type"`
+	EntityID	int64		`db:"entity_id"`
+	ObjectiveID	string		`db:"objective_id"`
+	Title		string		`db:"title"`
+	Progress	float64		`db:"progress"`
+	Target		float64		`db:"target"`
+	Unit		string		`db:"unit"`
+	Deadline	time.Time	`db:"deadline"`
+	DaysBefore	int		`db:"days_before"`
+	Stage		string		`db:"-"`
+	Probability	*float64	`db:"-"`
+}
+
+func (s *Service) GetDeadlineReminderSettings(ctx context.Context, userID int64) (*DeadlineReminderSettings, error) {
+	settings := DeadlineReminderSettings{UserID: userID, DaysBefore: DefaultDeadlineReminderDays, Enabled: true}
+	err := s.db.GetContext(ctx, &settings, `
+		SELECT user_id, days_before, enabled FROM deadline_reminder_settings WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при получении настроек напоминаний о дедлайнах: %v", err)
+	}
+	return &settings, nil
+}
+
+func (s *Service) SetDeadlineReminderSettings(ctx context.Context, userID int64, daysBefore int, enabled bool) error {
+	if daysBefore < 1 || daysBefore > MaxDeadlineReminderDays {
+		return fmt.Errorf("предупреждать можно за 1–%d дней", MaxDeadlineReminderDays)
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO deadline_reminder_settings (user_id, days_before, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET days_before = $2, enabled = $3, updated_at = NOW()
+	`, userID, daysBefore, enabled)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении настроек напоминаний о дедлайнах: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) StartDeadlineReminders(jm *jobs.Manager, forecast CompletionForecaster, send func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"deadline_reminders",
+		Spec:	"0 10 * * *",
+		Run: func(ctx context.Context) {
+			s.sendDeadlineReminders(ctx, forecast, send)
+		},
+	})
+
+	logrus.Info("Запущены напоминания о дедлайнах ключевых результатов и задач")
+}
+
+func (s *Service) sendDeadlineReminders(ctx context.Context, forecast CompletionForecaster, send func(chatID int64, text string) error) {
+	today := startOfDay(time.Now())
+
+	var items []DeadlineItem
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT o.user_id, 'key_result' AS entity_type, kr.id AS entity_id, o.id AS objective_id, kr.title,
+			kr.progress, kr.target, COALESCE(kr.unit, '') AS unit, kr.deadline, COALESCE(ds.days_before, $2) AS days_before
+		FROM key_results kr
+		JOIN objectives o ON o.id = kr.objective_id
+		LEFT JOIN deadline_reminder_settings ds ON ds.user_id = o.user_id
+		WHERE kr.deadline IS NOT NULL AND kr.deleted_at IS NULL AND o.deleted_at IS NULL
+			AND kr.progress < kr.target AND COALESCE(o.status, 'active') = 'active'
+			AND COALESCE(ds.enabled, TRUE)
+			AND kr.deadline >= $1 AND kr.deadline < $1 + make_interval(days => 2 * COALESCE(ds.days_before, $2) + 1)
+		UNION ALL
+		SELECT o.user_id, 'task' AS entity_type, t.id AS entity_id, o.id AS objective_id, t.title,
+			t.progress, t.target, COALESCE(t.unit, '') AS unit, t.deadline, COALESCE(ds.days_before, $2) AS days_before
+		FROM tasks t
+		JOIN key_results kr ON kr.id = t.key_result_id
+		JOIN objectives o ON o.id = kr.objective_id
+		LEFT JOIN deadline_reminder_settings ds ON ds.user_id = o.user_id
+		WHERE t.deadline IS NOT NULL AND t.deleted_at IS NULL AND kr.deleted_at IS NULL AND o.deleted_at IS NULL
+			AND t.recurrence_series IS NULL AND t.completion_date IS NULL
+			AND COALESCE(t.status, '') NOT IN ('completed', 'skipped') AND COALESCE(o.status, 'active') = 'active'
+			AND COALESCE(ds.enabled, TRUE)
+			AND t.deadline >= $1 AND t.deadline < $1 + make_interval(days => 2 * COALESCE(ds.days_before, $2) + 1)
+		ORDER BY user_id, deadline
+	`, today, DefaultDeadlineReminderDays)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении ближайших дедлайнов: %v", err)
+		return
+	}
+
+	probabilities := make(map[string]*float64)
+	byUser := make(map[int64][]DeadlineItem)
+	var userIDs []int64
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return
+		}
+		probability, ok := probabilities[item.ObjectiveID]
+		if !ok {
+			if p, err := forecast(ctx, item.UserID, item.ObjectiveID); err != nil {
+				logrus.Warnf("Не удалось оценить вероятность достижения цели %s: %v", item.ObjectiveID, err)
+			} else {
+				probability = &p
+			}
+			probabilities[item.ObjectiveID] = probability
+		}
+		item.Probability = probability
+		item.Stage = deadlineStage(item, today)
+		if item.Stage == "" {
+			continue
+		}
+
+		result, err := s.db.ExecContext(ctx, `
+			INSERT INTO deadline_reminder_log (user_id, entity_type, entity_id, deadline, stage, probability)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (entity_type, entity_id, deadline, stage) DO NOTHING
+		`, item.UserID, item.EntityType, item.EntityID, item.Deadline, item.Stage, item.Probability)
+		if err != nil {
+			logrus.Errorf("Ошибка при записи напоминания о дедлайне %s %d: %v", item.EntityType, item.EntityID, err)
+			continue
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			continue
+		}
+		if _, ok := byUser[item.UserID]; !ok {
+			userIDs = append(userIDs, item.UserID)
+		}
+		byUser[item.UserID] = append(byUser[item.UserID], item)
+	}
+
+	for _, userID := range userIDs {
+		if err := send(userID, FormatDeadlineReminders(byUser[userID], today)); err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания о дедлайнах пользователю %d: %v", userID, err)
+		}
+	}
+	if len(userIDs) > 0 {
+		logrus.Infof("Отправлены напоминания о дедлайнах: %d пользователям", len(userIDs))
+	}
+}
+
+func deadlineStage(item DeadlineItem, today time.Time) string {
+	daysLeft := daysUntil(item.Deadline, today)
+	atRisk := item.Probability != nil && *item.Probability < AtRiskProbability
+	switch {
+	case atRisk && daysLeft <= 1:
+		return DeadlineStageFinal
+	case atRisk && daysLeft <= 2*item.DaysBefore:
+		return DeadlineStageAtRisk
+	case daysLeft <= item.DaysBefore:
+		return DeadlineStageUpcoming
+	}
+	return ""
+}
+
+func daysUntil(deadline, today time.Time) int {
+	return int(math.Round(startOfDay(deadline.In(today.Location())).Sub(today).Hours() / 24))
+}
+
+func FormatDeadlineReminders(items []DeadlineItem, today time.Time) string {
+	var urgent, upcoming []string
+	for _, item := range items {
+		icon := "🔑"
+		if item.EntityType == DeadlineEntityTask {
+			icon = "📝"
+		}
+		amount := strings.TrimSpace(fmt.Sprintf("%s/%s %s", formatTaskAmount(item.Progress), formatTaskAmount(item.Target), item.Unit))
+		line := fmt.Sprintf("%s %s — %s (%s), %s", icon, item.Title, formatDaysLeft(daysUntil(item.Deadline, today)), item.Deadline.Format("02.01"), amount)
+		if item.Stage == DeadlineStageUpcoming {
+			upcoming = append(upcoming, line)
+			continue
+		}
+		urgent = append(urgent, line+fmt.Sprintf("\n   📉 Вероятность достижения цели: %.0f%%", *item.Probability*100))
+	}
+
+	var b strings.Builder
+	if len(urgent) > 0 {
+		b.WriteString("⚠️ Под угрозой срыва:\n")
+		b.WriteString(strings.Join(urgent, "\n"))
+		b.WriteString("\n\nСтоит выделить время уже сегодня или пересмотреть срок.")
+	}
+	if len(upcoming) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("⏰ Скоро дедлайны:\n")
+		b.WriteString(strings.Join(upcoming, "\n"))
+	}
+	return b.String()
+}
+
+func formatDaysLeft(days int) string {
+	switch days {
+	case 0:
+		return "сегодня"
+	case 1:
+		return "завтра"
+	}
+	return fmt.Sprintf("через %d дн.", days)
+}
@@ -0,0 +1,153 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DraftViaTelegram	= "telegram"
+	DraftViaWeb		= "web"
+)
+
+var ObjectiveDraftRequired = []string{"title", "sphere", "period", "deadline"}
+
+var (
+	ErrDraftNotFound	= errors.New("черновик цели не найден")
+	ErrDraftConflict	= errors.New("черновик цели изменился в другом канале")
+)
+
+type ObjectiveDraft struct {
+	UserID		int64			`json:"-"`
+	Fields		map[string]interface{}	`json:"fields"`
+	Version		int			`json:"version"`
+	UpdatedVia	string			`json:"updated_via"`
+	UpdatedAt	time.Time		`json:"updated_at"`
+}
+
+func (d *ObjectiveDraft) Missing() []string {
+	var missing []string
+	for _, name := range ObjectiveDraftRequired {
+		if value, _ := d.Fields[name].(string); value == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func (d *ObjectiveDraft) Continues(fields map[string]interface{}, version int) bool {
+	if version != 0 {
+		return version == d.Version
+	}
+	title, _ := fields["title"].(string)
+	current, _ := d.Fields["title"].(string)
+	return title != "" && strings.EqualFold(strings.TrimSpace(title), strings.TrimSpace(current))
+}
+
+func (d *ObjectiveDraft) Merge(fields map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(d.Fields)+len(fields))
+	for name, value := range d.Fields {
+		merged[name] = value
+	}
+	for name, value := range fields {
+		if value == nil || value == "" {
+			continue
+		}
+		merged[name] = value
+	}
+	return merged
+}
+
+type objectiveDraftRow struct {
+	UserID		int64		`db:"user_id"`
+	Fields		[]byte		`db:"fields"`
+	Version		int		`db:"version"`
+	UpdatedVia	string		`db:"updated_via"`
+	UpdatedAt	time.Time	`db:"updated_at"`
+}
+
+func (row objectiveDraftRow) draft() (*ObjectiveDraft, error) {
+	draft := &ObjectiveDraft{UserID: row.UserID, Version: row.Version, UpdatedVia: row.UpdatedVia, UpdatedAt: row.UpdatedAt}
+	if err := json.Unmarshal(row.Fields, &draft.Fields); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении черновика цели: %v", err)
+	}
+	if draft.Fields == nil {
+		draft.Fields = map[string]interface{}{}
+	}
+	return draft, nil
+}
+
+func draftFields(fields map[string]interface{}) (string, error) {
+	clean := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if value == nil || value == "" {
+			continue
+		}
+		clean[name] = value
+	}
+	data, err := json.Marshal(clean)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при сериализации черновика цели: %v", err)
+	}
+	return string(data), nil
+}
+
+func (s *Service) GetObjectiveDraft(ctx context.Context, userID int64) (*ObjectiveDraft, error) {
+	var row objectiveDraftRow
+	err := s.db.GetContext(ctx, &row, `
+		SELECT user_id, fields, version, updated_via, updated_at FROM objective_drafts WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении черновика цели: %v", err)
+	}
+	return row.draft()
+}
+
+func (s *Service) SaveObjectiveDraft(ctx context.Context, userID int64, fields map[string]interface{}, version int, via string) (*ObjectiveDraft, error) {
+	data, err := draftFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	var row objectiveDraftRow
+	if version == 0 {
+		err = s.db.GetContext(ctx, &row, `
+			INSERT INTO objective_drafts (user_id, fields, updated_via) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO NOTHING
+			RETURNING user_id, fields, version, updated_via, updated_at
+		`, userID, data, via)
+	} else {
+		err = s.db.GetContext(ctx, &row, `
+			UPDATE objective_drafts SET fields = $3, version = version + 1, updated_via = $4, updated_at = NOW()
+			WHERE user_id = $1 AND version = $2
+			RETURNING user_id, fields, version, updated_via, updated_at
+		`, userID, version, data, via)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDraftConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении черновика цели: %v", err)
+	}
+	return row.draft()
+}
+
+func (s *Service) DeleteObjectiveDraft(ctx context.Context, userID int64, version int) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM objective_drafts WHERE user_id = $1 AND ($2 = 0 OR version = $2)
+	`, userID, version)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении черновика цели: %v", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 && version != 0 {
+		return ErrDraftConflict
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package okr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func newDraftService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("не удалось создать sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewService(sqlx.NewDb(db, "postgres")), mock
+}
+
+func draftRows(fields string, version int, via string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"user_id", "fields", "version", "updated_via", "updated_at"}).
+		AddRow(int64(1), []byte(fields), version, via, time.Now())
+}
+
+func TestObjectiveDraftMissing(t *testing.T) {
+	tests := []struct {
+		name	string
+		fields	map[string]interface{}
+		want	[]string
+	}{
+		{"empty", map[string]interface{}{}, []string{"title", "sphere", "period", "deadline"}},
+		{"title only", map[string]interface{}{"title": "Марафон"}, []string{"sphere", "period", "deadline"}},
+		{"blank value", map[string]interface{}{"title": "Марафон", "sphere": "", "period": "month", "deadline": "2026-12-01"}, []string{"sphere"}},
+		{"complete", map[string]interface{}{"title": "Марафон", "sphere": "Здоровье", "period": "month", "deadline": "2026-12-01"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft := &ObjectiveDraft{Fields: tt.fields}
+			if got := draft.Missing(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Missing() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectiveDraftContinues(t *testing.T) {
+	draft := &ObjectiveDraft{Fields: map[string]interface{}{"title": "Марафон", "deadline": "2026-12-01"}, Version: 3}
+	tests := []struct {
+		name	string
+		fields	map[string]interface{}
+		version	int
+		want	bool
+	}{
+		{"same title", map[string]interface{}{"title": " марафон "}, 0, true},
+		{"current version", map[string]interface{}{"sphere": "Здоровье"}, 3, true},
+		{"stale version", map[string]interface{}{"title": "Марафон"}, 2, false},
+		{"new title", map[string]interface{}{"title": "Выучить испанский"}, 0, false},
+		{"no title and no version", map[string]interface{}{"sphere": "Здоровье"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := draft.Continues(tt.fields, tt.version); got != tt.want {
+				t.Fatalf("Continues() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectiveDraftMergeSkipsBlankFields(t *testing.T) {
+	draft := &ObjectiveDraft{Fields: map[string]interface{}{"title": "Марафон", "sphere": "Здоровье"}}
+	merged := draft.Merge(map[string]interface{}{"sphere": "", "deadline": nil, "period": "month"})
+
+	want := map[string]interface{}{"title": "Марафон", "sphere": "Здоровье", "period": "month"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("Merge() = %v, ожидалось %v", merged, want)
+	}
+	if _, ok := draft.Fields["period"]; ok {
+		t.Fatal("Merge не должен менять сохраненный черновик")
+	}
+}
+
+func TestSaveObjectiveDraftConflict(t *testing.T) {
+	tests := []struct {
+		name	string
+		version	int
+		query	string
+	}{
+		{"stale version", 3, "UPDATE objective_drafts"},
+		{"draft already started in another channel", 0, "INSERT INTO objective_drafts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newDraftService(t)
+			mock.ExpectQuery(regexp.QuoteMeta(tt.query)).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id", "fields", "version", "updated_via", "updated_at"}))
+
+			_, err := s.SaveObjectiveDraft(context.Background(), 1, map[string]interface{}{"title": "Марафон"}, tt.version, DraftViaWeb)
+			if !errors.Is(err, ErrDraftConflict) {
+				t.Fatalf("ожидался конфликт, получено %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveObjectiveDraftCurrentVersion(t *testing.T) {
+	s, mock := newDraftService(t)
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE objective_drafts")).
+		WithArgs(int64(1), 3, `{"period":"month","title":"Марафон"}`, DraftViaWeb).
+		WillReturnRows(draftRows(`{"period":"month","title":"Марафон"}`, 4, DraftViaWeb))
+
+	draft, err := s.SaveObjectiveDraft(context.Background(), 1, map[string]interface{}{"title": "Марафон", "period": "month"}, 3, DraftViaWeb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft.Version != 4 || draft.UpdatedVia != DraftViaWeb {
+		t.Fatalf("ожидалась версия 4 из веба, получено %+v", draft)
+	}
+}
+
+func TestDeleteObjectiveDraft(t *testing.T) {
+	tests := []struct {
+		name	string
+		version	int
+		deleted	int64
+		wantErr	error
+	}{
+		{"current version", 4, 1, nil},
+		{"stale version", 3, 0, ErrDraftConflict},
+		{"unconditional without draft", 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newDraftService(t)
+			mock.ExpectExec(regexp.QuoteMeta("DELETE FROM objective_drafts")).
+				WithArgs(int64(1), tt.version).
+				WillReturnResult(sqlmock.NewResult(0, tt.deleted))
+
+			if err := s.DeleteObjectiveDraft(context.Background(), 1, tt.version); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ожидалась ошибка %v, получено %v", tt.wantErr, err)
+			}
+		})
+	}
+}
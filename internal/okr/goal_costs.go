@@ -0,0 +1,145 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GoalCost struct {
+	ObjectiveID	string	`db:"objective_id" json:"objective_id"`
+	Title		string	`db:"title" json:"title"`
+	Sphere		string	`db:"sphere" json:"sphere"`
+	Progress	float64	`db:"progress" json:"progress"`
+	Invested	float64	`db:"invested" json:"invested"`
+	Transactions	int	`db:"transactions" json:"transactions"`
+}
+
+type QuarterRetrospective struct {
+	Quarter		string			`json:"quarter"`
+	From		time.Time		`json:"from"`
+	To		time.Time		`json:"to"`
+	TotalInvested	float64			`json:"total_invested"`
+	Goals		[]GoalCost		`json:"goals"`
+	DownRevised	[]DownRevisedKeyResult	`json:"down_revised"`
+}
+
+func (g GoalCost) CostPerPercent() float64 {
+	if g.Progress <= 0 {
+		return 0
+	}
+	return g.Invested / g.Progress
+}
+
+func (s *Service) getObjectiveInvestments(ctx context.Context, objectiveID string) (float64, map[int64]float64, error) {
+	var rows []struct {
+		KeyResultID	int64	`db:"key_result_id"`
+		Invested	float64	`db:"invested"`
+	}
+	err := s.db.SelectContext(ctx, &rows, `
+		SELECT COALESCE(key_result_id, 0) AS key_result_id, -SUM(amount) AS invested
+		FROM transactions
+		WHERE objective_id = $1 AND amount < 0
+		GROUP BY COALESCE(key_result_id, 0)
+	`, objectiveID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("ошибка при подсчете вложений в цель: %v", err)
+	}
+
+	total := 0.0
+	byKeyResult := make(map[int64]float64)
+	for _, row := range rows {
+		if row.KeyResultID != 0 {
+			byKeyResult[row.KeyResultID] = row.Invested
+		}
+		total += row.Invested
+	}
+	return total, byKeyResult, nil
+}
+
+func ParseQuarter(value string, now time.Time) (string, time.Time, time.Time, error) {
+	year, quarter := now.Year(), (int(now.Month())-1)/3+1
+	if value = strings.ToUpper(strings.TrimSpace(value)); value != "" {
+		yearPart, quarterPart, ok := strings.Cut(value, "-Q")
+		y, errYear := strconv.Atoi(yearPart)
+		q, errQuarter := strconv.Atoi(quarterPart)
+		if !ok || errYear != nil || errQuarter != nil || q < 1 || q > 4 {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("некорректный квартал %q, ожидается формат 2026-Q3", value)
+		}
+		year, quarter = y, q
+	}
+
+	from := time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, now.Location())
+	return fmt.Sprintf("%d-Q%d", year, quarter), from, from.AddDate(0, 3, 0), nil
+}
+
+func (s *Service) GetQuarterRetrospective(ctx context.Context, userID int64, quarter string) (*QuarterRetrospective, error) {
+	label, from, to, err := ParseQuarter(quarter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	goals := make([]GoalCost, 0)
+	err = s.db.SelectContext(ctx, &goals, `
+		SELECT o.id AS objective_id, o.title, COALESCE(o.sphere, '') AS sphere,
+			COALESCE((
+				SELECT AVG(CASE WHEN kr.target > 0 THEN LEAST(kr.progress / kr.target, 1) * 100 ELSE 0 END)
+				FROM key_results kr WHERE kr.objective_id = o.id AND kr.deleted_at IS NULL
+			), 0) AS progress,
+			COALESCE(-SUM(t.amount) FILTER (WHERE t.amount < 0), 0) AS invested,
+			COUNT(t.id) AS transactions
+		FROM objectives o
+		LEFT JOIN transactions t ON t.objective_id = o.id AND t.created_at >= $2 AND t.created_at < $3
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL
+		  AND (t.id IS NOT NULL OR (o.deadline >= $2 AND o.deadline < $3) OR (o.created_at >= $2 AND o.created_at < $3))
+		GROUP BY o.id, o.title, o.sphere
+		ORDER BY invested DESC, o.title
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете затрат по целям: %v", err)
+	}
+
+	downRevised, err := s.GetDownRevisedKeyResults(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	retro := &QuarterRetrospective{Quarter: label, From: from, To: to, Goals: goals, DownRevised: downRevised}
+	for _, g := range goals {
+		retro.TotalInvested += g.Invested
+	}
+	return retro, nil
+}
+
+func FormatQuarterRetrospective(retro *QuarterRetrospective) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📆 Ретроспектива %s\n\n", retro.Quarter))
+	if len(retro.Goals) == 0 {
+		b.WriteString("За этот квартал нет целей и связанных с ними трат")
+		return b.String()
+	}
+
+	for _, g := range retro.Goals {
+		b.WriteString(fmt.Sprintf("🎯 %s — %.0f%%\n", g.Title, g.Progress))
+		if g.Invested == 0 {
+			b.WriteString("   💸 Вложений нет\n")
+			continue
+		}
+		b.WriteString(fmt.Sprintf("   💸 Вложено: %.2f (%d операций)\n", g.Invested, g.Transactions))
+		if cost := g.CostPerPercent(); cost > 0 {
+			b.WriteString(fmt.Sprintf("   📐 Цена 10%% прогресса: %.2f\n", cost*10))
+		}
+	}
+	b.WriteString(fmt.Sprintf("\nВсего вложено в цели: %.2f", retro.TotalInvested))
+
+	if len(retro.DownRevised) > 0 {
+		b.WriteString("\n\n⚠️ Планку часто понижали:\n")
+		for _, kr := range retro.DownRevised {
+			b.WriteString(fmt.Sprintf("• %s («%s»): %d раз, %s → %s\n", kr.Title, kr.ObjectiveTitle, kr.Revisions, formatFloat(kr.OriginalTarget), formatFloat(kr.Target)))
+		}
+		b.WriteString("Возможно, цели ставятся слишком амбициозно или мешает что-то системное")
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ObjectiveSummary struct {
+	ID		string		`db:"id"`
+	Title		string		`db:"title"`
+	Sphere		string		`db:"sphere"`
+	Period		string		`db:"period"`
+	Status		string		`db:"status"`
+	Deadline	*time.Time	`db:"deadline"`
+	KeyResultsCount	int		`db:"key_results_count"`
+	Progress	float64		`db:"avg_progress"`
+	Total		int		`db:"total"`
+}
+
+type ObjectiveFilter struct {
+	Period			string
+	Status			string
+	Sphere			string
+	DeadlineWithinDays	int
+}
+
+const objectiveListCondition = `o.user_id = $1 AND o.deleted_at IS NULL
+	AND ($2 = 'all' OR o.period = $2)
+	AND ($3 = 'all' OR o.status = $3 OR ($3 = 'open' AND NOT ` + ArchivedObjectiveCondition + `))
+	AND ($4 = '' OR o.sphere = $4)
+	AND ($5 = 0 OR (o.deadline IS NOT NULL AND o.deadline < CURRENT_DATE + $5 + 1))`
+
+func (s *Service) ListObjectiveSummaries(ctx context.Context, userID int64, filter ObjectiveFilter, limit, offset int) ([]ObjectiveSummary, int, error) {
+	if filter.Period == "" {
+		filter.Period = "all"
+	}
+	if filter.Status == "" {
+		filter.Status = ObjectiveStatusOpen
+	}
+
+	var summaries []ObjectiveSummary
+	err := s.db.SelectContext(ctx, &summaries, `
+		SELECT o.id, o.title, COALESCE(o.sphere, '') AS sphere, COALESCE(o.period, '') AS period,
+			COALESCE(o.status, '') AS status, o.deadline,
+			COUNT(kr.id) AS key_results_count,
+			COALESCE(AVG(CASE WHEN kr.target > 0 THEN (kr.progress::float / kr.target::float) * 100 END), 0) AS avg_progress,
+			COUNT(*) OVER () AS total
+		FROM objectives o
+		LEFT JOIN key_results kr ON o.id = kr.objective_id AND kr.deleted_at IS NULL
+		WHERE `+objectiveListCondition+`
+		GROUP BY o.id
+		ORDER BY CASE WHEN $5 > 0 THEN o.deadline END ASC, o.created_at DESC
+		LIMIT $6 OFFSET $7
+	`, userID, filter.Period, filter.Status, filter.Sphere, filter.DeadlineWithinDays, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении целей: %v", err)
+	}
+
+	total := 0
+	if len(summaries) > 0 {
+		total = summaries[0].Total
+	} else if offset > 0 {
+		err := s.db.GetContext(ctx, &total, `
+			SELECT COUNT(*) FROM objectives o WHERE `+objectiveListCondition+`
+		`, userID, filter.Period, filter.Status, filter.Sphere, filter.DeadlineWithinDays)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка при подсчете целей: %v", err)
+		}
+	}
+	return summaries, total, nil
+}
+
+func (s *Service) GetKeyResultsForObjectives(ctx context.Context, objectiveIDs []string) (map[string][]KeyResult, error) {
+	result := make(map[string][]KeyResult)
+	if len(objectiveIDs) == 0 {
+		return result, nil
+	}
+
+	var keyResults []KeyResult
+	err := s.db.SelectContext(ctx, &keyResults, `
+		SELECT id, objective_id, title, target, unit, progress, deadline, created_at
+		FROM key_results
+		WHERE objective_id = ANY($1) AND deleted_at IS NULL
+		ORDER BY created_at ASC
+	`, pq.Array(objectiveIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ключевых результатов: %v", err)
+	}
+
+	for _, kr := range keyResults {
+		result[kr.ObjectiveID] = append(result[kr.ObjectiveID], kr)
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RecurringTaskPlan struct {
+	Title		string
+	DailyTarget	float64
+	Unit		string
+	StartDate	time.Time
+	EndDate		time.Time
+}
+
+type KeyResultPlan struct {
+	KeyResult
+	Recurring	*RecurringTaskPlan
+}
+
+type ObjectivePlan struct {
+	Title		string
+	Sphere		string
+	Period		string
+	Deadline	*time.Time
+	KeyResults	[]KeyResultPlan
+}
+
+type CreatedObjective struct {
+	ObjectiveID	string
+	Sphere		string
+	KeyResultIDs	[]int64
+	TaskIDs		[]int64
+}
+
+func (s *Service) CreateObjectivePlan(ctx context.Context, userID int64, plan ObjectivePlan) (*CreatedObjective, error) {
+	sphere, err := s.NormalizeSphere(ctx, userID, plan.Sphere)
+	if err != nil {
+		return nil, err
+	}
+	created := &CreatedObjective{ObjectiveID: uuid.New().String(), Sphere: sphere}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO objectives (id, user_id, title, sphere, period, deadline, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, created.ObjectiveID, userID, plan.Title, sphere, plan.Period, plan.Deadline, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении цели: %v", err)
+	}
+
+	for _, kr := range plan.KeyResults {
+		var keyResultID int64
+		err = tx.GetContext(ctx, &keyResultID, `
+			INSERT INTO key_results (objective_id, title, target, unit, progress, deadline, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`, created.ObjectiveID, kr.Title, kr.Target, kr.Unit, kr.Progress, kr.Deadline, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сохранении ключевого результата: %v", err)
+		}
+		created.KeyResultIDs = append(created.KeyResultIDs, keyResultID)
+
+		if kr.Recurring == nil {
+			continue
+		}
+		taskIDs, err := insertDailyTasks(ctx, tx, keyResultID, kr.Recurring.Title, kr.Recurring.DailyTarget, kr.Recurring.Unit, kr.Recurring.StartDate, kr.Recurring.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		created.TaskIDs = append(created.TaskIDs, taskIDs...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+	return created, nil
+}
+
+func insertDailyTasks(ctx context.Context, tx *sqlx.Tx, keyResultID int64, title string, dailyTarget float64, unit string, startDate, endDate time.Time) ([]int64, error) {
+	taskIDs := []int64{}
+	seriesID := uuid.New().String()
+
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		var taskID int64
+		err := tx.GetContext(ctx, &taskID, `
+			INSERT INTO tasks (key_result_id, title, target, unit, progress, deadline, created_at,
+				is_recurring, recurrence_pattern, recurrence_series)
+			VALUES ($1, $2, $3, $4, 0, $5, $6, TRUE, $7, $8)
+			RETURNING id
+		`, keyResultID, fmt.Sprintf("%s (%s)", title, day.Format("02.01.2006")), dailyTarget, unit,
+			time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, day.Location()),
+			time.Now(), RecurrenceDaily, seriesID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при создании задачи: %v", err)
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+	return taskIDs, nil
+}
@@ -2,16 +2,23 @@ package okr
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/health"
+	"telegrambot/internal/ownership"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
 )
 
 type Service struct {
-	db *sqlx.DB
+	db	*sqlx.DB
+	health	*health.Service
+	focus	*focus.Service
 }
 
 type Objective struct {
@@ -48,63 +55,30 @@ type Task struct {
 
 func NewService(db *sqlx.DB) *Service {
 	return &Service{
-		db: db,
+		db:	db,
+		health:	health.NewService(db),
+		focus:	focus.NewService(db),
 	}
 }
 
 func (s *Service) CreateObjective(ctx context.Context, userID int64, title, sphere, period string, deadline *time.Time, keyResults []KeyResult) (string, error) {
-
-	objectiveID := uuid.New().String()
-
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return "", fmt.Errorf("ошибка при начале транзакции: %v", err)
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	query := `
-		INSERT INTO objectives (id, user_id, title, sphere, period, deadline, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
-
-	_, err = tx.ExecContext(ctx, query, objectiveID, userID, title, sphere, period, deadline, time.Now())
-	if err != nil {
-		return "", fmt.Errorf("ошибка при сохранении цели: %v", err)
-	}
-
+	plan := ObjectivePlan{Title: title, Sphere: sphere, Period: period, Deadline: deadline}
 	for _, kr := range keyResults {
-		krQuery := `
-			INSERT INTO key_results (objective_id, title, target, unit, progress, deadline, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`
-
-		_, err = tx.ExecContext(ctx, krQuery, objectiveID, kr.Title, kr.Target, kr.Unit, kr.Progress, kr.Deadline, time.Now())
-		if err != nil {
-			return "", fmt.Errorf("ошибка при сохранении ключевого результата: %v", err)
-		}
+		plan.KeyResults = append(plan.KeyResults, KeyResultPlan{KeyResult: kr})
 	}
 
-	err = tx.Commit()
+	created, err := s.CreateObjectivePlan(ctx, userID, plan)
 	if err != nil {
-		return "", fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+		return "", err
 	}
-
-	return objectiveID, nil
+	return created.ObjectiveID, nil
 }
 
 func (s *Service) CreateKeyResult(ctx context.Context, userID int64, objectiveID string, title string, target float64, unit string, deadline *time.Time) (int64, error) {
 
-	checkQuery := `
-		SELECT id FROM objectives WHERE id = $1 AND user_id = $2
-	`
-	var id string
-	err := s.db.GetContext(ctx, &id, checkQuery, objectiveID, userID)
+	err := ownership.MustOwnObjective(ctx, s.db, userID, objectiveID)
 	if err != nil {
-		return 0, fmt.Errorf("цель не найдена или не принадлежит пользователю: %v", err)
+		return 0, err
 	}
 
 	query := `
@@ -135,16 +109,9 @@ func (s *Service) CreateKeyResult(ctx context.Context, userID int64, objectiveID
 
 func (s *Service) CreateTask(ctx context.Context, userID int64, keyResultID int64, title string, target float64, unit string, deadline *time.Time) (int64, error) {
 
-	checkQuery := `
-		SELECT kr.id
-		FROM key_results kr
-		JOIN objectives o ON kr.objective_id = o.id
-		WHERE kr.id = $1 AND o.user_id = $2
-	`
-	var id int64
-	err := s.db.GetContext(ctx, &id, checkQuery, keyResultID, userID)
+	err := ownership.MustOwnKeyResult(ctx, s.db, userID, keyResultID)
 	if err != nil {
-		return 0, fmt.Errorf("ключевой результат не найден или не принадлежит пользователю: %v", err)
+		return 0, err
 	}
 
 	query := `
@@ -175,10 +142,10 @@ func (s *Service) CreateTask(ctx context.Context, userID int64, keyResultID int6
 
 func (s *Service) GetObjectives(ctx context.Context, userID int64) ([]Objective, error) {
 	query := `
-		SELECT id, user_id, title, sphere, period, deadline, created_at
-		FROM objectives
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		SELECT o.id, o.user_id, o.title, o.sphere, o.period, o.deadline, o.created_at
+		FROM objectives o
+		WHERE o.user_id = $1 AND o.deleted_at IS NULL AND NOT ` + ArchivedObjectiveCondition + `
+		ORDER BY o.created_at DESC
 	`
 
 	var objectives []Objective
@@ -194,7 +161,7 @@ func (s *Service) GetKeyResults(ctx context.Context, objectiveID string) ([]KeyR
 	query := `
 		SELECT id, objective_id, title, target, unit, progress, deadline, created_at
 		FROM key_results
-		WHERE objective_id = $1
+		WHERE objective_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`
 
@@ -211,7 +178,7 @@ func (s *Service) GetTasks(ctx context.Context, keyResultID int64) ([]Task, erro
 	query := `
 		SELECT id, key_result_id, title, target, unit, progress, deadline, created_at
 		FROM tasks
-		WHERE key_result_id = $1
+		WHERE key_result_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at ASC
 	`
 
@@ -230,7 +197,7 @@ func (s *Service) UpdateKeyResultProgress(ctx context.Context, userID int64, key
 		SELECT kr.id, kr.target
 		FROM key_results kr
 		JOIN objectives o ON kr.objective_id = o.id
-		WHERE kr.id = $1 AND o.user_id = $2
+		WHERE kr.id = $1 AND kr.deleted_at IS NULL AND ` + KeyResultAccessCondition("$2") + `
 	`
 
 	type result struct {
@@ -266,45 +233,39 @@ func (s *Service) UpdateKeyResultProgress(ctx context.Context, userID int64, key
 		WHERE id = $2
 	`
 
-	_, err = s.db.ExecContext(ctx, updateQuery, newProgress, keyResultID)
+	err = WithProgressAuthor(ctx, s.db, userID, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, updateQuery, newProgress, keyResultID)
+		return err
+	})
 	if err != nil {
 		return false, fmt.Errorf("ошибка при обновлении прогресса: %v", err)
 	}
 
+	if _, err := s.ReconcileDailyTasks(ctx, keyResultID, progress); err != nil {
+		logrus.Warnf("Не удалось сверить задачи на сегодня с ключевым результатом %d: %v", keyResultID, err)
+	}
+
 	return exceeded, nil
 }
 
 func (s *Service) UpdateTaskProgress(ctx context.Context, userID int64, taskID int64, progress float64) (bool, error) {
 
-	checkQuery := `
-		SELECT t.id, t.target
-		FROM tasks t
-		JOIN key_results kr ON t.key_result_id = kr.id
-		JOIN objectives o ON kr.objective_id = o.id
-		WHERE t.id = $1 AND o.user_id = $2
-	`
+	if err := ownership.MustOwnTask(ctx, s.db, userID, taskID); err != nil {
+		return false, err
+	}
 
 	type result struct {
-		ID	int64	`db:"id"`
-		Target	float64	`db:"target"`
+		Target		float64	`db:"target"`
+		Progress	float64	`db:"progress"`
 	}
 
 	var res result
-	err := s.db.GetContext(ctx, &res, checkQuery, taskID, userID)
-	if err != nil {
-		return false, fmt.Errorf("задача не найдена или не принадлежит пользователю: %v", err)
-	}
-
-	currentProgressQuery := `
-		SELECT progress FROM tasks WHERE id = $1
-	`
-	var currentProgress float64
-	err = s.db.GetContext(ctx, &currentProgress, currentProgressQuery, taskID)
+	err := s.db.GetContext(ctx, &res, `SELECT target, progress FROM tasks WHERE id = $1`, taskID)
 	if err != nil {
 		return false, fmt.Errorf("ошибка при получении текущего прогресса: %v", err)
 	}
 
-	newProgress := currentProgress + progress
+	newProgress := res.Progress + progress
 
 	exceeded := false
 	if newProgress > res.Target {
@@ -313,7 +274,8 @@ func (s *Service) UpdateTaskProgress(ctx context.Context, userID int64, taskID i
 
 	updateQuery := `
 		UPDATE tasks
-		SET progress = $1
+		SET progress = $1,
+			completion_date = CASE WHEN $1 >= target THEN COALESCE(completion_date, NOW()) ELSE NULL END
 		WHERE id = $2
 	`
 
@@ -350,12 +312,19 @@ func (s *Service) GetObjectiveProgress(ctx context.Context, objectiveID string)
 type ObjectiveDetails struct {
 	Objective	Objective
 	Progress	float64
+	Invested	float64
+	TrackedMinutes	int
 	KeyResults	[]KeyResultDetails
+	Closing		*ObjectiveClosing
 }
 
 type KeyResultDetails struct {
 	KeyResult	KeyResult
 	Progress	float64
+	Invested	float64
+	TrackedMinutes	int
+	TaskMinutes	map[int64]int
+	Revisions	[]TargetRevision
 	Tasks		[]Task
 }
 
@@ -364,7 +333,7 @@ func (s *Service) GetObjectiveDetails(ctx context.Context, userID int64, objecti
 	objectiveQuery := `
 		SELECT id, user_id, title, sphere, period, deadline, created_at
 		FROM objectives
-		WHERE id = $1 AND user_id = $2
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 	var objective Objective
 	err := s.db.GetContext(ctx, &objective, objectiveQuery, objectiveID, userID)
@@ -382,10 +351,37 @@ func (s *Service) GetObjectiveDetails(ctx context.Context, userID int64, objecti
 		return nil, err
 	}
 
+	invested, investedByKeyResult, err := s.getObjectiveInvestments(ctx, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked, err := s.focus.GetTrackedMinutes(ctx, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyResultIDs := make([]int64, 0, len(keyResults))
+	for _, kr := range keyResults {
+		keyResultIDs = append(keyResultIDs, kr.ID)
+	}
+	revisions, err := s.GetTargetRevisions(ctx, keyResultIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	closing, err := s.GetObjectiveClosing(ctx, objectiveID)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &ObjectiveDetails{
 		Objective:	objective,
 		Progress:	objectiveProgress,
+		Invested:	invested,
+		TrackedMinutes:	tracked.Total,
 		KeyResults:	make([]KeyResultDetails, 0, len(keyResults)),
+		Closing:	closing,
 	}
 
 	for _, kr := range keyResults {
@@ -402,9 +398,20 @@ func (s *Service) GetObjectiveDetails(ctx context.Context, userID int64, objecti
 			}
 		}
 
+		taskMinutes := make(map[int64]int)
+		for _, task := range tasks {
+			if minutes := tracked.ByTask[task.ID]; minutes > 0 {
+				taskMinutes[task.ID] = minutes
+			}
+		}
+
 		result.KeyResults = append(result.KeyResults, KeyResultDetails{
 			KeyResult:	kr,
 			Progress:	krProgress,
+			Invested:	investedByKeyResult[kr.ID],
+			TrackedMinutes:	tracked.ByKeyResult[kr.ID],
+			TaskMinutes:	taskMinutes,
+			Revisions:	revisions[kr.ID],
 			Tasks:		tasks,
 		})
 	}
@@ -414,13 +421,9 @@ func (s *Service) GetObjectiveDetails(ctx context.Context, userID int64, objecti
 
 func (s *Service) DeleteObjective(ctx context.Context, userID int64, objectiveID string) error {
 
-	checkQuery := `
-		SELECT id FROM objectives WHERE id = $1 AND user_id = $2
-	`
-	var id string
-	err := s.db.GetContext(ctx, &id, checkQuery, objectiveID, userID)
+	err := ownership.MustOwnObjective(ctx, s.db, userID, objectiveID)
 	if err != nil {
-		return fmt.Errorf("цель не найдена или не принадлежит пользователю: %v", err)
+		return err
 	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
@@ -434,9 +437,9 @@ func (s *Service) DeleteObjective(ctx context.Context, userID int64, objectiveID
 	}()
 
 	deleteTasks := `
-		DELETE FROM tasks
-		WHERE key_result_id IN (
-			SELECT id FROM key_results WHERE objective_id = $1
+		UPDATE tasks SET deleted_at = NOW()
+		WHERE deleted_at IS NULL AND key_result_id IN (
+			SELECT id FROM key_results WHERE objective_id = $1 AND deleted_at IS NULL
 		)
 	`
 	_, err = tx.ExecContext(ctx, deleteTasks, objectiveID)
@@ -445,8 +448,8 @@ func (s *Service) DeleteObjective(ctx context.Context, userID int64, objectiveID
 	}
 
 	deleteKeyResults := `
-		DELETE FROM key_results
-		WHERE objective_id = $1
+		UPDATE key_results SET deleted_at = NOW()
+		WHERE objective_id = $1 AND deleted_at IS NULL
 	`
 	_, err = tx.ExecContext(ctx, deleteKeyResults, objectiveID)
 	if err != nil {
@@ -454,10 +457,10 @@ func (s *Service) DeleteObjective(ctx context.Context, userID int64, objectiveID
 	}
 
 	deleteObjective := `
-		DELETE FROM objectives
-		WHERE id = $1
+		UPDATE objectives SET deleted_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
-	_, err = tx.ExecContext(ctx, deleteObjective, objectiveID)
+	_, err = tx.ExecContext(ctx, deleteObjective, objectiveID, userID)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении цели: %v", err)
 	}
@@ -472,16 +475,9 @@ func (s *Service) DeleteObjective(ctx context.Context, userID int64, objectiveID
 
 func (s *Service) DeleteKeyResult(ctx context.Context, userID int64, keyResultID int64) error {
 
-	checkQuery := `
-		SELECT kr.id
-		FROM key_results kr
-		JOIN objectives o ON kr.objective_id = o.id
-		WHERE kr.id = $1 AND o.user_id = $2
-	`
-	var id int64
-	err := s.db.GetContext(ctx, &id, checkQuery, keyResultID, userID)
+	err := ownership.MustOwnKeyResult(ctx, s.db, userID, keyResultID)
 	if err != nil {
-		return fmt.Errorf("ключевой результат не найден или не принадлежит пользователю: %v", err)
+		return err
 	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
@@ -495,8 +491,8 @@ func (s *Service) DeleteKeyResult(ctx context.Context, userID int64, keyResultID
 	}()
 
 	deleteTasks := `
-		DELETE FROM tasks
-		WHERE key_result_id = $1
+		UPDATE tasks SET deleted_at = NOW()
+		WHERE key_result_id = $1 AND deleted_at IS NULL
 	`
 	_, err = tx.ExecContext(ctx, deleteTasks, keyResultID)
 	if err != nil {
@@ -504,10 +500,11 @@ func (s *Service) DeleteKeyResult(ctx context.Context, userID int64, keyResultID
 	}
 
 	deleteKeyResult := `
-		DELETE FROM key_results
-		WHERE id = $1
+		UPDATE key_results kr SET deleted_at = NOW()
+		FROM objectives o
+		WHERE kr.id = $1 AND kr.objective_id = o.id AND o.user_id = $2 AND kr.deleted_at IS NULL
 	`
-	_, err = tx.ExecContext(ctx, deleteKeyResult, keyResultID)
+	_, err = tx.ExecContext(ctx, deleteKeyResult, keyResultID, userID)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении ключевого результата: %v", err)
 	}
@@ -522,24 +519,17 @@ func (s *Service) DeleteKeyResult(ctx context.Context, userID int64, keyResultID
 
 func (s *Service) DeleteTask(ctx context.Context, userID int64, taskID int64) error {
 
-	checkQuery := `
-		SELECT t.id
-		FROM tasks t
-		JOIN key_results kr ON t.key_result_id = kr.id
-		JOIN objectives o ON kr.objective_id = o.id
-		WHERE t.id = $1 AND o.user_id = $2
-	`
-	var id int64
-	err := s.db.GetContext(ctx, &id, checkQuery, taskID, userID)
+	err := ownership.MustOwnTask(ctx, s.db, userID, taskID)
 	if err != nil {
-		return fmt.Errorf("задача не найдена или не принадлежит пользователю: %v", err)
+		return err
 	}
 
 	deleteTask := `
-		DELETE FROM tasks
-		WHERE id = $1
+		UPDATE tasks t SET deleted_at = NOW()
+		FROM key_results kr, objectives o
+		WHERE t.id = $1 AND t.key_result_id = kr.id AND kr.objective_id = o.id AND o.user_id = $2 AND t.deleted_at IS NULL
 	`
-	_, err = s.db.ExecContext(ctx, deleteTask, taskID)
+	_, err = s.db.ExecContext(ctx, deleteTask, taskID, userID)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении задачи: %v", err)
 	}
@@ -554,7 +544,7 @@ func (s *Service) FindObjectiveByDescription(ctx context.Context, userID int64,
 	query := `
 		SELECT id, user_id, title, sphere, period, deadline, created_at
 		FROM objectives
-		WHERE user_id = $1 AND LOWER(title) LIKE $2
+		WHERE user_id = $1 AND deleted_at IS NULL AND LOWER(title) LIKE $2
 		ORDER BY created_at DESC
 	`
 
@@ -581,7 +571,7 @@ func (s *Service) FindKeyResultByDescription(ctx context.Context, userID int64,
 			SELECT kr.id, kr.objective_id, kr.title, kr.target, kr.unit, kr.progress, kr.deadline, kr.created_at
 			FROM key_results kr
 			JOIN objectives o ON kr.objective_id = o.id
-			WHERE o.user_id = $1 AND LOWER(kr.title) LIKE $2 AND LOWER(o.title) LIKE $3
+			WHERE ` + KeyResultAccessCondition("$1") + ` AND kr.deleted_at IS NULL AND LOWER(kr.title) LIKE $2 AND LOWER(o.title) LIKE $3
 			ORDER BY kr.created_at DESC
 		`
 		args = []interface{}{userID, searchPattern, objSearchPattern}
@@ -591,7 +581,7 @@ func (s *Service) FindKeyResultByDescription(ctx context.Context, userID int64,
 			SELECT kr.id, kr.objective_id, kr.title, kr.target, kr.unit, kr.progress, kr.deadline, kr.created_at
 			FROM key_results kr
 			JOIN objectives o ON kr.objective_id = o.id
-			WHERE o.user_id = $1 AND LOWER(kr.title) LIKE $2
+			WHERE ` + KeyResultAccessCondition("$1") + ` AND kr.deleted_at IS NULL AND LOWER(kr.title) LIKE $2
 			ORDER BY kr.created_at DESC
 		`
 		args = []interface{}{userID, searchPattern}
@@ -620,7 +610,7 @@ func (s *Service) FindTaskByDescription(ctx context.Context, userID int64, taskD
 			FROM tasks t
 			JOIN key_results kr ON t.key_result_id = kr.id
 			JOIN objectives o ON kr.objective_id = o.id
-			WHERE o.user_id = $1 AND LOWER(t.title) LIKE $2 AND LOWER(kr.title) LIKE $3
+			WHERE o.user_id = $1 AND t.deleted_at IS NULL AND LOWER(t.title) LIKE $2 AND LOWER(kr.title) LIKE $3
 			ORDER BY t.created_at DESC
 		`
 		args = []interface{}{userID, searchPattern, krSearchPattern}
@@ -631,7 +621,7 @@ func (s *Service) FindTaskByDescription(ctx context.Context, userID int64, taskD
 			FROM tasks t
 			JOIN key_results kr ON t.key_result_id = kr.id
 			JOIN objectives o ON kr.objective_id = o.id
-			WHERE o.user_id = $1 AND LOWER(t.title) LIKE $2
+			WHERE o.user_id = $1 AND t.deleted_at IS NULL AND LOWER(t.title) LIKE $2
 			ORDER BY t.created_at DESC
 		`
 		args = []interface{}{userID, searchPattern}
@@ -653,7 +643,7 @@ func (s *Service) CreateRecurringTasks(ctx context.Context, userID int64, keyRes
         SELECT kr.id, kr.objective_id
         FROM key_results kr
         JOIN objectives o ON kr.objective_id = o.id
-        WHERE kr.id = $1 AND o.user_id = $2
+        WHERE kr.id = $1 AND o.user_id = $2 AND kr.deleted_at IS NULL
     `
 	var krResult struct {
 		ID		int64	`db:"id"`
@@ -674,45 +664,9 @@ func (s *Service) CreateRecurringTasks(ctx context.Context, userID int64, keyRes
 		}
 	}()
 
-	taskIDs := []int64{}
-	current := startDate
-
-	for !current.After(endDate) {
-
-		dateStr := current.Format("02.01.2006")
-		fullTitle := fmt.Sprintf("%s (%s)", taskTitle, dateStr)
-
-		deadline := time.Date(
-			current.Year(), current.Month(), current.Day(),
-			23, 59, 59, 0, current.Location(),
-		)
-
-		query := `
-            INSERT INTO tasks (key_result_id, title, target, unit, progress, deadline, created_at)
-            VALUES ($1, $2, $3, $4, $5, $6, $7)
-            RETURNING id
-        `
-
-		var taskID int64
-		err = tx.GetContext(
-			ctx,
-			&taskID,
-			query,
-			keyResultID,
-			fullTitle,
-			dailyTarget,
-			unit,
-			0.0,
-			deadline,
-			time.Now(),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка при создании задачи: %v", err)
-		}
-
-		taskIDs = append(taskIDs, taskID)
-
-		current = current.AddDate(0, 0, 1)
+	taskIDs, err := insertDailyTasks(ctx, tx, keyResultID, taskTitle, dailyTarget, unit, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
 	err = tx.Commit()
@@ -729,112 +683,207 @@ func (s *Service) CreateObjectiveWithRecurringTasks(ctx context.Context, userID
 	taskTitle string, dailyTarget float64, taskUnit string,
 	startDate, endDate time.Time) (string, int64, []int64, error) {
 
-	tx, err := s.db.BeginTxx(ctx, nil)
+	created, err := s.CreateObjectivePlan(ctx, userID, ObjectivePlan{
+		Title:		objectiveTitle,
+		Sphere:		sphere,
+		Period:		period,
+		Deadline:	objectiveDeadline,
+		KeyResults: []KeyResultPlan{{
+			KeyResult:	KeyResult{Title: keyResultTitle, Target: keyResultTarget, Unit: keyResultUnit, Deadline: keyResultDeadline},
+			Recurring:	&RecurringTaskPlan{Title: taskTitle, DailyTarget: dailyTarget, Unit: taskUnit, StartDate: startDate, EndDate: endDate},
+		}},
+	})
 	if err != nil {
-		return "", 0, nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+		return "", 0, nil, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
 
-	objectiveID := uuid.New().String()
+	return created.ObjectiveID, created.KeyResultIDs[0], created.TaskIDs, nil
+}
 
-	objectiveQuery := `
-		INSERT INTO objectives (id, user_id, title, sphere, period, deadline, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+func (s *Service) GetKeyResultsForObjective(ctx context.Context, objectiveID string) ([]KeyResult, error) {
+	query := `
+		SELECT id, objective_id, title, target, unit, progress, deadline, created_at
+		FROM key_results
+		WHERE objective_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at
 	`
-	_, err = tx.ExecContext(ctx, objectiveQuery, objectiveID, userID, objectiveTitle,
-		sphere, period, objectiveDeadline, time.Now())
+
+	var keyResults []KeyResult
+	err := s.db.SelectContext(ctx, &keyResults, query, objectiveID)
 	if err != nil {
-		return "", 0, nil, fmt.Errorf("ошибка при сохранении цели: %v", err)
+		return nil, fmt.Errorf("ошибка при получении ключевых результатов для цели %s: %v", objectiveID, err)
 	}
 
-	krQuery := `
-		INSERT INTO key_results (objective_id, title, target, unit, progress, deadline, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
+	return keyResults, nil
+}
+
+func (s *Service) GetOpenKeyResults(ctx context.Context, userID int64, limit int) ([]KeyResult, error) {
+	query := `
+		SELECT kr.id, kr.objective_id, kr.title, kr.target, kr.unit, kr.progress, kr.deadline, kr.created_at
+		FROM key_results kr
+		JOIN objectives o ON kr.objective_id = o.id
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND kr.progress < kr.target
+		  AND COALESCE(o.status, 'active') = 'active' AND o.completion_date IS NULL
+		ORDER BY kr.deadline NULLS LAST, kr.created_at DESC
+		LIMIT $2
 	`
-	var keyResultID int64
-	err = tx.GetContext(
-		ctx,
-		&keyResultID,
-		krQuery,
-		objectiveID,
-		keyResultTitle,
-		keyResultTarget,
-		keyResultUnit,
-		0.0,
-		keyResultDeadline,
-		time.Now(),
-	)
+
+	keyResults := make([]KeyResult, 0)
+	err := s.db.SelectContext(ctx, &keyResults, query, userID, limit)
 	if err != nil {
-		return "", 0, nil, fmt.Errorf("ошибка при создании ключевого результата: %v", err)
+		return nil, fmt.Errorf("ошибка при получении незавершенных ключевых результатов: %v", err)
 	}
 
-	taskIDs := []int64{}
-	current := startDate
+	return keyResults, nil
+}
 
-	for !current.After(endDate) {
+func (s *Service) GetKeyResultByID(ctx context.Context, userID int64, keyResultID int64) (*KeyResult, error) {
+	query := `
+		SELECT kr.id, kr.objective_id, kr.title, kr.target, kr.unit, kr.progress, kr.deadline, kr.created_at
+		FROM key_results kr
+		JOIN objectives o ON kr.objective_id = o.id
+		WHERE kr.id = $1 AND o.user_id = $2 AND kr.deleted_at IS NULL
+	`
 
-		dateStr := current.Format("02.01.2006")
-		fullTitle := fmt.Sprintf("%s (%s)", taskTitle, dateStr)
+	var keyResult KeyResult
+	err := s.db.GetContext(ctx, &keyResult, query, keyResultID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ключевой результат не найден или не принадлежит пользователю: %v", err)
+	}
 
-		taskDeadline := time.Date(
-			current.Year(), current.Month(), current.Day(),
-			23, 59, 59, 0, current.Location(),
-		)
+	return &keyResult, nil
+}
 
-		taskQuery := `
-			INSERT INTO tasks (key_result_id, title, target, unit, progress, deadline, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-			RETURNING id
-		`
+func (s *Service) GetTaskByID(ctx context.Context, userID int64, taskID int64) (*Task, error) {
+	query := `
+		SELECT t.id, t.key_result_id, t.title, t.target, t.unit, t.progress, t.deadline, t.created_at
+		FROM tasks t
+		JOIN key_results kr ON t.key_result_id = kr.id
+		JOIN objectives o ON kr.objective_id = o.id
+		WHERE t.id = $1 AND o.user_id = $2 AND t.deleted_at IS NULL
+	`
 
-		var taskID int64
-		err = tx.GetContext(
-			ctx,
-			&taskID,
-			taskQuery,
-			keyResultID,
-			fullTitle,
-			dailyTarget,
-			taskUnit,
-			0.0,
-			taskDeadline,
-			time.Now(),
-		)
+	var task Task
+	err := s.db.GetContext(ctx, &task, query, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("задача не найдена или не принадлежит пользователю: %v", err)
+	}
+
+	return &task, nil
+}
+
+func (s *Service) UpdateObjective(ctx context.Context, userID int64, objectiveID string, title, sphere, period *string, deadline *time.Time) error {
+	if sphere != nil {
+		normalized, err := s.NormalizeSphere(ctx, userID, *sphere)
 		if err != nil {
-			return "", 0, nil, fmt.Errorf("ошибка при создании задачи: %v", err)
+			return err
 		}
+		sphere = &normalized
+	}
 
-		taskIDs = append(taskIDs, taskID)
+	query := `
+		UPDATE objectives
+		SET title = COALESCE($1, title),
+			sphere = COALESCE($2, sphere),
+			period = COALESCE($3, period),
+			deadline = COALESCE($4, deadline),
+			updated_at = NOW()
+		WHERE id = $5 AND user_id = $6 AND deleted_at IS NULL
+	`
 
-		current = current.AddDate(0, 0, 1)
+	result, err := s.db.ExecContext(ctx, query, title, sphere, period, deadline, objectiveID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении цели: %v", err)
 	}
 
-	err = tx.Commit()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return "", 0, nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("цель не найдена или не принадлежит пользователю")
 	}
 
-	return objectiveID, keyResultID, taskIDs, nil
+	return nil
 }
 
-func (s *Service) GetKeyResultsForObjective(ctx context.Context, objectiveID string) ([]KeyResult, error) {
+func (s *Service) UpdateKeyResult(ctx context.Context, userID int64, keyResultID int64, title, unit *string, target, progress *float64, deadline *time.Time, targetReason string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var oldTarget float64
+	err = tx.GetContext(ctx, &oldTarget, `
+		SELECT kr.target
+		FROM key_results kr
+		JOIN objectives o ON kr.objective_id = o.id
+		WHERE kr.id = $1 AND o.user_id = $2 AND kr.deleted_at IS NULL
+		FOR UPDATE OF kr
+	`, keyResultID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("ключевой результат не найден или не принадлежит пользователю")
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при получении ключевого результата: %v", err)
+	}
+
 	query := `
-		SELECT id, objective_id, title, target, unit, progress, deadline, created_at
-		FROM key_results
-		WHERE objective_id = $1
-		ORDER BY created_at
+		UPDATE key_results
+		SET title = COALESCE($1, title),
+			unit = COALESCE($2, unit),
+			target = COALESCE($3, target),
+			progress = COALESCE($4, progress),
+			deadline = COALESCE($5, deadline),
+			updated_at = NOW()
+		WHERE id = $6
 	`
+	if _, err := tx.ExecContext(ctx, query, title, unit, target, progress, deadline, keyResultID); err != nil {
+		return fmt.Errorf("ошибка при обновлении ключевого результата: %v", err)
+	}
 
-	var keyResults []KeyResult
-	err := s.db.SelectContext(ctx, &keyResults, query, objectiveID)
+	if target != nil && *target != oldTarget {
+		if err := recordTargetRevision(ctx, tx, keyResultID, oldTarget, *target, targetReason); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при сохранении ключевого результата: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) UpdateTask(ctx context.Context, userID int64, taskID int64, title, unit *string, target, progress *float64, deadline *time.Time) error {
+	query := `
+		UPDATE tasks t
+		SET title = COALESCE($1, t.title),
+			unit = COALESCE($2, t.unit),
+			target = COALESCE($3, t.target),
+			progress = COALESCE($4, t.progress),
+			deadline = COALESCE($5, t.deadline),
+			completion_date = CASE WHEN COALESCE($4, t.progress) >= COALESCE($3, t.target) THEN COALESCE(t.completion_date, NOW()) ELSE NULL END,
+			updated_at = NOW()
+		FROM key_results kr
+		JOIN objectives o ON kr.objective_id = o.id
+		WHERE t.key_result_id = kr.id AND t.id = $6 AND o.user_id = $7 AND t.deleted_at IS NULL
+	`
+
+	result, err := s.db.ExecContext(ctx, query, title, unit, target, progress, deadline, taskID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении ключевых результатов для цели %s: %v", objectiveID, err)
+		return fmt.Errorf("ошибка при обновлении задачи: %v", err)
 	}
 
-	return keyResults, nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("задача не найдена или не принадлежит пользователю")
+	}
+
+	return nil
 }
@@ -0,0 +1,392 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPartnershipNotFound	= errors.New("партнерство не найдено")
+	ErrAlreadyPartners	= errors.New("вы уже партнеры или приглашение уже отправлено")
+	ErrSelfPartner		= errors.New("нельзя стать партнером самому себе")
+	ErrNotObjectiveOwner	= errors.New("цель принадлежит другому пользователю")
+)
+
+type Partnership struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	PartnerID	int64		`db:"partner_id" json:"partner_id"`
+	OtherID		int64		`db:"other_id" json:"other_id"`
+	OtherName	string		`db:"other_name" json:"other_name"`
+	Status		string		`db:"status" json:"status"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type PartnerObjective struct {
+	Objective
+	PartnershipID	int64	`db:"partnership_id" json:"partnership_id"`
+	OwnerName	string	`db:"owner_name" json:"owner_name"`
+	Progress	float64	`db:"-" json:"progress"`
+}
+
+const partnershipSelect = `
+	SELECT p.id, p.user_id, p.partner_id, p.status, p.created_at,
+		CASE WHEN p.user_id = $1 THEN p.partner_id ELSE p.user_id END AS other_id,
+		COALESCE(NULLIF(u.first_name, ''), u.username, '') AS other_name
+	FROM accountability_partners p
+	JOIN users u ON u.id = CASE WHEN p.user_id = $1 THEN p.partner_id ELSE p.user_id END
+`
+
+func (s *Service) InvitePartner(ctx context.Context, userID, partnerID int64) (*Partnership, error) {
+	if userID == partnerID {
+		return nil, ErrSelfPartner
+	}
+
+	var id int64
+	err := s.db.GetContext(ctx, &id, `
+		INSERT INTO accountability_partners (user_id, partner_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, userID, partnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAlreadyPartners
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании приглашения в партнеры: %v", err)
+	}
+
+	return s.getPartnership(ctx, userID, id)
+}
+
+func (s *Service) getPartnership(ctx context.Context, userID, partnershipID int64) (*Partnership, error) {
+	var p Partnership
+	err := s.db.GetContext(ctx, &p, partnershipSelect+`
+		WHERE p.id = $2 AND (p.user_id = $1 OR p.partner_id = $1)
+	`, userID, partnershipID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPartnershipNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении партнерства: %v", err)
+	}
+	return &p, nil
+}
+
+func (s *Service) GetPendingPartnerInvitations(ctx context.Context, userID int64) ([]Partnership, error) {
+	var invitations []Partnership
+	err := s.db.SelectContext(ctx, &invitations, partnershipSelect+`
+		WHERE p.partner_id = $1 AND p.status = 'pending'
+		ORDER BY p.created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении приглашений в партнеры: %v", err)
+	}
+	return invitations, nil
+}
+
+func (s *Service) RespondToPartnerInvitation(ctx context.Context, userID, partnershipID int64, accept bool) (*Partnership, error) {
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE accountability_partners
+		SET status = $3, responded_at = NOW()
+		WHERE id = $1 AND partner_id = $2 AND status = 'pending'
+	`, partnershipID, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при ответе на приглашение в партнеры: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrPartnershipNotFound
+	}
+
+	return s.getPartnership(ctx, userID, partnershipID)
+}
+
+func (s *Service) GetPartners(ctx context.Context, userID int64) ([]Partnership, error) {
+	var partners []Partnership
+	err := s.db.SelectContext(ctx, &partners, partnershipSelect+`
+		WHERE (p.user_id = $1 OR p.partner_id = $1) AND p.status = 'accepted'
+		ORDER BY p.responded_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении партнеров: %v", err)
+	}
+	return partners, nil
+}
+
+func (s *Service) FindPartner(ctx context.Context, userID int64, name string) (*Partnership, error) {
+	partners, err := s.GetPartners(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	needle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+	if needle == "" {
+		if len(partners) == 1 {
+			return &partners[0], nil
+		}
+		return nil, ErrPartnershipNotFound
+	}
+
+	for i := range partners {
+		if strings.Contains(strings.ToLower(partners[i].OtherName), needle) {
+			return &partners[i], nil
+		}
+	}
+
+	var otherID int64
+	err = s.db.GetContext(ctx, &otherID, `SELECT id FROM users WHERE LOWER(username) = $1`, needle)
+	if err == nil {
+		for i := range partners {
+			if partners[i].OtherID == otherID {
+				return &partners[i], nil
+			}
+		}
+	}
+	return nil, ErrPartnershipNotFound
+}
+
+func (s *Service) EndPartnership(ctx context.Context, userID, partnershipID int64) (*Partnership, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE accountability_partners
+		SET status = 'ended', ended_at = NOW()
+		WHERE id = $1 AND (user_id = $2 OR partner_id = $2) AND status = 'accepted'
+	`, partnershipID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при завершении партнерства: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrPartnershipNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE partner_shared_objectives SET revoked_at = NOW()
+		WHERE partnership_id = $1 AND revoked_at IS NULL
+	`, partnershipID); err != nil {
+		return nil, fmt.Errorf("ошибка при закрытии доступа к целям: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+	return s.getPartnership(ctx, userID, partnershipID)
+}
+
+func (s *Service) ShareObjectiveWithPartner(ctx context.Context, userID int64, objectiveID string, partnershipID int64) error {
+	var ownerID int64
+	if err := s.db.GetContext(ctx, &ownerID, `SELECT user_id FROM objectives WHERE id = $1 AND deleted_at IS NULL`, objectiveID); err != nil {
+		return fmt.Errorf("цель не найдена: %v", err)
+	}
+	if ownerID != userID {
+		return ErrNotObjectiveOwner
+	}
+
+	partnership, err := s.getPartnership(ctx, userID, partnershipID)
+	if err != nil {
+		return err
+	}
+	if partnership.Status != "accepted" {
+		return ErrPartnershipNotFound
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO partner_shared_objectives (partnership_id, objective_id, owner_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, partnershipID, objectiveID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии цели партнеру: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) RevokeObjectiveFromPartner(ctx context.Context, userID int64, objectiveID string, partnershipID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE partner_shared_objectives SET revoked_at = NOW()
+		WHERE partnership_id = $1 AND objective_id = $2 AND owner_id = $3 AND revoked_at IS NULL
+	`, partnershipID, objectiveID, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при закрытии доступа к цели: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (s *Service) getPartnerObjectives(ctx context.Context, condition string, args ...interface{}) ([]PartnerObjective, error) {
+	objectives := make([]PartnerObjective, 0)
+	err := s.db.SelectContext(ctx, &objectives, `
+		SELECT o.id, o.user_id, o.title, COALESCE(o.sphere, '') AS sphere, o.period, o.deadline, o.created_at,
+			ps.partnership_id, COALESCE(NULLIF(u.first_name, ''), u.username, '') AS owner_name
+		FROM partner_shared_objectives ps
+		JOIN accountability_partners p ON p.id = ps.partnership_id AND p.status = 'accepted'
+		JOIN objectives o ON o.id = ps.objective_id
+		JOIN users u ON u.id = ps.owner_id
+		WHERE ps.revoked_at IS NULL AND o.deleted_at IS NULL AND `+condition+`
+		ORDER BY u.id, o.created_at DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении целей партнеров: %v", err)
+	}
+
+	for i := range objectives {
+		progress, err := s.GetObjectiveProgress(ctx, objectives[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		objectives[i].Progress = progress
+	}
+	return objectives, nil
+}
+
+func (s *Service) GetObjectivesSharedWithMe(ctx context.Context, userID int64) ([]PartnerObjective, error) {
+	return s.getPartnerObjectives(ctx, `ps.owner_id <> $1 AND (p.user_id = $1 OR p.partner_id = $1)`, userID)
+}
+
+func (s *Service) GetObjectivesISharedWithPartners(ctx context.Context, userID int64) ([]PartnerObjective, error) {
+	return s.getPartnerObjectives(ctx, `ps.owner_id = $1`, userID)
+}
+
+func (s *Service) StartPartnerJobs(jm *jobs.Manager, sendInvite func(chatID int64, text string, partnershipID int64) error, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:	"partner_invites",
+		Spec:	"@every 20s",
+		Run: func(ctx context.Context) {
+			s.notifyPartnerInvitations(ctx, sendInvite)
+		},
+	})
+	jm.Register(jobs.Job{
+		Name:	"partner_weekly_digest",
+		Spec:	"0 19 * * 0",
+		Run: func(ctx context.Context) {
+			s.sendPartnerDigests(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущены приглашения и недельный дайджест партнеров по ответственности")
+}
+
+func (s *Service) notifyPartnerInvitations(ctx context.Context, sendInvite func(chatID int64, text string, partnershipID int64) error) {
+	var invitations []struct {
+		ID		int64	`db:"id"`
+		PartnerID	int64	`db:"partner_id"`
+		InviterName	string	`db:"inviter_name"`
+	}
+	err := s.db.SelectContext(ctx, &invitations, `
+		SELECT p.id, p.partner_id, COALESCE(NULLIF(u.first_name, ''), u.username, '') AS inviter_name
+		FROM accountability_partners p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.status = 'pending' AND p.notified = FALSE
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении приглашений в партнеры: %v", err)
+		return
+	}
+
+	for _, invitation := range invitations {
+		message := fmt.Sprintf("🤝 %s предлагает стать партнерами по ответственности.\n\n"+
+			"Каждый сам выбирает, какие цели открыть другому: партнер видит только их прогресс, без права менять. "+
+			"По воскресеньям обоим придет сводка. Доступ можно закрыть в любой момент.", invitation.InviterName)
+
+		if err := sendInvite(invitation.PartnerID, message, invitation.ID); err != nil {
+			logrus.Errorf("Ошибка при отправке приглашения в партнеры пользователю %d: %v", invitation.PartnerID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE accountability_partners SET notified = TRUE WHERE id = $1`, invitation.ID); err != nil {
+			logrus.Errorf("Ошибка при отметке приглашения в партнеры %d: %v", invitation.ID, err)
+		}
+	}
+}
+
+func (s *Service) sendPartnerDigests(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	var partnerships []struct {
+		ID		int64	`db:"id"`
+		UserID		int64	`db:"user_id"`
+		PartnerID	int64	`db:"partner_id"`
+	}
+	err := s.db.SelectContext(ctx, &partnerships, `
+		SELECT p.id, p.user_id, p.partner_id
+		FROM accountability_partners p
+		WHERE p.status = 'accepted'
+			AND (p.last_digest_at IS NULL OR p.last_digest_at < NOW() - INTERVAL '6 days')
+			AND EXISTS (SELECT 1 FROM partner_shared_objectives ps WHERE ps.partnership_id = p.id AND ps.revoked_at IS NULL)
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборе партнерств для дайджеста: %v", err)
+		return
+	}
+
+	weekStart := focus.WeekStart(time.Now())
+	for _, p := range partnerships {
+		if ctx.Err() != nil {
+			return
+		}
+		digest, err := s.partnerDigest(ctx, p.ID, weekStart)
+		if err != nil {
+			logrus.Warnf("Не удалось подготовить дайджест партнерства %d: %v", p.ID, err)
+			continue
+		}
+		for _, userID := range []int64{p.UserID, p.PartnerID} {
+			if err := sendMessageFunc(userID, digest); err != nil {
+				logrus.Warnf("Не удалось отправить дайджест партнерства пользователю %d: %v", userID, err)
+			}
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE accountability_partners SET last_digest_at = NOW() WHERE id = $1`, p.ID); err != nil {
+			logrus.Errorf("Ошибка при отметке дайджеста партнерства %d: %v", p.ID, err)
+		}
+	}
+}
+
+func (s *Service) partnerDigest(ctx context.Context, partnershipID int64, weekStart time.Time) (string, error) {
+	objectives, err := s.getPartnerObjectives(ctx, `ps.partnership_id = $1`, partnershipID)
+	if err != nil {
+		return "", err
+	}
+
+	deltas := make(map[string]float64)
+	progressByOwner := make(map[int64]bool)
+	for _, o := range objectives {
+		if progressByOwner[o.UserID] {
+			continue
+		}
+		progressByOwner[o.UserID] = true
+		weekly, err := s.GetWeeklyProgress(ctx, o.UserID, weekStart)
+		if err != nil {
+			return "", err
+		}
+		for _, w := range weekly.Objectives {
+			deltas[w.ID] = w.Delta
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("🤝 Неделя партнеров по ответственности\n")
+	owner := int64(0)
+	for _, o := range objectives {
+		if o.UserID != owner {
+			owner = o.UserID
+			b.WriteString(fmt.Sprintf("\n👤 %s\n", o.OwnerName))
+		}
+		b.WriteString(fmt.Sprintf("• %s — %.0f%% (%s)\n", o.Title, o.Progress, formatDelta(deltas[o.ID])))
+	}
+	b.WriteString("\nПоддержите друг друга: одно сообщение партнеру иногда решает всю неделю 💪")
+	return b.String(), nil
+}
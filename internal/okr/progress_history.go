@@ -0,0 +1,104 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const maxHistoryDays = 90
+
+type ProgressPoint struct {
+	Day	time.Time
+	Percent	float64
+}
+
+type ProgressHistory struct {
+	Objective	Objective
+	Start		time.Time
+	Points		[]ProgressPoint
+}
+
+func (s *Service) GetObjectiveProgressHistory(ctx context.Context, userID int64, objectiveID string) (*ProgressHistory, error) {
+	var objective Objective
+	err := s.db.GetContext(ctx, &objective, `
+		SELECT id, user_id, title, sphere, period, deadline, created_at
+		FROM objectives
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`, objectiveID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("цель не найдена или не принадлежит пользователю: %v", err)
+	}
+
+	var entries []struct {
+		KeyResultID	int64		`db:"key_result_id"`
+		Progress	float64		`db:"progress_after"`
+		Target		float64		`db:"target"`
+		CreatedAt	time.Time	`db:"created_at"`
+	}
+	err = s.db.SelectContext(ctx, &entries, `
+		SELECT l.key_result_id, l.progress_after, l.target, l.created_at
+		FROM key_result_progress_log l
+		JOIN key_results kr ON kr.id = l.key_result_id
+		WHERE kr.objective_id = $1 AND kr.deleted_at IS NULL
+		ORDER BY l.created_at, l.id
+	`, objectiveID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории прогресса: %v", err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := time.Date(objective.CreatedAt.Year(), objective.CreatedAt.Month(), objective.CreatedAt.Day(), 0, 0, 0, 0, now.Location())
+	if earliest := today.AddDate(0, 0, -maxHistoryDays); start.Before(earliest) {
+		start = earliest
+	}
+	if start.After(today) {
+		start = today
+	}
+
+	history := &ProgressHistory{Objective: objective, Start: start}
+	percents := make(map[int64]float64)
+	next := 0
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		for ; next < len(entries) && entries[next].CreatedAt.Before(dayEnd); next++ {
+			e := entries[next]
+			percents[e.KeyResultID] = 0
+			if e.Target > 0 {
+				percents[e.KeyResultID] = math.Max(0, math.Min(e.Progress/e.Target, 1)) * 100
+			}
+		}
+		total := 0.0
+		for _, percent := range percents {
+			total += percent
+		}
+		percent := 0.0
+		if len(percents) > 0 {
+			percent = total / float64(len(percents))
+		}
+		history.Points = append(history.Points, ProgressPoint{Day: day, Percent: percent})
+	}
+
+	return history, nil
+}
+
+func WithProgressAuthor(ctx context.Context, db *sqlx.DB, userID int64, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('jarvis.actor_id', $1, true)`, strconv.FormatInt(userID, 10)); err != nil {
+		return fmt.Errorf("ошибка при установке автора прогресса: %v", err)
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,102 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ReconciledTask struct {
+	ID		int64	`db:"id"`
+	Title		string	`db:"title"`
+	Target		float64	`db:"target"`
+	Progress	float64	`db:"progress"`
+	Unit		string	`db:"unit"`
+	Completed	bool	`db:"completed"`
+}
+
+func (s *Service) ReconcileDailyTasks(ctx context.Context, keyResultID int64, delta float64) ([]ReconciledTask, error) {
+	if delta <= 0 {
+		return nil, nil
+	}
+
+	today := startOfDay(time.Now())
+	var candidates []ReconciledTask
+	err := s.db.SelectContext(ctx, &candidates, `
+		SELECT t.id, t.title, t.target, t.progress, t.unit, FALSE AS completed
+		FROM tasks t
+		JOIN key_results kr ON kr.id = t.key_result_id
+		WHERE t.key_result_id = $1 AND t.recurrence_series IS NOT NULL AND t.deleted_at IS NULL
+			AND t.completion_date IS NULL AND t.status IS DISTINCT FROM 'skipped'
+			AND t.deadline >= $2 AND t.deadline < $3
+			AND LOWER(TRIM(t.unit)) = LOWER(TRIM(kr.unit))
+		ORDER BY t.deadline, t.id
+	`, keyResultID, today, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении задач на сегодня: %v", err)
+	}
+
+	var reconciled []ReconciledTask
+	for _, task := range candidates {
+		if delta <= 0 {
+			break
+		}
+		credit := math.Min(delta, math.Max(task.Target-task.Progress, 0))
+
+		var updated ReconciledTask
+		err := s.db.GetContext(ctx, &updated, `
+			UPDATE tasks
+			SET progress = LEAST(target, progress + $2),
+				completion_date = CASE WHEN progress + $2 >= target THEN NOW() ELSE NULL END,
+				updated_at = NOW()
+			WHERE id = $1 AND completion_date IS NULL AND status IS DISTINCT FROM 'skipped'
+			RETURNING id, title, target, progress, unit, completion_date IS NOT NULL AS completed
+		`, task.ID, credit)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return reconciled, fmt.Errorf("ошибка при обновлении задачи %d: %v", task.ID, err)
+		}
+		delta -= credit
+		reconciled = append(reconciled, updated)
+	}
+	return reconciled, nil
+}
+
+func (s *Service) CreditKeyResultFromTask(ctx context.Context, userID, taskID int64, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	err := WithProgressAuthor(ctx, s.db, userID, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE key_results
+			SET progress = progress + $2, updated_at = NOW()
+			WHERE id = (SELECT key_result_id FROM tasks WHERE id = $1)
+		`, taskID, amount)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при зачете задачи %d в ключевой результат: %v", taskID, err)
+	}
+	return nil
+}
+
+func FormatReconciledTasks(tasks []ReconciledTask) string {
+	var lines []string
+	for _, task := range tasks {
+		if task.Completed {
+			lines = append(lines, fmt.Sprintf("✅ Задача на сегодня «%s» отмечена выполненной", task.Title))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("🔁 Задача на сегодня «%s»: %s/%s %s", task.Title, formatTaskAmount(task.Progress), formatTaskAmount(task.Target), task.Unit))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,248 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RecurrenceDaily			= "daily"
+	TaskStatusSkipped		= "skipped"
+	DefaultRecurringReminderHour	= 20
+)
+
+var (
+	ErrRecurringTaskNotFound	= errors.New("повторяющаяся задача не найдена")
+	ErrRecurringTaskLogged		= errors.New("задача на этот день уже отмечена")
+)
+
+var instanceDateSuffix = regexp.MustCompile(` \(\d{2}\.\d{2}\.\d{4}\)$`)
+
+type RecurringInstance struct {
+	TaskID		int64		`db:"id"`
+	UserID		int64		`db:"user_id"`
+	KeyResultID	int64		`db:"key_result_id"`
+	SeriesID	string		`db:"recurrence_series"`
+	Title		string		`db:"title"`
+	Target		float64		`db:"target"`
+	Unit		string		`db:"unit"`
+	Progress	float64		`db:"progress"`
+	Deadline	time.Time	`db:"deadline"`
+}
+
+type RecurringSettings struct {
+	UserID		int64	`db:"user_id"`
+	ReminderHour	int	`db:"reminder_hour"`
+	Enabled		bool	`db:"enabled"`
+}
+
+type RecurringLogResult struct {
+	Instance	RecurringInstance
+	Skipped		bool
+	Streak		int
+	BestStreak	int
+}
+
+const recurringInstanceSelect = `
+	SELECT t.id, o.user_id, t.key_result_id, t.recurrence_series, t.title, t.target, t.unit, t.progress, t.deadline
+	FROM tasks t
+	JOIN key_results kr ON kr.id = t.key_result_id
+	JOIN objectives o ON o.id = kr.objective_id
+`
+
+func (i *RecurringInstance) SeriesTitle() string {
+	return instanceDateSuffix.ReplaceAllString(i.Title, "")
+}
+
+func (s *Service) GetRecurringSettings(ctx context.Context, userID int64) (*RecurringSettings, error) {
+	settings := RecurringSettings{UserID: userID, ReminderHour: DefaultRecurringReminderHour, Enabled: true}
+	err := s.db.GetContext(ctx, &settings, `
+		SELECT user_id, reminder_hour, enabled FROM recurring_task_settings WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при получении настроек повторяющихся задач: %v", err)
+	}
+	return &settings, nil
+}
+
+func (s *Service) SetRecurringSettings(ctx context.Context, userID int64, hour int, enabled bool) error {
+	if hour < 0 || hour > 23 {
+		return fmt.Errorf("час напоминания должен быть от 0 до 23")
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO recurring_task_settings (user_id, reminder_hour, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET reminder_hour = $2, enabled = $3, updated_at = NOW()
+	`, userID, hour, enabled)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении настроек повторяющихся задач: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) LogRecurringInstance(ctx context.Context, userID, taskID int64) (*RecurringLogResult, error) {
+	return s.closeRecurringInstance(ctx, userID, taskID, `
+		UPDATE tasks
+		SET progress = GREATEST(progress, target), completion_date = NOW(), updated_at = NOW()
+		WHERE id = $1 AND completion_date IS NULL AND status IS DISTINCT FROM 'skipped'
+	`, false)
+}
+
+func (s *Service) SkipRecurringInstance(ctx context.Context, userID, taskID int64) (*RecurringLogResult, error) {
+	return s.closeRecurringInstance(ctx, userID, taskID, `
+		UPDATE tasks
+		SET status = 'skipped', updated_at = NOW()
+		WHERE id = $1 AND completion_date IS NULL AND status IS DISTINCT FROM 'skipped'
+	`, true)
+}
+
+func (s *Service) closeRecurringInstance(ctx context.Context, userID, taskID int64, query string, skipped bool) (*RecurringLogResult, error) {
+	var instance RecurringInstance
+	err := s.db.GetContext(ctx, &instance, recurringInstanceSelect+`
+		WHERE t.id = $1 AND o.user_id = $2 AND t.recurrence_series IS NOT NULL AND t.deleted_at IS NULL
+	`, taskID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecurringTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении повторяющейся задачи: %v", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отметке повторяющейся задачи: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrRecurringTaskLogged
+	}
+	if !skipped {
+		if err := s.CreditKeyResultFromTask(ctx, userID, taskID, instance.Target-instance.Progress); err != nil {
+			logrus.Warnf("Не удалось зачесть задачу %d в ключевой результат: %v", taskID, err)
+		}
+	}
+
+	streak, best, err := s.SeriesStreak(ctx, instance.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+	return &RecurringLogResult{Instance: instance, Skipped: skipped, Streak: streak, BestStreak: best}, nil
+}
+
+func (s *Service) SeriesStreak(ctx context.Context, seriesID string) (int, int, error) {
+	var days []struct {
+		Deadline	time.Time	`db:"deadline"`
+		Done		bool		`db:"done"`
+		Skipped		bool		`db:"skipped"`
+	}
+	today := startOfDay(time.Now())
+	err := s.db.SelectContext(ctx, &days, `
+		SELECT deadline, completion_date IS NOT NULL AS done, status IS NOT DISTINCT FROM 'skipped' AS skipped
+		FROM tasks
+		WHERE recurrence_series = $1 AND deleted_at IS NULL AND deadline < $2
+		ORDER BY deadline
+	`, seriesID, today.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, 0, fmt.Errorf("ошибка при подсчете серии: %v", err)
+	}
+
+	run, best := 0, 0
+	for _, day := range days {
+		switch {
+		case day.Done:
+			run++
+			if run > best {
+				best = run
+			}
+		case day.Skipped || day.Deadline.Before(today):
+			run = 0
+		}
+	}
+	return run, best, nil
+}
+
+func (s *Service) StartRecurringTaskReminders(jm *jobs.Manager, sendReminder func(chatID int64, text string, taskID int64) error) {
+	jm.Register(jobs.Job{
+		Name:		"recurring_task_reminders",
+		Spec:		"*/5 * * * *",
+		Run: func(ctx context.Context) {
+			s.sendRecurringReminders(ctx, sendReminder)
+		},
+	})
+
+	logrus.Info("Запущены напоминания о повторяющихся задачах")
+}
+
+func (s *Service) sendRecurringReminders(ctx context.Context, sendReminder func(chatID int64, text string, taskID int64) error) {
+	now := time.Now()
+	today := startOfDay(now)
+
+	var instances []RecurringInstance
+	err := s.db.SelectContext(ctx, &instances, recurringInstanceSelect+`
+		LEFT JOIN recurring_task_settings rs ON rs.user_id = o.user_id
+		WHERE t.recurrence_series IS NOT NULL AND t.deleted_at IS NULL
+			AND t.completion_date IS NULL AND t.status IS DISTINCT FROM 'skipped' AND t.reminder_sent_at IS NULL
+			AND t.deadline >= $1 AND t.deadline < $2
+			AND COALESCE(o.status, 'active') = 'active'
+			AND COALESCE(rs.enabled, TRUE) AND COALESCE(rs.reminder_hour, $3) <= $4
+		ORDER BY o.user_id, t.deadline
+	`, today, today.AddDate(0, 0, 1), DefaultRecurringReminderHour, now.Hour())
+	if err != nil {
+		logrus.Errorf("Ошибка при получении повторяющихся задач для напоминаний: %v", err)
+		return
+	}
+
+	for _, instance := range instances {
+		result, err := s.db.ExecContext(ctx, `
+			UPDATE tasks SET reminder_sent_at = NOW() WHERE id = $1 AND reminder_sent_at IS NULL
+		`, instance.TaskID)
+		if err != nil {
+			logrus.Errorf("Ошибка при отметке напоминания о задаче %d: %v", instance.TaskID, err)
+			continue
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			continue
+		}
+
+		streak, _, err := s.SeriesStreak(ctx, instance.SeriesID)
+		if err != nil {
+			logrus.Warnf("Не удалось посчитать серию задачи %d: %v", instance.TaskID, err)
+		}
+		if err := sendReminder(instance.UserID, FormatRecurringReminder(&instance, streak), instance.TaskID); err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания о задаче %d пользователю %d: %v", instance.TaskID, instance.UserID, err)
+		}
+	}
+}
+
+func FormatRecurringReminder(instance *RecurringInstance, streak int) string {
+	text := fmt.Sprintf("🔁 %s — сегодня еще не отмечено\n🎯 %s %s", instance.SeriesTitle(), formatTaskAmount(instance.Target), instance.Unit)
+	if streak > 0 {
+		text += fmt.Sprintf("\n🔥 Серия: %d дн. — не прерывайте ее!", streak)
+	}
+	return text
+}
+
+func FormatRecurringLogResult(result *RecurringLogResult) string {
+	if result.Skipped {
+		return "⏭ Пропущено на сегодня, серия начнется заново"
+	}
+	text := fmt.Sprintf("✅ Отмечено! Серия: %d дн. 🔥", result.Streak)
+	if result.Streak > 1 && result.Streak == result.BestStreak {
+		text += "\n🏆 Это ваш лучший результат"
+	}
+	return text
+}
+
+func formatTaskAmount(value float64) string {
+	return fmt.Sprintf("%g", value)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
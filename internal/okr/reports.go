@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/health"
+	"telegrambot/internal/jobs"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,6 +23,8 @@ type ReportSettings struct {
 	CreatedAt	time.Time	`db:"created_at"`
 	UpdatedAt	time.Time	`db:"updated_at"`
 	LastReportSent	*time.Time	`db:"last_report_sent"`
+	LastError	*string		`db:"last_report_error"`
+	LastFailedAt	*time.Time	`db:"last_report_failed_at"`
 }
 
 func (s *Service) SetReportSettings(ctx context.Context, userID int64, reportPeriod string,
@@ -177,7 +182,12 @@ func (s *Service) GenerateReport(ctx context.Context, userID int64, period strin
 		return "", fmt.Errorf("ошибка при получении целей: %v", err)
 	}
 
-	if len(objectives) == 0 {
+	ownedKeyResults, err := s.GetOwnedTeamKeyResults(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении командных ключевых результатов пользователя %d: %v", userID, err)
+	}
+
+	if len(objectives) == 0 && len(ownedKeyResults) == 0 {
 		return fmt.Sprintf("За период %s у вас нет активных целей OKR.", formatPeriodRussian(period, startDate, now)), nil
 	}
 
@@ -234,6 +244,36 @@ func (s *Service) GenerateReport(ctx context.Context, userID int64, period strin
 		reportBuilder.WriteString("\n")
 	}
 
+	if len(ownedKeyResults) > 0 {
+		reportBuilder.WriteString("*Командные ключевые результаты, закрепленные за вами:*\n")
+		for i, kr := range ownedKeyResults {
+			reportBuilder.WriteString(fmt.Sprintf("%d. %s (цель «%s»): %.0f%% (%s/%s %s)\n",
+				i+1, kr.Title, kr.ObjectiveTitle, kr.Percent(), formatFloat(kr.Progress), formatFloat(kr.Target), kr.UnitName()))
+		}
+		reportBuilder.WriteString("\n")
+	}
+
+	if period == "week" {
+		adherence, err := s.health.GetAdherence(ctx, userID, startDate)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении статистики приема лекарств пользователя %d: %v", userID, err)
+		} else if text := health.FormatAdherence(adherence); text != "" {
+			reportBuilder.WriteString("*💊 Прием лекарств за неделю:*\n")
+			reportBuilder.WriteString(text)
+			reportBuilder.WriteString("\n")
+		}
+	}
+
+	if period == "week" {
+		focusStats, err := s.focus.GetWeeklyStats(ctx, userID, startDate)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении фокус-времени пользователя %d: %v", userID, err)
+		} else if focusStats.Minutes > 0 || focusStats.TargetMinutes > 0 {
+			reportBuilder.WriteString(focus.FormatWeeklyStats(focusStats))
+			reportBuilder.WriteString("\n\n")
+		}
+	}
+
 	reportBuilder.WriteString("Продолжайте двигаться к своим целям! 💪")
 
 	return reportBuilder.String(), nil
@@ -242,7 +282,8 @@ func (s *Service) GenerateReport(ctx context.Context, userID int64, period strin
 func (s *Service) UpdateLastReportSent(ctx context.Context, userID int64) error {
 	query := `
 		UPDATE okr_report_settings
-		SET last_report_sent = $1, updated_at = $1
+		SET last_report_sent = $1, updated_at = $1,
+			last_report_error = NULL, last_report_failed_at = NULL
 		WHERE user_id = $2
 	`
 
@@ -254,21 +295,64 @@ func (s *Service) UpdateLastReportSent(ctx context.Context, userID int64) error
 	return nil
 }
 
-func (s *Service) StartReportChecker(sendMessageFunc func(chatID int64, text string) error) {
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
-		defer ticker.Stop()
+func (s *Service) markReportFailed(ctx context.Context, userID int64, reportErr error) {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE okr_report_settings
+		SET last_report_error = $1, last_report_failed_at = NOW()
+		WHERE user_id = $2
+	`, reportErr.Error(), userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении ошибки отчета пользователя %d: %v", userID, err)
+	}
+}
 
-		for range ticker.C {
-			s.checkAndSendReports(sendMessageFunc)
-		}
-	}()
+func (s *Service) ListFailedReports(ctx context.Context) ([]ReportSettings, error) {
+	var settings []ReportSettings
+	err := s.db.SelectContext(ctx, &settings, `
+		SELECT id, user_id, report_period, day_of_week, hour, minute,
+			enabled, created_at, updated_at, last_report_sent, last_report_error, last_report_failed_at
+		FROM okr_report_settings
+		WHERE last_report_error IS NOT NULL
+		ORDER BY last_report_failed_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении упавших отчетов: %v", err)
+	}
+	return settings, nil
+}
+
+func (s *Service) RerunReport(ctx context.Context, userID int64, sendMessageFunc func(chatID int64, text string) error) error {
+	settings, err := s.GetReportSettings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.GenerateReport(ctx, userID, settings.ReportPeriod)
+	if err != nil {
+		s.markReportFailed(ctx, userID, err)
+		return fmt.Errorf("ошибка при генерации отчета: %v", err)
+	}
+	if err := sendMessageFunc(userID, report); err != nil {
+		s.markReportFailed(ctx, userID, err)
+		return fmt.Errorf("ошибка при отправке отчета: %v", err)
+	}
+
+	return s.UpdateLastReportSent(ctx, userID)
+}
+
+func (s *Service) StartReportChecker(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error, sendWeeklyReport func(ctx context.Context, userID int64) error) {
+	jm.Register(jobs.Job{
+		Name:		"okr_reports",
+		Spec:		"* * * * *",
+		Run: func(ctx context.Context) {
+			s.checkAndSendReports(ctx, sendMessageFunc, sendWeeklyReport)
+		},
+	})
 
 	logrus.Info("Запущен механизм периодической отправки отчетов OKR")
 }
 
-func (s *Service) checkAndSendReports(sendMessageFunc func(chatID int64, text string) error) {
-	ctx := context.Background()
+func (s *Service) checkAndSendReports(ctx context.Context, sendMessageFunc func(chatID int64, text string) error, sendWeeklyReport func(ctx context.Context, userID int64) error) {
 	now := time.Now()
 
 	query := `
@@ -326,15 +410,21 @@ func (s *Service) checkAndSendReports(sendMessageFunc func(chatID int64, text st
 				}
 			}
 
-			report, err := s.GenerateReport(ctx, setting.UserID, setting.ReportPeriod)
-			if err != nil {
-				logrus.Errorf("Ошибка при генерации отчета для пользователя %d: %v", setting.UserID, err)
-				continue
+			if setting.ReportPeriod == "week" && sendWeeklyReport != nil {
+				err = sendWeeklyReport(ctx, setting.UserID)
+			} else {
+				var report string
+				report, err = s.GenerateReport(ctx, setting.UserID, setting.ReportPeriod)
+				if err != nil {
+					logrus.Errorf("Ошибка при генерации отчета для пользователя %d: %v", setting.UserID, err)
+					s.markReportFailed(ctx, setting.UserID, err)
+					continue
+				}
+				err = sendMessageFunc(setting.UserID, report)
 			}
-
-			err = sendMessageFunc(setting.UserID, report)
 			if err != nil {
 				logrus.Errorf("Ошибка при отправке отчета пользователю %d: %v", setting.UserID, err)
+				s.markReportFailed(ctx, setting.UserID, err)
 				continue
 			}
 
@@ -373,7 +463,7 @@ func (s *Service) GetObjectivesByDateRange(ctx context.Context, userID int64, st
 	query := `
 		SELECT id, user_id, title, sphere, period, deadline, created_at
 		FROM objectives
-		WHERE user_id = $1 AND (
+		WHERE user_id = $1 AND deleted_at IS NULL AND (
 			(deadline IS NULL) OR
 			(deadline >= $2)
 		)
@@ -393,7 +483,7 @@ func (s *Service) GetTasksForKeyResult(ctx context.Context, keyResultID int64) (
 	query := `
 		SELECT id, key_result_id, title, target, unit, progress, deadline, created_at
 		FROM tasks
-		WHERE key_result_id = $1
+		WHERE key_result_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -0,0 +1,138 @@
+package okr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	EntityObjective	= "objective"
+	EntityKeyResult	= "key_result"
+	EntityTask	= "task"
+
+	minMatchScore	= 0.3
+	ambiguityMargin	= 0.15
+	maxCandidates	= 5
+)
+
+var ErrNoMatch = errors.New("ничего похожего не найдено")
+
+type Candidate struct {
+	ID	string	`db:"id" json:"id"`
+	Title	string	`db:"title" json:"title"`
+	Parent	string	`db:"parent" json:"parent,omitempty"`
+	Score	float64	`db:"score" json:"score"`
+}
+
+type AmbiguousMatchError struct {
+	Kind		string
+	Query		string
+	Candidates	[]Candidate
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("по описанию «%s» подходит несколько вариантов: %d", e.Query, len(e.Candidates))
+}
+
+func titleScore(column, param string) string {
+	return fmt.Sprintf(`GREATEST(
+		CASE WHEN LOWER(%[1]s) = %[2]s THEN 2 WHEN LOWER(%[1]s) LIKE '%%' || %[2]s || '%%' THEN 1 ELSE 0 END,
+		similarity(LOWER(%[1]s), %[2]s),
+		word_similarity(%[2]s, LOWER(%[1]s))
+	)`, column, param)
+}
+
+func parentMatches(column, param string) string {
+	return fmt.Sprintf(`(%[2]s = '' OR %[3]s >= %[1]g)`, minMatchScore, param, titleScore(column, param))
+}
+
+func normalizeQuery(description string) string {
+	return strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
+
+func (s *Service) ResolveObjective(ctx context.Context, userID int64, description string) (*Candidate, error) {
+	query := normalizeQuery(description)
+	var candidates []Candidate
+	err := s.db.SelectContext(ctx, &candidates, `
+		SELECT id, title, parent, score FROM (
+			SELECT o.id, o.title, COALESCE(o.sphere, '') AS parent, `+titleScore("o.title", "$2")+` AS score, o.created_at
+			FROM objectives o
+			WHERE o.user_id = $1 AND o.deleted_at IS NULL
+		) matches
+		WHERE score >= $3
+		ORDER BY score DESC, created_at DESC
+		LIMIT $4
+	`, userID, query, minMatchScore, maxCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске цели: %v", err)
+	}
+	return pickCandidate(EntityObjective, description, candidates)
+}
+
+func (s *Service) ResolveKeyResult(ctx context.Context, userID int64, description, objectiveDescription string) (*Candidate, error) {
+	query := normalizeQuery(description)
+	var candidates []Candidate
+	err := s.db.SelectContext(ctx, &candidates, `
+		SELECT id, title, parent, score FROM (
+			SELECT kr.id::text AS id, kr.title, o.title AS parent, `+titleScore("kr.title", "$2")+` AS score, kr.created_at
+			FROM key_results kr
+			JOIN objectives o ON kr.objective_id = o.id
+			WHERE `+KeyResultAccessCondition("$1")+` AND kr.deleted_at IS NULL AND o.deleted_at IS NULL
+				AND `+parentMatches("o.title", "$3")+`
+		) matches
+		WHERE score >= $4
+		ORDER BY score DESC, created_at DESC
+		LIMIT $5
+	`, userID, query, normalizeQuery(objectiveDescription), minMatchScore, maxCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске ключевого результата: %v", err)
+	}
+	return pickCandidate(EntityKeyResult, description, candidates)
+}
+
+func (s *Service) ResolveTask(ctx context.Context, userID int64, description, keyResultDescription string) (*Candidate, error) {
+	query := normalizeQuery(description)
+	var candidates []Candidate
+	err := s.db.SelectContext(ctx, &candidates, `
+		SELECT id, title, parent, score FROM (
+			SELECT t.id::text AS id, t.title, kr.title AS parent, `+titleScore("t.title", "$2")+` AS score, t.created_at
+			FROM tasks t
+			JOIN key_results kr ON t.key_result_id = kr.id
+			JOIN objectives o ON kr.objective_id = o.id
+			WHERE o.user_id = $1 AND t.deleted_at IS NULL AND kr.deleted_at IS NULL AND o.deleted_at IS NULL
+				AND `+parentMatches("kr.title", "$3")+`
+		) matches
+		WHERE score >= $4
+		ORDER BY score DESC, created_at DESC
+		LIMIT $5
+	`, userID, query, normalizeQuery(keyResultDescription), minMatchScore, maxCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске задачи: %v", err)
+	}
+	return pickCandidate(EntityTask, description, candidates)
+}
+
+func pickCandidate(kind, description string, candidates []Candidate) (*Candidate, error) {
+	if len(candidates) == 0 {
+		return nil, ErrNoMatch
+	}
+	if len(candidates) == 1 || candidates[0].Score-candidates[1].Score >= ambiguityMargin {
+		return &candidates[0], nil
+	}
+
+	similar := candidates[:1]
+	for _, candidate := range candidates[1:] {
+		if candidates[0].Score-candidate.Score < ambiguityMargin {
+			similar = append(similar, candidate)
+		}
+	}
+	return nil, &AmbiguousMatchError{Kind: kind, Query: strings.TrimSpace(description), Candidates: similar}
+}
+
+func (c Candidate) NumericID() int64 {
+	id, _ := strconv.ParseInt(c.ID, 10, 64)
+	return id
+}
@@ -0,0 +1,167 @@
+package okr
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"telegrambot/internal/ownership"
+	"time"
+)
+
+const ShareLinkPathPrefix = "/api/okr/shared/"
+
+const (
+	DefaultSharingType	= "progress_update"
+	DefaultShareAudience	= "friends"
+)
+
+var ErrShareLinkNotFound = errors.New("ссылка на цель не найдена или отозвана")
+
+type ShareLink struct {
+	Token		string		`db:"token" json:"token"`
+	ObjectiveID	string		`db:"objective_id" json:"objective_id"`
+	UserID		int64		`db:"user_id" json:"-"`
+	SharingType	string		`db:"sharing_type" json:"sharing_type"`
+	Audience	string		`db:"audience" json:"audience"`
+	ViewCount	int		`db:"view_count" json:"view_count"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type SharedKeyResult struct {
+	Title		string		`json:"title"`
+	Target		float64		`json:"target"`
+	Unit		string		`json:"unit"`
+	Current		float64		`json:"current"`
+	Progress	float64		`json:"progress"`
+	Deadline	*time.Time	`json:"deadline,omitempty"`
+}
+
+type SharedObjective struct {
+	Title		string			`json:"title"`
+	Sphere		string			`json:"sphere"`
+	Period		string			`json:"period"`
+	Deadline	*time.Time		`json:"deadline,omitempty"`
+	OwnerName	string			`json:"owner_name"`
+	SharingType	string			`json:"sharing_type"`
+	Progress	float64			`json:"progress"`
+	Outcome		string			`json:"outcome,omitempty"`
+	KeyResults	[]SharedKeyResult	`json:"key_results"`
+	UpdatedAt	time.Time		`json:"updated_at"`
+}
+
+func (s *Service) CreateShareLink(ctx context.Context, userID int64, objectiveID, sharingType, audience string) (*ShareLink, error) {
+	if err := ownership.MustOwnObjective(ctx, s.db, userID, objectiveID); err != nil {
+		return nil, err
+	}
+	if sharingType == "" {
+		sharingType = DefaultSharingType
+	}
+	if audience == "" {
+		audience = DefaultShareAudience
+	}
+
+	var link ShareLink
+	err := s.db.GetContext(ctx, &link, `
+		UPDATE objective_share_links SET sharing_type = $3, audience = $4
+		WHERE objective_id = $1 AND user_id = $2 AND revoked_at IS NULL
+		RETURNING token, objective_id, user_id, sharing_type, audience, view_count, created_at
+	`, objectiveID, userID, sharingType, audience)
+	if err == nil {
+		return &link, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при получении ссылки на цель: %v", err)
+	}
+
+	token, err := shareToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.GetContext(ctx, &link, `
+		INSERT INTO objective_share_links (token, objective_id, user_id, sharing_type, audience)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING token, objective_id, user_id, sharing_type, audience, view_count, created_at
+	`, token, objectiveID, userID, sharingType, audience)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании ссылки на цель: %v", err)
+	}
+	return &link, nil
+}
+
+func (s *Service) RevokeShareLinks(ctx context.Context, userID int64, objectiveID string) (int64, error) {
+	if err := ownership.MustOwnObjective(ctx, s.db, userID, objectiveID); err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE objective_share_links SET revoked_at = NOW()
+		WHERE objective_id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, objectiveID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при отзыве ссылки на цель: %v", err)
+	}
+	revoked, _ := result.RowsAffected()
+	return revoked, nil
+}
+
+func (s *Service) GetSharedObjective(ctx context.Context, token string) (*SharedObjective, error) {
+	var link struct {
+		ShareLink
+		OwnerName	string	`db:"owner_name"`
+	}
+	err := s.db.GetContext(ctx, &link, `
+		UPDATE objective_share_links l SET view_count = l.view_count + 1, last_accessed_at = NOW()
+		FROM objectives o, users u
+		WHERE l.token = $1 AND l.revoked_at IS NULL
+			AND o.id = l.objective_id AND o.deleted_at IS NULL AND u.id = l.user_id
+		RETURNING l.token, l.objective_id, l.user_id, l.sharing_type, l.audience, l.view_count, l.created_at,
+			COALESCE(NULLIF(u.first_name, ''), u.username, '') AS owner_name
+	`, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке ссылки на цель: %v", err)
+	}
+
+	details, err := s.GetObjectiveDetails(ctx, link.UserID, link.ObjectiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := &SharedObjective{
+		Title:		details.Objective.Title,
+		Sphere:		details.Objective.Sphere,
+		Period:		details.Objective.Period,
+		Deadline:	details.Objective.Deadline,
+		OwnerName:	link.OwnerName,
+		SharingType:	link.SharingType,
+		Progress:	details.Progress,
+		KeyResults:	make([]SharedKeyResult, 0, len(details.KeyResults)),
+		UpdatedAt:	time.Now(),
+	}
+	if details.Closing != nil {
+		shared.Outcome = details.Closing.Outcome
+	}
+	for _, kr := range details.KeyResults {
+		shared.KeyResults = append(shared.KeyResults, SharedKeyResult{
+			Title:		kr.KeyResult.Title,
+			Target:		kr.KeyResult.Target,
+			Unit:		kr.KeyResult.Unit,
+			Current:	kr.KeyResult.Progress,
+			Progress:	kr.Progress,
+			Deadline:	kr.KeyResult.Deadline,
+		})
+	}
+	return shared, nil
+}
+
+func shareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка при генерации ссылки на цель: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,179 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DefaultSphereIcon	= "🎯"
+	maxSphereNameRunes	= 100
+)
+
+var (
+	ErrInvalidSphereName	= errors.New("название сферы должно быть от 1 до 100 символов")
+	ErrStandardSphere	= errors.New("такая сфера уже есть в стандартном списке")
+	ErrSphereNotFound	= errors.New("своя сфера не найдена")
+)
+
+type Sphere struct {
+	Key	string		`json:"key"`
+	Name	string		`json:"name"`
+	Icon	string		`json:"icon"`
+	Custom	bool		`json:"custom"`
+	Aliases	[]string	`json:"aliases,omitempty"`
+}
+
+var standardSpheres = []Sphere{
+	{Key: "health", Name: "Здоровье", Icon: "🩺", Aliases: []string{"спорт", "фитнес", "тренировки", "питание", "сон", "health", "fitness", "sport"}},
+	{Key: "career", Name: "Карьера", Icon: "💼", Aliases: []string{"работа", "career", "work"}},
+	{Key: "business", Name: "Бизнес", Icon: "🚀", Aliases: []string{"стартап", "предпринимательство", "business"}},
+	{Key: "finance", Name: "Финансы", Icon: "💰", Aliases: []string{"деньги", "накопления", "инвестиции", "finance", "money"}},
+	{Key: "education", Name: "Образование", Icon: "📚", Aliases: []string{"обучение", "учеба", "учёба", "языки", "education", "learning"}},
+	{Key: "creativity", Name: "Творчество", Icon: "🎨", Aliases: []string{"контент", "блог", "музыка", "creativity"}},
+	{Key: "relationships", Name: "Отношения", Icon: "❤️", Aliases: []string{"семья", "друзья", "relationships", "family"}},
+	{Key: "growth", Name: "Саморазвитие", Icon: "🌱", Aliases: []string{"личностный рост", "личное развитие", "развитие", "personal growth"}},
+	{Key: "leisure", Name: "Хобби и отдых", Icon: "🏖", Aliases: []string{"хобби", "отдых", "путешествия", "travel"}},
+	{Key: "home", Name: "Дом и быт", Icon: "🏠", Aliases: []string{"дом", "быт", "ремонт", "home"}},
+}
+
+func StandardSpheres() []Sphere {
+	return standardSpheres
+}
+
+func findStandardSphere(name string) *Sphere {
+	needle := strings.ToLower(strings.TrimSpace(name))
+	for i := range standardSpheres {
+		if strings.ToLower(standardSpheres[i].Name) == needle || standardSpheres[i].Key == needle {
+			return &standardSpheres[i]
+		}
+		for _, alias := range standardSpheres[i].Aliases {
+			if alias == needle {
+				return &standardSpheres[i]
+			}
+		}
+	}
+	return nil
+}
+
+func SphereIcon(name string) string {
+	if sphere := findStandardSphere(name); sphere != nil {
+		return sphere.Icon
+	}
+	return DefaultSphereIcon
+}
+
+func (s *Service) GetSpheres(ctx context.Context, userID int64) ([]Sphere, error) {
+	spheres := make([]Sphere, 0, len(standardSpheres))
+	spheres = append(spheres, standardSpheres...)
+
+	var custom []Sphere
+	err := s.db.SelectContext(ctx, &custom, `
+		SELECT id::text AS key, name, icon, TRUE AS custom
+		FROM user_spheres
+		WHERE user_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сфер пользователя: %v", err)
+	}
+	return append(spheres, custom...), nil
+}
+
+func (s *Service) FindSphere(ctx context.Context, userID int64, value string) (*Sphere, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	if sphere := findStandardSphere(value); sphere != nil {
+		return sphere, nil
+	}
+
+	var sphere Sphere
+	err := s.db.GetContext(ctx, &sphere, `
+		SELECT id::text AS key, name, icon, TRUE AS custom
+		FROM user_spheres
+		WHERE user_id = $1 AND (LOWER(name) = LOWER($2) OR id::text = $2)
+	`, userID, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSphereNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске сферы: %v", err)
+	}
+	return &sphere, nil
+}
+
+func (s *Service) NormalizeSphere(ctx context.Context, userID int64, name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", nil
+	}
+	if sphere := findStandardSphere(name); sphere != nil {
+		return sphere.Name, nil
+	}
+
+	var existing string
+	err := s.db.GetContext(ctx, &existing, `
+		SELECT name FROM user_spheres WHERE user_id = $1 AND LOWER(name) = LOWER($2)
+	`, userID, name)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("ошибка при поиске сферы: %v", err)
+	}
+
+	sphere, err := s.AddCustomSphere(ctx, userID, name, "")
+	if err != nil {
+		return "", err
+	}
+	return sphere.Name, nil
+}
+
+func (s *Service) AddCustomSphere(ctx context.Context, userID int64, name, icon string) (*Sphere, error) {
+	name = capitalizeSphere(strings.Join(strings.Fields(name), " "))
+	if name == "" || utf8.RuneCountInString(name) > maxSphereNameRunes {
+		return nil, ErrInvalidSphereName
+	}
+	if findStandardSphere(name) != nil {
+		return nil, ErrStandardSphere
+	}
+	if strings.TrimSpace(icon) == "" {
+		icon = DefaultSphereIcon
+	}
+
+	sphere := Sphere{Custom: true}
+	err := s.db.GetContext(ctx, &sphere, `
+		INSERT INTO user_spheres (user_id, name, icon) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, LOWER(name)) DO UPDATE SET icon = CASE WHEN $4 THEN EXCLUDED.icon ELSE user_spheres.icon END
+		RETURNING id::text AS key, name, icon
+	`, userID, name, icon, icon != DefaultSphereIcon)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении сферы: %v", err)
+	}
+	return &sphere, nil
+}
+
+func (s *Service) DeleteCustomSphere(ctx context.Context, userID int64, name string) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM user_spheres WHERE user_id = $1 AND LOWER(name) = LOWER($2)
+	`, userID, strings.TrimSpace(name))
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении сферы: %v", err)
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return ErrSphereNotFound
+	}
+	return nil
+}
+
+func capitalizeSphere(name string) string {
+	first, size := utf8.DecodeRuneInString(name)
+	if first == utf8.RuneError {
+		return name
+	}
+	return string(unicode.ToUpper(first)) + name[size:]
+}
@@ -0,0 +1,102 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const frequentDownRevisions = 2
+
+type TargetRevision struct {
+	ID		int64		`db:"id" json:"id"`
+	KeyResultID	int64		`db:"key_result_id" json:"key_result_id"`
+	OldTarget	float64		`db:"old_target" json:"old_target"`
+	NewTarget	float64		`db:"new_target" json:"new_target"`
+	Reason		*string		`db:"reason" json:"reason,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type DownRevisedKeyResult struct {
+	KeyResultID	int64		`db:"key_result_id" json:"key_result_id"`
+	Title		string		`db:"title" json:"title"`
+	ObjectiveTitle	string		`db:"objective_title" json:"objective_title"`
+	Revisions	int		`db:"revisions" json:"revisions"`
+	OriginalTarget	float64		`db:"original_target" json:"original_target"`
+	Target		float64		`db:"target" json:"target"`
+}
+
+func (r TargetRevision) Lowered() bool {
+	return r.NewTarget < r.OldTarget
+}
+
+func recordTargetRevision(ctx context.Context, tx *sqlx.Tx, keyResultID int64, oldTarget, newTarget float64, reason string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO key_result_target_revisions (key_result_id, old_target, new_target, reason)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+	`, keyResultID, oldTarget, newTarget, strings.TrimSpace(reason))
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении изменения целевого значения: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) GetTargetRevisions(ctx context.Context, keyResultIDs []int64) (map[int64][]TargetRevision, error) {
+	result := make(map[int64][]TargetRevision)
+	if len(keyResultIDs) == 0 {
+		return result, nil
+	}
+
+	var revisions []TargetRevision
+	err := s.db.SelectContext(ctx, &revisions, `
+		SELECT id, key_result_id, old_target, new_target, reason, created_at
+		FROM key_result_target_revisions
+		WHERE key_result_id = ANY($1)
+		ORDER BY created_at
+	`, pq.Int64Array(keyResultIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории целевых значений: %v", err)
+	}
+
+	for _, r := range revisions {
+		result[r.KeyResultID] = append(result[r.KeyResultID], r)
+	}
+	return result, nil
+}
+
+func (s *Service) GetDownRevisedKeyResults(ctx context.Context, userID int64, from, to time.Time) ([]DownRevisedKeyResult, error) {
+	keyResults := make([]DownRevisedKeyResult, 0)
+	err := s.db.SelectContext(ctx, &keyResults, `
+		SELECT kr.id AS key_result_id, kr.title, o.title AS objective_title, kr.target,
+			COUNT(*) FILTER (WHERE r.new_target < r.old_target) AS revisions,
+			(SELECT first.old_target FROM key_result_target_revisions first
+			 WHERE first.key_result_id = kr.id ORDER BY first.created_at LIMIT 1) AS original_target
+		FROM key_result_target_revisions r
+		JOIN key_results kr ON kr.id = r.key_result_id
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND r.created_at >= $2 AND r.created_at < $3
+		GROUP BY kr.id, kr.title, o.title, kr.target
+		HAVING COUNT(*) FILTER (WHERE r.new_target < r.old_target) >= $4
+		ORDER BY revisions DESC, kr.title
+	`, userID, from, to, frequentDownRevisions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске понижений целевых значений: %v", err)
+	}
+	return keyResults, nil
+}
+
+func FormatTargetRevision(r TargetRevision, unit string) string {
+	arrow := "⬆️"
+	if r.Lowered() {
+		arrow = "⬇️"
+	}
+	line := fmt.Sprintf("%s %s: %s → %s %s", arrow, r.CreatedAt.Format("02.01"), formatFloat(r.OldTarget), formatFloat(r.NewTarget), unit)
+	if r.Reason != nil {
+		line += " — " + *r.Reason
+	}
+	return line
+}
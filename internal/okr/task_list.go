@@ -0,0 +1,76 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type TaskFilter struct {
+	KeyResultID		int64
+	ObjectiveID		string
+	Sphere			string
+	DeadlineWithinDays	int
+}
+
+type TaskSummary struct {
+	ID		int64		`db:"id"`
+	Title		string		`db:"title"`
+	Target		float64		`db:"target"`
+	Unit		string		`db:"unit"`
+	Progress	float64		`db:"progress"`
+	Deadline	*time.Time	`db:"deadline"`
+	Status		string		`db:"status"`
+	KeyResultTitle	string		`db:"kr_title"`
+	ObjectiveTitle	string		`db:"objective_title"`
+	Total		int		`db:"total"`
+}
+
+const taskListCondition = `o.user_id = $1 AND t.deleted_at IS NULL AND kr.deleted_at IS NULL AND o.deleted_at IS NULL
+	AND ($2 = 0 OR t.key_result_id = $2)
+	AND ($3 = '' OR o.id = $3)
+	AND ($4 = '' OR o.sphere = $4)
+	AND ($5 = 0 OR (t.deadline < CURRENT_DATE + $5 + 1 AND COALESCE(t.status, '') <> 'completed'))`
+
+func (s *Service) ListTaskSummaries(ctx context.Context, userID int64, filter TaskFilter, limit, offset int) ([]TaskSummary, int, error) {
+	order := "t.created_at DESC"
+	switch {
+	case filter.DeadlineWithinDays > 0:
+		order = "t.deadline ASC, t.created_at DESC"
+	case filter.ObjectiveID != "" && filter.KeyResultID == 0:
+		order = "kr.created_at, t.created_at DESC"
+	}
+
+	var tasks []TaskSummary
+	err := s.db.SelectContext(ctx, &tasks, `
+		SELECT t.id, t.title, t.target, t.unit, t.progress, t.deadline, COALESCE(t.status, '') AS status,
+			kr.title AS kr_title, o.title AS objective_title,
+			COUNT(*) OVER () AS total
+		FROM tasks t
+		JOIN key_results kr ON t.key_result_id = kr.id
+		JOIN objectives o ON kr.objective_id = o.id
+		WHERE `+taskListCondition+`
+		ORDER BY `+order+`
+		LIMIT $6 OFFSET $7
+	`, userID, filter.KeyResultID, filter.ObjectiveID, filter.Sphere, filter.DeadlineWithinDays, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении задач: %v", err)
+	}
+
+	total := 0
+	if len(tasks) > 0 {
+		total = tasks[0].Total
+	} else if offset > 0 {
+		err := s.db.GetContext(ctx, &total, `
+			SELECT COUNT(*)
+			FROM tasks t
+			JOIN key_results kr ON t.key_result_id = kr.id
+			JOIN objectives o ON kr.objective_id = o.id
+			WHERE `+taskListCondition+`
+		`, userID, filter.KeyResultID, filter.ObjectiveID, filter.Sphere, filter.DeadlineWithinDays)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка при подсчете задач: %v", err)
+		}
+	}
+	return tasks, total, nil
+}
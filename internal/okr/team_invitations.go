@@ -0,0 +1,256 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvitationNotFound	= errors.New("приглашение не найдено")
+	ErrAlreadyTeamMember	= errors.New("пользователь уже состоит в команде")
+)
+
+type TeamInvitation struct {
+	ID		int64		`db:"id" json:"id"`
+	TeamID		int64		`db:"team_id" json:"team_id"`
+	TeamName	string		`db:"team_name" json:"team_name"`
+	InvitedBy	int64		`db:"invited_by" json:"invited_by"`
+	InviterName	string		`db:"inviter_name" json:"inviter_name"`
+	InviteeID	int64		`db:"invitee_id" json:"invitee_id"`
+	Status		string		`db:"status" json:"status"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type MemberContribution struct {
+	UserID		int64	`db:"user_id" json:"user_id"`
+	Username	*string	`db:"username" json:"username,omitempty"`
+	FirstName	*string	`db:"first_name" json:"first_name,omitempty"`
+	KeyResultID	int64	`db:"key_result_id" json:"key_result_id"`
+	Delta		float64	`db:"delta" json:"delta"`
+}
+
+func (m *MemberContribution) DisplayName() string {
+	member := TeamMember{UserID: m.UserID, Username: m.Username, FirstName: m.FirstName}
+	return member.DisplayName()
+}
+
+const teamInvitationSelect = `
+	SELECT i.id, i.team_id, t.name AS team_name, i.invited_by,
+		COALESCE(NULLIF(u.first_name, ''), u.username, '') AS inviter_name,
+		i.invitee_id, i.status, i.created_at
+	FROM team_invitations i
+	JOIN user_teams t ON t.id = i.team_id
+	LEFT JOIN users u ON u.id = i.invited_by
+`
+
+func KeyResultAccessCondition(userParam string) string {
+	return fmt.Sprintf(`(o.user_id = %[1]s OR kr.owner_id = %[1]s OR EXISTS (
+		SELECT 1 FROM shared_objectives so
+		JOIN team_members tm ON tm.team_id = so.team_id
+		WHERE so.objective_id = o.id AND so.is_active = TRUE
+			AND tm.user_id = %[1]s AND tm.is_active = TRUE
+	))`, userParam)
+}
+
+func (s *Service) InviteToTeam(ctx context.Context, userID, teamID, inviteeID int64) (*TeamInvitation, error) {
+	role, err := s.getTeamRole(ctx, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" {
+		return nil, ErrTeamPermission
+	}
+
+	if _, err := s.getTeamRole(ctx, teamID, inviteeID); err == nil {
+		return nil, ErrAlreadyTeamMember
+	} else if !errors.Is(err, ErrNotTeamMember) {
+		return nil, err
+	}
+
+	var invitationID int64
+	err = s.db.GetContext(ctx, &invitationID, `
+		INSERT INTO team_invitations (team_id, invited_by, invitee_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (team_id, invitee_id) WHERE status = 'pending'
+		DO UPDATE SET invited_by = EXCLUDED.invited_by, notified = FALSE, created_at = NOW()
+		RETURNING id
+	`, teamID, userID, inviteeID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании приглашения в команду: %v", err)
+	}
+
+	return s.getInvitation(ctx, invitationID)
+}
+
+func (s *Service) getInvitation(ctx context.Context, invitationID int64) (*TeamInvitation, error) {
+	var invitation TeamInvitation
+	err := s.db.GetContext(ctx, &invitation, teamInvitationSelect+` WHERE i.id = $1`, invitationID)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении приглашения: %v", err)
+	}
+	return &invitation, nil
+}
+
+func (s *Service) GetPendingInvitations(ctx context.Context, userID int64) ([]TeamInvitation, error) {
+	var invitations []TeamInvitation
+	err := s.db.SelectContext(ctx, &invitations, teamInvitationSelect+`
+		WHERE i.invitee_id = $1 AND i.status = 'pending'
+		ORDER BY i.created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении приглашений: %v", err)
+	}
+	return invitations, nil
+}
+
+func (s *Service) RespondToInvitation(ctx context.Context, userID, invitationID int64, accept bool) (*TeamInvitation, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+
+	var teamID int64
+	err = tx.GetContext(ctx, &teamID, `
+		UPDATE team_invitations
+		SET status = $3, responded_at = NOW()
+		WHERE id = $1 AND invitee_id = $2 AND status = 'pending'
+		RETURNING team_id
+	`, invitationID, userID, status)
+	if err == sql.ErrNoRows {
+		err = ErrInvitationNotFound
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при ответе на приглашение: %v", err)
+	}
+
+	if accept {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO team_members (team_id, user_id, role)
+			VALUES ($1, $2, 'member')
+			ON CONFLICT (team_id, user_id) DO UPDATE SET is_active = TRUE
+		`, teamID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при добавлении участника в команду: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+
+	return s.getInvitation(ctx, invitationID)
+}
+
+func (s *Service) GetMemberContributions(ctx context.Context, objectiveID string) ([]MemberContribution, error) {
+	var contributions []MemberContribution
+	err := s.db.SelectContext(ctx, &contributions, `
+		SELECT l.user_id, u.username, u.first_name, l.key_result_id, SUM(l.delta) AS delta
+		FROM key_result_progress_log l
+		JOIN key_results kr ON kr.id = l.key_result_id
+		LEFT JOIN users u ON u.id = l.user_id
+		WHERE kr.objective_id = $1 AND l.user_id IS NOT NULL AND l.delta <> 0
+		GROUP BY l.user_id, u.username, u.first_name, l.key_result_id
+		ORDER BY SUM(l.delta) DESC
+	`, objectiveID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении вклада участников: %v", err)
+	}
+	return contributions, nil
+}
+
+func (s *Service) FormatTeamObjectiveProgress(ctx context.Context, objective Objective) (string, error) {
+	keyResults, err := s.GetKeyResultsForObjective(ctx, objective.ID)
+	if err != nil {
+		return "", err
+	}
+
+	contributions, err := s.GetMemberContributions(ctx, objective.ID)
+	if err != nil {
+		return "", err
+	}
+
+	targets := make(map[int64]float64)
+	var totalProgress float64
+	for _, kr := range keyResults {
+		targets[kr.ID] = kr.Target
+		totalProgress += kr.Progress
+	}
+	if len(keyResults) > 0 {
+		totalProgress /= float64(len(keyResults))
+	}
+
+	var order []int64
+	names := make(map[int64]string)
+	shares := make(map[int64]float64)
+	for _, c := range contributions {
+		if _, ok := names[c.UserID]; !ok {
+			order = append(order, c.UserID)
+			names[c.UserID] = c.DisplayName()
+		}
+		if target := targets[c.KeyResultID]; target > 0 && len(keyResults) > 0 {
+			shares[c.UserID] += c.Delta / target * 100 / float64(len(keyResults))
+		}
+	}
+
+	text := fmt.Sprintf("👥 **%s** — %.0f%%\n", objective.Title, totalProgress)
+	if len(order) == 0 {
+		return text + "   Участники еще не отмечали прогресс\n", nil
+	}
+	for _, userID := range order {
+		text += fmt.Sprintf("   • %s: +%.0f%%\n", names[userID], shares[userID])
+	}
+	return text, nil
+}
+
+func (s *Service) StartTeamInviteNotifier(jm *jobs.Manager, sendInvite func(chatID int64, text string, invitationID int64) error) {
+	jm.Register(jobs.Job{
+		Name:		"okr_team_invites",
+		Spec:		"@every 20s",
+		Run: func(ctx context.Context) {
+			s.notifyPendingInvitations(ctx, sendInvite)
+		},
+	})
+}
+
+func (s *Service) notifyPendingInvitations(ctx context.Context, sendInvite func(chatID int64, text string, invitationID int64) error) {
+	var invitations []TeamInvitation
+	err := s.db.SelectContext(ctx, &invitations, teamInvitationSelect+` WHERE i.status = 'pending' AND i.notified = FALSE`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении приглашений в команды OKR: %v", err)
+		return
+	}
+
+	for _, invitation := range invitations {
+		message := fmt.Sprintf("👥 %s приглашает вас в команду «%s».\n\nВы сможете отмечать прогресс по общим ключевым результатам, а команда увидит вклад каждого участника.",
+			invitation.InviterName, invitation.TeamName)
+
+		if err := sendInvite(invitation.InviteeID, message, invitation.ID); err != nil {
+			logrus.Errorf("Ошибка при отправке приглашения в команду пользователю %d: %v", invitation.InviteeID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE team_invitations SET notified = TRUE WHERE id = $1`, invitation.ID); err != nil {
+			logrus.Errorf("Ошибка при отметке приглашения %d: %v", invitation.ID, err)
+		}
+	}
+}
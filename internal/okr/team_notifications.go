@@ -0,0 +1,238 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/slack"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type TeamNotificationSettings struct {
+	TeamID		int64		`db:"team_id"`
+	Channel		string		`db:"channel"`
+	SlackWebhookURL	*string		`db:"slack_webhook_url"`
+	SlackBotToken	*string		`db:"slack_bot_token"`
+	SlackChannel	*string		`db:"slack_channel"`
+	WeeklyReport	bool		`db:"weekly_report"`
+	LastReportSent	*time.Time	`db:"last_report_sent"`
+}
+
+func (t *TeamNotificationSettings) SlackTarget() slack.Target {
+	var target slack.Target
+	if t.SlackWebhookURL != nil {
+		target.WebhookURL = *t.SlackWebhookURL
+	}
+	if t.SlackBotToken != nil {
+		target.BotToken = *t.SlackBotToken
+	}
+	if t.SlackChannel != nil {
+		target.Channel = *t.SlackChannel
+	}
+	return target
+}
+
+func (t *TeamNotificationSettings) UsesTelegram() bool {
+	return t.Channel == "telegram" || t.Channel == "both"
+}
+
+func (t *TeamNotificationSettings) UsesSlack() bool {
+	return t.Channel == "slack" || t.Channel == "both"
+}
+
+func (s *Service) GetTeamNotificationSettings(ctx context.Context, teamID int64) (*TeamNotificationSettings, error) {
+	query := `
+		SELECT team_id, channel, slack_webhook_url, slack_bot_token, slack_channel, weekly_report, last_report_sent
+		FROM team_notification_settings
+		WHERE team_id = $1
+	`
+
+	var settings TeamNotificationSettings
+	err := s.db.GetContext(ctx, &settings, query, teamID)
+	if err == sql.ErrNoRows {
+		return &TeamNotificationSettings{TeamID: teamID, Channel: "telegram", WeeklyReport: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении настроек уведомлений команды: %v", err)
+	}
+
+	return &settings, nil
+}
+
+func (s *Service) SetTeamNotificationSettings(ctx context.Context, userID int64, settings TeamNotificationSettings) error {
+	role, err := s.getTeamRole(ctx, settings.TeamID, userID)
+	if err != nil {
+		return err
+	}
+	if role != "admin" {
+		return ErrTeamPermission
+	}
+
+	if settings.Channel != "telegram" && settings.Channel != "slack" && settings.Channel != "both" {
+		return fmt.Errorf("неподдерживаемый канал уведомлений: %s", settings.Channel)
+	}
+
+	if settings.UsesSlack() && !settings.SlackTarget().Configured() {
+		return fmt.Errorf("для Slack нужен входящий webhook или токен бота с каналом")
+	}
+
+	query := `
+		INSERT INTO team_notification_settings (team_id, channel, slack_webhook_url, slack_bot_token, slack_channel, weekly_report)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (team_id) DO UPDATE
+		SET channel = $2, slack_webhook_url = $3, slack_bot_token = $4, slack_channel = $5, weekly_report = $6
+	`
+
+	_, err = s.db.ExecContext(ctx, query, settings.TeamID, settings.Channel, settings.SlackWebhookURL,
+		settings.SlackBotToken, settings.SlackChannel, settings.WeeklyReport)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении настроек уведомлений команды: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) NotifyTeam(ctx context.Context, teamID int64, text string, sendMessageFunc func(chatID int64, text string) error, slackClient *slack.Client) error {
+	settings, err := s.GetTeamNotificationSettings(ctx, teamID)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+
+	if settings.UsesSlack() {
+		if err := slackClient.Send(ctx, settings.SlackTarget(), text); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if settings.UsesTelegram() {
+		members, err := s.GetTeamMembers(ctx, teamID)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if err := sendMessageFunc(member.UserID, text); err != nil {
+				errs = append(errs, fmt.Sprintf("пользователь %d: %v", member.UserID, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("ошибка при уведомлении команды %d: %s", teamID, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (s *Service) StartTeamNotifier(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error, slackClient *slack.Client) {
+	jm.Register(jobs.Job{
+		Name:		"okr_team_notifications",
+		Spec:		"*/5 * * * *",
+		Run: func(ctx context.Context) {
+			s.notifyCompletedTeamObjectives(ctx, sendMessageFunc, slackClient)
+			s.sendWeeklyTeamReports(ctx, sendMessageFunc, slackClient)
+		},
+	})
+}
+
+func (s *Service) notifyCompletedTeamObjectives(ctx context.Context, sendMessageFunc func(chatID int64, text string) error, slackClient *slack.Client) {
+	query := `
+		SELECT so.id, so.team_id, o.id AS objective_id, o.title, t.name AS team_name
+		FROM shared_objectives so
+		JOIN objectives o ON o.id = so.objective_id
+		JOIN user_teams t ON t.id = so.team_id
+		WHERE so.is_active = TRUE AND so.completion_notified = FALSE AND o.deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM key_results kr WHERE kr.objective_id = o.id AND kr.deleted_at IS NULL)
+			AND NOT EXISTS (SELECT 1 FROM key_results kr WHERE kr.objective_id = o.id AND kr.deleted_at IS NULL AND kr.progress < kr.target)
+	`
+
+	var completed []struct {
+		ID		int64	`db:"id"`
+		TeamID		int64	`db:"team_id"`
+		ObjectiveID	string	`db:"objective_id"`
+		Title		string	`db:"title"`
+		TeamName	string	`db:"team_name"`
+	}
+	err := s.db.SelectContext(ctx, &completed, query)
+	if err != nil {
+		logrus.Errorf("Ошибка при поиске выполненных командных целей: %v", err)
+		return
+	}
+
+	for _, c := range completed {
+		text := fmt.Sprintf("🎉 Команда «%s» достигла цели «%s»! Все ключевые результаты выполнены.", c.TeamName, c.Title)
+
+		if err := s.NotifyTeam(ctx, c.TeamID, text, sendMessageFunc, slackClient); err != nil {
+			logrus.Errorf("Ошибка при уведомлении о выполнении цели %s: %v", c.ObjectiveID, err)
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE shared_objectives SET completion_notified = TRUE WHERE id = $1`, c.ID); err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса уведомления о цели %s: %v", c.ObjectiveID, err)
+		}
+	}
+}
+
+func (s *Service) sendWeeklyTeamReports(ctx context.Context, sendMessageFunc func(chatID int64, text string) error, slackClient *slack.Client) {
+	now := time.Now()
+	if now.Weekday() != time.Monday || now.Hour() < 10 {
+		return
+	}
+
+	query := `
+		SELECT team_id, channel, slack_webhook_url, slack_bot_token, slack_channel, weekly_report, last_report_sent
+		FROM team_notification_settings
+		WHERE weekly_report = TRUE
+			AND (last_report_sent IS NULL OR last_report_sent < NOW() - INTERVAL '6 days')
+	`
+
+	var settings []TeamNotificationSettings
+	err := s.db.SelectContext(ctx, &settings, query)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении настроек командных отчетов: %v", err)
+		return
+	}
+
+	for _, setting := range settings {
+		var objectives []struct {
+			ObjectiveID	string	`db:"objective_id"`
+			OwnerID		int64	`db:"owner_id"`
+		}
+		err := s.db.SelectContext(ctx, &objectives, `
+			SELECT so.objective_id, o.user_id AS owner_id
+			FROM shared_objectives so
+			JOIN objectives o ON o.id = so.objective_id
+			WHERE so.team_id = $1 AND so.is_active = TRUE AND o.deleted_at IS NULL
+			ORDER BY so.shared_at
+		`, setting.TeamID)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении целей команды %d: %v", setting.TeamID, err)
+			continue
+		}
+
+		var reports []string
+		for _, obj := range objectives {
+			report, err := s.GenerateTeamReport(ctx, obj.OwnerID, obj.ObjectiveID)
+			if err != nil {
+				logrus.Warnf("Не удалось сформировать отчет по цели %s: %v", obj.ObjectiveID, err)
+				continue
+			}
+			reports = append(reports, report)
+		}
+
+		if len(reports) > 0 {
+			text := "📊 Еженедельный командный отчет OKR\n\n" + strings.Join(reports, "\n\n")
+			if err := s.NotifyTeam(ctx, setting.TeamID, text, sendMessageFunc, slackClient); err != nil {
+				logrus.Errorf("Ошибка при отправке командного отчета: %v", err)
+			}
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE team_notification_settings SET last_report_sent = NOW() WHERE team_id = $1`, setting.TeamID); err != nil {
+			logrus.Errorf("Ошибка при обновлении времени командного отчета %d: %v", setting.TeamID, err)
+		}
+	}
+}
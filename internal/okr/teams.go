@@ -0,0 +1,563 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrTeamNotFound		= errors.New("команда не найдена")
+	ErrNotTeamMember	= errors.New("пользователь не состоит в команде")
+	ErrNotTeamObjective	= errors.New("цель не открыта ни одной команде")
+	ErrTeamPermission	= errors.New("недостаточно прав")
+)
+
+type Team struct {
+	ID		int64		`db:"id" json:"id"`
+	Name		string		`db:"name" json:"name"`
+	CreatedBy	int64		`db:"created_by" json:"created_by"`
+	Role		string		`db:"role" json:"role"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type TeamMember struct {
+	TeamID		int64		`db:"team_id" json:"team_id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Role		string		`db:"role" json:"role"`
+	Username	*string		`db:"username" json:"username,omitempty"`
+	FirstName	*string		`db:"first_name" json:"first_name,omitempty"`
+}
+
+func (m *TeamMember) DisplayName() string {
+	if m.FirstName != nil && *m.FirstName != "" {
+		return *m.FirstName
+	}
+	if m.Username != nil && *m.Username != "" {
+		return "@" + *m.Username
+	}
+	return fmt.Sprintf("%d", m.UserID)
+}
+
+type TeamKeyResult struct {
+	ID		int64		`db:"id"`
+	ObjectiveID	string		`db:"objective_id"`
+	ObjectiveTitle	string		`db:"objective_title"`
+	ObjectiveOwner	int64		`db:"objective_owner"`
+	Title		string		`db:"title"`
+	Target		float64		`db:"target"`
+	Unit		*string		`db:"unit"`
+	Progress	float64		`db:"progress"`
+	Deadline	*time.Time	`db:"deadline"`
+	OwnerID		*int64		`db:"owner_id"`
+	OwnerUsername	*string		`db:"owner_username"`
+	OwnerFirstName	*string		`db:"owner_first_name"`
+	LastOwnerNudge	*time.Time	`db:"last_owner_nudge"`
+}
+
+func (kr *TeamKeyResult) Percent() float64 {
+	if kr.Target <= 0 {
+		return 0
+	}
+	percent := kr.Progress / kr.Target * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+func (kr *TeamKeyResult) OwnerName() string {
+	if kr.OwnerID == nil {
+		return "без ответственного"
+	}
+	if kr.OwnerFirstName != nil && *kr.OwnerFirstName != "" {
+		return *kr.OwnerFirstName
+	}
+	if kr.OwnerUsername != nil && *kr.OwnerUsername != "" {
+		return "@" + *kr.OwnerUsername
+	}
+	return fmt.Sprintf("%d", *kr.OwnerID)
+}
+
+func (kr *TeamKeyResult) UnitName() string {
+	if kr.Unit == nil {
+		return ""
+	}
+	return *kr.Unit
+}
+
+const teamKeyResultSelect = `
+	SELECT kr.id, kr.objective_id, o.title AS objective_title, o.user_id AS objective_owner,
+		kr.title, kr.target, kr.unit, kr.progress, kr.deadline, kr.owner_id,
+		u.username AS owner_username, u.first_name AS owner_first_name, kr.last_owner_nudge
+	FROM key_results kr
+	JOIN objectives o ON kr.objective_id = o.id
+	LEFT JOIN users u ON u.id = kr.owner_id
+`
+
+func (s *Service) CreateTeam(ctx context.Context, userID int64, name string) (int64, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var teamID int64
+	err = tx.GetContext(ctx, &teamID, `
+		INSERT INTO user_teams (name, team_type, created_by)
+		VALUES ($1, 'okr', $2)
+		RETURNING id
+	`, name, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при создании команды: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO team_members (team_id, user_id, role)
+		VALUES ($1, $2, 'admin')
+	`, teamID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении создателя в команду: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+
+	return teamID, nil
+}
+
+func (s *Service) GetUserTeams(ctx context.Context, userID int64) ([]Team, error) {
+	query := `
+		SELECT t.id, t.name, COALESCE(t.created_by, 0) AS created_by, tm.role, t.created_at
+		FROM user_teams t
+		JOIN team_members tm ON tm.team_id = t.id
+		WHERE tm.user_id = $1 AND tm.is_active = TRUE AND t.is_active = TRUE
+		ORDER BY t.created_at
+	`
+
+	var teams []Team
+	err := s.db.SelectContext(ctx, &teams, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении команд: %v", err)
+	}
+
+	return teams, nil
+}
+
+func (s *Service) FindTeamByName(ctx context.Context, userID int64, name string) (*Team, error) {
+	teams, err := s.GetUserTeams(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		if len(teams) == 1 {
+			return &teams[0], nil
+		}
+		return nil, ErrTeamNotFound
+	}
+
+	needle := strings.ToLower(strings.TrimSpace(name))
+	for i := range teams {
+		if strings.Contains(strings.ToLower(teams[i].Name), needle) {
+			return &teams[i], nil
+		}
+	}
+
+	return nil, ErrTeamNotFound
+}
+
+func (s *Service) GetTeamMembers(ctx context.Context, teamID int64) ([]TeamMember, error) {
+	query := `
+		SELECT tm.team_id, tm.user_id, tm.role, u.username, u.first_name
+		FROM team_members tm
+		JOIN users u ON u.id = tm.user_id
+		WHERE tm.team_id = $1 AND tm.is_active = TRUE
+		ORDER BY tm.joined_at
+	`
+
+	var members []TeamMember
+	err := s.db.SelectContext(ctx, &members, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении участников команды: %v", err)
+	}
+
+	return members, nil
+}
+
+func (s *Service) getTeamRole(ctx context.Context, teamID, userID int64) (string, error) {
+	var role string
+	err := s.db.GetContext(ctx, &role, `
+		SELECT role FROM team_members
+		WHERE team_id = $1 AND user_id = $2 AND is_active = TRUE
+	`, teamID, userID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotTeamMember
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка при проверке участия в команде: %v", err)
+	}
+	return role, nil
+}
+
+func (s *Service) AddTeamMember(ctx context.Context, userID, teamID, memberID int64) error {
+	role, err := s.getTeamRole(ctx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if role != "admin" {
+		return ErrTeamPermission
+	}
+
+	query := `
+		INSERT INTO team_members (team_id, user_id, role)
+		VALUES ($1, $2, 'member')
+		ON CONFLICT (team_id, user_id) DO UPDATE SET is_active = TRUE
+	`
+	_, err = s.db.ExecContext(ctx, query, teamID, memberID)
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении участника в команду: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ShareObjectiveWithTeam(ctx context.Context, userID int64, objectiveID string, teamID int64) error {
+	var ownerID int64
+	err := s.db.GetContext(ctx, &ownerID, `SELECT user_id FROM objectives WHERE id = $1 AND deleted_at IS NULL`, objectiveID)
+	if err != nil {
+		return fmt.Errorf("цель не найдена: %v", err)
+	}
+	if ownerID != userID {
+		return ErrTeamPermission
+	}
+
+	if _, err := s.getTeamRole(ctx, teamID, userID); err != nil {
+		return err
+	}
+
+	var exists bool
+	err = s.db.GetContext(ctx, &exists, `
+		SELECT EXISTS(SELECT 1 FROM shared_objectives WHERE objective_id = $1 AND team_id = $2 AND is_active = TRUE)
+	`, objectiveID, teamID)
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке общего доступа: %v", err)
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO shared_objectives (objective_id, team_id, shared_by, can_edit, can_view_progress)
+		VALUES ($1, $2, $3, FALSE, TRUE)
+	`, objectiveID, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии цели команде: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetTeamObjectives(ctx context.Context, userID int64) ([]Objective, error) {
+	query := `
+		SELECT DISTINCT o.id, o.user_id, o.title, COALESCE(o.sphere, '') AS sphere, o.period, o.deadline, o.created_at
+		FROM objectives o
+		JOIN shared_objectives so ON so.objective_id = o.id AND so.is_active = TRUE
+		JOIN team_members tm ON tm.team_id = so.team_id AND tm.is_active = TRUE
+		WHERE tm.user_id = $1 AND o.deleted_at IS NULL
+		ORDER BY o.created_at DESC
+	`
+
+	var objectives []Objective
+	err := s.db.SelectContext(ctx, &objectives, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении командных целей: %v", err)
+	}
+
+	return objectives, nil
+}
+
+func (s *Service) isObjectiveTeamMember(ctx context.Context, objectiveID string, userID int64) (bool, error) {
+	var ok bool
+	err := s.db.GetContext(ctx, &ok, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM shared_objectives so
+			JOIN team_members tm ON tm.team_id = so.team_id
+			WHERE so.objective_id = $1 AND so.is_active = TRUE
+				AND tm.user_id = $2 AND tm.is_active = TRUE
+		)
+	`, objectiveID, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке доступа к цели: %v", err)
+	}
+	return ok, nil
+}
+
+func (s *Service) AssignKeyResultOwner(ctx context.Context, userID, keyResultID, ownerID int64) (*TeamKeyResult, error) {
+	var kr TeamKeyResult
+	err := s.db.GetContext(ctx, &kr, teamKeyResultSelect+` WHERE kr.id = $1`, keyResultID)
+	if err != nil {
+		return nil, fmt.Errorf("ключевой результат не найден: %v", err)
+	}
+	if kr.ObjectiveOwner != userID {
+		return nil, ErrTeamPermission
+	}
+
+	shared, err := s.isObjectiveTeamMember(ctx, kr.ObjectiveID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !shared {
+		return nil, ErrNotTeamObjective
+	}
+
+	if ownerID != userID {
+		isMember, err := s.isObjectiveTeamMember(ctx, kr.ObjectiveID, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, ErrNotTeamMember
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE key_results
+		SET owner_id = $1, owner_notified = $2, last_owner_nudge = NULL, updated_at = NOW()
+		WHERE id = $3
+	`, ownerID, ownerID == userID, keyResultID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при назначении ответственного: %v", err)
+	}
+
+	err = s.db.GetContext(ctx, &kr, teamKeyResultSelect+` WHERE kr.id = $1`, keyResultID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ключевого результата: %v", err)
+	}
+
+	return &kr, nil
+}
+
+func (s *Service) GetOwnedTeamKeyResults(ctx context.Context, userID int64) ([]TeamKeyResult, error) {
+	var keyResults []TeamKeyResult
+	err := s.db.SelectContext(ctx, &keyResults, teamKeyResultSelect+`
+		WHERE kr.owner_id = $1 AND o.user_id <> $1 AND kr.deleted_at IS NULL
+		ORDER BY kr.deadline NULLS LAST, kr.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении закрепленных ключевых результатов: %v", err)
+	}
+
+	return keyResults, nil
+}
+
+func (s *Service) GenerateTeamReport(ctx context.Context, userID int64, objectiveID string) (string, error) {
+	var objective Objective
+	err := s.db.GetContext(ctx, &objective, `
+		SELECT id, user_id, title, COALESCE(sphere, '') AS sphere, period, deadline, created_at
+		FROM objectives WHERE id = $1 AND deleted_at IS NULL
+	`, objectiveID)
+	if err != nil {
+		return "", fmt.Errorf("цель не найдена: %v", err)
+	}
+
+	if objective.UserID != userID {
+		isMember, err := s.isObjectiveTeamMember(ctx, objectiveID, userID)
+		if err != nil {
+			return "", err
+		}
+		if !isMember {
+			return "", ErrTeamPermission
+		}
+	}
+
+	var keyResults []TeamKeyResult
+	err = s.db.SelectContext(ctx, &keyResults, teamKeyResultSelect+`
+		WHERE kr.objective_id = $1 AND kr.deleted_at IS NULL
+		ORDER BY kr.owner_id NULLS LAST, kr.id
+	`, objectiveID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при получении ключевых результатов: %v", err)
+	}
+
+	type contribution struct {
+		KeyResultID	int64	`db:"key_result_id"`
+		UserID		int64	`db:"user_id"`
+		Delta		float64	`db:"delta"`
+	}
+	var contributions []contribution
+	err = s.db.SelectContext(ctx, &contributions, `
+		SELECT l.key_result_id, l.user_id, SUM(l.delta) AS delta
+		FROM key_result_progress_log l
+		JOIN key_results kr ON kr.id = l.key_result_id
+		WHERE kr.objective_id = $1 AND l.user_id IS NOT NULL AND l.delta <> 0
+		GROUP BY l.key_result_id, l.user_id
+	`, objectiveID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить вклад участников в цель %s: %v", objectiveID, err)
+	}
+
+	contributedByOthers := make(map[int64]float64)
+	for _, c := range contributions {
+		for _, kr := range keyResults {
+			if kr.ID == c.KeyResultID && (kr.OwnerID == nil || *kr.OwnerID != c.UserID) {
+				contributedByOthers[kr.ID] += c.Delta
+			}
+		}
+	}
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("👥 *Командный отчет: %s*\n\n", objective.Title))
+
+	if len(keyResults) == 0 {
+		report.WriteString("У цели пока нет ключевых результатов")
+		return report.String(), nil
+	}
+
+	var order []string
+	groups := make(map[string][]TeamKeyResult)
+	for _, kr := range keyResults {
+		owner := kr.OwnerName()
+		if _, ok := groups[owner]; !ok {
+			order = append(order, owner)
+		}
+		groups[owner] = append(groups[owner], kr)
+	}
+
+	now := time.Now()
+	var totalPercent float64
+	for _, owner := range order {
+		var ownerPercent float64
+		for _, kr := range groups[owner] {
+			ownerPercent += kr.Percent()
+		}
+		ownerPercent /= float64(len(groups[owner]))
+
+		report.WriteString(fmt.Sprintf("%s *%s* — %.0f%%\n", teamStatusIcon(ownerPercent, groups[owner], now), owner, ownerPercent))
+		for _, kr := range groups[owner] {
+			totalPercent += kr.Percent()
+			line := fmt.Sprintf("   • %s: %s/%s %s (%.0f%%)", kr.Title, formatFloat(kr.Progress), formatFloat(kr.Target), kr.UnitName(), kr.Percent())
+			if kr.Deadline != nil {
+				line += fmt.Sprintf(", до %s", kr.Deadline.Format("02.01"))
+			}
+			if delta := contributedByOthers[kr.ID]; delta != 0 {
+				line += fmt.Sprintf(", помощь команды: %s", formatFloat(delta))
+			}
+			report.WriteString(line + "\n")
+		}
+		report.WriteString("\n")
+	}
+
+	report.WriteString(fmt.Sprintf("📈 Общий прогресс команды: %.0f%%", totalPercent/float64(len(keyResults))))
+
+	return report.String(), nil
+}
+
+func teamStatusIcon(percent float64, keyResults []TeamKeyResult, now time.Time) string {
+	if percent >= 100 {
+		return "✅"
+	}
+	for _, kr := range keyResults {
+		if kr.Deadline != nil && kr.Deadline.Before(now) && kr.Percent() < 100 {
+			return "🔴"
+		}
+	}
+	if percent >= 50 {
+		return "🟢"
+	}
+	return "🟡"
+}
+
+func (s *Service) StartKeyResultOwnerNudger(jm *jobs.Manager, sendMessageFunc func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"okr_key_result_owner_nudges",
+		Spec:		"*/15 * * * *",
+		Run: func(ctx context.Context) {
+			s.checkKeyResultOwners(ctx, sendMessageFunc)
+		},
+	})
+
+	logrus.Info("Запущен механизм напоминаний ответственным за ключевые результаты")
+}
+
+func (s *Service) checkKeyResultOwners(ctx context.Context, sendMessageFunc func(chatID int64, text string) error) {
+	now := time.Now()
+
+	var assigned []TeamKeyResult
+	err := s.db.SelectContext(ctx, &assigned, teamKeyResultSelect+`
+		WHERE kr.owner_id IS NOT NULL AND kr.owner_notified = FALSE AND kr.deleted_at IS NULL
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении новых назначений ключевых результатов: %v", err)
+		return
+	}
+
+	for _, kr := range assigned {
+		text := fmt.Sprintf("🎯 Вам назначен ключевой результат «%s» в командной цели «%s».\nЦель: %s %s",
+			kr.Title, kr.ObjectiveTitle, formatFloat(kr.Target), kr.UnitName())
+		if kr.Deadline != nil {
+			text += fmt.Sprintf(", дедлайн %s", kr.Deadline.Format("02.01.2006"))
+		}
+		text += "\n\nСообщайте о прогрессе, например: «добавь 5 к " + kr.Title + "»"
+
+		if err := sendMessageFunc(*kr.OwnerID, text); err != nil {
+			logrus.Errorf("Ошибка при уведомлении ответственного %d: %v", *kr.OwnerID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE key_results SET owner_notified = TRUE, last_owner_nudge = NOW() WHERE id = $1`, kr.ID); err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса уведомления для ключевого результата %d: %v", kr.ID, err)
+		}
+	}
+
+	if now.Hour() < 10 || now.Hour() >= 20 {
+		return
+	}
+
+	var pending []TeamKeyResult
+	err = s.db.SelectContext(ctx, &pending, teamKeyResultSelect+`
+		WHERE kr.owner_id IS NOT NULL AND kr.owner_notified = TRUE AND kr.deleted_at IS NULL AND kr.progress < kr.target
+			AND (
+				(kr.deadline IS NOT NULL AND kr.deadline <= NOW() + INTERVAL '3 days'
+					AND (kr.last_owner_nudge IS NULL OR kr.last_owner_nudge < NOW() - INTERVAL '1 day'))
+				OR kr.last_owner_nudge IS NULL
+				OR kr.last_owner_nudge < NOW() - INTERVAL '7 days'
+			)
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении ключевых результатов для напоминаний: %v", err)
+		return
+	}
+
+	for _, kr := range pending {
+		var text string
+		if kr.Deadline != nil && kr.Deadline.Before(now.Add(72*time.Hour)) {
+			text = fmt.Sprintf("⏰ Приближается дедлайн по ключевому результату «%s» (%s): выполнено %.0f%%. Команда рассчитывает на вас!",
+				kr.Title, kr.Deadline.Format("02.01"), kr.Percent())
+		} else {
+			text = fmt.Sprintf("📋 Еженедельный чек-ин по командной цели «%s»\nКлючевой результат «%s»: %s/%s %s (%.0f%%)\n\nКак продвигается? Сообщите прогресс, и команда увидит его в отчете.",
+				kr.ObjectiveTitle, kr.Title, formatFloat(kr.Progress), formatFloat(kr.Target), kr.UnitName(), kr.Percent())
+		}
+
+		if err := sendMessageFunc(*kr.OwnerID, text); err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания ответственному %d: %v", *kr.OwnerID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE key_results SET last_owner_nudge = NOW() WHERE id = $1`, kr.ID); err != nil {
+			logrus.Errorf("Ошибка при обновлении времени напоминания для ключевого результата %d: %v", kr.ID, err)
+		}
+	}
+}
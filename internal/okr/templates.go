@@ -0,0 +1,173 @@
+package okr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrTemplateNotFound = errors.New("шаблон цели не найден")
+
+type TemplateRecurringTask struct {
+	Title		string	`json:"title"`
+	DailyTarget	float64	`json:"daily_target"`
+	Unit		string	`json:"unit"`
+}
+
+type TemplateKeyResult struct {
+	Title		string			`json:"title"`
+	Target		float64			`json:"target"`
+	Unit		string			`json:"unit"`
+	Recurring	*TemplateRecurringTask	`json:"recurring,omitempty"`
+}
+
+type ObjectiveTemplate struct {
+	ID		string			`json:"id"`
+	Title		string			`json:"title"`
+	Description	string			`json:"description"`
+	Sphere		string			`json:"sphere"`
+	Period		string			`json:"period"`
+	DurationDays	int			`json:"duration_days"`
+	KeyResults	[]TemplateKeyResult	`json:"key_results"`
+	keywords	[]string
+}
+
+type TemplateInstance struct {
+	ObjectiveID	string
+	Title		string
+	Deadline	time.Time
+	KeyResultIDs	[]int64
+	RecurringTasks	int
+}
+
+var objectiveTemplates = []ObjectiveTemplate{
+	{
+		ID:		"fitness",
+		Title:		"Прийти в форму",
+		Description:	"Регулярные тренировки, ежедневная активность и снижение веса за 3 месяца",
+		Sphere:		"Здоровье",
+		Period:		"quarter",
+		DurationDays:	90,
+		KeyResults: []TemplateKeyResult{
+			{Title: "Провести силовые тренировки", Target: 36, Unit: "тренировок"},
+			{Title: "Быть активным каждый день", Target: 90, Unit: "дней", Recurring: &TemplateRecurringTask{Title: "Пройти 8000 шагов", DailyTarget: 8000, Unit: "шагов"}},
+			{Title: "Снизить вес", Target: 4, Unit: "кг"},
+		},
+		keywords:	[]string{"фитнес", "форм", "спорт", "трениров", "похуд", "fitness"},
+	},
+	{
+		ID:		"content",
+		Title:		"Запустить авторский канал",
+		Description:	"Регулярные публикации, ежедневное письмо и первая тысяча подписчиков",
+		Sphere:		"Творчество",
+		Period:		"quarter",
+		DurationDays:	90,
+		KeyResults: []TemplateKeyResult{
+			{Title: "Опубликовать посты", Target: 36, Unit: "постов"},
+			{Title: "Набрать подписчиков", Target: 1000, Unit: "подписчиков"},
+			{Title: "Писать каждый день", Target: 90, Unit: "дней", Recurring: &TemplateRecurringTask{Title: "Писать черновик", DailyTarget: 30, Unit: "минут"}},
+		},
+		keywords:	[]string{"контент", "блог", "канал", "пост", "подписчик", "content"},
+	},
+	{
+		ID:		"savings",
+		Title:		"Создать финансовую подушку",
+		Description:	"Накопления, ежедневный учет расходов и сокращение необязательных трат",
+		Sphere:		"Финансы",
+		Period:		"quarter",
+		DurationDays:	90,
+		KeyResults: []TemplateKeyResult{
+			{Title: "Накопить резерв", Target: 100000, Unit: "рублей"},
+			{Title: "Вести учет расходов", Target: 90, Unit: "дней", Recurring: &TemplateRecurringTask{Title: "Записать расходы за день", DailyTarget: 1, Unit: "раз"}},
+			{Title: "Сократить необязательные траты", Target: 20, Unit: "%"},
+		},
+		keywords:	[]string{"накоп", "подушк", "сбереж", "финанс", "деньг", "savings"},
+	},
+	{
+		ID:		"language",
+		Title:		"Выучить иностранный язык до A2",
+		Description:	"Словарный запас, ежедневные занятия и разговорная практика",
+		Sphere:		"Образование",
+		Period:		"quarter",
+		DurationDays:	90,
+		KeyResults: []TemplateKeyResult{
+			{Title: "Выучить новые слова", Target: 1000, Unit: "слов"},
+			{Title: "Заниматься каждый день", Target: 90, Unit: "дней", Recurring: &TemplateRecurringTask{Title: "Урок языка", DailyTarget: 20, Unit: "минут"}},
+			{Title: "Провести разговорные практики", Target: 12, Unit: "занятий"},
+		},
+		keywords:	[]string{"язык", "англ", "немец", "испан", "француз", "language"},
+	},
+}
+
+func ObjectiveTemplates() []ObjectiveTemplate {
+	return objectiveTemplates
+}
+
+func FindObjectiveTemplate(ref string) (*ObjectiveTemplate, error) {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if ref == "" {
+		return nil, ErrTemplateNotFound
+	}
+	for i := range objectiveTemplates {
+		if objectiveTemplates[i].ID == ref || strings.ToLower(objectiveTemplates[i].Title) == ref {
+			return &objectiveTemplates[i], nil
+		}
+	}
+	for i := range objectiveTemplates {
+		for _, keyword := range objectiveTemplates[i].keywords {
+			if strings.Contains(ref, keyword) {
+				return &objectiveTemplates[i], nil
+			}
+		}
+	}
+	return nil, ErrTemplateNotFound
+}
+
+func (s *Service) InstantiateTemplate(ctx context.Context, userID int64, templateRef, title string, startDate time.Time) (*TemplateInstance, error) {
+	template, err := FindObjectiveTemplate(templateRef)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(title) == "" {
+		title = template.Title
+	}
+
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+	lastDay := start.AddDate(0, 0, template.DurationDays-1)
+	deadline := time.Date(lastDay.Year(), lastDay.Month(), lastDay.Day(), 23, 59, 59, 0, lastDay.Location())
+	plan := ObjectivePlan{Title: title, Sphere: template.Sphere, Period: template.Period, Deadline: &deadline}
+	for _, kr := range template.KeyResults {
+		keyResult := KeyResultPlan{KeyResult: KeyResult{Title: kr.Title, Target: kr.Target, Unit: kr.Unit, Deadline: &deadline}}
+		if kr.Recurring != nil {
+			keyResult.Recurring = &RecurringTaskPlan{Title: kr.Recurring.Title, DailyTarget: kr.Recurring.DailyTarget, Unit: kr.Recurring.Unit, StartDate: start, EndDate: lastDay}
+		}
+		plan.KeyResults = append(plan.KeyResults, keyResult)
+	}
+
+	created, err := s.CreateObjectivePlan(ctx, userID, plan)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateInstance{
+		ObjectiveID:	created.ObjectiveID,
+		Title:		title,
+		Deadline:	deadline,
+		KeyResultIDs:	created.KeyResultIDs,
+		RecurringTasks:	len(created.TaskIDs),
+	}, nil
+}
+
+func FormatObjectiveTemplate(t ObjectiveTemplate) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📋 **%s** (`%s`) — %s, %d дней\n%s\n", t.Title, t.ID, t.Sphere, t.DurationDays, t.Description))
+	for _, kr := range t.KeyResults {
+		b.WriteString(fmt.Sprintf("• %s: %g %s", kr.Title, kr.Target, kr.Unit))
+		if kr.Recurring != nil {
+			b.WriteString(fmt.Sprintf(" 🔁 ежедневно «%s» — %g %s", kr.Recurring.Title, kr.Recurring.DailyTarget, kr.Recurring.Unit))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
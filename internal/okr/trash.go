@@ -0,0 +1,289 @@
+package okr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/ownership"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const TrashRetentionDays = 30
+
+const (
+	DeletedKindObjective	= "objective"
+	DeletedKindKeyResult	= "key_result"
+	DeletedKindTask		= "task"
+)
+
+var (
+	ErrUnknownDeletedKind	= errors.New("неизвестный тип удаленного элемента")
+	ErrParentDeleted	= errors.New("родительский элемент тоже удален")
+)
+
+type DeletedItem struct {
+	Kind		string		`db:"kind"`
+	ID		string		`db:"id"`
+	Title		string		`db:"title"`
+	Parent		string		`db:"parent"`
+	DeletedAt	time.Time	`db:"deleted_at"`
+}
+
+func (i DeletedItem) ExpiresAt() time.Time {
+	return i.DeletedAt.AddDate(0, 0, TrashRetentionDays)
+}
+
+const deletedItemsQuery = `
+	SELECT * FROM (
+		SELECT 'objective' AS kind, o.id::text AS id, o.title, '' AS parent, o.deleted_at
+		FROM objectives o
+		WHERE o.user_id = $1 AND o.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'key_result', kr.id::text, kr.title, o.title, kr.deleted_at
+		FROM key_results kr
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = $1 AND kr.deleted_at IS NOT NULL AND o.deleted_at IS DISTINCT FROM kr.deleted_at
+		UNION ALL
+		SELECT 'task', t.id::text, t.title, kr.title, t.deleted_at
+		FROM tasks t
+		JOIN key_results kr ON kr.id = t.key_result_id
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = $1 AND t.deleted_at IS NOT NULL AND kr.deleted_at IS DISTINCT FROM t.deleted_at
+	) d
+`
+
+func (s *Service) GetDeletedItems(ctx context.Context, userID int64, limit int) ([]DeletedItem, error) {
+	items := make([]DeletedItem, 0)
+	err := s.db.SelectContext(ctx, &items, deletedItemsQuery+`
+		ORDER BY d.deleted_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении удаленных элементов: %v", err)
+	}
+	return items, nil
+}
+
+func (s *Service) GetLastDeletedItem(ctx context.Context, userID int64, within time.Duration) (*DeletedItem, error) {
+	var item DeletedItem
+	err := s.db.GetContext(ctx, &item, deletedItemsQuery+`
+		WHERE d.deleted_at > $2
+		ORDER BY d.deleted_at DESC
+		LIMIT 1
+	`, userID, time.Now().Add(-within))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении последнего удаленного элемента: %v", err)
+	}
+	return &item, nil
+}
+
+func (s *Service) FindDeletedItems(ctx context.Context, userID int64, kind, description string) ([]DeletedItem, error) {
+	items := make([]DeletedItem, 0)
+	err := s.db.SelectContext(ctx, &items, deletedItemsQuery+`
+		WHERE ($2 = '' OR d.kind = $2) AND LOWER(d.title) LIKE $3
+		ORDER BY d.deleted_at DESC
+		LIMIT 10
+	`, userID, kind, "%"+strings.ToLower(description)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске удаленных элементов: %v", err)
+	}
+	return items, nil
+}
+
+func (s *Service) RestoreDeletedItem(ctx context.Context, userID int64, kind, id string) (*DeletedItem, error) {
+	switch kind {
+	case DeletedKindObjective:
+		return s.RestoreDeletedObjective(ctx, userID, id)
+	case DeletedKindKeyResult:
+		keyResultID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, ownership.ErrKeyResultNotOwned
+		}
+		return s.RestoreDeletedKeyResult(ctx, userID, keyResultID)
+	case DeletedKindTask:
+		taskID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, ownership.ErrTaskNotOwned
+		}
+		return s.RestoreDeletedTask(ctx, userID, taskID)
+	}
+	return nil, ErrUnknownDeletedKind
+}
+
+func (s *Service) RestoreDeletedObjective(ctx context.Context, userID int64, objectiveID string) (*DeletedItem, error) {
+	return s.restoreDeleted(ctx, func(tx *sqlx.Tx) (*DeletedItem, error) {
+		item := DeletedItem{Kind: DeletedKindObjective, ID: objectiveID}
+		err := tx.GetContext(ctx, &item, `
+			SELECT title, deleted_at FROM objectives
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+			FOR UPDATE
+		`, objectiveID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ownership.ErrObjectiveNotOwned
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении удаленной цели: %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE tasks SET deleted_at = NULL
+			WHERE deleted_at = $2 AND key_result_id IN (SELECT id FROM key_results WHERE objective_id = $1)
+		`, objectiveID, item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("ошибка при восстановлении задач: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE key_results SET deleted_at = NULL WHERE objective_id = $1 AND deleted_at = $2
+		`, objectiveID, item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("ошибка при восстановлении ключевых результатов: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE objectives SET deleted_at = NULL WHERE id = $1`, objectiveID); err != nil {
+			return nil, fmt.Errorf("ошибка при восстановлении цели: %v", err)
+		}
+		return &item, nil
+	})
+}
+
+func (s *Service) RestoreDeletedKeyResult(ctx context.Context, userID int64, keyResultID int64) (*DeletedItem, error) {
+	return s.restoreDeleted(ctx, func(tx *sqlx.Tx) (*DeletedItem, error) {
+		var row struct {
+			Title			string		`db:"title"`
+			Parent			string		`db:"parent"`
+			DeletedAt		time.Time	`db:"deleted_at"`
+			ParentDeletedAt		*time.Time	`db:"parent_deleted_at"`
+		}
+		err := tx.GetContext(ctx, &row, `
+			SELECT kr.title, o.title AS parent, kr.deleted_at, o.deleted_at AS parent_deleted_at
+			FROM key_results kr
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE kr.id = $1 AND o.user_id = $2 AND kr.deleted_at IS NOT NULL
+			FOR UPDATE OF kr
+		`, keyResultID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ownership.ErrKeyResultNotOwned
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении удаленного ключевого результата: %v", err)
+		}
+		if row.ParentDeletedAt != nil {
+			return nil, ErrParentDeleted
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE tasks SET deleted_at = NULL WHERE key_result_id = $1 AND deleted_at = $2
+		`, keyResultID, row.DeletedAt); err != nil {
+			return nil, fmt.Errorf("ошибка при восстановлении задач: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE key_results SET deleted_at = NULL WHERE id = $1`, keyResultID); err != nil {
+			return nil, fmt.Errorf("ошибка при восстановлении ключевого результата: %v", err)
+		}
+		return &DeletedItem{Kind: DeletedKindKeyResult, ID: strconv.FormatInt(keyResultID, 10), Title: row.Title, Parent: row.Parent, DeletedAt: row.DeletedAt}, nil
+	})
+}
+
+func (s *Service) RestoreDeletedTask(ctx context.Context, userID int64, taskID int64) (*DeletedItem, error) {
+	return s.restoreDeleted(ctx, func(tx *sqlx.Tx) (*DeletedItem, error) {
+		var row struct {
+			Title			string		`db:"title"`
+			Parent			string		`db:"parent"`
+			DeletedAt		time.Time	`db:"deleted_at"`
+			ParentDeletedAt		*time.Time	`db:"parent_deleted_at"`
+		}
+		err := tx.GetContext(ctx, &row, `
+			SELECT t.title, kr.title AS parent, t.deleted_at, kr.deleted_at AS parent_deleted_at
+			FROM tasks t
+			JOIN key_results kr ON kr.id = t.key_result_id
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE t.id = $1 AND o.user_id = $2 AND t.deleted_at IS NOT NULL
+			FOR UPDATE OF t
+		`, taskID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ownership.ErrTaskNotOwned
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении удаленной задачи: %v", err)
+		}
+		if row.ParentDeletedAt != nil {
+			return nil, ErrParentDeleted
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE tasks SET deleted_at = NULL WHERE id = $1`, taskID); err != nil {
+			return nil, fmt.Errorf("ошибка при восстановлении задачи: %v", err)
+		}
+		return &DeletedItem{Kind: DeletedKindTask, ID: strconv.FormatInt(taskID, 10), Title: row.Title, Parent: row.Parent, DeletedAt: row.DeletedAt}, nil
+	})
+}
+
+func (s *Service) restoreDeleted(ctx context.Context, restore func(tx *sqlx.Tx) (*DeletedItem, error)) (*DeletedItem, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	item, err := restore(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при подтверждении транзакции: %v", err)
+	}
+	return item, nil
+}
+
+func (s *Service) PurgeDeleted(ctx context.Context) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -TrashRetentionDays)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var purged int64
+	for _, query := range []string{
+		`DELETE FROM tasks WHERE deleted_at < $1`,
+		`DELETE FROM key_results WHERE deleted_at < $1`,
+		`DELETE FROM objectives WHERE deleted_at < $1`,
+	} {
+		result, err := tx.ExecContext(ctx, query, cutoff)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка при очистке корзины: %v", err)
+		}
+		rows, _ := result.RowsAffected()
+		purged += rows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при подтверждении очистки корзины: %v", err)
+	}
+	return purged, nil
+}
+
+func (s *Service) StartTrashPurger(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:	"okr_trash_purge",
+		Spec:	"0 4 * * *",
+		Run: func(ctx context.Context) {
+			purged, err := s.PurgeDeleted(ctx)
+			if err != nil {
+				logrus.Errorf("Ошибка очистки удаленных целей, ключевых результатов и задач: %v", err)
+				return
+			}
+			if purged > 0 {
+				logrus.Infof("Окончательно удалено элементов OKR из корзины: %d", purged)
+			}
+		},
+	})
+
+	logrus.Info("Запущена очистка удаленных целей старше 30 дней")
+}
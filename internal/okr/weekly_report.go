@@ -0,0 +1,189 @@
+package okr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	riskPaceGap		= 20.0
+	riskStaleDays		= 14
+	riskStaleHorizonDays	= 30
+)
+
+type WeeklyKeyResult struct {
+	ID		int64		`db:"id"`
+	ObjectiveID	string		`db:"objective_id"`
+	ObjectiveTitle	string		`db:"objective_title"`
+	Title		string		`db:"title"`
+	Target		float64		`db:"target"`
+	Progress	float64		`db:"progress"`
+	Unit		string		`db:"unit"`
+	Deadline	*time.Time	`db:"deadline"`
+	CreatedAt	time.Time	`db:"created_at"`
+	WeekDelta	float64		`db:"week_delta"`
+	LastUpdate	*time.Time	`db:"last_update"`
+	RiskReason	string		`db:"-"`
+}
+
+type WeeklyObjective struct {
+	ID		string
+	Title		string
+	Progress	float64
+	Delta		float64
+}
+
+type WeeklyProgress struct {
+	WeekStart	time.Time
+	Objectives	[]WeeklyObjective
+	KeyResults	[]WeeklyKeyResult
+}
+
+func (kr *WeeklyKeyResult) Percent() float64 {
+	if kr.Target <= 0 {
+		return 0
+	}
+	return math.Min(kr.Progress/kr.Target*100, 100)
+}
+
+func (kr *WeeklyKeyResult) DeltaPercent() float64 {
+	if kr.Target <= 0 {
+		return 0
+	}
+	return kr.WeekDelta / kr.Target * 100
+}
+
+func (s *Service) GetWeeklyProgress(ctx context.Context, userID int64, weekStart time.Time) (*WeeklyProgress, error) {
+	var keyResults []WeeklyKeyResult
+	err := s.db.SelectContext(ctx, &keyResults, `
+		SELECT kr.id, kr.objective_id, o.title AS objective_title, kr.title, kr.target, kr.progress, kr.unit,
+			kr.deadline, kr.created_at,
+			COALESCE(SUM(l.delta) FILTER (WHERE l.created_at >= $2), 0) AS week_delta,
+			MAX(l.created_at) AS last_update
+		FROM key_results kr
+		JOIN objectives o ON o.id = kr.objective_id
+		LEFT JOIN key_result_progress_log l ON l.key_result_id = kr.id
+		WHERE o.user_id = $1 AND o.completion_date IS NULL AND kr.deleted_at IS NULL
+		GROUP BY kr.id, o.id, o.title, o.created_at
+		ORDER BY o.created_at DESC, kr.id
+	`, userID, weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении прогресса за неделю: %v", err)
+	}
+
+	now := time.Now()
+	progress := &WeeklyProgress{WeekStart: weekStart, KeyResults: keyResults}
+	index := make(map[string]int)
+	counts := make(map[string]int)
+	for i := range progress.KeyResults {
+		kr := &progress.KeyResults[i]
+		kr.RiskReason = keyResultRisk(kr, now)
+
+		j, ok := index[kr.ObjectiveID]
+		if !ok {
+			j = len(progress.Objectives)
+			index[kr.ObjectiveID] = j
+			progress.Objectives = append(progress.Objectives, WeeklyObjective{ID: kr.ObjectiveID, Title: kr.ObjectiveTitle})
+		}
+		progress.Objectives[j].Progress += kr.Percent()
+		progress.Objectives[j].Delta += kr.DeltaPercent()
+		counts[kr.ObjectiveID]++
+	}
+	for i := range progress.Objectives {
+		n := float64(counts[progress.Objectives[i].ID])
+		progress.Objectives[i].Progress /= n
+		progress.Objectives[i].Delta /= n
+	}
+
+	return progress, nil
+}
+
+func keyResultRisk(kr *WeeklyKeyResult, now time.Time) string {
+	percent := kr.Percent()
+	if percent >= 100 || kr.Deadline == nil {
+		return ""
+	}
+	if kr.Deadline.Before(now) {
+		return fmt.Sprintf("дедлайн %s прошел, выполнено %.0f%%", kr.Deadline.Format("02.01"), percent)
+	}
+
+	total := kr.Deadline.Sub(kr.CreatedAt)
+	if total > 0 {
+		expected := float64(now.Sub(kr.CreatedAt)) / float64(total) * 100
+		if percent < expected-riskPaceGap {
+			return fmt.Sprintf("отставание от темпа: %.0f%% вместо ~%.0f%% к дедлайну %s", percent, expected, kr.Deadline.Format("02.01"))
+		}
+	}
+
+	if kr.Deadline.Sub(now) < riskStaleHorizonDays*24*time.Hour {
+		if kr.LastUpdate == nil || now.Sub(*kr.LastUpdate) > riskStaleDays*24*time.Hour {
+			return fmt.Sprintf("нет прогресса больше %d дней, дедлайн %s", riskStaleDays, kr.Deadline.Format("02.01"))
+		}
+	}
+	return ""
+}
+
+func (w *WeeklyProgress) AtRisk() []WeeklyKeyResult {
+	risky := make([]WeeklyKeyResult, 0)
+	for _, kr := range w.KeyResults {
+		if kr.RiskReason != "" {
+			risky = append(risky, kr)
+		}
+	}
+	return risky
+}
+
+func (w *WeeklyProgress) Moved() []WeeklyKeyResult {
+	moved := make([]WeeklyKeyResult, 0)
+	for _, kr := range w.KeyResults {
+		if kr.WeekDelta != 0 {
+			moved = append(moved, kr)
+		}
+	}
+	return moved
+}
+
+func FormatWeeklyProgress(w *WeeklyProgress) string {
+	if len(w.Objectives) == 0 {
+		return "Активных целей с ключевыми результатами нет"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📊 *Неделя с %s*\n\n", w.WeekStart.Format("02.01")))
+	for i, o := range w.Objectives {
+		b.WriteString(fmt.Sprintf("%d. %s — %.0f%% (%s)\n", i+1, o.Title, o.Progress, formatDelta(o.Delta)))
+	}
+
+	if moved := w.Moved(); len(moved) > 0 {
+		b.WriteString("\n*Сдвинулись за неделю:*\n")
+		for _, kr := range moved {
+			b.WriteString(fmt.Sprintf("• %s: %s %s (%s)\n", kr.Title, formatSignedFloat(kr.WeekDelta), kr.Unit, formatDelta(kr.DeltaPercent())))
+		}
+	}
+
+	if risky := w.AtRisk(); len(risky) > 0 {
+		b.WriteString("\n⚠️ *Под угрозой:*\n")
+		for _, kr := range risky {
+			b.WriteString(fmt.Sprintf("• %s («%s») — %s\n", kr.Title, kr.ObjectiveTitle, kr.RiskReason))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func formatDelta(delta float64) string {
+	if math.Abs(delta) < 0.5 {
+		return "без изменений"
+	}
+	return fmt.Sprintf("%+.0f п.п.", delta)
+}
+
+func formatSignedFloat(value float64) string {
+	if value > 0 {
+		return "+" + formatFloat(value)
+	}
+	return formatFloat(value)
+}
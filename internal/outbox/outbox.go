@@ -0,0 +1,264 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"telegrambot/internal/jobs"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusPending	= "pending"
+	StatusSent	= "sent"
+	StatusFailed	= "failed"
+
+	batchSize	= 25
+	maxAttempts	= 8
+	baseBackoff	= 5 * time.Second
+	maxBackoff	= time.Hour
+	sentRetention	= 7 * 24 * time.Hour
+)
+
+type Message struct {
+	ID		int64		`db:"id" json:"id"`
+	ChatID		int64		`db:"chat_id" json:"chat_id"`
+	Text		string		`db:"text" json:"text"`
+	ParseMode	string		`db:"parse_mode" json:"parse_mode"`
+	Status		string		`db:"status" json:"status"`
+	Attempts	int		`db:"attempts" json:"attempts"`
+	NextAttemptAt	time.Time	`db:"next_attempt_at" json:"next_attempt_at"`
+	LastError	*string		`db:"last_error" json:"last_error,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type Stats struct {
+	Pending		int		`db:"pending" json:"pending"`
+	Failed		int		`db:"failed" json:"failed"`
+	OldestPending	*time.Time	`db:"oldest_pending" json:"oldest_pending,omitempty"`
+}
+
+type SendFunc func(ctx context.Context, chatID int64, text, parseMode string) error
+
+type Service struct {
+	db *sqlx.DB
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) Enqueue(ctx context.Context, chatID int64, parseMode string, chunks ...string) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, chunk := range chunks {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO outbound_messages (chat_id, text, parse_mode)
+			VALUES ($1, $2, $3)
+		`, chatID, chunk, parseMode)
+		if err != nil {
+			return fmt.Errorf("ошибка при постановке сообщения в очередь: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при постановке сообщения в очередь: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) StartSender(jm *jobs.Manager, send SendFunc) {
+	jm.Register(jobs.Job{
+		Name:	"outbox_sender",
+		Spec:	"@every 1s",
+		Run: func(ctx context.Context) {
+			s.deliverDue(ctx, send)
+		},
+	})
+	jm.Register(jobs.Job{
+		Name:	"outbox_cleanup",
+		Spec:	"30 4 * * *",
+		Run: func(ctx context.Context) {
+			s.cleanup(ctx)
+		},
+	})
+
+	logrus.Info("Запущена отправка сообщений из очереди")
+}
+
+func (s *Service) deliverDue(ctx context.Context, send SendFunc) {
+	var messages []Message
+	err := s.db.SelectContext(ctx, &messages, `
+		SELECT id, chat_id, text, parse_mode, status, attempts, next_attempt_at, last_error, created_at
+		FROM (
+			SELECT DISTINCT ON (chat_id) *
+			FROM outbound_messages
+			WHERE status = $1
+			ORDER BY chat_id, id
+		) head
+		WHERE next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT $2
+	`, StatusPending, batchSize)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборке сообщений из очереди: %v", err)
+		return
+	}
+
+	for _, message := range messages {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := send(ctx, message.ChatID, message.Text, message.ParseMode)
+		if err == nil {
+			if _, err := s.db.ExecContext(ctx, `
+				UPDATE outbound_messages SET status = $2, sent_at = NOW(), attempts = attempts + 1, last_error = NULL
+				WHERE id = $1
+			`, message.ID, StatusSent); err != nil {
+				logrus.Errorf("Ошибка при отметке отправленного сообщения %d: %v", message.ID, err)
+			}
+			continue
+		}
+
+		s.markFailedAttempt(ctx, message, err)
+	}
+}
+
+func (s *Service) markFailedAttempt(ctx context.Context, message Message, sendErr error) {
+	attempts := message.Attempts + 1
+	status := StatusPending
+	if attempts >= maxAttempts || IsPermanent(sendErr) {
+		status = StatusFailed
+	}
+
+	delay := Backoff(attempts)
+	if retryAfter := RetryAfter(sendErr); retryAfter > 0 {
+		delay = retryAfter
+	}
+
+	if status == StatusFailed {
+		logrus.Errorf("Сообщение %d для чата %d не доставлено после %d попыток: %v", message.ID, message.ChatID, attempts, sendErr)
+	} else {
+		logrus.Warnf("Не удалось отправить сообщение %d в чат %d (попытка %d), повтор через %s: %v", message.ID, message.ChatID, attempts, delay, sendErr)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE outbound_messages
+		SET status = $2, attempts = $3, next_attempt_at = NOW() + make_interval(secs => $4), last_error = $5
+		WHERE id = $1
+	`, message.ID, status, attempts, delay.Seconds(), sendErr.Error())
+	if err != nil {
+		logrus.Errorf("Ошибка при обновлении сообщения %d в очереди: %v", message.ID, err)
+	}
+}
+
+func (s *Service) cleanup(ctx context.Context) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM outbound_messages WHERE status = $1 AND sent_at < NOW() - make_interval(secs => $2)
+	`, StatusSent, sentRetention.Seconds())
+	if err != nil {
+		logrus.Errorf("Ошибка при очистке очереди сообщений: %v", err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		logrus.Infof("Удалено отправленных сообщений из очереди: %d", rows)
+	}
+}
+
+func (s *Service) GetStats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	err := s.db.GetContext(ctx, &stats, `
+		SELECT
+			COUNT(*) FILTER (WHERE status = $1) AS pending,
+			COUNT(*) FILTER (WHERE status = $2) AS failed,
+			MIN(created_at) FILTER (WHERE status = $1) AS oldest_pending
+		FROM outbound_messages
+	`, StatusPending, StatusFailed)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении статистики очереди: %v", err)
+	}
+	return &stats, nil
+}
+
+func (s *Service) ListFailed(ctx context.Context, limit int) ([]Message, error) {
+	var messages []Message
+	err := s.db.SelectContext(ctx, &messages, `
+		SELECT id, chat_id, text, parse_mode, status, attempts, next_attempt_at, last_error, created_at
+		FROM outbound_messages
+		WHERE status = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, StatusFailed, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении недоставленных сообщений: %v", err)
+	}
+	return messages, nil
+}
+
+func (s *Service) Retry(ctx context.Context, ids []int64) (int64, error) {
+	query := `UPDATE outbound_messages SET status = $1, attempts = 0, next_attempt_at = NOW() WHERE status = $2`
+	args := []interface{}{StatusPending, StatusFailed}
+	if len(ids) > 0 {
+		query += ` AND id = ANY($3)`
+		args = append(args, pq.Array(ids))
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при повторной постановке сообщений в очередь: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+func Backoff(attempts int) time.Duration {
+	delay := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempts-1)))
+	if delay > maxBackoff || delay <= 0 {
+		return maxBackoff
+	}
+	return delay
+}
+
+func RetryAfter(err error) time.Duration {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) && tgErr.RetryAfter > 0 {
+		return time.Duration(tgErr.RetryAfter) * time.Second
+	}
+	return 0
+}
+
+func IsPermanent(err error) bool {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	return tgErr.Code == http.StatusBadRequest || tgErr.Code == http.StatusForbidden || tgErr.Code == http.StatusNotFound
+}
+
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		return tgErr.Code == http.StatusTooManyRequests || tgErr.Code >= http.StatusInternalServerError
+	}
+	return !strings.Contains(err.Error(), "can't parse entities")
+}
@@ -0,0 +1,101 @@
+package ownership
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrObjectiveNotOwned	= errors.New("цель не найдена или не принадлежит пользователю")
+	ErrKeyResultNotOwned	= errors.New("ключевой результат не найден или не принадлежит пользователю")
+	ErrTaskNotOwned		= errors.New("задача не найдена или не принадлежит пользователю")
+	ErrEventNotOwned	= errors.New("событие не найдено или не принадлежит пользователю")
+)
+
+const (
+	objectiveOwnerQuery	= `SELECT EXISTS (SELECT 1 FROM objectives WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	keyResultOwnerQuery	= `
+		SELECT EXISTS (
+			SELECT 1 FROM key_results kr
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE kr.id = $1 AND o.user_id = $2 AND kr.deleted_at IS NULL
+		)`
+	taskOwnerQuery	= `
+		SELECT EXISTS (
+			SELECT 1 FROM tasks t
+			JOIN key_results kr ON kr.id = t.key_result_id
+			JOIN objectives o ON o.id = kr.objective_id
+			WHERE t.id = $1 AND o.user_id = $2 AND t.deleted_at IS NULL
+		)`
+	eventOwnerQuery	= `SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND user_id = $2)`
+)
+
+func IsNotOwned(err error) bool {
+	return errors.Is(err, ErrObjectiveNotOwned) || errors.Is(err, ErrKeyResultNotOwned) ||
+		errors.Is(err, ErrTaskNotOwned) || errors.Is(err, ErrEventNotOwned)
+}
+
+func MustOwnObjective(ctx context.Context, q sqlx.QueryerContext, userID int64, objectiveID string) error {
+	if objectiveID == "" {
+		return ErrObjectiveNotOwned
+	}
+	return check(ctx, q, objectiveOwnerQuery, ErrObjectiveNotOwned, objectiveID, userID)
+}
+
+func MustOwnKeyResult(ctx context.Context, q sqlx.QueryerContext, userID, keyResultID int64) error {
+	if keyResultID <= 0 {
+		return ErrKeyResultNotOwned
+	}
+	return check(ctx, q, keyResultOwnerQuery, ErrKeyResultNotOwned, keyResultID, userID)
+}
+
+func MustOwnTask(ctx context.Context, q sqlx.QueryerContext, userID, taskID int64) error {
+	if taskID <= 0 {
+		return ErrTaskNotOwned
+	}
+	return check(ctx, q, taskOwnerQuery, ErrTaskNotOwned, taskID, userID)
+}
+
+func MustOwnEvent(ctx context.Context, q sqlx.QueryerContext, userID int64, eventID string) error {
+	if eventID == "" {
+		return ErrEventNotOwned
+	}
+	return check(ctx, q, eventOwnerQuery, ErrEventNotOwned, eventID, userID)
+}
+
+func FirstOwner(userIDs []int64, guard func(userID int64) error) (int64, error) {
+	var lastErr error
+	for _, userID := range userIDs {
+		err := guard(userID)
+		if err == nil {
+			return userID, nil
+		}
+		if !IsNotOwned(err) {
+			return 0, err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("нет привязанных аккаунтов")
+	}
+	return 0, lastErr
+}
+
+func check(ctx context.Context, q sqlx.QueryerContext, query string, notOwned error, id interface{}, userID int64) error {
+	var owned bool
+	err := sqlx.GetContext(ctx, q, &owned, query, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notOwned
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке владельца: %v", err)
+	}
+	if !owned {
+		return notOwned
+	}
+	return nil
+}
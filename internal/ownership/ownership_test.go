@@ -0,0 +1,183 @@
+package ownership
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	owner	= int64(100)
+	intruder	= int64(200)
+)
+
+type guardCase struct {
+	name		string
+	query		string
+	id		interface{}
+	guard		func(ctx context.Context, q sqlx.QueryerContext, userID int64) error
+	notOwned	error
+}
+
+func guardCases() []guardCase {
+	return []guardCase{
+		{
+			name:		"objective",
+			query:		objectiveOwnerQuery,
+			id:		"obj-1",
+			guard:		func(ctx context.Context, q sqlx.QueryerContext, userID int64) error { return MustOwnObjective(ctx, q, userID, "obj-1") },
+			notOwned:	ErrObjectiveNotOwned,
+		},
+		{
+			name:		"key result",
+			query:		keyResultOwnerQuery,
+			id:		int64(7),
+			guard:		func(ctx context.Context, q sqlx.QueryerContext, userID int64) error { return MustOwnKeyResult(ctx, q, userID, 7) },
+			notOwned:	ErrKeyResultNotOwned,
+		},
+		{
+			name:		"task",
+			query:		taskOwnerQuery,
+			id:		int64(42),
+			guard:		func(ctx context.Context, q sqlx.QueryerContext, userID int64) error { return MustOwnTask(ctx, q, userID, 42) },
+			notOwned:	ErrTaskNotOwned,
+		},
+		{
+			name:		"event",
+			query:		eventOwnerQuery,
+			id:		"ev-1",
+			guard:		func(ctx context.Context, q sqlx.QueryerContext, userID int64) error { return MustOwnEvent(ctx, q, userID, "ev-1") },
+			notOwned:	ErrEventNotOwned,
+		},
+	}
+}
+
+func newMock(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("не удалось создать sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return sqlx.NewDb(db, "postgres"), mock
+}
+
+func TestMustOwnCrossUserAccess(t *testing.T) {
+	for _, tc := range guardCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := newMock(t)
+			mock.ExpectQuery(tc.query).WithArgs(tc.id, intruder).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectQuery(tc.query).WithArgs(tc.id, owner).
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+			err := tc.guard(context.Background(), db, intruder)
+			if !errors.Is(err, tc.notOwned) {
+				t.Fatalf("чужой пользователь: ожидалась ошибка %v, получено %v", tc.notOwned, err)
+			}
+			if !IsNotOwned(err) {
+				t.Fatalf("IsNotOwned(%v) = false", err)
+			}
+			if err := tc.guard(context.Background(), db, owner); err != nil {
+				t.Fatalf("владелец: ожидался доступ, получено %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMustOwnDatabaseError(t *testing.T) {
+	for _, tc := range guardCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := newMock(t)
+			mock.ExpectQuery(tc.query).WithArgs(tc.id, owner).WillReturnError(errors.New("connection reset"))
+
+			err := tc.guard(context.Background(), db, owner)
+			if err == nil || IsNotOwned(err) {
+				t.Fatalf("ошибка базы не должна выглядеть как чужой объект, получено %v", err)
+			}
+		})
+	}
+}
+
+func TestMustOwnInvalidID(t *testing.T) {
+	db, mock := newMock(t)
+	tests := []struct {
+		name	string
+		err	error
+		want	error
+	}{
+		{"empty objective", MustOwnObjective(context.Background(), db, owner, ""), ErrObjectiveNotOwned},
+		{"zero key result", MustOwnKeyResult(context.Background(), db, owner, 0), ErrKeyResultNotOwned},
+		{"negative task", MustOwnTask(context.Background(), db, owner, -1), ErrTaskNotOwned},
+		{"empty event", MustOwnEvent(context.Background(), db, owner, ""), ErrEventNotOwned},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: ожидалась ошибка %v, получено %v", tt.name, tt.want, tt.err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("для некорректного ID не должно быть запросов: %v", err)
+	}
+}
+
+func TestFirstOwner(t *testing.T) {
+	const userA, userB = int64(1), int64(2)
+	dbErr := errors.New("connection reset")
+	tests := []struct {
+		name	string
+		userIDs	[]int64
+		owners	map[int64]error
+		want	int64
+		wantErr	error
+	}{
+		{
+			name:		"rejects A, accepts B",
+			userIDs:	[]int64{userA, userB},
+			owners:		map[int64]error{userA: ErrObjectiveNotOwned, userB: nil},
+			want:		userB,
+		},
+		{
+			name:		"first owner wins",
+			userIDs:	[]int64{userB, userA},
+			owners:		map[int64]error{userA: ErrObjectiveNotOwned, userB: nil},
+			want:		userB,
+		},
+		{
+			name:		"nobody owns",
+			userIDs:	[]int64{userA, userB},
+			owners:		map[int64]error{userA: ErrTaskNotOwned, userB: ErrTaskNotOwned},
+			wantErr:	ErrTaskNotOwned,
+		},
+		{
+			name:		"database error stops the search",
+			userIDs:	[]int64{userA, userB},
+			owners:		map[int64]error{userA: dbErr, userB: nil},
+			wantErr:	dbErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FirstOwner(tt.userIDs, func(userID int64) error { return tt.owners[userID] })
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || got != 0 {
+					t.Fatalf("ожидалась ошибка %v, получено (%d, %v)", tt.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ожидался пользователь %d, получено (%d, %v)", tt.want, got, err)
+			}
+		})
+	}
+
+	if _, err := FirstOwner(nil, func(int64) error { return nil }); err == nil {
+		t.Fatal("без привязанных аккаунтов ожидалась ошибка")
+	}
+}
@@ -0,0 +1,74 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/admin"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const expiryReminderBefore = 3 * 24 * time.Hour
+
+func (s *Service) StartExpiryChecker(jm *jobs.Manager, sendMessage func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"subscription_expiry",
+		Spec:		"0 9 * * *",
+		RunOnStart:	true,
+		Run: func(ctx context.Context) {
+			s.remindExpiring(ctx, sendMessage)
+			s.downgradeExpired(ctx, sendMessage)
+		},
+	})
+
+	logrus.Info("Запущена проверка истекших подписок")
+}
+
+func (s *Service) remindExpiring(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	var users []struct {
+		ID		int64		`db:"id"`
+		ExpiresAt	time.Time	`db:"subscription_expires_at"`
+	}
+	err := s.db.SelectContext(ctx, &users, `
+		UPDATE users SET subscription_reminded = TRUE
+		WHERE role = $1 AND subscription_reminded = FALSE
+			AND subscription_expires_at BETWEEN NOW() AND $2
+		RETURNING id, subscription_expires_at
+	`, admin.RolePremium, time.Now().Add(expiryReminderBefore))
+	if err != nil {
+		logrus.Errorf("Ошибка при поиске истекающих подписок: %v", err)
+		return
+	}
+
+	for _, u := range users {
+		text := fmt.Sprintf("⏳ Подписка закончится %s. Продлить — /subscribe", u.ExpiresAt.Format("02.01.2006"))
+		if err := sendMessage(u.ID, text); err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания о подписке пользователю %d: %v", u.ID, err)
+		}
+	}
+}
+
+func (s *Service) downgradeExpired(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, `
+		UPDATE users SET role = $1
+		WHERE role = $2 AND subscription_expires_at < NOW()
+		RETURNING id
+	`, admin.RoleFree, admin.RolePremium)
+	if err != nil {
+		logrus.Errorf("Ошибка при отключении истекших подписок: %v", err)
+		return
+	}
+
+	if len(userIDs) > 0 {
+		logrus.Infof("Отключено истекших подписок: %d", len(userIDs))
+	}
+
+	for _, id := range userIDs {
+		if err := sendMessage(id, "🔒 Срок подписки закончился. Чтобы продолжить пользоваться ассистентом, оформите подписку — /subscribe"); err != nil {
+			logrus.Errorf("Ошибка при отправке уведомления об окончании подписки пользователю %d: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/admin"
+	"telegrambot/pkg/config"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const payloadPrefix = "sub"
+
+var (
+	ErrPaymentsDisabled	= errors.New("оплата не настроена")
+	ErrInvalidPayload	= errors.New("неверные данные счета")
+	ErrAmountMismatch	= errors.New("сумма платежа не совпадает с тарифом")
+)
+
+type Service struct {
+	db		*sqlx.DB
+	providerToken	string
+	plan		Plan
+	freeTier	FreeTier
+}
+
+type Plan struct {
+	Title		string
+	Description	string
+	Amount		int
+	Currency	string
+	Days		int
+}
+
+type Payment struct {
+	UserID			int64
+	TelegramChargeID	string
+	ProviderChargeID	string
+	Amount			int
+	Currency		string
+	Payload			string
+}
+
+type Subscription struct {
+	Role		string		`db:"role" json:"role"`
+	ExpiresAt	*time.Time	`db:"subscription_expires_at" json:"expires_at,omitempty"`
+}
+
+func NewService(db *sqlx.DB, cfg *config.Config) *Service {
+	amount, err := strconv.Atoi(cfg.SubscriptionPrice)
+	if err != nil {
+		logrus.Warnf("Некорректная цена подписки %q: %v", cfg.SubscriptionPrice, err)
+	}
+	freeMessages, err := strconv.Atoi(cfg.FreeMessageQuota)
+	if err != nil || freeMessages < 0 {
+		logrus.Warnf("Некорректная квота бесплатного тарифа %q, бесплатный тариф отключен", cfg.FreeMessageQuota)
+		freeMessages = 0
+	}
+	days, err := strconv.Atoi(cfg.SubscriptionDays)
+	if err != nil || days <= 0 {
+		logrus.Warnf("Некорректная длительность подписки %q, используется 30 дней", cfg.SubscriptionDays)
+		days = 30
+	}
+
+	return &Service{
+		db:		db,
+		providerToken:	cfg.PaymentProviderToken,
+		plan: Plan{
+			Title:		"Подписка Jarvis",
+			Description:	fmt.Sprintf("Полный доступ к ассистенту на %d дней: цели OKR, календарь, финансы, напоминания", days),
+			Amount:		amount,
+			Currency:	strings.ToUpper(cfg.SubscriptionCurrency),
+			Days:		days,
+		},
+		freeTier: FreeTier{
+			Messages:	freeMessages,
+			Audio:		cfg.FreeAudioEnabled == "true",
+		},
+	}
+}
+
+func (s *Service) Enabled() bool {
+	return s.providerToken != "" && s.plan.Amount > 0
+}
+
+func (s *Service) ProviderToken() string {
+	return s.providerToken
+}
+
+func (s *Service) Plan() Plan {
+	return s.plan
+}
+
+func (p Plan) FormatPrice() string {
+	return fmt.Sprintf("%d.%02d %s", p.Amount/100, p.Amount%100, p.Currency)
+}
+
+func (s *Service) InvoicePayload(userID int64) string {
+	return fmt.Sprintf("%s:%d:%d", payloadPrefix, userID, s.plan.Days)
+}
+
+func parsePayload(payload string) (int64, int, error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != payloadPrefix {
+		return 0, 0, ErrInvalidPayload
+	}
+
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidPayload
+	}
+	days, err := strconv.Atoi(parts[2])
+	if err != nil || days <= 0 {
+		return 0, 0, ErrInvalidPayload
+	}
+
+	return userID, days, nil
+}
+
+func (s *Service) ValidatePreCheckout(userID int64, payload, currency string, amount int) error {
+	if !s.Enabled() {
+		return ErrPaymentsDisabled
+	}
+
+	payloadUserID, days, err := parsePayload(payload)
+	if err != nil {
+		return err
+	}
+	if payloadUserID != userID || days != s.plan.Days {
+		return ErrInvalidPayload
+	}
+	if amount != s.plan.Amount || !strings.EqualFold(currency, s.plan.Currency) {
+		return ErrAmountMismatch
+	}
+
+	return nil
+}
+
+func (s *Service) Activate(ctx context.Context, payment Payment) (time.Time, error) {
+	payloadUserID, days, err := parsePayload(payment.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if payloadUserID != payment.UserID {
+		return time.Time{}, ErrInvalidPayload
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var expiresAt time.Time
+	err = tx.GetContext(ctx, &expiresAt, `
+		UPDATE users
+		SET role = $1,
+			subscription_expires_at = GREATEST(COALESCE(subscription_expires_at, NOW()), NOW()) + make_interval(days => $2),
+			subscription_reminded = FALSE
+		WHERE id = $3
+		RETURNING subscription_expires_at
+	`, admin.RolePremium, days, payment.UserID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка при продлении подписки: %v", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO payments (user_id, telegram_payment_charge_id, provider_payment_charge_id, amount, currency, payload, days, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (telegram_payment_charge_id) DO NOTHING
+	`, payment.UserID, payment.TelegramChargeID, payment.ProviderChargeID, payment.Amount, payment.Currency, payment.Payload, days, expiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка при сохранении платежа: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		err = fmt.Errorf("платеж %s уже обработан", payment.TelegramChargeID)
+		return time.Time{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("ошибка при фиксации транзакции: %v", err)
+	}
+
+	return expiresAt, nil
+}
+
+func (s *Service) GetSubscription(ctx context.Context, userID int64) (*Subscription, error) {
+	var sub Subscription
+	err := s.db.GetContext(ctx, &sub, `SELECT role, subscription_expires_at FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении подписки: %v", err)
+	}
+	return &sub, nil
+}
+
+func (s *Subscription) IsActive() bool {
+	return s.Role != admin.RoleFree
+}
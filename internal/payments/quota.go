@@ -0,0 +1,83 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type FreeTier struct {
+	Messages	int
+	Audio		bool
+}
+
+type Usage struct {
+	Used		int
+	Limit		int
+	ResetsAt	time.Time
+	UpsellSent	bool
+}
+
+func (u *Usage) Exhausted() bool {
+	return u.Used >= u.Limit
+}
+
+func (u *Usage) Remaining() int {
+	if u.Used >= u.Limit {
+		return 0
+	}
+	return u.Limit - u.Used
+}
+
+func (s *Service) FreeTier() FreeTier {
+	return s.freeTier
+}
+
+func (s *Service) FreeTierEnabled() bool {
+	return s.freeTier.Messages > 0
+}
+
+func periodStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+func (s *Service) ConsumeFreeMessage(ctx context.Context, userID int64) (*Usage, bool, error) {
+	now := time.Now()
+	period := periodStart(now)
+	usage := &Usage{Limit: s.freeTier.Messages, ResetsAt: period.AddDate(0, 1, 0)}
+
+	err := s.db.GetContext(ctx, &usage.Used, `
+		INSERT INTO usage_counters (user_id, period_start, messages)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, period_start) DO UPDATE
+		SET messages = usage_counters.messages + 1
+		WHERE usage_counters.messages < $3
+		RETURNING messages
+	`, userID, period.Format("2006-01-02"), s.freeTier.Messages)
+	if err == nil {
+		return usage, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("ошибка при учете сообщения бесплатного тарифа: %v", err)
+	}
+
+	var row struct {
+		Messages	int	`db:"messages"`
+		UpsellSent	bool	`db:"upsell_sent"`
+	}
+	err = s.db.GetContext(ctx, &row, `
+		UPDATE usage_counters
+		SET upsell_sent = TRUE
+		WHERE user_id = $1 AND period_start = $2
+		RETURNING messages, (SELECT upsell_sent FROM usage_counters WHERE user_id = $1 AND period_start = $2) AS upsell_sent
+	`, userID, period.Format("2006-01-02"))
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка при получении использования бесплатного тарифа: %v", err)
+	}
+
+	usage.Used = row.Messages
+	usage.UpsellSent = row.UpsellSent
+	return usage, false, nil
+}
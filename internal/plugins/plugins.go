@@ -0,0 +1,205 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"telegrambot/pkg/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	Separator	= "__"
+	maxNameLength	= 64
+)
+
+var (
+	ErrUnknownFunction	= errors.New("функция плагина не найдена")
+	ErrPluginDisabled	= errors.New("плагин выключен")
+)
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+type Handler func(ctx context.Context, userID int64, args map[string]interface{}) (string, error)
+
+type Function struct {
+	Name		string
+	Description	string
+	Parameters	json.RawMessage
+	Handler		Handler
+}
+
+type Plugin struct {
+	Name		string
+	Description	string
+	Functions	[]Function
+}
+
+type registry struct {
+	mu		sync.RWMutex
+	plugins		map[string]Plugin
+	enabled		map[string]bool
+	enableAll	bool
+}
+
+var defaultRegistry = &registry{
+	plugins:	make(map[string]Plugin),
+	enabled:	make(map[string]bool),
+}
+
+func QualifiedName(plugin, function string) string {
+	return plugin + Separator + function
+}
+
+func Register(plugin Plugin) error {
+	if !namePattern.MatchString(plugin.Name) {
+		return fmt.Errorf("некорректное имя плагина %q: допустимы строчные латинские буквы, цифры и одиночные подчеркивания", plugin.Name)
+	}
+	seen := make(map[string]bool, len(plugin.Functions))
+	for _, function := range plugin.Functions {
+		name := QualifiedName(plugin.Name, function.Name)
+		if !namePattern.MatchString(function.Name) || len(name) > maxNameLength {
+			return fmt.Errorf("некорректное имя функции %q плагина %s", function.Name, plugin.Name)
+		}
+		if seen[function.Name] {
+			return fmt.Errorf("функция %s объявлена в плагине %s дважды", function.Name, plugin.Name)
+		}
+		if function.Handler == nil {
+			return fmt.Errorf("у функции %s плагина %s нет обработчика", function.Name, plugin.Name)
+		}
+		if len(function.Parameters) > 0 && !json.Valid(function.Parameters) {
+			return fmt.Errorf("некорректная схема параметров функции %s плагина %s", function.Name, plugin.Name)
+		}
+		seen[function.Name] = true
+	}
+
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	if _, ok := defaultRegistry.plugins[plugin.Name]; ok {
+		return fmt.Errorf("плагин %s уже зарегистрирован", plugin.Name)
+	}
+	defaultRegistry.plugins[plugin.Name] = plugin
+	return nil
+}
+
+func MustRegister(plugin Plugin) {
+	if err := Register(plugin); err != nil {
+		panic(err)
+	}
+}
+
+func SetEnabled(names []string) {
+	defaultRegistry.mu.Lock()
+	defer defaultRegistry.mu.Unlock()
+	defaultRegistry.enabled = make(map[string]bool, len(names))
+	defaultRegistry.enableAll = false
+	for _, name := range names {
+		if name == "*" {
+			defaultRegistry.enableAll = true
+			continue
+		}
+		defaultRegistry.enabled[name] = true
+	}
+}
+
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func Configure(ctx context.Context, cfg *config.Config) {
+	SetEnabled(ParseList(cfg.PluginsEnabled))
+
+	servers, err := ParseServers(cfg.PluginServers)
+	if err != nil {
+		logrus.Errorf("Ошибка в настройке серверов плагинов: %v", err)
+	}
+	client := newToolServerClient(cfg.PluginServerToken)
+	for _, server := range servers {
+		plugin, err := client.load(ctx, server)
+		if err != nil {
+			logrus.Errorf("Не удалось загрузить функции сервера плагина %s (%s): %v", server.Name, server.URL, err)
+			continue
+		}
+		if err := Register(plugin); err != nil {
+			logrus.Errorf("Не удалось зарегистрировать плагин %s: %v", server.Name, err)
+			continue
+		}
+		logrus.Infof("Загружен сервер плагина %s: функций %d", plugin.Name, len(plugin.Functions))
+	}
+
+	for _, name := range Enabled() {
+		logrus.Infof("Включен плагин %s", name)
+	}
+}
+
+func (r *registry) isEnabled(name string) bool {
+	return r.enableAll || r.enabled[name]
+}
+
+func Enabled() []string {
+	defaultRegistry.mu.RLock()
+	defer defaultRegistry.mu.RUnlock()
+	var names []string
+	for name := range defaultRegistry.plugins {
+		if defaultRegistry.isEnabled(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Functions() []Function {
+	var functions []Function
+	for _, name := range Enabled() {
+		defaultRegistry.mu.RLock()
+		plugin := defaultRegistry.plugins[name]
+		defaultRegistry.mu.RUnlock()
+		for _, function := range plugin.Functions {
+			function.Name = QualifiedName(plugin.Name, function.Name)
+			functions = append(functions, function)
+		}
+	}
+	return functions
+}
+
+func IsPluginFunction(name string) bool {
+	return strings.Contains(name, Separator)
+}
+
+func Call(ctx context.Context, userID int64, name string, args map[string]interface{}) (string, error) {
+	pluginName, functionName, ok := strings.Cut(name, Separator)
+	if !ok {
+		return "", ErrUnknownFunction
+	}
+
+	defaultRegistry.mu.RLock()
+	plugin, registered := defaultRegistry.plugins[pluginName]
+	enabled := defaultRegistry.isEnabled(pluginName)
+	defaultRegistry.mu.RUnlock()
+	if !registered {
+		return "", ErrUnknownFunction
+	}
+	if !enabled {
+		return "", ErrPluginDisabled
+	}
+
+	for _, function := range plugin.Functions {
+		if function.Name == functionName {
+			return function.Handler(ctx, userID, args)
+		}
+	}
+	return "", ErrUnknownFunction
+}
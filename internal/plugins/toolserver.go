@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const maxToolServerResponse = 64 << 10
+
+type Server struct {
+	Name	string
+	URL	string
+}
+
+type toolServerFunction struct {
+	Name		string		`json:"name"`
+	Description	string		`json:"description"`
+	Parameters	json.RawMessage	`json:"parameters"`
+}
+
+type toolServerCall struct {
+	Function	string			`json:"function"`
+	Arguments	map[string]interface{}	`json:"arguments"`
+	UserID		int64			`json:"user_id"`
+}
+
+type toolServerResult struct {
+	Result	string	`json:"result"`
+	Error	string	`json:"error"`
+}
+
+type toolServerClient struct {
+	httpClient	*http.Client
+	token		string
+}
+
+func newToolServerClient(token string) *toolServerClient {
+	return &toolServerClient{
+		httpClient:	&http.Client{Timeout: 20 * time.Second},
+		token:		token,
+	}
+}
+
+func ParseServers(value string) ([]Server, error) {
+	var servers []Server
+	for _, item := range ParseList(value) {
+		name, rawURL, ok := strings.Cut(item, "=")
+		if !ok {
+			return servers, fmt.Errorf("ожидается имя=адрес, получено %q", item)
+		}
+		parsed, err := url.Parse(strings.TrimSpace(rawURL))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return servers, fmt.Errorf("некорректный адрес сервера плагина %q", rawURL)
+		}
+		servers = append(servers, Server{Name: strings.TrimSpace(name), URL: strings.TrimRight(parsed.String(), "/")})
+	}
+	return servers, nil
+}
+
+func (c *toolServerClient) load(ctx context.Context, server Server) (Plugin, error) {
+	var payload struct {
+		Description	string			`json:"description"`
+		Functions	[]toolServerFunction	`json:"functions"`
+	}
+	if err := c.do(ctx, http.MethodGet, server.URL+"/functions", nil, &payload); err != nil {
+		return Plugin{}, err
+	}
+
+	plugin := Plugin{Name: server.Name, Description: payload.Description}
+	for _, function := range payload.Functions {
+		name := function.Name
+		plugin.Functions = append(plugin.Functions, Function{
+			Name:		name,
+			Description:	function.Description,
+			Parameters:	function.Parameters,
+			Handler: func(ctx context.Context, userID int64, args map[string]interface{}) (string, error) {
+				var result toolServerResult
+				call := toolServerCall{Function: name, Arguments: args, UserID: userID}
+				if err := c.do(ctx, http.MethodPost, server.URL+"/call", call, &result); err != nil {
+					return "", err
+				}
+				if result.Error != "" {
+					return "", fmt.Errorf("сервер плагина %s: %s", server.Name, result.Error)
+				}
+				return result.Result, nil
+			},
+		})
+	}
+	return plugin, nil
+}
+
+func (c *toolServerClient) do(ctx context.Context, method, endpoint string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("ошибка при формировании запроса к серверу плагина: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса к серверу плагина: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при обращении к серверу плагина: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("сервер плагина вернул статус %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxToolServerResponse)).Decode(out); err != nil {
+		return fmt.Errorf("ошибка при чтении ответа сервера плагина: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	TypeCommunicationStyle	= "communication_style"
+	TypeMotivationType	= "motivation_type"
+	TypeReminderFrequency	= "reminder_frequency"
+	TypeDifficultyLevel	= "difficulty_level"
+	TypeProactivity		= "proactivity"
+	TypeVerbosity		= "verbosity"
+
+	ProactivityOff		= "off"
+	ProactivityLow		= "low"
+	ProactivityMedium	= "medium"
+	ProactivityHigh		= "high"
+
+	VerbosityConcise	= "concise"
+	VerbosityNormal		= "normal"
+	VerbosityDetailed	= "detailed"
+
+	KindNudge	= "nudge"
+	KindInsight	= "insight"
+	KindMotivation	= "motivation"
+	KindSuggestion	= "suggestion"
+
+	FeedbackPositive	= "positive"
+	FeedbackNegative	= "negative"
+	FeedbackSuggestion	= "suggestion"
+	FeedbackComplaint	= "complaint"
+)
+
+var (
+	ErrUnknownPreference	= errors.New("неизвестный тип предпочтения")
+	ErrInvalidValue		= errors.New("недопустимое значение предпочтения")
+	ErrInvalidFeedback	= errors.New("неизвестный тип обратной связи")
+)
+
+var Types = []string{TypeCommunicationStyle, TypeMotivationType, TypeReminderFrequency, TypeDifficultyLevel, TypeProactivity, TypeVerbosity}
+
+var FeedbackTypes = []string{FeedbackPositive, FeedbackNegative, FeedbackSuggestion, FeedbackComplaint}
+
+var AllowedValues = map[string][]string{
+	TypeCommunicationStyle:	{"friendly", "professional", "concise", "energetic", "calm"},
+	TypeMotivationType:	{"achievement", "progress", "challenge", "social", "reward", "growth", "visualization", "storytelling"},
+	TypeReminderFrequency:	{"rare", "normal", "frequent"},
+	TypeDifficultyLevel:	{"easy", "medium", "hard"},
+	TypeProactivity:	{ProactivityOff, ProactivityLow, ProactivityMedium, ProactivityHigh},
+	TypeVerbosity:		{VerbosityConcise, VerbosityNormal, VerbosityDetailed},
+}
+
+var proactivityLevels = map[string]int{
+	ProactivityOff:		0,
+	ProactivityLow:		1,
+	ProactivityMedium:	2,
+	ProactivityHigh:	3,
+}
+
+var kindMinLevels = map[string]int{
+	KindNudge:	1,
+	KindInsight:	2,
+	KindMotivation:	3,
+	KindSuggestion:	3,
+}
+
+var valueAliases = map[string]string{
+	"дружелюбный":		"friendly",
+	"дружеский":		"friendly",
+	"деловой":		"professional",
+	"официальный":		"professional",
+	"профессиональный":	"professional",
+	"кратко":		"concise",
+	"коротко":		"concise",
+	"краткий":		"concise",
+	"короче":		"concise",
+	"обычный":		"normal",
+	"подробный":		"detailed",
+	"подробно":		"detailed",
+	"подробнее":		"detailed",
+	"энергичный":		"energetic",
+	"спокойный":		"calm",
+	"достижения":		"achievement",
+	"прогресс":		"progress",
+	"вызов":		"challenge",
+	"соревнование":		"challenge",
+	"социальная":		"social",
+	"награды":		"reward",
+	"награда":		"reward",
+	"рост":			"growth",
+	"развитие":		"growth",
+	"визуализация":		"visualization",
+	"истории":		"storytelling",
+	"редко":		"rare",
+	"реже":			"rare",
+	"обычно":		"normal",
+	"нормально":		"normal",
+	"часто":		"frequent",
+	"чаще":			"frequent",
+	"легко":		"easy",
+	"легкий":		"easy",
+	"проще":		"easy",
+	"средний":		"medium",
+	"сложно":		"hard",
+	"сложный":		"hard",
+	"сложнее":		"hard",
+	"выкл":			"off",
+	"выключить":		"off",
+	"молчать":		"off",
+	"минимальный":		"low",
+	"минимум":		"low",
+	"низкий":		"low",
+	"высокий":		"high",
+	"максимум":		"high",
+}
+
+var labels = map[string]string{
+	TypeCommunicationStyle:	"Стиль общения",
+	TypeMotivationType:	"Тип мотивации",
+	TypeReminderFrequency:	"Частота напоминаний",
+	TypeDifficultyLevel:	"Уровень сложности",
+	TypeProactivity:	"Проактивность",
+	TypeVerbosity:		"Краткость ответов",
+}
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type Preferences struct {
+	UserID			int64		`db:"user_id" json:"user_id"`
+	CommunicationStyle	*string		`db:"communication_style" json:"communication_style,omitempty"`
+	MotivationType		*string		`db:"motivation_type" json:"motivation_type,omitempty"`
+	ReminderFrequency	*string		`db:"reminder_frequency" json:"reminder_frequency,omitempty"`
+	DifficultyLevel		*string		`db:"difficulty_level" json:"difficulty_level,omitempty"`
+	Proactivity		*string		`db:"proactivity" json:"proactivity,omitempty"`
+	Verbosity		*string		`db:"verbosity" json:"verbosity,omitempty"`
+	UpdatedAt		time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+type FeatureFeedback struct {
+	Feature		string	`db:"feature" json:"feature"`
+	Positive	int	`db:"positive" json:"positive"`
+	Negative	int	`db:"negative" json:"negative"`
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func NormalizeValue(preferenceType, value string) (string, error) {
+	allowed, ok := AllowedValues[preferenceType]
+	if !ok {
+		return "", ErrUnknownPreference
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	if alias, ok := valueAliases[value]; ok {
+		value = alias
+	}
+	for _, v := range allowed {
+		if v == value {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: допустимо %s", ErrInvalidValue, strings.Join(allowed, ", "))
+}
+
+func (s *Service) Get(ctx context.Context, userID int64) (*Preferences, error) {
+	prefs := Preferences{UserID: userID}
+	err := s.db.GetContext(ctx, &prefs, `
+		SELECT user_id, communication_style, motivation_type, reminder_frequency, difficulty_level, proactivity, verbosity, updated_at
+		FROM user_preferences
+		WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении предпочтений: %v", err)
+	}
+	return &prefs, nil
+}
+
+func (s *Service) Set(ctx context.Context, userID int64, preferenceType, value string) (*Preferences, string, error) {
+	normalized, err := NormalizeValue(preferenceType, value)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO user_preferences (user_id, %[1]s)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET %[1]s = EXCLUDED.%[1]s, updated_at = NOW()
+		RETURNING user_id, communication_style, motivation_type, reminder_frequency, difficulty_level, proactivity, verbosity, updated_at
+	`, preferenceType)
+
+	var prefs Preferences
+	if err := s.db.GetContext(ctx, &prefs, query, userID, normalized); err != nil {
+		return nil, "", fmt.Errorf("ошибка при сохранении предпочтения: %v", err)
+	}
+	return &prefs, normalized, nil
+}
+
+func (s *Service) AllowUnsolicited(ctx context.Context, userID int64, kind string) (bool, error) {
+	prefs, err := s.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return AllowsKind(prefs.Proactivity, kind), nil
+}
+
+func AllowsKind(proactivity *string, kind string) bool {
+	level := proactivityLevels[ProactivityHigh]
+	if proactivity != nil {
+		if l, ok := proactivityLevels[*proactivity]; ok {
+			level = l
+		}
+	}
+	minLevel, ok := kindMinLevels[kind]
+	if !ok {
+		minLevel = proactivityLevels[ProactivityHigh]
+	}
+	return level >= minLevel
+}
+
+func ProactivityLabel(level string) string {
+	switch level {
+	case ProactivityOff:
+		return "выключена"
+	case ProactivityLow:
+		return "минимальная"
+	case ProactivityMedium:
+		return "средняя"
+	default:
+		return "высокая"
+	}
+}
+
+func VerbosityLabel(level string) string {
+	switch level {
+	case VerbosityConcise:
+		return "краткие"
+	case VerbosityDetailed:
+		return "подробные"
+	default:
+		return "обычные"
+	}
+}
+
+func (s *Service) RecordFeedback(ctx context.Context, userID int64, feedbackType, feedbackContext, feature string) error {
+	valid := false
+	for _, t := range FeedbackTypes {
+		if t == feedbackType {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidFeedback
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_feedback (user_id, feedback_type, context, specific_feature)
+		VALUES ($1, $2, $3, $4)
+	`, userID, feedbackType, strings.TrimSpace(feedbackContext), strings.ToLower(strings.TrimSpace(feature)))
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении обратной связи: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) GetFeatureFeedback(ctx context.Context, userID int64, days int) ([]FeatureFeedback, error) {
+	feedback := make([]FeatureFeedback, 0)
+	err := s.db.SelectContext(ctx, &feedback, `
+		SELECT specific_feature AS feature,
+			COUNT(*) FILTER (WHERE feedback_type = 'positive') AS positive,
+			COUNT(*) FILTER (WHERE feedback_type IN ('negative', 'complaint')) AS negative
+		FROM user_feedback
+		WHERE user_id = $1 AND specific_feature <> '' AND created_at >= NOW() - make_interval(days => $2)
+		GROUP BY specific_feature
+	`, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении обратной связи: %v", err)
+	}
+	return feedback, nil
+}
+
+func Label(preferenceType string) string {
+	if label, ok := labels[preferenceType]; ok {
+		return label
+	}
+	return preferenceType
+}
+
+func Format(prefs *Preferences) string {
+	values := map[string]*string{
+		TypeCommunicationStyle:	prefs.CommunicationStyle,
+		TypeMotivationType:	prefs.MotivationType,
+		TypeReminderFrequency:	prefs.ReminderFrequency,
+		TypeDifficultyLevel:	prefs.DifficultyLevel,
+		TypeProactivity:	prefs.Proactivity,
+		TypeVerbosity:		prefs.Verbosity,
+	}
+
+	var b strings.Builder
+	b.WriteString("⚙️ Ваши предпочтения:\n")
+	for _, t := range Types {
+		value := "не задано"
+		if values[t] != nil {
+			value = *values[t]
+		}
+		b.WriteString(fmt.Sprintf("• %s: %s\n", Label(t), value))
+	}
+	return b.String()
+}
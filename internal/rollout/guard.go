@@ -0,0 +1,64 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	guardWindow		= 24 * time.Hour
+	guardMinCalls		= 30
+	guardMaxSuccessDrop	= 0.1
+)
+
+func (s *Service) StartRolloutGuard(jm *jobs.Manager, notify func(text string)) {
+	jm.Register(jobs.Job{
+		Name:		"prompt_rollout_guard",
+		Spec:		"*/15 * * * *",
+		Run: func(ctx context.Context) {
+			s.checkActiveRelease(ctx, notify)
+		},
+	})
+
+	logrus.Info("Запущен контроль выкатки промпта и схем функций")
+}
+
+func (s *Service) checkActiveRelease(ctx context.Context, notify func(text string)) {
+	release, err := s.GetActiveRelease(ctx)
+	if err != nil {
+		logrus.Errorf("Ошибка при проверке выкатки: %v", err)
+		return
+	}
+	if release == nil {
+		return
+	}
+
+	comparison, err := s.Compare(ctx, release, time.Now().Add(-guardWindow))
+	if err != nil {
+		logrus.Errorf("Ошибка при сравнении версий выкатки %s: %v", release.Version, err)
+		return
+	}
+
+	if comparison.Candidate.Calls < guardMinCalls || comparison.Stable.Calls < guardMinCalls {
+		return
+	}
+
+	drop := comparison.Stable.SuccessRate() - comparison.Candidate.SuccessRate()
+	if drop < guardMaxSuccessDrop {
+		return
+	}
+
+	reason := fmt.Sprintf("успешность вызовов функций ниже стабильной версии на %.1f п.п.", drop*100)
+	if _, err := s.Rollback(ctx, reason); err != nil {
+		logrus.Errorf("Ошибка автоматического отката выкатки %s: %v", release.Version, err)
+		return
+	}
+
+	if notify != nil {
+		notify(fmt.Sprintf("⏪ Выкатка %s автоматически откачена: %s\n\n%s", release.Version, reason, FormatComparison(comparison)))
+	}
+}
@@ -0,0 +1,339 @@
+package rollout
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StableVersion	= "stable"
+
+	StatusActive		= "active"
+	StatusRolledBack	= "rolled_back"
+	StatusCompleted		= "completed"
+
+	OutcomeSuccess		= "success"
+	OutcomeParseError	= "parse_error"
+	OutcomeFunctionError	= "function_error"
+	OutcomeNoCall		= "no_call"
+
+	cacheTTL	= 30 * time.Second
+)
+
+var (
+	ErrReleaseActive	= errors.New("уже есть активная выкатка, сначала откатите или завершите ее")
+	ErrNoActiveRelease	= errors.New("нет активной выкатки")
+	ErrInvalidVersion	= errors.New("версия не может быть пустой или равной stable")
+	ErrInvalidPercent	= errors.New("процент выкатки должен быть от 0 до 100")
+	ErrInvalidOverrides	= errors.New("переопределения функций должны быть JSON-объектом {\"имя_функции\": {...}}")
+)
+
+type Service struct {
+	db		*sqlx.DB
+	mu		sync.Mutex
+	cached		*Release
+	cachedAt	time.Time
+}
+
+type Release struct {
+	ID			int64			`db:"id" json:"id"`
+	Version			string			`db:"version" json:"version"`
+	Description		string			`db:"description" json:"description"`
+	PromptAddendum		string			`db:"prompt_addendum" json:"prompt_addendum"`
+	FunctionOverrides	json.RawMessage		`db:"function_overrides" json:"function_overrides"`
+	RolloutPercent		int			`db:"rollout_percent" json:"rollout_percent"`
+	Status			string			`db:"status" json:"status"`
+	RollbackReason		*string			`db:"rollback_reason" json:"rollback_reason,omitempty"`
+	CreatedAt		time.Time		`db:"created_at" json:"created_at"`
+	UpdatedAt		time.Time		`db:"updated_at" json:"updated_at"`
+}
+
+type VersionStats struct {
+	Version		string	`db:"version" json:"version"`
+	Messages	int	`db:"messages" json:"messages"`
+	Calls		int	`db:"calls" json:"calls"`
+	Successes	int	`db:"successes" json:"successes"`
+	ParseErrors	int	`db:"parse_errors" json:"parse_errors"`
+	FuncErrors	int	`db:"function_errors" json:"function_errors"`
+}
+
+type Comparison struct {
+	Release		*Release	`json:"release"`
+	Stable		VersionStats	`json:"stable"`
+	Candidate	VersionStats	`json:"candidate"`
+	Since		time.Time	`json:"since"`
+}
+
+const releaseSelect = `
+	SELECT id, version, description, prompt_addendum, function_overrides, rollout_percent,
+		status, rollback_reason, created_at, updated_at
+	FROM prompt_releases
+`
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func (v VersionStats) SuccessRate() float64 {
+	if v.Calls == 0 {
+		return 0
+	}
+	return float64(v.Successes) / float64(v.Calls)
+}
+
+func (r *Release) Overrides() (map[string]json.RawMessage, error) {
+	overrides := make(map[string]json.RawMessage)
+	if len(r.FunctionOverrides) == 0 {
+		return overrides, nil
+	}
+	if err := json.Unmarshal(r.FunctionOverrides, &overrides); err != nil {
+		return nil, ErrInvalidOverrides
+	}
+	return overrides, nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cached = nil
+	s.cachedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *Service) CreateRelease(ctx context.Context, version, description, promptAddendum string, overrides json.RawMessage, percent int) (*Release, error) {
+	version = strings.TrimSpace(version)
+	if version == "" || version == StableVersion {
+		return nil, ErrInvalidVersion
+	}
+	if percent < 0 || percent > 100 {
+		return nil, ErrInvalidPercent
+	}
+	if len(overrides) == 0 {
+		overrides = json.RawMessage("{}")
+	}
+	probe := Release{FunctionOverrides: overrides}
+	if _, err := probe.Overrides(); err != nil {
+		return nil, err
+	}
+
+	active, err := s.GetActiveRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrReleaseActive
+	}
+
+	var release Release
+	err = s.db.GetContext(ctx, &release, `
+		INSERT INTO prompt_releases (version, description, prompt_addendum, function_overrides, rollout_percent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, version, description, prompt_addendum, function_overrides, rollout_percent,
+			status, rollback_reason, created_at, updated_at
+	`, version, description, promptAddendum, []byte(overrides), percent)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании выкатки: %v", err)
+	}
+
+	s.invalidate()
+	logrus.Infof("Создана выкатка промпта %s на %d%% пользователей", release.Version, release.RolloutPercent)
+	return &release, nil
+}
+
+func (s *Service) GetActiveRelease(ctx context.Context) (*Release, error) {
+	var release Release
+	err := s.db.GetContext(ctx, &release, releaseSelect+` WHERE status = $1 LIMIT 1`, StatusActive)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении активной выкатки: %v", err)
+	}
+	return &release, nil
+}
+
+func (s *Service) ListReleases(ctx context.Context, limit int) ([]Release, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	releases := make([]Release, 0)
+	err := s.db.SelectContext(ctx, &releases, releaseSelect+` ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении выкаток: %v", err)
+	}
+	return releases, nil
+}
+
+func (s *Service) cachedActive(ctx context.Context) *Release {
+	s.mu.Lock()
+	if !s.cachedAt.IsZero() && time.Since(s.cachedAt) < cacheTTL {
+		release := s.cached
+		s.mu.Unlock()
+		return release
+	}
+	s.mu.Unlock()
+
+	release, err := s.GetActiveRelease(ctx)
+	if err != nil {
+		logrus.Warnf("Не удалось получить активную выкатку, используется стабильная версия: %v", err)
+		return nil
+	}
+
+	s.mu.Lock()
+	s.cached = release
+	s.cachedAt = time.Now()
+	s.mu.Unlock()
+	return release
+}
+
+func bucket(version string, userID int64) int {
+	h := fnv.New32a()
+	h.Write([]byte(fmt.Sprintf("%s:%d", version, userID)))
+	return int(h.Sum32() % 100)
+}
+
+func (s *Service) Assign(ctx context.Context, userID int64) *Release {
+	release := s.cachedActive(ctx)
+	if release == nil || bucket(release.Version, userID) >= release.RolloutPercent {
+		return nil
+	}
+	return release
+}
+
+func (s *Service) RecordOutcome(ctx context.Context, version string, userID int64, functionName, outcome string) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO prompt_release_outcomes (version, user_id, function_name, outcome)
+		VALUES ($1, $2, $3, $4)
+	`, version, userID, functionName, outcome)
+	if err != nil {
+		logrus.Warnf("Не удалось записать результат версии %s: %v", version, err)
+	}
+}
+
+func (s *Service) SetPercent(ctx context.Context, percent int) (*Release, error) {
+	if percent < 0 || percent > 100 {
+		return nil, ErrInvalidPercent
+	}
+
+	var release Release
+	err := s.db.GetContext(ctx, &release, `
+		UPDATE prompt_releases SET rollout_percent = $2, updated_at = NOW()
+		WHERE status = $1
+		RETURNING id, version, description, prompt_addendum, function_overrides, rollout_percent,
+			status, rollback_reason, created_at, updated_at
+	`, StatusActive, percent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveRelease
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при изменении процента выкатки: %v", err)
+	}
+
+	s.invalidate()
+	logrus.Infof("Выкатка %s расширена до %d%% пользователей", release.Version, release.RolloutPercent)
+	return &release, nil
+}
+
+func (s *Service) finish(ctx context.Context, status, reason string) (*Release, error) {
+	var release Release
+	err := s.db.GetContext(ctx, &release, `
+		UPDATE prompt_releases SET status = $2, rollback_reason = NULLIF($3, ''), updated_at = NOW()
+		WHERE status = $1
+		RETURNING id, version, description, prompt_addendum, function_overrides, rollout_percent,
+			status, rollback_reason, created_at, updated_at
+	`, StatusActive, status, reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoActiveRelease
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при завершении выкатки: %v", err)
+	}
+
+	s.invalidate()
+	return &release, nil
+}
+
+func (s *Service) Rollback(ctx context.Context, reason string) (*Release, error) {
+	release, err := s.finish(ctx, StatusRolledBack, reason)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Warnf("Выкатка %s откачена: %s", release.Version, reason)
+	return release, nil
+}
+
+func (s *Service) Complete(ctx context.Context) (*Release, error) {
+	release, err := s.finish(ctx, StatusCompleted, "")
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Выкатка %s завершена", release.Version)
+	return release, nil
+}
+
+func (s *Service) Compare(ctx context.Context, release *Release, since time.Time) (*Comparison, error) {
+	if since.Before(release.CreatedAt) {
+		since = release.CreatedAt
+	}
+
+	var stats []VersionStats
+	err := s.db.SelectContext(ctx, &stats, `
+		SELECT version,
+			COUNT(*) AS messages,
+			COUNT(*) FILTER (WHERE outcome <> $3) AS calls,
+			COUNT(*) FILTER (WHERE outcome = $4) AS successes,
+			COUNT(*) FILTER (WHERE outcome = $5) AS parse_errors,
+			COUNT(*) FILTER (WHERE outcome = $6) AS function_errors
+		FROM prompt_release_outcomes
+		WHERE version IN ($1, $2) AND created_at >= $7
+		GROUP BY version
+	`, StableVersion, release.Version, OutcomeNoCall, OutcomeSuccess, OutcomeParseError, OutcomeFunctionError, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сравнении версий: %v", err)
+	}
+
+	comparison := &Comparison{
+		Release:	release,
+		Stable:		VersionStats{Version: StableVersion},
+		Candidate:	VersionStats{Version: release.Version},
+		Since:		since,
+	}
+	for _, st := range stats {
+		if st.Version == StableVersion {
+			comparison.Stable = st
+		} else {
+			comparison.Candidate = st
+		}
+	}
+	return comparison, nil
+}
+
+func formatStats(label string, st VersionStats) string {
+	return fmt.Sprintf("%s: %d сообщ., %d вызовов функций, успешно %.1f%% (ошибок парсинга %d, ошибок выполнения %d)",
+		label, st.Messages, st.Calls, st.SuccessRate()*100, st.ParseErrors, st.FuncErrors)
+}
+
+func FormatComparison(c *Comparison) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🚦 Выкатка %s: %d%% пользователей, статус %s\n", c.Release.Version, c.Release.RolloutPercent, c.Release.Status))
+	if c.Release.Description != "" {
+		b.WriteString(c.Release.Description + "\n")
+	}
+	b.WriteString(fmt.Sprintf("\nС %s:\n", c.Since.Format("02.01 15:04")))
+	b.WriteString("• " + formatStats(StableVersion, c.Stable) + "\n")
+	b.WriteString("• " + formatStats(c.Release.Version, c.Candidate) + "\n")
+	if c.Stable.Calls > 0 && c.Candidate.Calls > 0 {
+		delta := (c.Candidate.SuccessRate() - c.Stable.SuccessRate()) * 100
+		b.WriteString(fmt.Sprintf("\nРазница успешности: %+.1f п.п.", delta))
+	}
+	return b.String()
+}
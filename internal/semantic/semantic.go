@@ -0,0 +1,210 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/jobs"
+	"telegrambot/internal/monitoring"
+	"telegrambot/pkg/config"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sashabaranov/go-openai"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	embeddingModel		= openai.SmallEmbedding3
+	indexBatchSize		= 100
+	maxEmbeddingRunes	= 4000
+	defaultSearchLimit	= 5
+	maxSearchLimit		= 20
+	minSimilarity		= 0.25
+)
+
+var ErrEmptyQuery = errors.New("пустой поисковый запрос")
+
+type Service struct {
+	db	*sqlx.DB
+	client	*openai.Client
+}
+
+type SearchOptions struct {
+	From	*time.Time
+	To	*time.Time
+	Limit	int
+}
+
+type Match struct {
+	MessageID	int64		`db:"id" json:"message_id"`
+	Text		string		`db:"message_text" json:"text"`
+	Response	string		`db:"response_text" json:"response,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	Similarity	float64		`db:"similarity" json:"similarity"`
+}
+
+type pendingMessage struct {
+	ID		int64	`db:"id"`
+	UserIdentifier	string	`db:"user_identifier"`
+	Text		string	`db:"message_text"`
+}
+
+func NewService(db *sqlx.DB, cfg *config.Config) *Service {
+	return &Service{
+		db:	db,
+		client:	openai.NewClient(cfg.OpenAIKey),
+	}
+}
+
+func (s *Service) StartIndexing(jm *jobs.Manager) {
+	jm.Register(jobs.Job{
+		Name:	"message_embeddings",
+		Spec:	"*/5 * * * *",
+		Run: func(ctx context.Context) {
+			if _, err := s.IndexPending(ctx); err != nil {
+				logrus.Errorf("Ошибка при индексации сообщений: %v", err)
+			}
+		},
+	})
+
+	logrus.Info("Запущена индексация сообщений для семантического поиска")
+}
+
+func (s *Service) IndexPending(ctx context.Context) (int, error) {
+	var pending []pendingMessage
+	err := s.db.SelectContext(ctx, &pending, `
+		SELECT um.id, um.user_identifier, um.message_text
+		FROM user_messages um
+		LEFT JOIN message_embeddings me ON me.user_message_id = um.id
+		WHERE me.user_message_id IS NULL
+		  AND LENGTH(TRIM(um.message_text)) >= 3
+		  AND um.message_text NOT LIKE '[%'
+		  AND um.message_text NOT LIKE 'enc:%'
+		ORDER BY um.id DESC
+		LIMIT $1
+	`, indexBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении сообщений для индексации: %v", err)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	inputs := make([]string, len(pending))
+	for i, m := range pending {
+		inputs[i] = truncate(m.Text, maxEmbeddingRunes)
+	}
+
+	vectors, err := s.embed(ctx, inputs)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, m := range pending {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO message_embeddings (user_message_id, user_identifier, model, embedding)
+			VALUES ($1, $2, $3, $4::vector)
+			ON CONFLICT (user_message_id) DO NOTHING
+		`, m.ID, m.UserIdentifier, string(embeddingModel), formatVector(vectors[i]))
+		if err != nil {
+			return i, fmt.Errorf("ошибка при сохранении эмбеддинга сообщения %d: %v", m.ID, err)
+		}
+	}
+
+	logrus.Infof("Проиндексировано сообщений для семантического поиска: %d", len(pending))
+	return len(pending), nil
+}
+
+func (s *Service) Search(ctx context.Context, userIDs []int64, query string, opts SearchOptions) ([]Match, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultSearchLimit
+	}
+	if opts.Limit > maxSearchLimit {
+		opts.Limit = maxSearchLimit
+	}
+
+	vectors, err := s.embed(ctx, []string{truncate(query, maxEmbeddingRunes)})
+	if err != nil {
+		return nil, err
+	}
+
+	identifiers := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		identifiers[i] = strconv.FormatInt(id, 10)
+	}
+
+	matches := make([]Match, 0)
+	err = s.db.SelectContext(ctx, &matches, `
+		SELECT um.id, um.message_text, um.created_at,
+			COALESCE(ar.response_text, '') AS response_text,
+			1 - (me.embedding <=> $2::vector) AS similarity
+		FROM message_embeddings me
+		JOIN user_messages um ON um.id = me.user_message_id
+		LEFT JOIN LATERAL (
+			SELECT response_text FROM ai_responses WHERE user_message_id = um.id ORDER BY id LIMIT 1
+		) ar ON TRUE
+		WHERE me.user_identifier = ANY($1)
+		  AND ($3::timestamptz IS NULL OR um.created_at >= $3)
+		  AND ($4::timestamptz IS NULL OR um.created_at < $4)
+		  AND 1 - (me.embedding <=> $2::vector) >= $5
+		ORDER BY me.embedding <=> $2::vector
+		LIMIT $6
+	`, pq.Array(identifiers), formatVector(vectors[0]), opts.From, opts.To, minSimilarity, opts.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при семантическом поиске по истории: %v", err)
+	}
+
+	return matches, nil
+}
+
+func (s *Service) embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	resp, err := s.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input:	inputs,
+		Model:	embeddingModel,
+	})
+	monitoring.Observe(monitoring.EventOpenAI, err)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении эмбеддингов: %v", err)
+	}
+	if len(resp.Data) != len(inputs) {
+		return nil, fmt.Errorf("получено %d эмбеддингов вместо %d", len(resp.Data), len(inputs))
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, item := range resp.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("некорректный индекс эмбеддинга %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+func formatVector(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const postMessageURL = "https://slack.com/api/chat.postMessage"
+
+type Target struct {
+	WebhookURL	string
+	BotToken	string
+	Channel		string
+}
+
+func (t Target) Configured() bool {
+	return t.WebhookURL != "" || (t.BotToken != "" && t.Channel != "")
+}
+
+type Client struct {
+	httpClient *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) Send(ctx context.Context, target Target, text string) error {
+	text = toSlackMarkdown(text)
+
+	if target.WebhookURL != "" {
+		return c.sendWebhook(ctx, target.WebhookURL, text)
+	}
+	if target.BotToken != "" && target.Channel != "" {
+		return c.postMessage(ctx, target.BotToken, target.Channel, text)
+	}
+
+	return fmt.Errorf("slack не настроен: нужен webhook или токен бота с каналом")
+}
+
+func (c *Client) sendWebhook(ctx context.Context, webhookURL, text string) error {
+	if !strings.HasPrefix(webhookURL, "https://hooks.slack.com/") {
+		return fmt.Errorf("некорректный адрес входящего webhook Slack")
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании сообщения для Slack: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса к Slack: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Slack: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack вернул статус %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c *Client) postMessage(ctx context.Context, token, channel, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"channel":	channel,
+		"text":		text,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при формировании сообщения для Slack: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, postMessageURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса к Slack: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке сообщения в Slack: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK	bool	`json:"ok"`
+		Error	string	`json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("ошибка при разборе ответа Slack: %v", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack отклонил сообщение: %s", result.Error)
+	}
+
+	return nil
+}
+
+func toSlackMarkdown(text string) string {
+	return strings.ReplaceAll(text, "**", "*")
+}
@@ -0,0 +1,179 @@
+package snapshot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const minSaltLength = 16
+
+var (
+	latinVowels		= []rune("aeiouy")
+	latinConsonants		= []rune("bcdfghjklmnprstvz")
+	cyrillicVowels		= []rune("аеиоуыэюя")
+	cyrillicConsonants	= []rune("бвгджзклмнпрстфхцчш")
+	pseudoNames		= []string{
+		"Алексей", "Мария", "Иван", "Анна", "Дмитрий", "Елена", "Сергей", "Ольга",
+		"Андрей", "Наталья", "Михаил", "Татьяна", "Никита", "Ксения", "Павел", "Юлия",
+		"Артем", "Дарья", "Роман", "Вера", "Егор", "Полина", "Кирилл", "Алиса",
+	}
+)
+
+type Anonymizer struct {
+	key []byte
+}
+
+func NewAnonymizer(salt string) (*Anonymizer, error) {
+	if len(salt) < minSaltLength {
+		return nil, fmt.Errorf("соль анонимизации должна быть не короче %d символов (SNAPSHOT_SALT)", minSaltLength)
+	}
+	return &Anonymizer{key: []byte(salt)}, nil
+}
+
+func (a *Anonymizer) digest(kind, value string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+func (a *Anonymizer) hexDigest(kind, value string, length int) string {
+	return hex.EncodeToString(a.digest(kind, value))[:length]
+}
+
+func (a *Anonymizer) Text(text string) string {
+	var out strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case unicode.IsLetter(runes[i]):
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			out.WriteString(a.word(string(runes[i:j])))
+		case unicode.IsDigit(runes[i]):
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			out.WriteString(a.number(string(runes[i:j])))
+		default:
+			out.WriteRune(runes[i])
+		}
+		i = j
+	}
+	return out.String()
+}
+
+func (a *Anonymizer) word(word string) string {
+	runes := []rune(word)
+	if len(runes) <= 2 {
+		return word
+	}
+
+	seed := a.digest("word", strings.ToLower(word))
+	pseudo := make([]rune, len(runes))
+	for i, r := range runes {
+		vowels, consonants := latinVowels, latinConsonants
+		if unicode.Is(unicode.Cyrillic, r) {
+			vowels, consonants = cyrillicVowels, cyrillicConsonants
+		}
+		alphabet := consonants
+		if strings.ContainsRune(string(vowels), unicode.ToLower(r)) {
+			alphabet = vowels
+		}
+		picked := alphabet[int(seed[i%len(seed)]^byte(i/len(seed)))%len(alphabet)]
+		if unicode.IsUpper(r) {
+			picked = unicode.ToUpper(picked)
+		}
+		pseudo[i] = picked
+	}
+	return string(pseudo)
+}
+
+func (a *Anonymizer) number(digits string) string {
+	if len(digits) <= 4 {
+		return digits
+	}
+	seed := a.digest("number", digits)
+	pseudo := make([]byte, len(digits))
+	for i := range pseudo {
+		pseudo[i] = '0' + seed[i%len(seed)]%10
+	}
+	return string(pseudo)
+}
+
+func (a *Anonymizer) Name(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return name
+	}
+	seed := a.digest("name", strings.ToLower(strings.TrimSpace(name)))
+	return pseudoNames[binary.BigEndian.Uint32(seed)%uint32(len(pseudoNames))]
+}
+
+func (a *Anonymizer) Handle(handle string) string {
+	if handle == "" {
+		return handle
+	}
+	return "user_" + a.hexDigest("handle", strings.ToLower(handle), 10)
+}
+
+func (a *Anonymizer) Email(email string) string {
+	if email == "" {
+		return email
+	}
+	return "user_" + a.hexDigest("email", strings.ToLower(email), 12) + "@example.invalid"
+}
+
+func (a *Anonymizer) Phone(phone string) string {
+	if phone == "" {
+		return phone
+	}
+	seed := a.digest("phone", phone)
+	return fmt.Sprintf("+7000%07d", binary.BigEndian.Uint32(seed)%10000000)
+}
+
+func (a *Anonymizer) Secret(secret string) string {
+	if secret == "" {
+		return secret
+	}
+	return "anon_" + a.hexDigest("secret", secret, 64)
+}
+
+func (a *Anonymizer) URL(rawURL string) string {
+	if rawURL == "" {
+		return rawURL
+	}
+	return "https://calendar.example.invalid/" + a.hexDigest("url", rawURL, 16)
+}
+
+func (a *Anonymizer) JSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("некорректный JSON: %v", err)
+	}
+	return json.Marshal(a.jsonValue(value))
+}
+
+func (a *Anonymizer) jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return a.Text(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = a.jsonValue(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = a.jsonValue(v[key])
+		}
+	}
+	return value
+}
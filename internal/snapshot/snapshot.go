@@ -0,0 +1,469 @@
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type Rule string
+
+const (
+	RuleKeep	Rule	= "keep"
+	RuleText	Rule	= "text"
+	RuleName	Rule	= "name"
+	RuleHandle	Rule	= "handle"
+	RuleEmail	Rule	= "email"
+	RulePhone	Rule	= "phone"
+	RuleSecret	Rule	= "secret"
+	RuleURL		Rule	= "url"
+)
+
+var ErrSameDatabase = errors.New("целевая база совпадает с исходной, снимок можно загружать только в отдельную базу")
+
+type Table struct {
+	Name	string
+	Filter	string
+	OrderBy	string
+	Catalog	bool
+	Rules	map[string]Rule
+}
+
+type Options struct {
+	UserIDs	[]int64
+	Since	time.Time
+}
+
+type TableStats struct {
+	Table	string
+	Rows	int
+}
+
+var Tables = []Table{
+	{Name: "objective_categories", Catalog: true},
+	{Name: "objective_templates", Catalog: true},
+	{
+		Name:	"users",
+		Filter: `{users} IS NULL OR id = ANY({users}) OR id IN (
+			SELECT kr.owner_id FROM key_results kr JOIN objectives o ON o.id = kr.objective_id WHERE o.user_id = ANY({users}))`,
+		Rules: map[string]Rule{
+			"username": RuleHandle, "first_name": RuleName, "role": RuleKeep, "personality_type": RuleKeep,
+			"motivation_style": RuleKeep, "communication_style": RuleKeep, "activity_level": RuleKeep,
+			"timezone": RuleKeep, "jarvis_settings": RuleKeep,
+		},
+	},
+	{
+		Name:	"web_users",
+		Filter:	`{users} IS NULL OR telegram_ids && {users}`,
+		Rules:	map[string]Rule{"login": RuleHandle, "email": RuleEmail, "phone": RulePhone, "password_hash": RuleSecret},
+	},
+	{
+		Name:		"objectives",
+		Filter:		`{users} IS NULL OR user_id = ANY({users})`,
+		OrderBy:	"created_at",
+		Rules:		map[string]Rule{"period": RuleKeep, "status": RuleKeep},
+	},
+	{
+		Name:	"key_results",
+		Filter:	`{users} IS NULL OR objective_id IN (SELECT id FROM objectives WHERE user_id = ANY({users}))`,
+		Rules:	map[string]Rule{"unit": RuleKeep, "status": RuleKeep},
+	},
+	{
+		Name: "tasks",
+		Filter: `{users} IS NULL OR key_result_id IN (
+			SELECT kr.id FROM key_results kr JOIN objectives o ON o.id = kr.objective_id WHERE o.user_id = ANY({users}))`,
+		Rules:	map[string]Rule{"unit": RuleKeep, "status": RuleKeep, "recurrence_pattern": RuleKeep},
+	},
+	{
+		Name: "key_result_progress_log",
+		Filter: `{users} IS NULL OR key_result_id IN (
+			SELECT kr.id FROM key_results kr JOIN objectives o ON o.id = kr.objective_id WHERE o.user_id = ANY({users}))`,
+	},
+	{
+		Name:	"events",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"google_event_id": RuleSecret, "external_uid": RuleSecret, "external_source": RuleKeep},
+	},
+	{
+		Name:	"event_attendees",
+		Filter:	`{users} IS NULL OR event_id IN (SELECT id FROM events WHERE user_id = ANY({users}))`,
+		Rules:	map[string]Rule{"email": RuleEmail, "name": RuleName, "status": RuleKeep},
+	},
+	{
+		Name:	"user_messages",
+		Filter:	`({users} IS NULL OR user_identifier = ANY({users}::TEXT[])) AND ({since} IS NULL OR created_at >= {since})`,
+		Rules:	map[string]Rule{"user_identifier": RuleKeep, "platform": RuleKeep},
+	},
+	{
+		Name: "ai_responses",
+		Filter: `user_message_id IN (
+			SELECT id FROM user_messages
+			WHERE ({users} IS NULL OR user_identifier = ANY({users}::TEXT[])) AND ({since} IS NULL OR created_at >= {since}))`,
+		Rules:	map[string]Rule{"function_name": RuleKeep},
+	},
+	{
+		Name:	"conversation_summaries",
+		Filter:	`{users} IS NULL OR user_identifier = ANY({users}::TEXT[])`,
+		Rules:	map[string]Rule{"user_identifier": RuleKeep},
+	},
+	{
+		Name:	"user_preferences",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules: map[string]Rule{
+			"communication_style": RuleKeep, "motivation_type": RuleKeep, "reminder_frequency": RuleKeep,
+			"difficulty_level": RuleKeep, "proactivity": RuleKeep, "verbosity": RuleKeep,
+		},
+	},
+	{
+		Name:	"journal_entries",
+		Filter:	`({users} IS NULL OR user_id = ANY({users})) AND ({since} IS NULL OR created_at >= {since})`,
+		Rules:	map[string]Rule{"source": RuleKeep},
+	},
+	{
+		Name:	"journal_summaries",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+	},
+	{
+		Name:	"inbox_items",
+		Filter:	`({users} IS NULL OR user_id = ANY({users})) AND ({since} IS NULL OR created_at >= {since})`,
+		Rules:	map[string]Rule{"source": RuleKeep, "status": RuleKeep},
+	},
+	{
+		Name:	"time_entries",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+	},
+	{
+		Name:	"time_trackers",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+	},
+	{
+		Name:	"productivity_hours",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"scores": RuleKeep},
+	},
+	{
+		Name:	"weekly_plans",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"status": RuleKeep},
+	},
+	{
+		Name:	"weekly_plan_blocks",
+		Filter:	`{users} IS NULL OR plan_id IN (SELECT id FROM weekly_plans WHERE user_id = ANY({users}))`,
+	},
+	{
+		Name: "key_result_target_revisions",
+		Filter: `{users} IS NULL OR key_result_id IN (
+			SELECT kr.id FROM key_results kr JOIN objectives o ON o.id = kr.objective_id WHERE o.user_id = ANY({users}))`,
+	},
+	{
+		Name:	"objective_closings",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"outcome": RuleKeep},
+	},
+	{
+		Name:	"accountability_partners",
+		Filter:	`{users} IS NULL OR user_id = ANY({users}) OR partner_id = ANY({users})`,
+		Rules:	map[string]Rule{"status": RuleKeep},
+	},
+	{
+		Name: "partner_shared_objectives",
+		Filter: `{users} IS NULL OR partnership_id IN (
+			SELECT id FROM accountability_partners WHERE user_id = ANY({users}) OR partner_id = ANY({users}))`,
+	},
+	{
+		Name:	"user_spheres",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"icon": RuleKeep},
+	},
+	{
+		Name:	"objective_share_links",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"token": RuleSecret, "sharing_type": RuleKeep, "audience": RuleKeep},
+	},
+	{
+		Name:	"notification_log",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"kind": RuleKeep},
+	},
+	{
+		Name:	"motivation_trigger_log",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"trigger_type": RuleKeep},
+	},
+	{
+		Name:	"notification_settings",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"muted_kinds": RuleKeep},
+	},
+	{
+		Name:	"transcription_jobs",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"status": RuleKeep, "mime_type": RuleKeep, "file_id": RuleSecret},
+	},
+	{
+		Name:	"pending_disambiguations",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"function": RuleKeep, "id_argument": RuleKeep},
+	},
+	{
+		Name:	"deadline_reminder_settings",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+	},
+	{
+		Name:	"deadline_reminder_log",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"entity_type": RuleKeep, "stage": RuleKeep},
+	},
+	{
+		Name:	"support_tickets",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"status": RuleKeep, "source": RuleKeep},
+	},
+	{
+		Name:	"support_messages",
+		Filter:	`{users} IS NULL OR ticket_id IN (SELECT id FROM support_tickets WHERE user_id = ANY({users}))`,
+		Rules:	map[string]Rule{"direction": RuleKeep},
+	},
+	{
+		Name:	"google_tokens",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"access_token": RuleSecret, "refresh_token": RuleSecret, "token_type": RuleKeep, "invalid_reason": RuleKeep},
+	},
+	{
+		Name:	"caldav_accounts",
+		Filter:	`{users} IS NULL OR user_id = ANY({users})`,
+		Rules:	map[string]Rule{"url": RuleURL, "username": RuleHandle, "password": RuleSecret},
+	},
+}
+
+func Copy(ctx context.Context, source, target *sqlx.DB, anonymizer *Anonymizer, opts Options) ([]TableStats, error) {
+	same, err := sameDatabase(ctx, source, target)
+	if err != nil {
+		return nil, err
+	}
+	if same {
+		return nil, ErrSameDatabase
+	}
+
+	tx, err := target.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции в целевой базе: %v", err)
+	}
+	defer tx.Rollback()
+
+	names := make([]string, 0, len(Tables))
+	for _, table := range Tables {
+		names = append(names, table.Name)
+	}
+	if _, err := tx.ExecContext(ctx, "TRUNCATE "+strings.Join(names, ", ")+" CASCADE"); err != nil {
+		return nil, fmt.Errorf("ошибка при очистке целевой базы: %v", err)
+	}
+
+	stats := make([]TableStats, 0, len(Tables))
+	for _, table := range Tables {
+		copied, err := copyTable(ctx, source, tx, anonymizer, table, opts)
+		if err != nil {
+			return nil, fmt.Errorf("таблица %s: %v", table.Name, err)
+		}
+		if err := resetSequence(ctx, tx, table.Name); err != nil {
+			return nil, fmt.Errorf("таблица %s: %v", table.Name, err)
+		}
+		stats = append(stats, TableStats{Table: table.Name, Rows: copied})
+		logrus.Infof("Снимок: таблица %s, строк %d", table.Name, copied)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении снимка: %v", err)
+	}
+	return stats, nil
+}
+
+func sameDatabase(ctx context.Context, source, target *sqlx.DB) (bool, error) {
+	const identity = `SELECT current_database() || '@' || COALESCE(inet_server_addr()::TEXT, 'local') || ':' || current_setting('port')`
+
+	var sourceID, targetID string
+	if err := source.GetContext(ctx, &sourceID, identity); err != nil {
+		return false, fmt.Errorf("ошибка при проверке исходной базы: %v", err)
+	}
+	if err := target.GetContext(ctx, &targetID, identity); err != nil {
+		return false, fmt.Errorf("ошибка при проверке целевой базы: %v", err)
+	}
+	return sourceID == targetID, nil
+}
+
+func (t Table) query(opts Options) (string, []interface{}) {
+	var args []interface{}
+	filter := t.Filter
+	if strings.Contains(filter, "{users}") {
+		var users interface{}
+		if len(opts.UserIDs) > 0 {
+			users = pq.Array(opts.UserIDs)
+		}
+		args = append(args, users)
+		filter = strings.ReplaceAll(filter, "{users}", fmt.Sprintf("$%d::BIGINT[]", len(args)))
+	}
+	if strings.Contains(filter, "{since}") {
+		var since interface{}
+		if !opts.Since.IsZero() {
+			since = opts.Since
+		}
+		args = append(args, since)
+		filter = strings.ReplaceAll(filter, "{since}", fmt.Sprintf("$%d::TIMESTAMPTZ", len(args)))
+	}
+
+	query := "SELECT * FROM " + t.Name
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+	if t.OrderBy != "" {
+		query += " ORDER BY " + t.OrderBy
+	}
+	return query, args
+}
+
+func copyTable(ctx context.Context, source *sqlx.DB, tx *sqlx.Tx, anonymizer *Anonymizer, table Table, opts Options) (int, error) {
+	query, args := table.query(opts)
+	rows, err := source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при чтении исходных данных: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении колонок: %v", err)
+	}
+
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = pq.QuoteIdentifier(column.Name())
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	insert, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table.Name, strings.Join(names, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подготовке вставки: %v", err)
+	}
+	defer insert.Close()
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	copied := 0
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return copied, fmt.Errorf("ошибка при чтении строки: %v", err)
+		}
+		for i, column := range columns {
+			values[i], err = anonymizeValue(anonymizer, table, column, values[i])
+			if err != nil {
+				return copied, fmt.Errorf("колонка %s: %v", column.Name(), err)
+			}
+		}
+		if _, err := insert.ExecContext(ctx, values...); err != nil {
+			return copied, fmt.Errorf("ошибка при вставке строки: %v", err)
+		}
+		copied++
+	}
+	return copied, rows.Err()
+}
+
+func anonymizeValue(anonymizer *Anonymizer, table Table, column *sql.ColumnType, value interface{}) (interface{}, error) {
+	if value == nil || table.Catalog {
+		return value, nil
+	}
+
+	kind := column.DatabaseTypeName()
+	rule, ok := table.Rules[column.Name()]
+	if !ok {
+		rule = defaultRule(column.Name(), kind)
+	}
+	if rule == RuleKeep {
+		return value, nil
+	}
+
+	switch kind {
+	case "JSON", "JSONB":
+		return anonymizer.JSON(asBytes(value))
+	case "_TEXT", "_VARCHAR", "_BPCHAR":
+		var items pq.StringArray
+		if err := items.Scan(asBytes(value)); err != nil {
+			return nil, fmt.Errorf("некорректный массив: %v", err)
+		}
+		for i := range items {
+			items[i] = anonymizer.Text(items[i])
+		}
+		return items, nil
+	}
+
+	text := string(asBytes(value))
+	switch rule {
+	case RuleName:
+		return anonymizer.Name(text), nil
+	case RuleHandle:
+		return anonymizer.Handle(text), nil
+	case RuleEmail:
+		return anonymizer.Email(text), nil
+	case RulePhone:
+		return anonymizer.Phone(text), nil
+	case RuleSecret:
+		return anonymizer.Secret(text), nil
+	case RuleURL:
+		return anonymizer.URL(text), nil
+	}
+	return anonymizer.Text(text), nil
+}
+
+func defaultRule(name, kind string) Rule {
+	switch kind {
+	case "TEXT", "VARCHAR", "BPCHAR", "JSON", "JSONB", "_TEXT", "_VARCHAR", "_BPCHAR":
+	default:
+		return RuleKeep
+	}
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		return RuleKeep
+	}
+	return RuleText
+}
+
+func asBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return []byte(fmt.Sprint(value))
+}
+
+func resetSequence(ctx context.Context, tx *sqlx.Tx, table string) error {
+	var sequence sql.NullString
+	err := tx.GetContext(ctx, &sequence, `
+		SELECT pg_get_serial_sequence($1, column_name)
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'id'
+	`, table)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !sequence.Valid) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при поиске последовательности: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`SELECT setval($1, COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)`, table), sequence.String)
+	if err != nil {
+		return fmt.Errorf("ошибка при сдвиге последовательности: %v", err)
+	}
+	return nil
+}
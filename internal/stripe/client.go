@@ -0,0 +1,217 @@
+package stripe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"telegrambot/pkg/config"
+	"time"
+)
+
+const (
+	apiURL			= "https://api.stripe.com/v1"
+	signatureTolerance	= 5 * time.Minute
+)
+
+var ErrInvalidSignature = errors.New("неверная подпись webhook Stripe")
+
+type Client struct {
+	secretKey	string
+	webhookSecret	string
+	priceID		string
+	successURL	string
+	cancelURL	string
+	httpClient	*http.Client
+}
+
+type CheckoutSession struct {
+	ID			string	`json:"id"`
+	URL			string	`json:"url"`
+	ClientReferenceID	string	`json:"client_reference_id"`
+	Customer		string	`json:"customer"`
+	Subscription		string	`json:"subscription"`
+}
+
+type Invoice struct {
+	ID			string	`json:"id"`
+	Customer		string	`json:"customer"`
+	Subscription		string	`json:"subscription"`
+	SubscriptionDetails	struct {
+		Metadata map[string]string `json:"metadata"`
+	} `json:"subscription_details"`
+	Lines			struct {
+		Data []struct {
+			Period struct {
+				End int64 `json:"end"`
+			} `json:"period"`
+		} `json:"data"`
+	} `json:"lines"`
+}
+
+type Subscription struct {
+	ID			string	`json:"id"`
+	Customer		string	`json:"customer"`
+	Status			string	`json:"status"`
+	CurrentPeriodEnd	int64	`json:"current_period_end"`
+}
+
+type Event struct {
+	ID	string	`json:"id"`
+	Type	string	`json:"type"`
+	Data	struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+func NewClient(cfg *config.Config) *Client {
+	return &Client{
+		secretKey:	cfg.StripeSecretKey,
+		webhookSecret:	cfg.StripeWebhookSecret,
+		priceID:	cfg.StripePriceID,
+		successURL:	cfg.BillingSuccessURL,
+		cancelURL:	cfg.BillingCancelURL,
+		httpClient:	&http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *Client) Configured() bool {
+	return c.secretKey != "" && c.priceID != ""
+}
+
+func (i *Invoice) PeriodEnd() time.Time {
+	var end int64
+	for _, line := range i.Lines.Data {
+		if line.Period.End > end {
+			end = line.Period.End
+		}
+	}
+	return time.Unix(end, 0)
+}
+
+func (i *Invoice) WebUserID() (int64, bool) {
+	id, err := strconv.ParseInt(i.SubscriptionDetails.Metadata["web_user_id"], 10, 64)
+	return id, err == nil
+}
+
+func (c *Client) CreateCheckoutSession(ctx context.Context, webUserID int64, customerID, email string) (*CheckoutSession, error) {
+	if !c.Configured() {
+		return nil, fmt.Errorf("оплата через Stripe не настроена")
+	}
+
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("line_items[0][price]", c.priceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("client_reference_id", strconv.FormatInt(webUserID, 10))
+	form.Set("success_url", c.successURL)
+	form.Set("cancel_url", c.cancelURL)
+	form.Set("metadata[web_user_id]", strconv.FormatInt(webUserID, 10))
+	form.Set("subscription_data[metadata][web_user_id]", strconv.FormatInt(webUserID, 10))
+	if customerID != "" {
+		form.Set("customer", customerID)
+	} else if email != "" {
+		form.Set("customer_email", email)
+	}
+
+	var session CheckoutSession
+	if err := c.post(ctx, "/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса к Stripe: %v", err)
+	}
+	req.SetBasicAuth(c.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при запросе к Stripe: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("ошибка при чтении ответа Stripe: %v", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(body, &apiErr)
+		return fmt.Errorf("Stripe вернул ошибку %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("ошибка при разборе ответа Stripe: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) ConstructEvent(payload []byte, signatureHeader string) (*Event, error) {
+	if c.webhookSecret == "" {
+		return nil, fmt.Errorf("не задан секрет webhook Stripe")
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signatureHeader, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return nil, fmt.Errorf("%w: устаревшая метка времени", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, sig := range signatures {
+		decoded, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(decoded, expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе события Stripe: %v", err)
+	}
+	return &event, nil
+}
@@ -0,0 +1,161 @@
+package support
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/pkg/config"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	StatusOpen	= "open"
+	StatusClosed	= "closed"
+
+	SourceCommand	= "command"
+	SourceAssistant	= "assistant"
+
+	DirectionUser		= "user"
+	DirectionOperator	= "operator"
+)
+
+var (
+	ErrTicketNotFound	= errors.New("обращение не найдено")
+	ErrTicketClosed		= errors.New("обращение уже закрыто")
+)
+
+type Service struct {
+	db		*sqlx.DB
+	operatorChatID	int64
+}
+
+type Ticket struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Status		string		`db:"status" json:"status"`
+	Source		string		`db:"source" json:"source"`
+	Reason		*string		`db:"reason" json:"reason,omitempty"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	ClosedAt	*time.Time	`db:"closed_at" json:"closed_at,omitempty"`
+	ClosedBy	*int64		`db:"closed_by" json:"closed_by,omitempty"`
+}
+
+const ticketSelect = `SELECT id, user_id, status, source, reason, created_at, closed_at, closed_by FROM support_tickets`
+
+func NewService(db *sqlx.DB, cfg *config.Config) *Service {
+	chatID := cfg.SupportChatID
+	if chatID == "" {
+		chatID = cfg.AdminAlertChatID
+	}
+	operatorChatID, _ := strconv.ParseInt(chatID, 10, 64)
+	return &Service{db: db, operatorChatID: operatorChatID}
+}
+
+func (s *Service) OperatorChatID() int64 {
+	return s.operatorChatID
+}
+
+func (s *Service) Available() bool {
+	return s.operatorChatID != 0
+}
+
+func (s *Service) Open(ctx context.Context, userID int64, source, reason string) (*Ticket, bool, error) {
+	var ticket Ticket
+	err := s.db.GetContext(ctx, &ticket, `
+		INSERT INTO support_tickets (user_id, source, reason)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (user_id) WHERE status = 'open' DO NOTHING
+		RETURNING id, user_id, status, source, reason, created_at, closed_at, closed_by
+	`, userID, source, strings.TrimSpace(reason))
+	if errors.Is(err, sql.ErrNoRows) {
+		existing, err := s.GetOpenTicket(ctx, userID)
+		return existing, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка при создании обращения: %v", err)
+	}
+	return &ticket, true, nil
+}
+
+func (s *Service) GetOpenTicket(ctx context.Context, userID int64) (*Ticket, error) {
+	var ticket Ticket
+	err := s.db.GetContext(ctx, &ticket, ticketSelect+` WHERE user_id = $1 AND status = $2`, userID, StatusOpen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении обращения: %v", err)
+	}
+	return &ticket, nil
+}
+
+func (s *Service) GetTicket(ctx context.Context, ticketID int64) (*Ticket, error) {
+	var ticket Ticket
+	err := s.db.GetContext(ctx, &ticket, ticketSelect+` WHERE id = $1`, ticketID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении обращения: %v", err)
+	}
+	return &ticket, nil
+}
+
+func (s *Service) FindByOperatorMessage(ctx context.Context, operatorMessageID int) (*Ticket, error) {
+	var ticket Ticket
+	err := s.db.GetContext(ctx, &ticket, `
+		SELECT t.id, t.user_id, t.status, t.source, t.reason, t.created_at, t.closed_at, t.closed_by
+		FROM support_messages m
+		JOIN support_tickets t ON t.id = m.ticket_id
+		WHERE m.operator_message_id = $1
+		ORDER BY m.id DESC
+		LIMIT 1
+	`, operatorMessageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске обращения: %v", err)
+	}
+	return &ticket, nil
+}
+
+func (s *Service) RecordMessage(ctx context.Context, ticketID int64, direction, text string, operatorMessageID int) error {
+	var messageID *int
+	if operatorMessageID != 0 {
+		messageID = &operatorMessageID
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO support_messages (ticket_id, direction, text, operator_message_id)
+		VALUES ($1, $2, $3, $4)
+	`, ticketID, direction, text, messageID)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении сообщения обращения: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) Close(ctx context.Context, ticketID, operatorID int64) (*Ticket, error) {
+	var ticket Ticket
+	err := s.db.GetContext(ctx, &ticket, `
+		UPDATE support_tickets
+		SET status = $2, closed_at = NOW(), closed_by = $3
+		WHERE id = $1 AND status = $4
+		RETURNING id, user_id, status, source, reason, created_at, closed_at, closed_by
+	`, ticketID, StatusClosed, operatorID, StatusOpen)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, getErr := s.GetTicket(ctx, ticketID); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrTicketClosed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при закрытии обращения: %v", err)
+	}
+	return &ticket, nil
+}
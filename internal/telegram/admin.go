@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"strconv"
+	"telegrambot/internal/admin"
+	"telegrambot/internal/rollout"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleAdminCommand(ctx context.Context, message *tgbotapi.Message) bool {
+	switch message.Command() {
+	case "grant":
+		h.handleAdminGrant(ctx, message, true)
+	case "revoke":
+		h.handleAdminGrant(ctx, message, false)
+	case "stats":
+		h.handleAdminStats(ctx, message)
+	case "rollout":
+		h.handleAdminRollout(ctx, message)
+	case "rollback":
+		h.handleAdminRollback(ctx, message)
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *Handler) handleAdminGrant(ctx context.Context, message *tgbotapi.Message, grant bool) {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		if grant {
+			h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /grant <@username|id> [premium]")
+		} else {
+			h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /revoke <@username|id>")
+		}
+		return
+	}
+
+	user, err := h.adminService.FindUser(ctx, args[0])
+	if errors.Is(err, admin.ErrUserNotFound) {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("❌ Пользователь %s не найден. Он должен хотя бы раз написать боту", args[0]))
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при поиске пользователя %s: %v", args[0], err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось найти пользователя")
+		return
+	}
+
+	role := admin.RoleFree
+	if grant {
+		role = admin.RolePremium
+		if len(args) > 1 {
+			role = strings.ToLower(args[1])
+		}
+	}
+
+	if err := h.adminService.SetRole(ctx, user.ID, role); err != nil {
+		logrus.Errorf("Ошибка при изменении роли пользователя %d: %v", user.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("❌ Не удалось изменить роль: %v", err))
+		return
+	}
+
+	logrus.Infof("Администратор %d изменил роль пользователя %d на %s", message.From.ID, user.ID, role)
+
+	if grant {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("✅ %s получил роль %s", user.DisplayName(), role))
+		h.sendMessageCtx(ctx, user.ID, "🎉 Вам открыт доступ к боту! Напишите, чем могу помочь")
+	} else {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("✅ Подписка %s отозвана", user.DisplayName()))
+	}
+}
+
+func (h *Handler) handleAdminStats(ctx context.Context, message *tgbotapi.Message) {
+	stats, err := h.adminService.GetStats(ctx)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении статистики: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось получить статистику")
+		return
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, admin.FormatStats(stats))
+}
+
+func (h *Handler) handleAdminRollout(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.Fields(message.CommandArguments())
+
+	if len(args) > 0 {
+		var (
+			release	*rollout.Release
+			err	error
+		)
+		if args[0] == "complete" {
+			release, err = h.rolloutService.Complete(ctx)
+		} else {
+			percent, convErr := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+			if convErr != nil {
+				h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /rollout [процент|complete]")
+				return
+			}
+			release, err = h.rolloutService.SetPercent(ctx, percent)
+		}
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+rolloutErrorMessage(err))
+			return
+		}
+		logrus.Infof("Администратор %d изменил выкатку %s: %s", message.From.ID, release.Version, args[0])
+		if release.Status == rollout.StatusCompleted {
+			h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("✅ Выкатка %s завершена", release.Version))
+			return
+		}
+	}
+
+	release, err := h.rolloutService.GetActiveRelease(ctx)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении выкатки: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось получить выкатку")
+		return
+	}
+	if release == nil {
+		h.sendMessageCtx(ctx, message.Chat.ID, "🚦 Активной выкатки нет, всем пользователям отдается стабильная версия")
+		return
+	}
+
+	comparison, err := h.rolloutService.Compare(ctx, release, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		logrus.Errorf("Ошибка при сравнении версий: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось сравнить версии")
+		return
+	}
+	h.sendMessageCtx(ctx, message.Chat.ID, rollout.FormatComparison(comparison))
+}
+
+func (h *Handler) handleAdminRollback(ctx context.Context, message *tgbotapi.Message) {
+	reason := strings.TrimSpace(message.CommandArguments())
+	if reason == "" {
+		reason = fmt.Sprintf("откат администратором %d", message.From.ID)
+	}
+
+	release, err := h.rolloutService.Rollback(ctx, reason)
+	if err != nil {
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+rolloutErrorMessage(err))
+		return
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("⏪ Выкатка %s откачена, все пользователи на стабильной версии", release.Version))
+}
+
+func rolloutErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, rollout.ErrNoActiveRelease), errors.Is(err, rollout.ErrInvalidPercent):
+		return err.Error()
+	default:
+		logrus.Errorf("Ошибка управления выкаткой: %v", err)
+		return "Не удалось изменить выкатку"
+	}
+}
@@ -0,0 +1,227 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	archiveSectionGoals	= "goals"
+	archiveSectionEvents	= "events"
+	archiveSectionFinance	= "finance"
+
+	archivePageSize	= 5
+)
+
+var archiveSectionAliases = map[string]string{
+	"goals":	archiveSectionGoals,
+	"цели":		archiveSectionGoals,
+	"events":	archiveSectionEvents,
+	"события":	archiveSectionEvents,
+	"finance":	archiveSectionFinance,
+	"финансы":	archiveSectionFinance,
+	"транзакции":	archiveSectionFinance,
+}
+
+func (h *Handler) handleArchiveCommand(ctx context.Context, message *tgbotapi.Message) {
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	section, ok := archiveSectionAliases[arg]
+	if !ok {
+		text := "🗄 Архив: выполненные цели, прошедшие события и старые транзакции.\n\nВыберите раздел или используйте /archive goals | events | finance"
+		if err := h.sendFormatted(ctx, message.Chat.ID, text, ParseModeHTML, archiveMenuKeyboard()); err != nil {
+			logrus.Errorf("Ошибка при отправке меню архива: %v", err)
+		}
+		return
+	}
+
+	text, keyboard, err := h.renderArchive(ctx, message.From.ID, section, 0)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении архива %s пользователя %d: %v", section, message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить архив")
+		return
+	}
+
+	if err := h.sendFormatted(ctx, message.Chat.ID, text, ParseModeHTML, keyboard); err != nil {
+		logrus.Errorf("Ошибка при отправке архива: %v", err)
+	}
+}
+
+func (h *Handler) handleArchiveCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	section, pageStr, _ := strings.Cut(payload, ":")
+	page, _ := strconv.Atoi(pageStr)
+	if page < 0 {
+		page = 0
+	}
+
+	text, keyboard, err := h.renderArchive(ctx, query.From.ID, section, page)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении архива %s пользователя %d: %v", section, query.From.ID, err)
+		h.answerCallback(query.ID, "Не удалось загрузить архив")
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.editArchiveMessage(query, text, keyboard)
+}
+
+func (h *Handler) handleArchiveRestoreCallback(ctx context.Context, query *tgbotapi.CallbackQuery, objectiveID string) {
+	userID := query.From.ID
+
+	err := h.okrService.RestoreObjective(ctx, userID, objectiveID)
+	if err != nil {
+		text := "Не удалось восстановить цель"
+		switch {
+		case ownership.IsNotOwned(err):
+			text = "Цель не найдена"
+		case errors.Is(err, okr.ErrObjectiveNotArchived):
+			text = "Цель уже активна"
+		default:
+			logrus.Errorf("Ошибка при восстановлении цели %s пользователя %d: %v", objectiveID, userID, err)
+		}
+		h.answerCallback(query.ID, text)
+		return
+	}
+
+	h.answerCallback(query.ID, "♻️ Цель снова активна")
+
+	text, keyboard, err := h.renderArchive(ctx, userID, archiveSectionGoals, 0)
+	if err != nil {
+		logrus.Warnf("Не удалось обновить архив после восстановления цели: %v", err)
+		return
+	}
+	h.editArchiveMessage(query, text, keyboard)
+}
+
+func (h *Handler) editArchiveMessage(query *tgbotapi.CallbackQuery, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if query.Message == nil {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить сообщение архива: %v", err)
+	}
+}
+
+func (h *Handler) renderArchive(ctx context.Context, userID int64, section string, page int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	offset := page * archivePageSize
+	limit := archivePageSize + 1
+
+	var b strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var count int
+
+	switch section {
+	case archiveSectionGoals:
+		objectives, err := h.okrService.GetArchivedObjectives(ctx, userID, limit, offset)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		count = len(objectives)
+		b.WriteString("🎯 Архив целей\n\n")
+		for i, o := range objectives {
+			if i == archivePageSize {
+				break
+			}
+			finished := "—"
+			if o.CompletionDate != nil {
+				finished = o.CompletionDate.Format("02.01.2006")
+			}
+			b.WriteString(fmt.Sprintf("%d. %s\n   %s • %.0f%% • завершена %s\n", offset+i+1, o.Title, archiveStatusLabel(o.Status), o.Progress, finished))
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("♻️ Восстановить: "+truncateButtonText(o.Title, 28), "archive_restore:"+o.ID),
+			))
+		}
+
+	case archiveSectionEvents:
+		events, err := h.calendarService.GetPastEvents(ctx, []int64{userID}, limit, offset)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		count = len(events)
+		b.WriteString("📅 Прошедшие события\n\n")
+		for i, e := range events {
+			if i == archivePageSize {
+				break
+			}
+			b.WriteString(fmt.Sprintf("%d. %s — %s\n", offset+i+1, e.StartTime.Format("02.01.2006 15:04"), e.Title))
+		}
+
+	case archiveSectionFinance:
+		now := time.Now()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		transactions, err := h.financeService.GetArchivedTransactions(ctx, []int64{userID}, monthStart, limit, offset)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		count = len(transactions)
+		b.WriteString("💰 Транзакции прошлых месяцев\n\n")
+		for i, t := range transactions {
+			if i == archivePageSize {
+				break
+			}
+			b.WriteString(fmt.Sprintf("%d. %s %+.2f — %s (%s)\n", offset+i+1, t.CreatedAt.Format("02.01.2006"), t.Amount, t.Details, t.Category))
+		}
+
+	default:
+		return "🗄 Выберите раздел архива", archiveMenuKeyboard(), nil
+	}
+
+	if count == 0 {
+		if page == 0 {
+			b.WriteString("Здесь пока пусто")
+		} else {
+			b.WriteString("Больше записей нет")
+		}
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬅️", fmt.Sprintf("archive:%s:%d", section, page-1)))
+	}
+	if count > archivePageSize {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("➡️", fmt.Sprintf("archive:%s:%d", section, page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, archiveMenuKeyboard().InlineKeyboard...)
+
+	return strings.TrimRight(b.String(), "\n"), tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
+
+func archiveMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎯 Цели", "archive:"+archiveSectionGoals+":0"),
+			tgbotapi.NewInlineKeyboardButtonData("📅 События", "archive:"+archiveSectionEvents+":0"),
+			tgbotapi.NewInlineKeyboardButtonData("💰 Финансы", "archive:"+archiveSectionFinance+":0"),
+		),
+	)
+}
+
+func archiveStatusLabel(status string) string {
+	switch status {
+	case okr.ObjectiveStatusArchived:
+		return "🗄 в архиве"
+	default:
+		return "✅ выполнена"
+	}
+}
+
+func truncateButtonText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
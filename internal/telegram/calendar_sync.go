@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleCalendarSyncCommand(ctx context.Context, message *tgbotapi.Message) {
+	text, keyboard := h.calendarSyncStatus(ctx, message.From.ID)
+
+	var markup interface{}
+	if keyboard != nil {
+		markup = *keyboard
+	}
+	if err := h.sendFormatted(ctx, message.Chat.ID, text, ParseModeHTML, markup); err != nil {
+		logrus.Errorf("Ошибка при отправке состояния синхронизации: %v", err)
+	}
+}
+
+func (h *Handler) handleCalendarSyncCallback(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	h.answerCallback(query.ID, "🔄 Синхронизирую...")
+
+	err := h.calendarService.SyncGoogleCalendarForUser(ctx, query.From.ID)
+	if err != nil && !errors.Is(err, calendar.ErrSyncInProgress) {
+		logrus.Warnf("Ошибка ручной синхронизации Google Calendar для пользователя %d: %v", query.From.ID, err)
+	}
+
+	if query.Message == nil {
+		return
+	}
+	text, keyboard := h.calendarSyncStatus(ctx, query.From.ID)
+	if errors.Is(err, calendar.ErrSyncInProgress) {
+		text = "⏳ Синхронизация уже идет, обновите статус через минуту\n\n" + text
+	}
+
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, formatHTML(text))
+	edit.ParseMode = ParseModeHTML
+	edit.ReplyMarkup = keyboard
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить сообщение о синхронизации: %v", err)
+	}
+}
+
+func (h *Handler) calendarSyncStatus(ctx context.Context, userID int64) (string, *tgbotapi.InlineKeyboardMarkup) {
+	status, err := h.calendarService.GetSyncStatus(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении состояния синхронизации пользователя %d: %v", userID, err)
+		return "❌ Не удалось получить состояние синхронизации", nil
+	}
+	if !status.Linked {
+		return calendar.FormatSyncStatus(status), nil
+	}
+	if status.NeedsRelink {
+		authURL, err := h.calendarService.GetGoogleAuthURL(userID, "telegram")
+		if err != nil {
+			logrus.Warnf("Не удалось получить ссылку переподключения Google для пользователя %d: %v", userID, err)
+			return calendar.FormatSyncStatus(status), nil
+		}
+		keyboard := googleRelinkKeyboard(authURL)
+		return calendar.FormatSyncStatus(status), &keyboard
+	}
+	keyboard := calendarSyncKeyboard()
+	return calendar.FormatSyncStatus(status), &keyboard
+}
+
+func googleRelinkKeyboard(authURL string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("🔗 Переподключить Google Calendar", authURL),
+		),
+	)
+}
+
+func (h *Handler) SendGoogleRelink(chatID int64, text, authURL string) error {
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, googleRelinkKeyboard(authURL)); err != nil {
+		return fmt.Errorf("ошибка при отправке ссылки переподключения Google: %v", err)
+	}
+	return nil
+}
+
+func calendarSyncKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Синхронизировать сейчас", "calendar_sync:now"),
+		),
+	)
+}
+
+func (h *Handler) handleCalendarFeedCommand(ctx context.Context, message *tgbotapi.Message) {
+	rotate := strings.TrimSpace(message.CommandArguments()) == "new"
+
+	var token string
+	var err error
+	if rotate {
+		token, err = h.calendarService.RotateFeedToken(ctx, message.From.ID)
+	} else {
+		token, err = h.calendarService.FeedToken(ctx, message.From.ID)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при получении ICS-ссылки пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось получить ссылку на календарь")
+		return
+	}
+
+	text := "📆 Ссылка-подписка на ваши события для Apple Calendar, Outlook и других приложений:\n\n" +
+		h.calendarService.FeedURL(token) +
+		"\n\nДобавьте ее как календарь по подписке (в Apple: Файл → Новая подписка на календарь). " +
+		"Не передавайте ссылку другим; если она утекла — /calendar_feed new выдаст новую, а старая перестанет работать"
+	if rotate {
+		text = "🔁 Старая ссылка отключена.\n\n" + text
+	}
+	h.sendFormatted(ctx, message.Chat.ID, text, ParseModeNone, nil)
+}
+
+func (h *Handler) formatScheduleConflict(ctx context.Context, userID int64, conflictErr *calendar.ConflictError, startStr, endStr string) string {
+	first := conflictErr.Conflicts[0]
+	kind := "событие"
+	if first.Kind == calendar.BusyMeeting {
+		kind = "встреча"
+	}
+	response := fmt.Sprintf("У тебя в это время уже %s «%s» (%s–%s) — перенести или создать поверх?",
+		kind, first.Title, first.Start.Local().Format("02.01 15:04"), first.End.Local().Format("15:04"))
+	if len(conflictErr.Conflicts) > 1 {
+		response += "\nЕще пересечения: " + calendar.FormatConflicts(conflictErr.Conflicts[1:])
+	}
+
+	start, errStart := time.Parse(time.RFC3339, startStr)
+	end, errEnd := time.Parse(time.RFC3339, endStr)
+	if errStart != nil || errEnd != nil || !end.After(start) {
+		return response
+	}
+	duration := end.Sub(start)
+	slots, err := h.calendarService.SuggestFreeSlots(ctx, userID, start.Add(-12*time.Hour), start.AddDate(0, 0, 3), duration, 3)
+	if err != nil {
+		logrus.Warnf("Не удалось подобрать свободное время для пользователя %d: %v", userID, err)
+		return response
+	}
+	if len(slots) > 0 {
+		response += "\n\nСвободно:\n" + calendar.FormatFreeSlots(slots, duration)
+	}
+	return response
+}
@@ -0,0 +1,547 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/finance"
+	"telegrambot/internal/health"
+	"telegrambot/internal/inbox"
+	"telegrambot/internal/meetings"
+	"telegrambot/internal/messagestore"
+	"telegrambot/internal/notifications"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	action, payload, _ := strings.Cut(query.Data, ":")
+
+	switch action {
+	case "date_task":
+		h.handleDateTaskCallback(ctx, query, payload)
+	case "date_skip":
+		h.answerCallback(query.ID, "Хорошо, без задачи")
+		h.removeInlineKeyboard(query)
+	case "fspace_accept":
+		h.handleFinanceInviteCallback(ctx, query, payload, true)
+	case "fspace_decline":
+		h.handleFinanceInviteCallback(ctx, query, payload, false)
+	case "meeting_accept":
+		h.handleMeetingInviteCallback(ctx, query, payload, true)
+	case "meeting_decline":
+		h.handleMeetingInviteCallback(ctx, query, payload, false)
+	case "team_accept":
+		h.handleTeamInviteCallback(ctx, query, payload, true)
+	case "team_decline":
+		h.handleTeamInviteCallback(ctx, query, payload, false)
+	case "partner_accept":
+		h.handlePartnerInviteCallback(ctx, query, payload, true)
+	case "partner_decline":
+		h.handlePartnerInviteCallback(ctx, query, payload, false)
+	case "challenge_join":
+		h.handleChallengeInviteCallback(ctx, query, payload, true)
+	case "challenge_decline":
+		h.handleChallengeInviteCallback(ctx, query, payload, false)
+	case "med_taken":
+		h.handleMedicationDoseCallback(ctx, query, payload, true)
+	case "med_skip":
+		h.handleMedicationDoseCallback(ctx, query, payload, false)
+	case "rtask_done":
+		h.handleRecurringTaskCallback(ctx, query, payload, true)
+	case "rtask_skip":
+		h.handleRecurringTaskCallback(ctx, query, payload, false)
+	case "rate_up":
+		h.handleResponseRatingCallback(ctx, query, payload, 1)
+	case "rate_down":
+		h.handleResponseRatingCallback(ctx, query, payload, -1)
+	case "archive":
+		h.handleArchiveCallback(ctx, query, payload)
+	case "archive_restore":
+		h.handleArchiveRestoreCallback(ctx, query, payload)
+	case "okr_undo":
+		h.handleUndoDeletionCallback(ctx, query, payload)
+	case "okr_list":
+		h.handleObjectivesPageCallback(ctx, query, payload)
+	case "task_list":
+		h.handleTasksPageCallback(ctx, query, payload)
+	case "okr_obj":
+		h.handleObjectiveDetailsCallback(ctx, query, payload)
+	case "inbox_task":
+		h.handleInboxTaskCallback(ctx, query, payload)
+	case "inbox_kr":
+		h.handleInboxKeyResultCallback(ctx, query, payload)
+	case "inbox_event":
+		h.handleInboxEventCallback(ctx, query, payload)
+	case "inbox_note":
+		h.handleInboxStatusCallback(ctx, query, payload, inbox.StatusNote)
+	case "inbox_drop":
+		h.handleInboxStatusCallback(ctx, query, payload, inbox.StatusDismissed)
+	case "proactivity":
+		h.handleProactivityCallback(ctx, query, payload)
+	case "verbosity":
+		h.handleVerbosityCallback(ctx, query, payload)
+	case "notif":
+		h.handleNotificationKindCallback(ctx, query, payload)
+	case "pick":
+		h.handleDisambiguationCallback(ctx, query, payload)
+	case "receipt_ok":
+		h.handleReceiptConfirmCallback(ctx, query, payload)
+	case "receipt_cancel":
+		h.handleReceiptCancelCallback(ctx, query, payload)
+	case "receipt_cat":
+		h.handleReceiptCategoryCallback(ctx, query, payload)
+	case "receipt_setcat":
+		h.handleReceiptSetCategoryCallback(ctx, query, payload)
+	case "support_close":
+		h.handleSupportCloseCallback(ctx, query, payload)
+	case "calendar_sync":
+		h.handleCalendarSyncCallback(ctx, query)
+	case "wplan_apply":
+		h.handleWeeklyPlanApplyCallback(ctx, query, payload)
+	case "wplan_drop":
+		h.handleWeeklyPlanDiscardCallback(ctx, query, payload)
+	case "wplan_revert":
+		h.handleWeeklyPlanRevertCallback(ctx, query, payload)
+	default:
+		logrus.Warnf("Неизвестный callback от пользователя %d: %s", query.From.ID, query.Data)
+		h.answerCallback(query.ID, "")
+	}
+}
+
+func (h *Handler) SendDateReminder(chatID int64, text string, dateID int64) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Создать задачу", fmt.Sprintf("date_task:%d", dateID)),
+			tgbotapi.NewInlineKeyboardButtonData("Не нужно", fmt.Sprintf("date_skip:%d", dateID)),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке напоминания о дате: %v", err)
+	}
+	h.recordNotification(context.Background(), chatID, notifications.KindReminder, text)
+	return nil
+}
+
+func (h *Handler) handleDateTaskCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	userID := query.From.ID
+
+	dateID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	date, err := h.datesService.GetDateByID(ctx, userID, dateID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить важную дату %d для пользователя %d: %v", dateID, userID, err)
+		h.answerCallback(query.ID, "Дата не найдена")
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	title, description, start, end := date.PreparationTask(time.Now())
+
+	_, err = h.calendarService.CreateEvent(ctx, userID, title, description, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		logrus.Errorf("Ошибка при создании задачи для даты %d: %v", dateID, err)
+		h.answerCallback(query.ID, "Не удалось создать задачу")
+		return
+	}
+
+	h.answerCallback(query.ID, "Задача создана")
+
+	if query.Message != nil {
+		text := query.Message.Text + fmt.Sprintf("\n\n✅ Задача «%s» добавлена в календарь на %s", title, start.Format("02.01 15:04"))
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с напоминанием: %v", err)
+		}
+	}
+}
+
+func (h *Handler) SendFinanceInvite(chatID int64, text string, spaceID string) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Принять", "fspace_accept:"+spaceID),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", "fspace_decline:"+spaceID),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке приглашения: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleFinanceInviteCallback(ctx context.Context, query *tgbotapi.CallbackQuery, spaceID string, accept bool) {
+	userID := query.From.ID
+
+	space, err := h.financeService.RespondToInvite(ctx, userID, spaceID, accept)
+	if err != nil {
+		logrus.Warnf("Не удалось обработать приглашение в пространство %s для пользователя %d: %v", spaceID, userID, err)
+
+		text := "Приглашение больше не действительно"
+		if errors.Is(err, finance.ErrAlreadyInSpace) {
+			text = "Вы уже состоите в другом общем бюджете"
+		}
+		h.answerCallback(query.ID, text)
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	name := query.From.FirstName
+	if name == "" {
+		name = "@" + query.From.UserName
+	}
+
+	var result string
+	if accept {
+		h.answerCallback(query.ID, "Вы присоединились к общему бюджету")
+		result = fmt.Sprintf("✅ Вы присоединились к общему бюджету «%s»", space.Name)
+		h.SendMessage(space.OwnerID, fmt.Sprintf("👨‍👩‍👧 %s присоединился к общему бюджету «%s»", name, space.Name))
+	} else {
+		h.answerCallback(query.ID, "Приглашение отклонено")
+		result = "❌ Приглашение отклонено"
+		h.SendMessage(space.OwnerID, fmt.Sprintf("%s отклонил приглашение в общий бюджет «%s»", name, space.Name))
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с приглашением: %v", err)
+		}
+	}
+}
+
+func (h *Handler) SendTeamInvite(chatID int64, text string, invitationID int64) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Принять", fmt.Sprintf("team_accept:%d", invitationID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", fmt.Sprintf("team_decline:%d", invitationID)),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке приглашения в команду: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleTeamInviteCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string, accept bool) {
+	userID := query.From.ID
+
+	invitationID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	invitation, err := h.okrService.RespondToInvitation(ctx, userID, invitationID, accept)
+	if err != nil {
+		logrus.Warnf("Не удалось обработать приглашение в команду %d для пользователя %d: %v", invitationID, userID, err)
+		h.answerCallback(query.ID, "Приглашение больше не действительно")
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	name := query.From.FirstName
+	if name == "" {
+		name = "@" + query.From.UserName
+	}
+
+	var result string
+	if accept {
+		h.answerCallback(query.ID, "Вы присоединились к команде")
+		result = fmt.Sprintf("✅ Вы в команде «%s»", invitation.TeamName)
+		h.SendMessage(invitation.InvitedBy, fmt.Sprintf("👥 %s присоединился к команде «%s»", name, invitation.TeamName))
+	} else {
+		h.answerCallback(query.ID, "Приглашение отклонено")
+		result = "❌ Приглашение отклонено"
+		h.SendMessage(invitation.InvitedBy, fmt.Sprintf("%s отклонил приглашение в команду «%s»", name, invitation.TeamName))
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с приглашением: %v", err)
+		}
+	}
+}
+
+func (h *Handler) SendPartnerInvite(chatID int64, text string, partnershipID int64) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🤝 Принять", fmt.Sprintf("partner_accept:%d", partnershipID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", fmt.Sprintf("partner_decline:%d", partnershipID)),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке приглашения в партнеры: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handlePartnerInviteCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string, accept bool) {
+	userID := query.From.ID
+
+	partnershipID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	partnership, err := h.okrService.RespondToPartnerInvitation(ctx, userID, partnershipID, accept)
+	if err != nil {
+		logrus.Warnf("Не удалось обработать приглашение в партнеры %d для пользователя %d: %v", partnershipID, userID, err)
+		h.answerCallback(query.ID, "Приглашение больше не действительно")
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	name := query.From.FirstName
+	if name == "" {
+		name = "@" + query.From.UserName
+	}
+
+	var result string
+	if accept {
+		h.answerCallback(query.ID, "Теперь вы партнеры")
+		result = fmt.Sprintf("🤝 Вы партнеры с %s. Открыть цель: «покажи партнеру цель X»", partnership.OtherName)
+		h.SendMessage(partnership.OtherID, fmt.Sprintf("🤝 %s принял приглашение — теперь вы партнеры по ответственности. Открыть цель: «покажи партнеру цель X»", name))
+	} else {
+		h.answerCallback(query.ID, "Приглашение отклонено")
+		result = "❌ Приглашение отклонено"
+		h.SendMessage(partnership.OtherID, fmt.Sprintf("%s отклонил приглашение стать партнерами", name))
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с приглашением: %v", err)
+		}
+	}
+}
+
+func (h *Handler) SendChallengeInvite(chatID int64, text string, challengeID int64) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏆 Участвую", fmt.Sprintf("challenge_join:%d", challengeID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Не сейчас", fmt.Sprintf("challenge_decline:%d", challengeID)),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке приглашения в челлендж: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleChallengeInviteCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string, accept bool) {
+	userID := query.From.ID
+
+	challengeID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	challenge, err := h.challengesService.RespondToInvite(ctx, userID, challengeID, accept)
+	if err != nil {
+		logrus.Warnf("Не удалось обработать приглашение в челлендж %d для пользователя %d: %v", challengeID, userID, err)
+		h.answerCallback(query.ID, "Приглашение больше не действительно")
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	name := query.From.FirstName
+	if name == "" {
+		name = "@" + query.From.UserName
+	}
+
+	var result string
+	if accept {
+		h.answerCallback(query.ID, "Вы в челлендже!")
+		result = fmt.Sprintf("✅ Вы участвуете в челлендже «%s». Отмечайте прогресс сообщением, например «сделал 20 в челлендже»", challenge.Title)
+		h.SendMessage(challenge.CreatedBy, fmt.Sprintf("🏆 %s присоединился к челленджу «%s»", name, challenge.Title))
+	} else {
+		h.answerCallback(query.ID, "Приглашение отклонено")
+		result = "❌ Приглашение отклонено"
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с приглашением: %v", err)
+		}
+	}
+}
+
+func (h *Handler) SendMeetingInvite(chatID int64, text string, meetingID string) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Принять", "meeting_accept:"+meetingID),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", "meeting_decline:"+meetingID),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке приглашения на встречу: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleMeetingInviteCallback(ctx context.Context, query *tgbotapi.CallbackQuery, meetingID string, accept bool) {
+	userID := query.From.ID
+
+	meeting, err := h.meetingsService.RespondToMeeting(ctx, meetingID, userID, accept)
+	if err != nil {
+		logrus.Warnf("Не удалось обработать ответ на встречу %s от пользователя %d: %v", meetingID, userID, err)
+
+		text := "Приглашение больше не действительно"
+		if errors.Is(err, meetings.ErrAlreadyResponded) {
+			text = "Вы уже ответили на это приглашение"
+		}
+		h.answerCallback(query.ID, text)
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	var result string
+	if accept {
+		h.answerCallback(query.ID, "Встреча подтверждена")
+		result = fmt.Sprintf("✅ Вы приняли приглашение на встречу «%s»", meeting.Title)
+	} else {
+		h.answerCallback(query.ID, "Приглашение отклонено")
+		result = "❌ Приглашение отклонено"
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с приглашением на встречу: %v", err)
+		}
+	}
+}
+
+func (h *Handler) SendMedicationReminder(chatID int64, text string, doseID int64) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Принял", fmt.Sprintf("med_taken:%d", doseID)),
+			tgbotapi.NewInlineKeyboardButtonData("Пропустить", fmt.Sprintf("med_skip:%d", doseID)),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке напоминания о приеме: %v", err)
+	}
+	h.recordNotification(context.Background(), chatID, notifications.KindReminder, text)
+	return nil
+}
+
+func (h *Handler) handleMedicationDoseCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string, taken bool) {
+	userID := query.From.ID
+
+	doseID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	dose, err := h.healthService.MarkDose(ctx, userID, doseID, taken)
+	if err != nil {
+		logrus.Warnf("Не удалось отметить прием %d для пользователя %d: %v", doseID, userID, err)
+
+		text := "Прием не найден"
+		if errors.Is(err, health.ErrDoseAlreadyLogged) {
+			text = "Этот прием уже отмечен"
+		}
+		h.answerCallback(query.ID, text)
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	var result string
+	if taken {
+		h.answerCallback(query.ID, "Отмечено")
+		result = fmt.Sprintf("✅ Принято в %s", dose.TakenAt.Format("15:04"))
+	} else {
+		h.answerCallback(query.ID, "Прием пропущен")
+		result = "⏭ Прием пропущен"
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с напоминанием о приеме: %v", err)
+		}
+	}
+}
+
+func (h *Handler) sendRatedReply(ctx context.Context, chatID int64, text string, responseID int, extraRows ...[]tgbotapi.InlineKeyboardButton) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(append(extraRows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👍", fmt.Sprintf("rate_up:%d", responseID)),
+			tgbotapi.NewInlineKeyboardButtonData("👎", fmt.Sprintf("rate_down:%d", responseID)),
+		),
+	)...)
+
+	if err := h.sendFormatted(ctx, chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке ответа с оценкой: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleResponseRatingCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string, rating int) {
+	userID := query.From.ID
+
+	responseID, err := strconv.Atoi(payload)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	function, err := h.messageStoreService.RateResponse(ctx, responseID, userID, rating)
+	if err != nil {
+		logrus.Warnf("Не удалось сохранить оценку ответа %d от пользователя %d: %v", responseID, userID, err)
+		text := "Не удалось сохранить оценку"
+		if errors.Is(err, messagestore.ErrResponseNotFound) {
+			text = "Ответ не найден"
+		}
+		h.answerCallback(query.ID, text)
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	h.chatgptService.ApplyResponseRating(ctx, userID, function, rating)
+
+	if rating > 0 {
+		h.answerCallback(query.ID, "Спасибо! Рад, что помог 👍")
+	} else {
+		h.answerCallback(query.ID, "Спасибо, учту и постараюсь лучше")
+	}
+	h.removeInlineKeyboard(query)
+}
+
+func (h *Handler) answerCallback(callbackID, text string) {
+	if _, err := h.bot.Request(tgbotapi.NewCallback(callbackID, text)); err != nil {
+		logrus.Warnf("Ошибка при ответе на callback: %v", err)
+	}
+}
+
+func (h *Handler) removeInlineKeyboard(query *tgbotapi.CallbackQuery) {
+	if query.Message == nil {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось убрать кнопки из сообщения: %v", err)
+	}
+}
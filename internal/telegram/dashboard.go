@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"telegrambot/internal/dashboard"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const photoCaptionLimit = 1024
+
+func (h *Handler) handleDashboardCommand(ctx context.Context, message *tgbotapi.Message) {
+	var monthArg string
+	var options []string
+	for _, arg := range strings.Fields(message.CommandArguments()) {
+		if _, err := time.Parse("2006-01", arg); err == nil {
+			monthArg = arg
+			continue
+		}
+		options = append(options, arg)
+	}
+
+	month, err := dashboard.ParseMonth(monthArg, time.Now())
+	if err != nil {
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+		return
+	}
+	redaction := dashboard.ParseRedaction(options)
+
+	card, err := h.dashboardService.BuildMonthlyCard(ctx, message.From.ID, month)
+	if err != nil {
+		logrus.Errorf("Ошибка при сборе карточки месяца для пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось собрать итоги месяца")
+		return
+	}
+
+	image, err := card.RenderPNG(redaction)
+	if err != nil {
+		logrus.Errorf("Ошибка при отрисовке карточки месяца для пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось нарисовать карточку")
+		return
+	}
+
+	caption := card.Caption(redaction)
+	if !redaction.Any() {
+		caption += "\n\nЧтобы поделиться без личных данных: /dashboard private (или titles, finance, meetings)"
+	}
+
+	photo := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FileBytes{
+		Name:	"dashboard-" + month.Format("2006-01") + ".png",
+		Bytes:	image,
+	})
+	if len([]rune(caption)) <= photoCaptionLimit {
+		photo.Caption = caption
+		caption = ""
+	}
+	if _, err := h.bot.Send(photo); err != nil {
+		logrus.Errorf("Ошибка при отправке карточки месяца: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось отправить карточку")
+		return
+	}
+	if caption != "" {
+		h.sendMessageCtx(ctx, message.Chat.ID, caption)
+	}
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleDeadlinesCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	settings, err := h.okrService.GetDeadlineReminderSettings(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении настроек напоминаний о дедлайнах пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройки")
+		return
+	}
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	switch arg {
+	case "":
+	case "off":
+		settings.Enabled = false
+	case "on":
+		settings.Enabled = true
+	default:
+		days, err := strconv.Atoi(arg)
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /deadlines 5 — предупреждать за 5 дней, /deadlines off — выключить")
+			return
+		}
+		settings.DaysBefore = days
+		settings.Enabled = true
+	}
+
+	if arg != "" {
+		if err := h.okrService.SetDeadlineReminderSettings(ctx, userID, settings.DaysBefore, settings.Enabled); err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+			return
+		}
+	}
+
+	status := fmt.Sprintf("за %d дн. до дедлайна; если цель под угрозой — за %d дн. и еще раз накануне", settings.DaysBefore, 2*settings.DaysBefore)
+	if !settings.Enabled {
+		status = "выключены"
+	}
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf(
+		"⏰ Напоминания о дедлайнах ключевых результатов и задач: %s\n\n"+
+			"/deadlines 5 — предупреждать за 5 дней\n"+
+			"/deadlines off — выключить, /deadlines on — включить\n"+
+			"В тихие часы и режиме «не беспокоить» напоминания откладываются до их окончания", status))
+}
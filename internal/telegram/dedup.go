@@ -0,0 +1,41 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	updateCacheTTL		= 10 * time.Minute
+	updateCachePruneSize	= 1000
+)
+
+type updateCache struct {
+	mu	sync.Mutex
+	seen	map[int]time.Time
+	ttl	time.Duration
+}
+
+func newUpdateCache(ttl time.Duration) *updateCache {
+	return &updateCache{seen: make(map[int]time.Time), ttl: ttl}
+}
+
+func (c *updateCache) Seen(updateID int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if at, ok := c.seen[updateID]; ok && now.Sub(at) < c.ttl {
+		return true
+	}
+	c.seen[updateID] = now
+
+	if len(c.seen) > updateCachePruneSize {
+		for id, at := range c.seen {
+			if now.Sub(at) >= c.ttl {
+				delete(c.seen, id)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"telegrambot/internal/chatgpt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) sendReplyChoices(ctx context.Context, chatID int64, userMessageID int, reply *chatgpt.Reply) {
+	if userMessageID != 0 {
+		if _, err := h.messageStoreService.StoreAiResponse(ctx, userMessageID, reply.Text, reply.Function, nil, nil); err != nil {
+			logrus.Errorf("Ошибка при сохранении ответа ИИ: %v", err)
+		}
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(reply.Choices))
+	for _, choice := range reply.Choices {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(truncateButtonText(choice.Label, 60), choice.Data),
+		))
+	}
+
+	if err := h.sendFormatted(ctx, chatID, reply.Text, ParseModeHTML, tgbotapi.NewInlineKeyboardMarkup(rows...)); err != nil {
+		logrus.Errorf("Ошибка при отправке вариантов уточнения: %v", err)
+		h.sendMessageCtx(ctx, chatID, reply.Text)
+	}
+}
+
+func (h *Handler) handleDisambiguationCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	rawID, rawIndex, _ := strings.Cut(payload, ":")
+	pendingID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректный выбор")
+		return
+	}
+	index, err := strconv.Atoi(rawIndex)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректный выбор")
+		return
+	}
+
+	reply, err := h.chatgptService.ResolveDisambiguation(ctx, query.From.ID, pendingID, index)
+	if errors.Is(err, chatgpt.ErrDisambiguationExpired) {
+		h.answerCallback(query.ID, "Этот выбор уже неактуален — повторите запрос")
+		h.removeInlineKeyboard(query)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при обработке уточнения %d пользователя %d: %v", pendingID, query.From.ID, err)
+		h.answerCallback(query.ID, "❌ Не удалось выполнить запрос")
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.removeInlineKeyboard(query)
+	if query.Message != nil {
+		h.storeAndSendReply(ctx, query.Message.Chat.ID, query.From.ID, 0, reply)
+	}
+}
@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/notifications"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleDNDCommand(ctx context.Context, message *tgbotapi.Message) {
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	userID := message.From.ID
+
+	switch arg {
+	case "":
+		until, err := h.notificationsService.GetDND(ctx, userID)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении режима «не беспокоить» пользователя %d: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройку")
+			return
+		}
+		if until == nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "🔔 Режим «не беспокоить» выключен.\nИспользование: /dnd 2h, /dnd 30m, /dnd до 18:00, /dnd до завтра, /dnd off")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("🔕 Не беспокою до %s. Выключить: /dnd off", until.Format("02.01 15:04")))
+		return
+	case "off", "выкл", "стоп":
+		if err := h.notificationsService.ClearDND(ctx, userID); err != nil {
+			logrus.Errorf("Ошибка при выключении режима «не беспокоить» пользователя %d: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось выключить режим")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, "🔔 Режим «не беспокоить» выключен")
+		if err := h.notificationsService.SendCatchUp(ctx, userID, h.SendMessage); err != nil {
+			logrus.Errorf("Ошибка при отправке сводки пользователю %d: %v", userID, err)
+		}
+		return
+	}
+
+	until, err := notifications.ParseUntil(arg, time.Now())
+	if err == nil {
+		err = h.notificationsService.SetDND(ctx, userID, until)
+	}
+	if errors.Is(err, notifications.ErrInvalidPeriod) || errors.Is(err, notifications.ErrPeriodTooLong) {
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при включении режима «не беспокоить» пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось включить режим")
+		return
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf(
+		"🔕 Не беспокою до %s. Напоминания о событиях и лекарствах продолжат приходить, остальное пришлю одной сводкой после",
+		until.Format("02.01 15:04")))
+}
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/messagestore"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const encryptCommandHelp = "/encrypt on — шифровать историю переписки\n" +
+	"/encrypt off — хранить новые сообщения без шифрования"
+
+func (h *Handler) handleEncryptCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := strconv.FormatInt(message.From.ID, 10)
+
+	switch strings.ToLower(strings.TrimSpace(message.CommandArguments())) {
+	case "on":
+		err := h.messageStoreService.SetEncryption(ctx, userID, true)
+		if errors.Is(err, messagestore.ErrEncryptionUnavailable) {
+			h.sendMessageCtx(ctx, message.Chat.ID, "⚠️ Шифрование истории пока не настроено на сервере")
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка включения шифрования истории пользователя %s: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось включить шифрование")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, "🔒 Шифрование включено: история переписки и резюме диалога хранятся в зашифрованном виде "+
+			"и расшифровываются только для ответа тебе.\n\nПоиск по старым сообщениям при этом недоступен.")
+
+	case "off":
+		err := h.messageStoreService.SetEncryption(ctx, userID, false)
+		if errors.Is(err, messagestore.ErrEncryptionUnavailable) {
+			h.sendMessageCtx(ctx, message.Chat.ID, "⚠️ Шифрование истории пока не настроено на сервере")
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка выключения шифрования истории пользователя %s: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось выключить шифрование")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, "🔓 Новые сообщения будут храниться без шифрования. Уже зашифрованная история останется зашифрованной")
+
+	default:
+		status, err := h.messageStoreService.GetEncryptionStatus(ctx, userID)
+		if err != nil {
+			logrus.Errorf("Ошибка получения статуса шифрования пользователя %s: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось проверить шифрование")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, formatEncryptionStatus(status)+"\n\n"+encryptCommandHelp)
+	}
+}
+
+func formatEncryptionStatus(status *messagestore.EncryptionStatus) string {
+	if !status.Available {
+		return "⚠️ Шифрование истории пока не настроено на сервере"
+	}
+	state := "🔓 Шифрование истории выключено"
+	if status.Enabled {
+		state = "🔒 Шифрование истории включено"
+	}
+	return fmt.Sprintf("%s\nЗашифровано сообщений: %d из %d", state, status.Encrypted, status.Messages)
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/focus"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const focusCommandHelp = "/focus 25 отчет — начать сессию на 25 минут\n" +
+	"/focus stop — завершить досрочно\n" +
+	"/focus stats — итоги недели"
+
+func (h *Handler) handleFocusCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	args := strings.Fields(message.CommandArguments())
+
+	command := ""
+	if len(args) > 0 {
+		command = strings.ToLower(args[0])
+	}
+
+	switch command {
+	case "stop":
+		session, err := h.focusService.StopSession(ctx, userID)
+		if errors.Is(err, focus.ErrNoActiveSession) {
+			h.sendMessageCtx(ctx, message.Chat.ID, "ℹ️ Активной фокус-сессии нет\n\n"+focusCommandHelp)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Ошибка завершения фокус-сессии пользователя %d: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось завершить фокус-сессию")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("✅ Фокус-сессия «%s» завершена: %s\n%s",
+			session.Title(), focus.FormatMinutes(*session.DurationMinutes), h.focusWeekSummary(ctx, userID)))
+		return
+
+	case "stats":
+		h.sendMessageCtx(ctx, message.Chat.ID, h.focusWeekSummary(ctx, userID))
+		return
+	}
+
+	if active, err := h.focusService.GetActiveSession(ctx, userID); err == nil {
+		status := fmt.Sprintf("⏳ Идет фокус-сессия «%s» с %s", active.Title(), active.StartedAt.In(time.Local).Format("15:04"))
+		if active.PlannedMinutes != nil {
+			left := time.Until(active.StartedAt.Add(time.Duration(*active.PlannedMinutes) * time.Minute))
+			if left > 0 {
+				status += fmt.Sprintf(", осталось %s", focus.FormatMinutes(int(left.Minutes())+1))
+			}
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, status+"\n\n/focus stop — завершить досрочно")
+		return
+	} else if !errors.Is(err, focus.ErrNoActiveSession) {
+		logrus.Errorf("Ошибка получения фокус-сессии пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось проверить фокус-сессию")
+		return
+	}
+
+	minutes := focus.DefaultPomodoroMinutes
+	label := strings.Join(args, " ")
+	if len(args) > 0 {
+		if value, err := strconv.Atoi(args[0]); err == nil {
+			minutes = value
+			label = strings.Join(args[1:], " ")
+		}
+	}
+	if minutes < 5 || minutes > 480 {
+		h.sendMessageCtx(ctx, message.Chat.ID, "Длительность сессии — от 5 до 480 минут\n\n"+focusCommandHelp)
+		return
+	}
+
+	session, err := h.focusService.StartSession(ctx, userID, label, minutes)
+	if errors.Is(err, focus.ErrSessionActive) {
+		h.sendMessageCtx(ctx, message.Chat.ID, "⏳ Фокус-сессия уже идет. /focus stop — завершить")
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка запуска фокус-сессии пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось начать фокус-сессию")
+		return
+	}
+
+	end := session.StartedAt.Add(time.Duration(minutes) * time.Minute).In(time.Local)
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("🍅 Фокус-сессия «%s» на %s началась. Напишу в %s, когда время выйдет\n\n/focus stop — завершить досрочно",
+		session.Title(), focus.FormatMinutes(minutes), end.Format("15:04")))
+}
+
+func (h *Handler) focusWeekSummary(ctx context.Context, userID int64) string {
+	stats, err := h.focusService.GetWeeklyStats(ctx, userID, focus.WeekStart(time.Now()))
+	if err != nil {
+		logrus.Warnf("Не удалось получить фокус-статистику пользователя %d: %v", userID, err)
+		return "Статистика недели сейчас недоступна"
+	}
+	return focus.FormatWeeklyStats(stats)
+}
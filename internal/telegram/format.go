@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ParseModeNone	= ""
+	ParseModeHTML	= "HTML"
+
+	maxMessageLength	= 4096
+)
+
+var (
+	boldPattern	= regexp.MustCompile(`\*\*([^*\n]+?)\*\*`)
+	codePattern	= regexp.MustCompile("`([^`\n]+?)`")
+	italicPattern	= regexp.MustCompile(`(^|[\s(])_([^_\n]+?)_([\s.,!?:;)]|$)`)
+)
+
+func formatHTML(text string) string {
+	formatted := html.EscapeString(text)
+	formatted = codePattern.ReplaceAllString(formatted, "<code>$1</code>")
+	formatted = boldPattern.ReplaceAllString(formatted, "<b>$1</b>")
+	formatted = italicPattern.ReplaceAllString(formatted, "$1<i>$2</i>$3")
+	return formatted
+}
+
+func formatMessage(text, parseMode string) string {
+	if parseMode == ParseModeHTML {
+		return formatHTML(text)
+	}
+	return text
+}
+
+func splitMessage(text, parseMode string) []string {
+	if utf8.RuneCountInString(formatMessage(text, parseMode)) <= maxMessageLength {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimRight(current.String(), "\n"); strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+	fits := func(candidate string) bool {
+		return utf8.RuneCountInString(formatMessage(candidate, parseMode)) <= maxMessageLength
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if fits(current.String() + line) {
+			current.WriteString(line)
+			continue
+		}
+		flush()
+		for !fits(line) {
+			head, tail := splitLine(line, parseMode)
+			chunks = append(chunks, head)
+			line = tail
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return chunks
+}
+
+func splitLine(line, parseMode string) (string, string) {
+	runes := []rune(line)
+	size := maxMessageLength
+	if size > len(runes) {
+		size = len(runes)
+	}
+	for size > 1 && utf8.RuneCountInString(formatMessage(string(runes[:size]), parseMode)) > maxMessageLength {
+		size = size * 9 / 10
+	}
+
+	cut := size
+	if space := strings.LastIndexAny(string(runes[:size]), " \t"); space > 0 {
+		if spaceRunes := utf8.RuneCountInString(string(runes[:size])[:space]); spaceRunes > size/2 {
+			cut = spaceRunes + 1
+		}
+	}
+	return string(runes[:cut]), string(runes[cut:])
+}
+
+func isParseError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "can't parse entities")
+}
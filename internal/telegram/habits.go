@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"telegrambot/internal/habits"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleHabitsCommand(ctx context.Context, message *tgbotapi.Message) {
+	summaries, err := h.habitsService.GetSummaries(ctx, message.From.ID, 7)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении счетчиков пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось получить счетчики")
+		return
+	}
+
+	if len(summaries) == 0 {
+		h.sendMessageCtx(ctx, message.Chat.ID, "📊 Быстрые счетчики не включены. Напишите, например: «хочу считать воду» или «цель 8000 шагов в день»")
+		return
+	}
+
+	var buttons []tgbotapi.KeyboardButton
+	for _, s := range summaries {
+		buttons = append(buttons, tgbotapi.NewKeyboardButton(s.Counter.ButtonText()))
+	}
+
+	keyboard := tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(buttons...))
+	keyboard.ResizeKeyboard = true
+	keyboard.InputFieldPlaceholder = "Нажмите кнопку, чтобы отметить"
+
+	text := "📊 Сегодня:\n\n" + strings.TrimRight(habits.FormatSummaries(summaries), "\n") + "\n\nКнопки ниже добавляют значение в одно нажатие. /habits_off — убрать кнопки"
+	if err := h.sendFormatted(ctx, message.Chat.ID, text, ParseModeHTML, keyboard); err != nil {
+		logrus.Errorf("Ошибка при отправке клавиатуры счетчиков: %v", err)
+	}
+}
+
+func (h *Handler) handleHabitsOffCommand(ctx context.Context, message *tgbotapi.Message) {
+	if err := h.sendFormatted(ctx, message.Chat.ID, "Кнопки счетчиков скрыты. /habits — вернуть", ParseModeHTML, tgbotapi.NewRemoveKeyboard(false)); err != nil {
+		logrus.Errorf("Ошибка при скрытии клавиатуры счетчиков: %v", err)
+	}
+}
+
+func (h *Handler) handleHabitButton(ctx context.Context, message *tgbotapi.Message) bool {
+	if !habits.IsButtonText(message.Text) {
+		return false
+	}
+
+	counter, err := h.habitsService.FindCounterByButton(ctx, message.From.ID, message.Text)
+	if errors.Is(err, habits.ErrCounterNotFound) {
+		return false
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при поиске счетчика пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось найти счетчик")
+		return true
+	}
+
+	result, err := h.habitsService.Add(ctx, counter, 0)
+	if err != nil {
+		logrus.Errorf("Ошибка при обновлении счетчика %d: %v", counter.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось записать значение")
+		return true
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, habits.FormatTapResult(result))
+	return true
+}
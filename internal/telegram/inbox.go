@@ -0,0 +1,279 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/inbox"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const inboxKeyResultChoices = 6
+
+func (h *Handler) handleCaptureCommand(ctx context.Context, message *tgbotapi.Message) {
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	userID := message.From.ID
+
+	var enabled bool
+	var triageHour *int
+	switch arg {
+	case "", "on", "вкл":
+		enabled = true
+	case "off", "выкл":
+		enabled = false
+	default:
+		hour, err := strconv.Atoi(strings.TrimSuffix(arg, ":00"))
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /capture on | off | <час разбора, например 20>")
+			return
+		}
+		enabled = true
+		triageHour = &hour
+	}
+
+	settings, err := h.inboxService.SetQuickCapture(ctx, userID, enabled, triageHour)
+	if errors.Is(err, inbox.ErrInvalidTriageHour) {
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при переключении быстрого захвата пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось сохранить настройку")
+		return
+	}
+
+	if !settings.QuickCapture {
+		h.sendMessageCtx(ctx, message.Chat.ID, "🎙 Быстрый захват выключен: голосовые снова обрабатывает Jarvis")
+		return
+	}
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf(
+		"🎙 Быстрый захват включен: голосовые до %d сек. сохраняю в инбокс без обработки.\nРазбор инбокса — каждый день в %02d:00, или в любой момент через /inbox",
+		settings.MaxVoiceSeconds, settings.TriageHour))
+}
+
+func (h *Handler) handleInboxCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+
+	total, err := h.inboxService.CountNew(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении инбокса пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить инбокс")
+		return
+	}
+	if total == 0 {
+		h.sendMessageCtx(ctx, message.Chat.ID, "📥 Инбокс пуст")
+		return
+	}
+
+	items, err := h.inboxService.GetNew(ctx, userID, inbox.TriageBatchSize)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении инбокса пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить инбокс")
+		return
+	}
+
+	if err := h.SendInboxTriage(userID, items, total); err != nil {
+		logrus.Errorf("Ошибка при отправке инбокса пользователю %d: %v", userID, err)
+	}
+}
+
+func (h *Handler) captureVoiceNote(ctx context.Context, message *tgbotapi.Message, audioData []byte) bool {
+	settings, err := h.inboxService.GetSettings(ctx, message.From.ID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить настройки быстрого захвата пользователя %d: %v", message.From.ID, err)
+		return false
+	}
+	if !settings.QuickCapture || message.Voice.Duration > settings.MaxVoiceSeconds {
+		return false
+	}
+
+	text, err := h.chatgptService.TranscribeVoiceNote(ctx, audioData)
+	if err != nil {
+		logrus.Errorf("Ошибка при расшифровке голосовой заметки: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось расшифровать голосовую заметку")
+		return true
+	}
+
+	item, err := h.inboxService.Add(ctx, message.From.ID, text, inbox.SourceVoice)
+	if errors.Is(err, inbox.ErrEmptyItem) {
+		h.sendMessageCtx(ctx, message.Chat.ID, "🤷 В голосовом не удалось разобрать слов")
+		return true
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении голосовой заметки: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось сохранить заметку")
+		return true
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("📥 В инбоксе: «%s»", item.Text))
+	return true
+}
+
+func (h *Handler) SendInboxTriage(userID int64, items []inbox.Item, total int) error {
+	header := fmt.Sprintf("📥 Разбор инбокса: %d заметок. Решите, что сделать с каждой:", total)
+	if total > len(items) {
+		header += fmt.Sprintf("\nПоказываю первые %d, остальные — /inbox", len(items))
+	}
+	if err := h.sendMessageCtx(context.Background(), userID, header); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		text := fmt.Sprintf("🎙 %s\n«%s»", item.CreatedAt.Format("02.01 15:04"), item.Text)
+		if err := h.sendFormatted(context.Background(), userID, text, ParseModeHTML, inboxItemKeyboard(item.ID)); err != nil {
+			return fmt.Errorf("ошибка при отправке заметки %d: %v", item.ID, err)
+		}
+	}
+	return nil
+}
+
+func inboxItemKeyboard(itemID int64) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(itemID, 10)
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Задача", "inbox_task:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("📅 Событие", "inbox_event:"+id),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📝 Заметка", "inbox_note:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", "inbox_drop:"+id),
+		),
+	)
+}
+
+func (h *Handler) loadInboxItem(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) *inbox.Item {
+	itemID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректная заметка")
+		return nil
+	}
+
+	item, err := h.inboxService.GetNewItem(ctx, query.From.ID, itemID)
+	if err != nil {
+		switch {
+		case errors.Is(err, inbox.ErrItemNotFound):
+			h.answerCallback(query.ID, "Заметка не найдена")
+		case errors.Is(err, inbox.ErrItemProcessed):
+			h.answerCallback(query.ID, "Заметка уже разобрана")
+			h.removeInlineKeyboard(query)
+		default:
+			logrus.Errorf("Ошибка при получении заметки %d: %v", itemID, err)
+			h.answerCallback(query.ID, "Не удалось загрузить заметку")
+		}
+		return nil
+	}
+	return item
+}
+
+func (h *Handler) handleInboxTaskCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	item := h.loadInboxItem(ctx, query, payload)
+	if item == nil {
+		return
+	}
+
+	keyResults, err := h.okrService.GetOpenKeyResults(ctx, query.From.ID, inboxKeyResultChoices)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении ключевых результатов для заметки %d: %v", item.ID, err)
+		h.answerCallback(query.ID, "Не удалось загрузить ключевые результаты")
+		return
+	}
+	if len(keyResults) == 0 {
+		h.answerCallback(query.ID, "Нет активных ключевых результатов — сохраните как заметку")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, kr := range keyResults {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎯 "+truncateButtonText(kr.Title, 40), fmt.Sprintf("inbox_kr:%d:%d", item.ID, kr.ID)),
+		))
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID,
+			fmt.Sprintf("«%s»\n\nК какому ключевому результату добавить задачу?", item.Text), tgbotapi.NewInlineKeyboardMarkup(rows...))
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось показать выбор ключевого результата: %v", err)
+		}
+	}
+}
+
+func (h *Handler) handleInboxKeyResultCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	itemPart, krPart, _ := strings.Cut(payload, ":")
+	keyResultID, err := strconv.ParseInt(krPart, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректный ключевой результат")
+		return
+	}
+	item := h.loadInboxItem(ctx, query, itemPart)
+	if item == nil {
+		return
+	}
+
+	if _, err := h.okrService.CreateTask(ctx, query.From.ID, keyResultID, item.Text, 1, "", nil); err != nil {
+		logrus.Errorf("Ошибка при создании задачи из заметки %d: %v", item.ID, err)
+		h.answerCallback(query.ID, "Не удалось создать задачу")
+		return
+	}
+
+	h.finishInboxItem(ctx, query, item, inbox.StatusTask, "✅ Задача создана")
+}
+
+func (h *Handler) handleInboxEventCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	item := h.loadInboxItem(ctx, query, payload)
+	if item == nil {
+		return
+	}
+
+	draft, err := h.chatgptService.DraftEventFromNote(ctx, item.Text)
+	if err != nil {
+		logrus.Errorf("Ошибка при разборе события из заметки %d: %v", item.ID, err)
+		h.answerCallback(query.ID, "Не удалось разобрать событие")
+		return
+	}
+	if draft == nil {
+		h.answerCallback(query.ID, "В заметке нет даты или события — сохраните как задачу или заметку")
+		return
+	}
+
+	if _, err := h.calendarService.CreateEvent(ctx, query.From.ID, draft.Title, draft.Description, draft.StartTime, draft.EndTime); err != nil {
+		logrus.Errorf("Ошибка при создании события из заметки %d: %v", item.ID, err)
+		h.answerCallback(query.ID, "Не удалось создать событие")
+		return
+	}
+
+	h.finishInboxItem(ctx, query, item, inbox.StatusEvent, "📅 Событие «"+draft.Title+"» добавлено в календарь")
+}
+
+func (h *Handler) handleInboxStatusCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload, status string) {
+	item := h.loadInboxItem(ctx, query, payload)
+	if item == nil {
+		return
+	}
+
+	text := "📝 Сохранено как заметка"
+	if status == inbox.StatusDismissed {
+		text = "🗑 Удалено из инбокса"
+	}
+	h.finishInboxItem(ctx, query, item, status, text)
+}
+
+func (h *Handler) finishInboxItem(ctx context.Context, query *tgbotapi.CallbackQuery, item *inbox.Item, status, text string) {
+	if err := h.inboxService.MarkProcessed(ctx, query.From.ID, item.ID, status); err != nil && !errors.Is(err, inbox.ErrItemProcessed) {
+		logrus.Errorf("Ошибка при отметке заметки %d: %v", item.ID, err)
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, fmt.Sprintf("«%s»\n\n%s", item.Text, text))
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить сообщение заметки: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/journal"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const journalPromptPrefix = "🌙 Вечерний дневник"
+
+func (h *Handler) SendJournalPrompt(chatID int64, text string) error {
+	reply := tgbotapi.ForceReply{
+		ForceReply:		true,
+		InputFieldPlaceholder:	"Что получилось сегодня?",
+		Selective:		true,
+	}
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, reply); err != nil {
+		return fmt.Errorf("ошибка при отправке вечернего вопроса: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleJournalReply(ctx context.Context, message *tgbotapi.Message) bool {
+	reply := message.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.ID != h.bot.Self.ID || !strings.HasPrefix(reply.Text, journalPromptPrefix) {
+		return false
+	}
+	if strings.TrimSpace(message.Text) == "" {
+		return false
+	}
+
+	entryDate := time.Unix(int64(reply.Date), 0)
+	if _, err := h.journalService.AddEntry(ctx, message.From.ID, entryDate, message.Text, journal.SourcePrompt); err != nil {
+		logrus.Errorf("Ошибка при сохранении записи дневника пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось сохранить запись в дневник")
+		return true
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, "📓 Записал в дневник. В воскресенье пришлю итоги недели")
+	return true
+}
+
+func (h *Handler) handleJournalCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	settings, err := h.journalService.GetSettings(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении настроек дневника пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройки")
+		return
+	}
+
+	raw := strings.TrimSpace(message.CommandArguments())
+	arg := strings.ToLower(raw)
+	switch arg {
+	case "":
+		now := time.Now()
+		entries, err := h.journalService.GetEntries(ctx, userID, now.AddDate(0, 0, -7), now, 20)
+		if err != nil {
+			logrus.Errorf("Ошибка при получении записей дневника пользователя %d: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить дневник")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, journal.FormatEntries(entries)+"\n\n"+journalStatus(settings))
+		return
+	case "off":
+		settings.Enabled = false
+	case "on":
+		settings.Enabled = true
+	default:
+		hour, err := strconv.Atoi(strings.TrimSuffix(arg, ":00"))
+		if err != nil {
+			if _, err := h.journalService.AddEntry(ctx, userID, time.Now(), raw, journal.SourceCommand); err != nil {
+				logrus.Errorf("Ошибка при сохранении записи дневника пользователя %d: %v", userID, err)
+				h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось сохранить запись в дневник")
+				return
+			}
+			h.sendMessageCtx(ctx, message.Chat.ID, "📓 Записал в дневник")
+			return
+		}
+		settings.PromptHour = hour
+		settings.Enabled = true
+	}
+
+	if err := h.journalService.SetSettings(ctx, userID, settings.PromptHour, settings.Enabled); err != nil {
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+		return
+	}
+	h.sendMessageCtx(ctx, message.Chat.ID, journalStatus(settings))
+}
+
+func journalStatus(settings *journal.Settings) string {
+	status := fmt.Sprintf("каждый вечер в %02d:00", settings.PromptHour)
+	if !settings.Enabled {
+		status = "выключен"
+	}
+	return fmt.Sprintf(
+		"🌙 Вечерний вопрос «%s»: %s\n\n"+
+			"/journal текст — записать сразу\n"+
+			"/journal 21 — спрашивать в 21:00\n"+
+			"/journal off — выключить, /journal on — включить", journal.PromptQuestion, status)
+}
@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (h *Handler) handleMaintenance(ctx context.Context, update tgbotapi.Update) bool {
+	notice, active := h.maintenanceService.Notice()
+	if !active {
+		return false
+	}
+
+	switch {
+	case update.CallbackQuery != nil:
+		if h.adminService.IsAdmin(update.CallbackQuery.From.ID) {
+			return false
+		}
+		h.maintenanceService.RecordAffected(ctx, update.CallbackQuery.From.ID)
+		h.answerCallback(update.CallbackQuery.ID, "🛠 Идет техническое обслуживание, попробуйте позже")
+		return true
+	case update.Message != nil && update.Message.From != nil:
+		if h.adminService.IsAdmin(update.Message.From.ID) {
+			return false
+		}
+		h.maintenanceService.RecordAffected(ctx, update.Message.From.ID)
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, notice)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/notifications"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleNotificationsCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	settings, err := h.notificationsService.GetSettings(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении настроек уведомлений пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройки")
+		return
+	}
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if arg != "" {
+		value := strings.TrimSpace(strings.TrimPrefix(arg, "quiet"))
+		switch value {
+		case "off", "выкл":
+			settings.QuietStart, settings.QuietEnd = nil, nil
+		default:
+			start, end, err := notifications.ParseQuietHours(value)
+			if errors.Is(err, notifications.ErrInvalidQuietHours) {
+				h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error()+"\nИспользование: /notifications 22:00-08:00, /notifications off")
+				return
+			}
+			settings.QuietStart, settings.QuietEnd = &start, &end
+		}
+		if err := h.notificationsService.SaveSettings(ctx, settings); err != nil {
+			logrus.Errorf("Ошибка при сохранении тихих часов пользователя %d: %v", userID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось сохранить настройку")
+			return
+		}
+	}
+
+	if err := h.sendFormatted(ctx, message.Chat.ID, formatNotificationSettings(settings), ParseModeHTML, notificationKindsKeyboard(settings)); err != nil {
+		logrus.Errorf("Ошибка при отправке настроек уведомлений: %v", err)
+	}
+}
+
+func (h *Handler) handleNotificationKindCallback(ctx context.Context, query *tgbotapi.CallbackQuery, kind string) {
+	settings, err := h.notificationsService.ToggleKind(ctx, query.From.ID, kind)
+	if errors.Is(err, notifications.ErrUnknownKind) {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при переключении уведомлений %s пользователя %d: %v", kind, query.From.ID, err)
+		h.answerCallback(query.ID, "Не удалось сохранить настройку")
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID,
+			formatNotificationSettings(settings), notificationKindsKeyboard(settings))
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение настроек уведомлений: %v", err)
+		}
+	}
+}
+
+func formatNotificationSettings(settings *notifications.Settings) string {
+	quiet := "не заданы"
+	if settings.HasQuietHours() {
+		quiet = settings.QuietHours()
+	}
+	return fmt.Sprintf("🔔 Уведомления\n\n"+
+		"🌙 Тихие часы: %s\n"+
+		"В тихие часы сообщения не приходят — пришлю их одной сводкой после.\n"+
+		"/notifications 22:00-08:00 — задать, /notifications off — выключить\n\n"+
+		"Нажмите на тип, чтобы включить или выключить его:", quiet)
+}
+
+func notificationKindsKeyboard(settings *notifications.Settings) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, kind := range notifications.Kinds {
+		mark := "✅"
+		if settings.Muted(kind) {
+			mark = "🚫"
+		}
+		label := fmt.Sprintf("%s %s", mark, notifications.KindLabel(kind))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "notif:"+kind)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
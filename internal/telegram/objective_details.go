@@ -0,0 +1,238 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/dashboard"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/okr"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	objectiveViewSummary		= "s"
+	objectiveViewKeyResults		= "k"
+	objectiveViewTasks		= "t"
+
+	objectiveButtonTitleRunes	= 28
+)
+
+var errObjectiveNotResolved = errors.New("цель не найдена")
+
+func objectiveDetailsCallback(objectiveID, view string, keyResultID int64) string {
+	if view == objectiveViewTasks {
+		return fmt.Sprintf("okr_obj:%s:%s:%d", objectiveID, view, keyResultID)
+	}
+	return fmt.Sprintf("okr_obj:%s:%s", objectiveID, view)
+}
+
+func (h *Handler) sendObjectiveCard(ctx context.Context, chatID, userID int64, args map[string]interface{}) error {
+	objectiveID, _ := args["objective_id"].(string)
+	if description, _ := args["objective_description"].(string); objectiveID == "" && description != "" {
+		objectives, err := h.okrService.FindObjectiveByDescription(ctx, userID, description)
+		if err != nil {
+			return err
+		}
+		if len(objectives) > 0 {
+			objectiveID = objectives[0].ID
+		}
+	}
+	if objectiveID == "" {
+		return errObjectiveNotResolved
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, userID, objectiveID)
+	if err != nil {
+		return err
+	}
+
+	h.sendObjectiveChart(ctx, chatID, userID, objectiveID)
+
+	text, keyboard := renderObjectiveDetails(details, objectiveViewSummary, 0)
+	var markup interface{}
+	if len(keyboard.InlineKeyboard) > 0 {
+		markup = keyboard
+	}
+	if err := h.sendFormatted(ctx, chatID, text, ParseModeHTML, markup); err != nil {
+		return fmt.Errorf("ошибка при отправке карточки цели: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) sendObjectiveChart(ctx context.Context, chatID, userID int64, objectiveID string) {
+	history, err := h.okrService.GetObjectiveProgressHistory(ctx, userID, objectiveID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить историю прогресса цели %s: %v", objectiveID, err)
+		return
+	}
+	if len(history.Points) < 2 {
+		return
+	}
+
+	image, err := dashboard.NewProgressChart(history).RenderPNG()
+	if err != nil {
+		logrus.Warnf("Не удалось нарисовать график цели %s: %v", objectiveID, err)
+		return
+	}
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+		Name:	"objective-progress.png",
+		Bytes:	image,
+	})
+	photo.Caption = "📈 Прогресс по дням, серая линия — нужный темп до дедлайна"
+	if _, err := h.bot.Send(photo); err != nil {
+		logrus.Warnf("Не удалось отправить график цели %s: %v", objectiveID, err)
+	}
+}
+
+func (h *Handler) handleObjectiveDetailsCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	parts := strings.Split(payload, ":")
+	if len(parts) < 2 {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+	objectiveID, view := parts[0], parts[1]
+	var keyResultID int64
+	if view == objectiveViewTasks {
+		if len(parts) != 3 {
+			h.answerCallback(query.ID, "Некорректные данные кнопки")
+			return
+		}
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			h.answerCallback(query.ID, "Некорректные данные кнопки")
+			return
+		}
+		keyResultID = id
+	}
+
+	details, err := h.okrService.GetObjectiveDetails(ctx, query.From.ID, objectiveID)
+	if err != nil {
+		logrus.Warnf("Не удалось получить детали цели %s пользователя %d: %v", objectiveID, query.From.ID, err)
+		h.answerCallback(query.ID, "Цель не найдена")
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message == nil {
+		return
+	}
+	text, keyboard := renderObjectiveDetails(details, view, keyResultID)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить карточку цели: %v", err)
+	}
+}
+
+func renderObjectiveDetails(details *okr.ObjectiveDetails, view string, keyResultID int64) (string, tgbotapi.InlineKeyboardMarkup) {
+	objective := details.Objective
+	tasksCount := 0
+	for _, kr := range details.KeyResults {
+		tasksCount += len(kr.Tasks)
+	}
+
+	deadline := "без дедлайна"
+	if objective.Deadline != nil {
+		deadline = objective.Deadline.Format("02.01.2006")
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🎯 %s\n", objective.Title))
+	b.WriteString(fmt.Sprintf("%s %s • %s • 📅 %s\n", okr.SphereIcon(objective.Sphere), objective.Sphere, translatePeriod(objective.Period), deadline))
+	b.WriteString(fmt.Sprintf("📊 Прогресс: %.1f%% • 🔑 KR: %d • 📋 задач: %d\n", details.Progress, len(details.KeyResults), tasksCount))
+	if details.TrackedMinutes > 0 {
+		b.WriteString(fmt.Sprintf("⏱ Затрачено: %s\n", focus.FormatMinutes(details.TrackedMinutes)))
+	}
+	if details.Closing != nil {
+		b.WriteString(okr.FormatObjectiveClosing(details.Closing) + "\n")
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	collapse := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔼 Свернуть", objectiveDetailsCallback(objective.ID, objectiveViewSummary, 0)))
+
+	switch view {
+	case objectiveViewKeyResults:
+		b.WriteString("\n🔑 Ключевые результаты:\n")
+		for i, kr := range details.KeyResults {
+			b.WriteString(fmt.Sprintf("%d. %s\n", i+1, kr.KeyResult.Title))
+			b.WriteString(fmt.Sprintf("   %s/%s %s (%.0f%%)", formatKeyResultAmount(kr.KeyResult.Progress), formatKeyResultAmount(kr.KeyResult.Target), kr.KeyResult.Unit, kr.Progress))
+			if kr.KeyResult.Deadline != nil {
+				b.WriteString(" • 📅 " + kr.KeyResult.Deadline.Format("02.01.2006"))
+			}
+			if kr.TrackedMinutes > 0 {
+				b.WriteString(" • ⏱ " + focus.FormatMinutes(kr.TrackedMinutes))
+			}
+			b.WriteString("\n")
+			for _, r := range kr.Revisions {
+				b.WriteString("   " + okr.FormatTargetRevision(r, kr.KeyResult.Unit) + "\n")
+			}
+			if len(kr.Tasks) > 0 {
+				label := fmt.Sprintf("📋 %d. %s (%d)", i+1, truncateButtonTitle(kr.KeyResult.Title), len(kr.Tasks))
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData(label, objectiveDetailsCallback(objective.ID, objectiveViewTasks, kr.KeyResult.ID))))
+			}
+		}
+		rows = append(rows, collapse)
+
+	case objectiveViewTasks:
+		var selected *okr.KeyResultDetails
+		for i := range details.KeyResults {
+			if details.KeyResults[i].KeyResult.ID == keyResultID {
+				selected = &details.KeyResults[i]
+				break
+			}
+		}
+		if selected == nil {
+			b.WriteString("\nКлючевой результат не найден — возможно, его удалили")
+		} else {
+			b.WriteString(fmt.Sprintf("\n🔑 %s — %.0f%%\n📋 Задачи:\n", selected.KeyResult.Title, selected.Progress))
+			for i, task := range selected.Tasks {
+				b.WriteString(fmt.Sprintf("%d. %s — %s/%s %s", i+1, task.Title, formatKeyResultAmount(task.Progress), formatKeyResultAmount(task.Target), task.Unit))
+				if task.Deadline != nil {
+					b.WriteString(" • 📅 " + task.Deadline.Format("02.01.2006"))
+				}
+				if minutes := selected.TaskMinutes[task.ID]; minutes > 0 {
+					b.WriteString(" • ⏱ " + focus.FormatMinutes(minutes))
+				}
+				b.WriteString("\n")
+			}
+		}
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("⬅️ Ключевые результаты", objectiveDetailsCallback(objective.ID, objectiveViewKeyResults, 0))),
+			collapse)
+
+	default:
+		if len(details.KeyResults) > 0 {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("🔑 Ключевые результаты (%d)", len(details.KeyResults)), objectiveDetailsCallback(objective.ID, objectiveViewKeyResults, 0))))
+		} else {
+			b.WriteString("\nУ этой цели пока нет ключевых результатов")
+		}
+	}
+
+	text := strings.TrimRight(b.String(), "\n")
+	if chunks := splitMessage(text, ParseModeNone); len(chunks) > 1 {
+		runes := []rune(chunks[0])
+		if len(runes) > maxMessageLength-2 {
+			runes = runes[:maxMessageLength-2]
+		}
+		text = string(runes) + "\n…"
+	}
+	if len(rows) == 0 {
+		return text, tgbotapi.InlineKeyboardMarkup{}
+	}
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func truncateButtonTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) <= objectiveButtonTitleRunes {
+		return title
+	}
+	return string(runes[:objectiveButtonTitleRunes-1]) + "…"
+}
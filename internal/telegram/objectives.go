@@ -0,0 +1,261 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/okr"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	objectivesModeFull	= "f"
+	objectivesModeCompact	= "c"
+
+	objectivesPageSizeFull		= 5
+	objectivesPageSizeCompact	= 15
+	objectivesKeyResultsShown	= 5
+)
+
+type objectivesView struct {
+	Mode		string
+	Page		int
+	Period		string
+	Status		string
+	Sphere		string
+	Days		int
+}
+
+func (v objectivesView) callback(mode string, page int) string {
+	return fmt.Sprintf("okr_list:%s:%d:%s:%s:%s:%d", mode, page, v.Period, v.Status, v.Sphere, v.Days)
+}
+
+func parseObjectivesView(payload string) (objectivesView, bool) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 4 && len(parts) != 6 {
+		return objectivesView{}, false
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil || page < 0 {
+		return objectivesView{}, false
+	}
+	view := objectivesView{Mode: parts[0], Page: page, Period: parts[2], Status: parts[3]}
+	if len(parts) == 6 {
+		view.Sphere = parts[4]
+		if view.Days, err = strconv.Atoi(parts[5]); err != nil || view.Days < 0 {
+			return objectivesView{}, false
+		}
+	}
+	return view, true
+}
+
+func (h *Handler) sendObjectivesList(ctx context.Context, chatID, userID int64, args map[string]interface{}) error {
+	view := objectivesView{Mode: objectivesModeFull, Period: "all", Status: okr.ObjectiveStatusOpen}
+	if period, _ := args["period"].(string); period != "" {
+		view.Period = period
+	}
+	if status, _ := args["status"].(string); status != "" {
+		view.Status = status
+	}
+	if days, ok := args["deadline_within_days"].(float64); ok && days >= 1 {
+		view.Days = int(days)
+	}
+	if name, _ := args["sphere"].(string); name != "" {
+		sphere, err := h.okrService.FindSphere(ctx, userID, name)
+		if err != nil {
+			return err
+		}
+		view.Sphere = sphere.Key
+	}
+
+	text, keyboard, err := h.renderObjectives(ctx, userID, view)
+	if err != nil {
+		return err
+	}
+
+	var markup interface{}
+	if len(keyboard.InlineKeyboard) > 0 {
+		markup = keyboard
+	}
+	if err := h.sendFormatted(ctx, chatID, text, ParseModeHTML, markup); err != nil {
+		return fmt.Errorf("ошибка при отправке списка целей: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleObjectivesPageCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	view, ok := parseObjectivesView(payload)
+	if !ok {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	text, keyboard, err := h.renderObjectives(ctx, query.From.ID, view)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении списка целей пользователя %d: %v", query.From.ID, err)
+		h.answerCallback(query.ID, "Не удалось загрузить цели")
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить сообщение со списком целей: %v", err)
+	}
+}
+
+func (h *Handler) renderObjectives(ctx context.Context, userID int64, view objectivesView) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	pageSize := objectivesPageSizeFull
+	if view.Mode == objectivesModeCompact {
+		pageSize = objectivesPageSizeCompact
+	}
+
+	filter := okr.ObjectiveFilter{Period: view.Period, Status: view.Status, DeadlineWithinDays: view.Days}
+	var filterLabel string
+	if view.Sphere != "" {
+		sphere, err := h.okrService.FindSphere(ctx, userID, view.Sphere)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		filter.Sphere = sphere.Name
+		filterLabel += fmt.Sprintf(" · %s %s", sphere.Icon, sphere.Name)
+	}
+	if view.Days > 0 {
+		filterLabel += fmt.Sprintf(" · дедлайн в ближайшие %d дн.", view.Days)
+	}
+
+	objectives, total, err := h.okrService.ListObjectiveSummaries(ctx, userID, filter, pageSize, view.Page*pageSize)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	var teamObjectives []okr.Objective
+	if filterLabel == "" {
+		teamObjectives, err = h.okrService.GetTeamObjectives(ctx, userID)
+		if err != nil {
+			logrus.Warnf("Не удалось получить командные цели пользователя %d: %v", userID, err)
+		}
+	}
+
+	pages := (total + pageSize - 1) / pageSize
+	if pages == 0 {
+		pages = 1
+	}
+	lastPage := view.Page >= pages-1
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🎯 Твои цели%s — %d", filterLabel, total))
+	if pages > 1 {
+		b.WriteString(fmt.Sprintf(" (стр. %d из %d)", view.Page+1, pages))
+	}
+	b.WriteString("\n\n")
+
+	if total == 0 && filterLabel != "" {
+		b.WriteString("Под этот фильтр целей нет")
+	} else if total == 0 && len(teamObjectives) == 0 {
+		b.WriteString("У тебя пока нет целей. Расскажи о своих планах, и я помогу сформулировать их в OKR")
+	} else if len(objectives) == 0 && total > 0 {
+		b.WriteString("Больше целей нет")
+	}
+
+	if view.Mode == objectivesModeCompact {
+		for i, o := range objectives {
+			b.WriteString(fmt.Sprintf("%d. %s %s — %.0f%%\n", view.Page*pageSize+i+1, objectiveStatusEmoji(o.Status), o.Title, o.Progress))
+		}
+	} else {
+		ids := make([]string, 0, len(objectives))
+		for _, o := range objectives {
+			ids = append(ids, o.ID)
+		}
+		keyResults, err := h.okrService.GetKeyResultsForObjectives(ctx, ids)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		for i, o := range objectives {
+			deadline := "без дедлайна"
+			if o.Deadline != nil {
+				deadline = o.Deadline.Format("02.01.2006")
+			}
+			b.WriteString(fmt.Sprintf("%d. %s %s (%s)\n", view.Page*pageSize+i+1, objectiveStatusEmoji(o.Status), o.Title, o.Sphere))
+			b.WriteString(fmt.Sprintf("   📊 %.1f%% • 🔑 KR: %d • 📅 %s\n", o.Progress, o.KeyResultsCount, deadline))
+			for j, kr := range keyResults[o.ID] {
+				if j == objectivesKeyResultsShown {
+					b.WriteString(fmt.Sprintf("   … и еще %d\n", len(keyResults[o.ID])-j))
+					break
+				}
+				b.WriteString(fmt.Sprintf("   • %s — %s/%s %s\n", kr.Title, formatKeyResultAmount(kr.Progress), formatKeyResultAmount(kr.Target), kr.Unit))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(teamObjectives) > 0 && lastPage {
+		if view.Mode == objectivesModeCompact {
+			b.WriteString(fmt.Sprintf("\n👥 Командных целей: %d — подробности в полном виде", len(teamObjectives)))
+		} else {
+			b.WriteString("👥 Командные цели\n\n")
+			for _, objective := range teamObjectives {
+				text, err := h.okrService.FormatTeamObjectiveProgress(ctx, objective)
+				if err != nil {
+					logrus.Warnf("Ошибка формирования прогресса командной цели %s: %v", objective.ID, err)
+					continue
+				}
+				b.WriteString(text + "\n")
+			}
+		}
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var nav []tgbotapi.InlineKeyboardButton
+	if view.Page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬅️", view.callback(view.Mode, view.Page-1)))
+	}
+	if !lastPage {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Показать ещё (%d) ➡️", total-(view.Page+1)*pageSize), view.callback(view.Mode, view.Page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	if total > 0 {
+		toggle := tgbotapi.NewInlineKeyboardButtonData("📋 Кратко", view.callback(objectivesModeCompact, 0))
+		if view.Mode == objectivesModeCompact {
+			toggle = tgbotapi.NewInlineKeyboardButtonData("📄 Подробно", view.callback(objectivesModeFull, 0))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(toggle))
+	}
+
+	text := strings.TrimRight(b.String(), "\n")
+	if chunks := splitMessage(text, ParseModeNone); len(chunks) > 1 {
+		runes := []rune(chunks[0])
+		if len(runes) > maxMessageLength-2 {
+			runes = runes[:maxMessageLength-2]
+		}
+		text = string(runes) + "\n…"
+	}
+	if len(rows) == 0 {
+		return text, tgbotapi.InlineKeyboardMarkup{}, nil
+	}
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
+
+func objectiveStatusEmoji(status string) string {
+	switch status {
+	case okr.ObjectiveStatusCompleted:
+		return "✅"
+	case "paused":
+		return "⏸️"
+	case okr.ObjectiveStatusActive:
+		return "🎯"
+	default:
+		return "🔄"
+	}
+}
+
+func formatKeyResultAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/notifications"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) SendWeeklyOKRReport(ctx context.Context, userID int64) error {
+	text, err := h.sendWeeklyOKRReport(ctx, userID)
+	if err != nil {
+		return err
+	}
+	h.recordNotification(ctx, userID, notifications.KindReport, text)
+	return nil
+}
+
+func (h *Handler) sendWeeklyOKRReport(ctx context.Context, userID int64) (string, error) {
+	report, err := h.chatgptService.ComposeWeeklyOKRReport(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при подготовке недельного отчета: %v", err)
+	}
+
+	if len(report.Chart) > 0 {
+		photo := tgbotapi.NewPhoto(userID, tgbotapi.FileBytes{
+			Name:	"okr-week.png",
+			Bytes:	report.Chart,
+		})
+		photo.Caption = "📈 Прогресс целей за неделю: желтым — было, зеленым — прирост"
+		if _, err := h.bot.Send(photo); err != nil {
+			logrus.Warnf("Не удалось отправить график недели пользователю %d: %v", userID, err)
+		}
+	}
+	for i, chart := range report.Objectives {
+		photo := tgbotapi.NewPhoto(userID, tgbotapi.FileBytes{
+			Name:	fmt.Sprintf("okr-objective-%d.png", i+1),
+			Bytes:	chart.Image,
+		})
+		photo.Caption = "🎯 " + chart.Title
+		if _, err := h.bot.Send(photo); err != nil {
+			logrus.Warnf("Не удалось отправить график цели пользователю %d: %v", userID, err)
+		}
+	}
+	return report.Text, h.sendMessageCtx(ctx, userID, report.Text)
+}
+
+func (h *Handler) handleReportCommand(ctx context.Context, message *tgbotapi.Message) {
+	period := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	switch period {
+	case "", "week", "неделя":
+		h.sendMessageCtx(ctx, message.Chat.ID, "⏳ Готовлю отчет за неделю...")
+		if _, err := h.sendWeeklyOKRReport(ctx, message.From.ID); err != nil {
+			logrus.Errorf("Ошибка отправки недельного отчета пользователю %d: %v", message.From.ID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось подготовить отчет")
+		}
+
+	case "day", "month", "день", "месяц":
+		if period == "день" {
+			period = "day"
+		} else if period == "месяц" {
+			period = "month"
+		}
+		report, err := h.okrService.GenerateReport(ctx, message.From.ID, period)
+		if err != nil {
+			logrus.Errorf("Ошибка генерации отчета пользователю %d: %v", message.From.ID, err)
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось подготовить отчет")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, report)
+
+	default:
+		h.sendMessageCtx(ctx, message.Chat.ID, "/report — отчет по целям за неделю с комментарием\n/report day, /report month — сводка за день или месяц")
+	}
+}
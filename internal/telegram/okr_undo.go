@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/ownership"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const undoDeletionWindow = 5 * time.Minute
+
+func (h *Handler) undoDeletionRow(ctx context.Context, userID int64) []tgbotapi.InlineKeyboardButton {
+	item, err := h.okrService.GetLastDeletedItem(ctx, userID, undoDeletionWindow)
+	if err != nil {
+		logrus.Warnf("Не удалось получить последний удаленный элемент пользователя %d: %v", userID, err)
+		return nil
+	}
+	if item == nil {
+		return nil
+	}
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("↩️ Отменить удаление", "okr_undo:"+item.Kind+":"+item.ID),
+	)
+}
+
+func (h *Handler) handleUndoDeletionCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	userID := query.From.ID
+
+	kind, id, ok := strings.Cut(payload, ":")
+	if !ok {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	item, err := h.okrService.RestoreDeletedItem(ctx, userID, kind, id)
+	if err != nil {
+		text := "Не удалось отменить удаление"
+		switch {
+		case ownership.IsNotOwned(err):
+			text = "Уже восстановлено или удалено окончательно"
+		case errors.Is(err, okr.ErrParentDeleted):
+			text = "Сначала восстановите цель или ключевой результат, в котором он был"
+		default:
+			logrus.Errorf("Ошибка при отмене удаления %s %s пользователя %d: %v", kind, id, userID, err)
+		}
+		h.answerCallback(query.ID, text)
+		return
+	}
+
+	h.answerCallback(query.ID, "↩️ Удаление отменено")
+	if query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, "↩️ Восстановлено: «"+item.Title+"»")
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить сообщение после отмены удаления: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/habits"
+	"telegrambot/internal/payments"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) sendSubscriptionInvoice(ctx context.Context, chatID, userID int64) {
+	if !h.paymentsService.Enabled() {
+		h.sendMessageCtx(ctx, chatID, "У вас нет подписки")
+		return
+	}
+
+	plan := h.paymentsService.Plan()
+	invoice := tgbotapi.NewInvoice(
+		chatID,
+		plan.Title,
+		plan.Description,
+		h.paymentsService.InvoicePayload(userID),
+		h.paymentsService.ProviderToken(),
+		"subscribe",
+		plan.Currency,
+		[]tgbotapi.LabeledPrice{{Label: fmt.Sprintf("%d дней доступа", plan.Days), Amount: plan.Amount}},
+	)
+	invoice.SuggestedTipAmounts = []int{}
+
+	if _, err := h.bot.Send(invoice); err != nil {
+		logrus.Errorf("Ошибка при отправке счета пользователю %d: %v", userID, err)
+		h.sendMessageCtx(ctx, chatID, "❌ Не удалось выставить счет, попробуйте позже")
+	}
+}
+
+func (h *Handler) handleSubscribeCommand(ctx context.Context, message *tgbotapi.Message) {
+	sub, err := h.paymentsService.GetSubscription(ctx, message.From.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении подписки пользователя %d: %v", message.From.ID, err)
+	} else if sub.IsActive() {
+		if sub.ExpiresAt == nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "✅ У вас бессрочный доступ")
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("✅ Подписка активна до %s. Оплата ниже продлит ее", sub.ExpiresAt.Format("02.01.2006")))
+	} else {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("💎 Подписка открывает полный доступ к Jarvis — %s", h.paymentsService.Plan().FormatPrice()))
+	}
+
+	h.sendSubscriptionInvoice(ctx, message.Chat.ID, message.From.ID)
+}
+
+func (h *Handler) handlePreCheckoutQuery(ctx context.Context, query *tgbotapi.PreCheckoutQuery) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+
+	if err := h.paymentsService.ValidatePreCheckout(query.From.ID, query.InvoicePayload, query.Currency, query.TotalAmount); err != nil {
+		logrus.Warnf("Отклонена предоплата пользователя %d: %v", query.From.ID, err)
+		answer.OK = false
+		answer.ErrorMessage = "Счет устарел. Запросите новый командой /subscribe"
+	}
+
+	if _, err := h.bot.Request(answer); err != nil {
+		logrus.Errorf("Ошибка при ответе на pre_checkout_query %s: %v", query.ID, err)
+	}
+}
+
+func (h *Handler) handleSuccessfulPayment(ctx context.Context, message *tgbotapi.Message) {
+	paid := message.SuccessfulPayment
+
+	expiresAt, err := h.paymentsService.Activate(ctx, payments.Payment{
+		UserID:			message.From.ID,
+		TelegramChargeID:	paid.TelegramPaymentChargeID,
+		ProviderChargeID:	paid.ProviderPaymentChargeID,
+		Amount:			paid.TotalAmount,
+		Currency:		paid.Currency,
+		Payload:		paid.InvoicePayload,
+	})
+	if err != nil {
+		logrus.Errorf("Ошибка при активации подписки пользователя %d (платеж %s): %v", message.From.ID, paid.TelegramPaymentChargeID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "⚠️ Платеж получен, но подписку не удалось активировать автоматически. Мы разберемся и свяжемся с вами")
+		return
+	}
+
+	logrus.Infof("Подписка пользователя %d активирована до %s", message.From.ID, expiresAt.Format(time.RFC3339))
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("🎉 Спасибо! Подписка активна до %s. Напишите, чем могу помочь", expiresAt.Format("02.01.2006")))
+}
+
+func (h *Handler) allowFreeTier(ctx context.Context, message *tgbotapi.Message) bool {
+	if !h.paymentsService.FreeTierEnabled() {
+		h.sendSubscriptionInvoice(ctx, message.Chat.ID, message.From.ID)
+		return false
+	}
+
+	if message.IsCommand() || habits.IsButtonText(message.Text) {
+		return true
+	}
+
+	if (message.Voice != nil || message.Audio != nil) && !h.paymentsService.FreeTier().Audio {
+		h.sendMessageCtx(ctx, message.Chat.ID, "🎙 Голосовые сообщения доступны только по подписке. Напишите запрос текстом или оформите подписку — /subscribe")
+		return false
+	}
+
+	usage, allowed, err := h.paymentsService.ConsumeFreeMessage(ctx, message.From.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка учета бесплатного тарифа пользователя %d: %v", message.From.ID, err)
+		return true
+	}
+
+	if allowed {
+		if usage.Remaining() == 3 {
+			h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("ℹ️ На бесплатном тарифе осталось 3 сообщения до %s", usage.ResetsAt.Format("02.01")))
+		}
+		return true
+	}
+
+	if usage.UpsellSent {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("⏳ Лимит бесплатных сообщений исчерпан до %s. Оформить подписку — /subscribe", usage.ResetsAt.Format("02.01")))
+		return false
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf(
+		"🚀 Вы использовали все %d бесплатных сообщений в этом месяце.\n\nС подпиской — без ограничений, с голосовыми сообщениями и напоминаниями: %s. Лимит обновится %s",
+		usage.Limit, h.paymentsService.Plan().FormatPrice(), usage.ResetsAt.Format("02.01"),
+	))
+	h.sendSubscriptionInvoice(ctx, message.Chat.ID, message.From.ID)
+	return false
+}
@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/notifications"
+	"telegrambot/internal/preferences"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) SendUnsolicited(kind string) func(chatID int64, text string) error {
+	return func(chatID int64, text string) error {
+		ctx := context.Background()
+		decision, err := h.notificationsService.Decide(ctx, chatID, kind)
+		if err != nil {
+			logrus.Warnf("Не удалось проверить политику уведомлений пользователя %d: %v", chatID, err)
+		}
+		switch decision {
+		case notifications.Suppress:
+			logrus.Debugf("Сообщение типа %s пользователю %d подавлено настройкой проактивности", kind, chatID)
+			return nil
+		case notifications.Defer:
+			return h.notificationsService.Defer(ctx, chatID, kind, text)
+		}
+		if err := h.sendMessageCtx(ctx, chatID, text); err != nil {
+			return err
+		}
+		h.recordNotification(ctx, chatID, kind, text)
+		return nil
+	}
+}
+
+func (h *Handler) SendDeferrable(kind string) func(chatID int64, text string) error {
+	return h.sendDecided(kind, h.notificationsService.DecideQuiet)
+}
+
+func (h *Handler) SendNotification(kind string) func(chatID int64, text string) error {
+	return h.sendDecided(kind, h.notificationsService.DecideScheduled)
+}
+
+func (h *Handler) sendDecided(kind string, decide func(ctx context.Context, userID int64, kind string) (notifications.Decision, error)) func(chatID int64, text string) error {
+	return func(chatID int64, text string) error {
+		ctx := context.Background()
+		decision, err := decide(ctx, chatID, kind)
+		if err != nil {
+			logrus.Warnf("Не удалось проверить настройки уведомлений пользователя %d: %v", chatID, err)
+		}
+		switch decision {
+		case notifications.Suppress:
+			logrus.Debugf("Уведомление типа %s пользователю %d отключено в настройках", kind, chatID)
+			return nil
+		case notifications.Defer:
+			return h.notificationsService.Defer(ctx, chatID, kind, text)
+		}
+		if err := h.SendMessage(chatID, text); err != nil {
+			return err
+		}
+		h.recordNotification(ctx, chatID, kind, text)
+		return nil
+	}
+}
+
+func (h *Handler) recordNotification(ctx context.Context, chatID int64, kind, text string) {
+	if err := h.notificationsService.Record(ctx, chatID, kind, text); err != nil {
+		logrus.Warnf("Не удалось записать уведомление пользователя %d в журнал: %v", chatID, err)
+	}
+}
+
+func (h *Handler) handleProactivityCommand(ctx context.Context, message *tgbotapi.Message) {
+	arg := strings.TrimSpace(message.CommandArguments())
+	if arg != "" {
+		text, err := h.setProactivity(ctx, message.From.ID, arg)
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, text)
+		return
+	}
+
+	prefs, err := h.preferencesService.Get(ctx, message.From.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении проактивности пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройку")
+		return
+	}
+	current := preferences.ProactivityHigh
+	if prefs.Proactivity != nil {
+		current = *prefs.Proactivity
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, level := range preferences.AllowedValues[preferences.TypeProactivity] {
+		label := preferences.ProactivityLabel(level)
+		if level == current {
+			label = "• " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "proactivity:"+level))
+	}
+
+	text := fmt.Sprintf(
+		"🔔 Проактивность Jarvis: %s\n\n"+
+			"Выключена — только то, что вы сами запросили (напоминания, отчеты).\n"+
+			"Минимальная — плюс важные подсказки по срокам и темпу.\n"+
+			"Средняя — плюс инсайты и сводки.\n"+
+			"Высокая — плюс мотивация и предложения.",
+		preferences.ProactivityLabel(current))
+	if err := h.sendFormatted(ctx, message.Chat.ID, text, ParseModeHTML, tgbotapi.NewInlineKeyboardMarkup(row)); err != nil {
+		logrus.Errorf("Ошибка при отправке настроек проактивности: %v", err)
+	}
+}
+
+func (h *Handler) handleProactivityCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	text, err := h.setProactivity(ctx, query.From.ID, payload)
+	if err != nil {
+		h.answerCallback(query.ID, err.Error())
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение настроек проактивности: %v", err)
+		}
+	}
+}
+
+func (h *Handler) setProactivity(ctx context.Context, userID int64, value string) (string, error) {
+	_, normalized, err := h.preferencesService.Set(ctx, userID, preferences.TypeProactivity, value)
+	if errors.Is(err, preferences.ErrInvalidValue) {
+		return "", errors.New("допустимые уровни: off, low, medium, high")
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении проактивности пользователя %d: %v", userID, err)
+		return "", errors.New("не удалось сохранить настройку")
+	}
+	return fmt.Sprintf("🔔 Проактивность Jarvis: %s", preferences.ProactivityLabel(normalized)), nil
+}
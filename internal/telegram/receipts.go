@@ -0,0 +1,178 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/finance/receipts"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleReceiptPDF(ctx context.Context, message *tgbotapi.Message) {
+	data, err := h.downloadFile(message.Document.FileID)
+	if err != nil {
+		logrus.Errorf("Ошибка при загрузке PDF: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "Не удалось загрузить файл")
+		return
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, "🧾 Распознаю чек...")
+
+	ext, err := h.chatgptService.ExtractReceiptPDF(ctx, data, message.Document.FileName)
+	if err != nil {
+		logrus.Errorf("Ошибка при распознавании PDF-чека: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "Не удалось распознать чек в PDF")
+		return
+	}
+
+	h.offerReceipt(ctx, message.Chat.ID, message.From.ID, *ext, receipts.SourcePDF)
+}
+
+func (h *Handler) offerReceipt(ctx context.Context, chatID, userID int64, ext receipts.Extraction, source string) {
+	receipt, err := h.receiptsService.Create(ctx, userID, ext, source)
+	if errors.Is(err, receipts.ErrNoTotal) {
+		h.sendMessageCtx(ctx, chatID, "🤷 Не нашел в чеке итоговую сумму. Запишите расход текстом, например: «потратил 1200 на продукты»")
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении чека пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, chatID, "❌ Не удалось сохранить чек")
+		return
+	}
+
+	if err := h.sendFormatted(ctx, chatID, receipts.Format(receipt)+"\n\nЗаписать в расходы?", ParseModeHTML, receiptKeyboard(receipt.ID)); err != nil {
+		logrus.Errorf("Ошибка при отправке подтверждения чека: %v", err)
+	}
+}
+
+func receiptKeyboard(receiptID int64) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(receiptID, 10)
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Записать", "receipt_ok:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("🗂 Категория", "receipt_cat:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "receipt_cancel:"+id),
+		),
+	)
+}
+
+func (h *Handler) parseReceiptID(query *tgbotapi.CallbackQuery, payload string) (int64, bool) {
+	receiptID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректный чек")
+		return 0, false
+	}
+	return receiptID, true
+}
+
+func (h *Handler) answerReceiptError(query *tgbotapi.CallbackQuery, err error) {
+	switch {
+	case errors.Is(err, receipts.ErrReceiptNotFound):
+		h.answerCallback(query.ID, "Чек не найден")
+	case errors.Is(err, receipts.ErrReceiptProcessed):
+		h.answerCallback(query.ID, "Чек уже обработан")
+		h.removeInlineKeyboard(query)
+	default:
+		logrus.Errorf("Ошибка при обработке чека: %v", err)
+		h.answerCallback(query.ID, "Не удалось обработать чек")
+	}
+}
+
+func (h *Handler) editReceiptMessage(query *tgbotapi.CallbackQuery, text string, markup *tgbotapi.InlineKeyboardMarkup) {
+	if query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ReplyMarkup = markup
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить сообщение чека: %v", err)
+	}
+}
+
+func (h *Handler) handleReceiptConfirmCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	receiptID, ok := h.parseReceiptID(query, payload)
+	if !ok {
+		return
+	}
+
+	receipt, err := h.receiptsService.Confirm(ctx, query.From.ID, receiptID)
+	if err != nil {
+		h.answerReceiptError(query, err)
+		return
+	}
+
+	h.answerCallback(query.ID, "Записано")
+	h.editReceiptMessage(query, receipts.Format(receipt)+"\n\n✅ Записано в расходы", nil)
+}
+
+func (h *Handler) handleReceiptCancelCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	receiptID, ok := h.parseReceiptID(query, payload)
+	if !ok {
+		return
+	}
+
+	if err := h.receiptsService.Cancel(ctx, query.From.ID, receiptID); err != nil {
+		h.answerReceiptError(query, err)
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.editReceiptMessage(query, "🧾 Чек не записан", nil)
+}
+
+func (h *Handler) handleReceiptCategoryCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	receiptID, ok := h.parseReceiptID(query, payload)
+	if !ok {
+		return
+	}
+
+	receipt, err := h.receiptsService.GetPending(ctx, query.From.ID, receiptID)
+	if err != nil {
+		h.answerReceiptError(query, err)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, category := range receipts.Categories {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(category, fmt.Sprintf("receipt_setcat:%d:%d", receiptID, i)))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	h.answerCallback(query.ID, "")
+	h.editReceiptMessage(query, receipts.Format(receipt)+"\n\nВыберите категорию:", &markup)
+}
+
+func (h *Handler) handleReceiptSetCategoryCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	idPart, indexPart, _ := strings.Cut(payload, ":")
+	receiptID, ok := h.parseReceiptID(query, idPart)
+	if !ok {
+		return
+	}
+	index, err := strconv.Atoi(indexPart)
+	if err != nil || index < 0 || index >= len(receipts.Categories) {
+		h.answerCallback(query.ID, "Некорректная категория")
+		return
+	}
+
+	receipt, err := h.receiptsService.SetCategory(ctx, query.From.ID, receiptID, receipts.Categories[index])
+	if err != nil {
+		h.answerReceiptError(query, err)
+		return
+	}
+
+	markup := receiptKeyboard(receipt.ID)
+	h.answerCallback(query.ID, "")
+	h.editReceiptMessage(query, receipts.Format(receipt)+"\n\nЗаписать в расходы?", &markup)
+}
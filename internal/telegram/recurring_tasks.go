@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/notifications"
+	"telegrambot/internal/okr"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const recurringReminderHint = "🔁 Если задачу на день не отметить до 20:00, я напомню с кнопкой «Сделано». Время меняется командой /recurring"
+
+func (h *Handler) SendRecurringTaskReminder(chatID int64, text string, taskID int64) error {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Сделано", fmt.Sprintf("rtask_done:%d", taskID)),
+			tgbotapi.NewInlineKeyboardButtonData("⏭ Пропустить", fmt.Sprintf("rtask_skip:%d", taskID)),
+		),
+	)
+
+	if err := h.sendFormatted(context.Background(), chatID, text, ParseModeHTML, keyboard); err != nil {
+		return fmt.Errorf("ошибка при отправке напоминания о задаче: %v", err)
+	}
+	h.recordNotification(context.Background(), chatID, notifications.KindReminder, text)
+	return nil
+}
+
+func (h *Handler) handleRecurringTaskCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string, done bool) {
+	userID := query.From.ID
+
+	taskID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	var result *okr.RecurringLogResult
+	if done {
+		result, err = h.okrService.LogRecurringInstance(ctx, userID, taskID)
+	} else {
+		result, err = h.okrService.SkipRecurringInstance(ctx, userID, taskID)
+	}
+	if err != nil {
+		logrus.Warnf("Не удалось отметить повторяющуюся задачу %d для пользователя %d: %v", taskID, userID, err)
+
+		text := "Задача не найдена"
+		if errors.Is(err, okr.ErrRecurringTaskLogged) {
+			text = "Задача на этот день уже отмечена"
+		}
+		h.answerCallback(query.ID, text)
+		h.removeInlineKeyboard(query)
+		return
+	}
+
+	if done {
+		h.answerCallback(query.ID, "Отмечено")
+	} else {
+		h.answerCallback(query.ID, "Пропущено")
+	}
+
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+okr.FormatRecurringLogResult(result))
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение с напоминанием о задаче: %v", err)
+		}
+	}
+}
+
+func (h *Handler) handleRecurringCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	settings, err := h.okrService.GetRecurringSettings(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении настроек повторяющихся задач пользователя %d: %v", userID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройки")
+		return
+	}
+
+	arg := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	switch arg {
+	case "":
+	case "off":
+		settings.Enabled = false
+	case "on":
+		settings.Enabled = true
+	default:
+		hour, err := strconv.Atoi(strings.TrimSuffix(arg, ":00"))
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /recurring 21 — напоминать в 21:00, /recurring off — выключить")
+			return
+		}
+		settings.ReminderHour = hour
+		settings.Enabled = true
+	}
+
+	if arg != "" {
+		if err := h.okrService.SetRecurringSettings(ctx, userID, settings.ReminderHour, settings.Enabled); err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+			return
+		}
+	}
+
+	status := fmt.Sprintf("в %02d:00, если задача на сегодня еще не отмечена", settings.ReminderHour)
+	if !settings.Enabled {
+		status = "выключены"
+	}
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf(
+		"🔁 Напоминания о повторяющихся задачах: %s\n\n"+
+			"/recurring 21 — напоминать в 21:00\n"+
+			"/recurring off — выключить, /recurring on — включить", status))
+}
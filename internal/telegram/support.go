@@ -0,0 +1,246 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/chatgpt"
+	"telegrambot/internal/monitoring"
+	"telegrambot/internal/support"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const supportBackMessage = "✅ Обращение #%d закрыто. Снова на связи Jarvis — можно продолжать как обычно"
+
+func (h *Handler) handleSupportCommand(ctx context.Context, message *tgbotapi.Message) {
+	if !h.supportService.Available() {
+		h.sendMessageCtx(ctx, message.Chat.ID, "😔 Сейчас поддержка недоступна. Попробуйте описать проблему Jarvis — он постарается помочь")
+		return
+	}
+
+	ticket, created, err := h.supportService.Open(ctx, message.From.ID, support.SourceCommand, message.CommandArguments())
+	if err != nil {
+		logrus.Errorf("Ошибка при создании обращения пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось создать обращение, попробуйте позже")
+		return
+	}
+
+	if !created {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("🎫 Обращение #%d уже открыто. Пишите сюда — сообщения передаются оператору", ticket.ID))
+		return
+	}
+
+	h.announceTicket(ctx, ticket, message.From)
+	h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("🎫 Обращение #%d создано. Оператор ответит здесь же; до закрытия обращения ваши сообщения уходят ему, а не Jarvis", ticket.ID))
+}
+
+func (h *Handler) handleEscalationReply(ctx context.Context, reply *chatgpt.Reply, from *tgbotapi.User) {
+	if reply.Function != chatgpt.EscalateToHumanFunction.Name {
+		return
+	}
+
+	ticket, err := h.supportService.GetOpenTicket(ctx, from.ID)
+	if err != nil {
+		if !errors.Is(err, support.ErrTicketNotFound) {
+			logrus.Errorf("Ошибка при получении обращения пользователя %d: %v", from.ID, err)
+		}
+		return
+	}
+	if ticket.Source == support.SourceAssistant {
+		h.announceTicket(ctx, ticket, from)
+	}
+}
+
+func (h *Handler) announceTicket(ctx context.Context, ticket *support.Ticket, from *tgbotapi.User) {
+	var text strings.Builder
+	fmt.Fprintf(&text, "🎫 Новое обращение #%d\n👤 %s\n", ticket.ID, supportUserName(from))
+	if ticket.Source == support.SourceAssistant {
+		text.WriteString("🤖 Передано Jarvis\n")
+	}
+	if ticket.Reason != nil {
+		fmt.Fprintf(&text, "\n%s\n", *ticket.Reason)
+	}
+	text.WriteString("\nОтветьте реплаем на сообщение пользователя, чтобы написать ему. /close реплаем или кнопкой — вернуть пользователя к Jarvis")
+
+	msg := tgbotapi.NewMessage(h.supportService.OperatorChatID(), text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ Закрыть #%d", ticket.ID), fmt.Sprintf("support_close:%d", ticket.ID)),
+		),
+	)
+	sent, err := h.bot.Send(msg)
+	monitoring.Observe(monitoring.EventTelegramSend, err)
+	if err != nil {
+		logrus.Errorf("Ошибка при отправке обращения #%d операторам: %v", ticket.ID, err)
+		return
+	}
+
+	reason := ""
+	if ticket.Reason != nil {
+		reason = *ticket.Reason
+	}
+	if err := h.supportService.RecordMessage(ctx, ticket.ID, support.DirectionUser, reason, sent.MessageID); err != nil {
+		logrus.Errorf("Ошибка при сохранении сообщения обращения #%d: %v", ticket.ID, err)
+	}
+}
+
+func (h *Handler) bridgeToSupport(ctx context.Context, message *tgbotapi.Message) bool {
+	if !h.supportService.Available() || message.Chat.ID == h.supportService.OperatorChatID() {
+		return false
+	}
+
+	ticket, err := h.supportService.GetOpenTicket(ctx, message.From.ID)
+	if errors.Is(err, support.ErrTicketNotFound) {
+		return false
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при получении обращения пользователя %d: %v", message.From.ID, err)
+		return false
+	}
+
+	var sent tgbotapi.Message
+	text := message.Text
+	if text != "" {
+		sent, err = h.bot.Send(tgbotapi.NewMessage(h.supportService.OperatorChatID(), fmt.Sprintf("🎫 #%d · %s\n\n%s", ticket.ID, supportUserName(message.From), text)))
+	} else {
+		text = "[вложение]"
+		if message.Caption != "" {
+			text = message.Caption
+		}
+		sent, err = h.bot.Send(tgbotapi.NewForward(h.supportService.OperatorChatID(), message.Chat.ID, message.MessageID))
+	}
+	monitoring.Observe(monitoring.EventTelegramSend, err)
+	if err != nil {
+		logrus.Errorf("Ошибка при передаче сообщения обращения #%d оператору: %v", ticket.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось передать сообщение оператору, попробуйте еще раз")
+		return true
+	}
+
+	if err := h.supportService.RecordMessage(ctx, ticket.ID, support.DirectionUser, text, sent.MessageID); err != nil {
+		logrus.Errorf("Ошибка при сохранении сообщения обращения #%d: %v", ticket.ID, err)
+	}
+	return true
+}
+
+func (h *Handler) handleOperatorMessage(ctx context.Context, message *tgbotapi.Message) bool {
+	if !h.supportService.Available() || message.Chat.ID != h.supportService.OperatorChatID() || message.From == nil {
+		return false
+	}
+
+	if message.Command() == "close" {
+		h.handleOperatorClose(ctx, message)
+		return true
+	}
+
+	if message.ReplyToMessage == nil || message.ReplyToMessage.From == nil || message.ReplyToMessage.From.ID != h.bot.Self.ID {
+		return false
+	}
+
+	ticket, err := h.supportService.FindByOperatorMessage(ctx, message.ReplyToMessage.MessageID)
+	if errors.Is(err, support.ErrTicketNotFound) {
+		return false
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при поиске обращения по сообщению %d: %v", message.ReplyToMessage.MessageID, err)
+		return true
+	}
+	if ticket.Status != support.StatusOpen {
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("ℹ️ Обращение #%d уже закрыто, сообщение не отправлено", ticket.ID))
+		return true
+	}
+
+	text := message.Text
+	if text != "" {
+		err = h.sendFormatted(ctx, ticket.UserID, "👤 Оператор поддержки:\n\n"+text, ParseModeNone, nil)
+	} else {
+		text = "[вложение]"
+		if message.Caption != "" {
+			text = message.Caption
+		}
+		_, err = h.bot.Request(tgbotapi.NewCopyMessage(ticket.UserID, message.Chat.ID, message.MessageID))
+		monitoring.Observe(monitoring.EventTelegramSend, err)
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при отправке ответа оператора по обращению #%d: %v", ticket.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, fmt.Sprintf("❌ Не удалось доставить ответ по обращению #%d", ticket.ID))
+		return true
+	}
+
+	if err := h.supportService.RecordMessage(ctx, ticket.ID, support.DirectionOperator, text, message.MessageID); err != nil {
+		logrus.Errorf("Ошибка при сохранении сообщения обращения #%d: %v", ticket.ID, err)
+	}
+	return true
+}
+
+func (h *Handler) handleOperatorClose(ctx context.Context, message *tgbotapi.Message) {
+	var ticketID int64
+	if args := strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "#"); args != "" {
+		id, err := strconv.ParseInt(args, 10, 64)
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /close <номер обращения> или /close реплаем на сообщение пользователя")
+			return
+		}
+		ticketID = id
+	} else if message.ReplyToMessage != nil {
+		ticket, err := h.supportService.FindByOperatorMessage(ctx, message.ReplyToMessage.MessageID)
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "ℹ️ Не нашел обращение для этого сообщения, укажите номер: /close <номер>")
+			return
+		}
+		ticketID = ticket.ID
+	} else {
+		h.sendMessageCtx(ctx, message.Chat.ID, "Использование: /close <номер обращения> или /close реплаем на сообщение пользователя")
+		return
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, h.closeTicket(ctx, ticketID, message.From))
+}
+
+func (h *Handler) handleSupportCloseCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	if query.Message == nil || query.Message.Chat.ID != h.supportService.OperatorChatID() {
+		h.answerCallback(query.ID, "")
+		return
+	}
+
+	ticketID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	result := h.closeTicket(ctx, ticketID, query.From)
+	h.answerCallback(query.ID, result)
+	h.removeInlineKeyboard(query)
+	h.sendMessageCtx(ctx, query.Message.Chat.ID, result)
+}
+
+func (h *Handler) closeTicket(ctx context.Context, ticketID int64, operator *tgbotapi.User) string {
+	ticket, err := h.supportService.Close(ctx, ticketID, operator.ID)
+	switch {
+	case errors.Is(err, support.ErrTicketNotFound):
+		return fmt.Sprintf("ℹ️ Обращение #%d не найдено", ticketID)
+	case errors.Is(err, support.ErrTicketClosed):
+		return fmt.Sprintf("ℹ️ Обращение #%d уже закрыто", ticketID)
+	case err != nil:
+		logrus.Errorf("Ошибка при закрытии обращения #%d: %v", ticketID, err)
+		return fmt.Sprintf("❌ Не удалось закрыть обращение #%d", ticketID)
+	}
+
+	if err := h.sendMessageCtx(ctx, ticket.UserID, fmt.Sprintf(supportBackMessage, ticket.ID)); err != nil {
+		logrus.Errorf("Ошибка при уведомлении пользователя %d о закрытии обращения #%d: %v", ticket.UserID, ticket.ID, err)
+	}
+	logrus.Infof("Обращение #%d пользователя %d закрыто оператором %d", ticket.ID, ticket.UserID, operator.ID)
+	return fmt.Sprintf("✅ Обращение #%d закрыто (%s), пользователь возвращен к Jarvis", ticket.ID, supportUserName(operator))
+}
+
+func supportUserName(user *tgbotapi.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if user.UserName != "" {
+		name += " @" + user.UserName
+	}
+	return fmt.Sprintf("%s (%d)", strings.TrimSpace(name), user.ID)
+}
@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"telegrambot/internal/okr"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const tasksPageSize = 10
+
+type tasksView struct {
+	Page	int
+	Scope	string
+	Days	int
+}
+
+func (v tasksView) callback(page int) string {
+	return fmt.Sprintf("task_list:%d:%s:%d", page, v.Scope, v.Days)
+}
+
+func parseTasksView(payload string) (tasksView, bool) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 {
+		return tasksView{}, false
+	}
+	page, err := strconv.Atoi(parts[0])
+	if err != nil || page < 0 {
+		return tasksView{}, false
+	}
+	days, err := strconv.Atoi(parts[2])
+	if err != nil || days < 0 {
+		return tasksView{}, false
+	}
+	return tasksView{Page: page, Scope: parts[1], Days: days}, true
+}
+
+func (h *Handler) sendTasksList(ctx context.Context, chatID, userID int64, args map[string]interface{}) error {
+	view := tasksView{Scope: "-"}
+	if days, ok := args["deadline_within_days"].(float64); ok && days >= 1 {
+		view.Days = int(days)
+	}
+	if offset, ok := args["offset"].(float64); ok && offset > 0 {
+		view.Page = int(offset) / tasksPageSize
+	}
+	if keyResultID, ok := args["key_result_id"].(float64); ok && keyResultID > 0 {
+		view.Scope = fmt.Sprintf("k%d", int64(keyResultID))
+	} else if objectiveID, _ := args["objective_id"].(string); objectiveID != "" {
+		view.Scope = "o" + objectiveID
+	} else if name, _ := args["sphere"].(string); name != "" {
+		sphere, err := h.okrService.FindSphere(ctx, userID, name)
+		if err != nil {
+			return err
+		}
+		view.Scope = "s" + sphere.Key
+	}
+
+	return h.sendTasksPage(ctx, chatID, userID, view)
+}
+
+func (h *Handler) handleTasksPageCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	view, ok := parseTasksView(payload)
+	if !ok || query.Message == nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.removeInlineKeyboard(query)
+	if err := h.sendTasksPage(ctx, query.Message.Chat.ID, query.From.ID, view); err != nil {
+		logrus.Errorf("Ошибка при получении списка задач пользователя %d: %v", query.From.ID, err)
+		h.sendMessageCtx(ctx, query.Message.Chat.ID, "❌ Не удалось загрузить задачи")
+	}
+}
+
+func (h *Handler) sendTasksPage(ctx context.Context, chatID, userID int64, view tasksView) error {
+	filter := okr.TaskFilter{DeadlineWithinDays: view.Days}
+	var filterLabel string
+	switch {
+	case strings.HasPrefix(view.Scope, "k"):
+		id, err := strconv.ParseInt(view.Scope[1:], 10, 64)
+		if err != nil {
+			return fmt.Errorf("некорректный ключевой результат %q", view.Scope)
+		}
+		filter.KeyResultID = id
+	case strings.HasPrefix(view.Scope, "o"):
+		filter.ObjectiveID = view.Scope[1:]
+	case strings.HasPrefix(view.Scope, "s"):
+		sphere, err := h.okrService.FindSphere(ctx, userID, view.Scope[1:])
+		if err != nil {
+			return err
+		}
+		filter.Sphere = sphere.Name
+		filterLabel += fmt.Sprintf(" · %s %s", sphere.Icon, sphere.Name)
+	}
+	if view.Days > 0 {
+		filterLabel += fmt.Sprintf(" · дедлайн в ближайшие %d дн.", view.Days)
+	}
+
+	offset := view.Page * tasksPageSize
+	tasks, total, err := h.okrService.ListTaskSummaries(ctx, userID, filter, tasksPageSize, offset)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if view.Page == 0 {
+		b.WriteString(fmt.Sprintf("📋 Твои задачи%s — %d\n\n", filterLabel, total))
+	} else {
+		b.WriteString(fmt.Sprintf("📋 Задачи %d–%d из %d\n\n", offset+1, offset+len(tasks), total))
+	}
+	if len(tasks) == 0 {
+		if total > 0 {
+			b.WriteString("Больше задач нет")
+		} else if filterLabel != "" || filter.KeyResultID > 0 || filter.ObjectiveID != "" {
+			b.WriteString("Под этот фильтр задач нет")
+		} else {
+			b.WriteString("Задач пока нет. Разбей ключевые результаты на задачи — и они появятся здесь")
+		}
+	}
+
+	for i, task := range tasks {
+		deadline := "без дедлайна"
+		if task.Deadline != nil {
+			deadline = task.Deadline.Format("02.01.2006")
+		}
+		b.WriteString(fmt.Sprintf("%d. %s %s\n", offset+i+1, taskStatusEmoji(task.Status), task.Title))
+		b.WriteString(fmt.Sprintf("   📊 %s/%s %s • 📅 %s\n", formatKeyResultAmount(task.Progress), formatKeyResultAmount(task.Target), task.Unit, deadline))
+		if filter.KeyResultID == 0 {
+			b.WriteString(fmt.Sprintf("   🎯 %s → 🔑 %s\n", task.ObjectiveTitle, task.KeyResultTitle))
+		}
+	}
+
+	var markup interface{}
+	if remaining := total - offset - len(tasks); remaining > 0 && len(tasks) > 0 {
+		markup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Показать ещё (%d)", remaining), view.callback(view.Page+1)),
+		))
+	}
+	if err := h.sendFormatted(ctx, chatID, strings.TrimRight(b.String(), "\n"), ParseModeHTML, markup); err != nil {
+		return fmt.Errorf("ошибка при отправке списка задач: %v", err)
+	}
+	return nil
+}
+
+func taskStatusEmoji(status string) string {
+	switch status {
+	case "completed":
+		return "✅"
+	case "paused":
+		return "⏸️"
+	case "active":
+		return "🔄"
+	default:
+		return "📋"
+	}
+}
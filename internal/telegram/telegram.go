@@ -9,21 +9,45 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"telegrambot/internal/admin"
 	"telegrambot/internal/calendar"
+	"telegrambot/internal/challenges"
 	"telegrambot/internal/chatgpt"
+	"telegrambot/internal/dashboard"
+	"telegrambot/internal/dates"
 	"telegrambot/internal/finance"
+	"telegrambot/internal/finance/receipts"
+	"telegrambot/internal/focus"
+	"telegrambot/internal/habits"
+	"telegrambot/internal/health"
+	"telegrambot/internal/inbox"
+	"telegrambot/internal/journal"
 	"telegrambot/internal/linking"
+	"telegrambot/internal/maintenance"
 	"telegrambot/internal/meetings"
 	"telegrambot/internal/messagestore"
 	"telegrambot/internal/messagestore/models"
+	"telegrambot/internal/monitoring"
+	"telegrambot/internal/notifications"
 	"telegrambot/internal/okr"
+	"telegrambot/internal/outbox"
+	"telegrambot/internal/payments"
+	"telegrambot/internal/preferences"
+	"telegrambot/internal/rollout"
+	"telegrambot/internal/support"
+	"telegrambot/internal/transcription"
 	"telegrambot/internal/users"
 	"telegrambot/pkg/config"
+	"telegrambot/pkg/tracing"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Handler struct {
@@ -33,11 +57,30 @@ type Handler struct {
 	meetingsService		*meetings.Service
 	financeService		*finance.Service
 	okrService		*okr.Service
+	datesService		*dates.Service
+	healthService		*health.Service
+	habitsService		*habits.Service
 	messageStoreService	*messagestore.Service
 	userService		*users.Service
 	linkingService		*linking.Service
+	adminService		*admin.Service
+	paymentsService		*payments.Service
+	challengesService	*challenges.Service
+	rolloutService		*rollout.Service
+	dashboardService	*dashboard.Service
+	inboxService		*inbox.Service
+	preferencesService	*preferences.Service
+	receiptsService		*receipts.Service
+	notificationsService	*notifications.Service
+	outboxService		*outbox.Service
+	maintenanceService	*maintenance.Service
+	supportService		*support.Service
+	journalService		*journal.Service
+	focusService		*focus.Service
+	transcriptionService	*transcription.Service
 	cfg			*config.Config
 	db			*sqlx.DB
+	updates			*updateCache
 }
 
 func NewHandler(
@@ -47,9 +90,27 @@ func NewHandler(
 	meetingsService *meetings.Service,
 	financeService *finance.Service,
 	okrService *okr.Service,
+	datesService *dates.Service,
+	healthService *health.Service,
+	habitsService *habits.Service,
 	messageStoreService *messagestore.Service,
 	usrService *users.Service,
 	lnkService *linking.Service,
+	adminService *admin.Service,
+	paymentsService *payments.Service,
+	challengesService *challenges.Service,
+	rolloutService *rollout.Service,
+	dashboardService *dashboard.Service,
+	inboxService *inbox.Service,
+	preferencesService *preferences.Service,
+	receiptsService *receipts.Service,
+	notificationsService *notifications.Service,
+	outboxService *outbox.Service,
+	maintenanceService *maintenance.Service,
+	supportService *support.Service,
+	journalService *journal.Service,
+	focusService *focus.Service,
+	transcriptionService *transcription.Service,
 	db *sqlx.DB,
 ) (*Handler, error) {
 	bot, err := tgbotapi.NewBotAPI(cfg.TelegramToken)
@@ -66,11 +127,30 @@ func NewHandler(
 		meetingsService:	meetingsService,
 		financeService:		financeService,
 		okrService:		okrService,
+		datesService:		datesService,
+		healthService:		healthService,
+		habitsService:		habitsService,
 		messageStoreService:	messageStoreService,
 		userService:		usrService,
 		linkingService:		lnkService,
+		adminService:		adminService,
+		paymentsService:	paymentsService,
+		challengesService:	challengesService,
+		rolloutService:		rolloutService,
+		dashboardService:	dashboardService,
+		inboxService:		inboxService,
+		preferencesService:	preferencesService,
+		receiptsService:	receiptsService,
+		notificationsService:	notificationsService,
+		outboxService:		outboxService,
+		maintenanceService:	maintenanceService,
+		supportService:		supportService,
+		journalService:		journalService,
+		focusService:		focusService,
+		transcriptionService:	transcriptionService,
 		cfg:			cfg,
 		db:			db,
+		updates:		newUpdateCache(updateCacheTTL),
 	}, nil
 }
 
@@ -92,29 +172,127 @@ func (h *Handler) SetupWebhook() error {
 func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	update, err := h.bot.HandleUpdate(r)
 	if err != nil {
+		monitoring.Observe(monitoring.EventTelegramWebhook, err)
 		logrus.Errorf("Ошибка при обработке обновления: %v", err)
 		return
 	}
 
-	h.handleUpdate(*update)
+	if h.updates.Seen(update.UpdateID) {
+		logrus.Infof("Повторная доставка обновления %d, пропускаем", update.UpdateID)
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracing.Start(ctx, "telegram.update", attribute.Int("telegram.update_id", update.UpdateID))
+	defer span.End()
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			monitoring.Observe(monitoring.EventTelegramWebhook, fmt.Errorf("паника: %v", rec))
+			logrus.Errorf("Паника при обработке обновления %d: %v", update.UpdateID, rec)
+			return
+		}
+		monitoring.Observe(monitoring.EventTelegramWebhook, nil)
+	}()
+
+	h.handleUpdate(ctx, *update)
 }
 
 func (h *Handler) SendMessage(chatID int64, text string) error {
-	msg := tgbotapi.NewMessage(chatID, text)
-	_, err := h.bot.Send(msg)
-	if err != nil {
-		return fmt.Errorf("ошибка при отправке сообщения: %v", err)
+	return h.outboxService.Enqueue(context.Background(), chatID, ParseModeHTML, splitMessage(text, ParseModeHTML)...)
+}
+
+func (h *Handler) SendMessageWithMode(chatID int64, text string, parseMode string) error {
+	return h.sendFormatted(context.Background(), chatID, text, parseMode, nil)
+}
+
+func (h *Handler) DeliverOutbound(ctx context.Context, chatID int64, text, parseMode string) error {
+	return h.sendChunk(ctx, chatID, text, parseMode, nil)
+}
+
+func (h *Handler) sendMessageCtx(ctx context.Context, chatID int64, text string) error {
+	return h.sendFormatted(ctx, chatID, text, ParseModeHTML, nil)
+}
+
+func (h *Handler) sendFormatted(ctx context.Context, chatID int64, text string, parseMode string, replyMarkup interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "telegram.send_message", attribute.Int64("telegram.chat_id", chatID))
+	defer func() { tracing.End(span, err) }()
+
+	chunks := splitMessage(text, parseMode)
+	span.SetAttributes(attribute.Int("telegram.chunks", len(chunks)))
+
+	for i, chunk := range chunks {
+		var markup interface{}
+		if i == len(chunks)-1 {
+			markup = replyMarkup
+		}
+
+		err = h.sendChunk(ctx, chatID, chunk, parseMode, markup)
+		if err == nil {
+			continue
+		}
+		if replyMarkup == nil && outbox.IsRetryable(err) {
+			logrus.Warnf("Не удалось отправить сообщение в чат %d, ставим в очередь: %v", chatID, err)
+			qErr := h.outboxService.Enqueue(ctx, chatID, parseMode, chunks[i:]...)
+			if qErr == nil {
+				return nil
+			}
+			logrus.Errorf("Ошибка при постановке сообщения в очередь: %v", qErr)
+		}
+		return fmt.Errorf("ошибка при отправке сообщения: %w", err)
 	}
 	return nil
 }
 
-func (h *Handler) handleUpdate(update tgbotapi.Update) {
-	ctx := context.Background()
+func (h *Handler) sendChunk(ctx context.Context, chatID int64, chunk, parseMode string, replyMarkup interface{}) error {
+	msg := tgbotapi.NewMessage(chatID, formatMessage(chunk, parseMode))
+	msg.ParseMode = parseMode
+	if replyMarkup != nil {
+		msg.ReplyMarkup = replyMarkup
+	}
+
+	_, err := h.bot.Send(msg)
+	if isParseError(err) {
+		logrus.Warnf("Telegram не принял разметку сообщения для чата %d, отправляем без форматирования: %v", chatID, err)
+		msg.Text = chunk
+		msg.ParseMode = ParseModeNone
+		_, err = h.bot.Send(msg)
+	}
+	monitoring.Observe(monitoring.EventTelegramSend, err)
+	return err
+}
+
+func (h *Handler) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	if h.handleMaintenance(ctx, update) {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("telegram.maintenance", true))
+		return
+	}
+
+	if update.CallbackQuery != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("telegram.update_type", "callback_query"))
+		h.handleCallbackQuery(ctx, update.CallbackQuery)
+		return
+	}
+
+	if update.PreCheckoutQuery != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("telegram.update_type", "pre_checkout_query"))
+		h.handlePreCheckoutQuery(ctx, update.PreCheckoutQuery)
+		return
+	}
 
 	if update.Message == nil {
 		return
 	}
 
+	if h.handleOperatorMessage(ctx, update.Message) {
+		return
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("telegram.update_type", "message"),
+		attribute.Int64("telegram.user_id", update.Message.From.ID),
+	)
+
 	err := h.meetingsService.StoreUser(ctx, update.Message.From.ID, update.Message.From.UserName, update.Message.From.FirstName)
 	if err != nil {
 		logrus.Errorf("Ошибка при сохранении пользователя: %v", err)
@@ -129,29 +307,138 @@ func (h *Handler) handleUpdate(update tgbotapi.Update) {
 		}
 	}
 
-	query := `SELECT role FROM users WHERE id = $1`
-	var role string
-	err = h.db.GetContext(ctx, &role, query, update.Message.From.ID)
+	if update.Message.SuccessfulPayment != nil {
+		h.handleSuccessfulPayment(ctx, update.Message)
+		return
+	}
+
+	if update.Message.Command() == "subscribe" {
+		h.handleSubscribeCommand(ctx, update.Message)
+		return
+	}
+
+	isAdmin := h.adminService.IsAdmin(update.Message.From.ID)
+	if isAdmin && h.handleAdminCommand(ctx, update.Message) {
+		return
+	}
+
+	query := `SELECT role, is_blocked FROM users WHERE id = $1`
+	var access struct {
+		Role		string	`db:"role"`
+		IsBlocked	bool	`db:"is_blocked"`
+	}
+	err = h.db.GetContext(ctx, &access, query, update.Message.From.ID)
 	if err != nil {
 		logrus.Errorf("Ошибка при получении роли пользователя: %v", err)
-		role = "free"
+		access.Role = admin.RoleFree
+	}
+
+	if access.IsBlocked && !isAdmin {
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "⛔ Ваш доступ к боту заблокирован")
+		return
 	}
 
-	if role == "free" {
-		h.SendMessage(update.Message.Chat.ID, "У вас нет подписки")
+	if update.Message.Command() == "support" {
+		h.handleSupportCommand(ctx, update.Message)
 		return
 	}
 
+	if h.bridgeToSupport(ctx, update.Message) {
+		return
+	}
+
+	if access.Role == admin.RoleFree && !isAdmin && !h.allowFreeTier(ctx, update.Message) {
+		return
+	}
+
+	stopTyping := h.startTyping(ctx, update.Message.Chat.ID)
+	defer stopTyping()
+
 	if update.Message.Voice != nil || update.Message.Audio != nil {
 		h.handleAudioMessage(ctx, update)
 		return
 	}
 
+	if len(update.Message.Photo) > 0 {
+		h.handlePhotoMessage(ctx, update)
+		return
+	}
+
+	if update.Message.Document != nil {
+		h.handleDocumentMessage(ctx, update)
+		return
+	}
+
 	if update.Message.Command() == "google_auth" {
 		h.handleGoogleAuth(ctx, update)
 		return
 	}
 
+	switch update.Message.Command() {
+	case "habits":
+		h.handleHabitsCommand(ctx, update.Message)
+		return
+	case "habits_off":
+		h.handleHabitsOffCommand(ctx, update.Message)
+		return
+	case "archive":
+		h.handleArchiveCommand(ctx, update.Message)
+		return
+	case "dashboard":
+		h.handleDashboardCommand(ctx, update.Message)
+		return
+	case "report":
+		h.handleReportCommand(ctx, update.Message)
+		return
+	case "capture":
+		h.handleCaptureCommand(ctx, update.Message)
+		return
+	case "inbox":
+		h.handleInboxCommand(ctx, update.Message)
+		return
+	case "proactivity":
+		h.handleProactivityCommand(ctx, update.Message)
+		return
+	case "brief":
+		h.handleVerbosityCommand(ctx, update.Message)
+		return
+	case "dnd":
+		h.handleDNDCommand(ctx, update.Message)
+		return
+	case "notifications":
+		h.handleNotificationsCommand(ctx, update.Message)
+		return
+	case "calendar_sync", "status":
+		h.handleCalendarSyncCommand(ctx, update.Message)
+		return
+	case "calendar_feed":
+		h.handleCalendarFeedCommand(ctx, update.Message)
+		return
+	case "recurring":
+		h.handleRecurringCommand(ctx, update.Message)
+		return
+	case "deadlines":
+		h.handleDeadlinesCommand(ctx, update.Message)
+		return
+	case "journal":
+		h.handleJournalCommand(ctx, update.Message)
+		return
+	case "focus":
+		h.handleFocusCommand(ctx, update.Message)
+		return
+	case "encrypt":
+		h.handleEncryptCommand(ctx, update.Message)
+		return
+	}
+
+	if h.handleJournalReply(ctx, update.Message) {
+		return
+	}
+
+	if h.handleHabitButton(ctx, update.Message) {
+		return
+	}
+
 	if update.Message.Text != "" {
 		h.handleTextMessage(ctx, update)
 		return
@@ -159,24 +446,30 @@ func (h *Handler) handleUpdate(update tgbotapi.Update) {
 }
 
 func (h *Handler) handleAudioMessage(ctx context.Context, update tgbotapi.Update) {
-	var fileID string
+	var fileID, mimeType string
+	var fileSize int64
 	if update.Message.Voice != nil {
-		fileID = update.Message.Voice.FileID
+		fileID, fileSize, mimeType = update.Message.Voice.FileID, int64(update.Message.Voice.FileSize), update.Message.Voice.MimeType
 	} else if update.Message.Audio != nil {
-		fileID = update.Message.Audio.FileID
+		fileID, fileSize, mimeType = update.Message.Audio.FileID, int64(update.Message.Audio.FileSize), update.Message.Audio.MimeType
+	}
+
+	if transcription.NeedsAsync(fileSize) {
+		h.enqueueTranscription(ctx, update.Message, fileID, fileSize, mimeType)
+		return
 	}
 
 	fileURL, err := h.bot.GetFileDirectURL(fileID)
 	if err != nil {
 		logrus.Errorf("Ошибка при получении URL файла: %v", err)
-		h.SendMessage(update.Message.Chat.ID, "Не удалось получить аудио файл")
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "Не удалось получить аудио файл")
 		return
 	}
 
 	resp, err := http.Get(fileURL)
 	if err != nil {
 		logrus.Errorf("Ошибка при загрузке файла: %v", err)
-		h.SendMessage(update.Message.Chat.ID, "Не удалось загрузить аудио файл")
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "Не удалось загрузить аудио файл")
 		return
 	}
 	defer resp.Body.Close()
@@ -184,11 +477,15 @@ func (h *Handler) handleAudioMessage(ctx context.Context, update tgbotapi.Update
 	audioData, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logrus.Errorf("Ошибка при чтении аудио данных: %v", err)
-		h.SendMessage(update.Message.Chat.ID, "Не удалось прочитать аудио файл")
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "Не удалось прочитать аудио файл")
+		return
+	}
+
+	if update.Message.Voice != nil && h.captureVoiceNote(ctx, update.Message, audioData) {
 		return
 	}
 
-	h.SendMessage(update.Message.Chat.ID, "🎧 Обрабатываю ваше аудио сообщение через Jarvis...")
+	h.sendMessageCtx(ctx, update.Message.Chat.ID, "🎧 Обрабатываю ваше аудио сообщение через Jarvis...")
 
 	userID := fmt.Sprintf("%d", update.Message.From.ID)
 	history, err := h.messageStoreService.GetMessageHistory(ctx, userID)
@@ -198,10 +495,10 @@ func (h *Handler) handleAudioMessage(ctx context.Context, update tgbotapi.Update
 	}
 
 	userIDInt64 := update.Message.From.ID
-	response, err := h.chatgptService.ProcessAudioMessage(ctx, userIDInt64, audioData, history)
+	reply, err := h.chatgptService.ProcessAudioMessage(ctx, userIDInt64, audioData, history)
 	if err != nil {
 		logrus.Errorf("Ошибка при обработке аудио через Jarvis: %v", err)
-		h.SendMessage(update.Message.Chat.ID, "Произошла ошибка при обработке аудио")
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "Произошла ошибка при обработке аудио")
 		return
 	}
 
@@ -210,13 +507,92 @@ func (h *Handler) handleAudioMessage(ctx context.Context, update tgbotapi.Update
 		logrus.Errorf("Ошибка при сохранении сообщения пользователя: %v", err)
 	}
 
-	var promptTokens, completionTokens *int
-	err = h.messageStoreService.StoreAiResponse(ctx, messageID, response, promptTokens, completionTokens)
+	h.storeAndSendReply(ctx, update.Message.Chat.ID, update.Message.From.ID, messageID, reply)
+	h.handleEscalationReply(ctx, reply, update.Message.From)
+}
+
+func (h *Handler) handlePhotoMessage(ctx context.Context, update tgbotapi.Update) {
+	photo := update.Message.Photo[len(update.Message.Photo)-1]
+	h.handleImage(ctx, update.Message, photo.FileID)
+}
+
+func (h *Handler) handleDocumentMessage(ctx context.Context, update tgbotapi.Update) {
+	document := update.Message.Document
+	if document.MimeType == "application/pdf" {
+		h.handleReceiptPDF(ctx, update.Message)
+		return
+	}
+	if !strings.HasPrefix(document.MimeType, "image/") {
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "📄 Я понимаю изображения (JPG, PNG) и PDF-чеки. Пришлите фото или скриншот документа")
+		return
+	}
+	h.handleImage(ctx, update.Message, document.FileID)
+}
+
+func (h *Handler) handleImage(ctx context.Context, message *tgbotapi.Message, fileID string) {
+	imageData, err := h.downloadFile(fileID)
 	if err != nil {
-		logrus.Errorf("Ошибка при сохранении ответа ИИ: %v", err)
+		logrus.Errorf("Ошибка при загрузке изображения: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "Не удалось загрузить изображение")
+		return
+	}
+
+	h.sendMessageCtx(ctx, message.Chat.ID, "🔍 Смотрю, что на изображении...")
+
+	analysis, err := h.chatgptService.AnalyzeImage(ctx, imageData, message.Caption)
+	if err != nil {
+		logrus.Errorf("Ошибка при распознавании изображения: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "Не удалось распознать изображение")
+		return
+	}
+	if analysis.Kind == chatgpt.ImageKindReceipt && analysis.Receipt != nil && analysis.Receipt.Total > 0 {
+		h.offerReceipt(ctx, message.Chat.ID, message.From.ID, *analysis.Receipt, receipts.SourcePhoto)
+		return
+	}
+
+	userID := fmt.Sprintf("%d", message.From.ID)
+	history, err := h.messageStoreService.GetMessageHistory(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении истории сообщений: %v", err)
+		history = []models.MessageHistoryItem{}
+	}
+
+	reply, err := h.chatgptService.ProcessImage(ctx, message.From.ID, imageData, message.Caption, analysis, history)
+	if err != nil {
+		logrus.Errorf("Ошибка при распознавании изображения: %v", err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "Не удалось распознать изображение")
+		return
+	}
+
+	stored := "[Изображение]"
+	if message.Caption != "" {
+		stored += " " + message.Caption
+	}
+	messageID, err := h.messageStoreService.StoreUserMessage(ctx, userID, stored, "telegram")
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении сообщения пользователя: %v", err)
 	}
 
-	h.SendMessage(update.Message.Chat.ID, response)
+	h.storeAndSendReply(ctx, message.Chat.ID, message.From.ID, messageID, reply)
+}
+
+func (h *Handler) downloadFile(fileID string) ([]byte, error) {
+	fileURL, err := h.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении URL файла: %v", err)
+	}
+
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при загрузке файла: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении файла: %v", err)
+	}
+	return data, nil
 }
 
 func (h *Handler) handleTextMessage(ctx context.Context, update tgbotapi.Update) {
@@ -233,21 +609,80 @@ func (h *Handler) handleTextMessage(ctx context.Context, update tgbotapi.Update)
 		history = []models.MessageHistoryItem{}
 	}
 
+	text := update.Message.Text
+	if update.Message.ForwardDate != 0 {
+		text = "Пересланное сообщение:\n" + text
+	}
+
 	userIDInt64 := update.Message.From.ID
-	response, err := h.chatgptService.ProcessMessage(ctx, userIDInt64, update.Message.Text, history)
+	reply, err := h.chatgptService.ProcessMessage(ctx, userIDInt64, text, history)
 	if err != nil {
 		logrus.Errorf("Ошибка при обработке текста через Jarvis: %v", err)
-		h.SendMessage(update.Message.Chat.ID, "Произошла ошибка при обработке сообщения")
+		h.sendMessageCtx(ctx, update.Message.Chat.ID, "Произошла ошибка при обработке сообщения")
 		return
 	}
 
+	h.storeAndSendReply(ctx, update.Message.Chat.ID, update.Message.From.ID, messageID, reply)
+}
+
+func (h *Handler) storeAndSendReply(ctx context.Context, chatID, userID int64, userMessageID int, reply *chatgpt.Reply) {
+	if len(reply.Choices) > 0 {
+		h.sendReplyChoices(ctx, chatID, userMessageID, reply)
+		return
+	}
+
+	var sendInteractive func(ctx context.Context, chatID, userID int64, args map[string]interface{}) error
+	switch reply.Function {
+	case chatgpt.GetObjectivesFunction.Name:
+		sendInteractive = h.sendObjectivesList
+	case chatgpt.GetObjectiveDetailsFunction.Name:
+		sendInteractive = h.sendObjectiveCard
+	case chatgpt.GetTasksFunction.Name:
+		sendInteractive = h.sendTasksList
+	}
+	if sendInteractive != nil {
+		if userMessageID != 0 {
+			if _, err := h.messageStoreService.StoreAiResponse(ctx, userMessageID, reply.Text, reply.Function, nil, nil); err != nil {
+				logrus.Errorf("Ошибка при сохранении ответа ИИ: %v", err)
+			}
+		}
+		err := sendInteractive(ctx, chatID, userID, reply.Arguments)
+		if err == nil {
+			return
+		}
+		logrus.Errorf("Ошибка при отправке интерактивного ответа %s, отправляем обычный ответ: %v", reply.Function, err)
+		h.sendMessageCtx(ctx, chatID, reply.Text)
+		return
+	}
+
+	if userMessageID == 0 {
+		h.sendMessageCtx(ctx, chatID, reply.Text)
+		return
+	}
+
+	var extraRows [][]tgbotapi.InlineKeyboardButton
+	switch reply.Function {
+	case chatgpt.DeleteObjectiveFunction.Name, chatgpt.DeleteKeyResultFunction.Name, chatgpt.DeleteTaskFunction.Name:
+		if row := h.undoDeletionRow(ctx, userID); row != nil {
+			extraRows = append(extraRows, row)
+		}
+	case chatgpt.GenerateWeeklyPlanFunction.Name:
+		if row := h.weeklyPlanRow(ctx, userID, reply.Arguments); row != nil {
+			extraRows = append(extraRows, row)
+		}
+	}
+
 	var promptTokens, completionTokens *int
-	err = h.messageStoreService.StoreAiResponse(ctx, messageID, response, promptTokens, completionTokens)
+	responseID, err := h.messageStoreService.StoreAiResponse(ctx, userMessageID, reply.Text, reply.Function, promptTokens, completionTokens)
 	if err != nil {
 		logrus.Errorf("Ошибка при сохранении ответа ИИ: %v", err)
+		h.sendMessageCtx(ctx, chatID, reply.Text)
+		return
 	}
 
-	h.SendMessage(update.Message.Chat.ID, response)
+	if err := h.sendRatedReply(ctx, chatID, reply.Text, responseID, extraRows...); err != nil {
+		logrus.Errorf("Ошибка при отправке ответа %d: %v", responseID, err)
+	}
 }
 
 func (h *Handler) handleFunctionCall(ctx context.Context, chatID int64, userID int64, functionCall *chatgpt.FunctionCall) string {
@@ -287,8 +722,19 @@ func (h *Handler) handleFunctionCall(ctx context.Context, chatID int64, userID i
 			}
 		}
 
-		eventID, err := h.calendarService.CreateEvent(ctx, userID, title, description, startTimeStr, endTimeStr)
-		if err != nil {
+		allowOverlap, _ := functionCall.Arguments["allow_overlap"].(bool)
+
+		var eventID string
+		var err error
+		if allowOverlap {
+			eventID, err = h.calendarService.CreateEvent(ctx, userID, title, description, startTimeStr, endTimeStr)
+		} else {
+			eventID, err = h.calendarService.CreateEventIfFree(ctx, userID, title, description, startTimeStr, endTimeStr)
+		}
+		var conflictErr *calendar.ConflictError
+		if errors.As(err, &conflictErr) {
+			response = h.formatScheduleConflict(ctx, userID, conflictErr, startTimeStr, endTimeStr)
+		} else if err != nil {
 			logrus.Errorf("Ошибка при создании события: %v", err)
 			response = "Не удалось создать событие в календаре"
 		} else {
@@ -301,11 +747,19 @@ func (h *Handler) handleFunctionCall(ctx context.Context, chatID int64, userID i
 		description, _ := functionCall.Arguments["description"].(string)
 		startTime, _ := functionCall.Arguments["start_time"].(string)
 		endTime, _ := functionCall.Arguments["end_time"].(string)
+		inPerson, _ := functionCall.Arguments["in_person"].(bool)
 
-		meetingID, err := h.meetingsService.CreateMeeting(ctx, userID, participantUsername, title, description, startTime, endTime)
-		if err != nil {
+		allowOverlap, _ := functionCall.Arguments["allow_overlap"].(bool)
+
+		meetingID, err := h.meetingsService.CreateMeeting(ctx, userID, participantUsername, title, description, startTime, endTime, inPerson, allowOverlap)
+		var conflictErr *calendar.ConflictError
+		if errors.As(err, &conflictErr) {
+			response = h.formatScheduleConflict(ctx, userID, conflictErr, startTime, endTime)
+		} else if errors.Is(err, meetings.ErrParticipantBusy) {
+			response = fmt.Sprintf("%v — предложить другое время или отправить приглашение поверх?", err)
+		} else if err != nil {
 			logrus.Errorf("Ошибка при создании встречи: %v", err)
-			response = "Не удалось создать встречу"
+			response = fmt.Sprintf("Не удалось создать встречу: %v", err)
 		} else {
 			response = fmt.Sprintf("Запрос на встречу '%s' с пользователем @%s успешно отправлен (ID: %s)", title, participantUsername, meetingID)
 		}
@@ -1540,6 +1994,7 @@ func (h *Handler) handleFunctionCall(ctx context.Context, chatID int64, userID i
 		response = fmt.Sprintf("Успешно создано %d ежедневных задач '%s' с целью %.1f %s в день для ключевого результата '%s'. Период: с %s по %s",
 			len(taskIDs), title, target, unit, keyResultTitle,
 			startDate.Format("02.01.2006"), endDate.Format("02.01.2006"))
+		response += "\n\n" + recurringReminderHint
 
 	case "create_objective_with_recurring_tasks":
 		objectiveTitle, _ := functionCall.Arguments["objective_title"].(string)
@@ -1598,6 +2053,7 @@ func (h *Handler) handleFunctionCall(ctx context.Context, chatID int64, userID i
 			objectiveTitle, keyResultTitle, len(taskIDs), taskTitle,
 			startDate.Format("02.01.2006"), endDate.Format("02.01.2006"),
 			dailyTarget, taskUnit, objectiveID, keyResultID)
+		response += "\n\n" + recurringReminderHint
 
 	default:
 		response = "Неизвестная функция"
@@ -1660,12 +2116,12 @@ func (h *Handler) handleGoogleAuth(ctx context.Context, update tgbotapi.Update)
 
 	authURL, err := h.calendarService.GetGoogleAuthURL(userID, "telegram")
 	if err != nil {
-		h.SendMessage(chatID, "Не удалось получить ссылку для авторизации Google Calendar")
+		h.sendMessageCtx(ctx, chatID, "Не удалось получить ссылку для авторизации Google Calendar")
 		return
 	}
 
 	msg := fmt.Sprintf("Для подключения Google Calendar перейдите по ссылке:\n%s", authURL)
-	h.SendMessage(chatID, msg)
+	h.sendMessageCtx(ctx, chatID, msg)
 }
 
 func (h *Handler) handleLinkTokenStart(ctx context.Context, chatID int64, telegramUserID int64, token string) {
@@ -1682,7 +2138,7 @@ func (h *Handler) handleLinkTokenStart(ctx context.Context, chatID int64, telegr
 		default:
 			errMsg = "Не удалось обработать ссылку для привязки. Попробуйте позже."
 		}
-		h.SendMessage(chatID, errMsg)
+		h.sendMessageCtx(ctx, chatID, errMsg)
 		return
 	}
 
@@ -1700,7 +2156,7 @@ func (h *Handler) handleLinkTokenStart(ctx context.Context, chatID int64, telegr
 		default:
 			errMsg = "Произошла ошибка при привязке вашего Telegram-аккаунта. Попробуйте позже."
 		}
-		h.SendMessage(chatID, errMsg)
+		h.sendMessageCtx(ctx, chatID, errMsg)
 		return
 	}
 
@@ -1714,7 +2170,7 @@ func (h *Handler) handleLinkTokenStart(ctx context.Context, chatID int64, telegr
 			logrus.Warnf("Не удалось получить детали web_user %d после привязки: %v", webUserID, err)
 		}
 	}
-	h.SendMessage(chatID, successMsg)
+	h.sendMessageCtx(ctx, chatID, successMsg)
 	logrus.Infof("Telegram аккаунт %d успешно привязан к web_user %d (токен: %s)", telegramUserID, webUserID, token)
 }
 
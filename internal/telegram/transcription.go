@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"telegrambot/internal/messagestore/models"
+	"telegrambot/internal/transcription"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) enqueueTranscription(ctx context.Context, message *tgbotapi.Message, fileID string, fileSize int64, mimeType string) {
+	job, err := h.transcriptionService.Enqueue(ctx, message.From.ID, message.Chat.ID, fileID, fileSize, mimeType)
+	if err != nil {
+		logrus.Errorf("Ошибка при постановке аудио пользователя %d в очередь: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "Не удалось принять аудио файл, попробуйте еще раз")
+		return
+	}
+
+	text := fmt.Sprintf("🎧 Получил аудио (%.1f МБ). Расшифровка займет несколько минут — пришлю текст и выполню поручения, когда будет готово", float64(fileSize)/(1<<20))
+	if !transcription.NeedsProgress(fileSize) {
+		h.sendMessageCtx(ctx, message.Chat.ID, text)
+		return
+	}
+
+	sent, err := h.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text+"\n\n⏳ Скачиваю файл..."))
+	if err != nil {
+		logrus.Warnf("Не удалось отправить сообщение о прогрессе расшифровки %d: %v", job.ID, err)
+		return
+	}
+	if err := h.transcriptionService.SetProgressMessage(ctx, job.ID, sent.MessageID); err != nil {
+		logrus.Warnf("Задача расшифровки %d: %v", job.ID, err)
+	}
+}
+
+func (h *Handler) ProcessTranscriptionJob(ctx context.Context, job transcription.Job) (err error) {
+	defer func() {
+		if err != nil && job.FinalAttempt() {
+			h.updateTranscriptionProgress(job, "❌ Не удалось расшифровать аудио")
+			h.sendMessageCtx(ctx, job.ChatID, "❌ Не удалось расшифровать аудио. Попробуйте отправить запись еще раз или разбить ее на части")
+		}
+	}()
+
+	audioData, err := h.downloadFile(job.FileID)
+	if err != nil {
+		return err
+	}
+
+	extension := transcription.FileExtension(audioData, job.MimeType)
+	chunks := transcription.Split(audioData, transcription.ChunkBytes)
+	h.updateTranscriptionProgress(job, fmt.Sprintf("⏳ Расшифровываю аудио: часть 1 из %d", len(chunks)))
+
+	parts := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		text, err := h.chatgptService.TranscribeAudioChunk(ctx, chunk, extension)
+		if err != nil {
+			return fmt.Errorf("ошибка расшифровки части %d из %d: %w", i+1, len(chunks), err)
+		}
+		parts = append(parts, strings.TrimSpace(text))
+
+		if err := h.transcriptionService.UpdateProgress(ctx, job.ID, i+1, len(chunks)); err != nil {
+			logrus.Warnf("Задача расшифровки %d: %v", job.ID, err)
+		}
+		if i+1 < len(chunks) {
+			h.updateTranscriptionProgress(job, fmt.Sprintf("⏳ Расшифровываю аудио: часть %d из %d", i+2, len(chunks)))
+		}
+	}
+
+	transcript := strings.TrimSpace(strings.Join(parts, " "))
+	if err := h.transcriptionService.Complete(ctx, job.ID, transcript); err != nil {
+		logrus.Errorf("Задача расшифровки %d: %v", job.ID, err)
+	}
+	h.updateTranscriptionProgress(job, "✅ Аудио расшифровано")
+
+	if transcript == "" {
+		h.sendMessageCtx(ctx, job.ChatID, "🤷 В записи не удалось разобрать речь")
+		return nil
+	}
+	h.sendMessageCtx(ctx, job.ChatID, "📝 **Расшифровка аудио:**\n\n"+transcript)
+
+	userID := fmt.Sprintf("%d", job.UserID)
+	history, err := h.messageStoreService.GetMessageHistory(ctx, userID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении истории сообщений: %v", err)
+		history = []models.MessageHistoryItem{}
+	}
+
+	reply, err := h.chatgptService.ProcessMessage(ctx, job.UserID, transcript, history)
+	if err != nil {
+		logrus.Errorf("Ошибка при обработке расшифровки %d через Jarvis: %v", job.ID, err)
+		h.sendMessageCtx(ctx, job.ChatID, "Расшифровка готова, но обработать поручения из нее не получилось")
+		return nil
+	}
+
+	messageID, err := h.messageStoreService.StoreUserMessage(ctx, userID, "[Аудио сообщение]", "telegram")
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении сообщения пользователя: %v", err)
+	}
+
+	h.storeAndSendReply(ctx, job.ChatID, job.UserID, messageID, reply)
+	h.handleEscalationReply(ctx, reply, &tgbotapi.User{ID: job.UserID})
+	return nil
+}
+
+func (h *Handler) updateTranscriptionProgress(job transcription.Job, status string) {
+	if job.ProgressMessageID == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(job.ChatID, *job.ProgressMessageID, fmt.Sprintf("🎧 Аудио %.1f МБ\n\n%s", float64(job.FileSize)/(1<<20), status))
+	if _, err := h.bot.Send(edit); err != nil {
+		logrus.Warnf("Не удалось обновить прогресс расшифровки %d: %v", job.ID, err)
+	}
+}
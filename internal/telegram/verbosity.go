@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/preferences"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+func (h *Handler) handleVerbosityCommand(ctx context.Context, message *tgbotapi.Message) {
+	arg := strings.TrimSpace(message.CommandArguments())
+	if arg != "" {
+		text, err := h.setVerbosity(ctx, message.From.ID, arg)
+		if err != nil {
+			h.sendMessageCtx(ctx, message.Chat.ID, "❌ "+err.Error())
+			return
+		}
+		h.sendMessageCtx(ctx, message.Chat.ID, text)
+		return
+	}
+
+	prefs, err := h.preferencesService.Get(ctx, message.From.ID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении краткости ответов пользователя %d: %v", message.From.ID, err)
+		h.sendMessageCtx(ctx, message.Chat.ID, "❌ Не удалось загрузить настройку")
+		return
+	}
+	current := preferences.VerbosityNormal
+	if prefs.Verbosity != nil {
+		current = *prefs.Verbosity
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, level := range preferences.AllowedValues[preferences.TypeVerbosity] {
+		label := preferences.VerbosityLabel(level)
+		if level == current {
+			label = "• " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "verbosity:"+level))
+	}
+
+	text := fmt.Sprintf(
+		"✂️ Ответы Jarvis: %s\n\n"+
+			"Краткие — одна-три строки, удобно для быстрого логирования.\n"+
+			"Обычные — как сейчас.\n"+
+			"Подробные — с объяснениями, примерами и следующими шагами.",
+		preferences.VerbosityLabel(current))
+	if err := h.sendFormatted(ctx, message.Chat.ID, text, ParseModeHTML, tgbotapi.NewInlineKeyboardMarkup(row)); err != nil {
+		logrus.Errorf("Ошибка при отправке настроек краткости ответов: %v", err)
+	}
+}
+
+func (h *Handler) handleVerbosityCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	text, err := h.setVerbosity(ctx, query.From.ID, payload)
+	if err != nil {
+		h.answerCallback(query.ID, err.Error())
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	if query.Message != nil {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+		if _, err := h.bot.Send(edit); err != nil {
+			logrus.Warnf("Не удалось обновить сообщение настроек краткости ответов: %v", err)
+		}
+	}
+}
+
+func (h *Handler) setVerbosity(ctx context.Context, userID int64, value string) (string, error) {
+	_, normalized, err := h.preferencesService.Set(ctx, userID, preferences.TypeVerbosity, value)
+	if errors.Is(err, preferences.ErrInvalidValue) {
+		return "", errors.New("допустимые значения: краткий, обычный, подробный")
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка при сохранении краткости ответов пользователя %d: %v", userID, err)
+		return "", errors.New("не удалось сохранить настройку")
+	}
+	return fmt.Sprintf("✂️ Ответы Jarvis: %s", preferences.VerbosityLabel(normalized)), nil
+}
@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"telegrambot/internal/focus"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const weeklyPlanButtonWindow = 10 * time.Minute
+
+func (h *Handler) weeklyPlanRow(ctx context.Context, userID int64, args map[string]interface{}) []tgbotapi.InlineKeyboardButton {
+	if confirm, _ := args["confirm"].(bool); confirm {
+		plan, err := h.focusService.LatestWeeklyPlan(ctx, userID, focus.WeeklyPlanApplied)
+		if err != nil || plan.AppliedAt == nil || time.Since(*plan.AppliedAt) > weeklyPlanButtonWindow {
+			return nil
+		}
+		return weeklyPlanRevertRow(plan.ID)
+	}
+
+	plan, err := h.focusService.LatestWeeklyPlan(ctx, userID, focus.WeeklyPlanProposed)
+	if err != nil || len(plan.Blocks) == 0 || time.Since(plan.CreatedAt) > weeklyPlanButtonWindow {
+		return nil
+	}
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗓️ Добавить в календарь", fmt.Sprintf("wplan_apply:%d", plan.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("Не нужно", fmt.Sprintf("wplan_drop:%d", plan.ID)),
+	)
+}
+
+func weeklyPlanRevertRow(planID int64) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("↩️ Отменить план", fmt.Sprintf("wplan_revert:%d", planID)),
+	)
+}
+
+func (h *Handler) handleWeeklyPlanApplyCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	planID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	plan, err := h.focusService.ApplyWeeklyPlanByID(ctx, h.calendarService, query.From.ID, planID)
+	if errors.Is(err, focus.ErrWeeklyPlanNotFound) {
+		h.answerCallback(query.ID, "План уже неактуален — попросите составить новый")
+		h.removeInlineKeyboard(query)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка применения недельного плана %d пользователя %d: %v", planID, query.From.ID, err)
+		h.answerCallback(query.ID, "❌ Не удалось добавить план в календарь")
+		return
+	}
+
+	created := 0
+	for _, block := range plan.Blocks {
+		if block.EventID != nil {
+			created++
+		}
+	}
+	h.answerCallback(query.ID, "")
+	h.removeInlineKeyboard(query)
+	if query.Message == nil {
+		return
+	}
+	if created == 0 {
+		h.sendMessageCtx(ctx, query.Message.Chat.ID, "❌ Не удалось добавить блоки плана в календарь")
+		return
+	}
+
+	text := fmt.Sprintf(
+		"✅ План добавлен в календарь, блоков: %d из %d\n\n%s\n\nБлоки предварительные: если план не подошел, его можно отменить целиком",
+		created, len(plan.Blocks), focus.FormatPlanBlocks(plan.Blocks))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(weeklyPlanRevertRow(plan.ID))
+	if err := h.sendFormatted(ctx, query.Message.Chat.ID, text, ParseModeHTML, keyboard); err != nil {
+		logrus.Errorf("Ошибка при отправке примененного недельного плана: %v", err)
+	}
+}
+
+func (h *Handler) handleWeeklyPlanDiscardCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	planID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	if err := h.focusService.DiscardWeeklyPlan(ctx, query.From.ID, planID); err != nil && !errors.Is(err, focus.ErrWeeklyPlanNotFound) {
+		logrus.Errorf("Ошибка отклонения недельного плана %d пользователя %d: %v", planID, query.From.ID, err)
+		h.answerCallback(query.ID, "❌ Не удалось отклонить план")
+		return
+	}
+	h.answerCallback(query.ID, "Хорошо, календарь не трогаю")
+	h.removeInlineKeyboard(query)
+}
+
+func (h *Handler) handleWeeklyPlanRevertCallback(ctx context.Context, query *tgbotapi.CallbackQuery, payload string) {
+	planID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		h.answerCallback(query.ID, "Некорректные данные кнопки")
+		return
+	}
+
+	removed, err := h.focusService.RevertWeeklyPlan(ctx, h.calendarService, query.From.ID, planID)
+	if errors.Is(err, focus.ErrWeeklyPlanNotApplied) {
+		h.answerCallback(query.ID, "План уже отменен")
+		h.removeInlineKeyboard(query)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Ошибка отмены недельного плана %d пользователя %d: %v", planID, query.From.ID, err)
+		h.answerCallback(query.ID, "❌ Не удалось отменить план")
+		return
+	}
+
+	h.answerCallback(query.ID, "")
+	h.removeInlineKeyboard(query)
+	if query.Message != nil {
+		h.sendMessageCtx(ctx, query.Message.Chat.ID, fmt.Sprintf("↩️ План на неделю отменен, удалено блоков из календаря: %d", removed))
+	}
+}
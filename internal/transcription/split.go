@@ -0,0 +1,116 @@
+package transcription
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+const oggPageHeaderSize = 27
+
+var oggCapturePattern = []byte("OggS")
+
+func FileExtension(data []byte, mimeType string) string {
+	switch {
+	case bytes.HasPrefix(data, oggCapturePattern):
+		return ".ogg"
+	case bytes.HasPrefix(data, []byte("ID3")) || isMP3Frame(data, 0):
+		return ".mp3"
+	case len(data) > 12 && bytes.Equal(data[4:8], []byte("ftyp")):
+		return ".m4a"
+	case bytes.HasPrefix(data, []byte("RIFF")):
+		return ".wav"
+	case strings.Contains(mimeType, "mpeg"):
+		return ".mp3"
+	}
+	return ".ogg"
+}
+
+func Split(data []byte, maxChunk int) [][]byte {
+	if len(data) <= maxChunk {
+		return [][]byte{data}
+	}
+	switch FileExtension(data, "") {
+	case ".ogg":
+		if chunks := splitOgg(data, maxChunk); chunks != nil {
+			return chunks
+		}
+	case ".mp3":
+		return splitMP3(data, maxChunk)
+	}
+	return [][]byte{data}
+}
+
+func splitOgg(data []byte, maxChunk int) [][]byte {
+	var header []byte
+	var chunks [][]byte
+	var current []byte
+
+	for offset := 0; offset < len(data); {
+		size, granule, ok := oggPage(data, offset)
+		if !ok {
+			return nil
+		}
+		page := data[offset : offset+size]
+		offset += size
+
+		if granule == 0 && len(chunks) == 0 && len(current) == 0 {
+			header = append(header, page...)
+			continue
+		}
+		if len(current) > 0 && len(header)+len(current)+len(page) > maxChunk {
+			chunks = append(chunks, current)
+			current = nil
+		}
+		current = append(current, page...)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	result := make([][]byte, 0, len(chunks))
+	for _, chunk := range chunks {
+		result = append(result, append(append([]byte{}, header...), chunk...))
+	}
+	return result
+}
+
+func oggPage(data []byte, offset int) (int, int64, bool) {
+	if len(data)-offset < oggPageHeaderSize || !bytes.Equal(data[offset:offset+4], oggCapturePattern) {
+		return 0, 0, false
+	}
+	granule := int64(binary.LittleEndian.Uint64(data[offset+6 : offset+14]))
+	segments := int(data[offset+26])
+	if len(data)-offset < oggPageHeaderSize+segments {
+		return 0, 0, false
+	}
+	size := oggPageHeaderSize + segments
+	for _, lacing := range data[offset+oggPageHeaderSize : offset+oggPageHeaderSize+segments] {
+		size += int(lacing)
+	}
+	if len(data)-offset < size {
+		return 0, 0, false
+	}
+	return size, granule, true
+}
+
+func splitMP3(data []byte, maxChunk int) [][]byte {
+	var chunks [][]byte
+	start := 0
+	for len(data)-start > maxChunk {
+		cut := start + maxChunk
+		for cut > start && !isMP3Frame(data, cut) {
+			cut--
+		}
+		if cut == start {
+			cut = start + maxChunk
+		}
+		chunks = append(chunks, data[start:cut])
+		start = cut
+	}
+	return append(chunks, data[start:])
+}
+
+func isMP3Frame(data []byte, offset int) bool {
+	return len(data)-offset >= 2 && data[offset] == 0xFF && data[offset+1]&0xE0 == 0xE0
+}
@@ -0,0 +1,217 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"telegrambot/internal/jobs"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusPending		= "pending"
+	StatusProcessing	= "processing"
+	StatusDone		= "done"
+	StatusFailed		= "failed"
+
+	AsyncThresholdBytes	= 2 << 20
+	ProgressThresholdBytes	= 8 << 20
+	ChunkBytes		= 4 << 20
+
+	workers		= 3
+	maxAttempts	= 3
+	staleAfter	= 30 * time.Minute
+	retention	= 30 * 24 * time.Hour
+)
+
+type Job struct {
+	ID			int64		`db:"id" json:"id"`
+	UserID			int64		`db:"user_id" json:"user_id"`
+	ChatID			int64		`db:"chat_id" json:"chat_id"`
+	FileID			string		`db:"file_id" json:"file_id"`
+	FileSize		int64		`db:"file_size" json:"file_size"`
+	MimeType		string		`db:"mime_type" json:"mime_type"`
+	Status			string		`db:"status" json:"status"`
+	Attempts		int		`db:"attempts" json:"attempts"`
+	ProgressMessageID	*int		`db:"progress_message_id" json:"progress_message_id,omitempty"`
+	ChunksTotal		int		`db:"chunks_total" json:"chunks_total"`
+	ChunksDone		int		`db:"chunks_done" json:"chunks_done"`
+	Transcript		*string		`db:"transcript" json:"transcript,omitempty"`
+	LastError		*string		`db:"last_error" json:"last_error,omitempty"`
+	CreatedAt		time.Time	`db:"created_at" json:"created_at"`
+	StartedAt		*time.Time	`db:"started_at" json:"started_at,omitempty"`
+	FinishedAt		*time.Time	`db:"finished_at" json:"finished_at,omitempty"`
+}
+
+type ProcessFunc func(ctx context.Context, job Job) error
+
+type Service struct {
+	db *sqlx.DB
+}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func NeedsAsync(fileSize int64) bool {
+	return fileSize > AsyncThresholdBytes
+}
+
+func NeedsProgress(fileSize int64) bool {
+	return fileSize > ProgressThresholdBytes
+}
+
+func (s *Service) Enqueue(ctx context.Context, userID, chatID int64, fileID string, fileSize int64, mimeType string) (*Job, error) {
+	var job Job
+	err := s.db.GetContext(ctx, &job, `
+		INSERT INTO transcription_jobs (user_id, chat_id, file_id, file_size, mime_type)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`, userID, chatID, fileID, fileSize, mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при постановке аудио в очередь расшифровки: %v", err)
+	}
+	return &job, nil
+}
+
+func (s *Service) SetProgressMessage(ctx context.Context, jobID int64, messageID int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE transcription_jobs SET progress_message_id = $2 WHERE id = $1
+	`, jobID, messageID)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении сообщения о прогрессе: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) UpdateProgress(ctx context.Context, jobID int64, done, total int) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE transcription_jobs SET chunks_done = $2, chunks_total = $3 WHERE id = $1
+	`, jobID, done, total)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении прогресса расшифровки: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) Complete(ctx context.Context, jobID int64, transcript string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE transcription_jobs SET status = $2, transcript = $3, last_error = NULL, finished_at = NOW()
+		WHERE id = $1
+	`, jobID, StatusDone, transcript)
+	if err != nil {
+		return fmt.Errorf("ошибка при завершении расшифровки: %v", err)
+	}
+	return nil
+}
+
+func (s *Service) StartWorker(jm *jobs.Manager, process ProcessFunc) {
+	jm.Register(jobs.Job{
+		Name:	"transcription_worker",
+		Spec:	"@every 5s",
+		Run: func(ctx context.Context) {
+			s.processPending(ctx, process)
+		},
+	})
+	jm.Register(jobs.Job{
+		Name:	"transcription_cleanup",
+		Spec:	"45 4 * * *",
+		Run: func(ctx context.Context) {
+			s.cleanup(ctx)
+		},
+	})
+
+	logrus.Info("Запущена фоновая расшифровка аудио")
+}
+
+func (s *Service) processPending(ctx context.Context, process ProcessFunc) {
+	claimed, err := s.claim(ctx)
+	if err != nil {
+		logrus.Errorf("Ошибка при выборке аудио для расшифровки: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range claimed {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					s.fail(ctx, job, fmt.Errorf("паника при расшифровке: %v", r))
+				}
+			}()
+
+			if err := process(ctx, job); err != nil {
+				s.fail(ctx, job, err)
+			}
+		}(job)
+	}
+	wg.Wait()
+}
+
+func (s *Service) claim(ctx context.Context) ([]Job, error) {
+	var claimed []Job
+	err := s.db.SelectContext(ctx, &claimed, `
+		UPDATE transcription_jobs
+		SET status = $1, attempts = attempts + 1, started_at = NOW()
+		WHERE id IN (
+			SELECT id FROM transcription_jobs
+			WHERE (status = $2 OR (status = $1 AND started_at < NOW() - make_interval(secs => $3)))
+				AND attempts < $4
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, StatusProcessing, StatusPending, staleAfter.Seconds(), maxAttempts, workers)
+	return claimed, err
+}
+
+func (s *Service) fail(ctx context.Context, job Job, jobErr error) {
+	status := StatusPending
+	if job.Attempts >= maxAttempts {
+		status = StatusFailed
+		logrus.Errorf("Расшифровка аудио %d пользователя %d не удалась после %d попыток: %v", job.ID, job.UserID, job.Attempts, jobErr)
+	} else {
+		logrus.Warnf("Ошибка расшифровки аудио %d пользователя %d (попытка %d), повторим: %v", job.ID, job.UserID, job.Attempts, jobErr)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE transcription_jobs
+		SET status = $2, last_error = $3, finished_at = CASE WHEN $2 = $4 THEN NOW() ELSE NULL END
+		WHERE id = $1
+	`, job.ID, status, jobErr.Error(), StatusFailed)
+	if err != nil {
+		logrus.Errorf("Ошибка при обновлении задачи расшифровки %d: %v", job.ID, err)
+	}
+}
+
+func (j Job) FinalAttempt() bool {
+	return j.Attempts >= maxAttempts
+}
+
+func (s *Service) cleanup(ctx context.Context) {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE transcription_jobs SET status = $1, finished_at = NOW()
+		WHERE status = $2 AND attempts >= $3 AND started_at < NOW() - make_interval(secs => $4)
+	`, StatusFailed, StatusProcessing, maxAttempts, staleAfter.Seconds())
+	if err != nil {
+		logrus.Errorf("Ошибка при закрытии зависших задач расшифровки: %v", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM transcription_jobs
+		WHERE status IN ($1, $2) AND finished_at < NOW() - make_interval(secs => $3)
+	`, StatusDone, StatusFailed, retention.Seconds())
+	if err != nil {
+		logrus.Errorf("Ошибка при очистке задач расшифровки: %v", err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		logrus.Infof("Удалено завершенных задач расшифровки: %d", rows)
+	}
+}
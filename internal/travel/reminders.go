@@ -0,0 +1,67 @@
+package travel
+
+import (
+	"context"
+	"fmt"
+	"telegrambot/internal/jobs"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (s *Service) StartCheckinReminder(jm *jobs.Manager, sendMessage func(chatID int64, text string) error) {
+	jm.Register(jobs.Job{
+		Name:		"travel_checkin_reminders",
+		Spec:		"*/5 * * * *",
+		Run: func(ctx context.Context) {
+			s.sendCheckinReminders(ctx, sendMessage)
+		},
+	})
+
+	logrus.Info("Запущены напоминания о регистрации на рейсы и заселении")
+}
+
+func (s *Service) sendCheckinReminders(ctx context.Context, sendMessage func(chatID int64, text string) error) {
+	var items []TripItem
+	err := s.db.SelectContext(ctx, &items, tripItemSelect+`
+		WHERE checkin_reminded = FALSE AND checkin_remind_at <= NOW() AND start_time > NOW()
+		ORDER BY start_time
+	`)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении бронирований для напоминаний: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		if err := sendMessage(item.UserID, checkinMessage(item)); err != nil {
+			logrus.Errorf("Ошибка при отправке напоминания о бронировании %d пользователю %d: %v", item.ID, item.UserID, err)
+			continue
+		}
+
+		if _, err := s.db.ExecContext(ctx, `UPDATE trip_items SET checkin_reminded = TRUE WHERE id = $1`, item.ID); err != nil {
+			logrus.Errorf("Ошибка при обновлении статуса напоминания о бронировании %d: %v", item.ID, err)
+		}
+	}
+}
+
+func checkinMessage(item TripItem) string {
+	start := item.LocalStart()
+
+	var text string
+	switch item.ItemType {
+	case "flight":
+		text = fmt.Sprintf("✈️ Открыта онлайн-регистрация на рейс «%s»\n🕐 Вылет: %s (%s)", item.Title, start.Format("02.01 15:04"), item.StartTimezone)
+	case "hotel":
+		text = fmt.Sprintf("🏨 Сегодня заселение: «%s»\n🕐 Заезд с %s (%s)", item.Title, start.Format("15:04"), item.StartTimezone)
+	default:
+		text = fmt.Sprintf("🧳 Скоро отправление: «%s»\n🕐 %s (%s)", item.Title, start.Format("02.01 15:04"), item.StartTimezone)
+	}
+
+	if item.ConfirmationCode != nil {
+		text += fmt.Sprintf("\n🔖 Код бронирования: %s", *item.ConfirmationCode)
+	}
+	if item.Location != nil {
+		text += fmt.Sprintf("\n📍 %s", *item.Location)
+	}
+
+	return text
+}
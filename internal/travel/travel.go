@@ -0,0 +1,442 @@
+package travel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrTripNotFound		= errors.New("поездка не найдена")
+	ErrNoBookings		= errors.New("в подтверждении не найдено ни одного бронирования")
+)
+
+type Service struct {
+	db		*sqlx.DB
+	calendar	*calendar.Service
+}
+
+type Trip struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	Title		string		`db:"title" json:"title"`
+	Destination	*string		`db:"destination" json:"destination,omitempty"`
+	StartTime	time.Time	`db:"start_time" json:"start_time"`
+	EndTime		time.Time	`db:"end_time" json:"end_time"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type TripItem struct {
+	ID			int64		`db:"id" json:"id"`
+	TripID			int64		`db:"trip_id" json:"trip_id"`
+	UserID			int64		`db:"user_id" json:"user_id"`
+	EventID			*string		`db:"event_id" json:"event_id,omitempty"`
+	ItemType		string		`db:"item_type" json:"item_type"`
+	Title			string		`db:"title" json:"title"`
+	Provider		*string		`db:"provider" json:"provider,omitempty"`
+	ConfirmationCode	*string		`db:"confirmation_code" json:"confirmation_code,omitempty"`
+	Location		*string		`db:"location" json:"location,omitempty"`
+	StartTime		time.Time	`db:"start_time" json:"start_time"`
+	EndTime			time.Time	`db:"end_time" json:"end_time"`
+	StartTimezone		string		`db:"start_timezone" json:"start_timezone"`
+	EndTimezone		string		`db:"end_timezone" json:"end_timezone"`
+	CheckinRemindAt		*time.Time	`db:"checkin_remind_at" json:"checkin_remind_at,omitempty"`
+	CheckinReminded		bool		`db:"checkin_reminded" json:"-"`
+}
+
+type Booking struct {
+	Type			string
+	Title			string
+	Provider		string
+	ConfirmationCode	string
+	Location		string
+	StartLocal		string
+	StartTimezone		string
+	EndLocal		string
+	EndTimezone		string
+}
+
+type ImportResult struct {
+	Trip		*Trip
+	Items		[]TripItem
+	Duplicates	int
+}
+
+const tripItemSelect = `
+	SELECT id, trip_id, user_id, event_id, item_type, title, provider, confirmation_code, location,
+		start_time, end_time, start_timezone, end_timezone, checkin_remind_at, checkin_reminded
+	FROM trip_items
+`
+
+func NewService(db *sqlx.DB, calendarService *calendar.Service) *Service {
+	return &Service{
+		db:		db,
+		calendar:	calendarService,
+	}
+}
+
+func (i *TripItem) TypeIcon() string {
+	switch i.ItemType {
+	case "flight":
+		return "✈️"
+	case "hotel":
+		return "🏨"
+	case "train":
+		return "🚆"
+	default:
+		return "📌"
+	}
+}
+
+func (i *TripItem) LocalStart() time.Time {
+	return inZone(i.StartTime, i.StartTimezone)
+}
+
+func (i *TripItem) LocalEnd() time.Time {
+	return inZone(i.EndTime, i.EndTimezone)
+}
+
+func inZone(t time.Time, timezone string) time.Time {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+func ParseLocalTime(value, timezone string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("неизвестный часовой пояс: %s", timezone)
+		}
+	}
+
+	formats := []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("не удалось распознать время: %s", value)
+}
+
+func checkinRemindAt(itemType string, start time.Time) time.Time {
+	switch itemType {
+	case "flight":
+		return start.Add(-24 * time.Hour)
+	case "hotel":
+		return start.Add(-3 * time.Hour)
+	default:
+		return start.Add(-2 * time.Hour)
+	}
+}
+
+func (b *Booking) normalize() error {
+	b.Type = strings.ToLower(strings.TrimSpace(b.Type))
+	if b.Type == "" {
+		b.Type = "other"
+	}
+	if b.Type != "flight" && b.Type != "hotel" && b.Type != "train" && b.Type != "other" {
+		return fmt.Errorf("неверный тип бронирования: %s. Допустимые значения: flight, hotel, train, other", b.Type)
+	}
+	if b.Title == "" {
+		return fmt.Errorf("не указано название бронирования")
+	}
+	if b.StartLocal == "" {
+		return fmt.Errorf("не указано время начала для «%s»", b.Title)
+	}
+	if b.StartTimezone == "" {
+		b.StartTimezone = "UTC"
+	}
+	if b.EndTimezone == "" {
+		b.EndTimezone = b.StartTimezone
+	}
+	return nil
+}
+
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func (s *Service) ImportBookings(ctx context.Context, userID int64, tripTitle, destination string, bookings []Booking) (*ImportResult, error) {
+	if len(bookings) == 0 {
+		return nil, ErrNoBookings
+	}
+
+	type resolved struct {
+		booking	Booking
+		start	time.Time
+		end	time.Time
+	}
+
+	var items []resolved
+	for _, b := range bookings {
+		if err := b.normalize(); err != nil {
+			return nil, err
+		}
+
+		start, err := ParseLocalTime(b.StartLocal, b.StartTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("«%s»: %v", b.Title, err)
+		}
+
+		end := start.Add(time.Hour)
+		if b.EndLocal != "" {
+			end, err = ParseLocalTime(b.EndLocal, b.EndTimezone)
+			if err != nil {
+				return nil, fmt.Errorf("«%s»: %v", b.Title, err)
+			}
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("«%s»: время окончания должно быть позже начала", b.Title)
+		}
+
+		items = append(items, resolved{booking: b, start: start, end: end})
+	}
+
+	result := &ImportResult{}
+	var createdEvents []string
+	var err error
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			for _, eventID := range createdEvents {
+				if delErr := s.calendar.DeleteEvent(context.Background(), userID, eventID); delErr != nil {
+					logrus.Warnf("Не удалось удалить событие %s после ошибки импорта поездки: %v", eventID, delErr)
+				}
+			}
+		}
+	}()
+
+	minStart, maxEnd := items[0].start, items[0].end
+	for _, item := range items[1:] {
+		if item.start.Before(minStart) {
+			minStart = item.start
+		}
+		if item.end.After(maxEnd) {
+			maxEnd = item.end
+		}
+	}
+
+	trip, err := s.findOrCreateTrip(ctx, tx, userID, tripTitle, destination, minStart, maxEnd)
+	if err != nil {
+		return nil, err
+	}
+	result.Trip = trip
+
+	for _, item := range items {
+		b := item.booking
+
+		if b.ConfirmationCode != "" {
+			var exists bool
+			err = tx.GetContext(ctx, &exists, `
+				SELECT EXISTS (
+					SELECT 1 FROM trip_items
+					WHERE user_id = $1 AND confirmation_code = $2 AND item_type = $3 AND start_time = $4
+				)
+			`, userID, b.ConfirmationCode, b.Type, item.start)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при проверке бронирования: %v", err)
+			}
+			if exists {
+				result.Duplicates++
+				continue
+			}
+		}
+
+		var eventID string
+		eventID, err = s.calendar.CreateEvent(ctx, userID, b.Title, bookingDescription(b, item.start, item.end),
+			item.start.Format(time.RFC3339), item.end.Format(time.RFC3339))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при создании события в календаре: %v", err)
+		}
+		createdEvents = append(createdEvents, eventID)
+
+		var remindAt *time.Time
+		if r := checkinRemindAt(b.Type, item.start); r.After(time.Now()) {
+			remindAt = &r
+		}
+
+		var tripItem TripItem
+		err = tx.GetContext(ctx, &tripItem, `
+			INSERT INTO trip_items (trip_id, user_id, event_id, item_type, title, provider, confirmation_code, location,
+				start_time, end_time, start_timezone, end_timezone, checkin_remind_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING id, trip_id, user_id, event_id, item_type, title, provider, confirmation_code, location,
+				start_time, end_time, start_timezone, end_timezone, checkin_remind_at, checkin_reminded
+		`, trip.ID, userID, eventID, b.Type, b.Title, nullableString(b.Provider), nullableString(b.ConfirmationCode),
+			nullableString(b.Location), item.start, item.end, b.StartTimezone, b.EndTimezone, remindAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сохранении бронирования: %v", err)
+		}
+
+		result.Items = append(result.Items, tripItem)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении поездки: %v", err)
+	}
+
+	return result, nil
+}
+
+func (s *Service) findOrCreateTrip(ctx context.Context, tx *sqlx.Tx, userID int64, title, destination string, start, end time.Time) (*Trip, error) {
+	var trip Trip
+	err := tx.GetContext(ctx, &trip, `
+		SELECT id, user_id, title, destination, start_time, end_time, created_at
+		FROM trips
+		WHERE user_id = $1
+			AND (($2::text <> '' AND LOWER(title) = LOWER($2))
+				OR (start_time <= $4 + INTERVAL '1 day' AND end_time >= $3 - INTERVAL '1 day'))
+		ORDER BY start_time
+		LIMIT 1
+	`, userID, title, start, end)
+
+	if err == sql.ErrNoRows {
+		if title == "" {
+			title = "Поездка"
+			if destination != "" {
+				title = "Поездка: " + destination
+			}
+		}
+
+		err = tx.GetContext(ctx, &trip, `
+			INSERT INTO trips (user_id, title, destination, start_time, end_time)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, user_id, title, destination, start_time, end_time, created_at
+		`, userID, title, nullableString(destination), start, end)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при создании поездки: %v", err)
+		}
+		return &trip, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске поездки: %v", err)
+	}
+
+	err = tx.GetContext(ctx, &trip, `
+		UPDATE trips
+		SET start_time = LEAST(start_time, $2), end_time = GREATEST(end_time, $3),
+			destination = COALESCE(destination, $4)
+		WHERE id = $1
+		RETURNING id, user_id, title, destination, start_time, end_time, created_at
+	`, trip.ID, start, end, nullableString(destination))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении поездки: %v", err)
+	}
+
+	return &trip, nil
+}
+
+func bookingDescription(b Booking, start, end time.Time) string {
+	var lines []string
+	if b.Provider != "" {
+		lines = append(lines, "Перевозчик/отель: "+b.Provider)
+	}
+	if b.ConfirmationCode != "" {
+		lines = append(lines, "Код бронирования: "+b.ConfirmationCode)
+	}
+	if b.Location != "" {
+		lines = append(lines, "Место: "+b.Location)
+	}
+	lines = append(lines, fmt.Sprintf("Начало: %s (%s)", start.Format("02.01.2006 15:04"), b.StartTimezone))
+	lines = append(lines, fmt.Sprintf("Окончание: %s (%s)", inZone(end, b.EndTimezone).Format("02.01.2006 15:04"), b.EndTimezone))
+	return strings.Join(lines, "\n")
+}
+
+func (s *Service) GetUpcomingTrips(ctx context.Context, userID int64) ([]Trip, error) {
+	query := `
+		SELECT id, user_id, title, destination, start_time, end_time, created_at
+		FROM trips
+		WHERE user_id = $1 AND end_time >= NOW()
+		ORDER BY start_time
+	`
+
+	var trips []Trip
+	err := s.db.SelectContext(ctx, &trips, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении поездок: %v", err)
+	}
+
+	return trips, nil
+}
+
+func (s *Service) GetTripItems(ctx context.Context, userID, tripID int64) ([]TripItem, error) {
+	var items []TripItem
+	err := s.db.SelectContext(ctx, &items, tripItemSelect+` WHERE trip_id = $1 AND user_id = $2 ORDER BY start_time`, tripID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении бронирований поездки: %v", err)
+	}
+
+	return items, nil
+}
+
+func (s *Service) DeleteTrip(ctx context.Context, userID, tripID int64) error {
+	items, err := s.GetTripItems(ctx, userID, tripID)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM trips WHERE id = $1 AND user_id = $2`, tripID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении поездки: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTripNotFound
+	}
+
+	for _, item := range items {
+		if item.EventID == nil {
+			continue
+		}
+		if err := s.calendar.DeleteEvent(ctx, userID, *item.EventID); err != nil {
+			logrus.Warnf("Не удалось удалить событие %s поездки %d: %v", *item.EventID, tripID, err)
+		}
+	}
+
+	return nil
+}
+
+func FormatItem(item TripItem) string {
+	start := item.LocalStart()
+	end := item.LocalEnd()
+
+	text := fmt.Sprintf("%s %s\n   %s %s → %s %s", item.TypeIcon(), item.Title,
+		start.Format("02.01 15:04"), start.Format("MST"), end.Format("02.01 15:04"), end.Format("MST"))
+	if item.ConfirmationCode != nil {
+		text += fmt.Sprintf("\n   🔖 %s", *item.ConfirmationCode)
+	}
+	return text
+}
@@ -6,6 +6,12 @@ import (
 	"github.com/lib/pq"
 )
 
+const (
+	SubscriptionPending	= "pending"
+	SubscriptionActive	= "active"
+	SubscriptionCanceled	= "canceled"
+)
+
 type WebUser struct {
 	ID		int64		`db:"id" json:"id"`
 	Login		string		`db:"login" json:"login"`
@@ -16,3 +22,27 @@ type WebUser struct {
 	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
 	UpdatedAt	time.Time	`db:"updated_at" json:"updated_at"`
 }
+
+type WebSubscription struct {
+	WebUserID		int64		`db:"web_user_id" json:"-"`
+	StripeCustomerID	*string		`db:"stripe_customer_id" json:"-"`
+	StripeSubscriptionID	*string		`db:"stripe_subscription_id" json:"-"`
+	Status			string		`db:"status" json:"status"`
+	CurrentPeriodEnd	*time.Time	`db:"current_period_end" json:"current_period_end,omitempty"`
+	UpdatedAt		time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+func (s *WebSubscription) IsActive() bool {
+	return s != nil && s.Status == SubscriptionActive && s.CurrentPeriodEnd != nil && s.CurrentPeriodEnd.After(time.Now())
+}
+
+type APIKey struct {
+	ID		int64		`db:"id" json:"id"`
+	WebUserID	int64		`db:"web_user_id" json:"-"`
+	Name		string		`db:"name" json:"name"`
+	Prefix		string		`db:"prefix" json:"prefix"`
+	KeyHash		string		`db:"key_hash" json:"-"`
+	LastUsedAt	*time.Time	`db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt	*time.Time	`db:"revoked_at" json:"-"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
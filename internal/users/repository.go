@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -113,3 +114,150 @@ func (r *Repository) GetWebUserByTelegramID(ctx context.Context, telegramID int6
 	}
 	return &user, nil
 }
+
+const webSubscriptionColumns = `web_user_id, stripe_customer_id, stripe_subscription_id, status, current_period_end, updated_at`
+
+func (r *Repository) GetWebSubscription(ctx context.Context, webUserID int64) (*WebSubscription, error) {
+	query := `SELECT ` + webSubscriptionColumns + ` FROM web_subscriptions WHERE web_user_id = $1`
+	var sub WebSubscription
+	err := r.db.GetContext(ctx, &sub, query, webUserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка при получении подписки web_user %d: %w", webUserID, err)
+	}
+	return &sub, nil
+}
+
+func (r *Repository) GetWebSubscriptionByStripe(ctx context.Context, subscriptionID, customerID string) (*WebSubscription, error) {
+	query := `
+		SELECT ` + webSubscriptionColumns + `
+		FROM web_subscriptions
+		WHERE ($1 <> '' AND stripe_subscription_id = $1) OR ($2 <> '' AND stripe_customer_id = $2)
+		ORDER BY (stripe_subscription_id = $1) IS TRUE DESC
+		LIMIT 1
+	`
+	var sub WebSubscription
+	err := r.db.GetContext(ctx, &sub, query, subscriptionID, customerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка при поиске подписки по Stripe ID: %w", err)
+	}
+	return &sub, nil
+}
+
+func (r *Repository) AttachStripeSubscription(ctx context.Context, webUserID int64, customerID, subscriptionID string) error {
+	query := `
+		INSERT INTO web_subscriptions (web_user_id, stripe_customer_id, stripe_subscription_id)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		ON CONFLICT (web_user_id) DO UPDATE
+		SET stripe_customer_id = COALESCE(EXCLUDED.stripe_customer_id, web_subscriptions.stripe_customer_id),
+			stripe_subscription_id = COALESCE(EXCLUDED.stripe_subscription_id, web_subscriptions.stripe_subscription_id)
+	`
+	if _, err := r.db.ExecContext(ctx, query, webUserID, customerID, subscriptionID); err != nil {
+		return fmt.Errorf("ошибка при сохранении Stripe подписки web_user %d: %w", webUserID, err)
+	}
+	return nil
+}
+
+func (r *Repository) UpdateWebSubscriptionStatus(ctx context.Context, webUserID int64, status string, periodEnd *time.Time) error {
+	query := `
+		UPDATE web_subscriptions
+		SET status = $2, current_period_end = COALESCE($3, current_period_end)
+		WHERE web_user_id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, webUserID, status, periodEnd); err != nil {
+		return fmt.Errorf("ошибка при обновлении статуса подписки web_user %d: %w", webUserID, err)
+	}
+	return nil
+}
+
+func (r *Repository) GrantTelegramPremium(ctx context.Context, telegramIDs []int64, until time.Time) error {
+	if len(telegramIDs) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE users
+		SET role = 'premium',
+			subscription_expires_at = GREATEST(COALESCE(subscription_expires_at, $2), $2),
+			subscription_reminded = FALSE
+		WHERE id = ANY($1)
+	`
+	if _, err := r.db.ExecContext(ctx, query, pq.Int64Array(telegramIDs), until); err != nil {
+		return fmt.Errorf("ошибка при выдаче подписки Telegram аккаунтам: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) RevokeTelegramPremium(ctx context.Context, telegramIDs []int64, paidUntil time.Time) error {
+	if len(telegramIDs) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE users
+		SET role = 'free', subscription_expires_at = NULL
+		WHERE id = ANY($1)
+			AND role = 'premium'
+			AND subscription_expires_at IS NOT NULL
+			AND subscription_expires_at <= $2
+	`
+	if _, err := r.db.ExecContext(ctx, query, pq.Int64Array(telegramIDs), paidUntil); err != nil {
+		return fmt.Errorf("ошибка при отзыве подписки Telegram аккаунтов: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) CreateAPIKey(ctx context.Context, webUserID int64, name, prefix, keyHash string) (*APIKey, error) {
+	query := `
+		INSERT INTO api_keys (web_user_id, name, prefix, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, web_user_id, name, prefix, key_hash, last_used_at, revoked_at, created_at
+	`
+	var key APIKey
+	if err := r.db.GetContext(ctx, &key, query, webUserID, name, prefix, keyHash); err != nil {
+		return nil, fmt.Errorf("ошибка при создании API-ключа: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context, webUserID int64) ([]APIKey, error) {
+	query := `
+		SELECT id, web_user_id, name, prefix, key_hash, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE web_user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+	var keys []APIKey
+	if err := r.db.SelectContext(ctx, &keys, query, webUserID); err != nil {
+		return nil, fmt.Errorf("ошибка при получении API-ключей: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *Repository) RevokeAPIKey(ctx context.Context, webUserID, keyID int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND web_user_id = $2 AND revoked_at IS NULL
+	`, keyID, webUserID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при отзыве API-ключа: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *Repository) TouchAPIKey(ctx context.Context, keyHash string) (int64, error) {
+	var webUserID int64
+	err := r.db.GetContext(ctx, &webUserID, `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING web_user_id
+	`, keyHash)
+	if err != nil {
+		return 0, err
+	}
+	return webUserID, nil
+}
@@ -2,10 +2,15 @@ package users
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"telegrambot/internal/auth"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -16,6 +21,8 @@ var (
 	ErrInvalidCredentials			= errors.New("неверный логин или пароль")
 	ErrTelegramIDAlreadyLinkedToOtherUser	= errors.New("этот Telegram аккаунт уже привязан к другому веб-пользователю")
 	ErrTelegramIDAlreadyLinkedToThisUser	= errors.New("этот Telegram аккаунт уже привязан к вашему веб-профилю")
+	ErrSubscriptionNotFound			= errors.New("подписка для Stripe не найдена")
+	ErrAPIKeyNotFound			= errors.New("API-ключ не найден или отозван")
 )
 
 type Service struct {
@@ -126,6 +133,17 @@ func (s *Service) LinkTelegramAccount(ctx context.Context, webUserID int64, tele
 	}
 
 	logrus.Infof("Telegram ID %d успешно привязан к web_user %d", telegramID, webUserID)
+
+	sub, err := s.repo.GetWebSubscription(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка получения подписки web_user %d при привязке Telegram: %v", webUserID, err)
+		return nil
+	}
+	if sub.IsActive() {
+		if err := s.repo.GrantTelegramPremium(ctx, []int64{telegramID}, *sub.CurrentPeriodEnd); err != nil {
+			logrus.Errorf("Ошибка выдачи подписки привязанному telegram_id %d: %v", telegramID, err)
+		}
+	}
 	return nil
 }
 
@@ -143,3 +161,136 @@ func (s *Service) FindWebUserByTelegramID(ctx context.Context, telegramID int64)
 	}
 	return user, nil
 }
+
+func (s *Service) GetWebSubscription(ctx context.Context, webUserID int64) (*WebSubscription, error) {
+	sub, err := s.repo.GetWebSubscription(ctx, webUserID)
+	if err != nil {
+		logrus.Errorf("Ошибка при получении подписки web_user %d: %v", webUserID, err)
+		return nil, fmt.Errorf("внутренняя ошибка сервера")
+	}
+	return sub, nil
+}
+
+func (s *Service) AttachStripeCheckout(ctx context.Context, webUserID int64, customerID, subscriptionID string) error {
+	if _, err := s.GetWebUserByID(ctx, webUserID); err != nil {
+		return err
+	}
+	if err := s.repo.AttachStripeSubscription(ctx, webUserID, customerID, subscriptionID); err != nil {
+		logrus.Errorf("Ошибка сохранения Stripe checkout для web_user %d: %v", webUserID, err)
+		return fmt.Errorf("внутренняя ошибка сервера при сохранении подписки")
+	}
+	return nil
+}
+
+func (s *Service) ActivateWebSubscription(ctx context.Context, subscriptionID, customerID string, periodEnd time.Time) (*WebUser, error) {
+	user, err := s.findUserByStripe(ctx, subscriptionID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AttachStripeSubscription(ctx, user.ID, customerID, subscriptionID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWebSubscriptionStatus(ctx, user.ID, SubscriptionActive, &periodEnd); err != nil {
+		return nil, err
+	}
+	if err := s.repo.GrantTelegramPremium(ctx, user.TelegramIDs, periodEnd); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Подписка web_user %d активна до %s, Telegram аккаунтов: %d", user.ID, periodEnd.Format("02.01.2006"), len(user.TelegramIDs))
+	return user, nil
+}
+
+func (s *Service) CancelWebSubscription(ctx context.Context, subscriptionID, customerID string) (*WebUser, error) {
+	user, err := s.findUserByStripe(ctx, subscriptionID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetWebSubscription(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateWebSubscriptionStatus(ctx, user.ID, SubscriptionCanceled, nil); err != nil {
+		return nil, err
+	}
+
+	paidUntil := time.Now()
+	if sub != nil && sub.CurrentPeriodEnd != nil && sub.CurrentPeriodEnd.After(paidUntil) {
+		paidUntil = *sub.CurrentPeriodEnd
+	}
+	if err := s.repo.RevokeTelegramPremium(ctx, user.TelegramIDs, paidUntil); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Подписка web_user %d отменена", user.ID)
+	return user, nil
+}
+
+func (s *Service) findUserByStripe(ctx context.Context, subscriptionID, customerID string) (*WebUser, error) {
+	sub, err := s.repo.GetWebSubscriptionByStripe(ctx, subscriptionID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	return s.GetWebUserByID(ctx, sub.WebUserID)
+}
+
+const apiKeyPrefix = "jv_"
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Service) CreateAPIKey(ctx context.Context, webUserID int64, name string) (string, *APIKey, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("ошибка при генерации API-ключа: %v", err)
+	}
+	plain := apiKeyPrefix + hex.EncodeToString(raw)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Интеграция"
+	}
+
+	key, err := s.repo.CreateAPIKey(ctx, webUserID, name, plain[:len(apiKeyPrefix)+6], hashAPIKey(plain))
+	if err != nil {
+		return "", nil, err
+	}
+	return plain, key, nil
+}
+
+func (s *Service) ListAPIKeys(ctx context.Context, webUserID int64) ([]APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, webUserID)
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, webUserID, keyID int64) error {
+	ok, err := s.repo.RevokeAPIKey(ctx, webUserID, keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (int64, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return 0, ErrAPIKeyNotFound
+	}
+
+	webUserID, err := s.repo.TouchAPIKey(ctx, hashAPIKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при проверке API-ключа: %v", err)
+	}
+	return webUserID, nil
+}
@@ -0,0 +1,82 @@
+package wellbeing
+
+import "fmt"
+
+const (
+	shortBreakMinutes	= 5
+	maxBreakMinutes		= 30
+	lowEnergy		= 2
+	highStress		= 4
+)
+
+var breakActivities = map[string][]string{
+	"active": {
+		"Пройдись по лестнице или выйди на улицу",
+		"Сделай разминку шеи, плеч и спины",
+		"Налей воды и постой у окна, глядя вдаль",
+	},
+	"passive": {
+		"Закрой глаза и сделай 10 медленных вдохов",
+		"Полежи или посиди без телефона",
+		"Выпей чай без экрана",
+	},
+	"creative": {
+		"Порисуй или набросай идеи на бумаге",
+		"Послушай один любимый трек целиком",
+		"Запиши три мысли, которые крутятся в голове",
+	},
+	"social": {
+		"Позвони или напиши близкому человеку",
+		"Перекинься парой слов с коллегой не о работе",
+	},
+	"solo": {
+		"Выйди на короткую прогулку в одиночестве",
+		"Сделай дыхание 4-7-8: вдох на 4, задержка на 7, выдох на 8",
+	},
+}
+
+type BreakSuggestion struct {
+	Minutes		int
+	Type		string
+	Activities	[]string
+	Reason		string
+}
+
+func SuggestBreak(workMinutes, energy int, breakType string, latest *CheckIn) BreakSuggestion {
+	suggestion := BreakSuggestion{Minutes: shortBreakMinutes, Type: breakType}
+	switch {
+	case workMinutes >= 90:
+		suggestion.Minutes = 15
+		suggestion.Reason = fmt.Sprintf("ты работаешь уже %d мин — после полутора часов концентрация заметно падает", workMinutes)
+	case workMinutes >= 50:
+		suggestion.Minutes = 10
+		suggestion.Reason = fmt.Sprintf("за %d мин работы пора дать глазам и голове отдохнуть", workMinutes)
+	default:
+		suggestion.Reason = "короткая пауза поможет сохранить темп"
+	}
+
+	tired := energy > 0 && energy <= lowEnergy
+	stressed := latest != nil && latest.StressLevel != nil && *latest.StressLevel >= highStress
+	if tired {
+		suggestion.Minutes += 5
+		suggestion.Reason += ", а энергии мало"
+	}
+	if stressed {
+		suggestion.Minutes += 5
+		suggestion.Reason += fmt.Sprintf(", а стресс в последней отметке — %d/5", *latest.StressLevel)
+	}
+	if suggestion.Minutes > maxBreakMinutes {
+		suggestion.Minutes = maxBreakMinutes
+	}
+
+	if _, ok := breakActivities[suggestion.Type]; !ok {
+		switch {
+		case tired || stressed:
+			suggestion.Type = "passive"
+		default:
+			suggestion.Type = "active"
+		}
+	}
+	suggestion.Activities = breakActivities[suggestion.Type]
+	return suggestion
+}
@@ -0,0 +1,244 @@
+package wellbeing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrEmptyCheckIn	= errors.New("нужно указать хотя бы один показатель самочувствия")
+	ErrInvalidScore	= errors.New("оценка должна быть от 1 до 5")
+)
+
+const (
+	minScore	= 1
+	maxScore	= 5
+
+	riskWindowDays		= 14
+	defaultTrendWeeks	= 6
+	maxTrendWeeks		= 26
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type CheckIn struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	CheckinDate	time.Time	`db:"checkin_date" json:"checkin_date"`
+	StressLevel	*int		`db:"stress_level" json:"stress_level,omitempty"`
+	SleepQuality	*int		`db:"sleep_quality" json:"sleep_quality,omitempty"`
+	WorkLifeBalance	*int		`db:"work_life_balance" json:"work_life_balance,omitempty"`
+	Note		string		`db:"note" json:"note"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+	UpdatedAt	time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+type WeeklyTrend struct {
+	WeekStart	time.Time	`db:"week_start" json:"week_start"`
+	AvgStress	*float64	`db:"avg_stress" json:"avg_stress,omitempty"`
+	AvgSleep	*float64	`db:"avg_sleep" json:"avg_sleep,omitempty"`
+	AvgBalance	*float64	`db:"avg_balance" json:"avg_balance,omitempty"`
+	CheckIns	int		`db:"check_ins" json:"check_ins"`
+}
+
+type Summary struct {
+	AvgStress	*float64	`db:"avg_stress" json:"avg_stress,omitempty"`
+	AvgSleep	*float64	`db:"avg_sleep" json:"avg_sleep,omitempty"`
+	AvgBalance	*float64	`db:"avg_balance" json:"avg_balance,omitempty"`
+	CheckIns	int		`db:"check_ins" json:"check_ins"`
+}
+
+const checkInSelect = `
+	SELECT id, user_id, checkin_date, stress_level, sleep_quality, work_life_balance, note, created_at, updated_at
+	FROM wellbeing_checkins
+`
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func validScore(score *int) bool {
+	return score == nil || (*score >= minScore && *score <= maxScore)
+}
+
+func (s *Service) RecordCheckIn(ctx context.Context, userID int64, stress, sleep, balance *int, note string) (*CheckIn, error) {
+	if stress == nil && sleep == nil && balance == nil {
+		return nil, ErrEmptyCheckIn
+	}
+	if !validScore(stress) || !validScore(sleep) || !validScore(balance) {
+		return nil, ErrInvalidScore
+	}
+
+	var checkIn CheckIn
+	err := s.db.GetContext(ctx, &checkIn, `
+		INSERT INTO wellbeing_checkins (user_id, stress_level, sleep_quality, work_life_balance, note)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, checkin_date) DO UPDATE SET
+			stress_level = COALESCE(EXCLUDED.stress_level, wellbeing_checkins.stress_level),
+			sleep_quality = COALESCE(EXCLUDED.sleep_quality, wellbeing_checkins.sleep_quality),
+			work_life_balance = COALESCE(EXCLUDED.work_life_balance, wellbeing_checkins.work_life_balance),
+			note = CASE WHEN EXCLUDED.note <> '' THEN EXCLUDED.note ELSE wellbeing_checkins.note END,
+			updated_at = NOW()
+		RETURNING id, user_id, checkin_date, stress_level, sleep_quality, work_life_balance, note, created_at, updated_at
+	`, userID, stress, sleep, balance, strings.TrimSpace(note))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении отметки самочувствия: %v", err)
+	}
+	return &checkIn, nil
+}
+
+func (s *Service) GetLatest(ctx context.Context, userID int64, maxAgeDays int) (*CheckIn, error) {
+	var checkIn CheckIn
+	err := s.db.GetContext(ctx, &checkIn, checkInSelect+`
+		WHERE user_id = $1 AND checkin_date >= CURRENT_DATE - $2::int
+		ORDER BY checkin_date DESC
+		LIMIT 1
+	`, userID, maxAgeDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении отметки самочувствия: %v", err)
+	}
+	return &checkIn, nil
+}
+
+func (s *Service) GetSummary(ctx context.Context, userID int64, days int) (*Summary, error) {
+	var summary Summary
+	err := s.db.GetContext(ctx, &summary, `
+		SELECT AVG(stress_level)::float8 AS avg_stress,
+			AVG(sleep_quality)::float8 AS avg_sleep,
+			AVG(work_life_balance)::float8 AS avg_balance,
+			COUNT(*) AS check_ins
+		FROM wellbeing_checkins
+		WHERE user_id = $1 AND checkin_date > CURRENT_DATE - $2::int
+	`, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при расчете средних показателей самочувствия: %v", err)
+	}
+	return &summary, nil
+}
+
+func (s *Service) GetWeeklyTrends(ctx context.Context, userID int64, weeks int) ([]WeeklyTrend, error) {
+	if weeks <= 0 {
+		weeks = defaultTrendWeeks
+	}
+	if weeks > maxTrendWeeks {
+		weeks = maxTrendWeeks
+	}
+
+	trends := make([]WeeklyTrend, 0)
+	err := s.db.SelectContext(ctx, &trends, `
+		SELECT date_trunc('week', checkin_date)::date AS week_start,
+			AVG(stress_level)::float8 AS avg_stress,
+			AVG(sleep_quality)::float8 AS avg_sleep,
+			AVG(work_life_balance)::float8 AS avg_balance,
+			COUNT(*) AS check_ins
+		FROM wellbeing_checkins
+		WHERE user_id = $1 AND checkin_date >= date_trunc('week', CURRENT_DATE)::date - ($2::int - 1) * 7
+		GROUP BY week_start
+		ORDER BY week_start
+	`, userID, weeks)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении динамики самочувствия: %v", err)
+	}
+	return trends, nil
+}
+
+func (s *Service) BurnoutRisk(ctx context.Context, userID int64) (float64, bool, error) {
+	summary, err := s.GetSummary(ctx, userID, riskWindowDays)
+	if err != nil {
+		return 0, false, err
+	}
+	if summary.CheckIns == 0 {
+		return 0, false, nil
+	}
+
+	var weighted, weights float64
+	if summary.AvgStress != nil {
+		weighted += 0.45 * (*summary.AvgStress - minScore) / (maxScore - minScore)
+		weights += 0.45
+	}
+	if summary.AvgSleep != nil {
+		weighted += 0.3 * (maxScore - *summary.AvgSleep) / (maxScore - minScore)
+		weights += 0.3
+	}
+	if summary.AvgBalance != nil {
+		weighted += 0.25 * (maxScore - *summary.AvgBalance) / (maxScore - minScore)
+		weights += 0.25
+	}
+	if weights == 0 {
+		return 0, false, nil
+	}
+	risk := weighted / weights
+
+	trends, err := s.GetWeeklyTrends(ctx, userID, 2)
+	if err == nil && len(trends) == 2 && trends[0].AvgStress != nil && trends[1].AvgStress != nil {
+		if *trends[1].AvgStress-*trends[0].AvgStress >= 0.5 {
+			risk += 0.1
+		}
+	}
+
+	return math.Max(0, math.Min(1, risk)), true, nil
+}
+
+func Recommendations(checkIn *CheckIn) []string {
+	var tips []string
+	if checkIn.StressLevel != nil && *checkIn.StressLevel >= 4 {
+		tips = append(tips, "Сделай 5-минутную дыхательную паузу и убери одну необязательную задачу с сегодняшнего дня")
+	}
+	if checkIn.SleepQuality != nil && *checkIn.SleepQuality <= 2 {
+		tips = append(tips, "Ляг сегодня на 30 минут раньше и отложи экран за час до сна")
+	}
+	if checkIn.WorkLifeBalance != nil && *checkIn.WorkLifeBalance <= 2 {
+		tips = append(tips, "Запланируй вечер без работы и поставь жесткое время окончания рабочего дня")
+	}
+	if len(tips) == 0 {
+		tips = append(tips, "Отличные показатели — поддерживай текущий режим")
+	}
+	return tips
+}
+
+func scoreBar(value *float64) string {
+	if value == nil {
+		return "—"
+	}
+	filled := int(math.Round(*value))
+	return fmt.Sprintf("%s%s %.1f", strings.Repeat("█", filled), strings.Repeat("░", maxScore-filled), *value)
+}
+
+func FormatTrends(trends []WeeklyTrend) string {
+	if len(trends) == 0 {
+		return "📊 Пока нет отметок самочувствия. Расскажи, как ты: уровень стресса, качество сна и баланс работы и жизни от 1 до 5."
+	}
+
+	var b strings.Builder
+	b.WriteString("📊 Динамика самочувствия по неделям:\n")
+	for _, t := range trends {
+		b.WriteString(fmt.Sprintf("\nНеделя с %s (%d отм.)\n", t.WeekStart.Format("02.01"), t.CheckIns))
+		b.WriteString(fmt.Sprintf("  Стресс: %s\n", scoreBar(t.AvgStress)))
+		b.WriteString(fmt.Sprintf("  Сон:    %s\n", scoreBar(t.AvgSleep)))
+		b.WriteString(fmt.Sprintf("  Баланс: %s\n", scoreBar(t.AvgBalance)))
+	}
+	return b.String()
+}
+
+func FormatRisk(risk float64) string {
+	switch {
+	case risk >= 0.7:
+		return fmt.Sprintf("🔴 Высокий риск выгорания: %.0f%%", risk*100)
+	case risk >= 0.4:
+		return fmt.Sprintf("🟡 Умеренный риск выгорания: %.0f%%", risk*100)
+	default:
+		return fmt.Sprintf("🟢 Низкий риск выгорания: %.0f%%", risk*100)
+	}
+}
@@ -0,0 +1,317 @@
+package worklocation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	LocationOffice	= "office"
+	LocationHome	= "home"
+	LocationTrip	= "trip"
+)
+
+type Service struct {
+	db *sqlx.DB
+}
+
+type DayLocation struct {
+	Date		time.Time	`json:"date"`
+	Location	string		`json:"location"`
+	Note		*string		`json:"note,omitempty"`
+	FromPattern	bool		`json:"from_pattern"`
+}
+
+type Pattern struct {
+	Weekday		int		`db:"weekday" json:"weekday"`
+	Location	string		`db:"location" json:"location"`
+	UpdatedAt	time.Time	`db:"updated_at" json:"updated_at"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun":	time.Sunday,
+	"mon":	time.Monday,
+	"tue":	time.Tuesday,
+	"wed":	time.Wednesday,
+	"thu":	time.Thursday,
+	"fri":	time.Friday,
+	"sat":	time.Saturday,
+	"вс":	time.Sunday,
+	"пн":	time.Monday,
+	"вт":	time.Tuesday,
+	"ср":	time.Wednesday,
+	"чт":	time.Thursday,
+	"пт":	time.Friday,
+	"сб":	time.Saturday,
+	"вос":	time.Sunday,
+	"пон":	time.Monday,
+	"вто":	time.Tuesday,
+	"сре":	time.Wednesday,
+	"чет":	time.Thursday,
+	"пят":	time.Friday,
+	"суб":	time.Saturday,
+}
+
+var weekdayLabels = []string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+func NewService(db *sqlx.DB) *Service {
+	return &Service{db: db}
+}
+
+func ValidateLocation(location string) error {
+	if location != LocationOffice && location != LocationHome && location != LocationTrip {
+		return fmt.Errorf("неверное место работы: %s. Допустимые значения: office, home, trip", location)
+	}
+	return nil
+}
+
+func IsRemote(location string) bool {
+	return location == LocationHome || location == LocationTrip
+}
+
+func LocationLabel(location string) string {
+	switch location {
+	case LocationOffice:
+		return "🏢 офис"
+	case LocationHome:
+		return "🏠 из дома"
+	case LocationTrip:
+		return "✈️ командировка"
+	default:
+		return "❔ не указано"
+	}
+}
+
+func WeekdayLabel(weekday int) string {
+	if weekday < 0 || weekday > 6 {
+		return ""
+	}
+	return weekdayLabels[weekday]
+}
+
+func ParseWeekdays(values []string) ([]int, error) {
+	var result []int
+	seen := make(map[int]bool)
+
+	for _, value := range values {
+		key := []rune(strings.ToLower(strings.TrimSpace(value)))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+
+		weekday, ok := weekdayNames[string(key)]
+		if !ok {
+			return nil, fmt.Errorf("некорректный день недели: %s", value)
+		}
+
+		if !seen[int(weekday)] {
+			seen[int(weekday)] = true
+			result = append(result, int(weekday))
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("не указаны дни недели")
+	}
+
+	return result, nil
+}
+
+func dayStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+func (s *Service) SetLocation(ctx context.Context, userID int64, date time.Time, location, note string) error {
+	if err := ValidateLocation(location); err != nil {
+		return err
+	}
+
+	var notePtr *string
+	if note != "" {
+		notePtr = &note
+	}
+
+	query := `
+		INSERT INTO work_locations (user_id, work_date, location, note)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, work_date) DO UPDATE
+		SET location = $3, note = $4
+	`
+
+	_, err := s.db.ExecContext(ctx, query, userID, dayStart(date).Format("2006-01-02"), location, notePtr)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении места работы: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ClearLocation(ctx context.Context, userID int64, date time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM work_locations WHERE user_id = $1 AND work_date = $2`,
+		userID, dayStart(date).Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении места работы: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) SetPattern(ctx context.Context, userID int64, weekdays []int, location string) (err error) {
+	if location != "" {
+		if err := ValidateLocation(location); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, weekday := range weekdays {
+		if location == "" {
+			_, err = tx.ExecContext(ctx, `DELETE FROM work_location_patterns WHERE user_id = $1 AND weekday = $2`, userID, weekday)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO work_location_patterns (user_id, weekday, location)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (user_id, weekday) DO UPDATE
+				SET location = $3
+			`, userID, weekday, location)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении графика работы: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при сохранении графика работы: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetPatterns(ctx context.Context, userID int64) ([]Pattern, error) {
+	query := `
+		SELECT weekday, location, updated_at
+		FROM work_location_patterns
+		WHERE user_id = $1
+		ORDER BY weekday
+	`
+
+	var patterns []Pattern
+	err := s.db.SelectContext(ctx, &patterns, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении графика работы: %v", err)
+	}
+
+	return patterns, nil
+}
+
+func (s *Service) GetLocation(ctx context.Context, userID int64, date time.Time) (*DayLocation, error) {
+	locations, err := s.GetLocations(ctx, userID, date, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return nil, nil
+	}
+
+	return &locations[0], nil
+}
+
+func (s *Service) GetLocations(ctx context.Context, userID int64, from time.Time, days int) ([]DayLocation, error) {
+	start := dayStart(from)
+	end := start.AddDate(0, 0, days)
+
+	var explicit []struct {
+		WorkDate	time.Time	`db:"work_date"`
+		Location	string		`db:"location"`
+		Note		*string		`db:"note"`
+	}
+	err := s.db.SelectContext(ctx, &explicit, `
+		SELECT work_date, location, note
+		FROM work_locations
+		WHERE user_id = $1 AND work_date >= $2 AND work_date < $3
+	`, userID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении мест работы: %v", err)
+	}
+
+	patterns, err := s.GetPatterns(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]DayLocation)
+	for _, e := range explicit {
+		byDate[e.WorkDate.Format("2006-01-02")] = DayLocation{Location: e.Location, Note: e.Note}
+	}
+
+	byWeekday := make(map[int]string)
+	for _, p := range patterns {
+		byWeekday[p.Weekday] = p.Location
+	}
+
+	var result []DayLocation
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if loc, ok := byDate[day.Format("2006-01-02")]; ok {
+			loc.Date = day
+			result = append(result, loc)
+			continue
+		}
+		if location, ok := byWeekday[int(day.Weekday())]; ok {
+			result = append(result, DayLocation{Date: day, Location: location, FromPattern: true})
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Service) GetTrackingUsers(ctx context.Context) ([]int64, error) {
+	query := `
+		SELECT user_id FROM work_location_patterns
+		UNION
+		SELECT user_id FROM work_locations WHERE work_date >= CURRENT_DATE
+	`
+
+	var userIDs []int64
+	err := s.db.SelectContext(ctx, &userIDs, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении пользователей с графиком работы: %v", err)
+	}
+
+	return userIDs, nil
+}
+
+func (s *Service) CheckInPersonAvailability(ctx context.Context, userID int64, date time.Time) (*DayLocation, error) {
+	location, err := s.GetLocation(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if location != nil && IsRemote(location.Location) {
+		return location, nil
+	}
+
+	return nil, nil
+}
+
+func FormatDayLocation(loc DayLocation) string {
+	text := fmt.Sprintf("%s %s — %s", WeekdayLabel(int(loc.Date.Weekday())), loc.Date.Format("02.01"), LocationLabel(loc.Location))
+	if loc.Note != nil && *loc.Note != "" {
+		text += fmt.Sprintf(" (%s)", *loc.Note)
+	}
+	if loc.FromPattern {
+		text += " · по графику"
+	}
+	return text
+}
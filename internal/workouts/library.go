@@ -0,0 +1,156 @@
+package workouts
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FocusStrength	= "strength"
+	FocusCardio	= "cardio"
+	FocusWeightLoss	= "weight_loss"
+	FocusGeneral	= "general"
+)
+
+const (
+	EquipmentNone		= "none"
+	EquipmentDumbbells	= "dumbbells"
+	EquipmentBands		= "bands"
+	EquipmentPullupBar	= "pullup_bar"
+	EquipmentGym		= "gym"
+)
+
+type sessionTemplate struct {
+	Title		string
+	Exercises	[]string
+}
+
+var focusKeywords = []struct {
+	Focus		string
+	Keywords	[]string
+}{
+	{FocusCardio, []string{"бег", "км", "марафон", "пробеж", "run", "плав", "вело", "кардио", "выносл"}},
+	{FocusWeightLoss, []string{"похуд", "вес", "кг", "сбросить", "жир", "талия"}},
+	{FocusStrength, []string{"подтяг", "отжим", "жим", "присед", "сил", "мышц", "масс", "пресс"}},
+}
+
+func DetectFocus(text string) string {
+	text = strings.ToLower(text)
+	for _, f := range focusKeywords {
+		for _, keyword := range f.Keywords {
+			if strings.Contains(text, keyword) {
+				return f.Focus
+			}
+		}
+	}
+	return FocusGeneral
+}
+
+func FocusLabel(focus string) string {
+	switch focus {
+	case FocusStrength:
+		return "💪 сила"
+	case FocusCardio:
+		return "🏃 выносливость"
+	case FocusWeightLoss:
+		return "🔥 снижение веса"
+	default:
+		return "🤸 общая форма"
+	}
+}
+
+func hasEquipment(equipment []string, item string) bool {
+	for _, e := range equipment {
+		if e == item || e == EquipmentGym {
+			return true
+		}
+	}
+	return false
+}
+
+func strengthExercises(equipment []string, upper bool) []string {
+	switch {
+	case hasEquipment(equipment, EquipmentGym) && upper:
+		return []string{"Жим штанги лежа 4×8", "Тяга верхнего блока 4×10", "Жим гантелей сидя 3×10", "Тяга гантели в наклоне 3×10", "Планка 3×45 сек"}
+	case hasEquipment(equipment, EquipmentGym):
+		return []string{"Приседания со штангой 4×8", "Румынская тяга 4×8", "Жим ногами 3×12", "Выпады с гантелями 3×10", "Подъемы на носки 3×15"}
+	case upper && hasEquipment(equipment, EquipmentPullupBar):
+		return []string{"Подтягивания 4×макс", "Отжимания 4×12", "Австралийские подтягивания 3×10", "Отжимания с узкой постановкой 3×10", "Планка 3×45 сек"}
+	case upper && hasEquipment(equipment, EquipmentDumbbells):
+		return []string{"Жим гантелей лежа на полу 4×10", "Тяга гантели в наклоне 4×10", "Жим гантелей стоя 3×10", "Отжимания 3×12", "Планка 3×45 сек"}
+	case upper && hasEquipment(equipment, EquipmentBands):
+		return []string{"Тяга резины к поясу 4×15", "Отжимания 4×12", "Жим резины над головой 3×15", "Разведения с резиной 3×15", "Планка 3×45 сек"}
+	case upper:
+		return []string{"Отжимания 4×12", "Отжимания от стула на трицепс 3×12", "Пайк-отжимания 3×8", "Супермен 3×15", "Планка 3×45 сек"}
+	case hasEquipment(equipment, EquipmentDumbbells):
+		return []string{"Гоблет-приседания 4×12", "Румынская тяга с гантелями 4×10", "Выпады с гантелями 3×10", "Ягодичный мост 3×15", "Подъемы на носки 3×20"}
+	default:
+		return []string{"Приседания 4×20", "Выпады назад 3×12", "Болгарские сплит-приседания 3×10", "Ягодичный мост 3×20", "Подъемы на носки 3×25"}
+	}
+}
+
+func cardioSession(long bool, durationMinutes int) []string {
+	if long {
+		return []string{"Разминка 10 мин", "Длительный бег в разговорном темпе " + minutes(durationMinutes-15), "Заминка и растяжка 5 мин"}
+	}
+	return []string{"Разминка 10 мин", "Интервалы 6×(2 мин быстро / 2 мин легко)", "Легкий бег " + minutes(durationMinutes-34), "Растяжка 5 мин"}
+}
+
+func circuitSession(equipment []string) []string {
+	if hasEquipment(equipment, EquipmentDumbbells) {
+		return []string{"Круг ×4: трастеры с гантелями 12", "берпи 10", "махи гантелью 15", "скалолаз 30 сек", "отдых 90 сек между кругами"}
+	}
+	return []string{"Круг ×4: приседания 20", "берпи 10", "отжимания 12", "скалолаз 30 сек", "прыжки Джек 40", "отдых 90 сек между кругами"}
+}
+
+func minutes(m int) string {
+	if m < 10 {
+		m = 10
+	}
+	return fmt.Sprintf("%d мин", m)
+}
+
+func BuildWeek(focus string, equipment []string, sessions, durationMinutes int) []sessionTemplate {
+	var week []sessionTemplate
+	for i := 0; i < sessions; i++ {
+		var t sessionTemplate
+		switch focus {
+		case FocusStrength:
+			upper := i%2 == 0
+			t.Title = "Силовая: верх тела"
+			if !upper {
+				t.Title = "Силовая: ноги и корпус"
+			}
+			t.Exercises = strengthExercises(equipment, upper)
+		case FocusCardio:
+			long := i == sessions-1 && sessions > 1
+			t.Title = "Кардио: интервалы"
+			if long {
+				t.Title = "Кардио: длительная"
+			}
+			t.Exercises = cardioSession(long, durationMinutes)
+		case FocusWeightLoss:
+			if i%2 == 0 {
+				t.Title = "Круговая тренировка"
+				t.Exercises = circuitSession(equipment)
+			} else {
+				t.Title = "Силовая: все тело"
+				t.Exercises = append(strengthExercises(equipment, false)[:3], strengthExercises(equipment, true)[:2]...)
+			}
+		default:
+			switch i % 3 {
+			case 0:
+				t.Title = "Силовая: все тело"
+				t.Exercises = append(strengthExercises(equipment, false)[:3], strengthExercises(equipment, true)[:2]...)
+			case 1:
+				t.Title = "Кардио"
+				t.Exercises = cardioSession(false, durationMinutes)
+			default:
+				t.Title = "Мобильность и кор"
+				t.Exercises = []string{"Суставная разминка 10 мин", "Планка 3×45 сек", "Боковая планка 3×30 сек", "Мертвый жук 3×12", "Растяжка всего тела 15 мин"}
+			}
+		}
+		week = append(week, t)
+	}
+	return week
+}
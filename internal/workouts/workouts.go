@@ -0,0 +1,464 @@
+package workouts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"telegrambot/internal/calendar"
+	"telegrambot/internal/okr"
+	"telegrambot/internal/worklocation"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPlanNotFound		= errors.New("активный план тренировок не найден")
+	ErrSessionNotFound	= errors.New("тренировка не найдена")
+	ErrNoSessions		= errors.New("в выбранном периоде нет дней для тренировок")
+)
+
+const (
+	StatusPlanned	= "planned"
+	StatusDone	= "done"
+	StatusSkipped	= "skipped"
+)
+
+type Service struct {
+	db		*sqlx.DB
+	calendar	*calendar.Service
+	okr		*okr.Service
+}
+
+type Plan struct {
+	ID		int64		`db:"id" json:"id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	KeyResultID	*int64		`db:"key_result_id" json:"key_result_id,omitempty"`
+	Focus		string		`db:"focus" json:"focus"`
+	Equipment	pq.StringArray	`db:"equipment" json:"equipment"`
+	Weekdays	pq.Int64Array	`db:"weekdays" json:"weekdays"`
+	SessionTime	string		`db:"session_time" json:"session_time"`
+	DurationMinutes	int		`db:"duration_minutes" json:"duration_minutes"`
+	StartsOn	time.Time	`db:"starts_on" json:"starts_on"`
+	EndsOn		time.Time	`db:"ends_on" json:"ends_on"`
+	CreatedAt	time.Time	`db:"created_at" json:"created_at"`
+}
+
+type Session struct {
+	ID		int64		`db:"id" json:"id"`
+	PlanID		int64		`db:"plan_id" json:"plan_id"`
+	UserID		int64		`db:"user_id" json:"user_id"`
+	EventID		*string		`db:"event_id" json:"event_id,omitempty"`
+	Title		string		`db:"title" json:"title"`
+	Exercises	pq.StringArray	`db:"exercises" json:"exercises"`
+	ScheduledAt	time.Time	`db:"scheduled_at" json:"scheduled_at"`
+	Status		string		`db:"status" json:"status"`
+	ProgressValue	*float64	`db:"progress_value" json:"progress_value,omitempty"`
+	CompletedAt	*time.Time	`db:"completed_at" json:"completed_at,omitempty"`
+}
+
+type HealthKeyResult struct {
+	ID		int64	`db:"id"`
+	Title		string	`db:"title"`
+	Unit		string	`db:"unit"`
+	Target		float64	`db:"target"`
+	Progress	float64	`db:"progress"`
+	Objective	string	`db:"objective_title"`
+}
+
+type PlanRequest struct {
+	KeyResultID	*int64
+	Weekdays	[]int
+	Equipment	[]string
+	SessionTime	string
+	DurationMinutes	int
+	Weeks		int
+}
+
+type CompletionResult struct {
+	Session		Session
+	KeyResult	*okr.KeyResult
+	Delta		float64
+}
+
+const sessionSelect = `
+	SELECT id, plan_id, user_id, event_id, title, exercises, scheduled_at, status, progress_value, completed_at
+	FROM workout_sessions
+`
+
+const planSelect = `
+	SELECT id, user_id, key_result_id, focus, equipment, weekdays, session_time, duration_minutes, starts_on, ends_on, created_at
+	FROM workout_plans
+`
+
+func NewService(db *sqlx.DB, calendarService *calendar.Service, okrService *okr.Service) *Service {
+	return &Service{
+		db:		db,
+		calendar:	calendarService,
+		okr:		okrService,
+	}
+}
+
+func (s *Service) GetHealthKeyResults(ctx context.Context, userID int64) ([]HealthKeyResult, error) {
+	var keyResults []HealthKeyResult
+	err := s.db.SelectContext(ctx, &keyResults, `
+		SELECT kr.id, kr.title, COALESCE(kr.unit, '') AS unit, kr.target, COALESCE(kr.progress, 0) AS progress, o.title AS objective_title
+		FROM key_results kr
+		JOIN objectives o ON o.id = kr.objective_id
+		WHERE o.user_id = $1 AND kr.deleted_at IS NULL AND COALESCE(kr.status, 'active') = 'active'
+			AND (o.sphere ILIKE ANY (ARRAY['%здоров%', '%спорт%', '%фитнес%', '%health%', '%sport%', '%fitness%'])
+				OR kr.title ILIKE ANY (ARRAY['%трениров%', '%бег%', '%подтяг%', '%отжим%', '%похуд%']))
+		ORDER BY kr.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ключевых результатов здоровья: %v", err)
+	}
+	return keyResults, nil
+}
+
+func normalizeSessionTime(value string) (string, error) {
+	if value == "" {
+		return "19:00", nil
+	}
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("некорректное время тренировки: %s. Используйте формат ЧЧ:ММ", value)
+	}
+	return t.Format("15:04"), nil
+}
+
+func sessionDates(weekdays []int, sessionTime string, weeks int, from time.Time) []time.Time {
+	t, _ := time.Parse("15:04", sessionTime)
+	allowed := make(map[time.Weekday]bool)
+	for _, d := range weekdays {
+		allowed[time.Weekday(d)] = true
+	}
+
+	var dates []time.Time
+	for i := 0; i < weeks*7; i++ {
+		day := from.AddDate(0, 0, i)
+		if !allowed[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, from.Location())
+		if start.After(from) {
+			dates = append(dates, start)
+		}
+	}
+	return dates
+}
+
+func (s *Service) GeneratePlan(ctx context.Context, userID int64, req PlanRequest) (*Plan, []Session, *HealthKeyResult, error) {
+	if len(req.Weekdays) == 0 {
+		return nil, nil, nil, fmt.Errorf("укажите дни недели для тренировок")
+	}
+	if len(req.Weekdays) > 6 {
+		return nil, nil, nil, fmt.Errorf("нужен хотя бы один день отдыха в неделю")
+	}
+
+	sessionTime, err := normalizeSessionTime(req.SessionTime)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if req.DurationMinutes == 0 {
+		req.DurationMinutes = 45
+	}
+	if req.DurationMinutes < 20 || req.DurationMinutes > 120 {
+		return nil, nil, nil, fmt.Errorf("длительность тренировки должна быть от 20 до 120 минут")
+	}
+	if req.Weeks <= 0 {
+		req.Weeks = 1
+	}
+	if req.Weeks > 4 {
+		req.Weeks = 4
+	}
+	if len(req.Equipment) == 0 {
+		req.Equipment = []string{EquipmentNone}
+	}
+
+	keyResults, err := s.GetHealthKeyResults(ctx, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var target *HealthKeyResult
+	if req.KeyResultID != nil {
+		for i := range keyResults {
+			if keyResults[i].ID == *req.KeyResultID {
+				target = &keyResults[i]
+				break
+			}
+		}
+		if target == nil {
+			kr, err := s.okr.GetKeyResultByID(ctx, userID, *req.KeyResultID)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			target = &HealthKeyResult{ID: kr.ID, Title: kr.Title, Unit: kr.Unit, Target: kr.Target, Progress: kr.Progress}
+		}
+	} else if len(keyResults) > 0 {
+		target = &keyResults[0]
+	}
+
+	focus := FocusGeneral
+	var keyResultID *int64
+	if target != nil {
+		focus = DetectFocus(target.Title + " " + target.Objective)
+		keyResultID = &target.ID
+	}
+
+	dates := sessionDates(req.Weekdays, sessionTime, req.Weeks, time.Now())
+	if len(dates) == 0 {
+		return nil, nil, nil, ErrNoSessions
+	}
+	week := BuildWeek(focus, req.Equipment, len(req.Weekdays), req.DurationMinutes)
+
+	if err := s.deactivatePlans(ctx, userID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var createdEvents []string
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ошибка при начале транзакции: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			for _, eventID := range createdEvents {
+				if delErr := s.calendar.DeleteEvent(context.Background(), userID, eventID); delErr != nil {
+					logrus.Warnf("Не удалось удалить событие %s после ошибки создания плана тренировок: %v", eventID, delErr)
+				}
+			}
+		}
+	}()
+
+	weekdays := make(pq.Int64Array, 0, len(req.Weekdays))
+	for _, d := range req.Weekdays {
+		weekdays = append(weekdays, int64(d))
+	}
+
+	var plan Plan
+	err = tx.GetContext(ctx, &plan, `
+		INSERT INTO workout_plans (user_id, key_result_id, focus, equipment, weekdays, session_time, duration_minutes, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, user_id, key_result_id, focus, equipment, weekdays, session_time, duration_minutes, starts_on, ends_on, created_at
+	`, userID, keyResultID, focus, pq.Array(req.Equipment), weekdays, sessionTime, req.DurationMinutes,
+		dates[0].Format("2006-01-02"), dates[len(dates)-1].Format("2006-01-02"))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ошибка при сохранении плана тренировок: %v", err)
+	}
+
+	var sessions []Session
+	for i, start := range dates {
+		template := week[i%len(week)]
+		end := start.Add(time.Duration(req.DurationMinutes) * time.Minute)
+
+		description := "Упражнения:\n• " + strings.Join(template.Exercises, "\n• ")
+		if target != nil {
+			description += "\n\nЦель: " + target.Title
+		}
+
+		var eventID string
+		eventID, err = s.calendar.CreateEvent(ctx, userID, "🏋️ "+template.Title, description,
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("ошибка при создании события в календаре: %v", err)
+		}
+		createdEvents = append(createdEvents, eventID)
+
+		var session Session
+		err = tx.GetContext(ctx, &session, `
+			INSERT INTO workout_sessions (plan_id, user_id, event_id, title, exercises, scheduled_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, plan_id, user_id, event_id, title, exercises, scheduled_at, status, progress_value, completed_at
+		`, plan.ID, userID, eventID, template.Title, pq.Array(template.Exercises), start)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("ошибка при сохранении тренировки: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, nil, fmt.Errorf("ошибка при сохранении плана тренировок: %v", err)
+	}
+
+	return &plan, sessions, target, nil
+}
+
+func (s *Service) deactivatePlans(ctx context.Context, userID int64) error {
+	var upcoming []Session
+	err := s.db.SelectContext(ctx, &upcoming, sessionSelect+`
+		WHERE user_id = $1 AND status = $2 AND scheduled_at > NOW()
+			AND plan_id IN (SELECT id FROM workout_plans WHERE user_id = $1 AND is_active = TRUE)
+	`, userID, StatusPlanned)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении запланированных тренировок: %v", err)
+	}
+
+	for _, session := range upcoming {
+		if session.EventID == nil {
+			continue
+		}
+		if err := s.calendar.DeleteEvent(ctx, userID, *session.EventID); err != nil {
+			logrus.Warnf("Не удалось удалить событие тренировки %d: %v", session.ID, err)
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		DELETE FROM workout_sessions
+		WHERE user_id = $1 AND status = $2 AND scheduled_at > NOW()
+			AND plan_id IN (SELECT id FROM workout_plans WHERE user_id = $1 AND is_active = TRUE)
+	`, userID, StatusPlanned)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении старых тренировок: %v", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE workout_plans SET is_active = FALSE WHERE user_id = $1 AND is_active = TRUE`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при закрытии старого плана: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetActivePlan(ctx context.Context, userID int64) (*Plan, []Session, error) {
+	var plan Plan
+	err := s.db.GetContext(ctx, &plan, planSelect+`
+		WHERE user_id = $1 AND is_active = TRUE
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при получении плана тренировок: %v", err)
+	}
+
+	var sessions []Session
+	err = s.db.SelectContext(ctx, &sessions, sessionSelect+`
+		WHERE plan_id = $1
+		ORDER BY scheduled_at
+	`, plan.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при получении тренировок: %v", err)
+	}
+
+	return &plan, sessions, nil
+}
+
+func countsSessions(unit string) bool {
+	unit = strings.ToLower(unit)
+	for _, keyword := range []string{"трениров", "заняти", "раз", "сесси", "workout", "session"} {
+		if strings.Contains(unit, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) findSessionForCompletion(ctx context.Context, userID, sessionID int64) (*Session, error) {
+	var session Session
+	var err error
+	if sessionID > 0 {
+		err = s.db.GetContext(ctx, &session, sessionSelect+`WHERE id = $1 AND user_id = $2`, sessionID, userID)
+	} else {
+		err = s.db.GetContext(ctx, &session, sessionSelect+`
+			WHERE user_id = $1 AND status = $2
+				AND scheduled_at BETWEEN NOW() - INTERVAL '36 hours' AND NOW() + INTERVAL '12 hours'
+			ORDER BY ABS(EXTRACT(EPOCH FROM scheduled_at - NOW()))
+			LIMIT 1
+		`, userID, StatusPlanned)
+	}
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске тренировки: %v", err)
+	}
+	return &session, nil
+}
+
+func (s *Service) CompleteSession(ctx context.Context, userID, sessionID int64, done bool, value *float64) (*CompletionResult, error) {
+	session, err := s.findSessionForCompletion(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := StatusSkipped
+	if done {
+		status = StatusDone
+	}
+
+	var plan Plan
+	if err := s.db.GetContext(ctx, &plan, planSelect+`WHERE id = $1`, session.PlanID); err != nil {
+		return nil, fmt.Errorf("ошибка при получении плана тренировок: %v", err)
+	}
+
+	err = s.db.GetContext(ctx, session, `
+		UPDATE workout_sessions
+		SET status = $1, progress_value = $2, completed_at = CASE WHEN $1 = 'done' THEN NOW() ELSE NULL END
+		WHERE id = $3 AND user_id = $4
+		RETURNING id, plan_id, user_id, event_id, title, exercises, scheduled_at, status, progress_value, completed_at
+	`, status, value, session.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при отметке тренировки: %v", err)
+	}
+
+	minutes, percentage := 0, 0.0
+	if done {
+		minutes, percentage = plan.DurationMinutes, 100
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO habit_tracking (user_id, key_result_id, date, completed, completion_percentage, time_spent_minutes, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, userID, plan.KeyResultID, session.ScheduledAt.Format("2006-01-02"), done, percentage, minutes, "Тренировка: "+session.Title)
+	if err != nil {
+		logrus.Warnf("Не удалось записать тренировку %d в трекер привычек: %v", session.ID, err)
+	}
+
+	result := &CompletionResult{Session: *session}
+	if !done || plan.KeyResultID == nil {
+		return result, nil
+	}
+
+	kr, err := s.okr.GetKeyResultByID(ctx, userID, *plan.KeyResultID)
+	if err != nil {
+		logrus.Warnf("Ключевой результат %d плана тренировок недоступен: %v", *plan.KeyResultID, err)
+		return result, nil
+	}
+	result.KeyResult = kr
+
+	switch {
+	case value != nil && *value > 0:
+		result.Delta = *value
+	case countsSessions(kr.Unit):
+		result.Delta = 1
+	}
+
+	if result.Delta > 0 {
+		if _, err := s.okr.UpdateKeyResultProgress(ctx, userID, kr.ID, result.Delta); err != nil {
+			return nil, fmt.Errorf("тренировка отмечена, но не удалось обновить ключевой результат: %v", err)
+		}
+		kr.Progress += result.Delta
+	}
+
+	return result, nil
+}
+
+func FormatSession(session Session) string {
+	icon := "🗓"
+	switch session.Status {
+	case StatusDone:
+		icon = "✅"
+	case StatusSkipped:
+		icon = "⏭"
+	}
+	return fmt.Sprintf("%s #%d %s %s — %s", icon, session.ID, worklocation.WeekdayLabel(int(session.ScheduledAt.Weekday())),
+		session.ScheduledAt.Format("02.01 15:04"), session.Title)
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS important_dates (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title               VARCHAR(255) NOT NULL,
+    person_name         VARCHAR(255),
+    date_type           VARCHAR(20) NOT NULL DEFAULT 'birthday', -- birthday, anniversary, other
+    month               SMALLINT NOT NULL CHECK (month >= 1 AND month <= 12),
+    day                 SMALLINT NOT NULL CHECK (day >= 1 AND day <= 31),
+    year                INTEGER,
+    remind_days_before  INTEGER NOT NULL DEFAULT 3 CHECK (remind_days_before >= 0 AND remind_days_before <= 60),
+    last_advance_sent   DATE,  -- дата события, для которой уже отправлено заблаговременное напоминание
+    last_day_sent       DATE,  -- дата события, для которой уже отправлено поздравление в сам день
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_important_dates
+BEFORE UPDATE ON important_dates
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE INDEX IF NOT EXISTS important_dates_user_id_idx     ON important_dates(user_id);
+CREATE INDEX IF NOT EXISTS important_dates_month_day_idx   ON important_dates(month, day);
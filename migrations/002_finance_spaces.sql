@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS finance_spaces (
+    id          VARCHAR(36) PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    owner_id    BIGINT NOT NULL REFERENCES users(id),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_finance_spaces
+BEFORE UPDATE ON finance_spaces
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS finance_space_members (
+    space_id    VARCHAR(36) NOT NULL REFERENCES finance_spaces(id) ON DELETE CASCADE,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role        VARCHAR(20) NOT NULL DEFAULT 'editor', -- owner, editor, viewer
+    status      VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, active, declined
+    invited_by  BIGINT REFERENCES users(id),
+    notified    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (space_id, user_id)
+);
+
+CREATE TRIGGER set_timestamp_finance_space_members
+BEFORE UPDATE ON finance_space_members
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS finance_space_categories (
+    id          BIGSERIAL PRIMARY KEY,
+    space_id    VARCHAR(36) NOT NULL REFERENCES finance_spaces(id) ON DELETE CASCADE,
+    name        VARCHAR(255) NOT NULL,
+    created_by  BIGINT REFERENCES users(id),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (space_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS finance_budgets (
+    id             BIGSERIAL PRIMARY KEY,
+    space_id       VARCHAR(36) NOT NULL REFERENCES finance_spaces(id) ON DELETE CASCADE,
+    category       VARCHAR(255) NOT NULL,
+    monthly_limit  DECIMAL(12,2) NOT NULL CHECK (monthly_limit > 0),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (space_id, category)
+);
+
+CREATE TRIGGER set_timestamp_finance_budgets
+BEFORE UPDATE ON finance_budgets
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS space_id VARCHAR(36) REFERENCES finance_spaces(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS transactions_space_id_idx           ON transactions(space_id);
+CREATE INDEX IF NOT EXISTS finance_space_members_user_id_idx   ON finance_space_members(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS finance_space_members_one_active_idx ON finance_space_members(user_id) WHERE status = 'active';
@@ -0,0 +1,16 @@
+ALTER TABLE key_results ADD COLUMN IF NOT EXISTS owner_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE key_results ADD COLUMN IF NOT EXISTS owner_notified BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE key_results ADD COLUMN IF NOT EXISTS last_owner_nudge TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS key_result_progress_log (
+    id             BIGSERIAL PRIMARY KEY,
+    key_result_id  BIGINT NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+    user_id        BIGINT NOT NULL REFERENCES users(id),
+    delta          DECIMAL(12,2) NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS key_results_owner_id_idx                 ON key_results(owner_id);
+CREATE INDEX IF NOT EXISTS key_result_progress_log_key_result_id_idx ON key_result_progress_log(key_result_id);
+CREATE INDEX IF NOT EXISTS shared_objectives_team_id_idx            ON shared_objectives(team_id);
+CREATE INDEX IF NOT EXISTS team_members_user_id_idx                 ON team_members(user_id);
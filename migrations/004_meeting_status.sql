@@ -0,0 +1,10 @@
+ALTER TABLE meetings ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending'; -- pending, accepted, declined
+ALTER TABLE meetings ADD COLUMN IF NOT EXISTS responded_at TIMESTAMPTZ;
+ALTER TABLE meetings ADD COLUMN IF NOT EXISTS participant_notified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE meetings ADD COLUMN IF NOT EXISTS initiator_notified BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE meetings SET status = 'accepted' WHERE confirmed = TRUE AND status = 'pending';
+UPDATE meetings SET participant_notified = TRUE WHERE created_at < NOW();
+
+CREATE INDEX IF NOT EXISTS meetings_participant_id_idx ON meetings(participant_id);
+CREATE INDEX IF NOT EXISTS meetings_initiator_id_idx   ON meetings(initiator_id);
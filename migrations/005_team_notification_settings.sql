@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS team_notification_settings (
+    team_id            BIGINT PRIMARY KEY REFERENCES user_teams(id) ON DELETE CASCADE,
+    channel            VARCHAR(20) NOT NULL DEFAULT 'telegram', -- telegram, slack, both
+    slack_webhook_url  TEXT,
+    slack_bot_token    TEXT,
+    slack_channel      VARCHAR(255),
+    weekly_report      BOOLEAN NOT NULL DEFAULT TRUE,
+    last_report_sent   TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_team_notification_settings
+BEFORE UPDATE ON team_notification_settings
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+ALTER TABLE shared_objectives ADD COLUMN IF NOT EXISTS completion_notified BOOLEAN NOT NULL DEFAULT FALSE;
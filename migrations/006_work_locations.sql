@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS work_locations (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    work_date   DATE NOT NULL,
+    location    VARCHAR(20) NOT NULL, -- office, home, trip
+    note        TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, work_date)
+);
+
+CREATE TRIGGER set_timestamp_work_locations
+BEFORE UPDATE ON work_locations
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS work_location_patterns (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    weekday     SMALLINT NOT NULL CHECK (weekday >= 0 AND weekday <= 6), -- 0 = воскресенье
+    location    VARCHAR(20) NOT NULL, -- office, home, trip
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, weekday)
+);
+
+CREATE TRIGGER set_timestamp_work_location_patterns
+BEFORE UPDATE ON work_location_patterns
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE INDEX IF NOT EXISTS work_locations_user_date_idx ON work_locations(user_id, work_date);
+
+ALTER TABLE meetings ADD COLUMN IF NOT EXISTS in_person BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS trips (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title        VARCHAR(255) NOT NULL,
+    destination  VARCHAR(255),
+    start_time   TIMESTAMPTZ NOT NULL,
+    end_time     TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_trips
+BEFORE UPDATE ON trips
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS trip_items (
+    id                 BIGSERIAL PRIMARY KEY,
+    trip_id            BIGINT NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id           VARCHAR(36) REFERENCES events(id) ON DELETE SET NULL,
+    item_type          VARCHAR(20) NOT NULL, -- flight, hotel, train, other
+    title              VARCHAR(255) NOT NULL,
+    provider           VARCHAR(255),
+    confirmation_code  VARCHAR(64),
+    location           TEXT,
+    start_time         TIMESTAMPTZ NOT NULL,
+    end_time           TIMESTAMPTZ NOT NULL,
+    start_timezone     VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    end_timezone       VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    checkin_remind_at  TIMESTAMPTZ,
+    checkin_reminded   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_trip_items
+BEFORE UPDATE ON trip_items
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE INDEX IF NOT EXISTS trips_user_id_idx           ON trips(user_id);
+CREATE INDEX IF NOT EXISTS trip_items_trip_id_idx      ON trip_items(trip_id);
+CREATE INDEX IF NOT EXISTS trip_items_checkin_idx      ON trip_items(checkin_remind_at) WHERE checkin_reminded = FALSE;
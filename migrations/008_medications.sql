@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS medications (
+    id                 BIGSERIAL PRIMARY KEY,
+    user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name               VARCHAR(255) NOT NULL,
+    dosage             VARCHAR(100),
+    times              TEXT[] NOT NULL, -- время приема в формате HH:MM
+    start_date         DATE NOT NULL DEFAULT CURRENT_DATE,
+    end_date           DATE,
+    is_active          BOOLEAN NOT NULL DEFAULT TRUE,
+    missed_streak      INTEGER NOT NULL DEFAULT 0,
+    last_escalated_at  TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_medications
+BEFORE UPDATE ON medications
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS medication_doses (
+    id             BIGSERIAL PRIMARY KEY,
+    medication_id  BIGINT NOT NULL REFERENCES medications(id) ON DELETE CASCADE,
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scheduled_at   TIMESTAMPTZ NOT NULL,
+    status         VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, taken, skipped, missed
+    taken_at       TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (medication_id, scheduled_at)
+);
+
+CREATE INDEX IF NOT EXISTS medications_user_id_idx          ON medications(user_id);
+CREATE INDEX IF NOT EXISTS medication_doses_user_time_idx   ON medication_doses(user_id, scheduled_at);
+CREATE INDEX IF NOT EXISTS medication_doses_pending_idx     ON medication_doses(scheduled_at) WHERE status = 'pending';
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS habit_counters (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind           VARCHAR(20) NOT NULL, -- water, steps, pages
+    step           DECIMAL(12,2) NOT NULL, -- на сколько увеличивает одно нажатие
+    daily_goal     DECIMAL(12,2) NOT NULL,
+    key_result_id  BIGINT REFERENCES key_results(id) ON DELETE SET NULL,
+    is_active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, kind)
+);
+
+CREATE TRIGGER set_timestamp_habit_counters
+BEFORE UPDATE ON habit_counters
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TABLE IF NOT EXISTS habit_counter_days (
+    counter_id     BIGINT NOT NULL REFERENCES habit_counters(id) ON DELETE CASCADE,
+    user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    log_date       DATE NOT NULL,
+    value          DECIMAL(12,2) NOT NULL DEFAULT 0,
+    taps           INTEGER NOT NULL DEFAULT 0,
+    goal_reached   BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (counter_id, log_date)
+);
+
+CREATE INDEX IF NOT EXISTS habit_counter_days_user_date_idx ON habit_counter_days(user_id, log_date);
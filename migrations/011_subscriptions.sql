@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS subscription_expires_at TIMESTAMPTZ; -- NULL при ручной выдаче роли означает бессрочный доступ
+ALTER TABLE users ADD COLUMN IF NOT EXISTS subscription_reminded BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS payments (
+    id                          BIGSERIAL PRIMARY KEY,
+    user_id                     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    telegram_payment_charge_id  VARCHAR(255) NOT NULL UNIQUE,
+    provider_payment_charge_id  VARCHAR(255),
+    amount                      INTEGER NOT NULL, -- в минимальных единицах валюты
+    currency                    VARCHAR(3) NOT NULL,
+    payload                     VARCHAR(128) NOT NULL,
+    days                        INTEGER NOT NULL,
+    expires_at                  TIMESTAMPTZ NOT NULL,
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payments_user_idx ON payments(user_id);
+CREATE INDEX IF NOT EXISTS users_subscription_expires_idx ON users(subscription_expires_at) WHERE subscription_expires_at IS NOT NULL;
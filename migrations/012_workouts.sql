@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS workout_plans (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key_result_id     BIGINT REFERENCES key_results(id) ON DELETE SET NULL,
+    focus             VARCHAR(20) NOT NULL, -- strength, cardio, weight_loss, general
+    equipment         TEXT[] NOT NULL DEFAULT '{}',
+    weekdays          INTEGER[] NOT NULL, -- 0 = воскресенье
+    session_time      VARCHAR(5) NOT NULL, -- HH:MM
+    duration_minutes  INTEGER NOT NULL DEFAULT 45,
+    starts_on         DATE NOT NULL,
+    ends_on           DATE NOT NULL,
+    is_active         BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    id              BIGSERIAL PRIMARY KEY,
+    plan_id         BIGINT NOT NULL REFERENCES workout_plans(id) ON DELETE CASCADE,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id        VARCHAR(36) REFERENCES events(id) ON DELETE SET NULL,
+    title           VARCHAR(255) NOT NULL,
+    exercises       TEXT[] NOT NULL,
+    scheduled_at    TIMESTAMPTZ NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'planned', -- planned, done, skipped
+    progress_value  DECIMAL(12,2),
+    completed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS workout_sessions_user_time_idx ON workout_sessions(user_id, scheduled_at);
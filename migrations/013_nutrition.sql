@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS meals (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    description  TEXT NOT NULL,
+    calories     INTEGER NOT NULL,
+    protein      DECIMAL(6,1) NOT NULL DEFAULT 0,
+    fat          DECIMAL(6,1) NOT NULL DEFAULT 0,
+    carbs        DECIMAL(6,1) NOT NULL DEFAULT 0,
+    source       VARCHAR(10) NOT NULL DEFAULT 'text', -- text, photo
+    eaten_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS meals_user_eaten_idx ON meals(user_id, eaten_at);
+
+CREATE TABLE IF NOT EXISTS nutrition_targets (
+    user_id         BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    daily_calories  INTEGER NOT NULL,
+    protein         INTEGER,
+    key_result_id   BIGINT REFERENCES key_results(id) ON DELETE SET NULL, -- +1 за каждый день в пределах нормы
+    last_scored_on  DATE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_nutrition_targets
+BEFORE UPDATE ON nutrition_targets
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS web_subscriptions (
+    web_user_id             BIGINT PRIMARY KEY REFERENCES web_users(id) ON DELETE CASCADE,
+    stripe_customer_id      VARCHAR(255) UNIQUE,
+    stripe_subscription_id  VARCHAR(255) UNIQUE,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, active, canceled
+    current_period_end      TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_web_subscriptions
+BEFORE UPDATE ON web_subscriptions
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
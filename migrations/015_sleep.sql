@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS sleep_logs (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sleep_date        DATE NOT NULL, -- дата пробуждения
+    bed_time          TIMESTAMPTZ NOT NULL,
+    wake_time         TIMESTAMPTZ NOT NULL,
+    duration_minutes  INTEGER NOT NULL,
+    quality           SMALLINT, -- 1-5
+    source            VARCHAR(20) NOT NULL DEFAULT 'manual', -- manual, integration
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, sleep_date)
+);
+
+CREATE TRIGGER set_timestamp_sleep_logs
+BEFORE UPDATE ON sleep_logs
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- Отметка об отправленной утренней сводке, чтобы не дублировать ее при запуске каждые 15 минут
+CREATE TABLE IF NOT EXISTS digest_deliveries (
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    digest_date  DATE NOT NULL,
+    sent_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, digest_date)
+);
@@ -0,0 +1,13 @@
+-- Счетчики использования бесплатного тарифа по календарным месяцам
+CREATE TABLE IF NOT EXISTS usage_counters (
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start  DATE NOT NULL, -- первое число месяца
+    messages      INTEGER NOT NULL DEFAULT 0,
+    upsell_sent   BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, period_start)
+);
+
+CREATE TRIGGER set_timestamp_usage_counters
+BEFORE UPDATE ON usage_counters
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS focus_sessions (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label             VARCHAR(255),
+    started_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at          TIMESTAMPTZ,
+    planned_minutes   INTEGER,
+    duration_minutes  INTEGER, -- заполняется при завершении сессии
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS focus_sessions_user_started_idx ON focus_sessions(user_id, started_at);
+CREATE UNIQUE INDEX IF NOT EXISTS focus_sessions_active_idx ON focus_sessions(user_id) WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS focus_targets (
+    user_id             BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    weekly_minutes      INTEGER NOT NULL,
+    last_pacing_alert   DATE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp_focus_targets
+BEFORE UPDATE ON focus_targets
+FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,15 @@
+-- События отвлечений от десктоп-блокировщиков и расширений браузера
+CREATE TABLE IF NOT EXISTS distraction_events (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    occurred_at       TIMESTAMPTZ NOT NULL,
+    source            VARCHAR(255) NOT NULL, -- домен сайта или название приложения
+    category          VARCHAR(50),
+    duration_seconds  INTEGER NOT NULL DEFAULT 0,
+    blocked           BOOLEAN NOT NULL DEFAULT FALSE,
+    focus_session_id  BIGINT REFERENCES focus_sessions(id) ON DELETE SET NULL,
+    client            VARCHAR(100),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS distraction_events_user_time_idx ON distraction_events(user_id, occurred_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS team_invitations (
+    id            BIGSERIAL PRIMARY KEY,
+    team_id       BIGINT NOT NULL REFERENCES user_teams(id) ON DELETE CASCADE,
+    invited_by    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invitee_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, accepted, declined
+    notified      BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS team_invitations_pending_idx ON team_invitations(team_id, invitee_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS team_invitations_invitee_idx ON team_invitations(invitee_id, status);
@@ -0,0 +1,19 @@
+-- Пользовательские правила автоматизации: условие по ключевому результату -> действие
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name            VARCHAR(255) NOT NULL,
+    condition_type  VARCHAR(30) NOT NULL, -- kr_stale | kr_deadline_near
+    key_result_id   BIGINT NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+    threshold_days  INTEGER NOT NULL DEFAULT 3,
+    action_type     VARCHAR(30) NOT NULL, -- create_task | send_reminder | shift_event
+    task_title      TEXT,
+    message         TEXT,
+    event_query     TEXT,
+    shift_minutes   INTEGER,
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    last_fired_at   TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS automation_rules_user_id_idx ON automation_rules(user_id) WHERE is_active = TRUE;
@@ -0,0 +1,38 @@
+-- Челленджи: ежедневные/еженедельные/ежемесячные цели с участниками и таблицей лидеров
+CREATE TABLE IF NOT EXISTS challenges (
+    id              BIGSERIAL PRIMARY KEY,
+    created_by      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title           VARCHAR(255) NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    period          VARCHAR(10) NOT NULL CHECK (period IN ('daily', 'weekly', 'monthly')),
+    target          DECIMAL(12,2) NOT NULL,
+    unit            VARCHAR(50) NOT NULL DEFAULT '',
+    starts_on       DATE NOT NULL,
+    ends_on         DATE NOT NULL,
+    start_notified  BOOLEAN NOT NULL DEFAULT FALSE,
+    end_notified    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE TABLE IF NOT EXISTS challenge_participants (
+    challenge_id  BIGINT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending | joined | declined
+    invited_by    BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    notified      BOOLEAN NOT NULL DEFAULT FALSE,
+    joined_at     TIMESTAMPTZ,
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS challenge_progress (
+    id            BIGSERIAL PRIMARY KEY,
+    challenge_id  BIGINT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount        DECIMAL(12,2) NOT NULL,
+    logged_on     DATE NOT NULL DEFAULT CURRENT_DATE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS challenge_participants_user_id_idx ON challenge_participants(user_id);
+CREATE INDEX IF NOT EXISTS challenge_progress_challenge_user_idx ON challenge_progress(challenge_id, user_id, logged_on);
@@ -0,0 +1,26 @@
+-- API-ключи и REST-хуки для Zapier/Make
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            BIGSERIAL PRIMARY KEY,
+    web_user_id   BIGINT NOT NULL REFERENCES web_users(id) ON DELETE CASCADE,
+    name          VARCHAR(100) NOT NULL,
+    prefix        VARCHAR(12) NOT NULL,
+    key_hash      CHAR(64) NOT NULL UNIQUE, -- sha256 от ключа, сам ключ не хранится
+    last_used_at  TIMESTAMPTZ,
+    revoked_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_web_user_id_idx ON api_keys(web_user_id);
+
+CREATE TABLE IF NOT EXISTS integration_webhooks (
+    id              BIGSERIAL PRIMARY KEY,
+    web_user_id     BIGINT NOT NULL REFERENCES web_users(id) ON DELETE CASCADE,
+    event           VARCHAR(50) NOT NULL, -- objective.created | task.completed | transaction.created
+    target_url      TEXT NOT NULL,
+    cursor_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    failures        INTEGER NOT NULL DEFAULT 0,
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS integration_webhooks_active_idx ON integration_webhooks(event) WHERE is_active = TRUE;
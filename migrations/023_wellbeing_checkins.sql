@@ -0,0 +1,15 @@
+-- Ежедневные отметки самочувствия: стресс, качество сна и баланс работы и жизни (1-5)
+CREATE TABLE IF NOT EXISTS wellbeing_checkins (
+    id                 BIGSERIAL PRIMARY KEY,
+    user_id            BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    checkin_date       DATE NOT NULL DEFAULT CURRENT_DATE,
+    stress_level       SMALLINT CHECK (stress_level BETWEEN 1 AND 5),
+    sleep_quality      SMALLINT CHECK (sleep_quality BETWEEN 1 AND 5),
+    work_life_balance  SMALLINT CHECK (work_life_balance BETWEEN 1 AND 5),
+    note               TEXT NOT NULL DEFAULT '',
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, checkin_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wellbeing_checkins_user_date ON wellbeing_checkins(user_id, checkin_date DESC);
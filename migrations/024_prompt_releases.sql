@@ -0,0 +1,28 @@
+-- Поэтапная выкатка изменений системного промпта и схем функций Jarvis
+CREATE TABLE IF NOT EXISTS prompt_releases (
+    id                  BIGSERIAL PRIMARY KEY,
+    version             VARCHAR(64) NOT NULL UNIQUE,
+    description         TEXT NOT NULL DEFAULT '',
+    prompt_addendum     TEXT NOT NULL DEFAULT '',
+    function_overrides  JSONB NOT NULL DEFAULT '{}',
+    rollout_percent     SMALLINT NOT NULL DEFAULT 10 CHECK (rollout_percent BETWEEN 0 AND 100),
+    status              VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'rolled_back', 'completed')),
+    rollback_reason     TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Одновременно может выкатываться только одна версия
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_releases_single_active ON prompt_releases(status) WHERE status = 'active';
+
+-- Результаты обработки сообщений по версиям для сравнения успешности вызовов функций
+CREATE TABLE IF NOT EXISTS prompt_release_outcomes (
+    id             BIGSERIAL PRIMARY KEY,
+    version        VARCHAR(64) NOT NULL,
+    user_id        BIGINT NOT NULL,
+    function_name  VARCHAR(100) NOT NULL DEFAULT '',
+    outcome        VARCHAR(20) NOT NULL CHECK (outcome IN ('success', 'parse_error', 'function_error', 'no_call')),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_release_outcomes_version_time ON prompt_release_outcomes(version, created_at);
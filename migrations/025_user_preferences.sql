@@ -0,0 +1,21 @@
+-- Явные предпочтения пользователя для Jarvis (update_preferences)
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id             BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    communication_style VARCHAR(30),
+    motivation_type     VARCHAR(30),
+    reminder_frequency  VARCHAR(30),
+    difficulty_level    VARCHAR(30),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Обратная связь пользователя о работе Jarvis (learn_from_feedback)
+CREATE TABLE IF NOT EXISTS user_feedback (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feedback_type     VARCHAR(20) NOT NULL CHECK (feedback_type IN ('positive', 'negative', 'suggestion', 'complaint')),
+    context           TEXT NOT NULL,
+    specific_feature  VARCHAR(100) NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_feedback_user ON user_feedback(user_id, created_at DESC);
@@ -0,0 +1,18 @@
+-- Профиль личности, вычисляемый по фактическому поведению (OKR, сообщения, привычки)
+CREATE TABLE IF NOT EXISTS personality_profiles (
+    user_id               BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    personality_type      VARCHAR(50) NOT NULL DEFAULT 'balanced',
+    motivation_style      VARCHAR(50) NOT NULL DEFAULT 'growth',
+    activity_level        VARCHAR(50) NOT NULL DEFAULT 'moderate',
+    chronotype            VARCHAR(20) NOT NULL DEFAULT 'day',
+    completion_rate       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    consistency           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    habit_adherence       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    preferred_difficulty  DOUBLE PRECISION NOT NULL DEFAULT 0.6,
+    social_score          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    engagement_trend      DOUBLE PRECISION NOT NULL DEFAULT 0.5,
+    peak_hours            INT[] NOT NULL DEFAULT '{}',
+    traits                JSONB NOT NULL DEFAULT '{}',
+    sample_size           INT NOT NULL DEFAULT 0,
+    computed_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- Оценки ответов Jarvis (👍/👎) с привязкой к ответу и вызванной функции
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS function_name VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS ai_response_ratings (
+    ai_response_id  BIGINT PRIMARY KEY REFERENCES ai_responses(id) ON DELETE CASCADE,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating          SMALLINT NOT NULL CHECK (rating IN (-1, 1)),
+    function_name   VARCHAR(100) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_response_ratings_user ON ai_response_ratings(user_id, created_at DESC);
@@ -0,0 +1,11 @@
+-- Сжатая память диалога: резюме старых сообщений, которые уже не попадают в историю запроса
+CREATE TABLE IF NOT EXISTS conversation_summaries (
+    user_identifier   VARCHAR(255) PRIMARY KEY,
+    summary           TEXT NOT NULL DEFAULT '',
+    summarized_until  TIMESTAMPTZ NOT NULL,
+    message_count     INT NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_messages_identifier_created ON user_messages(user_identifier, created_at);
@@ -0,0 +1,13 @@
+-- Векторные представления сообщений пользователя для семантического поиска (pgvector)
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS message_embeddings (
+    user_message_id  BIGINT PRIMARY KEY REFERENCES user_messages(id) ON DELETE CASCADE,
+    user_identifier  VARCHAR(255) NOT NULL,
+    model            VARCHAR(100) NOT NULL,
+    embedding        vector(1536) NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_embeddings_user ON message_embeddings(user_identifier);
+CREATE INDEX IF NOT EXISTS idx_message_embeddings_vector ON message_embeddings USING hnsw (embedding vector_cosine_ops);
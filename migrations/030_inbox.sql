@@ -0,0 +1,21 @@
+-- Инбокс быстрых заметок: голосовые сообщения сохраняются без вызова функций и разбираются раз в день
+CREATE TABLE IF NOT EXISTS inbox_items (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text          TEXT NOT NULL,
+    source        VARCHAR(20) NOT NULL DEFAULT 'voice', -- voice, text
+    status        VARCHAR(20) NOT NULL DEFAULT 'new', -- new, task, event, note, dismissed
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbox_items_user_status ON inbox_items(user_id, status, created_at);
+
+CREATE TABLE IF NOT EXISTS inbox_settings (
+    user_id           BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    quick_capture     BOOLEAN NOT NULL DEFAULT FALSE,
+    max_voice_seconds INT NOT NULL DEFAULT 60,
+    triage_hour       SMALLINT NOT NULL DEFAULT 19 CHECK (triage_hour BETWEEN 0 AND 23),
+    last_triage_date  DATE,
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
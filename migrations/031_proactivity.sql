@@ -0,0 +1,2 @@
+-- Уровень проактивности Jarvis: off, low, medium, high. NULL — без ограничений (как high)
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS proactivity VARCHAR(20);
@@ -0,0 +1,17 @@
+-- Распознанные чеки: ждут подтверждения пользователя, после чего превращаются в транзакцию
+CREATE TABLE IF NOT EXISTS receipts (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merchant        VARCHAR(255) NOT NULL DEFAULT '',
+    amount          NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    currency        VARCHAR(10) NOT NULL DEFAULT '',
+    purchase_date   DATE,
+    category        VARCHAR(100) NOT NULL DEFAULT '',
+    source          VARCHAR(20) NOT NULL DEFAULT 'photo', -- photo, pdf
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, confirmed, cancelled
+    transaction_id  VARCHAR(36),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipts_user_status ON receipts(user_id, status, created_at DESC);
@@ -0,0 +1,18 @@
+-- Режим «не беспокоить»: некритичные проактивные сообщения откладываются до окончания периода
+CREATE TABLE IF NOT EXISTS do_not_disturb (
+    user_id     BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    until       TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Отложенные уведомления, которые придут одной сводкой после окончания режима
+CREATE TABLE IF NOT EXISTS deferred_notifications (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind          VARCHAR(30) NOT NULL,
+    text          TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_deferred_notifications_pending ON deferred_notifications(user_id, created_at) WHERE delivered_at IS NULL;
@@ -0,0 +1,6 @@
+-- Привязка транзакций к целям и ключевым результатам: сколько денег вложено в каждую цель
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS objective_id VARCHAR(36) REFERENCES objectives(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS key_result_id BIGINT REFERENCES key_results(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_objective ON transactions(objective_id, created_at) WHERE objective_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_key_result ON transactions(key_result_id) WHERE key_result_id IS NOT NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS time_entries (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    objective_id      VARCHAR(36) REFERENCES objectives(id) ON DELETE SET NULL,
+    key_result_id     BIGINT REFERENCES key_results(id) ON DELETE SET NULL,
+    minutes           INTEGER NOT NULL CHECK (minutes > 0),
+    note              TEXT,
+    spent_on          DATE NOT NULL DEFAULT CURRENT_DATE,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS time_entries_user_spent_idx ON time_entries(user_id, spent_on);
+CREATE INDEX IF NOT EXISTS time_entries_objective_idx ON time_entries(objective_id) WHERE objective_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS time_entries_key_result_idx ON time_entries(key_result_id) WHERE key_result_id IS NOT NULL;
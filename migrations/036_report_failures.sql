@@ -0,0 +1,6 @@
+-- Последняя ошибка отправки отчета OKR, чтобы оператор мог найти и перезапустить упавшие отчеты
+ALTER TABLE okr_report_settings
+    ADD COLUMN IF NOT EXISTS last_report_error     TEXT,
+    ADD COLUMN IF NOT EXISTS last_report_failed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS okr_report_settings_failed_idx ON okr_report_settings(last_report_failed_at) WHERE last_report_error IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Очередь исходящих сообщений: фоновые уведомления и ответы, которые не удалось отправить сразу
+CREATE TABLE IF NOT EXISTS outbound_messages (
+    id               BIGSERIAL PRIMARY KEY,
+    chat_id          BIGINT NOT NULL,
+    text             TEXT NOT NULL,
+    parse_mode       VARCHAR(20) NOT NULL DEFAULT '',
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sent, failed
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbound_messages_pending_idx ON outbound_messages(chat_id, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS outbound_messages_status_idx ON outbound_messages(status, created_at);
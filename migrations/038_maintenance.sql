@@ -0,0 +1,15 @@
+-- Режим технического обслуживания: бот отвечает уведомлением, фоновые задачи приостановлены
+CREATE TABLE IF NOT EXISTS maintenance_state (
+    id          SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    enabled     BOOLEAN NOT NULL DEFAULT FALSE,
+    message     TEXT,
+    started_at  TIMESTAMPTZ,
+    ends_at     TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Пользователи, которые писали боту во время обслуживания: им придет сообщение о возобновлении работы
+CREATE TABLE IF NOT EXISTS maintenance_affected_users (
+    user_id     BIGINT PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
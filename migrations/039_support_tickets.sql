@@ -0,0 +1,25 @@
+-- Обращения в поддержку: пока обращение открыто, сообщения пользователя уходят оператору, а не в Jarvis
+CREATE TABLE IF NOT EXISTS support_tickets (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status      VARCHAR(20) NOT NULL DEFAULT 'open', -- open, closed
+    source      VARCHAR(20) NOT NULL,                -- command, assistant
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at   TIMESTAMPTZ,
+    closed_by   BIGINT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS support_tickets_open_idx ON support_tickets(user_id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS support_messages (
+    id                   BIGSERIAL PRIMARY KEY,
+    ticket_id            BIGINT NOT NULL REFERENCES support_tickets(id) ON DELETE CASCADE,
+    direction            VARCHAR(20) NOT NULL, -- user, operator
+    text                 TEXT NOT NULL,
+    operator_message_id  INTEGER,              -- сообщение в чате операторов, на которое можно ответить
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS support_messages_ticket_idx ON support_messages(ticket_id, created_at);
+CREATE INDEX IF NOT EXISTS support_messages_operator_idx ON support_messages(operator_message_id) WHERE operator_message_id IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Двусторонняя синхронизация с Google Calendar: инкрементальные sync token и очередь локальных изменений
+ALTER TABLE google_sync_state
+    ALTER COLUMN last_sync_time DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS sync_token        TEXT,
+    ADD COLUMN IF NOT EXISTS last_full_sync_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS last_error        TEXT,
+    ADD COLUMN IF NOT EXISTS last_error_at     TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS pulled_count      INTEGER NOT NULL DEFAULT 0, -- за последний прогон
+    ADD COLUMN IF NOT EXISTS pushed_count      INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS conflicts_count   INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS google_updated_at   TIMESTAMPTZ, -- поле updated события в Google на момент последней синхронизации
+    ADD COLUMN IF NOT EXISTS google_sync_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS events_google_pending_idx ON events(user_id) WHERE google_sync_pending;
+CREATE INDEX IF NOT EXISTS events_google_event_idx ON events(user_id, google_event_id) WHERE google_event_id IS NOT NULL;
+
+-- Удаления, которые не удалось сразу отправить в Google
+CREATE TABLE IF NOT EXISTS google_event_deletions (
+    user_id          BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    google_event_id  VARCHAR(255) NOT NULL,
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, google_event_id)
+);
@@ -0,0 +1,11 @@
+-- Каналы push-уведомлений Google Calendar (events.watch); без активного канала пользователь синхронизируется опросом
+CREATE TABLE IF NOT EXISTS google_watch_channels (
+    user_id      BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    channel_id   VARCHAR(64) NOT NULL UNIQUE,
+    resource_id  VARCHAR(255) NOT NULL,
+    token        VARCHAR(64) NOT NULL, -- сверяется с заголовком X-Goog-Channel-Token
+    expires_at   TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS google_watch_channels_expires_idx ON google_watch_channels(expires_at);
@@ -0,0 +1,23 @@
+-- ICS-фид событий для Apple/Outlook и импорт из CalDAV или опубликованных ICS-календарей
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    user_id           BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token             VARCHAR(64) NOT NULL UNIQUE,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_accessed_at  TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS caldav_accounts (
+    user_id         BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    url             TEXT NOT NULL, -- коллекция CalDAV или ссылка на .ics / webcal://
+    username        TEXT NOT NULL DEFAULT '',
+    password        TEXT NOT NULL DEFAULT '', -- пароль приложения, не основной пароль учетной записи
+    last_import_at  TIMESTAMPTZ,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS external_source VARCHAR(20), -- caldav для импортированных событий
+    ADD COLUMN IF NOT EXISTS external_uid    VARCHAR(512);
+
+CREATE UNIQUE INDEX IF NOT EXISTS events_external_uid_idx ON events(user_id, external_source, external_uid) WHERE external_uid IS NOT NULL;
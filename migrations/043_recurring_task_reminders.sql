@@ -0,0 +1,22 @@
+-- Повторяющиеся задачи: экземпляры одной серии (ежедневные отжимания и т.п.) связаны общим recurrence_series
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS recurrence_series VARCHAR(36),
+    ADD COLUMN IF NOT EXISTS reminder_sent_at  TIMESTAMPTZ; -- напоминание об экземпляре уже отправлено
+
+-- Серии, созданные до появления recurrence_series, восстанавливаются по названию вида "Задача (02.01.2006)"
+UPDATE tasks
+SET is_recurring = TRUE,
+    recurrence_pattern = 'daily',
+    recurrence_series = md5(key_result_id::TEXT || ':' || regexp_replace(title, ' \(\d{2}\.\d{2}\.\d{4}\)$', ''))
+WHERE recurrence_series IS NULL AND title ~ ' \(\d{2}\.\d{2}\.\d{4}\)$';
+
+CREATE INDEX IF NOT EXISTS tasks_recurrence_series_idx ON tasks(recurrence_series, deadline) WHERE recurrence_series IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tasks_recurring_due_idx ON tasks(deadline) WHERE is_recurring = TRUE AND completion_date IS NULL;
+
+-- Час, после которого приходит напоминание о неотмеченном экземпляре на сегодня
+CREATE TABLE IF NOT EXISTS recurring_task_settings (
+    user_id        BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    reminder_hour  SMALLINT NOT NULL DEFAULT 20 CHECK (reminder_hour BETWEEN 0 AND 23),
+    enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- Планы разгрузки дня/недели ("освободи вечер пятницы"): предлагаются ассистентом, применяются и откатываются целиком
+CREATE TABLE IF NOT EXISTS reschedule_plans (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    request     TEXT NOT NULL DEFAULT '',
+    changes     JSONB NOT NULL,                       -- переносы и отмены событий, сдвиги дедлайнов задач со старыми значениями
+    status      VARCHAR(20) NOT NULL DEFAULT 'proposed', -- proposed, applied, undone, discarded
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    applied_at  TIMESTAMPTZ,
+    undone_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS reschedule_plans_user_status_idx ON reschedule_plans(user_id, status, created_at DESC);
@@ -0,0 +1,17 @@
+-- Внешние гости событий: приглашения уходят письмом с ICS (METHOD:REQUEST), ответы приходят на входящий адрес (METHOD:REPLY)
+CREATE TABLE IF NOT EXISTS event_attendees (
+    id              BIGSERIAL PRIMARY KEY,
+    event_id        VARCHAR(36) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    email           VARCHAR(255) NOT NULL,
+    name            VARCHAR(255) NOT NULL DEFAULT '',
+    status          VARCHAR(20) NOT NULL DEFAULT 'needs_action', -- needs_action, accepted, declined, tentative
+    sequence        INT NOT NULL DEFAULT 0,                       -- увеличивается при изменении события, чтобы почтовые клиенты обновили приглашение
+    invite_sent_at  TIMESTAMPTZ,                                  -- NULL — приглашение нужно (пере)отправить
+    send_attempts   INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    responded_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (event_id, email)
+);
+
+CREATE INDEX IF NOT EXISTS event_attendees_pending_idx ON event_attendees(created_at) WHERE invite_sent_at IS NULL;
@@ -0,0 +1,29 @@
+-- Дневник: вечерний вопрос "что получилось сегодня?", записи и еженедельные итоги от ассистента
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entry_date  DATE NOT NULL,                        -- день, к которому относится запись (не обязательно день создания)
+    text        TEXT NOT NULL,
+    source      VARCHAR(20) NOT NULL DEFAULT 'prompt', -- prompt, command
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS journal_entries_user_date_idx ON journal_entries(user_id, entry_date DESC);
+
+CREATE TABLE IF NOT EXISTS journal_settings (
+    user_id         BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    prompt_hour     INT NOT NULL DEFAULT 21 CHECK (prompt_hour BETWEEN 0 AND 23),
+    enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+    last_prompted_on DATE,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS journal_summaries (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    week_start  DATE NOT NULL,
+    summary     TEXT NOT NULL,
+    entry_count INT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, week_start)
+);
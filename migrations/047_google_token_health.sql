@@ -0,0 +1,7 @@
+-- Отзыв доступа в Google (invalid_grant) помечает токен сломанным: синхронизация
+-- для пользователя останавливается, а ему один раз приходит ссылка на переподключение
+ALTER TABLE google_tokens ADD COLUMN IF NOT EXISTS invalid_at TIMESTAMPTZ;
+ALTER TABLE google_tokens ADD COLUMN IF NOT EXISTS invalid_reason TEXT;
+ALTER TABLE google_tokens ADD COLUMN IF NOT EXISTS relink_notified_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS google_tokens_relink_idx ON google_tokens(invalid_at) WHERE invalid_at IS NOT NULL AND relink_notified_at IS NULL;
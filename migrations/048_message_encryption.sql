@@ -0,0 +1,10 @@
+-- Шифрование истории переписки по желанию пользователя. Ключ пользователя не хранится:
+-- он выводится из мастер-ключа (MESSAGE_ENCRYPTION_KEY) и соли, поэтому утечка базы не раскрывает сообщения.
+-- Удаление строки делает уже зашифрованную историю нечитаемой
+CREATE TABLE IF NOT EXISTS message_encryption_keys (
+    user_identifier  VARCHAR(255) PRIMARY KEY,
+    salt             BYTEA NOT NULL,
+    enabled          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
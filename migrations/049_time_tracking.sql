@@ -0,0 +1,16 @@
+-- Трекинг времени по задачам: запуск/остановка таймера ("начни трекать время по задаче X")
+-- и привязка записей времени к задаче, а не только к цели и ключевому результату
+ALTER TABLE time_entries ADD COLUMN IF NOT EXISTS task_id BIGINT REFERENCES tasks(id) ON DELETE SET NULL;
+ALTER TABLE time_entries ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;   -- заполнено, если запись создана таймером
+
+CREATE INDEX IF NOT EXISTS time_entries_task_idx ON time_entries(task_id) WHERE task_id IS NOT NULL;
+
+-- Один запущенный таймер на пользователя; при остановке превращается в запись time_entries
+CREATE TABLE IF NOT EXISTS time_trackers (
+    user_id         BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    objective_id    VARCHAR(36) REFERENCES objectives(id) ON DELETE CASCADE,
+    key_result_id   BIGINT REFERENCES key_results(id) ON DELETE CASCADE,
+    task_id         BIGINT REFERENCES tasks(id) ON DELETE CASCADE,
+    note            TEXT,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
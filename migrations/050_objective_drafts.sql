@@ -0,0 +1,10 @@
+-- Черновик создания цели: поля, собранные в диалоге за несколько сообщений, пока цель не создана.
+-- Один черновик на пользователя, его видят и бот, и веб-приложение. version растет при каждом изменении:
+-- веб сохраняет и завершает черновик только с той версией, которую прочитал, иначе получает конфликт
+CREATE TABLE IF NOT EXISTS objective_drafts (
+    user_id      BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    fields       JSONB NOT NULL DEFAULT '{}',
+    version      INTEGER NOT NULL DEFAULT 1,
+    updated_via  VARCHAR(20) NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
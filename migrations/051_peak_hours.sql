@@ -0,0 +1,10 @@
+-- Пиковые часы продуктивности, посчитанные по реальной активности пользователя
+-- (обновления прогресса, фокус-сессии, таймеры, сообщения, привычки) за скользящее окно
+CREATE TABLE IF NOT EXISTS productivity_hours (
+    user_id      BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    peak_hours   INTEGER[] NOT NULL DEFAULT '{}',   -- лучшие часы по убыванию веса
+    scores       JSONB NOT NULL DEFAULT '{}',       -- вес активности по часам {"9": 4.2, ...}
+    samples      INTEGER NOT NULL DEFAULT 0,        -- сколько событий попало в окно
+    window_days  INTEGER NOT NULL,
+    computed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+-- История изменений целевого значения ключевых результатов: исходная амбиция не теряется,
+-- а частые понижения планки видны в ретроспективе
+CREATE TABLE IF NOT EXISTS key_result_target_revisions (
+    id              BIGSERIAL PRIMARY KEY,
+    key_result_id   BIGINT NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+    old_target      DOUBLE PRECISION NOT NULL,
+    new_target      DOUBLE PRECISION NOT NULL,
+    reason          TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS key_result_target_revisions_kr_idx ON key_result_target_revisions(key_result_id, created_at);
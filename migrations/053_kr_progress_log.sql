@@ -0,0 +1,61 @@
+-- Полная история прогресса ключевых результатов в key_result_progress_log: кроме приращения с автором
+-- храним значения до и после изменения и цель на тот момент. Пишет триггер при любом изменении progress
+-- или target, поэтому по таблице можно восстановить прогресс на любую дату и посчитать темп.
+-- Автора код передает настройкой jarvis.actor_id внутри транзакции, иначе запись приписывается владельцу цели
+ALTER TABLE key_result_progress_log ADD COLUMN IF NOT EXISTS progress_before DOUBLE PRECISION; -- NULL для записи о создании ключевого результата
+ALTER TABLE key_result_progress_log ADD COLUMN IF NOT EXISTS progress_after  DOUBLE PRECISION;
+ALTER TABLE key_result_progress_log ADD COLUMN IF NOT EXISTS target          DOUBLE PRECISION;
+ALTER TABLE key_result_progress_log ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS key_result_progress_log_kr_created_idx ON key_result_progress_log(key_result_id, created_at);
+
+-- Перенос истории: значения после каждого записанного приращения, восстановленные от текущего прогресса назад
+UPDATE key_result_progress_log l
+SET progress_before = h.progress_after - h.delta, progress_after = h.progress_after, target = h.target
+FROM (
+    SELECT l.id, l.delta, kr.target,
+        COALESCE(kr.progress, 0) - COALESCE(SUM(l.delta) OVER (
+            PARTITION BY l.key_result_id ORDER BY l.created_at DESC, l.id DESC
+            ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+        ), 0) AS progress_after
+    FROM key_result_progress_log l
+    JOIN key_results kr ON kr.id = l.key_result_id
+) h
+WHERE l.id = h.id AND l.progress_after IS NULL;
+
+-- Стартовое значение на дату создания ключевого результата
+INSERT INTO key_result_progress_log (key_result_id, user_id, delta, progress_before, progress_after, target, created_at)
+SELECT kr.id, o.user_id, 0, NULL, COALESCE(kr.progress, 0) - COALESCE(SUM(l.delta), 0), kr.target, kr.created_at
+FROM key_results kr
+JOIN objectives o ON o.id = kr.objective_id
+LEFT JOIN key_result_progress_log l ON l.key_result_id = kr.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM key_result_progress_log p
+    WHERE p.key_result_id = kr.id AND p.progress_before IS NULL AND p.progress_after IS NOT NULL
+)
+GROUP BY kr.id, o.user_id;
+
+CREATE OR REPLACE FUNCTION trigger_log_kr_progress()
+RETURNS TRIGGER AS $$
+DECLARE
+  actor BIGINT := NULLIF(current_setting('jarvis.actor_id', true), '')::BIGINT;
+BEGIN
+  IF actor IS NULL THEN
+    SELECT user_id INTO actor FROM objectives WHERE id = NEW.objective_id;
+  END IF;
+
+  IF TG_OP = 'INSERT' THEN
+    INSERT INTO key_result_progress_log (key_result_id, user_id, delta, progress_before, progress_after, target)
+    VALUES (NEW.id, actor, 0, NULL, COALESCE(NEW.progress, 0), NEW.target);
+  ELSIF NEW.progress IS DISTINCT FROM OLD.progress OR NEW.target IS DISTINCT FROM OLD.target THEN
+    INSERT INTO key_result_progress_log (key_result_id, user_id, delta, progress_before, progress_after, target)
+    VALUES (NEW.id, actor, COALESCE(NEW.progress, 0) - COALESCE(OLD.progress, 0), OLD.progress, COALESCE(NEW.progress, 0), NEW.target);
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS log_kr_progress ON key_results;
+CREATE TRIGGER log_kr_progress
+AFTER INSERT OR UPDATE OF progress, target ON key_results
+FOR EACH ROW EXECUTE PROCEDURE trigger_log_kr_progress();
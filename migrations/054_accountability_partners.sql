@@ -0,0 +1,31 @@
+-- Партнеры по ответственности: пара пользователей, связанная по приглашению с согласием обеих сторон
+CREATE TABLE IF NOT EXISTS accountability_partners (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,   -- кто пригласил
+    partner_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,   -- кого пригласили
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',                   -- pending, accepted, declined, ended
+    notified        BOOLEAN NOT NULL DEFAULT FALSE,
+    last_digest_at  TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at    TIMESTAMPTZ,
+    ended_at        TIMESTAMPTZ,
+    CHECK (user_id <> partner_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS accountability_partners_pair_idx
+    ON accountability_partners(LEAST(user_id, partner_id), GREATEST(user_id, partner_id))
+    WHERE status IN ('pending', 'accepted');
+CREATE INDEX IF NOT EXISTS accountability_partners_partner_idx ON accountability_partners(partner_id, status);
+
+-- Цели, которые владелец выборочно открыл партнеру только на просмотр; отзыв — revoked_at
+CREATE TABLE IF NOT EXISTS partner_shared_objectives (
+    id              BIGSERIAL PRIMARY KEY,
+    partnership_id  BIGINT NOT NULL REFERENCES accountability_partners(id) ON DELETE CASCADE,
+    objective_id    VARCHAR(36) NOT NULL REFERENCES objectives(id) ON DELETE CASCADE,
+    owner_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    shared_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at      TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS partner_shared_objectives_active_idx
+    ON partner_shared_objectives(partnership_id, objective_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,10 @@
+-- Мягкое удаление целей, ключевых результатов и задач. Удаление цели проставляет одинаковое deleted_at
+-- всем ее ключевым результатам и задачам, поэтому восстановление возвращает ровно то, что было удалено вместе с ней.
+-- Записи старше 30 дней окончательно удаляет задача okr_trash_purge
+ALTER TABLE objectives ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE key_results ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS objectives_deleted_idx ON objectives(user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS key_results_deleted_idx ON key_results(objective_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS tasks_deleted_idx ON tasks(key_result_id, deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Явное закрытие целей: completion_date уже хранит момент завершения, archived_at — момент отправки в архив.
+-- При закрытии пользователь отвечает на два вопроса ретроспективы, ответы хранятся в objective_closings
+ALTER TABLE objectives ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+UPDATE objectives SET archived_at = COALESCE(updated_at, created_at)
+WHERE status = 'archived' AND archived_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS objective_closings (
+    id             BIGSERIAL PRIMARY KEY,
+    objective_id   VARCHAR(36) NOT NULL REFERENCES objectives(id) ON DELETE CASCADE,
+    user_id        BIGINT NOT NULL REFERENCES users(id),
+    outcome        VARCHAR(20) NOT NULL CHECK (outcome IN ('completed', 'archived')),
+    final_progress DOUBLE PRECISION NOT NULL DEFAULT 0,
+    what_worked    TEXT NOT NULL DEFAULT '',
+    what_didnt     TEXT NOT NULL DEFAULT '',
+    closed_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS objective_closings_objective_idx ON objective_closings(objective_id, closed_at DESC);
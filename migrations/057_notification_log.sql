@@ -0,0 +1,13 @@
+-- Журнал доставленных уведомлений: напоминания, отчеты, инсайты и оповещения, которые бот отправил
+-- сам, без запроса пользователя. По нему веб-приложение показывает ленту уведомлений с отметкой о прочтении
+CREATE TABLE IF NOT EXISTS notification_log (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind        VARCHAR(30) NOT NULL,
+    text        TEXT NOT NULL,
+    read_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_log_user ON notification_log(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_log_unread ON notification_log(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,15 @@
+-- Ссылки только для просмотра прогресса цели: по токену друзья или ментор видят цель без входа в приложение.
+-- Отозванная ссылка перестает открываться, но остается в таблице для истории
+CREATE TABLE IF NOT EXISTS objective_share_links (
+    token             VARCHAR(64) PRIMARY KEY,
+    objective_id      VARCHAR(36) NOT NULL REFERENCES objectives(id) ON DELETE CASCADE,
+    user_id           BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sharing_type      VARCHAR(30) NOT NULL DEFAULT 'progress_update', -- progress_update, achievement, help_request, motivation
+    audience          VARCHAR(20) NOT NULL DEFAULT 'friends',         -- friends, team, public, family
+    view_count        INT NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_accessed_at  TIMESTAMPTZ,
+    revoked_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_objective_share_links_active ON objective_share_links(objective_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,44 @@
+-- Свои сферы пользователя в дополнение к стандартному справочнику (справочник и синонимы заданы в коде)
+CREATE TABLE IF NOT EXISTS user_spheres (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL,
+    icon        VARCHAR(16) NOT NULL DEFAULT '🎯',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_spheres_name_idx ON user_spheres(user_id, LOWER(name));
+
+-- Приведение свободного текста к стандартным сферам: «спорт», «фитнес» и «здоровье» должны считаться одной сферой.
+-- Список синонимов повторяет справочник в internal/okr/spheres.go на момент миграции
+WITH aliases(alias, canonical) AS (VALUES
+    ('здоровье', 'Здоровье'), ('спорт', 'Здоровье'), ('фитнес', 'Здоровье'), ('тренировки', 'Здоровье'),
+    ('питание', 'Здоровье'), ('сон', 'Здоровье'), ('health', 'Здоровье'), ('fitness', 'Здоровье'), ('sport', 'Здоровье'),
+    ('карьера', 'Карьера'), ('работа', 'Карьера'), ('career', 'Карьера'), ('work', 'Карьера'),
+    ('бизнес', 'Бизнес'), ('стартап', 'Бизнес'), ('предпринимательство', 'Бизнес'), ('business', 'Бизнес'),
+    ('финансы', 'Финансы'), ('деньги', 'Финансы'), ('накопления', 'Финансы'), ('инвестиции', 'Финансы'),
+    ('finance', 'Финансы'), ('money', 'Финансы'),
+    ('образование', 'Образование'), ('обучение', 'Образование'), ('учеба', 'Образование'), ('учёба', 'Образование'),
+    ('языки', 'Образование'), ('education', 'Образование'), ('learning', 'Образование'),
+    ('творчество', 'Творчество'), ('контент', 'Творчество'), ('блог', 'Творчество'), ('музыка', 'Творчество'),
+    ('creativity', 'Творчество'),
+    ('отношения', 'Отношения'), ('семья', 'Отношения'), ('друзья', 'Отношения'), ('relationships', 'Отношения'),
+    ('family', 'Отношения'),
+    ('саморазвитие', 'Саморазвитие'), ('личностный рост', 'Саморазвитие'), ('личное развитие', 'Саморазвитие'),
+    ('развитие', 'Саморазвитие'), ('personal growth', 'Саморазвитие'),
+    ('хобби', 'Хобби и отдых'), ('отдых', 'Хобби и отдых'), ('путешествия', 'Хобби и отдых'), ('travel', 'Хобби и отдых'),
+    ('дом', 'Дом и быт'), ('быт', 'Дом и быт'), ('ремонт', 'Дом и быт'), ('home', 'Дом и быт')
+)
+UPDATE objectives o
+SET sphere = a.canonical
+FROM aliases a
+WHERE LOWER(TRIM(o.sphere)) = a.alias AND o.sphere IS DISTINCT FROM a.canonical;
+
+-- Остальные непустые значения становятся своими сферами владельцев цели
+INSERT INTO user_spheres (user_id, name)
+SELECT DISTINCT ON (o.user_id, LOWER(TRIM(o.sphere))) o.user_id, TRIM(o.sphere)
+FROM objectives o
+WHERE TRIM(COALESCE(o.sphere, '')) <> ''
+    AND o.sphere NOT IN ('Здоровье', 'Карьера', 'Бизнес', 'Финансы', 'Образование', 'Творчество',
+        'Отношения', 'Саморазвитие', 'Хобби и отдых', 'Дом и быт')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,23 @@
+-- Очередь расшифровки больших аудиофайлов: вебхук только подтверждает получение, а файл скачивается,
+-- режется на части и расшифровывается фоновыми воркерами. Аудио не хранится — по file_id его можно скачать заново
+CREATE TABLE IF NOT EXISTS transcription_jobs (
+    id                   BIGSERIAL PRIMARY KEY,
+    user_id              BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_id              BIGINT NOT NULL,
+    file_id              TEXT NOT NULL,
+    file_size            BIGINT NOT NULL DEFAULT 0,
+    mime_type            VARCHAR(100) NOT NULL DEFAULT '',
+    status               VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, processing, done, failed
+    attempts             INT NOT NULL DEFAULT 0,
+    progress_message_id  INT,
+    chunks_total         INT NOT NULL DEFAULT 0,
+    chunks_done          INT NOT NULL DEFAULT 0,
+    transcript           TEXT,
+    last_error           TEXT,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at           TIMESTAMPTZ,
+    finished_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcription_jobs_pending ON transcription_jobs(created_at) WHERE status IN ('pending', 'processing');
+CREATE INDEX IF NOT EXISTS idx_transcription_jobs_user ON transcription_jobs(user_id, created_at DESC);
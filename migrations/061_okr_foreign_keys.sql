@@ -0,0 +1,71 @@
+-- Каскадное удаление для всего, что ссылается на цели, ключевые результаты и задачи.
+-- Раньше часть ссылок была без ON DELETE: очистка корзины падала на связанных строках,
+-- а удаление пользователя оставляло его цели. Имена ограничений совпадают с автоматическими,
+-- поэтому миграцию можно применять повторно
+
+ALTER TABLE objectives DROP CONSTRAINT IF EXISTS objectives_user_id_fkey;
+ALTER TABLE objectives ADD CONSTRAINT objectives_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- Подцели переживают удаление родительской цели и становятся самостоятельными
+ALTER TABLE objectives DROP CONSTRAINT IF EXISTS objectives_parent_objective_id_fkey;
+ALTER TABLE objectives ADD CONSTRAINT objectives_parent_objective_id_fkey
+    FOREIGN KEY (parent_objective_id) REFERENCES objectives(id) ON DELETE SET NULL;
+
+-- Инсайты, прогнозы и напоминания без своей цели бессмысленны — удаляем вместе с ней
+ALTER TABLE ai_insights DROP CONSTRAINT IF EXISTS ai_insights_objective_id_fkey;
+ALTER TABLE ai_insights ADD CONSTRAINT ai_insights_objective_id_fkey
+    FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE CASCADE;
+ALTER TABLE ai_insights DROP CONSTRAINT IF EXISTS ai_insights_key_result_id_fkey;
+ALTER TABLE ai_insights ADD CONSTRAINT ai_insights_key_result_id_fkey
+    FOREIGN KEY (key_result_id) REFERENCES key_results(id) ON DELETE CASCADE;
+ALTER TABLE ai_insights DROP CONSTRAINT IF EXISTS ai_insights_task_id_fkey;
+ALTER TABLE ai_insights ADD CONSTRAINT ai_insights_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE goal_predictions DROP CONSTRAINT IF EXISTS goal_predictions_objective_id_fkey;
+ALTER TABLE goal_predictions ADD CONSTRAINT goal_predictions_objective_id_fkey
+    FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE CASCADE;
+ALTER TABLE goal_predictions DROP CONSTRAINT IF EXISTS goal_predictions_key_result_id_fkey;
+ALTER TABLE goal_predictions ADD CONSTRAINT goal_predictions_key_result_id_fkey
+    FOREIGN KEY (key_result_id) REFERENCES key_results(id) ON DELETE CASCADE;
+ALTER TABLE goal_predictions DROP CONSTRAINT IF EXISTS goal_predictions_task_id_fkey;
+ALTER TABLE goal_predictions ADD CONSTRAINT goal_predictions_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE smart_reminders DROP CONSTRAINT IF EXISTS smart_reminders_objective_id_fkey;
+ALTER TABLE smart_reminders ADD CONSTRAINT smart_reminders_objective_id_fkey
+    FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE CASCADE;
+ALTER TABLE smart_reminders DROP CONSTRAINT IF EXISTS smart_reminders_key_result_id_fkey;
+ALTER TABLE smart_reminders ADD CONSTRAINT smart_reminders_key_result_id_fkey
+    FOREIGN KEY (key_result_id) REFERENCES key_results(id) ON DELETE CASCADE;
+ALTER TABLE smart_reminders DROP CONSTRAINT IF EXISTS smart_reminders_task_id_fkey;
+ALTER TABLE smart_reminders ADD CONSTRAINT smart_reminders_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+ALTER TABLE shared_objectives DROP CONSTRAINT IF EXISTS shared_objectives_objective_id_fkey;
+ALTER TABLE shared_objectives ADD CONSTRAINT shared_objectives_objective_id_fkey
+    FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE CASCADE;
+
+-- История привычек и достижения остаются у пользователя, теряется только ссылка
+ALTER TABLE habit_tracking DROP CONSTRAINT IF EXISTS habit_tracking_objective_id_fkey;
+ALTER TABLE habit_tracking ADD CONSTRAINT habit_tracking_objective_id_fkey
+    FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE SET NULL;
+ALTER TABLE habit_tracking DROP CONSTRAINT IF EXISTS habit_tracking_key_result_id_fkey;
+ALTER TABLE habit_tracking ADD CONSTRAINT habit_tracking_key_result_id_fkey
+    FOREIGN KEY (key_result_id) REFERENCES key_results(id) ON DELETE SET NULL;
+ALTER TABLE habit_tracking DROP CONSTRAINT IF EXISTS habit_tracking_task_id_fkey;
+ALTER TABLE habit_tracking ADD CONSTRAINT habit_tracking_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE SET NULL;
+
+ALTER TABLE user_achievements DROP CONSTRAINT IF EXISTS user_achievements_objective_id_fkey;
+ALTER TABLE user_achievements ADD CONSTRAINT user_achievements_objective_id_fkey
+    FOREIGN KEY (objective_id) REFERENCES objectives(id) ON DELETE SET NULL;
+ALTER TABLE user_achievements DROP CONSTRAINT IF EXISTS user_achievements_key_result_id_fkey;
+ALTER TABLE user_achievements ADD CONSTRAINT user_achievements_key_result_id_fkey
+    FOREIGN KEY (key_result_id) REFERENCES key_results(id) ON DELETE SET NULL;
+ALTER TABLE user_achievements DROP CONSTRAINT IF EXISTS user_achievements_task_id_fkey;
+ALTER TABLE user_achievements ADD CONSTRAINT user_achievements_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_objectives_parent ON objectives(parent_objective_id) WHERE parent_objective_id IS NOT NULL;
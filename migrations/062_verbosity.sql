@@ -0,0 +1,2 @@
+-- Краткость ответов Jarvis: concise, normal, detailed. NULL — обычные ответы
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS verbosity VARCHAR(20);
@@ -0,0 +1,21 @@
+-- Нечеткий поиск целей, ключевых результатов и задач по описанию пользователя.
+-- pg_trgm дает similarity/word_similarity, индексы ускоряют поиск по названиям
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_objectives_title_trgm ON objectives USING GIN (LOWER(title) gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_key_results_title_trgm ON key_results USING GIN (LOWER(title) gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_title_trgm ON tasks USING GIN (LOWER(title) gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- Вопросы «какую именно цель вы имели в виду?»: исходный вызов функции ждет, пока пользователь выберет вариант кнопкой
+CREATE TABLE IF NOT EXISTS pending_disambiguations (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    function     VARCHAR(100) NOT NULL,
+    arguments    JSONB NOT NULL,
+    id_argument  VARCHAR(50) NOT NULL,
+    candidates   JSONB NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_disambiguations_user ON pending_disambiguations(user_id, created_at DESC);
@@ -0,0 +1,26 @@
+-- Недельный план, разложенный по календарю: Jarvis предлагает блоки на каждый день,
+-- после подтверждения создает события и в течение недели сверяет их с фокус-сессиями
+CREATE TABLE IF NOT EXISTS weekly_plans (
+    id                    BIGSERIAL PRIMARY KEY,
+    user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    week_start            DATE NOT NULL,
+    status                VARCHAR(20) NOT NULL DEFAULT 'proposed', -- proposed, applied, discarded
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    applied_at            TIMESTAMPTZ,
+    last_adherence_alert  DATE
+);
+
+CREATE INDEX IF NOT EXISTS idx_weekly_plans_user ON weekly_plans(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_weekly_plans_applied ON weekly_plans(week_start) WHERE status = 'applied';
+
+CREATE TABLE IF NOT EXISTS weekly_plan_blocks (
+    id            BIGSERIAL PRIMARY KEY,
+    plan_id       BIGINT NOT NULL REFERENCES weekly_plans(id) ON DELETE CASCADE,
+    focus         VARCHAR(255) NOT NULL,
+    start_time    TIMESTAMPTZ NOT NULL,
+    end_time      TIMESTAMPTZ NOT NULL,
+    event_id      VARCHAR(36) REFERENCES events(id) ON DELETE SET NULL,
+    done_minutes  INTEGER -- заполняется после окончания блока по пересекающимся фокус-сессиям
+);
+
+CREATE INDEX IF NOT EXISTS idx_weekly_plan_blocks_plan ON weekly_plan_blocks(plan_id, start_time);
@@ -0,0 +1,22 @@
+-- Напоминания о дедлайнах ключевых результатов и задач: за сколько дней предупреждать
+CREATE TABLE IF NOT EXISTS deadline_reminder_settings (
+    user_id      BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    days_before  SMALLINT NOT NULL DEFAULT 3 CHECK (days_before BETWEEN 1 AND 30),
+    enabled      BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Отправленные напоминания: каждая стадия (скоро дедлайн, под угрозой, последний день) приходит один раз на дедлайн
+CREATE TABLE IF NOT EXISTS deadline_reminder_log (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity_type  VARCHAR(20) NOT NULL, -- key_result, task
+    entity_id    BIGINT NOT NULL,
+    deadline     DATE NOT NULL,
+    stage        VARCHAR(20) NOT NULL, -- upcoming, at_risk, final
+    probability  DOUBLE PRECISION,
+    sent_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (entity_type, entity_id, deadline, stage)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deadline_reminder_log_user ON deadline_reminder_log(user_id, sent_at DESC);
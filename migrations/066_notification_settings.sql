@@ -0,0 +1,8 @@
+-- Тихие часы и отключенные типы уведомлений: в тихие часы сообщения откладываются до утренней сводки
+CREATE TABLE IF NOT EXISTS notification_settings (
+    user_id      BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    quiet_start  SMALLINT CHECK (quiet_start BETWEEN 0 AND 1439), -- минуты от полуночи
+    quiet_end    SMALLINT CHECK (quiet_end BETWEEN 0 AND 1439),
+    muted_kinds  TEXT[] NOT NULL DEFAULT '{}', -- reminder, report, insight, nudge, alert, motivation, suggestion
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
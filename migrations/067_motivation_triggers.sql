@@ -0,0 +1,10 @@
+-- Сработавшие мотивационные триггеры: по журналу ограничивается частота каждого триггера
+CREATE TABLE IF NOT EXISTS motivation_trigger_log (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    trigger_type  VARCHAR(50) NOT NULL, -- low_progress, deadline_approaching, streak_breaking
+    objective_id  VARCHAR(36) REFERENCES objectives(id) ON DELETE SET NULL,
+    sent_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_motivation_trigger_log_user ON motivation_trigger_log(user_id, trigger_type, sent_at DESC);
@@ -0,0 +1,3 @@
+-- Предсказания по целям сохраняются не чаще раза в день и позже получают фактический исход для калибровки
+CREATE INDEX IF NOT EXISTS idx_goal_predictions_objective ON goal_predictions(objective_id, prediction_type, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_goal_predictions_unresolved ON goal_predictions(objective_id) WHERE actual_outcome IS NULL;
@@ -0,0 +1,2 @@
+-- Примененный недельный план можно отменить: события блоков удаляются, план получает статус reverted
+ALTER TABLE weekly_plans ADD COLUMN IF NOT EXISTS reverted_at TIMESTAMPTZ;
@@ -0,0 +1,70 @@
+-- Действие ON DELETE для всех оставшихся ссылок на users(id): без него удаление пользователя
+-- падало на первой же связанной строке. Имена ограничений совпадают с автоматическими,
+-- поэтому миграцию можно применять повторно
+
+-- Личные данные пользователя удаляются вместе с ним
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_user_id_fkey;
+ALTER TABLE events ADD CONSTRAINT events_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE meetings DROP CONSTRAINT IF EXISTS meetings_initiator_id_fkey;
+ALTER TABLE meetings ADD CONSTRAINT meetings_initiator_id_fkey
+    FOREIGN KEY (initiator_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE meetings DROP CONSTRAINT IF EXISTS meetings_participant_id_fkey;
+ALTER TABLE meetings ADD CONSTRAINT meetings_participant_id_fkey
+    FOREIGN KEY (participant_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_user_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE google_tokens DROP CONSTRAINT IF EXISTS google_tokens_user_id_fkey;
+ALTER TABLE google_tokens ADD CONSTRAINT google_tokens_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE ai_insights DROP CONSTRAINT IF EXISTS ai_insights_user_id_fkey;
+ALTER TABLE ai_insights ADD CONSTRAINT ai_insights_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE habit_tracking DROP CONSTRAINT IF EXISTS habit_tracking_user_id_fkey;
+ALTER TABLE habit_tracking ADD CONSTRAINT habit_tracking_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE user_achievements DROP CONSTRAINT IF EXISTS user_achievements_user_id_fkey;
+ALTER TABLE user_achievements ADD CONSTRAINT user_achievements_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE user_context DROP CONSTRAINT IF EXISTS user_context_user_id_fkey;
+ALTER TABLE user_context ADD CONSTRAINT user_context_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE user_behavior_patterns DROP CONSTRAINT IF EXISTS user_behavior_patterns_user_id_fkey;
+ALTER TABLE user_behavior_patterns ADD CONSTRAINT user_behavior_patterns_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE motivation_strategies DROP CONSTRAINT IF EXISTS motivation_strategies_user_id_fkey;
+ALTER TABLE motivation_strategies ADD CONSTRAINT motivation_strategies_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE goal_predictions DROP CONSTRAINT IF EXISTS goal_predictions_user_id_fkey;
+ALTER TABLE goal_predictions ADD CONSTRAINT goal_predictions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE smart_reminders DROP CONSTRAINT IF EXISTS smart_reminders_user_id_fkey;
+ALTER TABLE smart_reminders ADD CONSTRAINT smart_reminders_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE team_members DROP CONSTRAINT IF EXISTS team_members_user_id_fkey;
+ALTER TABLE team_members ADD CONSTRAINT team_members_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE finance_spaces DROP CONSTRAINT IF EXISTS finance_spaces_owner_id_fkey;
+ALTER TABLE finance_spaces ADD CONSTRAINT finance_spaces_owner_id_fkey
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE objective_closings DROP CONSTRAINT IF EXISTS objective_closings_user_id_fkey;
+ALTER TABLE objective_closings ADD CONSTRAINT objective_closings_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- Общие команды, пространства и история вклада остаются, теряется только ссылка на автора
+ALTER TABLE user_teams DROP CONSTRAINT IF EXISTS user_teams_created_by_fkey;
+ALTER TABLE user_teams ADD CONSTRAINT user_teams_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE shared_objectives DROP CONSTRAINT IF EXISTS shared_objectives_shared_by_fkey;
+ALTER TABLE shared_objectives ADD CONSTRAINT shared_objectives_shared_by_fkey
+    FOREIGN KEY (shared_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE finance_space_members DROP CONSTRAINT IF EXISTS finance_space_members_invited_by_fkey;
+ALTER TABLE finance_space_members ADD CONSTRAINT finance_space_members_invited_by_fkey
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE finance_space_categories DROP CONSTRAINT IF EXISTS finance_space_categories_created_by_fkey;
+ALTER TABLE finance_space_categories ADD CONSTRAINT finance_space_categories_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE key_result_progress_log DROP CONSTRAINT IF EXISTS key_result_progress_log_user_id_fkey;
+ALTER TABLE key_result_progress_log ADD CONSTRAINT key_result_progress_log_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
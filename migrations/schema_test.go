@@ -0,0 +1,270 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+var (
+	commentRe	= regexp.MustCompile(`--[^\n]*`)
+	bodyRe		= regexp.MustCompile(`(?s)\$\$.*?\$\$`)
+	tableRe		= regexp.MustCompile(`(?i)^\s*(?:CREATE TABLE(?: IF NOT EXISTS)?|ALTER TABLE(?: IF EXISTS)?(?: ONLY)?)\s+(\w+)`)
+	usersRefRe	= regexp.MustCompile(`(?i)REFERENCES\s+users\s*\(\s*id\s*\)((?:\s+ON DELETE\s+(?:CASCADE|SET NULL|SET DEFAULT|RESTRICT|NO ACTION))?)`)
+	foreignKeyRe	= regexp.MustCompile(`(?is)FOREIGN KEY\s*\((\w+)\)\s*REFERENCES\s+users\s*\(\s*id\s*\)((?:\s+ON DELETE\s+(?:CASCADE|SET NULL|SET DEFAULT|RESTRICT|NO ACTION))?)`)
+	addColumnRe	= regexp.MustCompile(`(?i)ADD COLUMN(?: IF NOT EXISTS)?\s+(\w+)`)
+	columnRe	= regexp.MustCompile(`^\s*(\w+)`)
+)
+
+func migrationFiles(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("*.sql")
+	if err != nil {
+		t.Fatalf("не удалось получить список миграций: %v", err)
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		t.Fatal("миграции не найдены")
+	}
+	return files
+}
+
+func userReferences(t *testing.T) map[string]string {
+	t.Helper()
+	refs := make(map[string]string)
+	for _, file := range migrationFiles(t) {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("не удалось прочитать %s: %v", file, err)
+		}
+		sql := bodyRe.ReplaceAllString(commentRe.ReplaceAllString(string(raw), ""), "")
+		for _, stmt := range strings.Split(sql, ";") {
+			if !usersRefRe.MatchString(stmt) {
+				continue
+			}
+			table := tableRe.FindStringSubmatch(stmt)
+			if table == nil {
+				t.Fatalf("%s: не удалось определить таблицу для ссылки на users: %q", file, strings.TrimSpace(stmt))
+			}
+			rest := stmt
+			for _, m := range foreignKeyRe.FindAllStringSubmatch(stmt, -1) {
+				refs[table[1]+"."+m[1]] = strings.TrimSpace(m[2])
+				rest = strings.Replace(rest, m[0], "", 1)
+			}
+			for _, line := range strings.Split(rest, "\n") {
+				ref := usersRefRe.FindStringSubmatch(line)
+				if ref == nil {
+					continue
+				}
+				column := columnRe.FindStringSubmatch(line)
+				if m := addColumnRe.FindStringSubmatch(line); m != nil {
+					column = m
+				}
+				refs[table[1]+"."+column[1]] = strings.TrimSpace(ref[1])
+			}
+		}
+	}
+	return refs
+}
+
+func TestUserReferencesHaveDeleteAction(t *testing.T) {
+	refs := userReferences(t)
+	for _, column := range []string{"objectives.user_id", "events.user_id", "finance_spaces.owner_id", "key_result_progress_log.user_id"} {
+		if _, ok := refs[column]; !ok {
+			t.Fatalf("ссылка %s на users не найдена, разбор миграций сломан", column)
+		}
+	}
+	for column, action := range refs {
+		if action == "" {
+			t.Errorf("%s ссылается на users(id) без ON DELETE: удаление пользователя упадет на этой строке", column)
+		}
+	}
+}
+
+func openMigratedDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан: нужна PostgreSQL с расширениями pgcrypto, vector и pg_trgm")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к тестовой базе: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if _, err := db.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("не удалось создать схему: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		db.Close()
+	})
+	if _, err := db.Exec(`SET search_path TO ` + schema + `, public`); err != nil {
+		t.Fatalf("не удалось переключить схему: %v", err)
+	}
+
+	for _, file := range migrationFiles(t) {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("не удалось прочитать %s: %v", file, err)
+		}
+		if _, err := db.Exec(string(raw)); err != nil {
+			t.Fatalf("миграция %s не применилась: %v", file, err)
+		}
+	}
+	return db
+}
+
+func mustExec(t *testing.T, db *sqlx.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("ошибка запроса %q: %v", strings.TrimSpace(query), err)
+	}
+}
+
+func count(t *testing.T, db *sqlx.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := db.Get(&n, query, args...); err != nil {
+		t.Fatalf("ошибка запроса %q: %v", strings.TrimSpace(query), err)
+	}
+	return n
+}
+
+func seedObjective(t *testing.T, db *sqlx.DB, userID int64, objectiveID string) (krID, taskID int64) {
+	t.Helper()
+	mustExec(t, db, `INSERT INTO objectives (id, user_id, title, period) VALUES ($1, $2, 'Цель', 'month')`, objectiveID, userID)
+	if err := db.Get(&krID, `INSERT INTO key_results (objective_id, title, target) VALUES ($1, 'KR', 10) RETURNING id`, objectiveID); err != nil {
+		t.Fatalf("не удалось создать ключевой результат: %v", err)
+	}
+	if err := db.Get(&taskID, `
+		INSERT INTO tasks (key_result_id, title, target, unit, deadline) VALUES ($1, 'Задача', 1, 'шт', NOW() + INTERVAL '1 day') RETURNING id
+	`, krID); err != nil {
+		t.Fatalf("не удалось создать задачу: %v", err)
+	}
+
+	mustExec(t, db, `UPDATE key_results SET progress = 3 WHERE id = $1`, krID)
+	mustExec(t, db, `INSERT INTO ai_insights (user_id, insight_type, category, title, content, objective_id, key_result_id, task_id)
+		VALUES ($1, 'tip', 'okr', 'Совет', 'Текст', $2, $3, $4)`, userID, objectiveID, krID, taskID)
+	mustExec(t, db, `INSERT INTO goal_predictions (user_id, objective_id, key_result_id, task_id, prediction_type)
+		VALUES ($1, $2, $3, $4, 'completion_probability')`, userID, objectiveID, krID, taskID)
+	mustExec(t, db, `INSERT INTO smart_reminders (user_id, objective_id, key_result_id, task_id, reminder_type, title, message, scheduled_at)
+		VALUES ($1, $2, $3, $4, 'deadline', 'Напоминание', 'Текст', NOW())`, userID, objectiveID, krID, taskID)
+	mustExec(t, db, `INSERT INTO habit_tracking (user_id, objective_id, key_result_id, task_id, date, completed)
+		VALUES ($1, $2, $3, $4, CURRENT_DATE, TRUE)`, userID, objectiveID, krID, taskID)
+	return krID, taskID
+}
+
+func TestDeleteObjectiveLeavesNoOrphans(t *testing.T) {
+	db := openMigratedDB(t)
+	mustExec(t, db, `INSERT INTO users (id, username) VALUES (1, 'owner')`)
+	krID, _ := seedObjective(t, db, 1, "obj-1")
+
+	mustExec(t, db, `DELETE FROM objectives WHERE id = 'obj-1'`)
+
+	orphans := map[string]string{
+		"key_results":			`SELECT COUNT(*) FROM key_results WHERE id = $1`,
+		"tasks":			`SELECT COUNT(*) FROM tasks WHERE key_result_id = $1`,
+		"key_result_progress_log":	`SELECT COUNT(*) FROM key_result_progress_log WHERE key_result_id = $1`,
+		"ai_insights":			`SELECT COUNT(*) FROM ai_insights WHERE key_result_id = $1`,
+		"goal_predictions":		`SELECT COUNT(*) FROM goal_predictions WHERE key_result_id = $1`,
+		"smart_reminders":		`SELECT COUNT(*) FROM smart_reminders WHERE key_result_id = $1`,
+	}
+	for table, query := range orphans {
+		if n := count(t, db, query, krID); n != 0 {
+			t.Errorf("после удаления цели в %s осталось строк: %d", table, n)
+		}
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM habit_tracking WHERE user_id = 1 AND objective_id IS NULL AND task_id IS NULL`); n != 1 {
+		t.Errorf("история привычек должна остаться без ссылки на цель, найдено строк: %d", n)
+	}
+}
+
+func TestDeleteFullyPopulatedUser(t *testing.T) {
+	db := openMigratedDB(t)
+	const deleted, kept = int64(1), int64(2)
+	mustExec(t, db, `INSERT INTO users (id, username) VALUES ($1, 'leaving'), ($2, 'staying')`, deleted, kept)
+
+	seedObjective(t, db, deleted, "obj-deleted")
+	keptKR, _ := seedObjective(t, db, kept, "obj-kept")
+
+	mustExec(t, db, `INSERT INTO events (id, user_id, title, start_time, end_time) VALUES ('ev-1', $1, 'Встреча', NOW(), NOW() + INTERVAL '1 hour')`, deleted)
+	mustExec(t, db, `INSERT INTO meetings (id, initiator_id, participant_id, title, start_time, end_time)
+		VALUES ('mt-1', $1, $2, 'Созвон', NOW(), NOW() + INTERVAL '1 hour')`, kept, deleted)
+	mustExec(t, db, `INSERT INTO transactions (id, user_id, amount) VALUES ('tx-1', $1, 100)`, deleted)
+	mustExec(t, db, `INSERT INTO google_tokens (user_id, access_token, token_type, expiry) VALUES ($1, 'token', 'Bearer', NOW())`, deleted)
+	mustExec(t, db, `INSERT INTO objective_drafts (user_id, fields, updated_via) VALUES ($1, '{"title": "Черновик"}', 'telegram')`, deleted)
+	mustExec(t, db, `INSERT INTO objective_closings (objective_id, user_id, outcome) VALUES ('obj-kept', $1, 'completed')`, deleted)
+
+	mustExec(t, db, `INSERT INTO finance_spaces (id, name, owner_id) VALUES ('space-deleted', 'Свое', $1), ('space-kept', 'Семья', $2)`, deleted, kept)
+	mustExec(t, db, `INSERT INTO finance_space_members (space_id, user_id, invited_by) VALUES ('space-kept', $2, $1)`, deleted, kept)
+	mustExec(t, db, `INSERT INTO finance_space_categories (space_id, name, created_by) VALUES ('space-kept', 'Продукты', $1)`, deleted)
+
+	var teamID int64
+	if err := db.Get(&teamID, `INSERT INTO user_teams (name, created_by) VALUES ('Команда', $1) RETURNING id`, deleted); err != nil {
+		t.Fatalf("не удалось создать команду: %v", err)
+	}
+	mustExec(t, db, `INSERT INTO team_members (team_id, user_id) VALUES ($1, $2), ($1, $3)`, teamID, deleted, kept)
+	mustExec(t, db, `INSERT INTO shared_objectives (objective_id, team_id, shared_by) VALUES ('obj-kept', $1, $2)`, teamID, deleted)
+
+	tx := db.MustBegin()
+	if _, err := tx.Exec(`SELECT set_config('jarvis.actor_id', $1, true)`, fmt.Sprint(deleted)); err != nil {
+		t.Fatalf("не удалось задать автора прогресса: %v", err)
+	}
+	if _, err := tx.Exec(`UPDATE key_results SET progress = progress + 2 WHERE id = $1`, keptKR); err != nil {
+		t.Fatalf("не удалось записать вклад в чужой ключевой результат: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("не удалось записать вклад: %v", err)
+	}
+
+	mustExec(t, db, `DELETE FROM users WHERE id = $1`, deleted)
+
+	var columns []struct {
+		Table	string	`db:"table_name"`
+		Column	string	`db:"column_name"`
+	}
+	err := db.Select(&columns, `
+		SELECT c.conrelid::regclass::text AS table_name, a.attname AS column_name
+		FROM pg_constraint c
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = ANY(c.conkey)
+		WHERE c.contype = 'f' AND c.confrelid = 'users'::regclass
+	`)
+	if err != nil {
+		t.Fatalf("не удалось получить ссылки на users: %v", err)
+	}
+	for _, ref := range columns {
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s = $1`, ref.Table, ref.Column)
+		if n := count(t, db, query, deleted); n != 0 {
+			t.Errorf("после удаления пользователя в %s.%s осталось строк: %d", ref.Table, ref.Column, n)
+		}
+	}
+
+	survivors := map[string]string{
+		"чужая цель":			`SELECT COUNT(*) FROM objectives WHERE id = 'obj-kept'`,
+		"общее пространство":		`SELECT COUNT(*) FROM finance_spaces WHERE id = 'space-kept'`,
+		"категория пространства":	`SELECT COUNT(*) FROM finance_space_categories WHERE space_id = 'space-kept' AND created_by IS NULL`,
+		"команда":			`SELECT COUNT(*) FROM user_teams WHERE created_by IS NULL`,
+		"общая цель команды":		`SELECT COUNT(*) FROM shared_objectives WHERE shared_by IS NULL`,
+		"вклад в чужой KR":		`SELECT COUNT(*) FROM key_result_progress_log WHERE user_id IS NULL AND delta = 2`,
+	}
+	for name, query := range survivors {
+		if n := count(t, db, query); n != 1 {
+			t.Errorf("%s: ожидалась одна строка без ссылки на удаленного пользователя, найдено: %d", name, n)
+		}
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM finance_spaces WHERE id = 'space-deleted'`); n != 0 {
+		t.Errorf("личное пространство удаленного пользователя должно быть удалено, найдено строк: %d", n)
+	}
+}
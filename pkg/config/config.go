@@ -17,9 +17,48 @@ type Config struct {
 	OpenAIKey		string
 	GoogleCalendarID	string
 	GoogleCredentials	string
+	GoogleWebhookURL	string
 	ServerHost		string
 	ServerPort		string
+	PublicBaseURL		string
 	JWTSigningKey		string
+	JobSchedules		string
+	OTLPEndpoint		string
+	OTLPInsecure		string
+	OTELServiceName		string
+	AdminTelegramIDs	string
+	PaymentProviderToken	string
+	SubscriptionPrice	string
+	SubscriptionCurrency	string
+	SubscriptionDays	string
+	FreeMessageQuota	string
+	FreeAudioEnabled	string
+	StripeSecretKey		string
+	StripeWebhookSecret	string
+	StripePriceID		string
+	BillingSuccessURL	string
+	BillingCancelURL	string
+	AdminAlertChatID	string
+	SupportChatID		string
+	AlertWebhookErrorRate	string
+	AlertOpenAIFailureRate	string
+	AlertOutboxBacklog	string
+	AlertWebhookToken	string
+	MetricsToken		string
+	LogLevel		string
+	LogModuleLevels		string
+	SnapshotSalt		string
+	SMTPHost		string
+	SMTPPort		string
+	SMTPUsername		string
+	SMTPPassword		string
+	SMTPFrom		string
+	InboundEmailToken	string
+	MessageEncryptionKey	string
+	PluginsEnabled		string
+	PluginServers		string
+	PluginServerToken	string
+	PredictionLLMEnabled	string
 }
 
 func LoadConfig() *Config {
@@ -37,9 +76,48 @@ func LoadConfig() *Config {
 		OpenAIKey:		getEnv("OPENAI_KEY", ""),
 		GoogleCalendarID:	getEnv("GOOGLE_CALENDAR_ID", ""),
 		GoogleCredentials:	getEnv("GOOGLE_CREDENTIALS", ""),
+		GoogleWebhookURL:	getEnv("GOOGLE_WEBHOOK_URL", ""),
 		ServerHost:		getEnv("SERVER_HOST", "0.0.0.0"),
 		ServerPort:		getEnv("SERVER_PORT", "8080"),
+		PublicBaseURL:		getEnv("PUBLIC_BASE_URL", ""),
 		JWTSigningKey:		getEnv("JWT_SIGNING_KEY", "your-secret-signing-key"),
+		JobSchedules:		getEnv("JOB_SCHEDULES", ""),
+		OTLPEndpoint:		getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTLPInsecure:		getEnv("OTEL_EXPORTER_OTLP_INSECURE", "false"),
+		OTELServiceName:	getEnv("OTEL_SERVICE_NAME", "telegrambot"),
+		AdminTelegramIDs:	getEnv("ADMIN_TELEGRAM_IDS", ""),
+		PaymentProviderToken:	getEnv("PAYMENT_PROVIDER_TOKEN", ""),
+		SubscriptionPrice:	getEnv("SUBSCRIPTION_PRICE", "29900"),
+		SubscriptionCurrency:	getEnv("SUBSCRIPTION_CURRENCY", "RUB"),
+		SubscriptionDays:	getEnv("SUBSCRIPTION_DAYS", "30"),
+		FreeMessageQuota:	getEnv("FREE_MESSAGE_QUOTA", "20"),
+		FreeAudioEnabled:	getEnv("FREE_AUDIO_ENABLED", "false"),
+		StripeSecretKey:	getEnv("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret:	getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		StripePriceID:		getEnv("STRIPE_PRICE_ID", ""),
+		BillingSuccessURL:	getEnv("BILLING_SUCCESS_URL", "http://localhost:3000/billing/success"),
+		BillingCancelURL:	getEnv("BILLING_CANCEL_URL", "http://localhost:3000/billing/cancel"),
+		AdminAlertChatID:	getEnv("ADMIN_ALERT_CHAT_ID", ""),
+		SupportChatID:		getEnv("SUPPORT_CHAT_ID", ""),
+		AlertWebhookErrorRate:	getEnv("ALERT_WEBHOOK_ERROR_RATE", "0.2"),
+		AlertOpenAIFailureRate:	getEnv("ALERT_OPENAI_FAILURE_RATE", "0.3"),
+		AlertOutboxBacklog:	getEnv("ALERT_OUTBOX_BACKLOG", "100"),
+		AlertWebhookToken:	getEnv("ALERT_WEBHOOK_TOKEN", ""),
+		MetricsToken:		getEnv("METRICS_TOKEN", ""),
+		LogLevel:		getEnv("LOG_LEVEL", "info"),
+		LogModuleLevels:	getEnv("LOG_MODULE_LEVELS", ""),
+		SnapshotSalt:		getEnv("SNAPSHOT_SALT", ""),
+		SMTPHost:		getEnv("SMTP_HOST", ""),
+		SMTPPort:		getEnv("SMTP_PORT", "587"),
+		SMTPUsername:		getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:		getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:		getEnv("SMTP_FROM", ""),
+		InboundEmailToken:	getEnv("INBOUND_EMAIL_TOKEN", ""),
+		MessageEncryptionKey:	getEnv("MESSAGE_ENCRYPTION_KEY", ""),
+		PluginsEnabled:		getEnv("PLUGINS_ENABLED", ""),
+		PluginServers:		getEnv("PLUGIN_SERVERS", ""),
+		PluginServerToken:	getEnv("PLUGIN_SERVER_TOKEN", ""),
+		PredictionLLMEnabled:	getEnv("PREDICTION_LLM_ENABLED", "false"),
 	}
 }
 
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"telegrambot/pkg/config"
+	"telegrambot/pkg/tracing"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,11 +15,13 @@ func NewPostgresDB(cfg *config.Config) (*sqlx.DB, error) {
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.PostgresHost, cfg.PostgresPort, cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresDB)
 
-	db, err := sqlx.Open("postgres", connStr)
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		return nil, err
 	}
 
+	db := sqlx.NewDb(sql.OpenDB(tracing.WrapConnector(connector)), "postgres")
+
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultModule = "default"
+
+type Levels struct {
+	Default	string			`json:"default"`
+	Modules	map[string]string	`json:"modules"`
+}
+
+type registry struct {
+	mu		sync.RWMutex
+	fallback	logrus.Level
+	modules		map[string]logrus.Level
+}
+
+type moduleFormatter struct {
+	next logrus.Formatter
+}
+
+var levels = &registry{fallback: logrus.InfoLevel, modules: map[string]logrus.Level{}}
+
+func Init(defaultLevel, moduleLevels string) error {
+	fallback, err := logrus.ParseLevel(strings.TrimSpace(defaultLevel))
+	if err != nil {
+		return fmt.Errorf("некорректный уровень логирования %q: %v", defaultLevel, err)
+	}
+
+	modules := map[string]logrus.Level{}
+	for _, pair := range strings.Split(moduleLevels, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		module, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("некорректная настройка уровня модуля %q, ожидается module=level", pair)
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("некорректный уровень логирования модуля %s: %v", module, err)
+		}
+		modules[strings.TrimSpace(module)] = level
+	}
+
+	levels.mu.Lock()
+	levels.fallback = fallback
+	levels.modules = modules
+	levels.mu.Unlock()
+
+	logger := logrus.StandardLogger()
+	if _, installed := logger.Formatter.(*moduleFormatter); !installed {
+		hideCaller(logger.Formatter)
+		logger.SetFormatter(&moduleFormatter{next: logger.Formatter})
+	}
+	logger.SetReportCaller(true)
+	levels.apply()
+	return nil
+}
+
+func SetLevel(module, value string) error {
+	module = strings.TrimSpace(module)
+	if module == "" {
+		module = DefaultModule
+	}
+
+	levels.mu.Lock()
+	if value == "" {
+		if module == DefaultModule {
+			levels.mu.Unlock()
+			return fmt.Errorf("уровень по умолчанию нельзя сбросить, укажите его явно")
+		}
+		delete(levels.modules, module)
+	} else {
+		level, err := logrus.ParseLevel(value)
+		if err != nil {
+			levels.mu.Unlock()
+			return fmt.Errorf("некорректный уровень логирования %q: %v", value, err)
+		}
+		if module == DefaultModule {
+			levels.fallback = level
+		} else {
+			levels.modules[module] = level
+		}
+	}
+	levels.mu.Unlock()
+
+	levels.apply()
+	return nil
+}
+
+func Current() Levels {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	current := Levels{Default: levels.fallback.String(), Modules: map[string]string{}}
+	for module, level := range levels.modules {
+		current.Modules[module] = level.String()
+	}
+	return current
+}
+
+func (r *registry) apply() {
+	r.mu.RLock()
+	verbose := r.fallback
+	for _, level := range r.modules {
+		if level > verbose {
+			verbose = level
+		}
+	}
+	r.mu.RUnlock()
+
+	logrus.SetLevel(verbose)
+}
+
+func (r *registry) enabled(module string, level logrus.Level) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	threshold, ok := r.modules[module]
+	if !ok {
+		threshold = r.fallback
+	}
+	return level <= threshold
+}
+
+func (f *moduleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	module := moduleOf(entry.Caller)
+	if !levels.enabled(module, entry.Level) {
+		return nil, nil
+	}
+	if module != "" {
+		entry.Data["module"] = module
+	}
+	return f.next.Format(entry)
+}
+
+func moduleOf(caller *runtime.Frame) string {
+	if caller == nil {
+		return ""
+	}
+	function := caller.Function
+	if slash := strings.LastIndex(function, "/"); slash >= 0 {
+		function = function[slash+1:]
+	}
+	module, _, _ := strings.Cut(function, ".")
+	return module
+}
+
+func hideCaller(formatter logrus.Formatter) {
+	hide := func(*runtime.Frame) (string, string) { return "", "" }
+	switch f := formatter.(type) {
+	case *logrus.JSONFormatter:
+		if f.CallerPrettyfier == nil {
+			f.CallerPrettyfier = hide
+		}
+	case *logrus.TextFormatter:
+		if f.CallerPrettyfier == nil {
+			f.CallerPrettyfier = hide
+		}
+	}
+}